@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cortexproject/cortex/tools/configconvert"
+)
+
+func main() {
+	var (
+		inputFilename  string
+		outputFilename string
+		source         string
+	)
+
+	flag.StringVar(&inputFilename, "config", "", "Path to the YAML config to convert.")
+	flag.StringVar(&outputFilename, "output", "", "Path the converted YAML config is written to. Written to stdout if empty.")
+	flag.StringVar(&source, "source", string(configconvert.SourceCortex), fmt.Sprintf("Project the converted config comes from. Supported values are: %v.", configconvert.Sources))
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s is a tool to convert an upstream Cortex or Mimir config into a config of this Cortex version.\nThe renamed, defaulted and unmappable fields are reported on stderr.\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Flags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if inputFilename == "" {
+		fatal("the -config flag is required")
+	}
+	buf, err := os.ReadFile(inputFilename)
+	if err != nil {
+		fatal("failed to load config file from %s: %v", inputFilename, err)
+	}
+
+	result, err := configconvert.Convert(buf, configconvert.Source(source))
+	if err != nil {
+		fatal("failed to convert config: %v", err)
+	}
+
+	if outputFilename == "" {
+		_, err = os.Stdout.Write(result.Config)
+	} else {
+		err = os.WriteFile(outputFilename, result.Config, 0644)
+	}
+	if err != nil {
+		fatal("failed to write the converted config: %v", err)
+	}
+
+	for _, r := range result.Renamed {
+		fmt.Fprintf(os.Stderr, "renamed: %s -> %s\n", r.From, r.To)
+	}
+	for _, d := range result.Defaulted {
+		fmt.Fprintf(os.Stderr, "defaulted: %s = %v\n", d.Path, d.Value)
+	}
+	for _, path := range result.Unmappable {
+		fmt.Fprintf(os.Stderr, "unmappable: %s\n", path)
+	}
+}
+
+func fatal(msg string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, msg+"\n", args...)
+	os.Exit(1)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -24,6 +25,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
 // Version is set via build flag -ldflags -X main.Version
@@ -66,6 +68,7 @@ func main() {
 		blockProfileRate     int
 		printVersion         bool
 		printModules         bool
+		validateConfig       bool
 	)
 
 	args := os.Args[1:]
@@ -101,6 +104,7 @@ func main() {
 	flag.IntVar(&blockProfileRate, "debug.block-profile-rate", 0, "Fraction of goroutine blocking events that are reported in the blocking profile. 1 to include every blocking event in the profile, 0 to disable.")
 	flag.BoolVar(&printVersion, "version", false, "Print Cortex version and exit.")
 	flag.BoolVar(&printModules, "modules", false, "List available values that can be used as target.")
+	flag.BoolVar(&validateConfig, "validate-config", false, "Validate the config and the runtime config overrides, including the consistency of the fields depending on each other, print the findings as JSON lines and exit. Exits with status 1 if any finding is an error.")
 
 	usage := flag.CommandLine.Usage
 	flag.CommandLine.Usage = func() { /* don't do anything by default, we will print usage ourselves, but only when requested. */ }
@@ -126,6 +130,14 @@ func main() {
 		return
 	}
 
+	if validateConfig {
+		validation.SetDefaultLimitsForYAMLUnmarshalling(cfg.LimitsConfig)
+		if !printConfigFindings(os.Stdout, cortex.CheckConfig(&cfg, util_log.Logger)) && !testMode {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Validate the config once both the config file has been loaded
 	// and CLI flags parsed.
 	err = cfg.Validate(util_log.Logger)
@@ -253,6 +265,21 @@ func LoadConfig(filename string, expandENV bool, cfg *cortex.Config) error {
 	return nil
 }
 
+// printConfigFindings prints the findings as JSON lines, and returns whether none is an error.
+func printConfigFindings(w io.Writer, findings []cortex.ConfigFinding) bool {
+	valid := true
+	enc := json.NewEncoder(w)
+	for _, f := range findings {
+		if err := enc.Encode(f); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if f.Severity == cortex.FindingError {
+			valid = false
+		}
+	}
+	return valid
+}
+
 func DumpYaml(cfg *cortex.Config) {
 	out, err := yaml.Marshal(cfg)
 	if err != nil {
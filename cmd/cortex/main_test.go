@@ -74,6 +74,12 @@ func TestFlagParsing(t *testing.T) {
 			stderrExcluded: "ingester\n",
 		},
 
+		"validate config": {
+			arguments:     []string{"-validate-config", "-querier.query-store-after=12h", "-blocks-storage.tsdb.retention-period=6h"},
+			yaml:          "target: querier",
+			stdoutMessage: `"severity":"error","fields":["blocks_storage.tsdb.retention_period","querier.query_store_after"]`,
+		},
+
 		"root level configuration option specified as an empty node in YAML": {
 			yaml:          "querier:",
 			stderrMessage: "the Querier configuration in YAML has been specified as an empty YAML node",
@@ -86,6 +86,7 @@ type Config struct {
 	PersisterConfig   PersisterConfig
 	APIConcurrency    int
 	GCInterval        time.Duration
+	DeliveryLog       DeliveryLogConfig
 }
 
 // An Alertmanager manages the alerts for one user.
@@ -97,6 +98,7 @@ type Alertmanager struct {
 	persister       *statePersister
 	nflog           *nflog.Log
 	silences        *silence.Silences
+	deliveryLog     *deliveryLog
 	marker          types.Marker
 	alerts          *mem.Alerts
 	dispatcher      *dispatch.Dispatcher
@@ -110,6 +112,11 @@ type Alertmanager struct {
 	// Pipeline created during last ApplyConfig call. Used for testing only.
 	lastPipeline notify.Stage
 
+	// Integrations of the receivers created during last ApplyConfig call, used to re-send the
+	// failed notifications.
+	integrationsMtx sync.Mutex
+	integrations    map[string][]notify.Integration
+
 	// The Dispatcher is the only component we need to recreate when we call ApplyConfig.
 	// Given its metrics don't have any variable labels we need to re-use the same metrics.
 	dispatcherMetrics *dispatch.DispatcherMetrics
@@ -230,6 +237,13 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 	}
 	c = am.state.AddState("sil:"+cfg.UserID, am.silences, am.registry)
 	am.silences.SetBroadcast(c.Broadcast)
+
+	if cfg.DeliveryLog.Enabled {
+		am.deliveryLog = newDeliveryLog(cfg.DeliveryLog)
+		c = am.state.AddState("dlv:"+cfg.UserID, am.deliveryLog, am.registry)
+		am.deliveryLog.SetBroadcast(c.Broadcast)
+	}
+
 	// State replication needs to be started after the state keys are defined.
 	if service, ok := am.state.(services.Service); ok {
 		if err := service.StartAsync(context.Background()); err != nil {
@@ -293,6 +307,10 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		am.mux.Handle(a, http.NotFoundHandler())
 	}
 
+	if am.deliveryLog != nil {
+		am.registerDeliveryLogHandlers()
+	}
+
 	am.dispatcherMetrics = dispatch.NewDispatcherMetrics(true, am.registry)
 
 	//TODO: From this point onward, the alertmanager _might_ receive requests - we need to make sure we've settled and are ready.
@@ -360,7 +378,7 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 	// Create a firewall binded to the per-tenant config.
 	firewallDialer := util_net.NewFirewallDialer(newFirewallDialerConfigProvider(userID, am.cfg.Limits))
 
-	integrationsMap, err := buildIntegrationsMap(conf.Receivers, tmpl, firewallDialer, am.logger, func(integrationName string, notifier notify.Notifier) notify.Notifier {
+	integrationsMap, err := buildIntegrationsMap(conf.Receivers, tmpl, firewallDialer, am.logger, func(integrationName string, index int, notifier notify.Notifier) notify.Notifier {
 		if am.cfg.Limits != nil {
 			rl := &tenantRateLimits{
 				tenant:      userID,
@@ -368,7 +386,11 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 				integration: integrationName,
 			}
 
-			return newRateLimitedNotifier(notifier, rl, 10*time.Second, am.rateLimitedNotifications.WithLabelValues(integrationName))
+			notifier = newRateLimitedNotifier(notifier, rl, 10*time.Second, am.rateLimitedNotifications.WithLabelValues(integrationName))
+		}
+		// The rate limited notifications are recorded as failed deliveries too.
+		if am.deliveryLog != nil {
+			notifier = newAuditedNotifier(notifier, integrationName, index, am.deliveryLog)
 		}
 		return notifier
 	})
@@ -376,6 +398,10 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 		return nil
 	}
 
+	am.integrationsMtx.Lock()
+	am.integrations = integrationsMap
+	am.integrationsMtx.Unlock()
+
 	timeIntervals := make(map[string][]timeinterval.TimeInterval, len(conf.MuteTimeIntervals)+len(conf.TimeIntervals))
 	for _, ti := range conf.MuteTimeIntervals {
 		timeIntervals[ti.Name] = ti.TimeIntervals
@@ -469,7 +495,7 @@ func (am *Alertmanager) getFullState() (*clusterpb.FullState, error) {
 
 // buildIntegrationsMap builds a map of name to the list of integration notifiers off of a
 // list of receiver config.
-func buildIntegrationsMap(nc []config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, notifierWrapper func(string, notify.Notifier) notify.Notifier) (map[string][]notify.Integration, error) {
+func buildIntegrationsMap(nc []config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, notifierWrapper func(string, int, notify.Notifier) notify.Notifier) (map[string][]notify.Integration, error) {
 	integrationsMap := make(map[string][]notify.Integration, len(nc))
 	for _, rcv := range nc {
 		integrations, err := buildReceiverIntegrations(rcv, tmpl, firewallDialer, logger, notifierWrapper)
@@ -484,7 +510,7 @@ func buildIntegrationsMap(nc []config.Receiver, tmpl *template.Template, firewal
 // buildReceiverIntegrations builds a list of integration notifiers off of a
 // receiver config.
 // Taken from https://github.com/prometheus/alertmanager/blob/d7b4f0c7322e7151d6e3b1e31cbc15361e295d8d/cmd/alertmanager/main.go#L135-L193.
-func buildReceiverIntegrations(nc config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, wrapper func(string, notify.Notifier) notify.Notifier) ([]notify.Integration, error) {
+func buildReceiverIntegrations(nc config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, wrapper func(string, int, notify.Notifier) notify.Notifier) ([]notify.Integration, error) {
 	var (
 		errs         types.MultiError
 		integrations []notify.Integration
@@ -494,7 +520,7 @@ func buildReceiverIntegrations(nc config.Receiver, tmpl *template.Template, fire
 				errs.Add(err)
 				return
 			}
-			n = wrapper(name, n)
+			n = wrapper(name, i, n)
 			integrations = append(integrations, notify.NewIntegration(n, rs, name, i))
 		}
 	)
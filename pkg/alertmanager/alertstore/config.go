@@ -2,11 +2,13 @@ package alertstore
 
 import (
 	"flag"
+	"reflect"
 
 	"github.com/cortexproject/cortex/pkg/alertmanager/alertstore/configdb"
 	"github.com/cortexproject/cortex/pkg/alertmanager/alertstore/local"
 	"github.com/cortexproject/cortex/pkg/configs/client"
 	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
 )
 
 // Config configures a the alertmanager storage backend.
@@ -26,6 +28,14 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.RegisterFlagsWithPrefix(prefix, f)
 }
 
+// IsDefaults returns true if the storage options have not been set.
+func (cfg *Config) IsDefaults() bool {
+	defaults := Config{}
+	flagext.DefaultValues(&defaults)
+
+	return reflect.DeepEqual(*cfg, defaults)
+}
+
 // IsFullStateSupported returns if the given configuration supports access to FullState objects.
 func (cfg *Config) IsFullStateSupported() bool {
 	for _, backend := range bucket.SupportedBackends {
@@ -15,6 +15,7 @@ import (
 	"github.com/prometheus/alertmanager/config"
 	"github.com/prometheus/alertmanager/template"
 	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
 	"gopkg.in/yaml.v2"
 
 	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
@@ -110,27 +111,9 @@ func (am *MultitenantAlertmanager) SetUserConfig(w http.ResponseWriter, r *http.
 		return
 	}
 
-	var input io.Reader
-	maxConfigSize := am.limits.AlertmanagerMaxConfigSize(userID)
-	if maxConfigSize > 0 {
-		// LimitReader will return EOF after reading specified number of bytes. To check if
-		// we have read too many bytes, allow one extra byte.
-		input = io.LimitReader(r.Body, int64(maxConfigSize)+1)
-	} else {
-		input = r.Body
-	}
-
-	payload, err := io.ReadAll(input)
+	payload, err := am.readUserConfigPayload(logger, r, userID)
 	if err != nil {
-		level.Error(logger).Log("msg", errReadingConfiguration, "err", err.Error())
-		http.Error(w, fmt.Sprintf("%s: %s", errReadingConfiguration, err.Error()), http.StatusBadRequest)
-		return
-	}
-
-	if maxConfigSize > 0 && len(payload) > maxConfigSize {
-		msg := fmt.Sprintf(errConfigurationTooBig, maxConfigSize)
-		level.Warn(logger).Log("msg", msg)
-		http.Error(w, msg, http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -159,6 +142,84 @@ func (am *MultitenantAlertmanager) SetUserConfig(w http.ResponseWriter, r *http.
 	w.WriteHeader(http.StatusCreated)
 }
 
+// ValidateUserConfig validates the candidate configuration in the request body without storing it,
+// returning the warnings of the lint of a valid configuration.
+func (am *MultitenantAlertmanager) ValidateUserConfig(w http.ResponseWriter, r *http.Request) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		level.Error(logger).Log("msg", errNoOrgID, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errNoOrgID, err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	invalid := func(err error) {
+		w.WriteHeader(http.StatusBadRequest)
+		util.WriteJSONResponse(w, ConfigValidationResult{Error: err.Error(), Warnings: []ConfigLintWarning{}})
+	}
+
+	payload, err := am.readUserConfigPayload(logger, r, userID)
+	if err != nil {
+		invalid(err)
+		return
+	}
+
+	req := &ConfigValidationRequest{}
+	if err := yaml.Unmarshal(payload, req); err != nil {
+		invalid(fmt.Errorf("%s: %s", errMarshallingYAML, err.Error()))
+		return
+	}
+
+	labelSets := make([]model.LabelSet, 0, len(req.LabelSets))
+	for _, ls := range req.LabelSets {
+		labelSet := make(model.LabelSet, len(ls))
+		for n, v := range ls {
+			labelSet[model.LabelName(n)] = model.LabelValue(v)
+		}
+		if err := labelSet.Validate(); err != nil {
+			invalid(errors.Wrap(err, "invalid label set"))
+			return
+		}
+		labelSets = append(labelSets, labelSet)
+	}
+
+	cfgDesc := alertspb.ToProto(req.AlertmanagerConfig, req.TemplateFiles, userID)
+	amCfg, tmpl, err := loadUserConfig(logger, cfgDesc, am.limits, userID)
+	if err != nil {
+		invalid(fmt.Errorf("%s: %s", errValidatingConfig, err.Error()))
+		return
+	}
+
+	warnings, routings := lintUserConfig(amCfg, tmpl, cfgDesc, labelSets)
+	util.WriteJSONResponse(w, ConfigValidationResult{Valid: true, Warnings: warnings, LabelSets: routings})
+}
+
+// readUserConfigPayload reads the configuration in the request body, enforcing the max size of the tenant.
+func (am *MultitenantAlertmanager) readUserConfigPayload(logger log.Logger, r *http.Request, userID string) ([]byte, error) {
+	var input io.Reader
+	maxConfigSize := am.limits.AlertmanagerMaxConfigSize(userID)
+	if maxConfigSize > 0 {
+		// LimitReader will return EOF after reading specified number of bytes. To check if
+		// we have read too many bytes, allow one extra byte.
+		input = io.LimitReader(r.Body, int64(maxConfigSize)+1)
+	} else {
+		input = r.Body
+	}
+
+	payload, err := io.ReadAll(input)
+	if err != nil {
+		level.Error(logger).Log("msg", errReadingConfiguration, "err", err.Error())
+		return nil, fmt.Errorf("%s: %s", errReadingConfiguration, err.Error())
+	}
+
+	if maxConfigSize > 0 && len(payload) > maxConfigSize {
+		msg := fmt.Sprintf(errConfigurationTooBig, maxConfigSize)
+		level.Warn(logger).Log("msg", msg)
+		return nil, errors.New(msg)
+	}
+	return payload, nil
+}
+
 // DeleteUserConfig is exposed via user-visible API (if enabled, uses DELETE method), but also as an internal endpoint using POST method.
 // Note that if no config exists for a user, StatusOK is returned.
 func (am *MultitenantAlertmanager) DeleteUserConfig(w http.ResponseWriter, r *http.Request) {
@@ -180,42 +241,48 @@ func (am *MultitenantAlertmanager) DeleteUserConfig(w http.ResponseWriter, r *ht
 	w.WriteHeader(http.StatusOK)
 }
 
-// Partially copied from: https://github.com/prometheus/alertmanager/blob/8e861c646bf67599a1704fc843c6a94d519ce312/cli/check_config.go#L65-L96
 func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits Limits, user string) error {
+	_, _, err := loadUserConfig(logger, cfg, limits, user)
+	return err
+}
+
+// loadUserConfig validates the configuration, returning the loaded Alertmanager config and templates.
+// Partially copied from: https://github.com/prometheus/alertmanager/blob/8e861c646bf67599a1704fc843c6a94d519ce312/cli/check_config.go#L65-L96
+func loadUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits Limits, user string) (*config.Config, *template.Template, error) {
 	// We don't have a valid use case for empty configurations. If a tenant does not have a
 	// configuration set and issue a request to the Alertmanager, we'll a) upload an empty
 	// config and b) immediately start an Alertmanager instance for them if a fallback
 	// configuration is provisioned.
 	if cfg.RawConfig == "" {
-		return fmt.Errorf("configuration provided is empty, if you'd like to remove your configuration please use the delete configuration endpoint")
+		return nil, nil, fmt.Errorf("configuration provided is empty, if you'd like to remove your configuration please use the delete configuration endpoint")
 	}
 
 	amCfg, err := config.Load(cfg.RawConfig)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// Validate the config recursively scanning it.
 	if err := validateAlertmanagerConfig(amCfg); err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// Validate templates referenced in the alertmanager config.
 	for _, name := range amCfg.Templates {
 		if err := validateTemplateFilename(name); err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
 
 	// Check template limits.
 	if l := limits.AlertmanagerMaxTemplatesCount(user); l > 0 && len(cfg.Templates) > l {
-		return fmt.Errorf(errTooManyTemplates, len(cfg.Templates), l)
+		return nil, nil, fmt.Errorf(errTooManyTemplates, len(cfg.Templates), l)
 	}
 
 	if maxSize := limits.AlertmanagerMaxTemplateSize(user); maxSize > 0 {
 		for _, tmpl := range cfg.Templates {
 			if size := len(tmpl.GetBody()); size > maxSize {
-				return fmt.Errorf(errTemplateTooBig, tmpl.GetFilename(), size, maxSize)
+				return nil, nil, fmt.Errorf(errTemplateTooBig, tmpl.GetFilename(), size, maxSize)
 			}
 		}
 	}
@@ -223,7 +290,7 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 	// Validate template files.
 	for _, tmpl := range cfg.Templates {
 		if err := validateTemplateFilename(tmpl.Filename); err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
 
@@ -234,7 +301,7 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 	// we see this in the wild.
 	userTempDir, err := os.MkdirTemp("", "validate-config-"+cfg.User)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	defer os.RemoveAll(userTempDir)
 
@@ -242,12 +309,12 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 		templateFilepath, err := safeTemplateFilepath(userTempDir, tmpl.Filename)
 		if err != nil {
 			level.Error(logger).Log("msg", "unable to create template file path", "err", err, "user", cfg.User)
-			return err
+			return nil, nil, err
 		}
 
 		if _, err = storeTemplateFile(templateFilepath, tmpl.Body); err != nil {
 			level.Error(logger).Log("msg", "unable to store template file", "err", err, "user", cfg.User)
-			return fmt.Errorf("unable to store template file '%s'", tmpl.Filename)
+			return nil, nil, fmt.Errorf("unable to store template file '%s'", tmpl.Filename)
 		}
 	}
 
@@ -256,9 +323,9 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 		templateFiles[i] = filepath.Join(userTempDir, t)
 	}
 
-	_, err = template.FromGlobs(templateFiles)
+	tmpl, err := template.FromGlobs(templateFiles)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// Note: Not validating the MultitenantAlertmanager.transformConfig function as that
@@ -266,7 +333,7 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 	// autoWebhookURL itself is broken. In that case, I would argue, we should accept the config
 	// not reject it.
 
-	return nil
+	return amCfg, tmpl, nil
 }
 
 func (am *MultitenantAlertmanager) ListAllConfigs(w http.ResponseWriter, r *http.Request) {
@@ -3,6 +3,7 @@ package alertmanager
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -683,6 +684,175 @@ alertmanager_config: |
 	}
 }
 
+func TestAMConfigLintAPI(t *testing.T) {
+	testCases := map[string]struct {
+		cfg string
+
+		expectedCode   int
+		expectedResult ConfigValidationResult
+	}{
+		"should return the error of an invalid config": {
+			cfg: `
+alertmanager_config: |
+  route:
+    receiver: 'default-receiver'
+`,
+			expectedCode: http.StatusBadRequest,
+			expectedResult: ConfigValidationResult{
+				Error:    "error validating Alertmanager config: undefined receiver \"default-receiver\" used in route",
+				Warnings: []ConfigLintWarning{},
+			},
+		},
+		"should return the error of an invalid label set": {
+			cfg: `
+alertmanager_config: |
+  route:
+    receiver: 'default-receiver'
+  receivers:
+    - name: default-receiver
+label_sets:
+  - {"invalid-name": "value"}
+`,
+			expectedCode: http.StatusBadRequest,
+			expectedResult: ConfigValidationResult{
+				Error:    "invalid label set: invalid name \"invalid-name\"",
+				Warnings: []ConfigLintWarning{},
+			},
+		},
+		"should return no warnings for a clean config": {
+			cfg: `
+template_files:
+  slack.tmpl: |
+    {{ define "slack.custom.title" }}[{{ .Status }}] {{ .CommonLabels.alertname }}{{ end }}
+alertmanager_config: |
+  templates:
+    - '*.tmpl'
+  route:
+    receiver: 'default-receiver'
+    routes:
+      - receiver: 'team-a'
+        matchers: ['team="a"']
+  receivers:
+    - name: default-receiver
+      webhook_configs:
+        - url: http://webhook.example.com
+    - name: team-a
+      slack_configs:
+        - api_url: http://slack.example.com
+          title: '{{ template "slack.custom.title" . }}'
+label_sets:
+  - {"alertname": "HighLatency", "team": "a"}
+`,
+			expectedCode: http.StatusOK,
+			expectedResult: ConfigValidationResult{
+				Valid:    true,
+				Warnings: []ConfigLintWarning{},
+				LabelSets: []LabelSetRouting{
+					{Labels: map[string]string{"alertname": "HighLatency", "team": "a"}, Receivers: []string{"team-a"}},
+				},
+			},
+		},
+		"should return the warnings of a valid config": {
+			cfg: `
+template_files:
+  unused.tmpl: |
+    {{ define "unused" }}unused{{ end }}
+alertmanager_config: |
+  templates:
+    - 'missing-*.tmpl'
+  route:
+    receiver: 'default-receiver'
+    routes:
+      - receiver: 'blackhole'
+      - receiver: 'team-a'
+        matchers: ['team="a"']
+  receivers:
+    - name: default-receiver
+      webhook_configs:
+        - url: http://webhook.example.com
+    - name: blackhole
+    - name: team-a
+      slack_configs:
+        - api_url: http://slack.example.com
+          title: '{{ template "slack.undefined.title" . }}'
+    - name: unused
+      webhook_configs:
+        - url: http://webhook.example.com
+label_sets:
+  - {"alertname": "HighLatency", "team": "a"}
+`,
+			expectedCode: http.StatusOK,
+			expectedResult: ConfigValidationResult{
+				Valid: true,
+				Warnings: []ConfigLintWarning{
+					{Type: "receiver", Message: "receiver \"blackhole\" has no integrations, the notifications routed to it are dropped"},
+					{Type: "receiver", Message: "receiver \"unused\" isn't used by any route"},
+					{Type: "template", Message: "template file \"unused.tmpl\" isn't loaded since it isn't matched by the templates of the configuration"},
+					{Type: "template", Message: "templates \"missing-*.tmpl\" don't match any template file"},
+					{Type: "template", Message: "template of the field slack_configs[0].title of the receiver \"team-a\" fails: template: :1:12: executing \"\" at <{{template \"slack.undefined.title\" .}}>: template \"slack.undefined.title\" not defined"},
+					{Type: "route", Message: "the routes after the route \"{}/{}\" are never matched, since it matches all the alerts without continue"},
+				},
+				LabelSets: []LabelSetRouting{
+					{Labels: map[string]string{"alertname": "HighLatency", "team": "a"}, Receivers: []string{"blackhole"}},
+				},
+			},
+		},
+		"should warn about the label sets only matched by the root route": {
+			cfg: `
+alertmanager_config: |
+  route:
+    receiver: 'default-receiver'
+    routes:
+      - receiver: 'team-a'
+        matchers: ['team="a"']
+  receivers:
+    - name: default-receiver
+      webhook_configs:
+        - url: http://webhook.example.com
+    - name: team-a
+      webhook_configs:
+        - url: http://webhook.example.com
+label_sets:
+  - {"alertname": "HighLatency", "team": "b"}
+`,
+			expectedCode: http.StatusOK,
+			expectedResult: ConfigValidationResult{
+				Valid: true,
+				Warnings: []ConfigLintWarning{
+					{Type: "route", Message: "the alerts {alertname=\"HighLatency\", team=\"b\"} are only matched by the root route, and notified to its receiver \"default-receiver\""},
+				},
+				LabelSets: []LabelSetRouting{
+					{Labels: map[string]string{"alertname": "HighLatency", "team": "b"}, Receivers: []string{"default-receiver"}},
+				},
+			},
+		},
+	}
+
+	am := &MultitenantAlertmanager{
+		store:  prepareInMemoryAlertStore(),
+		logger: util_log.Logger,
+		limits: &mockAlertManagerLimits{},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://alertmanager/api/v1/alerts/validate", bytes.NewReader([]byte(tc.cfg)))
+			ctx := user.InjectOrgID(req.Context(), "testing")
+			w := httptest.NewRecorder()
+			am.ValidateUserConfig(w, req.WithContext(ctx))
+			resp := w.Result()
+			require.Equal(t, tc.expectedCode, resp.StatusCode)
+
+			var result ConfigValidationResult
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tc.expectedResult, result)
+
+			// The config is never stored.
+			_, err := am.store.GetAlertConfig(ctx, "testing")
+			require.ErrorIs(t, err, alertspb.ErrNotFound)
+		})
+	}
+}
+
 func TestMultitenantAlertmanager_DeleteUserConfig(t *testing.T) {
 	storage := objstore.NewInMemBucket()
 	alertStore := bucketclient.NewBucketAlertStore(storage, nil, log.NewNopLogger())
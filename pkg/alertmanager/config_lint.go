@@ -0,0 +1,208 @@
+package alertmanager
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
+)
+
+const (
+	lintReceiver = "receiver"
+	lintTemplate = "template"
+	lintRoute    = "route"
+)
+
+// ConfigValidationRequest is the candidate configuration to validate, along with the label sets of
+// the alerts whose routing is checked.
+type ConfigValidationRequest struct {
+	UserConfig `yaml:",inline"`
+	LabelSets  []map[string]string `yaml:"label_sets"`
+}
+
+// ConfigValidationResult is the result of the validation of a candidate configuration.
+type ConfigValidationResult struct {
+	Valid     bool                `json:"valid"`
+	Error     string              `json:"error,omitempty"`
+	Warnings  []ConfigLintWarning `json:"warnings"`
+	LabelSets []LabelSetRouting   `json:"label_sets,omitempty"`
+}
+
+// ConfigLintWarning is an issue of a valid configuration, likely to fail or drop notifications.
+type ConfigLintWarning struct {
+	// Type is either receiver, template or route.
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// LabelSetRouting is the receivers the alerts with the label set are routed to.
+type LabelSetRouting struct {
+	Labels    map[string]string `json:"labels"`
+	Receivers []string          `json:"receivers"`
+}
+
+// lintUserConfig returns the warnings of the valid configuration, and the routing of the label sets.
+func lintUserConfig(amCfg *config.Config, tmpl *template.Template, cfg alertspb.AlertConfigDesc, labelSets []model.LabelSet) ([]ConfigLintWarning, []LabelSetRouting) {
+	warnings := []ConfigLintWarning{}
+	warn := func(typ, format string, args ...interface{}) {
+		warnings = append(warnings, ConfigLintWarning{Type: typ, Message: fmt.Sprintf(format, args...)})
+	}
+
+	root := dispatch.NewRoute(amCfg.Route, nil)
+
+	// Receivers.
+	used := map[string]struct{}{}
+	root.Walk(func(r *dispatch.Route) {
+		used[r.RouteOpts.Receiver] = struct{}{}
+	})
+	for _, r := range amCfg.Receivers {
+		if _, ok := used[r.Name]; !ok {
+			warn(lintReceiver, "receiver %q isn't used by any route", r.Name)
+		} else if !hasIntegrations(r) {
+			warn(lintReceiver, "receiver %q has no integrations, the notifications routed to it are dropped", r.Name)
+		}
+	}
+
+	// Templates.
+	for _, t := range cfg.Templates {
+		if !matchesAnyGlob(amCfg.Templates, t.Filename) {
+			warn(lintTemplate, "template file %q isn't loaded since it isn't matched by the templates of the configuration", t.Filename)
+		}
+	}
+	for _, glob := range amCfg.Templates {
+		matched := false
+		for _, t := range cfg.Templates {
+			matched = matched || matchesAnyGlob([]string{glob}, t.Filename)
+		}
+		if !matched {
+			warn(lintTemplate, "templates %q don't match any template file", glob)
+		}
+	}
+
+	// The templates of the receivers are executed against a sample alert, since the undefined
+	// templates or functions only fail at notification time.
+	tmpl.ExternalURL = &url.URL{Scheme: "http", Host: "alertmanager"}
+	sample := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{model.AlertNameLabel: "LintSampleAlert"},
+		StartsAt: time.Now(),
+	}}
+	for _, r := range amCfg.Receivers {
+		data := tmpl.Data(r.Name, sample.Labels, sample)
+		walkTemplateFields(reflect.ValueOf(r), "", func(field, text string) {
+			if _, err := tmpl.ExecuteTextString(text, data); err != nil {
+				warn(lintTemplate, "template of the field %s of the receiver %q fails: %s", field, r.Name, err)
+			}
+		})
+	}
+
+	// Routes.
+	root.Walk(func(r *dispatch.Route) {
+		for i, child := range r.Routes {
+			if len(child.Matchers) == 0 && !child.Continue && i < len(r.Routes)-1 {
+				warn(lintRoute, "the routes after the route %q are never matched, since it matches all the alerts without continue", child.Key())
+				break
+			}
+		}
+	})
+
+	routings := make([]LabelSetRouting, 0, len(labelSets))
+	for _, ls := range labelSets {
+		routing := LabelSetRouting{Labels: make(map[string]string, len(ls)), Receivers: []string{}}
+		for n, v := range ls {
+			routing.Labels[string(n)] = string(v)
+		}
+
+		matches := root.Match(ls)
+		for _, r := range matches {
+			routing.Receivers = append(routing.Receivers, r.RouteOpts.Receiver)
+		}
+		if len(root.Routes) > 0 && len(matches) == 1 && matches[0] == root {
+			warn(lintRoute, "the alerts %s are only matched by the root route, and notified to its receiver %q", ls, root.RouteOpts.Receiver)
+		}
+		routings = append(routings, routing)
+	}
+
+	return warnings, routings
+}
+
+// hasIntegrations returns whether the receiver has any notification integration configured.
+func hasIntegrations(r config.Receiver) bool {
+	v := reflect.ValueOf(r)
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); f.Kind() == reflect.Slice && f.Len() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyGlob(globs []string, filename string) bool {
+	for _, glob := range globs {
+		if ok, err := filepath.Match(glob, filename); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// walkTemplateFields calls fn with the YAML path of each string field of the value containing a
+// template. The secrets are skipped.
+func walkTemplateFields(v reflect.Value, path string, fn func(field, text string)) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walkTemplateFields(v.Elem(), path, fn)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			switch {
+			case name == "" && field.Anonymous:
+				name = path
+			case name == "" || name == "-":
+				name = field.Name
+			}
+			if path != "" && name != path {
+				name = path + "." + name
+			}
+			walkTemplateFields(v.Field(i), name, fn)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkTemplateFields(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			walkTemplateFields(v.MapIndex(k), fmt.Sprintf("%s[%v]", path, k), fn)
+		}
+
+	case reflect.String:
+		if v.Type() == reflect.TypeOf(config.Secret("")) {
+			return
+		}
+		if text := v.String(); strings.Contains(text, "{{") {
+			fn(path, text)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package alertmanager
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+const (
+	deliveryStatusSuccess = "success"
+	deliveryStatusFailed  = "failed"
+
+	deliveriesPath = "/api/v1/deliveries"
+	redeliverPath  = "/redeliver"
+)
+
+// The HTTP status code is only part of the error message of the integrations.
+var statusCodeRegexp = regexp.MustCompile(`unexpected status code (\d{3})`)
+
+// DeliveryLogConfig configures the audit trail of the notification deliveries.
+type DeliveryLogConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	Retention  time.Duration `yaml:"retention"`
+	MaxEntries int           `yaml:"max_entries"`
+}
+
+// RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet.
+func (cfg *DeliveryLogConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+".enabled", false, "Experimental: Record the delivery attempts of the notifications of each tenant, listed by the <alertmanager-http-prefix>/api/v1/deliveries API, and allow to re-send the failed notifications. The attempts are replicated to the other Alertmanagers of the tenant along with the notification log.")
+	f.DurationVar(&cfg.Retention, prefix+".retention", 24*time.Hour, "How long to keep the delivery attempts of the notifications.")
+	f.IntVar(&cfg.MaxEntries, prefix+".max-entries", 1000, "Maximum number of delivery attempts kept per tenant. The oldest attempts are removed first.")
+}
+
+// DeliveryAttempt is an attempt to deliver a notification to an integration of a receiver.
+type DeliveryAttempt struct {
+	ID               string         `json:"id"`
+	Timestamp        time.Time      `json:"timestamp"`
+	Receiver         string         `json:"receiver"`
+	Integration      string         `json:"integration"`
+	IntegrationIndex int            `json:"integrationIndex"`
+	GroupKey         string         `json:"groupKey"`
+	GroupLabels      model.LabelSet `json:"groupLabels,omitempty"`
+	Status           string         `json:"status"`
+	ResponseCode     int            `json:"responseCode,omitempty"`
+	Error            string         `json:"error,omitempty"`
+	LatencySeconds   float64        `json:"latencySeconds"`
+	RedeliveryOf     string         `json:"redeliveryOf,omitempty"`
+	Alerts           []model.Alert  `json:"alerts"`
+}
+
+// deliveryLog keeps the delivery attempts of the notifications of a tenant. It implements
+// cluster.State, so that the attempts are replicated like the notification log.
+type deliveryLog struct {
+	cfg DeliveryLogConfig
+
+	mtx       sync.Mutex
+	attempts  map[string]DeliveryAttempt
+	broadcast func([]byte)
+}
+
+func newDeliveryLog(cfg DeliveryLogConfig) *deliveryLog {
+	return &deliveryLog{
+		cfg:       cfg,
+		attempts:  map[string]DeliveryAttempt{},
+		broadcast: func([]byte) {},
+	}
+}
+
+// SetBroadcast sets the function replicating the new delivery attempts.
+func (l *deliveryLog) SetBroadcast(f func([]byte)) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.broadcast = f
+}
+
+func (l *deliveryLog) record(a DeliveryAttempt) DeliveryAttempt {
+	// Neither the location nor the monotonic clock reading are replicated.
+	a.Timestamp = a.Timestamp.UTC()
+	a.ID = ulid.MustNew(ulid.Timestamp(a.Timestamp), rand.Reader).String()
+
+	l.mtx.Lock()
+	l.attempts[a.ID] = a
+	l.gc()
+	broadcast := l.broadcast
+	l.mtx.Unlock()
+
+	if b, err := json.Marshal([]DeliveryAttempt{a}); err == nil {
+		broadcast(b)
+	}
+	return a
+}
+
+// MarshalBinary implements cluster.State.
+func (l *deliveryLog) MarshalBinary() ([]byte, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.gc()
+	attempts := make([]DeliveryAttempt, 0, len(l.attempts))
+	for _, a := range l.attempts {
+		attempts = append(attempts, a)
+	}
+	return json.Marshal(attempts)
+}
+
+// Merge implements cluster.State.
+func (l *deliveryLog) Merge(b []byte) error {
+	var attempts []DeliveryAttempt
+	if err := json.Unmarshal(b, &attempts); err != nil {
+		return err
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	for _, a := range attempts {
+		l.attempts[a.ID] = a
+	}
+	l.gc()
+	return nil
+}
+
+// gc removes the attempts older than the retention, and the oldest ones above the max entries.
+// Must be called with the lock held.
+func (l *deliveryLog) gc() {
+	minTime := time.Now().Add(-l.cfg.Retention)
+	for id, a := range l.attempts {
+		if a.Timestamp.Before(minTime) {
+			delete(l.attempts, id)
+		}
+	}
+
+	if l.cfg.MaxEntries <= 0 || len(l.attempts) <= l.cfg.MaxEntries {
+		return
+	}
+	ids := make([]string, 0, len(l.attempts))
+	for id := range l.attempts {
+		ids = append(ids, id)
+	}
+	// The IDs are sorted by time.
+	sort.Strings(ids)
+	for _, id := range ids[:len(ids)-l.cfg.MaxEntries] {
+		delete(l.attempts, id)
+	}
+}
+
+// list returns the attempts of the receiver and status, if not empty, the most recent first.
+func (l *deliveryLog) list(receiver, status string) []DeliveryAttempt {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	result := []DeliveryAttempt{}
+	for _, a := range l.attempts {
+		if (receiver == "" || a.Receiver == receiver) && (status == "" || a.Status == status) {
+			result = append(result, a)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+	return result
+}
+
+func (l *deliveryLog) get(id string) (DeliveryAttempt, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	a, ok := l.attempts[id]
+	return a, ok
+}
+
+type redeliveryKey struct{}
+
+// redelivery is the re-delivery of a failed notification in progress.
+type redelivery struct {
+	of      string
+	attempt DeliveryAttempt
+}
+
+// auditedNotifier records the delivery attempts of the notifications in the delivery log.
+type auditedNotifier struct {
+	upstream    notify.Notifier
+	integration string
+	index       int
+	log         *deliveryLog
+}
+
+func newAuditedNotifier(upstream notify.Notifier, integration string, index int, log *deliveryLog) *auditedNotifier {
+	return &auditedNotifier{
+		upstream:    upstream,
+		integration: integration,
+		index:       index,
+		log:         log,
+	}
+}
+
+func (n *auditedNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	start := time.Now()
+	retry, err := n.upstream.Notify(ctx, alerts...)
+
+	a := DeliveryAttempt{
+		Timestamp:        start,
+		Integration:      n.integration,
+		IntegrationIndex: n.index,
+		Status:           deliveryStatusSuccess,
+		LatencySeconds:   time.Since(start).Seconds(),
+		Alerts:           make([]model.Alert, 0, len(alerts)),
+	}
+	a.Receiver, _ = notify.ReceiverName(ctx)
+	a.GroupKey, _ = notify.GroupKey(ctx)
+	a.GroupLabels, _ = notify.GroupLabels(ctx)
+	for _, alert := range alerts {
+		a.Alerts = append(a.Alerts, alert.Alert)
+	}
+	if err != nil {
+		a.Status = deliveryStatusFailed
+		a.Error = err.Error()
+		if m := statusCodeRegexp.FindStringSubmatch(a.Error); m != nil {
+			a.ResponseCode, _ = strconv.Atoi(m[1])
+		}
+	}
+
+	r, _ := ctx.Value(redeliveryKey{}).(*redelivery)
+	if r != nil {
+		a.RedeliveryOf = r.of
+	}
+	a = n.log.record(a)
+	if r != nil {
+		r.attempt = a
+	}
+
+	return retry, err
+}
+
+// registerDeliveryLogHandlers registers the APIs listing the delivery attempts and re-sending a failed notification.
+func (am *Alertmanager) registerDeliveryLogHandlers() {
+	p := path.Join(am.cfg.ExternalURL.Path, deliveriesPath)
+	am.mux.HandleFunc(p, am.listDeliveries)
+	am.mux.HandleFunc(p+"/", am.redeliver)
+}
+
+func (am *Alertmanager) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	util.WriteJSONResponse(w, am.deliveryLog.list(r.FormValue("receiver"), r.FormValue("status")))
+}
+
+// redeliver re-sends the notification of a failed delivery attempt to the same integration.
+func (am *Alertmanager) redeliver(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, redeliverPath) {
+		http.NotFound(w, r)
+		return
+	}
+
+	id := path.Base(strings.TrimSuffix(r.URL.Path, redeliverPath))
+	failed, ok := am.deliveryLog.get(id)
+	if !ok {
+		http.Error(w, "delivery attempt not found", http.StatusNotFound)
+		return
+	}
+	if failed.Status != deliveryStatusFailed {
+		http.Error(w, "only the failed notifications can be re-delivered", http.StatusBadRequest)
+		return
+	}
+
+	var integration *notify.Integration
+	am.integrationsMtx.Lock()
+	for i, in := range am.integrations[failed.Receiver] {
+		if in.Name() == failed.Integration && in.Index() == failed.IntegrationIndex {
+			integration = &am.integrations[failed.Receiver][i]
+		}
+	}
+	am.integrationsMtx.Unlock()
+	if integration == nil {
+		http.Error(w, "the integration of the delivery attempt is no longer configured", http.StatusBadRequest)
+		return
+	}
+
+	alerts := make([]*types.Alert, 0, len(failed.Alerts))
+	for _, a := range failed.Alerts {
+		alerts = append(alerts, &types.Alert{Alert: a, UpdatedAt: time.Now()})
+	}
+
+	rd := &redelivery{of: failed.ID}
+	ctx := context.WithValue(r.Context(), redeliveryKey{}, rd)
+	ctx = notify.WithReceiverName(ctx, failed.Receiver)
+	ctx = notify.WithGroupKey(ctx, failed.GroupKey)
+	ctx = notify.WithGroupLabels(ctx, failed.GroupLabels)
+	ctx = notify.WithNow(ctx, time.Now())
+	if _, err := integration.Notify(ctx, alerts...); err != nil {
+		level.Warn(am.logger).Log("msg", "failed to re-deliver the notification", "id", failed.ID, "receiver", failed.Receiver, "integration", failed.Integration, "err", err)
+	}
+
+	util.WriteJSONResponse(w, rd.attempt)
+}
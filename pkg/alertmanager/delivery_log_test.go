@@ -0,0 +1,150 @@
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+func TestDeliveryLog_ShouldMergeTheAttemptsOfTheOtherReplicas(t *testing.T) {
+	cfg := DeliveryLogConfig{Enabled: true, Retention: time.Hour, MaxEntries: 3}
+	now := time.Now()
+
+	var broadcasted [][]byte
+	l := newDeliveryLog(cfg)
+	l.SetBroadcast(func(b []byte) { broadcasted = append(broadcasted, b) })
+
+	// The attempts older than the retention are removed.
+	l.record(DeliveryAttempt{Timestamp: now.Add(-2 * time.Hour), Receiver: "expired"})
+	first := l.record(DeliveryAttempt{Timestamp: now.Add(-3 * time.Minute), Receiver: "team-a", Status: deliveryStatusFailed})
+	second := l.record(DeliveryAttempt{Timestamp: now.Add(-2 * time.Minute), Receiver: "team-b", Status: deliveryStatusSuccess})
+	assert.Equal(t, []DeliveryAttempt{second, first}, l.list("", ""))
+	assert.Len(t, broadcasted, 3)
+
+	// The replica receives the attempts broadcasted, or the full state.
+	replica := newDeliveryLog(cfg)
+	require.NoError(t, replica.Merge(broadcasted[1]))
+	state, err := l.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, replica.Merge(state))
+	assert.Equal(t, []DeliveryAttempt{second, first}, replica.list("", ""))
+	assert.Equal(t, []DeliveryAttempt{first}, replica.list("team-a", ""))
+	assert.Equal(t, []DeliveryAttempt{second}, replica.list("", deliveryStatusSuccess))
+
+	// The oldest attempts are removed above the max entries.
+	third := l.record(DeliveryAttempt{Timestamp: now.Add(-time.Minute), Receiver: "team-a"})
+	fourth := l.record(DeliveryAttempt{Timestamp: now, Receiver: "team-a"})
+	assert.Equal(t, []DeliveryAttempt{fourth, third, second}, l.list("", ""))
+}
+
+func TestAlertmanager_ShouldRecordAndRedeliverTheNotifications(t *testing.T) {
+	const user = "test"
+
+	code := atomic.NewInt64(http.StatusBadRequest)
+	requests := atomic.NewInt64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Inc()
+		w.WriteHeader(int(code.Load()))
+	}))
+	t.Cleanup(server.Close)
+
+	am, err := New(&Config{
+		UserID:        user,
+		Logger:        log.NewNopLogger(),
+		Limits:        firewallDisabledLimits{&mockAlertManagerLimits{emailNotificationRateLimit: rate.Inf}},
+		TenantDataDir: t.TempDir(),
+		ExternalURL:   &url.URL{Path: "/am"},
+		GCInterval:    30 * time.Minute,
+		DeliveryLog:   DeliveryLogConfig{Enabled: true, Retention: time.Hour, MaxEntries: 10},
+	}, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	t.Cleanup(am.StopAndWait)
+
+	cfgRaw := fmt.Sprintf(`receivers:
+- name: 'team-a'
+  webhook_configs:
+  - url: %s
+route:
+  receiver: 'team-a'`, server.URL)
+	cfg, err := config.Load(cfgRaw)
+	require.NoError(t, err)
+	require.NoError(t, am.ApplyConfig(user, cfg, cfgRaw))
+
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighLatency"}, StartsAt: time.Now()}}
+	ctx := notify.WithReceiverName(context.Background(), "team-a")
+	ctx = notify.WithGroupKey(ctx, "{}:{alertname=\"HighLatency\"}")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	_, err = am.integrations["team-a"][0].Notify(ctx, alert)
+	require.Error(t, err)
+
+	attempts := listDeliveries(t, am, "")
+	require.Len(t, attempts, 1)
+	failed := attempts[0]
+	assert.Equal(t, "team-a", failed.Receiver)
+	assert.Equal(t, "webhook", failed.Integration)
+	assert.Equal(t, deliveryStatusFailed, failed.Status)
+	assert.Equal(t, http.StatusBadRequest, failed.ResponseCode)
+	assert.Equal(t, model.LabelSet{"alertname": "HighLatency"}, failed.Alerts[0].Labels)
+
+	code.Store(http.StatusOK)
+	rec := httptest.NewRecorder()
+	am.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/am/api/v1/deliveries/unknown/redeliver", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	am.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/am/api/v1/deliveries/"+failed.ID+"/redeliver", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var redelivered DeliveryAttempt
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &redelivered))
+	assert.Equal(t, deliveryStatusSuccess, redelivered.Status)
+	assert.Equal(t, failed.ID, redelivered.RedeliveryOf)
+	assert.Equal(t, int64(2), requests.Load())
+
+	// The successful attempts can't be re-delivered.
+	rec = httptest.NewRecorder()
+	am.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/am/api/v1/deliveries/"+redelivered.ID+"/redeliver", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	assert.Equal(t, []DeliveryAttempt{redelivered, failed}, listDeliveries(t, am, ""))
+	assert.Equal(t, []DeliveryAttempt{failed}, listDeliveries(t, am, "?status=failed"))
+}
+
+func listDeliveries(t *testing.T, am *Alertmanager, query string) []DeliveryAttempt {
+	rec := httptest.NewRecorder()
+	am.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/am/api/v1/deliveries"+query, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var attempts []DeliveryAttempt
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &attempts))
+	return attempts
+}
+
+type firewallDisabledLimits struct {
+	*mockAlertManagerLimits
+}
+
+func (firewallDisabledLimits) AlertmanagerReceiversBlockCIDRNetworks(string) []flagext.CIDR {
+	return nil
+}
+
+func (firewallDisabledLimits) AlertmanagerReceiversBlockPrivateAddresses(string) bool {
+	return false
+}
@@ -70,7 +70,8 @@ func (d *Distributor) isQuorumWritePath(p string) bool {
 }
 
 func (d *Distributor) isUnaryWritePath(p string) bool {
-	return strings.HasSuffix(p, "/silences")
+	// The delivery attempts are replicated, so that any replica can re-deliver a notification.
+	return strings.HasSuffix(p, "/silences") || strings.HasSuffix(p, redeliverPath)
 }
 
 func (d *Distributor) isUnaryDeletePath(p string) bool {
@@ -254,6 +254,14 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 			expectedTotalCalls:  0,
 			headersNotPreserved: true,
 			route:               "/receivers",
+		}, {
+			name:               "Write /v1/deliveries/id/redeliver is sent to only 1 AM",
+			numAM:              5,
+			numHappyAM:         5,
+			replicationFactor:  3,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 1,
+			route:              "/v1/deliveries/id/redeliver",
 		},
 	}
 
@@ -90,6 +90,8 @@ type MultitenantAlertmanagerConfig struct {
 
 	EnabledTenants  flagext.StringSliceCSV `yaml:"enabled_tenants"`
 	DisabledTenants flagext.StringSliceCSV `yaml:"disabled_tenants"`
+
+	DeliveryLog DeliveryLogConfig `yaml:"delivery_log"`
 }
 
 type ClusterConfig struct {
@@ -129,6 +131,7 @@ func (cfg *MultitenantAlertmanagerConfig) RegisterFlags(f *flag.FlagSet) {
 	cfg.Persister.RegisterFlagsWithPrefix("alertmanager", f)
 	cfg.ShardingRing.RegisterFlags(f)
 	cfg.Cluster.RegisterFlags(f)
+	cfg.DeliveryLog.RegisterFlagsWithPrefix("alertmanager.delivery-log", f)
 }
 
 func (cfg *ClusterConfig) RegisterFlags(f *flag.FlagSet) {
@@ -977,6 +980,7 @@ func (am *MultitenantAlertmanager) newAlertmanager(userID string, amConfig *amco
 		Limits:            am.limits,
 		APIConcurrency:    am.cfg.APIConcurrency,
 		GCInterval:        am.cfg.GCInterval,
+		DeliveryLog:       am.cfg.DeliveryLog,
 	}, reg)
 	if err != nil {
 		return nil, fmt.Errorf("unable to start Alertmanager for user %v: %v", userID, err)
@@ -1023,6 +1027,20 @@ func (am *MultitenantAlertmanager) ServeHTTP(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	if am.isUIAssetPath(req.URL.Path) && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+		userID, err := tenant.TenantID(req.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if !am.allowedTenants.IsAllowed(userID) {
+			http.Error(w, "Tenant is not allowed", http.StatusUnauthorized)
+			return
+		}
+		am.serveUIAsset(w, req)
+		return
+	}
+
 	if am.cfg.ShardingEnabled {
 		am.distributor.DistributeRequest(w, req, am.allowedTenants)
 		return
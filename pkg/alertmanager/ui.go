@@ -0,0 +1,69 @@
+package alertmanager
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/prometheus/alertmanager/asset"
+)
+
+// uiAssets serves the static assets of the Alertmanager UI, embedded in the binary.
+var uiAssets = http.FileServer(asset.Assets)
+
+// isUIAssetPath returns whether the path is a static asset of the Alertmanager UI. The assets are
+// the same for all the tenants, so any instance serves them without distributing the request to
+// the Alertmanagers of the tenant. The UI index isn't an asset, so that it's only served to the
+// tenants with an Alertmanager.
+func (am *MultitenantAlertmanager) isUIAssetPath(p string) bool {
+	rel, ok := am.relativeUIPath(p)
+	return ok && (rel == "/script.js" || rel == "/favicon.ico" || strings.HasPrefix(rel, "/lib/"))
+}
+
+// relativeUIPath returns the path relative to the external URL path.
+func (am *MultitenantAlertmanager) relativeUIPath(p string) (string, bool) {
+	prefix := am.cfg.ExternalURL.Path
+	if !strings.HasPrefix(p, prefix+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(p, prefix), true
+}
+
+// serveUIAsset serves a static asset of the Alertmanager UI, like the upstream Alertmanager.
+func (am *MultitenantAlertmanager) serveUIAsset(w http.ResponseWriter, req *http.Request) {
+	rel, _ := am.relativeUIPath(req.URL.Path)
+
+	// The assets change with the Cortex version, so they must not be cached.
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+
+	r := req.Clone(req.Context())
+	r.URL.Path = path.Join("/static", path.Clean(rel))
+	r.URL.RawPath = ""
+	uiAssets.ServeHTTP(w, r)
+}
+
+// WithPathPrefix returns a handler serving the Alertmanager UI and API requests received under the
+// given HTTP path prefix. The requests are rewritten under the path of the external URL, which
+// the Alertmanagers of the tenants are served under, when it's different: for example when a
+// reverse proxy exposes the Alertmanager under the external URL and forwards the requests to the
+// HTTP path prefix.
+func (am *MultitenantAlertmanager) WithPathPrefix(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" || prefix == am.cfg.ExternalURL.Path {
+		return am
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if p := req.URL.Path; p == prefix || strings.HasPrefix(p, prefix+"/") {
+			r := req.Clone(req.Context())
+			r.URL.Path = am.cfg.ExternalURL.Path + strings.TrimPrefix(p, prefix)
+			r.URL.RawPath = ""
+			// The requests distributed to the other Alertmanagers are sent with their request URI.
+			r.RequestURI = r.URL.RequestURI()
+			req = r
+		}
+		am.ServeHTTP(w, req)
+	})
+}
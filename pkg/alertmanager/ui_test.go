@@ -0,0 +1,75 @@
+package alertmanager
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+func TestMultitenantAlertmanager_ServeUI(t *testing.T) {
+	store := prepareInMemoryAlertStore()
+	amConfig := mockAlertmanagerConfig(t)
+
+	// The Alertmanager is exposed under /am by a reverse proxy forwarding the requests to /alertmanager.
+	externalURL := flagext.URLValue{}
+	require.NoError(t, externalURL.Set("http://localhost:8080/am"))
+	amConfig.ExternalURL = externalURL
+
+	am, err := createMultitenantAlertmanager(amConfig, nil, nil, store, nil, nil, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), am))
+	defer services.StopAndAwaitTerminated(context.Background(), am) //nolint:errcheck
+
+	require.NoError(t, store.SetAlertConfig(context.Background(), alertspb.AlertConfigDesc{
+		User:      "user1",
+		RawConfig: simpleConfigTwo,
+		Templates: []*alertspb.TemplateDesc{},
+	}))
+	require.NoError(t, am.loadAndSyncConfigs(context.Background(), reasonPeriodic))
+
+	handler := am.WithPathPrefix("/alertmanager")
+	serve := func(userID, method, path string) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		if userID != "" {
+			req = req.WithContext(user.InjectOrgID(req.Context(), userID))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	// The index of the UI is served by the Alertmanager of the tenant.
+	resp := serve("user1", http.MethodGet, "/alertmanager/")
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "script.js")
+	assert.Equal(t, http.StatusNotFound, serve("user2", http.MethodGet, "/alertmanager/").StatusCode)
+
+	// The API is served under the HTTP path prefix too.
+	assert.Equal(t, http.StatusOK, serve("user1", http.MethodGet, "/alertmanager/api/v2/status").StatusCode)
+
+	// The static assets are served to every tenant, but not to unauthenticated requests.
+	for _, p := range []string{"/alertmanager/script.js", "/alertmanager/favicon.ico"} {
+		resp = serve("user2", http.MethodGet, p)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, p)
+		assert.Equal(t, "no-cache, no-store, must-revalidate", resp.Header.Get("Cache-Control"), p)
+		assert.Equal(t, http.StatusUnauthorized, serve("", http.MethodGet, p).StatusCode, p)
+	}
+	assert.Equal(t, http.StatusNotFound, serve("user2", http.MethodGet, "/alertmanager/lib/missing.js").StatusCode)
+
+	// The requests under the external URL path are served as they are.
+	assert.Equal(t, http.StatusOK, serve("user1", http.MethodGet, "/am/api/v2/status").StatusCode)
+	assert.Same(t, am, am.WithPathPrefix("/am"))
+}
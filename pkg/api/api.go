@@ -6,12 +6,14 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/felixge/fgprof"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/regexp"
 	"github.com/klauspost/compress/gzhttp"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/util/httputil"
@@ -20,10 +22,12 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/alertmanager"
 	"github.com/cortexproject/cortex/pkg/alertmanager/alertmanagerpb"
+	apitenant "github.com/cortexproject/cortex/pkg/api/tenant"
 	"github.com/cortexproject/cortex/pkg/compactor"
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/distributor"
 	"github.com/cortexproject/cortex/pkg/distributor/distributorpb"
+	"github.com/cortexproject/cortex/pkg/freshness"
 	frontendv1 "github.com/cortexproject/cortex/pkg/frontend/v1"
 	"github.com/cortexproject/cortex/pkg/frontend/v1/frontendv1pb"
 	frontendv2 "github.com/cortexproject/cortex/pkg/frontend/v2"
@@ -66,20 +70,36 @@ type Config struct {
 	// DefaultConfigHandler.
 	CustomConfigHandler ConfigHandler `yaml:"-"`
 
+	// The following are exposed by the Prometheus status API, and injected by the upstream caller.
+	// StatusFlags holds the value of the configuration flags, with secrets redacted.
+	StatusFlags      map[string]string `yaml:"-"`
+	StartTime        time.Time         `yaml:"-"`
+	StorageRetention model.Duration    `yaml:"-"`
+	// RulerConfigAPIEnabled is whether the rules config API is served, advertised to the
+	// clients like Grafana in the build info features.
+	RulerConfigAPIEnabled bool `yaml:"-"`
+
 	// Allows and is used to configure the addition of HTTP Header fields to logs
 	HTTPRequestHeadersToLog flagext.StringSlice `yaml:"http_request_headers_to_log"`
 
+	// Enables the request ID, propagated through the components and added to the logs.
+	RequestIDEnabled bool `yaml:"request_id_enabled"`
+
 	// This sets the Origin header value
 	corsRegexString string `yaml:"cors_origin"`
 
 	buildInfoEnabled bool `yaml:"build_info_enabled"`
+
+	TenantResolution apitenant.Config `yaml:"tenant_resolution"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.ResponseCompression, "api.response-compression-enabled", false, "Use GZIP compression for API responses. Some endpoints serve large YAML or JSON blobs which can benefit from compression.")
 	f.Var(&cfg.HTTPRequestHeadersToLog, "api.http-request-headers-to-log", "Which HTTP Request headers to add to logs")
+	f.BoolVar(&cfg.RequestIDEnabled, "api.request-id-enabled", false, "Accept the request ID from the X-Request-ID header of the API requests, or generate one, and return it in the X-Request-ID header of the responses. The request ID is propagated to all the components serving the request, and added to their logs.")
 	f.BoolVar(&cfg.buildInfoEnabled, "api.build-info-enabled", false, "If enabled, build Info API will be served by query frontend or querier.")
+	cfg.TenantResolution.RegisterFlagsWithPrefix("api.tenant-resolution", f)
 	cfg.RegisterFlagsWithPrefix("", f)
 }
 
@@ -117,6 +137,9 @@ type API struct {
 	indexPage            *IndexPageContent
 	HTTPHeaderMiddleware *HTTPHeaderMiddleware
 	corsOrigin           *regexp.Regexp
+
+	// TenantDiagnosticsMiddleware is injected by the upstream caller.
+	TenantDiagnosticsMiddleware *TenantDiagnosticsMiddleware
 }
 
 func New(cfg Config, serverCfg server.Config, s *server.Server, logger log.Logger) (*API, error) {
@@ -152,6 +175,12 @@ func New(cfg Config, serverCfg server.Config, s *server.Server, logger log.Logge
 	if cfg.HTTPAuthMiddleware == nil {
 		api.AuthMiddleware = middleware.AuthenticateUser
 	}
+	if cfg.TenantResolution.Enabled {
+		api.AuthMiddleware, err = apitenant.NewMiddleware(cfg.TenantResolution, api.AuthMiddleware)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if len(cfg.HTTPRequestHeadersToLog) > 0 {
 		api.HTTPHeaderMiddleware = &HTTPHeaderMiddleware{TargetHeaders: cfg.HTTPRequestHeadersToLog}
 	}
@@ -167,15 +196,24 @@ func (a *API) RegisterRoute(path string, handler http.Handler, auth bool, method
 	level.Debug(a.logger).Log("msg", "api: registering route", "methods", strings.Join(methods, ","), "path", path, "auth", auth)
 
 	if auth {
+		if a.TenantDiagnosticsMiddleware != nil {
+			handler = a.TenantDiagnosticsMiddleware.Wrap(handler)
+		}
 		handler = a.AuthMiddleware.Wrap(handler)
 	}
 
+	// The error codes are looked for in the uncompressed responses.
+	handler = ErrorCodeMiddleware{}.Wrap(handler)
+
 	if a.cfg.ResponseCompression {
 		handler = gzhttp.GzipHandler(handler)
 	}
 	if a.HTTPHeaderMiddleware != nil {
 		handler = a.HTTPHeaderMiddleware.Wrap(handler)
 	}
+	if a.cfg.RequestIDEnabled {
+		handler = RequestIDMiddleware{}.Wrap(handler)
+	}
 
 	if len(methods) == 0 {
 		a.server.HTTP.Path(path).Handler(handler)
@@ -187,15 +225,24 @@ func (a *API) RegisterRoute(path string, handler http.Handler, auth bool, method
 func (a *API) RegisterRoutesWithPrefix(prefix string, handler http.Handler, auth bool, methods ...string) {
 	level.Debug(a.logger).Log("msg", "api: registering route", "methods", strings.Join(methods, ","), "prefix", prefix, "auth", auth)
 	if auth {
+		if a.TenantDiagnosticsMiddleware != nil {
+			handler = a.TenantDiagnosticsMiddleware.Wrap(handler)
+		}
 		handler = a.AuthMiddleware.Wrap(handler)
 	}
 
+	// The error codes are looked for in the uncompressed responses.
+	handler = ErrorCodeMiddleware{}.Wrap(handler)
+
 	if a.cfg.ResponseCompression {
 		handler = gzhttp.GzipHandler(handler)
 	}
 	if a.HTTPHeaderMiddleware != nil {
 		handler = a.HTTPHeaderMiddleware.Wrap(handler)
 	}
+	if a.cfg.RequestIDEnabled {
+		handler = RequestIDMiddleware{}.Wrap(handler)
+	}
 
 	if len(methods) == 0 {
 		a.server.HTTP.PathPrefix(prefix).Handler(handler)
@@ -218,7 +265,7 @@ func (a *API) RegisterAlertmanager(am *alertmanager.MultitenantAlertmanager, tar
 	a.RegisterRoute("/multitenant_alertmanager/delete_tenant_config", http.HandlerFunc(am.DeleteUserConfig), true, "POST")
 
 	// UI components lead to a large number of routes to support, utilize a path prefix instead
-	a.RegisterRoutesWithPrefix(a.cfg.AlertmanagerHTTPPrefix, am, true)
+	a.RegisterRoutesWithPrefix(a.cfg.AlertmanagerHTTPPrefix, am.WithPathPrefix(a.cfg.AlertmanagerHTTPPrefix), true)
 	level.Debug(a.logger).Log("msg", "api: registering alertmanager", "path_prefix", a.cfg.AlertmanagerHTTPPrefix)
 
 	// MultiTenant Alertmanager Experimental API routes
@@ -226,6 +273,7 @@ func (a *API) RegisterAlertmanager(am *alertmanager.MultitenantAlertmanager, tar
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.GetUserConfig), true, "GET")
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.SetUserConfig), true, "POST")
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.DeleteUserConfig), true, "DELETE")
+		a.RegisterRoute("/api/v1/alerts/validate", http.HandlerFunc(am.ValidateUserConfig), true, "POST")
 	}
 
 	// If the target is Alertmanager, enable the legacy behaviour. Otherwise only enable
@@ -260,18 +308,24 @@ func (a *API) RegisterRuntimeConfig(runtimeConfigHandler http.HandlerFunc) {
 func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distributor.Config) {
 	distributorpb.RegisterDistributorServer(a.server.GRPC, d)
 
-	a.RegisterRoute("/api/v1/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.wrapDistributorPush(d)), true, "POST")
+	a.RegisterRoute("/api/v1/push", push.Handler(pushConfig.MaxRecvMsgSize, pushConfig.DecodingLimits, a.sourceIPs, a.cfg.wrapDistributorPush(d)), true, "POST")
+	a.RegisterRoute("/api/v1/push/aggregated", push.PreAggregatedHandler(pushConfig.MaxRecvMsgSize, pushConfig.DecodingLimits, a.sourceIPs, a.cfg.wrapDistributorPush(d)), true, "POST")
 
 	a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/ring", "Distributor Ring Status")
 	a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/all_user_stats", "Usage Statistics")
 	a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/ha_tracker", "HA Tracking Status")
+	if pushConfig.MetricPrefixTracking.Enabled {
+		a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/metric_prefixes", "Ingestion by Metric Name Prefix")
+	}
 
 	a.RegisterRoute("/distributor/ring", d, false, "GET", "POST")
 	a.RegisterRoute("/distributor/all_user_stats", http.HandlerFunc(d.AllUserStatsHandler), false, "GET")
 	a.RegisterRoute("/distributor/ha_tracker", d.HATracker, false, "GET")
+	a.RegisterRoute("/distributor/ingesters_scale_down", http.HandlerFunc(d.IngestersScaleDownHandler), false, "GET", "POST")
+	a.RegisterRoute("/distributor/metric_prefixes", http.HandlerFunc(d.MetricPrefixesHandler), false, "GET")
 
 	// Legacy Routes
-	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/push"), push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.wrapDistributorPush(d)), true, "POST")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/push"), push.Handler(pushConfig.MaxRecvMsgSize, pushConfig.DecodingLimits, a.sourceIPs, a.cfg.wrapDistributorPush(d)), true, "POST")
 	a.RegisterRoute("/all_user_stats", http.HandlerFunc(d.AllUserStatsHandler), false, "GET")
 	a.RegisterRoute("/ha-tracker", d.HATracker, false, "GET")
 }
@@ -281,6 +335,7 @@ func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distrib
 type Ingester interface {
 	client.IngesterServer
 	FlushHandler(http.ResponseWriter, *http.Request)
+	FlushJobsHandler(http.ResponseWriter, *http.Request)
 	ShutdownHandler(http.ResponseWriter, *http.Request)
 	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
 }
@@ -292,13 +347,14 @@ func (a *API) RegisterIngester(i Ingester, pushConfig distributor.Config) {
 	a.indexPage.AddLink(SectionDangerous, "/ingester/flush", "Trigger a Flush of data from Ingester to storage")
 	a.indexPage.AddLink(SectionDangerous, "/ingester/shutdown", "Trigger Ingester Shutdown (Dangerous)")
 	a.RegisterRoute("/ingester/flush", http.HandlerFunc(i.FlushHandler), false, "GET", "POST")
+	a.RegisterRoute("/ingester/flush/jobs", http.HandlerFunc(i.FlushJobsHandler), false, "GET")
 	a.RegisterRoute("/ingester/shutdown", http.HandlerFunc(i.ShutdownHandler), false, "GET", "POST")
-	a.RegisterRoute("/ingester/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, i.Push), true, "POST") // For testing and debugging.
+	a.RegisterRoute("/ingester/push", push.Handler(pushConfig.MaxRecvMsgSize, pushConfig.DecodingLimits, a.sourceIPs, i.Push), true, "POST") // For testing and debugging.
 
 	// Legacy Routes
 	a.RegisterRoute("/flush", http.HandlerFunc(i.FlushHandler), false, "GET", "POST")
 	a.RegisterRoute("/shutdown", http.HandlerFunc(i.ShutdownHandler), false, "GET", "POST")
-	a.RegisterRoute("/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, i.Push), true, "POST") // For testing and debugging.
+	a.RegisterRoute("/push", push.Handler(pushConfig.MaxRecvMsgSize, pushConfig.DecodingLimits, a.sourceIPs, i.Push), true, "POST") // For testing and debugging.
 }
 
 func (a *API) RegisterTenantDeletion(api *purger.TenantDeletionAPI) {
@@ -337,6 +393,14 @@ func (a *API) RegisterRulerAPI(r *ruler.API) {
 	a.RegisterRoute("/api/v1/rules/{namespace}/{groupName}", http.HandlerFunc(r.DeleteRuleGroup), true, "DELETE")
 	a.RegisterRoute("/api/v1/rules/{namespace}", http.HandlerFunc(r.DeleteNamespace), true, "DELETE")
 
+	// Rules Config API Routes, used by the Grafana unified alerting to manage the rules
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules"), http.HandlerFunc(r.ListRulesConfig), true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}"), http.HandlerFunc(r.ListRulesConfig), true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}/{groupName}"), http.HandlerFunc(r.GetRuleGroup), true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}"), http.HandlerFunc(r.CreateRuleGroup), true, "POST")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}/{groupName}"), http.HandlerFunc(r.DeleteRuleGroup), true, "DELETE")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}"), http.HandlerFunc(r.DeleteNamespace), true, "DELETE")
+
 	// Legacy Prometheus Rule API Routes
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/rules"), http.HandlerFunc(r.PrometheusRules), true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/alerts"), http.HandlerFunc(r.PrometheusAlerts), true, "GET")
@@ -371,6 +435,11 @@ func (a *API) RegisterStoreGateway(s *storegateway.StoreGateway) {
 func (a *API) RegisterCompactor(c *compactor.Compactor) {
 	a.indexPage.AddLink(SectionAdminEndpoints, "/compactor/ring", "Compactor Ring Status")
 	a.RegisterRoute("/compactor/ring", http.HandlerFunc(c.RingHandler), false, "GET", "POST")
+
+	a.indexPage.AddLink(SectionAdminEndpoints, "/compactor/cleanup/plan", "Compactor Cleanup Plan")
+	a.RegisterRoute("/compactor/cleanup/plan", http.HandlerFunc(c.CleanupPlanHandler), false, "GET")
+	a.RegisterRoute("/compactor/cleanup/plan/approve", http.HandlerFunc(c.ApproveCleanupPlanHandler), false, "POST")
+	a.RegisterRoute("/compactor/block_lineage", http.HandlerFunc(c.BlockLineageHandler), false, "GET")
 }
 
 type Distributor interface {
@@ -405,6 +474,10 @@ func (a *API) RegisterQueryAPI(handler http.Handler) {
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/label/{name}/values"), hf, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/series"), hf, true, "GET", "POST", "DELETE")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/metadata"), hf, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/targets/metadata"), hf, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/status/tsdb"), hf, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/status/flags"), hf, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/status/runtimeinfo"), hf, true, "GET")
 
 	// Register Legacy Routers
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/read"), hf, true, "POST")
@@ -415,14 +488,43 @@ func (a *API) RegisterQueryAPI(handler http.Handler) {
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/label/{name}/values"), hf, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/series"), hf, true, "GET", "POST", "DELETE")
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/metadata"), hf, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/targets/metadata"), hf, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/status/tsdb"), hf, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/status/flags"), hf, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/status/runtimeinfo"), hf, true, "GET")
 
 	if a.cfg.buildInfoEnabled {
-		infoHandler := &buildInfoHandler{logger: a.logger}
+		infoHandler := &buildInfoHandler{features: buildInfoFeatures(a.cfg), logger: a.logger}
 		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/status/buildinfo"), infoHandler, true, "GET")
 		a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/status/buildinfo"), infoHandler, true, "GET")
 	}
 }
 
+// RegisterAdminQuery registers the admin query API, running a query across tenants.
+func (a *API) RegisterAdminQuery(handler http.Handler) {
+	a.RegisterRoute("/api/v1/admin/query", handler, true, "GET", "POST")
+}
+
+// RegisterQueryExport registers the query export API, running queries in the background and
+// serving their status and result.
+func (a *API) RegisterQueryExport(e *querier.QueryExporter) {
+	a.RegisterRoute("/api/v1/query_exports", e.SubmitHandler(), true, "POST")
+	a.RegisterRoute("/api/v1/query_exports/{id}", e.StatusHandler(), true, "GET")
+	a.RegisterRoute("/api/v1/query_exports/{id}/result", e.ResultHandler(), true, "GET")
+}
+
+// RegisterFreshnessProber registers the API serving the end-to-end freshness measured by the
+// freshness probe through each read path.
+func (a *API) RegisterFreshnessProber(p *freshness.Prober) {
+	a.indexPage.AddLink(SectionAdminEndpoints, "/querier/freshness", "End-to-end Freshness")
+	a.RegisterRoute("/querier/freshness", p, false, "GET")
+}
+
+// RegisterAdminTenants registers the admin tenants API, summarizing every known tenant.
+func (a *API) RegisterAdminTenants(handler http.Handler) {
+	a.RegisterRoute("/api/v1/admin/tenants", handler, true, "GET")
+}
+
 // RegisterQueryFrontendHandler registers the Prometheus routes supported by the
 // Cortex querier service. Currently, this can not be registered simultaneously
 // with the Querier.
@@ -430,6 +532,19 @@ func (a *API) RegisterQueryFrontendHandler(h http.Handler) {
 	a.RegisterQueryAPI(h)
 }
 
+// RegisterQueryFrontendTopQueries registers the handler serving the most expensive queries.
+func (a *API) RegisterQueryFrontendTopQueries(h http.Handler) {
+	a.indexPage.AddLink(SectionAdminEndpoints, "/frontend/top_queries", "Top Queries")
+	a.RegisterRoute("/frontend/top_queries", h, false, "GET")
+}
+
+// RegisterQueryFrontendActiveQueries registers the handlers listing and canceling the queries being executed.
+func (a *API) RegisterQueryFrontendActiveQueries(list, cancel http.Handler) {
+	a.indexPage.AddLink(SectionAdminEndpoints, "/frontend/active_queries", "Active Queries")
+	a.RegisterRoute("/frontend/active_queries", list, false, "GET")
+	a.RegisterRoute("/frontend/active_queries/{id}", cancel, false, "DELETE")
+}
+
 func (a *API) RegisterQueryFrontend1(f *frontendv1.Frontend) {
 	frontendv1pb.RegisterFrontendServer(a.server.GRPC, f)
 }
@@ -441,6 +556,9 @@ func (a *API) RegisterQueryFrontend2(f *frontendv2.Frontend) {
 func (a *API) RegisterQueryScheduler(f *scheduler.Scheduler) {
 	schedulerpb.RegisterSchedulerForFrontendServer(a.server.GRPC, f)
 	schedulerpb.RegisterSchedulerForQuerierServer(a.server.GRPC, f)
+
+	a.indexPage.AddLink(SectionAdminEndpoints, "/scheduler/autoscaling", "Query Scheduler Autoscaling Signals")
+	a.RegisterRoute("/scheduler/autoscaling", http.HandlerFunc(f.AutoscalingHandler), false, "GET")
 }
 
 // RegisterServiceMapHandler registers the Cortex structs service handler
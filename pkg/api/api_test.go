@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -12,6 +13,9 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/server"
+	"github.com/weaveworks/common/user"
+
+	apitenant "github.com/cortexproject/cortex/pkg/api/tenant"
 )
 
 const (
@@ -211,3 +215,44 @@ func Benchmark_Compression(b *testing.B) {
 		})
 	}
 }
+
+func TestNewApiWithTenantResolutionFromPathSegment(t *testing.T) {
+	cfg := Config{PrometheusHTTPPrefix: "/prometheus"}
+	cfg.TenantResolution = apitenant.Config{
+		Enabled: true,
+		Rules: []apitenant.ResolutionRule{
+			{Source: apitenant.SourcePathSegment, Segment: 0, Mapping: map[string]string{"api": "writes", "prometheus": "reads"}},
+		},
+	}
+	serverCfg := server.Config{
+		HTTPListenNetwork: server.DefaultNetwork,
+		MetricsNamespace:  "with_tenant_resolution",
+	}
+	server, err := server.New(serverCfg)
+	require.NoError(t, err)
+	t.Cleanup(server.Shutdown)
+
+	api, err := New(cfg, serverCfg, server, &FakeLogger{})
+	require.NoError(t, err)
+
+	var orgID string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orgID, err = user.ExtractOrgID(r.Context())
+		require.NoError(t, err)
+	})
+	api.RegisterRoute("/api/v1/push", handler, true, "POST")
+	api.RegisterQueryAPI(handler)
+
+	// The segments are the ones of the whole path of the routes, including the HTTP prefixes.
+	for path, expected := range map[string]string{
+		"/api/v1/push":                   "writes",
+		"/prometheus/api/v1/query":       "reads",
+		"/prometheus/api/v1/query_range": "reads",
+	} {
+		orgID = ""
+		rec := httptest.NewRecorder()
+		server.HTTP.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+		require.Equal(t, expected, orgID, path)
+	}
+}
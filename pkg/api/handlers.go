@@ -5,14 +5,16 @@ import (
 	"encoding/json"
 	"html/template"
 	"net/http"
+	"os"
 	"path"
+	"runtime"
+	"runtime/debug"
 	"sync"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
 	"github.com/grafana/regexp"
-	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	dto "github.com/prometheus/client_model/go"
@@ -26,6 +28,7 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/querier"
 	"github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware/queryrange"
 	"github.com/cortexproject/cortex/pkg/util"
 )
 
@@ -201,10 +204,10 @@ func NewQuerierHandler(
 		func(context.Context) v1.TargetRetriever { return &querier.DummyTargetRetriever{} },
 		func(context.Context) v1.AlertmanagerRetriever { return &querier.DummyAlertmanagerRetriever{} },
 		func() config.Config { return config.Config{} },
-		map[string]string{}, // TODO: include configuration flags
+		statusFlags(cfg),
 		v1.GlobalURLOptions{},
 		func(f http.HandlerFunc) http.HandlerFunc { return f },
-		nil,   // Only needed for admin APIs.
+		nil,   // Only needed for admin APIs and TSDB status, which is served by a custom handler.
 		"",    // This is for snapshots, which is disabled when admin APIs are disabled. Hence empty.
 		false, // Disable admin APIs.
 		logger,
@@ -212,7 +215,7 @@ func NewQuerierHandler(
 		0, 0, 0, // Remote read samples and concurrency limit.
 		false,
 		regexp.MustCompile(".*"),
-		func() (v1.RuntimeInfo, error) { return v1.RuntimeInfo{}, errors.New("not implemented") },
+		runtimeInfoFunc(cfg),
 		&v1.PrometheusVersion{
 			Version:   version.Version,
 			Branch:    version.Branch,
@@ -228,6 +231,9 @@ func NewQuerierHandler(
 		false,
 		false,
 	)
+	// The labels, label values and series responses are encoded as protobuf when requested
+	// by the Accept header. JSON stays the default encoding.
+	api.InstallCodec(querier.ProtobufCodec{})
 
 	router := mux.NewRouter()
 
@@ -257,55 +263,122 @@ func NewQuerierHandler(
 	router.Path(path.Join(prefix, "/api/v1/metadata")).Handler(querier.MetadataHandler(distributor))
 	router.Path(path.Join(prefix, "/api/v1/read")).Handler(querier.RemoteReadHandler(queryable, logger))
 	router.Path(path.Join(prefix, "/api/v1/read")).Methods("POST").Handler(promRouter)
-	router.Path(path.Join(prefix, "/api/v1/query")).Methods("GET", "POST").Handler(promRouter)
-	router.Path(path.Join(prefix, "/api/v1/query_range")).Methods("GET", "POST").Handler(promRouter)
+	router.Path(path.Join(prefix, "/api/v1/query")).Methods("GET", "POST").Handler(querier.MaxSourceResolutionMiddleware(querier.PartialResponseMiddleware(querier.SeriesLimitMiddleware(promRouter))))
+	router.Path(path.Join(prefix, "/api/v1/query_range")).Methods("GET", "POST").Handler(queryrange.ProtobufRequestMiddleware(querier.MaxSourceResolutionMiddleware(querier.PartialResponseMiddleware(querier.SeriesLimitMiddleware(promRouter)))))
 	router.Path(path.Join(prefix, "/api/v1/query_exemplars")).Methods("GET", "POST").Handler(promRouter)
 	router.Path(path.Join(prefix, "/api/v1/labels")).Methods("GET", "POST").Handler(promRouter)
 	router.Path(path.Join(prefix, "/api/v1/label/{name}/values")).Methods("GET").Handler(promRouter)
 	router.Path(path.Join(prefix, "/api/v1/series")).Methods("GET", "POST", "DELETE").Handler(promRouter)
 	router.Path(path.Join(prefix, "/api/v1/metadata")).Methods("GET").Handler(promRouter)
+	router.Path(path.Join(prefix, "/api/v1/targets/metadata")).Methods("GET").Handler(querier.TargetsMetadataHandler(distributor))
+	router.Path(path.Join(prefix, "/api/v1/status/tsdb")).Methods("GET").Handler(querier.TSDBStatusHandler(distributor))
+	router.Path(path.Join(prefix, "/api/v1/status/flags")).Methods("GET").Handler(promRouter)
+	router.Path(path.Join(prefix, "/api/v1/status/runtimeinfo")).Methods("GET").Handler(promRouter)
 
 	// TODO(gotjosh): This custom handler is temporary until we're able to vendor the changes in:
 	// https://github.com/prometheus/prometheus/pull/7125/files
 	router.Path(path.Join(legacyPrefix, "/api/v1/metadata")).Handler(querier.MetadataHandler(distributor))
 	router.Path(path.Join(legacyPrefix, "/api/v1/read")).Handler(querier.RemoteReadHandler(queryable, logger))
 	router.Path(path.Join(legacyPrefix, "/api/v1/read")).Methods("POST").Handler(legacyPromRouter)
-	router.Path(path.Join(legacyPrefix, "/api/v1/query")).Methods("GET", "POST").Handler(legacyPromRouter)
-	router.Path(path.Join(legacyPrefix, "/api/v1/query_range")).Methods("GET", "POST").Handler(legacyPromRouter)
+	router.Path(path.Join(legacyPrefix, "/api/v1/query")).Methods("GET", "POST").Handler(querier.MaxSourceResolutionMiddleware(querier.PartialResponseMiddleware(querier.SeriesLimitMiddleware(legacyPromRouter))))
+	router.Path(path.Join(legacyPrefix, "/api/v1/query_range")).Methods("GET", "POST").Handler(queryrange.ProtobufRequestMiddleware(querier.MaxSourceResolutionMiddleware(querier.PartialResponseMiddleware(querier.SeriesLimitMiddleware(legacyPromRouter)))))
 	router.Path(path.Join(legacyPrefix, "/api/v1/query_exemplars")).Methods("GET", "POST").Handler(legacyPromRouter)
 	router.Path(path.Join(legacyPrefix, "/api/v1/labels")).Methods("GET", "POST").Handler(legacyPromRouter)
 	router.Path(path.Join(legacyPrefix, "/api/v1/label/{name}/values")).Methods("GET").Handler(legacyPromRouter)
 	router.Path(path.Join(legacyPrefix, "/api/v1/series")).Methods("GET", "POST", "DELETE").Handler(legacyPromRouter)
 	router.Path(path.Join(legacyPrefix, "/api/v1/metadata")).Methods("GET").Handler(legacyPromRouter)
+	router.Path(path.Join(legacyPrefix, "/api/v1/targets/metadata")).Methods("GET").Handler(querier.TargetsMetadataHandler(distributor))
+	router.Path(path.Join(legacyPrefix, "/api/v1/status/tsdb")).Methods("GET").Handler(querier.TSDBStatusHandler(distributor))
+	router.Path(path.Join(legacyPrefix, "/api/v1/status/flags")).Methods("GET").Handler(legacyPromRouter)
+	router.Path(path.Join(legacyPrefix, "/api/v1/status/runtimeinfo")).Methods("GET").Handler(legacyPromRouter)
 
 	if cfg.buildInfoEnabled {
-		router.Path(path.Join(prefix, "/api/v1/status/buildinfo")).Methods("GET").Handler(promRouter)
-		router.Path(path.Join(legacyPrefix, "/api/v1/status/buildinfo")).Methods("GET").Handler(legacyPromRouter)
+		// The build info is served by a custom handler, to include the Cortex features.
+		infoHandler := &buildInfoHandler{features: buildInfoFeatures(cfg), logger: logger}
+		router.Path(path.Join(prefix, "/api/v1/status/buildinfo")).Methods("GET").Handler(infoHandler)
+		router.Path(path.Join(legacyPrefix, "/api/v1/status/buildinfo")).Methods("GET").Handler(infoHandler)
 	}
 
 	// Track execution time.
-	return stats.NewWallTimeMiddleware().Wrap(router)
+	return stats.NewWallTimeMiddleware().Wrap(querier.QueryPriorityMiddleware(router))
+}
+
+// statusFlags returns the flags exposed by the Prometheus status API.
+func statusFlags(cfg Config) map[string]string {
+	if cfg.StatusFlags == nil {
+		return map[string]string{}
+	}
+	return cfg.StatusFlags
+}
+
+// runtimeInfoFunc returns the function building the runtime information exposed by the
+// Prometheus status API. The configuration can't be reloaded, so it's reported as
+// successfully loaded at startup.
+func runtimeInfoFunc(cfg Config) func() (v1.RuntimeInfo, error) {
+	storageRetention := "unlimited"
+	if cfg.StorageRetention > 0 {
+		storageRetention = cfg.StorageRetention.String()
+	}
+
+	return func() (v1.RuntimeInfo, error) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			cwd = "<error retrieving current working directory>"
+		}
+
+		return v1.RuntimeInfo{
+			StartTime:           cfg.StartTime,
+			CWD:                 cwd,
+			ReloadConfigSuccess: true,
+			LastConfigTime:      cfg.StartTime,
+			GoroutineCount:      runtime.NumGoroutine(),
+			GOMAXPROCS:          runtime.GOMAXPROCS(0),
+			GOMEMLIMIT:          debug.SetMemoryLimit(-1),
+			GOGC:                os.Getenv("GOGC"),
+			GODEBUG:             os.Getenv("GODEBUG"),
+			StorageRetention:    storageRetention,
+		}, nil
+	}
+}
+
+// buildInfoFeatures returns the features advertised in the build info, which the clients like
+// Grafana use to detect the APIs they can use.
+func buildInfoFeatures(cfg Config) map[string]string {
+	features := map[string]string{}
+	if cfg.RulerConfigAPIEnabled {
+		features["ruler_config_api"] = "true"
+	}
+	return features
 }
 
 type buildInfoHandler struct {
-	logger log.Logger
+	features map[string]string
+	logger   log.Logger
 }
 
 type buildInfoResponse struct {
-	Status string                `json:"status"`
-	Data   *v1.PrometheusVersion `json:"data"`
+	Status string         `json:"status"`
+	Data   *buildInfoData `json:"data"`
+}
+
+type buildInfoData struct {
+	v1.PrometheusVersion
+	Features map[string]string `json:"features,omitempty"`
 }
 
 func (h *buildInfoHandler) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
 	infoResponse := buildInfoResponse{
 		Status: "success",
-		Data: &v1.PrometheusVersion{
-			Version:   version.Version,
-			Branch:    version.Branch,
-			Revision:  version.Revision,
-			BuildUser: version.BuildUser,
-			BuildDate: version.BuildDate,
-			GoVersion: version.GoVersion,
+		Data: &buildInfoData{
+			PrometheusVersion: v1.PrometheusVersion{
+				Version:   version.Version,
+				Branch:    version.Branch,
+				Revision:  version.Revision,
+				BuildUser: version.BuildUser,
+				BuildDate: version.BuildDate,
+				GoVersion: version.GoVersion,
+			},
+			Features: h.features,
 		},
 	}
 	output, err := json.Marshal(infoResponse)
@@ -314,6 +387,7 @@ func (h *buildInfoHandler) ServeHTTP(writer http.ResponseWriter, _ *http.Request
 		http.Error(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusOK)
 	if _, err := writer.Write(output); err != nil {
 		level.Error(h.logger).Log("msg", "write build info response", "error", err)
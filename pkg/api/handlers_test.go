@@ -8,7 +8,9 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/version"
 	v1 "github.com/prometheus/prometheus/web/api/v1"
 	"github.com/stretchr/testify/assert"
@@ -247,3 +249,77 @@ func TestBuildInfoAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildInfoAPI_Features(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cfg      Config
+		expected map[string]string
+	}{
+		{
+			name: "no features",
+			cfg:  Config{buildInfoEnabled: true},
+		},
+		{
+			name:     "ruler config API",
+			cfg:      Config{buildInfoEnabled: true, RulerConfigAPIEnabled: true},
+			expected: map[string]string{"ruler_config_api": "true"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewQuerierHandler(tc.cfg, nil, nil, nil, nil, nil, &FakeLogger{})
+			writer := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/v1/status/buildinfo", nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), "test"))
+			handler.ServeHTTP(writer, req)
+			require.Equal(t, http.StatusOK, writer.Code)
+
+			var info struct {
+				Data struct {
+					Features map[string]string `json:"features"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(writer.Body.Bytes(), &info))
+			require.Equal(t, tc.expected, info.Data.Features)
+		})
+	}
+}
+
+func TestStatusFlagsAndRuntimeInfo(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		StatusFlags:      map[string]string{"querier.max-concurrent": "20", "s3.secret-access-key": "********"},
+		StartTime:        startTime,
+		StorageRetention: model.Duration(30 * 24 * time.Hour),
+	}
+	handler := NewQuerierHandler(cfg, nil, nil, nil, nil, nil, &FakeLogger{})
+
+	get := func(path string, data interface{}) {
+		writer := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", path, nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "test"))
+		handler.ServeHTTP(writer, req)
+		require.Equal(t, http.StatusOK, writer.Code)
+
+		resp := struct {
+			Status string      `json:"status"`
+			Data   interface{} `json:"data"`
+		}{Data: data}
+		require.NoError(t, json.Unmarshal(writer.Body.Bytes(), &resp))
+		require.Equal(t, "success", resp.Status)
+	}
+
+	var flags map[string]string
+	get("/api/v1/status/flags", &flags)
+	assert.Equal(t, cfg.StatusFlags, flags)
+
+	var info v1.RuntimeInfo
+	get("/api/v1/status/runtimeinfo", &info)
+	assert.Equal(t, startTime, info.StartTime.UTC())
+	assert.Equal(t, startTime, info.LastConfigTime.UTC())
+	assert.True(t, info.ReloadConfigSuccess)
+	assert.Equal(t, runtime.GOMAXPROCS(0), info.GOMAXPROCS)
+	assert.Positive(t, info.GoroutineCount)
+	assert.NotEmpty(t, info.CWD)
+	assert.Equal(t, "30d", info.StorageRetention)
+}
@@ -2,8 +2,13 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
 	"net/http"
 
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/tracing"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 )
 
@@ -38,3 +43,116 @@ func (h HTTPHeaderMiddleware) Wrap(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequestIDMiddleware accepts the request ID from the request header, or generates one, and adds it to
+// the request context and the response header
+type RequestIDMiddleware struct{}
+
+// Wrap implements Middleware
+func (RequestIDMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(util_log.RequestIDHeaderName)
+		if !util_log.IsValidRequestID(requestID) {
+			requestID = util_log.NewRequestID()
+			// The request header is forwarded as is by the query-frontend to the queriers.
+			r.Header.Set(util_log.RequestIDHeaderName, requestID)
+		}
+		w.Header().Set(util_log.RequestIDHeaderName, requestID)
+
+		next.ServeHTTP(w, r.WithContext(util_log.ContextWithRequestID(r.Context(), requestID)))
+	})
+}
+
+// TenantDiagnosticsMiddleware traces the requests of the tenants with increased diagnostics, at the ratio
+// returned for the tenant by TraceSamplingRatio, regardless of the sampling of the tracer
+type TenantDiagnosticsMiddleware struct {
+	TraceSamplingRatio func(userID string) float64
+}
+
+// Wrap implements Middleware
+func (m TenantDiagnosticsMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.forceSampling(r.Context()) {
+			sp, ctx := tracing.StartForcedSampledSpan(r.Context(), "TenantDiagnostics")
+			defer sp.Finish()
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m TenantDiagnosticsMiddleware) forceSampling(ctx context.Context) bool {
+	if _, sampled := util_log.ExtractSampledTraceID(ctx); sampled {
+		return false
+	}
+
+	userIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return false
+	}
+
+	ratio := 0.0
+	for _, userID := range userIDs {
+		ratio = max(ratio, m.TraceSamplingRatio(userID))
+	}
+	return ratio > 0 && rand.Float64() < ratio
+}
+
+// ErrorCodeMiddleware adds the code of the error responses whose body has the message of a known error,
+// and the link to its documentation, to the response headers
+type ErrorCodeMiddleware struct{}
+
+// Wrap implements Middleware
+func (ErrorCodeMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ew := &errorCodeResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.writePendingHeader()
+	})
+}
+
+// errorCodeResponseWriter holds the status code of the error responses until the first write of their
+// body, in which the message of a known error is looked for.
+type errorCodeResponseWriter struct {
+	http.ResponseWriter
+
+	pendingStatusCode int
+}
+
+func (w *errorCodeResponseWriter) WriteHeader(statusCode int) {
+	if statusCode < http.StatusBadRequest {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	w.pendingStatusCode = statusCode
+}
+
+func (w *errorCodeResponseWriter) Write(b []byte) (int, error) {
+	if w.pendingStatusCode != 0 {
+		if id, ok := globalerror.IDFromMessage(string(b)); ok {
+			w.Header().Set(globalerror.HeaderName, id.Code())
+			w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="help"`, id.DocumentationURL()))
+		}
+		w.writePendingHeader()
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *errorCodeResponseWriter) Flush() {
+	w.writePendingHeader()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *errorCodeResponseWriter) writePendingHeader() {
+	if w.pendingStatusCode != 0 {
+		w.ResponseWriter.WriteHeader(w.pendingStatusCode)
+		w.pendingStatusCode = 0
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *errorCodeResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
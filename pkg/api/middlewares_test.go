@@ -3,9 +3,15 @@ package api
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 )
@@ -72,3 +78,105 @@ func TestExistingHeaderInContextIsNotOverridden(t *testing.T) {
 	require.Equal(t, contentsMap, util_log.HeaderMapFromContext(ctx))
 
 }
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var requestID string
+	handler := RequestIDMiddleware{}.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = util_log.RequestIDFromContext(r.Context())
+		require.Equal(t, requestID, r.Header.Get(util_log.RequestIDHeaderName))
+	}))
+
+	// The request ID of the client is accepted.
+	req := httptest.NewRequest("GET", "/api/v1/query", nil)
+	req.Header.Set(util_log.RequestIDHeaderName, "client-request-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, "client-request-1", requestID)
+	require.Equal(t, "client-request-1", w.Header().Get(util_log.RequestIDHeaderName))
+
+	// A request ID is generated when the client doesn't give a valid one.
+	for _, clientRequestID := range []string{"", "invalid request id", strings.Repeat("a", 129)} {
+		req = httptest.NewRequest("GET", "/api/v1/query", nil)
+		req.Header.Set(util_log.RequestIDHeaderName, clientRequestID)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.NotEqual(t, clientRequestID, requestID)
+		require.True(t, util_log.IsValidRequestID(requestID))
+		require.Equal(t, requestID, w.Header().Get(util_log.RequestIDHeaderName))
+	}
+}
+
+func TestErrorCodeMiddleware(t *testing.T) {
+	for name, tc := range map[string]struct {
+		statusCode   int
+		body         string
+		expectedCode string
+	}{
+		"error with a code": {
+			statusCode:   http.StatusUnprocessableEntity,
+			body:         `{"status":"error","errorType":"execution","error":"expanding series: the query hit the max number of series limit (limit: 10 series)"}`,
+			expectedCode: "err-cortex-max-series-per-query",
+		},
+		"error without code": {
+			statusCode: http.StatusBadRequest,
+			body:       "invalid parameter",
+		},
+		"success with the message of an error": {
+			statusCode: http.StatusOK,
+			body:       "the query hit the max number of series limit (limit: 10 series)",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			handler := ErrorCodeMiddleware{}.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, tc.body, tc.statusCode)
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/query", nil))
+			require.Equal(t, tc.statusCode, w.Code)
+			require.Equal(t, tc.body+"\n", w.Body.String())
+			require.Equal(t, tc.expectedCode, w.Header().Get("X-Cortex-Error-Code"))
+			if tc.expectedCode != "" {
+				require.Equal(t, `<https://cortexmetrics.io/docs/operations/error-codes/#err-cortex-max-series-per-query>; rel="help"`, w.Header().Get("Link"))
+			}
+		})
+	}
+
+	// The error responses without body are written too.
+	handler := ErrorCodeMiddleware{}.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/push", nil))
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestTenantDiagnosticsMiddleware(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	t.Cleanup(func() { opentracing.SetGlobalTracer(opentracing.NoopTracer{}) })
+
+	handler := TenantDiagnosticsMiddleware{TraceSamplingRatio: func(userID string) float64 {
+		if userID == "user-1" {
+			return 1
+		}
+		return 0
+	}}.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sp := opentracing.SpanFromContext(r.Context()); sp != nil {
+			w.Header().Set("Traced", "true")
+		}
+	}))
+
+	for userID, expectedTraced := range map[string]bool{"user-1": true, "user-2": false} {
+		req := httptest.NewRequest("GET", "/api/v1/query", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), userID))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, expectedTraced, w.Header().Get("Traced") == "true", userID)
+	}
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "TenantDiagnostics", spans[0].OperationName)
+	require.Equal(t, uint16(1), spans[0].Tag(string(ext.SamplingPriority)))
+}
@@ -0,0 +1,240 @@
+// Package tenant resolves the tenant of the HTTP requests from their headers, basic auth username
+// or URL path, so that the clients don't need to set the X-Scope-OrgID header themselves.
+package tenant
+
+import (
+	"flag"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/weaveworks/common/middleware"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+)
+
+const (
+	SourceHeader            = "header"
+	SourceBasicAuthUsername = "basic_auth_username"
+	SourcePathSegment       = "path_segment"
+
+	ActionResolve = "resolve"
+	ActionDeny    = "deny"
+)
+
+var (
+	errUnknownSource    = errors.New("unknown source, supported values are: header, basic_auth_username, path_segment")
+	errUnknownAction    = errors.New("unknown action, supported values are: resolve, deny")
+	errMissingHeader    = errors.New("the header source requires the name of the header")
+	errNegativeSegment  = errors.New("the index of the path segment must be positive or 0")
+	errNoTenantResolved = errors.New("no tenant resolved for the request")
+	errRequestDenied    = errors.New("the request is denied")
+	errUntrustedSource  = errors.New("the basic_auth_username source requires -api.tenant-resolution.trust-basic-auth-username, since the password is not checked")
+)
+
+// Config configures the resolution of the tenant of the HTTP requests.
+type Config struct {
+	Enabled                bool             `yaml:"enabled"`
+	DefaultTenant          string           `yaml:"default_tenant"`
+	DenyUnmatched          bool             `yaml:"deny_unmatched"`
+	TrustBasicAuthUsername bool             `yaml:"trust_basic_auth_username"`
+	Rules                  []ResolutionRule `yaml:"rules" doc:"nocli|description=The rules resolving the tenant, evaluated in order. The first rule matching the request resolves its tenant."`
+}
+
+// RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+".enabled", false, "Experimental: Resolve the tenant of the HTTP requests with the rules, overriding the X-Scope-OrgID header of the requests matched by a rule.")
+	f.StringVar(&cfg.DefaultTenant, prefix+".default-tenant", "", "Tenant of the requests not matched by any rule. Empty to use the X-Scope-OrgID header of the request.")
+	f.BoolVar(&cfg.DenyUnmatched, prefix+".deny-unmatched", false, "Reject the requests not matched by any rule, instead of using the X-Scope-OrgID header of the request, when no default tenant is set.")
+	f.BoolVar(&cfg.TrustBasicAuthUsername, prefix+".trust-basic-auth-username", false, "Allow the rules resolving the tenant from the basic auth username. The password is not checked, so the requests must be authenticated by a proxy in front of Cortex.")
+}
+
+// Validate the config.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.DefaultTenant != "" {
+		if _, err := tenant.TenantIDsFromOrgID(cfg.DefaultTenant); err != nil {
+			return errors.Wrap(err, "invalid default tenant")
+		}
+	}
+	_, err := cfg.compileRules()
+	return err
+}
+
+func (cfg *Config) compileRules() ([]compiledRule, error) {
+	rules := make([]compiledRule, 0, len(cfg.Rules))
+	for i, r := range cfg.Rules {
+		c, err := r.compile()
+		if err == nil && c.Source == SourceBasicAuthUsername && !cfg.TrustBasicAuthUsername {
+			err = errUntrustedSource
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid tenant resolution rule %d", i)
+		}
+		rules = append(rules, c)
+	}
+	return rules, nil
+}
+
+// ResolutionRule extracts a value of the requests, and resolves the tenant from it.
+type ResolutionRule struct {
+	Source      string            `yaml:"source" doc:"nocli|description=Source of the value the tenant is resolved from: header, basic_auth_username or path_segment."`
+	Header      string            `yaml:"header" doc:"nocli|description=Name of the header, for the header source."`
+	Segment     int               `yaml:"segment" doc:"nocli|description=Index of the segment of the URL path, starting from 0, for the path_segment source. The segments are the ones of the whole path of the request, including the HTTP prefixes: prometheus is the segment 0 of /prometheus/api/v1/query.|default=0"`
+	Regex       string            `yaml:"regex" doc:"nocli|description=Regex the value must match for the rule to match the request. It is fully anchored. Empty to match any non-empty value."`
+	Replacement string            `yaml:"replacement" doc:"nocli|description=Tenant resolved from the value matched by the regex, with the capture groups expanded. Empty to use the value.|default="`
+	Mapping     map[string]string `yaml:"mapping" doc:"nocli|description=Tenants of the values, after the replacement. If set, the values not in the mapping don't match the rule."`
+	Action      string            `yaml:"action" doc:"nocli|description=Action on the requests matched: resolve their tenant, or deny them.|default=resolve"`
+}
+
+type compiledRule struct {
+	ResolutionRule
+	regex *regexp.Regexp
+}
+
+func (r ResolutionRule) compile() (compiledRule, error) {
+	c := compiledRule{ResolutionRule: r}
+
+	switch r.Source {
+	case SourceHeader:
+		if r.Header == "" {
+			return c, errMissingHeader
+		}
+	case SourcePathSegment:
+		if r.Segment < 0 {
+			return c, errNegativeSegment
+		}
+	case SourceBasicAuthUsername:
+	default:
+		return c, errUnknownSource
+	}
+
+	switch r.Action {
+	case "":
+		c.Action = ActionResolve
+	case ActionResolve, ActionDeny:
+	default:
+		return c, errUnknownAction
+	}
+
+	if r.Regex != "" {
+		regex, err := regexp.Compile("^(?:" + r.Regex + ")$")
+		if err != nil {
+			return c, errors.Wrap(err, "invalid regex")
+		}
+		c.regex = regex
+	}
+
+	return c, nil
+}
+
+// value returns the value of the request the rule resolves the tenant from, or an empty string.
+// The basic auth username is returned as is, the password being checked by a proxy in front of Cortex.
+func (r compiledRule) value(req *http.Request) string {
+	switch r.Source {
+	case SourceHeader:
+		return req.Header.Get(r.Header)
+	case SourceBasicAuthUsername:
+		username, _, _ := req.BasicAuth()
+		return username
+	case SourcePathSegment:
+		// The path is the one routed, nothing is stripped from it before the middleware.
+		segments := strings.Split(strings.TrimPrefix(req.URL.Path, "/"), "/")
+		if r.Segment < len(segments) {
+			return segments[r.Segment]
+		}
+	}
+	return ""
+}
+
+// match returns the tenant resolved from the request, and whether the rule matches the request.
+func (r compiledRule) match(req *http.Request) (string, bool) {
+	value := r.value(req)
+	if value == "" {
+		return "", false
+	}
+
+	if r.regex != nil {
+		indexes := r.regex.FindStringSubmatchIndex(value)
+		if indexes == nil {
+			return "", false
+		}
+		if r.Replacement != "" {
+			value = string(r.regex.ExpandString(nil, r.Replacement, value, indexes))
+		}
+	} else if r.Replacement != "" {
+		value = r.Replacement
+	}
+
+	if len(r.Mapping) > 0 {
+		mapped, ok := r.Mapping[value]
+		if !ok {
+			return "", false
+		}
+		value = mapped
+	}
+
+	return value, value != ""
+}
+
+// NewMiddleware returns a middleware setting the X-Scope-OrgID header of the requests to the tenant
+// resolved by the rules, before authenticating them with the next middleware.
+func NewMiddleware(cfg Config, next middleware.Interface) (middleware.Interface, error) {
+	rules, err := cfg.compileRules()
+	if err != nil {
+		return nil, err
+	}
+
+	return middleware.Func(func(handler http.Handler) http.Handler {
+		authenticated := next.Wrap(handler)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			orgID, matched, err := resolve(rules, cfg, r)
+			if errors.Is(err, errRequestDenied) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if !matched {
+				authenticated.ServeHTTP(w, r)
+				return
+			}
+
+			// The header is also forwarded by the query-frontend to the queriers.
+			r.Header.Set(user.OrgIDHeaderName, orgID)
+			authenticated.ServeHTTP(w, r)
+		})
+	}), nil
+}
+
+// resolve returns the tenant of the request, and whether it was resolved by the rules or the default tenant.
+func resolve(rules []compiledRule, cfg Config, r *http.Request) (string, bool, error) {
+	for i, rule := range rules {
+		orgID, ok := rule.match(r)
+		if !ok {
+			continue
+		}
+		if rule.Action == ActionDeny {
+			return "", false, errors.Wrapf(errRequestDenied, "tenant resolution rule %d", i)
+		}
+		if _, err := tenant.TenantIDsFromOrgID(orgID); err != nil {
+			return "", false, errors.Wrapf(err, "invalid tenant resolved by the tenant resolution rule %d", i)
+		}
+		return orgID, true, nil
+	}
+
+	if cfg.DefaultTenant != "" {
+		return cfg.DefaultTenant, true, nil
+	}
+	if cfg.DenyUnmatched {
+		return "", false, errNoTenantResolved
+	}
+	return "", false, nil
+}
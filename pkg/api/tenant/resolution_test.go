@@ -0,0 +1,160 @@
+package tenant
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/middleware"
+	"github.com/weaveworks/common/user"
+)
+
+func TestMiddleware(t *testing.T) {
+	rules := []ResolutionRule{
+		{Source: SourceHeader, Header: "X-Blocked", Action: ActionDeny},
+		{Source: SourceHeader, Header: "X-Team", Regex: "team-(.+)", Replacement: "$1"},
+		{Source: SourceBasicAuthUsername, Mapping: map[string]string{"grafana": "ops", "federated": "ops|dev"}},
+		{Source: SourcePathSegment, Segment: 1, Regex: "tenant-[a-z]+"},
+	}
+
+	tests := map[string]struct {
+		cfg            Config
+		setup          func(r *http.Request)
+		path           string
+		expectedStatus int
+		expectedOrgID  string
+	}{
+		"should resolve the tenant from the header with the replacement": {
+			setup:          func(r *http.Request) { r.Header.Set("X-Team", "team-a") },
+			expectedStatus: http.StatusOK,
+			expectedOrgID:  "a",
+		},
+		"should override the X-Scope-OrgID header": {
+			setup: func(r *http.Request) {
+				r.Header.Set("X-Team", "team-a")
+				r.Header.Set(user.OrgIDHeaderName, "b")
+			},
+			expectedStatus: http.StatusOK,
+			expectedOrgID:  "a",
+		},
+		"should resolve the tenant from the mapping of the basic auth username": {
+			setup:          func(r *http.Request) { r.SetBasicAuth("federated", "secret") },
+			expectedStatus: http.StatusOK,
+			expectedOrgID:  "ops|dev",
+		},
+		"should resolve the tenant from the path segment": {
+			path:           "/api/tenant-c/push",
+			expectedStatus: http.StatusOK,
+			expectedOrgID:  "tenant-c",
+		},
+		"should evaluate the rules in order": {
+			setup: func(r *http.Request) {
+				r.Header.Set("X-Team", "team-a")
+				r.SetBasicAuth("grafana", "secret")
+			},
+			expectedStatus: http.StatusOK,
+			expectedOrgID:  "a",
+		},
+		"should deny the requests matched by a deny rule": {
+			setup: func(r *http.Request) {
+				r.Header.Set("X-Blocked", "true")
+				r.Header.Set("X-Team", "team-a")
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		"should reject the invalid tenants resolved": {
+			setup:          func(r *http.Request) { r.Header.Set("X-Team", "team-a/b") },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"should fall back to the X-Scope-OrgID header when no rule matches": {
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("unknown", "secret")
+				r.Header.Set(user.OrgIDHeaderName, "b")
+			},
+			expectedStatus: http.StatusOK,
+			expectedOrgID:  "b",
+		},
+		"should use the default tenant when no rule matches": {
+			cfg:            Config{DefaultTenant: "default", DenyUnmatched: true},
+			setup:          func(r *http.Request) { r.Header.Set(user.OrgIDHeaderName, "b") },
+			expectedStatus: http.StatusOK,
+			expectedOrgID:  "default",
+		},
+		"should deny the requests not matched when configured": {
+			cfg:            Config{DenyUnmatched: true},
+			setup:          func(r *http.Request) { r.Header.Set(user.OrgIDHeaderName, "b") },
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := tc.cfg
+			cfg.Enabled = true
+			cfg.TrustBasicAuthUsername = true
+			cfg.Rules = rules
+			require.NoError(t, cfg.Validate())
+
+			m, err := NewMiddleware(cfg, middleware.AuthenticateUser)
+			require.NoError(t, err)
+
+			var orgID string
+			handler := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				orgID, err = user.ExtractOrgID(r.Context())
+				require.NoError(t, err)
+				assert.Equal(t, orgID, r.Header.Get(user.OrgIDHeaderName))
+			}))
+
+			path := tc.path
+			if path == "" {
+				path = "/api/v1/push"
+			}
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			if tc.setup != nil {
+				tc.setup(req)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedOrgID, orgID)
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		rule     ResolutionRule
+		expected string
+	}{
+		"unknown source": {
+			rule:     ResolutionRule{Source: "query"},
+			expected: "invalid tenant resolution rule 0: " + errUnknownSource.Error(),
+		},
+		"header source without header": {
+			rule:     ResolutionRule{Source: SourceHeader},
+			expected: "invalid tenant resolution rule 0: " + errMissingHeader.Error(),
+		},
+		"unknown action": {
+			rule:     ResolutionRule{Source: SourceBasicAuthUsername, Action: "drop"},
+			expected: "invalid tenant resolution rule 0: " + errUnknownAction.Error(),
+		},
+		"invalid regex": {
+			rule:     ResolutionRule{Source: SourceBasicAuthUsername, Regex: "("},
+			expected: "invalid tenant resolution rule 0: invalid regex: error parsing regexp: missing closing ): `^(?:()$`",
+		},
+		"untrusted basic auth username": {
+			rule:     ResolutionRule{Source: SourceBasicAuthUsername},
+			expected: "invalid tenant resolution rule 0: " + errUntrustedSource.Error(),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := Config{Enabled: true, Rules: []ResolutionRule{tc.rule}}
+			assert.EqualError(t, cfg.Validate(), tc.expected)
+		})
+	}
+}
@@ -33,6 +33,7 @@ type Config struct {
 	Memcache       MemcachedConfig       `yaml:"memcached"`
 	MemcacheClient MemcachedClientConfig `yaml:"memcached_client"`
 	Redis          RedisConfig           `yaml:"redis"`
+	CircuitBreaker CircuitBreakerConfig  `yaml:"circuit_breaker"`
 	Fifocache      FifoCacheConfig       `yaml:"fifocache"`
 
 	// This is to name the cache metrics properly.
@@ -48,6 +49,7 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, description string, f
 	cfg.Memcache.RegisterFlagsWithPrefix(prefix, description, f)
 	cfg.MemcacheClient.RegisterFlagsWithPrefix(prefix, description, f)
 	cfg.Redis.RegisterFlagsWithPrefix(prefix, description, f)
+	cfg.CircuitBreaker.RegisterFlagsWithPrefix(prefix, description, f)
 	cfg.Fifocache.RegisterFlagsWithPrefix(prefix, description, f)
 
 	f.BoolVar(&cfg.EnableFifoCache, prefix+"cache.enable-fifocache", false, description+"Enable in-memory cache.")
@@ -57,6 +59,15 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, description string, f
 }
 
 func (cfg *Config) Validate() error {
+	if err := cfg.MemcacheClient.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.Redis.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.CircuitBreaker.Validate(); err != nil {
+		return err
+	}
 	return cfg.Fifocache.Validate()
 }
 
@@ -87,10 +98,14 @@ func New(cfg Config, reg prometheus.Registerer, logger log.Logger) (Cache, error
 			cfg.Memcache.Expiration = cfg.DefaultValidity
 		}
 
-		client := NewMemcachedClient(cfg.MemcacheClient, cfg.Prefix, reg, logger)
+		client, err := NewMemcachedClient(cfg.MemcacheClient, cfg.Prefix, reg, logger)
+		if err != nil {
+			return nil, err
+		}
 		cache := NewMemcached(cfg.Memcache, client, cfg.Prefix, reg, logger)
 
 		cacheName := cfg.Prefix + "memcache"
+		cache.breaker = newCircuitBreaker(cacheName, cfg.CircuitBreaker, reg, logger)
 		caches = append(caches, NewBackground(cacheName, cfg.Background, Instrument(cacheName, cache, reg), reg))
 	}
 
@@ -98,8 +113,13 @@ func New(cfg Config, reg prometheus.Registerer, logger log.Logger) (Cache, error
 		if cfg.Redis.Expiration == 0 && cfg.DefaultValidity != 0 {
 			cfg.Redis.Expiration = cfg.DefaultValidity
 		}
+		client, err := NewRedisClient(&cfg.Redis, logger)
+		if err != nil {
+			return nil, err
+		}
 		cacheName := cfg.Prefix + "redis"
-		cache := NewRedisCache(cacheName, NewRedisClient(&cfg.Redis), reg, logger)
+		cache := NewRedisCache(cacheName, client, reg, logger)
+		cache.breaker = newCircuitBreaker(cacheName, cfg.CircuitBreaker, reg, logger)
 		caches = append(caches, NewBackground(cacheName, cfg.Background, Instrument(cacheName, cache, reg), reg))
 	}
 
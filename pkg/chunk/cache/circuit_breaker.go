@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sony/gobreaker"
+)
+
+// errCacheBypassed is returned by the requests not sent to the cache backend, because its
+// circuit-breaker is open.
+var errCacheBypassed = errors.New("cache bypassed: the circuit-breaker is open")
+
+// CircuitBreakerConfig configures the circuit-breaker of a cache backend.
+type CircuitBreakerConfig struct {
+	Enabled             bool          `yaml:"enabled"`
+	ConsecutiveFailures uint          `yaml:"consecutive_failures"`
+	OpenDuration        time.Duration `yaml:"open_duration"`
+	HalfOpenMaxRequests uint          `yaml:"half_open_max_requests"`
+}
+
+// RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet
+func (cfg *CircuitBreakerConfig) RegisterFlagsWithPrefix(prefix, description string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"cache.circuit-breaker.enabled", false, description+"Bypass the memcached or Redis cache while it fails, instead of paying its timeout on every request: the fetches miss and the stores are dropped until the cache recovers.")
+	f.UintVar(&cfg.ConsecutiveFailures, prefix+"cache.circuit-breaker.consecutive-failures", 5, description+"Open the circuit-breaker, bypassing the cache, after this number of consecutive failed requests.")
+	f.DurationVar(&cfg.OpenDuration, prefix+"cache.circuit-breaker.open-duration", 10*time.Second, description+"How long the cache is bypassed once the circuit-breaker opens, before probing it again.")
+	f.UintVar(&cfg.HalfOpenMaxRequests, prefix+"cache.circuit-breaker.half-open-max-requests", 1, description+"Number of requests probing the cache once the circuit-breaker is half-open. The circuit-breaker closes if they all succeed, and opens again on the first failure.")
+}
+
+// Validate the config.
+func (cfg *CircuitBreakerConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.ConsecutiveFailures == 0 {
+		return errors.New("the cache circuit-breaker consecutive failures must be greater than 0")
+	}
+	if cfg.OpenDuration <= 0 {
+		return errors.New("the cache circuit-breaker open duration must be greater than 0")
+	}
+	if cfg.HalfOpenMaxRequests == 0 {
+		return errors.New("the cache circuit-breaker half-open max requests must be greater than 0")
+	}
+	return nil
+}
+
+// circuitBreaker bypasses a cache backend while it fails. A nil circuitBreaker sends every
+// request to the backend.
+type circuitBreaker struct {
+	cb *gobreaker.CircuitBreaker
+
+	bypassedRequests *prometheus.CounterVec
+}
+
+func newCircuitBreaker(name string, cfg CircuitBreakerConfig, reg prometheus.Registerer, logger log.Logger) *circuitBreaker {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	state := promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Namespace:   "cortex",
+		Name:        "cache_circuit_breaker_state",
+		Help:        "State of the circuit-breaker of the cache backend: 0 closed, 1 half-open (probing the backend), 2 open (bypassing the backend).",
+		ConstLabels: prometheus.Labels{"name": name},
+	})
+	transitions := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Namespace:   "cortex",
+		Name:        "cache_circuit_breaker_transitions_total",
+		Help:        "Total number of times the circuit-breaker of the cache backend changed to the state.",
+		ConstLabels: prometheus.Labels{"name": name},
+	}, []string{"state"})
+	bypassed := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Namespace:   "cortex",
+		Name:        "cache_bypassed_requests_total",
+		Help:        "Total number of requests not sent to the cache backend, because its circuit-breaker is open.",
+		ConstLabels: prometheus.Labels{"name": name},
+	}, []string{"operation"})
+
+	for _, s := range []gobreaker.State{gobreaker.StateClosed, gobreaker.StateHalfOpen, gobreaker.StateOpen} {
+		transitions.WithLabelValues(s.String())
+	}
+
+	return &circuitBreaker{
+		cb: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:        name,
+			MaxRequests: uint32(cfg.HalfOpenMaxRequests),
+			Timeout:     cfg.OpenDuration,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return uint(counts.ConsecutiveFailures) >= cfg.ConsecutiveFailures
+			},
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				level.Warn(logger).Log("msg", "cache circuit-breaker state change", "name", name, "from-state", from, "to-state", to)
+				state.Set(float64(to))
+				transitions.WithLabelValues(to.String()).Inc()
+			},
+			IsSuccessful: isCacheBackendHealthy,
+		}),
+		bypassedRequests: bypassed,
+	}
+}
+
+// execute runs the request against the cache backend, unless the circuit-breaker is open, in which
+// case errCacheBypassed is returned right away.
+func (c *circuitBreaker) execute(operation string, fn func() error) error {
+	if c == nil {
+		return fn()
+	}
+
+	_, err := c.cb.Execute(func() (interface{}, error) {
+		return nil, fn()
+	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		c.bypassedRequests.WithLabelValues(operation).Inc()
+		return errCacheBypassed
+	}
+	return err
+}
+
+// isCacheBackendHealthy returns whether the error of a request doesn't tell the cache backend is
+// unhealthy: the misses, the invalid keys, and the requests canceled by the callers don't count.
+func isCacheBackendHealthy(err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, memcache.ErrCacheMiss), errors.Is(err, memcache.ErrMalformedKey):
+		return true
+	case errors.Is(err, context.Canceled):
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+type unhealthyMemcache struct {
+	healthy  atomic.Bool
+	requests atomic.Int32
+}
+
+func (m *unhealthyMemcache) GetMulti(keys []string) (map[string]*memcache.Item, error) {
+	m.requests.Inc()
+	if !m.healthy.Load() {
+		return nil, errors.New("i/o timeout")
+	}
+	return map[string]*memcache.Item{}, nil
+}
+
+func (m *unhealthyMemcache) Set(_ *memcache.Item) error {
+	m.requests.Inc()
+	if !m.healthy.Load() {
+		return errors.New("i/o timeout")
+	}
+	return nil
+}
+
+func TestMemcached_CircuitBreaker(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	client := &unhealthyMemcache{}
+	c := NewMemcached(MemcachedConfig{}, client, "test", reg, log.NewNopLogger())
+	c.breaker = newCircuitBreaker("test", CircuitBreakerConfig{
+		Enabled:             true,
+		ConsecutiveFailures: 3,
+		OpenDuration:        100 * time.Millisecond,
+		HalfOpenMaxRequests: 1,
+	}, reg, log.NewNopLogger())
+
+	ctx := context.Background()
+	keys := []string{"a", "b"}
+
+	// The circuit-breaker opens after the consecutive failures, and then bypasses the cache.
+	for i := 0; i < 5; i++ {
+		_, _, missed := c.Fetch(ctx, keys)
+		assert.Equal(t, keys, missed)
+	}
+	c.Store(ctx, keys, [][]byte{{1}, {2}})
+	assert.Equal(t, int32(3), client.requests.Load())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_cache_bypassed_requests_total Total number of requests not sent to the cache backend, because its circuit-breaker is open.
+		# TYPE cortex_cache_bypassed_requests_total counter
+		cortex_cache_bypassed_requests_total{name="test",operation="get"} 2
+		cortex_cache_bypassed_requests_total{name="test",operation="set"} 2
+		# HELP cortex_cache_circuit_breaker_state State of the circuit-breaker of the cache backend: 0 closed, 1 half-open (probing the backend), 2 open (bypassing the backend).
+		# TYPE cortex_cache_circuit_breaker_state gauge
+		cortex_cache_circuit_breaker_state{name="test"} 2
+	`), "cortex_cache_bypassed_requests_total", "cortex_cache_circuit_breaker_state"))
+
+	// Once the cache recovers, the probe closes the circuit-breaker.
+	client.healthy.Store(true)
+	time.Sleep(150 * time.Millisecond)
+	c.Fetch(ctx, keys)
+	c.Fetch(ctx, keys)
+	assert.Equal(t, int32(5), client.requests.Load())
+	assert.Equal(t, gobreaker.StateClosed, c.breaker.cb.State())
+}
+
+func TestCircuitBreaker_IgnoresCallerErrors(t *testing.T) {
+	cb := newCircuitBreaker("test", CircuitBreakerConfig{
+		Enabled:             true,
+		ConsecutiveFailures: 1,
+		OpenDuration:        time.Minute,
+		HalfOpenMaxRequests: 1,
+	}, nil, log.NewNopLogger())
+
+	for _, err := range []error{memcache.ErrCacheMiss, memcache.ErrMalformedKey, context.Canceled} {
+		require.Equal(t, err, cb.execute("get", func() error { return err }))
+	}
+	require.NoError(t, cb.execute("get", func() error { return nil }))
+
+	require.EqualError(t, cb.execute("get", func() error { return errors.New("connection refused") }), "connection refused")
+	require.Equal(t, errCacheBypassed, cb.execute("get", func() error { return nil }))
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	cb := newCircuitBreaker("test", CircuitBreakerConfig{}, nil, log.NewNopLogger())
+	require.Nil(t, cb)
+
+	for i := 0; i < 10; i++ {
+		require.EqualError(t, cb.execute("get", func() error { return errors.New("connection refused") }), "connection refused")
+	}
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/discovery/dns"
+	memcacheDiscovery "github.com/thanos-io/thanos/pkg/discovery/memcache"
+)
+
+// Supported mechanisms to discover the cache servers.
+const (
+	// DiscoveryDNS resolves the addresses in the DNS service discovery format.
+	DiscoveryDNS = "dns"
+	// DiscoveryAutoDiscovery resolves the nodes of the memcached clusters from their configuration
+	// endpoint, as supported by AWS ElastiCache and GCP Memorystore.
+	DiscoveryAutoDiscovery = "auto-discovery"
+	// DiscoveryKubernetes watches the ready endpoints of the Kubernetes services.
+	DiscoveryKubernetes = "kubernetes"
+)
+
+// serverDiscovery discovers the addresses of the cache servers.
+type serverDiscovery interface {
+	// Resolve resolves the addresses of the servers from the configured ones.
+	Resolve(ctx context.Context, addresses []string) error
+	// Addresses returns the addresses of the servers resolved by the last Resolve.
+	Addresses() []string
+}
+
+// newServerDiscovery creates the serverDiscovery of the given mechanism. The discoveries watching
+// the servers call onChange when they change, for the client to resolve them again right away.
+func newServerDiscovery(mechanism string, dialTimeout time.Duration, onChange func(), logger log.Logger, reg prometheus.Registerer) (serverDiscovery, error) {
+	switch mechanism {
+	case DiscoveryDNS:
+		return dns.NewProvider(logger, reg, dns.GolangResolverType), nil
+	case DiscoveryAutoDiscovery:
+		return memcacheDiscovery.NewProvider(logger, reg, dialTimeout), nil
+	case DiscoveryKubernetes:
+		return newInClusterKubernetesDiscovery(onChange, logger)
+	default:
+		return nil, fmt.Errorf("unsupported cache servers discovery %q", mechanism)
+	}
+}
+
+// stopServerDiscovery stops the background work of the discovery, if any.
+func stopServerDiscovery(d serverDiscovery) {
+	if s, ok := d.(interface{ Stop() }); ok {
+		s.Stop()
+	}
+}
+
+func validateServerDiscovery(mechanism string, supported ...string) error {
+	for _, s := range supported {
+		if mechanism == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported cache servers discovery %q, supported values are: %s", mechanism, strings.Join(supported, ", "))
+}
@@ -0,0 +1,321 @@
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	kubernetesTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	kubernetesCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+	// kubernetesWatchBackoff is how long to wait before watching an endpoints again after a failure.
+	kubernetesWatchBackoff = time.Second
+)
+
+// kubernetesDiscovery discovers the cache servers from the ready endpoints of Kubernetes services,
+// given as <namespace>/<service>:<port>, where port is the name of the service port or the port
+// number of the pods. The endpoints are watched, so that the servers going away are noticed as
+// soon as their pods are not ready anymore, instead of on the next DNS resolution.
+type kubernetesDiscovery struct {
+	apiURL    string
+	tokenFile string
+	client    *http.Client
+	onChange  func()
+	logger    log.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mtx      sync.Mutex
+	services map[string]*kubernetesService // Keyed by the configured address.
+	resolved []string
+}
+
+type kubernetesService struct {
+	namespace string
+	name      string
+	port      string
+
+	addresses []string
+}
+
+// kubernetesEndpoints is the subset of the Kubernetes Endpoints object used by the discovery.
+type kubernetesEndpoints struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Subsets []struct {
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"subsets"`
+}
+
+type kubernetesWatchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// newInClusterKubernetesDiscovery creates a kubernetesDiscovery authenticated with the service
+// account of the pod it runs in.
+func newInClusterKubernetesDiscovery(onChange func(), logger log.Logger) (*kubernetesDiscovery, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("the kubernetes cache servers discovery is only supported when running in a Kubernetes cluster")
+	}
+
+	ca, err := os.ReadFile(kubernetesCAFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "read the Kubernetes CA")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid Kubernetes CA")
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	return newKubernetesDiscovery("https://"+net.JoinHostPort(host, port), kubernetesTokenFile, client, onChange, logger), nil
+}
+
+func newKubernetesDiscovery(apiURL, tokenFile string, client *http.Client, onChange func(), logger log.Logger) *kubernetesDiscovery {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &kubernetesDiscovery{
+		apiURL:    apiURL,
+		tokenFile: tokenFile,
+		client:    client,
+		onChange:  onChange,
+		logger:    logger,
+		ctx:       ctx,
+		cancel:    cancel,
+		services:  map[string]*kubernetesService{},
+	}
+}
+
+// Resolve implements serverDiscovery. The endpoints of the services not watched yet are listed,
+// and then watched in the background.
+func (d *kubernetesDiscovery) Resolve(ctx context.Context, addresses []string) error {
+	for _, addr := range addresses {
+		d.mtx.Lock()
+		_, ok := d.services[addr]
+		d.mtx.Unlock()
+		if ok {
+			continue
+		}
+
+		svc, err := parseKubernetesService(addr)
+		if err != nil {
+			return err
+		}
+		endpoints, err := d.getEndpoints(ctx, svc)
+		if err != nil {
+			return errors.Wrapf(err, "get the endpoints of %s", addr)
+		}
+		svc.addresses = endpointsAddresses(endpoints, svc.port)
+
+		d.mtx.Lock()
+		d.services[addr] = svc
+		d.mtx.Unlock()
+
+		d.wg.Add(1)
+		go d.watch(svc, endpoints.Metadata.ResourceVersion)
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var resolved []string
+	for _, addr := range addresses {
+		resolved = append(resolved, d.services[addr].addresses...)
+	}
+	d.resolved = resolved
+	return nil
+}
+
+// Addresses implements serverDiscovery.
+func (d *kubernetesDiscovery) Addresses() []string {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	return d.resolved
+}
+
+// Stop stops watching the endpoints.
+func (d *kubernetesDiscovery) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *kubernetesDiscovery) watch(svc *kubernetesService, resourceVersion string) {
+	defer d.wg.Done()
+
+	for d.ctx.Err() == nil {
+		if resourceVersion == "" {
+			// The watch failed: list the endpoints again, to get their latest version.
+			endpoints, err := d.getEndpoints(d.ctx, svc)
+			if err != nil {
+				level.Warn(d.logger).Log("msg", "failed to get the endpoints of the cache servers", "namespace", svc.namespace, "service", svc.name, "err", err)
+				d.sleep(kubernetesWatchBackoff)
+				continue
+			}
+			d.update(svc, endpointsAddresses(endpoints, svc.port))
+			resourceVersion = endpoints.Metadata.ResourceVersion
+		}
+
+		var err error
+		resourceVersion, err = d.watchEndpoints(svc, resourceVersion)
+		if err != nil && d.ctx.Err() == nil {
+			level.Warn(d.logger).Log("msg", "failed to watch the endpoints of the cache servers", "namespace", svc.namespace, "service", svc.name, "err", err)
+			d.sleep(kubernetesWatchBackoff)
+		}
+	}
+}
+
+// watchEndpoints watches the endpoints of the service from the given version, until the watch
+// ends. It returns the version to watch from next, empty if the endpoints must be listed again.
+func (d *kubernetesDiscovery) watchEndpoints(svc *kubernetesService, resourceVersion string) (string, error) {
+	query := url.Values{}
+	query.Set("watch", "true")
+	query.Set("fieldSelector", "metadata.name="+svc.name)
+	query.Set("resourceVersion", resourceVersion)
+
+	resp, err := d.do(d.ctx, fmt.Sprintf("/api/v1/namespaces/%s/endpoints?%s", url.PathEscape(svc.namespace), query.Encode()))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event kubernetesWatchEvent
+		if err := decoder.Decode(&event); err != nil {
+			// The API server ends the watches periodically.
+			return resourceVersion, nil
+		}
+
+		switch event.Type {
+		case "ADDED", "MODIFIED", "DELETED":
+			var endpoints kubernetesEndpoints
+			if err := json.Unmarshal(event.Object, &endpoints); err != nil {
+				return "", errors.Wrap(err, "decode the endpoints")
+			}
+			resourceVersion = endpoints.Metadata.ResourceVersion
+			if event.Type == "DELETED" {
+				d.update(svc, nil)
+			} else {
+				d.update(svc, endpointsAddresses(endpoints, svc.port))
+			}
+		case "ERROR":
+			// Typically, the version is too old to watch from.
+			return "", nil
+		}
+	}
+}
+
+func (d *kubernetesDiscovery) getEndpoints(ctx context.Context, svc *kubernetesService) (kubernetesEndpoints, error) {
+	var endpoints kubernetesEndpoints
+
+	resp, err := d.do(ctx, fmt.Sprintf("/api/v1/namespaces/%s/endpoints/%s", url.PathEscape(svc.namespace), url.PathEscape(svc.name)))
+	if err != nil {
+		return endpoints, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&endpoints)
+	return endpoints, errors.Wrap(err, "decode the endpoints")
+}
+
+func (d *kubernetesDiscovery) do(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.apiURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if d.tokenFile != "" {
+		// The service account tokens are rotated, so the token is read again on each request.
+		token, err := os.ReadFile(d.tokenFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read the service account token")
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// update sets the addresses of the service, and notifies the change.
+func (d *kubernetesDiscovery) update(svc *kubernetesService, addresses []string) {
+	d.mtx.Lock()
+	changed := !slices.Equal(svc.addresses, addresses)
+	svc.addresses = addresses
+	d.mtx.Unlock()
+
+	if changed && d.onChange != nil {
+		d.onChange()
+	}
+}
+
+func (d *kubernetesDiscovery) sleep(duration time.Duration) {
+	select {
+	case <-time.After(duration):
+	case <-d.ctx.Done():
+	}
+}
+
+func parseKubernetesService(addr string) (*kubernetesService, error) {
+	namespace, rest, ok := strings.Cut(addr, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid Kubernetes service %q, expected <namespace>/<service>:<port>", addr)
+	}
+	name, port, err := net.SplitHostPort(rest)
+	if err != nil || namespace == "" || name == "" || port == "" {
+		return nil, fmt.Errorf("invalid Kubernetes service %q, expected <namespace>/<service>:<port>", addr)
+	}
+	return &kubernetesService{namespace: namespace, name: name, port: port}, nil
+}
+
+// endpointsAddresses returns the sorted addresses of the ready endpoints, on the given port.
+func endpointsAddresses(endpoints kubernetesEndpoints, port string) []string {
+	var addresses []string
+	for _, subset := range endpoints.Subsets {
+		for _, p := range subset.Ports {
+			if p.Name != port && strconv.Itoa(p.Port) != port {
+				continue
+			}
+			for _, a := range subset.Addresses {
+				addresses = append(addresses, net.JoinHostPort(a.IP, strconv.Itoa(p.Port)))
+			}
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestKubernetesDiscovery(t *testing.T) {
+	endpoints := func(version int, ips ...string) string {
+		addresses := ""
+		for i, ip := range ips {
+			if i > 0 {
+				addresses += ","
+			}
+			addresses += fmt.Sprintf(`{"ip":%q}`, ip)
+		}
+		return fmt.Sprintf(`{"metadata":{"resourceVersion":"%d"},"subsets":[{"addresses":[%s],"ports":[{"name":"client","port":11211},{"name":"metrics","port":9150}]}]}`, version, addresses)
+	}
+
+	events := make(chan string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/namespaces/cortex/endpoints/memcached":
+			_, _ = w.Write([]byte(endpoints(1, "10.0.0.2", "10.0.0.1")))
+		case r.URL.Path == "/api/v1/namespaces/cortex/endpoints" && r.URL.Query().Get("watch") == "true":
+			assert.Equal(t, "metadata.name=memcached", r.URL.Query().Get("fieldSelector"))
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case event := <-events:
+					_, _ = w.Write([]byte(event))
+					w.(http.Flusher).Flush()
+				case <-r.Context().Done():
+					return
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	changes := atomic.NewInt32(0)
+	d := newKubernetesDiscovery(server.URL, "", server.Client(), func() { changes.Inc() }, log.NewNopLogger())
+	t.Cleanup(d.Stop)
+
+	ctx := context.Background()
+	require.NoError(t, d.Resolve(ctx, []string{"cortex/memcached:client"}))
+	assert.Equal(t, []string{"10.0.0.1:11211", "10.0.0.2:11211"}, d.Addresses())
+
+	// A pod not ready anymore is noticed right away.
+	events <- fmt.Sprintf(`{"type":"MODIFIED","object":%s}`, endpoints(2, "10.0.0.2"))
+	require.Eventually(t, func() bool { return changes.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, d.Resolve(ctx, []string{"cortex/memcached:client"}))
+	assert.Equal(t, []string{"10.0.0.2:11211"}, d.Addresses())
+
+	// The endpoints not changing the addresses aren't notified.
+	events <- fmt.Sprintf(`{"type":"MODIFIED","object":%s}`, endpoints(3, "10.0.0.2"))
+	events <- fmt.Sprintf(`{"type":"DELETED","object":%s}`, endpoints(4))
+	require.Eventually(t, func() bool { return changes.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, d.Resolve(ctx, []string{"cortex/memcached:client"}))
+	assert.Empty(t, d.Addresses())
+
+	// The services which don't exist fail to resolve.
+	require.ErrorContains(t, d.Resolve(ctx, []string{"cortex/redis:6379"}), "unexpected status code 404")
+	require.ErrorContains(t, d.Resolve(ctx, []string{"memcached:11211"}), "expected <namespace>/<service>:<port>")
+}
+
+func TestEndpointsAddresses(t *testing.T) {
+	var endpoints kubernetesEndpoints
+	require.NoError(t, json.Unmarshal([]byte(`{"subsets":[
+		{"addresses":[{"ip":"fd00::1"},{"ip":"10.0.0.1"}],"ports":[{"name":"client","port":6379},{"name":"metrics","port":9121}]},
+		{"addresses":[{"ip":"10.0.0.2"}],"ports":[{"name":"client","port":6380}]}
+	]}`), &endpoints))
+
+	assert.Equal(t, []string{"10.0.0.1:6379", "10.0.0.2:6380", "[fd00::1]:6379"}, endpointsAddresses(endpoints, "client"))
+	assert.Equal(t, []string{"10.0.0.1:9121", "[fd00::1]:9121"}, endpointsAddresses(endpoints, "9121"))
+	assert.Empty(t, endpointsAddresses(endpoints, "http"))
+}
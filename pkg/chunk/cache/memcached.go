@@ -40,6 +40,7 @@ type Memcached struct {
 	cfg      MemcachedConfig
 	memcache MemcachedClient
 	name     string
+	breaker  *circuitBreaker
 
 	requestDuration *instr.HistogramCollector
 
@@ -127,6 +128,8 @@ func memcacheStatusCode(err error) string {
 		return "404"
 	case memcache.ErrMalformedKey:
 		return "400"
+	case errCacheBypassed:
+		return "503"
 	default:
 		return "500"
 	}
@@ -153,13 +156,16 @@ func (c *Memcached) fetch(ctx context.Context, keys []string) (found []string, b
 		defer log.Finish()
 		log.LogFields(otlog.Int("keys requested", len(keys)))
 
-		var err error
-		items, err = c.memcache.GetMulti(keys)
+		err := c.breaker.execute("get", func() error {
+			var err error
+			items, err = c.memcache.GetMulti(keys)
+			return err
+		})
 
 		log.LogFields(otlog.Int("keys found", len(items)))
 
 		// Memcached returns partial results even on error.
-		if err != nil {
+		if err != nil && err != errCacheBypassed {
 			log.Error(err)
 			level.Error(log).Log("msg", "Failed to get keys from memcached", "err", err)
 		}
@@ -242,9 +248,11 @@ func (c *Memcached) Store(ctx context.Context, keys []string, bufs [][]byte) {
 				Value:      bufs[i],
 				Expiration: int32(c.cfg.Expiration.Seconds()),
 			}
-			return c.memcache.Set(&item)
+			return c.breaker.execute("set", func() error {
+				return c.memcache.Set(&item)
+			})
 		})
-		if err != nil {
+		if err != nil && err != errCacheBypassed {
 			level.Error(c.logger).Log("msg", "failed to put to memcached", "name", c.name, "err", err)
 		}
 	}
@@ -17,7 +17,6 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sony/gobreaker"
-	"github.com/thanos-io/thanos/pkg/discovery/dns"
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 )
@@ -34,7 +33,8 @@ type serverSelector interface {
 }
 
 // memcachedClient is a memcache client that gets its server list from SRV
-// records, and periodically updates that ServerList.
+// records, or from the discovery of the configured addresses, and periodically
+// updates that ServerList.
 type memcachedClient struct {
 	sync.Mutex
 	name string
@@ -45,7 +45,7 @@ type memcachedClient struct {
 	service  string
 
 	addresses []string
-	provider  *dns.Provider
+	provider  serverDiscovery
 
 	cbs        map[ /*address*/ string]*gobreaker.CircuitBreaker
 	cbFailures uint
@@ -54,8 +54,9 @@ type memcachedClient struct {
 
 	maxItemSize int
 
-	quit chan struct{}
-	wait sync.WaitGroup
+	quit   chan struct{}
+	update chan struct{}
+	wait   sync.WaitGroup
 
 	numServers prometheus.Gauge
 	skipped    prometheus.Counter
@@ -68,6 +69,7 @@ type MemcachedClientConfig struct {
 	Host           string        `yaml:"host"`
 	Service        string        `yaml:"service"`
 	Addresses      string        `yaml:"addresses"` // EXPERIMENTAL.
+	Discovery      string        `yaml:"discovery"` // EXPERIMENTAL.
 	Timeout        time.Duration `yaml:"timeout"`
 	MaxIdleConns   int           `yaml:"max_idle_conns"`
 	MaxItemSize    int           `yaml:"max_item_size"`
@@ -83,6 +85,7 @@ func (cfg *MemcachedClientConfig) RegisterFlagsWithPrefix(prefix, description st
 	f.StringVar(&cfg.Host, prefix+"memcached.hostname", "", description+"Hostname for memcached service to use. If empty and if addresses is unset, no memcached will be used.")
 	f.StringVar(&cfg.Service, prefix+"memcached.service", "memcached", description+"SRV service used to discover memcache servers.")
 	f.StringVar(&cfg.Addresses, prefix+"memcached.addresses", "", description+"EXPERIMENTAL: Comma separated addresses list in DNS Service Discovery format: https://cortexmetrics.io/docs/configuration/arguments/#dns-service-discovery")
+	f.StringVar(&cfg.Discovery, prefix+"memcached.discovery", DiscoveryDNS, description+"EXPERIMENTAL: How to discover the memcached servers from the addresses. Supported values are: dns (addresses in DNS Service Discovery format), auto-discovery (configuration endpoints of memcached clusters supporting the auto-discovery, like AWS ElastiCache and GCP Memorystore), kubernetes (addresses in the <namespace>/<service>:<port> format, whose ready endpoints are watched).")
 	f.IntVar(&cfg.MaxIdleConns, prefix+"memcached.max-idle-conns", 16, description+"Maximum number of idle connections in pool.")
 	f.DurationVar(&cfg.Timeout, prefix+"memcached.timeout", 100*time.Millisecond, description+"Maximum time to wait before giving up on memcached requests.")
 	f.DurationVar(&cfg.UpdateInterval, prefix+"memcached.update-interval", 1*time.Minute, description+"Period with which to poll DNS for memcache servers.")
@@ -93,9 +96,19 @@ func (cfg *MemcachedClientConfig) RegisterFlagsWithPrefix(prefix, description st
 	f.IntVar(&cfg.MaxItemSize, prefix+"memcached.max-item-size", 0, description+"The maximum size of an item stored in memcached. Bigger items are not stored. If set to 0, no maximum size is enforced.")
 }
 
+// Validate the config.
+func (cfg *MemcachedClientConfig) Validate() error {
+	if cfg.Addresses == "" || cfg.Discovery == "" {
+		return nil
+	}
+	return validateServerDiscovery(cfg.Discovery, DiscoveryDNS, DiscoveryAutoDiscovery, DiscoveryKubernetes)
+}
+
 // NewMemcachedClient creates a new MemcacheClient that gets its server list
-// from SRV and updates the server list on a regular basis.
-func NewMemcachedClient(cfg MemcachedClientConfig, name string, r prometheus.Registerer, logger log.Logger) MemcachedClient {
+// from SRV and updates the server list on a regular basis. The server list is
+// also updated right away when the discovery notices a change of the servers,
+// or when the circuit-breaker of a server trips.
+func NewMemcachedClient(cfg MemcachedClientConfig, name string, r prometheus.Registerer, logger log.Logger) (MemcachedClient, error) {
 	var selector serverSelector
 	if cfg.ConsistentHash {
 		selector = &MemcachedJumpHashSelector{}
@@ -118,13 +131,13 @@ func NewMemcachedClient(cfg MemcachedClientConfig, name string, r prometheus.Reg
 		hostname:    cfg.Host,
 		service:     cfg.Service,
 		logger:      logger,
-		provider:    dns.NewProvider(logger, dnsProviderRegisterer, dns.GolangResolverType),
 		cbs:         make(map[string]*gobreaker.CircuitBreaker),
 		cbFailures:  cfg.CBFailures,
 		cbInterval:  cfg.CBInterval,
 		cbTimeout:   cfg.CBTimeout,
 		maxItemSize: cfg.MaxItemSize,
 		quit:        make(chan struct{}),
+		update:      make(chan struct{}, 1),
 
 		numServers: promauto.With(r).NewGauge(prometheus.GaugeOpts{
 			Namespace:   "cortex",
@@ -147,6 +160,16 @@ func NewMemcachedClient(cfg MemcachedClientConfig, name string, r prometheus.Reg
 	if len(cfg.Addresses) > 0 {
 		util_log.WarnExperimentalUse("DNS-based memcached service discovery")
 		newClient.addresses = strings.Split(cfg.Addresses, ",")
+
+		discovery := cfg.Discovery
+		if discovery == "" {
+			discovery = DiscoveryDNS
+		}
+		var err error
+		newClient.provider, err = newServerDiscovery(discovery, cfg.Timeout, newClient.triggerUpdate, logger, dnsProviderRegisterer)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	err := newClient.updateMemcacheServers()
@@ -156,11 +179,25 @@ func NewMemcachedClient(cfg MemcachedClientConfig, name string, r prometheus.Reg
 
 	newClient.wait.Add(1)
 	go newClient.updateLoop(cfg.UpdateInterval)
-	return newClient
+	return newClient, nil
 }
 
 func (c *memcachedClient) circuitBreakerStateChange(name string, from gobreaker.State, to gobreaker.State) {
 	level.Info(c.logger).Log("msg", "circuit-breaker state change", "name", name, "from-state", from, "to-state", to)
+
+	if to == gobreaker.StateOpen {
+		// The server may be gone: don't wait for the next update to notice it.
+		c.triggerUpdate()
+	}
+}
+
+// triggerUpdate updates the server list without waiting for the next update interval.
+func (c *memcachedClient) triggerUpdate() {
+	select {
+	case c.update <- struct{}{}:
+	default:
+		// An update is already pending.
+	}
 }
 
 func (c *memcachedClient) dialViaCircuitBreaker(network, address string, timeout time.Duration) (net.Conn, error) {
@@ -193,6 +230,10 @@ func (c *memcachedClient) dialViaCircuitBreaker(network, address string, timeout
 func (c *memcachedClient) Stop() {
 	close(c.quit)
 	c.wait.Wait()
+
+	if c.provider != nil {
+		stopServerDiscovery(c.provider)
+	}
 }
 
 func (c *memcachedClient) Set(item *memcache.Item) error {
@@ -227,6 +268,11 @@ func (c *memcachedClient) updateLoop(updateInterval time.Duration) {
 			if err != nil {
 				level.Warn(c.logger).Log("msg", "error updating memcache servers", "err", err)
 			}
+		case <-c.update:
+			err := c.updateMemcacheServers()
+			if err != nil {
+				level.Warn(c.logger).Log("msg", "error updating memcache servers", "err", err)
+			}
 		case <-c.quit:
 			ticker.Stop()
 			return
@@ -234,8 +280,8 @@ func (c *memcachedClient) updateLoop(updateInterval time.Duration) {
 	}
 }
 
-// updateMemcacheServers sets a memcache server list from SRV records. SRV
-// priority & weight are ignored.
+// updateMemcacheServers sets a memcache server list from SRV records, or from
+// the discovery of the addresses. SRV priority & weight are ignored.
 func (c *memcachedClient) updateMemcacheServers() error {
 	var servers []string
 
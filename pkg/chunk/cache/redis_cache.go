@@ -18,6 +18,7 @@ import (
 type RedisCache struct {
 	name            string
 	redis           *RedisClient
+	breaker         *circuitBreaker
 	logger          log.Logger
 	requestDuration *instr.HistogramCollector
 }
@@ -50,6 +51,8 @@ func redisStatusCode(err error) string {
 	switch err {
 	case nil:
 		return "200"
+	case errCacheBypassed:
+		return "503"
 	default:
 		return "500"
 	}
@@ -65,8 +68,14 @@ func (c *RedisCache) Fetch(ctx context.Context, keys []string) (found []string,
 		defer log.Finish()
 		log.LogFields(otlog.Int("keys requested", len(keys)))
 
-		var err error
-		items, err = c.redis.MGet(ctx, keys)
+		err := c.breaker.execute("get", func() error {
+			var err error
+			items, err = c.redis.MGet(ctx, keys)
+			return err
+		})
+		if err == errCacheBypassed {
+			return err
+		}
 		if err != nil {
 			log.Error(err)
 			level.Error(util_log.WithContext(ctx, c.logger)).Log("msg", "failed to get from redis", "name", c.name, "err", err)
@@ -95,8 +104,10 @@ func (c *RedisCache) Fetch(ctx context.Context, keys []string) (found []string,
 
 // Store stores the key in the cache.
 func (c *RedisCache) Store(ctx context.Context, keys []string, bufs [][]byte) {
-	err := c.redis.MSet(ctx, keys, bufs)
-	if err != nil {
+	err := c.breaker.execute("set", func() error {
+		return c.redis.MSet(ctx, keys, bufs)
+	})
+	if err != nil && err != errCacheBypassed {
 		level.Error(util_log.WithContext(ctx, c.logger)).Log("msg", "failed to put to redis", "name", c.name, "err", err)
 	}
 }
@@ -5,15 +5,25 @@ import (
 	"crypto/tls"
 	"flag"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unsafe"
 
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
 
 	"github.com/cortexproject/cortex/pkg/util/flagext"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
 )
 
+// redisClusterSlots is the number of hash slots sharded across the discovered redis servers.
+const redisClusterSlots = 16384
+
 // RedisConfig defines how a RedisCache should be constructed.
 type RedisConfig struct {
 	Endpoint           string         `yaml:"endpoint"`
@@ -27,6 +37,9 @@ type RedisConfig struct {
 	InsecureSkipVerify bool           `yaml:"tls_insecure_skip_verify"`
 	IdleTimeout        time.Duration  `yaml:"idle_timeout"`
 	MaxConnAge         time.Duration  `yaml:"max_connection_age"`
+
+	Discovery               string        `yaml:"discovery"` // EXPERIMENTAL.
+	DiscoveryUpdateInterval time.Duration `yaml:"discovery_update_interval"`
 }
 
 // RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet
@@ -42,16 +55,47 @@ func (cfg *RedisConfig) RegisterFlagsWithPrefix(prefix, description string, f *f
 	f.BoolVar(&cfg.InsecureSkipVerify, prefix+"redis.tls-insecure-skip-verify", false, description+"Skip validating server certificate.")
 	f.DurationVar(&cfg.IdleTimeout, prefix+"redis.idle-timeout", 0, description+"Close connections after remaining idle for this duration. If the value is zero, then idle connections are not closed.")
 	f.DurationVar(&cfg.MaxConnAge, prefix+"redis.max-connection-age", 0, description+"Close connections older than this duration. If the value is zero, then the pool does not close connections based on age.")
+	f.StringVar(&cfg.Discovery, prefix+"redis.discovery", "", description+"EXPERIMENTAL: How to discover the redis servers from the endpoint. If empty, the endpoint is used as is. Supported values are: dns (comma separated addresses in DNS Service Discovery format), kubernetes (comma separated addresses in the <namespace>/<service>:<port> format, whose ready endpoints are watched). The keys are sharded across the discovered servers, which must not run in cluster mode.")
+	f.DurationVar(&cfg.DiscoveryUpdateInterval, prefix+"redis.discovery-update-interval", time.Minute, description+"Period with which to discover the redis servers, if the discovery is enabled.")
+}
+
+// Validate the config.
+func (cfg *RedisConfig) Validate() error {
+	if cfg.Endpoint == "" || cfg.Discovery == "" {
+		return nil
+	}
+	if err := validateServerDiscovery(cfg.Discovery, DiscoveryDNS, DiscoveryKubernetes); err != nil {
+		return err
+	}
+	if cfg.MasterName != "" || cfg.DB != 0 {
+		return errors.New("the redis servers discovery doesn't support Redis Sentinel nor a database index")
+	}
+	return nil
 }
 
 type RedisClient struct {
 	expiration time.Duration
 	timeout    time.Duration
 	rdb        redis.UniversalClient
+
+	// The discovery of the servers, if enabled.
+	addresses []string
+	discovery serverDiscovery
+	logger    log.Logger
+	quit      chan struct{}
+	update    chan struct{}
+	wait      sync.WaitGroup
+
+	serversMtx sync.Mutex
+	servers    []string
 }
 
 // NewRedisClient creates Redis client
-func NewRedisClient(cfg *RedisConfig) *RedisClient {
+func NewRedisClient(cfg *RedisConfig, logger log.Logger) (*RedisClient, error) {
+	if cfg.Discovery != "" {
+		return newDiscoveredRedisClient(cfg, logger)
+	}
+
 	opt := &redis.UniversalOptions{
 		Addrs:       strings.Split(cfg.Endpoint, ","),
 		MasterName:  cfg.MasterName,
@@ -68,7 +112,50 @@ func NewRedisClient(cfg *RedisConfig) *RedisClient {
 		expiration: cfg.Expiration,
 		timeout:    cfg.Timeout,
 		rdb:        redis.NewUniversalClient(opt),
+	}, nil
+}
+
+// newDiscoveredRedisClient creates a Redis client sharding the keys across the discovered servers,
+// which are updated on a regular basis, and right away when the discovery notices a change.
+func newDiscoveredRedisClient(cfg *RedisConfig, logger log.Logger) (*RedisClient, error) {
+	util_log.WarnExperimentalUse("Redis servers discovery")
+
+	c := &RedisClient{
+		expiration: cfg.Expiration,
+		timeout:    cfg.Timeout,
+		addresses:  strings.Split(cfg.Endpoint, ","),
+		logger:     logger,
+		quit:       make(chan struct{}),
+		update:     make(chan struct{}, 1),
+	}
+
+	var err error
+	c.discovery, err = newServerDiscovery(cfg.Discovery, cfg.Timeout, c.triggerUpdate, logger, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The servers are not in cluster mode: the cluster client shards the keys across them
+	// with the slots returned by ClusterSlots.
+	opt := &redis.ClusterOptions{
+		ClusterSlots: c.clusterSlots,
+		Password:     cfg.Password.Value,
+		PoolSize:     cfg.PoolSize,
+		IdleTimeout:  cfg.IdleTimeout,
+		MaxConnAge:   cfg.MaxConnAge,
+	}
+	if cfg.EnableTLS {
+		opt.TLSConfig = &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	}
+	c.rdb = redis.NewClusterClient(opt)
+
+	if err := c.updateServers(); err != nil {
+		level.Error(logger).Log("msg", "error discovering redis servers", "endpoint", cfg.Endpoint, "err", err)
 	}
+
+	c.wait.Add(1)
+	go c.updateLoop(cfg.DiscoveryUpdateInterval)
+	return c, nil
 }
 
 func (c *RedisClient) Ping(ctx context.Context) error {
@@ -150,9 +237,84 @@ func (c *RedisClient) MGet(ctx context.Context, keys []string) ([][]byte, error)
 }
 
 func (c *RedisClient) Close() error {
+	if c.discovery != nil {
+		close(c.quit)
+		c.wait.Wait()
+		stopServerDiscovery(c.discovery)
+	}
 	return c.rdb.Close()
 }
 
+// triggerUpdate updates the servers without waiting for the next update interval.
+func (c *RedisClient) triggerUpdate() {
+	select {
+	case c.update <- struct{}{}:
+	default:
+		// An update is already pending.
+	}
+}
+
+func (c *RedisClient) updateLoop(updateInterval time.Duration) {
+	defer c.wait.Done()
+	ticker := time.NewTicker(updateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.update:
+		case <-c.quit:
+			return
+		}
+
+		if err := c.updateServers(); err != nil {
+			level.Warn(c.logger).Log("msg", "error updating redis servers", "err", err)
+		}
+	}
+}
+
+// updateServers discovers the servers, and reloads the sharding of the keys if they changed.
+func (c *RedisClient) updateServers() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := c.discovery.Resolve(ctx, c.addresses); err != nil {
+		return err
+	}
+	servers := append([]string(nil), c.discovery.Addresses()...)
+	sort.Strings(servers)
+
+	c.serversMtx.Lock()
+	changed := !slices.Equal(c.servers, servers)
+	c.servers = servers
+	c.serversMtx.Unlock()
+
+	if changed {
+		c.rdb.(*redis.ClusterClient).ReloadState(ctx)
+	}
+	return nil
+}
+
+// clusterSlots shards the hash slots evenly across the discovered servers.
+func (c *RedisClient) clusterSlots(_ context.Context) ([]redis.ClusterSlot, error) {
+	c.serversMtx.Lock()
+	defer c.serversMtx.Unlock()
+
+	if len(c.servers) == 0 {
+		return nil, errors.New("no redis servers discovered")
+	}
+
+	slots := make([]redis.ClusterSlot, 0, len(c.servers))
+	for i, addr := range c.servers {
+		slots = append(slots, redis.ClusterSlot{
+			Start: i * redisClusterSlots / len(c.servers),
+			End:   (i+1)*redisClusterSlots/len(c.servers) - 1,
+			Nodes: []redis.ClusterNode{{Addr: addr}},
+		})
+	}
+	return slots, nil
+}
+
 // StringToBytes converts string to byte slice. (copied from vendor/github.com/go-redis/redis/v8/internal/util/unsafe.go)
 func StringToBytes(s string) []byte {
 	return *(*[]byte)(unsafe.Pointer(
@@ -2,10 +2,12 @@ package cache
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kit/log"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/require"
 )
@@ -98,3 +100,56 @@ func mockRedisClientCluster() (*RedisClient, error) {
 		}),
 	}, nil
 }
+
+func TestRedisClient_Discovery(t *testing.T) {
+	redisServer1, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(redisServer1.Close)
+	redisServer2, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(redisServer2.Close)
+
+	cfg := RedisConfig{
+		Endpoint:                redisServer1.Addr() + "," + redisServer2.Addr(),
+		Discovery:               DiscoveryDNS,
+		DiscoveryUpdateInterval: time.Minute,
+		Timeout:                 time.Second,
+	}
+	require.NoError(t, cfg.Validate())
+
+	client, err := NewRedisClient(&cfg, log.NewNopLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, client.Close()) })
+
+	ctx := context.Background()
+	var (
+		keys []string
+		bufs [][]byte
+	)
+	for i := 0; i < 20; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+		bufs = append(bufs, []byte(fmt.Sprintf("data%d", i)))
+	}
+	require.NoError(t, client.MSet(ctx, keys, bufs))
+
+	values, err := client.MGet(ctx, keys)
+	require.NoError(t, err)
+	require.Equal(t, bufs, values)
+
+	// The keys are sharded across the discovered servers.
+	require.NotEmpty(t, redisServer1.Keys())
+	require.NotEmpty(t, redisServer2.Keys())
+	require.Len(t, append(redisServer1.Keys(), redisServer2.Keys()...), len(keys))
+}
+
+func TestRedisConfig_Validate(t *testing.T) {
+	cfg := RedisConfig{Endpoint: "dns+redis:6379", Discovery: DiscoveryDNS}
+	require.NoError(t, cfg.Validate())
+
+	cfg.Discovery = DiscoveryAutoDiscovery
+	require.EqualError(t, cfg.Validate(), `unsupported cache servers discovery "auto-discovery", supported values are: dns, kubernetes`)
+
+	cfg.Discovery = DiscoveryKubernetes
+	cfg.MasterName = "master"
+	require.EqualError(t, cfg.Validate(), "the redis servers discovery doesn't support Redis Sentinel nor a database index")
+}
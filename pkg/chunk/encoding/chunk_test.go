@@ -29,7 +29,7 @@ import (
 
 func TestLen(t *testing.T) {
 	chunks := []Chunk{}
-	for _, encoding := range []Encoding{PrometheusXorChunk} {
+	for _, encoding := range []Encoding{PrometheusXorChunk, StorageEngineChunk} {
 		c, err := NewForEncoding(encoding)
 		if err != nil {
 			t.Fatal(err)
@@ -61,6 +61,7 @@ func TestChunk(t *testing.T) {
 		maxSamples int
 	}{
 		{PrometheusXorChunk, 2048},
+		{StorageEngineChunk, 2048},
 	} {
 		for samples := tc.maxSamples / 10; samples < tc.maxSamples; samples += tc.maxSamples / 10 {
 
@@ -24,6 +24,10 @@ const (
 	// PrometheusXorChunk is a wrapper around Prometheus XOR-encoded chunk.
 	// 4 is the magic value for backwards-compatibility with previous iota-based constants.
 	PrometheusXorChunk Encoding = 4
+
+	// StorageEngineChunk is a chunk encoded by one of the storage engines. Its data is
+	// prefixed by the chunk encoding of the engine.
+	StorageEngineChunk Encoding = 5
 )
 
 type encoding struct {
@@ -38,6 +42,12 @@ var encodings = map[Encoding]encoding{
 			return newPrometheusXorChunk()
 		},
 	},
+	StorageEngineChunk: {
+		Name: "StorageEngineChunk",
+		New: func() Chunk {
+			return newStorageEngineChunk()
+		},
+	},
 }
 
 // NewForEncoding allows configuring what chunk type you want
@@ -0,0 +1,54 @@
+package encoding
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/engine"
+)
+
+// Wrapper around a chunk encoded by one of the storage engines. The marshalled chunk is
+// the chunk encoding of the engine followed by the chunk data.
+type storageEngineChunk struct {
+	prometheusXorChunk
+}
+
+func newStorageEngineChunk() *storageEngineChunk {
+	return &storageEngineChunk{}
+}
+
+// MarshalStorageEngineChunk returns the data of the chunk encoded by a storage engine,
+// in the StorageEngineChunk encoding.
+func MarshalStorageEngineChunk(c chunkenc.Chunk) []byte {
+	data := make([]byte, 0, 1+len(c.Bytes()))
+	data = append(data, byte(c.Encoding()))
+	return append(data, c.Bytes()...)
+}
+
+func (p *storageEngineChunk) Marshal(i io.Writer) error {
+	if p.chunk == nil {
+		return errors.New("chunk data not set")
+	}
+	_, err := i.Write(MarshalStorageEngineChunk(p.chunk))
+	return err
+}
+
+func (p *storageEngineChunk) UnmarshalFromBuf(bytes []byte) error {
+	if len(bytes) < 1 {
+		return errors.New("storage engine chunk too short")
+	}
+
+	c, err := engine.FromData(chunkenc.Encoding(bytes[0]), bytes[1:])
+	if err != nil {
+		return errors.Wrap(err, "failed to create storage engine chunk from bytes")
+	}
+
+	p.chunk = c
+	return nil
+}
+
+func (p *storageEngineChunk) Encoding() Encoding {
+	return StorageEngineChunk
+}
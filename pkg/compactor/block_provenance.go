@@ -0,0 +1,59 @@
+package compactor
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/version"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/provenance"
+)
+
+// provenanceProducer is the producer recorded in the provenance of the compacted blocks.
+const provenanceProducer = "cortex-compactor"
+
+// provenanceCompactionLifecycleCallback records the provenance of each block produced by the
+// compaction, like the ingesters which shipped its sources and its ancestors, in the extensions
+// of its meta.json.
+type provenanceCompactionLifecycleCallback struct {
+	compact.CompactionLifecycleCallback
+
+	bucket objstore.Bucket
+}
+
+// PreCompactionCallback implements compact.CompactionLifecycleCallback.
+func (c *provenanceCompactionLifecycleCallback) PreCompactionCallback(ctx context.Context, logger log.Logger, group *compact.Group, toCompactBlocks []*metadata.Meta) error {
+	if err := c.CompactionLifecycleCallback.PreCompactionCallback(ctx, logger, group, toCompactBlocks); err != nil {
+		return err
+	}
+
+	// The ingester ID external label is removed from the metas fetched by the compactor, so the
+	// metas of the blocks shipped by the ingesters are read again.
+	parents := make([]*metadata.Meta, 0, len(toCompactBlocks))
+	for _, m := range toCompactBlocks {
+		if m.Thanos.Source == metadata.ReceiveSource {
+			if orig, err := block.DownloadMeta(ctx, logger, c.bucket, m.ULID); err == nil {
+				m = &orig
+			} else {
+				level.Warn(logger).Log("msg", "failed to read the meta of the block shipped by an ingester", "block", m.ULID, "err", err)
+			}
+		}
+		parents = append(parents, m)
+	}
+
+	// Failing to build the provenance doesn't fail the compaction.
+	p, err := provenance.ForCompaction(parents, provenanceProducer, version.Version)
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to build the provenance of the compacted block", "err", err)
+		group.SetExtensions(nil)
+		return nil
+	}
+
+	group.SetExtensions(&provenance.Extensions{Provenance: p})
+	return nil
+}
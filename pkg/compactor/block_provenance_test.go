@@ -0,0 +1,83 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/version"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/provenance"
+)
+
+func TestProvenanceCompactionLifecycleCallback(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	newCounter := func() prometheus.Counter { return prometheus.NewCounter(prometheus.CounterOpts{Name: "test"}) }
+	group, err := compact.NewGroup(log.NewNopLogger(), userBkt, "0@12345", labels.EmptyLabels(), 0, false, false,
+		newCounter(), newCounter(), newCounter(), newCounter(), newCounter(), newCounter(), newCounter(), newCounter(), metadata.NoneFunc, 1, 1)
+	require.NoError(t, err)
+
+	// The blocks are shipped by two ingesters, but the ingester ID external label is removed from
+	// the metas fetched by the compactor.
+	var toCompact []*metadata.Meta
+	for i, ingester := range []string{"ingester-1", "ingester-2"} {
+		id := ulid.MustNew(uint64(i+1), nil)
+		shipped := metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{ULID: id, MinTime: 10, MaxTime: 20, Version: metadata.TSDBVersion1},
+			Thanos: metadata.Thanos{
+				Version: metadata.ThanosVersion1,
+				Labels:  map[string]string{cortex_tsdb.TenantIDExternalLabel: userID, cortex_tsdb.IngesterIDExternalLabel: ingester},
+				Source:  metadata.ReceiveSource,
+			},
+		}
+		shipped.Compaction.Level = 1
+		shipped.Compaction.Sources = []ulid.ULID{id}
+
+		data, err := json.Marshal(shipped)
+		require.NoError(t, err)
+		require.NoError(t, userBkt.Upload(ctx, path.Join(id.String(), block.MetaFilename), bytes.NewReader(data)))
+
+		fetched := shipped
+		fetched.Thanos.Labels = map[string]string{cortex_tsdb.TenantIDExternalLabel: userID}
+		toCompact = append(toCompact, &fetched)
+	}
+
+	callback := &provenanceCompactionLifecycleCallback{
+		CompactionLifecycleCallback: compact.DefaultCompactionLifecycleCallback{},
+		bucket:                      userBkt,
+	}
+	require.NoError(t, callback.PreCompactionCallback(ctx, log.NewNopLogger(), group, toCompact))
+
+	ext, ok := group.Extensions().(*provenance.Extensions)
+	require.True(t, ok)
+	require.NotNil(t, ext.Provenance)
+	assert.Equal(t, provenanceProducer, ext.Provenance.Producer)
+	assert.Equal(t, version.Version, ext.Provenance.Version)
+	assert.Equal(t, []string{"ingester-1", "ingester-2"}, ext.Provenance.Ingesters)
+	require.Len(t, ext.Provenance.Ancestors, 2)
+	assert.Equal(t, toCompact[0].ULID, ext.Provenance.Ancestors[0].ID)
+	assert.Equal(t, []string{"ingester-1"}, ext.Provenance.Ancestors[0].Ingesters)
+
+	// The compaction of blocks with overlapping sources is still halted.
+	require.Error(t, callback.PreCompactionCallback(ctx, log.NewNopLogger(), group, []*metadata.Meta{toCompact[0], toCompact[0]}))
+}
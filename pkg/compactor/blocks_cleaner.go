@@ -35,6 +35,7 @@ type BlocksCleanerConfig struct {
 	CleanupConcurrency                 int
 	BlockDeletionMarksMigrationEnabled bool          // TODO Discuss whether we should remove it in Cortex 1.8.0 and document that upgrading to 1.7.0 before 1.8.0 is required.
 	TenantCleanupDelay                 time.Duration // Delay before removing tenant deletion mark and "debug".
+	DryRun                             bool          // Only perform the deletions of an approved cleanup plan.
 }
 
 type BlocksCleaner struct {
@@ -49,6 +50,9 @@ type BlocksCleaner struct {
 	// Keep track of the last owned users.
 	lastOwnedUsers []string
 
+	// The deletions planned and approved in dry-run mode, nil otherwise.
+	planner *cleanupPlanner
+
 	// Metrics.
 	runsStarted                       prometheus.Counter
 	runsCompleted                     prometheus.Counter
@@ -62,6 +66,7 @@ type BlocksCleaner struct {
 	tenantBlocksMarkedForNoCompaction *prometheus.GaugeVec
 	tenantPartialBlocks               *prometheus.GaugeVec
 	tenantBucketIndexLastUpdate       *prometheus.GaugeVec
+	plannedDeletions                  *prometheus.GaugeVec
 }
 
 func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.InstrumentedBucket, usersScanner *cortex_tsdb.UsersScanner, cfgProvider ConfigProvider, logger log.Logger, reg prometheus.Registerer) *BlocksCleaner {
@@ -124,6 +129,14 @@ func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Instrumente
 			Name: "cortex_bucket_index_last_successful_update_timestamp_seconds",
 			Help: "Timestamp of the last successful update of a tenant's bucket index.",
 		}, []string{"user"}),
+		plannedDeletions: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_compactor_blocks_cleanup_planned_deletions",
+			Help: "Number of deletions planned by the last blocks cleanup run in dry-run mode, waiting for approval.",
+		}, []string{"reason"}),
+	}
+
+	if cfg.DryRun {
+		c.planner = newCleanupPlanner()
 	}
 
 	c.Service = services.NewTimerService(cfg.CleanupInterval, c.starting, c.ticker, nil)
@@ -186,6 +199,15 @@ func (c *BlocksCleaner) cleanUsers(ctx context.Context, firstRun bool) error {
 	}
 	c.lastOwnedUsers = allUsers
 
+	if c.planner != nil {
+		c.planner.startRun()
+		defer func() {
+			if ctx.Err() == nil {
+				c.publishPlan()
+			}
+		}()
+	}
+
 	return concurrency.ForEachUser(ctx, allUsers, c.cfg.CleanupConcurrency, func(ctx context.Context, userID string) error {
 		if isDeleted[userID] {
 			return errors.Wrapf(c.deleteUserMarkedForDeletion(ctx, userID), "failed to delete user marked for deletion: %s", userID)
@@ -199,6 +221,13 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 	userLogger := util_log.WithUserID(userID, c.logger)
 	userBucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.cfgProvider)
 
+	if c.planner != nil {
+		// Nothing is deleted until the deletion of all the tenant blocks has been approved.
+		if approved, err := c.allowTenantDeletion(ctx, userID, userBucket, userLogger); err != nil || !approved {
+			return err
+		}
+	}
+
 	level.Info(userLogger).Log("msg", "deleting blocks for tenant marked for deletion")
 
 	// We immediately delete the bucket index, to signal to its consumers that
@@ -370,11 +399,11 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, firstRun b
 	// Note doing this before UpdateIndex, so it reads in the deletion marks.
 	// The trade-off being that retention is not applied if the index has to be
 	// built, but this is rare.
+	retention := c.cfgProvider.CompactorBlocksRetentionPeriod(userID)
 	if idx != nil {
 		// We do not want to stop the remaining work in the cleaner if an
 		// error occurs here. Errors are logged in the function.
-		retention := c.cfgProvider.CompactorBlocksRetentionPeriod(userID)
-		c.applyUserRetentionPeriod(ctx, idx, retention, userBucket, userLogger)
+		c.applyUserRetentionPeriod(ctx, userID, idx, retention, userBucket, userLogger)
 	}
 
 	// Generate an updated in-memory version of the bucket index.
@@ -393,6 +422,9 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, firstRun b
 		if time.Since(mark.GetDeletionTime()).Seconds() <= c.cfg.DeletionDelay.Seconds() {
 			continue
 		}
+		if !c.allowDeletion(userLogger, PlannedDeletion{Tenant: userID, Block: mark.ID.String(), Action: CleanupActionDelete, Reason: markedBlockDeletionReason(idx, mark.ID, retention)}) {
+			continue
+		}
 		blocksToDelete = append(blocksToDelete, mark.ID)
 	}
 
@@ -419,7 +451,7 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, firstRun b
 	// Partial blocks with a deletion mark can be cleaned up. This is a best effort, so we don't return
 	// error if the cleanup of partial blocks fail.
 	if len(partials) > 0 {
-		c.cleanUserPartialBlocks(ctx, partials, idx, userBucket, userLogger)
+		c.cleanUserPartialBlocks(ctx, userID, partials, idx, userBucket, userLogger)
 	}
 
 	// Upload the updated index to the storage.
@@ -437,7 +469,7 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, firstRun b
 
 // cleanUserPartialBlocks delete partial blocks which are safe to be deleted. The provided partials map
 // and index are updated accordingly.
-func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map[ulid.ULID]error, idx *bucketindex.Index, userBucket objstore.InstrumentedBucket, userLogger log.Logger) {
+func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, userID string, partials map[ulid.ULID]error, idx *bucketindex.Index, userBucket objstore.InstrumentedBucket, userLogger log.Logger) {
 	// Collect all blocks with missing meta.json into buffered channel.
 	blocks := make([]interface{}, 0, len(partials))
 
@@ -477,6 +509,10 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map
 			return nil
 		}
 
+		if !c.allowDeletion(userLogger, PlannedDeletion{Tenant: userID, Block: blockID.String(), Action: CleanupActionDelete, Reason: CleanupReasonPartial}) {
+			return nil
+		}
+
 		// Hard-delete partial blocks having a deletion mark, even if the deletion threshold has not
 		// been reached yet.
 		if err := block.Delete(ctx, userLogger, userBucket, blockID); err != nil {
@@ -498,7 +534,7 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map
 }
 
 // applyUserRetentionPeriod marks blocks for deletion which have aged past the retention period.
-func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucketindex.Index, retention time.Duration, userBucket objstore.Bucket, userLogger log.Logger) {
+func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, userID string, idx *bucketindex.Index, retention time.Duration, userBucket objstore.Bucket, userLogger log.Logger) {
 	// The retention period of zero is a special value indicating to never delete.
 	if retention <= 0 {
 		return
@@ -510,6 +546,9 @@ func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucke
 	// Attempt to mark all blocks. It is not critical if a marking fails, as
 	// the cleaner will retry applying the retention in its next cycle.
 	for _, b := range blocks {
+		if !c.allowDeletion(userLogger, PlannedDeletion{Tenant: userID, Block: b.ID.String(), Action: CleanupActionMarkForDeletion, Reason: CleanupReasonRetention}) {
+			continue
+		}
 		level.Info(userLogger).Log("msg", "applied retention: marking block for deletion", "block", b.ID, "maxTime", b.MaxTime)
 		if err := block.MarkForDeletion(ctx, userLogger, userBucket, b.ID, fmt.Sprintf("block exceeding retention of %v", retention), c.blocksMarkedForDeletion); err != nil {
 			level.Warn(userLogger).Log("msg", "failed to mark block for deletion", "block", b.ID, "err", err)
@@ -539,3 +578,85 @@ func listBlocksOutsideRetentionPeriod(idx *bucketindex.Index, threshold time.Tim
 
 	return
 }
+
+// markedBlockDeletionReason returns why the block has been marked for deletion: the blocks exceeding
+// the retention period are likely marked by the retention, while the other ones have been superseded.
+func markedBlockDeletionReason(idx *bucketindex.Index, id ulid.ULID, retention time.Duration) string {
+	if retention <= 0 {
+		return CleanupReasonSuperseded
+	}
+	threshold := time.Now().Add(-retention)
+	for _, b := range idx.Blocks {
+		if b.ID == id && time.Unix(b.MaxTime/1000, 0).Before(threshold) {
+			return CleanupReasonRetention
+		}
+	}
+	return CleanupReasonSuperseded
+}
+
+// allowDeletion returns whether the cleaner can perform the deletion. In dry-run mode, the deletions
+// not approved yet are skipped and added to the cleanup plan.
+func (c *BlocksCleaner) allowDeletion(userLogger log.Logger, d PlannedDeletion) bool {
+	if c.planner == nil {
+		return true
+	}
+	if c.planner.allow(d) {
+		level.Info(userLogger).Log("msg", "performing approved block deletion", "block", d.Block, "action", d.Action, "reason", d.Reason)
+		return true
+	}
+	level.Info(userLogger).Log("msg", "dry-run: planned block deletion", "block", d.Block, "action", d.Action, "reason", d.Reason)
+	return false
+}
+
+// allowTenantDeletion returns whether the deletion of all the blocks of the tenant marked for deletion
+// has been approved.
+func (c *BlocksCleaner) allowTenantDeletion(ctx context.Context, userID string, userBucket objstore.Bucket, userLogger log.Logger) (bool, error) {
+	approved := true
+	err := userBucket.Iter(ctx, "", func(name string) error {
+		id, ok := block.IsBlockDir(name)
+		if !ok {
+			return nil
+		}
+		if !c.allowDeletion(userLogger, PlannedDeletion{Tenant: userID, Block: id.String(), Action: CleanupActionDelete, Reason: CleanupReasonTenantDeleted}) {
+			approved = false
+		}
+		return nil
+	})
+	return approved, err
+}
+
+// publishPlan publishes the deletions planned by the completed cleanup run.
+func (c *BlocksCleaner) publishPlan() {
+	plan := c.planner.finishRun(time.Now())
+
+	counts := map[string]int{}
+	for _, d := range plan.Deletions {
+		counts[d.Reason]++
+	}
+	for _, reason := range []string{CleanupReasonRetention, CleanupReasonSuperseded, CleanupReasonPartial, CleanupReasonTenantDeleted} {
+		c.plannedDeletions.WithLabelValues(reason).Set(float64(counts[reason]))
+	}
+	level.Info(c.logger).Log("msg", "dry-run: updated the cleanup plan", "plan", plan.ID, "deletions", len(plan.Deletions))
+}
+
+// CleanupPlan returns the deletions planned by the last cleanup run in dry-run mode.
+func (c *BlocksCleaner) CleanupPlan() (*CleanupPlan, error) {
+	if c.planner == nil {
+		return nil, errCleanupDryRunDisabled
+	}
+	return c.planner.getPlan()
+}
+
+// ApproveCleanupPlan approves the deletions of the last cleanup plan, which are performed by the next
+// cleanup run. The ID must be the one of the reviewed plan, to not approve deletions planned since then.
+func (c *BlocksCleaner) ApproveCleanupPlan(id string) (*CleanupPlan, error) {
+	if c.planner == nil {
+		return nil, errCleanupDryRunDisabled
+	}
+	plan, err := c.planner.approve(id)
+	if err != nil {
+		return nil, err
+	}
+	level.Info(c.logger).Log("msg", "approved the cleanup plan", "plan", plan.ID, "deletions", len(plan.Deletions))
+	return plan, nil
+}
@@ -0,0 +1,152 @@
+package compactor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Actions of the deletions planned by the blocks cleaner.
+const (
+	CleanupActionMarkForDeletion = "mark-for-deletion"
+	CleanupActionDelete          = "delete"
+)
+
+// Reasons of the deletions planned by the blocks cleaner.
+const (
+	// CleanupReasonRetention is the reason of the deletion of the blocks exceeding the tenant retention period.
+	CleanupReasonRetention = "retention"
+	// CleanupReasonSuperseded is the reason of the deletion of the blocks marked for deletion once compacted
+	// or downsampled into other blocks, or marked for deletion by an operator.
+	CleanupReasonSuperseded = "superseded"
+	// CleanupReasonPartial is the reason of the deletion of the partially uploaded blocks.
+	CleanupReasonPartial = "partial"
+	// CleanupReasonTenantDeleted is the reason of the deletion of the blocks of a tenant marked for deletion.
+	CleanupReasonTenantDeleted = "tenant-deleted"
+)
+
+var (
+	errCleanupDryRunDisabled = errors.New("the blocks cleanup dry-run mode is disabled")
+	errCleanupPlanNotFound   = errors.New("the blocks cleanup has not completed a run yet")
+	errCleanupPlanOutdated   = errors.New("the cleanup plan has changed since it has been reviewed")
+)
+
+// PlannedDeletion is a deletion the blocks cleaner skipped in dry-run mode, since it has not been approved.
+type PlannedDeletion struct {
+	Tenant string `json:"tenant"`
+	Block  string `json:"block"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+// CleanupPlan is the set of deletions planned by the last run of the blocks cleaner in dry-run mode.
+// Its ID only depends on the planned deletions, so that consecutive runs planning the same deletions
+// have the same ID.
+type CleanupPlan struct {
+	ID        string            `json:"id"`
+	CreatedAt time.Time         `json:"created_at"`
+	Deletions []PlannedDeletion `json:"deletions"`
+}
+
+// cleanupPlanner keeps track of the deletions planned and approved in dry-run mode. The deletions
+// of an approved plan are performed by the next cleanup run, as long as the cleaner still plans them.
+type cleanupPlanner struct {
+	mtx      sync.Mutex
+	plan     *CleanupPlan
+	pending  []PlannedDeletion
+	approved map[PlannedDeletion]struct{}
+
+	// The approved deletions the running cleanup can perform.
+	executable map[PlannedDeletion]struct{}
+}
+
+func newCleanupPlanner() *cleanupPlanner {
+	return &cleanupPlanner{approved: map[PlannedDeletion]struct{}{}}
+}
+
+// startRun is called at the beginning of a cleanup run: the deletions approved so far are performed
+// by this run, while the ones approved during it are performed by the next one.
+func (p *cleanupPlanner) startRun() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.pending = nil
+	p.executable = p.approved
+	p.approved = map[PlannedDeletion]struct{}{}
+}
+
+// allow returns whether the deletion has been approved, or adds it to the plan of the running cleanup.
+func (p *cleanupPlanner) allow(d PlannedDeletion) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if _, ok := p.executable[d]; ok {
+		return true
+	}
+	p.pending = append(p.pending, d)
+	return false
+}
+
+// finishRun publishes the plan of the completed cleanup run.
+func (p *cleanupPlanner) finishRun(now time.Time) *CleanupPlan {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	deletions := p.pending
+	sort.Slice(deletions, func(i, j int) bool {
+		a, b := deletions[i], deletions[j]
+		if a.Tenant != b.Tenant {
+			return a.Tenant < b.Tenant
+		}
+		if a.Block != b.Block {
+			return a.Block < b.Block
+		}
+		return a.Action < b.Action
+	})
+
+	p.plan = &CleanupPlan{ID: cleanupPlanID(deletions), CreatedAt: now, Deletions: deletions}
+	p.pending = nil
+	p.executable = nil
+	return p.plan
+}
+
+func (p *cleanupPlanner) getPlan() (*CleanupPlan, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.plan == nil {
+		return nil, errCleanupPlanNotFound
+	}
+	return p.plan, nil
+}
+
+// approve approves the deletions of the plan with the given ID, which must be the last one.
+func (p *cleanupPlanner) approve(id string) (*CleanupPlan, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.plan == nil {
+		return nil, errCleanupPlanNotFound
+	}
+	if p.plan.ID != id {
+		return nil, errCleanupPlanOutdated
+	}
+
+	p.approved = make(map[PlannedDeletion]struct{}, len(p.plan.Deletions))
+	for _, d := range p.plan.Deletions {
+		p.approved[d] = struct{}{}
+	}
+	return p.plan, nil
+}
+
+func cleanupPlanID(deletions []PlannedDeletion) string {
+	// The planned deletions can always be encoded.
+	data, _ := json.Marshal(deletions)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -754,3 +754,100 @@ func (m *mockConfigProvider) S3SSEKMSKeyID(userID string) string {
 func (m *mockConfigProvider) S3SSEKMSEncryptionContext(userID string) string {
 	return ""
 }
+
+func (m *mockConfigProvider) ObjectStorageTags(userID string) map[string]string {
+	return nil
+}
+
+func TestBlocksCleaner_DryRunShouldOnlyPerformApprovedDeletions(t *testing.T) {
+	bucketClient, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
+
+	ts := func(hours int) int64 {
+		return time.Now().Add(time.Duration(hours)*time.Hour).Unix() * 1000
+	}
+
+	block1 := createTSDBBlock(t, bucketClient, "user-1", ts(-10), ts(-8), nil)
+	block2 := createTSDBBlock(t, bucketClient, "user-1", ts(-4), ts(-2), nil)
+	block3 := createTSDBBlock(t, bucketClient, "user-1", ts(-4), ts(-2), nil)
+	createDeletionMark(t, bucketClient, "user-1", block3, time.Now().Add(-2*time.Hour))
+	require.NoError(t, tsdb.WriteTenantDeletionMark(context.Background(), bucketClient, "user-2", tsdb.NewTenantDeletionMark(time.Now())))
+	block4 := createTSDBBlock(t, bucketClient, "user-2", ts(-4), ts(-2), nil)
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:      time.Hour,
+		CleanupInterval:    time.Minute,
+		CleanupConcurrency: 1,
+		DryRun:             true,
+	}
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	reg := prometheus.NewPedanticRegistry()
+	scanner := tsdb.NewUsersScanner(bucketClient, tsdb.AllUsers, logger)
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.userRetentionPeriods["user-1"] = 7 * time.Hour
+
+	cleaner := NewBlocksCleaner(cfg, bucketClient, scanner, cfgProvider, logger, reg)
+
+	assertExists := func(user string, block ulid.ULID, file string, expectExists bool) {
+		exists, err := bucketClient.Exists(ctx, path.Join(user, block.String(), file))
+		require.NoError(t, err)
+		assert.Equal(t, expectExists, exists, "%s/%s/%s", user, block, file)
+	}
+
+	_, err := cleaner.CleanupPlan()
+	require.ErrorIs(t, err, errCleanupPlanNotFound)
+
+	// The runs only plan the deletions. The retention is applied once the bucket index has been built
+	// by the first one.
+	require.NoError(t, cleaner.cleanUsers(ctx, true))
+	require.NoError(t, cleaner.cleanUsers(ctx, false))
+	assertExists("user-1", block1, metadata.MetaFilename, true)
+	assertExists("user-1", block1, metadata.DeletionMarkFilename, false)
+	assertExists("user-1", block2, metadata.MetaFilename, true)
+	assertExists("user-1", block3, metadata.MetaFilename, true)
+	assertExists("user-2", block4, metadata.MetaFilename, true)
+
+	plan, err := cleaner.CleanupPlan()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []PlannedDeletion{
+		{Tenant: "user-1", Block: block1.String(), Action: CleanupActionMarkForDeletion, Reason: CleanupReasonRetention},
+		{Tenant: "user-1", Block: block3.String(), Action: CleanupActionDelete, Reason: CleanupReasonSuperseded},
+		{Tenant: "user-2", Block: block4.String(), Action: CleanupActionDelete, Reason: CleanupReasonTenantDeleted},
+	}, plan.Deletions)
+
+	assert.NoError(t, prom_testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_blocks_cleanup_planned_deletions Number of deletions planned by the last blocks cleanup run in dry-run mode, waiting for approval.
+		# TYPE cortex_compactor_blocks_cleanup_planned_deletions gauge
+		cortex_compactor_blocks_cleanup_planned_deletions{reason="partial"} 0
+		cortex_compactor_blocks_cleanup_planned_deletions{reason="retention"} 1
+		cortex_compactor_blocks_cleanup_planned_deletions{reason="superseded"} 1
+		cortex_compactor_blocks_cleanup_planned_deletions{reason="tenant-deleted"} 1
+		`),
+		"cortex_compactor_blocks_cleanup_planned_deletions",
+	))
+
+	// A run planning the same deletions keeps the plan ID.
+	require.NoError(t, cleaner.cleanUsers(ctx, false))
+	replanned, err := cleaner.CleanupPlan()
+	require.NoError(t, err)
+	assert.Equal(t, plan.ID, replanned.ID)
+
+	_, err = cleaner.ApproveCleanupPlan("unknown")
+	require.ErrorIs(t, err, errCleanupPlanOutdated)
+	_, err = cleaner.ApproveCleanupPlan(plan.ID)
+	require.NoError(t, err)
+
+	// The next run performs the approved deletions.
+	require.NoError(t, cleaner.cleanUsers(ctx, false))
+	assertExists("user-1", block1, metadata.MetaFilename, true)
+	assertExists("user-1", block1, metadata.DeletionMarkFilename, true)
+	assertExists("user-1", block2, metadata.MetaFilename, true)
+	assertExists("user-1", block3, metadata.MetaFilename, false)
+	assertExists("user-2", block4, metadata.MetaFilename, false)
+
+	plan, err = cleaner.CleanupPlan()
+	require.NoError(t, err)
+	assert.Empty(t, plan.Deletions)
+}
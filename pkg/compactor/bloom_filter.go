@@ -0,0 +1,66 @@
+package compactor
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bloom"
+)
+
+// bloomFilterCompactionLifecycleCallback uploads the label pairs bloom filter of each block
+// produced by the compaction, which the store-gateways use to skip the blocks that can't
+// match a query.
+type bloomFilterCompactionLifecycleCallback struct {
+	compact.DefaultCompactionLifecycleCallback
+
+	bucket     objstore.Bucket
+	compactDir string
+
+	created prometheus.Counter
+	failed  prometheus.Counter
+}
+
+// PostCompactionCallback implements compact.CompactionLifecycleCallback. It's called once the
+// compacted block has been uploaded, while it's still in the group compaction dir.
+func (c *bloomFilterCompactionLifecycleCallback) PostCompactionCallback(ctx context.Context, logger log.Logger, group *compact.Group, blockID ulid.ULID) error {
+	// No block is produced when the compacted blocks have no samples.
+	if blockID == (ulid.ULID{}) {
+		return nil
+	}
+
+	// Failing to build the filter doesn't fail the compaction, since the block would then
+	// just be queried as usual.
+	bdir := filepath.Join(c.compactDir, group.Key(), blockID.String())
+	if err := c.uploadBloomFilter(ctx, bdir, blockID); err != nil {
+		c.failed.Inc()
+		level.Warn(logger).Log("msg", "failed to upload block bloom filter", "block", blockID, "err", err)
+		return nil
+	}
+
+	c.created.Inc()
+	return nil
+}
+
+func (c *bloomFilterCompactionLifecycleCallback) uploadBloomFilter(ctx context.Context, bdir string, blockID ulid.ULID) error {
+	ir, err := index.NewFileReader(filepath.Join(bdir, block.IndexFilename))
+	if err != nil {
+		return err
+	}
+	defer ir.Close() //nolint:errcheck
+
+	f, err := bloom.BuildBlockFilter(ctx, ir, bloom.DefaultFalsePositiveRate)
+	if err != nil {
+		return err
+	}
+
+	return bloom.UploadBlockFilter(ctx, c.bucket, blockID, f)
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/storage/bucket"
 	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/engine"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/backoff"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
@@ -96,7 +97,7 @@ var (
 	}
 
 	DefaultBlocksCompactorFactory = func(ctx context.Context, cfg Config, logger log.Logger, reg prometheus.Registerer) (compact.Compactor, PlannerFactory, error) {
-		compactor, err := tsdb.NewLeveledCompactor(ctx, reg, logger, cfg.BlockRanges.ToMilliseconds(), downsample.NewPool(), nil)
+		compactor, err := tsdb.NewLeveledCompactor(ctx, reg, logger, cfg.BlockRanges.ToMilliseconds(), engine.NewPool(downsample.NewPool()), nil)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -109,7 +110,7 @@ var (
 	}
 
 	ShuffleShardingBlocksCompactorFactory = func(ctx context.Context, cfg Config, logger log.Logger, reg prometheus.Registerer) (compact.Compactor, PlannerFactory, error) {
-		compactor, err := tsdb.NewLeveledCompactor(ctx, reg, logger, cfg.BlockRanges.ToMilliseconds(), downsample.NewPool(), nil)
+		compactor, err := tsdb.NewLeveledCompactor(ctx, reg, logger, cfg.BlockRanges.ToMilliseconds(), engine.NewPool(downsample.NewPool()), nil)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -180,6 +181,7 @@ type Config struct {
 	CleanupConcurrency                    int                      `yaml:"cleanup_concurrency"`
 	DeletionDelay                         time.Duration            `yaml:"deletion_delay"`
 	TenantCleanupDelay                    time.Duration            `yaml:"tenant_cleanup_delay"`
+	CleanupDryRun                         bool                     `yaml:"cleanup_dry_run"`
 	SkipBlocksWithOutOfOrderChunksEnabled bool                     `yaml:"skip_blocks_with_out_of_order_chunks_enabled"`
 	BlockFilesConcurrency                 int                      `yaml:"block_files_concurrency"`
 	BlocksFetchConcurrency                int                      `yaml:"blocks_fetch_concurrency"`
@@ -211,6 +213,11 @@ type Config struct {
 
 	AcceptMalformedIndex bool `yaml:"accept_malformed_index"`
 	CachingBucketEnabled bool `yaml:"caching_bucket_enabled"`
+
+	BloomFiltersEnabled  bool                   `yaml:"bloom_filters_enabled"`
+	SecondaryIndexLabels flagext.StringSliceCSV `yaml:"secondary_index_labels"`
+
+	BlockProvenanceEnabled bool `yaml:"block_provenance_enabled"`
 }
 
 // RegisterFlags registers the Compactor flags.
@@ -237,6 +244,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 		"If not 0, blocks will be marked for deletion and compactor component will permanently delete blocks marked for deletion from the bucket. "+
 		"If 0, blocks will be deleted straight away. Note that deleting blocks immediately can cause query failures.")
 	f.DurationVar(&cfg.TenantCleanupDelay, "compactor.tenant-cleanup-delay", 6*time.Hour, "For tenants marked for deletion, this is time between deleting of last block, and doing final cleanup (marker files, debug files) of the tenant.")
+	f.BoolVar(&cfg.CleanupDryRun, "compactor.cleanup-dry-run", false, "When enabled, the blocks cleanup doesn't mark the blocks exceeding the retention period for deletion, nor deletes blocks, but logs the deletions it would perform and adds them to a cleanup plan, exposed by the /compactor/cleanup/plan endpoint. The deletions of a plan approved through the /compactor/cleanup/plan/approve endpoint are performed by the next cleanup run.")
 	f.BoolVar(&cfg.BlockDeletionMarksMigrationEnabled, "compactor.block-deletion-marks-migration-enabled", false, "When enabled, at compactor startup the bucket will be scanned and all found deletion marks inside the block location will be copied to the markers global location too. This option can (and should) be safely disabled as soon as the compactor has successfully run at least once.")
 	f.BoolVar(&cfg.SkipBlocksWithOutOfOrderChunksEnabled, "compactor.skip-blocks-with-out-of-order-chunks-enabled", false, "When enabled, mark blocks containing index with out-of-order chunks for no compact instead of halting the compaction.")
 	f.IntVar(&cfg.BlockFilesConcurrency, "compactor.block-files-concurrency", 10, "Number of goroutines to use when fetching/uploading block files from object storage.")
@@ -250,6 +258,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 
 	f.BoolVar(&cfg.AcceptMalformedIndex, "compactor.accept-malformed-index", false, "When enabled, index verification will ignore out of order label names.")
 	f.BoolVar(&cfg.CachingBucketEnabled, "compactor.caching-bucket-enabled", false, "When enabled, caching bucket will be used for compactor, except cleaner service, which serves as the source of truth for block status")
+	f.BoolVar(&cfg.BloomFiltersEnabled, "compactor.bloom-filters-enabled", false, "When enabled, a bloom filter of the label pairs of each compacted block is stored alongside its meta.json, to let the store-gateways skip the blocks that can't match a query when -blocks-storage.bucket-store.bloom-filters-enabled is enabled.")
+	f.Var(&cfg.SecondaryIndexLabels, "compactor.secondary-index-labels", "Comma separated list of high-selectivity label names, like lookup IDs, whose values in each compacted block are stored in a secondary index alongside its meta.json, to let the store-gateways skip the blocks that can't match the lookups on these labels when -blocks-storage.bucket-store.secondary-index-enabled is enabled. If empty, no secondary index is created.")
+	f.BoolVar(&cfg.BlockProvenanceEnabled, "compactor.block-provenance-enabled", false, "When enabled, the provenance of each compacted block, like the ingesters which shipped its source blocks, its ancestors and the version of the compactor, is recorded in the extensions of its meta.json, and returned along with its lineage by the /compactor/block_lineage endpoint.")
 }
 
 func (cfg *Config) Validate(limits validation.Limits) error {
@@ -341,6 +352,10 @@ type Compactor struct {
 	remainingPlannedCompactions    prometheus.Gauge
 	blockVisitMarkerReadFailed     prometheus.Counter
 	blockVisitMarkerWriteFailed    prometheus.Counter
+	bloomFiltersCreated            prometheus.Counter
+	bloomFiltersFailed             prometheus.Counter
+	secondaryIndexesCreated        prometheus.Counter
+	secondaryIndexesFailed         prometheus.Counter
 
 	// TSDB syncer metrics
 	syncerMetrics *syncerMetrics
@@ -472,6 +487,22 @@ func newCompactor(
 			Name: "cortex_compactor_block_visit_marker_write_failed",
 			Help: "Number of block visit marker file failed to be written.",
 		}),
+		bloomFiltersCreated: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_bloom_filters_created_total",
+			Help: "Total number of bloom filters created for the compacted blocks.",
+		}),
+		bloomFiltersFailed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_bloom_filters_failed_total",
+			Help: "Total number of bloom filters that failed to be created for the compacted blocks.",
+		}),
+		secondaryIndexesCreated: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_secondary_indexes_created_total",
+			Help: "Total number of secondary indexes created for the compacted blocks.",
+		}),
+		secondaryIndexesFailed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_secondary_indexes_failed_total",
+			Help: "Total number of secondary indexes that failed to be created for the compacted blocks.",
+		}),
 		remainingPlannedCompactions: remainingPlannedCompactions,
 		limits:                      limits,
 	}
@@ -526,6 +557,7 @@ func (c *Compactor) starting(ctx context.Context) error {
 		CleanupConcurrency:                 c.compactorCfg.CleanupConcurrency,
 		BlockDeletionMarksMigrationEnabled: c.compactorCfg.BlockDeletionMarksMigrationEnabled,
 		TenantCleanupDelay:                 c.compactorCfg.TenantCleanupDelay,
+		DryRun:                             c.compactorCfg.CleanupDryRun,
 	}, c.bucketClient, c.usersScanner, c.limits, c.parentLogger, c.registerer)
 
 	// Initialize the compactors ring if sharding is enabled.
@@ -867,14 +899,43 @@ func (c *Compactor) compactUser(ctx context.Context, userID string) error {
 		return errors.Wrap(err, "failed to create syncer")
 	}
 
+	var compactionLifecycleCallback compact.CompactionLifecycleCallback = compact.DefaultCompactionLifecycleCallback{}
+	if c.compactorCfg.BloomFiltersEnabled {
+		compactionLifecycleCallback = &bloomFilterCompactionLifecycleCallback{
+			bucket:     bucket,
+			compactDir: c.compactDirForUser(userID),
+			created:    c.bloomFiltersCreated,
+			failed:     c.bloomFiltersFailed,
+		}
+	}
+	if len(c.compactorCfg.SecondaryIndexLabels) > 0 {
+		compactionLifecycleCallback = &secondaryIndexCompactionLifecycleCallback{
+			CompactionLifecycleCallback: compactionLifecycleCallback,
+			bucket:                      bucket,
+			compactDir:                  c.compactDirForUser(userID),
+			labelNames:                  c.compactorCfg.SecondaryIndexLabels,
+			created:                     c.secondaryIndexesCreated,
+			failed:                      c.secondaryIndexesFailed,
+		}
+	}
+
+	if c.compactorCfg.BlockProvenanceEnabled {
+		compactionLifecycleCallback = &provenanceCompactionLifecycleCallback{
+			CompactionLifecycleCallback: compactionLifecycleCallback,
+			bucket:                      bucket,
+		}
+	}
+
 	currentCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	compactor, err := compact.NewBucketCompactor(
+	compactor, err := compact.NewBucketCompactorWithCheckerAndCallback(
 		ulogger,
 		syncer,
 		c.blocksGrouperFactory(currentCtx, c.compactorCfg, bucket, ulogger, reg, c.blocksMarkedForDeletion, c.blocksMarkedForNoCompaction, c.garbageCollectedBlocks, c.remainingPlannedCompactions, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, c.ring, c.ringLifecycler, c.limits, userID, noCompactMarkerFilter),
 		c.blocksPlannerFactory(currentCtx, bucket, ulogger, c.compactorCfg, noCompactMarkerFilter, c.ringLifecycler, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed),
 		c.blocksCompactor,
+		compact.DefaultBlockDeletableChecker{},
+		compactionLifecycleCallback,
 		c.compactDirForUser(userID),
 		bucket,
 		c.compactorCfg.CompactionConcurrency,
@@ -5,7 +5,13 @@ import (
 	"net/http"
 
 	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
 
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/provenance"
+	"github.com/cortexproject/cortex/pkg/util"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/services"
 )
@@ -51,3 +57,91 @@ func (c *Compactor) RingHandler(w http.ResponseWriter, req *http.Request) {
 
 	c.ring.ServeHTTP(w, req)
 }
+
+// CleanupPlanHandler returns the deletions planned by the blocks cleanup running in dry-run mode.
+func (c *Compactor) CleanupPlanHandler(w http.ResponseWriter, req *http.Request) {
+	if c.State() != services.Running {
+		http.Error(w, "Compactor is not running yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	plan, err := c.blocksCleaner.CleanupPlan()
+	if err != nil {
+		writeCleanupPlanError(w, err)
+		return
+	}
+	util.WriteJSONResponse(w, plan)
+}
+
+// ApproveCleanupPlanHandler approves the deletions of the cleanup plan whose ID is given by the "id" parameter.
+func (c *Compactor) ApproveCleanupPlanHandler(w http.ResponseWriter, req *http.Request) {
+	if c.State() != services.Running {
+		http.Error(w, "Compactor is not running yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := req.FormValue("id")
+	if id == "" {
+		http.Error(w, "missing cleanup plan id", http.StatusBadRequest)
+		return
+	}
+
+	plan, err := c.blocksCleaner.ApproveCleanupPlan(id)
+	if err != nil {
+		writeCleanupPlanError(w, err)
+		return
+	}
+	util.WriteJSONResponse(w, plan)
+}
+
+func writeCleanupPlanError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errCleanupDryRunDisabled):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errCleanupPlanNotFound):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, errCleanupPlanOutdated):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// BlockLineageHandler returns the lineage of the block given by the "block" parameter, of the
+// tenant given by the "tenant" parameter.
+func (c *Compactor) BlockLineageHandler(w http.ResponseWriter, req *http.Request) {
+	if c.State() != services.Running {
+		http.Error(w, "Compactor is not running yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	userID := req.FormValue("tenant")
+	if userID == "" {
+		http.Error(w, "missing tenant", http.StatusBadRequest)
+		return
+	}
+	blockID, err := ulid.Parse(req.FormValue("block"))
+	if err != nil {
+		http.Error(w, "invalid block ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The index is only needed to look for the downsampling sources of the block.
+	idx, err := bucketindex.ReadIndex(req.Context(), c.bucketClient, userID, c.limits, c.logger)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "failed to read the bucket index, the downsampling sources of the block are not looked for", "user", userID, "err", err)
+		idx = nil
+	}
+
+	userBucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.limits)
+	lineage, err := provenance.GetLineage(req.Context(), userBucket, idx, blockID, c.logger)
+	if errors.Is(err, provenance.ErrBlockNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	util.WriteJSONResponse(w, lineage)
+}
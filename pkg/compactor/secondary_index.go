@@ -0,0 +1,70 @@
+package compactor
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/secondaryindex"
+)
+
+// secondaryIndexCompactionLifecycleCallback uploads the secondary index of the configured
+// labels of each block produced by the compaction, which the store-gateways use to skip the
+// blocks that can't match the lookups on these labels.
+type secondaryIndexCompactionLifecycleCallback struct {
+	compact.CompactionLifecycleCallback
+
+	bucket     objstore.Bucket
+	compactDir string
+	labelNames []string
+
+	created prometheus.Counter
+	failed  prometheus.Counter
+}
+
+// PostCompactionCallback implements compact.CompactionLifecycleCallback.
+func (c *secondaryIndexCompactionLifecycleCallback) PostCompactionCallback(ctx context.Context, logger log.Logger, group *compact.Group, blockID ulid.ULID) error {
+	if err := c.CompactionLifecycleCallback.PostCompactionCallback(ctx, logger, group, blockID); err != nil {
+		return err
+	}
+
+	// No block is produced when the compacted blocks have no samples.
+	if blockID == (ulid.ULID{}) {
+		return nil
+	}
+
+	// Failing to build the index doesn't fail the compaction, since the block would then
+	// just be queried as usual.
+	bdir := filepath.Join(c.compactDir, group.Key(), blockID.String())
+	if err := c.uploadSecondaryIndex(ctx, bdir, blockID); err != nil {
+		c.failed.Inc()
+		level.Warn(logger).Log("msg", "failed to upload block secondary index", "block", blockID, "err", err)
+		return nil
+	}
+
+	c.created.Inc()
+	return nil
+}
+
+func (c *secondaryIndexCompactionLifecycleCallback) uploadSecondaryIndex(ctx context.Context, bdir string, blockID ulid.ULID) error {
+	ir, err := index.NewFileReader(filepath.Join(bdir, block.IndexFilename))
+	if err != nil {
+		return err
+	}
+	defer ir.Close() //nolint:errcheck
+
+	idx, err := secondaryindex.BuildIndex(ctx, ir, c.labelNames)
+	if err != nil {
+		return err
+	}
+
+	return secondaryindex.UploadIndex(ctx, c.bucket, blockID, idx)
+}
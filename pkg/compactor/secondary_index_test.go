@@ -0,0 +1,73 @@
+package compactor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bloom"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/secondaryindex"
+)
+
+func TestSecondaryIndexCompactionLifecycleCallback(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	// The compacted block is in the group compaction dir.
+	blockID := createTSDBBlock(t, bkt, userID, 10, 20, nil)
+	newCounter := func() prometheus.Counter { return prometheus.NewCounter(prometheus.CounterOpts{Name: "test"}) }
+	group, err := compact.NewGroup(log.NewNopLogger(), userBkt, "0@12345", labels.EmptyLabels(), 0, false, false,
+		newCounter(), newCounter(), newCounter(), newCounter(), newCounter(), newCounter(), newCounter(), newCounter(), metadata.NoneFunc, 1, 1)
+	require.NoError(t, err)
+
+	compactDir := t.TempDir()
+	require.NoError(t, block.Download(ctx, log.NewNopLogger(), userBkt, blockID, filepath.Join(compactDir, group.Key(), blockID.String())))
+
+	// The callback is chained to the bloom filter one.
+	bloomFilterCallback := &bloomFilterCompactionLifecycleCallback{
+		bucket:     userBkt,
+		compactDir: compactDir,
+		created:    newCounter(),
+		failed:     newCounter(),
+	}
+	callback := &secondaryIndexCompactionLifecycleCallback{
+		CompactionLifecycleCallback: bloomFilterCallback,
+		bucket:                      userBkt,
+		compactDir:                  compactDir,
+		labelNames:                  []string{"series_id", "instance"},
+		created:                     newCounter(),
+		failed:                      newCounter(),
+	}
+	require.NoError(t, callback.PostCompactionCallback(ctx, log.NewNopLogger(), group, blockID))
+	assert.Equal(t, 1.0, testutil.ToFloat64(callback.created))
+	assert.Equal(t, 1.0, testutil.ToFloat64(bloomFilterCallback.created))
+
+	idx, err := secondaryindex.ReadIndex(ctx, userBkt, blockID)
+	require.NoError(t, err)
+	require.NotNil(t, idx)
+	assert.Equal(t, map[string][]string{"series_id": {"0", "1"}, "instance": {}}, idx.Labels)
+
+	f, err := bloom.ReadBlockFilter(ctx, userBkt, blockID)
+	require.NoError(t, err)
+	assert.NotNil(t, f)
+
+	// Failing to create the index doesn't fail the compaction.
+	require.NoError(t, callback.PostCompactionCallback(ctx, log.NewNopLogger(), group, ulid.MustNew(1, nil)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(callback.failed))
+}
@@ -0,0 +1,202 @@
+package cortex
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	thanos_downsample "github.com/thanos-io/thanos/pkg/compact/downsample"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const (
+	// FindingError is the severity of the findings making Cortex fail or misbehave.
+	FindingError = "error"
+	// FindingWarning is the severity of the findings making Cortex run in a likely unintended way.
+	FindingWarning = "warning"
+)
+
+// ConfigFinding is an issue found in the config by CheckConfig.
+type ConfigFinding struct {
+	Severity string   `json:"severity"`
+	Tenant   string   `json:"tenant,omitempty"`
+	Fields   []string `json:"fields,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// CheckConfig validates the config and the per-tenant overrides of the runtime config, and checks
+// the consistency of the fields depending on each other, which Cortex doesn't check on startup
+// since they are owned by different components. Like when Cortex runs, the per-tenant overrides are
+// defaulted to the limits set with validation.SetDefaultLimitsForYAMLUnmarshalling.
+func CheckConfig(cfg *Config, logger log.Logger) []ConfigFinding {
+	var findings []ConfigFinding
+
+	if err := cfg.Validate(logger); err != nil {
+		findings = append(findings, ConfigFinding{Severity: FindingError, Message: err.Error()})
+	}
+
+	tenantLimits, finding := loadTenantLimits(cfg)
+	if finding != nil {
+		findings = append(findings, *finding)
+	}
+
+	findings = append(findings, checkLimits(cfg, "", &cfg.LimitsConfig)...)
+	for userID, limits := range tenantLimits {
+		if limits == nil {
+			continue
+		}
+		if err := limits.Validate(cfg.Distributor.ShardByAllLabels); err != nil {
+			findings = append(findings, ConfigFinding{Severity: FindingError, Tenant: userID, Message: err.Error()})
+		}
+		findings = append(findings, checkLimits(cfg, userID, limits)...)
+	}
+
+	findings = append(findings, checkQueryStoreAfter(cfg)...)
+	findings = append(findings, checkDownsampling(cfg)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity == FindingError
+		}
+		return findings[i].Tenant < findings[j].Tenant
+	})
+	return findings
+}
+
+// loadTenantLimits loads the per-tenant overrides from the runtime config file, if any.
+func loadTenantLimits(cfg *Config) (map[string]*validation.Limits, *ConfigFinding) {
+	path := cfg.RuntimeConfig.LoadPath
+	if path == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return nil, &ConfigFinding{
+			Severity: FindingWarning,
+			Fields:   []string{"runtime_config.file"},
+			Message:  "the runtime config fetched from an URL is not checked",
+		}
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ConfigFinding{Severity: FindingError, Fields: []string{"runtime_config.file"}, Message: err.Error()}
+	}
+
+	values, err := loadRuntimeConfig(bytes.NewReader(buf))
+	if err != nil {
+		return nil, &ConfigFinding{
+			Severity: FindingError,
+			Fields:   []string{"runtime_config.file"},
+			Message:  fmt.Sprintf("invalid runtime config: %v", err),
+		}
+	}
+	return values.(*RuntimeConfigValues).TenantLimits, nil
+}
+
+// checkLimits checks the default limits, when the tenant is empty, or the overrides of a tenant.
+func checkLimits(cfg *Config, userID string, limits *validation.Limits) []ConfigFinding {
+	var findings []ConfigFinding
+
+	ingesterRing := cfg.Ingester.LifecyclerConfig.RingConfig
+	if cfg.Distributor.ShardingStrategy == util.ShardingStrategyShuffle && !ingesterRing.ZoneAwarenessEnabled &&
+		limits.IngestionTenantShardSize > 0 && limits.IngestionTenantShardSize < ingesterRing.ReplicationFactor {
+		findings = append(findings, ConfigFinding{
+			Severity: FindingWarning,
+			Tenant:   userID,
+			Fields:   []string{"limits.ingestion_tenant_shard_size", "ingester.lifecycler.ring.replication_factor"},
+			Message: fmt.Sprintf("the ingestion shard size (%d) is lower than the replication factor (%d): the series are written to %d ingesters",
+				limits.IngestionTenantShardSize, ingesterRing.ReplicationFactor, ingesterRing.ReplicationFactor),
+		})
+	}
+
+	storeGatewayRing := cfg.StoreGateway.ShardingRing
+	if cfg.StoreGateway.ShardingEnabled && cfg.StoreGateway.ShardingStrategy == util.ShardingStrategyShuffle && !storeGatewayRing.ZoneAwarenessEnabled &&
+		limits.StoreGatewayTenantShardSize >= 1 && int(limits.StoreGatewayTenantShardSize) < storeGatewayRing.ReplicationFactor {
+		findings = append(findings, ConfigFinding{
+			Severity: FindingWarning,
+			Tenant:   userID,
+			Fields:   []string{"limits.store_gateway_tenant_shard_size", "store_gateway.sharding_ring.replication_factor"},
+			Message: fmt.Sprintf("the store-gateway shard size (%d) is lower than the replication factor (%d): the blocks are replicated on %d store-gateways",
+				int(limits.StoreGatewayTenantShardSize), storeGatewayRing.ReplicationFactor, storeGatewayRing.ReplicationFactor),
+		})
+	}
+
+	queryStoreAfter := cfg.Querier.QueryStoreAfter
+	if retention := time.Duration(limits.CompactorBlocksRetentionPeriod); retention > 0 && retention < queryStoreAfter {
+		findings = append(findings, ConfigFinding{
+			Severity: FindingWarning,
+			Tenant:   userID,
+			Fields:   []string{"limits.compactor_blocks_retention_period", "querier.query_store_after"},
+			Message: fmt.Sprintf("the blocks retention period (%s) is lower than the query store after (%s): the long-term storage is never queried",
+				retention, queryStoreAfter),
+		})
+	}
+
+	return findings
+}
+
+// checkQueryStoreAfter checks the samples not queried from the long-term storage yet are still
+// in the ingesters.
+func checkQueryStoreAfter(cfg *Config) []ConfigFinding {
+	var findings []ConfigFinding
+
+	queryStoreAfter := cfg.Querier.QueryStoreAfter
+	if retention := cfg.BlocksStorage.TSDB.Retention; queryStoreAfter > 0 && retention < queryStoreAfter {
+		findings = append(findings, ConfigFinding{
+			Severity: FindingError,
+			Fields:   []string{"blocks_storage.tsdb.retention_period", "querier.query_store_after"},
+			Message: fmt.Sprintf("the ingesters retention period (%s) is lower than the query store after (%s): the samples between them aren't queryable",
+				retention, queryStoreAfter),
+		})
+	}
+
+	return findings
+}
+
+// checkDownsampling checks the blocks compacted by the compactor are large enough to be downsampled
+// at the resolutions checked by the query-frontend.
+func checkDownsampling(cfg *Config) []ConfigFinding {
+	var findings []ConfigFinding
+
+	check := cfg.Frontend.DownsamplingCheck
+	if !check.Enabled() || len(cfg.Compactor.BlockRanges) == 0 {
+		return nil
+	}
+	maxBlockRange := cfg.Compactor.BlockRanges[len(cfg.Compactor.BlockRanges)-1]
+
+	for _, s := range check.Resolutions {
+		resolution, err := downsample.ParseMaxSourceResolution(s, 0)
+		if err != nil {
+			// Already reported by the config validation.
+			continue
+		}
+
+		var downsampleRange time.Duration
+		switch {
+		case resolution >= thanos_downsample.ResLevel2:
+			downsampleRange = time.Duration(thanos_downsample.ResLevel2DownsampleRange) * time.Millisecond
+		case resolution >= thanos_downsample.ResLevel1:
+			downsampleRange = time.Duration(thanos_downsample.ResLevel1DownsampleRange) * time.Millisecond
+		default:
+			continue
+		}
+
+		if maxBlockRange < downsampleRange {
+			findings = append(findings, ConfigFinding{
+				Severity: FindingWarning,
+				Fields:   []string{"compactor.block_ranges", "frontend.downsampling_check.resolutions"},
+				Message: fmt.Sprintf("the largest compactor block range (%s) is lower than the %s range of the blocks downsampled at the resolution %s: the blocks are never downsampled at this resolution",
+					maxBlockRange, downsampleRange, s),
+			})
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,132 @@
+package cortex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+func TestCheckConfig(t *testing.T) {
+	tests := map[string]struct {
+		setup          func(cfg *Config)
+		runtimeConfig  string
+		expectedFields [][]string
+		expectedTenant []string
+		expectedErrors int
+	}{
+		"default config": {},
+		"ingestion shard size lower than the replication factor": {
+			setup: func(cfg *Config) {
+				cfg.Distributor.ShardingStrategy = util.ShardingStrategyShuffle
+				cfg.LimitsConfig.IngestionTenantShardSize = 6
+			},
+			runtimeConfig: `
+overrides:
+  user-1:
+    ingestion_tenant_shard_size: 2
+  user-2:
+    ingestion_tenant_shard_size: 0
+`,
+			expectedFields: [][]string{{"limits.ingestion_tenant_shard_size", "ingester.lifecycler.ring.replication_factor"}},
+			expectedTenant: []string{"user-1"},
+		},
+		"ingestion shard size lower than the replication factor with zone awareness": {
+			setup: func(cfg *Config) {
+				cfg.Distributor.ShardingStrategy = util.ShardingStrategyShuffle
+				cfg.Ingester.LifecyclerConfig.RingConfig.ZoneAwarenessEnabled = true
+				cfg.LimitsConfig.IngestionTenantShardSize = 2
+			},
+		},
+		"store-gateway shard size lower than the replication factor": {
+			setup: func(cfg *Config) {
+				cfg.StoreGateway.ShardingEnabled = true
+				cfg.StoreGateway.ShardingStrategy = util.ShardingStrategyShuffle
+				cfg.LimitsConfig.StoreGatewayTenantShardSize = 2
+			},
+			expectedFields: [][]string{{"limits.store_gateway_tenant_shard_size", "store_gateway.sharding_ring.replication_factor"}},
+			expectedTenant: []string{""},
+		},
+		"blocks retention lower than the query store after": {
+			setup: func(cfg *Config) {
+				cfg.Querier.QueryStoreAfter = 12 * time.Hour
+				cfg.Querier.QueryIngestersWithin = 13 * time.Hour
+				cfg.BlocksStorage.TSDB.Retention = 13 * time.Hour
+			},
+			runtimeConfig: `
+overrides:
+  user-1:
+    compactor_blocks_retention_period: 6h
+`,
+			expectedFields: [][]string{{"limits.compactor_blocks_retention_period", "querier.query_store_after"}},
+			expectedTenant: []string{"user-1"},
+		},
+		"ingesters retention lower than the query store after": {
+			setup: func(cfg *Config) {
+				cfg.Querier.QueryStoreAfter = 12 * time.Hour
+				cfg.BlocksStorage.TSDB.Retention = 6 * time.Hour
+			},
+			expectedFields: [][]string{{"blocks_storage.tsdb.retention_period", "querier.query_store_after"}},
+			expectedTenant: []string{""},
+			expectedErrors: 1,
+		},
+		"compactor block ranges too small to downsample": {
+			setup: func(cfg *Config) {
+				cfg.Frontend.DownsamplingCheck.Percentage = 10
+				cfg.Compactor.BlockRanges = cortex_tsdb.DurationList{2 * time.Hour, 48 * time.Hour}
+			},
+			expectedFields: [][]string{{"compactor.block_ranges", "frontend.downsampling_check.resolutions"}},
+			expectedTenant: []string{""},
+		},
+		"invalid runtime config": {
+			runtimeConfig: `
+overrides:
+  user-1:
+    unknown: 1
+`,
+			expectedFields: [][]string{{"runtime_config.file"}},
+			expectedTenant: []string{""},
+			expectedErrors: 1,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			if testData.setup != nil {
+				testData.setup(&cfg)
+			}
+			if testData.runtimeConfig != "" {
+				cfg.RuntimeConfig.LoadPath = filepath.Join(t.TempDir(), "runtime.yaml")
+				require.NoError(t, os.WriteFile(cfg.RuntimeConfig.LoadPath, []byte(testData.runtimeConfig), 0644))
+			}
+
+			findings := CheckConfig(&cfg, log.NewNopLogger())
+
+			var (
+				fields  [][]string
+				tenants []string
+				errors  int
+			)
+			for _, f := range findings {
+				fields = append(fields, f.Fields)
+				tenants = append(tenants, f.Tenant)
+				if f.Severity == FindingError {
+					errors++
+				}
+			}
+			assert.Equal(t, testData.expectedFields, fields)
+			assert.Equal(t, testData.expectedTenant, tenants)
+			assert.Equal(t, testData.expectedErrors, errors)
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -34,6 +35,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/distributor"
 	"github.com/cortexproject/cortex/pkg/flusher"
+	"github.com/cortexproject/cortex/pkg/freshness"
 	"github.com/cortexproject/cortex/pkg/frontend"
 	frontendv1 "github.com/cortexproject/cortex/pkg/frontend/v1"
 	"github.com/cortexproject/cortex/pkg/ingester"
@@ -43,10 +45,13 @@ import (
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware/queryrange"
 	querier_worker "github.com/cortexproject/cortex/pkg/querier/worker"
+	"github.com/cortexproject/cortex/pkg/querycanary"
+	"github.com/cortexproject/cortex/pkg/replicator"
 	"github.com/cortexproject/cortex/pkg/ring"
 	"github.com/cortexproject/cortex/pkg/ring/kv/memberlist"
 	"github.com/cortexproject/cortex/pkg/ruler"
 	"github.com/cortexproject/cortex/pkg/ruler/rulestore"
+	"github.com/cortexproject/cortex/pkg/scheduledquery"
 	"github.com/cortexproject/cortex/pkg/scheduler"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb"
 	"github.com/cortexproject/cortex/pkg/storegateway"
@@ -121,6 +126,10 @@ type Config struct {
 	RuntimeConfig       runtimeconfig.Config                       `yaml:"runtime_config"`
 	MemberlistKV        memberlist.KVConfig                        `yaml:"memberlist"`
 	QueryScheduler      scheduler.Config                           `yaml:"query_scheduler"`
+	ScheduledQuery      scheduledquery.Config                      `yaml:"scheduled_query"`
+	FreshnessProbe      freshness.Config                           `yaml:"freshness_probe"`
+	QueryCanary         querycanary.Config                         `yaml:"query_canary"`
+	Replicator          replicator.Config                          `yaml:"replicator"`
 
 	Tracing tracing.Config `yaml:"tracing"`
 }
@@ -167,6 +176,10 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	c.RuntimeConfig.RegisterFlags(f)
 	c.MemberlistKV.RegisterFlags(f)
 	c.QueryScheduler.RegisterFlags(f)
+	c.ScheduledQuery.RegisterFlags(f)
+	c.FreshnessProbe.RegisterFlags(f)
+	c.QueryCanary.RegisterFlags(f)
+	c.Replicator.RegisterFlags(f)
 	c.Tracing.RegisterFlags(f)
 }
 
@@ -181,6 +194,9 @@ func (c *Config) Validate(log log.Logger) error {
 		return errInvalidHTTPPrefix
 	}
 
+	if err := c.API.TenantResolution.Validate(); err != nil {
+		return errors.Wrap(err, "invalid api config")
+	}
 	if err := c.Storage.Validate(); err != nil {
 		return errors.Wrap(err, "invalid storage config")
 	}
@@ -202,12 +218,18 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.Querier.Validate(); err != nil {
 		return errors.Wrap(err, "invalid querier config")
 	}
+	if err := c.Ingester.Validate(); err != nil {
+		return errors.Wrap(err, "invalid ingester config")
+	}
 	if err := c.IngesterClient.Validate(log); err != nil {
 		return errors.Wrap(err, "invalid ingester_client config")
 	}
 	if err := c.Worker.Validate(log); err != nil {
 		return errors.Wrap(err, "invalid frontend_worker config")
 	}
+	if err := c.Frontend.Validate(); err != nil {
+		return errors.Wrap(err, "invalid frontend config")
+	}
 	if err := c.QueryRange.Validate(c.Querier); err != nil {
 		return errors.Wrap(err, "invalid query_range config")
 	}
@@ -223,6 +245,18 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.Alertmanager.Validate(c.AlertmanagerStorage); err != nil {
 		return errors.Wrap(err, "invalid alertmanager config")
 	}
+	if err := c.ScheduledQuery.Validate(); err != nil {
+		return errors.Wrap(err, "invalid scheduled query config")
+	}
+	if err := c.FreshnessProbe.Validate(); err != nil {
+		return errors.Wrap(err, "invalid freshness probe config")
+	}
+	if err := c.QueryCanary.Validate(); err != nil {
+		return errors.Wrap(err, "invalid query canary config")
+	}
+	if err := c.Replicator.Validate(); err != nil {
+		return errors.Wrap(err, "invalid replicator config")
+	}
 
 	if err := c.Tracing.Validate(); err != nil {
 		return errors.Wrap(err, "invalid tracing config")
@@ -306,20 +340,50 @@ type Cortex struct {
 	QuerierEngine            v1.QueryEngine
 	QueryFrontendTripperware tripperware.Tripperware
 
-	Ruler        *ruler.Ruler
-	RulerStorage rulestore.RuleStore
-	ConfigAPI    *configAPI.API
-	ConfigDB     db.DB
-	Alertmanager *alertmanager.MultitenantAlertmanager
-	Compactor    *compactor.Compactor
-	StoreGateway *storegateway.StoreGateway
-	MemberlistKV *memberlist.KVInitService
+	Ruler                *ruler.Ruler
+	RulerStorage         rulestore.RuleStore
+	RulerRemoteEvaluator *ruler.RemoteEvaluator
+	RulerWAL             *ruler.WALPusher
+	ConfigAPI            *configAPI.API
+	ConfigDB             db.DB
+	Alertmanager         *alertmanager.MultitenantAlertmanager
+	Compactor            *compactor.Compactor
+	StoreGateway         *storegateway.StoreGateway
+	MemberlistKV         *memberlist.KVInitService
 
 	// Queryables that the querier should use to query the long
 	// term storage. It depends on the storage engine used.
 	StoreQueryables []querier.QueryableWithFilter
+
+	// Checks of the readiness of the read and write paths, registered by the modules.
+	readReadinessChecks  []readinessCheck
+	writeReadinessChecks []readinessCheck
+}
+
+// readinessCheck checks whether a dependency of the read or write path can serve requests.
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
 }
 
+// ringReadinessCheck returns a readinessCheck failing if the ring hasn't enough healthy instances
+// for the operation.
+func ringReadinessCheck(name string, r ring.ReadRing, op ring.Operation) readinessCheck {
+	return readinessCheck{name: name, check: func(context.Context) error {
+		_, err := r.GetReplicationSetForOperation(op)
+		return err
+	}}
+}
+
+// readinessPath is the path whose readiness is checked by a readiness endpoint.
+type readinessPath int
+
+const (
+	readinessPathAll readinessPath = iota
+	readinessPathRead
+	readinessPathWrite
+)
+
 // New makes a new Cortex.
 func New(cfg Config) (*Cortex, error) {
 	if cfg.PrintConfig {
@@ -335,6 +399,8 @@ func New(cfg Config) (*Cortex, error) {
 		tenant.WithDefaultResolver(tenant.NewMultiResolver())
 	}
 
+	cfg.API.StartTime = time.Now()
+
 	// Don't check auth header on TransferChunks, as we weren't originally
 	// sending it and this could cause transfers to fail on update.
 	cfg.API.HTTPAuthMiddleware = fakeauth.SetupAuthMiddleware(&cfg.Server, cfg.AuthEnabled,
@@ -372,12 +438,18 @@ func (t *Cortex) setupThanosTracing() {
 }
 
 // setupGRPCHeaderForwarding appends a gRPC middleware used to enable the propagation of
-// HTTP Headers through child gRPC calls
+// HTTP Headers, of the request ID and of the query priority through child gRPC calls
 func (t *Cortex) setupGRPCHeaderForwarding() {
+	t.Cfg.Server.GRPCMiddleware = append(t.Cfg.Server.GRPCMiddleware, grpcutil.QueryPriorityPropagationServerInterceptor)
+	t.Cfg.Server.GRPCStreamMiddleware = append(t.Cfg.Server.GRPCStreamMiddleware, grpcutil.QueryPriorityPropagationStreamServerInterceptor)
 	if len(t.Cfg.API.HTTPRequestHeadersToLog) > 0 {
 		t.Cfg.Server.GRPCMiddleware = append(t.Cfg.Server.GRPCMiddleware, grpcutil.HTTPHeaderPropagationServerInterceptor)
 		t.Cfg.Server.GRPCStreamMiddleware = append(t.Cfg.Server.GRPCStreamMiddleware, grpcutil.HTTPHeaderPropagationStreamServerInterceptor)
 	}
+	if t.Cfg.API.RequestIDEnabled {
+		t.Cfg.Server.GRPCMiddleware = append(t.Cfg.Server.GRPCMiddleware, grpcutil.RequestIDPropagationServerInterceptor)
+		t.Cfg.Server.GRPCStreamMiddleware = append(t.Cfg.Server.GRPCStreamMiddleware, grpcutil.RequestIDPropagationStreamServerInterceptor)
+	}
 }
 
 func (t *Cortex) setupRequestSigning() {
@@ -423,7 +495,9 @@ func (t *Cortex) Run() error {
 
 	// before starting servers, register /ready handler and gRPC health check service.
 	// It should reflect entire Cortex.
-	t.Server.HTTP.Path("/ready").Handler(t.readyHandler(sm))
+	t.Server.HTTP.Path("/ready").Handler(t.readyHandler(sm, readinessPathAll))
+	t.Server.HTTP.Path("/ready/read").Handler(t.readyHandler(sm, readinessPathRead))
+	t.Server.HTTP.Path("/ready/write").Handler(t.readyHandler(sm, readinessPathWrite))
 	grpc_health_v1.RegisterHealthServer(t.Server.GRPC, grpcutil.NewHealthCheck(sm))
 
 	// Let's listen for events from this manager, and log them.
@@ -483,7 +557,10 @@ func (t *Cortex) Run() error {
 	return err
 }
 
-func (t *Cortex) readyHandler(sm *services.Manager) http.HandlerFunc {
+// readyHandler checks the readiness of the path. The read and write paths are also checked for the
+// availability of their dependencies, so that a load balancer can stop sending the requests of a path
+// without affecting the other one.
+func (t *Cortex) readyHandler(sm *services.Manager, path readinessPath) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if !sm.IsHealthy() {
 			msg := bytes.Buffer{}
@@ -509,13 +586,27 @@ func (t *Cortex) readyHandler(sm *services.Manager) http.HandlerFunc {
 
 		// Query Frontend has a special check that makes sure that a querier is attached before it signals
 		// itself as ready
-		if t.Frontend != nil {
+		if t.Frontend != nil && path != readinessPathWrite {
 			if err := t.Frontend.CheckReady(r.Context()); err != nil {
 				http.Error(w, "Query Frontend not ready: "+err.Error(), http.StatusServiceUnavailable)
 				return
 			}
 		}
 
+		var checks []readinessCheck
+		switch path {
+		case readinessPathRead:
+			checks = t.readReadinessChecks
+		case readinessPathWrite:
+			checks = t.writeReadinessChecks
+		}
+		for _, c := range checks {
+			if err := c.check(r.Context()); err != nil {
+				http.Error(w, c.name+" not ready: "+err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+
 		util.WriteTextResponse(w, "ready")
 	}
 }
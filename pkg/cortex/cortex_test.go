@@ -3,9 +3,12 @@ package cortex
 import (
 	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"strings"
@@ -316,3 +319,54 @@ func prepareGlobalMetricsRegistry(t *testing.T) {
 		prometheus.DefaultRegisterer, prometheus.DefaultGatherer = oldReg, oldGat
 	})
 }
+
+func TestReadyHandler_ShouldCheckTheReadAndWritePathsSeparately(t *testing.T) {
+	svc := services.NewIdleService(nil, nil)
+	sm, err := services.NewManager(svc)
+	require.NoError(t, err)
+	require.NoError(t, services.StartManagerAndAwaitHealthy(context.Background(), sm))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopManagerAndAwaitStopped(context.Background(), sm))
+	})
+
+	c := &Cortex{
+		readReadinessChecks: []readinessCheck{{name: "Long-term storage", check: func(context.Context) error {
+			return errors.New("no healthy store-gateway in the ring")
+		}}},
+		writeReadinessChecks: []readinessCheck{{name: "Ingesters ring", check: func(context.Context) error {
+			return nil
+		}}},
+	}
+
+	tests := map[string]struct {
+		path         readinessPath
+		expectedCode int
+		expectedBody string
+	}{
+		"all": {
+			path:         readinessPathAll,
+			expectedCode: http.StatusOK,
+			expectedBody: "ready",
+		},
+		"read": {
+			path:         readinessPathRead,
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: "Long-term storage not ready: no healthy store-gateway in the ring\n",
+		},
+		"write": {
+			path:         readinessPathWrite,
+			expectedCode: http.StatusOK,
+			expectedBody: "ready",
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c.readyHandler(sm, testData.path).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			assert.Equal(t, testData.expectedCode, rec.Code)
+			assert.Equal(t, testData.expectedBody, rec.Body.String())
+		})
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"path"
+	"runtime"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -21,6 +24,7 @@ import (
 	"github.com/thanos-io/promql-engine/logicalplan"
 	"github.com/thanos-io/thanos/pkg/discovery/dns"
 	"github.com/thanos-io/thanos/pkg/querysharding"
+	"github.com/weaveworks/common/httpgrpc"
 	httpgrpc_server "github.com/weaveworks/common/httpgrpc/server"
 	"github.com/weaveworks/common/server"
 
@@ -32,6 +36,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/configs/db"
 	"github.com/cortexproject/cortex/pkg/distributor"
 	"github.com/cortexproject/cortex/pkg/flusher"
+	"github.com/cortexproject/cortex/pkg/freshness"
 	"github.com/cortexproject/cortex/pkg/frontend"
 	"github.com/cortexproject/cortex/pkg/frontend/transport"
 	"github.com/cortexproject/cortex/pkg/ingester"
@@ -42,13 +47,18 @@ import (
 	"github.com/cortexproject/cortex/pkg/querier/tripperware/instantquery"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware/queryrange"
 	querier_worker "github.com/cortexproject/cortex/pkg/querier/worker"
+	"github.com/cortexproject/cortex/pkg/querycanary"
+	"github.com/cortexproject/cortex/pkg/replicator"
 	"github.com/cortexproject/cortex/pkg/ring"
 	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
 	"github.com/cortexproject/cortex/pkg/ring/kv/memberlist"
 	"github.com/cortexproject/cortex/pkg/ruler"
+	"github.com/cortexproject/cortex/pkg/scheduledquery"
 	"github.com/cortexproject/cortex/pkg/scheduler"
 	"github.com/cortexproject/cortex/pkg/storage/bucket"
 	"github.com/cortexproject/cortex/pkg/storegateway"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/grpcencoding"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/modules"
 	"github.com/cortexproject/cortex/pkg/util/runtimeconfig"
@@ -76,6 +86,8 @@ const (
 	QueryFrontendTripperware string = "query-frontend-tripperware"
 	RulerStorage             string = "ruler-storage"
 	Ruler                    string = "ruler"
+	RulerRemoteEvaluator     string = "ruler-remote-evaluator"
+	RulerWAL                 string = "ruler-wal"
 	Configs                  string = "configs"
 	AlertManager             string = "alertmanager"
 	Compactor                string = "compactor"
@@ -85,6 +97,11 @@ const (
 	Purger                   string = "purger"
 	QueryScheduler           string = "query-scheduler"
 	TenantFederation         string = "tenant-federation"
+	ScheduledQuery           string = "scheduled-query"
+	FreshnessProbeWriter     string = "freshness-probe-writer"
+	FreshnessProber          string = "freshness-prober"
+	QueryCanary              string = "query-canary"
+	Replicator               string = "replicator"
 	All                      string = "all"
 )
 
@@ -98,6 +115,9 @@ func newDefaultConfig() *Config {
 func (t *Cortex) initAPI() (services.Service, error) {
 	t.Cfg.API.ServerPrefix = t.Cfg.Server.PathPrefix
 	t.Cfg.API.LegacyHTTPPrefix = t.Cfg.HTTPPrefix
+	t.Cfg.API.StatusFlags = flagext.RedactedValues(flag.CommandLine)
+	t.Cfg.API.StorageRetention = t.Cfg.LimitsConfig.CompactorBlocksRetentionPeriod
+	t.Cfg.API.RulerConfigAPIEnabled = t.Cfg.Ruler.EnableAPI
 
 	a, err := api.New(t.Cfg.API, t.Cfg.Server, t.Server, util_log.Logger)
 	if err != nil {
@@ -105,6 +125,8 @@ func (t *Cortex) initAPI() (services.Service, error) {
 	}
 
 	t.API = a
+	// The overrides are initialized after the API, so they're looked up on each request.
+	t.API.TenantDiagnosticsMiddleware = &api.TenantDiagnosticsMiddleware{TraceSamplingRatio: t.tenantTraceSamplingRatio}
 	t.API.RegisterAPI(t.Cfg.Server.PathPrefix, t.Cfg, newDefaultConfig())
 
 	return nil, nil
@@ -113,6 +135,7 @@ func (t *Cortex) initAPI() (services.Service, error) {
 func (t *Cortex) initServer() (services.Service, error) {
 	// Cortex handles signals on its own.
 	DisableSignalHandling(&t.Cfg.Server)
+	grpcencoding.InstrumentCompressors(prometheus.DefaultRegisterer)
 	serv, err := server.New(t.Cfg.Server)
 	if err != nil {
 		return nil, err
@@ -137,7 +160,7 @@ func (t *Cortex) initServer() (services.Service, error) {
 }
 
 func (t *Cortex) initRing() (serv services.Service, err error) {
-	t.Cfg.Ingester.LifecyclerConfig.RingConfig.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig)
+	t.Cfg.Ingester.LifecyclerConfig.RingConfig.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig, "ingester")
 	t.Ring, err = ring.New(t.Cfg.Ingester.LifecyclerConfig.RingConfig, "ingester", ingester.RingKey, util_log.Logger, prometheus.WrapRegistererWithPrefix("cortex_", prometheus.DefaultRegisterer))
 	if err != nil {
 		return nil, err
@@ -154,6 +177,7 @@ func (t *Cortex) initRuntimeConfig() (services.Service, error) {
 		return nil, nil
 	}
 	t.Cfg.RuntimeConfig.Loader = loadRuntimeConfig
+	t.Cfg.RuntimeConfig.Validators = []runtimeconfig.Validator{runtimeConfigLimitsValidator(t.Cfg.Distributor.ShardByAllLabels)}
 
 	// make sure to set default limits before we start loading configuration into memory
 	validation.SetDefaultLimitsForYAMLUnmarshalling(t.Cfg.LimitsConfig)
@@ -178,11 +202,23 @@ func (t *Cortex) initRuntimeConfig() (services.Service, error) {
 
 func (t *Cortex) initOverrides() (serv services.Service, err error) {
 	t.Overrides, err = validation.NewOverrides(t.Cfg.LimitsConfig, t.TenantLimits)
+	if err == nil {
+		util_log.SetTenantLogLevel(t.Overrides.DiagnosticsLogLevel)
+	}
 	// overrides don't have operational state, nor do they need to do anything more in starting/stopping phase,
 	// so there is no need to return any service.
 	return nil, err
 }
 
+// tenantTraceSamplingRatio returns the ratio of the API requests of the tenant traced regardless of the
+// sampling of the tracer, from the per-tenant diagnostics overrides.
+func (t *Cortex) tenantTraceSamplingRatio(userID string) float64 {
+	if t.Overrides == nil {
+		return 0
+	}
+	return t.Overrides.DiagnosticsTraceSamplingRatio(userID)
+}
+
 func (t *Cortex) initOverridesExporter() (services.Service, error) {
 	if t.Cfg.isModuleEnabled(OverridesExporter) && t.TenantLimits == nil {
 		// This target isn't enabled by default ("all") and requires per-tenant limits to
@@ -200,6 +236,7 @@ func (t *Cortex) initOverridesExporter() (services.Service, error) {
 }
 
 func (t *Cortex) initDistributorService() (serv services.Service, err error) {
+	t.Cfg.Distributor.DistributorRing.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig, "distributor")
 	t.Cfg.Distributor.DistributorRing.ListenPort = t.Cfg.Server.GRPCListenPort
 	t.Cfg.Distributor.ShuffleShardingLookbackPeriod = t.Cfg.Querier.ShuffleShardingIngestersLookbackPeriod
 	t.Cfg.IngesterClient.GRPCClientConfig.SignWriteRequestsEnabled = t.Cfg.Distributor.SignWriteRequestsEnabled
@@ -220,6 +257,9 @@ func (t *Cortex) initDistributorService() (serv services.Service, err error) {
 func (t *Cortex) initDistributor() (serv services.Service, err error) {
 	t.API.RegisterDistributor(t.Distributor, t.Cfg.Distributor)
 
+	// The writes can't be accepted if they can't be replicated to the ingesters.
+	t.writeReadinessChecks = append(t.writeReadinessChecks, ringReadinessCheck("Ingesters ring", t.Ring, ring.Write))
+
 	return nil, nil
 }
 
@@ -230,10 +270,15 @@ func (t *Cortex) initQueryable() (serv services.Service, err error) {
 
 	// Create a querier queryable and PromQL engine
 	t.QuerierQueryable, t.ExemplarQueryable, t.QuerierEngine = querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, querierRegisterer, util_log.Logger)
+	t.QuerierEngine = querier.NewTenantConcurrencyEngine(t.QuerierEngine, t.Overrides, t.Cfg.Querier.TenantQueryQueueTimeout, querierRegisterer)
+	t.QuerierEngine = querier.NewNonFiniteValuesEngine(t.QuerierEngine, t.Overrides, querierRegisterer)
 
 	// Register the default endpoints that are always enabled for the querier module
 	t.API.RegisterQueryable(t.QuerierQueryable, t.Distributor)
 
+	// The queries can't be served if the recent samples can't be read from the ingesters.
+	t.readReadinessChecks = append(t.readReadinessChecks, ringReadinessCheck("Ingesters ring", t.Ring, ring.Read))
+
 	return nil, nil
 }
 
@@ -311,6 +356,43 @@ func (t *Cortex) initQuerier() (serv services.Service, err error) {
 		util_log.Logger,
 	)
 
+	// The queries are rejected once out of their memory budget, and wait once out of their
+	// concurrency budget, to protect the write path running in the same process. The heap is
+	// checked once the query got its slot.
+	if t.Cfg.Querier.MaxHeapInuseBytes > 0 {
+		internalQuerierRouter = querier.NewHeapInuseLimitMiddleware(t.Cfg.Querier.MaxHeapInuseBytes, prometheus.DefaultRegisterer).Wrap(internalQuerierRouter)
+	}
+	if limit := t.Cfg.Querier.InflightRequestsLimit(runtime.GOMAXPROCS(0)); limit > 0 {
+		internalQuerierRouter = querier.NewInflightLimitMiddleware(limit, prometheus.DefaultRegisterer).Wrap(internalQuerierRouter)
+	}
+
+	if t.Cfg.Querier.AdminQuery.Enabled {
+		bucketClient, err := bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, "admin-query", util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the bucket client for the admin query API")
+		}
+		// The admin query runs for the tenants in the ingesters too, not to miss the tenants
+		// which haven't shipped any block yet.
+		listTenants := querier.NewBucketTenantsLister(bucketClient, util_log.Logger)
+		listQueryTenants := querier.NewUnionTenantsLister(listTenants, querier.NewIngestersTenantsLister(t.Distributor.AllUserStats))
+		t.API.RegisterAdminQuery(querier.AdminQueryHandler(t.Cfg.Querier.AdminQuery, t.QuerierEngine, t.QuerierQueryable, listQueryTenants, util_log.Logger))
+
+		sources, err := t.adminTenantsSources(bucketClient, listTenants)
+		if err != nil {
+			return nil, err
+		}
+		t.API.RegisterAdminTenants(querier.AdminTenantsHandler(t.Cfg.Querier.AdminQuery, sources, util_log.Logger))
+	}
+
+	if t.Cfg.Querier.QueryExport.Enabled {
+		bucketClient, err := bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, "query-export", util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the bucket client for the query export API")
+		}
+		exportEngine := querier.NewQueryExportEngine(t.Cfg.Querier, util_log.Logger)
+		t.API.RegisterQueryExport(querier.NewQueryExporter(t.Cfg.Querier.QueryExport, exportEngine, t.QuerierQueryable, bucketClient, t.Overrides, util_log.Logger, prometheus.DefaultRegisterer))
+	}
+
 	// If the querier is running standalone without the query-frontend or query-scheduler, we must register it's internal
 	// HTTP handler externally and provide the external Cortex Server HTTP handler to the frontend worker
 	// to ensure requests it processes use the default middleware instrumentation.
@@ -346,6 +428,11 @@ func (t *Cortex) initQuerier() (serv services.Service, err error) {
 		}
 	}
 
+	// The rulers send the rule queries to the remote evaluators as HTTP requests over gRPC.
+	if t.Cfg.Querier.RuleEvaluatorEnabled {
+		httpgrpc.RegisterHTTPServer(t.Server.GRPC, httpgrpc_server.NewServer(internalQuerierRouter))
+	}
+
 	// If neither frontend address or scheduler address is configured, no worker is needed.
 	if t.Cfg.Worker.FrontendAddress == "" && t.Cfg.Worker.SchedulerAddress == "" {
 		return nil, nil
@@ -358,6 +445,7 @@ func (t *Cortex) initQuerier() (serv services.Service, err error) {
 
 func (t *Cortex) initStoreQueryables() (services.Service, error) {
 	var servs []services.Service
+	t.Cfg.StoreGateway.ShardingRing.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig, "store-gateway")
 
 	//nolint:revive // I prefer this form over removing 'else', because it allows q to have smaller scope.
 	if q, err := initQueryableForEngine(t.Cfg, t.Overrides, prometheus.DefaultRegisterer); err != nil {
@@ -367,6 +455,9 @@ func (t *Cortex) initStoreQueryables() (services.Service, error) {
 		if s, ok := q.(services.Service); ok {
 			servs = append(servs, s)
 		}
+		if c, ok := q.(interface{ CheckReady(context.Context) error }); ok {
+			t.readReadinessChecks = append(t.readReadinessChecks, readinessCheck{name: "Long-term storage", check: c.CheckReady})
+		}
 	}
 
 	// Return service, if any.
@@ -399,13 +490,14 @@ func (t *Cortex) tsdbIngesterConfig() {
 }
 
 func (t *Cortex) initIngesterService() (serv services.Service, err error) {
-	t.Cfg.Ingester.LifecyclerConfig.RingConfig.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig)
+	t.Cfg.Ingester.LifecyclerConfig.RingConfig.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig, "ingester")
 	t.Cfg.Ingester.LifecyclerConfig.ListenPort = t.Cfg.Server.GRPCListenPort
 	t.Cfg.Ingester.DistributorShardingStrategy = t.Cfg.Distributor.ShardingStrategy
 	t.Cfg.Ingester.DistributorShardByAllLabels = t.Cfg.Distributor.ShardByAllLabels
 	t.Cfg.Ingester.InstanceLimitsFn = ingesterInstanceLimits(t.RuntimeConfig)
 	t.Cfg.Ingester.QueryStoreForLabels = t.Cfg.Querier.QueryStoreForLabels
 	t.Cfg.Ingester.QueryIngestersWithin = t.Cfg.Querier.QueryIngestersWithin
+	t.Cfg.Ingester.IngesterClient = t.Cfg.IngesterClient
 	t.tsdbIngesterConfig()
 
 	t.Ingester, err = ingester.New(t.Cfg.Ingester, t.Overrides, prometheus.DefaultRegisterer, util_log.Logger)
@@ -441,14 +533,49 @@ func (t *Cortex) initFlusher() (serv services.Service, err error) {
 
 // initQueryFrontendTripperware instantiates the tripperware used by the query frontend
 // to optimize Prometheus query requests.
+// adminTenantsSources returns the sources of the admin tenants API. The ruler and Alertmanager
+// storage are only read if configured, and their clients are not instrumented to not clash with
+// the ones of the ruler and Alertmanager running in the same process.
+func (t *Cortex) adminTenantsSources(bucketClient objstore.Bucket, listTenants querier.TenantsLister) (querier.AdminTenantsSources, error) {
+	sources := querier.AdminTenantsSources{
+		BucketTenants:        listTenants,
+		Bucket:               bucketClient,
+		BucketConfigProvider: t.Overrides,
+		IngestersStats:       t.Distributor.AllUserStats,
+		ReplicationFactor:    t.Cfg.Ingester.LifecyclerConfig.RingConfig.ReplicationFactor,
+	}
+
+	if !t.Cfg.RulerStorage.IsDefaults() {
+		store, err := ruler.NewRuleStore(context.Background(), t.Cfg.RulerStorage, t.Overrides, rules.FileLoader{}, util_log.Logger, nil)
+		if err != nil {
+			return sources, errors.Wrap(err, "failed to create the ruler storage client for the admin tenants API")
+		}
+		sources.RuleGroups = store.ListAllRuleGroups
+	}
+
+	if !t.Cfg.AlertmanagerStorage.IsDefaults() {
+		store, err := alertstore.NewAlertStore(context.Background(), t.Cfg.AlertmanagerStorage, t.Overrides, util_log.Logger, nil)
+		if err != nil {
+			return sources, errors.Wrap(err, "failed to create the Alertmanager storage client for the admin tenants API")
+		}
+		sources.AlertmanagerTenants = store.ListAllUsers
+	}
+
+	return sources, nil
+}
+
 func (t *Cortex) initQueryFrontendTripperware() (serv services.Service, err error) {
 	queryAnalyzer := querysharding.NewQueryAnalyzer()
 	// PrometheusCodec is a codec to encode and decode Prometheus query range requests and responses.
-	prometheusCodec := queryrange.NewPrometheusCodec(false)
+	var prometheusCodec tripperware.Codec = queryrange.NewPrometheusCodec(false)
 	// ShardedPrometheusCodec is same as PrometheusCodec but to be used on the sharded queries (it sum up the stats)
-	shardedPrometheusCodec := queryrange.NewPrometheusCodec(true)
+	var shardedPrometheusCodec tripperware.Codec = queryrange.NewPrometheusCodec(true)
+	if t.Cfg.QueryRange.DownstreamRequestFormat == queryrange.RequestFormatProtobuf {
+		prometheusCodec = queryrange.NewProtobufCodec(false)
+		shardedPrometheusCodec = queryrange.NewProtobufCodec(true)
+	}
 
-	queryRangeMiddlewares, cache, err := queryrange.Middlewares(
+	queryRangeMiddlewares, cache, cacheWarmer, err := queryrange.Middlewares(
 		t.Cfg.QueryRange,
 		util_log.Logger,
 		t.Overrides,
@@ -479,13 +606,34 @@ func (t *Cortex) initQueryFrontendTripperware() (serv services.Service, err erro
 		t.Cfg.Querier.DefaultEvaluationInterval,
 		t.Cfg.Querier.MaxSubQuerySteps,
 		t.Cfg.Querier.LookbackDelta,
+		t.Cfg.QueryRange.InfoJoin,
 	)
 
-	return services.NewIdleService(nil, func(_ error) error {
+	var metadataCache *tripperware.MetadataCache
+	if t.Cfg.QueryRange.MetadataCache.Enabled {
+		metadataCache, err = tripperware.NewMetadataCache(t.Cfg.QueryRange.MetadataCache, util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+		t.QueryFrontendTripperware = metadataCache.Wrap(t.QueryFrontendTripperware)
+	}
+
+	return services.NewIdleService(func(ctx context.Context) error {
+		if cacheWarmer != nil {
+			return services.StartAndAwaitRunning(ctx, cacheWarmer)
+		}
+		return nil
+	}, func(_ error) error {
+		if cacheWarmer != nil {
+			_ = services.StopAndAwaitTerminated(context.Background(), cacheWarmer)
+		}
 		if cache != nil {
 			cache.Stop()
 			cache = nil
 		}
+		if metadataCache != nil {
+			metadataCache.Stop()
+		}
 		return nil
 	}), nil
 }
@@ -500,8 +648,33 @@ func (t *Cortex) initQueryFrontend() (serv services.Service, err error) {
 	// Wrap roundtripper into Tripperware.
 	roundTripper = t.QueryFrontendTripperware(roundTripper)
 
+	if t.Cfg.Frontend.DownsamplingCheck.Enabled() {
+		roundTripper = frontend.NewDownsamplingCheckRoundTripper(t.Cfg.Frontend.DownsamplingCheck, roundTripper, util_log.Logger, prometheus.DefaultRegisterer)
+	}
+
+	if t.Cfg.Frontend.Federation.Enabled() {
+		roundTripper, err = frontend.NewFederationRoundTripper(t.Cfg.Frontend.Federation, roundTripper, t.Overrides, util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The whole queries are mirrored, as received from the clients.
+	if t.Cfg.Frontend.Shadow.Enabled() {
+		roundTripper, err = frontend.NewShadowRoundTripper(t.Cfg.Frontend.Shadow, roundTripper, util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, prometheus.DefaultRegisterer)
 	t.API.RegisterQueryFrontendHandler(handler)
+	if t.Cfg.Frontend.Handler.TopQueriesSize > 0 {
+		t.API.RegisterQueryFrontendTopQueries(handler.TopQueriesHandler())
+	}
+	if t.Cfg.Frontend.Handler.ActiveQueriesAPIEnabled {
+		t.API.RegisterQueryFrontendActiveQueries(handler.ActiveQueriesHandler(), handler.CancelActiveQueryHandler())
+	}
 
 	if frontendV1 != nil {
 		t.API.RegisterQueryFrontend1(frontendV1)
@@ -531,6 +704,30 @@ func (t *Cortex) initRulerStorage() (serv services.Service, err error) {
 	return
 }
 
+func (t *Cortex) initRulerRemoteEvaluator() (services.Service, error) {
+	if len(t.Cfg.Ruler.RemoteEvaluation.Addresses) == 0 {
+		return nil, nil
+	}
+
+	// The remote evaluators serve the Prometheus API under the same prefix.
+	pathPrefix := path.Join(t.Cfg.API.ServerPrefix, t.Cfg.API.PrometheusHTTPPrefix)
+	t.RulerRemoteEvaluator = ruler.NewRemoteEvaluator(t.Cfg.Ruler.RemoteEvaluation, pathPrefix, t.Overrides, util_log.Logger, prometheus.DefaultRegisterer)
+	return t.RulerRemoteEvaluator, nil
+}
+
+func (t *Cortex) initRulerWAL() (services.Service, error) {
+	if !t.Cfg.Ruler.WAL.Enabled {
+		return nil, nil
+	}
+
+	var next ruler.Pusher = t.Distributor
+	if t.Cfg.ExternalPusher != nil {
+		next = t.Cfg.ExternalPusher
+	}
+	t.RulerWAL = ruler.NewWALPusher(t.Cfg.Ruler.WAL, next, t.Overrides, util_log.Logger, prometheus.DefaultRegisterer)
+	return t.RulerWAL, nil
+}
+
 func createActiveQueryTracker(cfg querier.Config, logger log.Logger) promql.QueryTracker {
 	dir := cfg.ActiveQueryTrackerDir
 
@@ -542,6 +739,7 @@ func createActiveQueryTracker(cfg querier.Config, logger log.Logger) promql.Quer
 }
 
 func (t *Cortex) initRuler() (serv services.Service, err error) {
+	t.Cfg.Ruler.Ring.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig, "ruler")
 	var manager *ruler.DefaultMultiTenantManager
 	if t.RulerStorage == nil {
 		level.Info(util_log.Logger).Log("msg", "RulerStorage is nil.  Not starting the ruler.")
@@ -577,14 +775,18 @@ func (t *Cortex) initRuler() (serv services.Service, err error) {
 			queryEngine = promql.NewEngine(opts)
 		}
 
-		managerFactory := ruler.DefaultTenantManagerFactory(t.Cfg.Ruler, t.Cfg.ExternalPusher, t.Cfg.ExternalQueryable, queryEngine, t.Overrides, prometheus.DefaultRegisterer)
+		queryEngine = ruler.NewQueryLimitsEngine(queryEngine, t.rulerQueryEngineFactory())
+		queryEngine = querier.NewTenantConcurrencyEngine(queryEngine, t.Overrides, t.Cfg.Querier.TenantQueryQueueTimeout, rulerRegisterer)
+		managerFactory := ruler.DefaultTenantManagerFactory(t.Cfg.Ruler, t.rulerPusher(t.Cfg.ExternalPusher), t.Cfg.ExternalQueryable, queryEngine, t.Overrides, t.RulerRemoteEvaluator, prometheus.DefaultRegisterer)
 		manager, err = ruler.NewDefaultMultiTenantManager(t.Cfg.Ruler, managerFactory, prometheus.DefaultRegisterer, util_log.Logger)
 	} else {
 		rulerRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "ruler"}, prometheus.DefaultRegisterer)
 		// TODO: Consider wrapping logger to differentiate from querier module logger
 		queryable, _, engine := querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, rulerRegisterer, util_log.Logger)
 
-		managerFactory := ruler.DefaultTenantManagerFactory(t.Cfg.Ruler, t.Distributor, queryable, engine, t.Overrides, prometheus.DefaultRegisterer)
+		engine = ruler.NewQueryLimitsEngine(engine, t.rulerQueryEngineFactory())
+		engine = querier.NewTenantConcurrencyEngine(engine, t.Overrides, t.Cfg.Querier.TenantQueryQueueTimeout, rulerRegisterer)
+		managerFactory := ruler.DefaultTenantManagerFactory(t.Cfg.Ruler, t.rulerPusher(t.Distributor), queryable, engine, t.Overrides, t.RulerRemoteEvaluator, prometheus.DefaultRegisterer)
 		manager, err = ruler.NewDefaultMultiTenantManager(t.Cfg.Ruler, managerFactory, prometheus.DefaultRegisterer, util_log.Logger)
 	}
 
@@ -615,6 +817,22 @@ func (t *Cortex) initRuler() (serv services.Service, err error) {
 	return t.Ruler, nil
 }
 
+// rulerPusher returns the pusher of the rule results, queueing them in the write-ahead log if enabled.
+func (t *Cortex) rulerPusher(p ruler.Pusher) ruler.Pusher {
+	if t.RulerWAL != nil {
+		return t.RulerWAL
+	}
+	return p
+}
+
+// rulerQueryEngineFactory returns the factory of the query engines evaluating the rule queries
+// of the rule groups with query limits overrides.
+func (t *Cortex) rulerQueryEngineFactory() ruler.QueryEngineFactory {
+	return func(maxSamples int, timeout time.Duration) v1.QueryEngine {
+		return querier.NewQueryEngineWithLimits(t.Cfg.Querier, maxSamples, timeout, util_log.Logger)
+	}
+}
+
 func (t *Cortex) initConfig() (serv services.Service, err error) {
 	t.ConfigDB, err = db.New(t.Cfg.Configs.DB)
 	if err != nil {
@@ -630,6 +848,7 @@ func (t *Cortex) initConfig() (serv services.Service, err error) {
 }
 
 func (t *Cortex) initAlertManager() (serv services.Service, err error) {
+	t.Cfg.Alertmanager.ShardingRing.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig, "alertmanager")
 	t.Cfg.Alertmanager.ShardingRing.ListenPort = t.Cfg.Server.GRPCListenPort
 
 	// Initialise the store.
@@ -648,6 +867,7 @@ func (t *Cortex) initAlertManager() (serv services.Service, err error) {
 }
 
 func (t *Cortex) initCompactor() (serv services.Service, err error) {
+	t.Cfg.Compactor.ShardingRing.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig, "compactor")
 	t.Cfg.Compactor.ShardingRing.ListenPort = t.Cfg.Server.GRPCListenPort
 
 	t.Compactor, err = compactor.NewCompactor(t.Cfg.Compactor, t.Cfg.BlocksStorage, util_log.Logger, prometheus.DefaultRegisterer, t.Overrides)
@@ -661,6 +881,7 @@ func (t *Cortex) initCompactor() (serv services.Service, err error) {
 }
 
 func (t *Cortex) initStoreGateway() (serv services.Service, err error) {
+	t.Cfg.StoreGateway.ShardingRing.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig, "store-gateway")
 	t.Cfg.StoreGateway.ShardingRing.ListenPort = t.Cfg.Server.GRPCListenPort
 
 	t.StoreGateway, err = storegateway.NewStoreGateway(t.Cfg.StoreGateway, t.Cfg.BlocksStorage, t.Overrides, t.Cfg.Server.LogLevel, util_log.Logger, prometheus.DefaultRegisterer)
@@ -723,6 +944,66 @@ func (t *Cortex) initQueryScheduler() (services.Service, error) {
 	return s, nil
 }
 
+func (t *Cortex) initScheduledQuery() (services.Service, error) {
+	if t.Cfg.ScheduledQuery.DefinitionsFile == "" {
+		return nil, errors.New("the scheduled queries definitions file is required by the scheduled-query module")
+	}
+
+	bucketClient, err := bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, "scheduled-query", util_log.Logger, prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the scheduled queries bucket client")
+	}
+
+	reg := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "scheduled-query"}, prometheus.DefaultRegisterer)
+	queryable, _, engine := querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, reg, util_log.Logger)
+
+	return scheduledquery.NewScheduler(t.Cfg.ScheduledQuery, engine, queryable, bucketClient, t.Distributor, t.Overrides, util_log.Logger, prometheus.DefaultRegisterer), nil
+}
+
+func (t *Cortex) initFreshnessProbeWriter() (services.Service, error) {
+	if !t.Cfg.FreshnessProbe.Enabled {
+		return nil, nil
+	}
+
+	return freshness.NewWriter(t.Cfg.FreshnessProbe, t.Distributor, t.Cfg.Distributor.DistributorRing.InstanceID, util_log.Logger, prometheus.DefaultRegisterer), nil
+}
+
+func (t *Cortex) initFreshnessProber() (services.Service, error) {
+	if !t.Cfg.FreshnessProbe.Enabled {
+		return nil, nil
+	}
+
+	ingesters := querier.NewIngestersQueryable(t.Cfg.Querier, t.Distributor)
+	prober := freshness.NewProber(t.Cfg.FreshnessProbe, ingesters, querier.NewStoresQueryable(t.StoreQueryables), util_log.Logger, prometheus.DefaultRegisterer)
+	t.API.RegisterFreshnessProber(prober)
+
+	return prober, nil
+}
+
+func (t *Cortex) initQueryCanary() (services.Service, error) {
+	if len(t.Cfg.QueryCanary.Tenants) == 0 {
+		return nil, errors.New("at least one tenant is required by the query-canary module")
+	}
+
+	reg := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "query-canary"}, prometheus.DefaultRegisterer)
+	queryable, _, engine := querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, reg, util_log.Logger)
+
+	return querycanary.NewCanary(t.Cfg.QueryCanary, engine, queryable, t.Distributor, util_log.Logger, prometheus.DefaultRegisterer)
+}
+
+func (t *Cortex) initReplicator() (services.Service, error) {
+	source, err := bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, "replicator-source", util_log.Logger, prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := bucket.NewClient(context.Background(), t.Cfg.Replicator.Destination, "replicator-destination", util_log.Logger, prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, err
+	}
+
+	return replicator.NewReplicator(t.Cfg.Replicator, source, destination, t.Overrides, util_log.Logger, prometheus.DefaultRegisterer), nil
+}
+
 func (t *Cortex) setupModuleManager() error {
 	mm := modules.NewManager(util_log.Logger)
 
@@ -746,6 +1027,8 @@ func (t *Cortex) setupModuleManager() error {
 	mm.RegisterModule(QueryFrontendTripperware, t.initQueryFrontendTripperware, modules.UserInvisibleModule)
 	mm.RegisterModule(QueryFrontend, t.initQueryFrontend)
 	mm.RegisterModule(RulerStorage, t.initRulerStorage, modules.UserInvisibleModule)
+	mm.RegisterModule(RulerRemoteEvaluator, t.initRulerRemoteEvaluator, modules.UserInvisibleModule)
+	mm.RegisterModule(RulerWAL, t.initRulerWAL, modules.UserInvisibleModule)
 	mm.RegisterModule(Ruler, t.initRuler)
 	mm.RegisterModule(Configs, t.initConfig)
 	mm.RegisterModule(AlertManager, t.initAlertManager)
@@ -755,6 +1038,11 @@ func (t *Cortex) setupModuleManager() error {
 	mm.RegisterModule(Purger, nil)
 	mm.RegisterModule(QueryScheduler, t.initQueryScheduler)
 	mm.RegisterModule(TenantFederation, t.initTenantFederation, modules.UserInvisibleModule)
+	mm.RegisterModule(ScheduledQuery, t.initScheduledQuery)
+	mm.RegisterModule(FreshnessProbeWriter, t.initFreshnessProbeWriter, modules.UserInvisibleModule)
+	mm.RegisterModule(FreshnessProber, t.initFreshnessProber, modules.UserInvisibleModule)
+	mm.RegisterModule(QueryCanary, t.initQueryCanary)
+	mm.RegisterModule(Replicator, t.initReplicator)
 	mm.RegisterModule(All, nil)
 
 	// Add dependencies
@@ -765,18 +1053,20 @@ func (t *Cortex) setupModuleManager() error {
 		Ring:                     {API, RuntimeConfig, MemberlistKV},
 		Overrides:                {RuntimeConfig},
 		OverridesExporter:        {RuntimeConfig},
-		Distributor:              {DistributorService, API},
+		Distributor:              {DistributorService, API, FreshnessProbeWriter},
 		DistributorService:       {Ring, Overrides},
 		Ingester:                 {IngesterService, Overrides, API},
 		IngesterService:          {Overrides, RuntimeConfig, MemberlistKV},
 		Flusher:                  {Overrides, API},
 		Queryable:                {Overrides, DistributorService, Overrides, Ring, API, StoreQueryable, MemberlistKV},
-		Querier:                  {TenantFederation},
+		Querier:                  {TenantFederation, FreshnessProber},
 		StoreQueryable:           {Overrides, Overrides, MemberlistKV},
 		QueryFrontendTripperware: {API, Overrides},
 		QueryFrontend:            {QueryFrontendTripperware},
 		QueryScheduler:           {API, Overrides},
-		Ruler:                    {DistributorService, Overrides, StoreQueryable, RulerStorage},
+		Ruler:                    {DistributorService, Overrides, StoreQueryable, RulerStorage, RulerRemoteEvaluator, RulerWAL},
+		RulerRemoteEvaluator:     {API, Overrides},
+		RulerWAL:                 {DistributorService, Overrides},
 		RulerStorage:             {Overrides},
 		Configs:                  {API},
 		AlertManager:             {API, MemberlistKV, Overrides},
@@ -785,10 +1075,16 @@ func (t *Cortex) setupModuleManager() error {
 		TenantDeletion:           {API, Overrides},
 		Purger:                   {TenantDeletion},
 		TenantFederation:         {Queryable},
+		ScheduledQuery:           {DistributorService, Overrides, StoreQueryable},
+		FreshnessProbeWriter:     {DistributorService},
+		FreshnessProber:          {API, DistributorService, StoreQueryable},
+		QueryCanary:              {DistributorService, Overrides, StoreQueryable},
+		Replicator:               {API, Overrides},
 		All:                      {QueryFrontend, Querier, Ingester, Distributor, Purger, StoreGateway, Ruler},
 	}
 	if t.Cfg.ExternalPusher != nil && t.Cfg.ExternalQueryable != nil {
-		deps[Ruler] = []string{Overrides, RulerStorage}
+		deps[Ruler] = []string{Overrides, RulerStorage, RulerRemoteEvaluator, RulerWAL}
+		deps[RulerWAL] = []string{Overrides}
 	}
 	for mod, targets := range deps {
 		if err := mm.AddDependency(mod, targets...); err != nil {
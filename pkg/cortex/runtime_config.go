@@ -2,6 +2,7 @@ package cortex
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 
@@ -77,7 +78,30 @@ func loadRuntimeConfig(r io.Reader) (interface{}, error) {
 	return overrides, nil
 }
 
-func multiClientRuntimeConfigChannel(manager *runtimeconfig.Manager) func() <-chan kv.MultiRuntimeConfig {
+// runtimeConfigLimitsValidator returns a runtimeconfig.Validator rejecting the runtime
+// configurations with invalid per-tenant limits.
+func runtimeConfigLimitsValidator(shardByAllLabels bool) runtimeconfig.Validator {
+	return func(config interface{}) error {
+		cfg, ok := config.(*RuntimeConfigValues)
+		if !ok || cfg == nil {
+			return nil
+		}
+
+		for userID, limits := range cfg.TenantLimits {
+			if limits == nil {
+				continue
+			}
+			if err := limits.Validate(shardByAllLabels); err != nil {
+				return fmt.Errorf("invalid overrides for tenant %s: %w", userID, err)
+			}
+		}
+		return nil
+	}
+}
+
+// multiClientRuntimeConfigChannel returns a kv.MultiConfig.ConfigProvider for the multi-clients of
+// the ring with the given name.
+func multiClientRuntimeConfigChannel(manager *runtimeconfig.Manager, ringName string) func() <-chan kv.MultiRuntimeConfig {
 	if manager == nil {
 		return nil
 	}
@@ -88,14 +112,14 @@ func multiClientRuntimeConfigChannel(manager *runtimeconfig.Manager) func() <-ch
 		// push initial config to the channel
 		val := manager.GetConfig()
 		if cfg, ok := val.(*RuntimeConfigValues); ok && cfg != nil {
-			outCh <- cfg.Multi
+			outCh <- cfg.Multi.ForRing(ringName)
 		}
 
 		ch := manager.CreateListenerChannel(1)
 		go func() {
 			for val := range ch {
 				if cfg, ok := val.(*RuntimeConfigValues); ok && cfg != nil {
-					outCh <- cfg.Multi
+					outCh <- cfg.Multi.ForRing(ringName)
 				}
 			}
 		}()
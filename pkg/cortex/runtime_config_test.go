@@ -109,3 +109,25 @@ overrides:
 		assert.Nil(t, actual)
 	}
 }
+
+func TestRuntimeConfigLimitsValidator(t *testing.T) {
+	runtimeCfg, err := loadRuntimeConfig(strings.NewReader(`
+overrides:
+  '1234':
+    max_global_series_per_user: 15000
+  '1235':
+    query_time_zone: Europe/Paris
+`))
+	require.NoError(t, err)
+
+	assert.NoError(t, runtimeConfigLimitsValidator(true)(runtimeCfg))
+	assert.ErrorContains(t, runtimeConfigLimitsValidator(false)(runtimeCfg), "invalid overrides for tenant 1234: The ingester.max-global-series-per-user limit is unsupported")
+
+	runtimeCfg, err = loadRuntimeConfig(strings.NewReader(`
+overrides:
+  '1234':
+    query_time_zone: Mars/Olympus_Mons
+`))
+	require.NoError(t, err)
+	assert.ErrorContains(t, runtimeConfigLimitsValidator(true)(runtimeCfg), "invalid overrides for tenant 1234: invalid query time zone")
+}
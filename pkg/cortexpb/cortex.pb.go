@@ -120,6 +120,8 @@ type WriteRequest struct {
 	Source                  WriteRequest_SourceEnum `protobuf:"varint,2,opt,name=Source,proto3,enum=cortexpb.WriteRequest_SourceEnum" json:"Source,omitempty"`
 	Metadata                []*MetricMetadata       `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty"`
 	SkipLabelNameValidation bool                    `protobuf:"varint,1000,opt,name=skip_label_name_validation,json=skipLabelNameValidation,proto3" json:"skip_label_name_validation,omitempty"`
+	// When true, the series are ephemeral: kept only in the ingesters memory and never shipped to the storage.
+	Ephemeral bool `protobuf:"varint,1001,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
 }
 
 func (m *WriteRequest) Reset()      { *m = WriteRequest{} }
@@ -175,7 +177,17 @@ func (m *WriteRequest) GetSkipLabelNameValidation() bool {
 	return false
 }
 
+func (m *WriteRequest) GetEphemeral() bool {
+	if m != nil {
+		return m.Ephemeral
+	}
+	return false
+}
+
 type WriteResponse struct {
+	// When greater than 1, only 1 in sampling_factor samples of each series has been ingested,
+	// because the tenant exceeded its ingestion rate limit.
+	SamplingFactor int32 `protobuf:"varint,1,opt,name=sampling_factor,json=samplingFactor,proto3" json:"sampling_factor,omitempty"`
 }
 
 func (m *WriteResponse) Reset()      { *m = WriteResponse{} }
@@ -210,12 +222,22 @@ func (m *WriteResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_WriteResponse proto.InternalMessageInfo
 
+func (m *WriteResponse) GetSamplingFactor() int32 {
+	if m != nil {
+		return m.SamplingFactor
+	}
+	return 0
+}
+
 type TimeSeries struct {
 	Labels []LabelAdapter `protobuf:"bytes,1,rep,name=labels,proto3,customtype=LabelAdapter" json:"labels"`
 	// Sorted by time, oldest sample first.
 	Samples    []Sample    `protobuf:"bytes,2,rep,name=samples,proto3" json:"samples"`
 	Exemplars  []Exemplar  `protobuf:"bytes,3,rep,name=exemplars,proto3" json:"exemplars"`
 	Histograms []Histogram `protobuf:"bytes,4,rep,name=histograms,proto3" json:"histograms"`
+	// The timestamp, in milliseconds, at which the counter series has been created or last reset.
+	// Zero when unknown.
+	CreatedTimestampMs int64 `protobuf:"varint,5,opt,name=created_timestamp_ms,json=createdTimestampMs,proto3" json:"created_timestamp_ms,omitempty"`
 }
 
 func (m *TimeSeries) Reset()      { *m = TimeSeries{} }
@@ -271,6 +293,13 @@ func (m *TimeSeries) GetHistograms() []Histogram {
 	return nil
 }
 
+func (m *TimeSeries) GetCreatedTimestampMs() int64 {
+	if m != nil {
+		return m.CreatedTimestampMs
+	}
+	return 0
+}
+
 type LabelPair struct {
 	Name  []byte `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
@@ -537,7 +566,6 @@ func (m *Exemplar) GetTimestampMs() int64 {
 // integer histogram as well as a float histogram.
 type Histogram struct {
 	// Types that are valid to be assigned to Count:
-	//
 	//	*Histogram_CountInt
 	//	*Histogram_CountFloat
 	Count isHistogram_Count `protobuf_oneof:"count"`
@@ -552,7 +580,6 @@ type Histogram struct {
 	Schema        int32   `protobuf:"zigzag32,4,opt,name=schema,proto3" json:"schema,omitempty"`
 	ZeroThreshold float64 `protobuf:"fixed64,5,opt,name=zero_threshold,json=zeroThreshold,proto3" json:"zero_threshold,omitempty"`
 	// Types that are valid to be assigned to ZeroCount:
-	//
 	//	*Histogram_ZeroCountInt
 	//	*Histogram_ZeroCountFloat
 	ZeroCount isHistogram_ZeroCount `protobuf_oneof:"zero_count"`
@@ -842,72 +869,75 @@ func init() {
 func init() { proto.RegisterFile("cortex.proto", fileDescriptor_893a47d0a749d749) }
 
 var fileDescriptor_893a47d0a749d749 = []byte{
-	// 1031 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4b, 0x6f, 0x23, 0x45,
-	0x17, 0xed, 0x72, 0xfb, 0x79, 0x63, 0x3b, 0x3d, 0xf5, 0x45, 0x1f, 0xad, 0x48, 0xd3, 0x71, 0x1a,
-	0x01, 0x16, 0x42, 0x01, 0x05, 0x01, 0x9a, 0x51, 0x84, 0x64, 0x0f, 0xce, 0x43, 0x33, 0x76, 0xa2,
-	0xb2, 0xc3, 0x68, 0xd8, 0x58, 0x15, 0xa7, 0x12, 0xb7, 0xa6, 0x5f, 0x74, 0x95, 0xa3, 0x09, 0x2b,
-	0x56, 0x88, 0x25, 0x6b, 0xb6, 0x6c, 0xf8, 0x05, 0xfc, 0x86, 0x2c, 0xb3, 0x1c, 0xb1, 0x88, 0x88,
-	0xb3, 0x99, 0xe5, 0x2c, 0xf8, 0x01, 0xa8, 0xaa, 0x5f, 0xce, 0x84, 0x11, 0x9b, 0xd9, 0x55, 0x9d,
-	0x7b, 0xcf, 0xbd, 0xa7, 0xea, 0x9e, 0x2e, 0x35, 0xd4, 0x27, 0x41, 0x24, 0xd8, 0x8b, 0x8d, 0x30,
-	0x0a, 0x44, 0x80, 0xab, 0xf1, 0x2e, 0x3c, 0x5a, 0x5d, 0x39, 0x0d, 0x4e, 0x03, 0x05, 0x7e, 0x2a,
-	0x57, 0x71, 0xdc, 0xfe, 0xa3, 0x00, 0xf5, 0xa7, 0x91, 0x23, 0x18, 0x61, 0xdf, 0xcf, 0x18, 0x17,
-	0xf8, 0x00, 0x40, 0x38, 0x1e, 0xe3, 0x2c, 0x72, 0x18, 0x37, 0x51, 0x4b, 0x6f, 0x2f, 0x6d, 0xae,
-	0x6c, 0xa4, 0x55, 0x36, 0x46, 0x8e, 0xc7, 0x86, 0x2a, 0xd6, 0x5d, 0xbd, 0xb8, 0x5a, 0xd3, 0xfe,
-	0xbc, 0x5a, 0xc3, 0x07, 0x11, 0xa3, 0xae, 0x1b, 0x4c, 0x46, 0x19, 0x8f, 0x2c, 0xd4, 0xc0, 0x0f,
-	0xa0, 0x3c, 0x0c, 0x66, 0xd1, 0x84, 0x99, 0x85, 0x16, 0x6a, 0x37, 0x37, 0xd7, 0xf3, 0x6a, 0x8b,
-	0x9d, 0x37, 0xe2, 0xa4, 0x9e, 0x3f, 0xf3, 0x48, 0x42, 0xc0, 0x0f, 0xa1, 0xea, 0x31, 0x41, 0x8f,
-	0xa9, 0xa0, 0xa6, 0xae, 0xa4, 0x98, 0x39, 0xb9, 0xcf, 0x44, 0xe4, 0x4c, 0xfa, 0x49, 0xbc, 0x5b,
-	0xbc, 0xb8, 0x5a, 0x43, 0x24, 0xcb, 0xc7, 0x5b, 0xb0, 0xca, 0x9f, 0x3b, 0xe1, 0xd8, 0xa5, 0x47,
-	0xcc, 0x1d, 0xfb, 0xd4, 0x63, 0xe3, 0x33, 0xea, 0x3a, 0xc7, 0x54, 0x38, 0x81, 0x6f, 0xbe, 0xaa,
-	0xb4, 0x50, 0xbb, 0x4a, 0xde, 0x93, 0x29, 0x4f, 0x64, 0xc6, 0x80, 0x7a, 0xec, 0xdb, 0x2c, 0x6e,
-	0xaf, 0x01, 0xe4, 0x7a, 0x70, 0x05, 0xf4, 0xce, 0xc1, 0x9e, 0xa1, 0xe1, 0x2a, 0x14, 0xc9, 0xe1,
-	0x93, 0x9e, 0x81, 0xec, 0x65, 0x68, 0x24, 0xea, 0x79, 0x18, 0xf8, 0x9c, 0xd9, 0x7f, 0x23, 0x80,
-	0xfc, 0x76, 0x70, 0x07, 0xca, 0xaa, 0x73, 0x7a, 0x87, 0xff, 0xcb, 0x85, 0xab, 0x7e, 0x07, 0xd4,
-	0x89, 0xba, 0x2b, 0xc9, 0x15, 0xd6, 0x15, 0xd4, 0x39, 0xa6, 0xa1, 0x60, 0x11, 0x49, 0x88, 0xf8,
-	0x33, 0xa8, 0x70, 0xea, 0x85, 0x2e, 0xe3, 0x66, 0x41, 0xd5, 0x30, 0xf2, 0x1a, 0x43, 0x15, 0x50,
-	0x87, 0xd6, 0x48, 0x9a, 0x86, 0xbf, 0x84, 0x1a, 0x7b, 0xc1, 0xbc, 0xd0, 0xa5, 0x11, 0x4f, 0x2e,
-	0x0c, 0xe7, 0x9c, 0x5e, 0x12, 0x4a, 0x58, 0x79, 0x2a, 0x7e, 0x00, 0x30, 0x75, 0xb8, 0x08, 0x4e,
-	0x23, 0xea, 0x71, 0xb3, 0xf8, 0xa6, 0xe0, 0xdd, 0x34, 0x96, 0x30, 0x17, 0x92, 0xed, 0x2f, 0xa0,
-	0x96, 0x9d, 0x07, 0x63, 0x28, 0xca, 0x8b, 0x36, 0x51, 0x0b, 0xb5, 0xeb, 0x44, 0xad, 0xf1, 0x0a,
-	0x94, 0xce, 0xa8, 0x3b, 0x8b, 0xa7, 0x5f, 0x27, 0xf1, 0xc6, 0xee, 0x40, 0x39, 0x3e, 0x42, 0x1e,
-	0x97, 0x24, 0x94, 0xc4, 0xf1, 0x3a, 0xd4, 0x95, 0x85, 0x04, 0xf5, 0xc2, 0xb1, 0xc7, 0x15, 0x59,
-	0x27, 0x4b, 0x19, 0xd6, 0xe7, 0xf6, 0xaf, 0x05, 0x68, 0xde, 0xf6, 0x00, 0xfe, 0x0a, 0x8a, 0xe2,
-	0x3c, 0x8c, 0x4b, 0x35, 0x37, 0xdf, 0x7f, 0x9b, 0x57, 0x92, 0xed, 0xe8, 0x3c, 0x64, 0x44, 0x11,
-	0xf0, 0x27, 0x80, 0x3d, 0x85, 0x8d, 0x4f, 0xa8, 0xe7, 0xb8, 0xe7, 0xca, 0x2f, 0xaa, 0x69, 0x8d,
-	0x18, 0x71, 0x64, 0x5b, 0x05, 0xa4, 0x4d, 0xe4, 0x31, 0xa7, 0xcc, 0x0d, 0xcd, 0xa2, 0x8a, 0xab,
-	0xb5, 0xc4, 0x66, 0xbe, 0x23, 0xcc, 0x52, 0x8c, 0xc9, 0xb5, 0x7d, 0x0e, 0x90, 0x77, 0xc2, 0x4b,
-	0x50, 0x39, 0x1c, 0x3c, 0x1e, 0xec, 0x3f, 0x1d, 0x18, 0x9a, 0xdc, 0x3c, 0xda, 0x3f, 0x1c, 0x8c,
-	0x7a, 0xc4, 0x40, 0xb8, 0x06, 0xa5, 0x9d, 0xce, 0xe1, 0x4e, 0xcf, 0x28, 0xe0, 0x06, 0xd4, 0x76,
-	0xf7, 0x86, 0xa3, 0xfd, 0x1d, 0xd2, 0xe9, 0x1b, 0x3a, 0xc6, 0xd0, 0x54, 0x91, 0x1c, 0x2b, 0x4a,
-	0xea, 0xf0, 0xb0, 0xdf, 0xef, 0x90, 0x67, 0x46, 0x49, 0x1a, 0x72, 0x6f, 0xb0, 0xbd, 0x6f, 0x94,
-	0x71, 0x1d, 0xaa, 0xc3, 0x51, 0x67, 0xd4, 0x1b, 0xf6, 0x46, 0x46, 0xc5, 0x7e, 0x0c, 0xe5, 0xb8,
-	0xf5, 0x3b, 0x30, 0xa2, 0xfd, 0x13, 0x82, 0x6a, 0x6a, 0x9e, 0x77, 0x61, 0xec, 0x5b, 0x96, 0x78,
-	0xeb, 0xc8, 0xf5, 0xbb, 0x23, 0xbf, 0x2c, 0x41, 0x2d, 0x33, 0x23, 0xbe, 0x0f, 0xb5, 0x49, 0x30,
-	0xf3, 0xc5, 0xd8, 0xf1, 0x85, 0x1a, 0x79, 0x71, 0x57, 0x23, 0x55, 0x05, 0xed, 0xf9, 0x02, 0xaf,
-	0xc3, 0x52, 0x1c, 0x3e, 0x71, 0x03, 0x2a, 0xe2, 0x5e, 0xbb, 0x1a, 0x01, 0x05, 0x6e, 0x4b, 0x0c,
-	0x1b, 0xa0, 0xf3, 0x99, 0xa7, 0x3a, 0x21, 0x22, 0x97, 0xf8, 0xff, 0x50, 0xe6, 0x93, 0x29, 0xf3,
-	0xa8, 0x1a, 0xee, 0x3d, 0x92, 0xec, 0xf0, 0x07, 0xd0, 0xfc, 0x81, 0x45, 0xc1, 0x58, 0x4c, 0x23,
-	0xc6, 0xa7, 0x81, 0x7b, 0xac, 0x06, 0x8d, 0x48, 0x43, 0xa2, 0xa3, 0x14, 0xc4, 0x1f, 0x26, 0x69,
-	0xb9, 0xae, 0xb2, 0xd2, 0x85, 0x48, 0x5d, 0xe2, 0x8f, 0x52, 0x6d, 0x1f, 0x83, 0xb1, 0x90, 0x17,
-	0x0b, 0xac, 0x28, 0x81, 0x88, 0x34, 0xb3, 0xcc, 0x58, 0x64, 0x07, 0x9a, 0x3e, 0x3b, 0xa5, 0xc2,
-	0x39, 0x63, 0x63, 0x1e, 0x52, 0x9f, 0x9b, 0xd5, 0x37, 0x5f, 0xe5, 0xee, 0x6c, 0xf2, 0x9c, 0x89,
-	0x61, 0x48, 0xfd, 0xe4, 0x0b, 0x6d, 0xa4, 0x0c, 0x89, 0x71, 0xfc, 0x11, 0x2c, 0x67, 0x25, 0x8e,
-	0x99, 0x2b, 0x28, 0x37, 0x6b, 0x2d, 0xbd, 0x8d, 0x49, 0x56, 0xf9, 0x1b, 0x85, 0xde, 0x4a, 0x54,
-	0xda, 0xb8, 0x09, 0x2d, 0xbd, 0x8d, 0xf2, 0x44, 0x25, 0x4c, 0x3e, 0x6f, 0xcd, 0x30, 0xe0, 0xce,
-	0x82, 0xa8, 0xa5, 0xff, 0x16, 0x95, 0x32, 0x32, 0x51, 0x59, 0x89, 0x44, 0x54, 0x3d, 0x16, 0x95,
-	0xc2, 0xb9, 0xa8, 0x2c, 0x31, 0x11, 0xd5, 0x88, 0x45, 0xa5, 0x70, 0x22, 0x6a, 0x0b, 0x20, 0x62,
-	0x9c, 0x89, 0xf1, 0x54, 0xde, 0x7c, 0x53, 0x3d, 0x02, 0xf7, 0xff, 0xe5, 0x19, 0xdb, 0x20, 0x32,
-	0x6b, 0xd7, 0xf1, 0x05, 0xa9, 0x45, 0xe9, 0xf2, 0x8e, 0xff, 0x96, 0xef, 0xfa, 0xef, 0x21, 0xd4,
-	0x32, 0xea, 0xed, 0xef, 0xb9, 0x02, 0xfa, 0xb3, 0xde, 0xd0, 0x40, 0xb8, 0x0c, 0x85, 0xc1, 0xbe,
-	0x51, 0xc8, 0xbf, 0x69, 0x7d, 0xb5, 0xf8, 0xf3, 0x6f, 0x16, 0xea, 0x56, 0xa0, 0xa4, 0xc4, 0x77,
-	0xeb, 0x00, 0xf9, 0xec, 0xed, 0x2d, 0x80, 0xfc, 0xa2, 0xa4, 0xfd, 0x82, 0x93, 0x13, 0xce, 0x62,
-	0x3f, 0xdf, 0x23, 0xc9, 0x4e, 0xe2, 0x2e, 0xf3, 0x4f, 0xc5, 0x54, 0xd9, 0xb8, 0x41, 0x92, 0x5d,
-	0xf7, 0xeb, 0xcb, 0x6b, 0x4b, 0x7b, 0x79, 0x6d, 0x69, 0xaf, 0xaf, 0x2d, 0xf4, 0xe3, 0xdc, 0x42,
-	0xbf, 0xcf, 0x2d, 0x74, 0x31, 0xb7, 0xd0, 0xe5, 0xdc, 0x42, 0x7f, 0xcd, 0x2d, 0xf4, 0x6a, 0x6e,
-	0x69, 0xaf, 0xe7, 0x16, 0xfa, 0xe5, 0xc6, 0xd2, 0x2e, 0x6f, 0x2c, 0xed, 0xe5, 0x8d, 0xa5, 0x7d,
-	0x97, 0xfd, 0x14, 0x1c, 0x95, 0xd5, 0x5f, 0xc0, 0xe7, 0xff, 0x04, 0x00, 0x00, 0xff, 0xff, 0x4b,
-	0xb6, 0xdb, 0xd4, 0x35, 0x08, 0x00, 0x00,
+	// 1083 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4b, 0x6f, 0x5b, 0x45,
+	0x14, 0xbe, 0xe3, 0xb7, 0x4f, 0x6c, 0xf7, 0x76, 0x88, 0xe0, 0x2a, 0x52, 0x6f, 0x5c, 0x23, 0xc0,
+	0x42, 0x28, 0x54, 0x41, 0x3c, 0x5a, 0x45, 0x48, 0x76, 0x71, 0x1e, 0x6a, 0xed, 0x44, 0x63, 0x87,
+	0xaa, 0x6c, 0xae, 0x26, 0xf6, 0xc4, 0xbe, 0xea, 0x7d, 0x71, 0x67, 0x1c, 0x35, 0xac, 0x58, 0x21,
+	0x96, 0xac, 0xd9, 0x22, 0x21, 0x7e, 0x4a, 0x96, 0xd9, 0x20, 0x55, 0x2c, 0x22, 0xe2, 0x6c, 0xca,
+	0xae, 0x3f, 0x01, 0xcd, 0xdc, 0x97, 0xd3, 0x50, 0xb1, 0xe9, 0x6e, 0xe6, 0xfb, 0xce, 0x39, 0xf3,
+	0xdd, 0x73, 0xbe, 0x19, 0x1b, 0x6a, 0x63, 0x3f, 0x14, 0xec, 0xf9, 0x46, 0x10, 0xfa, 0xc2, 0xc7,
+	0x95, 0x68, 0x17, 0x1c, 0xad, 0xad, 0x4e, 0xfd, 0xa9, 0xaf, 0xc0, 0x4f, 0xe5, 0x2a, 0xe2, 0x5b,
+	0x7f, 0xe6, 0xa0, 0xf6, 0x24, 0xb4, 0x05, 0x23, 0xec, 0xfb, 0x39, 0xe3, 0x02, 0x1f, 0x00, 0x08,
+	0xdb, 0x65, 0x9c, 0x85, 0x36, 0xe3, 0x06, 0x6a, 0xe6, 0xdb, 0x2b, 0x9b, 0xab, 0x1b, 0x49, 0x95,
+	0x8d, 0x91, 0xed, 0xb2, 0xa1, 0xe2, 0xba, 0x6b, 0x67, 0x17, 0xeb, 0xda, 0x5f, 0x17, 0xeb, 0xf8,
+	0x20, 0x64, 0xd4, 0x71, 0xfc, 0xf1, 0x28, 0xcd, 0x23, 0x4b, 0x35, 0xf0, 0x7d, 0x28, 0x0d, 0xfd,
+	0x79, 0x38, 0x66, 0x46, 0xae, 0x89, 0xda, 0x8d, 0xcd, 0xbb, 0x59, 0xb5, 0xe5, 0x93, 0x37, 0xa2,
+	0xa0, 0x9e, 0x37, 0x77, 0x49, 0x9c, 0x80, 0x1f, 0x40, 0xc5, 0x65, 0x82, 0x4e, 0xa8, 0xa0, 0x46,
+	0x5e, 0x49, 0x31, 0xb2, 0xe4, 0x3e, 0x13, 0xa1, 0x3d, 0xee, 0xc7, 0x7c, 0xb7, 0x70, 0x76, 0xb1,
+	0x8e, 0x48, 0x1a, 0x8f, 0xb7, 0x60, 0x8d, 0x3f, 0xb3, 0x03, 0xcb, 0xa1, 0x47, 0xcc, 0xb1, 0x3c,
+	0xea, 0x32, 0xeb, 0x84, 0x3a, 0xf6, 0x84, 0x0a, 0xdb, 0xf7, 0x8c, 0x97, 0xe5, 0x26, 0x6a, 0x57,
+	0xc8, 0x7b, 0x32, 0xe4, 0xb1, 0x8c, 0x18, 0x50, 0x97, 0x7d, 0x9b, 0xf2, 0xf8, 0x0e, 0x54, 0x59,
+	0x30, 0x63, 0x2e, 0x0b, 0xa9, 0x63, 0xfc, 0x13, 0x05, 0x67, 0x48, 0x6b, 0x1d, 0x20, 0x93, 0x8b,
+	0xcb, 0x90, 0xef, 0x1c, 0xec, 0xe9, 0x1a, 0xae, 0x40, 0x81, 0x1c, 0x3e, 0xee, 0xe9, 0xa8, 0xf5,
+	0x15, 0xd4, 0xe3, 0x8f, 0xe3, 0x81, 0xef, 0x71, 0x86, 0x3f, 0x82, 0x5b, 0x9c, 0xba, 0x81, 0x63,
+	0x7b, 0x53, 0xeb, 0x98, 0x8e, 0x85, 0x1f, 0x1a, 0xa8, 0x89, 0xda, 0x45, 0xd2, 0x48, 0xe0, 0x6d,
+	0x85, 0xb6, 0x7e, 0xcf, 0x01, 0x64, 0x5d, 0xc6, 0x1d, 0x28, 0xa9, 0x2f, 0x48, 0x66, 0xf1, 0x4e,
+	0xd6, 0x00, 0xa5, 0xfb, 0x80, 0xda, 0x61, 0x77, 0x35, 0x1e, 0x45, 0x4d, 0x41, 0x9d, 0x09, 0x0d,
+	0x04, 0x0b, 0x49, 0x9c, 0x88, 0xef, 0x41, 0x59, 0x9d, 0xc1, 0xb8, 0x91, 0x53, 0x35, 0xf4, 0xac,
+	0xc6, 0x50, 0x11, 0xaa, 0x79, 0x1a, 0x49, 0xc2, 0xf0, 0x17, 0x50, 0x65, 0xcf, 0x99, 0x1b, 0x38,
+	0x34, 0xe4, 0x71, 0xe3, 0x71, 0x96, 0xd3, 0x8b, 0xa9, 0x38, 0x2b, 0x0b, 0xc5, 0xf7, 0x01, 0x66,
+	0x36, 0x17, 0xfe, 0x34, 0xa4, 0x2e, 0x37, 0x0a, 0xaf, 0x0b, 0xde, 0x4d, 0xb8, 0x38, 0x73, 0x29,
+	0x18, 0xdf, 0x83, 0xd5, 0x71, 0xc8, 0xa8, 0x60, 0x13, 0x4b, 0x79, 0x47, 0x50, 0x37, 0xb0, 0x5c,
+	0x6e, 0x14, 0x9b, 0xa8, 0x9d, 0x27, 0x38, 0xe6, 0x46, 0x09, 0xd5, 0xe7, 0xad, 0xcf, 0xa1, 0x9a,
+	0x76, 0x00, 0x63, 0x28, 0xc8, 0x11, 0xab, 0x9e, 0xd6, 0x88, 0x5a, 0xe3, 0x55, 0x28, 0x9e, 0x50,
+	0x67, 0x1e, 0xf9, 0xae, 0x46, 0xa2, 0x4d, 0xab, 0x03, 0xa5, 0xe8, 0xa3, 0x33, 0x5e, 0x26, 0xa1,
+	0x98, 0xc7, 0x77, 0xa1, 0x76, 0x4d, 0x40, 0x4e, 0x09, 0x58, 0x11, 0x4b, 0x27, 0xff, 0x9a, 0x83,
+	0xc6, 0x75, 0xf7, 0xe1, 0x2f, 0xa1, 0x20, 0x4e, 0x83, 0xa8, 0x54, 0x63, 0xf3, 0xfd, 0x37, 0xb9,
+	0x34, 0xde, 0x8e, 0x4e, 0x03, 0x46, 0x54, 0x02, 0xfe, 0x04, 0xb0, 0xab, 0x30, 0xeb, 0x98, 0xba,
+	0xb6, 0x73, 0xaa, 0x9c, 0xaa, 0x0e, 0xad, 0x12, 0x3d, 0x62, 0xb6, 0x15, 0x21, 0x0d, 0x2a, 0x3f,
+	0x73, 0xc6, 0x9c, 0xc0, 0x28, 0x28, 0x5e, 0xad, 0x25, 0x36, 0xf7, 0x6c, 0xa1, 0x3a, 0x55, 0x25,
+	0x6a, 0xdd, 0x3a, 0x05, 0xc8, 0x4e, 0xc2, 0x2b, 0x50, 0x3e, 0x1c, 0x3c, 0x1a, 0xec, 0x3f, 0x19,
+	0xe8, 0x9a, 0xdc, 0x3c, 0xdc, 0x3f, 0x1c, 0x8c, 0x7a, 0x44, 0x47, 0xb8, 0x0a, 0xc5, 0x9d, 0xce,
+	0xe1, 0x4e, 0x4f, 0xcf, 0xe1, 0x3a, 0x54, 0x77, 0xf7, 0x86, 0xa3, 0xfd, 0x1d, 0xd2, 0xe9, 0xeb,
+	0x79, 0x8c, 0xa1, 0xa1, 0x98, 0x0c, 0x2b, 0xc8, 0xd4, 0xe1, 0x61, 0xbf, 0xdf, 0x21, 0x4f, 0xf5,
+	0xa2, 0xf4, 0xfa, 0xde, 0x60, 0x7b, 0x5f, 0x2f, 0xe1, 0x1a, 0x54, 0x86, 0xa3, 0xce, 0xa8, 0x37,
+	0xec, 0x8d, 0xf4, 0x72, 0xeb, 0x11, 0x94, 0xa2, 0xa3, 0xdf, 0x82, 0x75, 0x5b, 0x3f, 0x21, 0xa8,
+	0x24, 0x76, 0x7b, 0x1b, 0x57, 0xe1, 0x9a, 0x25, 0xde, 0x38, 0xf2, 0xfc, 0xcd, 0x91, 0x9f, 0x17,
+	0xa1, 0x9a, 0xda, 0x57, 0xbe, 0x0e, 0x63, 0x7f, 0xee, 0x09, 0xcb, 0xf6, 0x84, 0x1a, 0x79, 0x61,
+	0x57, 0x23, 0x15, 0x05, 0xed, 0x79, 0x02, 0xdf, 0x85, 0x95, 0x88, 0x3e, 0x76, 0x7c, 0x2a, 0xa2,
+	0xb3, 0x76, 0x35, 0x02, 0x0a, 0xdc, 0x96, 0x18, 0xd6, 0x21, 0xcf, 0xe7, 0xae, 0x3a, 0x09, 0x11,
+	0xb9, 0xc4, 0xef, 0x42, 0x89, 0x8f, 0x67, 0xcc, 0xa5, 0x6a, 0xb8, 0xb7, 0x49, 0xbc, 0xc3, 0x1f,
+	0x40, 0xe3, 0x07, 0x16, 0xfa, 0x96, 0x98, 0x85, 0x8c, 0xcf, 0x7c, 0x67, 0xa2, 0x06, 0x8d, 0x48,
+	0x5d, 0xa2, 0xa3, 0x04, 0xc4, 0x1f, 0xc6, 0x61, 0x99, 0xae, 0x92, 0xd2, 0x85, 0x48, 0x4d, 0xe2,
+	0x0f, 0x13, 0x6d, 0x1f, 0x83, 0xbe, 0x14, 0x17, 0x09, 0x2c, 0x2b, 0x81, 0x88, 0x34, 0xd2, 0xc8,
+	0x48, 0x64, 0x07, 0x1a, 0x1e, 0x9b, 0x52, 0x61, 0x9f, 0x30, 0x8b, 0x07, 0xd4, 0xe3, 0x46, 0xe5,
+	0xf5, 0xdf, 0x83, 0xee, 0x7c, 0xfc, 0x8c, 0x89, 0x61, 0x40, 0xbd, 0xf8, 0x4e, 0xd7, 0x93, 0x0c,
+	0x89, 0x71, 0xf9, 0xec, 0xa5, 0x25, 0x26, 0xcc, 0x11, 0x94, 0x1b, 0xd5, 0x66, 0xbe, 0x8d, 0x49,
+	0x5a, 0xf9, 0x1b, 0x85, 0x5e, 0x0b, 0x54, 0xda, 0xb8, 0x01, 0xcd, 0x7c, 0x1b, 0x65, 0x81, 0x4a,
+	0x98, 0x7c, 0x10, 0x1b, 0x81, 0xcf, 0xed, 0x25, 0x51, 0x2b, 0xff, 0x2f, 0x2a, 0xc9, 0x48, 0x45,
+	0xa5, 0x25, 0x62, 0x51, 0xb5, 0x48, 0x54, 0x02, 0x67, 0xa2, 0xd2, 0xc0, 0x58, 0x54, 0x3d, 0x12,
+	0x95, 0xc0, 0xb1, 0xa8, 0x2d, 0x80, 0x90, 0x71, 0x26, 0xac, 0x99, 0xec, 0x7c, 0x43, 0x3d, 0x02,
+	0x77, 0xfe, 0xe3, 0xe1, 0xdb, 0x20, 0x32, 0x6a, 0xd7, 0xf6, 0x04, 0xa9, 0x86, 0xc9, 0xf2, 0x86,
+	0xff, 0x6e, 0xdd, 0xf4, 0xdf, 0x03, 0xa8, 0xa6, 0xa9, 0xd7, 0xef, 0x73, 0x19, 0xf2, 0x4f, 0x7b,
+	0x43, 0x1d, 0xe1, 0x12, 0xe4, 0x06, 0xfb, 0x7a, 0x2e, 0xbb, 0xd3, 0xf9, 0xb5, 0xc2, 0xcf, 0xbf,
+	0x99, 0xa8, 0x5b, 0x86, 0xa2, 0x12, 0xdf, 0xad, 0x01, 0x64, 0xb3, 0x6f, 0x6d, 0x01, 0x64, 0x8d,
+	0x92, 0xf6, 0xf3, 0x8f, 0x8f, 0x39, 0x8b, 0xfc, 0x7c, 0x9b, 0xc4, 0x3b, 0x89, 0x3b, 0xcc, 0x9b,
+	0x8a, 0x99, 0xb2, 0x71, 0x9d, 0xc4, 0xbb, 0xee, 0xd7, 0xe7, 0x97, 0xa6, 0xf6, 0xe2, 0xd2, 0xd4,
+	0x5e, 0x5d, 0x9a, 0xe8, 0xc7, 0x85, 0x89, 0xfe, 0x58, 0x98, 0xe8, 0x6c, 0x61, 0xa2, 0xf3, 0x85,
+	0x89, 0xfe, 0x5e, 0x98, 0xe8, 0xe5, 0xc2, 0xd4, 0x5e, 0x2d, 0x4c, 0xf4, 0xcb, 0x95, 0xa9, 0x9d,
+	0x5f, 0x99, 0xda, 0x8b, 0x2b, 0x53, 0xfb, 0x2e, 0xfd, 0x3b, 0x72, 0x54, 0x52, 0xff, 0x3f, 0x3e,
+	0xfb, 0x77, 0x00, 0xff, 0x90, 0xd3, 0x79, 0xaf, 0x08, 0x00, 0x00,
 }
 
 func (x WriteRequest_SourceEnum) String() string {
@@ -972,6 +1002,9 @@ func (this *WriteRequest) Equal(that interface{}) bool {
 	if this.SkipLabelNameValidation != that1.SkipLabelNameValidation {
 		return false
 	}
+	if this.Ephemeral != that1.Ephemeral {
+		return false
+	}
 	return true
 }
 func (this *WriteResponse) Equal(that interface{}) bool {
@@ -993,6 +1026,9 @@ func (this *WriteResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
+	if this.SamplingFactor != that1.SamplingFactor {
+		return false
+	}
 	return true
 }
 func (this *TimeSeries) Equal(that interface{}) bool {
@@ -1046,6 +1082,9 @@ func (this *TimeSeries) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.CreatedTimestampMs != that1.CreatedTimestampMs {
+		return false
+	}
 	return true
 }
 func (this *LabelPair) Equal(that interface{}) bool {
@@ -1428,7 +1467,7 @@ func (this *WriteRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&cortexpb.WriteRequest{")
 	s = append(s, "Timeseries: "+fmt.Sprintf("%#v", this.Timeseries)+",\n")
 	s = append(s, "Source: "+fmt.Sprintf("%#v", this.Source)+",\n")
@@ -1436,6 +1475,7 @@ func (this *WriteRequest) GoString() string {
 		s = append(s, "Metadata: "+fmt.Sprintf("%#v", this.Metadata)+",\n")
 	}
 	s = append(s, "SkipLabelNameValidation: "+fmt.Sprintf("%#v", this.SkipLabelNameValidation)+",\n")
+	s = append(s, "Ephemeral: "+fmt.Sprintf("%#v", this.Ephemeral)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1443,8 +1483,9 @@ func (this *WriteResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 4)
+	s := make([]string, 0, 5)
 	s = append(s, "&cortexpb.WriteResponse{")
+	s = append(s, "SamplingFactor: "+fmt.Sprintf("%#v", this.SamplingFactor)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1452,7 +1493,7 @@ func (this *TimeSeries) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&cortexpb.TimeSeries{")
 	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
 	if this.Samples != nil {
@@ -1476,6 +1517,7 @@ func (this *TimeSeries) GoString() string {
 		}
 		s = append(s, "Histograms: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
+	s = append(s, "CreatedTimestampMs: "+fmt.Sprintf("%#v", this.CreatedTimestampMs)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1645,6 +1687,18 @@ func (m *WriteRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Ephemeral {
+		i--
+		if m.Ephemeral {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x3e
+		i--
+		dAtA[i] = 0xc8
+	}
 	if m.SkipLabelNameValidation {
 		i--
 		if m.SkipLabelNameValidation {
@@ -1713,6 +1767,11 @@ func (m *WriteResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.SamplingFactor != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.SamplingFactor))
+		i--
+		dAtA[i] = 0x8
+	}
 	return len(dAtA) - i, nil
 }
 
@@ -1736,6 +1795,11 @@ func (m *TimeSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.CreatedTimestampMs != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.CreatedTimestampMs))
+		i--
+		dAtA[i] = 0x28
+	}
 	if len(m.Histograms) > 0 {
 		for iNdEx := len(m.Histograms) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -2268,6 +2332,9 @@ func (m *WriteRequest) Size() (n int) {
 	if m.SkipLabelNameValidation {
 		n += 3
 	}
+	if m.Ephemeral {
+		n += 3
+	}
 	return n
 }
 
@@ -2277,6 +2344,9 @@ func (m *WriteResponse) Size() (n int) {
 	}
 	var l int
 	_ = l
+	if m.SamplingFactor != 0 {
+		n += 1 + sovCortex(uint64(m.SamplingFactor))
+	}
 	return n
 }
 
@@ -2310,6 +2380,9 @@ func (m *TimeSeries) Size() (n int) {
 			n += 1 + l + sovCortex(uint64(l))
 		}
 	}
+	if m.CreatedTimestampMs != 0 {
+		n += 1 + sovCortex(uint64(m.CreatedTimestampMs))
+	}
 	return n
 }
 
@@ -2538,6 +2611,7 @@ func (this *WriteRequest) String() string {
 		`Source:` + fmt.Sprintf("%v", this.Source) + `,`,
 		`Metadata:` + repeatedStringForMetadata + `,`,
 		`SkipLabelNameValidation:` + fmt.Sprintf("%v", this.SkipLabelNameValidation) + `,`,
+		`Ephemeral:` + fmt.Sprintf("%v", this.Ephemeral) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2547,6 +2621,7 @@ func (this *WriteResponse) String() string {
 		return "nil"
 	}
 	s := strings.Join([]string{`&WriteResponse{`,
+		`SamplingFactor:` + fmt.Sprintf("%v", this.SamplingFactor) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2575,6 +2650,7 @@ func (this *TimeSeries) String() string {
 		`Samples:` + repeatedStringForSamples + `,`,
 		`Exemplars:` + repeatedStringForExemplars + `,`,
 		`Histograms:` + repeatedStringForHistograms + `,`,
+		`CreatedTimestampMs:` + fmt.Sprintf("%v", this.CreatedTimestampMs) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2863,6 +2939,26 @@ func (m *WriteRequest) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.SkipLabelNameValidation = bool(v != 0)
+		case 1001:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ephemeral", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Ephemeral = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCortex(dAtA[iNdEx:])
@@ -2916,6 +3012,25 @@ func (m *WriteResponse) Unmarshal(dAtA []byte) error {
 			return fmt.Errorf("proto: WriteResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplingFactor", wireType)
+			}
+			m.SamplingFactor = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SamplingFactor |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCortex(dAtA[iNdEx:])
@@ -3105,6 +3220,25 @@ func (m *TimeSeries) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedTimestampMs", wireType)
+			}
+			m.CreatedTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreatedTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCortex(dAtA[iNdEx:])
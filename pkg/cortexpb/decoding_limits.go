@@ -0,0 +1,146 @@
+package cortexpb
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
+)
+
+// DecodingLimits are hard caps on the structures decoded from the write requests. They're checked on
+// the wire format of the requests, before the decoded structures are allocated, to protect from the
+// pathological payloads of malicious or buggy clients.
+type DecodingLimits struct {
+	MaxSeriesPerRequest   int `yaml:"max_series_per_request"`
+	MaxMetadataPerRequest int `yaml:"max_metadata_per_request"`
+	MaxLabelsPerSeries    int `yaml:"max_labels_per_series"`
+	MaxExemplarsPerSeries int `yaml:"max_exemplars_per_series"`
+}
+
+// RegisterFlagsWithPrefix registers the flags of the limits with the given prefix.
+func (l *DecodingLimits) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	f.IntVar(&l.MaxSeriesPerRequest, prefix+"max-series-per-request", 0, "Maximum number of series of a write request, checked before decoding the request. 0 to disable.")
+	f.IntVar(&l.MaxMetadataPerRequest, prefix+"max-metadata-per-request", 0, "Maximum number of metadata entries of a write request, checked before decoding the request. 0 to disable.")
+	f.IntVar(&l.MaxLabelsPerSeries, prefix+"max-labels-per-series", 0, "Maximum number of labels of each series of a write request, checked before decoding the request. Unlike the per-tenant -validation.max-label-names-per-series, this is a hard cap protecting the decoding of the request. 0 to disable.")
+	f.IntVar(&l.MaxExemplarsPerSeries, prefix+"max-exemplars-per-series", 0, "Maximum number of exemplars of each series of a write request, checked before decoding the request. 0 to disable.")
+}
+
+// DecodingLimitError is returned when a write request exceeds one of the decoding limits.
+type DecodingLimitError struct {
+	ID    globalerror.ID
+	Limit int
+	// What is exceeding the limit, like "series per request".
+	What string
+}
+
+func (e *DecodingLimitError) Error() string {
+	return fmt.Sprintf(errDecodingLimit, e.Limit, e.What)
+}
+
+const errDecodingLimit = "the write request exceeds the limit of %d %s"
+
+// decodingLimitsWhat is what exceeds each of the decoding limits.
+var decodingLimitsWhat = map[globalerror.ID]string{
+	globalerror.DecodingMaxSeriesPerRequest:   "series per request",
+	globalerror.DecodingMaxMetadataPerRequest: "metadata per request",
+	globalerror.DecodingMaxLabelsPerSeries:    "labels per series",
+	globalerror.DecodingMaxExemplarsPerSeries: "exemplars per series",
+}
+
+func init() {
+	// Register the messages of the decoding limit errors with their codes.
+	for id, what := range decodingLimitsWhat {
+		id.Message(strings.Replace(errDecodingLimit, "%s", what, 1))
+	}
+}
+
+func newDecodingLimitError(id globalerror.ID, limit int) error {
+	return &DecodingLimitError{ID: id, Limit: limit, What: decodingLimitsWhat[id]}
+}
+
+// Check returns a *DecodingLimitError if the encoded write request exceeds any of the limits.
+// It doesn't allocate, and returns an error if the request is malformed.
+func (l DecodingLimits) Check(dAtA []byte) error {
+	if l == (DecodingLimits{}) {
+		return nil
+	}
+
+	series, metadata := 0, 0
+	return forEachField(dAtA, func(num int, value []byte) error {
+		switch num {
+		case 1:
+			series++
+			if l.MaxSeriesPerRequest > 0 && series > l.MaxSeriesPerRequest {
+				return newDecodingLimitError(globalerror.DecodingMaxSeriesPerRequest, l.MaxSeriesPerRequest)
+			}
+			return l.checkSeries(value)
+		case 3:
+			metadata++
+			if l.MaxMetadataPerRequest > 0 && metadata > l.MaxMetadataPerRequest {
+				return newDecodingLimitError(globalerror.DecodingMaxMetadataPerRequest, l.MaxMetadataPerRequest)
+			}
+		}
+		return nil
+	})
+}
+
+func (l DecodingLimits) checkSeries(dAtA []byte) error {
+	if l.MaxLabelsPerSeries <= 0 && l.MaxExemplarsPerSeries <= 0 {
+		return nil
+	}
+
+	labels, exemplars := 0, 0
+	return forEachField(dAtA, func(num int, _ []byte) error {
+		switch num {
+		case 1:
+			labels++
+			if l.MaxLabelsPerSeries > 0 && labels > l.MaxLabelsPerSeries {
+				return newDecodingLimitError(globalerror.DecodingMaxLabelsPerSeries, l.MaxLabelsPerSeries)
+			}
+		case 3:
+			exemplars++
+			if l.MaxExemplarsPerSeries > 0 && exemplars > l.MaxExemplarsPerSeries {
+				return newDecodingLimitError(globalerror.DecodingMaxExemplarsPerSeries, l.MaxExemplarsPerSeries)
+			}
+		}
+		return nil
+	})
+}
+
+// forEachField calls fn with the number and the value of each length-delimited field of the encoded
+// message, skipping the other fields.
+func forEachField(dAtA []byte, fn func(num int, value []byte) error) error {
+	for i := 0; i < len(dAtA); {
+		tag, n := binary.Uvarint(dAtA[i:])
+		if n <= 0 {
+			return io.ErrUnexpectedEOF
+		}
+
+		if tag&0x7 != 2 {
+			skip, err := skipCortex(dAtA[i:])
+			if err != nil {
+				return err
+			}
+			i += skip
+			continue
+		}
+
+		length, m := binary.Uvarint(dAtA[i+n:])
+		if m <= 0 {
+			return io.ErrUnexpectedEOF
+		}
+		start := i + n + m
+		if length > uint64(len(dAtA)-start) {
+			return io.ErrUnexpectedEOF
+		}
+		end := start + int(length)
+		if err := fn(int(tag>>3), dAtA[start:end]); err != nil {
+			return err
+		}
+		i = end
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package cortexpb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
+)
+
+func TestDecodingLimits_Check(t *testing.T) {
+	series := func(labels, exemplars int) PreallocTimeseries {
+		ts := PreallocTimeseries{TimeSeries: &TimeSeries{
+			Samples:            []Sample{{TimestampMs: 1, Value: 1}},
+			CreatedTimestampMs: 1,
+		}}
+		for i := 0; i < labels; i++ {
+			ts.Labels = append(ts.Labels, LabelAdapter{Name: string(rune('a' + i)), Value: "value"})
+		}
+		for i := 0; i < exemplars; i++ {
+			ts.Exemplars = append(ts.Exemplars, Exemplar{TimestampMs: int64(i), Value: 1})
+		}
+		return ts
+	}
+	req := &WriteRequest{
+		Timeseries:              []PreallocTimeseries{series(3, 1), series(2, 2)},
+		Source:                  RULE,
+		Metadata:                []*MetricMetadata{{MetricFamilyName: "a"}, {MetricFamilyName: "b"}},
+		SkipLabelNameValidation: true,
+	}
+	dAtA, err := req.Marshal()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		limits     DecodingLimits
+		expectedID globalerror.ID
+	}{
+		"no limits": {},
+		"within the limits": {
+			limits: DecodingLimits{MaxSeriesPerRequest: 2, MaxMetadataPerRequest: 2, MaxLabelsPerSeries: 3, MaxExemplarsPerSeries: 2},
+		},
+		"too many series": {
+			limits:     DecodingLimits{MaxSeriesPerRequest: 1},
+			expectedID: globalerror.DecodingMaxSeriesPerRequest,
+		},
+		"too many metadata": {
+			limits:     DecodingLimits{MaxMetadataPerRequest: 1},
+			expectedID: globalerror.DecodingMaxMetadataPerRequest,
+		},
+		"too many labels": {
+			limits:     DecodingLimits{MaxLabelsPerSeries: 2},
+			expectedID: globalerror.DecodingMaxLabelsPerSeries,
+		},
+		"too many exemplars": {
+			limits:     DecodingLimits{MaxExemplarsPerSeries: 1},
+			expectedID: globalerror.DecodingMaxExemplarsPerSeries,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.limits.Check(dAtA)
+			if tc.expectedID == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var limitErr *DecodingLimitError
+			require.True(t, errors.As(err, &limitErr))
+			assert.Equal(t, tc.expectedID, limitErr.ID)
+		})
+	}
+}
+
+func TestDecodingLimits_Check_ShouldFailOnMalformedRequests(t *testing.T) {
+	req := &WriteRequest{Timeseries: []PreallocTimeseries{{TimeSeries: &TimeSeries{Labels: []LabelAdapter{{Name: "a", Value: "b"}}}}}}
+	dAtA, err := req.Marshal()
+	require.NoError(t, err)
+
+	limits := DecodingLimits{MaxSeriesPerRequest: 10}
+	require.NoError(t, limits.Check(dAtA))
+	// The length of the series exceeds the request.
+	assert.Error(t, limits.Check(dAtA[:len(dAtA)-1]))
+	// The tag is truncated.
+	assert.Error(t, limits.Check([]byte{0x80}))
+}
+
+func TestPreallocWriteRequest_Unmarshal_ShouldCheckTheDecodingLimits(t *testing.T) {
+	req := &WriteRequest{Timeseries: []PreallocTimeseries{
+		{TimeSeries: &TimeSeries{Labels: []LabelAdapter{{Name: "a", Value: "b"}}}},
+		{TimeSeries: &TimeSeries{Labels: []LabelAdapter{{Name: "a", Value: "c"}}}},
+	}}
+	dAtA, err := req.Marshal()
+	require.NoError(t, err)
+
+	decoded := PreallocWriteRequest{DecodingLimits: DecodingLimits{MaxSeriesPerRequest: 1}}
+	err = decoded.Unmarshal(dAtA)
+	require.Error(t, err)
+	assert.Equal(t, "the write request exceeds the limit of 1 series per request", err.Error())
+	assert.Empty(t, decoded.Timeseries)
+
+	decoded = PreallocWriteRequest{DecodingLimits: DecodingLimits{MaxSeriesPerRequest: 2}}
+	require.NoError(t, decoded.Unmarshal(dAtA))
+	assert.Len(t, decoded.Timeseries, 2)
+}
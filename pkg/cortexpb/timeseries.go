@@ -63,10 +63,16 @@ func (PreallocConfig) RegisterFlags(f *flag.FlagSet) {
 type PreallocWriteRequest struct {
 	WriteRequest
 	data *[]byte
+
+	// The limits checked on Unmarshal, before decoding the request.
+	DecodingLimits DecodingLimits
 }
 
 // Unmarshal implements proto.Message.
 func (p *PreallocWriteRequest) Unmarshal(dAtA []byte) error {
+	if err := p.DecodingLimits.Check(dAtA); err != nil {
+		return err
+	}
 	p.Timeseries = PreallocTimeseriesSliceFromPool()
 	return p.WriteRequest.Unmarshal(dAtA)
 }
@@ -99,6 +105,7 @@ func ReuseWriteRequest(req *PreallocWriteRequest) {
 		req.data = nil
 	}
 	req.Source = 0
+	req.Ephemeral = false
 	req.Metadata = nil
 	req.Timeseries = nil
 	writeRequestPool.Put(req)
@@ -347,5 +354,6 @@ func ReuseTimeseries(ts *TimeSeries) {
 
 	ts.Exemplars = ts.Exemplars[:0]
 	ts.Histograms = ts.Histograms[:0]
+	ts.CreatedTimestampMs = 0
 	timeSeriesPool.Put(ts)
 }
@@ -0,0 +1,84 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/distributor/distributorpb"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+// BatchPushConfig configures the push of batches of write requests of several tenants.
+type BatchPushConfig struct {
+	TrustedForwarders flagext.StringSliceCSV `yaml:"trusted_forwarders"`
+	MaxConcurrency    int                    `yaml:"max_concurrency"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *BatchPushConfig) RegisterFlags(f *flag.FlagSet) {
+	f.Var(&cfg.TrustedForwarders, "distributor.batch-push.trusted-forwarders", "Experimental: Comma separated list of the tenant IDs of the trusted forwarders, like the edge collectors, allowed to push batches of write requests of several tenants in a single PushBatch gRPC call. The batch push is disabled if empty.")
+	f.IntVar(&cfg.MaxConcurrency, "distributor.batch-push.max-concurrency", 8, "Maximum number of write requests of a batch pushed concurrently.")
+}
+
+// Validate validates the config.
+func (cfg *BatchPushConfig) Validate() error {
+	if len(cfg.TrustedForwarders) > 0 && cfg.MaxConcurrency <= 0 {
+		return errors.New("the batch push max concurrency must be greater than 0")
+	}
+	return nil
+}
+
+// PushBatch pushes the write requests of several tenants, sent in a single call by a trusted forwarder.
+// Each write request is validated and pushed like if it was sent by its tenant, and the failure of a
+// write request doesn't fail the others: the outcome of each of them is returned in the response.
+func (d *Distributor) PushBatch(ctx context.Context, req *distributorpb.BatchWriteRequest) (*distributorpb.BatchWriteResponse, error) {
+	forwarderID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !util.StringsContain(d.cfg.BatchPush.TrustedForwarders, forwarderID) {
+		return nil, httpgrpc.Errorf(http.StatusForbidden, "tenant %s is not a trusted forwarder allowed to push batches of write requests", forwarderID)
+	}
+
+	resp := &distributorpb.BatchWriteResponse{Responses: make([]distributorpb.TenantWriteResponse, len(req.Requests))}
+	jobs := make([]interface{}, 0, len(req.Requests))
+	for ix := range req.Requests {
+		jobs = append(jobs, ix)
+	}
+
+	err = concurrency.ForEach(ctx, jobs, d.cfg.BatchPush.MaxConcurrency, func(ctx context.Context, job interface{}) error {
+		ix := job.(int)
+		r := &req.Requests[ix]
+		resp.Responses[ix] = distributorpb.TenantWriteResponse{TenantId: r.TenantId, Code: http.StatusOK}
+
+		if err := tenant.ValidTenantID(r.TenantId); err != nil {
+			resp.Responses[ix].Code = http.StatusBadRequest
+			resp.Responses[ix].Error = err.Error()
+			return nil
+		}
+
+		if _, err := d.Push(user.InjectOrgID(ctx, r.TenantId), &r.Request); err != nil {
+			resp.Responses[ix].Code = http.StatusInternalServerError
+			if httpResp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+				resp.Responses[ix].Code = httpResp.Code
+				resp.Responses[ix].Error = string(httpResp.Body)
+			} else {
+				resp.Responses[ix].Error = err.Error()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
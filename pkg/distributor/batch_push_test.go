@@ -0,0 +1,70 @@
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/distributor/distributorpb"
+)
+
+func TestDistributor_PushBatch(t *testing.T) {
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:        3,
+		happyIngesters:      3,
+		numDistributors:     1,
+		shardByAllLabels:    true,
+		batchPushForwarders: []string{"edge-collector"},
+	})
+	d := ds[0]
+
+	req := &distributorpb.BatchWriteRequest{Requests: []distributorpb.TenantWriteRequest{
+		{TenantId: "user-1", Request: *mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "foo")}, 1, 100000)},
+		// Invalid label name.
+		{TenantId: "user-2", Request: *mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "foo", "0invalid", "value")}, 1, 100000)},
+		{TenantId: "../invalid", Request: *mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "foo")}, 1, 100000)},
+		{TenantId: "user-3", Request: *mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "bar")}, 1, 100000)},
+	}}
+
+	// Only the trusted forwarders can push batches.
+	_, err := d.PushBatch(user.InjectOrgID(context.Background(), "user-1"), req)
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusForbidden), resp.Code)
+
+	// The failure of a write request doesn't fail the others.
+	res, err := d.PushBatch(user.InjectOrgID(context.Background(), "edge-collector"), req)
+	require.NoError(t, err)
+	require.Len(t, res.Responses, 4)
+
+	codes := map[string]int32{}
+	for _, r := range res.Responses {
+		codes[r.TenantId] = r.Code
+		if r.Code != http.StatusOK {
+			assert.NotEmpty(t, r.Error)
+		}
+	}
+	assert.Equal(t, map[string]int32{
+		"user-1":     http.StatusOK,
+		"user-2":     http.StatusBadRequest,
+		"../invalid": http.StatusBadRequest,
+		"user-3":     http.StatusOK,
+	}, codes)
+
+	// The series of the successful write requests are pushed to the ingesters.
+	names := map[string]struct{}{}
+	for _, ing := range ingesters {
+		for _, ts := range ing.series() {
+			names[cortexpb.FromLabelAdaptersToLabels(ts.Labels).Get(labels.MetricName)] = struct{}{}
+		}
+	}
+	assert.Equal(t, map[string]struct{}{"foo": {}, "bar": {}}, names)
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	io "io"
+	"math"
 	"net/http"
 	"sort"
 	"strings"
@@ -35,6 +36,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/extract"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
 	"github.com/cortexproject/cortex/pkg/util/limiter"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	util_math "github.com/cortexproject/cortex/pkg/util/math"
@@ -52,8 +54,14 @@ var (
 	errInvalidTenantShardSize  = errors.New("invalid tenant shard size, the value must be greater than 0")
 
 	// Distributor instance limits errors.
-	errTooManyInflightPushRequests    = errors.New("too many inflight push requests in distributor")
-	errMaxSamplesPushRateLimitReached = errors.New("distributor's samples push rate limit reached")
+	errTooManyInflightPushRequests    = errors.New(globalerror.DistributorMaxInflightPushRequests.Message("too many inflight push requests in distributor"))
+	errMaxSamplesPushRateLimitReached = errors.New(globalerror.DistributorMaxIngestionRate.Message("distributor's samples push rate limit reached"))
+
+	// The retries of a push request still in progress fail with a 5xx error, so that the clients retry them later.
+	errWriteInProgress = httpgrpc.Errorf(http.StatusServiceUnavailable, globalerror.WriteInProgress.Message("a push request with the same idempotency key is in progress"))
+
+	// Per-tenant limits errors.
+	errIngestionRateLimited = globalerror.IngestionRateLimited.Message("ingestion rate limit (%v) exceeded while adding %d samples and %d metadata")
 )
 
 const (
@@ -64,6 +72,10 @@ const (
 	typeMetadata = "metadata"
 
 	instanceIngestionRateTickInterval = time.Second
+
+	// maxTSDBStatusIngesterLimit is the max number of items of the top lists of the TSDB statistics
+	// returned by each ingester, to bound the size of their responses.
+	maxTSDBStatusIngesterLimit = 10000
 )
 
 // Distributor is a storage.SampleAppender and a client.Querier which
@@ -97,6 +109,21 @@ type Distributor struct {
 	ingestionRate        *util_math.EwmaRate
 	inflightPushRequests atomic.Int64
 
+	// Ingestion breakdown by metric name prefix, nil if disabled.
+	metricPrefixes *metricPrefixTracker
+
+	// Idempotency keys of the push requests, nil if the deduplication is disabled.
+	writeDedup *writeDedupCache
+
+	// Labels of the tenants over their values budget, nil if the budgets aren't enforced.
+	labelValuesBudgets *labelValuesBudgets
+
+	// Series queried from the ingesters, nil if the cache is disabled.
+	queryStreamCache *queryStreamCache
+
+	// Metric types learned from the pushed metadata, for the tenants validating the samples against them.
+	metadataTypes *metadataTypeValidator
+
 	// Metrics
 	queryDuration                    *instrument.HistogramCollector
 	receivedSamples                  *prometheus.CounterVec
@@ -112,6 +139,8 @@ type Distributor struct {
 	ingesterAppendFailures           *prometheus.CounterVec
 	ingesterQueries                  *prometheus.CounterVec
 	ingesterQueryFailures            *prometheus.CounterVec
+	singleZoneReads                  prometheus.Counter
+	singleZoneReadFallbacks          *prometheus.CounterVec
 	replicationFactor                prometheus.Gauge
 	latestSeenSampleTimestampPerUser *prometheus.GaugeVec
 }
@@ -127,6 +156,9 @@ type Config struct {
 	RemoteTimeout   time.Duration `yaml:"remote_timeout"`
 	ExtraQueryDelay time.Duration `yaml:"extra_queue_delay"`
 
+	PreferSingleZoneReads           bool          `yaml:"prefer_single_zone_reads"`
+	SingleZoneReadsReplicationDelay time.Duration `yaml:"single_zone_reads_replication_delay"`
+
 	ShardingStrategy         string `yaml:"sharding_strategy"`
 	ShardByAllLabels         bool   `yaml:"shard_by_all_labels"`
 	ExtendWrites             bool   `yaml:"extend_writes"`
@@ -146,7 +178,18 @@ type Config struct {
 	ShuffleShardingLookbackPeriod time.Duration `yaml:"-"`
 
 	// Limits for distributor
-	InstanceLimits InstanceLimits `yaml:"instance_limits"`
+	InstanceLimits InstanceLimits          `yaml:"instance_limits"`
+	DecodingLimits cortexpb.DecodingLimits `yaml:"decoding_limits"`
+
+	MetricPrefixTracking MetricPrefixTrackingConfig `yaml:"metric_prefix_tracking"`
+
+	BatchPush BatchPushConfig `yaml:"batch_push"`
+
+	WriteDedup WriteDedupConfig `yaml:"write_dedup"`
+
+	LabelValuesBudgets LabelValuesBudgetsConfig `yaml:"label_values_budgets"`
+
+	QueryStreamCache QueryStreamCacheConfig `yaml:"query_stream_cache"`
 }
 
 type InstanceLimits struct {
@@ -159,10 +202,18 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.PoolConfig.RegisterFlags(f)
 	cfg.HATrackerConfig.RegisterFlags(f)
 	cfg.DistributorRing.RegisterFlags(f)
+	cfg.MetricPrefixTracking.RegisterFlags(f)
+	cfg.BatchPush.RegisterFlags(f)
+	cfg.WriteDedup.RegisterFlags(f)
+	cfg.LabelValuesBudgets.RegisterFlags(f)
+	cfg.QueryStreamCache.RegisterFlags(f)
+	cfg.DecodingLimits.RegisterFlagsWithPrefix(f, "distributor.decoding-limits.")
 
 	f.IntVar(&cfg.MaxRecvMsgSize, "distributor.max-recv-msg-size", 100<<20, "remote_write API max receive message size (bytes).")
 	f.DurationVar(&cfg.RemoteTimeout, "distributor.remote-timeout", 2*time.Second, "Timeout for downstream ingesters.")
 	f.DurationVar(&cfg.ExtraQueryDelay, "distributor.extra-query-delay", 0, "Time to wait before sending more than the minimum successful query requests.")
+	f.BoolVar(&cfg.PreferSingleZoneReads, "distributor.prefer-single-zone-reads", false, "Experimental. Query the ingesters of a single zone first when the zone-awareness is enabled, along with the ingesters of a second zone verifying that the zone didn't miss any data, instead of the ingesters of all the zones. The other zones are queried if any ingester of the two zones fails the query, or if the two zones don't return as many series and samples, e.g. because a zone failed to ingest some samples while the write quorum succeeded. Only the zones whose ingesters have all been registered to the ring before the start of the query are queried this way, since the others may miss some of the queried samples. Not applied to the lazy merge of the query streams.")
+	f.DurationVar(&cfg.SingleZoneReadsReplicationDelay, "distributor.single-zone-reads-replication-delay", time.Minute, "Experimental. The samples more recent than this period aren't compared between the two zones queried by -distributor.prefer-single-zone-reads, since they may still be replicated to one of the zones. The series are compared on the samples up to this period ago, and the results of both zones are returned.")
 	f.BoolVar(&cfg.ShardByAllLabels, "distributor.shard-by-all-labels", false, "Distribute samples based on all labels, as opposed to solely by user and metric name.")
 	f.BoolVar(&cfg.SignWriteRequestsEnabled, "distributor.sign-write-requests", false, "EXPERIMENTAL: If enabled, sign the write request between distributors and ingesters.")
 	f.StringVar(&cfg.ShardingStrategy, "distributor.sharding-strategy", util.ShardingStrategyDefault, fmt.Sprintf("The sharding strategy to use. Supported values are: %s.", strings.Join(supportedShardingStrategies, ", ")))
@@ -182,6 +233,26 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		return errInvalidTenantShardSize
 	}
 
+	if err := cfg.MetricPrefixTracking.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.BatchPush.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.WriteDedup.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.LabelValuesBudgets.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.QueryStreamCache.Validate(); err != nil {
+		return err
+	}
+
 	haHATrackerConfig := cfg.HATrackerConfig.ToHATrackerConfig()
 
 	return haHATrackerConfig.Validate()
@@ -325,6 +396,16 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Name:      "distributor_ingester_query_failures_total",
 			Help:      "The total number of failed queries sent to ingesters.",
 		}, []string{"ingester"}),
+		singleZoneReads: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_single_zone_reads_total",
+			Help:      "The total number of queries sent to the ingesters with the single zone reads enabled, including the ones which fell back to the ingesters of all the zones.",
+		}),
+		singleZoneReadFallbacks: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_single_zone_read_fallbacks_total",
+			Help:      "The total number of queries sent to the ingesters of all the zones instead of a single zone, because the zone failed the query, was missing some data or no zone was complete.",
+		}, []string{"reason"}),
 		replicationFactor: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
 			Namespace: "cortex",
 			Name:      "distributor_replication_factor",
@@ -360,6 +441,25 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		return d.ingestionRate.Rate()
 	})
 
+	if cfg.MetricPrefixTracking.Enabled {
+		d.metricPrefixes = newMetricPrefixTracker(cfg.MetricPrefixTracking, reg)
+	}
+
+	if cfg.WriteDedup.Enabled {
+		d.writeDedup = newWriteDedupCache(cfg.WriteDedup, reg)
+	}
+
+	if cfg.LabelValuesBudgets.Enabled {
+		d.labelValuesBudgets = newLabelValuesBudgets(limits, reg)
+	}
+
+	if cfg.QueryStreamCache.Enabled {
+		if d.queryStreamCache, err = newQueryStreamCache(cfg.QueryStreamCache, reg, log); err != nil {
+			return nil, err
+		}
+	}
+	d.metadataTypes = newMetadataTypeValidator(reg)
+
 	d.replicationFactor.Set(float64(ingestersRing.ReplicationFactor()))
 	d.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(d.cleanupInactiveUser)
 
@@ -379,6 +479,18 @@ func (d *Distributor) starting(ctx context.Context) error {
 	if d.cfg.InstanceLimits != (InstanceLimits{}) {
 		util_log.WarnExperimentalUse("distributor instance limits")
 	}
+	if d.metricPrefixes != nil {
+		util_log.WarnExperimentalUse("distributor metric prefix tracking")
+	}
+	if d.writeDedup != nil {
+		util_log.WarnExperimentalUse("distributor write deduplication")
+	}
+	if d.labelValuesBudgets != nil {
+		util_log.WarnExperimentalUse("distributor label values budgets")
+	}
+	if d.queryStreamCache != nil {
+		util_log.WarnExperimentalUse("distributor query stream cache")
+	}
 
 	// Only report success if all sub-services start properly
 	return services.StartManagerAndAwaitHealthy(ctx, d.subservices)
@@ -388,6 +500,27 @@ func (d *Distributor) running(ctx context.Context) error {
 	ingestionRateTicker := time.NewTicker(instanceIngestionRateTickInterval)
 	defer ingestionRateTicker.Stop()
 
+	var metricPrefixesTick <-chan time.Time
+	if d.metricPrefixes != nil {
+		metricPrefixesTicker := time.NewTicker(metricPrefixesRateTickInterval)
+		defer metricPrefixesTicker.Stop()
+		metricPrefixesTick = metricPrefixesTicker.C
+	}
+
+	var writeDedupTick <-chan time.Time
+	if d.writeDedup != nil {
+		writeDedupTicker := time.NewTicker(writeDedupPurgeInterval)
+		defer writeDedupTicker.Stop()
+		writeDedupTick = writeDedupTicker.C
+	}
+
+	var labelValuesBudgetsTick <-chan time.Time
+	if d.labelValuesBudgets != nil {
+		labelValuesBudgetsTicker := time.NewTicker(d.cfg.LabelValuesBudgets.SyncPeriod)
+		defer labelValuesBudgetsTicker.Stop()
+		labelValuesBudgetsTick = labelValuesBudgetsTicker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -396,6 +529,15 @@ func (d *Distributor) running(ctx context.Context) error {
 		case <-ingestionRateTicker.C:
 			d.ingestionRate.Tick()
 
+		case <-metricPrefixesTick:
+			d.metricPrefixes.tick()
+
+		case now := <-writeDedupTick:
+			d.writeDedup.purgeExpired(now)
+
+		case <-labelValuesBudgetsTick:
+			d.syncLabelValuesBudgets(ctx)
+
 		case err := <-d.subservicesWatcher.Chan():
 			return errors.Wrap(err, "distributor subservice failed")
 		}
@@ -416,6 +558,17 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	d.nonHASamples.DeleteLabelValues(userID)
 	d.latestSeenSampleTimestampPerUser.DeleteLabelValues(userID)
 
+	if d.metricPrefixes != nil {
+		d.metricPrefixes.deleteUser(userID)
+	}
+	if d.writeDedup != nil {
+		d.writeDedup.deleteUser(userID)
+	}
+	if d.labelValuesBudgets != nil {
+		d.labelValuesBudgets.deleteUser(userID)
+	}
+	d.metadataTypes.deleteUser(userID)
+
 	if err := util.DeleteMatchingLabels(d.dedupedSamples, map[string]string{"user": userID}); err != nil {
 		level.Warn(d.log).Log("msg", "failed to remove cortex_distributor_deduped_samples_total metric for user", "user", userID, "err", err)
 	}
@@ -425,6 +578,9 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 
 // Called after distributor is asked to stop via StopAsync.
 func (d *Distributor) stopping(_ error) error {
+	if d.queryStreamCache != nil {
+		d.queryStreamCache.Stop()
+	}
 	return services.StopManagerAndAwaitStopped(context.Background(), d.subservices)
 }
 
@@ -521,6 +677,18 @@ func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID stri
 		return emptyPreallocSeries, err
 	}
 
+	if err := validation.ValidatePreAggregatedLabels(limits, userID, ts.Labels); err != nil {
+		return emptyPreallocSeries, err
+	}
+
+	if err := validation.ValidateLabelSchema(limits, userID, ts.Labels); err != nil {
+		return emptyPreallocSeries, err
+	}
+
+	if err := d.labelValuesBudgets.validate(userID, ts.Labels); err != nil {
+		return emptyPreallocSeries, err
+	}
+
 	var samples []cortexpb.Sample
 	if len(ts.Samples) > 0 {
 		// Only alloc when data present
@@ -560,19 +728,27 @@ func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID stri
 		}
 	}
 
+	// The created timestamp is only kept when older than the samples of the series, since
+	// the ingesters can't make use of it otherwise.
+	var createdTimestamp int64
+	if len(samples) > 0 && ts.CreatedTimestampMs > 0 && ts.CreatedTimestampMs < samples[0].TimestampMs {
+		createdTimestamp = ts.CreatedTimestampMs
+	}
+
 	return cortexpb.PreallocTimeseries{
 			TimeSeries: &cortexpb.TimeSeries{
-				Labels:     ts.Labels,
-				Samples:    samples,
-				Exemplars:  exemplars,
-				Histograms: histograms,
+				Labels:             ts.Labels,
+				Samples:            samples,
+				Exemplars:          exemplars,
+				Histograms:         histograms,
+				CreatedTimestampMs: createdTimestamp,
 			},
 		},
 		nil
 }
 
 // Push implements client.IngesterServer
-func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (_ *cortexpb.WriteResponse, err error) {
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
@@ -581,6 +757,16 @@ func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*co
 	span, ctx := opentracing.StartSpanFromContext(ctx, "Distributor.Push")
 	defer span.Finish()
 
+	if key := util.IdempotencyKeyFromContext(ctx); d.writeDedup != nil && key != "" {
+		switch d.writeDedup.begin(userID, key, time.Now()) {
+		case writeDedupDone:
+			return &cortexpb.WriteResponse{}, nil
+		case writeDedupInProgress:
+			return nil, errWriteInProgress
+		}
+		defer func() { d.writeDedup.end(userID, key, err == nil, time.Now()) }()
+	}
+
 	// We will report *this* request in the error too.
 	inflight := d.inflightPushRequests.Inc()
 	defer d.inflightPushRequests.Dec()
@@ -606,6 +792,12 @@ func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*co
 		numSamples += len(ts.Samples) + len(ts.Histograms)
 		numExemplars += len(ts.Exemplars)
 	}
+	var prefixCounts *metricPrefixCounts
+	if d.metricPrefixes != nil {
+		prefixCounts = newMetricPrefixCounts(d.cfg.MetricPrefixTracking.PrefixSegments)
+		prefixCounts.addIncoming(req.Timeseries)
+		defer func() { d.metricPrefixes.add(userID, prefixCounts) }()
+	}
 	// Count the total samples, exemplars in, prior to validation or deduplication, for comparison with other metrics.
 	d.incomingSamples.WithLabelValues(userID).Add(float64(numSamples))
 	d.incomingExemplars.WithLabelValues(userID).Add(float64(numExemplars))
@@ -630,6 +822,7 @@ func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*co
 
 			if errors.Is(err, ha.TooManyReplicaGroupsError{}) {
 				validation.DiscardedSamples.WithLabelValues(validation.TooManyHAClusters, userID).Add(float64(numSamples))
+				prefixCounts.addDiscarded(req.Timeseries)
 				return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 			}
 
@@ -642,12 +835,18 @@ func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*co
 	}
 
 	// A WriteRequest can only contain series or metadata but not both. This might change in the future.
-	seriesKeys, validatedTimeseries, validatedSamples, validatedExemplars, firstPartialErr, err := d.prepareSeriesKeys(ctx, req, userID, limits, removeReplica)
+	seriesKeys, validatedTimeseries, validatedSamples, validatedExemplars, firstPartialErr, err := d.prepareSeriesKeys(ctx, req, userID, limits, removeReplica, prefixCounts)
 	if err != nil {
 		return nil, err
 	}
 	metadataKeys, validatedMetadata, firstPartialErr := d.prepareMetadataKeys(req, limits, userID, firstPartialErr)
 
+	// The samples violating the type of their metric family are only counted, not rejected.
+	if limits.MetadataTypeValidation {
+		d.metadataTypes.observe(userID, validatedMetadata)
+		d.metadataTypes.validate(userID, validatedTimeseries)
+	}
+
 	d.receivedSamples.WithLabelValues(userID).Add(float64(validatedSamples))
 	d.receivedExemplars.WithLabelValues(userID).Add(float64(validatedExemplars))
 	d.receivedMetadata.WithLabelValues(userID).Add(float64(len(validatedMetadata)))
@@ -660,17 +859,44 @@ func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*co
 	}
 
 	totalN := validatedSamples + validatedExemplars + len(validatedMetadata)
-	if !d.ingestionRateLimiter.AllowN(now, userID, totalN) {
+	allowed := d.ingestionRateLimiter.AllowN(now, userID, totalN)
+
+	// Instead of rejecting the request exceeding the rate limit, only keep 1 in N samples of each
+	// series if the tenant has sampling enabled, as long as the sampled request fits in the limit.
+	samplingFactor := 0
+	if !allowed && limits.IngestionSamplingFactor > 1 {
+		samplingFactor = limits.IngestionSamplingFactor
+
+		var droppedSamples, droppedExemplars int
+		seriesKeys, validatedTimeseries, droppedSamples, droppedExemplars = sampleSeries(userID, seriesKeys, validatedTimeseries, samplingFactor, prefixCounts)
+		validation.DiscardedSamples.WithLabelValues(validation.RateLimitedSampled, userID).Add(float64(droppedSamples))
+		validation.DiscardedExemplars.WithLabelValues(validation.RateLimitedSampled, userID).Add(float64(droppedExemplars))
+		validatedSamples -= droppedSamples
+		validatedExemplars -= droppedExemplars
+		totalN -= droppedSamples + droppedExemplars
+
+		allowed = d.ingestionRateLimiter.AllowN(now, userID, totalN)
+	}
+
+	if !allowed {
 		// Ensure the request slice is reused if the request is rate limited.
 		cortexpb.ReuseSlice(req.Timeseries)
 
 		validation.DiscardedSamples.WithLabelValues(validation.RateLimited, userID).Add(float64(validatedSamples))
+		prefixCounts.addDiscarded(validatedTimeseries)
 		validation.DiscardedExemplars.WithLabelValues(validation.RateLimited, userID).Add(float64(validatedExemplars))
 		validation.DiscardedMetadata.WithLabelValues(validation.RateLimited, userID).Add(float64(len(validatedMetadata)))
 		// Return a 429 here to tell the client it is going too fast.
 		// Client may discard the data or slow down and re-send.
 		// Prometheus v2.26 added a remote-write option 'retry_on_http_429'.
-		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (%v) exceeded while adding %d samples and %d metadata", d.ingestionRateLimiter.Limit(now, userID), validatedSamples, len(validatedMetadata))
+		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, errIngestionRateLimited, d.ingestionRateLimiter.Limit(now, userID), validatedSamples, len(validatedMetadata))
+	}
+
+	if len(seriesKeys) == 0 && len(metadataKeys) == 0 {
+		// All the samples have been sampled out.
+		cortexpb.ReuseSlice(req.Timeseries)
+
+		return &cortexpb.WriteResponse{SamplingFactor: int32(samplingFactor)}, firstPartialErr
 	}
 
 	// totalN included samples and metadata. Ingester follows this pattern when computing its ingestion rate.
@@ -691,7 +917,65 @@ func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*co
 		return nil, err
 	}
 
-	return &cortexpb.WriteResponse{}, firstPartialErr
+	return &cortexpb.WriteResponse{SamplingFactor: int32(samplingFactor)}, firstPartialErr
+}
+
+// sampleSeries only keeps 1 in factor samples of each series, and the series with samples left.
+// It returns the kept series, with their keys, and the number of dropped samples and exemplars.
+func sampleSeries(userID string, keys []uint32, series []cortexpb.PreallocTimeseries, factor int, prefixCounts *metricPrefixCounts) ([]uint32, []cortexpb.PreallocTimeseries, int, int) {
+	droppedSamples, droppedExemplars := 0, 0
+	keptKeys := keys[:0]
+	keptSeries := series[:0]
+
+	for i, ts := range series {
+		seriesHash := shardByAllLabels(userID, ts.Labels)
+
+		// The samples and histograms of the validated series are copies, so they can be filtered in place.
+		samples := ts.Samples[:0]
+		for _, s := range ts.Samples {
+			if keepSampledSample(seriesHash, s.TimestampMs, factor) {
+				samples = append(samples, s)
+			}
+		}
+		histograms := ts.Histograms[:0]
+		for _, h := range ts.Histograms {
+			if keepSampledSample(seriesHash, h.TimestampMs, factor) {
+				histograms = append(histograms, h)
+			}
+		}
+
+		dropped := len(ts.Samples) - len(samples) + len(ts.Histograms) - len(histograms)
+		droppedSamples += dropped
+		if prefixCounts != nil {
+			prefixCounts.addDiscardedPrefix(prefixCounts.prefix(ts.Labels), dropped)
+		}
+
+		ts.Samples = samples
+		ts.Histograms = histograms
+		if len(samples) == 0 && len(histograms) == 0 {
+			droppedExemplars += len(ts.Exemplars)
+			continue
+		}
+
+		keptKeys = append(keptKeys, keys[i])
+		keptSeries = append(keptSeries, ts)
+	}
+	return keptKeys, keptSeries, droppedSamples, droppedExemplars
+}
+
+// keepSampledSample returns whether the sample of the series at the timestamp is one of the 1 in
+// factor samples kept. The choice only depends on the series and the timestamp, so that the HA
+// replicas and the retries keep the same samples.
+func keepSampledSample(seriesHash uint32, timestampMs int64, factor int) bool {
+	// Mix the series hash and the timestamp (splitmix64 finalizer), so that the kept samples are
+	// spread evenly whatever the scrape interval.
+	x := uint64(seriesHash)<<32 ^ uint64(timestampMs)
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x%uint64(factor) == 0
 }
 
 func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, subRing ring.ReadRing, keys []uint32, initialMetadataIndex int, validatedMetadata []*cortexpb.MetricMetadata, validatedTimeseries []cortexpb.PreallocTimeseries, userID string) error {
@@ -708,6 +992,9 @@ func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, s
 	if headerMap := util_log.HeaderMapFromContext(ctx); headerMap != nil {
 		localCtx = util_log.ContextWithHeaderMap(localCtx, headerMap)
 	}
+	if requestID := util_log.RequestIDFromContext(ctx); requestID != "" {
+		localCtx = util_log.ContextWithRequestID(localCtx, requestID)
+	}
 	// Get clientIP(s) from Context and add it to localCtx
 	source := util.GetSourceIPsFromOutgoingCtx(ctx)
 	localCtx = util.AddSourceIPsToOutgoingContext(localCtx, source)
@@ -729,7 +1016,7 @@ func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, s
 			}
 		}
 
-		return d.send(localCtx, ingester, timeseries, metadata, req.Source)
+		return d.send(localCtx, ingester, timeseries, metadata, req.Source, req.Ephemeral)
 	}, func() {
 		cortexpb.ReuseSlice(req.Timeseries)
 		cancel()
@@ -757,7 +1044,7 @@ func (d *Distributor) prepareMetadataKeys(req *cortexpb.WriteRequest, limits *va
 	return metadataKeys, validatedMetadata, firstPartialErr
 }
 
-func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.WriteRequest, userID string, limits *validation.Limits, removeReplica bool) ([]uint32, []cortexpb.PreallocTimeseries, int, int, error, error) {
+func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.WriteRequest, userID string, limits *validation.Limits, removeReplica bool, prefixCounts *metricPrefixCounts) ([]uint32, []cortexpb.PreallocTimeseries, int, int, error, error) {
 	pSpan, _ := opentracing.StartSpanFromContext(ctx, "prepareSeriesKeys")
 	defer pSpan.Finish()
 
@@ -788,6 +1075,12 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 		}
 		// TODO(yeya24): use timestamp of the latest native histogram in the series as well.
 
+		// The discarded samples are accounted to the prefix of the series before relabeling.
+		var prefix string
+		if prefixCounts != nil {
+			prefix = prefixCounts.prefix(ts.Labels)
+		}
+
 		if mrc := limits.MetricRelabelConfigs; len(mrc) > 0 {
 			l, _ := relabel.Process(cortexpb.FromLabelAdaptersToLabels(ts.Labels), mrc...)
 			if len(l) == 0 {
@@ -796,6 +1089,7 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 					validation.DroppedByRelabelConfiguration,
 					userID,
 				).Add(float64(len(ts.Samples)))
+				prefixCounts.addDiscardedPrefix(prefix, len(ts.Samples))
 				continue
 			}
 			ts.Labels = cortexpb.FromLabelsToLabelAdapters(l)
@@ -817,6 +1111,7 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 				validation.DroppedByUserConfigurationOverride,
 				userID,
 			).Add(float64(len(ts.Samples)))
+			prefixCounts.addDiscardedPrefix(prefix, len(ts.Samples))
 
 			continue
 		}
@@ -828,12 +1123,6 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 		// later in the validation phase, we ignore them here.
 		sortLabelsIfNeeded(ts.Labels)
 
-		// Generate the sharding token based on the series labels without the HA replica
-		// label and dropped labels (if any)
-		key, err := d.tokenForLabels(userID, ts.Labels)
-		if err != nil {
-			return nil, nil, 0, 0, nil, err
-		}
 		validatedSeries, validationErr := d.validateSeries(ts, userID, skipLabelNameValidation, limits)
 
 		// Errors in validation are considered non-fatal, as one series in a request may contain
@@ -846,9 +1135,17 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 
 		// validateSeries would have returned an emptyPreallocSeries if there were no valid samples.
 		if validatedSeries == emptyPreallocSeries {
+			prefixCounts.addDiscardedPrefix(prefix, len(ts.Samples)+len(ts.Histograms))
 			continue
 		}
 
+		// Generate the sharding token based on the series labels without the HA replica
+		// label and dropped labels (if any), once the invalid label values have been replaced.
+		key, err := d.tokenForLabels(userID, validatedSeries.Labels)
+		if err != nil {
+			return nil, nil, 0, 0, nil, err
+		}
+
 		seriesKeys = append(seriesKeys, key)
 		validatedTimeseries = append(validatedTimeseries, validatedSeries)
 		// TODO(yeya24): add histogram samples as well when supported.
@@ -880,7 +1177,7 @@ func sortLabelsIfNeeded(labels []cortexpb.LabelAdapter) {
 	})
 }
 
-func (d *Distributor) send(ctx context.Context, ingester ring.InstanceDesc, timeseries []cortexpb.PreallocTimeseries, metadata []*cortexpb.MetricMetadata, source cortexpb.WriteRequest_SourceEnum) error {
+func (d *Distributor) send(ctx context.Context, ingester ring.InstanceDesc, timeseries []cortexpb.PreallocTimeseries, metadata []*cortexpb.MetricMetadata, source cortexpb.WriteRequest_SourceEnum, ephemeral bool) error {
 	h, err := d.ingesterPool.GetClientFor(ingester.Addr)
 	if err != nil {
 		return err
@@ -891,6 +1188,7 @@ func (d *Distributor) send(ctx context.Context, ingester ring.InstanceDesc, time
 	req.Timeseries = timeseries
 	req.Metadata = metadata
 	req.Source = source
+	req.Ephemeral = ephemeral
 
 	_, err = c.PushPreAlloc(ctx, req)
 
@@ -1275,6 +1573,101 @@ func (d *Distributor) UserStats(ctx context.Context) (*UserStats, error) {
 	return totalStats, nil
 }
 
+// TSDBStatus returns the cardinality statistics of the ingesters head for the current user.
+// Statistics are summed across ingesters and divided by the replication factor, except the
+// number of label pairs and of label values per label name, for which the max across ingesters
+// is taken since the same label value is typically found in many ingesters. The top lists are computed
+// from larger top lists of each ingester, so they're an approximation.
+func (d *Distributor) TSDBStatus(ctx context.Context, limit int) (*ingester_client.TSDBStatusResponse, error) {
+	replicationSet, err := d.GetIngestersForMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure we get a successful response from all of them.
+	replicationSet.MaxErrors = 0
+
+	// The ingesters return longer top lists, which are merged before being truncated, so that an entry
+	// missing from the top list of an ingester is less likely to be undercounted. They're still bounded,
+	// since all the statistics of a tenant with many label pairs could exceed the max gRPC message size.
+	// The limit is clamped before being multiplied, to not overflow.
+	rf := d.ingestersRing.ReplicationFactor()
+	req := &ingester_client.TSDBStatusRequest{Limit: int32(min(limit, maxTSDBStatusIngesterLimit/rf) * rf)}
+	resps, err := d.ForReplicationSet(ctx, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		return client.TSDBStatus(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	factor := uint64(d.ingestersRing.ReplicationFactor())
+	result := &ingester_client.TSDBStatusResponse{MinTime: math.MaxInt64, MaxTime: math.MinInt64}
+	seriesCountByMetricName := map[string]uint64{}
+	labelValueCountByLabelName := map[string]uint64{}
+	memoryInBytesByLabelName := map[string]uint64{}
+	seriesCountByLabelValuePair := map[string]uint64{}
+
+	for _, resp := range resps {
+		r := resp.(*ingester_client.TSDBStatusResponse)
+		result.NumSeries += r.NumSeries
+		result.ChunkCount += r.ChunkCount
+		if r.NumLabelPairs > result.NumLabelPairs {
+			result.NumLabelPairs = r.NumLabelPairs
+		}
+		if r.MinTime < result.MinTime {
+			result.MinTime = r.MinTime
+		}
+		if r.MaxTime > result.MaxTime {
+			result.MaxTime = r.MaxTime
+		}
+
+		for _, s := range r.SeriesCountByMetricName {
+			seriesCountByMetricName[s.Name] += s.Value
+		}
+		for _, s := range r.LabelValueCountByLabelName {
+			if s.Value > labelValueCountByLabelName[s.Name] {
+				labelValueCountByLabelName[s.Name] = s.Value
+			}
+		}
+		for _, s := range r.MemoryInBytesByLabelName {
+			memoryInBytesByLabelName[s.Name] += s.Value
+		}
+		for _, s := range r.SeriesCountByLabelValuePair {
+			seriesCountByLabelValuePair[s.Name] += s.Value
+		}
+	}
+
+	result.NumSeries /= factor
+	result.ChunkCount /= factor
+	result.SeriesCountByMetricName = topTSDBStatistics(seriesCountByMetricName, factor, limit)
+	result.LabelValueCountByLabelName = topTSDBStatistics(labelValueCountByLabelName, 1, limit)
+	result.MemoryInBytesByLabelName = topTSDBStatistics(memoryInBytesByLabelName, factor, limit)
+	result.SeriesCountByLabelValuePair = topTSDBStatistics(seriesCountByLabelValuePair, factor, limit)
+
+	return result, nil
+}
+
+// topTSDBStatistics returns the top limit statistics, sorted by value in descending order,
+// after dividing their value by the given factor.
+func topTSDBStatistics(stats map[string]uint64, factor uint64, limit int) []ingester_client.TSDBStatistic {
+	out := make([]ingester_client.TSDBStatistic, 0, len(stats))
+	for name, value := range stats {
+		out = append(out, ingester_client.TSDBStatistic{Name: name, Value: value / factor})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Value != out[j].Value {
+			return out[i].Value > out[j].Value
+		}
+		return out[i].Name < out[j].Name
+	})
+
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
 // UserIDStats models ingestion statistics for one user, including the user ID
 type UserIDStats struct {
 	UserID string `json:"userID"`
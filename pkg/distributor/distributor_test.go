@@ -31,12 +31,14 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
 	"github.com/cortexproject/cortex/pkg/chunk/encoding"
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/ha"
 	"github.com/cortexproject/cortex/pkg/ingester"
 	"github.com/cortexproject/cortex/pkg/ingester/client"
 	"github.com/cortexproject/cortex/pkg/prom1/storage/metric"
+	"github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/ring"
 	ring_client "github.com/cortexproject/cortex/pkg/ring/client"
 	"github.com/cortexproject/cortex/pkg/ring/kv"
@@ -45,6 +47,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/hll"
 	"github.com/cortexproject/cortex/pkg/util/limiter"
 	util_math "github.com/cortexproject/cortex/pkg/util/math"
 	"github.com/cortexproject/cortex/pkg/util/services"
@@ -505,6 +508,82 @@ func TestDistributor_PushIngestionRateLimiter(t *testing.T) {
 	}
 }
 
+func TestDistributor_PushIngestionRateLimiter_ShouldSampleTheRequestsOverTheLimit(t *testing.T) {
+	t.Parallel()
+	const userID = "sampled-user"
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.IngestionRate = 1
+	limits.IngestionBurstSize = 100
+	limits.IngestionSamplingFactor = 10
+
+	distributors, _, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           limits,
+	})
+
+	// 20 series with the given number of samples each.
+	makeRequest := func(samplesPerSeries int) *cortexpb.WriteRequest {
+		request := &cortexpb.WriteRequest{}
+		for i := 0; i < 20; i++ {
+			ts := makeWriteRequestTimeseries([]cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}, {Name: "series", Value: strconv.Itoa(i)}}, 0, 1)
+			for j := 1; j < samplesPerSeries; j++ {
+				ts.Samples = append(ts.Samples, cortexpb.Sample{TimestampMs: int64(j) * 15000, Value: 1})
+			}
+			request.Timeseries = append(request.Timeseries, ts)
+		}
+		return request
+	}
+
+	// The requests within the limit aren't sampled.
+	response, err := distributors[0].Push(ctx, makeWriteRequest(0, 10, 0))
+	require.NoError(t, err)
+	assert.Equal(t, emptyResponse, response)
+
+	// The request exceeding the limit is sampled, deterministically.
+	expectedKept := 0
+	for _, ts := range makeRequest(10).Timeseries {
+		for _, s := range ts.Samples {
+			if keepSampledSample(shardByAllLabels(userID, ts.Labels), s.TimestampMs, 10) {
+				expectedKept++
+			}
+		}
+	}
+	require.Greater(t, expectedKept, 0)
+	require.Less(t, expectedKept, 90)
+
+	response, err = distributors[0].Push(ctx, makeRequest(10))
+	require.NoError(t, err)
+	assert.Equal(t, &cortexpb.WriteResponse{SamplingFactor: 10}, response)
+	assert.Equal(t, float64(200-expectedKept), testutil.ToFloat64(validation.DiscardedSamples.WithLabelValues(validation.RateLimitedSampled, userID)))
+
+	// The request still exceeding the limit once sampled is rejected.
+	_, err = distributors[0].Push(ctx, makeRequest(100))
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+}
+
+func TestKeepSampledSample(t *testing.T) {
+	t.Parallel()
+
+	// About 1 in factor samples of a series are kept, whatever the scrape interval.
+	for _, interval := range []int64{1000, 15000, 60000} {
+		kept := 0
+		for i := int64(0); i < 10000; i++ {
+			if keepSampledSample(12345, i*interval, 10) {
+				kept++
+			}
+		}
+		assert.InDelta(t, 1000, kept, 150, "interval: %d", interval)
+	}
+}
+
 func TestPush_QuorumError(t *testing.T) {
 	t.Parallel()
 
@@ -1147,6 +1226,95 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxSeriesPerQueryLimitIsReac
 
 }
 
+func TestDistributor_QueryStream_ShouldMergeNativeHistograms(t *testing.T) {
+	t.Parallel()
+	const maxSeriesLimit = 2
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(maxSeriesLimit, 0, 0, 0))
+
+	// Prepare distributors.
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           limits,
+	})
+
+	// The pushes return once the quorum is reached, wait for all the ingesters to receive them
+	// before the next one, otherwise an ingester could receive the histograms out of order.
+	waitForHistograms := func(expected int) {
+		for _, ing := range ingesters {
+			ing := ing
+			test.Poll(t, time.Second, expected, func() interface{} {
+				ing.Lock()
+				defer ing.Unlock()
+
+				count := 0
+				for _, ts := range ing.timeseries {
+					count += len(ts.Histograms)
+				}
+				return count
+			})
+		}
+	}
+
+	histogramSeries := func(name string, timestamps ...int64) cortexpb.PreallocTimeseries {
+		ts := cortexpb.PreallocTimeseries{TimeSeries: &cortexpb.TimeSeries{
+			Labels: []cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: name}},
+		}}
+		for _, t := range timestamps {
+			ts.Histograms = append(ts.Histograms, cortexpb.Histogram{
+				Count:       &cortexpb.Histogram_CountInt{CountInt: uint64(t)},
+				Sum:         float64(t),
+				TimestampMs: t,
+			})
+		}
+		return ts
+	}
+
+	// Push the histograms of the same series in two requests.
+	for i, req := range []*cortexpb.WriteRequest{
+		{Timeseries: []cortexpb.PreallocTimeseries{histogramSeries("histogram", 10, 20), histogramSeries("another_histogram", 10)}},
+		{Timeseries: []cortexpb.PreallocTimeseries{histogramSeries("histogram", 30)}},
+	} {
+		_, err := ds[0].Push(ctx, req)
+		require.NoError(t, err)
+		waitForHistograms(3 + i)
+	}
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	// The histograms returned by each ingester of the replication set are deduplicated.
+	queryStats, queryCtx := stats.ContextWithEmptyStats(ctx)
+	queryRes, err := ds[0].QueryStream(queryCtx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.NoError(t, err)
+	require.Len(t, queryRes.Timeseries, 2)
+	assert.Empty(t, queryRes.Chunkseries)
+	sort.Slice(queryRes.Timeseries, func(i, j int) bool {
+		return queryRes.Timeseries[i].Labels[0].Value < queryRes.Timeseries[j].Labels[0].Value
+	})
+	assert.Equal(t, histogramSeries("another_histogram", 10).Histograms, queryRes.Timeseries[0].Histograms)
+	assert.Equal(t, histogramSeries("histogram", 10, 20, 30).Histograms, queryRes.Timeseries[1].Histograms)
+	assert.Equal(t, uint64(4), queryStats.LoadFetchedHistogramSamples())
+	assert.Equal(t, uint64(0), queryStats.LoadFetchedSamples())
+	assert.Equal(t, uint64(2), queryStats.LoadFetchedSeries())
+
+	// The series with only histograms are accounted by the query limiter.
+	_, err = ds[0].Push(ctx, &cortexpb.WriteRequest{Timeseries: []cortexpb.PreallocTimeseries{histogramSeries("yet_another_histogram", 10)}})
+	require.NoError(t, err)
+	waitForHistograms(5)
+
+	_, err = ds[0].QueryStream(ctx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max number of series limit")
+}
+
 func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunkBytesPerQueryLimitIsReached(t *testing.T) {
 	t.Parallel()
 	const seriesToAdd = 10
@@ -1583,6 +1751,41 @@ func TestDistributor_Push_LabelNameValidation(t *testing.T) {
 	}
 }
 
+func TestDistributor_Push_CreatedTimestamp(t *testing.T) {
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           &limits,
+	})
+
+	// The created timestamp is only forwarded to the ingesters when older than the samples.
+	req := &cortexpb.WriteRequest{Timeseries: []cortexpb.PreallocTimeseries{
+		{TimeSeries: &cortexpb.TimeSeries{
+			Labels:             []cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "valid_total"}},
+			Samples:            []cortexpb.Sample{{TimestampMs: 2000, Value: 1}},
+			CreatedTimestampMs: 1000,
+		}},
+		{TimeSeries: &cortexpb.TimeSeries{
+			Labels:             []cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "invalid_total"}},
+			Samples:            []cortexpb.Sample{{TimestampMs: 2000, Value: 1}},
+			CreatedTimestampMs: 3000,
+		}},
+	}}
+	_, err := ds[0].Push(user.InjectOrgID(context.Background(), "user"), req)
+	require.NoError(t, err)
+
+	createdTimestamps := map[string]int64{}
+	for _, series := range ingesters[0].series() {
+		createdTimestamps[cortexpb.FromLabelAdaptersToLabels(series.Labels).Get(model.MetricNameLabel)] = series.CreatedTimestampMs
+	}
+	assert.Equal(t, map[string]int64{"valid_total": 1000, "invalid_total": 0}, createdTimestamps)
+}
+
 func TestDistributor_Push_ExemplarValidation(t *testing.T) {
 	t.Parallel()
 	ctx := user.InjectOrgID(context.Background(), "user")
@@ -2356,6 +2559,72 @@ func TestDistributor_MetricsMetadata(t *testing.T) {
 	}
 }
 
+func TestDistributor_TSDBStatus(t *testing.T) {
+	t.Parallel()
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     5,
+		happyIngesters:   5,
+		numDistributors:  1,
+		shardByAllLabels: true,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	_, err := ds[0].Push(ctx, makeWriteRequest(0, 10, 0))
+	require.NoError(t, err)
+
+	// The push returns once the quorum is reached, so wait for every replica to have the series.
+	require.Eventually(t, func() bool {
+		replicas := 0
+		for _, ing := range ingesters {
+			ing.Lock()
+			replicas += len(ing.timeseries)
+			ing.Unlock()
+		}
+		return replicas == 30
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status, err := ds[0].TSDBStatus(ctx, 2)
+	require.NoError(t, err)
+
+	// Each series is replicated to 3 ingesters.
+	assert.Equal(t, uint64(10), status.NumSeries)
+	assert.Equal(t, int64(0), status.MinTime)
+	assert.Equal(t, int64(9), status.MaxTime)
+	assert.Equal(t, []client.TSDBStatistic{{Name: "foo", Value: 10}}, status.SeriesCountByMetricName)
+	assert.Equal(t, []client.TSDBStatistic{{Name: "__name__=foo", Value: 10}, {Name: "bar=baz", Value: 10}}, status.SeriesCountByLabelValuePair)
+
+	// The label values count is the max across ingesters.
+	require.Len(t, status.LabelValueCountByLabelName, 2)
+	assert.Equal(t, "sample", status.LabelValueCountByLabelName[0].Name)
+	assert.Equal(t, client.TSDBStatistic{Name: "__name__", Value: 1}, status.LabelValueCountByLabelName[1])
+
+	assert.Equal(t, 5, countMockIngestersCalls(ingesters, "TSDBStatus"))
+
+	// The top lists are truncated once merged.
+	status, err = ds[0].TSDBStatus(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []client.TSDBStatistic{{Name: "__name__=foo", Value: 10}}, status.SeriesCountByLabelValuePair)
+
+	// The ingesters return top lists of the limit times the replication factor items, up to a max.
+	for _, ing := range ingesters {
+		assert.Equal(t, int32(3), ing.tsdbStatusLimit)
+	}
+	_, err = ds[0].TSDBStatus(ctx, maxTSDBStatusIngesterLimit)
+	require.NoError(t, err)
+	for _, ing := range ingesters {
+		assert.Equal(t, int32(maxTSDBStatusIngesterLimit/3*3), ing.tsdbStatusLimit)
+	}
+
+	// A huge limit doesn't overflow the limit of the ingesters.
+	status, err = ds[0].TSDBStatus(ctx, math.MaxInt)
+	require.NoError(t, err)
+	assert.Len(t, status.SeriesCountByLabelValuePair, 12)
+	for _, ing := range ingesters {
+		assert.Equal(t, int32(maxTSDBStatusIngesterLimit/3*3), ing.tsdbStatusLimit)
+	}
+}
+
 func mustNewMatcher(t labels.MatchType, n, v string) *labels.Matcher {
 	m, err := labels.NewMatcher(t, n, v)
 	if err != nil {
@@ -2392,6 +2661,13 @@ type prepConfig struct {
 	enableTracker                bool
 	errFail                      error
 	tokens                       [][]uint32
+	metricPrefixTracking         bool
+	batchPushForwarders          []string
+	writeDedup                   bool
+	labelValuesBudgets           bool
+	numZones                     int
+	preferSingleZoneReads        bool
+	queryStreamCache             bool
 }
 
 func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []*prometheus.Registry, *ring.Ring) {
@@ -2425,9 +2701,13 @@ func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []
 			tokens = []uint32{uint32((math.MaxUint32 / cfg.numIngesters) * i)}
 		}
 		addr := fmt.Sprintf("%d", i)
+		zone := ""
+		if cfg.numZones > 0 {
+			zone = fmt.Sprintf("zone-%d", i%cfg.numZones)
+		}
 		ingesterDescs[addr] = ring.InstanceDesc{
 			Addr:                addr,
-			Zone:                "",
+			Zone:                zone,
 			State:               ring.ACTIVE,
 			Timestamp:           time.Now().Unix(),
 			RegisteredTimestamp: time.Now().Add(-2 * time.Hour).Unix(),
@@ -2458,8 +2738,9 @@ func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []
 		KVStore: kv.Config{
 			Mock: kvStore,
 		},
-		HeartbeatTimeout:  60 * time.Minute,
-		ReplicationFactor: rf,
+		HeartbeatTimeout:     60 * time.Minute,
+		ReplicationFactor:    rf,
+		ZoneAwarenessEnabled: cfg.numZones > 0,
 	}, ingester.RingKey, ingester.RingKey, nil, nil)
 	require.NoError(tb, err)
 	require.NoError(tb, services.StartAndAwaitRunning(context.Background(), ingestersRing))
@@ -2487,6 +2768,8 @@ func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []
 		distributorCfg.IngesterClientFactory = factory
 		distributorCfg.ShardByAllLabels = cfg.shardByAllLabels
 		distributorCfg.ExtraQueryDelay = 50 * time.Millisecond
+		distributorCfg.PreferSingleZoneReads = cfg.preferSingleZoneReads
+		distributorCfg.QueryStreamCache = QueryStreamCacheConfig{Enabled: cfg.queryStreamCache, TTL: time.Hour, CacheConfig: cache.Config{Cache: cache.NewMockCache()}}
 		distributorCfg.DistributorRing.HeartbeatPeriod = 100 * time.Millisecond
 		distributorCfg.DistributorRing.InstanceID = strconv.Itoa(i)
 		distributorCfg.DistributorRing.KVStore.Mock = kvStore
@@ -2494,6 +2777,11 @@ func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []
 		distributorCfg.SkipLabelNameValidation = cfg.skipLabelNameValidation
 		distributorCfg.InstanceLimits.MaxInflightPushRequests = cfg.maxInflightRequests
 		distributorCfg.InstanceLimits.MaxIngestionRate = cfg.maxIngestionRate
+		distributorCfg.MetricPrefixTracking.Enabled = cfg.metricPrefixTracking
+		distributorCfg.BatchPush.TrustedForwarders = cfg.batchPushForwarders
+		distributorCfg.WriteDedup = WriteDedupConfig{Enabled: cfg.writeDedup, TTL: time.Minute, MaxKeys: 100}
+		// The label values budgets are synced by the tests.
+		distributorCfg.LabelValuesBudgets = LabelValuesBudgetsConfig{Enabled: cfg.labelValuesBudgets, SyncPeriod: time.Hour}
 
 		if cfg.shuffleShardEnabled {
 			distributorCfg.ShardingStrategy = util.ShardingStrategyShuffle
@@ -2670,6 +2958,12 @@ type mockIngester struct {
 	metadata   map[uint32]map[cortexpb.MetricMetadata]struct{}
 	queryDelay time.Duration
 	calls      map[string]int
+	scaleDown  client.ScaleDownResponse
+
+	tsdbStatusLimit int32
+
+	// Set the time range of the returned chunks, which changes their size.
+	chunksTimeRange bool
 }
 
 func (i *mockIngester) series() map[uint32]*cortexpb.PreallocTimeseries {
@@ -2730,16 +3024,20 @@ func (i *mockIngester) Push(ctx context.Context, req *cortexpb.WriteRequest, opt
 		if !ok {
 			// Make a copy because the request Timeseries are reused
 			item := cortexpb.TimeSeries{
-				Labels:  make([]cortexpb.LabelAdapter, len(series.TimeSeries.Labels)),
-				Samples: make([]cortexpb.Sample, len(series.TimeSeries.Samples)),
+				Labels:             make([]cortexpb.LabelAdapter, len(series.TimeSeries.Labels)),
+				Samples:            make([]cortexpb.Sample, len(series.TimeSeries.Samples)),
+				Histograms:         make([]cortexpb.Histogram, len(series.TimeSeries.Histograms)),
+				CreatedTimestampMs: series.TimeSeries.CreatedTimestampMs,
 			}
 
 			copy(item.Labels, series.TimeSeries.Labels)
 			copy(item.Samples, series.TimeSeries.Samples)
+			copy(item.Histograms, series.TimeSeries.Histograms)
 
 			i.timeseries[hash] = &cortexpb.PreallocTimeseries{TimeSeries: &item}
 		} else {
 			existing.Samples = append(existing.Samples, series.Samples...)
+			existing.Histograms = append(existing.Histograms, series.Histograms...)
 		}
 	}
 
@@ -2799,11 +3097,36 @@ func (i *mockIngester) QueryStream(ctx context.Context, req *client.QueryRequest
 		return nil, err
 	}
 
-	results := []*client.QueryStreamResponse{}
+	series := make([]*cortexpb.PreallocTimeseries, 0, len(i.timeseries))
 	for _, ts := range i.timeseries {
+		series = append(series, ts)
+	}
+	if req.SortSeries || req.SeriesLimit > 0 {
+		sort.Slice(series, func(a, b int) bool {
+			return labels.Compare(cortexpb.FromLabelAdaptersToLabels(series[a].Labels), cortexpb.FromLabelAdaptersToLabels(series[b].Labels)) < 0
+		})
+	}
+
+	results := []*client.QueryStreamResponse{}
+	for _, ts := range series {
 		if !match(ts.Labels, matchers) {
 			continue
 		}
+		if req.SeriesLimit > 0 && int64(len(results)) >= req.SeriesLimit {
+			results[len(results)-1].SeriesLimitReached = true
+			break
+		}
+
+		// The native histograms aren't encoded in chunks, and are returned as time series
+		// in the same message as the chunks of the series, like the ingesters do.
+		result := &client.QueryStreamResponse{}
+		results = append(results, result)
+		if len(ts.Histograms) > 0 {
+			result.Timeseries = []cortexpb.TimeSeries{{Labels: ts.Labels, Histograms: ts.Histograms}}
+			if len(ts.Samples) == 0 {
+				continue
+			}
+		}
 
 		c, err := encoding.NewForEncoding(encoding.PrometheusXorChunk)
 		if err != nil {
@@ -2830,6 +3153,12 @@ func (i *mockIngester) QueryStream(ctx context.Context, req *client.QueryRequest
 			chunk := client.Chunk{
 				Encoding: int32(c.Encoding()),
 			}
+			for it, first := c.NewIterator(nil), true; i.chunksTimeRange && it.Scan(); first = false {
+				if first {
+					chunk.StartTimestampMs = int64(it.Value().Timestamp)
+				}
+				chunk.EndTimestampMs = int64(it.Value().Timestamp)
+			}
 			if err := c.Marshal(&buf); err != nil {
 				panic(err)
 			}
@@ -2837,14 +3166,12 @@ func (i *mockIngester) QueryStream(ctx context.Context, req *client.QueryRequest
 			wireChunks = append(wireChunks, chunk)
 		}
 
-		results = append(results, &client.QueryStreamResponse{
-			Chunkseries: []client.TimeSeriesChunk{
-				{
-					Labels: ts.Labels,
-					Chunks: wireChunks,
-				},
+		result.Chunkseries = []client.TimeSeriesChunk{
+			{
+				Labels: ts.Labels,
+				Chunks: wireChunks,
 			},
-		})
+		}
 	}
 	return &queryStream{
 		results: results,
@@ -2910,6 +3237,117 @@ func (i *mockIngester) MetricsForLabelMatchers(ctx context.Context, req *client.
 	return &response, nil
 }
 
+func (i *mockIngester) TSDBStatus(ctx context.Context, req *client.TSDBStatusRequest, opts ...grpc.CallOption) (*client.TSDBStatusResponse, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("TSDBStatus")
+	i.tsdbStatusLimit = req.Limit
+
+	if !i.happy.Load() {
+		return nil, errFail
+	}
+
+	resp := &client.TSDBStatusResponse{MinTime: math.MaxInt64, MaxTime: math.MinInt64}
+	seriesCountByMetricName := map[string]uint64{}
+	labelValues := map[string]map[string]struct{}{}
+	seriesCountByLabelValuePair := map[string]uint64{}
+	for _, ts := range i.timeseries {
+		resp.NumSeries++
+		for _, l := range ts.Labels {
+			if l.Name == model.MetricNameLabel {
+				seriesCountByMetricName[l.Value]++
+			}
+			if labelValues[l.Name] == nil {
+				labelValues[l.Name] = map[string]struct{}{}
+			}
+			labelValues[l.Name][l.Value] = struct{}{}
+			seriesCountByLabelValuePair[l.Name+"="+l.Value]++
+		}
+		for _, s := range ts.Samples {
+			resp.MinTime = util_math.Min64(resp.MinTime, s.TimestampMs)
+			resp.MaxTime = util_math.Max64(resp.MaxTime, s.TimestampMs)
+		}
+	}
+
+	labelValueCountByLabelName := map[string]uint64{}
+	for name, values := range labelValues {
+		labelValueCountByLabelName[name] = uint64(len(values))
+	}
+	limit := int(req.Limit)
+	resp.SeriesCountByMetricName = topTSDBStatistics(seriesCountByMetricName, 1, limit)
+	resp.LabelValueCountByLabelName = topTSDBStatistics(labelValueCountByLabelName, 1, limit)
+	resp.SeriesCountByLabelValuePair = topTSDBStatistics(seriesCountByLabelValuePair, 1, limit)
+	return resp, nil
+}
+
+// LabelValuesSketches returns the sketches of the values of all the labels, since the mock ingester
+// doesn't know the budgets. Its series are the ones of the "user" tenant.
+func (i *mockIngester) LabelValuesSketches(ctx context.Context, req *client.LabelValuesSketchesRequest, opts ...grpc.CallOption) (*client.LabelValuesSketchesResponse, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("LabelValuesSketches")
+
+	if !i.happy.Load() {
+		return nil, errFail
+	}
+
+	labelValues := map[string]map[string]struct{}{}
+	for _, ts := range i.timeseries {
+		for _, l := range ts.Labels {
+			if labelValues[l.Name] == nil {
+				labelValues[l.Name] = map[string]struct{}{}
+			}
+			labelValues[l.Name][l.Value] = struct{}{}
+		}
+	}
+
+	resp := &client.LabelValuesSketchesResponse{}
+	for name, values := range labelValues {
+		requested := false
+		for _, l := range req.ValueHashesLabels {
+			requested = requested || (l.UserId == "user" && l.LabelName == name)
+		}
+
+		s := client.LabelValuesSketch{UserId: "user", LabelName: name}
+		sketch := hll.New()
+		for v := range values {
+			sketch.Insert(client.LabelValueHash(v))
+			if requested {
+				s.ValueHashes = append(s.ValueHashes, client.LabelValueHash(v))
+			}
+		}
+		s.Sketch = sketch.Bytes()
+		resp.Sketches = append(resp.Sketches, s)
+	}
+	return resp, nil
+}
+
+func (i *mockIngester) ScaleDown(ctx context.Context, req *client.ScaleDownRequest, opts ...grpc.CallOption) (*client.ScaleDownResponse, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("ScaleDown/" + req.Action.String())
+
+	if !i.happy.Load() {
+		return nil, errFail
+	}
+
+	switch req.Action {
+	case client.SCALE_DOWN_PREPARE:
+		i.scaleDown.ReadOnly = true
+	case client.SCALE_DOWN_CANCEL:
+		i.scaleDown = client.ScaleDownResponse{}
+	case client.SCALE_DOWN_REMOVE:
+		i.scaleDown.Removable = false
+		i.scaleDown.Removing = true
+	}
+
+	resp := i.scaleDown
+	return &resp, nil
+}
+
 func (i *mockIngester) MetricsMetadata(ctx context.Context, req *client.MetricsMetadataRequest, opts ...grpc.CallOption) (*client.MetricsMetadataResponse, error) {
 	i.Lock()
 	defer i.Unlock()
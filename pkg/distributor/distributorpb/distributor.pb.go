@@ -12,7 +12,11 @@ import (
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	io "io"
 	math "math"
+	math_bits "math/bits"
+	reflect "reflect"
+	strings "strings"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -26,24 +30,419 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+// BatchWriteRequest is a batch of write requests of several tenants, pushed by a trusted forwarder.
+type BatchWriteRequest struct {
+	Requests []TenantWriteRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests"`
+}
+
+func (m *BatchWriteRequest) Reset()      { *m = BatchWriteRequest{} }
+func (*BatchWriteRequest) ProtoMessage() {}
+func (*BatchWriteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c518e33639ca565d, []int{0}
+}
+func (m *BatchWriteRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BatchWriteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BatchWriteRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BatchWriteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchWriteRequest.Merge(m, src)
+}
+func (m *BatchWriteRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *BatchWriteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchWriteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchWriteRequest proto.InternalMessageInfo
+
+func (m *BatchWriteRequest) GetRequests() []TenantWriteRequest {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
+type TenantWriteRequest struct {
+	TenantId string                `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Request  cortexpb.WriteRequest `protobuf:"bytes,2,opt,name=request,proto3" json:"request"`
+}
+
+func (m *TenantWriteRequest) Reset()      { *m = TenantWriteRequest{} }
+func (*TenantWriteRequest) ProtoMessage() {}
+func (*TenantWriteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c518e33639ca565d, []int{1}
+}
+func (m *TenantWriteRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TenantWriteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TenantWriteRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TenantWriteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TenantWriteRequest.Merge(m, src)
+}
+func (m *TenantWriteRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *TenantWriteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TenantWriteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TenantWriteRequest proto.InternalMessageInfo
+
+func (m *TenantWriteRequest) GetTenantId() string {
+	if m != nil {
+		return m.TenantId
+	}
+	return ""
+}
+
+func (m *TenantWriteRequest) GetRequest() cortexpb.WriteRequest {
+	if m != nil {
+		return m.Request
+	}
+	return cortexpb.WriteRequest{}
+}
+
+// BatchWriteResponse has the outcome of each write request of a batch, in the same order.
+type BatchWriteResponse struct {
+	Responses []TenantWriteResponse `protobuf:"bytes,1,rep,name=responses,proto3" json:"responses"`
+}
+
+func (m *BatchWriteResponse) Reset()      { *m = BatchWriteResponse{} }
+func (*BatchWriteResponse) ProtoMessage() {}
+func (*BatchWriteResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c518e33639ca565d, []int{2}
+}
+func (m *BatchWriteResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BatchWriteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BatchWriteResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BatchWriteResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchWriteResponse.Merge(m, src)
+}
+func (m *BatchWriteResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *BatchWriteResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchWriteResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchWriteResponse proto.InternalMessageInfo
+
+func (m *BatchWriteResponse) GetResponses() []TenantWriteResponse {
+	if m != nil {
+		return m.Responses
+	}
+	return nil
+}
+
+type TenantWriteResponse struct {
+	TenantId string `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	// HTTP status code of the write request, 200 on success.
+	Code  int32  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Error string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *TenantWriteResponse) Reset()      { *m = TenantWriteResponse{} }
+func (*TenantWriteResponse) ProtoMessage() {}
+func (*TenantWriteResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c518e33639ca565d, []int{3}
+}
+func (m *TenantWriteResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TenantWriteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TenantWriteResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TenantWriteResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TenantWriteResponse.Merge(m, src)
+}
+func (m *TenantWriteResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *TenantWriteResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TenantWriteResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TenantWriteResponse proto.InternalMessageInfo
+
+func (m *TenantWriteResponse) GetTenantId() string {
+	if m != nil {
+		return m.TenantId
+	}
+	return ""
+}
+
+func (m *TenantWriteResponse) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *TenantWriteResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*BatchWriteRequest)(nil), "distributor.BatchWriteRequest")
+	proto.RegisterType((*TenantWriteRequest)(nil), "distributor.TenantWriteRequest")
+	proto.RegisterType((*BatchWriteResponse)(nil), "distributor.BatchWriteResponse")
+	proto.RegisterType((*TenantWriteResponse)(nil), "distributor.TenantWriteResponse")
+}
+
 func init() { proto.RegisterFile("distributor.proto", fileDescriptor_c518e33639ca565d) }
 
 var fileDescriptor_c518e33639ca565d = []byte{
-	// 212 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x4c, 0xc9, 0x2c, 0x2e,
-	0x29, 0xca, 0x4c, 0x2a, 0x2d, 0xc9, 0x2f, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x46,
-	0x12, 0x92, 0x12, 0x49, 0xcf, 0x4f, 0xcf, 0x07, 0x8b, 0xeb, 0x83, 0x58, 0x10, 0x25, 0x52, 0x96,
-	0xe9, 0x99, 0x25, 0x19, 0xa5, 0x49, 0x7a, 0xc9, 0xf9, 0xb9, 0xfa, 0xc9, 0xf9, 0x45, 0x25, 0xa9,
-	0x15, 0x05, 0x45, 0xf9, 0x59, 0xa9, 0xc9, 0x25, 0x50, 0x9e, 0x7e, 0x41, 0x76, 0x3a, 0x4c, 0x22,
-	0x09, 0xca, 0x80, 0x68, 0x35, 0xf2, 0xe0, 0xe2, 0x76, 0x41, 0x98, 0x2f, 0x64, 0xc9, 0xc5, 0x12,
-	0x50, 0x5a, 0x9c, 0x21, 0x24, 0xa6, 0x07, 0x53, 0xae, 0x17, 0x5e, 0x94, 0x59, 0x92, 0x1a, 0x94,
-	0x5a, 0x58, 0x9a, 0x5a, 0x5c, 0x22, 0x25, 0x8e, 0x21, 0x5e, 0x5c, 0x90, 0x9f, 0x57, 0x9c, 0xaa,
-	0xc4, 0xe0, 0xe4, 0x7c, 0xe1, 0xa1, 0x1c, 0xc3, 0x8d, 0x87, 0x72, 0x0c, 0x1f, 0x1e, 0xca, 0x31,
-	0x36, 0x3c, 0x92, 0x63, 0x5c, 0xf1, 0x48, 0x8e, 0xf1, 0xc4, 0x23, 0x39, 0xc6, 0x0b, 0x8f, 0xe4,
-	0x18, 0x1f, 0x3c, 0x92, 0x63, 0x7c, 0xf1, 0x48, 0x8e, 0xe1, 0xc3, 0x23, 0x39, 0xc6, 0x09, 0x8f,
-	0xe5, 0x18, 0x2e, 0x3c, 0x96, 0x63, 0xb8, 0xf1, 0x58, 0x8e, 0x21, 0x8a, 0x17, 0xc9, 0x77, 0x05,
-	0x49, 0x49, 0x6c, 0x60, 0x57, 0x19, 0x03, 0x02, 0x00, 0x00, 0xff, 0xff, 0x0d, 0x5b, 0x37, 0x6f,
-	0x08, 0x01, 0x00, 0x00,
+	// 378 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x52, 0x31, 0x4b, 0xc3, 0x40,
+	0x18, 0xbd, 0xb3, 0xad, 0x36, 0x17, 0x1c, 0x7a, 0x16, 0x0d, 0x11, 0xae, 0x21, 0x53, 0xa7, 0x14,
+	0x2a, 0x08, 0x1d, 0xad, 0x5d, 0x5c, 0x44, 0x82, 0x28, 0x14, 0x41, 0x9a, 0xe4, 0x48, 0xa3, 0xd8,
+	0x8b, 0x97, 0x0b, 0x38, 0xfa, 0x13, 0x1c, 0xfd, 0x09, 0xfe, 0x94, 0x8e, 0x1d, 0x3b, 0x89, 0x4d,
+	0x17, 0xc7, 0xfe, 0x04, 0x69, 0x2e, 0xb1, 0x91, 0xb6, 0x6e, 0xef, 0x7b, 0xdf, 0x7b, 0x5f, 0xde,
+	0x4b, 0x82, 0x6a, 0x5e, 0x10, 0x09, 0x1e, 0x38, 0xb1, 0x60, 0xdc, 0x0a, 0x39, 0x13, 0x0c, 0xab,
+	0x05, 0x4a, 0xaf, 0xfb, 0xcc, 0x67, 0x29, 0xdf, 0x5a, 0x22, 0x29, 0xd1, 0x3b, 0x7e, 0x20, 0x86,
+	0xb1, 0x63, 0xb9, 0xec, 0xa9, 0xe5, 0x32, 0x2e, 0xe8, 0x4b, 0xc8, 0xd9, 0x03, 0x75, 0x45, 0x36,
+	0xb5, 0xc2, 0x47, 0x3f, 0x5f, 0x38, 0x19, 0x90, 0x56, 0xf3, 0x06, 0xd5, 0xba, 0x03, 0xe1, 0x0e,
+	0x6f, 0x79, 0x20, 0xa8, 0x4d, 0x9f, 0x63, 0x1a, 0x09, 0x7c, 0x86, 0xaa, 0x5c, 0xc2, 0x48, 0x83,
+	0x46, 0xa9, 0xa9, 0xb6, 0x1b, 0x56, 0x31, 0xd8, 0x35, 0x1d, 0x0d, 0x46, 0xa2, 0x68, 0xe9, 0x96,
+	0xc7, 0x9f, 0x0d, 0x60, 0xff, 0xda, 0xcc, 0x00, 0xe1, 0x75, 0x15, 0x3e, 0x46, 0x8a, 0x48, 0xd9,
+	0xfb, 0xc0, 0xd3, 0xa0, 0x01, 0x9b, 0x8a, 0x5d, 0x95, 0xc4, 0x85, 0x87, 0x4f, 0xd1, 0x5e, 0x66,
+	0xd7, 0x76, 0x0c, 0xd8, 0x54, 0xdb, 0x87, 0x56, 0x9e, 0xd9, 0xda, 0xf0, 0xac, 0x5c, 0x6c, 0xf6,
+	0x11, 0x2e, 0x56, 0x88, 0x42, 0x36, 0x8a, 0x28, 0xee, 0x21, 0x85, 0x67, 0x38, 0x2f, 0x61, 0x6c,
+	0x2f, 0x21, 0x85, 0xd9, 0xe5, 0x95, 0xd1, 0xbc, 0x43, 0x07, 0x1b, 0x74, 0xff, 0xf7, 0xc0, 0xa8,
+	0xec, 0x32, 0x8f, 0xa6, 0x25, 0x2a, 0x76, 0x8a, 0x71, 0x1d, 0x55, 0x28, 0xe7, 0x8c, 0x6b, 0xa5,
+	0x54, 0x2c, 0x87, 0xf6, 0x3b, 0x44, 0x6a, 0x6f, 0x15, 0x09, 0x77, 0x50, 0xf9, 0x2a, 0x8e, 0x86,
+	0x78, 0x4b, 0x71, 0xfd, 0x68, 0x8d, 0x97, 0x79, 0x4c, 0x80, 0x2f, 0x91, 0xb2, 0xb4, 0xa6, 0x2f,
+	0x02, 0x93, 0x3f, 0x45, 0xd7, 0xbe, 0xaf, 0xde, 0xd8, 0xba, 0xcf, 0xef, 0x75, 0xcf, 0x27, 0x33,
+	0x02, 0xa6, 0x33, 0x02, 0x16, 0x33, 0x02, 0x5f, 0x13, 0x02, 0x3f, 0x12, 0x02, 0xc7, 0x09, 0x81,
+	0x93, 0x84, 0xc0, 0xaf, 0x84, 0xc0, 0xef, 0x84, 0x80, 0x45, 0x42, 0xe0, 0xdb, 0x9c, 0x80, 0xc9,
+	0x9c, 0x80, 0xe9, 0x9c, 0x80, 0xfe, 0x7e, 0xe1, 0x6e, 0xe8, 0x38, 0xbb, 0xe9, 0x3f, 0x76, 0xf2,
+	0x33, 0x00, 0x18, 0x36, 0x61, 0x8e, 0xd6, 0x02, 0x00, 0x00,
+}
+
+func (this *BatchWriteRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*BatchWriteRequest)
+	if !ok {
+		that2, ok := that.(BatchWriteRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Requests) != len(that1.Requests) {
+		return false
+	}
+	for i := range this.Requests {
+		if !this.Requests[i].Equal(&that1.Requests[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *TenantWriteRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TenantWriteRequest)
+	if !ok {
+		that2, ok := that.(TenantWriteRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.TenantId != that1.TenantId {
+		return false
+	}
+	if !this.Request.Equal(&that1.Request) {
+		return false
+	}
+	return true
+}
+func (this *BatchWriteResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*BatchWriteResponse)
+	if !ok {
+		that2, ok := that.(BatchWriteResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Responses) != len(that1.Responses) {
+		return false
+	}
+	for i := range this.Responses {
+		if !this.Responses[i].Equal(&that1.Responses[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *TenantWriteResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TenantWriteResponse)
+	if !ok {
+		that2, ok := that.(TenantWriteResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.TenantId != that1.TenantId {
+		return false
+	}
+	if this.Code != that1.Code {
+		return false
+	}
+	if this.Error != that1.Error {
+		return false
+	}
+	return true
+}
+func (this *BatchWriteRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&distributorpb.BatchWriteRequest{")
+	if this.Requests != nil {
+		vs := make([]TenantWriteRequest, len(this.Requests))
+		for i := range vs {
+			vs[i] = this.Requests[i]
+		}
+		s = append(s, "Requests: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *TenantWriteRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&distributorpb.TenantWriteRequest{")
+	s = append(s, "TenantId: "+fmt.Sprintf("%#v", this.TenantId)+",\n")
+	s = append(s, "Request: "+strings.Replace(this.Request.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *BatchWriteResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&distributorpb.BatchWriteResponse{")
+	if this.Responses != nil {
+		vs := make([]TenantWriteResponse, len(this.Responses))
+		for i := range vs {
+			vs[i] = this.Responses[i]
+		}
+		s = append(s, "Responses: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *TenantWriteResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&distributorpb.TenantWriteResponse{")
+	s = append(s, "TenantId: "+fmt.Sprintf("%#v", this.TenantId)+",\n")
+	s = append(s, "Code: "+fmt.Sprintf("%#v", this.Code)+",\n")
+	s = append(s, "Error: "+fmt.Sprintf("%#v", this.Error)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringDistributor(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -59,6 +458,7 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type DistributorClient interface {
 	Push(ctx context.Context, in *cortexpb.WriteRequest, opts ...grpc.CallOption) (*cortexpb.WriteResponse, error)
+	PushBatch(ctx context.Context, in *BatchWriteRequest, opts ...grpc.CallOption) (*BatchWriteResponse, error)
 }
 
 type distributorClient struct {
@@ -78,9 +478,19 @@ func (c *distributorClient) Push(ctx context.Context, in *cortexpb.WriteRequest,
 	return out, nil
 }
 
+func (c *distributorClient) PushBatch(ctx context.Context, in *BatchWriteRequest, opts ...grpc.CallOption) (*BatchWriteResponse, error) {
+	out := new(BatchWriteResponse)
+	err := c.cc.Invoke(ctx, "/distributor.Distributor/PushBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DistributorServer is the server API for Distributor service.
 type DistributorServer interface {
 	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
+	PushBatch(context.Context, *BatchWriteRequest) (*BatchWriteResponse, error)
 }
 
 // UnimplementedDistributorServer can be embedded to have forward compatible implementations.
@@ -90,6 +500,9 @@ type UnimplementedDistributorServer struct {
 func (*UnimplementedDistributorServer) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Push not implemented")
 }
+func (*UnimplementedDistributorServer) PushBatch(ctx context.Context, req *BatchWriteRequest) (*BatchWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushBatch not implemented")
+}
 
 func RegisterDistributorServer(s *grpc.Server, srv DistributorServer) {
 	s.RegisterService(&_Distributor_serviceDesc, srv)
@@ -113,6 +526,24 @@ func _Distributor_Push_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Distributor_PushBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchWriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DistributorServer).PushBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/distributor.Distributor/PushBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DistributorServer).PushBatch(ctx, req.(*BatchWriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Distributor_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "distributor.Distributor",
 	HandlerType: (*DistributorServer)(nil),
@@ -121,7 +552,811 @@ var _Distributor_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Push",
 			Handler:    _Distributor_Push_Handler,
 		},
+		{
+			MethodName: "PushBatch",
+			Handler:    _Distributor_PushBatch_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "distributor.proto",
 }
+
+func (m *BatchWriteRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BatchWriteRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchWriteRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Requests) > 0 {
+		for iNdEx := len(m.Requests) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Requests[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintDistributor(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TenantWriteRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TenantWriteRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TenantWriteRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	{
+		size, err := m.Request.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintDistributor(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x12
+	if len(m.TenantId) > 0 {
+		i -= len(m.TenantId)
+		copy(dAtA[i:], m.TenantId)
+		i = encodeVarintDistributor(dAtA, i, uint64(len(m.TenantId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BatchWriteResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BatchWriteResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchWriteResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Responses) > 0 {
+		for iNdEx := len(m.Responses) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Responses[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintDistributor(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TenantWriteResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TenantWriteResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TenantWriteResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Error) > 0 {
+		i -= len(m.Error)
+		copy(dAtA[i:], m.Error)
+		i = encodeVarintDistributor(dAtA, i, uint64(len(m.Error)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Code != 0 {
+		i = encodeVarintDistributor(dAtA, i, uint64(m.Code))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.TenantId) > 0 {
+		i -= len(m.TenantId)
+		copy(dAtA[i:], m.TenantId)
+		i = encodeVarintDistributor(dAtA, i, uint64(len(m.TenantId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintDistributor(dAtA []byte, offset int, v uint64) int {
+	offset -= sovDistributor(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *BatchWriteRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Requests) > 0 {
+		for _, e := range m.Requests {
+			l = e.Size()
+			n += 1 + l + sovDistributor(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *TenantWriteRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.TenantId)
+	if l > 0 {
+		n += 1 + l + sovDistributor(uint64(l))
+	}
+	l = m.Request.Size()
+	n += 1 + l + sovDistributor(uint64(l))
+	return n
+}
+
+func (m *BatchWriteResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Responses) > 0 {
+		for _, e := range m.Responses {
+			l = e.Size()
+			n += 1 + l + sovDistributor(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *TenantWriteResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.TenantId)
+	if l > 0 {
+		n += 1 + l + sovDistributor(uint64(l))
+	}
+	if m.Code != 0 {
+		n += 1 + sovDistributor(uint64(m.Code))
+	}
+	l = len(m.Error)
+	if l > 0 {
+		n += 1 + l + sovDistributor(uint64(l))
+	}
+	return n
+}
+
+func sovDistributor(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozDistributor(x uint64) (n int) {
+	return sovDistributor(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *BatchWriteRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForRequests := "[]TenantWriteRequest{"
+	for _, f := range this.Requests {
+		repeatedStringForRequests += strings.Replace(strings.Replace(f.String(), "TenantWriteRequest", "TenantWriteRequest", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForRequests += "}"
+	s := strings.Join([]string{`&BatchWriteRequest{`,
+		`Requests:` + repeatedStringForRequests + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TenantWriteRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TenantWriteRequest{`,
+		`TenantId:` + fmt.Sprintf("%v", this.TenantId) + `,`,
+		`Request:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Request), "WriteRequest", "cortexpb.WriteRequest", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *BatchWriteResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForResponses := "[]TenantWriteResponse{"
+	for _, f := range this.Responses {
+		repeatedStringForResponses += strings.Replace(strings.Replace(f.String(), "TenantWriteResponse", "TenantWriteResponse", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForResponses += "}"
+	s := strings.Join([]string{`&BatchWriteResponse{`,
+		`Responses:` + repeatedStringForResponses + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TenantWriteResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TenantWriteResponse{`,
+		`TenantId:` + fmt.Sprintf("%v", this.TenantId) + `,`,
+		`Code:` + fmt.Sprintf("%v", this.Code) + `,`,
+		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringDistributor(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *BatchWriteRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDistributor
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BatchWriteRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BatchWriteRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Requests", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDistributor
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Requests = append(m.Requests, TenantWriteRequest{})
+			if err := m.Requests[len(m.Requests)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDistributor(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TenantWriteRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDistributor
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TenantWriteRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TenantWriteRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TenantId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDistributor
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TenantId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Request", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDistributor
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Request.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDistributor(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BatchWriteResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDistributor
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BatchWriteResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BatchWriteResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Responses", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDistributor
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Responses = append(m.Responses, TenantWriteResponse{})
+			if err := m.Responses[len(m.Responses)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDistributor(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TenantWriteResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDistributor
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TenantWriteResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TenantWriteResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TenantId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDistributor
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TenantId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Code", wireType)
+			}
+			m.Code = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDistributor
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Code |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDistributor
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Error = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDistributor(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthDistributor
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipDistributor(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowDistributor
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowDistributor
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowDistributor
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthDistributor
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupDistributor
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthDistributor
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthDistributor        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowDistributor          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupDistributor = fmt.Errorf("proto: unexpected end of group")
+)
@@ -0,0 +1,191 @@
+package distributor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log/level"
+
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// Phases of an ingester being scaled down.
+const (
+	scaleDownPhasePreparing = "preparing" // Switching to read-only, and flushing and shipping its series.
+	scaleDownPhaseDraining  = "draining"  // Serving queries until the shipped blocks are queryable from the storage.
+	scaleDownPhaseRemoving  = "removing"  // Leaving the ring.
+	scaleDownPhaseWaiting   = "waiting"   // Waiting for the ingester to be ACTIVE in the ring.
+)
+
+type ingesterScaleDownDesc struct {
+	ID              string `json:"id"`
+	Addr            string `json:"addr"`
+	State           string `json:"state"`
+	Phase           string `json:"phase"`
+	Shipped         bool   `json:"shipped"`
+	InflightQueries int64  `json:"inflight_queries"`
+	Error           string `json:"error,omitempty"`
+}
+
+type ingestersScaleDownStatus struct {
+	Replicas        int                     `json:"replicas"`
+	DesiredReplicas int                     `json:"desired_replicas"`
+	Done            bool                    `json:"done"`
+	Ingesters       []ingesterScaleDownDesc `json:"ingesters"`
+}
+
+// IngestersScaleDownHandler serves the scale-down of the ingesters to the "replicas" count. On
+// GET it only returns the progress, on POST it also moves each ingester being scaled down to the
+// next step: being switched to read-only, so that its series are flushed and shipped to the
+// storage, and, once they've been queryable from the storage for the drain period, leaving the
+// ring. Ingesters switched to read-only but not being scaled down anymore are switched back to
+// active.
+//
+// The controller is stateless, since the progress is tracked by the ingesters and the ring, so
+// that it can be driven by an operator (eg. a Kubernetes one) calling it periodically through
+// any distributor, until it's done. The ingesters with the highest ordinal in their ID are
+// scaled down first, like the pods of a StatefulSet, and can be deleted once not in the ring.
+func (d *Distributor) IngestersScaleDownHandler(w http.ResponseWriter, r *http.Request) {
+	replicas, err := strconv.Atoi(r.FormValue("replicas"))
+	if err != nil || replicas < 1 {
+		http.Error(w, "the replicas parameter must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	status, err := d.scaleDownIngesters(r.Context(), replicas, r.Method == http.MethodPost)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	util.WriteJSONResponse(w, status)
+}
+
+// scaleDownIngesters returns the progress of the scale-down of the ingesters to the desired
+// replicas and, if apply is true, moves each ingester to the next step.
+func (d *Distributor) scaleDownIngesters(ctx context.Context, desiredReplicas int, apply bool) (ingestersScaleDownStatus, error) {
+	instances, err := d.ingestersRing.GetInstanceDescsForOperation(ring.Reporting)
+	if err != nil {
+		return ingestersScaleDownStatus{}, err
+	}
+
+	// Don't pick the ingesters to scale down without a complete view of the ring, since the
+	// unhealthy ingesters could be the ones with the highest ordinal.
+	if unhealthy := d.ingestersRing.InstancesCount() - len(instances); unhealthy > 0 {
+		return ingestersScaleDownStatus{}, fmt.Errorf("cannot scale down the ingesters while %d of them are unhealthy", unhealthy)
+	}
+
+	ids := make([]string, 0, len(instances))
+	for id := range instances {
+		ids = append(ids, id)
+	}
+	sortIngestersByOrdinalDesc(ids)
+
+	status := ingestersScaleDownStatus{
+		Replicas:        len(ids),
+		DesiredReplicas: desiredReplicas,
+		Done:            len(ids) <= desiredReplicas,
+		Ingesters:       []ingesterScaleDownDesc{},
+	}
+
+	for idx, id := range ids {
+		instance := instances[id]
+		scaleDown := idx < len(ids)-desiredReplicas
+
+		// The ingesters not being scaled down only need to be switched back to active.
+		if !scaleDown && instance.State != ring.READONLY {
+			continue
+		}
+
+		desc := ingesterScaleDownDesc{ID: id, Addr: instance.Addr, State: instance.State.String()}
+		if err := d.scaleDownIngester(ctx, instance, scaleDown, apply, &desc); err != nil {
+			level.Warn(d.log).Log("msg", "failed to scale down ingester", "ingester", id, "err", err)
+			desc.Error = err.Error()
+		}
+		if scaleDown {
+			status.Ingesters = append(status.Ingesters, desc)
+		}
+	}
+
+	return status, nil
+}
+
+// scaleDownIngester fills the scale-down progress of an ingester and, if apply is true, moves
+// it to the next step. If scaleDown is false, the ingester is switched back to active.
+func (d *Distributor) scaleDownIngester(ctx context.Context, instance ring.InstanceDesc, scaleDown, apply bool, desc *ingesterScaleDownDesc) error {
+	switch instance.State {
+	case ring.ACTIVE, ring.READONLY:
+	case ring.LEAVING:
+		desc.Phase = scaleDownPhaseRemoving
+		return nil
+	default:
+		desc.Phase = scaleDownPhaseWaiting
+		return nil
+	}
+
+	c, err := d.ingesterPool.GetClientFor(instance.Addr)
+	if err != nil {
+		return err
+	}
+	client := c.(ingester_client.IngesterClient)
+
+	if !scaleDown {
+		if apply {
+			_, err = client.ScaleDown(ctx, &ingester_client.ScaleDownRequest{Action: ingester_client.SCALE_DOWN_CANCEL})
+		}
+		return err
+	}
+
+	action := ingester_client.SCALE_DOWN_STATUS
+	if apply {
+		action = ingester_client.SCALE_DOWN_PREPARE
+	}
+	resp, err := client.ScaleDown(ctx, &ingester_client.ScaleDownRequest{Action: action})
+	if err != nil {
+		desc.Phase = scaleDownPhasePreparing
+		return err
+	}
+	if apply && resp.Removable {
+		if resp, err = client.ScaleDown(ctx, &ingester_client.ScaleDownRequest{Action: ingester_client.SCALE_DOWN_REMOVE}); err != nil {
+			desc.Phase = scaleDownPhaseDraining
+			return err
+		}
+	}
+
+	desc.Shipped = resp.Shipped
+	desc.InflightQueries = resp.InflightQueries
+	switch {
+	case resp.Removing:
+		desc.Phase = scaleDownPhaseRemoving
+	case resp.ReadOnly && resp.Shipped:
+		desc.Phase = scaleDownPhaseDraining
+	default:
+		desc.Phase = scaleDownPhasePreparing
+	}
+	return nil
+}
+
+// sortIngestersByOrdinalDesc sorts the ingester IDs by the ordinal they end with, highest first.
+// The IDs without an ordinal are sorted last.
+func sortIngestersByOrdinalDesc(ids []string) {
+	ordinal := func(id string) int {
+		n, err := strconv.Atoi(id[strings.LastIndexFunc(id, func(r rune) bool { return r < '0' || r > '9' })+1:])
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		if oi, oj := ordinal(ids[i]), ordinal(ids[j]); oi != oj {
+			return oi > oj
+		}
+		return ids[i] > ids[j]
+	})
+}
@@ -0,0 +1,86 @@
+package distributor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+)
+
+func TestDistributor_IngestersScaleDownHandler(t *testing.T) {
+	t.Parallel()
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     5,
+		happyIngesters:   5,
+		numDistributors:  1,
+		shardByAllLabels: true,
+	})
+
+	scaleDown := func(method, replicas string) (int, ingestersScaleDownStatus) {
+		recorder := httptest.NewRecorder()
+		ds[0].IngestersScaleDownHandler(recorder, httptest.NewRequest(method, "/distributor/ingesters_scale_down?replicas="+replicas, nil))
+
+		var status ingestersScaleDownStatus
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+		}
+		return recorder.Code, status
+	}
+	phases := func(status ingestersScaleDownStatus) map[string]string {
+		out := map[string]string{}
+		for _, ing := range status.Ingesters {
+			out[ing.ID] = ing.Phase
+		}
+		return out
+	}
+
+	code, _ := scaleDown(http.MethodGet, "0")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// GET only returns the progress, without preparing the ingesters.
+	code, status := scaleDown(http.MethodGet, "3")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 5, status.Replicas)
+	assert.Equal(t, 3, status.DesiredReplicas)
+	assert.False(t, status.Done)
+	assert.Equal(t, map[string]string{"4": scaleDownPhasePreparing, "3": scaleDownPhasePreparing}, phases(status))
+	assert.Equal(t, 0, countMockIngestersCalls(ingesters, "ScaleDown/"+client.SCALE_DOWN_PREPARE.String()))
+
+	// POST prepares the ingesters with the highest ordinal.
+	_, status = scaleDown(http.MethodPost, "3")
+	assert.Equal(t, map[string]string{"4": scaleDownPhasePreparing, "3": scaleDownPhasePreparing}, phases(status))
+	assert.Equal(t, 1, ingesters[4].countCalls("ScaleDown/"+client.SCALE_DOWN_PREPARE.String()))
+	assert.Equal(t, 1, ingesters[3].countCalls("ScaleDown/"+client.SCALE_DOWN_PREPARE.String()))
+	assert.Equal(t, 2, countMockIngestersCalls(ingesters, "ScaleDown/"+client.SCALE_DOWN_PREPARE.String()))
+
+	// Shipped ingesters are drained, and removed once removable.
+	ingesters[3].Lock()
+	ingesters[3].scaleDown.Shipped = true
+	ingesters[3].Unlock()
+	ingesters[4].Lock()
+	ingesters[4].scaleDown.Shipped = true
+	ingesters[4].scaleDown.Removable = true
+	ingesters[4].Unlock()
+
+	_, status = scaleDown(http.MethodPost, "3")
+	assert.Equal(t, map[string]string{"4": scaleDownPhaseRemoving, "3": scaleDownPhaseDraining}, phases(status))
+	assert.Equal(t, 1, countMockIngestersCalls(ingesters, "ScaleDown/"+client.SCALE_DOWN_REMOVE.String()))
+	assert.Equal(t, 1, ingesters[4].countCalls("ScaleDown/"+client.SCALE_DOWN_REMOVE.String()))
+
+	// Scaling to the current replicas is a no-op.
+	_, status = scaleDown(http.MethodPost, "5")
+	assert.True(t, status.Done)
+	assert.Empty(t, status.Ingesters)
+}
+
+func TestSortIngestersByOrdinalDesc(t *testing.T) {
+	ids := []string{"ingester-zone-a-2", "ingester-zone-b-10", "ingester", "ingester-zone-a-9", "ingester-zone-b-2"}
+	sortIngestersByOrdinalDesc(ids)
+	assert.Equal(t, []string{"ingester-zone-b-10", "ingester-zone-a-9", "ingester-zone-b-2", "ingester-zone-a-2", "ingester"}, ids)
+}
@@ -0,0 +1,242 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+	"github.com/cortexproject/cortex/pkg/util/hll"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// The number of ingesters the sketches of the label values are concurrently fetched from.
+const labelValuesSketchesConcurrency = 16
+
+// LabelValuesBudgetsConfig configures the enforcement of the label values budgets of the tenants.
+type LabelValuesBudgetsConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	SyncPeriod time.Duration `yaml:"sync_period"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *LabelValuesBudgetsConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.label-values-budgets.enabled", false, "Experimental: Enforce the label_values_budgets of the tenants, rejecting the series with a new value of a label whose number of distinct values, approximately counted by the ingesters, exceeds its budget.")
+	f.DurationVar(&cfg.SyncPeriod, "distributor.label-values-budgets.sync-period", time.Minute, "How often the distributor syncs the number of distinct values of the labels with a budget, and the values of the labels over their budget, from the ingesters.")
+}
+
+// Validate validates the config.
+func (cfg *LabelValuesBudgetsConfig) Validate() error {
+	if cfg.Enabled && cfg.SyncPeriod <= 0 {
+		return errors.New("the label values budgets sync period must be greater than 0")
+	}
+	return nil
+}
+
+// exceededLabel is a label over its values budget, with the hashes of its values known by the ingesters.
+type exceededLabel struct {
+	budget int
+	values map[uint64]struct{}
+}
+
+// labelValuesBudgets tracks the labels of the tenants over their values budget, synced from the
+// sketches of the ingesters. The series with a new value of these labels are rejected, until
+// enough values are removed from the memory of the ingesters.
+type labelValuesBudgets struct {
+	limits *validation.Overrides
+
+	mtx sync.RWMutex
+	// The labels over their budget, by tenant and label name.
+	exceeded map[string]map[string]exceededLabel
+
+	labelValues  *prometheus.GaugeVec
+	syncFailures prometheus.Counter
+}
+
+func newLabelValuesBudgets(limits *validation.Overrides, reg prometheus.Registerer) *labelValuesBudgets {
+	return &labelValuesBudgets{
+		limits:   limits,
+		exceeded: map[string]map[string]exceededLabel{},
+		labelValues: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_distributor_label_values",
+			Help: "The estimated number of distinct values of the labels with a values budget, among the in-memory series of the tenant.",
+		}, []string{"user", "label_name"}),
+		syncFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_label_values_budgets_sync_failures_total",
+			Help: "The total number of failures to sync the label values budgets from the ingesters.",
+		}),
+	}
+}
+
+// validate returns an error if the series has a new value of a label over its budget.
+// The returned error may retain the provided series labels.
+func (b *labelValuesBudgets) validate(userID string, ls []cortexpb.LabelAdapter) validation.ValidationError {
+	if b == nil {
+		return nil
+	}
+
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	exceeded := b.exceeded[userID]
+	if len(exceeded) == 0 {
+		return nil
+	}
+	for _, l := range ls {
+		label, ok := exceeded[l.Name]
+		if !ok {
+			continue
+		}
+		if _, ok := label.values[ingester_client.LabelValueHash(l.Value)]; !ok {
+			validation.DiscardedSamples.WithLabelValues(validation.LabelValuesBudgetExceeded, userID).Inc()
+			return validation.NewLabelValuesBudgetExceededError(ls, l.Name, label.budget)
+		}
+	}
+	return nil
+}
+
+// valueHashesLabels returns the labels over their budget, whose values are synced.
+func (b *labelValuesBudgets) valueHashesLabels() []ingester_client.UserLabelName {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	var out []ingester_client.UserLabelName
+	for userID, labels := range b.exceeded {
+		for name := range labels {
+			out = append(out, ingester_client.UserLabelName{UserId: userID, LabelName: name})
+		}
+	}
+	return out
+}
+
+// update replaces the labels over their budget with the ones of the merged sketches of the ingesters.
+// The values of the labels are only known if requested, so the labels over their budget whose
+// values weren't requested are returned, and only enforced once their values have been synced.
+func (b *labelValuesBudgets) update(req *ingester_client.LabelValuesSketchesRequest, resps []*ingester_client.LabelValuesSketchesResponse) ([]ingester_client.UserLabelName, error) {
+	requested := map[ingester_client.UserLabelName]bool{}
+	for _, l := range req.ValueHashesLabels {
+		requested[l] = true
+	}
+
+	sketches := map[ingester_client.UserLabelName]*hll.Sketch{}
+	values := map[ingester_client.UserLabelName]map[uint64]struct{}{}
+	for _, resp := range resps {
+		for _, s := range resp.Sketches {
+			key := ingester_client.UserLabelName{UserId: s.UserId, LabelName: s.LabelName}
+			sketch, err := hll.FromBytes(s.Sketch)
+			if err != nil {
+				return nil, err
+			}
+			if merged, ok := sketches[key]; ok {
+				merged.Merge(sketch)
+			} else {
+				sketches[key] = sketch
+			}
+
+			if !requested[key] {
+				continue
+			}
+			if values[key] == nil {
+				values[key] = map[uint64]struct{}{}
+			}
+			for _, h := range s.ValueHashes {
+				values[key][h] = struct{}{}
+			}
+		}
+	}
+
+	var missing []ingester_client.UserLabelName
+	exceeded := map[string]map[string]exceededLabel{}
+	b.labelValues.Reset()
+	for key, sketch := range sketches {
+		budget := b.limits.LabelValuesBudgets(key.UserId)[key.LabelName]
+		if budget <= 0 {
+			continue
+		}
+
+		estimate := sketch.Estimate()
+		b.labelValues.WithLabelValues(key.UserId, key.LabelName).Set(float64(estimate))
+		if estimate <= uint64(budget) {
+			continue
+		}
+		if !requested[key] {
+			missing = append(missing, key)
+			continue
+		}
+		if exceeded[key.UserId] == nil {
+			exceeded[key.UserId] = map[string]exceededLabel{}
+		}
+		exceeded[key.UserId][key.LabelName] = exceededLabel{budget: budget, values: values[key]}
+	}
+
+	b.mtx.Lock()
+	b.exceeded = exceeded
+	b.mtx.Unlock()
+	return missing, nil
+}
+
+func (b *labelValuesBudgets) deleteUser(userID string) {
+	b.labelValues.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
+
+// syncLabelValuesBudgets syncs the labels over their values budget from the ingesters. The values of
+// the labels newly over their budget are fetched right away, so that they're enforced without delay.
+func (d *Distributor) syncLabelValuesBudgets(ctx context.Context) {
+	req := &ingester_client.LabelValuesSketchesRequest{ValueHashesLabels: d.labelValuesBudgets.valueHashesLabels()}
+
+	var missing []ingester_client.UserLabelName
+	resps, err := d.labelValuesSketches(ctx, req)
+	if err == nil {
+		missing, err = d.labelValuesBudgets.update(req, resps)
+	}
+	if err == nil && len(missing) > 0 {
+		req = &ingester_client.LabelValuesSketchesRequest{ValueHashesLabels: append(req.ValueHashesLabels, missing...)}
+		resps, err = d.labelValuesSketches(ctx, req)
+		if err == nil {
+			_, err = d.labelValuesBudgets.update(req, resps)
+		}
+	}
+	if err != nil {
+		d.labelValuesBudgets.syncFailures.Inc()
+		level.Warn(d.log).Log("msg", "failed to sync the label values budgets from the ingesters", "err", err)
+	}
+}
+
+// labelValuesSketches returns the sketches of the label values of all the ingesters. It fails if any
+// ingester fails, since the values known by the other ingesters would be incomplete.
+func (d *Distributor) labelValuesSketches(ctx context.Context, req *ingester_client.LabelValuesSketchesRequest) ([]*ingester_client.LabelValuesSketchesResponse, error) {
+	ctx = user.InjectOrgID(ctx, "1") // fake: ingester insists on having an org ID
+	replicationSet, err := d.ingestersRing.GetAllHealthy(ring.Read)
+	if err != nil {
+		return nil, err
+	}
+
+	resps := make([]*ingester_client.LabelValuesSketchesResponse, len(replicationSet.Instances))
+	jobs := make([]interface{}, 0, len(replicationSet.Instances))
+	for i := range replicationSet.Instances {
+		jobs = append(jobs, i)
+	}
+	err = concurrency.ForEach(ctx, jobs, labelValuesSketchesConcurrency, func(ctx context.Context, job interface{}) error {
+		i := job.(int)
+		client, err := d.ingesterPool.GetClientFor(replicationSet.Instances[i].Addr)
+		if err != nil {
+			return err
+		}
+		resps[i], err = client.(ingester_client.IngesterClient).LabelValuesSketches(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resps, nil
+}
@@ -0,0 +1,133 @@
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestDistributor_LabelValuesBudgets(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.LabelValuesBudgets = map[string]int{"pod": 2}
+
+	ds, ingesters, regs, _ := prepare(t, prepConfig{
+		numIngesters:       3,
+		happyIngesters:     3,
+		numDistributors:    1,
+		shardByAllLabels:   true,
+		limits:             limits,
+		labelValuesBudgets: true,
+	})
+	d, reg := ds[0], regs[0]
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	push := func(pod, instance string) error {
+		_, err := d.Push(ctx, mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "up", "pod", pod, "instance", instance)}, 1, 100000))
+		return err
+	}
+	// Wait for the series to be pushed to all the ingesters before syncing.
+	sync := func(numSeries int) {
+		for _, ing := range ingesters {
+			require.Eventually(t, func() bool { return len(ing.series()) == numSeries }, time.Second, 10*time.Millisecond)
+		}
+		d.syncLabelValuesBudgets(context.Background())
+	}
+
+	require.NoError(t, push("pod-1", "instance-1"))
+	require.NoError(t, push("pod-2", "instance-2"))
+	sync(2)
+
+	// The series with new values are accepted until the number of values is over the budget.
+	require.NoError(t, push("pod-3", "instance-3"))
+	sync(3)
+
+	// The series with the known values are still accepted, while the ones with a new value are rejected.
+	require.NoError(t, push("pod-1", "instance-4"))
+	err := push("pod-4", "instance-4")
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+	assert.Contains(t, err.Error(), "whose number of distinct values exceeds its budget")
+	id, ok := globalerror.IDFromMessage(string(resp.Body))
+	require.True(t, ok)
+	assert.Equal(t, globalerror.LabelValuesBudgetExceeded, id)
+	assert.Equal(t, 1.0, testutil.ToFloat64(validation.DiscardedSamples.WithLabelValues(validation.LabelValuesBudgetExceeded, "user")))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_label_values The estimated number of distinct values of the labels with a values budget, among the in-memory series of the tenant.
+		# TYPE cortex_distributor_label_values gauge
+		cortex_distributor_label_values{label_name="pod",user="user"} 3
+	`), "cortex_distributor_label_values"))
+
+	// The series with a new value are accepted again once enough values are removed from the ingesters.
+	for _, ing := range ingesters {
+		ing.Lock()
+		for key, ts := range ing.timeseries {
+			for _, l := range ts.Labels {
+				if l.Name == "pod" && l.Value == "pod-3" {
+					delete(ing.timeseries, key)
+				}
+			}
+		}
+		ing.Unlock()
+	}
+	sync(3)
+	require.NoError(t, push("pod-4", "instance-4"))
+}
+
+func TestDistributor_LabelValuesBudgets_SyncFailure(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.LabelValuesBudgets = map[string]int{"pod": 1}
+
+	ds, ingesters, regs, _ := prepare(t, prepConfig{
+		numIngesters:       3,
+		happyIngesters:     3,
+		numDistributors:    1,
+		shardByAllLabels:   true,
+		limits:             limits,
+		labelValuesBudgets: true,
+	})
+	d, reg := ds[0], regs[0]
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	for _, pod := range []string{"pod-1", "pod-2"} {
+		_, err := d.Push(ctx, mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "up", "pod", pod)}, 1, 100000))
+		require.NoError(t, err)
+	}
+	for _, ing := range ingesters {
+		require.Eventually(t, func() bool { return len(ing.series()) == 2 }, time.Second, 10*time.Millisecond)
+	}
+
+	// The budgets aren't enforced without the values known by all the ingesters.
+	ingesters[0].happy.Store(false)
+	d.syncLabelValuesBudgets(context.Background())
+	assert.Empty(t, d.labelValuesBudgets.valueHashesLabels())
+	assert.Equal(t, 1.0, testutil.ToFloat64(d.labelValuesBudgets.syncFailures))
+
+	ingesters[0].happy.Store(true)
+	d.syncLabelValuesBudgets(context.Background())
+	_, err := d.Push(ctx, mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "up", "pod", "pod-3")}, 1, 100000))
+	assert.Error(t, err)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_label_values_budgets_sync_failures_total The total number of failures to sync the label values budgets from the ingesters.
+		# TYPE cortex_distributor_label_values_budgets_sync_failures_total counter
+		cortex_distributor_label_values_budgets_sync_failures_total 1
+	`), "cortex_distributor_label_values_budgets_sync_failures_total"))
+}
@@ -0,0 +1,298 @@
+package distributor
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/value"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/extract"
+)
+
+const (
+	// Reasons of the metadata type violations.
+	metadataTypeMismatch                     = "type_mismatch"
+	metadataTypeCounterNegative              = "counter_negative"
+	metadataTypeCounterDecrease              = "counter_decrease"
+	metadataTypeHistogramInvalidBucket       = "histogram_invalid_bucket"
+	metadataTypeHistogramInconsistentBuckets = "histogram_inconsistent_buckets"
+	metadataTypeSummaryInvalidQuantile       = "summary_invalid_quantile"
+
+	// maxMetadataTypesPerTenant is the max number of metric families whose type is kept for each
+	// tenant. The series of the families over it aren't validated.
+	maxMetadataTypesPerTenant = 10000
+)
+
+// metadataTypeSuffixes are the suffixes of the series names of the metric families, for the
+// metadata named after the family rather than after the series.
+var metadataTypeSuffixes = []string{"_total", "_bucket", "_sum", "_count", "_created"}
+
+// metadataTypeValidator validates the pushed series against the type of their metric family,
+// learned from the pushed metadata. The violations are only counted, as the metadata and the
+// series are pushed in different requests and the metadata may be stale.
+type metadataTypeValidator struct {
+	mtx sync.RWMutex
+	// The metric type by metric family name, by tenant. The maps of the tenants are copied on
+	// write, so that they can be read without holding the lock.
+	types map[string]map[string]cortexpb.MetricMetadata_MetricType
+
+	violations *prometheus.CounterVec
+}
+
+func newMetadataTypeValidator(reg prometheus.Registerer) *metadataTypeValidator {
+	return &metadataTypeValidator{
+		types: map[string]map[string]cortexpb.MetricMetadata_MetricType{},
+		violations: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_metadata_type_violations_total",
+			Help:      "The total number of series pushed with samples violating the type of their metric family, learned from the metric metadata.",
+		}, []string{"user", "reason"}),
+	}
+}
+
+// observe learns the metric types of the tenant from the pushed metadata.
+func (v *metadataTypeValidator) observe(userID string, metadata []*cortexpb.MetricMetadata) {
+	if len(metadata) == 0 {
+		return
+	}
+
+	v.mtx.RLock()
+	current := v.types[userID]
+	changed := false
+	for _, m := range metadata {
+		if t, ok := current[m.MetricFamilyName]; m.Type != cortexpb.UNKNOWN && t != m.Type && (ok || len(current) < maxMetadataTypesPerTenant) {
+			changed = true
+			break
+		}
+	}
+	v.mtx.RUnlock()
+
+	// The metadata is pushed periodically, and the types rarely change.
+	if !changed {
+		return
+	}
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	current = v.types[userID]
+	updated := make(map[string]cortexpb.MetricMetadata_MetricType, len(current)+len(metadata))
+	for name, t := range current {
+		updated[name] = t
+	}
+	for _, m := range metadata {
+		if m.Type == cortexpb.UNKNOWN {
+			continue
+		}
+		if _, ok := updated[m.MetricFamilyName]; !ok && len(updated) >= maxMetadataTypesPerTenant {
+			continue
+		}
+		updated[strings.Clone(m.MetricFamilyName)] = m.Type
+	}
+	v.types[userID] = updated
+}
+
+// validate counts the series violating the type of their metric family.
+func (v *metadataTypeValidator) validate(userID string, series []cortexpb.PreallocTimeseries) {
+	v.mtx.RLock()
+	types := v.types[userID]
+	v.mtx.RUnlock()
+
+	if len(types) == 0 {
+		return
+	}
+
+	violations := map[string]int{}
+	// The buckets of the classic histograms in the request, by series without the le label.
+	buckets := map[string][]histogramBucket{}
+
+	for _, ts := range series {
+		name, err := extract.UnsafeMetricNameFromLabelAdapters(ts.Labels)
+		if err != nil {
+			continue
+		}
+		metricType, suffix, ok := lookupMetadataType(types, name)
+		if !ok {
+			continue
+		}
+
+		var reason string
+		switch metricType {
+		case cortexpb.COUNTER:
+			reason = validateCounterSamples(ts.TimeSeries, suffix)
+		case cortexpb.GAUGE:
+			if len(ts.Histograms) > 0 {
+				reason = metadataTypeMismatch
+			}
+		case cortexpb.HISTOGRAM, cortexpb.GAUGEHISTOGRAM:
+			reason = collectHistogramBuckets(ts.TimeSeries, suffix, buckets)
+		case cortexpb.SUMMARY:
+			reason = validateSummarySamples(ts.TimeSeries, suffix)
+		}
+		if reason != "" {
+			violations[reason]++
+		}
+	}
+
+	for _, series := range buckets {
+		if !histogramBucketsConsistent(series) {
+			violations[metadataTypeHistogramInconsistentBuckets]++
+		}
+	}
+
+	for reason, count := range violations {
+		v.violations.WithLabelValues(userID, reason).Add(float64(count))
+	}
+}
+
+func (v *metadataTypeValidator) deleteUser(userID string) {
+	v.mtx.Lock()
+	delete(v.types, userID)
+	v.mtx.Unlock()
+
+	v.violations.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
+
+// lookupMetadataType returns the type of the metric family of the series, and the suffix of the
+// series name if the family is named without it.
+func lookupMetadataType(types map[string]cortexpb.MetricMetadata_MetricType, name string) (cortexpb.MetricMetadata_MetricType, string, bool) {
+	if t, ok := types[name]; ok {
+		return t, "", true
+	}
+	for _, suffix := range metadataTypeSuffixes {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		if t, ok := types[strings.TrimSuffix(name, suffix)]; ok {
+			return t, suffix, true
+		}
+	}
+	return cortexpb.UNKNOWN, "", false
+}
+
+// validateCounterSamples returns the reason of the violation of the counter samples, if any.
+// A decrease to less than half of the previous value is considered a counter reset.
+func validateCounterSamples(ts *cortexpb.TimeSeries, suffix string) string {
+	if len(ts.Histograms) > 0 {
+		return metadataTypeMismatch
+	}
+	// The created timestamp isn't a counter value.
+	if suffix == "_created" {
+		return ""
+	}
+
+	prev := math.NaN()
+	for _, s := range ts.Samples {
+		if value.IsStaleNaN(s.Value) {
+			continue
+		}
+		if s.Value < 0 {
+			return metadataTypeCounterNegative
+		}
+		if s.Value < prev && s.Value >= prev/2 {
+			return metadataTypeCounterDecrease
+		}
+		prev = s.Value
+	}
+	return ""
+}
+
+// validateSummarySamples returns the reason of the violation of the summary samples, if any.
+func validateSummarySamples(ts *cortexpb.TimeSeries, suffix string) string {
+	if len(ts.Histograms) > 0 {
+		return metadataTypeMismatch
+	}
+	if suffix != "" {
+		return ""
+	}
+
+	for _, l := range ts.Labels {
+		if l.Name != model.QuantileLabel {
+			continue
+		}
+		q, err := strconv.ParseFloat(l.Value, 64)
+		if err != nil || !(q >= 0 && q <= 1) {
+			return metadataTypeSummaryInvalidQuantile
+		}
+		return ""
+	}
+	return metadataTypeSummaryInvalidQuantile
+}
+
+type histogramBucket struct {
+	timestampMs int64
+	le          float64
+	count       float64
+}
+
+// collectHistogramBuckets adds the samples of the classic histogram bucket series to the buckets,
+// and returns the reason of the violation of the series, if any.
+func collectHistogramBuckets(ts *cortexpb.TimeSeries, suffix string, buckets map[string][]histogramBucket) string {
+	switch suffix {
+	case "_bucket":
+	case "":
+		// Without suffix, the series of a histogram can only be a native histogram.
+		if len(ts.Samples) > 0 {
+			return metadataTypeMismatch
+		}
+		return ""
+	default:
+		return ""
+	}
+
+	var (
+		le    = math.NaN()
+		found bool
+		key   strings.Builder
+	)
+	for _, l := range ts.Labels {
+		if l.Name == model.BucketLabel {
+			var err error
+			le, err = strconv.ParseFloat(l.Value, 64)
+			found = err == nil && !math.IsNaN(le)
+			continue
+		}
+		key.WriteString(l.Name)
+		key.WriteByte(model.SeparatorByte)
+		key.WriteString(l.Value)
+		key.WriteByte(model.SeparatorByte)
+	}
+	if !found {
+		return metadataTypeHistogramInvalidBucket
+	}
+
+	k := key.String()
+	for _, s := range ts.Samples {
+		if value.IsStaleNaN(s.Value) {
+			continue
+		}
+		buckets[k] = append(buckets[k], histogramBucket{timestampMs: s.TimestampMs, le: le, count: s.Value})
+	}
+	return ""
+}
+
+// histogramBucketsConsistent returns whether the bucket counts of a classic histogram don't
+// decrease with the upper bound. The request may only have some of the buckets, so the missing
+// ones, like +Inf, aren't reported.
+func histogramBucketsConsistent(buckets []histogramBucket) bool {
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].timestampMs != buckets[j].timestampMs {
+			return buckets[i].timestampMs < buckets[j].timestampMs
+		}
+		return buckets[i].le < buckets[j].le
+	})
+
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i].timestampMs == buckets[i-1].timestampMs && buckets[i].count < buckets[i-1].count {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package distributor
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+func TestMetadataTypeValidator(t *testing.T) {
+	series := func(values []float64, lbls ...string) cortexpb.PreallocTimeseries {
+		ts := cortexpb.PreallocTimeseries{TimeSeries: &cortexpb.TimeSeries{}}
+		for i := 0; i < len(lbls); i += 2 {
+			ts.Labels = append(ts.Labels, cortexpb.LabelAdapter{Name: lbls[i], Value: lbls[i+1]})
+		}
+		for i, v := range values {
+			ts.Samples = append(ts.Samples, cortexpb.Sample{TimestampMs: int64(i), Value: v})
+		}
+		return ts
+	}
+	nativeHistogram := func(name string) cortexpb.PreallocTimeseries {
+		ts := series(nil, "__name__", name)
+		ts.Histograms = []cortexpb.Histogram{{TimestampMs: 1}}
+		return ts
+	}
+
+	for name, tc := range map[string]struct {
+		series   []cortexpb.PreallocTimeseries
+		expected map[string]float64
+	}{
+		"valid series": {
+			series: []cortexpb.PreallocTimeseries{
+				series([]float64{1, 2, 0, 5}, "__name__", "http_requests_total"),
+				series([]float64{1, math.Float64frombits(value.StaleNaN), 1}, "__name__", "http_requests_total", "code", "500"),
+				series([]float64{1700000000}, "__name__", "http_requests_created"),
+				series([]float64{-1, 5}, "__name__", "temperature"),
+				series([]float64{1, 2}, "__name__", "latency_seconds_bucket", "le", "0.1"),
+				series([]float64{3, 4}, "__name__", "latency_seconds_bucket", "le", "+Inf"),
+				series([]float64{2}, "__name__", "latency_seconds_sum"),
+				nativeHistogram("latency_seconds"),
+				series([]float64{0.3}, "__name__", "rpc_seconds", "quantile", "0.99"),
+				series([]float64{5}, "__name__", "rpc_seconds_count"),
+				series([]float64{-10}, "__name__", "unknown_metric"),
+			},
+		},
+		"decreasing counter": {
+			series: []cortexpb.PreallocTimeseries{
+				series([]float64{10, 9}, "__name__", "http_requests_total"),
+				series([]float64{-1}, "__name__", "http_requests_total", "code", "500"),
+				nativeHistogram("http_requests_total"),
+			},
+			expected: map[string]float64{
+				metadataTypeCounterDecrease: 1,
+				metadataTypeCounterNegative: 1,
+				metadataTypeMismatch:        1,
+			},
+		},
+		"inconsistent histogram buckets": {
+			series: []cortexpb.PreallocTimeseries{
+				series([]float64{5}, "__name__", "latency_seconds_bucket", "le", "0.1"),
+				series([]float64{3}, "__name__", "latency_seconds_bucket", "le", "1"),
+				series([]float64{3}, "__name__", "latency_seconds_bucket", "le", "fast"),
+				series([]float64{1}, "__name__", "latency_seconds"),
+			},
+			expected: map[string]float64{
+				metadataTypeHistogramInconsistentBuckets: 1,
+				metadataTypeHistogramInvalidBucket:       1,
+				metadataTypeMismatch:                     1,
+			},
+		},
+		"invalid summary quantiles": {
+			series: []cortexpb.PreallocTimeseries{
+				series([]float64{0.3}, "__name__", "rpc_seconds", "quantile", "99"),
+				series([]float64{0.3}, "__name__", "rpc_seconds"),
+				nativeHistogram("temperature"),
+			},
+			expected: map[string]float64{
+				metadataTypeSummaryInvalidQuantile: 2,
+				metadataTypeMismatch:               1,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			v := newMetadataTypeValidator(prometheus.NewPedanticRegistry())
+			v.observe("user-1", []*cortexpb.MetricMetadata{
+				{MetricFamilyName: "http_requests", Type: cortexpb.COUNTER},
+				{MetricFamilyName: "temperature", Type: cortexpb.GAUGE},
+				{MetricFamilyName: "latency_seconds", Type: cortexpb.HISTOGRAM},
+				{MetricFamilyName: "rpc_seconds", Type: cortexpb.SUMMARY},
+				{MetricFamilyName: "unknown_metric", Type: cortexpb.UNKNOWN},
+			})
+			v.validate("user-1", tc.series)
+
+			assert.Equal(t, len(tc.expected), testutil.CollectAndCount(v.violations))
+			for reason, count := range tc.expected {
+				assert.Equal(t, count, testutil.ToFloat64(v.violations.WithLabelValues("user-1", reason)), reason)
+			}
+
+			v.deleteUser("user-1")
+			assert.Equal(t, 0, testutil.CollectAndCount(v.violations))
+		})
+	}
+}
+
+func TestMetadataTypeValidator_ShouldBoundTheTrackedTypes(t *testing.T) {
+	v := newMetadataTypeValidator(nil)
+
+	metadata := make([]*cortexpb.MetricMetadata, 0, maxMetadataTypesPerTenant+1)
+	for i := 0; i <= maxMetadataTypesPerTenant; i++ {
+		metadata = append(metadata, &cortexpb.MetricMetadata{MetricFamilyName: "metric_" + strconv.Itoa(i), Type: cortexpb.GAUGE})
+	}
+	v.observe("user-1", metadata)
+	assert.Len(t, v.types["user-1"], maxMetadataTypesPerTenant)
+
+	// The type of the known families is still updated.
+	v.observe("user-1", []*cortexpb.MetricMetadata{{MetricFamilyName: metadata[0].MetricFamilyName, Type: cortexpb.COUNTER}})
+	assert.Equal(t, cortexpb.COUNTER, v.types["user-1"][metadata[0].MetricFamilyName])
+}
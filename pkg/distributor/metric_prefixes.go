@@ -0,0 +1,331 @@
+package distributor
+
+import (
+	"errors"
+	"flag"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/extract"
+	util_math "github.com/cortexproject/cortex/pkg/util/math"
+)
+
+const (
+	// metricPrefixOther is the prefix the samples are accounted to once the tenant has reached the
+	// max number of tracked prefixes.
+	metricPrefixOther = "__other__"
+
+	metricPrefixesRateTickInterval = 10 * time.Second
+)
+
+// MetricPrefixTrackingConfig configures the tracking of the ingested samples by metric name prefix.
+type MetricPrefixTrackingConfig struct {
+	Enabled              bool `yaml:"enabled"`
+	PrefixSegments       int  `yaml:"prefix_segments"`
+	TopN                 int  `yaml:"top_n"`
+	MaxPrefixesPerTenant int  `yaml:"max_prefixes_per_tenant"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *MetricPrefixTrackingConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.metric-prefix-tracking.enabled", false, "Experimental: Track the incoming and discarded samples of each tenant by metric name prefix, exposed for the top prefixes by ingestion rate as metrics and by the /distributor/metric_prefixes endpoint.")
+	f.IntVar(&cfg.PrefixSegments, "distributor.metric-prefix-tracking.prefix-segments", 1, "Number of underscore separated segments of the metric name making its prefix. For example, the prefix of http_requests_total is http with 1 segment and http_requests with 2.")
+	f.IntVar(&cfg.TopN, "distributor.metric-prefix-tracking.top-n", 10, "Number of prefixes of each tenant with the highest ingestion rate exposed as metrics.")
+	f.IntVar(&cfg.MaxPrefixesPerTenant, "distributor.metric-prefix-tracking.max-prefixes-per-tenant", 1000, "Maximum number of prefixes tracked per tenant. Once reached, the samples of new prefixes are accounted to the "+metricPrefixOther+" prefix.")
+}
+
+// Validate validates the config.
+func (cfg *MetricPrefixTrackingConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.PrefixSegments <= 0 {
+		return errors.New("the metric prefix tracking prefix segments must be greater than 0")
+	}
+	if cfg.TopN <= 0 || cfg.MaxPrefixesPerTenant <= 0 {
+		return errors.New("the metric prefix tracking top N and max prefixes per tenant must be greater than 0")
+	}
+	return nil
+}
+
+// metricPrefixCount is the number of incoming and discarded samples of a metric name prefix.
+type metricPrefixCount struct {
+	in, discarded int
+}
+
+// metricPrefixCounts accumulates the samples of a push request by metric name prefix, so that
+// the tracker is only locked once per request. A nil metricPrefixCounts doesn't count anything.
+type metricPrefixCounts struct {
+	segments int
+	counts   map[string]metricPrefixCount
+}
+
+func newMetricPrefixCounts(segments int) *metricPrefixCounts {
+	return &metricPrefixCounts{segments: segments, counts: map[string]metricPrefixCount{}}
+}
+
+// prefix returns the metric name prefix of the series. The returned string may be unsafe, and
+// must not be retained.
+func (c *metricPrefixCounts) prefix(labels []cortexpb.LabelAdapter) string {
+	name, err := extract.UnsafeMetricNameFromLabelAdapters(labels)
+	if err != nil {
+		return ""
+	}
+	return metricNamePrefix(name, c.segments)
+}
+
+func (c *metricPrefixCounts) add(prefix string, in, discarded int) {
+	count, ok := c.counts[prefix]
+	if !ok {
+		// The prefix may reference the request buffer, which is reused once sent.
+		prefix = strings.Clone(prefix)
+	}
+	count.in += in
+	count.discarded += discarded
+	c.counts[prefix] = count
+}
+
+// addIncoming counts the samples of the series as incoming.
+func (c *metricPrefixCounts) addIncoming(series []cortexpb.PreallocTimeseries) {
+	if c == nil {
+		return
+	}
+	for _, ts := range series {
+		c.add(c.prefix(ts.Labels), len(ts.Samples)+len(ts.Histograms), 0)
+	}
+}
+
+// addDiscarded counts the samples of the series as discarded.
+func (c *metricPrefixCounts) addDiscarded(series []cortexpb.PreallocTimeseries) {
+	if c == nil {
+		return
+	}
+	for _, ts := range series {
+		c.addDiscardedPrefix(c.prefix(ts.Labels), len(ts.Samples)+len(ts.Histograms))
+	}
+}
+
+func (c *metricPrefixCounts) addDiscardedPrefix(prefix string, discarded int) {
+	if c == nil || discarded == 0 {
+		return
+	}
+	c.add(prefix, 0, discarded)
+}
+
+// metricNamePrefix returns the first segments of the metric name separated by underscores.
+func metricNamePrefix(name string, segments int) string {
+	end := 0
+	for i := 0; i < segments; i++ {
+		idx := strings.IndexByte(name[end:], '_')
+		if idx < 0 {
+			return name
+		}
+		end += idx + 1
+	}
+	return name[:end-1]
+}
+
+type metricPrefixStats struct {
+	samplesIn        uint64
+	discardedSamples uint64
+	// Rate of the incoming samples.
+	ingestionRate *util_math.EwmaRate
+}
+
+// MetricPrefixStats are the ingestion stats of a metric name prefix of a tenant.
+type MetricPrefixStats struct {
+	Prefix           string  `json:"prefix"`
+	IngestionRate    float64 `json:"ingestion_rate"`
+	SamplesIn        uint64  `json:"samples_in"`
+	DiscardedSamples uint64  `json:"discarded_samples"`
+}
+
+// metricPrefixTracker tracks the incoming and discarded samples of each tenant by metric name
+// prefix, bounded to the max prefixes per tenant, and exposes the top ones by ingestion rate.
+type metricPrefixTracker struct {
+	cfg MetricPrefixTrackingConfig
+
+	mtx   sync.Mutex
+	users map[string]map[string]*metricPrefixStats
+
+	ingestionRateDesc    *prometheus.Desc
+	samplesInDesc        *prometheus.Desc
+	discardedSamplesDesc *prometheus.Desc
+}
+
+func newMetricPrefixTracker(cfg MetricPrefixTrackingConfig, reg prometheus.Registerer) *metricPrefixTracker {
+	t := &metricPrefixTracker{
+		cfg:   cfg,
+		users: map[string]map[string]*metricPrefixStats{},
+
+		ingestionRateDesc: prometheus.NewDesc(
+			"cortex_distributor_metric_prefix_ingestion_rate_samples_per_second",
+			"Ingestion rate of the incoming samples of the metric name prefixes with the highest rate of each tenant.",
+			[]string{"user", "prefix"}, nil),
+		samplesInDesc: prometheus.NewDesc(
+			"cortex_distributor_metric_prefix_samples_in_total",
+			"The total number of samples that have come in to the distributor for the metric name prefixes with the highest ingestion rate of each tenant.",
+			[]string{"user", "prefix"}, nil),
+		discardedSamplesDesc: prometheus.NewDesc(
+			"cortex_distributor_metric_prefix_discarded_samples_total",
+			"The total number of samples discarded by the distributor for the metric name prefixes with the highest ingestion rate of each tenant.",
+			[]string{"user", "prefix"}, nil),
+	}
+	if reg != nil {
+		reg.MustRegister(t)
+	}
+	return t
+}
+
+// add accounts the samples of a push request of the tenant.
+func (t *metricPrefixTracker) add(userID string, counts *metricPrefixCounts) {
+	if len(counts.counts) == 0 {
+		return
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	prefixes := t.users[userID]
+	if prefixes == nil {
+		prefixes = map[string]*metricPrefixStats{}
+		t.users[userID] = prefixes
+	}
+
+	for prefix, count := range counts.counts {
+		stats := prefixes[prefix]
+		if stats == nil {
+			if len(prefixes) >= t.cfg.MaxPrefixesPerTenant {
+				prefix = metricPrefixOther
+				stats = prefixes[prefix]
+			}
+			if stats == nil {
+				stats = &metricPrefixStats{ingestionRate: util_math.NewEWMARate(0.2, metricPrefixesRateTickInterval)}
+				prefixes[prefix] = stats
+			}
+		}
+
+		stats.samplesIn += uint64(count.in)
+		stats.discardedSamples += uint64(count.discarded)
+		stats.ingestionRate.Add(int64(count.in))
+	}
+}
+
+// tick updates the ingestion rates. It's expected to be called every metricPrefixesRateTickInterval.
+func (t *metricPrefixTracker) tick() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for _, prefixes := range t.users {
+		for _, stats := range prefixes {
+			stats.ingestionRate.Tick()
+		}
+	}
+}
+
+func (t *metricPrefixTracker) deleteUser(userID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	delete(t.users, userID)
+}
+
+// topPrefixes returns the n prefixes of the tenant with the highest ingestion rate.
+func (t *metricPrefixTracker) topPrefixes(userID string, n int) []MetricPrefixStats {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.topPrefixesLocked(userID, n)
+}
+
+func (t *metricPrefixTracker) topPrefixesLocked(userID string, n int) []MetricPrefixStats {
+	prefixes := t.users[userID]
+	result := make([]MetricPrefixStats, 0, len(prefixes))
+	for prefix, stats := range prefixes {
+		result = append(result, MetricPrefixStats{
+			Prefix:           prefix,
+			IngestionRate:    stats.ingestionRate.Rate(),
+			SamplesIn:        stats.samplesIn,
+			DiscardedSamples: stats.discardedSamples,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].IngestionRate != result[j].IngestionRate {
+			return result[i].IngestionRate > result[j].IngestionRate
+		}
+		if result[i].SamplesIn != result[j].SamplesIn {
+			return result[i].SamplesIn > result[j].SamplesIn
+		}
+		return result[i].Prefix < result[j].Prefix
+	})
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// Describe implements prometheus.Collector.
+func (t *metricPrefixTracker) Describe(out chan<- *prometheus.Desc) {
+	out <- t.ingestionRateDesc
+	out <- t.samplesInDesc
+	out <- t.discardedSamplesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (t *metricPrefixTracker) Collect(out chan<- prometheus.Metric) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for userID := range t.users {
+		for _, stats := range t.topPrefixesLocked(userID, t.cfg.TopN) {
+			out <- prometheus.MustNewConstMetric(t.ingestionRateDesc, prometheus.GaugeValue, stats.IngestionRate, userID, stats.Prefix)
+			out <- prometheus.MustNewConstMetric(t.samplesInDesc, prometheus.CounterValue, float64(stats.SamplesIn), userID, stats.Prefix)
+			out <- prometheus.MustNewConstMetric(t.discardedSamplesDesc, prometheus.CounterValue, float64(stats.DiscardedSamples), userID, stats.Prefix)
+		}
+	}
+}
+
+// MetricPrefixesHandler shows the metric name prefixes with the highest ingestion rate of each
+// tenant, or only of the tenant in the "user" parameter. The number of prefixes returned per
+// tenant defaults to the configured top N, and can be set with the "limit" parameter.
+func (d *Distributor) MetricPrefixesHandler(w http.ResponseWriter, r *http.Request) {
+	if d.metricPrefixes == nil {
+		http.Error(w, "the metric prefix tracking is disabled", http.StatusNotFound)
+		return
+	}
+
+	limit := d.cfg.MetricPrefixTracking.TopN
+	if v := r.FormValue("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			http.Error(w, "the limit parameter must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var userIDs []string
+	if userID := r.FormValue("user"); userID != "" {
+		userIDs = []string{userID}
+	} else {
+		d.metricPrefixes.mtx.Lock()
+		for userID := range d.metricPrefixes.users {
+			userIDs = append(userIDs, userID)
+		}
+		d.metricPrefixes.mtx.Unlock()
+	}
+
+	result := make(map[string][]MetricPrefixStats, len(userIDs))
+	for _, userID := range userIDs {
+		result[userID] = d.metricPrefixes.topPrefixes(userID, limit)
+	}
+	util.WriteJSONResponse(w, result)
+}
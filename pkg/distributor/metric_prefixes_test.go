@@ -0,0 +1,115 @@
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestMetricNamePrefix(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		segments int
+		expected string
+	}{
+		{name: "http_requests_total", segments: 1, expected: "http"},
+		{name: "http_requests_total", segments: 2, expected: "http_requests"},
+		{name: "http_requests_total", segments: 3, expected: "http_requests_total"},
+		{name: "http_requests_total", segments: 4, expected: "http_requests_total"},
+		{name: "up", segments: 1, expected: "up"},
+		{name: "_private", segments: 1, expected: ""},
+	} {
+		assert.Equal(t, tc.expected, metricNamePrefix(tc.name, tc.segments), "name: %s segments: %d", tc.name, tc.segments)
+	}
+}
+
+func TestMetricPrefixTracker_ShouldBoundTheTrackedPrefixes(t *testing.T) {
+	tracker := newMetricPrefixTracker(MetricPrefixTrackingConfig{Enabled: true, PrefixSegments: 1, TopN: 10, MaxPrefixesPerTenant: 2}, nil)
+
+	counts := newMetricPrefixCounts(1)
+	counts.add("http", 3, 0)
+	counts.add("node", 2, 1)
+	tracker.add("user-1", counts)
+
+	counts = newMetricPrefixCounts(1)
+	counts.add("http", 1, 0)
+	counts.add("process", 4, 0)
+	counts.add("go", 1, 1)
+	tracker.add("user-1", counts)
+	tracker.tick()
+
+	assert.Equal(t, []MetricPrefixStats{
+		{Prefix: metricPrefixOther, IngestionRate: 0.5, SamplesIn: 5, DiscardedSamples: 1},
+		{Prefix: "http", IngestionRate: 0.4, SamplesIn: 4},
+		{Prefix: "node", IngestionRate: 0.2, SamplesIn: 2, DiscardedSamples: 1},
+	}, tracker.topPrefixes("user-1", 0))
+	assert.Len(t, tracker.topPrefixes("user-1", 2), 2)
+
+	tracker.deleteUser("user-1")
+	assert.Empty(t, tracker.topPrefixes("user-1", 0))
+}
+
+func TestDistributor_MetricPrefixTracking(t *testing.T) {
+	ds, _, regs, _ := prepare(t, prepConfig{
+		numIngesters:         3,
+		happyIngesters:       3,
+		numDistributors:      1,
+		shardByAllLabels:     true,
+		metricPrefixTracking: true,
+	})
+	d, reg := ds[0], regs[0]
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	req := mockWriteRequest([]labels.Labels{
+		labels.FromStrings(labels.MetricName, "http_requests_total", "code", "200"),
+		labels.FromStrings(labels.MetricName, "http_requests_total", "code", "500"),
+		labels.FromStrings(labels.MetricName, "node_cpu_seconds_total"),
+		// Invalid label name.
+		labels.FromStrings(labels.MetricName, "node_memory_bytes", "0invalid", "value"),
+	}, 1, 100000)
+	_, err := d.Push(ctx, req)
+	require.Error(t, err)
+
+	d.metricPrefixes.tick()
+	assert.Equal(t, []MetricPrefixStats{
+		{Prefix: "http", IngestionRate: 0.2, SamplesIn: 2},
+		{Prefix: "node", IngestionRate: 0.2, SamplesIn: 2, DiscardedSamples: 1},
+	}, d.metricPrefixes.topPrefixes("user-1", 0))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_metric_prefix_discarded_samples_total The total number of samples discarded by the distributor for the metric name prefixes with the highest ingestion rate of each tenant.
+		# TYPE cortex_distributor_metric_prefix_discarded_samples_total counter
+		cortex_distributor_metric_prefix_discarded_samples_total{prefix="http",user="user-1"} 0
+		cortex_distributor_metric_prefix_discarded_samples_total{prefix="node",user="user-1"} 1
+		# HELP cortex_distributor_metric_prefix_samples_in_total The total number of samples that have come in to the distributor for the metric name prefixes with the highest ingestion rate of each tenant.
+		# TYPE cortex_distributor_metric_prefix_samples_in_total counter
+		cortex_distributor_metric_prefix_samples_in_total{prefix="http",user="user-1"} 2
+		cortex_distributor_metric_prefix_samples_in_total{prefix="node",user="user-1"} 2
+	`), "cortex_distributor_metric_prefix_samples_in_total", "cortex_distributor_metric_prefix_discarded_samples_total"))
+
+	// The API returns the top prefixes of each tenant.
+	w := httptest.NewRecorder()
+	d.MetricPrefixesHandler(w, httptest.NewRequest(http.MethodGet, "/distributor/metric_prefixes?limit=1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp map[string][]MetricPrefixStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, map[string][]MetricPrefixStats{
+		"user-1": {{Prefix: "http", IngestionRate: 0.2, SamplesIn: 2}},
+	}, resp)
+}
+
+func TestDistributor_MetricPrefixesHandler_ShouldFailWhenDisabled(t *testing.T) {
+	d := &Distributor{}
+	w := httptest.NewRecorder()
+	d.MetricPrefixesHandler(w, httptest.NewRequest(http.MethodGet, "/distributor/metric_prefixes", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
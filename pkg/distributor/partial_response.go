@@ -0,0 +1,93 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/cortexproject/cortex/pkg/querier/partialresponse"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// partialResponseEnabled returns whether the query is run in partial response mode, as requested
+// by the query or else by the tenant limits.
+func (d *Distributor) partialResponseEnabled(ctx context.Context) bool {
+	if enabled, ok := partialresponse.EnabledFromContext(ctx); ok {
+		return enabled
+	}
+
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return false
+	}
+	return d.limits.QueryPartialResponse(userID)
+}
+
+// queryReplicationSet runs f on the instances of the replication set, like ReplicationSet.Do. In partial
+// response mode, f runs on all the instances and the results of the ones that succeeded are returned,
+// unless all of them failed. The failed instances are recorded in the partial response failures of the
+// context when they're more than tolerated by the replication set, since the results may be incomplete.
+func (d *Distributor) queryReplicationSet(ctx context.Context, replicationSet ring.ReplicationSet, f func(context.Context, *ring.InstanceDesc) (interface{}, error)) ([]interface{}, error) {
+	if !d.partialResponseEnabled(ctx) {
+		return replicationSet.Do(ctx, d.cfg.ExtraQueryDelay, f)
+	}
+
+	type instanceResult struct {
+		res      interface{}
+		err      error
+		instance *ring.InstanceDesc
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan instanceResult, len(replicationSet.Instances))
+	for i := range replicationSet.Instances {
+		go func(instance *ring.InstanceDesc) {
+			res, err := f(ctx, instance)
+			ch <- instanceResult{res: res, err: err, instance: instance}
+		}(&replicationSet.Instances[i])
+	}
+
+	var (
+		results      = make([]interface{}, 0, len(replicationSet.Instances))
+		failed       []string
+		failedZones  = map[string]struct{}{}
+		lastErr      error
+		numInstances = len(replicationSet.Instances)
+	)
+	for i := 0; i < numInstances; i++ {
+		res := <-ch
+		// The query limits are still enforced, whatever the failed ingesters.
+		var limitErr validation.LimitError
+		if errors.As(res.err, &limitErr) {
+			return nil, res.err
+		}
+		if res.err != nil {
+			failed = append(failed, res.instance.Addr)
+			failedZones[res.instance.Zone] = struct{}{}
+			lastErr = res.err
+			continue
+		}
+		results = append(results, res.res)
+	}
+
+	if len(results) == 0 && numInstances > 0 {
+		return nil, lastErr
+	}
+
+	tolerated := len(failed) <= replicationSet.MaxErrors
+	if replicationSet.MaxUnavailableZones > 0 {
+		tolerated = len(failedZones) <= replicationSet.MaxUnavailableZones
+	}
+	if !tolerated {
+		level.Warn(util_log.WithContext(ctx, d.log)).Log("msg", "returning a partial response, some ingesters failed the query", "failed", strings.Join(failed, ","), "err", lastErr)
+		partialresponse.FailuresFromContext(ctx).Add(failed...)
+	}
+	return results, nil
+}
@@ -0,0 +1,125 @@
+package distributor
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/querier/partialresponse"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/test"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestDistributor_QueryStream_PartialResponse(t *testing.T) {
+	t.Parallel()
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	tests := map[string]struct {
+		limitEnabled    bool
+		override        *bool
+		happyIngesters  int
+		expectedErr     bool
+		expectedFailure bool
+	}{
+		"disabled, quorum lost": {
+			happyIngesters: 1,
+			expectedErr:    true,
+		},
+		"enabled by the tenant, quorum lost": {
+			limitEnabled:    true,
+			happyIngesters:  1,
+			expectedFailure: true,
+		},
+		"enabled by the tenant, failures tolerated by the replication": {
+			limitEnabled:   true,
+			happyIngesters: 2,
+		},
+		"enabled by the tenant, all ingesters failed": {
+			limitEnabled:   true,
+			happyIngesters: 0,
+			expectedErr:    true,
+		},
+		"enabled by the tenant, disabled by the query": {
+			limitEnabled:   true,
+			override:       boolPtr(false),
+			happyIngesters: 1,
+			expectedErr:    true,
+		},
+		"enabled by the query": {
+			override:        boolPtr(true),
+			happyIngesters:  1,
+			expectedFailure: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.QueryPartialResponse = tc.limitEnabled
+
+			ds, ingesters, _, _ := prepare(t, prepConfig{
+				numIngesters:     3,
+				happyIngesters:   3,
+				numDistributors:  1,
+				shardByAllLabels: true,
+				limits:           limits,
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "user")
+			_, err := ds[0].Push(ctx, makeWriteRequest(0, 10, 0))
+			require.NoError(t, err)
+
+			// The push returns once the quorum is reached, wait for all the ingesters to receive it.
+			for _, ing := range ingesters {
+				ing := ing
+				test.Poll(t, time.Second, 10, func() interface{} {
+					return len(ing.series())
+				})
+			}
+
+			for _, ing := range ingesters[tc.happyIngesters:] {
+				ing.happy.Store(false)
+			}
+
+			if tc.override != nil {
+				ctx = partialresponse.ContextWithEnabled(ctx, *tc.override)
+			}
+			failures, ctx := partialresponse.ContextWithFailures(ctx)
+
+			resp, err := ds[0].QueryStream(ctx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, resp.Chunkseries, 10)
+
+			if tc.expectedFailure {
+				assert.Len(t, failures.Instances(), 3-tc.happyIngesters)
+				assert.Error(t, failures.Warning())
+			} else {
+				assert.Empty(t, failures.Instances())
+				assert.NoError(t, failures.Warning())
+			}
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
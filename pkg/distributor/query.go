@@ -2,6 +2,7 @@ package distributor
 
 import (
 	"context"
+	"errors"
 	"io"
 	"sort"
 	"time"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/querier/serieslimit"
 	"github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/ring"
 	"github.com/cortexproject/cortex/pkg/tenant"
@@ -81,17 +83,13 @@ func (d *Distributor) QueryExemplars(ctx context.Context, from, to model.Time, m
 func (d *Distributor) QueryStream(ctx context.Context, from, to model.Time, matchers ...*labels.Matcher) (*ingester_client.QueryStreamResponse, error) {
 	var result *ingester_client.QueryStreamResponse
 	err := instrument.CollectedRequest(ctx, "Distributor.QueryStream", d.queryDuration, instrument.ErrorCode, func(ctx context.Context) error {
-		req, err := ingester_client.ToQueryRequest(from, to, matchers)
-		if err != nil {
-			return err
-		}
-
-		replicationSet, err := d.GetIngestersForQuery(ctx, matchers...)
-		if err != nil {
-			return err
+		var err error
+		// The truncation of the queries with a series limit isn't cached.
+		if d.queryStreamCache != nil && serieslimit.LimitFromContext(ctx) == 0 {
+			result, err = d.queryStreamCache.query(ctx, from, to, matchers, d.queryStream)
+		} else {
+			result, err = d.queryStream(ctx, from, to, matchers...)
 		}
-
-		result, err = d.queryIngesterStream(ctx, replicationSet, req)
 		if err != nil {
 			return err
 		}
@@ -104,6 +102,22 @@ func (d *Distributor) QueryStream(ctx context.Context, from, to model.Time, matc
 	return result, err
 }
 
+// queryStream queries the ingesters of the matchers via the streaming interface.
+func (d *Distributor) queryStream(ctx context.Context, from, to model.Time, matchers ...*labels.Matcher) (*ingester_client.QueryStreamResponse, error) {
+	req, err := ingester_client.ToQueryRequest(from, to, matchers)
+	if err != nil {
+		return nil, err
+	}
+	req.SeriesLimit = int64(serieslimit.LimitFromContext(ctx))
+
+	replicationSet, err := d.GetIngestersForQuery(ctx, matchers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.queryIngesterStream(ctx, replicationSet, req)
+}
+
 // GetIngestersForQuery returns a replication set including all ingesters that should be queried
 // to fetch series matching input label matchers.
 func (d *Distributor) GetIngestersForQuery(ctx context.Context, matchers ...*labels.Matcher) (ring.ReplicationSet, error) {
@@ -161,7 +175,7 @@ func (d *Distributor) GetIngestersForMetadata(ctx context.Context) (ring.Replica
 func (d *Distributor) queryIngesters(ctx context.Context, replicationSet ring.ReplicationSet, req *ingester_client.QueryRequest) (model.Matrix, error) {
 	// Fetch samples from multiple ingesters in parallel, using the replicationSet
 	// to deal with consistency.
-	results, err := replicationSet.Do(ctx, d.cfg.ExtraQueryDelay, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
+	results, err := d.queryIngestersReplicationSet(ctx, replicationSet, model.Time(req.StartTimestampMs), trackIngesterQueries(stats.FromContext(ctx), func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
 		client, err := d.ingesterPool.GetClientFor(ing.Addr)
 		if err != nil {
 			return nil, err
@@ -175,7 +189,7 @@ func (d *Distributor) queryIngesters(ctx context.Context, replicationSet ring.Re
 		}
 
 		return ingester_client.FromQueryResponse(resp), nil
-	})
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -232,7 +246,7 @@ func mergeExemplarSets(a, b []cortexpb.Exemplar) []cortexpb.Exemplar {
 func (d *Distributor) queryIngestersExemplars(ctx context.Context, replicationSet ring.ReplicationSet, req *ingester_client.ExemplarQueryRequest) (*ingester_client.ExemplarQueryResponse, error) {
 	// Fetch exemplars from multiple ingesters in parallel, using the replicationSet
 	// to deal with consistency.
-	results, err := replicationSet.Do(ctx, d.cfg.ExtraQueryDelay, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
+	results, err := d.queryReplicationSet(ctx, replicationSet, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
 		client, err := d.ingesterPool.GetClientFor(ing.Addr)
 		if err != nil {
 			return nil, err
@@ -293,7 +307,7 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 	)
 
 	// Fetch samples from multiple ingesters
-	results, err := replicationSet.Do(ctx, d.cfg.ExtraQueryDelay, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
+	results, err := d.queryIngestersReplicationSet(ctx, replicationSet, model.Time(req.StartTimestampMs), trackIngesterQueries(reqStats, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
 		client, err := d.ingesterPool.GetClientFor(ing.Addr)
 		if err != nil {
 			return nil, err
@@ -321,37 +335,18 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 				return nil, err
 			}
 
-			// Enforce the max chunks limits.
-			if chunkLimitErr := queryLimiter.AddChunks(resp.ChunksCount()); chunkLimitErr != nil {
-				return nil, validation.LimitError(chunkLimitErr.Error())
-			}
-
-			s := make([][]cortexpb.LabelAdapter, 0, len(resp.Chunkseries)+len(resp.Timeseries))
-			for _, series := range resp.Chunkseries {
-				s = append(s, series.Labels)
-			}
-
-			for _, series := range resp.Timeseries {
-				s = append(s, series.Labels)
-			}
-
-			if limitErr := queryLimiter.AddSeries(s...); limitErr != nil {
-				return nil, validation.LimitError(limitErr.Error())
-			}
-
-			if chunkBytesLimitErr := queryLimiter.AddChunkBytes(resp.ChunksSize()); chunkBytesLimitErr != nil {
-				return nil, validation.LimitError(chunkBytesLimitErr.Error())
+			if err := addQueryStreamResponseToLimiter(queryLimiter, resp); err != nil {
+				return nil, err
 			}
-
-			if dataBytesLimitErr := queryLimiter.AddDataBytes(resp.Size()); dataBytesLimitErr != nil {
-				return nil, validation.LimitError(dataBytesLimitErr.Error())
+			if resp.SeriesLimitReached {
+				serieslimit.TruncationFromContext(ctx).Mark()
 			}
 
 			result.Chunkseries = append(result.Chunkseries, resp.Chunkseries...)
 			result.Timeseries = append(result.Timeseries, resp.Timeseries...)
 		}
 		return result, nil
-	})
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -383,6 +378,11 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 			} else {
 				existing.Samples = mergeSamples(existing.Samples, series.Samples)
 			}
+			if existing.Histograms == nil {
+				existing.Histograms = series.Histograms
+			} else {
+				existing.Histograms = mergeHistograms(existing.Histograms, series.Histograms)
+			}
 			hashToTimeSeries[key] = existing
 		}
 	}
@@ -400,13 +400,75 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 
 	reqStats.AddFetchedSeries(uint64(len(resp.Chunkseries) + len(resp.Timeseries)))
 	reqStats.AddFetchedChunkBytes(uint64(resp.ChunksSize()))
+	reqStats.AddFetchedIngestersChunkBytes(uint64(resp.ChunksSize()))
 	reqStats.AddFetchedDataBytes(uint64(resp.Size()))
 	reqStats.AddFetchedChunks(uint64(resp.ChunksCount()))
 	reqStats.AddFetchedSamples(uint64(resp.SamplesCount()))
+	reqStats.AddFetchedHistogramSamples(uint64(resp.HistogramSamplesCount()))
 
 	return resp, nil
 }
 
+// trackIngesterQueries wraps f, querying an ingester, to record the fan-out of the query to the
+// ingesters in the stats of the query.
+func trackIngesterQueries(reqStats *stats.QueryStats, f func(context.Context, *ring.InstanceDesc) (interface{}, error)) func(context.Context, *ring.InstanceDesc) (interface{}, error) {
+	if reqStats == nil {
+		return f
+	}
+	return func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
+		start := time.Now()
+		result, err := f(ctx, ing)
+		recordIngesterQuery(reqStats, ing, time.Since(start), err)
+		return result, err
+	}
+}
+
+// recordIngesterQuery records the query of an ingester in the stats of the query. The ingesters
+// canceled because the query doesn't need them anymore aren't counted as failed.
+func recordIngesterQuery(reqStats *stats.QueryStats, ing *ring.InstanceDesc, latency time.Duration, err error) {
+	reqStats.AddQueriedIngesters(1)
+	if ing.Zone != "" {
+		reqStats.AddQueriedIngesterZones(ing.Zone)
+	}
+
+	switch {
+	case err == nil:
+		reqStats.UpdateSlowestIngesterLatency(latency)
+	case !errors.Is(err, context.Canceled) && !grpcutil.IsGRPCContextCanceled(err):
+		reqStats.AddFailedIngesters(1)
+	}
+}
+
+// addQueryStreamResponseToLimiter enforces the query limits on a message of the query stream of an ingester.
+func addQueryStreamResponseToLimiter(queryLimiter *limiter.QueryLimiter, resp *ingester_client.QueryStreamResponse) error {
+	// Enforce the max chunks limits.
+	if chunkLimitErr := queryLimiter.AddChunks(resp.ChunksCount()); chunkLimitErr != nil {
+		return validation.LimitError(chunkLimitErr.Error())
+	}
+
+	s := make([][]cortexpb.LabelAdapter, 0, len(resp.Chunkseries)+len(resp.Timeseries))
+	for _, series := range resp.Chunkseries {
+		s = append(s, series.Labels)
+	}
+
+	for _, series := range resp.Timeseries {
+		s = append(s, series.Labels)
+	}
+
+	if limitErr := queryLimiter.AddSeries(s...); limitErr != nil {
+		return validation.LimitError(limitErr.Error())
+	}
+
+	if chunkBytesLimitErr := queryLimiter.AddChunkBytes(resp.ChunksSize()); chunkBytesLimitErr != nil {
+		return validation.LimitError(chunkBytesLimitErr.Error())
+	}
+
+	if dataBytesLimitErr := queryLimiter.AddDataBytes(resp.Size()); dataBytesLimitErr != nil {
+		return validation.LimitError(dataBytesLimitErr.Error())
+	}
+	return nil
+}
+
 // Merges and dedupes two sorted slices with samples together.
 func mergeSamples(a, b []cortexpb.Sample) []cortexpb.Sample {
 	if sameSamples(a, b) {
@@ -446,3 +508,43 @@ func sameSamples(a, b []cortexpb.Sample) bool {
 	}
 	return true
 }
+
+// Merges and dedupes two sorted slices with histograms together.
+func mergeHistograms(a, b []cortexpb.Histogram) []cortexpb.Histogram {
+	if sameHistograms(a, b) {
+		return a
+	}
+
+	result := make([]cortexpb.Histogram, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i].TimestampMs < b[j].TimestampMs {
+			result = append(result, a[i])
+			i++
+		} else if a[i].TimestampMs > b[j].TimestampMs {
+			result = append(result, b[j])
+			j++
+		} else {
+			result = append(result, a[i])
+			i++
+			j++
+		}
+	}
+	// Add the rest of a or b. One of them is empty now.
+	result = append(result, a[i:]...)
+	result = append(result, b[j:]...)
+	return result
+}
+
+func sameHistograms(a, b []cortexpb.Histogram) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := 0; i < len(a); i++ {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+package distributor
+
+import (
+	"context"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/querier/partialresponse"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/limiter"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+// QueryStreamCacheConfig configures the cache of the series queried from the ingesters.
+type QueryStreamCacheConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	TTL         time.Duration `yaml:"ttl"`
+	CacheConfig cache.Config  `yaml:"cache"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *QueryStreamCacheConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.query-stream-cache.enabled", false, "Experimental: Cache the series queried from the ingesters, so that the identical queries repeated by the dashboards aren't sent to the ingesters again. The queries of a tenant with the same matchers, regardless of their order, and with a start and end within the same TTL period share the cached series. Not applied to the lazy merge of the query streams and to the queries with a series limit.")
+	f.DurationVar(&cfg.TTL, "distributor.query-stream-cache.ttl", 15*time.Second, "How long the series queried from the ingesters are served from the cache. The start and end of the queries are aligned to it, so the cached series may miss the samples ingested since up to the TTL.")
+	cfg.CacheConfig.RegisterFlagsWithPrefix("distributor.query-stream-cache.", "", f)
+}
+
+// Validate validates the config.
+func (cfg *QueryStreamCacheConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TTL <= 0 {
+		return errors.New("the query stream cache TTL must be positive")
+	}
+	return cfg.CacheConfig.Validate()
+}
+
+// queryStreamCache serves the queries to the ingesters from the cache.
+type queryStreamCache struct {
+	ttl    time.Duration
+	cache  cache.Cache
+	logger log.Logger
+
+	requests prometheus.Counter
+	hits     prometheus.Counter
+}
+
+func newQueryStreamCache(cfg QueryStreamCacheConfig, reg prometheus.Registerer, logger log.Logger) (*queryStreamCache, error) {
+	// The entries are never read once their TTL period is over.
+	if cfg.CacheConfig.DefaultValidity == 0 {
+		cfg.CacheConfig.DefaultValidity = 2 * cfg.TTL
+	}
+	c, err := cache.New(cfg.CacheConfig, reg, logger)
+	if err != nil {
+		return nil, err
+	}
+	if cache.IsEmptyTieredCache(c) {
+		return nil, errors.New("the query stream cache requires a cache backend")
+	}
+
+	return &queryStreamCache{
+		ttl:    cfg.TTL,
+		cache:  c,
+		logger: logger,
+		requests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_query_stream_cache_requests_total",
+			Help: "Total number of queries to the ingesters looked up in the query stream cache.",
+		}),
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_query_stream_cache_hits_total",
+			Help: "Total number of queries to the ingesters served from the query stream cache.",
+		}),
+	}, nil
+}
+
+// query returns the cached series of the query, or queries them with fetch over the start and end
+// aligned to the TTL, and caches them unless some ingesters failed the query in partial response mode.
+// The query limits are enforced on the cached series too.
+func (c *queryStreamCache) query(ctx context.Context, from, to model.Time, matchers []*labels.Matcher, fetch func(ctx context.Context, from, to model.Time, matchers ...*labels.Matcher) (*ingester_client.QueryStreamResponse, error)) (*ingester_client.QueryStreamResponse, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return fetch(ctx, from, to, matchers...)
+	}
+
+	from, to = alignQueryStreamRange(from, to, c.ttl)
+	key := queryStreamCacheKey(userID, from, to, matchers, c.ttl, time.Now())
+
+	c.requests.Inc()
+	if resp, ok := c.get(ctx, key); ok {
+		c.hits.Inc()
+		if err := addQueryStreamResponseToLimiter(limiter.QueryLimiterFromContextWithFallback(ctx), resp); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	failures := partialresponse.FailuresFromContext(ctx)
+	numFailures := len(failures.Instances())
+	resp, err := fetch(ctx, from, to, matchers...)
+	if err != nil {
+		return nil, err
+	}
+	if len(failures.Instances()) == numFailures {
+		c.put(ctx, key, resp)
+	}
+	return resp, nil
+}
+
+// Stop stops the cache.
+func (c *queryStreamCache) Stop() {
+	c.cache.Stop()
+}
+
+// get returns the cached response of the key. The key is stored along with the response, to detect
+// the collisions of the hashed keys.
+func (c *queryStreamCache) get(ctx context.Context, key string) (*ingester_client.QueryStreamResponse, bool) {
+	found, bufs, _ := c.cache.Fetch(ctx, []string{cache.HashKey(key)})
+	if len(found) != 1 {
+		return nil, false
+	}
+
+	buf := bufs[0]
+	keyLen, n := binary.Uvarint(buf)
+	if n <= 0 || uint64(len(buf)-n) < keyLen || string(buf[n:n+int(keyLen)]) != key {
+		return nil, false
+	}
+
+	resp := &ingester_client.QueryStreamResponse{}
+	if err := resp.Unmarshal(buf[n+int(keyLen):]); err != nil {
+		level.Error(util_log.WithContext(ctx, c.logger)).Log("msg", "error unmarshalling the cached query stream response", "err", err)
+		return nil, false
+	}
+	return resp, true
+}
+
+func (c *queryStreamCache) put(ctx context.Context, key string, resp *ingester_client.QueryStreamResponse) {
+	data, err := resp.Marshal()
+	if err != nil {
+		level.Error(util_log.WithContext(ctx, c.logger)).Log("msg", "error marshalling the query stream response", "err", err)
+		return
+	}
+
+	buf := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(key)+len(data)), uint64(len(key)))
+	buf = append(buf, key...)
+	buf = append(buf, data...)
+	c.cache.Store(ctx, []string{cache.HashKey(key)}, [][]byte{buf})
+}
+
+// alignQueryStreamRange returns the start aligned down and the end aligned up to the TTL, so that the
+// queries within the same TTL period share the same range. The series of the larger range are returned
+// to the queriers, which only read the samples of the range of the query.
+func alignQueryStreamRange(from, to model.Time, ttl time.Duration) (model.Time, model.Time) {
+	period := ttl.Milliseconds()
+	alignDown := func(t int64) int64 {
+		r := t % period
+		if r < 0 {
+			r += period
+		}
+		return t - r
+	}
+
+	alignedTo := model.Time(alignDown(int64(to)) + period - 1)
+	if alignedTo < to {
+		// The end overflowed.
+		alignedTo = to
+	}
+	return model.Time(alignDown(int64(from))), alignedTo
+}
+
+// queryStreamCacheKey returns the cache key of the query, made of its tenant, its aligned start and end,
+// its matchers sorted, and the current TTL period.
+func queryStreamCacheKey(userID string, from, to model.Time, matchers []*labels.Matcher, ttl time.Duration, now time.Time) string {
+	parts := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		parts = append(parts, m.String())
+	}
+	sort.Strings(parts)
+
+	return fmt.Sprintf("query-stream:%s:%d:%d:{%s}:%d", userID, from, to, strings.Join(parts, ","), now.UnixMilli()/ttl.Milliseconds())
+}
@@ -0,0 +1,139 @@
+package distributor
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/querier/partialresponse"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestDistributor_QueryStream_Cache(t *testing.T) {
+	t.Parallel()
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		queryStreamCache: true,
+	})
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		_, err := ds[0].Push(user.InjectOrgID(context.Background(), userID), makeWriteRequest(0, 10, 0))
+		require.NoError(t, err)
+	}
+
+	// The push returns once the quorum is reached, wait for all the ingesters to receive it.
+	for _, ing := range ingesters {
+		ing := ing
+		test.Poll(t, time.Second, 20, func() interface{} {
+			return len(ing.series())
+		})
+	}
+
+	countCalls := func() int {
+		calls := 0
+		for _, ing := range ingesters {
+			calls += ing.countCalls("QueryStream")
+		}
+		return calls
+	}
+	query := func(ctx context.Context, from, to model.Time, matchers ...*labels.Matcher) int {
+		resp, err := ds[0].QueryStream(ctx, from, to, matchers...)
+		require.NoError(t, err)
+		return len(resp.Chunkseries)
+	}
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	nameMatcher := labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+")
+	barMatcher := labels.MustNewMatcher(labels.MatchEqual, "bar", "baz")
+
+	assert.Equal(t, 10, query(ctx, math.MinInt32, math.MaxInt32, nameMatcher, barMatcher))
+	calls := countCalls()
+
+	// The queries with the same matchers, regardless of their order, and the same range are served
+	// from the cache.
+	assert.Equal(t, 10, query(ctx, math.MinInt32, math.MaxInt32, barMatcher, nameMatcher))
+	assert.Equal(t, calls, countCalls())
+	assert.Equal(t, float64(1), testutil.ToFloat64(ds[0].queryStreamCache.hits))
+
+	// The queries of other tenants and with other matchers aren't.
+	assert.Equal(t, 10, query(user.InjectOrgID(context.Background(), "user-2"), math.MinInt32, math.MaxInt32, nameMatcher, barMatcher))
+	assert.Equal(t, 10, query(ctx, math.MinInt32, math.MaxInt32, nameMatcher))
+	assert.Greater(t, countCalls(), calls)
+	assert.Equal(t, float64(1), testutil.ToFloat64(ds[0].queryStreamCache.hits))
+
+	// The partial responses aren't cached.
+	ingesters[0].happy.Store(false)
+	ingesters[1].happy.Store(false)
+	failures, partialCtx := partialresponse.ContextWithFailures(partialresponse.ContextWithEnabled(ctx, true))
+	otherMatcher := labels.MustNewMatcher(labels.MatchEqual, "bar", "other")
+	query(partialCtx, math.MinInt32, math.MaxInt32, nameMatcher, otherMatcher)
+	require.Len(t, failures.Instances(), 2)
+
+	ingesters[0].happy.Store(true)
+	ingesters[1].happy.Store(true)
+	calls = countCalls()
+	query(ctx, math.MinInt32, math.MaxInt32, nameMatcher, otherMatcher)
+	assert.Greater(t, countCalls(), calls)
+	assert.Equal(t, float64(1), testutil.ToFloat64(ds[0].queryStreamCache.hits))
+}
+
+func TestAlignQueryStreamRange(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		from, to                 model.Time
+		expectedFrom, expectedTo model.Time
+	}{
+		"aligned range": {
+			from: 60000, to: 120000,
+			expectedFrom: 60000, expectedTo: 179999,
+		},
+		"unaligned range": {
+			from: 61000, to: 119000,
+			expectedFrom: 60000, expectedTo: 119999,
+		},
+		"negative start": {
+			from: -1000, to: 1000,
+			expectedFrom: -60000, expectedTo: 59999,
+		},
+		"end overflowing": {
+			from: 0, to: math.MaxInt64,
+			expectedFrom: 0, expectedTo: math.MaxInt64,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			from, to := alignQueryStreamRange(tc.from, tc.to, time.Minute)
+			assert.Equal(t, tc.expectedFrom, from)
+			assert.Equal(t, tc.expectedTo, to)
+		})
+	}
+}
+
+func TestQueryStreamCacheKey(t *testing.T) {
+	t.Parallel()
+
+	now := time.UnixMilli(150000)
+	a := labels.MustNewMatcher(labels.MatchEqual, "a", "1")
+	b := labels.MustNewMatcher(labels.MatchRegexp, "b", "2|3")
+
+	assert.Equal(t, `query-stream:user:0:59999:{a="1",b=~"2|3"}:2`, queryStreamCacheKey("user", 0, 59999, []*labels.Matcher{b, a}, time.Minute, now))
+	assert.Equal(t, queryStreamCacheKey("user", 0, 59999, []*labels.Matcher{a, b}, time.Minute, now), queryStreamCacheKey("user", 0, 59999, []*labels.Matcher{b, a}, time.Minute, now))
+	assert.NotEqual(t, queryStreamCacheKey("user", 0, 59999, []*labels.Matcher{a, b}, time.Minute, now), queryStreamCacheKey("user", 0, 59999, []*labels.Matcher{a, b}, time.Minute, now.Add(time.Minute)))
+}
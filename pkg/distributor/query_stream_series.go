@@ -0,0 +1,317 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/querier/partialresponse"
+	"github.com/cortexproject/cortex/pkg/querier/serieslimit"
+	"github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util/grpcutil"
+	"github.com/cortexproject/cortex/pkg/util/limiter"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// QueryStreamSeries queries the ingesters via the streaming API like QueryStream, but returns the series
+// merged lazily as they're streamed by the ingesters instead of buffering all of them. Up to bufferSize
+// series are buffered per ingester, on top of the ones of the message being received from the ingester.
+func (d *Distributor) QueryStreamSeries(ctx context.Context, from, to model.Time, bufferSize int, matchers ...*labels.Matcher) (ingester_client.QueryStreamSeriesSet, error) {
+	req, err := ingester_client.ToQueryRequest(from, to, matchers)
+	if err != nil {
+		return nil, err
+	}
+	// The series are merged as they're received, so the ingesters must stream them in order.
+	req.SortSeries = true
+	req.SeriesLimit = int64(serieslimit.LimitFromContext(ctx))
+
+	replicationSet, err := d.GetIngestersForQuery(ctx, matchers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.queryIngesterStreamSeries(ctx, replicationSet, req, bufferSize), nil
+}
+
+// queryIngesterStreamSeries queries all the ingesters of the replication set, and returns their series
+// merged as they're streamed. Unlike queryReplicationSet, the extra query delay isn't applied since the
+// streams are read until their end anyway.
+func (d *Distributor) queryIngesterStreamSeries(ctx context.Context, replicationSet ring.ReplicationSet, req *ingester_client.QueryRequest, bufferSize int) *queryStreamSeriesSet {
+	ctx, cancel := context.WithCancel(ctx)
+	set := &queryStreamSeriesSet{
+		ctx:             ctx,
+		cancel:          cancel,
+		distributor:     d,
+		replicationSet:  replicationSet,
+		partialResponse: d.partialResponseEnabled(ctx),
+		reqStats:        stats.FromContext(ctx),
+		failedZones:     map[string]struct{}{},
+	}
+
+	for i := range replicationSet.Instances {
+		stream := &ingesterSeriesStream{
+			instance: &replicationSet.Instances[i],
+			series:   make(chan ingester_client.QueryStreamSeries, bufferSize),
+		}
+		set.streams = append(set.streams, stream)
+		go func() {
+			defer close(stream.series)
+			start := time.Now()
+			stream.err = d.streamIngesterSeries(ctx, req, stream.instance, stream.series)
+			if set.reqStats != nil {
+				recordIngesterQuery(set.reqStats, stream.instance, time.Since(start), stream.err)
+			}
+		}()
+	}
+	// The first series of all the streams are needed before merging them.
+	set.advance = append(set.advance, set.streams...)
+	return set
+}
+
+// streamIngesterSeries receives the query stream of the ingester, enforcing the query limits, and sends
+// its series to ch in order.
+func (d *Distributor) streamIngesterSeries(ctx context.Context, req *ingester_client.QueryRequest, ing *ring.InstanceDesc, ch chan<- ingester_client.QueryStreamSeries) error {
+	queryLimiter := limiter.QueryLimiterFromContextWithFallback(ctx)
+
+	client, err := d.ingesterPool.GetClientFor(ing.Addr)
+	if err != nil {
+		return err
+	}
+	d.ingesterQueries.WithLabelValues(ing.Addr).Inc()
+
+	stream, err := client.(ingester_client.IngesterClient).QueryStream(ctx, req)
+	if err != nil {
+		d.ingesterQueryFailures.WithLabelValues(ing.Addr).Inc()
+		return err
+	}
+	defer stream.CloseSend() //nolint:errcheck
+
+	var (
+		last  labels.Labels
+		first = true
+	)
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			// Do not track a failure if the context was canceled.
+			if !grpcutil.IsGRPCContextCanceled(err) {
+				d.ingesterQueryFailures.WithLabelValues(ing.Addr).Inc()
+			}
+			return err
+		}
+
+		if err := addQueryStreamResponseToLimiter(queryLimiter, resp); err != nil {
+			return err
+		}
+		if resp.SeriesLimitReached {
+			serieslimit.TruncationFromContext(ctx).Mark()
+		}
+
+		for _, series := range queryStreamResponseSeries(resp) {
+			lbls := cortexpb.FromLabelAdaptersToLabels(series.Labels)
+			if !first && labels.Compare(last, lbls) >= 0 {
+				return fmt.Errorf("the series streamed by the ingester %s are out of order, all the ingesters must be upgraded to merge their query streams lazily", ing.Addr)
+			}
+			last, first = lbls, false
+
+			select {
+			case ch <- series:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// queryStreamResponseSeries returns the series of a message of the query stream of an ingester, in order.
+// The chunks and the samples of a series are in the same message, in its chunk series and time series.
+func queryStreamResponseSeries(resp *ingester_client.QueryStreamResponse) []ingester_client.QueryStreamSeries {
+	result := make([]ingester_client.QueryStreamSeries, 0, len(resp.Chunkseries)+len(resp.Timeseries))
+	i, j := 0, 0
+	for i < len(resp.Chunkseries) || j < len(resp.Timeseries) {
+		var c int
+		switch {
+		case i == len(resp.Chunkseries):
+			c = 1
+		case j == len(resp.Timeseries):
+			c = -1
+		default:
+			c = labels.Compare(cortexpb.FromLabelAdaptersToLabels(resp.Chunkseries[i].Labels), cortexpb.FromLabelAdaptersToLabels(resp.Timeseries[j].Labels))
+		}
+
+		var series ingester_client.QueryStreamSeries
+		if c <= 0 {
+			series.Labels = resp.Chunkseries[i].Labels
+			series.Chunks = resp.Chunkseries[i].Chunks
+			i++
+		}
+		if c >= 0 {
+			series.Labels = resp.Timeseries[j].Labels
+			series.Samples = resp.Timeseries[j].Samples
+			series.Histograms = resp.Timeseries[j].Histograms
+			j++
+		}
+		result = append(result, series)
+	}
+	return result
+}
+
+// ingesterSeriesStream is the series of the query stream of an ingester, received by their own goroutine.
+type ingesterSeriesStream struct {
+	instance *ring.InstanceDesc
+	series   chan ingester_client.QueryStreamSeries
+	// err is set before series is closed.
+	err error
+
+	head ingester_client.QueryStreamSeries
+	done bool
+}
+
+// queryStreamSeriesSet merges the series of the query streams of the ingesters. The failed ingesters are
+// tolerated as long as the replication set tolerates them, or in partial response mode as long as at
+// least one ingester didn't fail.
+type queryStreamSeriesSet struct {
+	ctx             context.Context
+	cancel          context.CancelFunc
+	distributor     *Distributor
+	replicationSet  ring.ReplicationSet
+	partialResponse bool
+	reqStats        *stats.QueryStats
+
+	streams []*ingesterSeriesStream
+	// advance is the streams whose head was merged in the current series.
+	advance []*ingesterSeriesStream
+
+	failed      []string
+	failedZones map[string]struct{}
+	// reported is the number of failed ingesters already recorded in the partial response failures.
+	reported int
+
+	cur ingester_client.QueryStreamSeries
+	err error
+}
+
+func (s *queryStreamSeriesSet) Next() bool {
+	if s.err != nil {
+		return false
+	}
+
+	for _, stream := range s.advance {
+		if !s.receive(stream) {
+			s.cancel()
+			return false
+		}
+	}
+
+	// The number of ingesters is small, so their heads are scanned rather than kept in a heap.
+	s.advance = s.advance[:0]
+	for _, stream := range s.streams {
+		if stream.done {
+			continue
+		}
+		if len(s.advance) == 0 {
+			s.advance = append(s.advance, stream)
+			continue
+		}
+		c := labels.Compare(cortexpb.FromLabelAdaptersToLabels(stream.head.Labels), cortexpb.FromLabelAdaptersToLabels(s.advance[0].head.Labels))
+		if c < 0 {
+			s.advance = append(s.advance[:0], stream)
+		} else if c == 0 {
+			s.advance = append(s.advance, stream)
+		}
+	}
+	if len(s.advance) == 0 {
+		s.cancel()
+		return false
+	}
+
+	s.cur = s.advance[0].head
+	for _, stream := range s.advance[1:] {
+		s.cur.Chunks = append(s.cur.Chunks, stream.head.Chunks...)
+		if s.cur.Samples == nil {
+			s.cur.Samples = stream.head.Samples
+		} else if stream.head.Samples != nil {
+			s.cur.Samples = mergeSamples(s.cur.Samples, stream.head.Samples)
+		}
+		if s.cur.Histograms == nil {
+			s.cur.Histograms = stream.head.Histograms
+		} else if stream.head.Histograms != nil {
+			s.cur.Histograms = mergeHistograms(s.cur.Histograms, stream.head.Histograms)
+		}
+	}
+
+	resp := s.cur.Response()
+	s.reqStats.AddFetchedSeries(1)
+	s.reqStats.AddFetchedChunkBytes(uint64(resp.ChunksSize()))
+	s.reqStats.AddFetchedIngestersChunkBytes(uint64(resp.ChunksSize()))
+	s.reqStats.AddFetchedDataBytes(uint64(resp.Size()))
+	s.reqStats.AddFetchedChunks(uint64(resp.ChunksCount()))
+	s.reqStats.AddFetchedSamples(uint64(resp.SamplesCount()))
+	s.reqStats.AddFetchedHistogramSamples(uint64(resp.HistogramSamplesCount()))
+	return true
+}
+
+// receive receives the next series of the stream, and returns false if the stream failed the query.
+func (s *queryStreamSeriesSet) receive(stream *ingesterSeriesStream) bool {
+	series, ok := <-stream.series
+	if ok {
+		stream.head = series
+		return true
+	}
+
+	stream.done = true
+	if stream.err == nil {
+		return true
+	}
+
+	// The query limits are still enforced, whatever the failed ingesters.
+	var limitErr validation.LimitError
+	if errors.As(stream.err, &limitErr) || s.ctx.Err() != nil {
+		s.err = stream.err
+		return false
+	}
+
+	s.failed = append(s.failed, stream.instance.Addr)
+	s.failedZones[stream.instance.Zone] = struct{}{}
+	tolerated := len(s.failed) <= s.replicationSet.MaxErrors
+	if s.replicationSet.MaxUnavailableZones > 0 {
+		tolerated = len(s.failedZones) <= s.replicationSet.MaxUnavailableZones
+	}
+	if tolerated {
+		return true
+	}
+	if !s.partialResponse || len(s.failed) == len(s.streams) {
+		s.err = stream.err
+		return false
+	}
+
+	level.Warn(util_log.WithContext(s.ctx, s.distributor.log)).Log("msg", "returning a partial response, some ingesters failed the query", "failed", stream.instance.Addr, "err", stream.err)
+	partialresponse.FailuresFromContext(s.ctx).Add(s.failed[s.reported:]...)
+	s.reported = len(s.failed)
+	return true
+}
+
+func (s *queryStreamSeriesSet) At() ingester_client.QueryStreamSeries {
+	return s.cur
+}
+
+func (s *queryStreamSeriesSet) Err() error {
+	return s.err
+}
+
+// Close cancels the query streams still being received, whose goroutines then terminate.
+func (s *queryStreamSeriesSet) Close() {
+	s.cancel()
+}
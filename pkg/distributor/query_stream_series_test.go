@@ -0,0 +1,348 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/querier/partialresponse"
+	"github.com/cortexproject/cortex/pkg/querier/serieslimit"
+	"github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/limiter"
+	"github.com/cortexproject/cortex/pkg/util/test"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestDistributor_QueryStreamSeries(t *testing.T) {
+	t.Parallel()
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	for _, bufferSize := range []int{0, 1, 100} {
+		bufferSize := bufferSize
+		t.Run(fmt.Sprintf("buffer size %d", bufferSize), func(t *testing.T) {
+			t.Parallel()
+
+			ds, ingesters, _, _ := prepare(t, prepConfig{
+				numIngesters:     3,
+				happyIngesters:   3,
+				numDistributors:  1,
+				shardByAllLabels: true,
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "user")
+			_, err := ds[0].Push(ctx, makeWriteRequest(0, 10, 0))
+			require.NoError(t, err)
+
+			// The push returns once the quorum is reached, wait for all the ingesters to receive it.
+			for _, ing := range ingesters {
+				ing := ing
+				test.Poll(t, time.Second, 10, func() interface{} {
+					return len(ing.series())
+				})
+			}
+
+			reqStats, ctx := stats.ContextWithEmptyStats(ctx)
+			set, err := ds[0].QueryStreamSeries(ctx, math.MinInt32, math.MaxInt32, bufferSize, allSeriesMatchers...)
+			require.NoError(t, err)
+			series, err := drainQueryStreamSeriesSet(set)
+			require.NoError(t, err)
+
+			require.Len(t, series, 10)
+			for i, s := range series {
+				if i > 0 {
+					assert.Less(t, labels.Compare(cortexpb.FromLabelAdaptersToLabels(series[i-1].Labels), cortexpb.FromLabelAdaptersToLabels(s.Labels)), 0)
+				}
+				// The chunks of the series are the ones of all the ingesters, since all their streams are read.
+				assert.Len(t, s.Chunks, 3)
+			}
+			assert.Equal(t, uint64(10), reqStats.LoadFetchedSeries())
+			assert.Equal(t, uint64(30), reqStats.LoadFetchedChunks())
+		})
+	}
+}
+
+func TestDistributor_QueryStreamSeries_Failures(t *testing.T) {
+	t.Parallel()
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	tests := map[string]struct {
+		partialResponse bool
+		happyIngesters  int
+		expectedErr     bool
+		expectedFailure bool
+	}{
+		"failures tolerated by the replication": {
+			happyIngesters: 2,
+		},
+		"quorum lost": {
+			happyIngesters: 1,
+			expectedErr:    true,
+		},
+		"partial response, quorum lost": {
+			partialResponse: true,
+			happyIngesters:  1,
+			expectedFailure: true,
+		},
+		"partial response, all ingesters failed": {
+			partialResponse: true,
+			happyIngesters:  0,
+			expectedErr:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ds, ingesters, _, _ := prepare(t, prepConfig{
+				numIngesters:     3,
+				happyIngesters:   3,
+				numDistributors:  1,
+				shardByAllLabels: true,
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "user")
+			_, err := ds[0].Push(ctx, makeWriteRequest(0, 10, 0))
+			require.NoError(t, err)
+
+			// The push returns once the quorum is reached, wait for all the ingesters to receive it.
+			for _, ing := range ingesters {
+				ing := ing
+				test.Poll(t, time.Second, 10, func() interface{} {
+					return len(ing.series())
+				})
+			}
+
+			for _, ing := range ingesters[tc.happyIngesters:] {
+				ing.happy.Store(false)
+			}
+
+			ctx = partialresponse.ContextWithEnabled(ctx, tc.partialResponse)
+			failures, ctx := partialresponse.ContextWithFailures(ctx)
+
+			set, err := ds[0].QueryStreamSeries(ctx, math.MinInt32, math.MaxInt32, 1, allSeriesMatchers...)
+			require.NoError(t, err)
+			series, err := drainQueryStreamSeriesSet(set)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, series, 10)
+
+			if tc.expectedFailure {
+				assert.Len(t, failures.Instances(), 3-tc.happyIngesters)
+			} else {
+				assert.Empty(t, failures.Instances())
+			}
+		})
+	}
+}
+
+func TestDistributor_QueryStreamSeries_IngesterFanOutStats(t *testing.T) {
+	t.Parallel()
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		numZones:         3,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	_, err := ds[0].Push(ctx, makeWriteRequest(0, 10, 0))
+	require.NoError(t, err)
+
+	// The push returns once the quorum is reached, wait for all the ingesters to receive it.
+	for _, ing := range ingesters {
+		ing := ing
+		test.Poll(t, time.Second, 10, func() interface{} {
+			return len(ing.series())
+		})
+	}
+	ingesters[2].happy.Store(false)
+
+	reqStats, ctx := stats.ContextWithEmptyStats(ctx)
+	set, err := ds[0].QueryStreamSeries(ctx, math.MinInt32, math.MaxInt32, 1, allSeriesMatchers...)
+	require.NoError(t, err)
+	series, err := drainQueryStreamSeriesSet(set)
+	require.NoError(t, err)
+	require.Len(t, series, 10)
+
+	assert.Equal(t, uint64(3), reqStats.LoadQueriedIngesters())
+	assert.Equal(t, uint64(1), reqStats.LoadFailedIngesters())
+	assert.Greater(t, reqStats.LoadSlowestIngesterLatency(), time.Duration(0))
+	assert.Equal(t, []string{"zone-0", "zone-1", "zone-2"}, reqStats.LoadQueriedIngesterZones())
+}
+
+func TestRecordIngesterQuery(t *testing.T) {
+	t.Parallel()
+
+	reqStats := &stats.QueryStats{}
+	recordIngesterQuery(reqStats, &ring.InstanceDesc{Zone: "zone-a"}, 2*time.Second, nil)
+	recordIngesterQuery(reqStats, &ring.InstanceDesc{Zone: "zone-b"}, time.Second, nil)
+	recordIngesterQuery(reqStats, &ring.InstanceDesc{Zone: "zone-b"}, 3*time.Second, errors.New("failed"))
+	// The ingesters canceled once the query doesn't need them anymore didn't fail.
+	recordIngesterQuery(reqStats, &ring.InstanceDesc{Zone: "zone-c"}, 3*time.Second, context.Canceled)
+	recordIngesterQuery(reqStats, &ring.InstanceDesc{}, 3*time.Second, status.Error(codes.Canceled, context.Canceled.Error()))
+
+	assert.Equal(t, uint64(5), reqStats.LoadQueriedIngesters())
+	assert.Equal(t, uint64(1), reqStats.LoadFailedIngesters())
+	assert.Equal(t, 2*time.Second, reqStats.LoadSlowestIngesterLatency())
+	assert.Equal(t, []string{"zone-a", "zone-b", "zone-c"}, reqStats.LoadQueriedIngesterZones())
+}
+
+func TestDistributor_QueryStreamSeries_ShouldReturnErrorIfMaxChunksPerQueryLimitIsReached(t *testing.T) {
+	t.Parallel()
+	const maxChunksLimit = 30 // Chunks are duplicated due to replication factor.
+
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.MaxChunksPerQuery = maxChunksLimit
+
+	ds, _, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           limits,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, maxChunksLimit, 0))
+
+	_, err := ds[0].Push(ctx, makeWriteRequest(0, maxChunksLimit, 0))
+	require.NoError(t, err)
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+	set, err := ds[0].QueryStreamSeries(ctx, math.MinInt32, math.MaxInt32, 1, allSeriesMatchers...)
+	require.NoError(t, err)
+	_, err = drainQueryStreamSeriesSet(set)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the query hit the max number of chunks limit")
+}
+
+func TestDistributor_QueryStream_SeriesLimit(t *testing.T) {
+	t.Parallel()
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	_, err := ds[0].Push(ctx, makeWriteRequest(0, 10, 0))
+	require.NoError(t, err)
+
+	// The push returns once the quorum is reached, wait for all the ingesters to receive it.
+	for _, ing := range ingesters {
+		ing := ing
+		test.Poll(t, time.Second, 10, func() interface{} {
+			return len(ing.series())
+		})
+	}
+
+	set, err := ds[0].QueryStreamSeries(ctx, math.MinInt32, math.MaxInt32, 1, allSeriesMatchers...)
+	require.NoError(t, err)
+	all, err := drainQueryStreamSeriesSet(set)
+	require.NoError(t, err)
+	require.Len(t, all, 10)
+
+	for _, limit := range []int{3, 10} {
+		limit := limit
+		t.Run(fmt.Sprintf("limit %d", limit), func(t *testing.T) {
+			t.Parallel()
+
+			truncation, ctx := serieslimit.ContextWithLimit(ctx, limit)
+			set, err := ds[0].QueryStreamSeries(ctx, math.MinInt32, math.MaxInt32, 1, allSeriesMatchers...)
+			require.NoError(t, err)
+			series, err := drainQueryStreamSeriesSet(set)
+			require.NoError(t, err)
+
+			// The ingesters return the first series by labels, so the subset is deterministic.
+			require.Len(t, series, limit)
+			for i, s := range series {
+				assert.Equal(t, all[i].Labels, s.Labels)
+			}
+			assert.Equal(t, limit < len(all), truncation.Truncated())
+
+			truncation, ctx = serieslimit.ContextWithLimit(ctx, limit)
+			resp, err := ds[0].QueryStream(ctx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+			require.NoError(t, err)
+			assert.Len(t, resp.Chunkseries, limit)
+			assert.Equal(t, limit < len(all), truncation.Truncated())
+		})
+	}
+}
+
+func TestQueryStreamResponseSeries(t *testing.T) {
+	t.Parallel()
+
+	lbls := func(name string) []cortexpb.LabelAdapter {
+		return []cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: name}}
+	}
+	chunks := []ingester_client.Chunk{{Data: []byte{1}}}
+	samples := []cortexpb.Sample{{TimestampMs: 1, Value: 1}}
+
+	resp := &ingester_client.QueryStreamResponse{
+		Chunkseries: []ingester_client.TimeSeriesChunk{
+			{Labels: lbls("a"), Chunks: chunks},
+			{Labels: lbls("b"), Chunks: chunks},
+		},
+		Timeseries: []cortexpb.TimeSeries{
+			{Labels: lbls("b"), Samples: samples},
+			{Labels: lbls("c"), Samples: samples},
+		},
+	}
+
+	assert.Equal(t, []ingester_client.QueryStreamSeries{
+		{Labels: lbls("a"), Chunks: chunks},
+		{Labels: lbls("b"), Chunks: chunks, Samples: samples},
+		{Labels: lbls("c"), Samples: samples},
+	}, queryStreamResponseSeries(resp))
+}
+
+func drainQueryStreamSeriesSet(set ingester_client.QueryStreamSeriesSet) ([]ingester_client.QueryStreamSeries, error) {
+	defer set.Close()
+
+	var series []ingester_client.QueryStreamSeries
+	for set.Next() {
+		series = append(series, set.At())
+	}
+	return series, set.Err()
+}
@@ -116,6 +116,21 @@ func TestMergeSamplesIntoFirstNilB(t *testing.T) {
 	require.Equal(t, b, a)
 }
 
+func TestMergeHistograms(t *testing.T) {
+	t.Parallel()
+	histogram := func(ts int64) cortexpb.Histogram {
+		return cortexpb.Histogram{Count: &cortexpb.Histogram_CountInt{CountInt: uint64(ts)}, Sum: float64(ts), TimestampMs: ts}
+	}
+
+	a := []cortexpb.Histogram{histogram(10), histogram(20), histogram(30)}
+	b := []cortexpb.Histogram{histogram(5), histogram(20), histogram(25), histogram(30), histogram(40)}
+
+	require.Equal(t, []cortexpb.Histogram{histogram(5), histogram(10), histogram(20), histogram(25), histogram(30), histogram(40)}, mergeHistograms(a, b))
+	require.Equal(t, a, mergeHistograms(a, a))
+	require.Equal(t, a, mergeHistograms(nil, a))
+	require.Equal(t, a, mergeHistograms(a, nil))
+}
+
 func TestMergeExemplars(t *testing.T) {
 	t.Parallel()
 	now := timestamp.FromTime(time.Now())
@@ -0,0 +1,240 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/cortexproject/cortex/pkg/chunk/encoding"
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const (
+	singleZoneReadFailed          = "failed"
+	singleZoneReadIncompleteZones = "incomplete_zones"
+	singleZoneReadMissingData     = "missing_data"
+)
+
+// queryIngestersReplicationSet runs f on the instances of the replication set like queryReplicationSet,
+// but on the instances of a single zone first when the single zone reads are enabled. The instances of
+// a second zone are queried alongside to verify that the zone didn't miss any data: the other zones
+// are queried if any instance of the zone fails, or if the two zones don't return as many series and
+// samples up to the replication delay ago. from is the start of the query.
+func (d *Distributor) queryIngestersReplicationSet(ctx context.Context, replicationSet ring.ReplicationSet, from model.Time, f func(context.Context, *ring.InstanceDesc) (interface{}, error)) ([]interface{}, error) {
+	if !d.cfg.PreferSingleZoneReads || replicationSet.MaxUnavailableZones == 0 {
+		return d.queryReplicationSet(ctx, replicationSet, f)
+	}
+
+	d.singleZoneReads.Inc()
+	zone, ok := singleReadZone(replicationSet, from)
+	if !ok {
+		d.singleZoneReadFallbacks.WithLabelValues(singleZoneReadIncompleteZones).Inc()
+		return d.queryReplicationSet(ctx, replicationSet, f)
+	}
+
+	zoneSet, othersSet := splitReplicationSetByZone(replicationSet, zone)
+	verifyZone, ok := singleReadZone(othersSet, from)
+	if !ok {
+		d.singleZoneReadFallbacks.WithLabelValues(singleZoneReadIncompleteZones).Inc()
+		return d.queryReplicationSet(ctx, replicationSet, f)
+	}
+	verifySet, remainingSet := splitReplicationSetByZone(othersSet, verifyZone)
+
+	var (
+		g                          errgroup.Group
+		zoneResults, verifyResults []interface{}
+		verifyErr                  error
+	)
+	g.Go(func() error {
+		var err error
+		zoneResults, err = zoneSet.Do(ctx, 0, f)
+		return err
+	})
+	g.Go(func() error {
+		// A failure of the second zone doesn't fail the query, the zone is then verified by the others.
+		verifyResults, verifyErr = verifySet.Do(ctx, 0, f)
+		return nil
+	})
+	err := g.Wait()
+
+	// The query limits are enforced whatever the zone, and a canceled query isn't retried.
+	var limitErr validation.LimitError
+	if err != nil && (errors.As(err, &limitErr) || ctx.Err() != nil) {
+		return nil, err
+	}
+	if err == nil && verifyErr != nil && (errors.As(verifyErr, &limitErr) || ctx.Err() != nil) {
+		return nil, verifyErr
+	}
+
+	switch {
+	case err != nil:
+		level.Warn(util_log.WithContext(ctx, d.log)).Log("msg", "querying the ingesters of the other zones, an ingester of the zone failed the query", "zone", zone, "err", err)
+		d.singleZoneReadFallbacks.WithLabelValues(singleZoneReadFailed).Inc()
+		return d.queryReplicationSet(ctx, othersSet, f)
+
+	case verifyErr != nil:
+		level.Warn(util_log.WithContext(ctx, d.log)).Log("msg", "querying the ingesters of the other zones, an ingester of the zone verifying the query failed it", "zone", verifyZone, "err", verifyErr)
+		d.singleZoneReadFallbacks.WithLabelValues(singleZoneReadFailed).Inc()
+		results, err := d.queryReplicationSet(ctx, remainingSet, f)
+		if err != nil {
+			return nil, err
+		}
+		return append(zoneResults, results...), nil
+	}
+
+	// Both zones are returned: if they missed different samples of the same size, their union still has them all.
+	// The most recent samples may still be replicated to one of the zones, so they aren't compared.
+	results := append(zoneResults, verifyResults...)
+	cutoff := model.Now().Add(-d.cfg.SingleZoneReadsReplicationDelay)
+	zoneSeries, zoneSamples := queryResultsSize(zoneResults, cutoff)
+	verifySeries, verifySamples := queryResultsSize(verifyResults, cutoff)
+	if zoneSeries == verifySeries && zoneSamples == verifySamples {
+		return results, nil
+	}
+
+	level.Warn(util_log.WithContext(ctx, d.log)).Log("msg", "querying the ingesters of the other zones, a zone is missing some data", "zone", zone, "series", zoneSeries, "samples", zoneSamples, "verify_zone", verifyZone, "verify_series", verifySeries, "verify_samples", verifySamples)
+	d.singleZoneReadFallbacks.WithLabelValues(singleZoneReadMissingData).Inc()
+	if len(remainingSet.Instances) == 0 {
+		return results, nil
+	}
+
+	// The two zones already reached the quorum of the replication set, so if the remaining zones fail,
+	// the results are the same as the ones of the replication set.
+	remainingResults, err := d.queryReplicationSet(ctx, remainingSet, f)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		level.Warn(util_log.WithContext(ctx, d.log)).Log("msg", "failed to query the ingesters of the remaining zones", "err", err)
+		return results, nil
+	}
+	return append(results, remainingResults...), nil
+}
+
+// queryResultsSize returns the number of series and samples of the results of the ingesters, up to
+// through included. The samples of the chunks are counted without decoding them, unless the chunk
+// ends after through.
+func queryResultsSize(results []interface{}, through model.Time) (series, samples int) {
+	for _, result := range results {
+		switch r := result.(type) {
+		case model.Matrix:
+			for _, ss := range r {
+				n := 0
+				for _, s := range ss.Values {
+					if s.Timestamp <= through {
+						n++
+					}
+				}
+				for _, h := range ss.Histograms {
+					if h.Timestamp <= through {
+						n++
+					}
+				}
+				series, samples = addSeriesSize(series, samples, n)
+			}
+		case *ingester_client.QueryStreamResponse:
+			for _, ts := range r.Timeseries {
+				n := 0
+				for _, s := range ts.Samples {
+					if s.TimestampMs <= int64(through) {
+						n++
+					}
+				}
+				for _, h := range ts.Histograms {
+					if h.TimestampMs <= int64(through) {
+						n++
+					}
+				}
+				series, samples = addSeriesSize(series, samples, n)
+			}
+			for _, cs := range r.Chunkseries {
+				n := 0
+				for _, c := range cs.Chunks {
+					n += chunkSamplesCount(c, through)
+				}
+				series, samples = addSeriesSize(series, samples, n)
+			}
+		}
+	}
+	return series, samples
+}
+
+// addSeriesSize adds a series of n samples to the series and samples counts, if it has any sample.
+func addSeriesSize(series, samples, n int) (int, int) {
+	if n == 0 {
+		return series, samples
+	}
+	return series + 1, samples + n
+}
+
+// chunkSamplesCount returns the number of samples of the chunk up to through included.
+func chunkSamplesCount(c ingester_client.Chunk, through model.Time) int {
+	switch {
+	case c.StartTimestampMs > int64(through):
+		return 0
+	case c.EndTimestampMs <= int64(through):
+		return c.SamplesCount()
+	}
+
+	chk, err := encoding.NewForEncoding(encoding.Encoding(byte(c.Encoding)))
+	if err != nil {
+		return 0
+	}
+	if err := chk.UnmarshalFromBuf(c.Data); err != nil {
+		return 0
+	}
+	n := 0
+	for it := chk.NewIterator(nil); it.Scan() && it.Value().Timestamp <= through; {
+		n++
+	}
+	return n
+}
+
+// singleReadZone returns a random zone of the replication set whose instances have all been registered
+// before from, so that they have received all the samples of the query. Recently registered instances
+// may have been restarted, missing the samples written meanwhile.
+func singleReadZone(replicationSet ring.ReplicationSet, from model.Time) (string, bool) {
+	complete := map[string]bool{}
+	for _, instance := range replicationSet.Instances {
+		registeredAt := instance.GetRegisteredAt()
+		wasComplete, ok := complete[instance.Zone]
+		complete[instance.Zone] = (wasComplete || !ok) && !registeredAt.IsZero() && registeredAt.Before(from.Time())
+	}
+
+	var zones []string
+	for zone, ok := range complete {
+		if ok {
+			zones = append(zones, zone)
+		}
+	}
+	if len(zones) == 0 {
+		return "", false
+	}
+
+	// The zones are sorted to pick them evenly, whatever the order of the map.
+	sort.Strings(zones)
+	return zones[rand.Intn(len(zones))], true
+}
+
+// splitReplicationSetByZone returns the replication set of the instances of zone, which must all
+// succeed, and the one of the instances of the other zones, tolerating one less unavailable zone.
+func splitReplicationSetByZone(replicationSet ring.ReplicationSet, zone string) (ring.ReplicationSet, ring.ReplicationSet) {
+	zoneSet := ring.ReplicationSet{}
+	othersSet := ring.ReplicationSet{MaxUnavailableZones: max(replicationSet.MaxUnavailableZones-1, 0)}
+	for _, instance := range replicationSet.Instances {
+		if instance.Zone == zone {
+			zoneSet.Instances = append(zoneSet.Instances, instance)
+		} else {
+			othersSet.Instances = append(othersSet.Instances, instance)
+		}
+	}
+	return zoneSet, othersSet
+}
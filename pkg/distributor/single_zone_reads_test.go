@@ -0,0 +1,345 @@
+package distributor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/chunk"
+	"github.com/cortexproject/cortex/pkg/chunk/encoding"
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestDistributor_QueryStream_PreferSingleZoneReads(t *testing.T) {
+	t.Parallel()
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:          6,
+		happyIngesters:        6,
+		numDistributors:       1,
+		shardByAllLabels:      true,
+		numZones:              3,
+		preferSingleZoneReads: true,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	_, err := ds[0].Push(ctx, makeWriteRequest(0, 10, 0))
+	require.NoError(t, err)
+
+	// The push returns once the quorum is reached, wait for all the zones to receive it.
+	test.Poll(t, time.Second, 30, func() interface{} {
+		numSeries := 0
+		for _, ing := range ingesters {
+			numSeries += len(ing.series())
+		}
+		return numSeries
+	})
+
+	// The ingesters have been registered 2 hours ago, before the start of the queries.
+	from, to := model.Now().Add(-time.Hour), model.Now()
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+	queriedZones := func(before []int) map[string]int {
+		zones := map[string]int{}
+		for i, ing := range ingesters {
+			if ing.countCalls("QueryStream") > before[i] {
+				zones[ingesterZone(i)]++
+			}
+		}
+		return zones
+	}
+	countCalls := func() []int {
+		calls := make([]int, len(ingesters))
+		for i, ing := range ingesters {
+			calls[i] = ing.countCalls("QueryStream")
+		}
+		return calls
+	}
+
+	// Only the ingesters of a single zone, and of the zone verifying it, are queried.
+	for i := 0; i < 10; i++ {
+		before := countCalls()
+		resp, err := ds[0].QueryStream(ctx, from, to, allSeriesMatchers...)
+		require.NoError(t, err)
+		assert.Len(t, resp.Chunkseries, 10)
+
+		zones := queriedZones(before)
+		require.Len(t, zones, 2)
+		for _, numIngesters := range zones {
+			assert.Equal(t, 2, numIngesters)
+		}
+	}
+	assert.Equal(t, float64(0), testutil.ToFloat64(ds[0].singleZoneReadFallbacks.WithLabelValues(singleZoneReadFailed)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(ds[0].singleZoneReadFallbacks.WithLabelValues(singleZoneReadMissingData)))
+
+	// The other zones are queried when the zone fails.
+	ingesters[0].happy.Store(false)
+	failedQueries := 0
+	for i := 0; i < 10; i++ {
+		before := countCalls()
+		resp, err := ds[0].QueryStream(ctx, from, to, allSeriesMatchers...)
+		require.NoError(t, err)
+		assert.Len(t, resp.Chunkseries, 10)
+
+		zones := queriedZones(before)
+		if zones[ingesterZone(0)] > 0 {
+			failedQueries++
+			assert.Len(t, zones, 3)
+		} else {
+			assert.Len(t, zones, 2)
+		}
+	}
+	assert.Equal(t, float64(failedQueries), testutil.ToFloat64(ds[0].singleZoneReadFallbacks.WithLabelValues(singleZoneReadFailed)))
+}
+
+func TestDistributor_QueryStream_PreferSingleZoneReads_MissingData(t *testing.T) {
+	t.Parallel()
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:          6,
+		happyIngesters:        6,
+		numDistributors:       1,
+		shardByAllLabels:      true,
+		numZones:              3,
+		preferSingleZoneReads: true,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	_, err := ds[0].Push(ctx, makeWriteRequest(0, 10, 0))
+	require.NoError(t, err)
+
+	test.Poll(t, time.Second, 30, func() interface{} {
+		numSeries := 0
+		for _, ing := range ingesters {
+			numSeries += len(ing.series())
+		}
+		return numSeries
+	})
+
+	// An ingester of the first zone missed some series, while succeeding the queries.
+	missing := ingesters[0]
+	missing.Lock()
+	require.NotEmpty(t, missing.timeseries)
+	for hash := range missing.timeseries {
+		delete(missing.timeseries, hash)
+		break
+	}
+	missing.Unlock()
+
+	from, to := model.Now().Add(-time.Hour), model.Now()
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	missingDataQueries := 0
+	for i := 0; i < 20; i++ {
+		before := missing.countCalls("QueryStream")
+		resp, err := ds[0].QueryStream(ctx, from, to, allSeriesMatchers...)
+		require.NoError(t, err)
+
+		// The series missing from the zone are always returned.
+		assert.Len(t, resp.Chunkseries, 10)
+		if missing.countCalls("QueryStream") > before {
+			missingDataQueries++
+		}
+	}
+	require.Greater(t, missingDataQueries, 0)
+	assert.Equal(t, float64(missingDataQueries), testutil.ToFloat64(ds[0].singleZoneReadFallbacks.WithLabelValues(singleZoneReadMissingData)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(ds[0].singleZoneReadFallbacks.WithLabelValues(singleZoneReadFailed)))
+}
+
+func TestDistributor_QueryStream_PreferSingleZoneReads_ReplicationDelay(t *testing.T) {
+	t.Parallel()
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:          6,
+		happyIngesters:        6,
+		numDistributors:       1,
+		shardByAllLabels:      true,
+		numZones:              3,
+		preferSingleZoneReads: true,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	_, err := ds[0].Push(ctx, makeWriteRequest(0, 10, 0))
+	require.NoError(t, err)
+
+	test.Poll(t, time.Second, 30, func() interface{} {
+		numSeries := 0
+		for _, ing := range ingesters {
+			numSeries += len(ing.series())
+		}
+		return numSeries
+	})
+
+	for _, ing := range ingesters {
+		ing.Lock()
+		ing.chunksTimeRange = true
+		ing.Unlock()
+	}
+
+	// An ingester of the first zone already has a recent sample, not yet replicated to the other zones.
+	recent := ingesters[0]
+	recent.Lock()
+	require.NotEmpty(t, recent.timeseries)
+	for _, ts := range recent.timeseries {
+		ts.Samples = append(ts.Samples, cortexpb.Sample{TimestampMs: int64(model.Now()), Value: 1})
+		break
+	}
+	recent.Unlock()
+
+	from, to := model.Now().Add(-time.Hour), model.Now()
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	// The samples within the replication delay aren't compared, so the queries don't fall back to all the zones.
+	for i := 0; i < 20; i++ {
+		resp, err := ds[0].QueryStream(ctx, from, to, allSeriesMatchers...)
+		require.NoError(t, err)
+		assert.Len(t, resp.Chunkseries, 10)
+	}
+	assert.Equal(t, float64(20), testutil.ToFloat64(ds[0].singleZoneReads))
+	assert.Equal(t, float64(0), testutil.ToFloat64(ds[0].singleZoneReadFallbacks.WithLabelValues(singleZoneReadMissingData)))
+
+	// Without replication delay, the recent sample is considered missing from the other zones.
+	ds[0].cfg.SingleZoneReadsReplicationDelay = 0
+	for i := 0; i < 20; i++ {
+		_, err := ds[0].QueryStream(ctx, from, to, allSeriesMatchers...)
+		require.NoError(t, err)
+	}
+	assert.Greater(t, testutil.ToFloat64(ds[0].singleZoneReadFallbacks.WithLabelValues(singleZoneReadMissingData)), float64(0))
+}
+
+func TestQueryResultsSize(t *testing.T) {
+	t.Parallel()
+
+	chunk := func(from, through int64) ingester_client.Chunk {
+		c, err := encoding.NewForEncoding(encoding.PrometheusXorChunk)
+		require.NoError(t, err)
+		for ts := from; ts <= through; ts++ {
+			overflow, err := c.Add(model.SamplePair{Timestamp: model.Time(ts), Value: 1})
+			require.NoError(t, err)
+			require.Nil(t, overflow)
+		}
+		chunks, err := chunkcompat.ToChunks([]chunk.Chunk{chunk.NewChunk(labels.EmptyLabels(), c, model.Time(from), model.Time(through))})
+		require.NoError(t, err)
+		return chunks[0]
+	}
+
+	results := []interface{}{
+		model.Matrix{
+			{Values: []model.SamplePair{{Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 2}}},
+			{Values: []model.SamplePair{{Timestamp: 1, Value: 1}}},
+			{Values: []model.SamplePair{{Timestamp: 20, Value: 1}}},
+		},
+		&ingester_client.QueryStreamResponse{
+			Timeseries: []cortexpb.TimeSeries{
+				{Samples: []cortexpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 20, Value: 1}}},
+			},
+			Chunkseries: []ingester_client.TimeSeriesChunk{
+				{Chunks: []ingester_client.Chunk{chunk(1, 5), chunk(6, 15)}},
+				{Chunks: []ingester_client.Chunk{chunk(11, 20)}},
+			},
+		},
+	}
+
+	series, samples := queryResultsSize(results, 30)
+	assert.Equal(t, 6, series)
+	assert.Equal(t, 4+2+15+10, samples)
+
+	// The samples after through aren't counted, and neither are the series without samples before it,
+	// decoding the chunks spanning through.
+	series, samples = queryResultsSize(results, 10)
+	assert.Equal(t, 4, series)
+	assert.Equal(t, 3+1+10, samples)
+}
+
+func TestSingleReadZone(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	from := model.TimeFromUnixNano(now.Add(-time.Hour).UnixNano())
+	instance := func(zone string, registeredAt time.Time) ring.InstanceDesc {
+		desc := ring.InstanceDesc{Zone: zone}
+		if !registeredAt.IsZero() {
+			desc.RegisteredTimestamp = registeredAt.Unix()
+		}
+		return desc
+	}
+
+	tests := map[string]struct {
+		instances     []ring.InstanceDesc
+		expectedZones []string
+	}{
+		"all the zones are complete": {
+			instances: []ring.InstanceDesc{
+				instance("zone-a", now.Add(-2*time.Hour)),
+				instance("zone-b", now.Add(-2*time.Hour)),
+				instance("zone-c", now.Add(-2*time.Hour)),
+			},
+			expectedZones: []string{"zone-a", "zone-b", "zone-c"},
+		},
+		"an instance registered after the start of the query": {
+			instances: []ring.InstanceDesc{
+				instance("zone-a", now.Add(-2*time.Hour)),
+				instance("zone-a", now.Add(-time.Minute)),
+				instance("zone-b", now.Add(-2*time.Hour)),
+				instance("zone-c", now.Add(-2*time.Hour)),
+			},
+			expectedZones: []string{"zone-b", "zone-c"},
+		},
+		"an instance without registration timestamp": {
+			instances: []ring.InstanceDesc{
+				instance("zone-a", now.Add(-2*time.Hour)),
+				instance("zone-b", time.Time{}),
+			},
+			expectedZones: []string{"zone-a"},
+		},
+		"no complete zone": {
+			instances: []ring.InstanceDesc{
+				instance("zone-a", now.Add(-time.Minute)),
+				instance("zone-b", now.Add(-time.Minute)),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			picked := map[string]struct{}{}
+			for i := 0; i < 100; i++ {
+				zone, ok := singleReadZone(ring.ReplicationSet{Instances: tc.instances, MaxUnavailableZones: 1}, from)
+				if len(tc.expectedZones) == 0 {
+					require.False(t, ok)
+					continue
+				}
+				require.True(t, ok)
+				require.Contains(t, tc.expectedZones, zone)
+				picked[zone] = struct{}{}
+			}
+			// The zones are picked randomly among the complete ones.
+			assert.Len(t, picked, len(tc.expectedZones))
+		})
+	}
+}
+
+// ingesterZone returns the zone of the i-th ingester of a ring prepared with 3 zones.
+func ingesterZone(i int) string {
+	return fmt.Sprintf("zone-%d", i%3)
+}
@@ -0,0 +1,135 @@
+package distributor
+
+import (
+	"errors"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const writeDedupPurgeInterval = 30 * time.Second
+
+// WriteDedupConfig configures the deduplication of the retries of the push requests.
+type WriteDedupConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"`
+	MaxKeys int           `yaml:"max_keys"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *WriteDedupConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.write-dedup.enabled", false, "Experimental: Deduplicate the retries of the push requests carrying the same Idempotency-Key header, so that the retries after an ambiguous failure don't ingest the samples twice. The keys are kept in the memory of each distributor, so the retries are only deduplicated when sent to the same distributor.")
+	f.DurationVar(&cfg.TTL, "distributor.write-dedup.ttl", 5*time.Minute, "How long the idempotency key of a successful push request is kept to deduplicate its retries.")
+	f.IntVar(&cfg.MaxKeys, "distributor.write-dedup.max-keys", 100000, "Maximum number of idempotency keys kept by each distributor. Once reached, the push requests with a new key aren't deduplicated.")
+}
+
+// Validate validates the config.
+func (cfg *WriteDedupConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TTL <= 0 || cfg.MaxKeys <= 0 {
+		return errors.New("the write dedup TTL and max keys must be greater than 0")
+	}
+	return nil
+}
+
+type writeDedupState int
+
+const (
+	// writeDedupNew is the state of a push request whose key hasn't been seen yet, or isn't tracked.
+	writeDedupNew writeDedupState = iota
+	// writeDedupInProgress is the state of a retry of a push request still in progress.
+	writeDedupInProgress
+	// writeDedupDone is the state of a retry of a successful push request.
+	writeDedupDone
+)
+
+type writeDedupEntry struct {
+	done    bool
+	expires time.Time
+}
+
+// writeDedupCache tracks the idempotency keys of the push requests of each tenant.
+type writeDedupCache struct {
+	cfg WriteDedupConfig
+
+	mtx  sync.Mutex
+	keys map[string]writeDedupEntry
+
+	dedupedRequests *prometheus.CounterVec
+}
+
+func newWriteDedupCache(cfg WriteDedupConfig, reg prometheus.Registerer) *writeDedupCache {
+	return &writeDedupCache{
+		cfg:  cfg,
+		keys: map[string]writeDedupEntry{},
+		dedupedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_write_dedup_requests_total",
+			Help: "The total number of retries of push requests deduplicated by their idempotency key.",
+		}, []string{"user", "state"}),
+	}
+}
+
+func writeDedupKey(userID, key string) string {
+	return userID + "/" + key
+}
+
+// begin returns the state of the push request with the given key, and starts tracking it if new.
+func (c *writeDedupCache) begin(userID, key string, now time.Time) writeDedupState {
+	k := writeDedupKey(userID, key)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if e, ok := c.keys[k]; ok && (!e.done || now.Before(e.expires)) {
+		if e.done {
+			c.dedupedRequests.WithLabelValues(userID, "done").Inc()
+			return writeDedupDone
+		}
+		c.dedupedRequests.WithLabelValues(userID, "in_progress").Inc()
+		return writeDedupInProgress
+	}
+
+	if len(c.keys) < c.cfg.MaxKeys {
+		c.keys[k] = writeDedupEntry{}
+	}
+	return writeDedupNew
+}
+
+// end records the outcome of the push request with the given key. The key of a failed push
+// request is forgotten, so that its retries are pushed.
+func (c *writeDedupCache) end(userID, key string, success bool, now time.Time) {
+	k := writeDedupKey(userID, key)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, ok := c.keys[k]; !ok {
+		return
+	}
+	if !success {
+		delete(c.keys, k)
+		return
+	}
+	c.keys[k] = writeDedupEntry{done: true, expires: now.Add(c.cfg.TTL)}
+}
+
+// purgeExpired forgets the keys of the successful push requests older than the TTL.
+func (c *writeDedupCache) purgeExpired(now time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for k, e := range c.keys {
+		if e.done && !now.Before(e.expires) {
+			delete(c.keys, k)
+		}
+	}
+}
+
+func (c *writeDedupCache) deleteUser(userID string) {
+	c.dedupedRequests.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
@@ -0,0 +1,92 @@
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+func TestWriteDedupCache(t *testing.T) {
+	c := newWriteDedupCache(WriteDedupConfig{Enabled: true, TTL: time.Minute, MaxKeys: 2}, prometheus.NewPedanticRegistry())
+	now := time.Now()
+
+	assert.Equal(t, writeDedupNew, c.begin("user-1", "key-1", now))
+	assert.Equal(t, writeDedupInProgress, c.begin("user-1", "key-1", now))
+	// The keys are scoped to the tenant.
+	assert.Equal(t, writeDedupNew, c.begin("user-2", "key-1", now))
+
+	// The key of a successful push request is kept for the TTL.
+	c.end("user-1", "key-1", true, now)
+	assert.Equal(t, writeDedupDone, c.begin("user-1", "key-1", now.Add(30*time.Second)))
+	c.purgeExpired(now.Add(time.Minute))
+	assert.Equal(t, writeDedupNew, c.begin("user-1", "key-1", now.Add(time.Minute)))
+
+	// The key of a failed push request is forgotten.
+	c.end("user-2", "key-1", false, now)
+	assert.Equal(t, writeDedupNew, c.begin("user-2", "key-1", now))
+
+	// The new keys aren't tracked once the max keys is reached.
+	assert.Equal(t, writeDedupNew, c.begin("user-3", "key-1", now))
+	assert.Equal(t, writeDedupNew, c.begin("user-3", "key-1", now))
+}
+
+func TestDistributor_WriteDedup(t *testing.T) {
+	ds, ingesters, regs, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		writeDedup:       true,
+	})
+	d, reg := ds[0], regs[0]
+	ctx := util.ContextWithIdempotencyKey(user.InjectOrgID(context.Background(), "user-1"), "key-1")
+
+	push := func(ctx context.Context) error {
+		_, err := d.Push(ctx, mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "foo")}, 1, 100000))
+		return err
+	}
+	countPushes := func() (count int) {
+		for _, ing := range ingesters {
+			count += ing.countCalls("Push")
+		}
+		return count
+	}
+
+	// The push request is sent to all the ingesters, the last one possibly after the quorum is reached.
+	require.NoError(t, push(ctx))
+	require.Eventually(t, func() bool { return countPushes() == 3 }, time.Second, 10*time.Millisecond)
+	pushes := countPushes()
+
+	// The retry is deduplicated, while a push request with another key isn't.
+	require.NoError(t, push(ctx))
+	assert.Equal(t, pushes, countPushes())
+	require.NoError(t, push(util.ContextWithIdempotencyKey(ctx, "key-2")))
+	assert.Greater(t, countPushes(), pushes)
+
+	// The retries of a push request in progress fail with a retryable error.
+	assert.Equal(t, writeDedupNew, d.writeDedup.begin("user-1", "key-3", time.Now()))
+	err := push(util.ContextWithIdempotencyKey(ctx, "key-3"))
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusServiceUnavailable), resp.Code)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_write_dedup_requests_total The total number of retries of push requests deduplicated by their idempotency key.
+		# TYPE cortex_distributor_write_dedup_requests_total counter
+		cortex_distributor_write_dedup_requests_total{state="done",user="user-1"} 1
+		cortex_distributor_write_dedup_requests_total{state="in_progress",user="user-1"} 1
+	`), "cortex_distributor_write_dedup_requests_total"))
+}
@@ -0,0 +1,62 @@
+package freshness
+
+import (
+	"flag"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+)
+
+const (
+	// MetricName is the name of the synthetic series written by the distributors. The value of each
+	// sample is its timestamp in seconds.
+	MetricName = "cortex_freshness_probe"
+
+	// ZoneLabel is the label of the synthetic series holding the zone of the distributor writing it.
+	ZoneLabel = "zone"
+	// InstanceLabel is the label of the synthetic series holding the distributor writing it.
+	InstanceLabel = "instance"
+
+	// PathIngesters is the read path querying the ingesters.
+	PathIngesters = "ingester"
+	// PathStore is the read path querying the long-term storage.
+	PathStore = "store"
+)
+
+// Config configures the end-to-end freshness probe, writing synthetic series through the
+// distributors and reading them back through the queriers.
+type Config struct {
+	Enabled  bool          `yaml:"enabled"`
+	TenantID string        `yaml:"tenant_id"`
+	Interval time.Duration `yaml:"interval"`
+	Zone     string        `yaml:"zone"`
+	Lookback time.Duration `yaml:"lookback"`
+}
+
+// RegisterFlags registers the freshness probe flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "freshness-probe.enabled", false, "Experimental: Write a synthetic series through each distributor and read it back from each querier, through the ingesters and through the long-term storage, to measure the end-to-end freshness and queryability of the ingested samples.")
+	f.StringVar(&cfg.TenantID, "freshness-probe.tenant-id", "__freshness_probe__", "Tenant the synthetic series are written to and read from.")
+	f.DurationVar(&cfg.Interval, "freshness-probe.interval", 15*time.Second, "How frequently the synthetic series are written and read back.")
+	f.StringVar(&cfg.Zone, "freshness-probe.zone", "", "Zone of this Cortex instance, added as a label to the synthetic series written by the distributor so that the freshness is measured per zone.")
+	f.DurationVar(&cfg.Lookback, "freshness-probe.lookback", 24*time.Hour, "How far back the synthetic series are looked for when read back. The freshness of a read path without any synthetic sample within the lookback is not measured.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if err := tenant.ValidTenantID(cfg.TenantID); err != nil {
+		return errors.Wrap(err, "invalid freshness probe tenant ID")
+	}
+	if cfg.Interval <= 0 {
+		return errors.New("the freshness probe interval must be greater than 0")
+	}
+	if cfg.Lookback < cfg.Interval {
+		return errors.New("the freshness probe lookback must be greater than or equal to the interval")
+	}
+	return nil
+}
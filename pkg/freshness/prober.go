@@ -0,0 +1,174 @@
+package freshness
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+// ZoneFreshness is the freshness of the synthetic series written by the distributors of a zone,
+// as read back through a read path.
+type ZoneFreshness struct {
+	Zone             string    `json:"zone"`
+	LastSampleTime   time.Time `json:"last_sample_time"`
+	FreshnessSeconds float64   `json:"freshness_seconds"`
+}
+
+// PathStatus is the outcome of the last read of the synthetic series through a read path.
+type PathStatus struct {
+	Path      string          `json:"path"`
+	LastProbe time.Time       `json:"last_probe"`
+	Error     string          `json:"error,omitempty"`
+	Zones     []ZoneFreshness `json:"zones"`
+}
+
+type readPath struct {
+	name      string
+	queryable storage.Queryable
+}
+
+// Prober periodically reads back the synthetic series through the ingesters and the long-term
+// storage, and tracks how old their last sample is in each zone.
+type Prober struct {
+	services.Service
+
+	cfg    Config
+	paths  []readPath
+	logger log.Logger
+
+	mtx    sync.Mutex
+	status map[string]PathStatus
+
+	freshness *prometheus.GaugeVec
+	queries   *prometheus.CounterVec
+}
+
+// NewProber makes a new Prober. The store queryable is optional: when nil, only the freshness
+// through the ingesters is measured.
+func NewProber(cfg Config, ingesters, store storage.Queryable, logger log.Logger, reg prometheus.Registerer) *Prober {
+	p := &Prober{
+		cfg:    cfg,
+		paths:  []readPath{{name: PathIngesters, queryable: ingesters}},
+		logger: logger,
+		status: map[string]PathStatus{},
+
+		freshness: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_freshness_probe_freshness_seconds",
+			Help: "How old the last sample of the synthetic series readable through each read path is, per zone of the distributors writing it.",
+		}, []string{"path", "zone"}),
+		queries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_freshness_probe_queries_total",
+			Help: "Total number of reads of the synthetic series through each read path.",
+		}, []string{"path", "status"}),
+	}
+	if store != nil {
+		p.paths = append(p.paths, readPath{name: PathStore, queryable: store})
+	}
+
+	p.Service = services.NewTimerService(cfg.Interval, nil, p.iteration, nil)
+	return p
+}
+
+func (p *Prober) iteration(ctx context.Context) error {
+	p.probe(ctx, time.Now())
+
+	// A failed read is only tracked: the probe keeps running.
+	return nil
+}
+
+func (p *Prober) probe(ctx context.Context, now time.Time) {
+	ctx = user.InjectOrgID(ctx, p.cfg.TenantID)
+
+	for _, path := range p.paths {
+		status := PathStatus{Path: path.name, LastProbe: now}
+
+		latest, err := p.readLatest(ctx, path.queryable, now)
+		if err != nil {
+			p.queries.WithLabelValues(path.name, "failure").Inc()
+			level.Warn(p.logger).Log("msg", "failed to read the freshness probe series", "path", path.name, "err", err)
+			status.Error = err.Error()
+		} else {
+			p.queries.WithLabelValues(path.name, "success").Inc()
+		}
+
+		// The zones without any sample within the lookback aren't measured anymore.
+		p.freshness.DeletePartialMatch(prometheus.Labels{"path": path.name})
+		for zone, ts := range latest {
+			last := time.UnixMilli(ts).UTC()
+			freshness := now.Sub(last).Seconds()
+			p.freshness.WithLabelValues(path.name, zone).Set(freshness)
+			status.Zones = append(status.Zones, ZoneFreshness{Zone: zone, LastSampleTime: last, FreshnessSeconds: freshness})
+		}
+		sort.Slice(status.Zones, func(i, j int) bool { return status.Zones[i].Zone < status.Zones[j].Zone })
+
+		p.mtx.Lock()
+		p.status[path.name] = status
+		p.mtx.Unlock()
+	}
+}
+
+// readLatest returns the timestamp of the last sample of the synthetic series within the
+// lookback, per zone.
+func (p *Prober) readLatest(ctx context.Context, queryable storage.Queryable, now time.Time) (map[string]int64, error) {
+	mint, maxt := now.Add(-p.cfg.Lookback).UnixMilli(), now.UnixMilli()
+
+	q, err := queryable.Querier(mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	hints := &storage.SelectHints{Start: mint, End: maxt}
+	set := q.Select(ctx, false, hints, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, MetricName))
+
+	latest := map[string]int64{}
+	var it chunkenc.Iterator
+	for set.Next() {
+		series := set.At()
+		zone := series.Labels().Get(ZoneLabel)
+
+		it = series.Iterator(it)
+		for it.Next() != chunkenc.ValNone {
+			if ts := it.AtT(); ts > latest[zone] {
+				latest[zone] = ts
+			}
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return latest, set.Err()
+}
+
+// Status returns the outcome of the last read through each read path.
+func (p *Prober) Status() []PathStatus {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	res := make([]PathStatus, 0, len(p.paths))
+	for _, path := range p.paths {
+		if status, ok := p.status[path.name]; ok {
+			res = append(res, status)
+		}
+	}
+	return res
+}
+
+// ServeHTTP serves the outcome of the last read through each read path.
+func (p *Prober) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, p.Status())
+}
@@ -0,0 +1,80 @@
+package freshness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/teststorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProber(t *testing.T) {
+	now := time.Unix(10000, 0)
+
+	ingesters := teststorage.New(t)
+	t.Cleanup(func() { _ = ingesters.Close() })
+	app := ingesters.Appender(context.Background())
+	for _, s := range []struct {
+		instance, zone string
+		ts             time.Time
+	}{
+		{"distributor-1", "zone-a", now.Add(-20 * time.Second)},
+		{"distributor-1", "zone-a", now.Add(-5 * time.Second)},
+		{"distributor-2", "zone-a", now.Add(-10 * time.Second)},
+		{"distributor-3", "zone-b", now.Add(-30 * time.Second)},
+		// Samples older than the lookback aren't read.
+		{"distributor-4", "zone-c", now.Add(-2 * time.Hour)},
+	} {
+		_, err := app.Append(0, labels.FromStrings(labels.MetricName, MetricName, InstanceLabel, s.instance, ZoneLabel, s.zone), s.ts.UnixMilli(), float64(s.ts.Unix()))
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	store := storage.QueryableFunc(func(_, _ int64) (storage.Querier, error) {
+		return nil, errors.New("store-gateways unavailable")
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	p := NewProber(Config{TenantID: "probe", Interval: time.Second, Lookback: time.Hour}, ingesters, store, log.NewNopLogger(), reg)
+	p.probe(context.Background(), now)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_freshness_probe_freshness_seconds How old the last sample of the synthetic series readable through each read path is, per zone of the distributors writing it.
+		# TYPE cortex_freshness_probe_freshness_seconds gauge
+		cortex_freshness_probe_freshness_seconds{path="ingester",zone="zone-a"} 5
+		cortex_freshness_probe_freshness_seconds{path="ingester",zone="zone-b"} 30
+		# HELP cortex_freshness_probe_queries_total Total number of reads of the synthetic series through each read path.
+		# TYPE cortex_freshness_probe_queries_total counter
+		cortex_freshness_probe_queries_total{path="ingester",status="success"} 1
+		cortex_freshness_probe_queries_total{path="store",status="failure"} 1
+	`)))
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/querier/freshness", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var status []PathStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	require.Len(t, status, 2)
+	assert.Equal(t, PathIngesters, status[0].Path)
+	assert.Empty(t, status[0].Error)
+	require.Len(t, status[0].Zones, 2)
+	assert.Equal(t, "zone-a", status[0].Zones[0].Zone)
+	assert.True(t, now.Add(-5*time.Second).Equal(status[0].Zones[0].LastSampleTime))
+	assert.Equal(t, 5.0, status[0].Zones[0].FreshnessSeconds)
+	assert.Equal(t, PathStore, status[1].Path)
+	assert.Equal(t, "store-gateways unavailable", status[1].Error)
+	assert.Empty(t, status[1].Zones)
+}
@@ -0,0 +1,87 @@
+package freshness
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+// Pusher writes the synthetic series, like the distributor does.
+type Pusher interface {
+	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
+}
+
+// Writer periodically writes a sample of the synthetic series of a distributor, whose value is
+// the time it's written at.
+type Writer struct {
+	services.Service
+
+	cfg    Config
+	pusher Pusher
+	series labels.Labels
+	logger log.Logger
+
+	writes    *prometheus.CounterVec
+	lastWrite prometheus.Gauge
+}
+
+// NewWriter makes a new Writer.
+func NewWriter(cfg Config, pusher Pusher, instanceID string, logger log.Logger, reg prometheus.Registerer) *Writer {
+	series := labels.NewBuilder(labels.FromStrings(labels.MetricName, MetricName, InstanceLabel, instanceID))
+	if cfg.Zone != "" {
+		series.Set(ZoneLabel, cfg.Zone)
+	}
+
+	w := &Writer{
+		cfg:    cfg,
+		pusher: pusher,
+		series: series.Labels(),
+		logger: logger,
+
+		writes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_freshness_probe_writes_total",
+			Help: "Total number of samples of the synthetic series written by the freshness probe.",
+		}, []string{"status"}),
+		lastWrite: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_freshness_probe_last_successful_write_timestamp_seconds",
+			Help: "Timestamp of the last sample of the synthetic series successfully written by the freshness probe.",
+		}),
+	}
+
+	w.Service = services.NewTimerService(cfg.Interval, nil, w.iteration, nil)
+	return w
+}
+
+func (w *Writer) iteration(ctx context.Context) error {
+	w.write(ctx, time.Now())
+
+	// A failed write is only tracked: the probe keeps running.
+	return nil
+}
+
+func (w *Writer) write(ctx context.Context, now time.Time) {
+	ts := now.UnixMilli()
+	req := cortexpb.ToWriteRequest(
+		[]labels.Labels{w.series},
+		[]cortexpb.Sample{{TimestampMs: ts, Value: float64(ts) / 1000}},
+		nil, nil, cortexpb.API,
+	)
+
+	if _, err := w.pusher.Push(user.InjectOrgID(ctx, w.cfg.TenantID), req); err != nil {
+		w.writes.WithLabelValues("failure").Inc()
+		level.Warn(w.logger).Log("msg", "failed to write the freshness probe sample", "err", err)
+		return
+	}
+
+	w.writes.WithLabelValues("success").Inc()
+	w.lastWrite.Set(float64(ts) / 1000)
+}
@@ -0,0 +1,80 @@
+package freshness
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+type pusherFunc func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
+
+func (f pusherFunc) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	return f(ctx, req)
+}
+
+func TestWriter(t *testing.T) {
+	var (
+		pushed []*cortexpb.WriteRequest
+		err    error
+	)
+	pusher := pusherFunc(func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+		userID, _ := user.ExtractOrgID(ctx)
+		assert.Equal(t, "probe", userID)
+		pushed = append(pushed, req)
+		return &cortexpb.WriteResponse{}, err
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	w := NewWriter(Config{TenantID: "probe", Interval: time.Second, Zone: "zone-a"}, pusher, "distributor-1", log.NewNopLogger(), reg)
+
+	now := time.Unix(1000, 0)
+	w.write(context.Background(), now)
+	err = errors.New("ingesters unavailable")
+	w.write(context.Background(), now.Add(time.Second))
+
+	require.Len(t, pushed, 2)
+	ts := pushed[0].Timeseries[0]
+	assert.Equal(t, `{__name__="cortex_freshness_probe", instance="distributor-1", zone="zone-a"}`, cortexpb.FromLabelAdaptersToLabels(ts.Labels).String())
+	assert.Equal(t, []cortexpb.Sample{{TimestampMs: 1000000, Value: 1000}}, ts.Samples)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_freshness_probe_last_successful_write_timestamp_seconds Timestamp of the last sample of the synthetic series successfully written by the freshness probe.
+		# TYPE cortex_freshness_probe_last_successful_write_timestamp_seconds gauge
+		cortex_freshness_probe_last_successful_write_timestamp_seconds 1000
+		# HELP cortex_freshness_probe_writes_total Total number of samples of the synthetic series written by the freshness probe.
+		# TYPE cortex_freshness_probe_writes_total counter
+		cortex_freshness_probe_writes_total{status="failure"} 1
+		cortex_freshness_probe_writes_total{status="success"} 1
+	`)))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := Config{}
+	flagext.DefaultValues(&valid)
+	valid.Enabled = true
+	require.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.TenantID = "a/b"
+	require.Error(t, invalid.Validate())
+
+	invalid = valid
+	invalid.Lookback = time.Second
+	require.Error(t, invalid.Validate())
+
+	// The config isn't validated when the probe is disabled.
+	invalid.Enabled = false
+	require.NoError(t, invalid.Validate())
+}
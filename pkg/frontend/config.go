@@ -21,6 +21,10 @@ type CombinedFrontendConfig struct {
 	FrontendV2 v2.Config               `yaml:",inline"`
 
 	DownstreamURL string `yaml:"downstream_url"`
+
+	Shadow            ShadowConfig            `yaml:"shadow"`
+	DownsamplingCheck DownsamplingCheckConfig `yaml:"downsampling_check"`
+	Federation        FederationConfig        `yaml:"federation"`
 }
 
 func (cfg *CombinedFrontendConfig) RegisterFlags(f *flag.FlagSet) {
@@ -29,6 +33,23 @@ func (cfg *CombinedFrontendConfig) RegisterFlags(f *flag.FlagSet) {
 	cfg.FrontendV2.RegisterFlags(f)
 
 	f.StringVar(&cfg.DownstreamURL, "frontend.downstream-url", "", "URL of downstream Prometheus.")
+	cfg.Shadow.RegisterFlags(f)
+	cfg.DownsamplingCheck.RegisterFlags(f)
+	cfg.Federation.RegisterFlags(f)
+}
+
+// Validate validates the config.
+func (cfg *CombinedFrontendConfig) Validate() error {
+	if err := cfg.Handler.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.Shadow.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.DownsamplingCheck.Validate(); err != nil {
+		return err
+	}
+	return cfg.Federation.Validate()
 }
 
 // InitFrontend initializes frontend (either V1 -- without scheduler, or V2 -- with scheduler) or no frontend at
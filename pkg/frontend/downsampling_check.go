@@ -0,0 +1,326 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	downsamplingCheckResultChecked = "checked"
+	downsamplingCheckResultSkipped = "skipped"
+	downsamplingCheckResultFailed  = "failed"
+)
+
+// DownsamplingCheckConfig configures the checking of the accuracy of the downsampled data, by evaluating
+// a sample of the range queries against both the raw and the downsampled data.
+type DownsamplingCheckConfig struct {
+	Percentage     float64                `yaml:"percentage"`
+	Resolutions    flagext.StringSliceCSV `yaml:"resolutions"`
+	MinAge         time.Duration          `yaml:"min_age"`
+	MaxAge         time.Duration          `yaml:"max_age"`
+	Timeout        time.Duration          `yaml:"timeout"`
+	MaxConcurrency int                    `yaml:"max_concurrency"`
+
+	resolutions []int64
+}
+
+func (cfg *DownsamplingCheckConfig) RegisterFlags(f *flag.FlagSet) {
+	cfg.Resolutions = []string{"5m", "1h"}
+
+	f.Float64Var(&cfg.Percentage, "frontend.downsampling-check.percentage", 0, "Experimental: Percentage, between 0 and 100, of the range queries evaluated again in the background against both the raw and the downsampled data, to report the relative error of the downsampled results. 0 to disable.")
+	f.Var(&cfg.Resolutions, "frontend.downsampling-check.resolutions", "Comma separated list of the downsampling resolutions whose results are compared with the raw ones.")
+	f.DurationVar(&cfg.MinAge, "frontend.downsampling-check.min-age", 48*time.Hour, "Minimum age of the data the checked queries are evaluated against. The time range of the checked queries is restricted to data old enough to have been downsampled.")
+	f.DurationVar(&cfg.MaxAge, "frontend.downsampling-check.max-age", 0, "Maximum age of the data the checked queries are evaluated against. Should be set to the retention of the raw data, so that the time range of the checked queries is restricted to data available at both the raw and the downsampled resolutions. 0 to disable.")
+	f.DurationVar(&cfg.Timeout, "frontend.downsampling-check.timeout", 2*time.Minute, "Timeout of each of the checked queries.")
+	f.IntVar(&cfg.MaxConcurrency, "frontend.downsampling-check.max-concurrency", 2, "Maximum number of range queries checked at the same time. The queries over the limit aren't checked.")
+}
+
+// Validate validates the config.
+func (cfg *DownsamplingCheckConfig) Validate() error {
+	if cfg.Percentage < 0 || cfg.Percentage > 100 {
+		return errors.New("the downsampling check percentage must be between 0 and 100")
+	}
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.MaxConcurrency <= 0 {
+		return errors.New("the downsampling check max concurrency must be greater than 0")
+	}
+	if cfg.MaxAge > 0 && cfg.MaxAge <= cfg.MinAge {
+		return errors.New("the downsampling check max age must be greater than the min age")
+	}
+
+	cfg.resolutions = cfg.resolutions[:0]
+	for _, s := range cfg.Resolutions {
+		resolution, err := downsample.ParseMaxSourceResolution(s, 0)
+		if err != nil {
+			return errors.Wrap(err, "invalid downsampling check resolution")
+		}
+		if resolution <= 0 {
+			return errors.Errorf("invalid downsampling check resolution %q", s)
+		}
+		cfg.resolutions = append(cfg.resolutions, resolution)
+	}
+	if len(cfg.resolutions) == 0 {
+		return errors.New("at least a downsampling check resolution is required")
+	}
+	return nil
+}
+
+// Enabled returns whether the accuracy of the downsampled data is checked.
+func (cfg *DownsamplingCheckConfig) Enabled() bool {
+	return cfg.Percentage > 0
+}
+
+// downsamplingCheckRoundTripper evaluates again a sample of the range queries, in the background,
+// against the raw and the downsampled data, and reports the relative error of the downsampled results.
+type downsamplingCheckRoundTripper struct {
+	cfg    DownsamplingCheckConfig
+	next   http.RoundTripper
+	logger log.Logger
+
+	// Semaphore limiting the queries checked at the same time.
+	inflight chan struct{}
+
+	queries           *prometheus.CounterVec
+	relativeError     *prometheus.HistogramVec
+	mismatchingSeries *prometheus.CounterVec
+}
+
+// NewDownsamplingCheckRoundTripper returns a round tripper checking a sample of the range queries
+// executed through next. The queries are always executed through next, unchanged.
+func NewDownsamplingCheckRoundTripper(cfg DownsamplingCheckConfig, next http.RoundTripper, logger log.Logger, reg prometheus.Registerer) http.RoundTripper {
+	return &downsamplingCheckRoundTripper{
+		cfg:      cfg,
+		next:     next,
+		logger:   logger,
+		inflight: make(chan struct{}, cfg.MaxConcurrency),
+		queries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_downsampling_check_queries_total",
+			Help: "Total number of range queries selected for checking the accuracy of the downsampled data, by result.",
+		}, []string{"result"}),
+		relativeError: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name: "cortex_frontend_downsampling_check_relative_error",
+			Help: "Relative error of the samples of the checked range queries evaluated against the downsampled data, compared with the raw data.",
+			// From 0.01% to about 1000%.
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"resolution"}),
+		mismatchingSeries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_downsampling_check_mismatching_series_total",
+			Help: "Total number of series of the checked range queries returned only at the raw resolution, or only at the downsampled resolution.",
+		}, []string{"resolution"}),
+	}
+}
+
+func (d *downsamplingCheckRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	if d.shouldCheck(r) {
+		select {
+		case d.inflight <- struct{}{}:
+			// The request is copied before being executed, as the round trippers may modify it.
+			params := copyQueryParams(r)
+			req := r.Clone(context.WithoutCancel(r.Context()))
+			go func() {
+				defer func() { <-d.inflight }()
+				d.check(req, params)
+			}()
+		default:
+			d.queries.WithLabelValues(downsamplingCheckResultSkipped).Inc()
+		}
+	}
+
+	return d.next.RoundTrip(r)
+}
+
+// shouldCheck returns whether the request is a range query, evaluated against the raw data, selected
+// for checking.
+func (d *downsamplingCheckRoundTripper) shouldCheck(r *http.Request) bool {
+	if !strings.HasSuffix(r.URL.Path, "/api/v1/query_range") || rand.Float64()*100 >= d.cfg.Percentage {
+		return false
+	}
+	return r.FormValue(downsample.MaxSourceResolutionParam) == ""
+}
+
+func (d *downsamplingCheckRoundTripper) check(r *http.Request, params url.Values) {
+	logger := util_log.WithContext(r.Context(), d.logger)
+
+	ok, err := d.restrictTimeRange(params, time.Now())
+	if err != nil || !ok {
+		d.queries.WithLabelValues(downsamplingCheckResultSkipped).Inc()
+		return
+	}
+
+	raw, err := d.evaluate(r, params, 0)
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to evaluate the checked query against the raw data", "query", params.Get("query"), "err", err)
+		d.queries.WithLabelValues(downsamplingCheckResultFailed).Inc()
+		return
+	}
+
+	for _, resolution := range d.cfg.resolutions {
+		downsampled, err := d.evaluate(r, params, resolution)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to evaluate the checked query against the downsampled data", "query", params.Get("query"), "resolution", model.Duration(time.Duration(resolution)*time.Millisecond), "err", err)
+			d.queries.WithLabelValues(downsamplingCheckResultFailed).Inc()
+			return
+		}
+		d.compare(raw, downsampled, model.Duration(time.Duration(resolution)*time.Millisecond).String())
+	}
+	d.queries.WithLabelValues(downsamplingCheckResultChecked).Inc()
+}
+
+// restrictTimeRange restricts the time range of the query to the data available at both the raw and the
+// downsampled resolutions, and returns false if there's none.
+func (d *downsamplingCheckRoundTripper) restrictTimeRange(params url.Values, now time.Time) (bool, error) {
+	startMs, err := util.ParseTime(params.Get("start"))
+	if err != nil {
+		return false, err
+	}
+	endMs, err := util.ParseTime(params.Get("end"))
+	if err != nil {
+		return false, err
+	}
+	start, end := util.TimeFromMillis(startMs), util.TimeFromMillis(endMs)
+
+	if maxEnd := now.Add(-d.cfg.MinAge); end.After(maxEnd) {
+		end = maxEnd
+	}
+	if d.cfg.MaxAge > 0 {
+		if minStart := now.Add(-d.cfg.MaxAge); start.Before(minStart) {
+			start = minStart
+		}
+	}
+	if !start.Before(end) {
+		return false, nil
+	}
+
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	return true, nil
+}
+
+// evaluate executes the range query at the given max source resolution, 0 for the raw data.
+func (d *downsamplingCheckRoundTripper) evaluate(r *http.Request, params url.Values, resolution int64) (model.Matrix, error) {
+	values := url.Values{}
+	for name, v := range params {
+		values[name] = v
+	}
+	if resolution > 0 {
+		values.Set(downsample.MaxSourceResolutionParam, strconv.FormatFloat(float64(resolution)/1000, 'f', -1, 64))
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), d.cfg.Timeout)
+	defer cancel()
+
+	req := r.Clone(ctx)
+	req.Method = http.MethodGet
+	req.Body = http.NoBody
+	req.ContentLength = 0
+	req.Header.Del("Content-Type")
+	req.Form = nil
+	req.PostForm = nil
+	req.URL.RawQuery = values.Encode()
+
+	resp, err := d.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := tripperware.BodyBuffer(resp, d.logger)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, errors.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+	}
+
+	var result struct {
+		Data struct {
+			Result model.Matrix `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal the response")
+	}
+	return result.Data.Result, nil
+}
+
+// compare observes the relative error of the samples of the downsampled result at the timestamps of the
+// raw result.
+func (d *downsamplingCheckRoundTripper) compare(raw, downsampled model.Matrix, resolution string) {
+	relativeError := d.relativeError.WithLabelValues(resolution)
+
+	downsampledByFingerprint := make(map[model.Fingerprint]*model.SampleStream, len(downsampled))
+	for _, s := range downsampled {
+		downsampledByFingerprint[s.Metric.Fingerprint()] = s
+	}
+
+	mismatching := 0
+	for _, rawSeries := range raw {
+		fp := rawSeries.Metric.Fingerprint()
+		downsampledSeries, ok := downsampledByFingerprint[fp]
+		if !ok {
+			mismatching++
+			continue
+		}
+		delete(downsampledByFingerprint, fp)
+
+		i := 0
+		for _, rawSample := range rawSeries.Values {
+			for i < len(downsampledSeries.Values) && downsampledSeries.Values[i].Timestamp < rawSample.Timestamp {
+				i++
+			}
+			if i == len(downsampledSeries.Values) {
+				break
+			}
+			if downsampledSeries.Values[i].Timestamp == rawSample.Timestamp {
+				relativeError.Observe(sampleRelativeError(float64(rawSample.Value), float64(downsampledSeries.Values[i].Value)))
+			}
+		}
+	}
+	mismatching += len(downsampledByFingerprint)
+
+	d.mismatchingSeries.WithLabelValues(resolution).Add(float64(mismatching))
+}
+
+// sampleRelativeError returns the relative error of the value compared with the expected one.
+func sampleRelativeError(expected, value float64) float64 {
+	switch {
+	case math.IsNaN(expected) && math.IsNaN(value), expected == value:
+		return 0
+	case expected == 0 || math.IsNaN(expected) || math.IsNaN(value):
+		return math.Inf(1)
+	default:
+		return math.Abs((value - expected) / expected)
+	}
+}
+
+// copyQueryParams returns a copy of the parameters of the query, parsed from the URL and the body.
+func copyQueryParams(r *http.Request) url.Values {
+	params := url.Values{}
+	for name, v := range r.Form {
+		params[name] = append([]string(nil), v...)
+	}
+	return params
+}
@@ -0,0 +1,137 @@
+package frontend
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+)
+
+func TestDownsamplingCheckRoundTripper(t *testing.T) {
+	now := time.Now()
+	start := now.Add(-72 * time.Hour).Unix()
+	end := now.Add(-71 * time.Hour).Unix()
+
+	executed := atomic.NewInt32(0)
+	next := shadowTestRoundTripper(func(r *http.Request) (*http.Response, error) {
+		executed.Inc()
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "user-1", r.Header.Get("X-Test-Org"))
+
+		body := ""
+		switch r.Form.Get("max_source_resolution") {
+		case "":
+			body = fmt.Sprintf(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"foo":"bar"},"values":[[%d,"100"],[%d,"200"]]}]}}`, start, end)
+		case "300":
+			body = fmt.Sprintf(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"foo":"bar"},"values":[[%d,"101"],[%d,"200"]]},{"metric":{"foo":"baz"},"values":[[%d,"1"]]}]}}`, start, end, start)
+		default:
+			t.Errorf("unexpected max source resolution %q", r.Form.Get("max_source_resolution"))
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+
+	cfg := DownsamplingCheckConfig{
+		Percentage:     100,
+		Resolutions:    []string{"5m"},
+		MinAge:         48 * time.Hour,
+		Timeout:        time.Minute,
+		MaxConcurrency: 1,
+	}
+	require.NoError(t, cfg.Validate())
+
+	reg := prometheus.NewPedanticRegistry()
+	rt := NewDownsamplingCheckRoundTripper(cfg, next, log.NewNopLogger(), reg)
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/prometheus/api/v1/query_range?query=up&start=%d&end=%d&step=3600", start, end), nil)
+	req.Header.Set("X-Test-Org", "user-1")
+	req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+	_, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(rt.(*downsamplingCheckRoundTripper).queries.WithLabelValues(downsamplingCheckResultChecked)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The query itself, then the raw and the downsampled checked queries.
+	assert.Equal(t, int32(3), executed.Load())
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_downsampling_check_mismatching_series_total Total number of series of the checked range queries returned only at the raw resolution, or only at the downsampled resolution.
+		# TYPE cortex_frontend_downsampling_check_mismatching_series_total counter
+		cortex_frontend_downsampling_check_mismatching_series_total{resolution="5m"} 1
+		# HELP cortex_frontend_downsampling_check_relative_error Relative error of the samples of the checked range queries evaluated against the downsampled data, compared with the raw data.
+		# TYPE cortex_frontend_downsampling_check_relative_error histogram
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="0.0001"} 1
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="0.0004"} 1
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="0.0016"} 1
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="0.0064"} 1
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="0.0256"} 2
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="0.1024"} 2
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="0.4096"} 2
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="1.6384"} 2
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="6.5536"} 2
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="26.2144"} 2
+		cortex_frontend_downsampling_check_relative_error_bucket{resolution="5m",le="+Inf"} 2
+		cortex_frontend_downsampling_check_relative_error_sum{resolution="5m"} 0.01
+		cortex_frontend_downsampling_check_relative_error_count{resolution="5m"} 2
+	`), "cortex_frontend_downsampling_check_mismatching_series_total", "cortex_frontend_downsampling_check_relative_error"))
+}
+
+func TestDownsamplingCheckRoundTripper_RestrictTimeRange(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	d := &downsamplingCheckRoundTripper{cfg: DownsamplingCheckConfig{MinAge: 48 * time.Hour, MaxAge: 96 * time.Hour}}
+
+	for name, tc := range map[string]struct {
+		start, end                 time.Duration
+		expectedOK                 bool
+		expectedStart, expectedEnd time.Duration
+	}{
+		"within the overlap": {
+			start: 72 * time.Hour, end: 60 * time.Hour,
+			expectedOK: true, expectedStart: 72 * time.Hour, expectedEnd: 60 * time.Hour,
+		},
+		"restricted to the overlap": {
+			start: 120 * time.Hour, end: time.Hour,
+			expectedOK: true, expectedStart: 96 * time.Hour, expectedEnd: 48 * time.Hour,
+		},
+		"too recent": {
+			start: 24 * time.Hour, end: 0,
+		},
+		"too old": {
+			start: 200 * time.Hour, end: 100 * time.Hour,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			params := url.Values{}
+			params.Set("start", strconv.FormatInt(now.Add(-tc.start).Unix(), 10))
+			params.Set("end", strconv.FormatInt(now.Add(-tc.end).Unix(), 10))
+
+			ok, err := d.restrictTimeRange(params, now)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedOK, ok)
+			if ok {
+				assert.Equal(t, strconv.FormatInt(now.Add(-tc.expectedStart).Unix(), 10), params.Get("start"))
+				assert.Equal(t, strconv.FormatInt(now.Add(-tc.expectedEnd).Unix(), 10), params.Get("end"))
+			}
+		})
+	}
+}
+
+func TestSampleRelativeError(t *testing.T) {
+	assert.Equal(t, 0.0, sampleRelativeError(10, 10))
+	assert.InDelta(t, 0.1, sampleRelativeError(10, 11), 1e-9)
+	assert.InDelta(t, 0.1, sampleRelativeError(-10, -9), 1e-9)
+	assert.Equal(t, 0.0, sampleRelativeError(0, 0))
+}
@@ -0,0 +1,506 @@
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	federationResultSuccess = "success"
+	federationResultFailed  = "failed"
+)
+
+// Kinds of the requests fanned out to the remote clusters.
+const (
+	federatedQuery = iota + 1
+	federatedSeries
+	federatedLabelNames
+	federatedLabelValues
+)
+
+// FederationConfig configures the fan out of the queries to remote Cortex clusters, like the ones
+// of the other regions, so that a single datasource can be used to query all of them.
+type FederationConfig struct {
+	LocalClusterName       string                 `yaml:"local_cluster_name"`
+	RemoteClusters         flagext.StringSliceCSV `yaml:"remote_clusters"`
+	ClusterLabel           string                 `yaml:"cluster_label"`
+	Timeout                time.Duration          `yaml:"timeout"`
+	PartialResponseEnabled bool                   `yaml:"partial_response_enabled"`
+}
+
+func (cfg *FederationConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.LocalClusterName, "frontend.federation.local-cluster-name", "local", "Name of the local cluster, set as the cluster label of the series it returns to the federated queries.")
+	f.Var(&cfg.RemoteClusters, "frontend.federation.remote-clusters", "Experimental: Comma-separated list of the remote Cortex clusters, as name=url pairs, the instant, range, series and labels queries of the tenants can be fanned out to, as configured by the -frontend.federation-clusters limit. The path of the queries is appended to the URL of the cluster. Empty to disable.")
+	f.StringVar(&cfg.ClusterLabel, "frontend.federation.cluster-label", "cluster", "Name of the label set to the name of the cluster on the series returned to the federated queries.")
+	f.DurationVar(&cfg.Timeout, "frontend.federation.timeout", 2*time.Minute, "Timeout of the queries fanned out to the remote clusters.")
+	f.BoolVar(&cfg.PartialResponseEnabled, "frontend.federation.partial-response-enabled", true, "Return the results of the clusters which succeeded, with a warning, when some of the clusters fail. If disabled, the federated query fails when any cluster fails.")
+}
+
+// Validate validates the config.
+func (cfg *FederationConfig) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.LocalClusterName == "" {
+		return errors.New("the federation local cluster name must be set")
+	}
+	if !model.LabelName(cfg.ClusterLabel).IsValid() {
+		return fmt.Errorf("invalid federation cluster label %q", cfg.ClusterLabel)
+	}
+	_, err := cfg.remoteClusters()
+	return err
+}
+
+// Enabled returns whether the queries can be fanned out to remote clusters.
+func (cfg *FederationConfig) Enabled() bool {
+	return len(cfg.RemoteClusters) > 0
+}
+
+type federationCluster struct {
+	name string
+	url  string
+}
+
+func (cfg *FederationConfig) remoteClusters() ([]federationCluster, error) {
+	names := map[string]struct{}{cfg.LocalClusterName: {}}
+	clusters := make([]federationCluster, 0, len(cfg.RemoteClusters))
+
+	for _, c := range cfg.RemoteClusters {
+		name, rawURL, ok := strings.Cut(c, "=")
+		if !ok || name == "" || rawURL == "" {
+			return nil, fmt.Errorf("invalid federation remote cluster %q, expected name=url", c)
+		}
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("duplicate federation cluster name %q", name)
+		}
+		if _, err := url.Parse(rawURL); err != nil {
+			return nil, errors.Wrapf(err, "invalid URL of the federation remote cluster %q", name)
+		}
+		names[name] = struct{}{}
+		clusters = append(clusters, federationCluster{name: name, url: rawURL})
+	}
+	return clusters, nil
+}
+
+// FederationLimits is the per-tenant routing of the queries to the remote clusters.
+type FederationLimits interface {
+	FederationClusters(userID string) []string
+}
+
+// federationRoundTripper fans the queries out to the remote clusters the tenant is routed to, and
+// merges their results with the local ones, labelled with the name of the cluster they come from.
+type federationRoundTripper struct {
+	cfg      FederationConfig
+	next     http.RoundTripper
+	clusters map[string]http.RoundTripper
+	order    []string
+	limits   FederationLimits
+	logger   log.Logger
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewFederationRoundTripper returns a round tripper fanning the instant, range, series and labels
+// queries out to both next and the remote clusters the tenant is routed to.
+func NewFederationRoundTripper(cfg FederationConfig, next http.RoundTripper, limits FederationLimits, logger log.Logger, reg prometheus.Registerer) (http.RoundTripper, error) {
+	remotes, err := cfg.remoteClusters()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &federationRoundTripper{
+		cfg:      cfg,
+		next:     next,
+		clusters: make(map[string]http.RoundTripper, len(remotes)),
+		limits:   limits,
+		logger:   logger,
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_federation_requests_total",
+			Help: "Total number of federated queries executed by each cluster, by result.",
+		}, []string{"cluster", "result"}),
+		duration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cortex_frontend_federation_request_duration_seconds",
+			Help:    "Time spent executing the federated queries, by cluster.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"cluster"}),
+	}
+
+	for _, c := range remotes {
+		rt, err := NewDownstreamRoundTripper(c.url, http.DefaultTransport)
+		if err != nil {
+			return nil, errors.Wrapf(err, "federation remote cluster %q", c.name)
+		}
+		f.clusters[c.name] = rt
+		f.order = append(f.order, c.name)
+	}
+	return f, nil
+}
+
+// federationResult is the outcome of a federated query on a cluster.
+type federationResult struct {
+	cluster string
+
+	// Original response or error, returned as is when the query can't be merged.
+	resp *http.Response
+	err  error
+
+	body []byte
+	// Failure of the cluster, if any.
+	failure error
+}
+
+// federationResponse is a response of the Prometheus API.
+type federationResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ErrorType string          `json:"errorType,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+type federationQueryData struct {
+	ResultType string          `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+func (f *federationRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	kind, labelName := federatedRequestKind(r.URL.Path)
+	if kind == 0 {
+		return f.next.RoundTrip(r)
+	}
+
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return f.next.RoundTrip(r)
+	}
+	remotes := f.tenantClusters(tenantIDs)
+	if len(remotes) == 0 {
+		return f.next.RoundTrip(r)
+	}
+
+	var body []byte
+	if r.Body != nil {
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*federationResult, len(remotes)+1)
+	wg := sync.WaitGroup{}
+	wg.Add(len(results))
+
+	go func() {
+		defer wg.Done()
+		local := r.Clone(r.Context())
+		local.Body = io.NopCloser(bytes.NewReader(body))
+		results[0] = f.execute(f.cfg.LocalClusterName, f.next, local)
+	}()
+	for i, name := range remotes {
+		go func(i int, name string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), f.cfg.Timeout)
+			defer cancel()
+			results[i+1] = f.execute(name, f.clusters[name], newFederatedRequest(ctx, r, body, tenantIDs))
+		}(i, name)
+	}
+	wg.Wait()
+
+	return f.merge(r, kind, labelName, results)
+}
+
+// tenantClusters returns the remote clusters the queries of all the tenants are routed to.
+func (f *federationRoundTripper) tenantClusters(tenantIDs []string) []string {
+	routed := map[string]int{}
+	for _, tenantID := range tenantIDs {
+		for _, name := range f.limits.FederationClusters(tenantID) {
+			routed[name]++
+		}
+	}
+
+	var clusters []string
+	for _, name := range f.order {
+		if routed[name] == len(tenantIDs) {
+			clusters = append(clusters, name)
+		}
+	}
+	return clusters
+}
+
+// newFederatedRequest returns a copy of the request to send to a remote cluster.
+func newFederatedRequest(ctx context.Context, r *http.Request, body []byte, tenantIDs []string) *http.Request {
+	req := r.Clone(ctx)
+	req.RequestURI = ""
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.Header.Set(user.OrgIDHeaderName, tenant.JoinTenantIDs(tenantIDs))
+	util_log.InjectRequestIDIntoHTTPRequest(ctx, req)
+	// Let the HTTP transport negotiate the compression, to transparently decompress the response.
+	req.Header.Del("Accept-Encoding")
+	return req
+}
+
+func (f *federationRoundTripper) execute(cluster string, rt http.RoundTripper, req *http.Request) *federationResult {
+	start := time.Now()
+	res := &federationResult{cluster: cluster}
+	res.resp, res.err = rt.RoundTrip(req)
+	f.duration.WithLabelValues(cluster).Observe(time.Since(start).Seconds())
+
+	switch {
+	case res.err != nil:
+		res.failure = res.err
+		if errResp, ok := httpgrpc.HTTPResponseFromError(res.err); ok {
+			res.failure = fmt.Errorf("status code %d: %s", errResp.Code, errResp.Body)
+		}
+	default:
+		res.body, res.failure = tripperware.BodyBuffer(res.resp, f.logger)
+		_ = res.resp.Body.Close()
+		// The body is decoded, in case it's returned as is.
+		res.resp.Body = io.NopCloser(bytes.NewReader(res.body))
+		res.resp.ContentLength = int64(len(res.body))
+		if res.resp.Header != nil {
+			res.resp.Header.Del("Content-Encoding")
+			res.resp.Header.Del("Content-Length")
+		}
+		if res.failure == nil && res.resp.StatusCode/100 != 2 {
+			res.failure = fmt.Errorf("status code %d: %s", res.resp.StatusCode, federatedErrorMessage(res.body))
+		}
+	}
+
+	if res.failure != nil {
+		f.requests.WithLabelValues(cluster, federationResultFailed).Inc()
+	} else {
+		f.requests.WithLabelValues(cluster, federationResultSuccess).Inc()
+	}
+	return res
+}
+
+func (f *federationRoundTripper) merge(r *http.Request, kind int, labelName string, results []*federationResult) (*http.Response, error) {
+	logger := util_log.WithContext(r.Context(), f.logger)
+	local := results[0]
+
+	// The errors of the client, like an invalid query, are returned as is.
+	if local.failure != nil && isClientError(local) {
+		return local.resp, local.err
+	}
+
+	var (
+		responses []*federationResponse
+		clusters  []string
+		warnings  []string
+	)
+	for _, res := range results {
+		if res.failure == nil {
+			resp := &federationResponse{}
+			if err := json.Unmarshal(res.body, resp); err != nil {
+				res.failure = errors.Wrap(err, "invalid response")
+			} else {
+				responses = append(responses, resp)
+				clusters = append(clusters, res.cluster)
+				warnings = append(warnings, resp.Warnings...)
+				continue
+			}
+		}
+
+		level.Warn(logger).Log("msg", "federated query failed", "cluster", res.cluster, "path", r.URL.Path, "err", res.failure)
+		if !f.cfg.PartialResponseEnabled {
+			return nil, httpgrpc.Errorf(http.StatusServiceUnavailable, "federated query failed on cluster %s: %v", res.cluster, res.failure)
+		}
+		warnings = append(warnings, fmt.Sprintf("federated query failed on cluster %s: %v", res.cluster, res.failure))
+	}
+
+	if len(responses) == 0 {
+		return local.resp, local.err
+	}
+
+	var (
+		data json.RawMessage
+		err  error
+	)
+	switch kind {
+	case federatedQuery:
+		data, err = f.mergeQueryData(responses, clusters)
+	case federatedSeries:
+		data, err = f.mergeSeries(responses, clusters)
+	case federatedLabelNames:
+		data, err = mergeStrings(responses, []string{f.cfg.ClusterLabel})
+	case federatedLabelValues:
+		if labelName == f.cfg.ClusterLabel {
+			data, err = json.Marshal(clusters)
+		} else {
+			data, err = mergeStrings(responses, nil)
+		}
+	}
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusInternalServerError, "failed to merge the federated responses: %v", err)
+	}
+
+	out, err := json.Marshal(&federationResponse{Status: "success", Data: data, Warnings: warnings})
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(out)),
+		ContentLength: int64(len(out)),
+	}, nil
+}
+
+// mergeQueryData merges the results of the instant and range queries. The series of the vectors and
+// matrices are labelled with their cluster, while the scalars and strings are taken from the first cluster.
+func (f *federationRoundTripper) mergeQueryData(responses []*federationResponse, clusters []string) (json.RawMessage, error) {
+	var merged *federationQueryData
+	var series []json.RawMessage
+
+	for i, resp := range responses {
+		data := &federationQueryData{}
+		if err := json.Unmarshal(resp.Data, data); err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = data
+		} else if data.ResultType != merged.ResultType {
+			return nil, fmt.Errorf("cluster %s returned a %s instead of a %s", clusters[i], data.ResultType, merged.ResultType)
+		}
+		if data.ResultType != model.ValMatrix.String() && data.ResultType != model.ValVector.String() {
+			return json.Marshal(merged)
+		}
+
+		var result []map[string]json.RawMessage
+		if err := json.Unmarshal(data.Result, &result); err != nil {
+			return nil, err
+		}
+		for _, s := range result {
+			metric := map[string]string{}
+			if raw, ok := s["metric"]; ok {
+				if err := json.Unmarshal(raw, &metric); err != nil {
+					return nil, err
+				}
+			}
+			metric[f.cfg.ClusterLabel] = clusters[i]
+
+			var err error
+			if s["metric"], err = json.Marshal(metric); err != nil {
+				return nil, err
+			}
+			encoded, err := json.Marshal(s)
+			if err != nil {
+				return nil, err
+			}
+			series = append(series, encoded)
+		}
+	}
+
+	var err error
+	if series == nil {
+		series = []json.RawMessage{}
+	}
+	if merged.Result, err = json.Marshal(series); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merged)
+}
+
+// mergeSeries merges the results of the series queries, labelled with their cluster.
+func (f *federationRoundTripper) mergeSeries(responses []*federationResponse, clusters []string) (json.RawMessage, error) {
+	merged := []map[string]string{}
+	for i, resp := range responses {
+		var series []map[string]string
+		if err := json.Unmarshal(resp.Data, &series); err != nil {
+			return nil, err
+		}
+		for _, s := range series {
+			s[f.cfg.ClusterLabel] = clusters[i]
+			merged = append(merged, s)
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// mergeStrings merges the sorted, deduplicated results of the labels queries.
+func mergeStrings(responses []*federationResponse, extra []string) (json.RawMessage, error) {
+	set := map[string]struct{}{}
+	for _, v := range extra {
+		set[v] = struct{}{}
+	}
+	for _, resp := range responses {
+		var values []string
+		if err := json.Unmarshal(resp.Data, &values); err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			set[v] = struct{}{}
+		}
+	}
+
+	merged := make([]string, 0, len(set))
+	for v := range set {
+		merged = append(merged, v)
+	}
+	sort.Strings(merged)
+	return json.Marshal(merged)
+}
+
+func isClientError(res *federationResult) bool {
+	code := 0
+	if res.resp != nil {
+		code = res.resp.StatusCode
+	} else if errResp, ok := httpgrpc.HTTPResponseFromError(res.err); ok {
+		code = int(errResp.Code)
+	}
+	return code/100 == 4
+}
+
+// federatedErrorMessage returns the error of the Prometheus API response, or the response itself.
+func federatedErrorMessage(body []byte) string {
+	resp := federationResponse{}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error != "" {
+		return resp.Error
+	}
+	return string(body)
+}
+
+// federatedRequestKind returns the kind of the federated request, or 0 if it's not federated, and the
+// name of the label of the label values requests.
+func federatedRequestKind(path string) (int, string) {
+	switch {
+	case strings.HasSuffix(path, "/api/v1/query"), strings.HasSuffix(path, "/api/v1/query_range"):
+		return federatedQuery, ""
+	case strings.HasSuffix(path, "/api/v1/series"):
+		return federatedSeries, ""
+	case strings.HasSuffix(path, "/api/v1/labels"):
+		return federatedLabelNames, ""
+	}
+
+	if i := strings.Index(path, "/api/v1/label/"); i >= 0 && strings.HasSuffix(path, "/values") {
+		name := strings.TrimSuffix(path[i+len("/api/v1/label/"):], "/values")
+		if name != "" && !strings.Contains(name, "/") {
+			return federatedLabelValues, name
+		}
+	}
+	return 0, ""
+}
@@ -0,0 +1,248 @@
+package frontend
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+type federationLimitsMock map[string][]string
+
+func (m federationLimitsMock) FederationClusters(userID string) []string {
+	return m[userID]
+}
+
+func TestFederationConfig_Validate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cfg         FederationConfig
+		expectedErr string
+	}{
+		"disabled": {
+			cfg: FederationConfig{},
+		},
+		"valid": {
+			cfg: FederationConfig{LocalClusterName: "eu", ClusterLabel: "cluster", RemoteClusters: flagext.StringSliceCSV{"us=http://us/prometheus", "ap=http://ap/prometheus"}},
+		},
+		"missing URL": {
+			cfg:         FederationConfig{LocalClusterName: "eu", ClusterLabel: "cluster", RemoteClusters: flagext.StringSliceCSV{"us"}},
+			expectedErr: `invalid federation remote cluster "us", expected name=url`,
+		},
+		"duplicate cluster": {
+			cfg:         FederationConfig{LocalClusterName: "eu", ClusterLabel: "cluster", RemoteClusters: flagext.StringSliceCSV{"eu=http://eu/prometheus"}},
+			expectedErr: `duplicate federation cluster name "eu"`,
+		},
+		"invalid cluster label": {
+			cfg:         FederationConfig{LocalClusterName: "eu", ClusterLabel: "a-b", RemoteClusters: flagext.StringSliceCSV{"us=http://us/prometheus"}},
+			expectedErr: `invalid federation cluster label "a-b"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestFederationRoundTripper(t *testing.T) {
+	const (
+		localMatrix  = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up"},"values":[[1,"1"]]}]}}`
+		remoteMatrix = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up"},"values":[[1,"0"]]}]},"warnings":["remote warning"]}`
+	)
+
+	for name, tc := range map[string]struct {
+		path           string
+		tenant         string
+		localBody      string
+		localErr       error
+		remoteStatus   int
+		remoteBody     string
+		partial        bool
+		expectedBody   string
+		expectedErr    error
+		expectedRemote bool
+	}{
+		"tenant not routed to remote clusters": {
+			path:         "/api/v1/query_range",
+			tenant:       "user-2",
+			localBody:    localMatrix,
+			expectedBody: localMatrix,
+		},
+		"not a federated request": {
+			path:         "/api/v1/metadata",
+			tenant:       "user-1",
+			localBody:    localMatrix,
+			expectedBody: localMatrix,
+		},
+		"range query": {
+			path:           "/api/v1/query_range",
+			tenant:         "user-1",
+			localBody:      localMatrix,
+			remoteStatus:   http.StatusOK,
+			remoteBody:     remoteMatrix,
+			expectedBody:   `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up","cluster":"eu"},"values":[[1,"1"]]},{"metric":{"__name__":"up","cluster":"us"},"values":[[1,"0"]]}]},"warnings":["remote warning"]}`,
+			expectedRemote: true,
+		},
+		"instant query returning a scalar": {
+			path:           "/api/v1/query",
+			tenant:         "user-1",
+			localBody:      `{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`,
+			remoteStatus:   http.StatusOK,
+			remoteBody:     `{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`,
+			expectedBody:   `{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`,
+			expectedRemote: true,
+		},
+		"series": {
+			path:           "/api/v1/series",
+			tenant:         "user-1",
+			localBody:      `{"status":"success","data":[{"__name__":"up"}]}`,
+			remoteStatus:   http.StatusOK,
+			remoteBody:     `{"status":"success","data":[{"__name__":"up"}]}`,
+			expectedBody:   `{"status":"success","data":[{"__name__":"up","cluster":"eu"},{"__name__":"up","cluster":"us"}]}`,
+			expectedRemote: true,
+		},
+		"label names": {
+			path:           "/api/v1/labels",
+			tenant:         "user-1",
+			localBody:      `{"status":"success","data":["__name__","job"]}`,
+			remoteStatus:   http.StatusOK,
+			remoteBody:     `{"status":"success","data":["__name__","instance"]}`,
+			expectedBody:   `{"status":"success","data":["__name__","cluster","instance","job"]}`,
+			expectedRemote: true,
+		},
+		"label values": {
+			path:           "/api/v1/label/job/values",
+			tenant:         "user-1",
+			localBody:      `{"status":"success","data":["b","c"]}`,
+			remoteStatus:   http.StatusOK,
+			remoteBody:     `{"status":"success","data":["a","b"]}`,
+			expectedBody:   `{"status":"success","data":["a","b","c"]}`,
+			expectedRemote: true,
+		},
+		"cluster label values": {
+			path:           "/api/v1/label/cluster/values",
+			tenant:         "user-1",
+			localBody:      `{"status":"success","data":[]}`,
+			remoteStatus:   http.StatusOK,
+			remoteBody:     `{"status":"success","data":[]}`,
+			expectedBody:   `{"status":"success","data":["eu","us"]}`,
+			expectedRemote: true,
+		},
+		"remote cluster failure with partial response": {
+			path:           "/api/v1/query_range",
+			tenant:         "user-1",
+			localBody:      localMatrix,
+			remoteStatus:   http.StatusInternalServerError,
+			remoteBody:     `{"status":"error","errorType":"internal","error":"boom"}`,
+			partial:        true,
+			expectedBody:   `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up","cluster":"eu"},"values":[[1,"1"]]}]},"warnings":["federated query failed on cluster us: status code 500: boom"]}`,
+			expectedRemote: true,
+		},
+		"remote cluster failure without partial response": {
+			path:           "/api/v1/query_range",
+			tenant:         "user-1",
+			localBody:      localMatrix,
+			remoteStatus:   http.StatusInternalServerError,
+			remoteBody:     `{"status":"error","errorType":"internal","error":"boom"}`,
+			expectedErr:    httpgrpc.Errorf(http.StatusServiceUnavailable, "federated query failed on cluster us: status code 500: boom"),
+			expectedRemote: true,
+		},
+		"local client error is returned as is": {
+			path:           "/api/v1/query_range",
+			tenant:         "user-1",
+			localErr:       httpgrpc.Errorf(http.StatusBadRequest, "invalid query"),
+			remoteStatus:   http.StatusBadRequest,
+			remoteBody:     `{"status":"error","errorType":"bad_data","error":"invalid query"}`,
+			partial:        true,
+			expectedErr:    httpgrpc.Errorf(http.StatusBadRequest, "invalid query"),
+			expectedRemote: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			remoteRequests := make(chan *http.Request, 1)
+			remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				remoteRequests <- r
+				w.WriteHeader(tc.remoteStatus)
+				_, _ = io.WriteString(w, tc.remoteBody)
+			}))
+			t.Cleanup(remote.Close)
+
+			next := shadowTestRoundTripper(func(r *http.Request) (*http.Response, error) {
+				if tc.localErr != nil {
+					return nil, tc.localErr
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(tc.localBody)),
+				}, nil
+			})
+
+			reg := prometheus.NewPedanticRegistry()
+			rt, err := NewFederationRoundTripper(FederationConfig{
+				LocalClusterName:       "eu",
+				RemoteClusters:         flagext.StringSliceCSV{"us=" + remote.URL},
+				ClusterLabel:           "cluster",
+				Timeout:                time.Minute,
+				PartialResponseEnabled: tc.partial,
+			}, next, federationLimitsMock{"user-1": {"us", "unknown"}}, log.NewNopLogger(), reg)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "/prometheus"+tc.path, nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), tc.tenant))
+
+			resp, err := rt.RoundTrip(req)
+			if tc.expectedErr != nil {
+				require.Equal(t, tc.expectedErr, err)
+			} else {
+				require.NoError(t, err)
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, tc.expectedBody, string(body))
+			}
+
+			if !tc.expectedRemote {
+				assert.Len(t, remoteRequests, 0)
+				assert.Equal(t, 0, testutil.CollectAndCount(rt.(*federationRoundTripper).requests))
+				return
+			}
+
+			remoteReq := <-remoteRequests
+			assert.Equal(t, "/prometheus"+tc.path, remoteReq.URL.Path)
+			assert.Equal(t, tc.tenant, remoteReq.Header.Get(user.OrgIDHeaderName))
+		})
+	}
+}
+
+func TestFederationRoundTripper_ShouldOnlyFanOutToClustersSharedByAllTenants(t *testing.T) {
+	rt, err := NewFederationRoundTripper(FederationConfig{
+		LocalClusterName: "eu",
+		RemoteClusters:   flagext.StringSliceCSV{"us=http://us", "ap=http://ap"},
+		ClusterLabel:     "cluster",
+	}, nil, federationLimitsMock{
+		"user-1": {"ap", "us"},
+		"user-2": {"us"},
+	}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	f := rt.(*federationRoundTripper)
+	assert.Equal(t, []string{"us", "ap"}, f.tenantClusters([]string{"user-1"}))
+	assert.Equal(t, []string{"us"}, f.tenantClusters([]string{"user-1", "user-2"}))
+	assert.Empty(t, f.tenantClusters([]string{"user-1", "user-3"}))
+}
@@ -0,0 +1,280 @@
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/tools/querytee"
+)
+
+const (
+	shadowResultMatch        = "match"
+	shadowResultMismatch     = "mismatch"
+	shadowResultShadowFailed = "shadow_failed"
+	shadowResultSkipped      = "skipped"
+)
+
+var errInvalidShadowPercentage = errors.New("the shadow percentage must be between 0 and 100")
+
+// ShadowConfig configures the mirroring of a percentage of the queries to a shadow downstream, to
+// compare its results and latency with the ones of the primary downstream before rolling it out.
+type ShadowConfig struct {
+	DownstreamURL            string        `yaml:"downstream_url"`
+	Percentage               float64       `yaml:"percentage"`
+	Timeout                  time.Duration `yaml:"timeout"`
+	MaxConcurrency           int           `yaml:"max_concurrency"`
+	ValueComparisonTolerance float64       `yaml:"value_comparison_tolerance"`
+}
+
+func (cfg *ShadowConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.DownstreamURL, "frontend.shadow.downstream-url", "", "Experimental: URL of the shadow downstream, like a new querier deployment or another Cortex cluster, to which a percentage of the instant and range queries are mirrored. The responses of the shadow downstream are compared with the primary ones asynchronously, and never returned to the clients. Empty to disable.")
+	f.Float64Var(&cfg.Percentage, "frontend.shadow.percentage", 0, "Percentage, between 0 and 100, of the instant and range queries mirrored to the shadow downstream.")
+	f.DurationVar(&cfg.Timeout, "frontend.shadow.timeout", 2*time.Minute, "Timeout of the queries mirrored to the shadow downstream.")
+	f.IntVar(&cfg.MaxConcurrency, "frontend.shadow.max-concurrency", 10, "Maximum number of queries mirrored to the shadow downstream at the same time. The queries over the limit aren't mirrored.")
+	f.Float64Var(&cfg.ValueComparisonTolerance, "frontend.shadow.value-comparison-tolerance", 0.000001, "The tolerance to apply when comparing the floating point values of the shadow responses with the primary ones. 0 to require an exact match.")
+}
+
+// Validate validates the config.
+func (cfg *ShadowConfig) Validate() error {
+	if cfg.DownstreamURL == "" {
+		return nil
+	}
+	if _, err := url.Parse(cfg.DownstreamURL); err != nil {
+		return errors.Wrap(err, "invalid shadow downstream URL")
+	}
+	if cfg.Percentage < 0 || cfg.Percentage > 100 {
+		return errInvalidShadowPercentage
+	}
+	if cfg.MaxConcurrency <= 0 {
+		return errors.New("the shadow max concurrency must be greater than 0")
+	}
+	return nil
+}
+
+// Enabled returns whether the queries are mirrored to a shadow downstream.
+func (cfg *ShadowConfig) Enabled() bool {
+	return cfg.DownstreamURL != "" && cfg.Percentage > 0
+}
+
+// shadowRoundTripper mirrors a percentage of the queries to a shadow downstream, without affecting
+// the responses of the primary round tripper.
+type shadowRoundTripper struct {
+	cfg        ShadowConfig
+	next       http.RoundTripper
+	shadow     http.RoundTripper
+	comparator *querytee.SamplesComparator
+	logger     log.Logger
+
+	// Semaphore limiting the queries mirrored at the same time.
+	inflight chan struct{}
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// shadowResponse is the response of a downstream, as compared by the shadowRoundTripper.
+type shadowResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	duration   time.Duration
+}
+
+// NewShadowRoundTripper returns a round tripper mirroring a percentage of the instant and range
+// queries to the shadow downstream, and comparing its responses with the ones of next.
+func NewShadowRoundTripper(cfg ShadowConfig, next http.RoundTripper, logger log.Logger, reg prometheus.Registerer) (http.RoundTripper, error) {
+	shadow, err := NewDownstreamRoundTripper(cfg.DownstreamURL, http.DefaultTransport)
+	if err != nil {
+		return nil, err
+	}
+
+	return &shadowRoundTripper{
+		cfg:        cfg,
+		next:       next,
+		shadow:     shadow,
+		comparator: querytee.NewSamplesComparator(cfg.ValueComparisonTolerance),
+		logger:     logger,
+		inflight:   make(chan struct{}, cfg.MaxConcurrency),
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_shadow_requests_total",
+			Help: "Total number of queries selected for mirroring to the shadow downstream, by result of the comparison with the primary response.",
+		}, []string{"result"}),
+		duration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cortex_frontend_shadow_request_duration_seconds",
+			Help:    "Time spent executing the mirrored queries, by downstream.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"downstream"}),
+	}, nil
+}
+
+func (s *shadowRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	if !isShadowedQuery(r) || rand.Float64()*100 >= s.cfg.Percentage {
+		return s.next.RoundTrip(r)
+	}
+
+	select {
+	case s.inflight <- struct{}{}:
+	default:
+		s.requests.WithLabelValues(shadowResultSkipped).Inc()
+		return s.next.RoundTrip(r)
+	}
+
+	shadowReq, cancel, err := s.newShadowRequest(r)
+	if err != nil {
+		<-s.inflight
+		level.Warn(util_log.WithContext(r.Context(), s.logger)).Log("msg", "failed to mirror the query to the shadow downstream", "err", err)
+		s.requests.WithLabelValues(shadowResultSkipped).Inc()
+		return s.next.RoundTrip(r)
+	}
+
+	// The primary response is passed to the shadow request once available, to be compared with the
+	// shadow response. It's buffered to never block the primary request.
+	primary := make(chan *shadowResponse, 1)
+	go func() {
+		defer func() { <-s.inflight }()
+		defer cancel()
+		s.mirror(shadowReq, primary)
+	}()
+
+	start := time.Now()
+	resp, err := s.next.RoundTrip(r)
+	primaryResp := &shadowResponse{duration: time.Since(start)}
+
+	switch {
+	case err != nil:
+		if errResp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+			primaryResp.statusCode = int(errResp.Code)
+		}
+	default:
+		var body []byte
+		body, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			resp = nil
+			break
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		primaryResp.statusCode = resp.StatusCode
+		primaryResp.header = resp.Header
+		primaryResp.body = body
+	}
+
+	primary <- primaryResp
+	return resp, err
+}
+
+// newShadowRequest returns a copy of the request to send to the shadow downstream, with a context
+// not canceled with the primary request.
+func (s *shadowRoundTripper) newShadowRequest(r *http.Request) (*http.Request, context.CancelFunc, error) {
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var body []byte
+	if r.Body != nil {
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), s.cfg.Timeout)
+	req := r.Clone(ctx)
+	req.RequestURI = ""
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.Header.Set(user.OrgIDHeaderName, tenant.JoinTenantIDs(tenantIDs))
+	util_log.InjectRequestIDIntoHTTPRequest(ctx, req)
+	// Let the HTTP transport negotiate the compression, to transparently decompress the response.
+	req.Header.Del("Accept-Encoding")
+
+	return req, cancel, nil
+}
+
+func (s *shadowRoundTripper) mirror(req *http.Request, primary <-chan *shadowResponse) {
+	logger := util_log.WithContext(req.Context(), s.logger)
+
+	start := time.Now()
+	resp, err := s.shadow.RoundTrip(req)
+	shadowResp := &shadowResponse{duration: time.Since(start)}
+	if err == nil {
+		shadowResp.statusCode = resp.StatusCode
+		shadowResp.header = resp.Header
+		shadowResp.body, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	primaryResp := <-primary
+	if primaryResp.statusCode == 0 {
+		// The primary request failed without response, like when canceled by the client.
+		s.requests.WithLabelValues(shadowResultSkipped).Inc()
+		return
+	}
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to execute the query mirrored to the shadow downstream", "path", req.URL.Path, "err", err)
+		s.requests.WithLabelValues(shadowResultShadowFailed).Inc()
+		return
+	}
+
+	s.duration.WithLabelValues("primary").Observe(primaryResp.duration.Seconds())
+	s.duration.WithLabelValues("shadow").Observe(shadowResp.duration.Seconds())
+
+	if err := s.compare(primaryResp, shadowResp); err != nil {
+		level.Warn(logger).Log("msg", "the shadow downstream response doesn't match the primary one", "path", req.URL.Path, "query", req.Form.Get("query"), "err", err)
+		s.requests.WithLabelValues(shadowResultMismatch).Inc()
+		return
+	}
+	s.requests.WithLabelValues(shadowResultMatch).Inc()
+}
+
+func (s *shadowRoundTripper) compare(primary, shadow *shadowResponse) error {
+	if primary.statusCode != shadow.statusCode {
+		return fmt.Errorf("expected status code %d but got %d", primary.statusCode, shadow.statusCode)
+	}
+	// The error messages may legitimately differ, for example when the downstreams are differently sized.
+	if primary.statusCode/100 != 2 {
+		return nil
+	}
+
+	primaryBody, err := decodeShadowResponseBody(primary, s.logger)
+	if err != nil {
+		return errors.Wrap(err, "unable to read the primary response")
+	}
+	shadowBody, err := decodeShadowResponseBody(shadow, s.logger)
+	if err != nil {
+		return errors.Wrap(err, "unable to read the shadow response")
+	}
+	return s.comparator.Compare(primaryBody, shadowBody)
+}
+
+func decodeShadowResponseBody(resp *shadowResponse, logger log.Logger) ([]byte, error) {
+	return tripperware.BodyBuffer(&http.Response{
+		Header:        resp.header,
+		Body:          io.NopCloser(bytes.NewReader(resp.body)),
+		ContentLength: int64(len(resp.body)),
+	}, logger)
+}
+
+// isShadowedQuery returns whether the request is an instant or range query, whose results can be compared.
+func isShadowedQuery(r *http.Request) bool {
+	return strings.HasSuffix(r.URL.Path, "/api/v1/query") || strings.HasSuffix(r.URL.Path, "/api/v1/query_range")
+}
@@ -0,0 +1,139 @@
+package frontend
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+type shadowTestRoundTripper func(*http.Request) (*http.Response, error)
+
+func (f shadowTestRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestShadowRoundTripper(t *testing.T) {
+	const mismatchingBody = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"foo":"bar"},"values":[[1536673680,"137"],[1536673780,"138"]]}]}}`
+	matchingBody := strings.ReplaceAll(responseBody, "Matrix", "matrix")
+
+	for name, tc := range map[string]struct {
+		path           string
+		shadowStatus   int
+		shadowBody     string
+		expectedResult string
+	}{
+		"matching response": {
+			path:           query,
+			shadowStatus:   http.StatusOK,
+			shadowBody:     matchingBody,
+			expectedResult: shadowResultMatch,
+		},
+		"mismatching samples": {
+			path:           query,
+			shadowStatus:   http.StatusOK,
+			shadowBody:     mismatchingBody,
+			expectedResult: shadowResultMismatch,
+		},
+		"mismatching status code": {
+			path:           query,
+			shadowStatus:   http.StatusInternalServerError,
+			shadowBody:     "error",
+			expectedResult: shadowResultMismatch,
+		},
+		"not a query": {
+			path: "/api/v1/series?match[]=up",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			shadowRequests := make(chan *http.Request, 1)
+			shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				shadowRequests <- r
+				w.WriteHeader(tc.shadowStatus)
+				_, _ = io.WriteString(w, tc.shadowBody)
+			}))
+			t.Cleanup(shadow.Close)
+
+			reg := prometheus.NewPedanticRegistry()
+			next := shadowTestRoundTripper(func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(matchingBody)),
+				}, nil
+			})
+			rt, err := NewShadowRoundTripper(ShadowConfig{
+				DownstreamURL:  shadow.URL,
+				Percentage:     100,
+				Timeout:        time.Minute,
+				MaxConcurrency: 1,
+			}, next, log.NewNopLogger(), reg)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "/prometheus"+tc.path, nil)
+			ctx, cancel := context.WithCancel(user.InjectOrgID(req.Context(), "user-1"))
+			req = req.WithContext(ctx)
+
+			resp, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, matchingBody, string(body))
+
+			// The mirrored query isn't canceled with the primary one.
+			cancel()
+
+			if tc.expectedResult == "" {
+				assert.Equal(t, 0, testutil.CollectAndCount(rt.(*shadowRoundTripper).requests))
+				assert.Len(t, shadowRequests, 0)
+				return
+			}
+
+			shadowReq := <-shadowRequests
+			assert.Equal(t, "/prometheus/api/v1/query_range", shadowReq.URL.Path)
+			assert.Equal(t, "user-1", shadowReq.Header.Get(user.OrgIDHeaderName))
+
+			require.Eventually(t, func() bool {
+				return testutil.ToFloat64(rt.(*shadowRoundTripper).requests.WithLabelValues(tc.expectedResult)) == 1
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestShadowRoundTripper_MaxConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(shadow.Close)
+	t.Cleanup(func() { close(release) })
+
+	next := shadowTestRoundTripper(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(responseBody))}, nil
+	})
+	rt, err := NewShadowRoundTripper(ShadowConfig{
+		DownstreamURL:  shadow.URL,
+		Percentage:     100,
+		Timeout:        time.Minute,
+		MaxConcurrency: 1,
+	}, next, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", query, nil)
+		_, err := rt.RoundTrip(req.WithContext(user.InjectOrgID(req.Context(), "user-1")))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(rt.(*shadowRoundTripper).requests.WithLabelValues(shadowResultSkipped)))
+}
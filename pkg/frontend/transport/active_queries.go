@@ -0,0 +1,185 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/httpgrpc"
+
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// errQueryCanceledByOperator is the cause of the cancellation of queries canceled through
+// the active queries API, and the error returned to their clients.
+var errQueryCanceledByOperator = httpgrpc.Errorf(http.StatusServiceUnavailable, "query canceled by an operator")
+
+// activeQuery is a query being executed.
+type activeQuery struct {
+	id     string
+	tenant string
+	path   string
+	query  string
+	start  time.Time
+	stats  *querier_stats.QueryStats
+	cancel context.CancelCauseFunc
+}
+
+// activeQueries tracks the queries being executed, so they can be listed and canceled.
+type activeQueries struct {
+	mtx     sync.Mutex
+	queries map[string]*activeQuery
+}
+
+func newActiveQueries() *activeQueries {
+	return &activeQueries{
+		queries: map[string]*activeQuery{},
+	}
+}
+
+// track tracks the request until the returned function is called, returning the request
+// with a context canceled when the query is canceled.
+func (a *activeQueries) track(r *http.Request, tenant string, stats *querier_stats.QueryStats) (*http.Request, func()) {
+	ctx, cancel := context.WithCancelCause(r.Context())
+
+	query := r.Form.Get("query")
+	if query == "" {
+		query = strings.Join(r.Form["match[]"], ",")
+	}
+	q := &activeQuery{
+		tenant: tenant,
+		path:   r.URL.Path,
+		query:  query,
+		start:  time.Now(),
+		stats:  stats,
+		cancel: cancel,
+	}
+
+	a.mtx.Lock()
+	for {
+		q.id = fmt.Sprintf("%016x", rand.Uint64())
+		if _, ok := a.queries[q.id]; !ok {
+			break
+		}
+	}
+	a.queries[q.id] = q
+	a.mtx.Unlock()
+
+	return r.WithContext(ctx), func() {
+		a.mtx.Lock()
+		delete(a.queries, q.id)
+		a.mtx.Unlock()
+		cancel(nil)
+	}
+}
+
+// cancelQuery cancels the query with the given ID, returning false if it's not running.
+func (a *activeQueries) cancelQuery(id string) bool {
+	a.mtx.Lock()
+	q, ok := a.queries[id]
+	a.mtx.Unlock()
+
+	if ok {
+		q.cancel(errQueryCanceledByOperator)
+	}
+	return ok
+}
+
+type activeQueryDesc struct {
+	ID                string  `json:"id"`
+	Tenant            string  `json:"tenant"`
+	Path              string  `json:"path"`
+	Query             string  `json:"query"`
+	StartedAt         string  `json:"started_at"`
+	AgeSeconds        float64 `json:"age_seconds"`
+	WallTimeSeconds   float64 `json:"wall_time_seconds"`
+	FetchedSeries     uint64  `json:"fetched_series"`
+	FetchedChunkBytes uint64  `json:"fetched_chunk_bytes"`
+	FetchedDataBytes  uint64  `json:"fetched_data_bytes"`
+	FetchedSamples    uint64  `json:"fetched_samples"`
+}
+
+// list returns the queries running since at least minAge, the oldest first.
+func (a *activeQueries) list(now time.Time, minAge time.Duration) []activeQueryDesc {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	out := make([]activeQueryDesc, 0, len(a.queries))
+	for _, q := range a.queries {
+		age := now.Sub(q.start)
+		if age < minAge {
+			continue
+		}
+
+		// The stats only include the sub-queries completed so far.
+		out = append(out, activeQueryDesc{
+			ID:                q.id,
+			Tenant:            q.tenant,
+			Path:              q.path,
+			Query:             q.query,
+			StartedAt:         q.start.UTC().Format(time.RFC3339Nano),
+			AgeSeconds:        age.Seconds(),
+			WallTimeSeconds:   q.stats.LoadWallTime().Seconds(),
+			FetchedSeries:     q.stats.LoadFetchedSeries(),
+			FetchedChunkBytes: q.stats.LoadFetchedChunkBytes(),
+			FetchedDataBytes:  q.stats.LoadFetchedDataBytes(),
+			FetchedSamples:    q.stats.LoadFetchedSamples(),
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].AgeSeconds > out[j].AgeSeconds })
+	return out
+}
+
+// ActiveQueriesHandler returns a handler listing the queries being executed, optionally
+// only the ones running since at least the "min_age" duration.
+func (f *Handler) ActiveQueriesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.activeQueries == nil {
+			http.Error(w, "active queries tracking is disabled", http.StatusNotFound)
+			return
+		}
+
+		var minAge time.Duration
+		if s := r.FormValue("min_age"); s != "" {
+			d, err := model.ParseDuration(s)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid min_age: %v", err), http.StatusBadRequest)
+				return
+			}
+			minAge = time.Duration(d)
+		}
+
+		util.WriteJSONResponse(w, f.activeQueries.list(time.Now(), minAge))
+	})
+}
+
+// CancelActiveQueryHandler returns a handler canceling the query with the "id" path variable.
+// The cancellation is propagated to the queriers, and from them to the ingesters and
+// store-gateways, through the request context.
+func (f *Handler) CancelActiveQueryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.activeQueries == nil {
+			http.Error(w, "active queries tracking is disabled", http.StatusNotFound)
+			return
+		}
+
+		id := mux.Vars(r)["id"]
+		if !f.activeQueries.cancelQuery(id) {
+			http.Error(w, fmt.Sprintf("query %s not found", id), http.StatusNotFound)
+			return
+		}
+
+		level.Info(f.log).Log("msg", "query canceled by an operator", "query_id", id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestHandler_ActiveQueries(t *testing.T) {
+	started := make(chan struct{})
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		querier_stats.FromContext(req.Context()).AddFetchedSamples(10)
+		close(started)
+
+		// Wait until the query is canceled.
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+
+	cfg := HandlerConfig{QueryStatsEnabled: true, MaxBodySize: 1024, ActiveQueriesAPIEnabled: true}
+	handler := NewHandler(cfg, roundTripper, log.NewNopLogger(), nil)
+
+	listActiveQueries := func(params string) []activeQueryDesc {
+		recorder := httptest.NewRecorder()
+		handler.ActiveQueriesHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/frontend/active_queries?"+params, nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var res []activeQueryDesc
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+		return res
+	}
+	cancelQuery := func(id string) int {
+		req := mux.SetURLVars(httptest.NewRequest("DELETE", "/frontend/active_queries/"+id, nil), map[string]string{"id": id})
+		recorder := httptest.NewRecorder()
+		handler.CancelActiveQueryHandler().ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	queryRecorder := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+		handler.ServeHTTP(queryRecorder, req)
+	}()
+	<-started
+
+	queries := listActiveQueries("")
+	require.Len(t, queries, 1)
+	assert.Equal(t, "user-1", queries[0].Tenant)
+	assert.Equal(t, "/api/v1/query", queries[0].Path)
+	assert.Equal(t, "up", queries[0].Query)
+	assert.Equal(t, uint64(10), queries[0].FetchedSamples)
+
+	assert.Empty(t, listActiveQueries("min_age=1h"))
+
+	assert.Equal(t, http.StatusNotFound, cancelQuery("unknown"))
+	assert.Equal(t, http.StatusNoContent, cancelQuery(queries[0].ID))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the query has not been canceled")
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, queryRecorder.Code)
+	assert.Contains(t, queryRecorder.Body.String(), "query canceled by an operator")
+
+	test.Poll(t, time.Second, 0, func() interface{} {
+		return len(listActiveQueries(""))
+	})
+}
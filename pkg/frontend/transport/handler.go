@@ -39,6 +39,8 @@ var (
 	errCanceled              = httpgrpc.Errorf(StatusClientClosedRequest, context.Canceled.Error())
 	errDeadlineExceeded      = httpgrpc.Errorf(http.StatusGatewayTimeout, context.DeadlineExceeded.Error())
 	errRequestEntityTooLarge = httpgrpc.Errorf(http.StatusRequestEntityTooLarge, "http: request body too large")
+
+	errTopQueriesWithoutQueryStats = errors.New("the top queries tracking requires the query stats to be enabled")
 )
 
 const (
@@ -68,17 +70,49 @@ const (
 	limitBytesStoreGateway  = `exceeded bytes limit`
 )
 
+// Sources of the chunks fetched to execute a query. The chunks fetched from the store-gateways
+// are split between the raw and the downsampled blocks.
+const (
+	sourceIngesters         = "ingesters"
+	sourceRawBlocks         = "raw-blocks"
+	sourceDownsampledBlocks = "downsampled-blocks"
+)
+
 // Config for a Handler.
 type HandlerConfig struct {
 	LogQueriesLongerThan time.Duration `yaml:"log_queries_longer_than"`
 	MaxBodySize          int64         `yaml:"max_body_size"`
 	QueryStatsEnabled    bool          `yaml:"query_stats_enabled"`
+
+	TopQueriesSize        int           `yaml:"top_queries_size"`
+	TopQueriesWindow      time.Duration `yaml:"top_queries_window"`
+	TopQueriesLogInterval time.Duration `yaml:"top_queries_log_interval"`
+
+	ActiveQueriesAPIEnabled bool `yaml:"active_queries_api_enabled"`
 }
 
 func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.LogQueriesLongerThan, "frontend.log-queries-longer-than", 0, "Log queries that are slower than the specified duration. Set to 0 to disable. Set to < 0 to enable on all queries.")
 	f.Int64Var(&cfg.MaxBodySize, "frontend.max-body-size", 10*1024*1024, "Max body size for downstream prometheus.")
 	f.BoolVar(&cfg.QueryStatsEnabled, "frontend.query-stats-enabled", false, "True to enable query statistics tracking. When enabled, a message with some statistics is logged for every query.")
+	f.IntVar(&cfg.TopQueriesSize, "frontend.top-queries-size", 0, "Experimental: Number of most expensive queries, by wall time, fetched data bytes and fetched samples, tracked in memory and served at /frontend/top_queries. Requires -frontend.query-stats-enabled. 0 to disable.")
+	f.DurationVar(&cfg.TopQueriesWindow, "frontend.top-queries-window", time.Hour, "Rolling window over which the most expensive queries are tracked.")
+	f.DurationVar(&cfg.TopQueriesLogInterval, "frontend.top-queries-log-interval", 0, "How frequently the most expensive queries are logged. 0 to disable.")
+	f.BoolVar(&cfg.ActiveQueriesAPIEnabled, "frontend.active-queries-api-enabled", false, "Experimental: Track the queries being executed, so they can be listed at /frontend/active_queries and canceled by ID. The execution stats of the queries are only reported if -frontend.query-stats-enabled is true.")
+}
+
+// Validate validates the config.
+func (cfg *HandlerConfig) Validate() error {
+	if cfg.TopQueriesSize <= 0 {
+		return nil
+	}
+	if !cfg.QueryStatsEnabled {
+		return errTopQueriesWithoutQueryStats
+	}
+	if cfg.TopQueriesWindow <= 0 {
+		return errors.New("the top queries window must be greater than 0")
+	}
+	return nil
 }
 
 // Handler accepts queries and forwards them to RoundTripper. It can log slow queries,
@@ -89,12 +123,19 @@ type Handler struct {
 	roundTripper http.RoundTripper
 
 	// Metrics.
-	querySeconds    *prometheus.CounterVec
-	querySeries     *prometheus.CounterVec
-	queryChunkBytes *prometheus.CounterVec
-	queryDataBytes  *prometheus.CounterVec
-	rejectedQueries *prometheus.CounterVec
-	activeUsers     *util.ActiveUsersCleanupService
+	querySeconds            *prometheus.CounterVec
+	querySeries             *prometheus.CounterVec
+	queryChunkBytes         *prometheus.CounterVec
+	queryChunkBytesBySource *prometheus.CounterVec
+	queryDataBytes          *prometheus.CounterVec
+	rejectedQueries         *prometheus.CounterVec
+	activeUsers             *util.ActiveUsersCleanupService
+
+	// Most expensive queries, nil if the tracking is disabled.
+	topQueries *topQueries
+
+	// Queries being executed, nil if the tracking is disabled.
+	activeQueries *activeQueries
 }
 
 // NewHandler creates a new frontend handler.
@@ -105,6 +146,10 @@ func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logge
 		roundTripper: roundTripper,
 	}
 
+	if cfg.ActiveQueriesAPIEnabled {
+		h.activeQueries = newActiveQueries()
+	}
+
 	if cfg.QueryStatsEnabled {
 		h.querySeconds = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_seconds_total",
@@ -121,6 +166,11 @@ func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logge
 			Help: "Size of all chunks fetched to execute a query in bytes.",
 		}, []string{"user"})
 
+		h.queryChunkBytesBySource = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_fetched_chunks_bytes_by_source_total",
+			Help: "Size of the chunks fetched to execute a query in bytes, by source: ingesters, raw blocks or downsampled blocks.",
+		}, []string{"user", "source"})
+
 		h.queryDataBytes = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_fetched_data_bytes_total",
 			Help: "Size of all data fetched to execute a query in bytes.",
@@ -139,12 +189,22 @@ func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logge
 			h.querySeries.DeleteLabelValues(user)
 			h.queryChunkBytes.DeleteLabelValues(user)
 			h.queryDataBytes.DeleteLabelValues(user)
+			if err := util.DeleteMatchingLabels(h.queryChunkBytesBySource, map[string]string{"user": user}); err != nil {
+				level.Warn(log).Log("msg", "failed to remove cortex_query_fetched_chunks_bytes_by_source_total metric for user", "user", user, "err", err)
+			}
 			if err := util.DeleteMatchingLabels(h.rejectedQueries, map[string]string{"user": user}); err != nil {
 				level.Warn(log).Log("msg", "failed to remove cortex_rejected_queries_total metric for user", "user", user, "err", err)
 			}
 		})
 		// If cleaner stops or fail, we will simply not clean the metrics for inactive users.
 		_ = h.activeUsers.StartAsync(context.Background())
+
+		if cfg.TopQueriesSize > 0 {
+			h.topQueries = newTopQueries(cfg.TopQueriesSize, cfg.TopQueriesWindow, log)
+			if cfg.TopQueriesLogInterval > 0 {
+				h.topQueries.startLogging(cfg.TopQueriesLogInterval)
+			}
+		}
 	}
 
 	return h
@@ -200,10 +260,20 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		r.Body = io.NopCloser(&buf)
 	}
 
+	if f.activeQueries != nil {
+		var done func()
+		r, done = f.activeQueries.track(r, userID, stats)
+		defer done()
+	}
+
 	startTime := time.Now()
 	resp, err := f.roundTripper.RoundTrip(r)
 	queryResponseTime := time.Since(startTime)
 
+	if err != nil && errors.Is(context.Cause(r.Context()), errQueryCanceledByOperator) {
+		err = errQueryCanceledByOperator
+	}
+
 	// Check whether we should parse the query string.
 	shouldReportSlowQuery := f.cfg.LogQueriesLongerThan != 0 && queryResponseTime > f.cfg.LogQueriesLongerThan
 	if shouldReportSlowQuery || f.cfg.QueryStatsEnabled {
@@ -292,7 +362,11 @@ func (f *Handler) reportQueryStats(r *http.Request, userID string, queryString u
 	numSeries := stats.LoadFetchedSeries()
 	numChunks := stats.LoadFetchedChunks()
 	numSamples := stats.LoadFetchedSamples()
+	numHistogramSamples := stats.LoadFetchedHistogramSamples()
 	numChunkBytes := stats.LoadFetchedChunkBytes()
+	numIngestersChunkBytes := stats.LoadFetchedIngestersChunkBytes()
+	numRawBlocksChunkBytes := stats.LoadFetchedRawBlocksChunkBytes()
+	numDownsampledBlocksChunkBytes := stats.LoadFetchedDownsampledBlocksChunkBytes()
 	numDataBytes := stats.LoadFetchedDataBytes()
 	splitQueries := stats.LoadSplitQueries()
 
@@ -300,9 +374,24 @@ func (f *Handler) reportQueryStats(r *http.Request, userID string, queryString u
 	f.querySeconds.WithLabelValues(userID).Add(wallTime.Seconds())
 	f.querySeries.WithLabelValues(userID).Add(float64(numSeries))
 	f.queryChunkBytes.WithLabelValues(userID).Add(float64(numChunkBytes))
+	f.queryChunkBytesBySource.WithLabelValues(userID, sourceIngesters).Add(float64(numIngestersChunkBytes))
+	f.queryChunkBytesBySource.WithLabelValues(userID, sourceRawBlocks).Add(float64(numRawBlocksChunkBytes))
+	f.queryChunkBytesBySource.WithLabelValues(userID, sourceDownsampledBlocks).Add(float64(numDownsampledBlocksChunkBytes))
 	f.queryDataBytes.WithLabelValues(userID).Add(float64(numDataBytes))
 	f.activeUsers.UpdateUserTimestamp(userID, time.Now())
 
+	if f.topQueries != nil {
+		q := newTopQuery(r, userID, queryString, time.Now())
+		q.StatusCode = statusCode
+		q.ResponseTimeSeconds = queryResponseTime.Seconds()
+		q.WallTimeSeconds = wallTime.Seconds()
+		q.FetchedSeries = numSeries
+		q.FetchedChunkBytes = numChunkBytes
+		q.FetchedDataBytes = numDataBytes
+		q.FetchedSamples = numSamples
+		f.topQueries.observe(q)
+	}
+
 	var (
 		contentLength int64
 		encoding      string
@@ -323,13 +412,28 @@ func (f *Handler) reportQueryStats(r *http.Request, userID string, queryString u
 		"fetched_series_count", numSeries,
 		"fetched_chunks_count", numChunks,
 		"fetched_samples_count", numSamples,
+		"fetched_histogram_samples_count", numHistogramSamples,
 		"fetched_chunks_bytes", numChunkBytes,
+		"fetched_ingesters_chunks_bytes", numIngestersChunkBytes,
+		"fetched_store_gateway_chunks_bytes", numRawBlocksChunkBytes + numDownsampledBlocksChunkBytes,
+		"fetched_raw_blocks_chunks_bytes", numRawBlocksChunkBytes,
+		"fetched_downsampled_blocks_chunks_bytes", numDownsampledBlocksChunkBytes,
 		"fetched_data_bytes", numDataBytes,
 		"split_queries", splitQueries,
 		"status_code", statusCode,
 		"response_size", contentLength,
 	}, stats.LoadExtraFields()...)
 
+	// The fan-out of the query to the ingesters, for the queries which queried them.
+	if queriedIngesters := stats.LoadQueriedIngesters(); queriedIngesters > 0 {
+		logMessage = append(logMessage,
+			"queried_ingesters", queriedIngesters,
+			"failed_ingesters", stats.LoadFailedIngesters(),
+			"slowest_ingester_latency_seconds", stats.LoadSlowestIngesterLatency().Seconds(),
+			"queried_ingester_zones", strings.Join(stats.LoadQueriedIngesterZones(), ","),
+		)
+	}
+
 	grafanaFields := formatGrafanaStatsFields(r)
 	if len(grafanaFields) > 0 {
 		logMessage = append(logMessage, grafanaFields...)
@@ -461,6 +565,10 @@ func writeServiceTimingHeader(queryResponseTime time.Duration, headers http.Head
 		parts := make([]string, 0)
 		parts = append(parts, statsValue("querier_wall_time", stats.LoadWallTime()))
 		parts = append(parts, statsValue("response_time", queryResponseTime))
+		if queriedIngesters := stats.LoadQueriedIngesters(); queriedIngesters > 0 {
+			parts = append(parts, statsValue("slowest_ingester_latency", stats.LoadSlowestIngesterLatency()))
+			parts = append(parts, fmt.Sprintf(`ingesters;desc="queried=%d failed=%d zones=%d"`, queriedIngesters, stats.LoadFailedIngesters(), len(stats.LoadQueriedIngesterZones())))
+		}
 		headers.Set(ServiceTimingHeaderName, strings.Join(parts, ", "))
 	}
 }
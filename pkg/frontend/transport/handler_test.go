@@ -84,6 +84,27 @@ func TestHandler_ServeHTTP(t *testing.T) {
 			roundTripperFunc:   roundTripper,
 			expectedStatusCode: http.StatusOK,
 		},
+		{
+			name:            "test handler with chunk bytes fetched by source",
+			cfg:             HandlerConfig{QueryStatsEnabled: true},
+			expectedMetrics: 3,
+			roundTripperFunc: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				stats := querier_stats.FromContext(req.Context())
+				stats.AddFetchedIngestersChunkBytes(100)
+				stats.AddFetchedRawBlocksChunkBytes(200)
+				stats.AddFetchedDownsampledBlocksChunkBytes(50)
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("{}")),
+				}, nil
+			}),
+			additionalMetricsCheckFunc: func(h *Handler) {
+				assert.Equal(t, float64(100), promtest.ToFloat64(h.queryChunkBytesBySource.WithLabelValues(userID, sourceIngesters)))
+				assert.Equal(t, float64(200), promtest.ToFloat64(h.queryChunkBytesBySource.WithLabelValues(userID, sourceRawBlocks)))
+				assert.Equal(t, float64(50), promtest.ToFloat64(h.queryChunkBytesBySource.WithLabelValues(userID, sourceDownsampledBlocks)))
+			},
+			expectedStatusCode: http.StatusOK,
+		},
 		{
 			name:               "test handler with stats disabled",
 			cfg:                HandlerConfig{QueryStatsEnabled: false},
@@ -318,11 +339,11 @@ func TestReportQueryStatsFormat(t *testing.T) {
 
 	tests := map[string]testCase{
 		"should not include query and header details if empty": {
-			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000`,
+			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_histogram_samples_count=0 fetched_chunks_bytes=0 fetched_ingesters_chunks_bytes=0 fetched_store_gateway_chunks_bytes=0 fetched_raw_blocks_chunks_bytes=0 fetched_downsampled_blocks_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000`,
 		},
 		"should include query length and string at the end": {
 			queryString: url.Values(map[string][]string{"query": {"up"}}),
-			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000 query_length=2 param_query=up`,
+			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_histogram_samples_count=0 fetched_chunks_bytes=0 fetched_ingesters_chunks_bytes=0 fetched_store_gateway_chunks_bytes=0 fetched_raw_blocks_chunks_bytes=0 fetched_downsampled_blocks_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000 query_length=2 param_query=up`,
 		},
 		"should include query stats": {
 			queryStats: &querier_stats.QueryStats{
@@ -334,22 +355,38 @@ func TestReportQueryStatsFormat(t *testing.T) {
 					FetchedChunkBytes:   1024,
 					FetchedDataBytes:    2048,
 					SplitQueries:        10,
+
+					FetchedIngestersChunkBytes:         512,
+					FetchedRawBlocksChunkBytes:         384,
+					FetchedDownsampledBlocksChunkBytes: 128,
+					FetchedHistogramSamplesCount:       30,
+				},
+			},
+			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=3 fetched_series_count=100 fetched_chunks_count=200 fetched_samples_count=300 fetched_histogram_samples_count=30 fetched_chunks_bytes=1024 fetched_ingesters_chunks_bytes=512 fetched_store_gateway_chunks_bytes=512 fetched_raw_blocks_chunks_bytes=384 fetched_downsampled_blocks_chunks_bytes=128 fetched_data_bytes=2048 split_queries=10 status_code=200 response_size=1000`,
+		},
+		"should include ingester fan-out stats": {
+			queryStats: &querier_stats.QueryStats{
+				Stats: querier_stats.Stats{
+					QueriedIngesters:       6,
+					FailedIngesters:        1,
+					SlowestIngesterLatency: 1500 * time.Millisecond,
+					QueriedIngesterZones:   []string{"zone-a", "zone-b"},
 				},
 			},
-			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=3 fetched_series_count=100 fetched_chunks_count=200 fetched_samples_count=300 fetched_chunks_bytes=1024 fetched_data_bytes=2048 split_queries=10 status_code=200 response_size=1000`,
+			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_histogram_samples_count=0 fetched_chunks_bytes=0 fetched_ingesters_chunks_bytes=0 fetched_store_gateway_chunks_bytes=0 fetched_raw_blocks_chunks_bytes=0 fetched_downsampled_blocks_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000 queried_ingesters=6 failed_ingesters=1 slowest_ingester_latency_seconds=1.5 queried_ingester_zones=zone-a,zone-b`,
 		},
 		"should include user agent": {
 			header:      http.Header{"User-Agent": []string{"Grafana"}},
-			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000 user_agent=Grafana`,
+			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_histogram_samples_count=0 fetched_chunks_bytes=0 fetched_ingesters_chunks_bytes=0 fetched_store_gateway_chunks_bytes=0 fetched_raw_blocks_chunks_bytes=0 fetched_downsampled_blocks_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000 user_agent=Grafana`,
 		},
 		"should include response error": {
 			responseErr: errors.New("foo_err"),
-			expectedLog: `level=error msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000 error=foo_err`,
+			expectedLog: `level=error msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_histogram_samples_count=0 fetched_chunks_bytes=0 fetched_ingesters_chunks_bytes=0 fetched_store_gateway_chunks_bytes=0 fetched_raw_blocks_chunks_bytes=0 fetched_downsampled_blocks_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000 error=foo_err`,
 		},
 		"should include query priority": {
 			queryString: url.Values(map[string][]string{"query": {"up"}}),
 			header:      http.Header{util.QueryPriorityHeaderKey: []string{"99"}},
-			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000 query_length=2 priority=99 param_query=up`,
+			expectedLog: `level=info msg="query stats" component=query-frontend method=GET path=/prometheus/api/v1/query response_time=1s query_wall_time_seconds=0 fetched_series_count=0 fetched_chunks_count=0 fetched_samples_count=0 fetched_histogram_samples_count=0 fetched_chunks_bytes=0 fetched_ingesters_chunks_bytes=0 fetched_store_gateway_chunks_bytes=0 fetched_raw_blocks_chunks_bytes=0 fetched_downsampled_blocks_chunks_bytes=0 fetched_data_bytes=0 split_queries=0 status_code=200 response_size=1000 query_length=2 priority=99 param_query=up`,
 		},
 	}
 
@@ -363,3 +400,23 @@ func TestReportQueryStatsFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteServiceTimingHeader(t *testing.T) {
+	t.Run("without ingesters queried", func(t *testing.T) {
+		headers := http.Header{}
+		writeServiceTimingHeader(time.Second, headers, &querier_stats.QueryStats{Stats: querier_stats.Stats{WallTime: 3 * time.Second}})
+		assert.Equal(t, "querier_wall_time;dur=3000, response_time;dur=1000", headers.Get(ServiceTimingHeaderName))
+	})
+
+	t.Run("with ingesters queried", func(t *testing.T) {
+		headers := http.Header{}
+		writeServiceTimingHeader(time.Second, headers, &querier_stats.QueryStats{Stats: querier_stats.Stats{
+			WallTime:               3 * time.Second,
+			QueriedIngesters:       6,
+			FailedIngesters:        1,
+			SlowestIngesterLatency: 250 * time.Millisecond,
+			QueriedIngesterZones:   []string{"zone-a", "zone-b", "zone-c"},
+		}})
+		assert.Equal(t, `querier_wall_time;dur=3000, response_time;dur=1000, slowest_ingester_latency;dur=250, ingesters;desc="queried=6 failed=1 zones=3"`, headers.Get(ServiceTimingHeaderName))
+	})
+}
@@ -0,0 +1,199 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+// topQuery is a completed query tracked among the most expensive ones.
+type topQuery struct {
+	Tenant              string    `json:"tenant"`
+	Path                string    `json:"path"`
+	Query               string    `json:"query"`
+	Start               string    `json:"start,omitempty"`
+	End                 string    `json:"end,omitempty"`
+	Step                string    `json:"step,omitempty"`
+	Time                string    `json:"time,omitempty"`
+	CompletedAt         time.Time `json:"completed_at"`
+	StatusCode          int       `json:"status_code"`
+	ResponseTimeSeconds float64   `json:"response_time_seconds"`
+	WallTimeSeconds     float64   `json:"wall_time_seconds"`
+	FetchedSeries       uint64    `json:"fetched_series"`
+	FetchedChunkBytes   uint64    `json:"fetched_chunk_bytes"`
+	FetchedDataBytes    uint64    `json:"fetched_data_bytes"`
+	FetchedSamples      uint64    `json:"fetched_samples"`
+}
+
+// topQueriesDimension is a dimension queries are ranked by.
+type topQueriesDimension struct {
+	name  string
+	value func(q *topQuery) float64
+}
+
+var topQueriesDimensions = []topQueriesDimension{
+	{name: "wall_time", value: func(q *topQuery) float64 { return q.WallTimeSeconds }},
+	{name: "fetched_data_bytes", value: func(q *topQuery) float64 { return float64(q.FetchedDataBytes) }},
+	{name: "fetched_samples", value: func(q *topQuery) float64 { return float64(q.FetchedSamples) }},
+}
+
+// topQueries tracks the most expensive queries completed within a rolling window, for each
+// dimension. Queries are ranked in two consecutive generations, each one spanning the window,
+// and both generations are merged when reading, so the result is an approximation: a query
+// evicted from a generation by queries older than the window may be missing.
+type topQueries struct {
+	size   int
+	window time.Duration
+	logger log.Logger
+
+	mtx          sync.Mutex
+	currentStart time.Time
+	current      [][]*topQuery
+	previous     [][]*topQuery
+}
+
+func newTopQueries(size int, window time.Duration, logger log.Logger) *topQueries {
+	return &topQueries{
+		size:     size,
+		window:   window,
+		logger:   logger,
+		current:  make([][]*topQuery, len(topQueriesDimensions)),
+		previous: make([][]*topQuery, len(topQueriesDimensions)),
+	}
+}
+
+// observe tracks a completed query, if it's among the most expensive ones by any dimension.
+func (t *topQueries) observe(q *topQuery) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if elapsed := q.CompletedAt.Sub(t.currentStart); elapsed >= t.window {
+		if elapsed >= 2*t.window {
+			t.previous = make([][]*topQuery, len(topQueriesDimensions))
+		} else {
+			t.previous = t.current
+		}
+		t.current = make([][]*topQuery, len(topQueriesDimensions))
+		t.currentStart = q.CompletedAt
+	}
+
+	for d, dim := range topQueriesDimensions {
+		ranked := t.current[d]
+		value := dim.value(q)
+
+		// Queries are sorted by value, in descending order.
+		i := sort.Search(len(ranked), func(i int) bool { return dim.value(ranked[i]) < value })
+		if i >= t.size {
+			continue
+		}
+		if len(ranked) < t.size {
+			ranked = append(ranked, nil)
+		}
+		copy(ranked[i+1:], ranked[i:])
+		ranked[i] = q
+		t.current[d] = ranked
+	}
+}
+
+// top returns the most expensive queries completed in the window ending at now, by dimension name.
+func (t *topQueries) top(now time.Time) map[string][]topQuery {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	minCompletedAt := now.Add(-t.window)
+	out := make(map[string][]topQuery, len(topQueriesDimensions))
+	for d, dim := range topQueriesDimensions {
+		ranked := make([]topQuery, 0, t.size)
+		for _, generation := range [][]*topQuery{t.current[d], t.previous[d]} {
+			for _, q := range generation {
+				if !q.CompletedAt.Before(minCompletedAt) {
+					ranked = append(ranked, *q)
+				}
+			}
+		}
+
+		sort.SliceStable(ranked, func(i, j int) bool { return dim.value(&ranked[i]) > dim.value(&ranked[j]) })
+		if len(ranked) > t.size {
+			ranked = ranked[:t.size]
+		}
+		out[dim.name] = ranked
+	}
+	return out
+}
+
+// logTopQueries logs the most expensive queries completed in the last window.
+func (t *topQueries) logTopQueries(_ context.Context) error {
+	top := t.top(time.Now())
+	for _, dim := range topQueriesDimensions {
+		for i, q := range top[dim.name] {
+			level.Info(t.logger).Log(
+				"msg", "top query",
+				"by", dim.name,
+				"rank", i+1,
+				"org_id", q.Tenant,
+				"path", q.Path,
+				"query", q.Query,
+				"completed_at", q.CompletedAt,
+				"query_wall_time_seconds", q.WallTimeSeconds,
+				"fetched_data_bytes", q.FetchedDataBytes,
+				"fetched_samples_count", q.FetchedSamples,
+			)
+		}
+	}
+	return nil
+}
+
+// startLogging periodically logs the most expensive queries. As for the per-user metrics
+// cleanup, the logging is never stopped.
+func (t *topQueries) startLogging(interval time.Duration) {
+	_ = services.NewTimerService(interval, nil, t.logTopQueries, nil).StartAsync(context.Background())
+}
+
+type topQueriesResponse struct {
+	Window string                `json:"window"`
+	Top    map[string][]topQuery `json:"top"`
+}
+
+// TopQueriesHandler returns a handler serving the most expensive queries completed in the
+// last -frontend.top-queries-window, by wall time, fetched data bytes and fetched samples.
+func (f *Handler) TopQueriesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.topQueries == nil {
+			http.Error(w, "top queries tracking is disabled", http.StatusNotFound)
+			return
+		}
+
+		util.WriteJSONResponse(w, topQueriesResponse{
+			Window: f.topQueries.window.String(),
+			Top:    f.topQueries.top(time.Now()),
+		})
+	})
+}
+
+func newTopQuery(r *http.Request, userID string, queryString url.Values, completedAt time.Time) *topQuery {
+	query := queryString.Get("query")
+	if query == "" {
+		query = strings.Join(queryString["match[]"], ",")
+	}
+
+	return &topQuery{
+		Tenant:      userID,
+		Path:        r.URL.Path,
+		Query:       query,
+		Start:       queryString.Get("start"),
+		End:         queryString.Get("end"),
+		Step:        queryString.Get("step"),
+		Time:        queryString.Get("time"),
+		CompletedAt: completedAt,
+	}
+}
@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+)
+
+func TestHandlerConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&HandlerConfig{}).Validate())
+	assert.Equal(t, errTopQueriesWithoutQueryStats, (&HandlerConfig{TopQueriesSize: 10, TopQueriesWindow: time.Hour}).Validate())
+	assert.Error(t, (&HandlerConfig{QueryStatsEnabled: true, TopQueriesSize: 10}).Validate())
+	assert.NoError(t, (&HandlerConfig{QueryStatsEnabled: true, TopQueriesSize: 10, TopQueriesWindow: time.Hour}).Validate())
+}
+
+func TestTopQueries(t *testing.T) {
+	now := time.Now()
+	top := newTopQueries(2, time.Minute, log.NewNopLogger())
+
+	observe := func(query string, completedAt time.Time, wallTime float64, dataBytes, samples uint64) {
+		top.observe(&topQuery{Query: query, CompletedAt: completedAt, WallTimeSeconds: wallTime, FetchedDataBytes: dataBytes, FetchedSamples: samples})
+	}
+	queries := func(res []topQuery) []string {
+		var out []string
+		for _, q := range res {
+			out = append(out, q.Query)
+		}
+		return out
+	}
+
+	observe("a", now, 1, 300, 10)
+	observe("b", now, 3, 100, 20)
+	observe("c", now, 2, 200, 30)
+
+	res := top.top(now)
+	assert.Equal(t, []string{"b", "c"}, queries(res["wall_time"]))
+	assert.Equal(t, []string{"a", "c"}, queries(res["fetched_data_bytes"]))
+	assert.Equal(t, []string{"c", "b"}, queries(res["fetched_samples"]))
+
+	// Queries of the previous generation are kept until they're older than the window.
+	observe("d", now.Add(90*time.Second), 2.5, 0, 0)
+	res = top.top(now.Add(90 * time.Second))
+	assert.Equal(t, []string{"d"}, queries(res["wall_time"]))
+	res = top.top(now.Add(30 * time.Second))
+	assert.Equal(t, []string{"b", "d"}, queries(res["wall_time"]))
+
+	// Both generations are dropped after two windows without queries.
+	observe("e", now.Add(5*time.Minute), 0.5, 0, 0)
+	res = top.top(now.Add(5 * time.Minute))
+	assert.Equal(t, []string{"e"}, queries(res["wall_time"]))
+}
+
+func TestHandler_TopQueries(t *testing.T) {
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		stats := querier_stats.FromContext(req.Context())
+		stats.AddWallTime(time.Duration(len(req.FormValue("query"))) * time.Second)
+		stats.AddFetchedSamples(uint64(len(req.FormValue("query"))))
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+
+	cfg := HandlerConfig{QueryStatsEnabled: true, MaxBodySize: 1024, TopQueriesSize: 2, TopQueriesWindow: time.Hour}
+	handler := NewHandler(cfg, roundTripper, log.NewNopLogger(), nil)
+
+	for _, query := range []string{"up", "sum(up)", "count(up)"} {
+		req := httptest.NewRequest("GET", "/api/v1/query?query="+query, nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.TopQueriesHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/frontend/top_queries", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var res topQueriesResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Equal(t, "1h0m0s", res.Window)
+	require.Len(t, res.Top["wall_time"], 2)
+	assert.Equal(t, "count(up)", res.Top["wall_time"][0].Query)
+	assert.Equal(t, "user-1", res.Top["wall_time"][0].Tenant)
+	assert.Equal(t, "/api/v1/query", res.Top["wall_time"][0].Path)
+	assert.Equal(t, 9.0, res.Top["wall_time"][0].WallTimeSeconds)
+	assert.Equal(t, uint64(9), res.Top["fetched_samples"][0].FetchedSamples)
+	assert.Equal(t, "sum(up)", res.Top["wall_time"][1].Query)
+
+	// The endpoint is not found when the tracking is disabled.
+	recorder = httptest.NewRecorder()
+	NewHandler(HandlerConfig{QueryStatsEnabled: true}, roundTripper, log.NewNopLogger(), nil).TopQueriesHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/frontend/top_queries", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -76,3 +76,23 @@ func (m *IngesterServerMock) MetricsMetadata(ctx context.Context, r *MetricsMeta
 	args := m.Called(ctx, r)
 	return args.Get(0).(*MetricsMetadataResponse), args.Error(1)
 }
+
+func (m *IngesterServerMock) TSDBStatus(ctx context.Context, r *TSDBStatusRequest) (*TSDBStatusResponse, error) {
+	args := m.Called(ctx, r)
+	return args.Get(0).(*TSDBStatusResponse), args.Error(1)
+}
+
+func (m *IngesterServerMock) ScaleDown(ctx context.Context, r *ScaleDownRequest) (*ScaleDownResponse, error) {
+	args := m.Called(ctx, r)
+	return args.Get(0).(*ScaleDownResponse), args.Error(1)
+}
+
+func (m *IngesterServerMock) LabelValuesSketches(ctx context.Context, r *LabelValuesSketchesRequest) (*LabelValuesSketchesResponse, error) {
+	args := m.Called(ctx, r)
+	return args.Get(0).(*LabelValuesSketchesResponse), args.Error(1)
+}
+
+func (m *IngesterServerMock) TransferTSDB(s Ingester_TransferTSDBServer) error {
+	args := m.Called(s)
+	return args.Error(0)
+}
@@ -3,6 +3,8 @@ package client
 import (
 	"encoding/binary"
 
+	"github.com/cespare/xxhash/v2"
+
 	"github.com/cortexproject/cortex/pkg/chunk/encoding"
 )
 
@@ -34,16 +36,41 @@ func (m *QueryStreamResponse) ChunksSize() int {
 	return size
 }
 
+// SamplesCount returns the number of float samples in the response, including the ones of the chunks.
 func (m *QueryStreamResponse) SamplesCount() (count int) {
 	for _, ts := range m.Timeseries {
 		count += len(ts.Samples)
 	}
 	for _, cs := range m.Chunkseries {
 		for _, c := range cs.Chunks {
-			if c.Encoding == int32(encoding.PrometheusXorChunk) {
-				count += int(binary.BigEndian.Uint16(c.Data))
-			}
+			count += c.SamplesCount()
 		}
 	}
 	return
 }
+
+// SamplesCount returns the number of samples of the chunk, without decoding it.
+func (m *Chunk) SamplesCount() int {
+	switch m.Encoding {
+	case int32(encoding.PrometheusXorChunk):
+		return int(binary.BigEndian.Uint16(m.Data))
+	case int32(encoding.StorageEngineChunk):
+		// The engine's chunk data, following the engine's chunk encoding, starts with the number of samples.
+		return int(binary.BigEndian.Uint16(m.Data[1:]))
+	}
+	return 0
+}
+
+// HistogramSamplesCount returns the number of native histogram samples of the time series in the response.
+func (m *QueryStreamResponse) HistogramSamplesCount() (count int) {
+	for _, ts := range m.Timeseries {
+		count += len(ts.Histograms)
+	}
+	return
+}
+
+// LabelValueHash returns the hash of a label value, added to the LabelValuesSketch and
+// returned in its value hashes.
+func LabelValueHash(value string) uint64 {
+	return xxhash.Sum64String(value)
+}
@@ -36,6 +36,37 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+type ScaleDownAction int32
+
+const (
+	// Only return the scale-down status.
+	SCALE_DOWN_STATUS ScaleDownAction = 0
+	// Switch the ingester to read-only, and flush and ship its in-memory series.
+	SCALE_DOWN_PREPARE ScaleDownAction = 1
+	// Switch the ingester back to active.
+	SCALE_DOWN_CANCEL ScaleDownAction = 2
+	// Remove the ingester from the ring, once it's removable.
+	SCALE_DOWN_REMOVE ScaleDownAction = 3
+)
+
+var ScaleDownAction_name = map[int32]string{
+	0: "SCALE_DOWN_STATUS",
+	1: "SCALE_DOWN_PREPARE",
+	2: "SCALE_DOWN_CANCEL",
+	3: "SCALE_DOWN_REMOVE",
+}
+
+var ScaleDownAction_value = map[string]int32{
+	"SCALE_DOWN_STATUS":  0,
+	"SCALE_DOWN_PREPARE": 1,
+	"SCALE_DOWN_CANCEL":  2,
+	"SCALE_DOWN_REMOVE":  3,
+}
+
+func (ScaleDownAction) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{0}
+}
+
 type MatchType int32
 
 const (
@@ -60,7 +91,7 @@ var MatchType_value = map[string]int32{
 }
 
 func (MatchType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{0}
+	return fileDescriptor_60f6df4f3586b478, []int{1}
 }
 
 type ReadRequest struct {
@@ -153,6 +184,8 @@ type QueryRequest struct {
 	StartTimestampMs int64           `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64           `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
 	Matchers         []*LabelMatcher `protobuf:"bytes,3,rep,name=matchers,proto3" json:"matchers,omitempty"`
+	SortSeries       bool            `protobuf:"varint,4,opt,name=sort_series,json=sortSeries,proto3" json:"sort_series,omitempty"`
+	SeriesLimit      int64           `protobuf:"varint,5,opt,name=series_limit,json=seriesLimit,proto3" json:"series_limit,omitempty"`
 }
 
 func (m *QueryRequest) Reset()      { *m = QueryRequest{} }
@@ -208,6 +241,20 @@ func (m *QueryRequest) GetMatchers() []*LabelMatcher {
 	return nil
 }
 
+func (m *QueryRequest) GetSortSeries() bool {
+	if m != nil {
+		return m.SortSeries
+	}
+	return false
+}
+
+func (m *QueryRequest) GetSeriesLimit() int64 {
+	if m != nil {
+		return m.SeriesLimit
+	}
+	return 0
+}
+
 type ExemplarQueryRequest struct {
 	StartTimestampMs int64            `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64            `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
@@ -312,8 +359,9 @@ func (m *QueryResponse) GetTimeseries() []cortexpb.TimeSeries {
 
 // QueryStreamResponse contains a batch of timeseries chunks or timeseries. Only one of these series will be populated.
 type QueryStreamResponse struct {
-	Chunkseries []TimeSeriesChunk     `protobuf:"bytes,1,rep,name=chunkseries,proto3" json:"chunkseries"`
-	Timeseries  []cortexpb.TimeSeries `protobuf:"bytes,2,rep,name=timeseries,proto3" json:"timeseries"`
+	Chunkseries        []TimeSeriesChunk     `protobuf:"bytes,1,rep,name=chunkseries,proto3" json:"chunkseries"`
+	Timeseries         []cortexpb.TimeSeries `protobuf:"bytes,2,rep,name=timeseries,proto3" json:"timeseries"`
+	SeriesLimitReached bool                  `protobuf:"varint,3,opt,name=series_limit_reached,json=seriesLimitReached,proto3" json:"series_limit_reached,omitempty"`
 }
 
 func (m *QueryStreamResponse) Reset()      { *m = QueryStreamResponse{} }
@@ -362,6 +410,13 @@ func (m *QueryStreamResponse) GetTimeseries() []cortexpb.TimeSeries {
 	return nil
 }
 
+func (m *QueryStreamResponse) GetSeriesLimitReached() bool {
+	if m != nil {
+		return m.SeriesLimitReached
+	}
+	return false
+}
+
 type ExemplarQueryResponse struct {
 	Timeseries []cortexpb.TimeSeries `protobuf:"bytes,1,rep,name=timeseries,proto3" json:"timeseries"`
 }
@@ -1122,24 +1177,22 @@ func (m *MetricsMetadataResponse) GetMetadata() []*cortexpb.MetricMetadata {
 	return nil
 }
 
-type TimeSeriesChunk struct {
-	FromIngesterId string                                                      `protobuf:"bytes,1,opt,name=from_ingester_id,json=fromIngesterId,proto3" json:"from_ingester_id,omitempty"`
-	UserId         string                                                      `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Labels         []github_com_cortexproject_cortex_pkg_cortexpb.LabelAdapter `protobuf:"bytes,3,rep,name=labels,proto3,customtype=github.com/cortexproject/cortex/pkg/cortexpb.LabelAdapter" json:"labels"`
-	Chunks         []Chunk                                                     `protobuf:"bytes,4,rep,name=chunks,proto3" json:"chunks"`
+type TSDBStatusRequest struct {
+	// Max number of items returned in each of the top lists, 0 for the default.
+	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
 }
 
-func (m *TimeSeriesChunk) Reset()      { *m = TimeSeriesChunk{} }
-func (*TimeSeriesChunk) ProtoMessage() {}
-func (*TimeSeriesChunk) Descriptor() ([]byte, []int) {
+func (m *TSDBStatusRequest) Reset()      { *m = TSDBStatusRequest{} }
+func (*TSDBStatusRequest) ProtoMessage() {}
+func (*TSDBStatusRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_60f6df4f3586b478, []int{22}
 }
-func (m *TimeSeriesChunk) XXX_Unmarshal(b []byte) error {
+func (m *TSDBStatusRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
 }
-func (m *TimeSeriesChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+func (m *TSDBStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	if deterministic {
-		return xxx_messageInfo_TimeSeriesChunk.Marshal(b, m, deterministic)
+		return xxx_messageInfo_TSDBStatusRequest.Marshal(b, m, deterministic)
 	} else {
 		b = b[:cap(b)]
 		n, err := m.MarshalToSizedBuffer(b)
@@ -1149,57 +1202,48 @@ func (m *TimeSeriesChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, err
 		return b[:n], nil
 	}
 }
-func (m *TimeSeriesChunk) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_TimeSeriesChunk.Merge(m, src)
+func (m *TSDBStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TSDBStatusRequest.Merge(m, src)
 }
-func (m *TimeSeriesChunk) XXX_Size() int {
+func (m *TSDBStatusRequest) XXX_Size() int {
 	return m.Size()
 }
-func (m *TimeSeriesChunk) XXX_DiscardUnknown() {
-	xxx_messageInfo_TimeSeriesChunk.DiscardUnknown(m)
-}
-
-var xxx_messageInfo_TimeSeriesChunk proto.InternalMessageInfo
-
-func (m *TimeSeriesChunk) GetFromIngesterId() string {
-	if m != nil {
-		return m.FromIngesterId
-	}
-	return ""
+func (m *TSDBStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TSDBStatusRequest.DiscardUnknown(m)
 }
 
-func (m *TimeSeriesChunk) GetUserId() string {
-	if m != nil {
-		return m.UserId
-	}
-	return ""
-}
+var xxx_messageInfo_TSDBStatusRequest proto.InternalMessageInfo
 
-func (m *TimeSeriesChunk) GetChunks() []Chunk {
+func (m *TSDBStatusRequest) GetLimit() int32 {
 	if m != nil {
-		return m.Chunks
+		return m.Limit
 	}
-	return nil
+	return 0
 }
 
-type Chunk struct {
-	StartTimestampMs int64  `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
-	EndTimestampMs   int64  `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
-	Encoding         int32  `protobuf:"varint,3,opt,name=encoding,proto3" json:"encoding,omitempty"`
-	Data             []byte `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+type TSDBStatusResponse struct {
+	NumSeries                   uint64          `protobuf:"varint,1,opt,name=num_series,json=numSeries,proto3" json:"num_series,omitempty"`
+	ChunkCount                  uint64          `protobuf:"varint,2,opt,name=chunk_count,json=chunkCount,proto3" json:"chunk_count,omitempty"`
+	MinTime                     int64           `protobuf:"varint,3,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
+	MaxTime                     int64           `protobuf:"varint,4,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
+	NumLabelPairs               int64           `protobuf:"varint,5,opt,name=num_label_pairs,json=numLabelPairs,proto3" json:"num_label_pairs,omitempty"`
+	SeriesCountByMetricName     []TSDBStatistic `protobuf:"bytes,6,rep,name=series_count_by_metric_name,json=seriesCountByMetricName,proto3" json:"series_count_by_metric_name"`
+	LabelValueCountByLabelName  []TSDBStatistic `protobuf:"bytes,7,rep,name=label_value_count_by_label_name,json=labelValueCountByLabelName,proto3" json:"label_value_count_by_label_name"`
+	MemoryInBytesByLabelName    []TSDBStatistic `protobuf:"bytes,8,rep,name=memory_in_bytes_by_label_name,json=memoryInBytesByLabelName,proto3" json:"memory_in_bytes_by_label_name"`
+	SeriesCountByLabelValuePair []TSDBStatistic `protobuf:"bytes,9,rep,name=series_count_by_label_value_pair,json=seriesCountByLabelValuePair,proto3" json:"series_count_by_label_value_pair"`
 }
 
-func (m *Chunk) Reset()      { *m = Chunk{} }
-func (*Chunk) ProtoMessage() {}
-func (*Chunk) Descriptor() ([]byte, []int) {
+func (m *TSDBStatusResponse) Reset()      { *m = TSDBStatusResponse{} }
+func (*TSDBStatusResponse) ProtoMessage() {}
+func (*TSDBStatusResponse) Descriptor() ([]byte, []int) {
 	return fileDescriptor_60f6df4f3586b478, []int{23}
 }
-func (m *Chunk) XXX_Unmarshal(b []byte) error {
+func (m *TSDBStatusResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
 }
-func (m *Chunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+func (m *TSDBStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	if deterministic {
-		return xxx_messageInfo_Chunk.Marshal(b, m, deterministic)
+		return xxx_messageInfo_TSDBStatusResponse.Marshal(b, m, deterministic)
 	} else {
 		b = b[:cap(b)]
 		n, err := m.MarshalToSizedBuffer(b)
@@ -1209,61 +1253,97 @@ func (m *Chunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 		return b[:n], nil
 	}
 }
-func (m *Chunk) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Chunk.Merge(m, src)
+func (m *TSDBStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TSDBStatusResponse.Merge(m, src)
 }
-func (m *Chunk) XXX_Size() int {
+func (m *TSDBStatusResponse) XXX_Size() int {
 	return m.Size()
 }
-func (m *Chunk) XXX_DiscardUnknown() {
-	xxx_messageInfo_Chunk.DiscardUnknown(m)
+func (m *TSDBStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TSDBStatusResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_Chunk proto.InternalMessageInfo
+var xxx_messageInfo_TSDBStatusResponse proto.InternalMessageInfo
 
-func (m *Chunk) GetStartTimestampMs() int64 {
+func (m *TSDBStatusResponse) GetNumSeries() uint64 {
 	if m != nil {
-		return m.StartTimestampMs
+		return m.NumSeries
 	}
 	return 0
 }
 
-func (m *Chunk) GetEndTimestampMs() int64 {
+func (m *TSDBStatusResponse) GetChunkCount() uint64 {
 	if m != nil {
-		return m.EndTimestampMs
+		return m.ChunkCount
 	}
 	return 0
 }
 
-func (m *Chunk) GetEncoding() int32 {
+func (m *TSDBStatusResponse) GetMinTime() int64 {
 	if m != nil {
-		return m.Encoding
+		return m.MinTime
 	}
 	return 0
 }
 
-func (m *Chunk) GetData() []byte {
+func (m *TSDBStatusResponse) GetMaxTime() int64 {
 	if m != nil {
-		return m.Data
+		return m.MaxTime
+	}
+	return 0
+}
+
+func (m *TSDBStatusResponse) GetNumLabelPairs() int64 {
+	if m != nil {
+		return m.NumLabelPairs
+	}
+	return 0
+}
+
+func (m *TSDBStatusResponse) GetSeriesCountByMetricName() []TSDBStatistic {
+	if m != nil {
+		return m.SeriesCountByMetricName
 	}
 	return nil
 }
 
-type LabelMatchers struct {
-	Matchers []*LabelMatcher `protobuf:"bytes,1,rep,name=matchers,proto3" json:"matchers,omitempty"`
+func (m *TSDBStatusResponse) GetLabelValueCountByLabelName() []TSDBStatistic {
+	if m != nil {
+		return m.LabelValueCountByLabelName
+	}
+	return nil
 }
 
-func (m *LabelMatchers) Reset()      { *m = LabelMatchers{} }
-func (*LabelMatchers) ProtoMessage() {}
-func (*LabelMatchers) Descriptor() ([]byte, []int) {
+func (m *TSDBStatusResponse) GetMemoryInBytesByLabelName() []TSDBStatistic {
+	if m != nil {
+		return m.MemoryInBytesByLabelName
+	}
+	return nil
+}
+
+func (m *TSDBStatusResponse) GetSeriesCountByLabelValuePair() []TSDBStatistic {
+	if m != nil {
+		return m.SeriesCountByLabelValuePair
+	}
+	return nil
+}
+
+type TSDBStatistic struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value uint64 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *TSDBStatistic) Reset()      { *m = TSDBStatistic{} }
+func (*TSDBStatistic) ProtoMessage() {}
+func (*TSDBStatistic) Descriptor() ([]byte, []int) {
 	return fileDescriptor_60f6df4f3586b478, []int{24}
 }
-func (m *LabelMatchers) XXX_Unmarshal(b []byte) error {
+func (m *TSDBStatistic) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
 }
-func (m *LabelMatchers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+func (m *TSDBStatistic) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	if deterministic {
-		return xxx_messageInfo_LabelMatchers.Marshal(b, m, deterministic)
+		return xxx_messageInfo_TSDBStatistic.Marshal(b, m, deterministic)
 	} else {
 		b = b[:cap(b)]
 		n, err := m.MarshalToSizedBuffer(b)
@@ -1273,42 +1353,47 @@ func (m *LabelMatchers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error
 		return b[:n], nil
 	}
 }
-func (m *LabelMatchers) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_LabelMatchers.Merge(m, src)
+func (m *TSDBStatistic) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TSDBStatistic.Merge(m, src)
 }
-func (m *LabelMatchers) XXX_Size() int {
+func (m *TSDBStatistic) XXX_Size() int {
 	return m.Size()
 }
-func (m *LabelMatchers) XXX_DiscardUnknown() {
-	xxx_messageInfo_LabelMatchers.DiscardUnknown(m)
+func (m *TSDBStatistic) XXX_DiscardUnknown() {
+	xxx_messageInfo_TSDBStatistic.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_LabelMatchers proto.InternalMessageInfo
+var xxx_messageInfo_TSDBStatistic proto.InternalMessageInfo
 
-func (m *LabelMatchers) GetMatchers() []*LabelMatcher {
+func (m *TSDBStatistic) GetName() string {
 	if m != nil {
-		return m.Matchers
+		return m.Name
 	}
-	return nil
+	return ""
 }
 
-type LabelMatcher struct {
-	Type  MatchType `protobuf:"varint,1,opt,name=type,proto3,enum=cortex.MatchType" json:"type,omitempty"`
-	Name  string    `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Value string    `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+func (m *TSDBStatistic) GetValue() uint64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
 }
 
-func (m *LabelMatcher) Reset()      { *m = LabelMatcher{} }
-func (*LabelMatcher) ProtoMessage() {}
-func (*LabelMatcher) Descriptor() ([]byte, []int) {
+type ScaleDownRequest struct {
+	Action ScaleDownAction `protobuf:"varint,1,opt,name=action,proto3,enum=cortex.ScaleDownAction" json:"action,omitempty"`
+}
+
+func (m *ScaleDownRequest) Reset()      { *m = ScaleDownRequest{} }
+func (*ScaleDownRequest) ProtoMessage() {}
+func (*ScaleDownRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_60f6df4f3586b478, []int{25}
 }
-func (m *LabelMatcher) XXX_Unmarshal(b []byte) error {
+func (m *ScaleDownRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
 }
-func (m *LabelMatcher) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+func (m *ScaleDownRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	if deterministic {
-		return xxx_messageInfo_LabelMatcher.Marshal(b, m, deterministic)
+		return xxx_messageInfo_ScaleDownRequest.Marshal(b, m, deterministic)
 	} else {
 		b = b[:cap(b)]
 		n, err := m.MarshalToSizedBuffer(b)
@@ -1318,57 +1403,121 @@ func (m *LabelMatcher) XXX_Marshal(b []byte, deterministic bool) ([]byte, error)
 		return b[:n], nil
 	}
 }
-func (m *LabelMatcher) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_LabelMatcher.Merge(m, src)
+func (m *ScaleDownRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScaleDownRequest.Merge(m, src)
 }
-func (m *LabelMatcher) XXX_Size() int {
+func (m *ScaleDownRequest) XXX_Size() int {
 	return m.Size()
 }
-func (m *LabelMatcher) XXX_DiscardUnknown() {
-	xxx_messageInfo_LabelMatcher.DiscardUnknown(m)
+func (m *ScaleDownRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScaleDownRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_LabelMatcher proto.InternalMessageInfo
+var xxx_messageInfo_ScaleDownRequest proto.InternalMessageInfo
 
-func (m *LabelMatcher) GetType() MatchType {
+func (m *ScaleDownRequest) GetAction() ScaleDownAction {
 	if m != nil {
-		return m.Type
+		return m.Action
 	}
-	return EQUAL
+	return SCALE_DOWN_STATUS
 }
 
-func (m *LabelMatcher) GetName() string {
+type ScaleDownResponse struct {
+	// Whether the ingester is READONLY in the ring.
+	ReadOnly bool `protobuf:"varint,1,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	// Whether all the in-memory series have been flushed and shipped to the storage.
+	Shipped bool `protobuf:"varint,2,opt,name=shipped,proto3" json:"shipped,omitempty"`
+	// Number of queries being executed by the ingester.
+	InflightQueries int64 `protobuf:"varint,3,opt,name=inflight_queries,json=inflightQueries,proto3" json:"inflight_queries,omitempty"`
+	// Whether the ingester can be removed from the ring without losing data.
+	Removable bool `protobuf:"varint,4,opt,name=removable,proto3" json:"removable,omitempty"`
+	// Whether the ingester is being removed from the ring.
+	Removing bool `protobuf:"varint,5,opt,name=removing,proto3" json:"removing,omitempty"`
+}
+
+func (m *ScaleDownResponse) Reset()      { *m = ScaleDownResponse{} }
+func (*ScaleDownResponse) ProtoMessage() {}
+func (*ScaleDownResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{26}
+}
+func (m *ScaleDownResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ScaleDownResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ScaleDownResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ScaleDownResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScaleDownResponse.Merge(m, src)
+}
+func (m *ScaleDownResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ScaleDownResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScaleDownResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScaleDownResponse proto.InternalMessageInfo
+
+func (m *ScaleDownResponse) GetReadOnly() bool {
 	if m != nil {
-		return m.Name
+		return m.ReadOnly
 	}
-	return ""
+	return false
 }
 
-func (m *LabelMatcher) GetValue() string {
+func (m *ScaleDownResponse) GetShipped() bool {
 	if m != nil {
-		return m.Value
+		return m.Shipped
 	}
-	return ""
+	return false
 }
 
-type TimeSeriesFile struct {
-	FromIngesterId string `protobuf:"bytes,1,opt,name=from_ingester_id,json=fromIngesterId,proto3" json:"from_ingester_id,omitempty"`
-	UserId         string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Filename       string `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`
-	Data           []byte `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+func (m *ScaleDownResponse) GetInflightQueries() int64 {
+	if m != nil {
+		return m.InflightQueries
+	}
+	return 0
 }
 
-func (m *TimeSeriesFile) Reset()      { *m = TimeSeriesFile{} }
-func (*TimeSeriesFile) ProtoMessage() {}
-func (*TimeSeriesFile) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{26}
+func (m *ScaleDownResponse) GetRemovable() bool {
+	if m != nil {
+		return m.Removable
+	}
+	return false
 }
-func (m *TimeSeriesFile) XXX_Unmarshal(b []byte) error {
+
+func (m *ScaleDownResponse) GetRemoving() bool {
+	if m != nil {
+		return m.Removing
+	}
+	return false
+}
+
+type LabelValuesSketchesRequest struct {
+	// The labels over their values budget, whose value hashes are returned.
+	ValueHashesLabels []UserLabelName `protobuf:"bytes,1,rep,name=value_hashes_labels,json=valueHashesLabels,proto3" json:"value_hashes_labels"`
+}
+
+func (m *LabelValuesSketchesRequest) Reset()      { *m = LabelValuesSketchesRequest{} }
+func (*LabelValuesSketchesRequest) ProtoMessage() {}
+func (*LabelValuesSketchesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{27}
+}
+func (m *LabelValuesSketchesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
 }
-func (m *TimeSeriesFile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+func (m *LabelValuesSketchesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	if deterministic {
-		return xxx_messageInfo_TimeSeriesFile.Marshal(b, m, deterministic)
+		return xxx_messageInfo_LabelValuesSketchesRequest.Marshal(b, m, deterministic)
 	} else {
 		b = b[:cap(b)]
 		n, err := m.MarshalToSizedBuffer(b)
@@ -1378,403 +1527,712 @@ func (m *TimeSeriesFile) XXX_Marshal(b []byte, deterministic bool) ([]byte, erro
 		return b[:n], nil
 	}
 }
-func (m *TimeSeriesFile) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_TimeSeriesFile.Merge(m, src)
+func (m *LabelValuesSketchesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelValuesSketchesRequest.Merge(m, src)
 }
-func (m *TimeSeriesFile) XXX_Size() int {
+func (m *LabelValuesSketchesRequest) XXX_Size() int {
 	return m.Size()
 }
-func (m *TimeSeriesFile) XXX_DiscardUnknown() {
-	xxx_messageInfo_TimeSeriesFile.DiscardUnknown(m)
+func (m *LabelValuesSketchesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelValuesSketchesRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_TimeSeriesFile proto.InternalMessageInfo
+var xxx_messageInfo_LabelValuesSketchesRequest proto.InternalMessageInfo
 
-func (m *TimeSeriesFile) GetFromIngesterId() string {
+func (m *LabelValuesSketchesRequest) GetValueHashesLabels() []UserLabelName {
 	if m != nil {
-		return m.FromIngesterId
+		return m.ValueHashesLabels
 	}
-	return ""
+	return nil
 }
 
-func (m *TimeSeriesFile) GetUserId() string {
+type UserLabelName struct {
+	UserId    string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LabelName string `protobuf:"bytes,2,opt,name=label_name,json=labelName,proto3" json:"label_name,omitempty"`
+}
+
+func (m *UserLabelName) Reset()      { *m = UserLabelName{} }
+func (*UserLabelName) ProtoMessage() {}
+func (*UserLabelName) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{28}
+}
+func (m *UserLabelName) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *UserLabelName) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_UserLabelName.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *UserLabelName) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UserLabelName.Merge(m, src)
+}
+func (m *UserLabelName) XXX_Size() int {
+	return m.Size()
+}
+func (m *UserLabelName) XXX_DiscardUnknown() {
+	xxx_messageInfo_UserLabelName.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UserLabelName proto.InternalMessageInfo
+
+func (m *UserLabelName) GetUserId() string {
 	if m != nil {
 		return m.UserId
 	}
 	return ""
 }
 
-func (m *TimeSeriesFile) GetFilename() string {
+func (m *UserLabelName) GetLabelName() string {
 	if m != nil {
-		return m.Filename
+		return m.LabelName
 	}
 	return ""
 }
 
-func (m *TimeSeriesFile) GetData() []byte {
-	if m != nil {
-		return m.Data
-	}
-	return nil
+type LabelValuesSketchesResponse struct {
+	Sketches []LabelValuesSketch `protobuf:"bytes,1,rep,name=sketches,proto3" json:"sketches"`
 }
 
-func init() {
-	proto.RegisterEnum("cortex.MatchType", MatchType_name, MatchType_value)
-	proto.RegisterType((*ReadRequest)(nil), "cortex.ReadRequest")
-	proto.RegisterType((*ReadResponse)(nil), "cortex.ReadResponse")
-	proto.RegisterType((*QueryRequest)(nil), "cortex.QueryRequest")
-	proto.RegisterType((*ExemplarQueryRequest)(nil), "cortex.ExemplarQueryRequest")
-	proto.RegisterType((*QueryResponse)(nil), "cortex.QueryResponse")
-	proto.RegisterType((*QueryStreamResponse)(nil), "cortex.QueryStreamResponse")
-	proto.RegisterType((*ExemplarQueryResponse)(nil), "cortex.ExemplarQueryResponse")
-	proto.RegisterType((*LabelValuesRequest)(nil), "cortex.LabelValuesRequest")
-	proto.RegisterType((*LabelValuesResponse)(nil), "cortex.LabelValuesResponse")
-	proto.RegisterType((*LabelValuesStreamResponse)(nil), "cortex.LabelValuesStreamResponse")
-	proto.RegisterType((*LabelNamesRequest)(nil), "cortex.LabelNamesRequest")
-	proto.RegisterType((*LabelNamesResponse)(nil), "cortex.LabelNamesResponse")
-	proto.RegisterType((*LabelNamesStreamResponse)(nil), "cortex.LabelNamesStreamResponse")
-	proto.RegisterType((*UserStatsRequest)(nil), "cortex.UserStatsRequest")
-	proto.RegisterType((*UserStatsResponse)(nil), "cortex.UserStatsResponse")
-	proto.RegisterType((*UserIDStatsResponse)(nil), "cortex.UserIDStatsResponse")
-	proto.RegisterType((*UsersStatsResponse)(nil), "cortex.UsersStatsResponse")
-	proto.RegisterType((*MetricsForLabelMatchersRequest)(nil), "cortex.MetricsForLabelMatchersRequest")
-	proto.RegisterType((*MetricsForLabelMatchersResponse)(nil), "cortex.MetricsForLabelMatchersResponse")
-	proto.RegisterType((*MetricsForLabelMatchersStreamResponse)(nil), "cortex.MetricsForLabelMatchersStreamResponse")
-	proto.RegisterType((*MetricsMetadataRequest)(nil), "cortex.MetricsMetadataRequest")
-	proto.RegisterType((*MetricsMetadataResponse)(nil), "cortex.MetricsMetadataResponse")
-	proto.RegisterType((*TimeSeriesChunk)(nil), "cortex.TimeSeriesChunk")
-	proto.RegisterType((*Chunk)(nil), "cortex.Chunk")
-	proto.RegisterType((*LabelMatchers)(nil), "cortex.LabelMatchers")
-	proto.RegisterType((*LabelMatcher)(nil), "cortex.LabelMatcher")
-	proto.RegisterType((*TimeSeriesFile)(nil), "cortex.TimeSeriesFile")
+func (m *LabelValuesSketchesResponse) Reset()      { *m = LabelValuesSketchesResponse{} }
+func (*LabelValuesSketchesResponse) ProtoMessage() {}
+func (*LabelValuesSketchesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{29}
+}
+func (m *LabelValuesSketchesResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelValuesSketchesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelValuesSketchesResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LabelValuesSketchesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelValuesSketchesResponse.Merge(m, src)
+}
+func (m *LabelValuesSketchesResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelValuesSketchesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelValuesSketchesResponse.DiscardUnknown(m)
 }
 
-func init() { proto.RegisterFile("ingester.proto", fileDescriptor_60f6df4f3586b478) }
+var xxx_messageInfo_LabelValuesSketchesResponse proto.InternalMessageInfo
 
-var fileDescriptor_60f6df4f3586b478 = []byte{
-	// 1304 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x57, 0xcf, 0x6f, 0xd4, 0xd6,
-	0x13, 0xf7, 0x4b, 0x76, 0x97, 0xec, 0xec, 0x66, 0xd9, 0xbc, 0x04, 0xb2, 0x98, 0x2f, 0x0e, 0xf8,
-	0x2b, 0xda, 0xa8, 0x2d, 0x09, 0xa4, 0x3f, 0x04, 0xfd, 0x85, 0x12, 0x08, 0x90, 0x42, 0x08, 0x38,
-	0x81, 0x56, 0x95, 0x2a, 0xcb, 0xd9, 0x7d, 0x24, 0x2e, 0xfe, 0x85, 0xfd, 0x8c, 0xa0, 0xa7, 0x4a,
-	0xfd, 0x03, 0x5a, 0xf5, 0xd4, 0x53, 0xa5, 0xde, 0x7a, 0xee, 0x1f, 0xd0, 0x33, 0x47, 0x8e, 0xa8,
-	0xaa, 0x50, 0x59, 0x2e, 0x3d, 0xd2, 0xff, 0xa0, 0xf2, 0xfb, 0xe1, 0xb5, 0x1d, 0x6f, 0x12, 0x24,
-	0xe8, 0x6d, 0x3d, 0xf3, 0x99, 0xcf, 0xcc, 0x9b, 0x99, 0xf7, 0x66, 0x16, 0x5a, 0xb6, 0xb7, 0x45,
-	0x22, 0x4a, 0xc2, 0xb9, 0x20, 0xf4, 0xa9, 0x8f, 0x6b, 0x5d, 0x3f, 0xa4, 0xe4, 0x81, 0x3a, 0xb5,
-	0xe5, 0x6f, 0xf9, 0x4c, 0x34, 0x9f, 0xfc, 0xe2, 0x5a, 0xf5, 0xdc, 0x96, 0x4d, 0xb7, 0xe3, 0xcd,
-	0xb9, 0xae, 0xef, 0xce, 0x73, 0x60, 0x10, 0xfa, 0x5f, 0x93, 0x2e, 0x15, 0x5f, 0xf3, 0xc1, 0xdd,
-	0x2d, 0xa9, 0xd8, 0x14, 0x3f, 0xb8, 0xa9, 0xfe, 0x09, 0x34, 0x0c, 0x62, 0xf5, 0x0c, 0x72, 0x2f,
-	0x26, 0x11, 0xc5, 0x73, 0x70, 0xe0, 0x5e, 0x4c, 0x42, 0x9b, 0x44, 0x1d, 0x74, 0x7c, 0x74, 0xb6,
-	0xb1, 0x30, 0x35, 0x27, 0xe0, 0x37, 0x63, 0x12, 0x3e, 0x14, 0x30, 0x43, 0x82, 0xf4, 0xf3, 0xd0,
-	0xe4, 0xe6, 0x51, 0xe0, 0x7b, 0x11, 0xc1, 0xf3, 0x70, 0x20, 0x24, 0x51, 0xec, 0x50, 0x69, 0x7f,
-	0xa8, 0x60, 0xcf, 0x71, 0x86, 0x44, 0xe9, 0x3f, 0x21, 0x68, 0x66, 0xa9, 0xf1, 0x3b, 0x80, 0x23,
-	0x6a, 0x85, 0xd4, 0xa4, 0xb6, 0x4b, 0x22, 0x6a, 0xb9, 0x81, 0xe9, 0x26, 0x64, 0x68, 0x76, 0xd4,
-	0x68, 0x33, 0xcd, 0x86, 0x54, 0xac, 0x46, 0x78, 0x16, 0xda, 0xc4, 0xeb, 0xe5, 0xb1, 0x23, 0x0c,
-	0xdb, 0x22, 0x5e, 0x2f, 0x8b, 0x3c, 0x0d, 0x63, 0xae, 0x45, 0xbb, 0xdb, 0x24, 0x8c, 0x3a, 0xa3,
-	0xf9, 0xa3, 0x5d, 0xb3, 0x36, 0x89, 0xb3, 0xca, 0x95, 0x46, 0x8a, 0xd2, 0x7f, 0x41, 0x30, 0xb5,
-	0xfc, 0x80, 0xb8, 0x81, 0x63, 0x85, 0xff, 0x49, 0x88, 0x67, 0x76, 0x84, 0x78, 0xa8, 0x2c, 0xc4,
-	0x28, 0x13, 0xe3, 0x55, 0x18, 0xcf, 0x25, 0x16, 0x7f, 0x08, 0xc0, 0x3c, 0x95, 0xd5, 0x30, 0xd8,
-	0x9c, 0x4b, 0xdc, 0xad, 0x33, 0xdd, 0x52, 0xe5, 0xd1, 0xd3, 0x19, 0xc5, 0xc8, 0xa0, 0xf5, 0x1f,
-	0x11, 0x4c, 0x32, 0xb6, 0x75, 0x1a, 0x12, 0xcb, 0x4d, 0x39, 0xcf, 0x43, 0xa3, 0xbb, 0x1d, 0x7b,
-	0x77, 0x73, 0xa4, 0xd3, 0x32, 0xb4, 0x01, 0xe5, 0x85, 0x04, 0x24, 0x78, 0xb3, 0x16, 0x85, 0xa0,
-	0x46, 0x5e, 0x2a, 0xa8, 0x75, 0x38, 0x54, 0x28, 0xc2, 0x2b, 0x38, 0xe9, 0xef, 0x08, 0x30, 0x4b,
-	0xe9, 0x6d, 0xcb, 0x89, 0x49, 0x24, 0x0b, 0x7b, 0x0c, 0xc0, 0x49, 0xa4, 0xa6, 0x67, 0xb9, 0x84,
-	0x15, 0xb4, 0x6e, 0xd4, 0x99, 0xe4, 0xba, 0xe5, 0x92, 0x21, 0x75, 0x1f, 0x79, 0x89, 0xba, 0x8f,
-	0xee, 0x59, 0xf7, 0xca, 0x71, 0xb4, 0x9f, 0xba, 0x9f, 0x85, 0xc9, 0x5c, 0xfc, 0x22, 0x27, 0x27,
-	0xa0, 0xc9, 0x0f, 0x70, 0x9f, 0xc9, 0x59, 0x56, 0xea, 0x46, 0xc3, 0x19, 0x40, 0xf5, 0x4f, 0xe1,
-	0x48, 0xc6, 0xb2, 0x50, 0xe9, 0x7d, 0xd8, 0xdf, 0x85, 0x89, 0x6b, 0x32, 0x23, 0xd1, 0x6b, 0xbe,
-	0x11, 0xfa, 0xfb, 0xa2, 0x4c, 0xc2, 0x99, 0x88, 0x72, 0x06, 0x1a, 0x83, 0x32, 0xc9, 0x20, 0x21,
-	0xad, 0x53, 0xa4, 0x7f, 0x04, 0x9d, 0x81, 0x59, 0xe1, 0x88, 0x7b, 0x1a, 0x63, 0x68, 0xdf, 0x8a,
-	0x48, 0xb8, 0x4e, 0x2d, 0x2a, 0xcf, 0xa7, 0xff, 0x89, 0x60, 0x22, 0x23, 0x14, 0x54, 0x27, 0xe5,
-	0x33, 0x6d, 0xfb, 0x9e, 0x19, 0x5a, 0x94, 0xb7, 0x0c, 0x32, 0xc6, 0x53, 0xa9, 0x61, 0x51, 0x92,
-	0x74, 0x95, 0x17, 0xbb, 0x66, 0xda, 0xfd, 0x68, 0xb6, 0x62, 0xd4, 0xbd, 0xd8, 0xe5, 0xdd, 0x99,
-	0xe4, 0xce, 0x0a, 0x6c, 0xb3, 0xc0, 0x34, 0xca, 0x98, 0xda, 0x56, 0x60, 0xaf, 0xe4, 0xc8, 0xe6,
-	0x60, 0x32, 0x8c, 0x1d, 0x52, 0x84, 0x57, 0x18, 0x7c, 0x22, 0x51, 0xe5, 0xf1, 0xff, 0x87, 0x71,
-	0xab, 0x4b, 0xed, 0xfb, 0x44, 0xfa, 0xaf, 0x32, 0xff, 0x4d, 0x2e, 0xe4, 0x21, 0xe8, 0x5f, 0xc1,
-	0x64, 0x72, 0xba, 0x95, 0x8b, 0xf9, 0xf3, 0x4d, 0xc3, 0x81, 0x38, 0x22, 0xa1, 0x69, 0xf7, 0xc4,
-	0x5d, 0xa8, 0x25, 0x9f, 0x2b, 0x3d, 0x7c, 0x0a, 0x2a, 0x3d, 0x8b, 0x5a, 0xec, 0x2c, 0x8d, 0x85,
-	0x23, 0xb2, 0x59, 0x77, 0x64, 0xc8, 0x60, 0x30, 0xfd, 0x32, 0xe0, 0x44, 0x15, 0xe5, 0xd9, 0xcf,
-	0x40, 0x35, 0x4a, 0x04, 0xe2, 0xea, 0x1e, 0xcd, 0xb2, 0x14, 0x22, 0x31, 0x38, 0x52, 0xff, 0x0d,
-	0x81, 0xb6, 0x4a, 0x68, 0x68, 0x77, 0xa3, 0x4b, 0x7e, 0x98, 0xbf, 0x1b, 0xaf, 0xf9, 0x6d, 0x3e,
-	0x0b, 0x4d, 0x79, 0xf9, 0xcc, 0x88, 0xd0, 0xdd, 0xdf, 0xe7, 0x86, 0x84, 0xae, 0x13, 0xaa, 0x5f,
-	0x85, 0x99, 0xa1, 0x31, 0x8b, 0x54, 0xcc, 0x42, 0xcd, 0x65, 0x10, 0x91, 0x8b, 0xf6, 0xe0, 0x19,
-	0xe3, 0xa6, 0x86, 0xd0, 0xeb, 0x37, 0xe1, 0xe4, 0x10, 0xb2, 0x42, 0x9b, 0xef, 0x9f, 0xb2, 0x03,
-	0x87, 0x05, 0xe5, 0x2a, 0xa1, 0x56, 0x52, 0x30, 0xd9, 0xf5, 0x6b, 0x30, 0xbd, 0x43, 0x23, 0xe8,
-	0xdf, 0x83, 0x31, 0x57, 0xc8, 0x84, 0x83, 0x4e, 0xd1, 0x41, 0x6a, 0x93, 0x22, 0xf5, 0x7f, 0x10,
-	0x1c, 0x2c, 0x8c, 0x8b, 0xa4, 0x04, 0x77, 0x42, 0xdf, 0x35, 0xe5, 0xc2, 0x33, 0xe8, 0xb6, 0x56,
-	0x22, 0x5f, 0x11, 0xe2, 0x95, 0x5e, 0xb6, 0x1d, 0x47, 0x72, 0xed, 0xe8, 0x41, 0x8d, 0xdd, 0x5f,
-	0x39, 0x35, 0x27, 0x07, 0xa1, 0xb0, 0x14, 0xdd, 0xb0, 0xec, 0x70, 0x69, 0x31, 0x19, 0x02, 0x7f,
-	0x3c, 0x9d, 0x79, 0xa9, 0x5d, 0x89, 0xdb, 0x2f, 0xf6, 0xac, 0x80, 0x92, 0xd0, 0x10, 0x5e, 0xf0,
-	0xdb, 0x50, 0xe3, 0xd3, 0xad, 0x53, 0x61, 0xfe, 0xc6, 0x65, 0x17, 0x64, 0x07, 0xa0, 0x80, 0xe8,
-	0xdf, 0x23, 0xa8, 0xf2, 0x93, 0xbe, 0xae, 0xd6, 0x54, 0x61, 0x8c, 0x78, 0x5d, 0xbf, 0x67, 0x7b,
-	0x5b, 0xec, 0xd9, 0xa8, 0x1a, 0xe9, 0x37, 0xc6, 0xe2, 0xa6, 0x26, 0xef, 0x43, 0x53, 0x5c, 0xc7,
-	0x45, 0x18, 0xcf, 0x75, 0x4e, 0x6e, 0x35, 0x42, 0xfb, 0x5a, 0x8d, 0x4c, 0x68, 0x66, 0x35, 0xf8,
-	0x24, 0x54, 0xe8, 0xc3, 0x80, 0xbf, 0x7f, 0xad, 0x85, 0x09, 0x69, 0xcd, 0xd4, 0x1b, 0x0f, 0x03,
-	0x62, 0x30, 0x75, 0x12, 0x0d, 0x9b, 0xac, 0xbc, 0x7c, 0xec, 0x37, 0x9e, 0x82, 0x2a, 0x1b, 0x36,
-	0x2c, 0xf4, 0xba, 0xc1, 0x3f, 0xf4, 0xef, 0x10, 0xb4, 0x06, 0x9d, 0x72, 0xc9, 0x76, 0xc8, 0xab,
-	0x68, 0x14, 0x15, 0xc6, 0xee, 0xd8, 0x0e, 0x61, 0x31, 0x70, 0x77, 0xe9, 0x77, 0x59, 0xa6, 0xde,
-	0xfa, 0x0c, 0xea, 0xe9, 0x11, 0x70, 0x1d, 0xaa, 0xcb, 0x37, 0x6f, 0x2d, 0x5e, 0x6b, 0x2b, 0x78,
-	0x1c, 0xea, 0xd7, 0xd7, 0x36, 0x4c, 0xfe, 0x89, 0xf0, 0x41, 0x68, 0x18, 0xcb, 0x97, 0x97, 0xbf,
-	0x30, 0x57, 0x17, 0x37, 0x2e, 0x5c, 0x69, 0x8f, 0x60, 0x0c, 0x2d, 0x2e, 0xb8, 0xbe, 0x26, 0x64,
-	0xa3, 0x0b, 0x3f, 0x8f, 0xc1, 0x98, 0x8c, 0x11, 0x9f, 0x83, 0xca, 0x8d, 0x38, 0xda, 0xc6, 0x87,
-	0x07, 0x9d, 0xfa, 0x79, 0x68, 0x53, 0x22, 0x6e, 0x9e, 0x3a, 0xbd, 0x43, 0xce, 0xef, 0x9d, 0xae,
-	0xe0, 0x0f, 0xa0, 0xca, 0xf6, 0x20, 0x5c, 0xba, 0x99, 0xab, 0xe5, 0xfb, 0xb6, 0xae, 0xe0, 0x8b,
-	0xd0, 0xc8, 0xec, 0x76, 0x43, 0xac, 0x8f, 0xe6, 0xa4, 0xf9, 0x27, 0x45, 0x57, 0x4e, 0x23, 0xbc,
-	0x06, 0x2d, 0xa6, 0x92, 0x2b, 0x59, 0x84, 0xff, 0x27, 0x4d, 0xca, 0x56, 0x65, 0xf5, 0xd8, 0x10,
-	0x6d, 0x1a, 0xd6, 0x15, 0x68, 0x64, 0xd6, 0x11, 0xac, 0xe6, 0x1a, 0x2f, 0xb7, 0x9d, 0x0d, 0x82,
-	0x2b, 0xd9, 0x7c, 0x74, 0x05, 0xdf, 0x16, 0x8b, 0x49, 0x76, 0xb1, 0xd9, 0x95, 0xef, 0x44, 0x89,
-	0xae, 0xe4, 0xc8, 0xcb, 0x00, 0x83, 0x65, 0x02, 0x1f, 0xc9, 0x19, 0x65, 0x97, 0x20, 0x55, 0x2d,
-	0x53, 0xa5, 0xe1, 0xad, 0x43, 0xbb, 0xb8, 0x93, 0xec, 0x46, 0x76, 0x7c, 0xa7, 0xaa, 0x24, 0xb6,
-	0x25, 0xa8, 0xa7, 0x43, 0x17, 0x77, 0x4a, 0xe6, 0x30, 0x27, 0x1b, 0x3e, 0xa1, 0x75, 0x05, 0x5f,
-	0x82, 0xe6, 0xa2, 0xe3, 0xec, 0x87, 0x46, 0xcd, 0x6a, 0xa2, 0x22, 0x8f, 0x93, 0x4e, 0x8b, 0xe2,
-	0x68, 0xc2, 0x6f, 0xa4, 0x0f, 0xc2, 0xae, 0xc3, 0x5b, 0x7d, 0x73, 0x4f, 0x5c, 0xea, 0xed, 0x1b,
-	0x38, 0xb6, 0xeb, 0x20, 0xdc, 0xb7, 0xcf, 0x53, 0x7b, 0xe0, 0x4a, 0xb2, 0xbe, 0x01, 0x07, 0x0b,
-	0x73, 0x11, 0x6b, 0x05, 0x96, 0xc2, 0x28, 0x55, 0x67, 0x86, 0xea, 0x25, 0xef, 0xd2, 0xc7, 0x8f,
-	0x9f, 0x69, 0xca, 0x93, 0x67, 0x9a, 0xf2, 0xe2, 0x99, 0x86, 0xbe, 0xed, 0x6b, 0xe8, 0xd7, 0xbe,
-	0x86, 0x1e, 0xf5, 0x35, 0xf4, 0xb8, 0xaf, 0xa1, 0xbf, 0xfa, 0x1a, 0xfa, 0xbb, 0xaf, 0x29, 0x2f,
-	0xfa, 0x1a, 0xfa, 0xe1, 0xb9, 0xa6, 0x3c, 0x7e, 0xae, 0x29, 0x4f, 0x9e, 0x6b, 0xca, 0x97, 0xb5,
-	0xae, 0x63, 0x13, 0x8f, 0x6e, 0xd6, 0xd8, 0xdf, 0xf9, 0x77, 0xff, 0x0d, 0x00, 0x00, 0xff, 0xff,
-	0x0a, 0x84, 0x23, 0x5a, 0x39, 0x10, 0x00, 0x00,
+func (m *LabelValuesSketchesResponse) GetSketches() []LabelValuesSketch {
+	if m != nil {
+		return m.Sketches
+	}
+	return nil
 }
 
-func (x MatchType) String() string {
-	s, ok := MatchType_name[int32(x)]
-	if ok {
-		return s
-	}
-	return strconv.Itoa(int(x))
+// LabelValuesSketch is the HyperLogLog sketch of the distinct values of a label with a values
+// budget, among the in-memory series of a tenant.
+type LabelValuesSketch struct {
+	UserId    string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LabelName string `protobuf:"bytes,2,opt,name=label_name,json=labelName,proto3" json:"label_name,omitempty"`
+	Sketch    []byte `protobuf:"bytes,3,opt,name=sketch,proto3" json:"sketch,omitempty"`
+	// The hashes of the values of the label, only when requested.
+	ValueHashes []uint64 `protobuf:"varint,4,rep,packed,name=value_hashes,json=valueHashes,proto3" json:"value_hashes,omitempty"`
 }
-func (this *ReadRequest) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
-	}
 
-	that1, ok := that.(*ReadRequest)
-	if !ok {
-		that2, ok := that.(ReadRequest)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+func (m *LabelValuesSketch) Reset()      { *m = LabelValuesSketch{} }
+func (*LabelValuesSketch) ProtoMessage() {}
+func (*LabelValuesSketch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{30}
+}
+func (m *LabelValuesSketch) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelValuesSketch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelValuesSketch.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
+}
+func (m *LabelValuesSketch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelValuesSketch.Merge(m, src)
+}
+func (m *LabelValuesSketch) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelValuesSketch) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelValuesSketch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelValuesSketch proto.InternalMessageInfo
+
+func (m *LabelValuesSketch) GetUserId() string {
+	if m != nil {
+		return m.UserId
 	}
-	if len(this.Queries) != len(that1.Queries) {
-		return false
+	return ""
+}
+
+func (m *LabelValuesSketch) GetLabelName() string {
+	if m != nil {
+		return m.LabelName
 	}
-	for i := range this.Queries {
-		if !this.Queries[i].Equal(that1.Queries[i]) {
-			return false
-		}
+	return ""
+}
+
+func (m *LabelValuesSketch) GetSketch() []byte {
+	if m != nil {
+		return m.Sketch
 	}
-	return true
+	return nil
 }
-func (this *ReadResponse) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *LabelValuesSketch) GetValueHashes() []uint64 {
+	if m != nil {
+		return m.ValueHashes
 	}
+	return nil
+}
 
-	that1, ok := that.(*ReadResponse)
-	if !ok {
-		that2, ok := that.(ReadResponse)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+type TimeSeriesChunk struct {
+	FromIngesterId string                                                      `protobuf:"bytes,1,opt,name=from_ingester_id,json=fromIngesterId,proto3" json:"from_ingester_id,omitempty"`
+	UserId         string                                                      `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Labels         []github_com_cortexproject_cortex_pkg_cortexpb.LabelAdapter `protobuf:"bytes,3,rep,name=labels,proto3,customtype=github.com/cortexproject/cortex/pkg/cortexpb.LabelAdapter" json:"labels"`
+	Chunks         []Chunk                                                     `protobuf:"bytes,4,rep,name=chunks,proto3" json:"chunks"`
+}
+
+func (m *TimeSeriesChunk) Reset()      { *m = TimeSeriesChunk{} }
+func (*TimeSeriesChunk) ProtoMessage() {}
+func (*TimeSeriesChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{31}
+}
+func (m *TimeSeriesChunk) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TimeSeriesChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TimeSeriesChunk.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if len(this.Results) != len(that1.Results) {
-		return false
+}
+func (m *TimeSeriesChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TimeSeriesChunk.Merge(m, src)
+}
+func (m *TimeSeriesChunk) XXX_Size() int {
+	return m.Size()
+}
+func (m *TimeSeriesChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_TimeSeriesChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TimeSeriesChunk proto.InternalMessageInfo
+
+func (m *TimeSeriesChunk) GetFromIngesterId() string {
+	if m != nil {
+		return m.FromIngesterId
 	}
-	for i := range this.Results {
-		if !this.Results[i].Equal(that1.Results[i]) {
-			return false
-		}
+	return ""
+}
+
+func (m *TimeSeriesChunk) GetUserId() string {
+	if m != nil {
+		return m.UserId
 	}
-	return true
+	return ""
 }
-func (this *QueryRequest) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *TimeSeriesChunk) GetChunks() []Chunk {
+	if m != nil {
+		return m.Chunks
 	}
+	return nil
+}
 
-	that1, ok := that.(*QueryRequest)
-	if !ok {
-		that2, ok := that.(QueryRequest)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+type Chunk struct {
+	StartTimestampMs int64  `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
+	EndTimestampMs   int64  `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
+	Encoding         int32  `protobuf:"varint,3,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	Data             []byte `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *Chunk) Reset()      { *m = Chunk{} }
+func (*Chunk) ProtoMessage() {}
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{32}
+}
+func (m *Chunk) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Chunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Chunk.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if this.StartTimestampMs != that1.StartTimestampMs {
-		return false
-	}
-	if this.EndTimestampMs != that1.EndTimestampMs {
-		return false
-	}
-	if len(this.Matchers) != len(that1.Matchers) {
-		return false
-	}
-	for i := range this.Matchers {
-		if !this.Matchers[i].Equal(that1.Matchers[i]) {
-			return false
-		}
+}
+func (m *Chunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Chunk.Merge(m, src)
+}
+func (m *Chunk) XXX_Size() int {
+	return m.Size()
+}
+func (m *Chunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_Chunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Chunk proto.InternalMessageInfo
+
+func (m *Chunk) GetStartTimestampMs() int64 {
+	if m != nil {
+		return m.StartTimestampMs
 	}
-	return true
+	return 0
 }
-func (this *ExemplarQueryRequest) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *Chunk) GetEndTimestampMs() int64 {
+	if m != nil {
+		return m.EndTimestampMs
 	}
+	return 0
+}
 
-	that1, ok := that.(*ExemplarQueryRequest)
-	if !ok {
-		that2, ok := that.(ExemplarQueryRequest)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
-		}
-	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if this.StartTimestampMs != that1.StartTimestampMs {
-		return false
-	}
-	if this.EndTimestampMs != that1.EndTimestampMs {
-		return false
+func (m *Chunk) GetEncoding() int32 {
+	if m != nil {
+		return m.Encoding
 	}
-	if len(this.Matchers) != len(that1.Matchers) {
-		return false
+	return 0
+}
+
+func (m *Chunk) GetData() []byte {
+	if m != nil {
+		return m.Data
 	}
-	for i := range this.Matchers {
-		if !this.Matchers[i].Equal(that1.Matchers[i]) {
-			return false
+	return nil
+}
+
+type LabelMatchers struct {
+	Matchers []*LabelMatcher `protobuf:"bytes,1,rep,name=matchers,proto3" json:"matchers,omitempty"`
+}
+
+func (m *LabelMatchers) Reset()      { *m = LabelMatchers{} }
+func (*LabelMatchers) ProtoMessage() {}
+func (*LabelMatchers) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{33}
+}
+func (m *LabelMatchers) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelMatchers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelMatchers.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	return true
 }
-func (this *QueryResponse) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+func (m *LabelMatchers) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelMatchers.Merge(m, src)
+}
+func (m *LabelMatchers) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelMatchers) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelMatchers.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelMatchers proto.InternalMessageInfo
+
+func (m *LabelMatchers) GetMatchers() []*LabelMatcher {
+	if m != nil {
+		return m.Matchers
 	}
+	return nil
+}
 
-	that1, ok := that.(*QueryResponse)
-	if !ok {
-		that2, ok := that.(QueryResponse)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+type LabelMatcher struct {
+	Type  MatchType `protobuf:"varint,1,opt,name=type,proto3,enum=cortex.MatchType" json:"type,omitempty"`
+	Name  string    `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Value string    `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *LabelMatcher) Reset()      { *m = LabelMatcher{} }
+func (*LabelMatcher) ProtoMessage() {}
+func (*LabelMatcher) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{34}
+}
+func (m *LabelMatcher) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelMatcher) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelMatcher.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if len(this.Timeseries) != len(that1.Timeseries) {
-		return false
+}
+func (m *LabelMatcher) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelMatcher.Merge(m, src)
+}
+func (m *LabelMatcher) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelMatcher) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelMatcher.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelMatcher proto.InternalMessageInfo
+
+func (m *LabelMatcher) GetType() MatchType {
+	if m != nil {
+		return m.Type
 	}
-	for i := range this.Timeseries {
-		if !this.Timeseries[i].Equal(&that1.Timeseries[i]) {
-			return false
-		}
+	return EQUAL
+}
+
+func (m *LabelMatcher) GetName() string {
+	if m != nil {
+		return m.Name
 	}
-	return true
+	return ""
 }
-func (this *QueryStreamResponse) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *LabelMatcher) GetValue() string {
+	if m != nil {
+		return m.Value
 	}
+	return ""
+}
 
-	that1, ok := that.(*QueryStreamResponse)
-	if !ok {
-		that2, ok := that.(QueryStreamResponse)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+type TimeSeriesFile struct {
+	FromIngesterId string `protobuf:"bytes,1,opt,name=from_ingester_id,json=fromIngesterId,proto3" json:"from_ingester_id,omitempty"`
+	UserId         string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Filename       string `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`
+	Data           []byte `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *TimeSeriesFile) Reset()      { *m = TimeSeriesFile{} }
+func (*TimeSeriesFile) ProtoMessage() {}
+func (*TimeSeriesFile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{35}
+}
+func (m *TimeSeriesFile) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TimeSeriesFile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TimeSeriesFile.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if len(this.Chunkseries) != len(that1.Chunkseries) {
-		return false
-	}
-	for i := range this.Chunkseries {
-		if !this.Chunkseries[i].Equal(&that1.Chunkseries[i]) {
-			return false
-		}
+}
+func (m *TimeSeriesFile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TimeSeriesFile.Merge(m, src)
+}
+func (m *TimeSeriesFile) XXX_Size() int {
+	return m.Size()
+}
+func (m *TimeSeriesFile) XXX_DiscardUnknown() {
+	xxx_messageInfo_TimeSeriesFile.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TimeSeriesFile proto.InternalMessageInfo
+
+func (m *TimeSeriesFile) GetFromIngesterId() string {
+	if m != nil {
+		return m.FromIngesterId
 	}
-	if len(this.Timeseries) != len(that1.Timeseries) {
-		return false
+	return ""
+}
+
+func (m *TimeSeriesFile) GetUserId() string {
+	if m != nil {
+		return m.UserId
 	}
-	for i := range this.Timeseries {
-		if !this.Timeseries[i].Equal(&that1.Timeseries[i]) {
-			return false
-		}
+	return ""
+}
+
+func (m *TimeSeriesFile) GetFilename() string {
+	if m != nil {
+		return m.Filename
 	}
-	return true
+	return ""
 }
-func (this *ExemplarQueryResponse) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *TimeSeriesFile) GetData() []byte {
+	if m != nil {
+		return m.Data
 	}
+	return nil
+}
 
-	that1, ok := that.(*ExemplarQueryResponse)
-	if !ok {
-		that2, ok := that.(ExemplarQueryResponse)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+type TransferTSDBResponse struct {
+}
+
+func (m *TransferTSDBResponse) Reset()      { *m = TransferTSDBResponse{} }
+func (*TransferTSDBResponse) ProtoMessage() {}
+func (*TransferTSDBResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{36}
+}
+func (m *TransferTSDBResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TransferTSDBResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TransferTSDBResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if len(this.Timeseries) != len(that1.Timeseries) {
-		return false
+}
+func (m *TransferTSDBResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferTSDBResponse.Merge(m, src)
+}
+func (m *TransferTSDBResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *TransferTSDBResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferTSDBResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferTSDBResponse proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterEnum("cortex.ScaleDownAction", ScaleDownAction_name, ScaleDownAction_value)
+	proto.RegisterEnum("cortex.MatchType", MatchType_name, MatchType_value)
+	proto.RegisterType((*ReadRequest)(nil), "cortex.ReadRequest")
+	proto.RegisterType((*ReadResponse)(nil), "cortex.ReadResponse")
+	proto.RegisterType((*QueryRequest)(nil), "cortex.QueryRequest")
+	proto.RegisterType((*ExemplarQueryRequest)(nil), "cortex.ExemplarQueryRequest")
+	proto.RegisterType((*QueryResponse)(nil), "cortex.QueryResponse")
+	proto.RegisterType((*QueryStreamResponse)(nil), "cortex.QueryStreamResponse")
+	proto.RegisterType((*ExemplarQueryResponse)(nil), "cortex.ExemplarQueryResponse")
+	proto.RegisterType((*LabelValuesRequest)(nil), "cortex.LabelValuesRequest")
+	proto.RegisterType((*LabelValuesResponse)(nil), "cortex.LabelValuesResponse")
+	proto.RegisterType((*LabelValuesStreamResponse)(nil), "cortex.LabelValuesStreamResponse")
+	proto.RegisterType((*LabelNamesRequest)(nil), "cortex.LabelNamesRequest")
+	proto.RegisterType((*LabelNamesResponse)(nil), "cortex.LabelNamesResponse")
+	proto.RegisterType((*LabelNamesStreamResponse)(nil), "cortex.LabelNamesStreamResponse")
+	proto.RegisterType((*UserStatsRequest)(nil), "cortex.UserStatsRequest")
+	proto.RegisterType((*UserStatsResponse)(nil), "cortex.UserStatsResponse")
+	proto.RegisterType((*UserIDStatsResponse)(nil), "cortex.UserIDStatsResponse")
+	proto.RegisterType((*UsersStatsResponse)(nil), "cortex.UsersStatsResponse")
+	proto.RegisterType((*MetricsForLabelMatchersRequest)(nil), "cortex.MetricsForLabelMatchersRequest")
+	proto.RegisterType((*MetricsForLabelMatchersResponse)(nil), "cortex.MetricsForLabelMatchersResponse")
+	proto.RegisterType((*MetricsForLabelMatchersStreamResponse)(nil), "cortex.MetricsForLabelMatchersStreamResponse")
+	proto.RegisterType((*MetricsMetadataRequest)(nil), "cortex.MetricsMetadataRequest")
+	proto.RegisterType((*MetricsMetadataResponse)(nil), "cortex.MetricsMetadataResponse")
+	proto.RegisterType((*TSDBStatusRequest)(nil), "cortex.TSDBStatusRequest")
+	proto.RegisterType((*TSDBStatusResponse)(nil), "cortex.TSDBStatusResponse")
+	proto.RegisterType((*TSDBStatistic)(nil), "cortex.TSDBStatistic")
+	proto.RegisterType((*ScaleDownRequest)(nil), "cortex.ScaleDownRequest")
+	proto.RegisterType((*ScaleDownResponse)(nil), "cortex.ScaleDownResponse")
+	proto.RegisterType((*LabelValuesSketchesRequest)(nil), "cortex.LabelValuesSketchesRequest")
+	proto.RegisterType((*UserLabelName)(nil), "cortex.UserLabelName")
+	proto.RegisterType((*LabelValuesSketchesResponse)(nil), "cortex.LabelValuesSketchesResponse")
+	proto.RegisterType((*LabelValuesSketch)(nil), "cortex.LabelValuesSketch")
+	proto.RegisterType((*TimeSeriesChunk)(nil), "cortex.TimeSeriesChunk")
+	proto.RegisterType((*Chunk)(nil), "cortex.Chunk")
+	proto.RegisterType((*LabelMatchers)(nil), "cortex.LabelMatchers")
+	proto.RegisterType((*LabelMatcher)(nil), "cortex.LabelMatcher")
+	proto.RegisterType((*TimeSeriesFile)(nil), "cortex.TimeSeriesFile")
+	proto.RegisterType((*TransferTSDBResponse)(nil), "cortex.TransferTSDBResponse")
+}
+
+func init() { proto.RegisterFile("ingester.proto", fileDescriptor_60f6df4f3586b478) }
+
+var fileDescriptor_60f6df4f3586b478 = []byte{
+	// 1943 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x18, 0x49, 0x6f, 0x1b, 0xd7,
+	0x99, 0x23, 0x2e, 0x22, 0x3f, 0x92, 0x12, 0xf9, 0x24, 0x4b, 0xd4, 0x28, 0xa6, 0xe4, 0x17, 0x38,
+	0x55, 0xd2, 0x44, 0x72, 0xdc, 0x05, 0x71, 0xd3, 0x36, 0xa0, 0x24, 0xda, 0x56, 0xad, 0xc5, 0x1e,
+	0xd2, 0x4e, 0x1b, 0xb4, 0x98, 0x0c, 0xc9, 0x67, 0x71, 0xea, 0x59, 0x98, 0x99, 0xa1, 0x6b, 0xf6,
+	0x54, 0xa0, 0x40, 0xaf, 0xed, 0x5f, 0xe8, 0xad, 0xb7, 0xa2, 0xfd, 0x01, 0x3d, 0xf5, 0x90, 0xa3,
+	0x2f, 0x05, 0x82, 0xa2, 0x08, 0x6a, 0xf9, 0xd2, 0x63, 0xfa, 0x0f, 0x8a, 0xb7, 0xcc, 0xca, 0xa1,
+	0x24, 0x17, 0x71, 0x6e, 0xf3, 0xbe, 0x7d, 0x7b, 0xdf, 0xf7, 0xbe, 0x81, 0x05, 0xdd, 0x3a, 0x25,
+	0xae, 0x47, 0x9c, 0xed, 0x91, 0x63, 0x7b, 0x36, 0x2a, 0xf4, 0x6d, 0xc7, 0x23, 0xcf, 0xe4, 0xe5,
+	0x53, 0xfb, 0xd4, 0x66, 0xa0, 0x1d, 0xfa, 0xc5, 0xb1, 0xf2, 0xad, 0x53, 0xdd, 0x1b, 0x8e, 0x7b,
+	0xdb, 0x7d, 0xdb, 0xdc, 0xe1, 0x84, 0x23, 0xc7, 0xfe, 0x25, 0xe9, 0x7b, 0xe2, 0xb4, 0x33, 0x7a,
+	0x72, 0xea, 0x23, 0x7a, 0xe2, 0x83, 0xb3, 0xe2, 0x1f, 0x41, 0x59, 0x21, 0xda, 0x40, 0x21, 0x9f,
+	0x8d, 0x89, 0xeb, 0xa1, 0x6d, 0x98, 0xff, 0x6c, 0x4c, 0x1c, 0x9d, 0xb8, 0x0d, 0x69, 0x33, 0xbb,
+	0x55, 0xbe, 0xb9, 0xbc, 0x2d, 0xc8, 0x1f, 0x8c, 0x89, 0x33, 0x11, 0x64, 0x8a, 0x4f, 0x84, 0x3f,
+	0x82, 0x0a, 0x67, 0x77, 0x47, 0xb6, 0xe5, 0x12, 0xb4, 0x03, 0xf3, 0x0e, 0x71, 0xc7, 0x86, 0xe7,
+	0xf3, 0x5f, 0x49, 0xf0, 0x73, 0x3a, 0xc5, 0xa7, 0xc2, 0xff, 0x92, 0xa0, 0x12, 0x15, 0x8d, 0xde,
+	0x05, 0xe4, 0x7a, 0x9a, 0xe3, 0xa9, 0x9e, 0x6e, 0x12, 0xd7, 0xd3, 0xcc, 0x91, 0x6a, 0x52, 0x61,
+	0xd2, 0x56, 0x56, 0xa9, 0x31, 0x4c, 0xd7, 0x47, 0x1c, 0xb9, 0x68, 0x0b, 0x6a, 0xc4, 0x1a, 0xc4,
+	0x69, 0xe7, 0x18, 0xed, 0x02, 0xb1, 0x06, 0x51, 0xca, 0x1b, 0x50, 0x34, 0x35, 0xaf, 0x3f, 0x24,
+	0x8e, 0xdb, 0xc8, 0xc6, 0x5d, 0x3b, 0xd4, 0x7a, 0xc4, 0x38, 0xe2, 0x48, 0x25, 0xa0, 0x42, 0x1b,
+	0x50, 0x76, 0x6d, 0xc7, 0x53, 0x5d, 0x1e, 0x8f, 0xdc, 0xa6, 0xb4, 0x55, 0x54, 0x80, 0x82, 0x3a,
+	0x0c, 0x82, 0xae, 0x41, 0x85, 0xe3, 0x54, 0x43, 0x37, 0x75, 0xaf, 0x91, 0x67, 0x8a, 0xcb, 0x1c,
+	0x76, 0x48, 0x41, 0xf8, 0x8f, 0x12, 0x2c, 0xb7, 0x9f, 0x11, 0x73, 0x64, 0x68, 0xce, 0x37, 0xe2,
+	0xe6, 0xfb, 0x53, 0x6e, 0x5e, 0x49, 0x73, 0xd3, 0x0d, 0xfd, 0xc4, 0xf7, 0xa0, 0x1a, 0x4b, 0x0e,
+	0xfa, 0x01, 0x00, 0xd3, 0x94, 0x56, 0x07, 0xa3, 0xde, 0x36, 0x55, 0xc7, 0x23, 0xb0, 0x9b, 0xfb,
+	0xfc, 0xcb, 0x8d, 0x8c, 0x12, 0xa1, 0xc6, 0x7f, 0x97, 0x60, 0x89, 0x49, 0xeb, 0x78, 0x0e, 0xd1,
+	0xcc, 0x40, 0xe6, 0x47, 0x50, 0xee, 0x0f, 0xc7, 0xd6, 0x93, 0x98, 0xd0, 0x55, 0xdf, 0xb4, 0x50,
+	0xe4, 0x1e, 0x25, 0x12, 0x72, 0xa3, 0x1c, 0x09, 0xa3, 0xe6, 0x5e, 0xc5, 0x28, 0x74, 0x03, 0x96,
+	0xa3, 0x89, 0x52, 0x1d, 0xa2, 0xf5, 0x87, 0x64, 0xd0, 0xc8, 0xb2, 0x94, 0xa2, 0x48, 0xc2, 0x14,
+	0x8e, 0xc1, 0x1d, 0xb8, 0x92, 0x48, 0xdb, 0xd7, 0x10, 0x9b, 0xbf, 0x49, 0x80, 0x58, 0x12, 0x1e,
+	0x69, 0xc6, 0x98, 0xb8, 0x7e, 0x29, 0x5c, 0x05, 0x30, 0x28, 0x54, 0xb5, 0x34, 0x93, 0xb0, 0x12,
+	0x28, 0x29, 0x25, 0x06, 0x39, 0xd6, 0x4c, 0x32, 0xa3, 0x52, 0xe6, 0x5e, 0xa1, 0x52, 0xb2, 0x17,
+	0x56, 0x0a, 0xad, 0xed, 0x4b, 0x54, 0xca, 0x07, 0xb0, 0x14, 0xb3, 0x5f, 0xc4, 0xe4, 0x1a, 0x54,
+	0xb8, 0x03, 0x4f, 0x19, 0x9c, 0x45, 0xa5, 0xa4, 0x94, 0x8d, 0x90, 0x14, 0xff, 0x18, 0xd6, 0x22,
+	0x9c, 0x89, 0xda, 0xb8, 0x04, 0xff, 0x13, 0xa8, 0x1f, 0xfa, 0x11, 0x71, 0x5f, 0xf3, 0x1d, 0xc2,
+	0xdf, 0x03, 0x14, 0x55, 0x26, 0xac, 0xdc, 0x80, 0x72, 0x98, 0x26, 0xdf, 0x48, 0x08, 0xf2, 0xe4,
+	0xe2, 0x0f, 0xa1, 0x11, 0xb2, 0x25, 0x5c, 0xbc, 0x90, 0x19, 0x41, 0xed, 0xa1, 0x4b, 0x9c, 0x8e,
+	0xa7, 0x79, 0xbe, 0x7f, 0xb4, 0x37, 0xd6, 0x23, 0x40, 0x21, 0xea, 0xba, 0x3f, 0x1c, 0x74, 0xdb,
+	0x52, 0x1d, 0xcd, 0xe3, 0x25, 0x23, 0x29, 0xd5, 0x00, 0xaa, 0x68, 0x1e, 0xa1, 0x55, 0x65, 0x8d,
+	0x4d, 0x35, 0xb8, 0x2f, 0xd2, 0x56, 0x4e, 0x29, 0x59, 0x63, 0x53, 0xf4, 0xae, 0x77, 0x01, 0x69,
+	0x23, 0x5d, 0x4d, 0x48, 0xca, 0x32, 0x49, 0x35, 0x6d, 0xa4, 0x1f, 0xc4, 0x84, 0x6d, 0xc3, 0x92,
+	0x33, 0x36, 0x48, 0x92, 0x3c, 0xc7, 0xc8, 0xeb, 0x14, 0x15, 0xa7, 0x7f, 0x13, 0xaa, 0x5a, 0xdf,
+	0xd3, 0x9f, 0x12, 0x5f, 0x7f, 0x9e, 0xe9, 0xaf, 0x70, 0x20, 0x37, 0x01, 0xff, 0x02, 0x96, 0xa8,
+	0x77, 0x07, 0xfb, 0x71, 0xff, 0x56, 0x61, 0x7e, 0xec, 0x12, 0x47, 0xd5, 0x07, 0xe2, 0x2e, 0x14,
+	0xe8, 0xf1, 0x60, 0x80, 0xde, 0x83, 0xdc, 0x40, 0xf3, 0x34, 0xe6, 0x4b, 0xf9, 0xe6, 0x9a, 0x5f,
+	0xac, 0x53, 0x11, 0x52, 0x18, 0x19, 0xbe, 0x03, 0x88, 0xa2, 0xdc, 0xb8, 0xf4, 0xf7, 0x21, 0xef,
+	0x52, 0x80, 0xb8, 0xba, 0xeb, 0x51, 0x29, 0x09, 0x4b, 0x14, 0x4e, 0x89, 0xff, 0x2a, 0x41, 0xf3,
+	0x88, 0x78, 0x8e, 0xde, 0x77, 0x6f, 0xdb, 0x4e, 0xfc, 0x6e, 0xbc, 0xe6, 0x6e, 0xfe, 0x01, 0x54,
+	0xfc, 0xcb, 0xa7, 0xba, 0xc4, 0x3b, 0xbf, 0xa3, 0x97, 0x7d, 0xd2, 0x0e, 0xf1, 0xf0, 0x3d, 0xd8,
+	0x98, 0x69, 0xb3, 0x08, 0xc5, 0x16, 0x14, 0x4c, 0x46, 0x22, 0x62, 0x51, 0x0b, 0xdb, 0x18, 0x67,
+	0x55, 0x04, 0x1e, 0x3f, 0x80, 0xeb, 0x33, 0x84, 0x25, 0xca, 0xfc, 0xf2, 0x22, 0x1b, 0xb0, 0x22,
+	0x44, 0x1e, 0x11, 0x4f, 0xa3, 0x09, 0xf3, 0xab, 0xfe, 0x04, 0x56, 0xa7, 0x30, 0x42, 0xfc, 0x77,
+	0xa1, 0x68, 0x0a, 0x98, 0x50, 0xd0, 0x48, 0x2a, 0x08, 0x78, 0x02, 0x4a, 0xfc, 0x36, 0xd4, 0xbb,
+	0x9d, 0xfd, 0x5d, 0x9a, 0xdb, 0x71, 0x90, 0xb1, 0x65, 0xc8, 0xf3, 0xa1, 0x4d, 0x93, 0x94, 0x57,
+	0xf8, 0x01, 0xff, 0x25, 0x07, 0x28, 0x4a, 0x2b, 0xf4, 0xc6, 0xef, 0x92, 0x94, 0xbc, 0x4b, 0x1b,
+	0x62, 0xb6, 0xa9, 0x7d, 0x7b, 0x6c, 0x79, 0xe2, 0xae, 0x01, 0x03, 0xed, 0x51, 0x08, 0x5a, 0x83,
+	0xa2, 0xa9, 0x5b, 0x2c, 0xe1, 0xa2, 0x19, 0xcf, 0x9b, 0xba, 0x45, 0x13, 0xcd, 0x50, 0xda, 0x33,
+	0x8e, 0xca, 0x09, 0x94, 0xf6, 0x8c, 0xa1, 0xde, 0x82, 0x45, 0xaa, 0x95, 0xf7, 0x8d, 0x91, 0xa6,
+	0x3b, 0xae, 0x78, 0x61, 0x54, 0xad, 0xb1, 0xc9, 0xb2, 0x70, 0x9f, 0x02, 0xd1, 0xcf, 0x60, 0x5d,
+	0x4c, 0x37, 0xa6, 0x5f, 0xed, 0x4d, 0x54, 0x1e, 0x64, 0x3e, 0x50, 0x0a, 0xf1, 0x9a, 0xf1, 0xdd,
+	0xd3, 0x5d, 0x4f, 0xef, 0x8b, 0x21, 0xb5, 0xca, 0xf9, 0x99, 0xb1, 0xbb, 0x13, 0x1e, 0x48, 0x36,
+	0x7b, 0x3e, 0x85, 0x8d, 0x48, 0x67, 0x0e, 0xe5, 0x47, 0xe6, 0xd5, 0xfc, 0xc5, 0xe2, 0xe5, 0xb0,
+	0x93, 0x0b, 0x15, 0x41, 0x9f, 0x44, 0x3f, 0x87, 0xab, 0x26, 0x31, 0x6d, 0x67, 0xa2, 0xea, 0x96,
+	0xda, 0x9b, 0x78, 0xc4, 0x4d, 0xc8, 0x2f, 0x5e, 0x2c, 0xbf, 0xc1, 0x25, 0x1c, 0x58, 0xbb, 0x94,
+	0x3f, 0x2a, 0xbd, 0x07, 0x9b, 0xc9, 0xd0, 0x44, 0xfd, 0xa1, 0x41, 0x6d, 0x94, 0x2e, 0x56, 0xb0,
+	0x1e, 0x8b, 0x4f, 0x38, 0xc8, 0x68, 0xfc, 0xf1, 0x2d, 0xa8, 0xc6, 0x78, 0x10, 0x82, 0x5c, 0x64,
+	0x92, 0xb3, 0x6f, 0x5a, 0x6e, 0x4c, 0xa5, 0x28, 0x0e, 0x7e, 0xc0, 0x7b, 0x50, 0xeb, 0xf4, 0x35,
+	0x83, 0xec, 0xdb, 0xbf, 0xb2, 0xfc, 0xc2, 0xdc, 0x81, 0x02, 0xed, 0x92, 0xb6, 0xc5, 0xf8, 0x17,
+	0xc2, 0x37, 0x52, 0x40, 0xd9, 0x62, 0x68, 0x45, 0x90, 0xe1, 0x3f, 0x4b, 0x50, 0x8f, 0x48, 0x11,
+	0x25, 0xbb, 0x0e, 0x25, 0x87, 0x68, 0x03, 0xd5, 0xb6, 0x8c, 0x09, 0x93, 0x54, 0x54, 0x8a, 0x14,
+	0x70, 0x62, 0x19, 0x13, 0xd4, 0x80, 0x79, 0x77, 0xa8, 0x8f, 0x46, 0x64, 0xc0, 0xec, 0x29, 0x2a,
+	0xfe, 0x11, 0xbd, 0x0d, 0x35, 0xdd, 0x7a, 0x6c, 0xe8, 0xa7, 0x43, 0x4f, 0xf5, 0x17, 0x01, 0x5e,
+	0xb1, 0x8b, 0x3e, 0xfc, 0x01, 0x07, 0xa3, 0x37, 0xa8, 0x06, 0xd3, 0x7e, 0xaa, 0xf5, 0x0c, 0x22,
+	0x1e, 0xc7, 0x21, 0x00, 0xc9, 0x50, 0x64, 0x07, 0xdd, 0x3a, 0x6d, 0xe4, 0x7d, 0xf5, 0xfc, 0x8c,
+	0x75, 0x90, 0xa3, 0x8f, 0x81, 0x27, 0x84, 0xf6, 0x92, 0xe0, 0x66, 0xde, 0x83, 0x25, 0x9e, 0x9d,
+	0xa1, 0xe6, 0x0e, 0xe9, 0x93, 0x8d, 0x92, 0x4e, 0xad, 0x13, 0xb4, 0x5f, 0x07, 0x79, 0x16, 0x69,
+	0xaa, 0x33, 0xbe, 0xbb, 0x8c, 0x8d, 0xe1, 0x5c, 0x7c, 0x07, 0xaa, 0x31, 0xca, 0xd9, 0xd3, 0x25,
+	0xfe, 0x0a, 0x9b, 0x4b, 0xbc, 0xc2, 0xf0, 0x27, 0xb0, 0x9e, 0x6a, 0xb3, 0x08, 0xf7, 0x87, 0x50,
+	0x74, 0x05, 0x4c, 0x58, 0xba, 0x16, 0x6b, 0xd2, 0x51, 0x36, 0x61, 0x6d, 0xc0, 0x80, 0x7f, 0x27,
+	0x41, 0x7d, 0x8a, 0xea, 0xff, 0xb5, 0x14, 0xad, 0x40, 0x81, 0x4b, 0x66, 0x89, 0xab, 0x28, 0xe2,
+	0x44, 0x5f, 0x59, 0xd1, 0xb8, 0x36, 0x72, 0x9b, 0xd9, 0xad, 0x9c, 0x52, 0x8e, 0xc4, 0x0c, 0xff,
+	0x57, 0x82, 0xc5, 0xc4, 0x53, 0x9c, 0x0e, 0xab, 0xc7, 0x8e, 0x6d, 0xaa, 0xfe, 0x42, 0x1a, 0xda,
+	0xb3, 0x40, 0xe1, 0x07, 0x02, 0x7c, 0x30, 0x88, 0x1a, 0x3c, 0x17, 0x33, 0xd8, 0x82, 0x82, 0x48,
+	0x22, 0x9f, 0x5f, 0x4b, 0x61, 0xd3, 0x0e, 0xda, 0xd8, 0x6e, 0x8b, 0x06, 0xe5, 0x9f, 0x5f, 0x6e,
+	0xbc, 0xd2, 0x2e, 0xcb, 0xf9, 0x5b, 0x03, 0x6d, 0xe4, 0x11, 0x47, 0x11, 0x5a, 0xd0, 0xb7, 0xa1,
+	0xc0, 0x37, 0x07, 0xe6, 0x63, 0xf9, 0x66, 0xd5, 0x4f, 0x45, 0x74, 0xb9, 0x10, 0x24, 0xf8, 0xf7,
+	0x12, 0xe4, 0xb9, 0xa7, 0xaf, 0x6b, 0x88, 0xcb, 0x50, 0x24, 0x56, 0xdf, 0x1e, 0xd0, 0xab, 0x90,
+	0x65, 0xd3, 0x26, 0x38, 0xd3, 0x5e, 0xc1, 0xa6, 0x59, 0x8e, 0xa5, 0x8a, 0x7d, 0xe3, 0x16, 0x54,
+	0x63, 0x33, 0x36, 0xb6, 0xba, 0x4a, 0x97, 0x59, 0x5d, 0xb1, 0x0a, 0x95, 0x28, 0x06, 0x5d, 0x87,
+	0x9c, 0x37, 0x19, 0x11, 0xd1, 0x52, 0xea, 0x3e, 0x37, 0x43, 0x77, 0x27, 0x23, 0xa2, 0x30, 0x74,
+	0xd0, 0xb9, 0xe6, 0xd2, 0x3a, 0x57, 0x96, 0x01, 0xf9, 0x01, 0xff, 0x56, 0x82, 0x85, 0xb0, 0x52,
+	0x6e, 0xeb, 0x06, 0xf9, 0x3a, 0x0a, 0x45, 0x86, 0xe2, 0x63, 0xdd, 0x20, 0xcc, 0x06, 0xae, 0x2e,
+	0x38, 0xa7, 0x46, 0x6a, 0x05, 0x96, 0xbb, 0x8e, 0x66, 0xb9, 0x8f, 0x89, 0x43, 0x5b, 0xb0, 0x7f,
+	0x1b, 0xdf, 0xb1, 0x60, 0x31, 0xd1, 0x2d, 0xd1, 0x15, 0xa8, 0x77, 0xf6, 0x5a, 0x87, 0x6d, 0x75,
+	0xff, 0xe4, 0xe3, 0x63, 0xb5, 0xd3, 0x6d, 0x75, 0x1f, 0x76, 0x6a, 0x19, 0xb4, 0x02, 0x28, 0x02,
+	0xbe, 0xaf, 0xb4, 0xef, 0xb7, 0x94, 0x76, 0x4d, 0x4a, 0x90, 0xef, 0xb5, 0x8e, 0xf7, 0xda, 0x87,
+	0xb5, 0xb9, 0x04, 0x58, 0x69, 0x1f, 0x9d, 0x3c, 0x6a, 0xd7, 0xb2, 0xef, 0xfc, 0x04, 0x4a, 0x41,
+	0x28, 0x51, 0x09, 0xf2, 0xed, 0x07, 0x0f, 0x5b, 0x87, 0xb5, 0x0c, 0xaa, 0x42, 0xe9, 0xf8, 0xa4,
+	0xab, 0xf2, 0xa3, 0x84, 0x16, 0xa1, 0xac, 0xb4, 0xef, 0xb4, 0x7f, 0xaa, 0x1e, 0xb5, 0xba, 0x7b,
+	0x77, 0x6b, 0x73, 0x08, 0xc1, 0x02, 0x07, 0x1c, 0x9f, 0x08, 0x58, 0xf6, 0xe6, 0x3f, 0x00, 0x8a,
+	0x7e, 0xac, 0xd0, 0x2d, 0xc8, 0xdd, 0x1f, 0xbb, 0x43, 0xb4, 0x12, 0xde, 0x98, 0x8f, 0x1d, 0xdd,
+	0x23, 0xa2, 0x57, 0xca, 0xab, 0x53, 0x70, 0x1e, 0x01, 0x9c, 0x41, 0xdf, 0x87, 0x3c, 0xdb, 0x5c,
+	0x51, 0xea, 0x1f, 0x1c, 0x39, 0xfd, 0xbf, 0x0c, 0xce, 0xa0, 0x7d, 0x28, 0x47, 0xf6, 0xf7, 0x19,
+	0xdc, 0xeb, 0x31, 0x68, 0xfc, 0x11, 0x88, 0x33, 0x37, 0x24, 0x74, 0x02, 0x0b, 0x0c, 0xe5, 0x2f,
+	0xd1, 0x2e, 0x7a, 0xc3, 0x67, 0x49, 0xfb, 0x1d, 0x22, 0x5f, 0x9d, 0x81, 0x0d, 0xcc, 0xba, 0x0b,
+	0xe5, 0x48, 0x8b, 0x44, 0x72, 0x4a, 0x77, 0x9d, 0x32, 0x2e, 0x65, 0x57, 0xc5, 0x19, 0xf4, 0x28,
+	0xde, 0x6c, 0xb9, 0x9b, 0xe7, 0xc9, 0xbb, 0x96, 0x82, 0x4b, 0x71, 0xb9, 0x0d, 0x10, 0xae, 0x7f,
+	0x28, 0xde, 0xfe, 0xa3, 0x6b, 0xab, 0x2c, 0xa7, 0xa1, 0x02, 0xf3, 0x3a, 0x50, 0x4b, 0x6e, 0x91,
+	0xe7, 0x09, 0xdb, 0x9c, 0x46, 0xa5, 0xd8, 0xb6, 0x0b, 0xa5, 0x60, 0x4d, 0x42, 0x8d, 0x94, 0xcd,
+	0x89, 0x0b, 0x9b, 0xbd, 0x53, 0xe1, 0x0c, 0xba, 0x0d, 0x95, 0x96, 0x61, 0x5c, 0x46, 0x8c, 0x1c,
+	0xc5, 0xb8, 0x49, 0x39, 0x06, 0xac, 0xce, 0x58, 0x26, 0xd0, 0x5b, 0x41, 0x63, 0x3a, 0x77, 0xdd,
+	0x92, 0xbf, 0x75, 0x21, 0x5d, 0xa0, 0xed, 0xd7, 0x70, 0xf5, 0xdc, 0xd5, 0xe5, 0xd2, 0x3a, 0xdf,
+	0xbb, 0x80, 0x2e, 0x25, 0xea, 0x5d, 0x58, 0x4c, 0x6c, 0x32, 0xa8, 0x99, 0x90, 0x92, 0x58, 0x7e,
+	0xe4, 0x8d, 0x99, 0xf8, 0xc0, 0xa3, 0x36, 0x40, 0xb8, 0xa2, 0x84, 0xa5, 0x31, 0xb5, 0xe2, 0xc8,
+	0x72, 0x1a, 0x2a, 0x10, 0xb3, 0x0b, 0xa5, 0xa0, 0x47, 0x86, 0xb9, 0x4c, 0x3e, 0x47, 0xe5, 0xb5,
+	0x14, 0x4c, 0x20, 0xe3, 0x53, 0x58, 0x9a, 0x7a, 0xb7, 0x10, 0x17, 0xe1, 0x99, 0x4f, 0x9f, 0xb0,
+	0x6e, 0xdf, 0x3c, 0x97, 0x26, 0x72, 0xed, 0x2b, 0xd1, 0x0e, 0x1f, 0x34, 0xc2, 0xed, 0xf8, 0xf0,
+	0x91, 0x83, 0xee, 0x92, 0x36, 0x0f, 0x70, 0x66, 0x4b, 0xda, 0xfd, 0xe1, 0xf3, 0x17, 0xcd, 0xcc,
+	0x17, 0x2f, 0x9a, 0x99, 0xaf, 0x5e, 0x34, 0xa5, 0xdf, 0x9c, 0x35, 0xa5, 0x3f, 0x9d, 0x35, 0xa5,
+	0xcf, 0xcf, 0x9a, 0xd2, 0xf3, 0xb3, 0xa6, 0xf4, 0xef, 0xb3, 0xa6, 0xf4, 0x9f, 0xb3, 0x66, 0xe6,
+	0xab, 0xb3, 0xa6, 0xf4, 0x87, 0x97, 0xcd, 0xcc, 0xf3, 0x97, 0xcd, 0xcc, 0x17, 0x2f, 0x9b, 0x99,
+	0x4f, 0x0a, 0x7d, 0x43, 0x27, 0x96, 0xd7, 0x2b, 0xb0, 0xbf, 0xe5, 0xdf, 0xf9, 0xdf, 0x00, 0x46,
+	0x29, 0xfe, 0x0a, 0x98, 0x17, 0x00, 0x00,
+}
+
+func (x ScaleDownAction) String() string {
+	s, ok := ScaleDownAction_name[int32(x)]
+	if ok {
+		return s
 	}
-	for i := range this.Timeseries {
-		if !this.Timeseries[i].Equal(&that1.Timeseries[i]) {
-			return false
-		}
+	return strconv.Itoa(int(x))
+}
+func (x MatchType) String() string {
+	s, ok := MatchType_name[int32(x)]
+	if ok {
+		return s
 	}
-	return true
+	return strconv.Itoa(int(x))
 }
-func (this *LabelValuesRequest) Equal(that interface{}) bool {
+func (this *ReadRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelValuesRequest)
+	that1, ok := that.(*ReadRequest)
 	if !ok {
-		that2, ok := that.(LabelValuesRequest)
+		that2, ok := that.(ReadRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1786,28 +2244,24 @@ func (this *LabelValuesRequest) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.LabelName != that1.LabelName {
-		return false
-	}
-	if this.StartTimestampMs != that1.StartTimestampMs {
-		return false
-	}
-	if this.EndTimestampMs != that1.EndTimestampMs {
+	if len(this.Queries) != len(that1.Queries) {
 		return false
 	}
-	if !this.Matchers.Equal(that1.Matchers) {
-		return false
+	for i := range this.Queries {
+		if !this.Queries[i].Equal(that1.Queries[i]) {
+			return false
+		}
 	}
 	return true
 }
-func (this *LabelValuesResponse) Equal(that interface{}) bool {
+func (this *ReadResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelValuesResponse)
+	that1, ok := that.(*ReadResponse)
 	if !ok {
-		that2, ok := that.(LabelValuesResponse)
+		that2, ok := that.(ReadResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1819,24 +2273,24 @@ func (this *LabelValuesResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.LabelValues) != len(that1.LabelValues) {
+	if len(this.Results) != len(that1.Results) {
 		return false
 	}
-	for i := range this.LabelValues {
-		if this.LabelValues[i] != that1.LabelValues[i] {
+	for i := range this.Results {
+		if !this.Results[i].Equal(that1.Results[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *LabelValuesStreamResponse) Equal(that interface{}) bool {
+func (this *QueryRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelValuesStreamResponse)
+	that1, ok := that.(*QueryRequest)
 	if !ok {
-		that2, ok := that.(LabelValuesStreamResponse)
+		that2, ok := that.(QueryRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1848,24 +2302,36 @@ func (this *LabelValuesStreamResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.LabelValues) != len(that1.LabelValues) {
+	if this.StartTimestampMs != that1.StartTimestampMs {
 		return false
 	}
-	for i := range this.LabelValues {
-		if this.LabelValues[i] != that1.LabelValues[i] {
+	if this.EndTimestampMs != that1.EndTimestampMs {
+		return false
+	}
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(that1.Matchers[i]) {
 			return false
 		}
 	}
+	if this.SortSeries != that1.SortSeries {
+		return false
+	}
+	if this.SeriesLimit != that1.SeriesLimit {
+		return false
+	}
 	return true
 }
-func (this *LabelNamesRequest) Equal(that interface{}) bool {
+func (this *ExemplarQueryRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelNamesRequest)
+	that1, ok := that.(*ExemplarQueryRequest)
 	if !ok {
-		that2, ok := that.(LabelNamesRequest)
+		that2, ok := that.(ExemplarQueryRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1883,16 +2349,24 @@ func (this *LabelNamesRequest) Equal(that interface{}) bool {
 	if this.EndTimestampMs != that1.EndTimestampMs {
 		return false
 	}
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(that1.Matchers[i]) {
+			return false
+		}
+	}
 	return true
 }
-func (this *LabelNamesResponse) Equal(that interface{}) bool {
+func (this *QueryResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelNamesResponse)
+	that1, ok := that.(*QueryResponse)
 	if !ok {
-		that2, ok := that.(LabelNamesResponse)
+		that2, ok := that.(QueryResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1904,24 +2378,24 @@ func (this *LabelNamesResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.LabelNames) != len(that1.LabelNames) {
+	if len(this.Timeseries) != len(that1.Timeseries) {
 		return false
 	}
-	for i := range this.LabelNames {
-		if this.LabelNames[i] != that1.LabelNames[i] {
+	for i := range this.Timeseries {
+		if !this.Timeseries[i].Equal(&that1.Timeseries[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *LabelNamesStreamResponse) Equal(that interface{}) bool {
+func (this *QueryStreamResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelNamesStreamResponse)
+	that1, ok := that.(*QueryStreamResponse)
 	if !ok {
-		that2, ok := that.(LabelNamesStreamResponse)
+		that2, ok := that.(QueryStreamResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1933,24 +2407,35 @@ func (this *LabelNamesStreamResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.LabelNames) != len(that1.LabelNames) {
+	if len(this.Chunkseries) != len(that1.Chunkseries) {
 		return false
 	}
-	for i := range this.LabelNames {
-		if this.LabelNames[i] != that1.LabelNames[i] {
+	for i := range this.Chunkseries {
+		if !this.Chunkseries[i].Equal(&that1.Chunkseries[i]) {
+			return false
+		}
+	}
+	if len(this.Timeseries) != len(that1.Timeseries) {
+		return false
+	}
+	for i := range this.Timeseries {
+		if !this.Timeseries[i].Equal(&that1.Timeseries[i]) {
 			return false
 		}
 	}
+	if this.SeriesLimitReached != that1.SeriesLimitReached {
+		return false
+	}
 	return true
 }
-func (this *UserStatsRequest) Equal(that interface{}) bool {
+func (this *ExemplarQueryResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*UserStatsRequest)
+	that1, ok := that.(*ExemplarQueryResponse)
 	if !ok {
-		that2, ok := that.(UserStatsRequest)
+		that2, ok := that.(ExemplarQueryResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1962,16 +2447,24 @@ func (this *UserStatsRequest) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
+	if len(this.Timeseries) != len(that1.Timeseries) {
+		return false
+	}
+	for i := range this.Timeseries {
+		if !this.Timeseries[i].Equal(&that1.Timeseries[i]) {
+			return false
+		}
+	}
 	return true
 }
-func (this *UserStatsResponse) Equal(that interface{}) bool {
+func (this *LabelValuesRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*UserStatsResponse)
+	that1, ok := that.(*LabelValuesRequest)
 	if !ok {
-		that2, ok := that.(UserStatsResponse)
+		that2, ok := that.(LabelValuesRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1983,31 +2476,28 @@ func (this *UserStatsResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.IngestionRate != that1.IngestionRate {
-		return false
-	}
-	if this.NumSeries != that1.NumSeries {
+	if this.LabelName != that1.LabelName {
 		return false
 	}
-	if this.ApiIngestionRate != that1.ApiIngestionRate {
+	if this.StartTimestampMs != that1.StartTimestampMs {
 		return false
 	}
-	if this.RuleIngestionRate != that1.RuleIngestionRate {
+	if this.EndTimestampMs != that1.EndTimestampMs {
 		return false
 	}
-	if this.ActiveSeries != that1.ActiveSeries {
+	if !this.Matchers.Equal(that1.Matchers) {
 		return false
 	}
 	return true
 }
-func (this *UserIDStatsResponse) Equal(that interface{}) bool {
+func (this *LabelValuesResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*UserIDStatsResponse)
+	that1, ok := that.(*LabelValuesResponse)
 	if !ok {
-		that2, ok := that.(UserIDStatsResponse)
+		that2, ok := that.(LabelValuesResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2019,22 +2509,24 @@ func (this *UserIDStatsResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.UserId != that1.UserId {
+	if len(this.LabelValues) != len(that1.LabelValues) {
 		return false
 	}
-	if !this.Data.Equal(that1.Data) {
-		return false
+	for i := range this.LabelValues {
+		if this.LabelValues[i] != that1.LabelValues[i] {
+			return false
+		}
 	}
 	return true
 }
-func (this *UsersStatsResponse) Equal(that interface{}) bool {
+func (this *LabelValuesStreamResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*UsersStatsResponse)
+	that1, ok := that.(*LabelValuesStreamResponse)
 	if !ok {
-		that2, ok := that.(UsersStatsResponse)
+		that2, ok := that.(LabelValuesStreamResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2046,24 +2538,24 @@ func (this *UsersStatsResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Stats) != len(that1.Stats) {
+	if len(this.LabelValues) != len(that1.LabelValues) {
 		return false
 	}
-	for i := range this.Stats {
-		if !this.Stats[i].Equal(that1.Stats[i]) {
+	for i := range this.LabelValues {
+		if this.LabelValues[i] != that1.LabelValues[i] {
 			return false
 		}
 	}
 	return true
 }
-func (this *MetricsForLabelMatchersRequest) Equal(that interface{}) bool {
+func (this *LabelNamesRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*MetricsForLabelMatchersRequest)
+	that1, ok := that.(*LabelNamesRequest)
 	if !ok {
-		that2, ok := that.(MetricsForLabelMatchersRequest)
+		that2, ok := that.(LabelNamesRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2081,24 +2573,16 @@ func (this *MetricsForLabelMatchersRequest) Equal(that interface{}) bool {
 	if this.EndTimestampMs != that1.EndTimestampMs {
 		return false
 	}
-	if len(this.MatchersSet) != len(that1.MatchersSet) {
-		return false
-	}
-	for i := range this.MatchersSet {
-		if !this.MatchersSet[i].Equal(that1.MatchersSet[i]) {
-			return false
-		}
-	}
 	return true
 }
-func (this *MetricsForLabelMatchersResponse) Equal(that interface{}) bool {
+func (this *LabelNamesResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*MetricsForLabelMatchersResponse)
+	that1, ok := that.(*LabelNamesResponse)
 	if !ok {
-		that2, ok := that.(MetricsForLabelMatchersResponse)
+		that2, ok := that.(LabelNamesResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2110,24 +2594,24 @@ func (this *MetricsForLabelMatchersResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Metric) != len(that1.Metric) {
+	if len(this.LabelNames) != len(that1.LabelNames) {
 		return false
 	}
-	for i := range this.Metric {
-		if !this.Metric[i].Equal(that1.Metric[i]) {
+	for i := range this.LabelNames {
+		if this.LabelNames[i] != that1.LabelNames[i] {
 			return false
 		}
 	}
 	return true
 }
-func (this *MetricsForLabelMatchersStreamResponse) Equal(that interface{}) bool {
+func (this *LabelNamesStreamResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*MetricsForLabelMatchersStreamResponse)
+	that1, ok := that.(*LabelNamesStreamResponse)
 	if !ok {
-		that2, ok := that.(MetricsForLabelMatchersStreamResponse)
+		that2, ok := that.(LabelNamesStreamResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2139,24 +2623,24 @@ func (this *MetricsForLabelMatchersStreamResponse) Equal(that interface{}) bool
 	} else if this == nil {
 		return false
 	}
-	if len(this.Metric) != len(that1.Metric) {
+	if len(this.LabelNames) != len(that1.LabelNames) {
 		return false
 	}
-	for i := range this.Metric {
-		if !this.Metric[i].Equal(that1.Metric[i]) {
+	for i := range this.LabelNames {
+		if this.LabelNames[i] != that1.LabelNames[i] {
 			return false
 		}
 	}
 	return true
 }
-func (this *MetricsMetadataRequest) Equal(that interface{}) bool {
+func (this *UserStatsRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*MetricsMetadataRequest)
+	that1, ok := that.(*UserStatsRequest)
 	if !ok {
-		that2, ok := that.(MetricsMetadataRequest)
+		that2, ok := that.(UserStatsRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2170,14 +2654,14 @@ func (this *MetricsMetadataRequest) Equal(that interface{}) bool {
 	}
 	return true
 }
-func (this *MetricsMetadataResponse) Equal(that interface{}) bool {
+func (this *UserStatsResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*MetricsMetadataResponse)
+	that1, ok := that.(*UserStatsResponse)
 	if !ok {
-		that2, ok := that.(MetricsMetadataResponse)
+		that2, ok := that.(UserStatsResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2189,24 +2673,31 @@ func (this *MetricsMetadataResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Metadata) != len(that1.Metadata) {
+	if this.IngestionRate != that1.IngestionRate {
 		return false
 	}
-	for i := range this.Metadata {
-		if !this.Metadata[i].Equal(that1.Metadata[i]) {
-			return false
-		}
+	if this.NumSeries != that1.NumSeries {
+		return false
+	}
+	if this.ApiIngestionRate != that1.ApiIngestionRate {
+		return false
+	}
+	if this.RuleIngestionRate != that1.RuleIngestionRate {
+		return false
+	}
+	if this.ActiveSeries != that1.ActiveSeries {
+		return false
 	}
 	return true
 }
-func (this *TimeSeriesChunk) Equal(that interface{}) bool {
+func (this *UserIDStatsResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*TimeSeriesChunk)
+	that1, ok := that.(*UserIDStatsResponse)
 	if !ok {
-		that2, ok := that.(TimeSeriesChunk)
+		that2, ok := that.(UserIDStatsResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2218,38 +2709,51 @@ func (this *TimeSeriesChunk) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.FromIngesterId != that1.FromIngesterId {
-		return false
-	}
 	if this.UserId != that1.UserId {
 		return false
 	}
-	if len(this.Labels) != len(that1.Labels) {
+	if !this.Data.Equal(that1.Data) {
 		return false
 	}
-	for i := range this.Labels {
-		if !this.Labels[i].Equal(that1.Labels[i]) {
+	return true
+}
+func (this *UsersStatsResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*UsersStatsResponse)
+	if !ok {
+		that2, ok := that.(UsersStatsResponse)
+		if ok {
+			that1 = &that2
+		} else {
 			return false
 		}
 	}
-	if len(this.Chunks) != len(that1.Chunks) {
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
 		return false
 	}
-	for i := range this.Chunks {
-		if !this.Chunks[i].Equal(&that1.Chunks[i]) {
+	if len(this.Stats) != len(that1.Stats) {
+		return false
+	}
+	for i := range this.Stats {
+		if !this.Stats[i].Equal(that1.Stats[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *Chunk) Equal(that interface{}) bool {
+func (this *MetricsForLabelMatchersRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*Chunk)
+	that1, ok := that.(*MetricsForLabelMatchersRequest)
 	if !ok {
-		that2, ok := that.(Chunk)
+		that2, ok := that.(MetricsForLabelMatchersRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2267,22 +2771,24 @@ func (this *Chunk) Equal(that interface{}) bool {
 	if this.EndTimestampMs != that1.EndTimestampMs {
 		return false
 	}
-	if this.Encoding != that1.Encoding {
+	if len(this.MatchersSet) != len(that1.MatchersSet) {
 		return false
 	}
-	if !bytes.Equal(this.Data, that1.Data) {
-		return false
+	for i := range this.MatchersSet {
+		if !this.MatchersSet[i].Equal(that1.MatchersSet[i]) {
+			return false
+		}
 	}
 	return true
 }
-func (this *LabelMatchers) Equal(that interface{}) bool {
+func (this *MetricsForLabelMatchersResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelMatchers)
+	that1, ok := that.(*MetricsForLabelMatchersResponse)
 	if !ok {
-		that2, ok := that.(LabelMatchers)
+		that2, ok := that.(MetricsForLabelMatchersResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2294,24 +2800,24 @@ func (this *LabelMatchers) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Matchers) != len(that1.Matchers) {
+	if len(this.Metric) != len(that1.Metric) {
 		return false
 	}
-	for i := range this.Matchers {
-		if !this.Matchers[i].Equal(that1.Matchers[i]) {
+	for i := range this.Metric {
+		if !this.Metric[i].Equal(that1.Metric[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *LabelMatcher) Equal(that interface{}) bool {
+func (this *MetricsForLabelMatchersStreamResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelMatcher)
+	that1, ok := that.(*MetricsForLabelMatchersStreamResponse)
 	if !ok {
-		that2, ok := that.(LabelMatcher)
+		that2, ok := that.(MetricsForLabelMatchersStreamResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2323,25 +2829,45 @@ func (this *LabelMatcher) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.Type != that1.Type {
+	if len(this.Metric) != len(that1.Metric) {
 		return false
 	}
-	if this.Name != that1.Name {
-		return false
+	for i := range this.Metric {
+		if !this.Metric[i].Equal(that1.Metric[i]) {
+			return false
+		}
 	}
-	if this.Value != that1.Value {
+	return true
+}
+func (this *MetricsMetadataRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*MetricsMetadataRequest)
+	if !ok {
+		that2, ok := that.(MetricsMetadataRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
 		return false
 	}
 	return true
 }
-func (this *TimeSeriesFile) Equal(that interface{}) bool {
+func (this *MetricsMetadataResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*TimeSeriesFile)
+	that1, ok := that.(*MetricsMetadataResponse)
 	if !ok {
-		that2, ok := that.(TimeSeriesFile)
+		that2, ok := that.(MetricsMetadataResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2353,2947 +2879,5805 @@ func (this *TimeSeriesFile) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.FromIngesterId != that1.FromIngesterId {
+	if len(this.Metadata) != len(that1.Metadata) {
 		return false
 	}
-	if this.UserId != that1.UserId {
-		return false
+	for i := range this.Metadata {
+		if !this.Metadata[i].Equal(that1.Metadata[i]) {
+			return false
+		}
 	}
-	if this.Filename != that1.Filename {
+	return true
+}
+func (this *TSDBStatusRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TSDBStatusRequest)
+	if !ok {
+		that2, ok := that.(TSDBStatusRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
 		return false
 	}
-	if !bytes.Equal(this.Data, that1.Data) {
+	if this.Limit != that1.Limit {
 		return false
 	}
 	return true
 }
-func (this *ReadRequest) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *TSDBStatusResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.ReadRequest{")
-	if this.Queries != nil {
-		s = append(s, "Queries: "+fmt.Sprintf("%#v", this.Queries)+",\n")
+
+	that1, ok := that.(*TSDBStatusResponse)
+	if !ok {
+		that2, ok := that.(TSDBStatusResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *ReadResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.ReadResponse{")
-	if this.Results != nil {
-		s = append(s, "Results: "+fmt.Sprintf("%#v", this.Results)+",\n")
+	if this.NumSeries != that1.NumSeries {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *QueryRequest) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.ChunkCount != that1.ChunkCount {
+		return false
 	}
-	s := make([]string, 0, 7)
-	s = append(s, "&client.QueryRequest{")
-	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
-	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
-	if this.Matchers != nil {
-		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
+	if this.MinTime != that1.MinTime {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *ExemplarQueryRequest) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.MaxTime != that1.MaxTime {
+		return false
 	}
-	s := make([]string, 0, 7)
-	s = append(s, "&client.ExemplarQueryRequest{")
-	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
-	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
-	if this.Matchers != nil {
-		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
+	if this.NumLabelPairs != that1.NumLabelPairs {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *QueryResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if len(this.SeriesCountByMetricName) != len(that1.SeriesCountByMetricName) {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.QueryResponse{")
-	if this.Timeseries != nil {
-		vs := make([]*cortexpb.TimeSeries, len(this.Timeseries))
-		for i := range vs {
-			vs[i] = &this.Timeseries[i]
+	for i := range this.SeriesCountByMetricName {
+		if !this.SeriesCountByMetricName[i].Equal(&that1.SeriesCountByMetricName[i]) {
+			return false
 		}
-		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *QueryStreamResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if len(this.LabelValueCountByLabelName) != len(that1.LabelValueCountByLabelName) {
+		return false
 	}
-	s := make([]string, 0, 6)
-	s = append(s, "&client.QueryStreamResponse{")
-	if this.Chunkseries != nil {
-		vs := make([]*TimeSeriesChunk, len(this.Chunkseries))
-		for i := range vs {
-			vs[i] = &this.Chunkseries[i]
+	for i := range this.LabelValueCountByLabelName {
+		if !this.LabelValueCountByLabelName[i].Equal(&that1.LabelValueCountByLabelName[i]) {
+			return false
 		}
-		s = append(s, "Chunkseries: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	if this.Timeseries != nil {
-		vs := make([]*cortexpb.TimeSeries, len(this.Timeseries))
-		for i := range vs {
-			vs[i] = &this.Timeseries[i]
-		}
-		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
-	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *ExemplarQueryResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if len(this.MemoryInBytesByLabelName) != len(that1.MemoryInBytesByLabelName) {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.ExemplarQueryResponse{")
-	if this.Timeseries != nil {
-		vs := make([]*cortexpb.TimeSeries, len(this.Timeseries))
-		for i := range vs {
-			vs[i] = &this.Timeseries[i]
+	for i := range this.MemoryInBytesByLabelName {
+		if !this.MemoryInBytesByLabelName[i].Equal(&that1.MemoryInBytesByLabelName[i]) {
+			return false
 		}
-		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *LabelValuesRequest) GoString() string {
-	if this == nil {
-		return "nil"
+	if len(this.SeriesCountByLabelValuePair) != len(that1.SeriesCountByLabelValuePair) {
+		return false
 	}
-	s := make([]string, 0, 8)
-	s = append(s, "&client.LabelValuesRequest{")
-	s = append(s, "LabelName: "+fmt.Sprintf("%#v", this.LabelName)+",\n")
-	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
-	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
-	if this.Matchers != nil {
-		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
+	for i := range this.SeriesCountByLabelValuePair {
+		if !this.SeriesCountByLabelValuePair[i].Equal(&that1.SeriesCountByLabelValuePair[i]) {
+			return false
+		}
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
+	return true
 }
-func (this *LabelValuesResponse) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *TSDBStatistic) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.LabelValuesResponse{")
-	s = append(s, "LabelValues: "+fmt.Sprintf("%#v", this.LabelValues)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *LabelValuesStreamResponse) GoString() string {
-	if this == nil {
-		return "nil"
+
+	that1, ok := that.(*TSDBStatistic)
+	if !ok {
+		that2, ok := that.(TSDBStatistic)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.LabelValuesStreamResponse{")
-	s = append(s, "LabelValues: "+fmt.Sprintf("%#v", this.LabelValues)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *LabelNamesRequest) GoString() string {
-	if this == nil {
-		return "nil"
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s := make([]string, 0, 6)
-	s = append(s, "&client.LabelNamesRequest{")
-	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
-	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *LabelNamesResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.Name != that1.Name {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.LabelNamesResponse{")
-	s = append(s, "LabelNames: "+fmt.Sprintf("%#v", this.LabelNames)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *LabelNamesStreamResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.Value != that1.Value {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.LabelNamesStreamResponse{")
-	s = append(s, "LabelNames: "+fmt.Sprintf("%#v", this.LabelNames)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
+	return true
 }
-func (this *UserStatsRequest) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *ScaleDownRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 4)
-	s = append(s, "&client.UserStatsRequest{")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *UserStatsResponse) GoString() string {
-	if this == nil {
-		return "nil"
+
+	that1, ok := that.(*ScaleDownRequest)
+	if !ok {
+		that2, ok := that.(ScaleDownRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	s := make([]string, 0, 9)
-	s = append(s, "&client.UserStatsResponse{")
-	s = append(s, "IngestionRate: "+fmt.Sprintf("%#v", this.IngestionRate)+",\n")
-	s = append(s, "NumSeries: "+fmt.Sprintf("%#v", this.NumSeries)+",\n")
-	s = append(s, "ApiIngestionRate: "+fmt.Sprintf("%#v", this.ApiIngestionRate)+",\n")
-	s = append(s, "RuleIngestionRate: "+fmt.Sprintf("%#v", this.RuleIngestionRate)+",\n")
-	s = append(s, "ActiveSeries: "+fmt.Sprintf("%#v", this.ActiveSeries)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *UserIDStatsResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s := make([]string, 0, 6)
-	s = append(s, "&client.UserIDStatsResponse{")
-	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
-	if this.Data != nil {
-		s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
+	if this.Action != that1.Action {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
+	return true
 }
-func (this *UsersStatsResponse) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *ScaleDownResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.UsersStatsResponse{")
-	if this.Stats != nil {
-		s = append(s, "Stats: "+fmt.Sprintf("%#v", this.Stats)+",\n")
+
+	that1, ok := that.(*ScaleDownResponse)
+	if !ok {
+		that2, ok := that.(ScaleDownResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *MetricsForLabelMatchersRequest) GoString() string {
-	if this == nil {
-		return "nil"
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s := make([]string, 0, 7)
-	s = append(s, "&client.MetricsForLabelMatchersRequest{")
-	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
-	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
-	if this.MatchersSet != nil {
-		s = append(s, "MatchersSet: "+fmt.Sprintf("%#v", this.MatchersSet)+",\n")
+	if this.ReadOnly != that1.ReadOnly {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *MetricsForLabelMatchersResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.Shipped != that1.Shipped {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.MetricsForLabelMatchersResponse{")
-	if this.Metric != nil {
-		s = append(s, "Metric: "+fmt.Sprintf("%#v", this.Metric)+",\n")
+	if this.InflightQueries != that1.InflightQueries {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *MetricsForLabelMatchersStreamResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.Removable != that1.Removable {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.MetricsForLabelMatchersStreamResponse{")
-	if this.Metric != nil {
-		s = append(s, "Metric: "+fmt.Sprintf("%#v", this.Metric)+",\n")
+	if this.Removing != that1.Removing {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
+	return true
 }
-func (this *MetricsMetadataRequest) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *LabelValuesSketchesRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 4)
-	s = append(s, "&client.MetricsMetadataRequest{")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *MetricsMetadataResponse) GoString() string {
-	if this == nil {
-		return "nil"
+
+	that1, ok := that.(*LabelValuesSketchesRequest)
+	if !ok {
+		that2, ok := that.(LabelValuesSketchesRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.MetricsMetadataResponse{")
-	if this.Metadata != nil {
-		s = append(s, "Metadata: "+fmt.Sprintf("%#v", this.Metadata)+",\n")
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *TimeSeriesChunk) GoString() string {
-	if this == nil {
-		return "nil"
+	if len(this.ValueHashesLabels) != len(that1.ValueHashesLabels) {
+		return false
 	}
-	s := make([]string, 0, 8)
-	s = append(s, "&client.TimeSeriesChunk{")
-	s = append(s, "FromIngesterId: "+fmt.Sprintf("%#v", this.FromIngesterId)+",\n")
-	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
-	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
-	if this.Chunks != nil {
-		vs := make([]*Chunk, len(this.Chunks))
-		for i := range vs {
-			vs[i] = &this.Chunks[i]
+	for i := range this.ValueHashesLabels {
+		if !this.ValueHashesLabels[i].Equal(&that1.ValueHashesLabels[i]) {
+			return false
 		}
-		s = append(s, "Chunks: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
+	return true
 }
-func (this *Chunk) GoString() string {
-	if this == nil {
-		return "nil"
-	}
-	s := make([]string, 0, 8)
-	s = append(s, "&client.Chunk{")
-	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
-	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
-	s = append(s, "Encoding: "+fmt.Sprintf("%#v", this.Encoding)+",\n")
-	s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *LabelMatchers) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *UserLabelName) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.LabelMatchers{")
-	if this.Matchers != nil {
-		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
+
+	that1, ok := that.(*UserLabelName)
+	if !ok {
+		that2, ok := that.(UserLabelName)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *LabelMatcher) GoString() string {
-	if this == nil {
-		return "nil"
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s := make([]string, 0, 7)
-	s = append(s, "&client.LabelMatcher{")
-	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
-	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
-	s = append(s, "Value: "+fmt.Sprintf("%#v", this.Value)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *TimeSeriesFile) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.UserId != that1.UserId {
+		return false
 	}
-	s := make([]string, 0, 8)
-	s = append(s, "&client.TimeSeriesFile{")
-	s = append(s, "FromIngesterId: "+fmt.Sprintf("%#v", this.FromIngesterId)+",\n")
-	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
-	s = append(s, "Filename: "+fmt.Sprintf("%#v", this.Filename)+",\n")
-	s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func valueToGoStringIngester(v interface{}, typ string) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
-		return "nil"
+	if this.LabelName != that1.LabelName {
+		return false
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
-}
-
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// IngesterClient is the client API for Ingester service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type IngesterClient interface {
-	Push(ctx context.Context, in *cortexpb.WriteRequest, opts ...grpc.CallOption) (*cortexpb.WriteResponse, error)
-	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
-	QueryStream(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Ingester_QueryStreamClient, error)
-	QueryExemplars(ctx context.Context, in *ExemplarQueryRequest, opts ...grpc.CallOption) (*ExemplarQueryResponse, error)
-	LabelValues(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (*LabelValuesResponse, error)
-	LabelValuesStream(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (Ingester_LabelValuesStreamClient, error)
-	LabelNames(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (*LabelNamesResponse, error)
-	LabelNamesStream(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (Ingester_LabelNamesStreamClient, error)
-	UserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UserStatsResponse, error)
-	AllUserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UsersStatsResponse, error)
-	MetricsForLabelMatchers(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (*MetricsForLabelMatchersResponse, error)
-	MetricsForLabelMatchersStream(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (Ingester_MetricsForLabelMatchersStreamClient, error)
-	MetricsMetadata(ctx context.Context, in *MetricsMetadataRequest, opts ...grpc.CallOption) (*MetricsMetadataResponse, error)
-}
-
-type ingesterClient struct {
-	cc *grpc.ClientConn
-}
-
-func NewIngesterClient(cc *grpc.ClientConn) IngesterClient {
-	return &ingesterClient{cc}
+	return true
 }
-
-func (c *ingesterClient) Push(ctx context.Context, in *cortexpb.WriteRequest, opts ...grpc.CallOption) (*cortexpb.WriteResponse, error) {
-	out := new(cortexpb.WriteResponse)
-	err := c.cc.Invoke(ctx, "/cortex.Ingester/Push", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (this *LabelValuesSketchesResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return out, nil
-}
 
-func (c *ingesterClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error) {
-	out := new(QueryResponse)
-	err := c.cc.Invoke(ctx, "/cortex.Ingester/Query", in, out, opts...)
-	if err != nil {
-		return nil, err
+	that1, ok := that.(*LabelValuesSketchesResponse)
+	if !ok {
+		that2, ok := that.(LabelValuesSketchesResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	return out, nil
-}
-
-func (c *ingesterClient) QueryStream(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Ingester_QueryStreamClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[0], "/cortex.Ingester/QueryStream", opts...)
-	if err != nil {
-		return nil, err
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	x := &ingesterQueryStreamClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
+	if len(this.Sketches) != len(that1.Sketches) {
+		return false
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+	for i := range this.Sketches {
+		if !this.Sketches[i].Equal(&that1.Sketches[i]) {
+			return false
+		}
 	}
-	return x, nil
-}
-
-type Ingester_QueryStreamClient interface {
-	Recv() (*QueryStreamResponse, error)
-	grpc.ClientStream
-}
-
-type ingesterQueryStreamClient struct {
-	grpc.ClientStream
+	return true
 }
-
-func (x *ingesterQueryStreamClient) Recv() (*QueryStreamResponse, error) {
-	m := new(QueryStreamResponse)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+func (this *LabelValuesSketch) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return m, nil
-}
 
-func (c *ingesterClient) QueryExemplars(ctx context.Context, in *ExemplarQueryRequest, opts ...grpc.CallOption) (*ExemplarQueryResponse, error) {
-	out := new(ExemplarQueryResponse)
-	err := c.cc.Invoke(ctx, "/cortex.Ingester/QueryExemplars", in, out, opts...)
-	if err != nil {
-		return nil, err
+	that1, ok := that.(*LabelValuesSketch)
+	if !ok {
+		that2, ok := that.(LabelValuesSketch)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	return out, nil
-}
-
-func (c *ingesterClient) LabelValues(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (*LabelValuesResponse, error) {
-	out := new(LabelValuesResponse)
-	err := c.cc.Invoke(ctx, "/cortex.Ingester/LabelValues", in, out, opts...)
-	if err != nil {
-		return nil, err
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return out, nil
-}
-
-func (c *ingesterClient) LabelValuesStream(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (Ingester_LabelValuesStreamClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[1], "/cortex.Ingester/LabelValuesStream", opts...)
-	if err != nil {
-		return nil, err
+	if this.UserId != that1.UserId {
+		return false
 	}
-	x := &ingesterLabelValuesStreamClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
+	if this.LabelName != that1.LabelName {
+		return false
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+	if !bytes.Equal(this.Sketch, that1.Sketch) {
+		return false
 	}
-	return x, nil
-}
-
-type Ingester_LabelValuesStreamClient interface {
-	Recv() (*LabelValuesStreamResponse, error)
-	grpc.ClientStream
-}
-
-type ingesterLabelValuesStreamClient struct {
-	grpc.ClientStream
-}
-
-func (x *ingesterLabelValuesStreamClient) Recv() (*LabelValuesStreamResponse, error) {
-	m := new(LabelValuesStreamResponse)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+	if len(this.ValueHashes) != len(that1.ValueHashes) {
+		return false
 	}
-	return m, nil
-}
-
-func (c *ingesterClient) LabelNames(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (*LabelNamesResponse, error) {
-	out := new(LabelNamesResponse)
-	err := c.cc.Invoke(ctx, "/cortex.Ingester/LabelNames", in, out, opts...)
-	if err != nil {
-		return nil, err
+	for i := range this.ValueHashes {
+		if this.ValueHashes[i] != that1.ValueHashes[i] {
+			return false
+		}
 	}
-	return out, nil
+	return true
 }
+func (this *TimeSeriesChunk) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (c *ingesterClient) LabelNamesStream(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (Ingester_LabelNamesStreamClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[2], "/cortex.Ingester/LabelNamesStream", opts...)
-	if err != nil {
-		return nil, err
+	that1, ok := that.(*TimeSeriesChunk)
+	if !ok {
+		that2, ok := that.(TimeSeriesChunk)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	x := &ingesterLabelNamesStreamClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+	if this.FromIngesterId != that1.FromIngesterId {
+		return false
 	}
-	return x, nil
-}
-
-type Ingester_LabelNamesStreamClient interface {
-	Recv() (*LabelNamesStreamResponse, error)
-	grpc.ClientStream
-}
-
-type ingesterLabelNamesStreamClient struct {
-	grpc.ClientStream
-}
-
-func (x *ingesterLabelNamesStreamClient) Recv() (*LabelNamesStreamResponse, error) {
-	m := new(LabelNamesStreamResponse)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+	if this.UserId != that1.UserId {
+		return false
 	}
-	return m, nil
-}
-
-func (c *ingesterClient) UserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UserStatsResponse, error) {
-	out := new(UserStatsResponse)
-	err := c.cc.Invoke(ctx, "/cortex.Ingester/UserStats", in, out, opts...)
-	if err != nil {
-		return nil, err
+	if len(this.Labels) != len(that1.Labels) {
+		return false
 	}
-	return out, nil
-}
-
-func (c *ingesterClient) AllUserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UsersStatsResponse, error) {
-	out := new(UsersStatsResponse)
-	err := c.cc.Invoke(ctx, "/cortex.Ingester/AllUserStats", in, out, opts...)
-	if err != nil {
-		return nil, err
+	for i := range this.Labels {
+		if !this.Labels[i].Equal(that1.Labels[i]) {
+			return false
+		}
 	}
-	return out, nil
-}
-
-func (c *ingesterClient) MetricsForLabelMatchers(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (*MetricsForLabelMatchersResponse, error) {
-	out := new(MetricsForLabelMatchersResponse)
-	err := c.cc.Invoke(ctx, "/cortex.Ingester/MetricsForLabelMatchers", in, out, opts...)
-	if err != nil {
-		return nil, err
+	if len(this.Chunks) != len(that1.Chunks) {
+		return false
 	}
-	return out, nil
+	for i := range this.Chunks {
+		if !this.Chunks[i].Equal(&that1.Chunks[i]) {
+			return false
+		}
+	}
+	return true
 }
+func (this *Chunk) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (c *ingesterClient) MetricsForLabelMatchersStream(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (Ingester_MetricsForLabelMatchersStreamClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[3], "/cortex.Ingester/MetricsForLabelMatchersStream", opts...)
-	if err != nil {
-		return nil, err
+	that1, ok := that.(*Chunk)
+	if !ok {
+		that2, ok := that.(Chunk)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	x := &ingesterMetricsForLabelMatchersStreamClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+	if this.StartTimestampMs != that1.StartTimestampMs {
+		return false
 	}
-	return x, nil
-}
-
-type Ingester_MetricsForLabelMatchersStreamClient interface {
-	Recv() (*MetricsForLabelMatchersStreamResponse, error)
-	grpc.ClientStream
-}
-
-type ingesterMetricsForLabelMatchersStreamClient struct {
-	grpc.ClientStream
-}
-
-func (x *ingesterMetricsForLabelMatchersStreamClient) Recv() (*MetricsForLabelMatchersStreamResponse, error) {
-	m := new(MetricsForLabelMatchersStreamResponse)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+	if this.EndTimestampMs != that1.EndTimestampMs {
+		return false
 	}
-	return m, nil
-}
-
-func (c *ingesterClient) MetricsMetadata(ctx context.Context, in *MetricsMetadataRequest, opts ...grpc.CallOption) (*MetricsMetadataResponse, error) {
-	out := new(MetricsMetadataResponse)
-	err := c.cc.Invoke(ctx, "/cortex.Ingester/MetricsMetadata", in, out, opts...)
-	if err != nil {
-		return nil, err
+	if this.Encoding != that1.Encoding {
+		return false
 	}
-	return out, nil
-}
-
-// IngesterServer is the server API for Ingester service.
-type IngesterServer interface {
-	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
-	Query(context.Context, *QueryRequest) (*QueryResponse, error)
-	QueryStream(*QueryRequest, Ingester_QueryStreamServer) error
-	QueryExemplars(context.Context, *ExemplarQueryRequest) (*ExemplarQueryResponse, error)
-	LabelValues(context.Context, *LabelValuesRequest) (*LabelValuesResponse, error)
-	LabelValuesStream(*LabelValuesRequest, Ingester_LabelValuesStreamServer) error
-	LabelNames(context.Context, *LabelNamesRequest) (*LabelNamesResponse, error)
-	LabelNamesStream(*LabelNamesRequest, Ingester_LabelNamesStreamServer) error
-	UserStats(context.Context, *UserStatsRequest) (*UserStatsResponse, error)
-	AllUserStats(context.Context, *UserStatsRequest) (*UsersStatsResponse, error)
-	MetricsForLabelMatchers(context.Context, *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error)
-	MetricsForLabelMatchersStream(*MetricsForLabelMatchersRequest, Ingester_MetricsForLabelMatchersStreamServer) error
-	MetricsMetadata(context.Context, *MetricsMetadataRequest) (*MetricsMetadataResponse, error)
-}
-
-// UnimplementedIngesterServer can be embedded to have forward compatible implementations.
-type UnimplementedIngesterServer struct {
-}
-
-func (*UnimplementedIngesterServer) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Push not implemented")
-}
-func (*UnimplementedIngesterServer) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
-}
-func (*UnimplementedIngesterServer) QueryStream(req *QueryRequest, srv Ingester_QueryStreamServer) error {
-	return status.Errorf(codes.Unimplemented, "method QueryStream not implemented")
-}
-func (*UnimplementedIngesterServer) QueryExemplars(ctx context.Context, req *ExemplarQueryRequest) (*ExemplarQueryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method QueryExemplars not implemented")
-}
-func (*UnimplementedIngesterServer) LabelValues(ctx context.Context, req *LabelValuesRequest) (*LabelValuesResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method LabelValues not implemented")
-}
-func (*UnimplementedIngesterServer) LabelValuesStream(req *LabelValuesRequest, srv Ingester_LabelValuesStreamServer) error {
-	return status.Errorf(codes.Unimplemented, "method LabelValuesStream not implemented")
-}
-func (*UnimplementedIngesterServer) LabelNames(ctx context.Context, req *LabelNamesRequest) (*LabelNamesResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method LabelNames not implemented")
-}
-func (*UnimplementedIngesterServer) LabelNamesStream(req *LabelNamesRequest, srv Ingester_LabelNamesStreamServer) error {
-	return status.Errorf(codes.Unimplemented, "method LabelNamesStream not implemented")
-}
-func (*UnimplementedIngesterServer) UserStats(ctx context.Context, req *UserStatsRequest) (*UserStatsResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method UserStats not implemented")
-}
-func (*UnimplementedIngesterServer) AllUserStats(ctx context.Context, req *UserStatsRequest) (*UsersStatsResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AllUserStats not implemented")
-}
-func (*UnimplementedIngesterServer) MetricsForLabelMatchers(ctx context.Context, req *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method MetricsForLabelMatchers not implemented")
-}
-func (*UnimplementedIngesterServer) MetricsForLabelMatchersStream(req *MetricsForLabelMatchersRequest, srv Ingester_MetricsForLabelMatchersStreamServer) error {
-	return status.Errorf(codes.Unimplemented, "method MetricsForLabelMatchersStream not implemented")
-}
-func (*UnimplementedIngesterServer) MetricsMetadata(ctx context.Context, req *MetricsMetadataRequest) (*MetricsMetadataResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method MetricsMetadata not implemented")
-}
-
-func RegisterIngesterServer(s *grpc.Server, srv IngesterServer) {
-	s.RegisterService(&_Ingester_serviceDesc, srv)
+	if !bytes.Equal(this.Data, that1.Data) {
+		return false
+	}
+	return true
 }
+func (this *LabelMatchers) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func _Ingester_Push_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(cortexpb.WriteRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+	that1, ok := that.(*LabelMatchers)
+	if !ok {
+		that2, ok := that.(LabelMatchers)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	if interceptor == nil {
-		return srv.(IngesterServer).Push(ctx, in)
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/cortex.Ingester/Push",
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IngesterServer).Push(ctx, req.(*cortexpb.WriteRequest))
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(that1.Matchers[i]) {
+			return false
+		}
 	}
-	return interceptor(ctx, in, info, handler)
+	return true
 }
+func (this *LabelMatcher) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func _Ingester_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(QueryRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+	that1, ok := that.(*LabelMatcher)
+	if !ok {
+		that2, ok := that.(LabelMatcher)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	if interceptor == nil {
-		return srv.(IngesterServer).Query(ctx, in)
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/cortex.Ingester/Query",
+	if this.Type != that1.Type {
+		return false
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IngesterServer).Query(ctx, req.(*QueryRequest))
+	if this.Name != that1.Name {
+		return false
 	}
-	return interceptor(ctx, in, info, handler)
+	if this.Value != that1.Value {
+		return false
+	}
+	return true
 }
-
-func _Ingester_QueryStream_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(QueryRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func (this *TimeSeriesFile) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return srv.(IngesterServer).QueryStream(m, &ingesterQueryStreamServer{stream})
-}
-
-type Ingester_QueryStreamServer interface {
-	Send(*QueryStreamResponse) error
-	grpc.ServerStream
-}
-
-type ingesterQueryStreamServer struct {
-	grpc.ServerStream
-}
-
-func (x *ingesterQueryStreamServer) Send(m *QueryStreamResponse) error {
-	return x.ServerStream.SendMsg(m)
-}
 
-func _Ingester_QueryExemplars_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ExemplarQueryRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(IngesterServer).QueryExemplars(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/cortex.Ingester/QueryExemplars",
+	that1, ok := that.(*TimeSeriesFile)
+	if !ok {
+		that2, ok := that.(TimeSeriesFile)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IngesterServer).QueryExemplars(ctx, req.(*ExemplarQueryRequest))
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return interceptor(ctx, in, info, handler)
-}
-
-func _Ingester_LabelValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LabelValuesRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+	if this.FromIngesterId != that1.FromIngesterId {
+		return false
 	}
-	if interceptor == nil {
-		return srv.(IngesterServer).LabelValues(ctx, in)
+	if this.UserId != that1.UserId {
+		return false
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/cortex.Ingester/LabelValues",
+	if this.Filename != that1.Filename {
+		return false
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IngesterServer).LabelValues(ctx, req.(*LabelValuesRequest))
+	if !bytes.Equal(this.Data, that1.Data) {
+		return false
 	}
-	return interceptor(ctx, in, info, handler)
+	return true
 }
-
-func _Ingester_LabelValuesStream_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(LabelValuesRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func (this *TransferTSDBResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return srv.(IngesterServer).LabelValuesStream(m, &ingesterLabelValuesStreamServer{stream})
-}
-
-type Ingester_LabelValuesStreamServer interface {
-	Send(*LabelValuesStreamResponse) error
-	grpc.ServerStream
-}
-
-type ingesterLabelValuesStreamServer struct {
-	grpc.ServerStream
-}
-
-func (x *ingesterLabelValuesStreamServer) Send(m *LabelValuesStreamResponse) error {
-	return x.ServerStream.SendMsg(m)
-}
 
-func _Ingester_LabelNames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LabelNamesRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(IngesterServer).LabelNames(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/cortex.Ingester/LabelNames",
+	that1, ok := that.(*TransferTSDBResponse)
+	if !ok {
+		that2, ok := that.(TransferTSDBResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IngesterServer).LabelNames(ctx, req.(*LabelNamesRequest))
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return interceptor(ctx, in, info, handler)
+	return true
 }
-
-func _Ingester_LabelNamesStream_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(LabelNamesRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func (this *ReadRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return srv.(IngesterServer).LabelNamesStream(m, &ingesterLabelNamesStreamServer{stream})
-}
-
-type Ingester_LabelNamesStreamServer interface {
-	Send(*LabelNamesStreamResponse) error
-	grpc.ServerStream
-}
-
-type ingesterLabelNamesStreamServer struct {
-	grpc.ServerStream
-}
-
-func (x *ingesterLabelNamesStreamServer) Send(m *LabelNamesStreamResponse) error {
-	return x.ServerStream.SendMsg(m)
+	s := make([]string, 0, 5)
+	s = append(s, "&client.ReadRequest{")
+	if this.Queries != nil {
+		s = append(s, "Queries: "+fmt.Sprintf("%#v", this.Queries)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func _Ingester_UserStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserStatsRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+func (this *ReadResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	if interceptor == nil {
-		return srv.(IngesterServer).UserStats(ctx, in)
+	s := make([]string, 0, 5)
+	s = append(s, "&client.ReadResponse{")
+	if this.Results != nil {
+		s = append(s, "Results: "+fmt.Sprintf("%#v", this.Results)+",\n")
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/cortex.Ingester/UserStats",
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *QueryRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IngesterServer).UserStats(ctx, req.(*UserStatsRequest))
+	s := make([]string, 0, 9)
+	s = append(s, "&client.QueryRequest{")
+	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
+	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
+	if this.Matchers != nil {
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
-	return interceptor(ctx, in, info, handler)
+	s = append(s, "SortSeries: "+fmt.Sprintf("%#v", this.SortSeries)+",\n")
+	s = append(s, "SeriesLimit: "+fmt.Sprintf("%#v", this.SeriesLimit)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func _Ingester_AllUserStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserStatsRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+func (this *ExemplarQueryRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	if interceptor == nil {
-		return srv.(IngesterServer).AllUserStats(ctx, in)
+	s := make([]string, 0, 7)
+	s = append(s, "&client.ExemplarQueryRequest{")
+	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
+	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
+	if this.Matchers != nil {
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/cortex.Ingester/AllUserStats",
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *QueryResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IngesterServer).AllUserStats(ctx, req.(*UserStatsRequest))
+	s := make([]string, 0, 5)
+	s = append(s, "&client.QueryResponse{")
+	if this.Timeseries != nil {
+		vs := make([]*cortexpb.TimeSeries, len(this.Timeseries))
+		for i := range vs {
+			vs[i] = &this.Timeseries[i]
+		}
+		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	return interceptor(ctx, in, info, handler)
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func _Ingester_MetricsForLabelMatchers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MetricsForLabelMatchersRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(IngesterServer).MetricsForLabelMatchers(ctx, in)
+func (this *QueryStreamResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/cortex.Ingester/MetricsForLabelMatchers",
+	s := make([]string, 0, 7)
+	s = append(s, "&client.QueryStreamResponse{")
+	if this.Chunkseries != nil {
+		vs := make([]*TimeSeriesChunk, len(this.Chunkseries))
+		for i := range vs {
+			vs[i] = &this.Chunkseries[i]
+		}
+		s = append(s, "Chunkseries: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IngesterServer).MetricsForLabelMatchers(ctx, req.(*MetricsForLabelMatchersRequest))
+	if this.Timeseries != nil {
+		vs := make([]*cortexpb.TimeSeries, len(this.Timeseries))
+		for i := range vs {
+			vs[i] = &this.Timeseries[i]
+		}
+		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	return interceptor(ctx, in, info, handler)
+	s = append(s, "SeriesLimitReached: "+fmt.Sprintf("%#v", this.SeriesLimitReached)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func _Ingester_MetricsForLabelMatchersStream_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(MetricsForLabelMatchersRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
-	}
-	return srv.(IngesterServer).MetricsForLabelMatchersStream(m, &ingesterMetricsForLabelMatchersStreamServer{stream})
-}
-
-type Ingester_MetricsForLabelMatchersStreamServer interface {
-	Send(*MetricsForLabelMatchersStreamResponse) error
-	grpc.ServerStream
-}
-
-type ingesterMetricsForLabelMatchersStreamServer struct {
-	grpc.ServerStream
-}
-
-func (x *ingesterMetricsForLabelMatchersStreamServer) Send(m *MetricsForLabelMatchersStreamResponse) error {
-	return x.ServerStream.SendMsg(m)
-}
-
-func _Ingester_MetricsMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MetricsMetadataRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+func (this *ExemplarQueryResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	if interceptor == nil {
-		return srv.(IngesterServer).MetricsMetadata(ctx, in)
+	s := make([]string, 0, 5)
+	s = append(s, "&client.ExemplarQueryResponse{")
+	if this.Timeseries != nil {
+		vs := make([]*cortexpb.TimeSeries, len(this.Timeseries))
+		for i := range vs {
+			vs[i] = &this.Timeseries[i]
+		}
+		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/cortex.Ingester/MetricsMetadata",
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *LabelValuesRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IngesterServer).MetricsMetadata(ctx, req.(*MetricsMetadataRequest))
+	s := make([]string, 0, 8)
+	s = append(s, "&client.LabelValuesRequest{")
+	s = append(s, "LabelName: "+fmt.Sprintf("%#v", this.LabelName)+",\n")
+	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
+	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
+	if this.Matchers != nil {
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
-	return interceptor(ctx, in, info, handler)
-}
-
-var _Ingester_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "cortex.Ingester",
-	HandlerType: (*IngesterServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Push",
-			Handler:    _Ingester_Push_Handler,
-		},
-		{
-			MethodName: "Query",
-			Handler:    _Ingester_Query_Handler,
-		},
-		{
-			MethodName: "QueryExemplars",
-			Handler:    _Ingester_QueryExemplars_Handler,
-		},
-		{
-			MethodName: "LabelValues",
-			Handler:    _Ingester_LabelValues_Handler,
-		},
-		{
-			MethodName: "LabelNames",
-			Handler:    _Ingester_LabelNames_Handler,
-		},
-		{
-			MethodName: "UserStats",
-			Handler:    _Ingester_UserStats_Handler,
-		},
-		{
-			MethodName: "AllUserStats",
-			Handler:    _Ingester_AllUserStats_Handler,
-		},
-		{
-			MethodName: "MetricsForLabelMatchers",
-			Handler:    _Ingester_MetricsForLabelMatchers_Handler,
-		},
-		{
-			MethodName: "MetricsMetadata",
-			Handler:    _Ingester_MetricsMetadata_Handler,
-		},
-	},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "QueryStream",
-			Handler:       _Ingester_QueryStream_Handler,
-			ServerStreams: true,
-		},
-		{
-			StreamName:    "LabelValuesStream",
-			Handler:       _Ingester_LabelValuesStream_Handler,
-			ServerStreams: true,
-		},
-		{
-			StreamName:    "LabelNamesStream",
-			Handler:       _Ingester_LabelNamesStream_Handler,
-			ServerStreams: true,
-		},
-		{
-			StreamName:    "MetricsForLabelMatchersStream",
-			Handler:       _Ingester_MetricsForLabelMatchersStream_Handler,
-			ServerStreams: true,
-		},
-	},
-	Metadata: "ingester.proto",
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *ReadRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *LabelValuesResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ReadRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	s := make([]string, 0, 5)
+	s = append(s, "&client.LabelValuesResponse{")
+	s = append(s, "LabelValues: "+fmt.Sprintf("%#v", this.LabelValues)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *ReadRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Queries) > 0 {
-		for iNdEx := len(m.Queries) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Queries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+func (this *LabelValuesStreamResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return len(dAtA) - i, nil
+	s := make([]string, 0, 5)
+	s = append(s, "&client.LabelValuesStreamResponse{")
+	s = append(s, "LabelValues: "+fmt.Sprintf("%#v", this.LabelValues)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *ReadResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *LabelNamesRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ReadResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	s := make([]string, 0, 6)
+	s = append(s, "&client.LabelNamesRequest{")
+	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
+	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *ReadResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Results) > 0 {
-		for iNdEx := len(m.Results) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Results[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+func (this *LabelNamesResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return len(dAtA) - i, nil
+	s := make([]string, 0, 5)
+	s = append(s, "&client.LabelNamesResponse{")
+	s = append(s, "LabelNames: "+fmt.Sprintf("%#v", this.LabelNames)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *QueryRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *LabelNamesStreamResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
+	s := make([]string, 0, 5)
+	s = append(s, "&client.LabelNamesStreamResponse{")
+	s = append(s, "LabelNames: "+fmt.Sprintf("%#v", this.LabelNames)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *QueryRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (this *UserStatsRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 4)
+	s = append(s, "&client.UserStatsRequest{")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *QueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Matchers) > 0 {
-		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1a
-		}
+func (this *UserStatsResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	if m.EndTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
-		i--
-		dAtA[i] = 0x10
+	s := make([]string, 0, 9)
+	s = append(s, "&client.UserStatsResponse{")
+	s = append(s, "IngestionRate: "+fmt.Sprintf("%#v", this.IngestionRate)+",\n")
+	s = append(s, "NumSeries: "+fmt.Sprintf("%#v", this.NumSeries)+",\n")
+	s = append(s, "ApiIngestionRate: "+fmt.Sprintf("%#v", this.ApiIngestionRate)+",\n")
+	s = append(s, "RuleIngestionRate: "+fmt.Sprintf("%#v", this.RuleIngestionRate)+",\n")
+	s = append(s, "ActiveSeries: "+fmt.Sprintf("%#v", this.ActiveSeries)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *UserIDStatsResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	if m.StartTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
-		i--
-		dAtA[i] = 0x8
+	s := make([]string, 0, 6)
+	s = append(s, "&client.UserIDStatsResponse{")
+	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
+	if this.Data != nil {
+		s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
 	}
-	return len(dAtA) - i, nil
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *ExemplarQueryRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *UsersStatsResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ExemplarQueryRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *ExemplarQueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Matchers) > 0 {
-		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1a
-		}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.UsersStatsResponse{")
+	if this.Stats != nil {
+		s = append(s, "Stats: "+fmt.Sprintf("%#v", this.Stats)+",\n")
 	}
-	if m.EndTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
-		i--
-		dAtA[i] = 0x10
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *MetricsForLabelMatchersRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	if m.StartTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
-		i--
-		dAtA[i] = 0x8
+	s := make([]string, 0, 7)
+	s = append(s, "&client.MetricsForLabelMatchersRequest{")
+	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
+	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
+	if this.MatchersSet != nil {
+		s = append(s, "MatchersSet: "+fmt.Sprintf("%#v", this.MatchersSet)+",\n")
 	}
-	return len(dAtA) - i, nil
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *QueryResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *MetricsForLabelMatchersResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
+	s := make([]string, 0, 5)
+	s = append(s, "&client.MetricsForLabelMatchersResponse{")
+	if this.Metric != nil {
+		s = append(s, "Metric: "+fmt.Sprintf("%#v", this.Metric)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *QueryResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (this *MetricsForLabelMatchersStreamResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.MetricsForLabelMatchersStreamResponse{")
+	if this.Metric != nil {
+		s = append(s, "Metric: "+fmt.Sprintf("%#v", this.Metric)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *QueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Timeseries) > 0 {
-		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Timeseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+func (this *MetricsMetadataRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return len(dAtA) - i, nil
+	s := make([]string, 0, 4)
+	s = append(s, "&client.MetricsMetadataRequest{")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *QueryStreamResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *MetricsMetadataResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
+	s := make([]string, 0, 5)
+	s = append(s, "&client.MetricsMetadataResponse{")
+	if this.Metadata != nil {
+		s = append(s, "Metadata: "+fmt.Sprintf("%#v", this.Metadata)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *QueryStreamResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (this *TSDBStatusRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.TSDBStatusRequest{")
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *QueryStreamResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Timeseries) > 0 {
-		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Timeseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x12
+func (this *TSDBStatusResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 13)
+	s = append(s, "&client.TSDBStatusResponse{")
+	s = append(s, "NumSeries: "+fmt.Sprintf("%#v", this.NumSeries)+",\n")
+	s = append(s, "ChunkCount: "+fmt.Sprintf("%#v", this.ChunkCount)+",\n")
+	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
+	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
+	s = append(s, "NumLabelPairs: "+fmt.Sprintf("%#v", this.NumLabelPairs)+",\n")
+	if this.SeriesCountByMetricName != nil {
+		vs := make([]*TSDBStatistic, len(this.SeriesCountByMetricName))
+		for i := range vs {
+			vs[i] = &this.SeriesCountByMetricName[i]
 		}
+		s = append(s, "SeriesCountByMetricName: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	if len(m.Chunkseries) > 0 {
-		for iNdEx := len(m.Chunkseries) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Chunkseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
+	if this.LabelValueCountByLabelName != nil {
+		vs := make([]*TSDBStatistic, len(this.LabelValueCountByLabelName))
+		for i := range vs {
+			vs[i] = &this.LabelValueCountByLabelName[i]
 		}
+		s = append(s, "LabelValueCountByLabelName: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	return len(dAtA) - i, nil
+	if this.MemoryInBytesByLabelName != nil {
+		vs := make([]*TSDBStatistic, len(this.MemoryInBytesByLabelName))
+		for i := range vs {
+			vs[i] = &this.MemoryInBytesByLabelName[i]
+		}
+		s = append(s, "MemoryInBytesByLabelName: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.SeriesCountByLabelValuePair != nil {
+		vs := make([]*TSDBStatistic, len(this.SeriesCountByLabelValuePair))
+		for i := range vs {
+			vs[i] = &this.SeriesCountByLabelValuePair[i]
+		}
+		s = append(s, "SeriesCountByLabelValuePair: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *ExemplarQueryResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *TSDBStatistic) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
+	s := make([]string, 0, 6)
+	s = append(s, "&client.TSDBStatistic{")
+	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
+	s = append(s, "Value: "+fmt.Sprintf("%#v", this.Value)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *ExemplarQueryResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (this *ScaleDownRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.ScaleDownRequest{")
+	s = append(s, "Action: "+fmt.Sprintf("%#v", this.Action)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *ExemplarQueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Timeseries) > 0 {
-		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Timeseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+func (this *ScaleDownResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return len(dAtA) - i, nil
+	s := make([]string, 0, 9)
+	s = append(s, "&client.ScaleDownResponse{")
+	s = append(s, "ReadOnly: "+fmt.Sprintf("%#v", this.ReadOnly)+",\n")
+	s = append(s, "Shipped: "+fmt.Sprintf("%#v", this.Shipped)+",\n")
+	s = append(s, "InflightQueries: "+fmt.Sprintf("%#v", this.InflightQueries)+",\n")
+	s = append(s, "Removable: "+fmt.Sprintf("%#v", this.Removable)+",\n")
+	s = append(s, "Removing: "+fmt.Sprintf("%#v", this.Removing)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelValuesRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *LabelValuesSketchesRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
+	s := make([]string, 0, 5)
+	s = append(s, "&client.LabelValuesSketchesRequest{")
+	if this.ValueHashesLabels != nil {
+		vs := make([]UserLabelName, len(this.ValueHashesLabels))
+		for i := range vs {
+			vs[i] = this.ValueHashesLabels[i]
+		}
+		s = append(s, "ValueHashesLabels: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelValuesRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (this *UserLabelName) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.UserLabelName{")
+	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
+	s = append(s, "LabelName: "+fmt.Sprintf("%#v", this.LabelName)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelValuesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Matchers != nil {
-		{
-			size, err := m.Matchers.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintIngester(dAtA, i, uint64(size))
+func (this *LabelValuesSketchesResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.LabelValuesSketchesResponse{")
+	if this.Sketches != nil {
+		vs := make([]LabelValuesSketch, len(this.Sketches))
+		for i := range vs {
+			vs[i] = this.Sketches[i]
 		}
-		i--
-		dAtA[i] = 0x22
+		s = append(s, "Sketches: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	if m.EndTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
-		i--
-		dAtA[i] = 0x18
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *LabelValuesSketch) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	if m.StartTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
-		i--
-		dAtA[i] = 0x10
+	s := make([]string, 0, 8)
+	s = append(s, "&client.LabelValuesSketch{")
+	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
+	s = append(s, "LabelName: "+fmt.Sprintf("%#v", this.LabelName)+",\n")
+	s = append(s, "Sketch: "+fmt.Sprintf("%#v", this.Sketch)+",\n")
+	s = append(s, "ValueHashes: "+fmt.Sprintf("%#v", this.ValueHashes)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *TimeSeriesChunk) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	if len(m.LabelName) > 0 {
-		i -= len(m.LabelName)
-		copy(dAtA[i:], m.LabelName)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelName)))
-		i--
-		dAtA[i] = 0xa
+	s := make([]string, 0, 8)
+	s = append(s, "&client.TimeSeriesChunk{")
+	s = append(s, "FromIngesterId: "+fmt.Sprintf("%#v", this.FromIngesterId)+",\n")
+	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
+	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
+	if this.Chunks != nil {
+		vs := make([]*Chunk, len(this.Chunks))
+		for i := range vs {
+			vs[i] = &this.Chunks[i]
+		}
+		s = append(s, "Chunks: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	return len(dAtA) - i, nil
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelValuesResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *Chunk) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
-}
-
-func (m *LabelValuesResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	s := make([]string, 0, 8)
+	s = append(s, "&client.Chunk{")
+	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
+	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
+	s = append(s, "Encoding: "+fmt.Sprintf("%#v", this.Encoding)+",\n")
+	s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelValuesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.LabelValues) > 0 {
-		for iNdEx := len(m.LabelValues) - 1; iNdEx >= 0; iNdEx-- {
-			i -= len(m.LabelValues[iNdEx])
-			copy(dAtA[i:], m.LabelValues[iNdEx])
-			i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelValues[iNdEx])))
-			i--
-			dAtA[i] = 0xa
-		}
+func (this *LabelMatchers) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return len(dAtA) - i, nil
+	s := make([]string, 0, 5)
+	s = append(s, "&client.LabelMatchers{")
+	if this.Matchers != nil {
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelValuesStreamResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *LabelMatcher) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
+	s := make([]string, 0, 7)
+	s = append(s, "&client.LabelMatcher{")
+	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
+	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
+	s = append(s, "Value: "+fmt.Sprintf("%#v", this.Value)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelValuesStreamResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (this *TimeSeriesFile) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&client.TimeSeriesFile{")
+	s = append(s, "FromIngesterId: "+fmt.Sprintf("%#v", this.FromIngesterId)+",\n")
+	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
+	s = append(s, "Filename: "+fmt.Sprintf("%#v", this.Filename)+",\n")
+	s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelValuesStreamResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.LabelValues) > 0 {
-		for iNdEx := len(m.LabelValues) - 1; iNdEx >= 0; iNdEx-- {
-			i -= len(m.LabelValues[iNdEx])
-			copy(dAtA[i:], m.LabelValues[iNdEx])
-			i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelValues[iNdEx])))
-			i--
-			dAtA[i] = 0xa
-		}
+func (this *TransferTSDBResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return len(dAtA) - i, nil
+	s := make([]string, 0, 4)
+	s = append(s, "&client.TransferTSDBResponse{")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelNamesRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func valueToGoStringIngester(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
 	}
-	return dAtA[:n], nil
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
 }
 
-func (m *LabelNamesRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
 
-func (m *LabelNamesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.EndTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
-		i--
-		dAtA[i] = 0x10
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// IngesterClient is the client API for Ingester service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type IngesterClient interface {
+	Push(ctx context.Context, in *cortexpb.WriteRequest, opts ...grpc.CallOption) (*cortexpb.WriteResponse, error)
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
+	QueryStream(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Ingester_QueryStreamClient, error)
+	QueryExemplars(ctx context.Context, in *ExemplarQueryRequest, opts ...grpc.CallOption) (*ExemplarQueryResponse, error)
+	LabelValues(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (*LabelValuesResponse, error)
+	LabelValuesStream(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (Ingester_LabelValuesStreamClient, error)
+	LabelNames(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (*LabelNamesResponse, error)
+	LabelNamesStream(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (Ingester_LabelNamesStreamClient, error)
+	UserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UserStatsResponse, error)
+	AllUserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UsersStatsResponse, error)
+	MetricsForLabelMatchers(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (*MetricsForLabelMatchersResponse, error)
+	MetricsForLabelMatchersStream(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (Ingester_MetricsForLabelMatchersStreamClient, error)
+	MetricsMetadata(ctx context.Context, in *MetricsMetadataRequest, opts ...grpc.CallOption) (*MetricsMetadataResponse, error)
+	TSDBStatus(ctx context.Context, in *TSDBStatusRequest, opts ...grpc.CallOption) (*TSDBStatusResponse, error)
+	ScaleDown(ctx context.Context, in *ScaleDownRequest, opts ...grpc.CallOption) (*ScaleDownResponse, error)
+	LabelValuesSketches(ctx context.Context, in *LabelValuesSketchesRequest, opts ...grpc.CallOption) (*LabelValuesSketchesResponse, error)
+	// TransferTSDB transfers all files of a LEAVING ingester's TSDBs to a PENDING one.
+	TransferTSDB(ctx context.Context, opts ...grpc.CallOption) (Ingester_TransferTSDBClient, error)
+}
+
+type ingesterClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewIngesterClient(cc *grpc.ClientConn) IngesterClient {
+	return &ingesterClient{cc}
+}
+
+func (c *ingesterClient) Push(ctx context.Context, in *cortexpb.WriteRequest, opts ...grpc.CallOption) (*cortexpb.WriteResponse, error) {
+	out := new(cortexpb.WriteResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/Push", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.StartTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
-		i--
-		dAtA[i] = 0x8
+	return out, nil
+}
+
+func (c *ingesterClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error) {
+	out := new(QueryResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/Query", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *LabelNamesResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *ingesterClient) QueryStream(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Ingester_QueryStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[0], "/cortex.Ingester/QueryStream", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	x := &ingesterQueryStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-func (m *LabelNamesResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type Ingester_QueryStreamClient interface {
+	Recv() (*QueryStreamResponse, error)
+	grpc.ClientStream
 }
 
-func (m *LabelNamesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.LabelNames) > 0 {
-		for iNdEx := len(m.LabelNames) - 1; iNdEx >= 0; iNdEx-- {
-			i -= len(m.LabelNames[iNdEx])
-			copy(dAtA[i:], m.LabelNames[iNdEx])
-			i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelNames[iNdEx])))
-			i--
-			dAtA[i] = 0xa
-		}
-	}
-	return len(dAtA) - i, nil
+type ingesterQueryStreamClient struct {
+	grpc.ClientStream
 }
 
-func (m *LabelNamesStreamResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
+func (x *ingesterQueryStreamClient) Recv() (*QueryStreamResponse, error) {
+	m := new(QueryStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return m, nil
 }
 
-func (m *LabelNamesStreamResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (c *ingesterClient) QueryExemplars(ctx context.Context, in *ExemplarQueryRequest, opts ...grpc.CallOption) (*ExemplarQueryResponse, error) {
+	out := new(ExemplarQueryResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/QueryExemplars", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (m *LabelNamesStreamResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.LabelNames) > 0 {
-		for iNdEx := len(m.LabelNames) - 1; iNdEx >= 0; iNdEx-- {
-			i -= len(m.LabelNames[iNdEx])
-			copy(dAtA[i:], m.LabelNames[iNdEx])
-			i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelNames[iNdEx])))
-			i--
-			dAtA[i] = 0xa
-		}
+func (c *ingesterClient) LabelValues(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (*LabelValuesResponse, error) {
+	out := new(LabelValuesResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/LabelValues", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *UserStatsRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *ingesterClient) LabelValuesStream(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (Ingester_LabelValuesStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[1], "/cortex.Ingester/LabelValuesStream", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	x := &ingesterLabelValuesStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-func (m *UserStatsRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type Ingester_LabelValuesStreamClient interface {
+	Recv() (*LabelValuesStreamResponse, error)
+	grpc.ClientStream
 }
 
-func (m *UserStatsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	return len(dAtA) - i, nil
+type ingesterLabelValuesStreamClient struct {
+	grpc.ClientStream
 }
 
-func (m *UserStatsResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
+func (x *ingesterLabelValuesStreamClient) Recv() (*LabelValuesStreamResponse, error) {
+	m := new(LabelValuesStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return m, nil
 }
 
-func (m *UserStatsResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (c *ingesterClient) LabelNames(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (*LabelNamesResponse, error) {
+	out := new(LabelNamesResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/LabelNames", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (m *UserStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.ActiveSeries != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.ActiveSeries))
-		i--
-		dAtA[i] = 0x28
-	}
-	if m.RuleIngestionRate != 0 {
-		i -= 8
-		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.RuleIngestionRate))))
-		i--
-		dAtA[i] = 0x21
+func (c *ingesterClient) LabelNamesStream(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (Ingester_LabelNamesStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[2], "/cortex.Ingester/LabelNamesStream", opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.ApiIngestionRate != 0 {
-		i -= 8
-		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ApiIngestionRate))))
-		i--
-		dAtA[i] = 0x19
+	x := &ingesterLabelNamesStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
 	}
-	if m.NumSeries != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.NumSeries))
-		i--
-		dAtA[i] = 0x10
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
 	}
-	if m.IngestionRate != 0 {
-		i -= 8
-		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.IngestionRate))))
-		i--
-		dAtA[i] = 0x9
+	return x, nil
+}
+
+type Ingester_LabelNamesStreamClient interface {
+	Recv() (*LabelNamesStreamResponse, error)
+	grpc.ClientStream
+}
+
+type ingesterLabelNamesStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingesterLabelNamesStreamClient) Recv() (*LabelNamesStreamResponse, error) {
+	m := new(LabelNamesStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return m, nil
 }
 
-func (m *UserIDStatsResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *ingesterClient) UserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UserStatsResponse, error) {
+	out := new(UserStatsResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/UserStats", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return out, nil
 }
 
-func (m *UserIDStatsResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (c *ingesterClient) AllUserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UsersStatsResponse, error) {
+	out := new(UsersStatsResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/AllUserStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (m *UserIDStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Data != nil {
-		{
-			size, err := m.Data.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintIngester(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x12
-	}
-	if len(m.UserId) > 0 {
-		i -= len(m.UserId)
-		copy(dAtA[i:], m.UserId)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.UserId)))
-		i--
-		dAtA[i] = 0xa
+func (c *ingesterClient) MetricsForLabelMatchers(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (*MetricsForLabelMatchersResponse, error) {
+	out := new(MetricsForLabelMatchersResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/MetricsForLabelMatchers", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *UsersStatsResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *ingesterClient) MetricsForLabelMatchersStream(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (Ingester_MetricsForLabelMatchersStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[3], "/cortex.Ingester/MetricsForLabelMatchersStream", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
-}
-
-func (m *UsersStatsResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *UsersStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Stats) > 0 {
-		for iNdEx := len(m.Stats) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Stats[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+	x := &ingesterMetricsForLabelMatchersStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *MetricsForLabelMatchersRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
+	if err := x.ClientStream.CloseSend(); err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return x, nil
 }
 
-func (m *MetricsForLabelMatchersRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type Ingester_MetricsForLabelMatchersStreamClient interface {
+	Recv() (*MetricsForLabelMatchersStreamResponse, error)
+	grpc.ClientStream
 }
 
-func (m *MetricsForLabelMatchersRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.MatchersSet) > 0 {
-		for iNdEx := len(m.MatchersSet) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.MatchersSet[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1a
-		}
-	}
-	if m.EndTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
-		i--
-		dAtA[i] = 0x10
-	}
-	if m.StartTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
-		i--
-		dAtA[i] = 0x8
-	}
-	return len(dAtA) - i, nil
+type ingesterMetricsForLabelMatchersStreamClient struct {
+	grpc.ClientStream
 }
 
-func (m *MetricsForLabelMatchersResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
+func (x *ingesterMetricsForLabelMatchersStreamClient) Recv() (*MetricsForLabelMatchersStreamResponse, error) {
+	m := new(MetricsForLabelMatchersStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
-}
-
-func (m *MetricsForLabelMatchersResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	return m, nil
 }
 
-func (m *MetricsForLabelMatchersResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Metric) > 0 {
-		for iNdEx := len(m.Metric) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Metric[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+func (c *ingesterClient) MetricsMetadata(ctx context.Context, in *MetricsMetadataRequest, opts ...grpc.CallOption) (*MetricsMetadataResponse, error) {
+	out := new(MetricsMetadataResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/MetricsMetadata", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *MetricsForLabelMatchersStreamResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *ingesterClient) TSDBStatus(ctx context.Context, in *TSDBStatusRequest, opts ...grpc.CallOption) (*TSDBStatusResponse, error) {
+	out := new(TSDBStatusResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/TSDBStatus", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
-}
-
-func (m *MetricsForLabelMatchersStreamResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	return out, nil
 }
 
-func (m *MetricsForLabelMatchersStreamResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Metric) > 0 {
-		for iNdEx := len(m.Metric) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Metric[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+func (c *ingesterClient) ScaleDown(ctx context.Context, in *ScaleDownRequest, opts ...grpc.CallOption) (*ScaleDownResponse, error) {
+	out := new(ScaleDownResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/ScaleDown", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *MetricsMetadataRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *ingesterClient) LabelValuesSketches(ctx context.Context, in *LabelValuesSketchesRequest, opts ...grpc.CallOption) (*LabelValuesSketchesResponse, error) {
+	out := new(LabelValuesSketchesResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/LabelValuesSketches", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
-}
-
-func (m *MetricsMetadataRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *MetricsMetadataRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *MetricsMetadataResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *ingesterClient) TransferTSDB(ctx context.Context, opts ...grpc.CallOption) (Ingester_TransferTSDBClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[4], "/cortex.Ingester/TransferTSDB", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	x := &ingesterTransferTSDBClient{stream}
+	return x, nil
 }
 
-func (m *MetricsMetadataResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type Ingester_TransferTSDBClient interface {
+	Send(*TimeSeriesFile) error
+	CloseAndRecv() (*TransferTSDBResponse, error)
+	grpc.ClientStream
 }
 
-func (m *MetricsMetadataResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Metadata) > 0 {
-		for iNdEx := len(m.Metadata) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Metadata[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
-	}
-	return len(dAtA) - i, nil
+type ingesterTransferTSDBClient struct {
+	grpc.ClientStream
 }
 
-func (m *TimeSeriesChunk) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+func (x *ingesterTransferTSDBClient) Send(m *TimeSeriesFile) error {
+	return x.ClientStream.SendMsg(m)
 }
 
-func (m *TimeSeriesChunk) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (x *ingesterTransferTSDBClient) CloseAndRecv() (*TransferTSDBResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(TransferTSDBResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
-func (m *TimeSeriesChunk) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Chunks) > 0 {
-		for iNdEx := len(m.Chunks) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Chunks[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x22
-		}
-	}
-	if len(m.Labels) > 0 {
-		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size := m.Labels[iNdEx].Size()
-				i -= size
-				if _, err := m.Labels[iNdEx].MarshalTo(dAtA[i:]); err != nil {
-					return 0, err
-				}
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1a
-		}
-	}
-	if len(m.UserId) > 0 {
-		i -= len(m.UserId)
-		copy(dAtA[i:], m.UserId)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.UserId)))
-		i--
-		dAtA[i] = 0x12
-	}
-	if len(m.FromIngesterId) > 0 {
-		i -= len(m.FromIngesterId)
-		copy(dAtA[i:], m.FromIngesterId)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.FromIngesterId)))
-		i--
-		dAtA[i] = 0xa
-	}
-	return len(dAtA) - i, nil
+// IngesterServer is the server API for Ingester service.
+type IngesterServer interface {
+	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
+	Query(context.Context, *QueryRequest) (*QueryResponse, error)
+	QueryStream(*QueryRequest, Ingester_QueryStreamServer) error
+	QueryExemplars(context.Context, *ExemplarQueryRequest) (*ExemplarQueryResponse, error)
+	LabelValues(context.Context, *LabelValuesRequest) (*LabelValuesResponse, error)
+	LabelValuesStream(*LabelValuesRequest, Ingester_LabelValuesStreamServer) error
+	LabelNames(context.Context, *LabelNamesRequest) (*LabelNamesResponse, error)
+	LabelNamesStream(*LabelNamesRequest, Ingester_LabelNamesStreamServer) error
+	UserStats(context.Context, *UserStatsRequest) (*UserStatsResponse, error)
+	AllUserStats(context.Context, *UserStatsRequest) (*UsersStatsResponse, error)
+	MetricsForLabelMatchers(context.Context, *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error)
+	MetricsForLabelMatchersStream(*MetricsForLabelMatchersRequest, Ingester_MetricsForLabelMatchersStreamServer) error
+	MetricsMetadata(context.Context, *MetricsMetadataRequest) (*MetricsMetadataResponse, error)
+	TSDBStatus(context.Context, *TSDBStatusRequest) (*TSDBStatusResponse, error)
+	ScaleDown(context.Context, *ScaleDownRequest) (*ScaleDownResponse, error)
+	LabelValuesSketches(context.Context, *LabelValuesSketchesRequest) (*LabelValuesSketchesResponse, error)
+	// TransferTSDB transfers all files of a LEAVING ingester's TSDBs to a PENDING one.
+	TransferTSDB(Ingester_TransferTSDBServer) error
 }
 
-func (m *Chunk) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+// UnimplementedIngesterServer can be embedded to have forward compatible implementations.
+type UnimplementedIngesterServer struct {
 }
 
-func (m *Chunk) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (*UnimplementedIngesterServer) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Push not implemented")
+}
+func (*UnimplementedIngesterServer) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (*UnimplementedIngesterServer) QueryStream(req *QueryRequest, srv Ingester_QueryStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method QueryStream not implemented")
+}
+func (*UnimplementedIngesterServer) QueryExemplars(ctx context.Context, req *ExemplarQueryRequest) (*ExemplarQueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryExemplars not implemented")
+}
+func (*UnimplementedIngesterServer) LabelValues(ctx context.Context, req *LabelValuesRequest) (*LabelValuesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LabelValues not implemented")
+}
+func (*UnimplementedIngesterServer) LabelValuesStream(req *LabelValuesRequest, srv Ingester_LabelValuesStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method LabelValuesStream not implemented")
+}
+func (*UnimplementedIngesterServer) LabelNames(ctx context.Context, req *LabelNamesRequest) (*LabelNamesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LabelNames not implemented")
+}
+func (*UnimplementedIngesterServer) LabelNamesStream(req *LabelNamesRequest, srv Ingester_LabelNamesStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method LabelNamesStream not implemented")
+}
+func (*UnimplementedIngesterServer) UserStats(ctx context.Context, req *UserStatsRequest) (*UserStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UserStats not implemented")
+}
+func (*UnimplementedIngesterServer) AllUserStats(ctx context.Context, req *UserStatsRequest) (*UsersStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AllUserStats not implemented")
+}
+func (*UnimplementedIngesterServer) MetricsForLabelMatchers(ctx context.Context, req *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MetricsForLabelMatchers not implemented")
+}
+func (*UnimplementedIngesterServer) MetricsForLabelMatchersStream(req *MetricsForLabelMatchersRequest, srv Ingester_MetricsForLabelMatchersStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method MetricsForLabelMatchersStream not implemented")
+}
+func (*UnimplementedIngesterServer) MetricsMetadata(ctx context.Context, req *MetricsMetadataRequest) (*MetricsMetadataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MetricsMetadata not implemented")
+}
+func (*UnimplementedIngesterServer) TSDBStatus(ctx context.Context, req *TSDBStatusRequest) (*TSDBStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TSDBStatus not implemented")
+}
+func (*UnimplementedIngesterServer) ScaleDown(ctx context.Context, req *ScaleDownRequest) (*ScaleDownResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScaleDown not implemented")
+}
+func (*UnimplementedIngesterServer) LabelValuesSketches(ctx context.Context, req *LabelValuesSketchesRequest) (*LabelValuesSketchesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LabelValuesSketches not implemented")
+}
+func (*UnimplementedIngesterServer) TransferTSDB(srv Ingester_TransferTSDBServer) error {
+	return status.Errorf(codes.Unimplemented, "method TransferTSDB not implemented")
 }
 
-func (m *Chunk) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Data) > 0 {
-		i -= len(m.Data)
-		copy(dAtA[i:], m.Data)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.Data)))
-		i--
-		dAtA[i] = 0x22
+func RegisterIngesterServer(s *grpc.Server, srv IngesterServer) {
+	s.RegisterService(&_Ingester_serviceDesc, srv)
+}
+
+func _Ingester_Push_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(cortexpb.WriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.Encoding != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.Encoding))
-		i--
-		dAtA[i] = 0x18
+	if interceptor == nil {
+		return srv.(IngesterServer).Push(ctx, in)
 	}
-	if m.EndTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
-		i--
-		dAtA[i] = 0x10
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/Push",
 	}
-	if m.StartTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
-		i--
-		dAtA[i] = 0x8
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).Push(ctx, req.(*cortexpb.WriteRequest))
 	}
-	return len(dAtA) - i, nil
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *LabelMatchers) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
+func _Ingester_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
-}
-
-func (m *LabelMatchers) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	if interceptor == nil {
+		return srv.(IngesterServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/Query",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *LabelMatchers) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Matchers) > 0 {
-		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintIngester(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+func _Ingester_QueryStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(QueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
 	}
-	return len(dAtA) - i, nil
+	return srv.(IngesterServer).QueryStream(m, &ingesterQueryStreamServer{stream})
 }
 
-func (m *LabelMatcher) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+type Ingester_QueryStreamServer interface {
+	Send(*QueryStreamResponse) error
+	grpc.ServerStream
 }
 
-func (m *LabelMatcher) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type ingesterQueryStreamServer struct {
+	grpc.ServerStream
 }
 
-func (m *LabelMatcher) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Value) > 0 {
-		i -= len(m.Value)
-		copy(dAtA[i:], m.Value)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.Value)))
-		i--
-		dAtA[i] = 0x1a
-	}
-	if len(m.Name) > 0 {
-		i -= len(m.Name)
-		copy(dAtA[i:], m.Name)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.Name)))
-		i--
-		dAtA[i] = 0x12
-	}
-	if m.Type != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.Type))
-		i--
-		dAtA[i] = 0x8
-	}
-	return len(dAtA) - i, nil
+func (x *ingesterQueryStreamServer) Send(m *QueryStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
 }
 
-func (m *TimeSeriesFile) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
+func _Ingester_QueryExemplars_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExemplarQueryRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
-}
-
-func (m *TimeSeriesFile) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	if interceptor == nil {
+		return srv.(IngesterServer).QueryExemplars(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/QueryExemplars",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).QueryExemplars(ctx, req.(*ExemplarQueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *TimeSeriesFile) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Data) > 0 {
-		i -= len(m.Data)
-		copy(dAtA[i:], m.Data)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.Data)))
-		i--
-		dAtA[i] = 0x22
+func _Ingester_LabelValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LabelValuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if len(m.Filename) > 0 {
-		i -= len(m.Filename)
-		copy(dAtA[i:], m.Filename)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.Filename)))
-		i--
-		dAtA[i] = 0x1a
+	if interceptor == nil {
+		return srv.(IngesterServer).LabelValues(ctx, in)
 	}
-	if len(m.UserId) > 0 {
-		i -= len(m.UserId)
-		copy(dAtA[i:], m.UserId)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.UserId)))
-		i--
-		dAtA[i] = 0x12
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/LabelValues",
 	}
-	if len(m.FromIngesterId) > 0 {
-		i -= len(m.FromIngesterId)
-		copy(dAtA[i:], m.FromIngesterId)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.FromIngesterId)))
-		i--
-		dAtA[i] = 0xa
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).LabelValues(ctx, req.(*LabelValuesRequest))
 	}
-	return len(dAtA) - i, nil
+	return interceptor(ctx, in, info, handler)
 }
 
-func encodeVarintIngester(dAtA []byte, offset int, v uint64) int {
-	offset -= sovIngester(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func _Ingester_LabelValuesStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LabelValuesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return srv.(IngesterServer).LabelValuesStream(m, &ingesterLabelValuesStreamServer{stream})
 }
-func (m *ReadRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Queries) > 0 {
-		for _, e := range m.Queries {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
-	}
-	return n
+
+type Ingester_LabelValuesStreamServer interface {
+	Send(*LabelValuesStreamResponse) error
+	grpc.ServerStream
 }
 
-func (m *ReadResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Results) > 0 {
-		for _, e := range m.Results {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
-	}
-	return n
+type ingesterLabelValuesStreamServer struct {
+	grpc.ServerStream
 }
 
-func (m *QueryRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func (x *ingesterLabelValuesStreamServer) Send(m *LabelValuesStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Ingester_LabelNames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LabelNamesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.StartTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+	if interceptor == nil {
+		return srv.(IngesterServer).LabelNames(ctx, in)
 	}
-	if m.EndTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/LabelNames",
 	}
-	if len(m.Matchers) > 0 {
-		for _, e := range m.Matchers {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).LabelNames(ctx, req.(*LabelNamesRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *ExemplarQueryRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func _Ingester_LabelNamesStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LabelNamesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
 	}
-	var l int
-	_ = l
-	if m.StartTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+	return srv.(IngesterServer).LabelNamesStream(m, &ingesterLabelNamesStreamServer{stream})
+}
+
+type Ingester_LabelNamesStreamServer interface {
+	Send(*LabelNamesStreamResponse) error
+	grpc.ServerStream
+}
+
+type ingesterLabelNamesStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingesterLabelNamesStreamServer) Send(m *LabelNamesStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Ingester_UserStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.EndTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	if interceptor == nil {
+		return srv.(IngesterServer).UserStats(ctx, in)
 	}
-	if len(m.Matchers) > 0 {
-		for _, e := range m.Matchers {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/UserStats",
 	}
-	return n
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).UserStats(ctx, req.(*UserStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *QueryResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func _Ingester_AllUserStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if len(m.Timeseries) > 0 {
-		for _, e := range m.Timeseries {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
+	if interceptor == nil {
+		return srv.(IngesterServer).AllUserStats(ctx, in)
 	}
-	return n
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/AllUserStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).AllUserStats(ctx, req.(*UserStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *QueryStreamResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func _Ingester_MetricsForLabelMatchers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetricsForLabelMatchersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if len(m.Chunkseries) > 0 {
-		for _, e := range m.Chunkseries {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
+	if interceptor == nil {
+		return srv.(IngesterServer).MetricsForLabelMatchers(ctx, in)
 	}
-	if len(m.Timeseries) > 0 {
-		for _, e := range m.Timeseries {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/MetricsForLabelMatchers",
 	}
-	return n
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).MetricsForLabelMatchers(ctx, req.(*MetricsForLabelMatchersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *ExemplarQueryResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Timeseries) > 0 {
-		for _, e := range m.Timeseries {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
+func _Ingester_MetricsForLabelMatchersStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MetricsForLabelMatchersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
 	}
-	return n
+	return srv.(IngesterServer).MetricsForLabelMatchersStream(m, &ingesterMetricsForLabelMatchersStreamServer{stream})
 }
 
-func (m *LabelValuesRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.LabelName)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
-	}
-	if m.StartTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.StartTimestampMs))
-	}
-	if m.EndTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.EndTimestampMs))
-	}
-	if m.Matchers != nil {
-		l = m.Matchers.Size()
-		n += 1 + l + sovIngester(uint64(l))
-	}
-	return n
+type Ingester_MetricsForLabelMatchersStreamServer interface {
+	Send(*MetricsForLabelMatchersStreamResponse) error
+	grpc.ServerStream
 }
 
-func (m *LabelValuesResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.LabelValues) > 0 {
-		for _, s := range m.LabelValues {
-			l = len(s)
-			n += 1 + l + sovIngester(uint64(l))
-		}
-	}
-	return n
+type ingesterMetricsForLabelMatchersStreamServer struct {
+	grpc.ServerStream
 }
 
-func (m *LabelValuesStreamResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.LabelValues) > 0 {
-		for _, s := range m.LabelValues {
-			l = len(s)
-			n += 1 + l + sovIngester(uint64(l))
-		}
-	}
-	return n
+func (x *ingesterMetricsForLabelMatchersStreamServer) Send(m *MetricsForLabelMatchersStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
 }
 
-func (m *LabelNamesRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.StartTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+func _Ingester_MetricsMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetricsMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.EndTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	if interceptor == nil {
+		return srv.(IngesterServer).MetricsMetadata(ctx, in)
 	}
-	return n
-}
-
-func (m *LabelNamesResponse) Size() (n int) {
-	if m == nil {
-		return 0
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/MetricsMetadata",
 	}
-	var l int
-	_ = l
-	if len(m.LabelNames) > 0 {
-		for _, s := range m.LabelNames {
-			l = len(s)
-			n += 1 + l + sovIngester(uint64(l))
-		}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).MetricsMetadata(ctx, req.(*MetricsMetadataRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *LabelNamesStreamResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func _Ingester_TSDBStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TSDBStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if len(m.LabelNames) > 0 {
-		for _, s := range m.LabelNames {
-			l = len(s)
-			n += 1 + l + sovIngester(uint64(l))
-		}
+	if interceptor == nil {
+		return srv.(IngesterServer).TSDBStatus(ctx, in)
 	}
-	return n
-}
-
-func (m *UserStatsRequest) Size() (n int) {
-	if m == nil {
-		return 0
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/TSDBStatus",
 	}
-	var l int
-	_ = l
-	return n
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).TSDBStatus(ctx, req.(*TSDBStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *UserStatsResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.IngestionRate != 0 {
-		n += 9
-	}
-	if m.NumSeries != 0 {
-		n += 1 + sovIngester(uint64(m.NumSeries))
+func _Ingester_ScaleDown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScaleDownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.ApiIngestionRate != 0 {
-		n += 9
+	if interceptor == nil {
+		return srv.(IngesterServer).ScaleDown(ctx, in)
 	}
-	if m.RuleIngestionRate != 0 {
-		n += 9
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/ScaleDown",
 	}
-	if m.ActiveSeries != 0 {
-		n += 1 + sovIngester(uint64(m.ActiveSeries))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).ScaleDown(ctx, req.(*ScaleDownRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *UserIDStatsResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.UserId)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
+func _Ingester_LabelValuesSketches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LabelValuesSketchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.Data != nil {
-		l = m.Data.Size()
-		n += 1 + l + sovIngester(uint64(l))
+	if interceptor == nil {
+		return srv.(IngesterServer).LabelValuesSketches(ctx, in)
 	}
-	return n
-}
-
-func (m *UsersStatsResponse) Size() (n int) {
-	if m == nil {
-		return 0
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/LabelValuesSketches",
 	}
-	var l int
-	_ = l
-	if len(m.Stats) > 0 {
-		for _, e := range m.Stats {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).LabelValuesSketches(ctx, req.(*LabelValuesSketchesRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *MetricsForLabelMatchersRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.StartTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.StartTimestampMs))
-	}
-	if m.EndTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.EndTimestampMs))
-	}
-	if len(m.MatchersSet) > 0 {
-		for _, e := range m.MatchersSet {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
-	}
-	return n
+func _Ingester_TransferTSDB_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(IngesterServer).TransferTSDB(&ingesterTransferTSDBServer{stream})
 }
 
-func (m *MetricsForLabelMatchersResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Metric) > 0 {
-		for _, e := range m.Metric {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
-	}
-	return n
+type Ingester_TransferTSDBServer interface {
+	SendAndClose(*TransferTSDBResponse) error
+	Recv() (*TimeSeriesFile, error)
+	grpc.ServerStream
 }
 
-func (m *MetricsForLabelMatchersStreamResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Metric) > 0 {
-		for _, e := range m.Metric {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
-	}
-	return n
+type ingesterTransferTSDBServer struct {
+	grpc.ServerStream
 }
 
-func (m *MetricsMetadataRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	return n
+func (x *ingesterTransferTSDBServer) SendAndClose(m *TransferTSDBResponse) error {
+	return x.ServerStream.SendMsg(m)
 }
 
-func (m *MetricsMetadataResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Metadata) > 0 {
-		for _, e := range m.Metadata {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
+func (x *ingesterTransferTSDBServer) Recv() (*TimeSeriesFile, error) {
+	m := new(TimeSeriesFile)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	return n
+	return m, nil
 }
 
-func (m *TimeSeriesChunk) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.FromIngesterId)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
-	}
-	l = len(m.UserId)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
-	}
-	if len(m.Labels) > 0 {
-		for _, e := range m.Labels {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
-	}
-	if len(m.Chunks) > 0 {
-		for _, e := range m.Chunks {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
-		}
-	}
-	return n
+var _Ingester_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "cortex.Ingester",
+	HandlerType: (*IngesterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Push",
+			Handler:    _Ingester_Push_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _Ingester_Query_Handler,
+		},
+		{
+			MethodName: "QueryExemplars",
+			Handler:    _Ingester_QueryExemplars_Handler,
+		},
+		{
+			MethodName: "LabelValues",
+			Handler:    _Ingester_LabelValues_Handler,
+		},
+		{
+			MethodName: "LabelNames",
+			Handler:    _Ingester_LabelNames_Handler,
+		},
+		{
+			MethodName: "UserStats",
+			Handler:    _Ingester_UserStats_Handler,
+		},
+		{
+			MethodName: "AllUserStats",
+			Handler:    _Ingester_AllUserStats_Handler,
+		},
+		{
+			MethodName: "MetricsForLabelMatchers",
+			Handler:    _Ingester_MetricsForLabelMatchers_Handler,
+		},
+		{
+			MethodName: "MetricsMetadata",
+			Handler:    _Ingester_MetricsMetadata_Handler,
+		},
+		{
+			MethodName: "TSDBStatus",
+			Handler:    _Ingester_TSDBStatus_Handler,
+		},
+		{
+			MethodName: "ScaleDown",
+			Handler:    _Ingester_ScaleDown_Handler,
+		},
+		{
+			MethodName: "LabelValuesSketches",
+			Handler:    _Ingester_LabelValuesSketches_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "QueryStream",
+			Handler:       _Ingester_QueryStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "LabelValuesStream",
+			Handler:       _Ingester_LabelValuesStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "LabelNamesStream",
+			Handler:       _Ingester_LabelNamesStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "MetricsForLabelMatchersStream",
+			Handler:       _Ingester_MetricsForLabelMatchersStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "TransferTSDB",
+			Handler:       _Ingester_TransferTSDB_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "ingester.proto",
 }
 
-func (m *Chunk) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.StartTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.StartTimestampMs))
-	}
-	if m.EndTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.EndTimestampMs))
-	}
-	if m.Encoding != 0 {
-		n += 1 + sovIngester(uint64(m.Encoding))
-	}
-	l = len(m.Data)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
+func (m *ReadRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *LabelMatchers) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+func (m *ReadRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReadRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if len(m.Matchers) > 0 {
-		for _, e := range m.Matchers {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
+	if len(m.Queries) > 0 {
+		for iNdEx := len(m.Queries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Queries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
 		}
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *LabelMatcher) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ReadResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ReadResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReadResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.Type != 0 {
-		n += 1 + sovIngester(uint64(m.Type))
-	}
-	l = len(m.Name)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
-	}
-	l = len(m.Value)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
+	if len(m.Results) > 0 {
+		for iNdEx := len(m.Results) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Results[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *TimeSeriesFile) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *QueryRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *QueryRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	l = len(m.FromIngesterId)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
+	if m.SeriesLimit != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.SeriesLimit))
+		i--
+		dAtA[i] = 0x28
 	}
-	l = len(m.UserId)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
+	if m.SortSeries {
+		i--
+		if m.SortSeries {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
 	}
-	l = len(m.Filename)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
 	}
-	l = len(m.Data)
-	if l > 0 {
-		n += 1 + l + sovIngester(uint64(l))
+	if m.EndTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
+		i--
+		dAtA[i] = 0x10
 	}
-	return n
+	if m.StartTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
 }
 
-func sovIngester(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozIngester(x uint64) (n int) {
-	return sovIngester(uint64((x << 1) ^ uint64((int64(x) >> 63))))
-}
-func (this *ReadRequest) String() string {
-	if this == nil {
-		return "nil"
-	}
-	repeatedStringForQueries := "[]*QueryRequest{"
-	for _, f := range this.Queries {
-		repeatedStringForQueries += strings.Replace(f.String(), "QueryRequest", "QueryRequest", 1) + ","
+func (m *ExemplarQueryRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	repeatedStringForQueries += "}"
-	s := strings.Join([]string{`&ReadRequest{`,
-		`Queries:` + repeatedStringForQueries + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *ReadResponse) String() string {
-	if this == nil {
-		return "nil"
-	}
-	repeatedStringForResults := "[]*QueryResponse{"
-	for _, f := range this.Results {
-		repeatedStringForResults += strings.Replace(f.String(), "QueryResponse", "QueryResponse", 1) + ","
-	}
-	repeatedStringForResults += "}"
-	s := strings.Join([]string{`&ReadResponse{`,
-		`Results:` + repeatedStringForResults + `,`,
-		`}`,
-	}, "")
-	return s
+
+func (m *ExemplarQueryRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
-func (this *QueryRequest) String() string {
-	if this == nil {
-		return "nil"
-	}
-	repeatedStringForMatchers := "[]*LabelMatcher{"
-	for _, f := range this.Matchers {
-		repeatedStringForMatchers += strings.Replace(f.String(), "LabelMatcher", "LabelMatcher", 1) + ","
+
+func (m *ExemplarQueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
 	}
-	repeatedStringForMatchers += "}"
-	s := strings.Join([]string{`&QueryRequest{`,
-		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
-		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
-		`Matchers:` + repeatedStringForMatchers + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *ExemplarQueryRequest) String() string {
-	if this == nil {
-		return "nil"
+	if m.EndTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
+		i--
+		dAtA[i] = 0x10
 	}
-	repeatedStringForMatchers := "[]*LabelMatchers{"
-	for _, f := range this.Matchers {
-		repeatedStringForMatchers += strings.Replace(f.String(), "LabelMatchers", "LabelMatchers", 1) + ","
+	if m.StartTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
+		i--
+		dAtA[i] = 0x8
 	}
-	repeatedStringForMatchers += "}"
-	s := strings.Join([]string{`&ExemplarQueryRequest{`,
-		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
-		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
-		`Matchers:` + repeatedStringForMatchers + `,`,
-		`}`,
-	}, "")
-	return s
+	return len(dAtA) - i, nil
 }
-func (this *QueryResponse) String() string {
-	if this == nil {
-		return "nil"
-	}
-	repeatedStringForTimeseries := "[]TimeSeries{"
-	for _, f := range this.Timeseries {
-		repeatedStringForTimeseries += fmt.Sprintf("%v", f) + ","
+
+func (m *QueryResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	repeatedStringForTimeseries += "}"
-	s := strings.Join([]string{`&QueryResponse{`,
-		`Timeseries:` + repeatedStringForTimeseries + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *QueryStreamResponse) String() string {
-	if this == nil {
-		return "nil"
-	}
-	repeatedStringForChunkseries := "[]TimeSeriesChunk{"
-	for _, f := range this.Chunkseries {
-		repeatedStringForChunkseries += strings.Replace(strings.Replace(f.String(), "TimeSeriesChunk", "TimeSeriesChunk", 1), `&`, ``, 1) + ","
+
+func (m *QueryResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Timeseries) > 0 {
+		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Timeseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	repeatedStringForChunkseries += "}"
-	repeatedStringForTimeseries := "[]TimeSeries{"
-	for _, f := range this.Timeseries {
-		repeatedStringForTimeseries += fmt.Sprintf("%v", f) + ","
+	return len(dAtA) - i, nil
+}
+
+func (m *QueryStreamResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	repeatedStringForTimeseries += "}"
-	s := strings.Join([]string{`&QueryStreamResponse{`,
-		`Chunkseries:` + repeatedStringForChunkseries + `,`,
-		`Timeseries:` + repeatedStringForTimeseries + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *ExemplarQueryResponse) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *QueryStreamResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryStreamResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.SeriesLimitReached {
+		i--
+		if m.SeriesLimitReached {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
 	}
-	repeatedStringForTimeseries := "[]TimeSeries{"
-	for _, f := range this.Timeseries {
-		repeatedStringForTimeseries += fmt.Sprintf("%v", f) + ","
+	if len(m.Timeseries) > 0 {
+		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Timeseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
 	}
-	repeatedStringForTimeseries += "}"
-	s := strings.Join([]string{`&ExemplarQueryResponse{`,
-		`Timeseries:` + repeatedStringForTimeseries + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *LabelValuesRequest) String() string {
-	if this == nil {
-		return "nil"
+	if len(m.Chunkseries) > 0 {
+		for iNdEx := len(m.Chunkseries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Chunkseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	s := strings.Join([]string{`&LabelValuesRequest{`,
-		`LabelName:` + fmt.Sprintf("%v", this.LabelName) + `,`,
-		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
-		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
-		`Matchers:` + strings.Replace(this.Matchers.String(), "LabelMatchers", "LabelMatchers", 1) + `,`,
-		`}`,
-	}, "")
-	return s
+	return len(dAtA) - i, nil
 }
-func (this *LabelValuesResponse) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *ExemplarQueryResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	s := strings.Join([]string{`&LabelValuesResponse{`,
-		`LabelValues:` + fmt.Sprintf("%v", this.LabelValues) + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *LabelValuesStreamResponse) String() string {
-	if this == nil {
-		return "nil"
-	}
-	s := strings.Join([]string{`&LabelValuesStreamResponse{`,
-		`LabelValues:` + fmt.Sprintf("%v", this.LabelValues) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *LabelNamesRequest) String() string {
-	if this == nil {
-		return "nil"
-	}
-	s := strings.Join([]string{`&LabelNamesRequest{`,
-		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
-		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
-		`}`,
-	}, "")
-	return s
+
+func (m *ExemplarQueryResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
-func (this *LabelNamesResponse) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *ExemplarQueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Timeseries) > 0 {
+		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Timeseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	s := strings.Join([]string{`&LabelNamesResponse{`,
-		`LabelNames:` + fmt.Sprintf("%v", this.LabelNames) + `,`,
-		`}`,
-	}, "")
-	return s
+	return len(dAtA) - i, nil
 }
-func (this *LabelNamesStreamResponse) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelValuesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	s := strings.Join([]string{`&LabelNamesStreamResponse{`,
-		`LabelNames:` + fmt.Sprintf("%v", this.LabelNames) + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *UserStatsRequest) String() string {
-	if this == nil {
-		return "nil"
-	}
-	s := strings.Join([]string{`&UserStatsRequest{`,
-		`}`,
-	}, "")
-	return s
+
+func (m *LabelValuesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
-func (this *UserStatsResponse) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelValuesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Matchers != nil {
+		{
+			size, err := m.Matchers.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIngester(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
 	}
-	s := strings.Join([]string{`&UserStatsResponse{`,
-		`IngestionRate:` + fmt.Sprintf("%v", this.IngestionRate) + `,`,
-		`NumSeries:` + fmt.Sprintf("%v", this.NumSeries) + `,`,
-		`ApiIngestionRate:` + fmt.Sprintf("%v", this.ApiIngestionRate) + `,`,
-		`RuleIngestionRate:` + fmt.Sprintf("%v", this.RuleIngestionRate) + `,`,
-		`ActiveSeries:` + fmt.Sprintf("%v", this.ActiveSeries) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *UserIDStatsResponse) String() string {
-	if this == nil {
-		return "nil"
+	if m.EndTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
+		i--
+		dAtA[i] = 0x18
 	}
-	s := strings.Join([]string{`&UserIDStatsResponse{`,
-		`UserId:` + fmt.Sprintf("%v", this.UserId) + `,`,
-		`Data:` + strings.Replace(this.Data.String(), "UserStatsResponse", "UserStatsResponse", 1) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *UsersStatsResponse) String() string {
-	if this == nil {
-		return "nil"
+	if m.StartTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
+		i--
+		dAtA[i] = 0x10
 	}
-	repeatedStringForStats := "[]*UserIDStatsResponse{"
-	for _, f := range this.Stats {
-		repeatedStringForStats += strings.Replace(f.String(), "UserIDStatsResponse", "UserIDStatsResponse", 1) + ","
+	if len(m.LabelName) > 0 {
+		i -= len(m.LabelName)
+		copy(dAtA[i:], m.LabelName)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelName)))
+		i--
+		dAtA[i] = 0xa
 	}
-	repeatedStringForStats += "}"
-	s := strings.Join([]string{`&UsersStatsResponse{`,
-		`Stats:` + repeatedStringForStats + `,`,
-		`}`,
-	}, "")
-	return s
+	return len(dAtA) - i, nil
 }
-func (this *MetricsForLabelMatchersRequest) String() string {
-	if this == nil {
-		return "nil"
-	}
-	repeatedStringForMatchersSet := "[]*LabelMatchers{"
-	for _, f := range this.MatchersSet {
-		repeatedStringForMatchersSet += strings.Replace(f.String(), "LabelMatchers", "LabelMatchers", 1) + ","
+
+func (m *LabelValuesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	repeatedStringForMatchersSet += "}"
-	s := strings.Join([]string{`&MetricsForLabelMatchersRequest{`,
-		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
-		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
-		`MatchersSet:` + repeatedStringForMatchersSet + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *MetricsForLabelMatchersResponse) String() string {
-	if this == nil {
-		return "nil"
-	}
-	repeatedStringForMetric := "[]*Metric{"
-	for _, f := range this.Metric {
-		repeatedStringForMetric += strings.Replace(fmt.Sprintf("%v", f), "Metric", "cortexpb.Metric", 1) + ","
-	}
-	repeatedStringForMetric += "}"
-	s := strings.Join([]string{`&MetricsForLabelMatchersResponse{`,
-		`Metric:` + repeatedStringForMetric + `,`,
-		`}`,
-	}, "")
-	return s
+
+func (m *LabelValuesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
-func (this *MetricsForLabelMatchersStreamResponse) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelValuesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.LabelValues) > 0 {
+		for iNdEx := len(m.LabelValues) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.LabelValues[iNdEx])
+			copy(dAtA[i:], m.LabelValues[iNdEx])
+			i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelValues[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	repeatedStringForMetric := "[]*Metric{"
-	for _, f := range this.Metric {
-		repeatedStringForMetric += strings.Replace(fmt.Sprintf("%v", f), "Metric", "cortexpb.Metric", 1) + ","
+	return len(dAtA) - i, nil
+}
+
+func (m *LabelValuesStreamResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	repeatedStringForMetric += "}"
-	s := strings.Join([]string{`&MetricsForLabelMatchersStreamResponse{`,
-		`Metric:` + repeatedStringForMetric + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *MetricsMetadataRequest) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelValuesStreamResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValuesStreamResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.LabelValues) > 0 {
+		for iNdEx := len(m.LabelValues) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.LabelValues[iNdEx])
+			copy(dAtA[i:], m.LabelValues[iNdEx])
+			i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelValues[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	s := strings.Join([]string{`&MetricsMetadataRequest{`,
-		`}`,
-	}, "")
-	return s
+	return len(dAtA) - i, nil
 }
-func (this *MetricsMetadataResponse) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelNamesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	repeatedStringForMetadata := "[]*MetricMetadata{"
-	for _, f := range this.Metadata {
-		repeatedStringForMetadata += strings.Replace(fmt.Sprintf("%v", f), "MetricMetadata", "cortexpb.MetricMetadata", 1) + ","
+	return dAtA[:n], nil
+}
+
+func (m *LabelNamesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelNamesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.EndTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
+		i--
+		dAtA[i] = 0x10
 	}
-	repeatedStringForMetadata += "}"
-	s := strings.Join([]string{`&MetricsMetadataResponse{`,
-		`Metadata:` + repeatedStringForMetadata + `,`,
-		`}`,
-	}, "")
-	return s
+	if m.StartTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
 }
-func (this *TimeSeriesChunk) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelNamesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	repeatedStringForChunks := "[]Chunk{"
-	for _, f := range this.Chunks {
-		repeatedStringForChunks += strings.Replace(strings.Replace(f.String(), "Chunk", "Chunk", 1), `&`, ``, 1) + ","
+	return dAtA[:n], nil
+}
+
+func (m *LabelNamesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelNamesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.LabelNames) > 0 {
+		for iNdEx := len(m.LabelNames) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.LabelNames[iNdEx])
+			copy(dAtA[i:], m.LabelNames[iNdEx])
+			i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelNames[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	repeatedStringForChunks += "}"
-	s := strings.Join([]string{`&TimeSeriesChunk{`,
-		`FromIngesterId:` + fmt.Sprintf("%v", this.FromIngesterId) + `,`,
-		`UserId:` + fmt.Sprintf("%v", this.UserId) + `,`,
-		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
-		`Chunks:` + repeatedStringForChunks + `,`,
-		`}`,
-	}, "")
-	return s
+	return len(dAtA) - i, nil
 }
-func (this *Chunk) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelNamesStreamResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	s := strings.Join([]string{`&Chunk{`,
-		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
-		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
-		`Encoding:` + fmt.Sprintf("%v", this.Encoding) + `,`,
-		`Data:` + fmt.Sprintf("%v", this.Data) + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *LabelMatchers) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelNamesStreamResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelNamesStreamResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.LabelNames) > 0 {
+		for iNdEx := len(m.LabelNames) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.LabelNames[iNdEx])
+			copy(dAtA[i:], m.LabelNames[iNdEx])
+			i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelNames[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	repeatedStringForMatchers := "[]*LabelMatcher{"
-	for _, f := range this.Matchers {
-		repeatedStringForMatchers += strings.Replace(f.String(), "LabelMatcher", "LabelMatcher", 1) + ","
+	return len(dAtA) - i, nil
+}
+
+func (m *UserStatsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	repeatedStringForMatchers += "}"
-	s := strings.Join([]string{`&LabelMatchers{`,
-		`Matchers:` + repeatedStringForMatchers + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *LabelMatcher) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *UserStatsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UserStatsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *UserStatsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	s := strings.Join([]string{`&LabelMatcher{`,
-		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
-		`Name:` + fmt.Sprintf("%v", this.Name) + `,`,
-		`Value:` + fmt.Sprintf("%v", this.Value) + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *TimeSeriesFile) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *UserStatsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UserStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.ActiveSeries != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.ActiveSeries))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.RuleIngestionRate != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.RuleIngestionRate))))
+		i--
+		dAtA[i] = 0x21
+	}
+	if m.ApiIngestionRate != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ApiIngestionRate))))
+		i--
+		dAtA[i] = 0x19
+	}
+	if m.NumSeries != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.NumSeries))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.IngestionRate != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.IngestionRate))))
+		i--
+		dAtA[i] = 0x9
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *UserIDStatsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UserIDStatsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UserIDStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Data != nil {
+		{
+			size, err := m.Data.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIngester(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.UserId) > 0 {
+		i -= len(m.UserId)
+		copy(dAtA[i:], m.UserId)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.UserId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *UsersStatsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UsersStatsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UsersStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Stats) > 0 {
+		for iNdEx := len(m.Stats) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Stats[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetricsForLabelMatchersRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetricsForLabelMatchersRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetricsForLabelMatchersRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.MatchersSet) > 0 {
+		for iNdEx := len(m.MatchersSet) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.MatchersSet[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.EndTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.StartTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetricsForLabelMatchersResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetricsForLabelMatchersResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetricsForLabelMatchersResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for iNdEx := len(m.Metric) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Metric[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetricsForLabelMatchersStreamResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetricsForLabelMatchersStreamResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetricsForLabelMatchersStreamResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for iNdEx := len(m.Metric) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Metric[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetricsMetadataRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetricsMetadataRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetricsMetadataRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *MetricsMetadataResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetricsMetadataResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetricsMetadataResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Metadata) > 0 {
+		for iNdEx := len(m.Metadata) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Metadata[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TSDBStatusRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TSDBStatusRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TSDBStatusRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Limit != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TSDBStatusResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TSDBStatusResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TSDBStatusResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.SeriesCountByLabelValuePair) > 0 {
+		for iNdEx := len(m.SeriesCountByLabelValuePair) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SeriesCountByLabelValuePair[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x4a
+		}
+	}
+	if len(m.MemoryInBytesByLabelName) > 0 {
+		for iNdEx := len(m.MemoryInBytesByLabelName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.MemoryInBytesByLabelName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x42
+		}
+	}
+	if len(m.LabelValueCountByLabelName) > 0 {
+		for iNdEx := len(m.LabelValueCountByLabelName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.LabelValueCountByLabelName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if len(m.SeriesCountByMetricName) > 0 {
+		for iNdEx := len(m.SeriesCountByMetricName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SeriesCountByMetricName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if m.NumLabelPairs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.NumLabelPairs))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.MaxTime != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MaxTime))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.MinTime != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MinTime))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.ChunkCount != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.ChunkCount))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.NumSeries != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.NumSeries))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TSDBStatistic) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TSDBStatistic) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TSDBStatistic) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Value != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Value))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ScaleDownRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ScaleDownRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ScaleDownRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Action != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Action))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ScaleDownResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ScaleDownResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ScaleDownResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Removing {
+		i--
+		if m.Removing {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Removable {
+		i--
+		if m.Removable {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.InflightQueries != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.InflightQueries))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Shipped {
+		i--
+		if m.Shipped {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.ReadOnly {
+		i--
+		if m.ReadOnly {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LabelValuesSketchesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelValuesSketchesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValuesSketchesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.ValueHashesLabels) > 0 {
+		for iNdEx := len(m.ValueHashesLabels) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.ValueHashesLabels[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *UserLabelName) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UserLabelName) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UserLabelName) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.LabelName) > 0 {
+		i -= len(m.LabelName)
+		copy(dAtA[i:], m.LabelName)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelName)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.UserId) > 0 {
+		i -= len(m.UserId)
+		copy(dAtA[i:], m.UserId)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.UserId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LabelValuesSketchesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelValuesSketchesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValuesSketchesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Sketches) > 0 {
+		for iNdEx := len(m.Sketches) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Sketches[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LabelValuesSketch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelValuesSketch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValuesSketch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.ValueHashes) > 0 {
+		dAtA4 := make([]byte, len(m.ValueHashes)*10)
+		var j3 int
+		for _, num := range m.ValueHashes {
+			for num >= 1<<7 {
+				dAtA4[j3] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j3++
+			}
+			dAtA4[j3] = uint8(num)
+			j3++
+		}
+		i -= j3
+		copy(dAtA[i:], dAtA4[:j3])
+		i = encodeVarintIngester(dAtA, i, uint64(j3))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Sketch) > 0 {
+		i -= len(m.Sketch)
+		copy(dAtA[i:], m.Sketch)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Sketch)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.LabelName) > 0 {
+		i -= len(m.LabelName)
+		copy(dAtA[i:], m.LabelName)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.LabelName)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.UserId) > 0 {
+		i -= len(m.UserId)
+		copy(dAtA[i:], m.UserId)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.UserId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TimeSeriesChunk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TimeSeriesChunk) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TimeSeriesChunk) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Chunks) > 0 {
+		for iNdEx := len(m.Chunks) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Chunks[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if len(m.Labels) > 0 {
+		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Labels[iNdEx].Size()
+				i -= size
+				if _, err := m.Labels[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.UserId) > 0 {
+		i -= len(m.UserId)
+		copy(dAtA[i:], m.UserId)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.UserId)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.FromIngesterId) > 0 {
+		i -= len(m.FromIngesterId)
+		copy(dAtA[i:], m.FromIngesterId)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.FromIngesterId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Chunk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Chunk) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Chunk) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Encoding != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Encoding))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.EndTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.StartTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LabelMatchers) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelMatchers) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelMatchers) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LabelMatcher) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelMatcher) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelMatcher) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Type != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Type))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TimeSeriesFile) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TimeSeriesFile) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TimeSeriesFile) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Filename) > 0 {
+		i -= len(m.Filename)
+		copy(dAtA[i:], m.Filename)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Filename)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.UserId) > 0 {
+		i -= len(m.UserId)
+		copy(dAtA[i:], m.UserId)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.UserId)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.FromIngesterId) > 0 {
+		i -= len(m.FromIngesterId)
+		copy(dAtA[i:], m.FromIngesterId)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.FromIngesterId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TransferTSDBResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TransferTSDBResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TransferTSDBResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintIngester(dAtA []byte, offset int, v uint64) int {
+	offset -= sovIngester(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *ReadRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Queries) > 0 {
+		for _, e := range m.Queries {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ReadResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Results) > 0 {
+		for _, e := range m.Results {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *QueryRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StartTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+	}
+	if m.EndTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if m.SortSeries {
+		n += 2
+	}
+	if m.SeriesLimit != 0 {
+		n += 1 + sovIngester(uint64(m.SeriesLimit))
+	}
+	return n
+}
+
+func (m *ExemplarQueryRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StartTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+	}
+	if m.EndTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *QueryResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Timeseries) > 0 {
+		for _, e := range m.Timeseries {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *QueryStreamResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Chunkseries) > 0 {
+		for _, e := range m.Chunkseries {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if len(m.Timeseries) > 0 {
+		for _, e := range m.Timeseries {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if m.SeriesLimitReached {
+		n += 2
+	}
+	return n
+}
+
+func (m *ExemplarQueryResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Timeseries) > 0 {
+		for _, e := range m.Timeseries {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelValuesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.LabelName)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	if m.StartTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+	}
+	if m.EndTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	}
+	if m.Matchers != nil {
+		l = m.Matchers.Size()
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	return n
+}
+
+func (m *LabelValuesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.LabelValues) > 0 {
+		for _, s := range m.LabelValues {
+			l = len(s)
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelValuesStreamResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.LabelValues) > 0 {
+		for _, s := range m.LabelValues {
+			l = len(s)
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelNamesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StartTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+	}
+	if m.EndTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	}
+	return n
+}
+
+func (m *LabelNamesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.LabelNames) > 0 {
+		for _, s := range m.LabelNames {
+			l = len(s)
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelNamesStreamResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.LabelNames) > 0 {
+		for _, s := range m.LabelNames {
+			l = len(s)
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *UserStatsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *UserStatsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.IngestionRate != 0 {
+		n += 9
+	}
+	if m.NumSeries != 0 {
+		n += 1 + sovIngester(uint64(m.NumSeries))
+	}
+	if m.ApiIngestionRate != 0 {
+		n += 9
+	}
+	if m.RuleIngestionRate != 0 {
+		n += 9
+	}
+	if m.ActiveSeries != 0 {
+		n += 1 + sovIngester(uint64(m.ActiveSeries))
+	}
+	return n
+}
+
+func (m *UserIDStatsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.UserId)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	if m.Data != nil {
+		l = m.Data.Size()
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	return n
+}
+
+func (m *UsersStatsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Stats) > 0 {
+		for _, e := range m.Stats {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *MetricsForLabelMatchersRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StartTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+	}
+	if m.EndTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	}
+	if len(m.MatchersSet) > 0 {
+		for _, e := range m.MatchersSet {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *MetricsForLabelMatchersResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for _, e := range m.Metric {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *MetricsForLabelMatchersStreamResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for _, e := range m.Metric {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *MetricsMetadataRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *MetricsMetadataResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Metadata) > 0 {
+		for _, e := range m.Metadata {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *TSDBStatusRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Limit != 0 {
+		n += 1 + sovIngester(uint64(m.Limit))
+	}
+	return n
+}
+
+func (m *TSDBStatusResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.NumSeries != 0 {
+		n += 1 + sovIngester(uint64(m.NumSeries))
+	}
+	if m.ChunkCount != 0 {
+		n += 1 + sovIngester(uint64(m.ChunkCount))
+	}
+	if m.MinTime != 0 {
+		n += 1 + sovIngester(uint64(m.MinTime))
+	}
+	if m.MaxTime != 0 {
+		n += 1 + sovIngester(uint64(m.MaxTime))
+	}
+	if m.NumLabelPairs != 0 {
+		n += 1 + sovIngester(uint64(m.NumLabelPairs))
+	}
+	if len(m.SeriesCountByMetricName) > 0 {
+		for _, e := range m.SeriesCountByMetricName {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if len(m.LabelValueCountByLabelName) > 0 {
+		for _, e := range m.LabelValueCountByLabelName {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if len(m.MemoryInBytesByLabelName) > 0 {
+		for _, e := range m.MemoryInBytesByLabelName {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if len(m.SeriesCountByLabelValuePair) > 0 {
+		for _, e := range m.SeriesCountByLabelValuePair {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *TSDBStatistic) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	if m.Value != 0 {
+		n += 1 + sovIngester(uint64(m.Value))
+	}
+	return n
+}
+
+func (m *ScaleDownRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Action != 0 {
+		n += 1 + sovIngester(uint64(m.Action))
+	}
+	return n
+}
+
+func (m *ScaleDownResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ReadOnly {
+		n += 2
+	}
+	if m.Shipped {
+		n += 2
+	}
+	if m.InflightQueries != 0 {
+		n += 1 + sovIngester(uint64(m.InflightQueries))
+	}
+	if m.Removable {
+		n += 2
+	}
+	if m.Removing {
+		n += 2
+	}
+	return n
+}
+
+func (m *LabelValuesSketchesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.ValueHashesLabels) > 0 {
+		for _, e := range m.ValueHashesLabels {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *UserLabelName) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.UserId)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	l = len(m.LabelName)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	return n
+}
+
+func (m *LabelValuesSketchesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Sketches) > 0 {
+		for _, e := range m.Sketches {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelValuesSketch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.UserId)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	l = len(m.LabelName)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	l = len(m.Sketch)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	if len(m.ValueHashes) > 0 {
+		l = 0
+		for _, e := range m.ValueHashes {
+			l += sovIngester(uint64(e))
+		}
+		n += 1 + sovIngester(uint64(l)) + l
+	}
+	return n
+}
+
+func (m *TimeSeriesChunk) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.FromIngesterId)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	l = len(m.UserId)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	if len(m.Labels) > 0 {
+		for _, e := range m.Labels {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if len(m.Chunks) > 0 {
+		for _, e := range m.Chunks {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Chunk) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StartTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+	}
+	if m.EndTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	}
+	if m.Encoding != 0 {
+		n += 1 + sovIngester(uint64(m.Encoding))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	return n
+}
+
+func (m *LabelMatchers) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelMatcher) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Type != 0 {
+		n += 1 + sovIngester(uint64(m.Type))
+	}
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	return n
+}
+
+func (m *TimeSeriesFile) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.FromIngesterId)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	l = len(m.UserId)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	l = len(m.Filename)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	return n
+}
+
+func (m *TransferTSDBResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func sovIngester(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozIngester(x uint64) (n int) {
+	return sovIngester(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *ReadRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForQueries := "[]*QueryRequest{"
+	for _, f := range this.Queries {
+		repeatedStringForQueries += strings.Replace(f.String(), "QueryRequest", "QueryRequest", 1) + ","
+	}
+	repeatedStringForQueries += "}"
+	s := strings.Join([]string{`&ReadRequest{`,
+		`Queries:` + repeatedStringForQueries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ReadResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForResults := "[]*QueryResponse{"
+	for _, f := range this.Results {
+		repeatedStringForResults += strings.Replace(f.String(), "QueryResponse", "QueryResponse", 1) + ","
+	}
+	repeatedStringForResults += "}"
+	s := strings.Join([]string{`&ReadResponse{`,
+		`Results:` + repeatedStringForResults + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QueryRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]*LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += strings.Replace(f.String(), "LabelMatcher", "LabelMatcher", 1) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&QueryRequest{`,
+		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
+		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`SortSeries:` + fmt.Sprintf("%v", this.SortSeries) + `,`,
+		`SeriesLimit:` + fmt.Sprintf("%v", this.SeriesLimit) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarQueryRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]*LabelMatchers{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += strings.Replace(f.String(), "LabelMatchers", "LabelMatchers", 1) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&ExemplarQueryRequest{`,
+		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
+		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QueryResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForTimeseries := "[]TimeSeries{"
+	for _, f := range this.Timeseries {
+		repeatedStringForTimeseries += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForTimeseries += "}"
+	s := strings.Join([]string{`&QueryResponse{`,
+		`Timeseries:` + repeatedStringForTimeseries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QueryStreamResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForChunkseries := "[]TimeSeriesChunk{"
+	for _, f := range this.Chunkseries {
+		repeatedStringForChunkseries += strings.Replace(strings.Replace(f.String(), "TimeSeriesChunk", "TimeSeriesChunk", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForChunkseries += "}"
+	repeatedStringForTimeseries := "[]TimeSeries{"
+	for _, f := range this.Timeseries {
+		repeatedStringForTimeseries += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForTimeseries += "}"
+	s := strings.Join([]string{`&QueryStreamResponse{`,
+		`Chunkseries:` + repeatedStringForChunkseries + `,`,
+		`Timeseries:` + repeatedStringForTimeseries + `,`,
+		`SeriesLimitReached:` + fmt.Sprintf("%v", this.SeriesLimitReached) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarQueryResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForTimeseries := "[]TimeSeries{"
+	for _, f := range this.Timeseries {
+		repeatedStringForTimeseries += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForTimeseries += "}"
+	s := strings.Join([]string{`&ExemplarQueryResponse{`,
+		`Timeseries:` + repeatedStringForTimeseries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelValuesRequest{`,
+		`LabelName:` + fmt.Sprintf("%v", this.LabelName) + `,`,
+		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
+		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
+		`Matchers:` + strings.Replace(this.Matchers.String(), "LabelMatchers", "LabelMatchers", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelValuesResponse{`,
+		`LabelValues:` + fmt.Sprintf("%v", this.LabelValues) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesStreamResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelValuesStreamResponse{`,
+		`LabelValues:` + fmt.Sprintf("%v", this.LabelValues) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelNamesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelNamesRequest{`,
+		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
+		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelNamesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelNamesResponse{`,
+		`LabelNames:` + fmt.Sprintf("%v", this.LabelNames) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelNamesStreamResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelNamesStreamResponse{`,
+		`LabelNames:` + fmt.Sprintf("%v", this.LabelNames) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *UserStatsRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&UserStatsRequest{`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *UserStatsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&UserStatsResponse{`,
+		`IngestionRate:` + fmt.Sprintf("%v", this.IngestionRate) + `,`,
+		`NumSeries:` + fmt.Sprintf("%v", this.NumSeries) + `,`,
+		`ApiIngestionRate:` + fmt.Sprintf("%v", this.ApiIngestionRate) + `,`,
+		`RuleIngestionRate:` + fmt.Sprintf("%v", this.RuleIngestionRate) + `,`,
+		`ActiveSeries:` + fmt.Sprintf("%v", this.ActiveSeries) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *UserIDStatsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&UserIDStatsResponse{`,
+		`UserId:` + fmt.Sprintf("%v", this.UserId) + `,`,
+		`Data:` + strings.Replace(this.Data.String(), "UserStatsResponse", "UserStatsResponse", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *UsersStatsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForStats := "[]*UserIDStatsResponse{"
+	for _, f := range this.Stats {
+		repeatedStringForStats += strings.Replace(f.String(), "UserIDStatsResponse", "UserIDStatsResponse", 1) + ","
+	}
+	repeatedStringForStats += "}"
+	s := strings.Join([]string{`&UsersStatsResponse{`,
+		`Stats:` + repeatedStringForStats + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *MetricsForLabelMatchersRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchersSet := "[]*LabelMatchers{"
+	for _, f := range this.MatchersSet {
+		repeatedStringForMatchersSet += strings.Replace(f.String(), "LabelMatchers", "LabelMatchers", 1) + ","
+	}
+	repeatedStringForMatchersSet += "}"
+	s := strings.Join([]string{`&MetricsForLabelMatchersRequest{`,
+		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
+		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
+		`MatchersSet:` + repeatedStringForMatchersSet + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *MetricsForLabelMatchersResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMetric := "[]*Metric{"
+	for _, f := range this.Metric {
+		repeatedStringForMetric += strings.Replace(fmt.Sprintf("%v", f), "Metric", "cortexpb.Metric", 1) + ","
+	}
+	repeatedStringForMetric += "}"
+	s := strings.Join([]string{`&MetricsForLabelMatchersResponse{`,
+		`Metric:` + repeatedStringForMetric + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *MetricsForLabelMatchersStreamResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMetric := "[]*Metric{"
+	for _, f := range this.Metric {
+		repeatedStringForMetric += strings.Replace(fmt.Sprintf("%v", f), "Metric", "cortexpb.Metric", 1) + ","
+	}
+	repeatedStringForMetric += "}"
+	s := strings.Join([]string{`&MetricsForLabelMatchersStreamResponse{`,
+		`Metric:` + repeatedStringForMetric + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *MetricsMetadataRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&MetricsMetadataRequest{`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *MetricsMetadataResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMetadata := "[]*MetricMetadata{"
+	for _, f := range this.Metadata {
+		repeatedStringForMetadata += strings.Replace(fmt.Sprintf("%v", f), "MetricMetadata", "cortexpb.MetricMetadata", 1) + ","
+	}
+	repeatedStringForMetadata += "}"
+	s := strings.Join([]string{`&MetricsMetadataResponse{`,
+		`Metadata:` + repeatedStringForMetadata + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TSDBStatusRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TSDBStatusRequest{`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TSDBStatusResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSeriesCountByMetricName := "[]TSDBStatistic{"
+	for _, f := range this.SeriesCountByMetricName {
+		repeatedStringForSeriesCountByMetricName += strings.Replace(strings.Replace(f.String(), "TSDBStatistic", "TSDBStatistic", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSeriesCountByMetricName += "}"
+	repeatedStringForLabelValueCountByLabelName := "[]TSDBStatistic{"
+	for _, f := range this.LabelValueCountByLabelName {
+		repeatedStringForLabelValueCountByLabelName += strings.Replace(strings.Replace(f.String(), "TSDBStatistic", "TSDBStatistic", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForLabelValueCountByLabelName += "}"
+	repeatedStringForMemoryInBytesByLabelName := "[]TSDBStatistic{"
+	for _, f := range this.MemoryInBytesByLabelName {
+		repeatedStringForMemoryInBytesByLabelName += strings.Replace(strings.Replace(f.String(), "TSDBStatistic", "TSDBStatistic", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForMemoryInBytesByLabelName += "}"
+	repeatedStringForSeriesCountByLabelValuePair := "[]TSDBStatistic{"
+	for _, f := range this.SeriesCountByLabelValuePair {
+		repeatedStringForSeriesCountByLabelValuePair += strings.Replace(strings.Replace(f.String(), "TSDBStatistic", "TSDBStatistic", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSeriesCountByLabelValuePair += "}"
+	s := strings.Join([]string{`&TSDBStatusResponse{`,
+		`NumSeries:` + fmt.Sprintf("%v", this.NumSeries) + `,`,
+		`ChunkCount:` + fmt.Sprintf("%v", this.ChunkCount) + `,`,
+		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
+		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
+		`NumLabelPairs:` + fmt.Sprintf("%v", this.NumLabelPairs) + `,`,
+		`SeriesCountByMetricName:` + repeatedStringForSeriesCountByMetricName + `,`,
+		`LabelValueCountByLabelName:` + repeatedStringForLabelValueCountByLabelName + `,`,
+		`MemoryInBytesByLabelName:` + repeatedStringForMemoryInBytesByLabelName + `,`,
+		`SeriesCountByLabelValuePair:` + repeatedStringForSeriesCountByLabelValuePair + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TSDBStatistic) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TSDBStatistic{`,
+		`Name:` + fmt.Sprintf("%v", this.Name) + `,`,
+		`Value:` + fmt.Sprintf("%v", this.Value) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ScaleDownRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ScaleDownRequest{`,
+		`Action:` + fmt.Sprintf("%v", this.Action) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ScaleDownResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ScaleDownResponse{`,
+		`ReadOnly:` + fmt.Sprintf("%v", this.ReadOnly) + `,`,
+		`Shipped:` + fmt.Sprintf("%v", this.Shipped) + `,`,
+		`InflightQueries:` + fmt.Sprintf("%v", this.InflightQueries) + `,`,
+		`Removable:` + fmt.Sprintf("%v", this.Removable) + `,`,
+		`Removing:` + fmt.Sprintf("%v", this.Removing) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesSketchesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForValueHashesLabels := "[]UserLabelName{"
+	for _, f := range this.ValueHashesLabels {
+		repeatedStringForValueHashesLabels += strings.Replace(strings.Replace(f.String(), "UserLabelName", "UserLabelName", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForValueHashesLabels += "}"
+	s := strings.Join([]string{`&LabelValuesSketchesRequest{`,
+		`ValueHashesLabels:` + repeatedStringForValueHashesLabels + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *UserLabelName) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&UserLabelName{`,
+		`UserId:` + fmt.Sprintf("%v", this.UserId) + `,`,
+		`LabelName:` + fmt.Sprintf("%v", this.LabelName) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesSketchesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSketches := "[]LabelValuesSketch{"
+	for _, f := range this.Sketches {
+		repeatedStringForSketches += strings.Replace(strings.Replace(f.String(), "LabelValuesSketch", "LabelValuesSketch", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSketches += "}"
+	s := strings.Join([]string{`&LabelValuesSketchesResponse{`,
+		`Sketches:` + repeatedStringForSketches + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesSketch) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelValuesSketch{`,
+		`UserId:` + fmt.Sprintf("%v", this.UserId) + `,`,
+		`LabelName:` + fmt.Sprintf("%v", this.LabelName) + `,`,
+		`Sketch:` + fmt.Sprintf("%v", this.Sketch) + `,`,
+		`ValueHashes:` + fmt.Sprintf("%v", this.ValueHashes) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TimeSeriesChunk) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForChunks := "[]Chunk{"
+	for _, f := range this.Chunks {
+		repeatedStringForChunks += strings.Replace(strings.Replace(f.String(), "Chunk", "Chunk", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForChunks += "}"
+	s := strings.Join([]string{`&TimeSeriesChunk{`,
+		`FromIngesterId:` + fmt.Sprintf("%v", this.FromIngesterId) + `,`,
+		`UserId:` + fmt.Sprintf("%v", this.UserId) + `,`,
+		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
+		`Chunks:` + repeatedStringForChunks + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Chunk) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Chunk{`,
+		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
+		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
+		`Encoding:` + fmt.Sprintf("%v", this.Encoding) + `,`,
+		`Data:` + fmt.Sprintf("%v", this.Data) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelMatchers) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]*LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += strings.Replace(f.String(), "LabelMatcher", "LabelMatcher", 1) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&LabelMatchers{`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelMatcher) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelMatcher{`,
+		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
+		`Name:` + fmt.Sprintf("%v", this.Name) + `,`,
+		`Value:` + fmt.Sprintf("%v", this.Value) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TimeSeriesFile) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TimeSeriesFile{`,
+		`FromIngesterId:` + fmt.Sprintf("%v", this.FromIngesterId) + `,`,
+		`UserId:` + fmt.Sprintf("%v", this.UserId) + `,`,
+		`Filename:` + fmt.Sprintf("%v", this.Filename) + `,`,
+		`Data:` + fmt.Sprintf("%v", this.Data) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TransferTSDBResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TransferTSDBResponse{`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringIngester(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *ReadRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReadRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReadRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Queries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Queries = append(m.Queries, &QueryRequest{})
+			if err := m.Queries[len(m.Queries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ReadResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReadResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReadResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Results", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Results = append(m.Results, &QueryResponse{})
+			if err := m.Results[len(m.Results)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QueryRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			}
+			m.StartTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			}
+			m.EndTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, &LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SortSeries", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.SortSeries = bool(v != 0)
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesLimit", wireType)
+			}
+			m.SeriesLimit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SeriesLimit |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExemplarQueryRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExemplarQueryRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExemplarQueryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			}
+			m.StartTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			}
+			m.EndTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, &LabelMatchers{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QueryResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeseries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Timeseries = append(m.Timeseries, cortexpb.TimeSeries{})
+			if err := m.Timeseries[len(m.Timeseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QueryStreamResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryStreamResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunkseries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Chunkseries = append(m.Chunkseries, TimeSeriesChunk{})
+			if err := m.Chunkseries[len(m.Chunkseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeseries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Timeseries = append(m.Timeseries, cortexpb.TimeSeries{})
+			if err := m.Timeseries[len(m.Timeseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesLimitReached", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.SeriesLimitReached = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExemplarQueryResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExemplarQueryResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExemplarQueryResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeseries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Timeseries = append(m.Timeseries, cortexpb.TimeSeries{})
+			if err := m.Timeseries[len(m.Timeseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelValuesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelValuesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LabelName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			}
+			m.StartTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			}
+			m.EndTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Matchers == nil {
+				m.Matchers = &LabelMatchers{}
+			}
+			if err := m.Matchers.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelValuesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelValuesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelValues", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LabelValues = append(m.LabelValues, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelValuesStreamResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelValuesStreamResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelValuesStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelValues", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LabelValues = append(m.LabelValues, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelNamesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelNamesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			}
+			m.StartTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			}
+			m.EndTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	s := strings.Join([]string{`&TimeSeriesFile{`,
-		`FromIngesterId:` + fmt.Sprintf("%v", this.FromIngesterId) + `,`,
-		`UserId:` + fmt.Sprintf("%v", this.UserId) + `,`,
-		`Filename:` + fmt.Sprintf("%v", this.Filename) + `,`,
-		`Data:` + fmt.Sprintf("%v", this.Data) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func valueToStringIngester(v interface{}) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
-		return "nil"
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("*%v", pv)
+	return nil
 }
-func (m *ReadRequest) Unmarshal(dAtA []byte) error {
+func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5316,17 +8700,17 @@ func (m *ReadRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ReadRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelNamesResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ReadRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelNamesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Queries", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelNames", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -5336,25 +8720,23 @@ func (m *ReadRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Queries = append(m.Queries, &QueryRequest{})
-			if err := m.Queries[len(m.Queries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.LabelNames = append(m.LabelNames, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -5380,7 +8762,7 @@ func (m *ReadRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ReadResponse) Unmarshal(dAtA []byte) error {
+func (m *LabelNamesStreamResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5403,17 +8785,17 @@ func (m *ReadResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ReadResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelNamesStreamResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ReadResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelNamesStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Results", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelNames", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -5423,26 +8805,77 @@ func (m *ReadResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Results = append(m.Results, &QueryResponse{})
-			if err := m.Results[len(m.Results)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.LabelNames = append(m.LabelNames, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
 				return err
 			}
-			iNdEx = postIndex
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *UserStatsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: UserStatsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: UserStatsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -5467,7 +8900,7 @@ func (m *ReadResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *QueryRequest) Unmarshal(dAtA []byte) error {
+func (m *UserStatsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5490,36 +8923,28 @@ func (m *QueryRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: QueryRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: UserStatsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: QueryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: UserStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IngestionRate", wireType)
 			}
-			m.StartTimestampMs = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.StartTimestampMs |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
 			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.IngestionRate = float64(math.Float64frombits(v))
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field NumSeries", wireType)
 			}
-			m.EndTimestampMs = 0
+			m.NumSeries = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -5529,16 +8954,38 @@ func (m *QueryRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.EndTimestampMs |= int64(b&0x7F) << shift
+				m.NumSeries |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ApiIngestionRate", wireType)
 			}
-			var msglen int
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.ApiIngestionRate = float64(math.Float64frombits(v))
+		case 4:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RuleIngestionRate", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.RuleIngestionRate = float64(math.Float64frombits(v))
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ActiveSeries", wireType)
+			}
+			m.ActiveSeries = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -5548,26 +8995,11 @@ func (m *QueryRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.ActiveSeries |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Matchers = append(m.Matchers, &LabelMatcher{})
-			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -5592,7 +9024,7 @@ func (m *QueryRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExemplarQueryRequest) Unmarshal(dAtA []byte) error {
+func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5615,17 +9047,17 @@ func (m *ExemplarQueryRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ExemplarQueryRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: UserIDStatsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExemplarQueryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: UserIDStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
 			}
-			m.StartTimestampMs = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -5635,33 +9067,27 @@ func (m *ExemplarQueryRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.StartTimestampMs |= int64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
 			}
-			m.EndTimestampMs = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.EndTimestampMs |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
 			}
-		case 3:
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5688,8 +9114,10 @@ func (m *ExemplarQueryRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Matchers = append(m.Matchers, &LabelMatchers{})
-			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Data == nil {
+				m.Data = &UserStatsResponse{}
+			}
+			if err := m.Data.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -5717,7 +9145,7 @@ func (m *ExemplarQueryRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *QueryResponse) Unmarshal(dAtA []byte) error {
+func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5740,15 +9168,15 @@ func (m *QueryResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: QueryResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: UsersStatsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: QueryResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: UsersStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Timeseries", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5775,8 +9203,8 @@ func (m *QueryResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Timeseries = append(m.Timeseries, cortexpb.TimeSeries{})
-			if err := m.Timeseries[len(m.Timeseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Stats = append(m.Stats, &UserIDStatsResponse{})
+			if err := m.Stats[len(m.Stats)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -5804,7 +9232,7 @@ func (m *QueryResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *QueryStreamResponse) Unmarshal(dAtA []byte) error {
+func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5827,17 +9255,17 @@ func (m *QueryStreamResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: QueryStreamResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: MetricsForLabelMatchersRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: QueryStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MetricsForLabelMatchersRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Chunkseries", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
 			}
-			var msglen int
+			m.StartTimestampMs = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -5847,29 +9275,33 @@ func (m *QueryStreamResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.StartTimestampMs |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
 			}
-			m.Chunkseries = append(m.Chunkseries, TimeSeriesChunk{})
-			if err := m.Chunkseries[len(m.Chunkseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.EndTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
-		case 2:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Timeseries", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field MatchersSet", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5896,8 +9328,8 @@ func (m *QueryStreamResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Timeseries = append(m.Timeseries, cortexpb.TimeSeries{})
-			if err := m.Timeseries[len(m.Timeseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.MatchersSet = append(m.MatchersSet, &LabelMatchers{})
+			if err := m.MatchersSet[len(m.MatchersSet)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -5925,7 +9357,7 @@ func (m *QueryStreamResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExemplarQueryResponse) Unmarshal(dAtA []byte) error {
+func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5948,15 +9380,15 @@ func (m *ExemplarQueryResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ExemplarQueryResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: MetricsForLabelMatchersResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExemplarQueryResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MetricsForLabelMatchersResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Timeseries", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5983,8 +9415,8 @@ func (m *ExemplarQueryResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Timeseries = append(m.Timeseries, cortexpb.TimeSeries{})
-			if err := m.Timeseries[len(m.Timeseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Metric = append(m.Metric, &cortexpb.Metric{})
+			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -6012,7 +9444,7 @@ func (m *ExemplarQueryResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
+func (m *MetricsForLabelMatchersStreamResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6035,85 +9467,15 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelValuesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: MetricsForLabelMatchersStreamResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelValuesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MetricsForLabelMatchersStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LabelName", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.LabelName = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
-			}
-			m.StartTimestampMs = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.StartTimestampMs |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
-			}
-			m.EndTimestampMs = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.EndTimestampMs |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -6140,10 +9502,8 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Matchers == nil {
-				m.Matchers = &LabelMatchers{}
-			}
-			if err := m.Matchers.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Metric = append(m.Metric, &cortexpb.Metric{})
+			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -6171,7 +9531,7 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
+func (m *MetricsMetadataRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6194,44 +9554,12 @@ func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelValuesResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: MetricsMetadataRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelValuesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MetricsMetadataRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LabelValues", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.LabelValues = append(m.LabelValues, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -6256,7 +9584,7 @@ func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelValuesStreamResponse) Unmarshal(dAtA []byte) error {
+func (m *MetricsMetadataResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6279,17 +9607,17 @@ func (m *LabelValuesStreamResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelValuesStreamResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: MetricsMetadataResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelValuesStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MetricsMetadataResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LabelValues", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6299,23 +9627,25 @@ func (m *LabelValuesStreamResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.LabelValues = append(m.LabelValues, string(dAtA[iNdEx:postIndex]))
+			m.Metadata = append(m.Metadata, &cortexpb.MetricMetadata{})
+			if err := m.Metadata[len(m.Metadata)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -6341,7 +9671,7 @@ func (m *LabelValuesStreamResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
+func (m *TSDBStatusRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6364,36 +9694,17 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: TSDBStatusRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TSDBStatusRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
-			}
-			m.StartTimestampMs = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.StartTimestampMs |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
 			}
-			m.EndTimestampMs = 0
+			m.Limit = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6403,7 +9714,7 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.EndTimestampMs |= int64(b&0x7F) << shift
+				m.Limit |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -6432,7 +9743,7 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
+func (m *TSDBStatusResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6455,17 +9766,112 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: TSDBStatusResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TSDBStatusResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumSeries", wireType)
+			}
+			m.NumSeries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumSeries |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkCount", wireType)
+			}
+			m.ChunkCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunkCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinTime", wireType)
+			}
+			m.MinTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
+			}
+			m.MaxTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumLabelPairs", wireType)
+			}
+			m.NumLabelPairs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumLabelPairs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LabelNames", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesCountByMetricName", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6475,82 +9881,65 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.LabelNames = append(m.LabelNames, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipIngester(dAtA[iNdEx:])
-			if err != nil {
+			m.SeriesCountByMetricName = append(m.SeriesCountByMetricName, TSDBStatistic{})
+			if err := m.SeriesCountByMetricName[len(m.SeriesCountByMetricName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthIngester
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelValueCountByLabelName", wireType)
 			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthIngester
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *LabelNamesStreamResponse) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowIngester
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
 			}
-			if iNdEx >= l {
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			m.LabelValueCountByLabelName = append(m.LabelValueCountByLabelName, TSDBStatistic{})
+			if err := m.LabelValueCountByLabelName[len(m.LabelValueCountByLabelName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesStreamResponse: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+			iNdEx = postIndex
+		case 8:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LabelNames", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field MemoryInBytesByLabelName", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6560,77 +9949,60 @@ func (m *LabelNamesStreamResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.LabelNames = append(m.LabelNames, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipIngester(dAtA[iNdEx:])
-			if err != nil {
+			m.MemoryInBytesByLabelName = append(m.MemoryInBytesByLabelName, TSDBStatistic{})
+			if err := m.MemoryInBytesByLabelName[len(m.MemoryInBytesByLabelName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthIngester
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesCountByLabelValuePair", wireType)
 			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthIngester
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *UserStatsRequest) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowIngester
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
 			}
-			if iNdEx >= l {
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			m.SeriesCountByLabelValuePair = append(m.SeriesCountByLabelValuePair, TSDBStatistic{})
+			if err := m.SeriesCountByLabelValuePair[len(m.SeriesCountByLabelValuePair)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: UserStatsRequest: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: UserStatsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -6655,7 +10027,7 @@ func (m *UserStatsRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *UserStatsResponse) Unmarshal(dAtA []byte) error {
+func (m *TSDBStatistic) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6678,28 +10050,17 @@ func (m *UserStatsResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: UserStatsResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: TSDBStatistic: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: UserStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TSDBStatistic: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IngestionRate", wireType)
-			}
-			var v uint64
-			if (iNdEx + 8) > l {
-				return io.ErrUnexpectedEOF
-			}
-			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-			m.IngestionRate = float64(math.Float64frombits(v))
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field NumSeries", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
-			m.NumSeries = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6709,38 +10070,29 @@ func (m *UserStatsResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.NumSeries |= uint64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ApiIngestionRate", wireType)
-			}
-			var v uint64
-			if (iNdEx + 8) > l {
-				return io.ErrUnexpectedEOF
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
 			}
-			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-			m.ApiIngestionRate = float64(math.Float64frombits(v))
-		case 4:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RuleIngestionRate", wireType)
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
 			}
-			var v uint64
-			if (iNdEx + 8) > l {
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-			m.RuleIngestionRate = float64(math.Float64frombits(v))
-		case 5:
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ActiveSeries", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
 			}
-			m.ActiveSeries = 0
+			m.Value = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6750,7 +10102,7 @@ func (m *UserStatsResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ActiveSeries |= uint64(b&0x7F) << shift
+				m.Value |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -6779,7 +10131,7 @@ func (m *UserStatsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
+func (m *ScaleDownRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6802,49 +10154,17 @@ func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: UserIDStatsResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: ScaleDownRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: UserIDStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ScaleDownRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.UserId = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Action", wireType)
 			}
-			var msglen int
+			m.Action = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6854,38 +10174,18 @@ func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Action |= ScaleDownAction(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Data == nil {
-				m.Data = &UserStatsResponse{}
-			}
-			if err := m.Data.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if (iNdEx + skippy) > l {
@@ -6900,7 +10200,7 @@ func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
+func (m *LabelValuesSketchesRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6923,15 +10223,15 @@ func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: UsersStatsResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelValuesSketchesRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: UsersStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelValuesSketchesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ValueHashesLabels", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -6958,8 +10258,8 @@ func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Stats = append(m.Stats, &UserIDStatsResponse{})
-			if err := m.Stats[len(m.Stats)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.ValueHashesLabels = append(m.ValueHashesLabels, UserLabelName{})
+			if err := m.ValueHashesLabels[len(m.ValueHashesLabels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -6969,10 +10269,7 @@ func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if (iNdEx + skippy) > l {
@@ -6987,7 +10284,7 @@ func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
+func (m *UserLabelName) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7010,17 +10307,17 @@ func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: UserLabelName: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: UserLabelName: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
 			}
-			m.StartTimestampMs = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7030,35 +10327,29 @@ func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.StartTimestampMs |= int64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
 			}
-			m.EndTimestampMs = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.EndTimestampMs |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
 			}
-		case 3:
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MatchersSet", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelName", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7068,25 +10359,23 @@ func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.MatchersSet = append(m.MatchersSet, &LabelMatchers{})
-			if err := m.MatchersSet[len(m.MatchersSet)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.LabelName = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -7094,10 +10383,7 @@ func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if (iNdEx + skippy) > l {
@@ -7112,7 +10398,7 @@ func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
+func (m *LabelValuesSketchesResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7135,15 +10421,15 @@ func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelValuesSketchesResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelValuesSketchesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Sketches", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -7170,8 +10456,8 @@ func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Metric = append(m.Metric, &cortexpb.Metric{})
-			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Sketches = append(m.Sketches, LabelValuesSketch{})
+			if err := m.Sketches[len(m.Sketches)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -7181,10 +10467,7 @@ func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if (iNdEx + skippy) > l {
@@ -7199,7 +10482,7 @@ func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MetricsForLabelMatchersStreamResponse) Unmarshal(dAtA []byte) error {
+func (m *LabelValuesSketch) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7218,21 +10501,53 @@ func (m *MetricsForLabelMatchersStreamResponse) Unmarshal(dAtA []byte) error {
 			if b < 0x80 {
 				break
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersStreamResponse: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelValuesSketch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelValuesSketch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelName", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7242,79 +10557,134 @@ func (m *MetricsForLabelMatchersStreamResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Metric = append(m.Metric, &cortexpb.Metric{})
-			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.LabelName = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipIngester(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sketch", wireType)
 			}
-			if skippy < 0 {
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			if (iNdEx + skippy) < 0 {
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
-			if (iNdEx + skippy) > l {
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *MetricsMetadataRequest) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowIngester
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
+			m.Sketch = append(m.Sketch[:0], dAtA[iNdEx:postIndex]...)
+			if m.Sketch == nil {
+				m.Sketch = []byte{}
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			iNdEx = postIndex
+		case 4:
+			if wireType == 0 {
+				var v uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIngester
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.ValueHashes = append(m.ValueHashes, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIngester
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthIngester
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthIngester
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.ValueHashes) == 0 {
+					m.ValueHashes = make([]uint64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowIngester
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.ValueHashes = append(m.ValueHashes, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field ValueHashes", wireType)
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: MetricsMetadataRequest: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetricsMetadataRequest: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -7339,7 +10709,7 @@ func (m *MetricsMetadataRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MetricsMetadataResponse) Unmarshal(dAtA []byte) error {
+func (m *ScaleDownResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7362,17 +10732,17 @@ func (m *MetricsMetadataResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MetricsMetadataResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: ScaleDownResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetricsMetadataResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ScaleDownResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadOnly", wireType)
 			}
-			var msglen int
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7382,26 +10752,91 @@ func (m *MetricsMetadataResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthIngester
+			m.ReadOnly = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Shipped", wireType)
 			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			m.Shipped = bool(v != 0)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InflightQueries", wireType)
 			}
-			m.Metadata = append(m.Metadata, &cortexpb.MetricMetadata{})
-			if err := m.Metadata[len(m.Metadata)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.InflightQueries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.InflightQueries |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Removable", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Removable = bool(v != 0)
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Removing", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Removing = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -8161,6 +11596,59 @@ func (m *TimeSeriesFile) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *TransferTSDBResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TransferTSDBResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TransferTSDBResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipIngester(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+// QueryStreamSeries is a series of the query streams of the ingesters, merged across the ingesters.
+// The chunks are the ones of all the ingesters, while the samples and histograms are deduplicated.
+type QueryStreamSeries struct {
+	Labels     []cortexpb.LabelAdapter
+	Chunks     []Chunk
+	Samples    []cortexpb.Sample
+	Histograms []cortexpb.Histogram
+}
+
+// Response returns the series as a QueryStreamResponse, as QueryStream would have returned it.
+func (s QueryStreamSeries) Response() *QueryStreamResponse {
+	resp := &QueryStreamResponse{}
+	if len(s.Chunks) > 0 {
+		resp.Chunkseries = []TimeSeriesChunk{{Labels: s.Labels, Chunks: s.Chunks}}
+	}
+	if len(s.Samples) > 0 || len(s.Histograms) > 0 {
+		resp.Timeseries = []cortexpb.TimeSeries{{Labels: s.Labels, Samples: s.Samples, Histograms: s.Histograms}}
+	}
+	return resp
+}
+
+// QueryStreamSeriesSet iterates the series of the query streams of the ingesters, sorted by labels.
+type QueryStreamSeriesSet interface {
+	Next() bool
+	At() QueryStreamSeries
+	Err() error
+
+	// Close releases the query streams. It must be called if the set isn't iterated until its end.
+	Close()
+}
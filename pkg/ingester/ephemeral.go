@@ -0,0 +1,143 @@
+package ingester
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"go.uber.org/atomic"
+
+	logutil "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	// ephemeralSeriesDir is the directory, in the user's TSDB directory, where the head of the
+	// ephemeral series memory-maps its full chunks. It's wiped whenever the head is created,
+	// since the ephemeral series are never replayed.
+	ephemeralSeriesDir = "ephemeral"
+
+	// Period at which the samples of the ephemeral series older than the retention are removed.
+	ephemeralSeriesTruncatePeriod = time.Minute
+)
+
+var errMaxEphemeralSeriesPerUserLimitExceeded = errors.New("per-user ephemeral series limit exceeded")
+
+// ephemeralSeriesLimiter counts the ephemeral series of a user, and enforces their limit when
+// the series are created in the ephemeral head.
+type ephemeralSeriesLimiter struct {
+	userID  string
+	i       *Ingester
+	current atomic.Int64
+}
+
+func (l *ephemeralSeriesLimiter) PreCreation(labels.Labels) error {
+	if limit := l.i.limits.MaxEphemeralSeriesPerUser(l.userID); limit > 0 && l.current.Load() >= int64(limit) {
+		return errMaxEphemeralSeriesPerUserLimitExceeded
+	}
+	return nil
+}
+
+func (l *ephemeralSeriesLimiter) PostCreation(labels.Labels) {
+	l.current.Inc()
+	l.i.metrics.memEphemeralSeries.Inc()
+}
+
+func (l *ephemeralSeriesLimiter) PostDeletion(metrics map[chunks.HeadSeriesRef]labels.Labels) {
+	l.current.Sub(int64(len(metrics)))
+	l.i.metrics.memEphemeralSeries.Sub(float64(len(metrics)))
+}
+
+// release discounts all the series of the user, once their head has been closed.
+func (l *ephemeralSeriesLimiter) release() {
+	l.i.metrics.memEphemeralSeries.Sub(float64(l.current.Swap(0)))
+}
+
+// ephemeralHead returns the head of the ephemeral series, or nil if no ephemeral series have been pushed.
+func (u *userTSDB) ephemeralHead() *tsdb.Head {
+	u.ephemeralMtx.RLock()
+	defer u.ephemeralMtx.RUnlock()
+
+	return u.ephemeral
+}
+
+// getOrCreateEphemeralHead returns the head of the user's ephemeral series, creating it if needed.
+// The head has no WAL, so the ephemeral series are lost when the ingester restarts.
+func (i *Ingester) getOrCreateEphemeralHead(db *userTSDB) (*tsdb.Head, error) {
+	db.ephemeralMtx.Lock()
+	defer db.ephemeralMtx.Unlock()
+
+	if db.ephemeral != nil {
+		return db.ephemeral, nil
+	}
+
+	dir := filepath.Join(db.db.Dir(), ephemeralSeriesDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, errors.Wrapf(err, "failed to remove the ephemeral series dir: %s", dir)
+	}
+
+	opts := tsdb.DefaultHeadOptions()
+	opts.ChunkDirRoot = dir
+	opts.ChunkRange = i.cfg.EphemeralSeriesRetentionPeriod.Milliseconds()
+	opts.IsolationDisabled = true
+	limiter := &ephemeralSeriesLimiter{userID: db.userID, i: i}
+	opts.SeriesCallback = limiter
+
+	head, err := tsdb.NewHead(nil, logutil.WithUserID(db.userID, i.logger), nil, nil, opts, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the ephemeral series head")
+	}
+	if err := head.Init(math.MinInt64); err != nil {
+		_ = head.Close()
+		return nil, errors.Wrap(err, "failed to initialize the ephemeral series head")
+	}
+
+	db.ephemeral = head
+	db.ephemeralLimiter = limiter
+	return head, nil
+}
+
+// truncateEphemeralSeries removes the samples of the ephemeral series older than the retention.
+func (i *Ingester) truncateEphemeralSeries() {
+	mint := time.Now().Add(-i.cfg.EphemeralSeriesRetentionPeriod).UnixMilli()
+
+	for _, userID := range i.getTSDBUsers() {
+		db := i.getTSDB(userID)
+		if db == nil {
+			continue
+		}
+
+		// Hold the read lock for the whole truncation, so that the head isn't closed meanwhile.
+		db.ephemeralMtx.RLock()
+		if db.ephemeral != nil {
+			if err := db.ephemeral.Truncate(mint); err != nil {
+				level.Warn(logutil.WithUserID(userID, i.logger)).Log("msg", "failed to truncate the ephemeral series", "err", err)
+			}
+		}
+		db.ephemeralMtx.RUnlock()
+	}
+}
+
+// isEphemeralSeries returns whether the series matches any of the sets of matchers.
+func isEphemeralSeries(matchers [][]*labels.Matcher, lset labels.Labels) bool {
+	for _, set := range matchers {
+		if matchesAll(set, lset) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAll(matchers []*labels.Matcher, lset labels.Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(lset.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package ingester
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+func TestIngester_EphemeralSeries(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.EphemeralSeriesRetentionPeriod = 10 * time.Minute
+
+	limits := defaultLimitsTestConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+ephemeral_series_matchers: ['{__name__="autoscaling_signal"}']
+max_ephemeral_series_per_user: 2
+`), &limits))
+
+	reg := prometheus.NewPedanticRegistry()
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	now := time.Now().UnixMilli()
+	push := func(name string, ephemeral bool) error {
+		req, _, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, name), 1, now)
+		req.Ephemeral = ephemeral
+		_, err := i.Push(ctx, req)
+		return err
+	}
+
+	require.NoError(t, push("persistent", false))
+	require.NoError(t, push("autoscaling_signal", false))
+	require.NoError(t, push("ephemeral", true))
+
+	// The ephemeral series are kept apart from the persistent ones, which are shipped to the storage.
+	db := i.getTSDB(userID)
+	require.NotNil(t, db)
+	assert.Equal(t, uint64(1), db.Head().NumSeries())
+	assert.Equal(t, uint64(2), db.ephemeralHead().NumSeries())
+
+	// The ephemeral series are queryable like the persistent ones.
+	res, err := i.Query(ctx, &client.QueryRequest{
+		StartTimestampMs: now - 1000,
+		EndTimestampMs:   now + 1000,
+		Matchers:         []*client.LabelMatcher{{Type: client.REGEX_MATCH, Name: labels.MetricName, Value: ".+"}},
+	})
+	require.NoError(t, err)
+	var names []string
+	for _, ts := range res.Timeseries {
+		names = append(names, cortexpb.FromLabelAdaptersToLabels(ts.Labels).Get(labels.MetricName))
+	}
+	assert.ElementsMatch(t, []string{"persistent", "autoscaling_signal", "ephemeral"}, names)
+
+	// The ephemeral series have their own limit.
+	err = push("ephemeral_over_the_limit", true)
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+	assert.Contains(t, string(resp.Body), "per-user ephemeral series limit of 2 exceeded")
+	id, ok := globalerror.IDFromMessage(string(resp.Body))
+	require.True(t, ok)
+	assert.Equal(t, globalerror.MaxEphemeralSeriesPerUser, id)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ingester_memory_ephemeral_series The current number of ephemeral series in memory.
+		# TYPE cortex_ingester_memory_ephemeral_series gauge
+		cortex_ingester_memory_ephemeral_series 2
+	`), "cortex_ingester_memory_ephemeral_series"))
+
+	// The ephemeral series are removed once older than the retention.
+	i.cfg.EphemeralSeriesRetentionPeriod = -time.Minute
+	i.truncateEphemeralSeries()
+	assert.Equal(t, uint64(0), db.ephemeralHead().NumSeries())
+	assert.Equal(t, uint64(1), db.Head().NumSeries())
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ingester_memory_ephemeral_series The current number of ephemeral series in memory.
+		# TYPE cortex_ingester_memory_ephemeral_series gauge
+		cortex_ingester_memory_ephemeral_series 0
+	`), "cortex_ingester_memory_ephemeral_series"))
+}
+
+func TestIsEphemeralSeries(t *testing.T) {
+	matchers := [][]*labels.Matcher{
+		{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"), labels.MustNewMatcher(labels.MatchEqual, "job", "autoscaler")},
+		{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "autoscaling_.+")},
+	}
+
+	assert.True(t, isEphemeralSeries(matchers, labels.FromStrings(labels.MetricName, "up", "job", "autoscaler")))
+	assert.True(t, isEphemeralSeries(matchers, labels.FromStrings(labels.MetricName, "autoscaling_queue_length")))
+	assert.False(t, isEphemeralSeries(matchers, labels.FromStrings(labels.MetricName, "up", "job", "api")))
+	assert.False(t, isEphemeralSeries(nil, labels.FromStrings(labels.MetricName, "up")))
+}
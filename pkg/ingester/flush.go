@@ -1,7 +1,27 @@
 package ingester
 
 import (
+	"crypto/rand"
+	"math"
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid"
+
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// maxFlushJobs is the number of flush jobs whose status is kept in memory. The oldest
+// finished jobs are evicted first.
+const maxFlushJobs = 100
+
+const (
+	flushJobPending    = "pending"
+	flushJobCompacting = "compacting"
+	flushJobShipping   = "shipping"
+	flushJobDone       = "done"
+	flushJobAborted    = "aborted"
 )
 
 // Flush triggers a flush of all the chunks and closes the flush queues.
@@ -15,3 +35,220 @@ func (i *Ingester) Flush() {
 func (i *Ingester) FlushHandler(w http.ResponseWriter, r *http.Request) {
 	i.flushHandler(w, r)
 }
+
+// FlushJobsHandler reports the status of the flush jobs triggered by the FlushHandler.
+func (i *Ingester) FlushJobsHandler(w http.ResponseWriter, r *http.Request) {
+	if id := r.FormValue(jobIDParam); id != "" {
+		job := i.flushJobs.get(id)
+		if job == nil {
+			http.Error(w, "flush job not found", http.StatusNotFound)
+			return
+		}
+		util.WriteJSONResponse(w, job.status())
+		return
+	}
+
+	util.WriteJSONResponse(w, i.flushJobs.list())
+}
+
+// flushJob tracks the progress of a flush of the in-memory series of the selected tenants,
+// overlapping the selected time range. The flushes without a job, like the ones of the
+// scale-down, aren't tracked: the methods of a nil job are no-ops.
+type flushJob struct {
+	id      string
+	tenants []string
+	users   *util.AllowedTenants // if nil, all tenants are flushed.
+	minTime int64
+	maxTime int64
+
+	mtx        sync.Mutex
+	state      string
+	createdAt  time.Time
+	finishedAt time.Time
+	progress   map[string]*flushJobTenant
+}
+
+// flushJobTenant is the progress of a flush job for a tenant.
+type flushJobTenant struct {
+	Compacted bool   `json:"compacted"`
+	Shipped   bool   `json:"shipped"`
+	Error     string `json:"error,omitempty"`
+}
+
+// flushJobStatus is the JSON representation of a flush job.
+type flushJobStatus struct {
+	ID         string                     `json:"id"`
+	Status     string                     `json:"status"`
+	Tenants    []string                   `json:"tenants,omitempty"`
+	Start      *time.Time                 `json:"start,omitempty"`
+	End        *time.Time                 `json:"end,omitempty"`
+	CreatedAt  time.Time                  `json:"created_at"`
+	FinishedAt *time.Time                 `json:"finished_at,omitempty"`
+	Progress   map[string]*flushJobTenant `json:"progress"`
+}
+
+func newFlushJob(tenants []string, minTime, maxTime int64, now time.Time) *flushJob {
+	job := &flushJob{
+		id:        ulid.MustNew(ulid.Timestamp(now), rand.Reader).String(),
+		tenants:   tenants,
+		minTime:   minTime,
+		maxTime:   maxTime,
+		state:     flushJobPending,
+		createdAt: now,
+		progress:  map[string]*flushJobTenant{},
+	}
+	if len(tenants) > 0 {
+		job.users = util.NewAllowedTenants(tenants, nil)
+	}
+	return job
+}
+
+// allowedUsers returns the tenants selected by the job, or nil if all the tenants are flushed.
+func (j *flushJob) allowedUsers() *util.AllowedTenants {
+	if j == nil {
+		return nil
+	}
+	return j.users
+}
+
+// overlaps returns whether the time range of the job overlaps the given one.
+func (j *flushJob) overlaps(minTime, maxTime int64) bool {
+	return j.minTime <= maxTime && j.maxTime >= minTime
+}
+
+func (j *flushJob) setState(state string, now time.Time) {
+	if j == nil {
+		return
+	}
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	j.state = state
+	if state == flushJobDone || state == flushJobAborted {
+		j.finishedAt = now
+	}
+}
+
+func (j *flushJob) finished() bool {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	return j.state == flushJobDone || j.state == flushJobAborted
+}
+
+// compacted records the result of the compaction of the head of a tenant.
+func (j *flushJob) compacted(userID string, err error) {
+	if j == nil {
+		return
+	}
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	p := &flushJobTenant{Compacted: err == nil}
+	if err != nil {
+		p.Error = err.Error()
+	}
+	j.progress[userID] = p
+}
+
+// shipped records the result of the shipping of the blocks of a tenant.
+func (j *flushJob) shipped(userID string, err error) {
+	if j == nil {
+		return
+	}
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	p, ok := j.progress[userID]
+	if !ok {
+		return
+	}
+	p.Shipped = err == nil
+	if err != nil {
+		p.Error = err.Error()
+	}
+}
+
+func (j *flushJob) status() flushJobStatus {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	s := flushJobStatus{
+		ID:        j.id,
+		Status:    j.state,
+		Tenants:   j.tenants,
+		CreatedAt: j.createdAt,
+		Progress:  make(map[string]*flushJobTenant, len(j.progress)),
+	}
+	if j.minTime != math.MinInt64 {
+		start := util.TimeFromMillis(j.minTime)
+		s.Start = &start
+	}
+	if j.maxTime != math.MaxInt64 {
+		end := util.TimeFromMillis(j.maxTime)
+		s.End = &end
+	}
+	if !j.finishedAt.IsZero() {
+		finishedAt := j.finishedAt
+		s.FinishedAt = &finishedAt
+	}
+	for userID, p := range j.progress {
+		c := *p
+		s.Progress[userID] = &c
+	}
+	return s
+}
+
+// flushJobs holds the most recent flush jobs.
+type flushJobs struct {
+	mtx  sync.Mutex
+	jobs []*flushJob // Sorted by creation time.
+}
+
+func (f *flushJobs) add(job *flushJob) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.jobs = append(f.jobs, job)
+	for n := len(f.jobs) - maxFlushJobs; n > 0; n-- {
+		idx := -1
+		for i, j := range f.jobs {
+			if j.finished() {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			// The jobs in progress are never evicted.
+			return
+		}
+		f.jobs = append(f.jobs[:idx], f.jobs[idx+1:]...)
+	}
+}
+
+func (f *flushJobs) get(id string) *flushJob {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	for _, j := range f.jobs {
+		if j.id == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// list returns the status of the flush jobs, most recent first.
+func (f *flushJobs) list() []flushJobStatus {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	res := make([]flushJobStatus, 0, len(f.jobs))
+	for i := len(f.jobs) - 1; i >= 0; i-- {
+		res = append(res, f.jobs[i].status())
+	}
+	return res
+}
@@ -29,9 +29,11 @@ import (
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/prometheus/prometheus/tsdb/wlog"
 	"github.com/thanos-io/objstore"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/gate"
 	"github.com/thanos-io/thanos/pkg/shipper"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/weaveworks/common/httpgrpc"
@@ -47,12 +49,15 @@ import (
 	"github.com/cortexproject/cortex/pkg/storage/bucket"
 	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/engine"
 	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/concurrency"
 	"github.com/cortexproject/cortex/pkg/util/extract"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
 	logutil "github.com/cortexproject/cortex/pkg/util/log"
 	util_math "github.com/cortexproject/cortex/pkg/util/math"
+	"github.com/cortexproject/cortex/pkg/util/priority"
 	"github.com/cortexproject/cortex/pkg/util/services"
 	"github.com/cortexproject/cortex/pkg/util/spanlogger"
 	"github.com/cortexproject/cortex/pkg/util/validation"
@@ -73,6 +78,9 @@ const (
 
 	instanceIngestionRateTickInterval = time.Second
 
+	// Number of items returned in each TSDB status top list, when not specified in the request.
+	defaultTSDBStatusLimit = 10
+
 	// Number of timeseries to return in each batch of a QueryStream.
 	queryStreamBatchSize    = 128
 	metadataStreamBatchSize = 128
@@ -88,6 +96,8 @@ const (
 var (
 	errExemplarRef      = errors.New("exemplars not ingested because series not already present")
 	errIngesterStopping = errors.New("ingester stopping")
+
+	errInvalidEphemeralSeriesRetentionPeriod = errors.New("the ephemeral series retention period must be greater than 0")
 )
 
 // Config for an Ingester.
@@ -116,6 +126,12 @@ type Config struct {
 	QueryStoreForLabels  bool          `yaml:"-"`
 	QueryIngestersWithin time.Duration `yaml:"-"`
 
+	// Injected at runtime and read from the ingester client config, used to
+	// transfer the TSDBs to another ingester on shutdown.
+	IngesterClient client.Config `yaml:"-"`
+
+	MaxTransferRetries int `yaml:"max_transfer_retries"`
+
 	DefaultLimits    InstanceLimits         `yaml:"instance_limits"`
 	InstanceLimitsFn func() *InstanceLimits `yaml:"-"`
 
@@ -126,12 +142,23 @@ type Config struct {
 
 	// For admin contact details
 	AdminLimitMessage string `yaml:"admin_limit_message"`
+
+	ScaleDownDrainPeriod time.Duration `yaml:"scale_down_drain_period"`
+
+	EphemeralSeriesRetentionPeriod time.Duration `yaml:"ephemeral_series_retention_period"`
+
+	MaxConcurrentQueries int `yaml:"max_concurrent_queries"`
+
+	QueryStreamSamplesWindow     time.Duration `yaml:"query_stream_samples_window"`
+	QueryStreamSnapshotsEnabled  bool          `yaml:"query_stream_snapshots_enabled"`
+	QueryStreamSnapshotsMaxBytes int           `yaml:"query_stream_snapshots_max_bytes"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.LifecyclerConfig.RegisterFlags(f)
 
+	f.IntVar(&cfg.MaxTransferRetries, "ingester.max-transfer-retries", 0, "Number of times to try to transfer the TSDBs to a PENDING ingester on shutdown, before falling back to flushing and shipping them. The transfer keeps the recently written series queryable without waiting for the blocks to be shipped and loaded by the store-gateways, and requires the new ingesters to wait in the PENDING state for -ingester.join-after. 0 = no transfers.")
 	f.DurationVar(&cfg.MetadataRetainPeriod, "ingester.metadata-retain-period", 10*time.Minute, "Period at which metadata we have not seen will remain in memory before being deleted.")
 
 	f.DurationVar(&cfg.RateUpdatePeriod, "ingester.rate-update-period", 15*time.Second, "Period with which to update the per-user ingestion rates.")
@@ -149,6 +176,23 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 
 	f.StringVar(&cfg.AdminLimitMessage, "ingester.admin-limit-message", "please contact administrator to raise it", "Customize the message contained in limit errors")
 
+	f.DurationVar(&cfg.ScaleDownDrainPeriod, "ingester.scale-down-drain-period", time.Hour, "How long an ingester being scaled down keeps serving queries after having shipped all its series, before it can be removed from the ring. It should be long enough for the store-gateways to load the shipped blocks.")
+
+	f.DurationVar(&cfg.EphemeralSeriesRetentionPeriod, "ingester.ephemeral-series-retention-period", 10*time.Minute, "Experimental: How long the samples of the ephemeral series are kept in the ingesters memory. The ephemeral series are never written to the WAL nor shipped to the storage.")
+
+	f.IntVar(&cfg.MaxConcurrentQueries, "ingester.max-concurrent-queries", 0, "Experimental: Max number of queries the ingester executes concurrently, across all tenants. The waiting queries are executed by decreasing priority, as assigned by the query-frontend. 0 = unlimited.")
+
+	f.DurationVar(&cfg.QueryStreamSamplesWindow, "ingester.query-stream-samples-window", 0, "Experimental: The float chunks of the head overlapping this window before the query time are returned to the queriers as raw samples instead of chunks, saving their encoding and decoding for the queries of the most recent data. 0 to always return chunks.")
+	f.BoolVar(&cfg.QueryStreamSnapshotsEnabled, "ingester.query-stream-snapshots-enabled", false, "Experimental: Read the series and chunks of the query stream requests into memory, and release the TSDB head before sending them to the queriers. The long-running queries and the slow queriers don't delay the head compaction, and the responses are a consistent snapshot of the TSDB, at the cost of holding the responses in memory, up to -ingester.query-stream-snapshots-max-bytes.")
+	f.IntVar(&cfg.QueryStreamSnapshotsMaxBytes, "ingester.query-stream-snapshots-max-bytes", 100<<20, "Max size of the series and chunks of a query stream request read into memory when -ingester.query-stream-snapshots-enabled is set. The series of the requests exceeding it, after the ones already read, are streamed from the TSDB head as when the snapshots are disabled. 0 to disable the limit.")
+}
+
+// Validate the config.
+func (cfg *Config) Validate() error {
+	if cfg.EphemeralSeriesRetentionPeriod <= 0 {
+		return errInvalidEphemeralSeriesRetentionPeriod
+	}
+	return nil
 }
 
 func (cfg *Config) getIgnoreSeriesLimitForMetricNamesMap() map[string]struct{} {
@@ -200,6 +244,16 @@ type Ingester struct {
 	// Rate of pushed samples. Only used by V2-ingester to limit global samples push rate.
 	ingestionRate        *util_math.EwmaRate
 	inflightPushRequests atomic.Int64
+
+	// Number of queries being executed, and state of the scale-down preparation.
+	inflightQueryRequests atomic.Int64
+	scaleDown             scaleDownState
+
+	// Gate used to limit the query concurrency, admitting the queries by priority.
+	queryGate gate.Gate
+
+	// Most recent flush jobs triggered by the flush handler.
+	flushJobs flushJobs
 }
 
 // Shipper interface is used to have an easy way to mock it in tests.
@@ -213,6 +267,7 @@ const (
 	active          tsdbState = iota // Pushes are allowed.
 	activeShipping                   // Pushes are allowed. Blocks shipping is in progress.
 	forceCompacting                  // TSDB is being force-compacted.
+	snapshotting                     // Pushes are allowed, but wait for the in-memory data snapshot in progress.
 	closing                          // Used while closing idle TSDB.
 	closed                           // Used to avoid setting closing back to active in closeAndDeleteIdleUsers method.
 )
@@ -245,12 +300,18 @@ type userTSDB struct {
 	seriesInMetric *metricCounter
 	limiter        *Limiter
 
+	// Sketches of the distinct values of the labels with a values budget.
+	labelValuesSketches *labelValuesSketches
+
 	instanceSeriesCount *atomic.Int64 // Shared across all userTSDB instances created by ingester.
 	instanceLimitsFn    func() *InstanceLimits
 
 	stateMtx       sync.RWMutex
 	state          tsdbState
-	pushesInFlight sync.WaitGroup // Increased with stateMtx read lock held, only if state == active or activeShipping.
+	pushesInFlight sync.WaitGroup // Increased with stateMtx read lock held, only if state == active, activeShipping or snapshotting.
+
+	// Read-locked by the pushes while appending, and write-locked by the memory snapshots.
+	snapshotMtx sync.RWMutex
 
 	// Used to detect idle TSDBs.
 	lastUpdate atomic.Int64
@@ -273,6 +334,14 @@ type userTSDB struct {
 	// Cached shipped blocks.
 	shippedBlocksMtx sync.Mutex
 	shippedBlocks    map[ulid.ULID]struct{}
+
+	// Registry the TSDB metrics are registered to.
+	registry prometheus.Gatherer
+
+	// Head of the ephemeral series, created on the first push of ephemeral series.
+	ephemeralMtx     sync.RWMutex
+	ephemeral        *tsdb.Head
+	ephemeralLimiter *ephemeralSeriesLimiter
 }
 
 // Explicitly wrapping the tsdb.DB functions that we use.
@@ -282,11 +351,39 @@ func (u *userTSDB) Appender(ctx context.Context) storage.Appender {
 }
 
 func (u *userTSDB) Querier(mint, maxt int64) (storage.Querier, error) {
-	return u.db.Querier(mint, maxt)
+	q, err := u.db.Querier(mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+
+	head := u.ephemeralHead()
+	if head == nil {
+		return q, nil
+	}
+	eq, err := tsdb.NewBlockQuerier(tsdb.NewRangeHeadWithIsolationDisabled(head, mint, maxt), mint, maxt)
+	if err != nil {
+		_ = q.Close()
+		return nil, err
+	}
+	return storage.NewMergeQuerier([]storage.Querier{q, eq}, nil, storage.ChainedSeriesMerge), nil
 }
 
 func (u *userTSDB) ChunkQuerier(mint, maxt int64) (storage.ChunkQuerier, error) {
-	return u.db.ChunkQuerier(mint, maxt)
+	q, err := u.db.ChunkQuerier(mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+
+	head := u.ephemeralHead()
+	if head == nil {
+		return q, nil
+	}
+	eq, err := tsdb.NewBlockChunkQuerier(tsdb.NewRangeHeadWithIsolationDisabled(head, mint, maxt), mint, maxt)
+	if err != nil {
+		_ = q.Close()
+		return nil, err
+	}
+	return storage.NewMergeChunkQuerier([]storage.ChunkQuerier{q, eq}, nil, storage.NewCompactingChunkSeriesMerger(storage.ChainedSeriesMerge)), nil
 }
 
 func (u *userTSDB) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
@@ -297,12 +394,41 @@ func (u *userTSDB) Head() *tsdb.Head {
 	return u.db.Head()
 }
 
+// headChunks returns the number of chunks in the head, as tracked by the TSDB metrics.
+func (u *userTSDB) headChunks() uint64 {
+	if u.registry == nil {
+		return 0
+	}
+
+	families, err := u.registry.Gather()
+	if err != nil {
+		return 0
+	}
+	mfm, err := util.NewMetricFamilyMap(families)
+	if err != nil {
+		return 0
+	}
+	return uint64(mfm.SumGauges("prometheus_tsdb_head_chunks"))
+}
+
 func (u *userTSDB) Blocks() []*tsdb.Block {
 	return u.db.Blocks()
 }
 
 func (u *userTSDB) Close() error {
-	return u.db.Close()
+	u.ephemeralMtx.Lock()
+	defer u.ephemeralMtx.Unlock()
+
+	var ephemeralErr error
+	if u.ephemeral != nil {
+		ephemeralErr = u.ephemeral.Close()
+		u.ephemeralLimiter.release()
+		u.ephemeral, u.ephemeralLimiter = nil, nil
+	}
+	if err := u.db.Close(); err != nil {
+		return err
+	}
+	return ephemeralErr
 }
 
 func (u *userTSDB) Compact(ctx context.Context) error {
@@ -325,7 +451,7 @@ func (u *userTSDB) casState(from, to tsdbState) bool {
 }
 
 // compactHead compacts the Head block at specified block durations avoiding a single huge block.
-func (u *userTSDB) compactHead(blockDuration int64) error {
+func (u *userTSDB) compactHead(blockDuration, maxTime int64) error {
 	if !u.casState(active, forceCompacting) {
 		return errors.New("TSDB head cannot be compacted because it is not in active state (possibly being closed or blocks shipping in progress)")
 	}
@@ -339,9 +465,13 @@ func (u *userTSDB) compactHead(blockDuration int64) error {
 
 	h := u.Head()
 
-	minTime, maxTime := h.MinTime(), h.MaxTime()
+	// Only the data up to the max time is compacted, and the head is truncated up to it.
+	headRange := func() (int64, int64) {
+		return h.MinTime(), min(h.MaxTime(), maxTime)
+	}
+	minTime, rangeMaxTime := headRange()
 
-	for (minTime/blockDuration)*blockDuration != (maxTime/blockDuration)*blockDuration {
+	for minTime <= rangeMaxTime && (minTime/blockDuration)*blockDuration != (rangeMaxTime/blockDuration)*blockDuration {
 		// Data in Head spans across multiple block ranges, so we break it into blocks here.
 		// Block max time is exclusive, so we do a -1 here.
 		blockMaxTime := ((minTime/blockDuration)+1)*blockDuration - 1
@@ -350,10 +480,13 @@ func (u *userTSDB) compactHead(blockDuration int64) error {
 		}
 
 		// Get current min/max times after compaction.
-		minTime, maxTime = h.MinTime(), h.MaxTime()
+		minTime, rangeMaxTime = headRange()
+	}
+	if minTime > rangeMaxTime {
+		return nil
 	}
 
-	return u.db.CompactHead(tsdb.NewRangeHead(h, minTime, maxTime))
+	return u.db.CompactHead(tsdb.NewRangeHead(h, minTime, rangeMaxTime))
 }
 
 // PreCreation implements SeriesLifecycleCallback interface.
@@ -397,6 +530,7 @@ func (u *userTSDB) PostCreation(metric labels.Labels) {
 		return
 	}
 	u.seriesInMetric.increaseSeriesForMetric(metricName)
+	u.labelValuesSketches.add(metric)
 }
 
 // PostDeletion implements SeriesLifecycleCallback interface.
@@ -547,10 +681,16 @@ type TSDBState struct {
 	appenderAddDuration    prometheus.Histogram
 	appenderCommitDuration prometheus.Histogram
 	idleTsdbChecks         *prometheus.CounterVec
+
+	// In-memory data snapshots metrics.
+	memorySnapshotsTotal   prometheus.Counter
+	memorySnapshotsFailed  prometheus.Counter
+	memorySnapshotDuration prometheus.Histogram
 }
 
 type requestWithUsersAndCallback struct {
 	users    *util.AllowedTenants // if nil, all tenants are allowed.
+	job      *flushJob            // if not nil, the flush job tracking the progress of the request.
 	callback chan<- struct{}      // when compaction/shipping is finished, this channel is closed
 }
 
@@ -604,6 +744,20 @@ func newTSDBState(bucketClient objstore.Bucket, registerer prometheus.Registerer
 		}),
 
 		idleTsdbChecks: idleTsdbChecks,
+
+		memorySnapshotsTotal: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_tsdb_memory_snapshots_total",
+			Help: "Total number of periodic snapshots of the in-memory TSDB data.",
+		}),
+		memorySnapshotsFailed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_tsdb_memory_snapshots_failed_total",
+			Help: "Total number of periodic snapshots of the in-memory TSDB data that failed.",
+		}),
+		memorySnapshotDuration: promauto.With(registerer).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_ingester_tsdb_memory_snapshot_duration_seconds",
+			Help:    "The time it takes to snapshot the in-memory TSDB data of a tenant.",
+			Buckets: prometheus.DefBuckets,
+		}),
 	}
 }
 
@@ -629,6 +783,11 @@ func New(cfg Config, limits *validation.Overrides, registerer prometheus.Registe
 	}
 	i.metrics = newIngesterMetrics(registerer, false, cfg.ActiveSeriesMetricsEnabled, i.getInstanceLimits, i.ingestionRate, &i.inflightPushRequests)
 
+	i.queryGate = gate.NewNoop()
+	if cfg.MaxConcurrentQueries > 0 {
+		i.queryGate = priority.NewGate(cfg.MaxConcurrentQueries, gate.NewNoop(), "cortex_ingester_queries_", registerer)
+	}
+
 	// Replace specific metrics which we can't directly track but we need to read
 	// them from the underlying system (ie. TSDB).
 	if registerer != nil {
@@ -691,6 +850,7 @@ func NewForFlusher(cfg Config, limits *validation.Overrides, registerer promethe
 		limits:    limits,
 		TSDBState: newTSDBState(bucketClient, registerer),
 		logger:    logger,
+		queryGate: gate.NewNoop(),
 	}
 	i.metrics = newIngesterMetrics(registerer, false, false, i.getInstanceLimits, nil, &i.inflightPushRequests)
 
@@ -815,10 +975,20 @@ func (i *Ingester) updateLoop(ctx context.Context) error {
 	metadataPurgeTicker := time.NewTicker(metadataPurgePeriod)
 	defer metadataPurgeTicker.Stop()
 
+	ephemeralSeriesTruncateTicker := time.NewTicker(ephemeralSeriesTruncatePeriod)
+	defer ephemeralSeriesTruncateTicker.Stop()
+
+	labelValuesSketchesTicker := time.NewTicker(labelValuesSketchesRebuildPeriod)
+	defer labelValuesSketchesTicker.Stop()
+
 	for {
 		select {
 		case <-metadataPurgeTicker.C:
 			i.purgeUserMetricsMetadata()
+		case <-ephemeralSeriesTruncateTicker.C:
+			i.truncateEphemeralSeries()
+		case <-labelValuesSketchesTicker.C:
+			i.rebuildLabelValuesSketches(ctx)
 		case <-ingestionRateTicker.C:
 			i.ingestionRate.Tick()
 		case <-rateUpdateTicker.C:
@@ -946,6 +1116,9 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	if err := i.checkRunning(); err != nil {
 		return nil, err
 	}
+	if i.lifecycler.GetState() == ring.READONLY {
+		return nil, errIngesterReadOnly
+	}
 
 	span, ctx := opentracing.StartSpanFromContext(ctx, "Ingester.Push")
 	defer span.Finish()
@@ -1016,6 +1189,9 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 		perUserSeriesLimitCount   = 0
 		perMetricSeriesLimitCount = 0
 		nativeHistogramCount      = 0
+		createdSamplesCount       = 0
+
+		perUserEphemeralSeriesLimitCount = 0
 
 		updateFirstPartial = func(errFn func() error) {
 			if firstPartialErr == nil {
@@ -1026,26 +1202,69 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 
 	// Walk the samples, appending them to the users database
 	app := db.Appender(ctx).(extendedAppender)
+	// The ephemeral series are appended to their own head, created on the first ephemeral series.
+	var ephemeralApp extendedAppender
+	ephemeralMatchers := i.limits.EphemeralSeriesMatchers(userID)
+	// The zero sample at the created timestamp could be ingested in the middle of the existing
+	// samples when out-of-order samples are accepted, so it's only ingested when they're not.
+	createdTimestampZeroIngestion := i.limits.CreatedTimestampZeroIngestion(userID) && i.limits.OutOfOrderTimeWindow(userID) == 0
+	rollback := func() {
+		if rollbackErr := app.Rollback(); rollbackErr != nil {
+			level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "failed to rollback on error", "user", userID, "err", rollbackErr)
+		}
+		if ephemeralApp != nil {
+			if rollbackErr := ephemeralApp.Rollback(); rollbackErr != nil {
+				level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "failed to rollback the ephemeral series on error", "user", userID, "err", rollbackErr)
+			}
+		}
+	}
+
 	for _, ts := range req.Timeseries {
 		// The labels must be sorted (in our case, it's guaranteed a write request
 		// has sorted labels once hit the ingester).
-
-		// Look up a reference for this series.
 		tsLabels := cortexpb.FromLabelAdaptersToLabels(ts.Labels)
 		tsLabelsHash := tsLabels.Hash()
-		ref, copiedLabels := app.GetRef(tsLabels, tsLabelsHash)
+
+		seriesApp := app
+		ephemeral := req.Ephemeral || isEphemeralSeries(ephemeralMatchers, tsLabels)
+		if ephemeral {
+			if ephemeralApp == nil {
+				head, err := i.getOrCreateEphemeralHead(db)
+				if err != nil {
+					rollback()
+					return nil, wrapWithUser(err, userID)
+				}
+				ephemeralApp = head.Appender(ctx).(extendedAppender)
+			}
+			seriesApp = ephemeralApp
+		}
+
+		// Look up a reference for this series.
+		ref, copiedLabels := seriesApp.GetRef(tsLabels, tsLabelsHash)
 
 		// To find out if any sample was added to this series, we keep old value.
 		oldSucceededSamplesCount := succeededSamplesCount
 
 		nativeHistogramCount += len(ts.Histograms)
 
+		// The zero sample is rejected as out-of-order unless the counter has been created or
+		// reset after the latest sample of the series, so its failures are ignored.
+		if createdTimestampZeroIngestion && ts.CreatedTimestampMs > 0 && len(ts.Samples) > 0 && ts.CreatedTimestampMs < ts.Samples[0].TimestampMs {
+			if ref == 0 {
+				copiedLabels = cortexpb.FromLabelAdaptersToLabelsWithCopy(ts.Labels)
+			}
+			if createdRef, err := seriesApp.Append(ref, copiedLabels, ts.CreatedTimestampMs, 0); err == nil {
+				ref = createdRef
+				createdSamplesCount++
+			}
+		}
+
 		for _, s := range ts.Samples {
 			var err error
 
 			// If the cached reference exists, we try to use it.
 			if ref != 0 {
-				if _, err = app.Append(ref, copiedLabels, s.TimestampMs, s.Value); err == nil {
+				if _, err = seriesApp.Append(ref, copiedLabels, s.TimestampMs, s.Value); err == nil {
 					succeededSamplesCount++
 					continue
 				}
@@ -1055,7 +1274,7 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 				copiedLabels = cortexpb.FromLabelAdaptersToLabelsWithCopy(ts.Labels)
 
 				// Retain the reference in case there are multiple samples for the series.
-				if ref, err = app.Append(0, copiedLabels, s.TimestampMs, s.Value); err == nil {
+				if ref, err = seriesApp.Append(0, copiedLabels, s.TimestampMs, s.Value); err == nil {
 					succeededSamplesCount++
 					continue
 				}
@@ -1093,6 +1312,11 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 				updateFirstPartial(func() error { return makeLimitError(perUserSeriesLimit, i.limiter.FormatError(userID, cause)) })
 				continue
 
+			case errMaxEphemeralSeriesPerUserLimitExceeded:
+				perUserEphemeralSeriesLimitCount++
+				updateFirstPartial(func() error { return makeLimitError(perUserEphemeralSeriesLimit, i.limiter.FormatError(userID, cause)) })
+				continue
+
 			case errMaxSeriesPerMetricLimitExceeded:
 				perMetricSeriesLimitCount++
 				updateFirstPartial(func() error {
@@ -1102,13 +1326,16 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 			}
 
 			// The error looks an issue on our side, so we should rollback
-			if rollbackErr := app.Rollback(); rollbackErr != nil {
-				level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "failed to rollback on error", "user", userID, "err", rollbackErr)
-			}
+			rollback()
 
 			return nil, wrapWithUser(err, userID)
 		}
 
+		// The ephemeral series aren't tracked as active series, and have no exemplars.
+		if ephemeral {
+			continue
+		}
+
 		if i.cfg.ActiveSeriesMetricsEnabled && succeededSamplesCount > oldSucceededSamplesCount {
 			db.activeSeries.UpdateSeries(tsLabels, tsLabelsHash, startAppend, func(l labels.Labels) labels.Labels {
 				// we must already have copied the labels if succeededSamplesCount has been incremented.
@@ -1154,6 +1381,12 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	i.TSDBState.appenderAddDuration.Observe(time.Since(startAppend).Seconds())
 
 	startCommit := time.Now()
+	if ephemeralApp != nil {
+		if err := ephemeralApp.Commit(); err != nil {
+			_ = app.Rollback()
+			return nil, wrapWithUser(err, userID)
+		}
+	}
 	if err := app.Commit(); err != nil {
 		return nil, wrapWithUser(err, userID)
 	}
@@ -1169,6 +1402,7 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	// which will be converted into an HTTP 5xx and the client should/will retry.
 	i.metrics.ingestedSamples.Add(float64(succeededSamplesCount))
 	i.metrics.ingestedSamplesFail.Add(float64(failedSamplesCount))
+	i.metrics.ingestedCreatedSamples.Add(float64(createdSamplesCount))
 	i.metrics.ingestedExemplars.Add(float64(succeededExemplarsCount))
 	i.metrics.ingestedExemplarsFail.Add(float64(failedExemplarsCount))
 
@@ -1190,6 +1424,9 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	if perMetricSeriesLimitCount > 0 {
 		validation.DiscardedSamples.WithLabelValues(perMetricSeriesLimit, userID).Add(float64(perMetricSeriesLimitCount))
 	}
+	if perUserEphemeralSeriesLimitCount > 0 {
+		validation.DiscardedSamples.WithLabelValues(perUserEphemeralSeriesLimit, userID).Add(float64(perUserEphemeralSeriesLimitCount))
+	}
 
 	if nativeHistogramCount > 0 {
 		validation.DiscardedSamples.WithLabelValues(nativeHistogramSample, userID).Add(float64(nativeHistogramCount))
@@ -1221,12 +1458,23 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 }
 
 func (u *userTSDB) acquireAppendLock() error {
+	if err := u.addPushInFlight(); err != nil {
+		return err
+	}
+
+	// The pushes wait for the memory snapshot in progress, if any, instead of failing.
+	u.snapshotMtx.RLock()
+	return nil
+}
+
+func (u *userTSDB) addPushInFlight() error {
 	u.stateMtx.RLock()
 	defer u.stateMtx.RUnlock()
 
 	switch u.state {
 	case active:
 	case activeShipping:
+	case snapshotting:
 		// Pushes are allowed.
 	case forceCompacting:
 		return errors.New("forced compaction in progress")
@@ -1241,6 +1489,7 @@ func (u *userTSDB) acquireAppendLock() error {
 }
 
 func (u *userTSDB) releaseAppendLock() {
+	u.snapshotMtx.RUnlock()
 	u.pushesInFlight.Done()
 }
 
@@ -1250,6 +1499,14 @@ func (i *Ingester) Query(ctx context.Context, req *client.QueryRequest) (*client
 		return nil, err
 	}
 
+	i.inflightQueryRequests.Inc()
+	defer i.inflightQueryRequests.Dec()
+
+	if err := i.queryGate.Start(ctx); err != nil {
+		return nil, err
+	}
+	defer i.queryGate.Done()
+
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
@@ -1322,6 +1579,9 @@ func (i *Ingester) QueryExemplars(ctx context.Context, req *client.ExemplarQuery
 		return nil, err
 	}
 
+	i.inflightQueryRequests.Inc()
+	defer i.inflightQueryRequests.Dec()
+
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
@@ -1732,6 +1992,49 @@ func createUserStats(db *userTSDB, activeSeriesMetricsEnabled bool) *client.User
 	}
 }
 
+// TSDBStatus returns the cardinality statistics of the head of the current user TSDB.
+func (i *Ingester) TSDBStatus(ctx context.Context, req *client.TSDBStatusRequest) (*client.TSDBStatusResponse, error) {
+	if err := i.checkRunning(); err != nil {
+		return nil, err
+	}
+
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	db := i.getTSDB(userID)
+	if db == nil {
+		return &client.TSDBStatusResponse{MinTime: math.MaxInt64, MaxTime: math.MinInt64}, nil
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultTSDBStatusLimit
+	}
+
+	stats := db.Head().Stats(labels.MetricName, limit)
+	return &client.TSDBStatusResponse{
+		NumSeries:                   stats.NumSeries,
+		ChunkCount:                  db.headChunks(),
+		MinTime:                     stats.MinTime,
+		MaxTime:                     stats.MaxTime,
+		NumLabelPairs:               int64(stats.IndexPostingStats.NumLabelPairs),
+		SeriesCountByMetricName:     toTSDBStatistics(stats.IndexPostingStats.CardinalityMetricsStats),
+		LabelValueCountByLabelName:  toTSDBStatistics(stats.IndexPostingStats.CardinalityLabelStats),
+		MemoryInBytesByLabelName:    toTSDBStatistics(stats.IndexPostingStats.LabelValueStats),
+		SeriesCountByLabelValuePair: toTSDBStatistics(stats.IndexPostingStats.LabelValuePairsStats),
+	}, nil
+}
+
+func toTSDBStatistics(stats []index.Stat) []client.TSDBStatistic {
+	out := make([]client.TSDBStatistic, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, client.TSDBStatistic{Name: s.Name, Value: s.Count})
+	}
+	return out
+}
+
 const queryStreamBatchMessageSize = 1 * 1024 * 1024
 
 // QueryStream implements service.IngesterServer
@@ -1741,9 +2044,17 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 		return err
 	}
 
+	i.inflightQueryRequests.Inc()
+	defer i.inflightQueryRequests.Dec()
+
 	spanlog, ctx := spanlogger.New(stream.Context(), "QueryStream")
 	defer spanlog.Finish()
 
+	if err := i.queryGate.Start(ctx); err != nil {
+		return err
+	}
+	defer i.queryGate.Done()
+
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return err
@@ -1768,9 +2079,17 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 		return nil
 	}
 
+	// The chunks overlapping the samples window are returned as raw samples.
+	samplesFrom := int64(math.MaxInt64)
+	if i.cfg.QueryStreamSamplesWindow > 0 {
+		samplesFrom = time.Now().Add(-i.cfg.QueryStreamSamplesWindow).UnixMilli()
+	}
+
 	numSamples := 0
 	numSeries := 0
-	numSeries, numSamples, err = i.queryStreamChunks(ctx, db, int64(from), int64(through), matchers, shardMatcher, stream)
+	// The series limit keeps the first series by labels, so that the subset is deterministic.
+	sortSeries := req.SortSeries || req.SeriesLimit > 0
+	numSeries, numSamples, err = i.queryStreamChunks(ctx, db, int64(from), int64(through), samplesFrom, sortSeries, int(req.SeriesLimit), matchers, shardMatcher, stream)
 
 	if err != nil {
 		return err
@@ -1782,23 +2101,60 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 	return nil
 }
 
-// queryStreamChunks streams metrics from a TSDB. This implements the client.IngesterServer interface
-func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, sm *storepb.ShardMatcher, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
+// queryStreamChunks streams metrics from a TSDB. This implements the client.IngesterServer interface.
+// The float chunks ending after samplesFrom are streamed as raw samples. The series are streamed
+// sorted by labels if sortSeries is set, for the distributor to merge them lazily. If seriesLimit
+// is positive, the series after the first seriesLimit ones aren't streamed, and the last message
+// is flagged as reaching the limit.
+func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, through, samplesFrom int64, sortSeries bool, seriesLimit int, matchers []*labels.Matcher, sm *storepb.ShardMatcher, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
 	q, err := db.ChunkQuerier(from, through)
 	if err != nil {
 		return 0, 0, err
 	}
-	defer q.Close()
+	querierOpen := true
+	defer func() {
+		if querierOpen {
+			_ = q.Close()
+		}
+	}()
 
-	// It's not required to return sorted series because series are sorted by the Cortex querier.
-	ss := q.Select(ctx, false, nil, matchers...)
+	// Unless requested, it's not required to return sorted series because series are sorted by the Cortex querier.
+	ss := q.Select(ctx, sortSeries, nil, matchers...)
 	if ss.Err() != nil {
 		return 0, 0, ss.Err()
 	}
 
+	if i.cfg.QueryStreamSnapshotsEnabled {
+		snapshotAt := time.Now()
+		var complete bool
+		ss, complete, err = snapshotChunkSeriesSet(ss, i.cfg.QueryStreamSnapshotsMaxBytes)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if complete {
+			// The head truncation waits for the open queriers, so the querier is closed before
+			// streaming the series, which may take a while with the slow queriers.
+			querierOpen = false
+			if err := q.Close(); err != nil {
+				return 0, 0, err
+			}
+			defer func() {
+				i.metrics.queryStreamSnapshotAge.Observe(time.Since(snapshotAt).Seconds())
+			}()
+		} else {
+			// The series which didn't fit in the snapshot are streamed from the open querier.
+			i.metrics.queryStreamSnapshotsTooLarge.Inc()
+		}
+	}
+
+	storageEngine := i.limits.StorageEngine(db.userID)
 	chunkSeries := make([]client.TimeSeriesChunk, 0, queryStreamBatchSize)
+	var samplesSeries []cortexpb.TimeSeries
 	batchSizeBytes := 0
 	var it chunks.Iterator
+	var samplesIt chunkenc.Iterator
+	limitReached := false
 	for ss.Next() {
 		series := ss.At()
 
@@ -1806,10 +2162,16 @@ func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, th
 			continue
 		}
 
+		if seriesLimit > 0 && numSeries >= seriesLimit {
+			limitReached = true
+			break
+		}
+
 		// convert labels to LabelAdapter
 		ts := client.TimeSeriesChunk{
 			Labels: cortexpb.FromLabelsToLabelAdapters(series.Labels()),
 		}
+		samples := cortexpb.TimeSeries{Labels: ts.Labels}
 
 		it := series.Iterator(it)
 		for it.Next() {
@@ -1822,30 +2184,65 @@ func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, th
 				return 0, 0, errors.Errorf("unfilled chunk returned from TSDB chunk querier")
 			}
 
+			if meta.MaxTime >= samplesFrom && meta.Chunk.Encoding() == chunkenc.EncXOR {
+				samplesIt = meta.Chunk.Iterator(samplesIt)
+				for samplesIt.Next() == chunkenc.ValFloat {
+					t, v := samplesIt.At()
+					if t >= from && t <= through {
+						samples.Samples = append(samples.Samples, cortexpb.Sample{TimestampMs: t, Value: v})
+						numSamples++
+					}
+				}
+				if err := samplesIt.Err(); err != nil {
+					return 0, 0, errors.Wrap(err, "failed to read chunk samples")
+				}
+				continue
+			}
+
+			// The chunks the tenant's storage engine can't encode are sent in their original encoding.
+			chk, err := engine.Encode(storageEngine, meta.Chunk)
+			if err != nil {
+				return 0, 0, errors.Wrap(err, "failed to encode chunk with the storage engine")
+			}
+
 			ch := client.Chunk{
 				StartTimestampMs: meta.MinTime,
 				EndTimestampMs:   meta.MaxTime,
-				Data:             meta.Chunk.Bytes(),
 			}
 
-			switch meta.Chunk.Encoding() {
+			switch chk.Encoding() {
 			case chunkenc.EncXOR:
 				ch.Encoding = int32(encoding.PrometheusXorChunk)
+				ch.Data = chk.Bytes()
 			default:
-				return 0, 0, errors.Errorf("unknown chunk encoding from TSDB chunk querier: %v", meta.Chunk.Encoding())
+				if _, ok := engine.ForEncoding(chk.Encoding()); !ok {
+					return 0, 0, errors.Errorf("unknown chunk encoding from TSDB chunk querier: %v", chk.Encoding())
+				}
+				ch.Encoding = int32(encoding.StorageEngineChunk)
+				ch.Data = encoding.MarshalStorageEngineChunk(chk)
 			}
 
 			ts.Chunks = append(ts.Chunks, ch)
 			numSamples += meta.Chunk.NumSamples()
 		}
 		numSeries++
-		tsSize := ts.Size()
 
-		if (batchSizeBytes > 0 && batchSizeBytes+tsSize > queryStreamBatchMessageSize) || len(chunkSeries) >= queryStreamBatchSize {
+		// The series is sent as chunks, unless all its chunks are sent as samples.
+		sendChunks := len(ts.Chunks) > 0 || len(samples.Samples) == 0
+		tsSize := 0
+		if sendChunks {
+			tsSize += ts.Size()
+		}
+		if len(samples.Samples) > 0 {
+			tsSize += samples.Size()
+		}
+
+		if (batchSizeBytes > 0 && batchSizeBytes+tsSize > queryStreamBatchMessageSize) || len(chunkSeries)+len(samplesSeries) >= queryStreamBatchSize {
 			// Adding this series to the batch would make it too big,
 			// flush the data and add it to new batch instead.
 			err = client.SendQueryStream(stream, &client.QueryStreamResponse{
 				Chunkseries: chunkSeries,
+				Timeseries:  samplesSeries,
 			})
 			if err != nil {
 				return 0, 0, err
@@ -1853,9 +2250,15 @@ func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, th
 
 			batchSizeBytes = 0
 			chunkSeries = chunkSeries[:0]
+			samplesSeries = nil
 		}
 
-		chunkSeries = append(chunkSeries, ts)
+		if sendChunks {
+			chunkSeries = append(chunkSeries, ts)
+		}
+		if len(samples.Samples) > 0 {
+			samplesSeries = append(samplesSeries, samples)
+		}
 		batchSizeBytes += tsSize
 	}
 
@@ -1865,9 +2268,11 @@ func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, th
 	}
 
 	// Final flush any existing metrics
-	if batchSizeBytes != 0 {
+	if batchSizeBytes != 0 || limitReached {
 		err = client.SendQueryStream(stream, &client.QueryStreamResponse{
-			Chunkseries: chunkSeries,
+			Chunkseries:        chunkSeries,
+			Timeseries:         samplesSeries,
+			SeriesLimitReached: limitReached,
 		})
 		if err != nil {
 			return 0, 0, err
@@ -1954,8 +2359,10 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 
 	userDB := &userTSDB{
 		userID:              userID,
+		registry:            tsdbPromReg,
 		activeSeries:        NewActiveSeries(),
 		seriesInMetric:      newMetricCounter(i.limiter, i.cfg.getIgnoreSeriesLimitForMetricNamesMap()),
+		labelValuesSketches: newLabelValuesSketches(i.limits.LabelValuesBudgets(userID)),
 		ingestedAPISamples:  util_math.NewEWMARate(0.2, i.cfg.RateUpdatePeriod),
 		ingestedRuleSamples: util_math.NewEWMARate(0.2, i.cfg.RateUpdatePeriod),
 
@@ -1974,6 +2381,15 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 	if i.cfg.BlocksStorageConfig.TSDB.WALCompressionEnabled {
 		walCompressType = wlog.CompressionSnappy
 	}
+	walSegmentSize := i.cfg.BlocksStorageConfig.TSDB.WALSegmentSizeBytes
+	enableMemorySnapshotOnShutdown := i.cfg.BlocksStorageConfig.TSDB.IsMemorySnapshotEnabled()
+	if i.limits.IngesterWALDisabled(userID) {
+		// The tenant opted in to rely on the replication only: a negative segment size disables
+		// the WAL, and the memory snapshot is skipped because it can't be replayed without it.
+		level.Info(userLogger).Log("msg", "WAL disabled for the tenant")
+		walSegmentSize = -1
+		enableMemorySnapshotOnShutdown = false
+	}
 	// Create a new user database
 	db, err := tsdb.Open(udir, userLogger, tsdbPromReg, &tsdb.Options{
 		RetentionDuration:              i.cfg.BlocksStorageConfig.TSDB.Retention.Milliseconds(),
@@ -1983,14 +2399,14 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 		StripeSize:                     i.cfg.BlocksStorageConfig.TSDB.StripeSize,
 		HeadChunksWriteBufferSize:      i.cfg.BlocksStorageConfig.TSDB.HeadChunksWriteBufferSize,
 		WALCompression:                 walCompressType,
-		WALSegmentSize:                 i.cfg.BlocksStorageConfig.TSDB.WALSegmentSizeBytes,
+		WALSegmentSize:                 walSegmentSize,
 		SeriesLifecycleCallback:        userDB,
 		BlocksToDelete:                 userDB.blocksToDelete,
 		EnableExemplarStorage:          enableExemplars,
 		IsolationDisabled:              true,
 		MaxExemplars:                   maxExemplarsForUser,
 		HeadChunksWriteQueueSize:       i.cfg.BlocksStorageConfig.TSDB.HeadChunksWriteQueueSize,
-		EnableMemorySnapshotOnShutdown: i.cfg.BlocksStorageConfig.TSDB.MemorySnapshotOnShutdown,
+		EnableMemorySnapshotOnShutdown: enableMemorySnapshotOnShutdown,
 		OutOfOrderTimeWindow:           time.Duration(oooTimeWindow).Milliseconds(),
 		OutOfOrderCapMax:               i.cfg.BlocksStorageConfig.TSDB.OutOfOrderCapMax,
 	}, nil)
@@ -2244,10 +2660,10 @@ func (i *Ingester) shipBlocksLoop(ctx context.Context) error {
 	for {
 		select {
 		case <-shipTicker.C:
-			i.shipBlocks(ctx, nil)
+			i.shipBlocks(ctx, nil, nil)
 
 		case req := <-i.TSDBState.shipTrigger:
-			i.shipBlocks(ctx, req.users)
+			i.shipBlocks(ctx, req.users, req.job)
 			close(req.callback) // Notify back.
 
 		case <-ctx.Done():
@@ -2256,8 +2672,9 @@ func (i *Ingester) shipBlocksLoop(ctx context.Context) error {
 	}
 }
 
-// shipBlocks runs shipping for all users.
-func (i *Ingester) shipBlocks(ctx context.Context, allowed *util.AllowedTenants) {
+// shipBlocks runs shipping for all users. The shipping results of the tenants compacted by the
+// flush job, if not nil, are recorded in the job.
+func (i *Ingester) shipBlocks(ctx context.Context, allowed *util.AllowedTenants, job *flushJob) {
 	// Do not ship blocks if the ingester is PENDING or JOINING. It's
 	// particularly important for the JOINING state because there could
 	// be a blocks transfer in progress (from another ingester) and if we
@@ -2320,6 +2737,7 @@ func (i *Ingester) shipBlocks(ctx context.Context, allowed *util.AllowedTenants)
 		}
 
 		uploaded, err := userDB.shipper.Sync(ctx)
+		job.shipped(userID, err)
 		if err != nil {
 			level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "shipper failed to synchronize TSDB blocks with the storage", "user", userID, "uploaded", uploaded, "err", err)
 		} else {
@@ -2345,13 +2763,25 @@ func (i *Ingester) compactionLoop(ctx context.Context) error {
 	ticker := time.NewTicker(i.cfg.BlocksStorageConfig.TSDB.HeadCompactionInterval)
 	defer ticker.Stop()
 
+	// The memory snapshots run in the compaction loop, so that they're never taken
+	// while the head is being compacted and truncated.
+	var snapshotTicker <-chan time.Time
+	if interval := i.cfg.BlocksStorageConfig.TSDB.MemorySnapshotInterval; interval > 0 {
+		t := time.NewTicker(util.DurationWithJitter(interval, 0.05))
+		defer t.Stop()
+		snapshotTicker = t.C
+	}
+
 	for ctx.Err() == nil {
 		select {
 		case <-ticker.C:
-			i.compactBlocks(ctx, false, nil)
+			i.compactBlocks(ctx, false, nil, nil)
+
+		case <-snapshotTicker:
+			i.snapshotMemory(ctx)
 
 		case req := <-i.TSDBState.forceCompactTrigger:
-			i.compactBlocks(ctx, true, req.users)
+			i.compactBlocks(ctx, true, req.users, req.job)
 			close(req.callback) // Notify back.
 
 		case <-ctx.Done():
@@ -2362,7 +2792,9 @@ func (i *Ingester) compactionLoop(ctx context.Context) error {
 }
 
 // Compacts all compactable blocks. Force flag will force compaction even if head is not compactable yet.
-func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *util.AllowedTenants) {
+// The forced compaction of a flush job, if not nil, only compacts the heads overlapping the time range
+// of the job, up to its end, and records the results in the job.
+func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *util.AllowedTenants, job *flushJob) {
 	// Don't compact TSDB blocks while JOINING as there may be ongoing blocks transfers.
 	// Compaction loop is not running in LEAVING state, so if we get here in LEAVING state, we're flushing blocks.
 	if i.lifecycler != nil {
@@ -2388,20 +2820,30 @@ func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *util.
 			return nil
 		}
 
+		// The flush jobs only compact the heads overlapping their time range.
+		if force && job != nil && !job.overlaps(h.MinTime(), h.MaxTime()) {
+			return nil
+		}
+
 		var err error
 
 		i.TSDBState.compactionsTriggered.Inc()
 
 		reason := ""
 		switch {
+		case force && job != nil:
+			reason = "forced"
+			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds(), job.maxTime)
+			job.compacted(userID, err)
+
 		case force:
 			reason = "forced"
-			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds())
+			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds(), math.MaxInt64)
 
 		case i.TSDBState.compactionIdleTimeout > 0 && userDB.isIdle(time.Now(), i.TSDBState.compactionIdleTimeout):
 			reason = "idle"
 			level.Info(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB is idle, forcing compaction", "user", userID)
-			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds())
+			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds(), math.MaxInt64)
 
 		default:
 			reason = "regular"
@@ -2419,6 +2861,46 @@ func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *util.
 	})
 }
 
+// snapshotMemory snapshots the in-memory data of all TSDBs on disk, so that a restart
+// only replays the WAL written after the snapshot.
+func (i *Ingester) snapshotMemory(ctx context.Context) {
+	_ = concurrency.ForEachUser(ctx, i.getTSDBUsers(), i.cfg.BlocksStorageConfig.TSDB.HeadCompactionConcurrency, func(ctx context.Context, userID string) error {
+		if userDB := i.getTSDB(userID); userDB != nil {
+			i.snapshotUserMemory(ctx, userDB)
+		}
+		return nil
+	})
+}
+
+func (i *Ingester) snapshotUserMemory(ctx context.Context, userDB *userTSDB) {
+	// Make sure the TSDB state is active, in order to avoid any race condition with closing idle TSDBs.
+	if !userDB.casState(active, snapshotting) {
+		level.Debug(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB memory snapshot skipped because the TSDB is not active", "user", userDB.userID)
+		return
+	}
+	defer userDB.casState(snapshotting, active)
+
+	// The snapshot records the WAL position before walking the series, while the appends are
+	// logged to the WAL before being added to the series. So we wait for the in-flight appends
+	// to finish, otherwise their samples could be neither in the snapshot nor in the replayed WAL.
+	// Future appends wait until the snapshot is over.
+	userDB.snapshotMtx.Lock()
+	defer userDB.snapshotMtx.Unlock()
+
+	i.TSDBState.memorySnapshotsTotal.Inc()
+	start := time.Now()
+
+	stats, err := userDB.Head().ChunkSnapshot()
+	if err != nil {
+		i.TSDBState.memorySnapshotsFailed.Inc()
+		level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB memory snapshot for user has failed", "user", userDB.userID, "err", err)
+		return
+	}
+
+	i.TSDBState.memorySnapshotDuration.Observe(time.Since(start).Seconds())
+	level.Debug(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB memory snapshot completed successfully", "user", userDB.userID, "series", stats.TotalSeries, "duration", time.Since(start))
+}
+
 func (i *Ingester) closeAndDeleteIdleUserTSDBs(ctx context.Context) error {
 	for _, userID := range i.getTSDBUsers() {
 		if ctx.Err() != nil {
@@ -2634,9 +3116,9 @@ func (i *Ingester) lifecyclerFlush() {
 
 	ctx := context.Background()
 
-	i.compactBlocks(ctx, true, nil)
+	i.compactBlocks(ctx, true, nil, nil)
 	if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
-		i.shipBlocks(ctx, nil)
+		i.shipBlocks(ctx, nil, nil)
 	}
 
 	level.Info(i.logger).Log("msg", "finished flushing and shipping TSDB blocks")
@@ -2645,81 +3127,136 @@ func (i *Ingester) lifecyclerFlush() {
 const (
 	tenantParam = "tenant"
 	waitParam   = "wait"
+	startParam  = "start"
+	endParam    = "end"
+	jobIDParam  = "job_id"
 )
 
-// Blocks version of Flush handler. It force-compacts blocks, and triggers shipping.
+// Blocks version of Flush handler. It force-compacts blocks, and triggers shipping. The flush is
+// tracked by a flush job, whose status is returned if the request selects a time range. The requests
+// without a time range get an empty response, as before the flush jobs were introduced.
 func (i *Ingester) flushHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logutil.WithContext(r.Context(), i.logger)
+
 	err := r.ParseForm()
 	if err != nil {
-		level.Warn(logutil.WithContext(r.Context(), i.logger)).Log("msg", "failed to parse HTTP request in flush handler", "err", err)
+		level.Warn(logger).Log("msg", "failed to parse HTTP request in flush handler", "err", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	tenants := r.Form[tenantParam]
-
-	allowedUsers := util.NewAllowedTenants(tenants, nil)
-	run := func() {
-		ingCtx := i.BasicService.ServiceContext()
-		if ingCtx == nil || ingCtx.Err() != nil {
-			level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "flushing TSDB blocks: ingester not running, ignoring flush request")
-			return
-		}
-
-		compactionCallbackCh := make(chan struct{})
-
-		level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "flushing TSDB blocks: triggering compaction")
-		select {
-		case i.TSDBState.forceCompactTrigger <- requestWithUsersAndCallback{users: allowedUsers, callback: compactionCallbackCh}:
-			// Compacting now.
-		case <-ingCtx.Done():
-			level.Warn(logutil.WithContext(r.Context(), i.logger)).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
+	minTime, maxTime := int64(math.MinInt64), int64(math.MaxInt64)
+	withTimeRange := r.FormValue(startParam) != "" || r.FormValue(endParam) != ""
+	if v := r.FormValue(startParam); v != "" {
+		if minTime, err = util.ParseTime(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-
-		// Wait until notified about compaction being finished.
-		select {
-		case <-compactionCallbackCh:
-			level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "finished compacting TSDB blocks")
-		case <-ingCtx.Done():
-			level.Warn(logutil.WithContext(r.Context(), i.logger)).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
+	}
+	if v := r.FormValue(endParam); v != "" {
+		if maxTime, err = util.ParseTime(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+	}
+	if minTime > maxTime {
+		http.Error(w, "the end time of the flush must not be before its start time", http.StatusBadRequest)
+		return
+	}
 
-		if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
-			shippingCallbackCh := make(chan struct{}) // must be new channel, as compactionCallbackCh is closed now.
-
-			level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "flushing TSDB blocks: triggering shipping")
-
-			select {
-			case i.TSDBState.shipTrigger <- requestWithUsersAndCallback{users: allowedUsers, callback: shippingCallbackCh}:
-				// shipping now
-			case <-ingCtx.Done():
-				level.Warn(logutil.WithContext(r.Context(), i.logger)).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
-				return
-			}
-
-			// Wait until shipping finished.
-			select {
-			case <-shippingCallbackCh:
-				level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "shipping of TSDB blocks finished")
-			case <-ingCtx.Done():
-				level.Warn(logutil.WithContext(r.Context(), i.logger)).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
-				return
-			}
-		}
+	job := newFlushJob(r.Form[tenantParam], minTime, maxTime, time.Now())
+	i.flushJobs.add(job)
 
-		level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "flushing TSDB blocks: finished")
+	logger = log.With(logger, "job_id", job.id)
+	run := func() {
+		i.flushAndShipBlocks(logger, job)
 	}
 
-	if len(r.Form[waitParam]) > 0 && r.Form[waitParam][0] == "true" {
+	wait := len(r.Form[waitParam]) > 0 && r.Form[waitParam][0] == "true"
+	if wait {
 		// Run synchronously. This simplifies and speeds up tests.
 		run()
 	} else {
 		go run()
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	switch {
+	case !withTimeRange:
+		w.WriteHeader(http.StatusNoContent)
+	case wait:
+		util.WriteJSONResponse(w, job.status())
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		util.WriteJSONResponse(w, job.status())
+	}
+}
+
+// flushAndShipBlocks force-compacts the TSDB heads of the tenants selected by the flush job, or
+// all the tenants if nil, and ships the resulting blocks, returning false if the ingester stopped
+// running in the meanwhile.
+func (i *Ingester) flushAndShipBlocks(logger log.Logger, job *flushJob) bool {
+	allowedUsers := job.allowedUsers()
+
+	ingCtx := i.BasicService.ServiceContext()
+	if ingCtx == nil || ingCtx.Err() != nil {
+		level.Info(logger).Log("msg", "flushing TSDB blocks: ingester not running, ignoring flush request")
+		job.setState(flushJobAborted, time.Now())
+		return false
+	}
+
+	compactionCallbackCh := make(chan struct{})
+
+	level.Info(logger).Log("msg", "flushing TSDB blocks: triggering compaction")
+	job.setState(flushJobCompacting, time.Now())
+	select {
+	case i.TSDBState.forceCompactTrigger <- requestWithUsersAndCallback{users: allowedUsers, job: job, callback: compactionCallbackCh}:
+		// Compacting now.
+	case <-ingCtx.Done():
+		level.Warn(logger).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
+		job.setState(flushJobAborted, time.Now())
+		return false
+	}
+
+	// Wait until notified about compaction being finished.
+	select {
+	case <-compactionCallbackCh:
+		level.Info(logger).Log("msg", "finished compacting TSDB blocks")
+	case <-ingCtx.Done():
+		level.Warn(logger).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
+		job.setState(flushJobAborted, time.Now())
+		return false
+	}
+
+	if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
+		shippingCallbackCh := make(chan struct{}) // must be new channel, as compactionCallbackCh is closed now.
+
+		level.Info(logger).Log("msg", "flushing TSDB blocks: triggering shipping")
+		job.setState(flushJobShipping, time.Now())
+
+		select {
+		case i.TSDBState.shipTrigger <- requestWithUsersAndCallback{users: allowedUsers, job: job, callback: shippingCallbackCh}:
+			// shipping now
+		case <-ingCtx.Done():
+			level.Warn(logger).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
+			job.setState(flushJobAborted, time.Now())
+			return false
+		}
+
+		// Wait until shipping finished.
+		select {
+		case <-shippingCallbackCh:
+			level.Info(logger).Log("msg", "shipping of TSDB blocks finished")
+		case <-ingCtx.Done():
+			level.Warn(logger).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
+			job.setState(flushJobAborted, time.Now())
+			return false
+		}
+	}
+
+	level.Info(logger).Log("msg", "flushing TSDB blocks: finished")
+	job.setState(flushJobDone, time.Now())
+	return true
 }
 
 // metadataQueryRange returns the best range to query for metadata queries based on the timerange in the ingester.
@@ -2763,6 +3300,18 @@ func wrappedTSDBIngestErr(ingestErr error, timestamp model.Time, labels []cortex
 	return fmt.Errorf(errTSDBIngest, ingestErr, timestamp.Time().UTC().Format(time.RFC3339Nano), cortexpb.FromLabelAdaptersToLabels(labels).String())
 }
 
+func init() {
+	// Register the messages of the samples rejected by TSDB with the codes of their errors.
+	for ingestErr, id := range map[error]globalerror.ID{
+		storage.ErrOutOfBounds:                 globalerror.SampleOutOfBounds,
+		storage.ErrOutOfOrderSample:            globalerror.SampleOutOfOrder,
+		storage.ErrDuplicateSampleForTimestamp: globalerror.SampleDuplicateTimestamp,
+		storage.ErrTooOldSample:                globalerror.SampleTooOld,
+	} {
+		id.Message(strings.Replace(errTSDBIngest, "%v", ingestErr.Error(), 1))
+	}
+}
+
 func wrappedTSDBIngestExemplarErr(ingestErr error, timestamp model.Time, seriesLabels, exemplarLabels []cortexpb.LabelAdapter) error {
 	if ingestErr == nil {
 		return nil
@@ -3,6 +3,7 @@ package ingester
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -34,6 +35,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
 	"github.com/thanos-io/thanos/pkg/shipper"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/middleware"
 	"github.com/weaveworks/common/user"
@@ -49,6 +51,8 @@ import (
 	"github.com/cortexproject/cortex/pkg/ring"
 	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
 	util_math "github.com/cortexproject/cortex/pkg/util/math"
 	"github.com/cortexproject/cortex/pkg/util/services"
 	"github.com/cortexproject/cortex/pkg/util/test"
@@ -1736,6 +1740,27 @@ func Test_Ingester_Query(t *testing.T) {
 		})
 	}
 }
+func TestWrappedTSDBIngestErr_ShouldHaveTheCodeOfTheError(t *testing.T) {
+	series := []cortexpb.LabelAdapter{{Name: labels.MetricName, Value: "test"}}
+
+	for ingestErr, expected := range map[error]globalerror.ID{
+		storage.ErrOutOfBounds:                 globalerror.SampleOutOfBounds,
+		storage.ErrOutOfOrderSample:            globalerror.SampleOutOfOrder,
+		storage.ErrDuplicateSampleForTimestamp: globalerror.SampleDuplicateTimestamp,
+		storage.ErrTooOldSample:                globalerror.SampleTooOld,
+	} {
+		err := wrapWithUser(wrappedTSDBIngestErr(ingestErr, model.Time(10), series), userID)
+		assert.Equal(t, fmt.Sprintf("user=%s: err: %v. timestamp=1970-01-01T00:00:00.01Z, series={__name__=\"test\"}", userID, ingestErr), err.Error())
+
+		id, ok := globalerror.IDFromMessage(err.Error())
+		require.True(t, ok, err.Error())
+		assert.Equal(t, expected, id)
+	}
+
+	_, ok := globalerror.IDFromMessage(wrappedTSDBIngestErr(errors.New("unknown"), model.Time(10), series).Error())
+	assert.False(t, ok)
+}
+
 func TestIngester_Query_ShouldNotCreateTSDBIfDoesNotExists(t *testing.T) {
 	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil)
 	require.NoError(t, err)
@@ -2692,7 +2717,7 @@ func TestIngester_shipBlocks(t *testing.T) {
 			}
 
 			// Ship blocks and assert on the mocked shipper
-			i.shipBlocks(context.Background(), nil)
+			i.shipBlocks(context.Background(), nil, nil)
 
 			for _, m := range mocks {
 				m.AssertNumberOfCalls(t, "Sync", tc.expectetNumberOfCall)
@@ -2724,9 +2749,9 @@ func TestIngester_dontShipBlocksWhenTenantDeletionMarkerIsPresent(t *testing.T)
 
 	pushSingleSampleWithMetadata(t, i)
 	require.Equal(t, int64(1), i.TSDBState.seriesCount.Load())
-	i.compactBlocks(context.Background(), true, nil)
+	i.compactBlocks(context.Background(), true, nil, nil)
 	require.Equal(t, int64(0), i.TSDBState.seriesCount.Load())
-	i.shipBlocks(context.Background(), nil)
+	i.shipBlocks(context.Background(), nil, nil)
 
 	numObjects := len(bucket.Objects())
 	require.NotZero(t, numObjects)
@@ -2741,9 +2766,9 @@ func TestIngester_dontShipBlocksWhenTenantDeletionMarkerIsPresent(t *testing.T)
 	// After writing tenant deletion mark,
 	pushSingleSampleWithMetadata(t, i)
 	require.Equal(t, int64(1), i.TSDBState.seriesCount.Load())
-	i.compactBlocks(context.Background(), true, nil)
+	i.compactBlocks(context.Background(), true, nil, nil)
 	require.Equal(t, int64(0), i.TSDBState.seriesCount.Load())
-	i.shipBlocks(context.Background(), nil)
+	i.shipBlocks(context.Background(), nil, nil)
 
 	numObjectsAfterMarkingTenantForDeletion := len(bucket.Objects())
 	require.Equal(t, numObjects, numObjectsAfterMarkingTenantForDeletion)
@@ -2778,7 +2803,7 @@ func TestIngester_seriesCountIsCorrectAfterClosingTSDBForDeletedTenant(t *testin
 	require.Equal(t, int64(1), i.TSDBState.seriesCount.Load())
 
 	// We call shipBlocks to check for deletion marker (it happens inside this method).
-	i.shipBlocks(context.Background(), nil)
+	i.shipBlocks(context.Background(), nil, nil)
 
 	// Verify that tenant deletion mark was found.
 	db := i.getTSDB(userID)
@@ -2863,7 +2888,7 @@ func TestIngester_closeAndDeleteUserTSDBIfIdle_shouldNotCloseTSDBIfShippingIsInP
 	}))
 
 	// Run blocks shipping in a separate go routine.
-	go i.shipBlocks(ctx, nil)
+	go i.shipBlocks(ctx, nil, nil)
 
 	// Wait until shipping starts.
 	test.Poll(t, 1*time.Second, activeShipping, func() interface{} {
@@ -2949,8 +2974,8 @@ func TestIngester_idleCloseEmptyTSDB(t *testing.T) {
 	require.NotNil(t, db)
 
 	// Run compaction and shipping.
-	i.compactBlocks(context.Background(), true, nil)
-	i.shipBlocks(context.Background(), nil)
+	i.compactBlocks(context.Background(), true, nil, nil)
+	i.shipBlocks(context.Background(), nil, nil)
 
 	// Make sure we can close completely empty TSDB without problems.
 	require.Equal(t, tsdbIdleClosed, i.closeAndDeleteUserTSDBIfIdle(userID))
@@ -3202,6 +3227,92 @@ func TestIngester_flushing(t *testing.T) {
 				require.Equal(t, 50*time.Hour.Milliseconds()+1, blocks[2].Meta().MaxTime) // Block maxt is exclusive.
 			},
 		},
+
+		"flushHandlerWithTimeRange": {
+			setupIngester: func(cfg *Config) {
+				cfg.BlocksStorageConfig.TSDB.FlushBlocksOnShutdown = false
+			},
+
+			action: func(t *testing.T, i *Ingester, reg *prometheus.Registry) {
+				pushSingleSampleAtTime(t, i, 23*time.Hour.Milliseconds())
+				pushSingleSampleAtTime(t, i, 24*time.Hour.Milliseconds()+1)
+				pushSingleSampleAtTime(t, i, 50*time.Hour.Milliseconds())
+
+				// The time range doesn't overlap the head, so nothing is compacted.
+				rec := httptest.NewRecorder()
+				i.FlushHandler(rec, httptest.NewRequest("POST", "/flush?wait=true&start=216000", nil))
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				status := flushJobStatus{}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+				require.Equal(t, flushJobDone, status.Status)
+				require.Empty(t, status.Progress)
+				verifyCompactedHead(t, i, false)
+
+				// Only the head data up to the end of the time range is flushed.
+				rec = httptest.NewRecorder()
+				i.FlushHandler(rec, httptest.NewRequest("POST", "/flush?wait=true&start=0&end=90000&tenant="+userID, nil))
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+				require.Equal(t, flushJobDone, status.Status)
+				require.Equal(t, []string{userID}, status.Tenants)
+				require.Equal(t, map[string]*flushJobTenant{userID: {Compacted: true, Shipped: true}}, status.Progress)
+
+				require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+					# HELP cortex_ingester_shipper_uploads_total Total number of uploaded TSDB blocks
+					# TYPE cortex_ingester_shipper_uploads_total counter
+					cortex_ingester_shipper_uploads_total 2
+				`), "cortex_ingester_shipper_uploads_total"))
+
+				userDB := i.getTSDB(userID)
+				require.NotNil(t, userDB)
+				blocks := userDB.Blocks()
+				require.Equal(t, 2, len(blocks))
+				require.Equal(t, 25*time.Hour.Milliseconds()+1, blocks[1].Meta().MaxTime) // Block maxt is exclusive.
+				require.Equal(t, 50*time.Hour.Milliseconds(), userDB.Head().MaxTime())
+
+				// The jobs are listed, most recent first.
+				rec = httptest.NewRecorder()
+				i.FlushJobsHandler(rec, httptest.NewRequest("GET", "/ingester/flush/jobs", nil))
+				jobs := []flushJobStatus{}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jobs))
+				require.Len(t, jobs, 2)
+				require.Equal(t, status.ID, jobs[0].ID)
+
+				rec = httptest.NewRecorder()
+				i.FlushJobsHandler(rec, httptest.NewRequest("GET", "/ingester/flush/jobs?job_id="+jobs[1].ID, nil))
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				rec = httptest.NewRecorder()
+				i.FlushJobsHandler(rec, httptest.NewRequest("GET", "/ingester/flush/jobs?job_id=unknown", nil))
+				require.Equal(t, http.StatusNotFound, rec.Code)
+
+				// Invalid time ranges are rejected.
+				rec = httptest.NewRecorder()
+				i.FlushHandler(rec, httptest.NewRequest("POST", "/flush?start=100&end=10", nil))
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+
+				// The flushes without a time range get an empty response, but are tracked by a job too.
+				rec = httptest.NewRecorder()
+				i.FlushHandler(rec, httptest.NewRequest("POST", "/flush?wait=true", nil))
+				require.Equal(t, http.StatusNoContent, rec.Code)
+				require.Empty(t, rec.Body.String())
+
+				rec = httptest.NewRecorder()
+				i.FlushJobsHandler(rec, httptest.NewRequest("GET", "/ingester/flush/jobs", nil))
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jobs))
+				require.Len(t, jobs, 3)
+				require.Equal(t, flushJobDone, jobs[0].Status)
+
+				// The asynchronous flushes with a time range return the status of their job right away.
+				rec = httptest.NewRecorder()
+				i.FlushHandler(rec, httptest.NewRequest("POST", "/flush?start=0", nil))
+				require.Equal(t, http.StatusAccepted, rec.Code)
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+				require.NotEmpty(t, status.ID)
+			},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			cfg := defaultIngesterTestConfig(t)
@@ -3346,6 +3457,63 @@ func Test_Ingester_UserStats(t *testing.T) {
 	assert.Equal(t, uint64(3), res.NumSeries)
 }
 
+func Test_Ingester_TSDBStatus(t *testing.T) {
+	series := []struct {
+		lbls      labels.Labels
+		value     float64
+		timestamp int64
+	}{
+		{labels.Labels{{Name: labels.MetricName, Value: "test_1"}, {Name: "status", Value: "200"}, {Name: "route", Value: "get_user"}}, 1, 100000},
+		{labels.Labels{{Name: labels.MetricName, Value: "test_1"}, {Name: "status", Value: "500"}, {Name: "route", Value: "get_user"}}, 1, 110000},
+		{labels.Labels{{Name: labels.MetricName, Value: "test_2"}}, 2, 200000},
+	}
+
+	// Create ingester
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	// A tenant without TSDB has empty statistics.
+	res, err := i.TSDBStatus(ctx, &client.TSDBStatusRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), res.NumSeries)
+	assert.Equal(t, int64(math.MaxInt64), res.MinTime)
+	assert.Equal(t, int64(math.MinInt64), res.MaxTime)
+
+	// Push series
+	for _, series := range series {
+		req, _, _ := mockWriteRequest(t, series.lbls, series.value, series.timestamp)
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	res, err = i.TSDBStatus(ctx, &client.TSDBStatusRequest{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), res.NumSeries)
+	assert.Equal(t, uint64(3), res.ChunkCount)
+	assert.Equal(t, int64(100000), res.MinTime)
+	assert.Equal(t, int64(200000), res.MaxTime)
+	assert.Equal(t, []client.TSDBStatistic{{Name: "test_1", Value: 2}}, res.SeriesCountByMetricName)
+	require.Len(t, res.LabelValueCountByLabelName, 1)
+	assert.Equal(t, uint64(2), res.LabelValueCountByLabelName[0].Value)
+	assert.Len(t, res.MemoryInBytesByLabelName, 1)
+	assert.Len(t, res.SeriesCountByLabelValuePair, 1)
+
+	res, err = i.TSDBStatus(ctx, &client.TSDBStatusRequest{Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, []client.TSDBStatistic{{Name: "test_1", Value: 2}, {Name: "test_2", Value: 1}}, res.SeriesCountByMetricName)
+	assert.Len(t, res.LabelValueCountByLabelName, 3)
+	assert.Len(t, res.SeriesCountByLabelValuePair, 5)
+}
+
 func Test_Ingester_AllUserStats(t *testing.T) {
 	series := []struct {
 		user      string
@@ -3437,7 +3605,7 @@ func TestIngesterCompactIdleBlock(t *testing.T) {
 
 	pushSingleSampleWithMetadata(t, i)
 
-	i.compactBlocks(context.Background(), false, nil)
+	i.compactBlocks(context.Background(), false, nil, nil)
 	verifyCompactedHead(t, i, false)
 	require.NoError(t, testutil.GatherAndCompare(r, strings.NewReader(`
 		# HELP cortex_ingester_memory_series_created_total The total number of series that were created per user.
@@ -3456,7 +3624,7 @@ func TestIngesterCompactIdleBlock(t *testing.T) {
 	// wait one second (plus maximum jitter) -- TSDB is now idle.
 	time.Sleep(time.Duration(float64(cfg.BlocksStorageConfig.TSDB.HeadCompactionIdleTimeout) * (1 + compactionIdleTimeoutJitter)))
 
-	i.compactBlocks(context.Background(), false, nil)
+	i.compactBlocks(context.Background(), false, nil, nil)
 	verifyCompactedHead(t, i, true)
 	require.NoError(t, testutil.GatherAndCompare(r, strings.NewReader(`
 		# HELP cortex_ingester_memory_series_created_total The total number of series that were created per user.
@@ -3491,6 +3659,84 @@ func TestIngesterCompactIdleBlock(t *testing.T) {
     `), memSeriesCreatedTotalName, memSeriesRemovedTotalName, "cortex_ingester_memory_users"))
 }
 
+func TestIngester_snapshotMemory(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.BlocksStorageConfig.TSDB.MemorySnapshotInterval = 1 * time.Hour // Long enough to not be reached during the test.
+
+	dataDir := t.TempDir()
+	r := prometheus.NewRegistry()
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, defaultLimitsTestConfig(), dataDir, r)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	pushSingleSampleWithMetadata(t, i)
+	i.snapshotMemory(context.Background())
+
+	snapshots, err := filepath.Glob(filepath.Join(dataDir, userID, "chunk_snapshot.*"))
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	require.NoError(t, testutil.GatherAndCompare(r, strings.NewReader(`
+		# HELP cortex_ingester_tsdb_memory_snapshots_failed_total Total number of periodic snapshots of the in-memory TSDB data that failed.
+		# TYPE cortex_ingester_tsdb_memory_snapshots_failed_total counter
+		cortex_ingester_tsdb_memory_snapshots_failed_total 0
+
+		# HELP cortex_ingester_tsdb_memory_snapshots_total Total number of periodic snapshots of the in-memory TSDB data.
+		# TYPE cortex_ingester_tsdb_memory_snapshots_total counter
+		cortex_ingester_tsdb_memory_snapshots_total 1
+	`), "cortex_ingester_tsdb_memory_snapshots_total", "cortex_ingester_tsdb_memory_snapshots_failed_total"))
+
+	// Ingestion waits for the snapshot in progress, and then succeeds.
+	db := i.getTSDB(userID)
+	require.True(t, db.casState(active, snapshotting))
+	db.snapshotMtx.Lock()
+
+	pushErr := make(chan error, 1)
+	go func() {
+		req, _, _ := mockWriteRequest(t, labels.Labels{{Name: labels.MetricName, Value: "test_during_snapshot"}}, 1, util.TimeToMillis(time.Now()))
+		_, err := i.Push(user.InjectOrgID(context.Background(), userID), req)
+		pushErr <- err
+	}()
+
+	select {
+	case err := <-pushErr:
+		require.FailNow(t, "the push should wait for the snapshot in progress", "err: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	db.snapshotMtx.Unlock()
+	require.True(t, db.casState(snapshotting, active))
+	select {
+	case err := <-pushErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the push should succeed once the snapshot is over")
+	}
+
+	// Nothing is snapshotted while the TSDB is not active.
+	require.True(t, db.casState(active, activeShipping))
+	i.snapshotMemory(context.Background())
+	require.True(t, db.casState(activeShipping, active))
+	assert.Equal(t, 1.0, testutil.ToFloat64(i.TSDBState.memorySnapshotsTotal))
+
+	// The series are loaded back from the snapshot, and the WAL written after it, on restart.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+
+	i, err = prepareIngesterWithBlocksStorageAndLimits(t, cfg, defaultLimitsTestConfig(), dataDir, prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	assert.Equal(t, uint64(2), i.getTSDB(userID).Head().NumSeries())
+}
+
 func TestIngesterCompactAndCloseIdleTSDB(t *testing.T) {
 	cfg := defaultIngesterTestConfig(t)
 	cfg.LifecyclerConfig.JoinAfter = 0
@@ -4233,6 +4479,473 @@ func TestIngester_MaxExemplarsFallBack(t *testing.T) {
 	require.Equal(t, maxExemplars, int64(5))
 }
 
+func TestIngester_WALDisabledPerTenant(t *testing.T) {
+	for name, walDisabled := range map[string]bool{
+		"WAL enabled":  false,
+		"WAL disabled": true,
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := defaultIngesterTestConfig(t)
+			cfg.LifecyclerConfig.JoinAfter = 0
+
+			limits := defaultLimitsTestConfig()
+			limits.IngesterWALDisabled = walDisabled
+			i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", nil)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			t.Cleanup(func() {
+				_ = services.StopAndAwaitTerminated(context.Background(), i)
+			})
+
+			pushSingleSampleWithMetadata(t, i)
+
+			db := i.getTSDB(userID)
+			require.NotNil(t, db)
+			assert.Equal(t, uint64(1), db.Head().NumSeries())
+
+			_, err = os.Stat(filepath.Join(db.db.Dir(), "wal"))
+			if walDisabled {
+				assert.True(t, os.IsNotExist(err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+type capturingQueryStreamServer struct {
+	mockQueryStreamServer
+	responses []*client.QueryStreamResponse
+}
+
+func (m *capturingQueryStreamServer) Send(response *client.QueryStreamResponse) error {
+	m.responses = append(m.responses, response)
+	return nil
+}
+
+func TestIngester_QueryStreamWithStorageEngine(t *testing.T) {
+	for name, tc := range map[string]struct {
+		storageEngine    string
+		expectedEncoding encoding.Encoding
+	}{
+		"default engine": {
+			storageEngine:    "xor",
+			expectedEncoding: encoding.PrometheusXorChunk,
+		},
+		"xor-snappy engine": {
+			storageEngine:    "xor-snappy",
+			expectedEncoding: encoding.StorageEngineChunk,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := defaultIngesterTestConfig(t)
+			cfg.LifecyclerConfig.JoinAfter = 0
+
+			limits := defaultLimitsTestConfig()
+			limits.StorageEngine = tc.storageEngine
+			i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", nil)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			t.Cleanup(func() {
+				_ = services.StopAndAwaitTerminated(context.Background(), i)
+			})
+
+			ctx := user.InjectOrgID(context.Background(), userID)
+			samples := make([]cortexpb.Sample, 0, 100)
+			for ix := 0; ix < 100; ix++ {
+				samples = append(samples, cortexpb.Sample{TimestampMs: int64(ix), Value: float64(ix)})
+			}
+			_, err = i.Push(ctx, writeRequestSingleSeries(labels.FromStrings(labels.MetricName, "foo"), samples))
+			require.NoError(t, err)
+
+			stream := &capturingQueryStreamServer{mockQueryStreamServer: mockQueryStreamServer{ctx: ctx}}
+			require.NoError(t, i.QueryStream(&client.QueryRequest{
+				StartTimestampMs: 0,
+				EndTimestampMs:   100,
+				Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "foo"}},
+			}, stream))
+			require.Len(t, stream.responses, 1)
+
+			resp := stream.responses[0]
+			require.Len(t, resp.Chunkseries, 1)
+			for _, c := range resp.Chunkseries[0].Chunks {
+				assert.Equal(t, int32(tc.expectedEncoding), c.Encoding)
+			}
+			assert.Equal(t, 100, resp.SamplesCount())
+
+			// The chunks are read back by the queriers whatever the engine.
+			matrix, err := chunkcompat.SeriesChunksToMatrix(0, 100, resp.Chunkseries)
+			require.NoError(t, err)
+			require.Len(t, matrix, 1)
+			require.Len(t, matrix[0].Values, 100)
+			for ix, s := range matrix[0].Values {
+				assert.Equal(t, model.SamplePair{Timestamp: model.Time(ix), Value: model.SampleValue(ix)}, s)
+			}
+		})
+	}
+}
+
+func TestIngester_QueryStreamWithSamplesWindow(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	// The window starts before the samples, so that they're all within it whatever the query time.
+	cfg.QueryStreamSamplesWindow = time.Since(time.UnixMilli(0)) + time.Hour
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	// The recent series has samples every second over 5 minutes, while the old series has no
+	// sample after its first 100 seconds.
+	ctx := user.InjectOrgID(context.Background(), userID)
+	var recent, old []cortexpb.Sample
+	for ix := int64(0); ix < 300; ix++ {
+		recent = append(recent, cortexpb.Sample{TimestampMs: ix * 1000, Value: float64(ix)})
+		if ix < 100 {
+			old = append(old, cortexpb.Sample{TimestampMs: ix * 1000, Value: float64(ix)})
+		}
+	}
+	_, err = i.Push(ctx, writeRequestSingleSeries(labels.FromStrings(labels.MetricName, "recent"), recent))
+	require.NoError(t, err)
+	_, err = i.Push(ctx, writeRequestSingleSeries(labels.FromStrings(labels.MetricName, "old"), old))
+	require.NoError(t, err)
+
+	// All the chunks overlapping the configured window are returned as raw samples.
+	matchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, "recent|old")}
+	stream := &capturingQueryStreamServer{mockQueryStreamServer: mockQueryStreamServer{ctx: ctx}}
+	require.NoError(t, i.QueryStream(&client.QueryRequest{
+		StartTimestampMs: 0,
+		EndTimestampMs:   299000,
+		Matchers:         []*client.LabelMatcher{{Type: client.REGEX_MATCH, Name: model.MetricNameLabel, Value: "recent|old"}},
+	}, stream))
+	require.Len(t, stream.responses, 1)
+	assert.Equal(t, 400, stream.responses[0].SamplesCount())
+	assert.Empty(t, stream.responses[0].Chunkseries)
+	assert.Len(t, stream.responses[0].Timeseries, 2)
+
+	// With the window starting at a fixed time, the chunks ending before it are returned as chunks,
+	// and the ones ending after it as raw samples.
+	const samplesFrom = 240000
+	stream = &capturingQueryStreamServer{mockQueryStreamServer: mockQueryStreamServer{ctx: ctx}}
+	_, _, err = i.queryStreamChunks(ctx, i.getTSDB(userID), 0, 299000, samplesFrom, false, 0, matchers, &storepb.ShardMatcher{}, stream)
+	require.NoError(t, err)
+	require.Len(t, stream.responses, 1)
+	resp := stream.responses[0]
+	assert.Equal(t, 400, resp.SamplesCount())
+
+	for _, series := range resp.Chunkseries {
+		for _, chk := range series.Chunks {
+			assert.Less(t, chk.EndTimestampMs, int64(samplesFrom))
+		}
+	}
+
+	// The raw samples are the ones of the chunks ending after the start of the window, which
+	// include all the samples of the window.
+	require.Len(t, resp.Timeseries, 1)
+	assert.Equal(t, labels.FromStrings(labels.MetricName, "recent"), cortexpb.FromLabelAdaptersToLabels(resp.Timeseries[0].Labels))
+	rawSamples := resp.Timeseries[0].Samples
+	require.NotEmpty(t, rawSamples)
+	assert.Equal(t, recent[len(recent)-len(rawSamples):], rawSamples)
+	assert.LessOrEqual(t, rawSamples[0].TimestampMs, int64(samplesFrom))
+	assert.Less(t, len(rawSamples), len(recent))
+
+	matrix, err := chunkcompat.SeriesChunksToMatrix(0, 299000, resp.Chunkseries)
+	require.NoError(t, err)
+	for _, series := range matrix {
+		switch series.Metric[model.MetricNameLabel] {
+		case "old":
+			assert.Len(t, series.Values, 100)
+		case "recent":
+			assert.Len(t, series.Values, 300-len(rawSamples))
+		}
+	}
+}
+
+func TestIngester_QueryStreamWithSortSeries(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	// The series are pushed in the reverse order of their labels.
+	ctx := user.InjectOrgID(context.Background(), userID)
+	for ix := 9; ix >= 0; ix-- {
+		_, err = i.Push(ctx, writeRequestSingleSeries(labels.FromStrings(labels.MetricName, fmt.Sprintf("series_%d", ix)), []cortexpb.Sample{{TimestampMs: 1, Value: 1}}))
+		require.NoError(t, err)
+	}
+
+	stream := &capturingQueryStreamServer{mockQueryStreamServer: mockQueryStreamServer{ctx: ctx}}
+	require.NoError(t, i.QueryStream(&client.QueryRequest{
+		StartTimestampMs: 0,
+		EndTimestampMs:   10,
+		Matchers:         []*client.LabelMatcher{{Type: client.REGEX_MATCH, Name: model.MetricNameLabel, Value: "series_.*"}},
+		SortSeries:       true,
+	}, stream))
+	require.Len(t, stream.responses, 1)
+	require.Len(t, stream.responses[0].Chunkseries, 10)
+	for ix, series := range stream.responses[0].Chunkseries {
+		assert.Equal(t, labels.FromStrings(labels.MetricName, fmt.Sprintf("series_%d", ix)), cortexpb.FromLabelAdaptersToLabels(series.Labels))
+	}
+}
+
+func TestIngester_QueryStreamWithSeriesLimit(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	// The series are pushed in the reverse order of their labels.
+	ctx := user.InjectOrgID(context.Background(), userID)
+	for ix := 9; ix >= 0; ix-- {
+		_, err = i.Push(ctx, writeRequestSingleSeries(labels.FromStrings(labels.MetricName, fmt.Sprintf("series_%d", ix)), []cortexpb.Sample{{TimestampMs: 1, Value: 1}}))
+		require.NoError(t, err)
+	}
+
+	tests := map[string]struct {
+		limit           int64
+		expectedSeries  int
+		expectedReached bool
+	}{
+		"below the number of series":    {limit: 3, expectedSeries: 3, expectedReached: true},
+		"equal to the number of series": {limit: 10, expectedSeries: 10},
+		"above the number of series":    {limit: 20, expectedSeries: 10},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			stream := &capturingQueryStreamServer{mockQueryStreamServer: mockQueryStreamServer{ctx: ctx}}
+			require.NoError(t, i.QueryStream(&client.QueryRequest{
+				StartTimestampMs: 0,
+				EndTimestampMs:   10,
+				Matchers:         []*client.LabelMatcher{{Type: client.REGEX_MATCH, Name: model.MetricNameLabel, Value: "series_.*"}},
+				SeriesLimit:      tc.limit,
+			}, stream))
+			require.Len(t, stream.responses, 1)
+			assert.Equal(t, tc.expectedReached, stream.responses[0].SeriesLimitReached)
+
+			// The first series by labels are returned, even if not requested sorted.
+			require.Len(t, stream.responses[0].Chunkseries, tc.expectedSeries)
+			for ix, series := range stream.responses[0].Chunkseries {
+				assert.Equal(t, labels.FromStrings(labels.MetricName, fmt.Sprintf("series_%d", ix)), cortexpb.FromLabelAdaptersToLabels(series.Labels))
+			}
+		})
+	}
+}
+
+// blockingQueryStreamServer blocks the sending of the responses until unblocked.
+type blockingQueryStreamServer struct {
+	capturingQueryStreamServer
+	sending chan struct{}
+	unblock chan struct{}
+}
+
+func (m *blockingQueryStreamServer) Send(response *client.QueryStreamResponse) error {
+	if len(m.responses) == 0 {
+		close(m.sending)
+	}
+	<-m.unblock
+	return m.capturingQueryStreamServer.Send(response)
+}
+
+func TestIngester_QueryStreamWithSnapshots(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.QueryStreamSnapshotsEnabled = true
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	now := time.Now().UnixMilli()
+	var samples []cortexpb.Sample
+	for ix := int64(0); ix < 300; ix++ {
+		samples = append(samples, cortexpb.Sample{TimestampMs: now - (299-ix)*1000, Value: float64(ix)})
+	}
+	_, err = i.Push(ctx, writeRequestSingleSeries(labels.FromStrings(labels.MetricName, "test"), samples))
+	require.NoError(t, err)
+
+	stream := &blockingQueryStreamServer{
+		capturingQueryStreamServer: capturingQueryStreamServer{mockQueryStreamServer: mockQueryStreamServer{ctx: ctx}},
+		sending:                    make(chan struct{}),
+		unblock:                    make(chan struct{}),
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- i.QueryStream(&client.QueryRequest{
+			StartTimestampMs: 0,
+			EndTimestampMs:   now,
+			Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "test"}},
+		}, stream)
+	}()
+	<-stream.sending
+
+	// The head compaction isn't blocked by the query being streamed.
+	compacted := make(chan struct{})
+	go func() {
+		i.compactBlocks(context.Background(), true, nil, nil)
+		close(compacted)
+	}()
+	select {
+	case <-compacted:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the head compaction is blocked by the query stream")
+	}
+	require.Len(t, i.getTSDB(userID).db.Blocks(), 1)
+	assert.Equal(t, uint64(0), i.getTSDB(userID).Head().NumSeries())
+
+	// The response is the snapshot taken before the head compaction.
+	close(stream.unblock)
+	require.NoError(t, <-errs)
+	require.Len(t, stream.responses, 1)
+	matrix, err := chunkcompat.SeriesChunksToMatrix(0, model.Time(now), stream.responses[0].Chunkseries)
+	require.NoError(t, err)
+	require.Len(t, matrix, 1)
+	require.Len(t, matrix[0].Values, 300)
+	for ix, s := range matrix[0].Values {
+		assert.Equal(t, model.SamplePair{Timestamp: model.Time(samples[ix].TimestampMs), Value: model.SampleValue(ix)}, s)
+	}
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	var observed uint64
+	for _, mf := range families {
+		if mf.GetName() == "cortex_ingester_query_stream_snapshot_age_seconds" {
+			observed = mf.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	assert.Equal(t, uint64(1), observed)
+}
+
+func TestIngester_QueryStreamWithSnapshotsExceedingMaxBytes(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.QueryStreamSnapshotsEnabled = true
+	cfg.QueryStreamSnapshotsMaxBytes = 1
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	now := time.Now().UnixMilli()
+	for ix := 0; ix < 3; ix++ {
+		_, err = i.Push(ctx, writeRequestSingleSeries(labels.FromStrings(labels.MetricName, "test", "ix", strconv.Itoa(ix)), []cortexpb.Sample{{TimestampMs: now, Value: float64(ix)}}))
+		require.NoError(t, err)
+	}
+
+	// The series exceeding the max size of the snapshot are streamed from the TSDB head.
+	stream := &capturingQueryStreamServer{mockQueryStreamServer: mockQueryStreamServer{ctx: ctx}}
+	require.NoError(t, i.QueryStream(&client.QueryRequest{
+		StartTimestampMs: 0,
+		EndTimestampMs:   now,
+		Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "test"}},
+	}, stream))
+	var series int
+	for _, resp := range stream.responses {
+		series += len(resp.Chunkseries)
+	}
+	assert.Equal(t, 3, series)
+
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+		# HELP cortex_ingester_query_stream_snapshots_too_large_total Total number of query stream requests exceeding the max size of the snapshots, partially streamed from the TSDB head.
+		# TYPE cortex_ingester_query_stream_snapshots_too_large_total counter
+		cortex_ingester_query_stream_snapshots_too_large_total 1
+	`), "cortex_ingester_query_stream_snapshots_too_large_total"))
+}
+
+func TestIngester_PushCreatedTimestampZeroSample(t *testing.T) {
+	for name, tc := range map[string]struct {
+		enabled         bool
+		oooTimeWindow   time.Duration
+		expectedSamples func(now int64) []cortexpb.Sample
+		expectedCreated float64
+	}{
+		"disabled": {
+			expectedSamples: func(now int64) []cortexpb.Sample {
+				return []cortexpb.Sample{{TimestampMs: now + 1000, Value: 5}, {TimestampMs: now + 2000, Value: 10}, {TimestampMs: now + 4000, Value: 2}}
+			},
+		},
+		"enabled": {
+			enabled: true,
+			expectedSamples: func(now int64) []cortexpb.Sample {
+				return []cortexpb.Sample{{TimestampMs: now + 500, Value: 0}, {TimestampMs: now + 1000, Value: 5}, {TimestampMs: now + 2000, Value: 10}, {TimestampMs: now + 3000, Value: 0}, {TimestampMs: now + 4000, Value: 2}}
+			},
+			expectedCreated: 2,
+		},
+		"enabled with an out-of-order time window": {
+			enabled:       true,
+			oooTimeWindow: time.Hour,
+			expectedSamples: func(now int64) []cortexpb.Sample {
+				return []cortexpb.Sample{{TimestampMs: now + 1000, Value: 5}, {TimestampMs: now + 2000, Value: 10}, {TimestampMs: now + 4000, Value: 2}}
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			cfg := defaultIngesterTestConfig(t)
+			cfg.LifecyclerConfig.JoinAfter = 0
+			limits := defaultLimitsTestConfig()
+			limits.CreatedTimestampZeroIngestion = tc.enabled
+			limits.OutOfOrderTimeWindow = model.Duration(tc.oooTimeWindow)
+
+			i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", registry)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			t.Cleanup(func() {
+				_ = services.StopAndAwaitTerminated(context.Background(), i)
+			})
+
+			ctx := user.InjectOrgID(context.Background(), userID)
+			now := time.Now().UnixMilli()
+			push := func(createdTimestamp int64, samples ...cortexpb.Sample) {
+				req := writeRequestSingleSeries(labels.FromStrings(labels.MetricName, "requests_total"), samples)
+				req.Timeseries[0].CreatedTimestampMs = createdTimestamp
+				_, err := i.Push(ctx, req)
+				require.NoError(t, err)
+			}
+
+			// The counter is created, then pushed again with the same created timestamp, and
+			// finally reset.
+			push(now+500, cortexpb.Sample{TimestampMs: now + 1000, Value: 5})
+			push(now+500, cortexpb.Sample{TimestampMs: now + 2000, Value: 10})
+			push(now+3000, cortexpb.Sample{TimestampMs: now + 4000, Value: 2})
+
+			res, err := i.Query(ctx, &client.QueryRequest{
+				StartTimestampMs: now,
+				EndTimestampMs:   now + 5000,
+				Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "requests_total"}},
+			})
+			require.NoError(t, err)
+			require.Len(t, res.Timeseries, 1)
+			assert.Equal(t, tc.expectedSamples(now), res.Timeseries[0].Samples)
+			assert.Equal(t, tc.expectedCreated, testutil.ToFloat64(i.metrics.ingestedCreatedSamples))
+			assert.Equal(t, 3.0, testutil.ToFloat64(i.metrics.ingestedSamples))
+		})
+	}
+}
+
 func generateSamplesForLabel(l labels.Labels, count int) *cortexpb.WriteRequest {
 	var lbls = make([]labels.Labels, 0, count)
 	var samples = make([]cortexpb.Sample, 0, count)
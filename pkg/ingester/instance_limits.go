@@ -1,13 +1,17 @@
 package ingester
 
-import "github.com/pkg/errors"
+import (
+	"github.com/pkg/errors"
+
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
+)
 
 var (
 	// We don't include values in the message to avoid leaking Cortex cluster configuration to users.
-	errMaxSamplesPushRateLimitReached = errors.New("cannot push more samples: ingester's samples push rate limit reached")
-	errMaxUsersLimitReached           = errors.New("cannot create TSDB: ingesters's max tenants limit reached")
-	errMaxSeriesLimitReached          = errors.New("cannot add series: ingesters's max series limit reached")
-	errTooManyInflightPushRequests    = errors.New("cannot push: too many inflight push requests in ingester")
+	errMaxSamplesPushRateLimitReached = errors.New(globalerror.IngesterMaxIngestionRate.Message("cannot push more samples: ingester's samples push rate limit reached"))
+	errMaxUsersLimitReached           = errors.New(globalerror.IngesterMaxTenants.Message("cannot create TSDB: ingesters's max tenants limit reached"))
+	errMaxSeriesLimitReached          = errors.New(globalerror.IngesterMaxSeries.Message("cannot add series: ingesters's max series limit reached"))
+	errTooManyInflightPushRequests    = errors.New(globalerror.IngesterMaxInflightPushRequests.Message("cannot push: too many inflight push requests in ingester"))
 )
 
 // InstanceLimits describes limits used by ingester. Reaching any of these will result in Push method to return
@@ -0,0 +1,165 @@
+package ingester
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/util/hll"
+)
+
+// The sketches of the label values are periodically rebuilt from the head, to forget the values of
+// the series removed from the memory. Similarly to the metadata purge period, this is a hardcoded value.
+const labelValuesSketchesRebuildPeriod = 5 * time.Minute
+
+// labelValuesSketches tracks the HyperLogLog sketches of the distinct values of the labels with a
+// values budget, among the in-memory series of a tenant. The values of the new series are added as
+// they're created, and the sketches are periodically rebuilt from the head index.
+type labelValuesSketches struct {
+	mtx      sync.Mutex
+	sketches map[string]*hll.Sketch
+	// The sketches being rebuilt, also getting the values of the new series.
+	rebuilding map[string]*hll.Sketch
+}
+
+func newLabelValuesSketches(budgets map[string]int) *labelValuesSketches {
+	sketches := make(map[string]*hll.Sketch, len(budgets))
+	for name := range budgets {
+		sketches[name] = hll.New()
+	}
+	return &labelValuesSketches{sketches: sketches}
+}
+
+// add adds the values of the labels of a new series.
+func (s *labelValuesSketches) add(lset labels.Labels) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if len(s.sketches) == 0 && len(s.rebuilding) == 0 {
+		return
+	}
+	lset.Range(func(l labels.Label) {
+		if sketch, ok := s.sketches[l.Name]; ok {
+			sketch.Insert(client.LabelValueHash(l.Value))
+		}
+		if sketch, ok := s.rebuilding[l.Name]; ok {
+			sketch.Insert(client.LabelValueHash(l.Value))
+		}
+	})
+}
+
+// rebuild replaces the sketches with the ones of the values of the labels with a budget in the index.
+func (s *labelValuesSketches) rebuild(ctx context.Context, valuesFn func(context.Context, string) ([]string, error), budgets map[string]int) error {
+	s.mtx.Lock()
+	s.rebuilding = make(map[string]*hll.Sketch, len(budgets))
+	for name := range budgets {
+		s.rebuilding[name] = hll.New()
+	}
+	s.mtx.Unlock()
+
+	defer func() {
+		s.mtx.Lock()
+		s.rebuilding = nil
+		s.mtx.Unlock()
+	}()
+
+	for name := range budgets {
+		values, err := valuesFn(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		s.mtx.Lock()
+		sketch := s.rebuilding[name]
+		for _, v := range values {
+			sketch.Insert(client.LabelValueHash(v))
+		}
+		s.mtx.Unlock()
+	}
+
+	s.mtx.Lock()
+	s.sketches = s.rebuilding
+	s.mtx.Unlock()
+	return nil
+}
+
+// encoded returns the encoded sketches, by label name.
+func (s *labelValuesSketches) encoded() map[string][]byte {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	out := make(map[string][]byte, len(s.sketches))
+	for name, sketch := range s.sketches {
+		out[name] = sketch.Bytes()
+	}
+	return out
+}
+
+// labelValues returns the values of the label among the in-memory series.
+func (u *userTSDB) labelValues(ctx context.Context, name string) ([]string, error) {
+	ir, err := u.db.Head().Index()
+	if err != nil {
+		return nil, err
+	}
+	defer ir.Close()
+
+	return ir.LabelValues(ctx, name)
+}
+
+// rebuildLabelValuesSketches rebuilds the sketches of the values of the labels with a budget, of all the tenants.
+func (i *Ingester) rebuildLabelValuesSketches(ctx context.Context) {
+	for _, userID := range i.getTSDBUsers() {
+		userDB := i.getTSDB(userID)
+		if userDB == nil {
+			continue
+		}
+
+		if err := userDB.labelValuesSketches.rebuild(ctx, userDB.labelValues, i.limits.LabelValuesBudgets(userID)); err != nil {
+			level.Warn(i.logger).Log("msg", "failed to rebuild the sketches of the label values", "user", userID, "err", err)
+		}
+	}
+}
+
+// LabelValuesSketches returns the sketches of the distinct values of the labels with a values budget,
+// of all the tenants, and the hashes of the values of the requested labels.
+func (i *Ingester) LabelValuesSketches(ctx context.Context, req *client.LabelValuesSketchesRequest) (*client.LabelValuesSketchesResponse, error) {
+	if err := i.checkRunning(); err != nil {
+		return nil, err
+	}
+
+	valueHashesLabels := map[string]map[string]bool{}
+	for _, l := range req.ValueHashesLabels {
+		if valueHashesLabels[l.UserId] == nil {
+			valueHashesLabels[l.UserId] = map[string]bool{}
+		}
+		valueHashesLabels[l.UserId][l.LabelName] = true
+	}
+
+	resp := &client.LabelValuesSketchesResponse{}
+	for _, userID := range i.getTSDBUsers() {
+		userDB := i.getTSDB(userID)
+		if userDB == nil {
+			continue
+		}
+
+		for name, sketch := range userDB.labelValuesSketches.encoded() {
+			s := client.LabelValuesSketch{UserId: userID, LabelName: name, Sketch: sketch}
+			if valueHashesLabels[userID][name] {
+				values, err := userDB.labelValues(ctx, name)
+				if err != nil {
+					return nil, err
+				}
+				s.ValueHashes = make([]uint64, 0, len(values))
+				for _, v := range values {
+					s.ValueHashes = append(s.ValueHashes, client.LabelValueHash(v))
+				}
+			}
+			resp.Sketches = append(resp.Sketches, s)
+		}
+	}
+	return resp, nil
+}
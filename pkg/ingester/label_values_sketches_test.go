@@ -0,0 +1,101 @@
+package ingester
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/util/hll"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+func TestIngester_LabelValuesSketches(t *testing.T) {
+	limits := defaultLimitsTestConfig()
+	limits.LabelValuesBudgets = map[string]int{"pod": 10}
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, defaultIngesterTestConfig(t), limits, "", prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	for ix := 0; ix < 100; ix++ {
+		// Each pod has two series.
+		for _, metric := range []string{"up", "requests_total"} {
+			lbls := labels.FromStrings(labels.MetricName, metric, "pod", fmt.Sprintf("pod-%d", ix), "instance", fmt.Sprintf("instance-%d", ix))
+			_, err := i.Push(ctx, writeRequestSingleSeries(lbls, []cortexpb.Sample{{TimestampMs: 1000, Value: 1}}))
+			require.NoError(t, err)
+		}
+	}
+
+	estimate := func(s client.LabelValuesSketch) uint64 {
+		sketch, err := hll.FromBytes(s.Sketch)
+		require.NoError(t, err)
+		return sketch.Estimate()
+	}
+
+	// Only the labels with a budget are tracked, and their values are only returned when requested.
+	// The number of distinct values is approximate.
+	resp, err := i.LabelValuesSketches(ctx, &client.LabelValuesSketchesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Sketches, 1)
+	assert.Equal(t, userID, resp.Sketches[0].UserId)
+	assert.Equal(t, "pod", resp.Sketches[0].LabelName)
+	assert.InDelta(t, 100, float64(estimate(resp.Sketches[0])), 2)
+	assert.Empty(t, resp.Sketches[0].ValueHashes)
+
+	resp, err = i.LabelValuesSketches(ctx, &client.LabelValuesSketchesRequest{ValueHashesLabels: []client.UserLabelName{{UserId: userID, LabelName: "pod"}}})
+	require.NoError(t, err)
+	require.Len(t, resp.Sketches, 1)
+	assert.Len(t, resp.Sketches[0].ValueHashes, 100)
+	assert.Contains(t, resp.Sketches[0].ValueHashes, client.LabelValueHash("pod-42"))
+
+	// The sketches rebuilt from the head have the same values.
+	i.rebuildLabelValuesSketches(ctx)
+	resp, err = i.LabelValuesSketches(ctx, &client.LabelValuesSketchesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Sketches, 1)
+	assert.InDelta(t, 100, float64(estimate(resp.Sketches[0])), 2)
+}
+
+func TestLabelValuesSketches_Rebuild(t *testing.T) {
+	s := newLabelValuesSketches(map[string]int{"pod": 10})
+	s.add(labels.FromStrings("pod", "pod-1", "instance", "instance-1"))
+	s.add(labels.FromStrings("pod", "pod-2", "instance", "instance-2"))
+
+	estimates := func() map[string]uint64 {
+		out := map[string]uint64{}
+		for name, b := range s.encoded() {
+			sketch, err := hll.FromBytes(b)
+			require.NoError(t, err)
+			out[name] = sketch.Estimate()
+		}
+		return out
+	}
+	assert.Equal(t, map[string]uint64{"pod": 2}, estimates())
+
+	// The values of the removed series are forgotten, while the values of the series created
+	// during the rebuild are kept. The sketches follow the budgets of the tenant.
+	values := map[string][]string{"pod": {"pod-2"}, "instance": {"instance-2"}}
+	err := s.rebuild(context.Background(), func(_ context.Context, name string) ([]string, error) {
+		s.add(labels.FromStrings("pod", "pod-3", "instance", "instance-3"))
+		return values[name], nil
+	}, map[string]int{"pod": 10, "instance": 10})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"pod": 2, "instance": 2}, estimates())
+
+	// No values are tracked without budgets.
+	require.NoError(t, s.rebuild(context.Background(), nil, nil))
+	s.add(labels.FromStrings("pod", "pod-4"))
+	assert.Empty(t, estimates())
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
 	util_math "github.com/cortexproject/cortex/pkg/util/math"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
@@ -18,6 +19,15 @@ var (
 	errMaxMetadataPerUserLimitExceeded   = errors.New("per-user metric metadata limit exceeded")
 )
 
+// The formats of the messages of the limit errors returned to the clients.
+var (
+	errMaxSeriesPerUserMsg          = globalerror.MaxSeriesPerUser.Message("per-user series limit of %d exceeded, %s (local limit: %d global limit: %d actual local limit: %d)")
+	errMaxEphemeralSeriesPerUserMsg = globalerror.MaxEphemeralSeriesPerUser.Message("per-user ephemeral series limit of %d exceeded, %s")
+	errMaxSeriesPerMetricMsg        = globalerror.MaxSeriesPerMetric.Message("per-metric series limit of %d exceeded, %s (local limit: %d global limit: %d actual local limit: %d)")
+	errMaxMetadataPerUserMsg        = globalerror.MaxMetadataPerUser.Message("per-user metric metadata limit of %d exceeded, %s (local limit: %d global limit: %d actual local limit: %d)")
+	errMaxMetadataPerMetricMsg      = globalerror.MaxMetadataPerMetric.Message("per-metric metadata limit of %d exceeded, %s (local limit: %d global limit: %d actual local limit: %d)")
+)
+
 // RingCount is the interface exposed by a ring implementation which allows
 // to count members
 type RingCount interface {
@@ -115,6 +125,8 @@ func (l *Limiter) FormatError(userID string, err error) error {
 		return l.formatMaxMetadataPerUserError(userID)
 	case errMaxMetadataPerMetricLimitExceeded:
 		return l.formatMaxMetadataPerMetricError(userID)
+	case errMaxEphemeralSeriesPerUserLimitExceeded:
+		return l.formatMaxEphemeralSeriesPerUserError(userID)
 	default:
 		return err
 	}
@@ -125,16 +137,21 @@ func (l *Limiter) formatMaxSeriesPerUserError(userID string) error {
 	localLimit := l.limits.MaxLocalSeriesPerUser(userID)
 	globalLimit := l.limits.MaxGlobalSeriesPerUser(userID)
 
-	return fmt.Errorf("per-user series limit of %d exceeded, %s (local limit: %d global limit: %d actual local limit: %d)",
+	return fmt.Errorf(errMaxSeriesPerUserMsg,
 		minNonZero(localLimit, globalLimit), l.AdminLimitMessage, localLimit, globalLimit, actualLimit)
 }
 
+func (l *Limiter) formatMaxEphemeralSeriesPerUserError(userID string) error {
+	return fmt.Errorf(errMaxEphemeralSeriesPerUserMsg,
+		l.limits.MaxEphemeralSeriesPerUser(userID), l.AdminLimitMessage)
+}
+
 func (l *Limiter) formatMaxSeriesPerMetricError(userID string) error {
 	actualLimit := l.maxSeriesPerMetric(userID)
 	localLimit := l.limits.MaxLocalSeriesPerMetric(userID)
 	globalLimit := l.limits.MaxGlobalSeriesPerMetric(userID)
 
-	return fmt.Errorf("per-metric series limit of %d exceeded, %s (local limit: %d global limit: %d actual local limit: %d)",
+	return fmt.Errorf(errMaxSeriesPerMetricMsg,
 		minNonZero(localLimit, globalLimit), l.AdminLimitMessage, localLimit, globalLimit, actualLimit)
 }
 
@@ -143,7 +160,7 @@ func (l *Limiter) formatMaxMetadataPerUserError(userID string) error {
 	localLimit := l.limits.MaxLocalMetricsWithMetadataPerUser(userID)
 	globalLimit := l.limits.MaxGlobalMetricsWithMetadataPerUser(userID)
 
-	return fmt.Errorf("per-user metric metadata limit of %d exceeded, %s (local limit: %d global limit: %d actual local limit: %d)",
+	return fmt.Errorf(errMaxMetadataPerUserMsg,
 		minNonZero(localLimit, globalLimit), l.AdminLimitMessage, localLimit, globalLimit, actualLimit)
 }
 
@@ -152,7 +169,7 @@ func (l *Limiter) formatMaxMetadataPerMetricError(userID string) error {
 	localLimit := l.limits.MaxLocalMetadataPerMetric(userID)
 	globalLimit := l.limits.MaxGlobalMetadataPerMetric(userID)
 
-	return fmt.Errorf("per-metric metadata limit of %d exceeded, %s (local limit: %d global limit: %d actual local limit: %d)",
+	return fmt.Errorf(errMaxMetadataPerMetricMsg,
 		minNonZero(localLimit, globalLimit), l.AdminLimitMessage, localLimit, globalLimit, actualLimit)
 }
 
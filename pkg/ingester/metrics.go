@@ -18,24 +18,28 @@ const (
 )
 
 type ingesterMetrics struct {
-	ingestedSamples         prometheus.Counter
-	ingestedExemplars       prometheus.Counter
-	ingestedMetadata        prometheus.Counter
-	ingestedSamplesFail     prometheus.Counter
-	ingestedExemplarsFail   prometheus.Counter
-	ingestedMetadataFail    prometheus.Counter
-	queries                 prometheus.Counter
-	queriedSamples          prometheus.Histogram
-	queriedExemplars        prometheus.Histogram
-	queriedSeries           prometheus.Histogram
-	queriedChunks           prometheus.Histogram
-	memSeries               prometheus.Gauge
-	memMetadata             prometheus.Gauge
-	memUsers                prometheus.Gauge
-	memSeriesCreatedTotal   *prometheus.CounterVec
-	memMetadataCreatedTotal *prometheus.CounterVec
-	memSeriesRemovedTotal   *prometheus.CounterVec
-	memMetadataRemovedTotal *prometheus.CounterVec
+	ingestedSamples              prometheus.Counter
+	ingestedExemplars            prometheus.Counter
+	ingestedMetadata             prometheus.Counter
+	ingestedSamplesFail          prometheus.Counter
+	ingestedCreatedSamples       prometheus.Counter
+	ingestedExemplarsFail        prometheus.Counter
+	ingestedMetadataFail         prometheus.Counter
+	queries                      prometheus.Counter
+	queriedSamples               prometheus.Histogram
+	queriedExemplars             prometheus.Histogram
+	queriedSeries                prometheus.Histogram
+	queriedChunks                prometheus.Histogram
+	queryStreamSnapshotAge       prometheus.Histogram
+	queryStreamSnapshotsTooLarge prometheus.Counter
+	memSeries                    prometheus.Gauge
+	memEphemeralSeries           prometheus.Gauge
+	memMetadata                  prometheus.Gauge
+	memUsers                     prometheus.Gauge
+	memSeriesCreatedTotal        *prometheus.CounterVec
+	memMetadataCreatedTotal      *prometheus.CounterVec
+	memSeriesRemovedTotal        *prometheus.CounterVec
+	memMetadataRemovedTotal      *prometheus.CounterVec
 
 	activeSeriesPerUser *prometheus.GaugeVec
 
@@ -72,6 +76,10 @@ func newIngesterMetrics(r prometheus.Registerer, createMetricsConflictingWithTSD
 			Name: "cortex_ingester_ingested_samples_failures_total",
 			Help: "The total number of samples that errored on ingestion.",
 		}),
+		ingestedCreatedSamples: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_ingested_created_timestamp_samples_total",
+			Help: "The total number of zero samples ingested at the created timestamp of the counter series.",
+		}),
 		ingestedExemplarsFail: promauto.With(r).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_ingester_ingested_exemplars_failures_total",
 			Help: "The total number of exemplars that errored on ingestion.",
@@ -108,10 +116,24 @@ func newIngesterMetrics(r prometheus.Registerer, createMetricsConflictingWithTSD
 			// A small number of chunks per series - 10*(8^(7-1)) = 2.6m.
 			Buckets: prometheus.ExponentialBuckets(10, 8, 7),
 		}),
+		queryStreamSnapshotAge: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+			Name: "cortex_ingester_query_stream_snapshot_age_seconds",
+			Help: "Age of the TSDB snapshots of the query stream requests when their last series is sent to the querier.",
+			// From 10ms to 163s - 0.01*(4^(8-1)).
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+		}),
+		queryStreamSnapshotsTooLarge: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_query_stream_snapshots_too_large_total",
+			Help: "Total number of query stream requests exceeding the max size of the snapshots, partially streamed from the TSDB head.",
+		}),
 		memSeries: promauto.With(r).NewGauge(prometheus.GaugeOpts{
 			Name: "cortex_ingester_memory_series",
 			Help: "The current number of series in memory.",
 		}),
+		memEphemeralSeries: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_ingester_memory_ephemeral_series",
+			Help: "The current number of ephemeral series in memory.",
+		}),
 		memMetadata: promauto.With(r).NewGauge(prometheus.GaugeOpts{
 			Name: "cortex_ingester_memory_metadata",
 			Help: "The current number of metadata in memory.",
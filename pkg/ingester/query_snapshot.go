@@ -0,0 +1,104 @@
+package ingester
+
+import (
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/util/annotations"
+)
+
+// snapshotChunkSeriesSet reads the series of ss into memory, copying their labels and chunks, so
+// that the querier they're read from can be closed while the series are still in use. The chunks
+// of the head may otherwise be mutated, or truncated by the head compaction, in the meantime.
+//
+// Once the series read exceed maxBytes, if positive, the reading stops: the returned set is
+// followed by the series left in ss, and the snapshot isn't complete, so the querier can't be
+// closed until the series are consumed.
+func snapshotChunkSeriesSet(ss storage.ChunkSeriesSet, maxBytes int) (_ storage.ChunkSeriesSet, complete bool, _ error) {
+	var (
+		series []storage.ChunkSeries
+		size   int
+		it     chunks.Iterator
+	)
+	for ss.Next() {
+		s := ss.At()
+
+		var metas []chunks.Meta
+		it = s.Iterator(it)
+		for it.Next() {
+			meta := it.At()
+			if meta.Chunk == nil {
+				return nil, false, errors.Errorf("unfilled chunk returned from TSDB chunk querier")
+			}
+
+			chk, err := chunkenc.FromData(meta.Chunk.Encoding(), append([]byte(nil), meta.Chunk.Bytes()...))
+			if err != nil {
+				return nil, false, errors.Wrap(err, "failed to copy chunk")
+			}
+			metas = append(metas, chunks.Meta{MinTime: meta.MinTime, MaxTime: meta.MaxTime, Chunk: chk})
+			size += len(chk.Bytes())
+		}
+		if err := it.Err(); err != nil {
+			return nil, false, err
+		}
+
+		lset := s.Labels().Copy()
+		size += len(lset.Bytes(nil))
+		series = append(series, &storage.ChunkSeriesEntry{
+			Lset: lset,
+			ChunkIteratorFn: func(chunks.Iterator) chunks.Iterator {
+				return storage.NewListChunkSeriesIterator(metas...)
+			},
+		})
+
+		if maxBytes > 0 && size > maxBytes {
+			return &snapshotSeriesSet{series: series, rest: ss, idx: -1}, false, nil
+		}
+	}
+	if err := ss.Err(); err != nil {
+		return nil, false, err
+	}
+
+	return &snapshotSeriesSet{series: series, warnings: ss.Warnings(), idx: -1}, true, nil
+}
+
+// snapshotSeriesSet is a storage.ChunkSeriesSet of the series read into memory, followed by the
+// series left in rest, if any.
+type snapshotSeriesSet struct {
+	series   []storage.ChunkSeries
+	rest     storage.ChunkSeriesSet
+	warnings annotations.Annotations
+	idx      int
+}
+
+func (s *snapshotSeriesSet) Next() bool {
+	if s.idx < len(s.series) {
+		s.idx++
+	}
+	if s.idx < len(s.series) {
+		return true
+	}
+	return s.rest != nil && s.rest.Next()
+}
+
+func (s *snapshotSeriesSet) At() storage.ChunkSeries {
+	if s.idx < len(s.series) {
+		return s.series[s.idx]
+	}
+	return s.rest.At()
+}
+
+func (s *snapshotSeriesSet) Err() error {
+	if s.rest != nil {
+		return s.rest.Err()
+	}
+	return nil
+}
+
+func (s *snapshotSeriesSet) Warnings() annotations.Annotations {
+	if s.rest != nil {
+		return s.rest.Warnings()
+	}
+	return s.warnings
+}
@@ -0,0 +1,183 @@
+package ingester
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+var (
+	errIngesterReadOnly          = status.Error(codes.Unavailable, "cannot push: the ingester is read-only")
+	errScaleDownShippingDisabled = status.Error(codes.FailedPrecondition, "cannot scale down the ingester: blocks shipping is disabled")
+	errScaleDownAlreadyRemoving  = status.Error(codes.FailedPrecondition, "the ingester is already being removed from the ring")
+	errScaleDownNotRemovable     = status.Error(codes.FailedPrecondition, "the ingester cannot be removed from the ring yet")
+)
+
+// scaleDownState tracks the preparation of the ingester for being scaled down.
+type scaleDownState struct {
+	mtx       sync.Mutex
+	flushing  bool      // Whether the series are being flushed and shipped.
+	shippedAt time.Time // When all the series have been shipped, zero if they haven't.
+	removing  bool
+}
+
+// ScaleDown implements client.IngesterServer. It drives the ingester through the steps
+// required to safely remove it from the ring: being switched to READONLY, so that it doesn't
+// receive writes anymore, flushing and shipping its series and, after the drain period,
+// leaving the ring.
+func (i *Ingester) ScaleDown(ctx context.Context, req *client.ScaleDownRequest) (*client.ScaleDownResponse, error) {
+	if err := i.checkRunningOrStopping(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch req.Action {
+	case client.SCALE_DOWN_PREPARE:
+		err = i.prepareScaleDown(ctx)
+	case client.SCALE_DOWN_CANCEL:
+		err = i.cancelScaleDown(ctx)
+	case client.SCALE_DOWN_REMOVE:
+		err = i.removeForScaleDown()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return i.scaleDownStatus(time.Now()), nil
+}
+
+// prepareScaleDown switches the ingester to READONLY, and flushes and ships its series
+// in the background. It's idempotent, and flushes again the series pushed while the
+// ring was propagating the READONLY state.
+func (i *Ingester) prepareScaleDown(ctx context.Context) error {
+	if !i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
+		return errScaleDownShippingDisabled
+	}
+	if err := i.checkRunning(); err != nil {
+		return err
+	}
+
+	i.scaleDown.mtx.Lock()
+	defer i.scaleDown.mtx.Unlock()
+
+	if i.scaleDown.removing {
+		return errScaleDownAlreadyRemoving
+	}
+
+	switch state := i.lifecycler.GetState(); state {
+	case ring.READONLY:
+	case ring.ACTIVE:
+		if err := i.lifecycler.ChangeState(ctx, ring.READONLY); err != nil {
+			return err
+		}
+		level.Info(i.logger).Log("msg", "ingester switched to read-only to be scaled down")
+	default:
+		return status.Errorf(codes.FailedPrecondition, "cannot scale down the ingester in state %s", state)
+	}
+
+	if i.scaleDown.flushing || !i.scaleDown.shippedAt.IsZero() {
+		return nil
+	}
+
+	i.scaleDown.flushing = true
+	go func() {
+		flushed := i.flushAndShipBlocks(i.logger, nil)
+
+		i.scaleDown.mtx.Lock()
+		defer i.scaleDown.mtx.Unlock()
+
+		i.scaleDown.flushing = false
+		if flushed && !i.hasUnshippedSeries() && i.lifecycler.GetState() == ring.READONLY {
+			i.scaleDown.shippedAt = time.Now()
+		}
+	}()
+	return nil
+}
+
+// cancelScaleDown switches the ingester back to ACTIVE, unless it's being removed.
+func (i *Ingester) cancelScaleDown(ctx context.Context) error {
+	i.scaleDown.mtx.Lock()
+	defer i.scaleDown.mtx.Unlock()
+
+	if i.scaleDown.removing {
+		return errScaleDownAlreadyRemoving
+	}
+	i.scaleDown.shippedAt = time.Time{}
+
+	if i.lifecycler.GetState() != ring.READONLY {
+		return nil
+	}
+	if err := i.lifecycler.ChangeState(ctx, ring.ACTIVE); err != nil {
+		return err
+	}
+	level.Info(i.logger).Log("msg", "ingester scale-down canceled, switched back to active")
+	return nil
+}
+
+// removeForScaleDown stops the ingester, unregistering it from the ring, once it's removable.
+func (i *Ingester) removeForScaleDown() error {
+	i.scaleDown.mtx.Lock()
+	defer i.scaleDown.mtx.Unlock()
+
+	if i.scaleDown.removing {
+		return nil
+	}
+	if !i.scaleDownStatusLocked(time.Now()).Removable {
+		return errScaleDownNotRemovable
+	}
+	i.scaleDown.removing = true
+
+	level.Info(i.logger).Log("msg", "removing the scaled down ingester from the ring")
+	i.lifecycler.SetFlushOnShutdown(true)
+	i.lifecycler.SetUnregisterOnShutdown(true)
+	go func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	}()
+	return nil
+}
+
+// scaleDownStatus returns the progress of the ingester scale-down.
+func (i *Ingester) scaleDownStatus(now time.Time) *client.ScaleDownResponse {
+	i.scaleDown.mtx.Lock()
+	defer i.scaleDown.mtx.Unlock()
+
+	return i.scaleDownStatusLocked(now)
+}
+
+// scaleDownStatusLocked is like scaleDownStatus, but the caller must hold the scale-down lock.
+func (i *Ingester) scaleDownStatusLocked(now time.Time) *client.ScaleDownResponse {
+	resp := &client.ScaleDownResponse{
+		ReadOnly:        i.lifecycler.GetState() == ring.READONLY,
+		Shipped:         !i.scaleDown.shippedAt.IsZero(),
+		InflightQueries: i.inflightQueryRequests.Load(),
+		Removing:        i.scaleDown.removing,
+	}
+
+	// The queriers keep querying the ingester for the recent data until the store-gateways
+	// load the shipped blocks, which is what the drain period accounts for.
+	resp.Removable = resp.ReadOnly && resp.Shipped && !resp.Removing && resp.InflightQueries == 0 &&
+		now.Sub(i.scaleDown.shippedAt) >= i.cfg.ScaleDownDrainPeriod
+	return resp
+}
+
+// hasUnshippedSeries returns whether any tenant has series in the head, or blocks not shipped yet.
+func (i *Ingester) hasUnshippedSeries() bool {
+	for _, userID := range i.getTSDBUsers() {
+		db := i.getTSDB(userID)
+		if db == nil {
+			continue
+		}
+		if db.Head().NumSeries() > 0 || db.getOldestUnshippedBlockTime() > 0 {
+			return true
+		}
+	}
+	return false
+}
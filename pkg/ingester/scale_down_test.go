@@ -0,0 +1,90 @@
+package ingester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestIngester_ScaleDown(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.BlocksStorageConfig.TSDB.ShipConcurrency = 1
+	cfg.BlocksStorageConfig.TSDB.ShipInterval = 1 * time.Minute // Long enough to not be reached during the test.
+	cfg.ScaleDownDrainPeriod = 0
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := context.Background()
+	scaleDown := func(action client.ScaleDownAction) (*client.ScaleDownResponse, error) {
+		return i.ScaleDown(ctx, &client.ScaleDownRequest{Action: action})
+	}
+
+	pushSingleSampleWithMetadata(t, i)
+
+	// An active ingester can't be removed.
+	resp, err := scaleDown(client.SCALE_DOWN_STATUS)
+	require.NoError(t, err)
+	assert.Equal(t, &client.ScaleDownResponse{}, resp)
+	_, err = scaleDown(client.SCALE_DOWN_REMOVE)
+	assert.Equal(t, errScaleDownNotRemovable, err)
+
+	// Once prepared, the ingester is read-only and ships its series.
+	_, err = scaleDown(client.SCALE_DOWN_PREPARE)
+	require.NoError(t, err)
+	assert.Equal(t, ring.READONLY, i.lifecycler.GetState())
+
+	req, _, _ := mockWriteRequest(t, labels.Labels{{Name: labels.MetricName, Value: "test"}}, 1, time.Now().UnixMilli())
+	_, err = i.Push(user.InjectOrgID(ctx, userID), req)
+	assert.Equal(t, errIngesterReadOnly, err)
+
+	test.Poll(t, 5*time.Second, true, func() interface{} {
+		resp, err := scaleDown(client.SCALE_DOWN_STATUS)
+		return err == nil && resp.Shipped && resp.Removable
+	})
+	verifyCompactedHead(t, i, true)
+
+	// Canceling switches it back to active.
+	_, err = scaleDown(client.SCALE_DOWN_CANCEL)
+	require.NoError(t, err)
+	assert.Equal(t, ring.ACTIVE, i.lifecycler.GetState())
+	resp, err = scaleDown(client.SCALE_DOWN_STATUS)
+	require.NoError(t, err)
+	assert.False(t, resp.Shipped)
+
+	// Once removable, the ingester is removed from the ring.
+	_, err = scaleDown(client.SCALE_DOWN_PREPARE)
+	require.NoError(t, err)
+	test.Poll(t, 5*time.Second, true, func() interface{} {
+		resp, err := scaleDown(client.SCALE_DOWN_STATUS)
+		return err == nil && resp.Removable
+	})
+
+	resp, err = scaleDown(client.SCALE_DOWN_REMOVE)
+	require.NoError(t, err)
+	assert.True(t, resp.Removing)
+	assert.Equal(t, errScaleDownAlreadyRemoving, i.cancelScaleDown(ctx))
+
+	require.NoError(t, i.AwaitTerminated(ctx))
+	assert.Equal(t, 0, numTokens(cfg.LifecyclerConfig.RingConfig.KVStore.Mock, "localhost", RingKey))
+}
@@ -2,16 +2,416 @@ package ingester
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/weaveworks/common/user"
 
+	"github.com/cortexproject/cortex/pkg/ingester/client"
 	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util/backoff"
 )
 
-// TransferOut finds an ingester in PENDING state and transfers our chunks to it.
-// Called as part of the ingester shutdown process.
+// transferFileChunkSize is the max size of the file data sent in a single message.
+const transferFileChunkSize = 1024 * 1024
+
+var (
+	errTransferNoPendingIngesters = errors.New("no pending ingesters")
+	errTransferTSDBsAlreadyOpen   = errors.New("cannot receive TSDBs while some are already open")
+)
+
+// TransferOut finds an ingester in PENDING state and transfers our TSDBs to it.
+// Called as part of the ingester shutdown process, after the ingester has stopped
+// accepting writes.
 func (i *Ingester) TransferOut(ctx context.Context) error {
-	// The blocks storage doesn't support blocks transferring.
-	level.Info(i.logger).Log("msg", "transfer between a LEAVING ingester and a PENDING one is not supported for the blocks storage")
-	return ring.ErrTransferDisabled
+	if i.cfg.MaxTransferRetries <= 0 {
+		return ring.ErrTransferDisabled
+	}
+
+	backoff := backoff.New(ctx, backoff.Config{
+		MinBackoff: 100 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+		MaxRetries: i.cfg.MaxTransferRetries,
+	})
+
+	for backoff.Ongoing() {
+		err := i.transferOut(ctx)
+		if err == nil {
+			level.Info(i.logger).Log("msg", "transfer successfully completed")
+			return nil
+		}
+
+		level.Warn(i.logger).Log("msg", "transfer attempt failed", "err", err, "attempt", backoff.NumRetries()+1, "max_retries", i.cfg.MaxTransferRetries)
+		backoff.Wait()
+	}
+
+	return backoff.Err()
+}
+
+func (i *Ingester) transferOut(ctx context.Context) error {
+	userIDs := i.getTSDBUsers()
+	if len(userIDs) == 0 {
+		level.Info(i.logger).Log("msg", "nothing to transfer")
+		return nil
+	}
+
+	// Compact the head of each TSDB, so that all the series are in immutable blocks
+	// on disk and the files can be safely copied while the TSDBs are open.
+	i.compactBlocks(ctx, true, nil, nil)
+
+	target, err := i.findTargetIngester(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot find ingester to transfer TSDBs to")
+	}
+
+	level.Info(i.logger).Log("msg", "sending TSDBs", "to_ingester", target.Addr)
+	c, err := i.cfg.ingesterClientFactory(target.Addr, i.cfg.IngesterClient)
+	if err != nil {
+		return err
+	}
+	defer c.Close() //nolint:errcheck
+
+	ctx = user.InjectOrgID(ctx, "-1")
+	stream, err := c.TransferTSDB(ctx)
+	if err != nil {
+		return errors.Wrap(err, "TransferTSDB")
+	}
+
+	for _, userID := range userIDs {
+		db := i.getTSDB(userID)
+		if db == nil {
+			continue
+		}
+
+		// Wait for the pushes started before the ingester stopped accepting writes.
+		db.pushesInFlight.Wait()
+
+		if err := i.transferUser(stream, userID); err != nil {
+			return errors.Wrapf(err, "transfer TSDB of user %s", userID)
+		}
+	}
+
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return errors.Wrap(err, "CloseAndRecv")
+	}
+
+	// The target ingester owns the series now, so they don't need to be shipped again.
+	level.Info(i.logger).Log("msg", "successfully sent TSDBs", "to_ingester", target.Addr)
+	return nil
+}
+
+// transferUser sends all the files of the user's TSDB, with their path relative to the TSDB dir.
+func (i *Ingester) transferUser(stream client.Ingester_TransferTSDBClient, userID string) error {
+	baseDir := i.cfg.BlocksStorageConfig.TSDB.Dir
+	buf := make([]byte, transferFileChunkSize)
+
+	userDir := i.cfg.BlocksStorageConfig.TSDB.BlocksDir(userID)
+	return filepath.Walk(userDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// The ephemeral series aren't transferred, like they're never shipped.
+			if path != userDir && info.Name() == ephemeralSeriesDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		filename, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck
+
+		for sent := false; ; sent = true {
+			n, err := f.Read(buf)
+			if err == io.EOF {
+				if sent {
+					return nil
+				}
+			} else if err != nil {
+				return err
+			}
+
+			// Empty files are sent as well, since they may be required to open the TSDB.
+			if err := stream.Send(&client.TimeSeriesFile{
+				FromIngesterId: i.lifecycler.ID,
+				UserId:         userID,
+				Filename:       filename,
+				Data:           buf[:n],
+			}); err != nil {
+				return err
+			}
+			if n == 0 {
+				return nil
+			}
+		}
+	})
+}
+
+// findTargetIngester returns a PENDING ingester, preferably in the same zone.
+func (i *Ingester) findTargetIngester(ctx context.Context) (*ring.InstanceDesc, error) {
+	ringDesc, err := i.lifecycler.KVStore.Get(ctx, i.lifecycler.RingKey)
+	if err != nil {
+		return nil, err
+	}
+	desc, ok := ringDesc.(*ring.Desc)
+	if !ok || desc == nil {
+		return nil, errTransferNoPendingIngesters
+	}
+
+	ingesters := desc.FindIngestersByState(ring.PENDING)
+	if len(ingesters) == 0 {
+		return nil, errTransferNoPendingIngesters
+	}
+
+	for _, ingester := range ingesters {
+		if ingester.Zone == i.lifecycler.Zone {
+			return &ingester, nil
+		}
+	}
+	return &ingesters[0], nil
+}
+
+// TransferTSDB receives all the TSDBs of a LEAVING ingester, and claims its tokens.
+func (i *Ingester) TransferTSDB(stream client.Ingester_TransferTSDBServer) error {
+	if err := i.checkRunning(); err != nil {
+		return err
+	}
+
+	if err := i.transfer(stream.Context(), func() (string, error) {
+		return i.xfer(stream)
+	}); err != nil {
+		return err
+	}
+
+	// Close the stream last, as this is what tells the sending ingester that
+	// it's OK to shut down.
+	if err := stream.SendAndClose(&client.TransferTSDBResponse{}); err != nil {
+		level.Error(i.logger).Log("msg", "error sending response to TSDB transfer", "err", err)
+		return err
+	}
+	return nil
+}
+
+// transfer switches the ingester to JOINING while xfer receives the TSDBs and, once
+// done, claims the tokens of the sending ingester and switches to ACTIVE. If anything
+// fails, the ingester goes back to PENDING, so that it can still join the ring.
+func (i *Ingester) transfer(ctx context.Context, xfer func() (string, error)) error {
+	if err := i.lifecycler.ChangeState(ctx, ring.JOINING); err != nil {
+		return err
+	}
+
+	defer func() {
+		if i.lifecycler.GetState() != ring.JOINING {
+			return
+		}
+
+		// The context of the stream may be canceled by now.
+		if err := i.lifecycler.ChangeState(context.Background(), ring.PENDING); err != nil {
+			level.Error(i.logger).Log("msg", "failed to switch back to PENDING after a failed transfer", "err", err)
+		}
+		i.lifecycler.Join()
+	}()
+
+	fromIngesterID, err := xfer()
+	if err != nil {
+		level.Error(i.logger).Log("msg", "TSDB transfer failed", "err", err)
+		return err
+	}
+
+	if err := i.lifecycler.ClaimTokensFor(ctx, fromIngesterID); err != nil {
+		return errors.Wrap(err, "ClaimTokensFor")
+	}
+
+	if err := i.lifecycler.ChangeState(ctx, ring.ACTIVE); err != nil {
+		return errors.Wrap(err, "ChangeState")
+	}
+
+	level.Info(i.logger).Log("msg", "TSDB transfer complete", "from_ingester", fromIngesterID)
+	return nil
+}
+
+// xfer receives the TSDB files in a temporary dir, moves them to the TSDB dir once all
+// of them have been received and opens the TSDBs. It returns the ID of the sending ingester.
+func (i *Ingester) xfer(stream client.Ingester_TransferTSDBServer) (string, error) {
+	if len(i.getTSDBUsers()) > 0 {
+		return "", errTransferTSDBsAlreadyOpen
+	}
+
+	baseDir := filepath.Clean(i.cfg.BlocksStorageConfig.TSDB.Dir)
+	tmpDir, err := os.MkdirTemp(filepath.Dir(baseDir), "tsdb-transfer-")
+	if err != nil {
+		return "", errors.Wrap(err, "create temporary dir")
+	}
+	defer os.RemoveAll(tmpDir) //nolint:errcheck
+
+	var (
+		fromIngesterID string
+		users          = map[string]struct{}{}
+		file           *os.File
+		filename       string
+		filesXfer      int
+		bytesXfer      int
+	)
+	defer func() {
+		if file != nil {
+			_ = file.Close()
+		}
+	}()
+
+	for {
+		f, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", errors.Wrap(err, "TransferTSDB Recv")
+		}
+
+		if fromIngesterID == "" {
+			fromIngesterID = f.FromIngesterId
+			level.Info(i.logger).Log("msg", "processing TSDB transfer", "from_ingester", fromIngesterID)
+
+			if err := i.checkFromIngesterIsInLeavingState(stream.Context(), fromIngesterID); err != nil {
+				return "", errors.Wrap(err, "TransferTSDB")
+			}
+		}
+
+		if f.Filename == filename && file != nil {
+			n, err := file.Write(f.Data)
+			if err != nil {
+				return "", errors.Wrap(err, "write file")
+			}
+			bytesXfer += n
+			continue
+		}
+
+		if !validTransferFilename(f.UserId, f.Filename) {
+			return "", fmt.Errorf("invalid filename %q for user %s", f.Filename, f.UserId)
+		}
+
+		// A new file is being received, so the previous one is complete.
+		if file != nil {
+			if err := file.Close(); err != nil {
+				return "", errors.Wrap(err, "close file")
+			}
+			file = nil
+		}
+
+		path := filepath.Join(tmpDir, f.Filename)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return "", errors.Wrap(err, "create dir")
+		}
+		file, err = os.Create(path)
+		if err != nil {
+			return "", errors.Wrap(err, "create file")
+		}
+		filename = f.Filename
+		users[f.UserId] = struct{}{}
+		filesXfer++
+
+		n, err := file.Write(f.Data)
+		if err != nil {
+			return "", errors.Wrap(err, "write file")
+		}
+		bytesXfer += n
+	}
+
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return "", errors.Wrap(err, "close file")
+		}
+		file = nil
+	}
+
+	if fromIngesterID == "" {
+		return "", errors.New("no TSDB files received")
+	}
+
+	level.Info(i.logger).Log("msg", "received TSDB files", "from_ingester", fromIngesterID, "users", len(users), "files", filesXfer, "bytes", bytesXfer)
+
+	for userID := range users {
+		if err := i.moveTransferredTSDB(filepath.Join(tmpDir, userID), userID); err != nil {
+			return "", err
+		}
+	}
+
+	for userID := range users {
+		if _, err := i.getOrCreateTSDB(userID, true); err != nil {
+			return "", errors.Wrapf(err, "open transferred TSDB of user %s", userID)
+		}
+	}
+
+	return fromIngesterID, nil
+}
+
+// moveTransferredTSDB moves the TSDB of a user received in srcDir to its TSDB dir,
+// which must not exist or be empty.
+func (i *Ingester) moveTransferredTSDB(srcDir, userID string) error {
+	dstDir := i.cfg.BlocksStorageConfig.TSDB.BlocksDir(userID)
+
+	if entries, err := os.ReadDir(dstDir); err == nil {
+		if len(entries) > 0 {
+			return fmt.Errorf("the TSDB dir of user %s is not empty", userID)
+		}
+		if err := os.Remove(dstDir); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstDir), 0750); err != nil {
+		return err
+	}
+	return errors.Wrapf(os.Rename(srcDir, dstDir), "move transferred TSDB of user %s", userID)
+}
+
+// validTransferFilename returns whether a transferred file belongs to the user's TSDB,
+// without escaping its dir.
+func validTransferFilename(userID, filename string) bool {
+	if userID == "" || filepath.IsAbs(filename) {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(filename), "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	parts := strings.SplitN(filepath.ToSlash(filepath.Clean(filename)), "/", 2)
+	return len(parts) == 2 && parts[0] == userID
+}
+
+// checkFromIngesterIsInLeavingState checks the sending ingester is LEAVING, otherwise
+// claiming its tokens could conflict with the ring updates it's still doing.
+func (i *Ingester) checkFromIngesterIsInLeavingState(ctx context.Context, fromIngesterID string) error {
+	v, err := i.lifecycler.KVStore.Get(ctx, i.lifecycler.RingKey)
+	if err != nil {
+		return errors.Wrap(err, "get ring")
+	}
+	desc, ok := v.(*ring.Desc)
+	if !ok || desc == nil {
+		return fmt.Errorf("ingester %s not found in the ring", fromIngesterID)
+	}
+
+	ingester, ok := desc.Ingesters[fromIngesterID]
+	if !ok {
+		return fmt.Errorf("ingester %s not found in the ring", fromIngesterID)
+	}
+	if ingester.State != ring.LEAVING {
+		return fmt.Errorf("ingester %s is in state %v, expected LEAVING", fromIngesterID, ingester.State)
+	}
+	return nil
 }
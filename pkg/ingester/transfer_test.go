@@ -0,0 +1,100 @@
+package ingester
+
+import (
+	"context"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestIngester_TransferOut(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.LifecyclerConfig.ID = "ingester-1"
+	cfg.MaxTransferRetries = 1
+	cfg.IngesterClient = defaultClientTestConfig()
+
+	sender, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), sender))
+
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+		return sender.lifecycler.GetState()
+	})
+	pushSingleSampleWithMetadata(t, sender)
+
+	// The receiving ingester waits in the PENDING state, and is reachable through gRPC.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	cfg.LifecyclerConfig.JoinAfter = time.Hour
+	cfg.LifecyclerConfig.ID = "ingester-2"
+	cfg.LifecyclerConfig.Addr = "127.0.0.1"
+	cfg.LifecyclerConfig.Port = listener.Addr().(*net.TCPAddr).Port
+
+	receiver, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), receiver))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), receiver)
+	})
+
+	server := grpc.NewServer()
+	client.RegisterIngesterServer(server, receiver)
+	go server.Serve(listener) //nolint:errcheck
+	t.Cleanup(server.Stop)
+
+	test.Poll(t, 1*time.Second, ring.PENDING, func() interface{} {
+		return receiver.lifecycler.GetState()
+	})
+
+	// Stopping the sender transfers its TSDBs to the receiver, which takes over its tokens.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), sender))
+
+	assert.Equal(t, ring.ACTIVE, receiver.lifecycler.GetState())
+	assert.Equal(t, 1, numTokens(cfg.LifecyclerConfig.RingConfig.KVStore.Mock, "ingester-2", RingKey))
+
+	res, err := receiver.Query(user.InjectOrgID(context.Background(), userID), &client.QueryRequest{
+		StartTimestampMs: math.MinInt64,
+		EndTimestampMs:   math.MaxInt64,
+		Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: labels.MetricName, Value: "test"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Timeseries, 1)
+	assert.Len(t, res.Timeseries[0].Samples, 1)
+}
+
+func TestIngester_TransferOutDisabled(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+
+	assert.Equal(t, ring.ErrTransferDisabled, i.TransferOut(context.Background()))
+}
+
+func TestValidTransferFilename(t *testing.T) {
+	for filename, expected := range map[string]bool{
+		"user-1/wal/00000000":       true,
+		"user-1/01H0000000/index":   true,
+		"user-2/wal/00000000":       false,
+		"user-1":                    false,
+		"/user-1/wal/00000000":      false,
+		"user-1/../user-2/index":    false,
+		"user-1/wal/../../../index": false,
+	} {
+		assert.Equal(t, expected, validTransferFilename("user-1", filename), filename)
+	}
+}
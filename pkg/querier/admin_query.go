@@ -0,0 +1,238 @@
+package querier
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/regexp"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+var errAdminQueryNoOperatorTenants = errors.New("at least one operator tenant must be configured when the admin APIs are enabled")
+
+// AdminQueryConfig configures the admin APIs, running a query across tenants and summarizing
+// every known tenant.
+type AdminQueryConfig struct {
+	Enabled         bool                   `yaml:"enabled"`
+	OperatorTenants flagext.StringSliceCSV `yaml:"operator_tenants"`
+	MaxConcurrency  int                    `yaml:"max_concurrency"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *AdminQueryConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "querier.admin-query.enabled", false, "Experimental: Enable the admin APIs, running an instant query across all tenants or the tenants matching a regex (/api/v1/admin/query) and summarizing every known tenant (/api/v1/admin/tenants).")
+	f.Var(&cfg.OperatorTenants, "querier.admin-query.operator-tenants", "Comma separated list of tenants allowed to use the admin APIs. Requests from other tenants are rejected.")
+	f.IntVar(&cfg.MaxConcurrency, "querier.admin-query.max-concurrency", 4, "Maximum number of tenants queried concurrently by a single admin query, or whose bucket index is read concurrently by a single tenants summary.")
+}
+
+// Validate validates the config.
+func (cfg *AdminQueryConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if len(cfg.OperatorTenants) == 0 {
+		return errAdminQueryNoOperatorTenants
+	}
+	if cfg.MaxConcurrency <= 0 {
+		return errors.New("the admin query max concurrency must be greater than 0")
+	}
+	return nil
+}
+
+// TenantsLister returns the list of known tenants.
+type TenantsLister func(ctx context.Context) ([]string, error)
+
+// NewBucketTenantsLister returns a TenantsLister listing the tenants found in the bucket,
+// excluding the ones marked for deletion.
+func NewBucketTenantsLister(bkt objstore.Bucket, logger log.Logger) TenantsLister {
+	scanner := cortex_tsdb.NewUsersScanner(bkt, cortex_tsdb.AllUsers, logger)
+	return func(ctx context.Context) ([]string, error) {
+		tenants, _, err := scanner.ScanUsers(ctx)
+		return tenants, err
+	}
+}
+
+// NewIngestersTenantsLister returns a TenantsLister listing the tenants with series in the
+// ingesters, which includes the tenants whose first block hasn't been shipped yet.
+func NewIngestersTenantsLister(stats IngestersStatsLister) TenantsLister {
+	return func(ctx context.Context) ([]string, error) {
+		userStats, err := stats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tenants := make([]string, 0, len(userStats))
+		for _, s := range userStats {
+			tenants = append(tenants, s.UserID)
+		}
+		return tenants, nil
+	}
+}
+
+// NewUnionTenantsLister returns a TenantsLister listing, sorted and deduplicated, the tenants
+// returned by any of the given listers. It fails if any of the listers fails.
+func NewUnionTenantsLister(listers ...TenantsLister) TenantsLister {
+	return func(ctx context.Context) ([]string, error) {
+		seen := map[string]struct{}{}
+		for _, list := range listers {
+			tenants, err := list(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range tenants {
+				seen[t] = struct{}{}
+			}
+		}
+
+		tenants := make([]string, 0, len(seen))
+		for t := range seen {
+			tenants = append(tenants, t)
+		}
+		sort.Strings(tenants)
+		return tenants, nil
+	}
+}
+
+type adminQueryTenantResult struct {
+	Tenant     string           `json:"tenant"`
+	ResultType parser.ValueType `json:"resultType,omitempty"`
+	Result     parser.Value     `json:"result,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+type adminQueryResult struct {
+	Status string                   `json:"status"`
+	Data   []adminQueryTenantResult `json:"data,omitempty"`
+	Error  string                   `json:"error,omitempty"`
+}
+
+// AdminQueryHandler returns a handler running an instant query for each tenant, or each tenant
+// matching the fully anchored "tenants" regex, and returning the results per tenant. Tenants are
+// queried with bounded concurrency, and only the configured operator tenants are allowed to use it.
+func AdminQueryHandler(cfg AdminQueryConfig, engine v1.QueryEngine, queryable storage.Queryable, listTenants TenantsLister, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if code, err := authorizeAdminRequest(ctx, cfg); err != nil {
+			writeAdminQueryError(w, code, err)
+			return
+		}
+
+		qs := r.FormValue("query")
+		if qs == "" {
+			writeAdminQueryError(w, http.StatusBadRequest, errors.New("missing query"))
+			return
+		}
+		if _, err := parser.ParseExpr(qs); err != nil {
+			writeAdminQueryError(w, http.StatusBadRequest, err)
+			return
+		}
+		ts, err := util.ParseTimeParam(r, "time", util.TimeToMillis(time.Now()))
+		if err != nil {
+			writeAdminQueryError(w, http.StatusBadRequest, err)
+			return
+		}
+		tenantsRegex, err := parseTenantsRegex(r)
+		if err != nil {
+			writeAdminQueryError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		allTenants, err := listTenants(ctx)
+		if err != nil {
+			writeAdminQueryError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to list tenants"))
+			return
+		}
+		var tenants []string
+		for _, t := range allTenants {
+			if tenantsRegex.MatchString(t) {
+				tenants = append(tenants, t)
+			}
+		}
+
+		level.Info(util_log.WithContext(ctx, logger)).Log("msg", "running admin query", "query", qs, "tenants", len(tenants))
+
+		var (
+			resultsMx sync.Mutex
+			results   = make([]adminQueryTenantResult, 0, len(tenants))
+		)
+		err = concurrency.ForEachUser(ctx, tenants, cfg.MaxConcurrency, func(ctx context.Context, tenantID string) error {
+			res := runAdminQuery(user.InjectOrgID(ctx, tenantID), engine, queryable, qs, util.TimeFromMillis(ts))
+			res.Tenant = tenantID
+
+			resultsMx.Lock()
+			results = append(results, res)
+			resultsMx.Unlock()
+			return nil
+		})
+		if err != nil {
+			writeAdminQueryError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		sort.Slice(results, func(i, j int) bool { return results[i].Tenant < results[j].Tenant })
+		util.WriteJSONResponse(w, adminQueryResult{Status: statusSuccess, Data: results})
+	})
+}
+
+// authorizeAdminRequest checks the request has been issued by one of the operator tenants,
+// returning the HTTP status code and error to reply with if it hasn't.
+func authorizeAdminRequest(ctx context.Context, cfg AdminQueryConfig) (int, error) {
+	callerID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+	if !util.StringsContain(cfg.OperatorTenants, callerID) {
+		return http.StatusForbidden, errors.Errorf("tenant %s is not allowed to use the admin APIs", callerID)
+	}
+	return 0, nil
+}
+
+// parseTenantsRegex parses the optional "tenants" request parameter as a fully anchored regex,
+// matching all tenants if the parameter is missing.
+func parseTenantsRegex(r *http.Request) (*regexp.Regexp, error) {
+	tenantsPattern := r.FormValue("tenants")
+	if tenantsPattern == "" {
+		tenantsPattern = ".*"
+	}
+	tenantsRegex, err := regexp.Compile("^(?:" + tenantsPattern + ")$")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid tenants regex")
+	}
+	return tenantsRegex, nil
+}
+
+func runAdminQuery(ctx context.Context, engine v1.QueryEngine, queryable storage.Queryable, qs string, ts time.Time) adminQueryTenantResult {
+	q, err := engine.NewInstantQuery(ctx, queryable, nil, qs, ts)
+	if err != nil {
+		return adminQueryTenantResult{Error: err.Error()}
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return adminQueryTenantResult{Error: res.Err.Error()}
+	}
+	return adminQueryTenantResult{ResultType: res.Value.Type(), Result: res.Value}
+}
+
+func writeAdminQueryError(w http.ResponseWriter, code int, err error) {
+	w.WriteHeader(code)
+	util.WriteJSONResponse(w, adminQueryResult{Status: statusError, Error: err.Error()})
+}
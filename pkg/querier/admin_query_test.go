@@ -0,0 +1,174 @@
+package querier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/distributor"
+	"github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/tenant"
+)
+
+func TestAdminQueryConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&AdminQueryConfig{}).Validate())
+	assert.Equal(t, errAdminQueryNoOperatorTenants, (&AdminQueryConfig{Enabled: true, MaxConcurrency: 1}).Validate())
+	assert.Error(t, (&AdminQueryConfig{Enabled: true, OperatorTenants: []string{"ops"}}).Validate())
+	assert.NoError(t, (&AdminQueryConfig{Enabled: true, OperatorTenants: []string{"ops"}, MaxConcurrency: 1}).Validate())
+}
+
+func TestAdminQueryHandler(t *testing.T) {
+	cfg := AdminQueryConfig{Enabled: true, OperatorTenants: []string{"ops"}, MaxConcurrency: 2}
+	engine := promql.NewEngine(promql.EngineOpts{
+		Logger:     log.NewNopLogger(),
+		Timeout:    10 * time.Second,
+		MaxSamples: 1e6,
+	})
+
+	// Each tenant has a single series, named after the tenant.
+	queryable := storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+		return tenantQuerier{}, nil
+	})
+	listTenants := func(context.Context) ([]string, error) {
+		return []string{"team-b", "team-a", "other", "broken"}, nil
+	}
+
+	for name, tc := range map[string]struct {
+		caller       string
+		query        string
+		listTenants  TenantsLister
+		expectedCode int
+		expectedJSON string
+	}{
+		"should reject non operator tenants": {
+			caller:       "team-a",
+			query:        `query=up&time=100`,
+			expectedCode: http.StatusForbidden,
+			expectedJSON: `{"status": "error", "error": "tenant team-a is not allowed to use the admin APIs"}`,
+		},
+		"should reject requests without query": {
+			caller:       "ops",
+			query:        `time=100`,
+			expectedCode: http.StatusBadRequest,
+			expectedJSON: `{"status": "error", "error": "missing query"}`,
+		},
+		"should reject invalid tenants regex": {
+			caller:       "ops",
+			query:        `query=up&tenants=(`,
+			expectedCode: http.StatusBadRequest,
+			expectedJSON: `{"status": "error", "error": "invalid tenants regex: error parsing regexp: missing closing ): ` + "`^(?:()$`" + `"}`,
+		},
+		"should reject invalid query": {
+			caller:       "ops",
+			query:        `query=up{&time=100`,
+			expectedCode: http.StatusBadRequest,
+			expectedJSON: `{"status": "error", "error": "1:4: parse error: unexpected end of input inside braces"}`,
+		},
+		"should fail if tenants can't be listed": {
+			caller:       "ops",
+			query:        `query=up`,
+			listTenants:  func(context.Context) ([]string, error) { return nil, errors.New("bucket unavailable") },
+			expectedCode: http.StatusInternalServerError,
+			expectedJSON: `{"status": "error", "error": "failed to list tenants: bucket unavailable"}`,
+		},
+		"should query the tenants matching the regex": {
+			caller:       "ops",
+			query:        `query=up&time=100&tenants=team-.*`,
+			expectedCode: http.StatusOK,
+			expectedJSON: `{"status": "success", "data": [
+				{"tenant": "team-a", "resultType": "vector", "result": [{"metric": {"__name__": "up", "tenant": "team-a"}, "value": [100, "1"]}]},
+				{"tenant": "team-b", "resultType": "vector", "result": [{"metric": {"__name__": "up", "tenant": "team-b"}, "value": [100, "1"]}]}
+			]}`,
+		},
+		"should report per tenant errors": {
+			caller:       "ops",
+			query:        `query=up&time=100&tenants=other|broken`,
+			expectedCode: http.StatusOK,
+			expectedJSON: `{"status": "success", "data": [
+				{"tenant": "broken", "error": "expanding series: tenant broken is broken"},
+				{"tenant": "other", "resultType": "vector", "result": [{"metric": {"__name__": "up", "tenant": "other"}, "value": [100, "1"]}]}
+			]}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			lister := tc.listTenants
+			if lister == nil {
+				lister = listTenants
+			}
+			handler := AdminQueryHandler(cfg, engine, queryable, lister, log.NewNopLogger())
+
+			req := httptest.NewRequest("GET", "/api/v1/admin/query?"+tc.query, nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), tc.caller))
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			assert.JSONEq(t, tc.expectedJSON, recorder.Body.String())
+		})
+	}
+}
+
+func TestNewUnionTenantsLister(t *testing.T) {
+	bucketTenants := func(context.Context) ([]string, error) {
+		return []string{"team-b", "team-a"}, nil
+	}
+	ingestersStats := func(context.Context) ([]distributor.UserIDStats, error) {
+		// team-c has only been pushing recently, so it has no block in the bucket yet.
+		return []distributor.UserIDStats{{UserID: "team-c"}, {UserID: "team-a"}}, nil
+	}
+
+	listTenants := NewUnionTenantsLister(bucketTenants, NewIngestersTenantsLister(ingestersStats))
+	tenants, err := listTenants(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-a", "team-b", "team-c"}, tenants)
+
+	failingStats := func(context.Context) ([]distributor.UserIDStats, error) {
+		return nil, errors.New("ingesters unavailable")
+	}
+	listTenants = NewUnionTenantsLister(bucketTenants, NewIngestersTenantsLister(failingStats))
+	_, err = listTenants(context.Background())
+	assert.EqualError(t, err, "ingesters unavailable")
+}
+
+// tenantQuerier returns a single "up" series per tenant, labelled with the tenant ID,
+// and fails for the "broken" tenant.
+type tenantQuerier struct {
+	storage.LabelQuerier
+}
+
+func (tenantQuerier) Select(ctx context.Context, sortSeries bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	if tenantID == "broken" {
+		return storage.ErrSeriesSet(fmt.Errorf("tenant %s is broken", tenantID))
+	}
+
+	lbls := labels.FromStrings(labels.MetricName, "up", "tenant", tenantID)
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return storage.EmptySeriesSet()
+		}
+	}
+	return series.NewConcreteSeriesSet(sortSeries, []storage.Series{
+		series.NewConcreteSeries(lbls, []model.SamplePair{{Timestamp: 100 * 1000, Value: 1}}),
+	})
+}
+
+func (tenantQuerier) Close() error {
+	return nil
+}
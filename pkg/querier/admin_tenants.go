@@ -0,0 +1,230 @@
+package querier
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/distributor"
+	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+)
+
+// RuleGroupsLister returns the rule groups of every tenant.
+type RuleGroupsLister func(ctx context.Context) (map[string]rulespb.RuleGroupList, error)
+
+// IngestersStatsLister returns the series statistics of every tenant, summed across ingesters.
+type IngestersStatsLister func(ctx context.Context) ([]distributor.UserIDStats, error)
+
+// AdminTenantsSources are the sources the tenants summary is aggregated from. All sources
+// but the bucket ones are optional, and the related summary fields are left empty when missing.
+type AdminTenantsSources struct {
+	// BucketTenants lists the tenants found in the blocks storage, whose bucket index is read
+	// from Bucket.
+	BucketTenants        TenantsLister
+	Bucket               objstore.Bucket
+	BucketConfigProvider bucket.TenantConfigProvider
+
+	// IngestersStats returns the per tenant stats summed across ingesters, which get divided
+	// by the ReplicationFactor.
+	IngestersStats    IngestersStatsLister
+	ReplicationFactor int
+
+	// RuleGroups lists the rule groups configured in the ruler storage.
+	RuleGroups RuleGroupsLister
+
+	// AlertmanagerTenants lists the tenants with an Alertmanager configuration.
+	AlertmanagerTenants TenantsLister
+}
+
+type adminTenantSummary struct {
+	Tenant string `json:"tenant"`
+
+	// Blocks is the number of blocks not marked for deletion by resolution, and
+	// StorageBytes their total size.
+	Blocks       map[string]int `json:"blocks"`
+	StorageBytes int64          `json:"storage_bytes"`
+
+	Series       uint64 `json:"series"`
+	ActiveSeries uint64 `json:"active_series"`
+
+	RuleGroups         int  `json:"rule_groups"`
+	AlertmanagerConfig bool `json:"alertmanager_config"`
+
+	Error string `json:"error,omitempty"`
+}
+
+type adminTenantsResult struct {
+	Status string               `json:"status"`
+	Data   []adminTenantSummary `json:"data,omitempty"`
+	Error  string               `json:"error,omitempty"`
+}
+
+// AdminTenantsHandler returns a handler listing every known tenant, or every tenant matching
+// the fully anchored "tenants" regex, with a summary of its blocks, series, rule groups and
+// Alertmanager configuration. Blocks are counted from the bucket index, so they can be behind
+// the storage by up to the bucket index update interval, while series come from the ingesters.
+func AdminTenantsHandler(cfg AdminQueryConfig, sources AdminTenantsSources, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if code, err := authorizeAdminRequest(ctx, cfg); err != nil {
+			writeAdminTenantsError(w, code, err)
+			return
+		}
+		tenantsRegex, err := parseTenantsRegex(r)
+		if err != nil {
+			writeAdminTenantsError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		summaries, err := collectTenantsSummary(ctx, sources)
+		if err != nil {
+			writeAdminTenantsError(w, http.StatusInternalServerError, err)
+			return
+		}
+		for tenantID := range summaries {
+			if !tenantsRegex.MatchString(tenantID) {
+				delete(summaries, tenantID)
+			}
+		}
+
+		// The bucket index is only read for the tenants found in the bucket.
+		bucketTenants := make([]string, 0, len(summaries))
+		for tenantID, summary := range summaries {
+			if summary.Blocks != nil {
+				bucketTenants = append(bucketTenants, tenantID)
+			}
+		}
+
+		var summariesMx sync.Mutex
+		err = concurrency.ForEachUser(ctx, bucketTenants, cfg.MaxConcurrency, func(ctx context.Context, tenantID string) error {
+			idx, err := bucketindex.ReadIndex(ctx, sources.Bucket, tenantID, sources.BucketConfigProvider, logger)
+
+			summariesMx.Lock()
+			defer summariesMx.Unlock()
+
+			summary := summaries[tenantID]
+			switch {
+			case errors.Is(err, bucketindex.ErrIndexNotFound):
+				// The tenant has no bucket index yet, so there are no queryable blocks either.
+			case err != nil:
+				summary.Error = errors.Wrap(err, "reading bucket index").Error()
+			default:
+				addBucketIndexSummary(summary, idx)
+			}
+			return nil
+		})
+		if err != nil {
+			writeAdminTenantsError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		result := make([]adminTenantSummary, 0, len(summaries))
+		for _, summary := range summaries {
+			if summary.Blocks == nil {
+				summary.Blocks = map[string]int{}
+			}
+			result = append(result, *summary)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].Tenant < result[j].Tenant })
+		util.WriteJSONResponse(w, adminTenantsResult{Status: statusSuccess, Data: result})
+	})
+}
+
+// collectTenantsSummary returns the summary of every tenant known to any source, except the
+// blocks ones. The Blocks map is initialised for the tenants found in the bucket only.
+func collectTenantsSummary(ctx context.Context, sources AdminTenantsSources) (map[string]*adminTenantSummary, error) {
+	summaries := map[string]*adminTenantSummary{}
+	get := func(tenantID string) *adminTenantSummary {
+		summary, ok := summaries[tenantID]
+		if !ok {
+			summary = &adminTenantSummary{Tenant: tenantID}
+			summaries[tenantID] = summary
+		}
+		return summary
+	}
+
+	tenants, err := sources.BucketTenants(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tenants in the bucket")
+	}
+	for _, tenantID := range tenants {
+		get(tenantID).Blocks = map[string]int{}
+	}
+
+	if sources.IngestersStats != nil {
+		stats, err := sources.IngestersStats(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the ingesters stats")
+		}
+		factor := uint64(sources.ReplicationFactor)
+		if factor == 0 {
+			factor = 1
+		}
+		for _, s := range stats {
+			summary := get(s.UserID)
+			summary.Series = s.NumSeries / factor
+			summary.ActiveSeries = s.ActiveSeries / factor
+		}
+	}
+
+	if sources.RuleGroups != nil {
+		groups, err := sources.RuleGroups(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list rule groups")
+		}
+		for tenantID, list := range groups {
+			get(tenantID).RuleGroups = len(list)
+		}
+	}
+
+	if sources.AlertmanagerTenants != nil {
+		tenants, err := sources.AlertmanagerTenants(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list Alertmanager configurations")
+		}
+		for _, tenantID := range tenants {
+			get(tenantID).AlertmanagerConfig = true
+		}
+	}
+
+	return summaries, nil
+}
+
+func addBucketIndexSummary(summary *adminTenantSummary, idx *bucketindex.Index) {
+	deleted := make(map[string]struct{}, len(idx.BlockDeletionMarks))
+	for _, m := range idx.BlockDeletionMarks {
+		deleted[m.ID.String()] = struct{}{}
+	}
+
+	for _, b := range idx.Blocks {
+		if _, ok := deleted[b.ID.String()]; ok {
+			continue
+		}
+		summary.Blocks[resolutionName(b.Resolution)]++
+		summary.StorageBytes += b.SizeBytes
+	}
+}
+
+// resolutionName returns the name of a block resolution, expressed in milliseconds.
+func resolutionName(resolution int64) string {
+	if resolution == 0 {
+		return "raw"
+	}
+	return model.Duration(time.Duration(resolution) * time.Millisecond).String()
+}
+
+func writeAdminTenantsError(w http.ResponseWriter, code int, err error) {
+	w.WriteHeader(code)
+	util.WriteJSONResponse(w, adminTenantsResult{Status: statusError, Error: err.Error()})
+}
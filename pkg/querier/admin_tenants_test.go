@@ -0,0 +1,129 @@
+package querier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/distributor"
+	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	cortex_testutil "github.com/cortexproject/cortex/pkg/storage/tsdb/testutil"
+)
+
+func TestAdminTenantsHandler(t *testing.T) {
+	ctx := context.Background()
+	cfg := AdminQueryConfig{Enabled: true, OperatorTenants: []string{"ops"}, MaxConcurrency: 2}
+
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, "team-a", nil, &bucketindex.Index{
+		Version: bucketindex.IndexVersion1,
+		Blocks: bucketindex.Blocks{
+			{ID: ulid.MustNew(1, nil), SizeBytes: 100},
+			{ID: ulid.MustNew(2, nil), SizeBytes: 200},
+			{ID: ulid.MustNew(3, nil), Resolution: 300000, SizeBytes: 10},
+			{ID: ulid.MustNew(4, nil), Resolution: 3600000, SizeBytes: 1},
+			{ID: ulid.MustNew(5, nil), SizeBytes: 1000},
+		},
+		BlockDeletionMarks: bucketindex.BlockDeletionMarks{
+			{ID: ulid.MustNew(5, nil)},
+		},
+	}))
+	require.NoError(t, bkt.Upload(ctx, "broken/"+bucketindex.IndexCompressedFilename, strings.NewReader("not gzipped")))
+
+	sources := AdminTenantsSources{
+		// team-b has no bucket index yet.
+		BucketTenants: func(context.Context) ([]string, error) {
+			return []string{"team-a", "team-b", "broken"}, nil
+		},
+		Bucket: bkt,
+		IngestersStats: func(context.Context) ([]distributor.UserIDStats, error) {
+			return []distributor.UserIDStats{
+				{UserID: "team-a", UserStats: distributor.UserStats{NumSeries: 300, ActiveSeries: 30}},
+				{UserID: "new", UserStats: distributor.UserStats{NumSeries: 3, ActiveSeries: 3}},
+			}, nil
+		},
+		ReplicationFactor: 3,
+		RuleGroups: func(context.Context) (map[string]rulespb.RuleGroupList, error) {
+			return map[string]rulespb.RuleGroupList{"team-b": {{Name: "g1"}, {Name: "g2"}}}, nil
+		},
+		AlertmanagerTenants: func(context.Context) ([]string, error) {
+			return []string{"team-a"}, nil
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		caller       string
+		query        string
+		sources      func(AdminTenantsSources) AdminTenantsSources
+		expectedCode int
+		expectedJSON string
+	}{
+		"should reject non operator tenants": {
+			caller:       "team-a",
+			expectedCode: http.StatusForbidden,
+			expectedJSON: `{"status": "error", "error": "tenant team-a is not allowed to use the admin APIs"}`,
+		},
+		"should fail if a source fails": {
+			caller: "ops",
+			sources: func(s AdminTenantsSources) AdminTenantsSources {
+				s.RuleGroups = func(context.Context) (map[string]rulespb.RuleGroupList, error) {
+					return nil, errors.New("storage unavailable")
+				}
+				return s
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedJSON: `{"status": "error", "error": "failed to list rule groups: storage unavailable"}`,
+		},
+		"should summarize every known tenant": {
+			caller:       "ops",
+			expectedCode: http.StatusOK,
+			expectedJSON: `{"status": "success", "data": [
+				{"tenant": "broken", "blocks": {}, "storage_bytes": 0, "series": 0, "active_series": 0, "rule_groups": 0, "alertmanager_config": false, "error": "reading bucket index: bucket index corrupted"},
+				{"tenant": "new", "blocks": {}, "storage_bytes": 0, "series": 1, "active_series": 1, "rule_groups": 0, "alertmanager_config": false},
+				{"tenant": "team-a", "blocks": {"raw": 2, "5m": 1, "1h": 1}, "storage_bytes": 311, "series": 100, "active_series": 10, "rule_groups": 0, "alertmanager_config": true},
+				{"tenant": "team-b", "blocks": {}, "storage_bytes": 0, "series": 0, "active_series": 0, "rule_groups": 2, "alertmanager_config": false}
+			]}`,
+		},
+		"should only summarize the tenants matching the regex, with the optional sources missing": {
+			caller: "ops",
+			query:  "tenants=team-.*",
+			sources: func(s AdminTenantsSources) AdminTenantsSources {
+				s.IngestersStats = nil
+				s.RuleGroups = nil
+				s.AlertmanagerTenants = nil
+				return s
+			},
+			expectedCode: http.StatusOK,
+			expectedJSON: `{"status": "success", "data": [
+				{"tenant": "team-a", "blocks": {"raw": 2, "5m": 1, "1h": 1}, "storage_bytes": 311, "series": 0, "active_series": 0, "rule_groups": 0, "alertmanager_config": false},
+				{"tenant": "team-b", "blocks": {}, "storage_bytes": 0, "series": 0, "active_series": 0, "rule_groups": 0, "alertmanager_config": false}
+			]}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := sources
+			if tc.sources != nil {
+				s = tc.sources(s)
+			}
+			handler := AdminTenantsHandler(cfg, s, log.NewNopLogger())
+
+			req := httptest.NewRequest("GET", "/api/v1/admin/tenants?"+tc.query, nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), tc.caller))
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			assert.JSONEq(t, tc.expectedJSON, recorder.Body.String())
+		})
+	}
+}
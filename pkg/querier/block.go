@@ -9,6 +9,7 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/util/annotations"
+	thanos_downsample "github.com/thanos-io/thanos/pkg/compact/downsample"
 	"github.com/thanos-io/thanos/pkg/store/labelpb"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 
@@ -112,24 +113,77 @@ func (bqs *blockQuerierSeries) Iterator(chunkenc.Iterator) chunkenc.Iterator {
 	}
 
 	its := make([]chunkenc.Iterator, 0, len(bqs.chunks))
+	counter := false
 
 	for _, c := range bqs.chunks {
-		// Ignore if the current chunk is not XOR chunk.
-		if c.Raw == nil {
+		if c.Raw != nil {
+			ch, err := chunkenc.FromData(chunkenc.EncXOR, c.Raw.Data)
+			if err != nil {
+				return series.NewErrIterator(errors.Wrapf(err, "failed to initialize chunk from XOR encoded raw data (series: %v min time: %d max time: %d)", bqs.Labels(), c.MinTime, c.MaxTime))
+			}
+
+			its = append(its, ch.Iterator(nil))
 			continue
 		}
-		ch, err := chunkenc.FromData(chunkenc.EncXOR, c.Raw.Data)
+
+		// Chunks of downsampled blocks only contain the requested aggregates.
+		it, isCounter, err := aggrChunkIterator(c)
 		if err != nil {
-			return series.NewErrIterator(errors.Wrapf(err, "failed to initialize chunk from XOR encoded raw data (series: %v min time: %d max time: %d)", bqs.Labels(), c.MinTime, c.MaxTime))
+			return series.NewErrIterator(errors.Wrapf(err, "failed to initialize chunk from aggregated data (series: %v min time: %d max time: %d)", bqs.Labels(), c.MinTime, c.MaxTime))
+		}
+		if it == nil {
+			continue
 		}
-
-		it := ch.Iterator(nil)
 		its = append(its, it)
+		counter = counter || isCounter
+	}
+
+	// Counter resets must be applied across all the chunks of the series.
+	if counter {
+		its = []chunkenc.Iterator{thanos_downsample.NewApplyCounterResetsIterator(its...)}
 	}
 
 	return iterators.NewCompatibleChunksIterator(newBlockQuerierSeriesIterator(bqs.Labels(), its))
 }
 
+// aggrChunkIterator returns an iterator over the aggregates of a downsampled chunk, picking
+// them in the same order the Thanos querier does: counter, average, then any single aggregate.
+// It returns whether the iterator is over the counter aggregate, and nil if the chunk has no aggregates.
+func aggrChunkIterator(c storepb.AggrChunk) (chunkenc.Iterator, bool, error) {
+	if c.Counter != nil {
+		it, err := xorChunkIterator(c.Counter)
+		return it, true, err
+	}
+
+	if c.Sum != nil && c.Count != nil {
+		sum, err := xorChunkIterator(c.Sum)
+		if err != nil {
+			return nil, false, err
+		}
+		cnt, err := xorChunkIterator(c.Count)
+		if err != nil {
+			return nil, false, err
+		}
+		return thanos_downsample.NewAverageChunkIterator(cnt, sum), false, nil
+	}
+
+	for _, chk := range []*storepb.Chunk{c.Min, c.Max, c.Sum, c.Count} {
+		if chk != nil {
+			it, err := xorChunkIterator(chk)
+			return it, false, err
+		}
+	}
+	return nil, false, nil
+}
+
+func xorChunkIterator(c *storepb.Chunk) (chunkenc.Iterator, error) {
+	ch, err := chunkenc.FromData(chunkenc.EncXOR, c.Data)
+	if err != nil {
+		return nil, err
+	}
+	return ch.Iterator(nil), nil
+}
+
 func newBlockQuerierSeriesIterator(labels labels.Labels, its []chunkenc.Iterator) *blockQuerierSeriesIterator {
 	return &blockQuerierSeriesIterator{labels: labels, iterators: its, lastT: math.MinInt64}
 }
@@ -65,6 +65,54 @@ func TestBlockQuerierSeries(t *testing.T) {
 			expectedMetric: labels.Labels{labels.Label{Name: "foo", Value: "bar"}},
 			expectedErr:    `cannot iterate chunk for series: {foo="bar"}: EOF`,
 		},
+		"should return the average of downsampled sum and count": {
+			series: &storepb.Series{
+				Labels: []labelpb.ZLabel{{Name: "foo", Value: "bar"}},
+				Chunks: []storepb.AggrChunk{
+					{
+						MinTime: 1000,
+						MaxTime: 2000,
+						Sum:     mockXORChunk(promql.FPoint{T: 1000, F: 10}, promql.FPoint{T: 2000, F: 30}),
+						Count:   mockXORChunk(promql.FPoint{T: 1000, F: 2}, promql.FPoint{T: 2000, F: 3}),
+					},
+				},
+			},
+			expectedMetric: labels.Labels{{Name: "foo", Value: "bar"}},
+			expectedSamples: []model.SamplePair{
+				{Timestamp: 1000, Value: 5},
+				{Timestamp: 2000, Value: 10},
+			},
+		},
+		"should return the only downsampled aggregate": {
+			series: &storepb.Series{
+				Labels: []labelpb.ZLabel{{Name: "foo", Value: "bar"}},
+				Chunks: []storepb.AggrChunk{
+					{MinTime: 1000, MaxTime: 2000, Max: mockXORChunk(promql.FPoint{T: 1000, F: 7}, promql.FPoint{T: 2000, F: 9})},
+				},
+			},
+			expectedMetric: labels.Labels{{Name: "foo", Value: "bar"}},
+			expectedSamples: []model.SamplePair{
+				{Timestamp: 1000, Value: 7},
+				{Timestamp: 2000, Value: 9},
+			},
+		},
+		"should apply counter resets across downsampled counter chunks": {
+			series: &storepb.Series{
+				Labels: []labelpb.ZLabel{{Name: "foo", Value: "bar"}},
+				Chunks: []storepb.AggrChunk{
+					// Downsampled counter chunks end with the last raw sample, at the same timestamp of the last aggregated sample.
+					{MinTime: 1000, MaxTime: 2000, Counter: mockXORChunk(promql.FPoint{T: 1000, F: 10}, promql.FPoint{T: 2000, F: 20}, promql.FPoint{T: 2000, F: 20})},
+					{MinTime: 3000, MaxTime: 4000, Counter: mockXORChunk(promql.FPoint{T: 3000, F: 5}, promql.FPoint{T: 4000, F: 15}, promql.FPoint{T: 4000, F: 15})},
+				},
+			},
+			expectedMetric: labels.Labels{{Name: "foo", Value: "bar"}},
+			expectedSamples: []model.SamplePair{
+				{Timestamp: 1000, Value: 10},
+				{Timestamp: 2000, Value: 20},
+				{Timestamp: 3000, Value: 25},
+				{Timestamp: 4000, Value: 35},
+			},
+		},
 	}
 
 	for testName, testData := range tests {
@@ -235,6 +283,20 @@ func createAggrChunkWithSineSamples(minTime, maxTime time.Time, step time.Durati
 	return createAggrChunk(minT, maxT, samples...)
 }
 
+func mockXORChunk(samples ...promql.FPoint) *storepb.Chunk {
+	chunk := chunkenc.NewXORChunk()
+	appender, err := chunk.Appender()
+	if err != nil {
+		panic(err)
+	}
+
+	for _, s := range samples {
+		appender.Append(s.T, s.F)
+	}
+
+	return &storepb.Chunk{Type: storepb.Chunk_XOR, Data: chunk.Bytes()}
+}
+
 func createAggrChunkWithSamples(samples ...promql.FPoint) storepb.AggrChunk {
 	return createAggrChunk(samples[0].T, samples[len(samples)-1].T, samples...)
 }
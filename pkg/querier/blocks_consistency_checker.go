@@ -10,6 +10,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/util"
 )
 
 type BlocksConsistencyChecker struct {
@@ -17,14 +18,23 @@ type BlocksConsistencyChecker struct {
 	deletionGracePeriod time.Duration
 	logger              log.Logger
 
-	checksTotal  prometheus.Counter
-	checksFailed prometheus.Counter
+	// The blocks uploaded within the tolerance window which can't be queried don't fail the
+	// query, as long as their samples are still served by the ingesters (which keep them for
+	// the ingesters retention) or by other blocks.
+	toleranceWindow    time.Duration
+	ingestersRetention time.Duration
+
+	checksTotal    prometheus.Counter
+	checksFailed   prometheus.Counter
+	toleratedTotal prometheus.Counter
 }
 
-func NewBlocksConsistencyChecker(uploadGracePeriod, deletionGracePeriod time.Duration, logger log.Logger, reg prometheus.Registerer) *BlocksConsistencyChecker {
+func NewBlocksConsistencyChecker(uploadGracePeriod, deletionGracePeriod, toleranceWindow, ingestersRetention time.Duration, logger log.Logger, reg prometheus.Registerer) *BlocksConsistencyChecker {
 	return &BlocksConsistencyChecker{
 		uploadGracePeriod:   uploadGracePeriod,
 		deletionGracePeriod: deletionGracePeriod,
+		toleranceWindow:     toleranceWindow,
+		ingestersRetention:  ingestersRetention,
 		logger:              logger,
 		checksTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_querier_blocks_consistency_checks_total",
@@ -34,6 +44,10 @@ func NewBlocksConsistencyChecker(uploadGracePeriod, deletionGracePeriod time.Dur
 			Name: "cortex_querier_blocks_consistency_checks_failed_total",
 			Help: "Total number of consistency checks failed on queried blocks.",
 		}),
+		toleratedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_blocks_consistency_tolerated_blocks_total",
+			Help: "Total number of recently uploaded blocks which couldn't be queried but didn't fail the query, since their samples are still served by the ingesters or by other blocks.",
+		}),
 	}
 }
 
@@ -84,3 +98,67 @@ func (c *BlocksConsistencyChecker) Check(knownBlocks bucketindex.Blocks, knownDe
 
 	return missingBlocks
 }
+
+// Tolerate returns the missing blocks which must fail the query. The blocks uploaded within the
+// tolerance window, not loaded by any store-gateway yet, are tolerated when their samples are still
+// queried from elsewhere:
+//   - Blocks within the ingesters retention: the samples are served by the ingesters.
+//   - Downsampled blocks: the samples are served by the raw blocks they've been downsampled from.
+//   - Compacted blocks overlapping queried blocks marked for deletion: the samples are served by the
+//     blocks they've been compacted from, until they're deleted.
+func (c *BlocksConsistencyChecker) Tolerate(knownBlocks bucketindex.Blocks, knownDeletionMarks map[ulid.ULID]*bucketindex.BlockDeletionMark, queriedBlocks, missingBlocks []ulid.ULID) []ulid.ULID {
+	if c.toleranceWindow <= 0 || len(missingBlocks) == 0 {
+		return missingBlocks
+	}
+
+	blocks := make(map[ulid.ULID]*bucketindex.Block, len(knownBlocks))
+	for _, b := range knownBlocks {
+		blocks[b.ID] = b
+	}
+
+	var (
+		now           = time.Now()
+		ingestersMinT = util.TimeToMillis(now.Add(-c.ingestersRetention))
+		res           []ulid.ULID
+	)
+
+	for _, id := range missingBlocks {
+		b := blocks[id]
+		if b == nil || now.Sub(b.GetUploadedAt()) >= c.toleranceWindow {
+			res = append(res, id)
+			continue
+		}
+
+		var reason string
+		switch {
+		case c.ingestersRetention > 0 && b.MinTime >= ingestersMinT:
+			reason = "within the ingesters retention"
+		case b.Resolution > 0:
+			reason = "downsampled"
+		case queriedSourcesOverlap(b, blocks, knownDeletionMarks, queriedBlocks):
+			reason = "compacted from queried blocks"
+		default:
+			res = append(res, id)
+			continue
+		}
+
+		c.toleratedTotal.Inc()
+		level.Debug(c.logger).Log("msg", "missing block tolerated by the consistency check because it was uploaded recently", "block", id.String(), "uploadedAt", b.GetUploadedAt().String(), "reason", reason)
+	}
+
+	return res
+}
+
+// queriedSourcesOverlap returns whether some queried blocks marked for deletion, like the
+// sources of a compacted block, overlap the block.
+func queriedSourcesOverlap(b *bucketindex.Block, blocks map[ulid.ULID]*bucketindex.Block, knownDeletionMarks map[ulid.ULID]*bucketindex.BlockDeletionMark, queriedBlocks []ulid.ULID) bool {
+	for _, id := range queriedBlocks {
+		if _, ok := knownDeletionMarks[id]; !ok {
+			continue
+		}
+		if q := blocks[id]; q != nil && q.Resolution == b.Resolution && q.MinTime < b.MaxTime && q.MaxTime > b.MinTime {
+			return true
+		}
+	}
+	return false
+}
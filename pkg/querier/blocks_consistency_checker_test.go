@@ -108,7 +108,7 @@ func TestBlocksConsistencyChecker_Check(t *testing.T) {
 			t.Parallel()
 
 			reg := prometheus.NewPedanticRegistry()
-			c := NewBlocksConsistencyChecker(uploadGracePeriod, deletionGracePeriod, log.NewNopLogger(), reg)
+			c := NewBlocksConsistencyChecker(uploadGracePeriod, deletionGracePeriod, 0, 0, log.NewNopLogger(), reg)
 
 			missingBlocks := c.Check(testData.knownBlocks, testData.knownDeletionMarks, testData.queriedBlocks)
 			assert.Equal(t, testData.expectedMissingBlocks, missingBlocks)
@@ -122,3 +122,97 @@ func TestBlocksConsistencyChecker_Check(t *testing.T) {
 		})
 	}
 }
+
+func TestBlocksConsistencyChecker_Tolerate(t *testing.T) {
+	now := time.Now()
+	toleranceWindow := time.Hour
+	ingestersRetention := 6 * time.Hour
+
+	recentRawBlock := ulid.MustNew(1, nil)
+	recentCompactedBlock := ulid.MustNew(2, nil)
+	recentDownsampledBlock := ulid.MustNew(3, nil)
+	oldBlock := ulid.MustNew(4, nil)
+	sourceBlock := ulid.MustNew(5, nil)
+
+	var (
+		recentlyUploaded = now.Add(-toleranceWindow / 2).Unix()
+		uploadedLongAgo  = now.Add(-toleranceWindow * 2).Unix()
+		withinIngesters  = util.TimeToMillis(now.Add(-2 * time.Hour))
+		beforeIngesters  = util.TimeToMillis(now.Add(-24 * time.Hour))
+	)
+
+	tests := map[string]struct {
+		toleranceWindow       time.Duration
+		knownBlocks           bucketindex.Blocks
+		knownDeletionMarks    map[ulid.ULID]*bucketindex.BlockDeletionMark
+		queriedBlocks         []ulid.ULID
+		missingBlocks         []ulid.ULID
+		expectedMissingBlocks []ulid.ULID
+	}{
+		"should not tolerate any missing block if the tolerance window is disabled": {
+			knownBlocks: bucketindex.Blocks{
+				&bucketindex.Block{ID: recentRawBlock, MinTime: withinIngesters, MaxTime: withinIngesters + 1, UploadedAt: recentlyUploaded},
+			},
+			missingBlocks:         []ulid.ULID{recentRawBlock},
+			expectedMissingBlocks: []ulid.ULID{recentRawBlock},
+		},
+		"should tolerate a recently uploaded block within the ingesters retention": {
+			toleranceWindow: toleranceWindow,
+			knownBlocks: bucketindex.Blocks{
+				&bucketindex.Block{ID: recentRawBlock, MinTime: withinIngesters, MaxTime: withinIngesters + 1, UploadedAt: recentlyUploaded},
+			},
+			missingBlocks: []ulid.ULID{recentRawBlock},
+		},
+		"should not tolerate a block uploaded before the tolerance window": {
+			toleranceWindow: toleranceWindow,
+			knownBlocks: bucketindex.Blocks{
+				&bucketindex.Block{ID: oldBlock, MinTime: withinIngesters, MaxTime: withinIngesters + 1, UploadedAt: uploadedLongAgo},
+			},
+			missingBlocks:         []ulid.ULID{oldBlock},
+			expectedMissingBlocks: []ulid.ULID{oldBlock},
+		},
+		"should tolerate a recently uploaded downsampled block": {
+			toleranceWindow: toleranceWindow,
+			knownBlocks: bucketindex.Blocks{
+				&bucketindex.Block{ID: recentDownsampledBlock, MinTime: beforeIngesters, MaxTime: beforeIngesters + 1, Resolution: 300000, UploadedAt: recentlyUploaded},
+			},
+			missingBlocks: []ulid.ULID{recentDownsampledBlock},
+		},
+		"should tolerate a recently compacted block whose queried sources are marked for deletion": {
+			toleranceWindow: toleranceWindow,
+			knownBlocks: bucketindex.Blocks{
+				&bucketindex.Block{ID: recentCompactedBlock, MinTime: beforeIngesters, MaxTime: beforeIngesters + 10, UploadedAt: recentlyUploaded},
+				&bucketindex.Block{ID: sourceBlock, MinTime: beforeIngesters, MaxTime: beforeIngesters + 5, UploadedAt: uploadedLongAgo},
+			},
+			knownDeletionMarks: map[ulid.ULID]*bucketindex.BlockDeletionMark{
+				sourceBlock: {ID: sourceBlock, DeletionTime: recentlyUploaded},
+			},
+			queriedBlocks: []ulid.ULID{sourceBlock},
+			missingBlocks: []ulid.ULID{recentCompactedBlock},
+		},
+		"should not tolerate a recently compacted block out of the ingesters retention without queried sources": {
+			toleranceWindow: toleranceWindow,
+			knownBlocks: bucketindex.Blocks{
+				&bucketindex.Block{ID: recentCompactedBlock, MinTime: beforeIngesters, MaxTime: beforeIngesters + 10, UploadedAt: recentlyUploaded},
+				&bucketindex.Block{ID: sourceBlock, MinTime: beforeIngesters, MaxTime: beforeIngesters + 5, UploadedAt: uploadedLongAgo},
+			},
+			queriedBlocks:         []ulid.ULID{sourceBlock},
+			missingBlocks:         []ulid.ULID{recentCompactedBlock},
+			expectedMissingBlocks: []ulid.ULID{recentCompactedBlock},
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			reg := prometheus.NewPedanticRegistry()
+			c := NewBlocksConsistencyChecker(0, 0, testData.toleranceWindow, ingestersRetention, log.NewNopLogger(), reg)
+
+			missingBlocks := c.Tolerate(testData.knownBlocks, testData.knownDeletionMarks, testData.queriedBlocks, testData.missingBlocks)
+			assert.Equal(t, testData.expectedMissingBlocks, missingBlocks)
+			assert.Equal(t, float64(len(testData.missingBlocks)-len(testData.expectedMissingBlocks)), testutil.ToFloat64(c.toleratedTotal))
+		})
+	}
+}
@@ -3,7 +3,10 @@ package querier
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -28,6 +31,11 @@ type blocksStoreBalancedSet struct {
 	serviceAddresses []string
 	clientsPool      *client.Pool
 	dnsProvider      *dns.Provider
+	health           *storeGatewaysHealth
+
+	// The subset of the resolved addresses preferred by the querier, identified by subsetID.
+	subsetSize int
+	subsetID   string
 
 	logger log.Logger
 }
@@ -37,12 +45,23 @@ func newBlocksStoreBalancedSet(serviceAddresses []string, clientConfig ClientCon
 
 	dnsProviderReg := extprom.WrapRegistererWithPrefix("cortex_storegateway_client_", reg)
 
+	subsetID, err := os.Hostname()
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to get the hostname identifying the subset of store-gateways", "err", err)
+	}
+
 	s := &blocksStoreBalancedSet{
 		serviceAddresses: serviceAddresses,
 		dnsProvider:      dns.NewProvider(logger, dnsProviderReg, dns.GolangResolverType),
-		clientsPool:      newStoreGatewayClientPool(nil, clientConfig, logger, reg),
+		health:           newStoreGatewaysHealth(clientConfig, reg),
+		subsetSize:       clientConfig.SubsetSize,
+		subsetID:         subsetID,
 		logger:           logger,
 	}
+	// The clients of the store-gateways no longer resolved, or out of the subset, are closed.
+	s.clientsPool = newStoreGatewayClientPool(func() ([]string, error) {
+		return s.addresses(), nil
+	}, clientConfig, s.health, logger, reg)
 
 	s.Service = services.NewTimerService(dnsResolveInterval, s.starting, s.resolve, nil)
 	return s
@@ -60,13 +79,30 @@ func (s *blocksStoreBalancedSet) resolve(ctx context.Context) error {
 	return nil
 }
 
+func (s *blocksStoreBalancedSet) CheckReady(_ context.Context) error {
+	if len(s.dnsProvider.Addresses()) == 0 {
+		return fmt.Errorf("no address resolved for the store-gateway service addresses %s", strings.Join(s.serviceAddresses, ","))
+	}
+	return nil
+}
+
+// addresses returns the resolved addresses of the subset of the querier, or all of them if the
+// subsetting is disabled.
+func (s *blocksStoreBalancedSet) addresses() []string {
+	return storeGatewaysSubset(s.dnsProvider.Addresses(), s.subsetID, s.subsetSize)
+}
+
 func (s *blocksStoreBalancedSet) GetClientsFor(_ string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string, _ map[ulid.ULID]map[string]int) (map[BlocksStoreClient][]ulid.ULID, error) {
-	addresses := s.dnsProvider.Addresses()
-	if len(addresses) == 0 {
+	all := s.dnsProvider.Addresses()
+	if len(all) == 0 {
 		return nil, fmt.Errorf("no address resolved for the store-gateway service addresses %s", strings.Join(s.serviceAddresses, ","))
 	}
 
 	// Randomize the list of addresses to not always query the same address.
+	rand.Shuffle(len(all), func(i, j int) {
+		all[i], all[j] = all[j], all[i]
+	})
+	addresses := storeGatewaysSubset(all, s.subsetID, s.subsetSize)
 	rand.Shuffle(len(addresses), func(i, j int) {
 		addresses[i], addresses[j] = addresses[j], addresses[i]
 	})
@@ -75,8 +111,14 @@ func (s *blocksStoreBalancedSet) GetClientsFor(_ string, blockIDs []ulid.ULID, e
 	clients := map[BlocksStoreClient][]ulid.ULID{}
 
 	for _, blockID := range blockIDs {
-		// Pick the first non excluded store-gateway instance.
-		addr := getFirstNonExcludedAddr(addresses, exclude[blockID])
+		// Pick the first non excluded store-gateway instance, preferring the healthy ones of the
+		// subset, then the healthy ones out of the subset.
+		addr := getFirstNonExcludedAddr(addresses, exclude[blockID], s.health)
+		if (addr == "" || !s.health.healthy(addr)) && len(addresses) < len(all) {
+			if other := getFirstNonExcludedAddr(all, exclude[blockID], s.health); other != "" && (addr == "" || s.health.healthy(other)) {
+				addr = other
+			}
+		}
 		if addr == "" {
 			return nil, fmt.Errorf("no store-gateway instance left after filtering out excluded instances for block %s", blockID.String())
 		}
@@ -92,12 +134,44 @@ func (s *blocksStoreBalancedSet) GetClientsFor(_ string, blockIDs []ulid.ULID, e
 	return clients, nil
 }
 
-func getFirstNonExcludedAddr(addresses, exclude []string) string {
+// getFirstNonExcludedAddr returns the first non excluded address, preferring the healthy ones.
+func getFirstNonExcludedAddr(addresses, exclude []string, health *storeGatewaysHealth) string {
+	unhealthy := ""
 	for _, addr := range addresses {
-		if !util.StringsContain(exclude, addr) {
+		if util.StringsContain(exclude, addr) {
+			continue
+		}
+		if health.healthy(addr) {
 			return addr
 		}
+		if unhealthy == "" {
+			unhealthy = addr
+		}
+	}
+
+	return unhealthy
+}
+
+// storeGatewaysSubset returns the subset of size addresses of the querier identified by id, picked
+// by rendezvous hashing so that the subsets are spread evenly over the store-gateways and barely
+// change when a store-gateway is added or removed. All the addresses are returned if size is 0.
+func storeGatewaysSubset(addresses []string, id string, size int) []string {
+	if size <= 0 || len(addresses) <= size {
+		return addresses
 	}
 
-	return ""
+	scores := make(map[string]uint64, len(addresses))
+	for _, addr := range addresses {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(id))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(addr))
+		scores[addr] = h.Sum64()
+	}
+
+	subset := append([]string(nil), addresses...)
+	sort.Slice(subset, func(i, j int) bool {
+		return scores[subset[i]] > scores[subset[j]]
+	})
+	return subset[:size]
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/oklog/ulid"
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/services"
 )
 
@@ -150,3 +152,82 @@ func TestBlocksStoreBalancedSet_GetClientsFor_Exclude(t *testing.T) {
 		})
 	}
 }
+
+func TestBlocksStoreBalancedSet_GetClientsFor_Subset(t *testing.T) {
+	t.Parallel()
+
+	serviceAddrs := []string{"127.0.0.1", "127.0.0.2", "127.0.0.3", "127.0.0.4"}
+	block1 := ulid.MustNew(1, nil)
+
+	ctx := context.Background()
+	s := newBlocksStoreBalancedSet(serviceAddrs, ClientConfig{SubsetSize: 2}, log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
+
+	subset := storeGatewaysSubset(serviceAddrs, s.subsetID, 2)
+	require.Len(t, subset, 2)
+
+	// The blocks are only queried on the subset.
+	for i := 0; i < 100; i++ {
+		clients, err := s.GetClientsFor("", []ulid.ULID{block1}, nil, nil)
+		require.NoError(t, err)
+		for addr := range getStoreGatewayClientAddrs(clients) {
+			assert.Contains(t, subset, addr)
+		}
+	}
+
+	// The other store-gateways are queried once the subset is exhausted.
+	clients, err := s.GetClientsFor("", []ulid.ULID{block1}, map[ulid.ULID][]string{block1: subset}, nil)
+	require.NoError(t, err)
+	for addr := range getStoreGatewayClientAddrs(clients) {
+		assert.NotContains(t, subset, addr)
+	}
+}
+
+func TestStoreGatewaysSubset(t *testing.T) {
+	var addresses []string
+	for i := 0; i < 200; i++ {
+		addresses = append(addresses, fmt.Sprintf("10.0.%d.%d:9095", i/100, i%100))
+	}
+
+	assert.Equal(t, addresses, storeGatewaysSubset(addresses, "querier-1", 0))
+	assert.Equal(t, addresses, storeGatewaysSubset(addresses, "querier-1", 300))
+
+	// The subset of a querier is stable, whatever the order of the addresses.
+	subset := storeGatewaysSubset(addresses, "querier-1", 20)
+	require.Len(t, subset, 20)
+	reversed := make([]string, 0, len(addresses))
+	for i := len(addresses) - 1; i >= 0; i-- {
+		reversed = append(reversed, addresses[i])
+	}
+	assert.Equal(t, subset, storeGatewaysSubset(reversed, "querier-1", 20))
+
+	// Removing a store-gateway out of the subset doesn't change it.
+	for i, addr := range addresses {
+		if !util.StringsContain(subset, addr) {
+			assert.Equal(t, subset, storeGatewaysSubset(append(append([]string(nil), addresses[:i]...), addresses[i+1:]...), "querier-1", 20))
+			break
+		}
+	}
+
+	// The subsets of the queriers are spread over the store-gateways.
+	hits := map[string]int{}
+	for q := 0; q < 100; q++ {
+		for _, addr := range storeGatewaysSubset(addresses, fmt.Sprintf("querier-%d", q), 20) {
+			hits[addr]++
+		}
+	}
+	assert.Greater(t, len(hits), 180)
+}
+
+func TestGetFirstNonExcludedAddr_ShouldPreferHealthyStoreGateways(t *testing.T) {
+	health := newStoreGatewaysHealth(ClientConfig{UnhealthyFailuresThreshold: 1, UnhealthyCooldown: time.Hour}, nil)
+	health.failure("127.0.0.1")
+
+	addresses := []string{"127.0.0.1", "127.0.0.2", "127.0.0.3"}
+	assert.Equal(t, "127.0.0.2", getFirstNonExcludedAddr(addresses, nil, health))
+	assert.Equal(t, "127.0.0.3", getFirstNonExcludedAddr(addresses, []string{"127.0.0.2"}, health))
+	// The unhealthy store-gateways are queried when no healthy one is left.
+	assert.Equal(t, "127.0.0.1", getFirstNonExcludedAddr(addresses, []string{"127.0.0.2", "127.0.0.3"}, health))
+	assert.Equal(t, "", getFirstNonExcludedAddr(addresses, addresses, health))
+}
@@ -34,6 +34,7 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/querier/serieslimit"
 	"github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/querysharding"
 	"github.com/cortexproject/cortex/pkg/ring"
@@ -41,10 +42,12 @@ import (
 	"github.com/cortexproject/cortex/pkg/storage/bucket"
 	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
 	"github.com/cortexproject/cortex/pkg/storegateway"
 	"github.com/cortexproject/cortex/pkg/storegateway/storegatewaypb"
 	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
 	"github.com/cortexproject/cortex/pkg/util/limiter"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/math"
@@ -63,7 +66,7 @@ const (
 
 var (
 	errNoStoreGatewayAddress  = errors.New("no store-gateway address configured")
-	errMaxChunksPerQueryLimit = "the query hit the max number of chunks limit while fetching chunks from store-gateways for %s (limit: %d)"
+	errMaxChunksPerQueryLimit = globalerror.MaxChunksPerQuery.Message("the query hit the max number of chunks limit while fetching chunks from store-gateways for %s (limit: %d)")
 )
 
 // BlocksStoreSet is the interface used to get the clients to query series on a set of blocks.
@@ -74,6 +77,9 @@ type BlocksStoreSet interface {
 	// query the set of blocks in input. The exclude parameter is the map of
 	// blocks -> store-gateway addresses that should be excluded.
 	GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string, attemptedBlocksZones map[ulid.ULID]map[string]int) (map[BlocksStoreClient][]ulid.ULID, error)
+
+	// CheckReady returns an error if no store-gateway can be queried.
+	CheckReady(ctx context.Context) error
 }
 
 // BlocksFinder is the interface used to find blocks for a given user and time range.
@@ -104,8 +110,10 @@ type BlocksStoreLimits interface {
 }
 
 type blocksStoreQueryableMetrics struct {
-	storesHit prometheus.Histogram
-	refetches prometheus.Histogram
+	storesHit        prometheus.Histogram
+	refetches        prometheus.Histogram
+	crossZoneRetries prometheus.Counter
+	rejectedRetries  prometheus.Counter
 }
 
 func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQueryableMetrics {
@@ -122,6 +130,16 @@ func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQuery
 			Help:      "Number of re-fetches attempted while querying store-gateway instances due to missing blocks.",
 			Buckets:   []float64{0, 1, 2},
 		}),
+		crossZoneRetries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_storegateway_cross_zone_retries_total",
+			Help:      "Number of blocks queried on a store-gateway of another zone than the ones previously attempted for the block.",
+		}),
+		rejectedRetries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_storegateway_rejected_retries_total",
+			Help:      "Number of retries to the store-gateways not attempted because the retry budget was spent.",
+		}),
 	}
 }
 
@@ -138,6 +156,9 @@ type BlocksStoreQueryable struct {
 	metrics         *blocksStoreQueryableMetrics
 	limits          BlocksStoreLimits
 
+	zoneFailoverEnabled bool
+	retryBudget         *retryBudget
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -149,6 +170,8 @@ func NewBlocksStoreQueryable(
 	consistency *BlocksConsistencyChecker,
 	limits BlocksStoreLimits,
 	queryStoreAfter time.Duration,
+	zoneFailoverEnabled bool,
+	retryBudget *retryBudget,
 	logger log.Logger,
 	reg prometheus.Registerer,
 ) (*BlocksStoreQueryable, error) {
@@ -167,6 +190,9 @@ func NewBlocksStoreQueryable(
 		subservicesWatcher: services.NewFailureWatcher(),
 		metrics:            newBlocksStoreQueryableMetrics(reg),
 		limits:             limits,
+
+		zoneFailoverEnabled: zoneFailoverEnabled,
+		retryBudget:         retryBudget,
 	}
 
 	q.Service = services.NewBasicService(q.starting, q.running, q.stopping)
@@ -175,7 +201,12 @@ func NewBlocksStoreQueryable(
 }
 
 func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegateway.Config, storageCfg cortex_tsdb.BlocksStorageConfig, limits BlocksStoreLimits, logger log.Logger, reg prometheus.Registerer) (*BlocksStoreQueryable, error) {
-	var stores BlocksStoreSet
+	var (
+		stores BlocksStoreSet
+
+		// The blocks can only be queried on another zone if the store-gateways replicate them across zones.
+		zoneFailoverEnabled bool
+	)
 
 	bucketClient, err := bucket.NewClient(context.Background(), storageCfg.Bucket, "querier", logger, reg)
 	if err != nil {
@@ -236,6 +267,7 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create store set")
 		}
+		zoneFailoverEnabled = querierCfg.StoreGatewayZoneFailoverEnabled && storesRingCfg.ZoneAwarenessEnabled
 	} else {
 		if len(querierCfg.GetStoreGatewayAddresses()) == 0 {
 			return nil, errNoStoreGatewayAddress
@@ -244,6 +276,12 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		stores = newBlocksStoreBalancedSet(querierCfg.GetStoreGatewayAddresses(), querierCfg.StoreGatewayClient, logger, reg)
 	}
 
+	// The ingesters serve the samples they keep for their retention period, as long as they're queried.
+	ingestersRetention := storageCfg.TSDB.Retention
+	if querierCfg.QueryIngestersWithin > 0 && querierCfg.QueryIngestersWithin < ingestersRetention {
+		ingestersRetention = querierCfg.QueryIngestersWithin
+	}
+
 	consistency := NewBlocksConsistencyChecker(
 		// Exclude blocks which have been recently uploaded, in order to give enough time to store-gateways
 		// to discover and load them (3 times the sync interval).
@@ -252,11 +290,13 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		// recently marked for deletion, until the "ignore delay / 2". This means the consistency checker
 		// exclude such blocks about 50% of the time before querier and store-gateway stops querying them.
 		storageCfg.BucketStore.IgnoreDeletionMarksDelay/2,
+		querierCfg.ConsistencyCheckGracePeriod,
+		ingestersRetention,
 		logger,
 		reg,
 	)
 
-	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, logger, reg)
+	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, zoneFailoverEnabled, newRetryBudget(querierCfg.StoreGatewayClient), logger, reg)
 }
 
 func (q *BlocksStoreQueryable) starting(ctx context.Context) error {
@@ -285,6 +325,12 @@ func (q *BlocksStoreQueryable) stopping(_ error) error {
 }
 
 // Querier returns a new Querier on the storage.
+// CheckReady returns an error if the long-term storage can't be queried because no store-gateway
+// is available.
+func (q *BlocksStoreQueryable) CheckReady(ctx context.Context) error {
+	return q.stores.CheckReady(ctx)
+}
+
 func (q *BlocksStoreQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
 	if s := q.State(); s != services.Running {
 		return nil, errors.Errorf("BlocksStoreQueryable is not running: %v", s)
@@ -300,6 +346,9 @@ func (q *BlocksStoreQueryable) Querier(mint, maxt int64) (storage.Querier, error
 		consistency:     q.consistency,
 		logger:          q.logger,
 		queryStoreAfter: q.queryStoreAfter,
+
+		zoneFailoverEnabled: q.zoneFailoverEnabled,
+		retryBudget:         q.retryBudget,
 	}, nil
 }
 
@@ -315,6 +364,13 @@ type blocksStoreQuerier struct {
 	// If set, the querier manipulates the max time to not be greater than
 	// "now - queryStoreAfter" so that most recent blocks are not queried.
 	queryStoreAfter time.Duration
+
+	// If set, the blocks are queried on the store-gateways of the other zones
+	// when a store-gateway fails, whatever the error.
+	zoneFailoverEnabled bool
+
+	// If set, the retries to other store-gateways are limited by the budget.
+	retryBudget *retryBudget
 }
 
 // Select implements storage.Querier interface.
@@ -423,7 +479,7 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 		resSeriesSets = []storage.SeriesSet(nil)
 		resWarnings   = annotations.Annotations(nil)
 
-		maxChunksLimit  = q.limits.MaxChunksPerQueryFromStore(userID)
+		maxChunksLimit  = limiter.MaxChunksPerQueryFromContext(ctx, q.limits.MaxChunksPerQueryFromStore(userID))
 		leftChunksLimit = maxChunksLimit
 
 		resultMtx sync.Mutex
@@ -510,11 +566,26 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 
 		queriedBlocks  []ulid.ULID
 		retryableError error
+		refetches      int
 	)
 
+	q.retryBudget.query()
 	for attempt := 1; attempt <= maxFetchSeriesAttempts; attempt++ {
+		// The retries are given up once the budget is spent, to not overload the store-gateways
+		// when some of them are slow or failing.
+		if attempt > 1 && !q.retryBudget.retry() {
+			q.metrics.rejectedRetries.Inc()
+			level.Warn(logger).Log("msg", "not retrying to fetch missing blocks because the retry budget is spent", "attempt", attempt)
+			break
+		}
+
 		// Find the set of store-gateway instances having the blocks. The exclude parameter is the
 		// map of blocks queried so far, with the list of store-gateway addresses for each block.
+		attemptedZones := make(map[ulid.ULID]int, len(remainingBlocks))
+		for _, blockID := range remainingBlocks {
+			attemptedZones[blockID] = len(attemptedBlocksZones[blockID])
+		}
+
 		clients, err := q.stores.GetClientsFor(userID, remainingBlocks, attemptedBlocks, attemptedBlocksZones)
 		if err != nil {
 			// If it's a retry and we get an error, it means there are no more store-gateways left
@@ -528,8 +599,17 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		}
 		level.Debug(logger).Log("msg", "found store-gateway instances to query", "num instances", len(clients), "attempt", attempt)
 
+		// The blocks previously attempted in some zones and now attempted in a new one are
+		// retried across zones. The zones are only tracked when the zone awareness is enabled.
+		for blockID, n := range attemptedZones {
+			if n > 0 && len(attemptedBlocksZones[blockID]) > n {
+				q.metrics.crossZoneRetries.Inc()
+			}
+		}
+
 		// Fetch series from stores. If an error occur we do not retry because retries
 		// are only meant to cover missing blocks.
+		refetches = attempt - 1
 		queriedBlocks, err, retryableError = queryFunc(clients, minT, maxT)
 		if err != nil {
 			return err
@@ -562,6 +642,15 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		remainingBlocks = missingBlocks
 	}
 
+	// The recently uploaded blocks the store-gateways haven't loaded yet don't fail the query
+	// while their samples are served from elsewhere.
+	if remainingBlocks = q.consistency.Tolerate(knownBlocks, knownDeletionMarks, resQueriedBlocks, remainingBlocks); len(remainingBlocks) == 0 {
+		q.metrics.storesHit.Observe(float64(len(touchedStores)))
+		q.metrics.refetches.Observe(float64(refetches))
+
+		return nil
+	}
+
 	// After we exhausted retries, if retryable error is not nil return the retryable error.
 	// It can be helpful to know whether we need to retry more or not.
 	if retryableError != nil {
@@ -605,6 +694,20 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 	}
 	convertedMatchers := convertMatchersToLabelMatcher(matchers)
 
+	// The store-gateways abort the requests as soon as they exceed the limits of the query,
+	// instead of sending all the series to fail here.
+	maxChunks := 0
+	if maxChunksLimit > 0 {
+		maxChunks = leftChunksLimit
+	}
+	gCtx = storegateway.AppendQueryLimitsToOutgoingContext(gCtx, queryLimiter.MaxSeriesPerQuery(), maxChunks)
+
+	// The store-gateways return one more series than the series limit, to tell whether the
+	// results are truncated.
+	if limit := serieslimit.LimitFromContext(ctx); limit > 0 {
+		gCtx = storegateway.AppendSeriesLimitToOutgoingContext(gCtx, limit+1)
+	}
+
 	// Concurrently fetch series from all clients.
 	for c, blockIDs := range clients {
 		// Change variables scope since it will be used in a goroutine.
@@ -626,11 +729,14 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			if err != nil {
 				return errors.Wrapf(err, "failed to create series request")
 			}
+			if resolution := downsample.MaxResolutionFromContext(ctx); resolution > 0 && !skipChunks {
+				setSeriesRequestResolution(req, resolution, sp)
+			}
 
 			begin := time.Now()
 			stream, err := c.Series(gCtx, req)
 			if err != nil {
-				if isRetryableError(err) {
+				if q.isRetryableError(ctx, err) {
 					level.Warn(spanLog).Log("err", errors.Wrapf(err, "failed to fetch series from %s due to retryable error", c.RemoteAddress()))
 					merrMtx.Lock()
 					merr.Add(err)
@@ -656,7 +762,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 					break
 				}
 
-				if isRetryableError(err) {
+				if q.isRetryableError(ctx, err) {
 					level.Warn(spanLog).Log("err", errors.Wrapf(err, "failed to receive series from %s due to retryable error", c.RemoteAddress()))
 					merrMtx.Lock()
 					merr.Add(err)
@@ -737,12 +843,15 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			numSeries := len(mySeries)
 			numSamples, chunksCount := countSamplesAndChunks(mySeries...)
 			chunkBytes := countChunkBytes(mySeries...)
+			rawChunkBytes, downsampledChunkBytes := countChunkBytesByResolution(mySeries...)
 			dataBytes := countDataBytes(mySeries...)
 
 			reqStats.AddFetchedSeries(uint64(numSeries))
 			reqStats.AddFetchedChunks(chunksCount)
 			reqStats.AddFetchedSamples(numSamples)
 			reqStats.AddFetchedChunkBytes(uint64(chunkBytes))
+			reqStats.AddFetchedRawBlocksChunkBytes(uint64(rawChunkBytes))
+			reqStats.AddFetchedDownsampledBlocksChunkBytes(uint64(downsampledChunkBytes))
 			reqStats.AddFetchedDataBytes(uint64(dataBytes))
 
 			level.Debug(spanLog).Log("msg", "received series from store-gateway",
@@ -757,6 +866,8 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 				level.Info(spanLog).Log("msg", "store gateway series request stats",
 					"instance", c.RemoteAddress(),
 					"queryable_chunk_bytes_fetched", chunkBytes,
+					"queryable_raw_chunk_bytes_fetched", rawChunkBytes,
+					"queryable_downsampled_chunk_bytes_fetched", downsampledChunkBytes,
 					"queryable_data_bytes_fetched", dataBytes,
 					"blocks_queried", seriesQueryStats.BlocksQueried,
 					"series_merged_count", seriesQueryStats.MergedSeriesCount,
@@ -837,7 +948,7 @@ func (q *blocksStoreQuerier) fetchLabelNamesFromStore(
 
 			namesResp, err := c.LabelNames(gCtx, req)
 			if err != nil {
-				if isRetryableError(err) {
+				if q.isRetryableError(ctx, err) {
 					level.Warn(spanLog).Log("err", errors.Wrapf(err, "failed to fetch label names from %s due to retryable error", c.RemoteAddress()))
 					merrMtx.Lock()
 					merr.Add(err)
@@ -939,7 +1050,7 @@ func (q *blocksStoreQuerier) fetchLabelValuesFromStore(
 
 			valuesResp, err := c.LabelValues(gCtx, req)
 			if err != nil {
-				if isRetryableError(err) {
+				if q.isRetryableError(ctx, err) {
 					level.Warn(spanLog).Log("err", errors.Wrapf(err, "failed to fetch label values from %s due to retryable error", c.RemoteAddress()))
 					merrMtx.Lock()
 					merr.Add(err)
@@ -1038,6 +1149,18 @@ func createSeriesRequest(minT, maxT int64, matchers []storepb.LabelMatcher, shar
 	}, nil
 }
 
+// setSeriesRequestResolution allows the store-gateway to serve the request from downsampled blocks
+// up to the given resolution, reading the aggregates required by the function the series are selected for.
+func setSeriesRequestResolution(req *storepb.SeriesRequest, resolution int64, sp *storage.SelectHints) {
+	function := ""
+	if sp != nil {
+		function = sp.Func
+	}
+
+	req.MaxResolutionWindow = resolution
+	req.Aggregates = downsample.StoreAggregates(function)
+}
+
 func createLabelNamesRequest(minT, maxT int64, blockIDs []ulid.ULID, matchers []storepb.LabelMatcher) (*storepb.LabelNamesRequest, error) {
 	req := &storepb.LabelNamesRequest{
 		Start:    minT,
@@ -1129,6 +1252,22 @@ func countChunkBytes(series ...*storepb.Series) (count int) {
 	return count
 }
 
+// countChunkBytesByResolution returns the size of the raw chunks and the size of the downsampled
+// chunks, made of aggregates, of the provided series in bytes
+func countChunkBytesByResolution(series ...*storepb.Series) (raw, downsampled int) {
+	for _, s := range series {
+		for _, c := range s.Chunks {
+			if c.Raw != nil {
+				raw += c.Size()
+			} else {
+				downsampled += c.Size()
+			}
+		}
+	}
+
+	return raw, downsampled
+}
+
 // countDataBytes returns the combined size of the all series
 func countDataBytes(series ...*storepb.Series) (count int) {
 	for _, s := range series {
@@ -1151,6 +1290,27 @@ func countSamplesAndChunks(series ...*storepb.Series) (samplesCount, chunksCount
 	return
 }
 
+// isRetryableError returns whether the blocks requested to the store-gateway which failed with the
+// error should be queried on another store-gateway. When the zone failover is enabled, the failures
+// of the store-gateway are retried as long as the query itself has not been canceled.
+func (q *blocksStoreQuerier) isRetryableError(ctx context.Context, err error) bool {
+	if isRetryableError(err) {
+		return true
+	}
+	return q.zoneFailoverEnabled && ctx.Err() == nil && isStoreGatewayFailure(err)
+}
+
+// isStoreGatewayFailure returns whether the error is a failure of the store-gateway rather than
+// an error caused by the query, like a limit hit.
+func isStoreGatewayFailure(err error) bool {
+	switch status.Code(errors.Cause(err)) {
+	case codes.Unavailable, codes.Unknown, codes.Internal, codes.DeadlineExceeded, codes.Canceled, codes.Aborted, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
 // only retry connection issues
 func isRetryableError(err error) bool {
 	switch status.Code(err) {
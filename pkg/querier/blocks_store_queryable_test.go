@@ -837,7 +837,7 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 				maxT:        maxT,
 				finder:      finder,
 				stores:      stores,
-				consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+				consistency: NewBlocksConsistencyChecker(0, 0, 0, 0, log.NewNopLogger(), nil),
 				logger:      log.NewNopLogger(),
 				metrics:     newBlocksStoreQueryableMetrics(reg),
 				limits:      testData.limits,
@@ -886,7 +886,7 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 
 			// Assert on metrics (optional, only for test cases defining it).
 			if testData.expectedMetrics != "" {
-				assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics)))
+				assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics), "cortex_querier_storegateway_instances_hit_per_query", "cortex_querier_storegateway_refetches_per_query"))
 			}
 		})
 	}
@@ -1354,7 +1354,7 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 					maxT:        maxT,
 					finder:      finder,
 					stores:      stores,
-					consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+					consistency: NewBlocksConsistencyChecker(0, 0, 0, 0, log.NewNopLogger(), nil),
 					logger:      log.NewNopLogger(),
 					metrics:     newBlocksStoreQueryableMetrics(reg),
 					limits:      &blocksStoreLimitsMock{},
@@ -1373,7 +1373,7 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 
 					// Assert on metrics (optional, only for test cases defining it).
 					if testData.expectedMetrics != "" {
-						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics)))
+						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics), "cortex_querier_storegateway_instances_hit_per_query", "cortex_querier_storegateway_refetches_per_query"))
 					}
 				}
 
@@ -1390,7 +1390,7 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 
 					// Assert on metrics (optional, only for test cases defining it).
 					if testData.expectedMetrics != "" {
-						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics)))
+						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics), "cortex_querier_storegateway_instances_hit_per_query", "cortex_querier_storegateway_refetches_per_query"))
 					}
 				}
 			}
@@ -1398,6 +1398,240 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 	}
 }
 
+func TestBlocksStoreQuerier_ZoneFailover(t *testing.T) {
+	t.Parallel()
+
+	const (
+		metricName = "test_metric"
+		minT       = int64(10)
+		maxT       = int64(20)
+	)
+
+	var (
+		block1          = ulid.MustNew(1, nil)
+		metricNameLabel = labels.Label{Name: labels.MetricName, Value: metricName}
+		series1Label    = labels.Label{Name: "series", Value: "1"}
+		zones           = map[string]string{"1.1.1.1": "zone-a", "2.2.2.2": "zone-b"}
+	)
+
+	tests := map[string]struct {
+		zoneFailoverEnabled bool
+		firstErr            error
+		expectedErr         string
+		expectedRetries     int
+	}{
+		"should fail the query on store-gateway failure if the zone failover is disabled": {
+			zoneFailoverEnabled: false,
+			firstErr:            status.Error(codes.Internal, "failed to read the index"),
+			expectedErr:         "failed to fetch series from 1.1.1.1: rpc error: code = Internal desc = failed to read the index",
+		},
+		"should query the block on another zone on store-gateway failure if the zone failover is enabled": {
+			zoneFailoverEnabled: true,
+			firstErr:            status.Error(codes.Internal, "failed to read the index"),
+			expectedRetries:     1,
+		},
+		"should query the block on another zone on store-gateway timeout if the zone failover is enabled": {
+			zoneFailoverEnabled: true,
+			firstErr:            status.Error(codes.DeadlineExceeded, "context deadline exceeded"),
+			expectedRetries:     1,
+		},
+		"should count the cross-zone retries of the retryable errors if the zone failover is disabled": {
+			zoneFailoverEnabled: false,
+			firstErr:            status.Error(codes.Unavailable, "unavailable"),
+			expectedRetries:     1,
+		},
+		"should not query the block on another zone on limit hit if the zone failover is enabled": {
+			zoneFailoverEnabled: true,
+			firstErr:            status.Error(codes.ResourceExhausted, "exceeded series limit"),
+			expectedErr:         "exceeded series limit",
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, 0, 0))
+			reg := prometheus.NewPedanticRegistry()
+			stores := &blocksStoreSetMock{
+				mockedResponses: []interface{}{
+					map[BlocksStoreClient][]ulid.ULID{
+						&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesErr: testData.firstErr}: {block1},
+					},
+					map[BlocksStoreClient][]ulid.ULID{
+						&storeGatewayClientMock{remoteAddr: "2.2.2.2", mockedSeriesResponses: []*storepb.SeriesResponse{
+							mockSeriesResponse(labels.Labels{metricNameLabel, series1Label}, minT, 2),
+							mockHintsResponse(block1),
+						}}: {block1},
+					},
+				},
+				mockedZones: zones,
+			}
+			finder := &blocksFinderMock{}
+			finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{&bucketindex.Block{ID: block1}}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+			q := &blocksStoreQuerier{
+				minT:        minT,
+				maxT:        maxT,
+				finder:      finder,
+				stores:      stores,
+				consistency: NewBlocksConsistencyChecker(0, 0, 0, 0, log.NewNopLogger(), nil),
+				logger:      log.NewNopLogger(),
+				metrics:     newBlocksStoreQueryableMetrics(reg),
+				limits:      &blocksStoreLimitsMock{},
+
+				zoneFailoverEnabled: testData.zoneFailoverEnabled,
+			}
+
+			matchers := []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName),
+			}
+
+			set := q.Select(ctx, true, nil, matchers...)
+			if testData.expectedErr != "" {
+				require.Error(t, set.Err())
+				assert.Contains(t, set.Err().Error(), testData.expectedErr)
+			} else {
+				require.NoError(t, set.Err())
+				require.True(t, set.Next())
+				assert.Equal(t, labels.New(metricNameLabel, series1Label), set.At().Labels())
+				assert.False(t, set.Next())
+			}
+
+			assert.Equal(t, float64(testData.expectedRetries), testutil.ToFloat64(q.metrics.crossZoneRetries))
+		})
+	}
+}
+
+func TestBlocksStoreQuerier_ShouldTolerateRecentlyUploadedBlocksNotLoadedYet(t *testing.T) {
+	t.Parallel()
+
+	const metricName = "test_metric"
+
+	var (
+		now             = time.Now()
+		minT            = util.TimeToMillis(now.Add(-2 * time.Hour))
+		maxT            = util.TimeToMillis(now)
+		block1          = ulid.MustNew(1, nil)
+		block2          = ulid.MustNew(2, nil)
+		metricNameLabel = labels.Label{Name: labels.MetricName, Value: metricName}
+		series1Label    = labels.Label{Name: "series", Value: "1"}
+	)
+
+	for _, toleranceWindow := range []time.Duration{0, time.Hour} {
+		toleranceWindow := toleranceWindow
+		t.Run(fmt.Sprintf("tolerance window: %s", toleranceWindow), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, 0, 0))
+
+			// The store-gateways have not loaded the recently uploaded block2 yet.
+			var responses []interface{}
+			for i := 0; i < maxFetchSeriesAttempts; i++ {
+				responses = append(responses, map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: fmt.Sprintf("%d.%d.%d.%d", i, i, i, i), mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockSeriesResponse(labels.Labels{metricNameLabel, series1Label}, minT, 2),
+						mockHintsResponse(block1),
+					}}: {block1, block2},
+				})
+			}
+
+			finder := &blocksFinderMock{}
+			finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{
+				&bucketindex.Block{ID: block1, MinTime: minT, MaxTime: maxT, UploadedAt: now.Add(-2 * time.Hour).Unix()},
+				&bucketindex.Block{ID: block2, MinTime: minT, MaxTime: maxT, UploadedAt: now.Add(-30 * time.Minute).Unix()},
+			}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+			q := &blocksStoreQuerier{
+				minT:        minT,
+				maxT:        maxT,
+				finder:      finder,
+				stores:      &blocksStoreSetMock{mockedResponses: responses},
+				consistency: NewBlocksConsistencyChecker(0, 0, toleranceWindow, 6*time.Hour, log.NewNopLogger(), nil),
+				logger:      log.NewNopLogger(),
+				metrics:     newBlocksStoreQueryableMetrics(nil),
+				limits:      &blocksStoreLimitsMock{},
+			}
+
+			set := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName))
+			if toleranceWindow == 0 {
+				require.EqualError(t, set.Err(), fmt.Sprintf("consistency check failed because some blocks were not queried: %s", block2.String()))
+				return
+			}
+
+			require.NoError(t, set.Err())
+			require.True(t, set.Next())
+			assert.Equal(t, labels.New(metricNameLabel, series1Label), set.At().Labels())
+		})
+	}
+}
+
+func TestBlocksStoreQuerier_ShouldNotRetryOnceTheRetryBudgetIsSpent(t *testing.T) {
+	t.Parallel()
+
+	const metricName = "test_metric"
+
+	var (
+		minT            = int64(10)
+		maxT            = int64(20)
+		block1          = ulid.MustNew(1, nil)
+		block2          = ulid.MustNew(2, nil)
+		metricNameLabel = labels.Label{Name: labels.MetricName, Value: metricName}
+		series1Label    = labels.Label{Name: "series", Value: "1"}
+	)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, 0, 0))
+
+	// The first store-gateway misses block2, which is only found on the second one.
+	responses := []interface{}{
+		map[BlocksStoreClient][]ulid.ULID{
+			&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+				mockSeriesResponse(labels.Labels{metricNameLabel, series1Label}, minT, 2),
+				mockHintsResponse(block1),
+			}}: {block1, block2},
+		},
+		map[BlocksStoreClient][]ulid.ULID{
+			&storeGatewayClientMock{remoteAddr: "2.2.2.2", mockedSeriesResponses: []*storepb.SeriesResponse{
+				mockHintsResponse(block2),
+			}}: {block2},
+		},
+	}
+
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{
+		&bucketindex.Block{ID: block1},
+		&bucketindex.Block{ID: block2},
+	}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	reg := prometheus.NewPedanticRegistry()
+	q := &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      &blocksStoreSetMock{mockedResponses: responses},
+		consistency: NewBlocksConsistencyChecker(0, 0, 0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(reg),
+		limits:      &blocksStoreLimitsMock{},
+		// The budget of a single query doesn't allow any retry.
+		retryBudget: newRetryBudget(ClientConfig{RetryBudgetRatio: 0.5, RetryBudgetMinPerSecond: 0}),
+	}
+
+	set := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName))
+	require.EqualError(t, set.Err(), fmt.Sprintf("consistency check failed because some blocks were not queried: %s", block2.String()))
+	assert.Equal(t, float64(1), testutil.ToFloat64(q.metrics.rejectedRetries))
+
+	// The budget of the second query allows the retry.
+	q.stores = &blocksStoreSetMock{mockedResponses: responses}
+	set = q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName))
+	require.NoError(t, set.Err())
+	assert.Equal(t, float64(1), testutil.ToFloat64(q.metrics.rejectedRetries))
+}
+
 func TestBlocksStoreQuerier_SelectSortedShouldHonorQueryStoreAfter(t *testing.T) {
 
 	now := time.Now()
@@ -1453,7 +1687,7 @@ func TestBlocksStoreQuerier_SelectSortedShouldHonorQueryStoreAfter(t *testing.T)
 				maxT:            testData.queryMaxT,
 				finder:          finder,
 				stores:          &blocksStoreSetMock{},
-				consistency:     NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+				consistency:     NewBlocksConsistencyChecker(0, 0, 0, 0, log.NewNopLogger(), nil),
 				logger:          log.NewNopLogger(),
 				metrics:         newBlocksStoreQueryableMetrics(nil),
 				limits:          &blocksStoreLimitsMock{},
@@ -1588,7 +1822,7 @@ func TestBlocksStoreQuerier_PromQLExecution(t *testing.T) {
 			}
 
 			// Instance the querier that will be executed to run the query.
-			queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, logger, nil)
+			queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, 0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, false, nil, logger, nil)
 			require.NoError(t, err)
 			require.NoError(t, services.StartAndAwaitRunning(context.Background(), queryable))
 			defer services.StopAndAwaitTerminated(context.Background(), queryable) // nolint:errcheck
@@ -1619,9 +1853,16 @@ type blocksStoreSetMock struct {
 
 	mockedResponses []interface{}
 	nextResult      int
+
+	// The zone of each store-gateway address, tracked in the attempted zones of the blocks if set.
+	mockedZones map[string]string
 }
 
-func (m *blocksStoreSetMock) GetClientsFor(_ string, _ []ulid.ULID, _ map[ulid.ULID][]string, _ map[ulid.ULID]map[string]int) (map[BlocksStoreClient][]ulid.ULID, error) {
+func (m *blocksStoreSetMock) CheckReady(context.Context) error {
+	return nil
+}
+
+func (m *blocksStoreSetMock) GetClientsFor(_ string, _ []ulid.ULID, _ map[ulid.ULID][]string, attemptedBlocksZones map[ulid.ULID]map[string]int) (map[BlocksStoreClient][]ulid.ULID, error) {
 	if m.nextResult >= len(m.mockedResponses) {
 		panic("not enough mocked results")
 	}
@@ -1633,6 +1874,18 @@ func (m *blocksStoreSetMock) GetClientsFor(_ string, _ []ulid.ULID, _ map[ulid.U
 		return nil, err
 	}
 	if clients, ok := res.(map[BlocksStoreClient][]ulid.ULID); ok {
+		for c, blockIDs := range clients {
+			zone, ok := m.mockedZones[c.RemoteAddress()]
+			if !ok {
+				continue
+			}
+			for _, blockID := range blockIDs {
+				if attemptedBlocksZones[blockID] == nil {
+					attemptedBlocksZones[blockID] = map[string]int{}
+				}
+				attemptedBlocksZones[blockID][zone]++
+			}
+		}
 		return clients, nil
 	}
 
@@ -1725,6 +1978,10 @@ func (m *blocksStoreLimitsMock) S3SSEKMSEncryptionContext(_ string) string {
 	return ""
 }
 
+func (m *blocksStoreLimitsMock) ObjectStorageTags(_ string) map[string]string {
+	return nil
+}
+
 func mockSeriesResponse(lbls labels.Labels, timeMillis int64, value float64) *storepb.SeriesResponse {
 	// Generate a chunk containing a single value (for simplicity).
 	chunk := chunkenc.NewXORChunk()
@@ -1889,3 +2146,50 @@ func TestCountSamplesAndChunks(t *testing.T) {
 		})
 	}
 }
+
+func TestCountChunkBytesByResolution(t *testing.T) {
+	raw := storepb.AggrChunk{Raw: &storepb.Chunk{Type: storepb.Chunk_XOR, Data: []byte{1, 2, 3, 4}}}
+	downsampled := storepb.AggrChunk{
+		Sum:   &storepb.Chunk{Type: storepb.Chunk_XOR, Data: []byte{1, 2}},
+		Count: &storepb.Chunk{Type: storepb.Chunk_XOR, Data: []byte{1, 2}},
+	}
+
+	series := []*storepb.Series{
+		{Chunks: []storepb.AggrChunk{raw, raw}},
+		{Chunks: []storepb.AggrChunk{downsampled}},
+	}
+
+	rawBytes, downsampledBytes := countChunkBytesByResolution(series...)
+	assert.Equal(t, 2*raw.Size(), rawBytes)
+	assert.Equal(t, downsampled.Size(), downsampledBytes)
+	assert.Equal(t, countChunkBytes(series...), rawBytes+downsampledBytes)
+}
+
+func TestSetSeriesRequestResolution(t *testing.T) {
+	tests := map[string]struct {
+		hints              *storage.SelectHints
+		expectedAggregates []storepb.Aggr
+	}{
+		"no hints": {
+			expectedAggregates: []storepb.Aggr{storepb.Aggr_SUM, storepb.Aggr_COUNT},
+		},
+		"counter function": {
+			hints:              &storage.SelectHints{Func: "rate"},
+			expectedAggregates: []storepb.Aggr{storepb.Aggr_COUNTER},
+		},
+		"gauge function": {
+			hints:              &storage.SelectHints{Func: "max_over_time"},
+			expectedAggregates: []storepb.Aggr{storepb.Aggr_MAX},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := &storepb.SeriesRequest{}
+			setSeriesRequestResolution(req, time.Hour.Milliseconds(), tc.hints)
+
+			assert.Equal(t, time.Hour.Milliseconds(), req.MaxResolutionWindow)
+			assert.Equal(t, tc.expectedAggregates, req.Aggregates)
+		})
+	}
+}
@@ -36,6 +36,7 @@ type blocksStoreReplicationSet struct {
 	shardingStrategy  string
 	balancingStrategy loadBalancingStrategy
 	limits            BlocksStoreLimits
+	health            *storeGatewaysHealth
 
 	zoneAwarenessEnabled      bool
 	zoneStableShuffleSharding bool
@@ -56,12 +57,14 @@ func newBlocksStoreReplicationSet(
 	zoneAwarenessEnabled bool,
 	zoneStableShuffleSharding bool,
 ) (*blocksStoreReplicationSet, error) {
+	health := newStoreGatewaysHealth(clientConfig, reg)
 	s := &blocksStoreReplicationSet{
 		storesRing:        storesRing,
-		clientsPool:       newStoreGatewayClientPool(client.NewRingServiceDiscovery(storesRing), clientConfig, logger, reg),
+		clientsPool:       newStoreGatewayClientPool(client.NewRingServiceDiscovery(storesRing), clientConfig, health, logger, reg),
 		shardingStrategy:  shardingStrategy,
 		balancingStrategy: balancingStrategy,
 		limits:            limits,
+		health:            health,
 
 		zoneAwarenessEnabled:      zoneAwarenessEnabled,
 		zoneStableShuffleSharding: zoneStableShuffleSharding,
@@ -103,6 +106,17 @@ func (s *blocksStoreReplicationSet) stopping(_ error) error {
 	return services.StopManagerAndAwaitStopped(context.Background(), s.subservices)
 }
 
+func (s *blocksStoreReplicationSet) CheckReady(_ context.Context) error {
+	set, err := s.storesRing.GetAllHealthy(storegateway.BlocksRead)
+	if err != nil {
+		return errors.Wrap(err, "store-gateway ring")
+	}
+	if len(set.Instances) == 0 {
+		return errors.New("no healthy store-gateway in the ring")
+	}
+	return nil
+}
+
 func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string, attemptedBlocksZones map[ulid.ULID]map[string]int) (map[BlocksStoreClient][]ulid.ULID, error) {
 	shards := map[string][]ulid.ULID{}
 
@@ -127,7 +141,7 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 		}
 
 		// Pick a non excluded store-gateway instance.
-		instance := getNonExcludedInstance(set, exclude[blockID], s.balancingStrategy, s.zoneAwarenessEnabled, attemptedBlocksZones[blockID])
+		instance := getNonExcludedInstance(set, exclude[blockID], s.balancingStrategy, s.zoneAwarenessEnabled, attemptedBlocksZones[blockID], s.health)
 		// A valid instance should have a non-empty address.
 		if instance.Addr == "" {
 			return nil, fmt.Errorf("no store-gateway instance left after checking exclude for block %s", blockID.String())
@@ -157,7 +171,9 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 	return clients, nil
 }
 
-func getNonExcludedInstance(set ring.ReplicationSet, exclude []string, balancingStrategy loadBalancingStrategy, zoneAwarenessEnabled bool, attemptedZones map[string]int) ring.InstanceDesc {
+// getNonExcludedInstance picks a non excluded instance of the replication set, preferring the
+// healthy ones. The unhealthy instances are picked only when no healthy instance is left.
+func getNonExcludedInstance(set ring.ReplicationSet, exclude []string, balancingStrategy loadBalancingStrategy, zoneAwarenessEnabled bool, attemptedZones map[string]int, health *storeGatewaysHealth) ring.InstanceDesc {
 	if balancingStrategy == randomLoadBalancing {
 		// Randomize the list of instances to not always query the same one.
 		rand.Shuffle(len(set.Instances), func(i, j int) {
@@ -178,15 +194,20 @@ func getNonExcludedInstance(set ring.ReplicationSet, exclude []string, balancing
 			}
 		}
 	}
-	for _, instance := range set.Instances {
-		if util.StringsContain(exclude, instance.Addr) {
-			continue
+	for _, healthyOnly := range []bool{health != nil, false} {
+		for _, instance := range set.Instances {
+			if util.StringsContain(exclude, instance.Addr) || (healthyOnly && !health.healthy(instance.Addr)) {
+				continue
+			}
+			// If zone awareness is not enabled, pick first non-excluded instance.
+			// Otherwise, keep iterating until we find an instance in a zone where
+			// we have the least retries.
+			if !zoneAwarenessEnabled || attemptedZones[instance.Zone] == minAttempt {
+				return instance
+			}
 		}
-		// If zone awareness is not enabled, pick first non-excluded instance.
-		// Otherwise, keep iterating until we find an instance in a zone where
-		// we have the least retries.
-		if !zoneAwarenessEnabled || attemptedZones[instance.Zone] == minAttempt {
-			return instance
+		if !healthyOnly {
+			break
 		}
 	}
 
@@ -759,3 +759,23 @@ func getStoreGatewayClientAddrs(clients map[BlocksStoreClient][]ulid.ULID) map[s
 	}
 	return addrs
 }
+
+func TestGetNonExcludedInstance_ShouldPreferHealthyStoreGateways(t *testing.T) {
+	health := newStoreGatewaysHealth(ClientConfig{UnhealthyFailuresThreshold: 1, UnhealthyCooldown: time.Hour}, nil)
+	health.failure("127.0.0.1")
+
+	set := ring.ReplicationSet{Instances: []ring.InstanceDesc{
+		{Addr: "127.0.0.1", Zone: "a"},
+		{Addr: "127.0.0.2", Zone: "b"},
+		{Addr: "127.0.0.3", Zone: "a"},
+	}}
+
+	assert.Equal(t, "127.0.0.2", getNonExcludedInstance(set, nil, noLoadBalancing, false, nil, health).Addr)
+	// The zone with the least attempts is still preferred, with its healthy instances.
+	assert.Equal(t, "127.0.0.3", getNonExcludedInstance(set, nil, noLoadBalancing, true, map[string]int{"b": 1}, health).Addr)
+	// The unhealthy instances are picked when no healthy one is left.
+	assert.Equal(t, "127.0.0.1", getNonExcludedInstance(set, []string{"127.0.0.2", "127.0.0.3"}, noLoadBalancing, false, nil, health).Addr)
+	assert.Equal(t, "127.0.0.1", getNonExcludedInstance(set, []string{"127.0.0.3"}, noLoadBalancing, true, map[string]int{"b": 1}, health).Addr)
+	// All the instances are healthy without health tracking.
+	assert.Equal(t, "127.0.0.1", getNonExcludedInstance(set, nil, noLoadBalancing, false, nil, nil).Addr)
+}
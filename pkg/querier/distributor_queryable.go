@@ -3,6 +3,7 @@ package querier
 import (
 	"context"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log/level"
@@ -16,6 +17,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/ingester/client"
 	"github.com/cortexproject/cortex/pkg/prom1/storage/metric"
+	"github.com/cortexproject/cortex/pkg/querier/partialresponse"
 	"github.com/cortexproject/cortex/pkg/querier/series"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
@@ -28,6 +30,7 @@ import (
 type Distributor interface {
 	Query(ctx context.Context, from, to model.Time, matchers ...*labels.Matcher) (model.Matrix, error)
 	QueryStream(ctx context.Context, from, to model.Time, matchers ...*labels.Matcher) (*client.QueryStreamResponse, error)
+	QueryStreamSeries(ctx context.Context, from, to model.Time, bufferSize int, matchers ...*labels.Matcher) (client.QueryStreamSeriesSet, error)
 	QueryExemplars(ctx context.Context, from, to model.Time, matchers ...[]*labels.Matcher) (*client.ExemplarQueryResponse, error)
 	LabelValuesForLabelName(ctx context.Context, from, to model.Time, label model.LabelName, matchers ...*labels.Matcher) ([]string, error)
 	LabelValuesForLabelNameStream(ctx context.Context, from, to model.Time, label model.LabelName, matchers ...*labels.Matcher) ([]string, error)
@@ -36,38 +39,42 @@ type Distributor interface {
 	MetricsForLabelMatchers(ctx context.Context, from, through model.Time, matchers ...*labels.Matcher) ([]metric.Metric, error)
 	MetricsForLabelMatchersStream(ctx context.Context, from, through model.Time, matchers ...*labels.Matcher) ([]metric.Metric, error)
 	MetricsMetadata(ctx context.Context) ([]scrape.MetricMetadata, error)
+	TSDBStatus(ctx context.Context, limit int) (*client.TSDBStatusResponse, error)
 }
 
-func newDistributorQueryable(distributor Distributor, streaming bool, streamingMetdata bool, iteratorFn chunkIteratorFunc, queryIngestersWithin time.Duration, queryStoreForLabels bool) QueryableWithFilter {
+func newDistributorQueryable(distributor Distributor, streaming bool, streamingMergeBufferSize int, streamingMetdata bool, iteratorFn chunkIteratorFunc, queryIngestersWithin time.Duration, queryStoreForLabels bool) QueryableWithFilter {
 	return distributorQueryable{
-		distributor:          distributor,
-		streaming:            streaming,
-		streamingMetdata:     streamingMetdata,
-		iteratorFn:           iteratorFn,
-		queryIngestersWithin: queryIngestersWithin,
-		queryStoreForLabels:  queryStoreForLabels,
+		distributor:              distributor,
+		streaming:                streaming,
+		streamingMergeBufferSize: streamingMergeBufferSize,
+		streamingMetdata:         streamingMetdata,
+		iteratorFn:               iteratorFn,
+		queryIngestersWithin:     queryIngestersWithin,
+		queryStoreForLabels:      queryStoreForLabels,
 	}
 }
 
 type distributorQueryable struct {
-	distributor          Distributor
-	streaming            bool
-	streamingMetdata     bool
-	iteratorFn           chunkIteratorFunc
-	queryIngestersWithin time.Duration
-	queryStoreForLabels  bool
+	distributor              Distributor
+	streaming                bool
+	streamingMergeBufferSize int
+	streamingMetdata         bool
+	iteratorFn               chunkIteratorFunc
+	queryIngestersWithin     time.Duration
+	queryStoreForLabels      bool
 }
 
 func (d distributorQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
 	return &distributorQuerier{
-		distributor:          d.distributor,
-		mint:                 mint,
-		maxt:                 maxt,
-		streaming:            d.streaming,
-		streamingMetadata:    d.streamingMetdata,
-		chunkIterFn:          d.iteratorFn,
-		queryIngestersWithin: d.queryIngestersWithin,
-		queryStoreForLabels:  d.queryStoreForLabels,
+		distributor:              d.distributor,
+		mint:                     mint,
+		maxt:                     maxt,
+		streaming:                d.streaming,
+		streamingMergeBufferSize: d.streamingMergeBufferSize,
+		streamingMetadata:        d.streamingMetdata,
+		chunkIterFn:              d.iteratorFn,
+		queryIngestersWithin:     d.queryIngestersWithin,
+		queryStoreForLabels:      d.queryStoreForLabels,
 	}, nil
 }
 
@@ -77,13 +84,18 @@ func (d distributorQueryable) UseQueryable(now time.Time, _, queryMaxT int64) bo
 }
 
 type distributorQuerier struct {
-	distributor          Distributor
-	mint, maxt           int64
-	streaming            bool
-	streamingMetadata    bool
-	chunkIterFn          chunkIteratorFunc
-	queryIngestersWithin time.Duration
-	queryStoreForLabels  bool
+	distributor              Distributor
+	mint, maxt               int64
+	streaming                bool
+	streamingMergeBufferSize int
+	streamingMetadata        bool
+	chunkIterFn              chunkIteratorFunc
+	queryIngestersWithin     time.Duration
+	queryStoreForLabels      bool
+
+	// The series sets merging the ingesters' streams lazily, closed with the querier.
+	setsMtx sync.Mutex
+	sets    []client.QueryStreamSeriesSet
 }
 
 // Select implements storage.Querier interface.
@@ -144,17 +156,30 @@ func (q *distributorQuerier) Select(ctx context.Context, sortSeries bool, sp *st
 		return series.MetricsToSeriesSet(sortSeries, ms)
 	}
 
-	if q.streaming {
-		return q.streamingSelect(ctx, sortSeries, minT, maxT, matchers)
+	// The ingesters failing the query in partial response mode are reported as a warning.
+	failures, ctx := partialresponse.ContextWithFailures(ctx)
+	if q.streaming && q.streamingMergeBufferSize > 0 {
+		// The ingesters may fail while the series are iterated, so the warning is only known then.
+		return q.lazyStreamingSelect(ctx, failures, minT, maxT, matchers)
 	}
 
-	matrix, err := q.distributor.Query(ctx, model.Time(minT), model.Time(maxT), matchers...)
-	if err != nil {
-		return storage.ErrSeriesSet(err)
+	var set storage.SeriesSet
+	if q.streaming {
+		set = q.streamingSelect(ctx, sortSeries, minT, maxT, matchers)
+	} else {
+		matrix, err := q.distributor.Query(ctx, model.Time(minT), model.Time(maxT), matchers...)
+		if err != nil {
+			return storage.ErrSeriesSet(err)
+		}
+
+		// Using MatrixToSeriesSet (and in turn NewConcreteSeriesSet), sorts the series.
+		set = series.MatrixToSeriesSet(sortSeries, matrix)
 	}
 
-	// Using MatrixToSeriesSet (and in turn NewConcreteSeriesSet), sorts the series.
-	return series.MatrixToSeriesSet(sortSeries, matrix)
+	if warning := failures.Warning(); warning != nil {
+		set = series.NewSeriesSetWithWarnings(set, annotations.New().Add(warning))
+	}
+	return set
 }
 
 func (q *distributorQuerier) streamingSelect(ctx context.Context, sortSeries bool, minT, maxT int64, matchers []*labels.Matcher) storage.SeriesSet {
@@ -207,6 +232,27 @@ func (q *distributorQuerier) streamingSelect(ctx context.Context, sortSeries boo
 	return storage.NewMergeSeriesSet(sets, storage.ChainedSeriesMerge)
 }
 
+// lazyStreamingSelect returns the series merged as they're streamed by the ingesters. The series are
+// always sorted, since the ingesters stream them sorted.
+func (q *distributorQuerier) lazyStreamingSelect(ctx context.Context, failures *partialresponse.Failures, minT, maxT int64, matchers []*labels.Matcher) storage.SeriesSet {
+	set, err := q.distributor.QueryStreamSeries(ctx, model.Time(minT), model.Time(maxT), q.streamingMergeBufferSize, matchers...)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+
+	q.setsMtx.Lock()
+	q.sets = append(q.sets, set)
+	q.setsMtx.Unlock()
+
+	return &queryStreamSeriesSet{
+		set:         set,
+		failures:    failures,
+		chunkIterFn: q.chunkIterFn,
+		mint:        minT,
+		maxt:        maxT,
+	}
+}
+
 func (q *distributorQuerier) LabelValues(ctx context.Context, name string, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
 	var (
 		lvs []string
@@ -281,6 +327,13 @@ func (q *distributorQuerier) labelNamesWithMatchers(ctx context.Context, matcher
 }
 
 func (q *distributorQuerier) Close() error {
+	q.setsMtx.Lock()
+	defer q.setsMtx.Unlock()
+
+	for _, set := range q.sets {
+		set.Close()
+	}
+	q.sets = nil
 	return nil
 }
 
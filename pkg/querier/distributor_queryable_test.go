@@ -49,7 +49,7 @@ func TestDistributorQuerier(t *testing.T) {
 		},
 		nil)
 
-	queryable := newDistributorQueryable(d, false, false, nil, 0, false)
+	queryable := newDistributorQueryable(d, false, 0, false, nil, 0, false)
 	querier, err := queryable.Querier(mint, maxt)
 	require.NoError(t, err)
 
@@ -142,7 +142,7 @@ func TestDistributorQuerier_SelectShouldHonorQueryIngestersWithin(t *testing.T)
 				distributor.On("MetricsForLabelMatchersStream", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]metric.Metric{}, nil)
 
 				ctx := user.InjectOrgID(context.Background(), "test")
-				queryable := newDistributorQueryable(distributor, streamingEnabled, streamingEnabled, nil, testData.queryIngestersWithin, testData.queryStoreForLabels)
+				queryable := newDistributorQueryable(distributor, streamingEnabled, 0, streamingEnabled, nil, testData.queryIngestersWithin, testData.queryStoreForLabels)
 				querier, err := queryable.Querier(testData.queryMinT, testData.queryMaxT)
 				require.NoError(t, err)
 
@@ -181,7 +181,7 @@ func TestDistributorQueryableFilter(t *testing.T) {
 	t.Parallel()
 
 	d := &MockDistributor{}
-	dq := newDistributorQueryable(d, false, false, nil, 1*time.Hour, true)
+	dq := newDistributorQueryable(d, false, 0, false, nil, 1*time.Hour, true)
 
 	now := time.Now()
 
@@ -231,7 +231,7 @@ func TestIngesterStreaming(t *testing.T) {
 		nil)
 
 	ctx := user.InjectOrgID(context.Background(), "0")
-	queryable := newDistributorQueryable(d, true, true, mergeChunks, 0, true)
+	queryable := newDistributorQueryable(d, true, 0, true, mergeChunks, 0, true)
 	querier, err := queryable.Querier(mint, maxt)
 	require.NoError(t, err)
 
@@ -309,7 +309,7 @@ func TestIngesterStreamingMixedResults(t *testing.T) {
 		nil)
 
 	ctx := user.InjectOrgID(context.Background(), "0")
-	queryable := newDistributorQueryable(d, true, true, mergeChunks, 0, true)
+	queryable := newDistributorQueryable(d, true, 0, true, mergeChunks, 0, true)
 	querier, err := queryable.Querier(mint, maxt)
 	require.NoError(t, err)
 
@@ -329,6 +329,75 @@ func TestIngesterStreamingMixedResults(t *testing.T) {
 	require.NoError(t, seriesSet.Err())
 }
 
+func TestIngesterStreamingMergeResults(t *testing.T) {
+	t.Parallel()
+
+	const (
+		mint = 0
+		maxt = 10000
+	)
+	s1 := []cortexpb.Sample{
+		{Value: 1, TimestampMs: 1000},
+		{Value: 2, TimestampMs: 2000},
+		{Value: 3, TimestampMs: 3000},
+	}
+	s2 := []cortexpb.Sample{
+		{Value: 3, TimestampMs: 3000},
+		{Value: 4, TimestampMs: 4000},
+	}
+
+	// The distributor merges the series of the ingesters, a series may have both chunks and samples.
+	set := &sliceQueryStreamSeriesSet{series: []client.QueryStreamSeries{
+		{Labels: []cortexpb.LabelAdapter{{Name: labels.MetricName, Value: "one"}}, Chunks: convertToChunks(t, s1)},
+		{Labels: []cortexpb.LabelAdapter{{Name: labels.MetricName, Value: "three"}}},
+		{Labels: []cortexpb.LabelAdapter{{Name: labels.MetricName, Value: "two"}}, Chunks: convertToChunks(t, s1), Samples: s2},
+	}}
+
+	d := &MockDistributor{}
+	d.On("QueryStreamSeries", mock.Anything, mock.Anything, mock.Anything, 10, mock.Anything).Return(set, nil)
+
+	ctx := user.InjectOrgID(context.Background(), "0")
+	queryable := newDistributorQueryable(d, true, 10, true, mergeChunks, 0, true)
+	querier, err := queryable.Querier(mint, maxt)
+	require.NoError(t, err)
+
+	seriesSet := querier.Select(ctx, true, &storage.SelectHints{Start: mint, End: maxt}, labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".*"))
+	require.NoError(t, seriesSet.Err())
+
+	require.True(t, seriesSet.Next())
+	verifySeries(t, seriesSet.At(), labels.Labels{{Name: labels.MetricName, Value: "one"}}, s1)
+
+	// The series without data is skipped.
+	require.True(t, seriesSet.Next())
+	verifySeries(t, seriesSet.At(), labels.Labels{{Name: labels.MetricName, Value: "two"}}, append(s1[:2:2], s2...))
+
+	require.False(t, seriesSet.Next())
+	require.NoError(t, seriesSet.Err())
+	assert.Empty(t, seriesSet.Warnings())
+
+	// The set is closed with the querier, in case the query didn't iterate it until its end.
+	require.NoError(t, querier.Close())
+	assert.True(t, set.closed)
+}
+
+type sliceQueryStreamSeriesSet struct {
+	series []client.QueryStreamSeries
+	cur    int
+	closed bool
+}
+
+func (s *sliceQueryStreamSeriesSet) Next() bool {
+	if s.cur >= len(s.series) {
+		return false
+	}
+	s.cur++
+	return true
+}
+
+func (s *sliceQueryStreamSeriesSet) At() client.QueryStreamSeries { return s.series[s.cur-1] }
+func (s *sliceQueryStreamSeriesSet) Err() error                   { return nil }
+func (s *sliceQueryStreamSeriesSet) Close()                       { s.closed = true }
+
 func verifySeries(t *testing.T, series storage.Series, l labels.Labels, samples []cortexpb.Sample) {
 	require.Equal(t, l, series.Labels())
 
@@ -365,7 +434,7 @@ func TestDistributorQuerier_LabelNames(t *testing.T) {
 			d.On("MetricsForLabelMatchersStream", mock.Anything, model.Time(mint), model.Time(maxt), someMatchers).
 				Return(metrics, nil)
 
-			queryable := newDistributorQueryable(d, false, streamingEnabled, nil, 0, true)
+			queryable := newDistributorQueryable(d, false, 0, streamingEnabled, nil, 0, true)
 			querier, err := queryable.Querier(mint, maxt)
 			require.NoError(t, err)
 
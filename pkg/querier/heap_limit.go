@@ -0,0 +1,58 @@
+package querier
+
+import (
+	"net/http"
+	"runtime/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/middleware"
+)
+
+const heapInuseMetric = "/memory/classes/heap/objects:bytes"
+
+// HeapInuseLimitMiddleware rejects the queries while the heap in use by the process exceeds
+// the limit, so that the queries can't starve of memory the other components running in the
+// same process.
+type HeapInuseLimitMiddleware struct {
+	limit     uint64
+	heapInuse func() uint64
+	rejected  prometheus.Counter
+}
+
+// NewHeapInuseLimitMiddleware makes a new HeapInuseLimitMiddleware.
+func NewHeapInuseLimitMiddleware(limit uint64, reg prometheus.Registerer) *HeapInuseLimitMiddleware {
+	return &HeapInuseLimitMiddleware{
+		limit:     limit,
+		heapInuse: readHeapInuse,
+		rejected: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_heap_limit_rejected_requests_total",
+			Help: "Total number of requests rejected because the heap in use by the process exceeded -querier.max-heap-inuse-bytes.",
+		}),
+	}
+}
+
+// Wrap implements middleware.Interface.
+func (m *HeapInuseLimitMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.heapInuse() > m.limit {
+			m.rejected.Inc()
+			http.Error(w, "the querier is out of its memory budget, try again later", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var _ middleware.Interface = (*HeapInuseLimitMiddleware)(nil)
+
+// readHeapInuse returns the memory occupied by the live and not yet collected heap objects. It
+// doesn't stop the world, unlike runtime.ReadMemStats.
+func readHeapInuse() uint64 {
+	sample := []metrics.Sample{{Name: heapInuseMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
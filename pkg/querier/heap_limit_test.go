@@ -0,0 +1,36 @@
+package querier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeapInuseLimitMiddleware(t *testing.T) {
+	m := NewHeapInuseLimitMiddleware(1000, prometheus.NewPedanticRegistry())
+	handler := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range []struct {
+		heapInuse    uint64
+		expectedCode int
+	}{
+		{heapInuse: 500, expectedCode: http.StatusOK},
+		{heapInuse: 1000, expectedCode: http.StatusOK},
+		{heapInuse: 1001, expectedCode: http.StatusServiceUnavailable},
+	} {
+		m.heapInuse = func() uint64 { return tc.heapInuse }
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil))
+		assert.Equal(t, tc.expectedCode, rec.Code, "heap in use: %d", tc.heapInuse)
+	}
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.rejected))
+	assert.Greater(t, readHeapInuse(), uint64(0))
+}
@@ -0,0 +1,52 @@
+package querier
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/middleware"
+)
+
+// InflightLimitMiddleware caps the number of requests the querier executes at the same time, so
+// that the goroutines and the CPU used by the read path are bounded whatever the origin of the
+// requests. The requests over the limit wait for a slot.
+type InflightLimitMiddleware struct {
+	slots   chan struct{}
+	waiting prometheus.Gauge
+}
+
+// NewInflightLimitMiddleware makes a new InflightLimitMiddleware.
+func NewInflightLimitMiddleware(limit int, reg prometheus.Registerer) *InflightLimitMiddleware {
+	return &InflightLimitMiddleware{
+		slots: make(chan struct{}, limit),
+		waiting: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_querier_inflight_limit_waiting_requests",
+			Help: "Number of requests waiting because the querier is executing its max number of inflight requests.",
+		}),
+	}
+}
+
+// Wrap implements middleware.Interface.
+func (m *InflightLimitMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case m.slots <- struct{}{}:
+		default:
+			m.waiting.Inc()
+			select {
+			case m.slots <- struct{}{}:
+				m.waiting.Dec()
+			case <-r.Context().Done():
+				m.waiting.Dec()
+				http.Error(w, r.Context().Err().Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		defer func() { <-m.slots }()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+var _ middleware.Interface = (*InflightLimitMiddleware)(nil)
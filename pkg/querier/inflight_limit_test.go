@@ -0,0 +1,74 @@
+package querier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestInflightLimitMiddleware(t *testing.T) {
+	var (
+		inflight = atomic.NewInt32(0)
+		release  = make(chan struct{})
+	)
+	m := NewInflightLimitMiddleware(2, prometheus.NewPedanticRegistry())
+	handler := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		assert.LessOrEqual(t, inflight.Inc(), int32(2))
+		<-release
+		inflight.Dec()
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	codes := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil))
+			codes <- rec.Code
+		}()
+	}
+
+	// The request over the limit waits for a slot.
+	require.Eventually(t, func() bool {
+		return inflight.Load() == 2 && testutil.ToFloat64(m.waiting) == 1
+	}, 5*time.Second, time.Millisecond)
+
+	// The waiting requests are given up once canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, <-codes)
+	}
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.waiting))
+}
+
+func TestConfig_InflightRequestsLimit(t *testing.T) {
+	for name, tc := range map[string]struct {
+		maxInflight, reserved, maxProcs, expected int
+	}{
+		"unlimited":             {maxProcs: 8, expected: 0},
+		"max inflight requests": {maxInflight: 4, maxProcs: 8, expected: 4},
+		"reserved CPUs":         {reserved: 2, maxProcs: 8, expected: 6},
+		"max inflight requests lower than the CPUs left": {maxInflight: 4, reserved: 2, maxProcs: 8, expected: 4},
+		"CPUs left lower than max inflight requests":     {maxInflight: 4, reserved: 6, maxProcs: 8, expected: 2},
+		"all CPUs reserved":                              {reserved: 8, maxProcs: 8, expected: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := Config{MaxInflightRequests: tc.maxInflight, WritePathReservedCPUs: tc.reserved}
+			assert.Equal(t, tc.expected, cfg.InflightRequestsLimit(tc.maxProcs))
+		})
+	}
+}
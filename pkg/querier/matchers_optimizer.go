@@ -0,0 +1,211 @@
+package querier
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// maxSetMatches is the maximum number of values of a regex matcher rewritten as a set match.
+const maxSetMatches = 256
+
+// optimizeMatchers simplifies the matchers of a selector and orders them by estimated
+// selectivity, without changing the series they select:
+//
+//   - The regex matchers of a single literal value become equality matchers, and the
+//     regex matchers of a set of literal values are rewritten as a sorted alternation.
+//   - The matchers matching everything (=~".*") are removed, as well as the duplicated
+//     matchers and the non-empty matchers of a label already matching a non-empty value.
+//   - The most selective matchers come first: the equality matchers, the set matches,
+//     then the inequality and the regex matchers.
+func optimizeMatchers(matchers []*labels.Matcher) []*labels.Matcher {
+	out := make([]*labels.Matcher, 0, len(matchers))
+	seen := make(map[string]struct{}, len(matchers))
+	for _, m := range matchers {
+		m = simplifyMatcher(m)
+		if m == nil {
+			continue
+		}
+		if _, ok := seen[m.String()]; ok {
+			continue
+		}
+		seen[m.String()] = struct{}{}
+		out = append(out, m)
+	}
+
+	// A non-empty value of the label is implied by an equality matcher of a non-empty value.
+	nonEmpty := map[string]bool{}
+	for _, m := range out {
+		if m.Type == labels.MatchEqual && m.Value != "" {
+			nonEmpty[m.Name] = true
+		}
+	}
+	filtered := out[:0]
+	for _, m := range out {
+		if m.Type == labels.MatchNotEqual && m.Value == "" && nonEmpty[m.Name] {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+	out = filtered
+
+	// The selectors must have at least a matcher: keep the original ones if they all match everything.
+	if len(out) == 0 {
+		return matchers
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return matcherRank(out[i]) < matcherRank(out[j])
+	})
+	return out
+}
+
+// simplifyMatcher returns the simplest matcher equivalent to the given one, or nil if it
+// matches every value.
+func simplifyMatcher(m *labels.Matcher) *labels.Matcher {
+	if m.Type != labels.MatchRegexp && m.Type != labels.MatchNotRegexp {
+		return m
+	}
+
+	switch m.Value {
+	case ".*":
+		if m.Type == labels.MatchRegexp {
+			return nil
+		}
+		return m
+	case ".+":
+		if m.Type == labels.MatchRegexp {
+			return labels.MustNewMatcher(labels.MatchNotEqual, m.Name, "")
+		}
+		return labels.MustNewMatcher(labels.MatchEqual, m.Name, "")
+	}
+
+	values, ok := regexSetValues(m.Value)
+	if !ok {
+		return m
+	}
+	if len(values) == 1 {
+		if m.Type == labels.MatchRegexp {
+			return labels.MustNewMatcher(labels.MatchEqual, m.Name, values[0])
+		}
+		return labels.MustNewMatcher(labels.MatchNotEqual, m.Name, values[0])
+	}
+
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, regexp.QuoteMeta(v))
+	}
+	simplified, err := labels.NewMatcher(m.Type, m.Name, strings.Join(quoted, "|"))
+	if err != nil {
+		return m
+	}
+	return simplified
+}
+
+// regexSetValues returns the sorted values matched by the regex, if it only matches a small
+// set of literal values. The regex is anchored, like the Prometheus ones.
+func regexSetValues(expr string) ([]string, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, false
+	}
+	values, ok := literalValues(re.Simplify())
+	if !ok {
+		return nil, false
+	}
+
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	res := make([]string, 0, len(set))
+	for v := range set {
+		res = append(res, v)
+	}
+	sort.Strings(res)
+	return res, true
+}
+
+func literalValues(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return []string{""}, true
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return []string{string(re.Rune)}, true
+	case syntax.OpCharClass:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		var res []string
+		for i := 0; i < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if len(res) >= maxSetMatches {
+					return nil, false
+				}
+				res = append(res, string(r))
+			}
+		}
+		return res, true
+	case syntax.OpCapture:
+		return literalValues(re.Sub[0])
+	case syntax.OpAlternate:
+		var res []string
+		for _, sub := range re.Sub {
+			values, ok := literalValues(sub)
+			if !ok || len(res)+len(values) > maxSetMatches {
+				return nil, false
+			}
+			res = append(res, values...)
+		}
+		return res, true
+	case syntax.OpConcat:
+		res := []string{""}
+		for _, sub := range re.Sub {
+			values, ok := literalValues(sub)
+			if !ok || len(res)*len(values) > maxSetMatches {
+				return nil, false
+			}
+			next := make([]string, 0, len(res)*len(values))
+			for _, prefix := range res {
+				for _, v := range values {
+					next = append(next, prefix+v)
+				}
+			}
+			res = next
+		}
+		return res, true
+	default:
+		return nil, false
+	}
+}
+
+// matcherRank estimates how selective a matcher is: the lower, the more selective.
+func matcherRank(m *labels.Matcher) int {
+	switch {
+	case m.Type == labels.MatchEqual && m.Name == labels.MetricName:
+		return 0
+	case m.Type == labels.MatchEqual && m.Value != "":
+		return 1
+	case m.Type == labels.MatchRegexp && isSetRegex(m.Value):
+		return 2
+	case m.Type == labels.MatchRegexp:
+		return 3
+	case m.Type == labels.MatchNotEqual && m.Value == "":
+		return 4
+	case m.Type == labels.MatchNotEqual || m.Type == labels.MatchEqual:
+		return 5
+	default:
+		return 6
+	}
+}
+
+func isSetRegex(expr string) bool {
+	_, ok := regexSetValues(expr)
+	return ok
+}
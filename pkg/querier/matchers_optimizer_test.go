@@ -0,0 +1,104 @@
+package querier
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimizeMatchers(t *testing.T) {
+	tests := map[string]struct {
+		selector string
+		expected string
+	}{
+		"should keep the optimized matchers as is": {
+			selector: `{__name__="up", job="api"}`,
+			expected: `{__name__="up", job="api"}`,
+		},
+		"should rewrite a regex of a single literal as an equality matcher": {
+			selector: `{__name__="up", job=~"api", env!~"dev"}`,
+			expected: `{__name__="up", job="api", env!="dev"}`,
+		},
+		"should rewrite a regex of literals as a sorted set match": {
+			selector: `{__name__="up", job=~"web|api|web|(db)"}`,
+			expected: `{__name__="up", job=~"api|db|web"}`,
+		},
+		"should rewrite the factored and character class alternations": {
+			selector: `{__name__="up", instance=~"host-[12]|host-3"}`,
+			expected: `{__name__="up", instance=~"host-1|host-2|host-3"}`,
+		},
+		"should keep the regex matchers which aren't sets": {
+			selector: `{__name__="up", job=~"api.*", env=~"(?i)dev"}`,
+			expected: `{__name__="up", job=~"api.*", env=~"(?i)dev"}`,
+		},
+		"should remove the matchers matching everything and the duplicated ones": {
+			selector: `{__name__="up", job=~".*", env="prod", env="prod"}`,
+			expected: `{__name__="up", env="prod"}`,
+		},
+		"should remove the non-empty matchers implied by an equality matcher": {
+			selector: `{__name__="up", job=~".+", job="api", env!=""}`,
+			expected: `{__name__="up", job="api", env!=""}`,
+		},
+		"should order the matchers by selectivity": {
+			selector: `{job!~"api.*", env=~"prod.*", instance!="a", zone=~"a|b", cluster="c", __name__="up"}`,
+			expected: `{__name__="up", cluster="c", zone=~"a|b", env=~"prod.*", instance!="a", job!~"api.*"}`,
+		},
+		"should keep the matchers if they all match everything": {
+			selector: `{job=~".*", job=~".*"}`,
+			expected: `{job=~".*", job=~".*"}`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			matchers, err := parser.ParseMetricSelector(tc.selector)
+			require.NoError(t, err)
+			expected, err := parser.ParseMetricSelector(tc.expected)
+			require.NoError(t, err)
+
+			assert.Equal(t, matchersStrings(expected), matchersStrings(optimizeMatchers(matchers)))
+		})
+	}
+}
+
+func TestOptimizeMatchers_ShouldSelectTheSameValues(t *testing.T) {
+	selectors := []string{
+		`{job=~"web|api|(db)"}`,
+		`{job!~"web|api"}`,
+		`{job=~"host-[1-3]|"}`,
+		`{job=~".+"}`,
+		`{job!~".+"}`,
+		`{job=~"a(b|c)d"}`,
+	}
+	values := []string{"", "web", "api", "db", "dbx", "host-1", "host-3", "host-4", "abd", "acd", "ad"}
+
+	for _, selector := range selectors {
+		matchers, err := parser.ParseMetricSelector(selector)
+		require.NoError(t, err)
+		optimized := optimizeMatchers(matchers)
+
+		for _, v := range values {
+			assert.Equal(t, matchAll(matchers, v), matchAll(optimized, v), "selector: %s, value: %q", selector, v)
+		}
+	}
+}
+
+func matchAll(matchers []*labels.Matcher, v string) bool {
+	for _, m := range matchers {
+		if !m.Matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchersStrings(matchers []*labels.Matcher) []string {
+	res := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		res = append(res, m.String())
+	}
+	return res
+}
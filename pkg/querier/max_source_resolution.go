@@ -0,0 +1,41 @@
+package querier
+
+import (
+	"net/http"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
+)
+
+// MaxSourceResolutionMiddleware injects the max source resolution in the request context, so
+// that the query is evaluated against downsampled data up to the requested resolution. The
+// resolution is read from the query parameter or, if not set, from the hint forwarded by the
+// query-frontend. Requests without any of them are evaluated against raw data.
+func MaxSourceResolutionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			resolution int64
+			err        error
+		)
+
+		if value := r.FormValue(downsample.MaxSourceResolutionParam); value != "" {
+			// The step has the same format of the max source resolution and is only
+			// used to select the resolution automatically.
+			step, _ := downsample.ParseMaxSourceResolution(r.FormValue("step"), 0)
+			resolution, err = downsample.ParseMaxSourceResolution(value, step)
+		} else if r.Header.Get(tripperware.MaxSourceResolutionHeader) != "" {
+			var hints tripperware.RequestHints
+			hints, err = tripperware.DecodeHints(r.Header)
+			resolution = hints.MaxSourceResolution
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if resolution > 0 {
+			r = r.WithContext(downsample.ContextWithMaxResolution(r.Context(), resolution))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
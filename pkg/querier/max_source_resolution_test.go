@@ -0,0 +1,71 @@
+package querier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
+)
+
+func TestMaxSourceResolutionMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		url                string
+		headers            map[string]string
+		expectedStatus     int
+		expectedResolution int64
+	}{
+		"no max source resolution": {
+			url:            "/api/v1/query_range?query=up&step=60",
+			expectedStatus: http.StatusOK,
+		},
+		"explicit max source resolution": {
+			url:                "/api/v1/query_range?query=up&step=60&max_source_resolution=1h",
+			expectedStatus:     http.StatusOK,
+			expectedResolution: 3600000,
+		},
+		"auto max source resolution": {
+			url:                "/api/v1/query_range?query=up&step=1h&max_source_resolution=auto",
+			expectedStatus:     http.StatusOK,
+			expectedResolution: 720000,
+		},
+		"max source resolution forwarded by the query-frontend": {
+			url:                "/api/v1/query_range?query=up&step=60",
+			headers:            map[string]string{tripperware.MaxSourceResolutionHeader: "300000"},
+			expectedStatus:     http.StatusOK,
+			expectedResolution: 300000,
+		},
+		"invalid forwarded max source resolution": {
+			url:            "/api/v1/query_range?query=up&step=60",
+			headers:        map[string]string{tripperware.MaxSourceResolutionHeader: "foo"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		"invalid max source resolution": {
+			url:            "/api/v1/query_range?query=up&step=60&max_source_resolution=foo",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actualResolution int64
+			next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				actualResolution = downsample.MaxResolutionFromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			MaxSourceResolutionMiddleware(next).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedResolution, actualResolution)
+		})
+	}
+}
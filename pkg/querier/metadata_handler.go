@@ -1,7 +1,18 @@
 package querier
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/scrape"
 
 	"github.com/cortexproject/cortex/pkg/util"
 )
@@ -49,3 +60,163 @@ func MetadataHandler(d Distributor) http.Handler {
 		util.WriteJSONResponse(w, metadataResult{Status: statusSuccess, Data: metrics})
 	})
 }
+
+// targetLabels are the labels of the series identifying the target exposing them.
+var targetLabels = []model.LabelName{model.JobLabel, model.InstanceLabel}
+
+// metricFamilySuffixes are the suffixes of the series of a metric family, added to its name.
+var metricFamilySuffixes = []string{"", "_total", "_bucket", "_count", "_sum", "_created", "_info"}
+
+type targetMetricMetadata struct {
+	Target map[string]string `json:"target"`
+	Metric string            `json:"metric"`
+	Type   string            `json:"type"`
+	Help   string            `json:"help"`
+	Unit   string            `json:"unit"`
+}
+
+type targetsMetadataResult struct {
+	Status string                 `json:"status"`
+	Data   []targetMetricMetadata `json:"data"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// TargetsMetadataHandler returns the metric metadata held by Cortex for a given tenant, per
+// target, like the Prometheus /api/v1/targets/metadata API. The remote write requests don't
+// tell the target of the metadata, so the targets of a metric are the job and instance labels
+// of its series held by the ingesters.
+func TargetsMetadataHandler(d Distributor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fail := func(code int, err error) {
+			w.WriteHeader(code)
+			util.WriteJSONResponse(w, targetsMetadataResult{Status: statusError, Error: err.Error()})
+		}
+
+		limit := -1
+		if s := r.FormValue("limit"); s != "" {
+			var err error
+			if limit, err = strconv.Atoi(s); err != nil {
+				fail(http.StatusBadRequest, errors.New("limit must be a number"))
+				return
+			}
+		}
+
+		var targetMatchers []*labels.Matcher
+		if s := r.FormValue("match_target"); s != "" {
+			var err error
+			if targetMatchers, err = parser.ParseMetricSelector(s); err != nil {
+				fail(http.StatusBadRequest, err)
+				return
+			}
+		}
+		metricName := r.FormValue("metric")
+
+		resp, err := d.MetricsMetadata(r.Context())
+		if err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+
+		metadata := map[string][]scrape.MetricMetadata{}
+		for _, m := range resp {
+			if metricName == "" || m.Metric == metricName {
+				metadata[m.Metric] = append(metadata[m.Metric], m)
+			}
+		}
+		if len(metadata) == 0 {
+			util.WriteJSONResponse(w, targetsMetadataResult{Status: statusSuccess, Data: []targetMetricMetadata{}})
+			return
+		}
+
+		// Look up the targets of all the metrics at once.
+		names := make([]string, 0, len(metadata))
+		for name := range metadata {
+			names = append(names, regexp.QuoteMeta(name))
+		}
+		sort.Strings(names)
+		suffixes := make([]string, 0, len(metricFamilySuffixes))
+		for _, s := range metricFamilySuffixes {
+			suffixes = append(suffixes, regexp.QuoteMeta(s))
+		}
+		nameMatcher, err := labels.NewMatcher(labels.MatchRegexp, labels.MetricName, fmt.Sprintf("(%s)(%s)", strings.Join(names, "|"), strings.Join(suffixes, "|")))
+		if err != nil {
+			fail(http.StatusInternalServerError, err)
+			return
+		}
+
+		series, err := d.MetricsForLabelMatchers(r.Context(), model.Earliest, model.Latest, append([]*labels.Matcher{nameMatcher}, targetMatchers...)...)
+		if err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+
+		type targetMetric struct {
+			target model.Fingerprint
+			metric string
+		}
+		targets := map[model.Fingerprint]model.LabelSet{}
+		seen := map[targetMetric]bool{}
+		var keys []targetMetric
+		for _, s := range series {
+			target := model.LabelSet{}
+			for _, name := range targetLabels {
+				if v, ok := s.Metric[name]; ok {
+					target[name] = v
+				}
+			}
+			if len(target) == 0 {
+				continue
+			}
+
+			metric := metadataMetricName(string(s.Metric[model.MetricNameLabel]), metadata)
+			key := targetMetric{target: target.Fingerprint(), metric: metric}
+			if metric == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			targets[key.target] = target
+			keys = append(keys, key)
+		}
+
+		sort.Slice(keys, func(i, j int) bool {
+			ti, tj := targets[keys[i].target], targets[keys[j].target]
+			if !ti.Equal(tj) {
+				return ti.Before(tj)
+			}
+			return keys[i].metric < keys[j].metric
+		})
+
+		data := []targetMetricMetadata{}
+		for _, key := range keys {
+			target := map[string]string{}
+			for name, value := range targets[key.target] {
+				target[string(name)] = string(value)
+			}
+			for _, m := range metadata[key.metric] {
+				if limit >= 0 && len(data) >= limit {
+					break
+				}
+				data = append(data, targetMetricMetadata{Target: target, Metric: m.Metric, Type: string(m.Type), Help: m.Help, Unit: m.Unit})
+			}
+		}
+
+		util.WriteJSONResponse(w, targetsMetadataResult{Status: statusSuccess, Data: data})
+	})
+}
+
+// metadataMetricName returns the name of the metric family of the series with the given name,
+// among the ones with metadata, or an empty string if none.
+func metadataMetricName(seriesName string, metadata map[string][]scrape.MetricMetadata) string {
+	if _, ok := metadata[seriesName]; ok {
+		return seriesName
+	}
+	for _, suffix := range metricFamilySuffixes {
+		if suffix == "" || !strings.HasSuffix(seriesName, suffix) {
+			continue
+		}
+		if name := strings.TrimSuffix(seriesName, suffix); metadata[name] != nil {
+			return name
+		}
+	}
+	return ""
+}
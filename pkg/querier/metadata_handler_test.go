@@ -7,9 +7,13 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/scrape"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/prom1/storage/metric"
 )
 
 func TestMetadataHandler_Success(t *testing.T) {
@@ -79,3 +83,75 @@ func TestMetadataHandler_Error(t *testing.T) {
 
 	require.JSONEq(t, expectedJSON, string(responseBody))
 }
+
+func TestTargetsMetadataHandler(t *testing.T) {
+	t.Parallel()
+
+	metadata := []scrape.MetricMetadata{
+		{Metric: "http_requests", Help: "Total number of HTTP requests", Type: "counter", Unit: ""},
+		{Metric: "http_request_duration_seconds", Help: "Duration of the HTTP requests", Type: "histogram", Unit: "seconds"},
+		{Metric: "unused", Help: "Metric without series", Type: "gauge", Unit: ""},
+	}
+	series := []metric.Metric{
+		{Metric: model.Metric{model.MetricNameLabel: "http_requests_total", "job": "api", "instance": "api-1", "code": "200"}},
+		{Metric: model.Metric{model.MetricNameLabel: "http_requests_total", "job": "api", "instance": "api-1", "code": "500"}},
+		{Metric: model.Metric{model.MetricNameLabel: "http_request_duration_seconds_bucket", "job": "api", "instance": "api-1", "le": "1"}},
+		{Metric: model.Metric{model.MetricNameLabel: "http_requests_total", "job": "api", "instance": "api-2"}},
+		// Series without target labels are skipped.
+		{Metric: model.Metric{model.MetricNameLabel: "http_requests_total"}},
+	}
+
+	tests := map[string]struct {
+		query            string
+		expectedMatchers []*labels.Matcher
+		expectedCode     int
+		expectedJSON     string
+	}{
+		"should return the metadata of each target": {
+			query: "",
+			expectedMatchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "(http_request_duration_seconds|http_requests|unused)(|_total|_bucket|_count|_sum|_created|_info)"),
+			},
+			expectedCode: http.StatusOK,
+			expectedJSON: `{"status": "success", "data": [
+				{"target": {"instance": "api-1", "job": "api"}, "metric": "http_request_duration_seconds", "type": "histogram", "help": "Duration of the HTTP requests", "unit": "seconds"},
+				{"target": {"instance": "api-1", "job": "api"}, "metric": "http_requests", "type": "counter", "help": "Total number of HTTP requests", "unit": ""},
+				{"target": {"instance": "api-2", "job": "api"}, "metric": "http_requests", "type": "counter", "help": "Total number of HTTP requests", "unit": ""}
+			]}`,
+		},
+		"should filter by metric and target, and limit the result": {
+			query: `?metric=http_requests&match_target={job="api"}&limit=1`,
+			expectedMatchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "(http_requests)(|_total|_bucket|_count|_sum|_created|_info)"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "api"),
+			},
+			expectedCode: http.StatusOK,
+			expectedJSON: `{"status": "success", "data": [
+				{"target": {"instance": "api-1", "job": "api"}, "metric": "http_requests", "type": "counter", "help": "Total number of HTTP requests", "unit": ""}
+			]}`,
+		},
+		"should fail on an invalid target selector": {
+			query:        `?match_target={job=`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			d := &MockDistributor{}
+			d.On("MetricsMetadata", mock.Anything).Return(metadata, nil)
+			d.On("MetricsForLabelMatchers", mock.Anything, model.Earliest, model.Latest, tc.expectedMatchers).Return(series, nil)
+
+			recorder := httptest.NewRecorder()
+			TargetsMetadataHandler(d).ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/targets/metadata"+tc.query, nil))
+
+			require.Equal(t, tc.expectedCode, recorder.Result().StatusCode)
+			if tc.expectedJSON != "" {
+				require.JSONEq(t, tc.expectedJSON, recorder.Body.String())
+			}
+		})
+	}
+}
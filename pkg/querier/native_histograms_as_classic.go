@@ -0,0 +1,219 @@
+package querier
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	seriesset "github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const (
+	classicBucketSuffix = "_bucket"
+	classicSumSuffix    = "_sum"
+	classicCountSuffix  = "_count"
+)
+
+// NativeHistogramsAsClassicQueryable returns a queryable synthesizing the classic _bucket, _sum and
+// _count series of the native histograms selected by these names, for the tenants enabling it,
+// so that the queries written for the classic histograms keep working once migrated to native
+// histograms.
+func NativeHistogramsAsClassicQueryable(next storage.Queryable, limits *validation.Overrides) storage.Queryable {
+	return storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+		q, err := next.Querier(mint, maxt)
+		if err != nil {
+			return nil, err
+		}
+		return &nativeHistogramsAsClassicQuerier{Querier: q, limits: limits}, nil
+	})
+}
+
+type nativeHistogramsAsClassicQuerier struct {
+	storage.Querier
+
+	limits *validation.Overrides
+}
+
+// Select implements storage.Querier.
+func (q *nativeHistogramsAsClassicQuerier) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil || !q.limits.NativeHistogramsAsClassic(userID) {
+		return q.Querier.Select(ctx, sortSeries, hints, matchers...)
+	}
+
+	name, suffix, ok := classicHistogramName(matchers)
+	if !ok {
+		return q.Querier.Select(ctx, sortSeries, hints, matchers...)
+	}
+
+	// The native histogram has neither the suffix nor the le label, which is matched against
+	// the synthesized buckets instead.
+	nativeMatchers := make([]*labels.Matcher, 0, len(matchers))
+	var leMatchers []*labels.Matcher
+	for _, m := range matchers {
+		switch {
+		case m.Name == labels.MetricName:
+			nativeMatchers = append(nativeMatchers, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, name))
+		case m.Name == model.BucketLabel && suffix == classicBucketSuffix:
+			leMatchers = append(leMatchers, m)
+		default:
+			nativeMatchers = append(nativeMatchers, m)
+		}
+	}
+
+	// Both sets must be sorted to be merged.
+	classic := q.Querier.Select(ctx, true, hints, matchers...)
+	native := q.Querier.Select(ctx, true, hints, nativeMatchers...)
+	synthesized := synthesizeClassicHistograms(native, suffix, leMatchers, q.limits.NativeHistogramsClassicLe(userID))
+	return storage.NewMergeSeriesSet([]storage.SeriesSet{classic, synthesized}, storage.ChainedSeriesMerge)
+}
+
+// classicHistogramName returns the name of the native histogram and the suffix of the classic
+// series selected by the matchers, if any.
+func classicHistogramName(matchers []*labels.Matcher) (string, string, bool) {
+	for _, m := range matchers {
+		if m.Name != labels.MetricName || m.Type != labels.MatchEqual {
+			continue
+		}
+		for _, suffix := range []string{classicBucketSuffix, classicSumSuffix, classicCountSuffix} {
+			if name := strings.TrimSuffix(m.Value, suffix); name != m.Value && name != "" {
+				return name, suffix, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// synthesizeClassicHistograms returns the classic series with the given suffix of the native
+// histograms of the set. The buckets have the given upper bounds or, if empty, the upper bounds of
+// all the native buckets of the series.
+func synthesizeClassicHistograms(set storage.SeriesSet, suffix string, leMatchers []*labels.Matcher, les []float64) storage.SeriesSet {
+	var result []storage.Series
+	var it chunkenc.Iterator
+	for set.Next() {
+		s := set.At()
+
+		var (
+			timestamps []int64
+			histograms []*histogram.FloatHistogram
+		)
+		it = s.Iterator(it)
+		for typ := it.Next(); typ != chunkenc.ValNone; typ = it.Next() {
+			// The float samples aren't part of a native histogram.
+			if typ != chunkenc.ValHistogram && typ != chunkenc.ValFloatHistogram {
+				continue
+			}
+			t, h := it.AtFloatHistogram()
+			timestamps = append(timestamps, t)
+			histograms = append(histograms, h.Copy())
+		}
+		if err := it.Err(); err != nil {
+			return storage.ErrSeriesSet(err)
+		}
+		if len(histograms) == 0 {
+			continue
+		}
+
+		name := s.Labels().Get(labels.MetricName)
+		lbls := labels.NewBuilder(s.Labels()).Set(labels.MetricName, name+suffix)
+
+		switch suffix {
+		case classicSumSuffix, classicCountSuffix:
+			samples := make([]model.SamplePair, 0, len(histograms))
+			for i, h := range histograms {
+				v := h.Count
+				if suffix == classicSumSuffix {
+					v = h.Sum
+				}
+				samples = append(samples, model.SamplePair{Timestamp: model.Time(timestamps[i]), Value: model.SampleValue(v)})
+			}
+			result = append(result, seriesset.NewConcreteSeries(lbls.Labels(), samples))
+
+		case classicBucketSuffix:
+			for _, le := range classicBucketBounds(histograms, les) {
+				leValue := formatBucketBound(le)
+				if !matchesAll(leMatchers, leValue) {
+					continue
+				}
+				samples := make([]model.SamplePair, 0, len(histograms))
+				for i, h := range histograms {
+					samples = append(samples, model.SamplePair{Timestamp: model.Time(timestamps[i]), Value: model.SampleValue(cumulativeCount(h, le))})
+				}
+				result = append(result, seriesset.NewConcreteSeries(lbls.Set(model.BucketLabel, leValue).Labels(), samples))
+			}
+		}
+	}
+	if err := set.Err(); err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+
+	return seriesset.NewConcreteSeriesSet(true, result)
+}
+
+// classicBucketBounds returns the sorted upper bounds of the classic buckets, always including +Inf.
+func classicBucketBounds(histograms []*histogram.FloatHistogram, les []float64) []float64 {
+	bounds := map[float64]struct{}{math.Inf(1): {}}
+	if len(les) > 0 {
+		for _, le := range les {
+			bounds[le] = struct{}{}
+		}
+	} else {
+		for _, h := range histograms {
+			for it := h.AllBucketIterator(); it.Next(); {
+				bounds[it.At().Upper] = struct{}{}
+			}
+		}
+	}
+
+	result := make([]float64, 0, len(bounds))
+	for le := range bounds {
+		result = append(result, le)
+	}
+	sort.Float64s(result)
+	return result
+}
+
+// cumulativeCount returns the number of observations of the native buckets whose upper bound
+// is less than or equal to le.
+func cumulativeCount(h *histogram.FloatHistogram, le float64) float64 {
+	if math.IsInf(le, 1) {
+		return h.Count
+	}
+
+	var count float64
+	for it := h.AllBucketIterator(); it.Next(); {
+		b := it.At()
+		if b.Upper > le {
+			break
+		}
+		count += b.Count
+	}
+	return count
+}
+
+// formatBucketBound formats the upper bound of a bucket the way the Prometheus client libraries do.
+func formatBucketBound(le float64) string {
+	if math.IsInf(le, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(le, 'g', -1, 64)
+}
+
+func matchesAll(matchers []*labels.Matcher, value string) bool {
+	for _, m := range matchers {
+		if !m.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
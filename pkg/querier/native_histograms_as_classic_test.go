@@ -0,0 +1,157 @@
+package querier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	seriesset "github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestNativeHistogramsAsClassicQueryable(t *testing.T) {
+	// The native histogram has the zero bucket [-0.001, 0.001] and the buckets (0.5, 1], (1, 2] and (2, 4].
+	h := &histogram.FloatHistogram{
+		Schema:          0,
+		ZeroThreshold:   0.001,
+		ZeroCount:       1,
+		Count:           7,
+		Sum:             10,
+		PositiveSpans:   []histogram.Span{{Offset: 0, Length: 3}},
+		PositiveBuckets: []float64{1, 2, 3},
+	}
+	stored := []storage.Series{
+		storage.NewListSeries(labels.FromStrings(labels.MetricName, "http_duration_seconds", "job", "api"), []chunks.Sample{
+			histogramSample{t: 1000, fh: h},
+			histogramSample{t: 2000, fh: h.Copy().Mul(2)},
+		}),
+		storage.NewListSeries(labels.FromStrings(labels.MetricName, "http_duration_seconds_count", "job", "legacy"), []chunks.Sample{
+			histogramSample{t: 1000, f: 3},
+		}),
+	}
+
+	for name, tc := range map[string]struct {
+		enabled  bool
+		les      []float64
+		matchers []*labels.Matcher
+		expected map[string][]model.SamplePair
+	}{
+		"disabled": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "http_duration_seconds_count")},
+			expected: map[string][]model.SamplePair{
+				`{__name__="http_duration_seconds_count", job="legacy"}`: {{Timestamp: 1000, Value: 3}},
+			},
+		},
+		"count": {
+			enabled:  true,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "http_duration_seconds_count")},
+			expected: map[string][]model.SamplePair{
+				`{__name__="http_duration_seconds_count", job="api"}`:    {{Timestamp: 1000, Value: 7}, {Timestamp: 2000, Value: 14}},
+				`{__name__="http_duration_seconds_count", job="legacy"}`: {{Timestamp: 1000, Value: 3}},
+			},
+		},
+		"sum": {
+			enabled:  true,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "http_duration_seconds_sum"), labels.MustNewMatcher(labels.MatchEqual, "job", "api")},
+			expected: map[string][]model.SamplePair{
+				`{__name__="http_duration_seconds_sum", job="api"}`: {{Timestamp: 1000, Value: 10}, {Timestamp: 2000, Value: 20}},
+			},
+		},
+		"buckets of the native histogram": {
+			enabled:  true,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "http_duration_seconds_bucket")},
+			expected: map[string][]model.SamplePair{
+				`{__name__="http_duration_seconds_bucket", job="api", le="0.001"}`: {{Timestamp: 1000, Value: 1}, {Timestamp: 2000, Value: 2}},
+				`{__name__="http_duration_seconds_bucket", job="api", le="1"}`:     {{Timestamp: 1000, Value: 2}, {Timestamp: 2000, Value: 4}},
+				`{__name__="http_duration_seconds_bucket", job="api", le="2"}`:     {{Timestamp: 1000, Value: 4}, {Timestamp: 2000, Value: 8}},
+				`{__name__="http_duration_seconds_bucket", job="api", le="4"}`:     {{Timestamp: 1000, Value: 7}, {Timestamp: 2000, Value: 14}},
+				`{__name__="http_duration_seconds_bucket", job="api", le="+Inf"}`:  {{Timestamp: 1000, Value: 7}, {Timestamp: 2000, Value: 14}},
+			},
+		},
+		"configured buckets matched by le": {
+			enabled:  true,
+			les:      []float64{1, 3},
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "http_duration_seconds_bucket"), labels.MustNewMatcher(labels.MatchNotEqual, model.BucketLabel, "1")},
+			expected: map[string][]model.SamplePair{
+				`{__name__="http_duration_seconds_bucket", job="api", le="3"}`:    {{Timestamp: 1000, Value: 4}, {Timestamp: 2000, Value: 8}},
+				`{__name__="http_duration_seconds_bucket", job="api", le="+Inf"}`: {{Timestamp: 1000, Value: 7}, {Timestamp: 2000, Value: 14}},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			limits := validation.Limits{NativeHistogramsAsClassic: tc.enabled, NativeHistogramsClassicLe: tc.les}
+			overrides, err := validation.NewOverrides(limits, nil)
+			require.NoError(t, err)
+
+			next := storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+				return &listQuerier{series: stored}, nil
+			})
+			q, err := NativeHistogramsAsClassicQueryable(next, overrides).Querier(0, 3000)
+			require.NoError(t, err)
+
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			set := q.Select(ctx, true, nil, tc.matchers...)
+			actual := map[string][]model.SamplePair{}
+			for set.Next() {
+				var samples []model.SamplePair
+				it := set.At().Iterator(nil)
+				for it.Next() != chunkenc.ValNone {
+					ts, v := it.At()
+					samples = append(samples, model.SamplePair{Timestamp: model.Time(ts), Value: model.SampleValue(v)})
+				}
+				require.NoError(t, it.Err())
+				actual[set.At().Labels().String()] = samples
+			}
+			require.NoError(t, set.Err())
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+// listQuerier returns the series matching all the matchers.
+type listQuerier struct {
+	storage.Querier
+
+	series []storage.Series
+}
+
+func (q *listQuerier) Select(_ context.Context, _ bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	var result []storage.Series
+	for _, s := range q.series {
+		matches := true
+		for _, m := range matchers {
+			matches = matches && m.Matches(s.Labels().Get(m.Name))
+		}
+		if matches {
+			result = append(result, s)
+		}
+	}
+	return seriesset.NewConcreteSeriesSet(true, result)
+}
+
+type histogramSample struct {
+	t  int64
+	f  float64
+	fh *histogram.FloatHistogram
+}
+
+func (s histogramSample) T() int64                      { return s.t }
+func (s histogramSample) F() float64                    { return s.f }
+func (s histogramSample) H() *histogram.Histogram       { return nil }
+func (s histogramSample) FH() *histogram.FloatHistogram { return s.fh }
+
+func (s histogramSample) Type() chunkenc.ValueType {
+	if s.fh != nil {
+		return chunkenc.ValFloatHistogram
+	}
+	return chunkenc.ValFloat
+}
@@ -0,0 +1,176 @@
+package querier
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// NonFiniteValuesLimits is the limits of the non-finite values engine.
+type NonFiniteValuesLimits interface {
+	QueryNonFiniteValuesPolicy(userID string) string
+}
+
+// nonFiniteValuesEngine applies the non-finite values policy of the tenant to the results of
+// the queries run by engine.
+type nonFiniteValuesEngine struct {
+	engine v1.QueryEngine
+	limits NonFiniteValuesLimits
+
+	filteredValues *prometheus.CounterVec
+}
+
+// NewNonFiniteValuesEngine returns a query engine dropping or clamping the NaN and ±Inf float
+// values of the results of the queries run by engine, as configured by the tenant for the clients
+// whose JSON parsers break on them.
+func NewNonFiniteValuesEngine(engine v1.QueryEngine, limits NonFiniteValuesLimits, reg prometheus.Registerer) v1.QueryEngine {
+	return &nonFiniteValuesEngine{
+		engine: engine,
+		limits: limits,
+		filteredValues: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_querier_non_finite_values_filtered_total",
+			Help: "Total number of NaN and ±Inf values dropped or clamped from the query results, by the non-finite values policy of the tenant.",
+		}, []string{"user", "policy"}),
+	}
+}
+
+func (e *nonFiniteValuesEngine) SetQueryLogger(l promql.QueryLogger) {
+	e.engine.SetQueryLogger(l)
+}
+
+func (e *nonFiniteValuesEngine) NewInstantQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
+	query, err := e.engine.NewInstantQuery(ctx, q, opts, qs, ts)
+	if err != nil {
+		return nil, err
+	}
+	return &nonFiniteValuesQuery{Query: query, engine: e}, nil
+}
+
+func (e *nonFiniteValuesEngine) NewRangeQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	query, err := e.engine.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+	return &nonFiniteValuesQuery{Query: query, engine: e}, nil
+}
+
+// policy returns the policy of the tenants of the query. The strictest policy wins for the
+// queries federating several tenants.
+func (e *nonFiniteValuesEngine) policy(ctx context.Context) (string, string) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return "", validation.NonFiniteValuesPolicyKeep
+	}
+
+	policy := validation.NonFiniteValuesPolicyKeep
+	for _, tenantID := range tenantIDs {
+		switch e.limits.QueryNonFiniteValuesPolicy(tenantID) {
+		case validation.NonFiniteValuesPolicyDrop:
+			policy = validation.NonFiniteValuesPolicyDrop
+		case validation.NonFiniteValuesPolicyClamp:
+			if policy == validation.NonFiniteValuesPolicyKeep {
+				policy = validation.NonFiniteValuesPolicyClamp
+			}
+		}
+	}
+	return tenant.JoinTenantIDs(tenantIDs), policy
+}
+
+type nonFiniteValuesQuery struct {
+	promql.Query
+	engine *nonFiniteValuesEngine
+}
+
+func (q *nonFiniteValuesQuery) Exec(ctx context.Context) *promql.Result {
+	res := q.Query.Exec(ctx)
+	if res.Err != nil {
+		return res
+	}
+
+	userID, policy := q.engine.policy(ctx)
+	if policy == validation.NonFiniteValuesPolicyKeep {
+		return res
+	}
+
+	var filtered int
+	res.Value, filtered = filterNonFiniteValues(res.Value, policy)
+	if filtered > 0 {
+		q.engine.filteredValues.WithLabelValues(userID, policy).Add(float64(filtered))
+	}
+	return res
+}
+
+// filterNonFiniteValues applies the policy to the float values of the query result, and returns
+// the filtered result with the number of values dropped or clamped. The histograms are kept as is.
+func filterNonFiniteValues(value parser.Value, policy string) (parser.Value, int) {
+	filtered := 0
+	// filter returns the value to replace f with, and whether f is kept.
+	filter := func(f float64) (float64, bool) {
+		switch {
+		case !math.IsNaN(f) && !math.IsInf(f, 0):
+			return f, true
+		case policy == validation.NonFiniteValuesPolicyClamp && math.IsInf(f, 1):
+			filtered++
+			return math.MaxFloat64, true
+		case policy == validation.NonFiniteValuesPolicyClamp && math.IsInf(f, -1):
+			filtered++
+			return -math.MaxFloat64, true
+		default:
+			filtered++
+			return f, false
+		}
+	}
+
+	switch v := value.(type) {
+	case promql.Vector:
+		kept := v[:0]
+		for _, s := range v {
+			if s.H == nil {
+				var ok bool
+				if s.F, ok = filter(s.F); !ok {
+					continue
+				}
+			}
+			kept = append(kept, s)
+		}
+		return kept, filtered
+
+	case promql.Matrix:
+		kept := v[:0]
+		for _, s := range v {
+			floats := s.Floats[:0]
+			for _, p := range s.Floats {
+				var ok bool
+				if p.F, ok = filter(p.F); ok {
+					floats = append(floats, p)
+				}
+			}
+			s.Floats = floats
+			if len(s.Floats) == 0 && len(s.Histograms) == 0 {
+				continue
+			}
+			kept = append(kept, s)
+		}
+		return kept, filtered
+
+	case promql.Scalar:
+		// A scalar can't lack its value, it's only clamped.
+		if f, ok := filter(v.V); ok {
+			v.V = f
+		} else {
+			filtered--
+		}
+		return v, filtered
+	}
+	return value, filtered
+}
@@ -0,0 +1,178 @@
+package querier
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+type nonFiniteValuesLimitsMock map[string]string
+
+func (m nonFiniteValuesLimitsMock) QueryNonFiniteValuesPolicy(userID string) string {
+	return m[userID]
+}
+
+// staticEngine returns the same result to all the queries.
+type staticEngine struct {
+	value parser.Value
+}
+
+func (e *staticEngine) SetQueryLogger(promql.QueryLogger) {}
+
+func (e *staticEngine) NewInstantQuery(context.Context, storage.Queryable, promql.QueryOpts, string, time.Time) (promql.Query, error) {
+	return &staticQuery{value: e.value}, nil
+}
+
+func (e *staticEngine) NewRangeQuery(context.Context, storage.Queryable, promql.QueryOpts, string, time.Time, time.Time, time.Duration) (promql.Query, error) {
+	return &staticQuery{value: e.value}, nil
+}
+
+type staticQuery struct {
+	promql.Query
+	value parser.Value
+}
+
+func (q *staticQuery) Exec(context.Context) *promql.Result {
+	return &promql.Result{Value: q.value}
+}
+
+func TestNonFiniteValuesEngine(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	h := &histogram.FloatHistogram{Count: 1, Sum: nan}
+
+	vector := func() parser.Value {
+		return promql.Vector{
+			{Metric: labels.FromStrings("i", "0"), T: 1, F: 1},
+			{Metric: labels.FromStrings("i", "1"), T: 1, F: nan},
+			{Metric: labels.FromStrings("i", "2"), T: 1, F: inf},
+			{Metric: labels.FromStrings("i", "3"), T: 1, F: -inf},
+			{Metric: labels.FromStrings("i", "4"), T: 1, H: h},
+		}
+	}
+	matrix := func() parser.Value {
+		return promql.Matrix{
+			{Metric: labels.FromStrings("i", "0"), Floats: []promql.FPoint{{T: 1, F: 1}, {T: 2, F: nan}, {T: 3, F: inf}, {T: 4, F: -inf}}},
+			{Metric: labels.FromStrings("i", "1"), Floats: []promql.FPoint{{T: 1, F: nan}}},
+			{Metric: labels.FromStrings("i", "2"), Histograms: []promql.HPoint{{T: 1, H: h}}},
+		}
+	}
+
+	tests := map[string]struct {
+		policy           string
+		value            parser.Value
+		expected         parser.Value
+		expectedFiltered int
+	}{
+		"vector, keep": {
+			policy:   validation.NonFiniteValuesPolicyKeep,
+			value:    vector(),
+			expected: vector(),
+		},
+		"vector, drop": {
+			policy: validation.NonFiniteValuesPolicyDrop,
+			value:  vector(),
+			expected: promql.Vector{
+				{Metric: labels.FromStrings("i", "0"), T: 1, F: 1},
+				{Metric: labels.FromStrings("i", "4"), T: 1, H: h},
+			},
+			expectedFiltered: 3,
+		},
+		"vector, clamp": {
+			policy: validation.NonFiniteValuesPolicyClamp,
+			value:  vector(),
+			expected: promql.Vector{
+				{Metric: labels.FromStrings("i", "0"), T: 1, F: 1},
+				{Metric: labels.FromStrings("i", "2"), T: 1, F: math.MaxFloat64},
+				{Metric: labels.FromStrings("i", "3"), T: 1, F: -math.MaxFloat64},
+				{Metric: labels.FromStrings("i", "4"), T: 1, H: h},
+			},
+			expectedFiltered: 3,
+		},
+		"matrix, drop": {
+			policy: validation.NonFiniteValuesPolicyDrop,
+			value:  matrix(),
+			expected: promql.Matrix{
+				{Metric: labels.FromStrings("i", "0"), Floats: []promql.FPoint{{T: 1, F: 1}}},
+				{Metric: labels.FromStrings("i", "2"), Histograms: []promql.HPoint{{T: 1, H: h}}},
+			},
+			expectedFiltered: 4,
+		},
+		"matrix, clamp": {
+			policy: validation.NonFiniteValuesPolicyClamp,
+			value:  matrix(),
+			expected: promql.Matrix{
+				{Metric: labels.FromStrings("i", "0"), Floats: []promql.FPoint{{T: 1, F: 1}, {T: 3, F: math.MaxFloat64}, {T: 4, F: -math.MaxFloat64}}},
+				{Metric: labels.FromStrings("i", "2"), Histograms: []promql.HPoint{{T: 1, H: h}}},
+			},
+			expectedFiltered: 4,
+		},
+		"scalar, drop": {
+			policy:   validation.NonFiniteValuesPolicyDrop,
+			value:    promql.Scalar{T: 1, V: inf},
+			expected: promql.Scalar{T: 1, V: inf},
+		},
+		"scalar, clamp": {
+			policy:           validation.NonFiniteValuesPolicyClamp,
+			value:            promql.Scalar{T: 1, V: -inf},
+			expected:         promql.Scalar{T: 1, V: -math.MaxFloat64},
+			expectedFiltered: 1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			e := NewNonFiniteValuesEngine(&staticEngine{value: tc.value}, nonFiniteValuesLimitsMock{"user-1": tc.policy}, reg)
+
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			q, err := e.NewRangeQuery(ctx, nil, nil, "up", time.Unix(0, 0), time.Unix(60, 0), time.Minute)
+			require.NoError(t, err)
+			res := q.Exec(ctx)
+			require.NoError(t, res.Err)
+
+			// NaN isn't equal to itself, compare the string representations.
+			assert.Equal(t, tc.expected.String(), res.Value.String())
+			assert.Equal(t, float64(tc.expectedFiltered), testutil.ToFloat64(e.(*nonFiniteValuesEngine).filteredValues.WithLabelValues("user-1", tc.policy)))
+		})
+	}
+}
+
+func TestNonFiniteValuesEngine_ShouldApplyTheStrictestPolicyOfTheFederatedTenants(t *testing.T) {
+	// Set a multi tenant resolver.
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() { tenant.WithDefaultResolver(tenant.NewSingleResolver()) })
+
+	reg := prometheus.NewPedanticRegistry()
+	value := promql.Vector{{Metric: labels.FromStrings("i", "0"), T: 1, F: math.Inf(1)}}
+	limits := nonFiniteValuesLimitsMock{"user-1": validation.NonFiniteValuesPolicyClamp, "user-2": validation.NonFiniteValuesPolicyDrop}
+	e := NewNonFiniteValuesEngine(&staticEngine{value: value}, limits, reg)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1|user-2")
+	q, err := e.NewInstantQuery(ctx, nil, nil, "up", time.Unix(0, 0))
+	require.NoError(t, err)
+	res := q.Exec(ctx)
+	require.NoError(t, res.Err)
+	assert.Empty(t, res.Value)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_non_finite_values_filtered_total Total number of NaN and ±Inf values dropped or clamped from the query results, by the non-finite values policy of the tenant.
+		# TYPE cortex_querier_non_finite_values_filtered_total counter
+		cortex_querier_non_finite_values_filtered_total{policy="drop",user="user-1|user-2"} 1
+	`)))
+}
@@ -0,0 +1,60 @@
+package querier
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cortexproject/cortex/pkg/querier/partialresponse"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+// PartialResponseMiddleware overrides the partial response mode of the tenant with the one of the
+// request, read from the header or from the hint forwarded by the query-frontend. The responses
+// missing the results of failed ingesters aren't cached, since they're incomplete.
+func PartialResponseMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hints, err := tripperware.DecodeHints(r.Header)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		if v, ok := hints.Metadata[tripperware.PartialResponseHint]; ok {
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				http.Error(w, "invalid partial response hint: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			ctx = partialresponse.ContextWithEnabled(ctx, enabled)
+		}
+		failures, ctx := partialresponse.ContextWithFailures(ctx)
+
+		next.ServeHTTP(&partialResponseWriter{ResponseWriter: w, failures: failures}, r.WithContext(ctx))
+	})
+}
+
+// partialResponseWriter disables the caching of the partial responses, whose failures are known once
+// the query has been evaluated, before the response is written.
+type partialResponseWriter struct {
+	http.ResponseWriter
+	failures    *partialresponse.Failures
+	wroteHeader bool
+}
+
+func (w *partialResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if len(w.failures.Instances()) > 0 {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *partialResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
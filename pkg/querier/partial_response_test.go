@@ -0,0 +1,75 @@
+package querier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cortexproject/cortex/pkg/querier/partialresponse"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+func TestPartialResponseMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		headers              map[string]string
+		failed               []string
+		expectedStatus       int
+		expectedEnabled      bool
+		expectedOverridden   bool
+		expectedCacheControl string
+	}{
+		"no header": {
+			expectedStatus: http.StatusOK,
+		},
+		"enabled by the header": {
+			headers:            map[string]string{tripperware.PartialResponseHeader: "true"},
+			expectedStatus:     http.StatusOK,
+			expectedEnabled:    true,
+			expectedOverridden: true,
+		},
+		"disabled by the hint forwarded by the query-frontend": {
+			headers:            map[string]string{tripperware.RequestHintsHeader: "partial_response=false"},
+			expectedStatus:     http.StatusOK,
+			expectedOverridden: true,
+		},
+		"invalid header": {
+			headers:        map[string]string{tripperware.PartialResponseHeader: "maybe"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		"partial response isn't cached": {
+			headers:              map[string]string{tripperware.PartialResponseHeader: "true"},
+			failed:               []string{"ingester-1"},
+			expectedStatus:       http.StatusOK,
+			expectedEnabled:      true,
+			expectedOverridden:   true,
+			expectedCacheControl: "no-store",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				enabled, overridden bool
+			)
+			handler := PartialResponseMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				enabled, overridden = partialresponse.EnabledFromContext(r.Context())
+				partialresponse.FailuresFromContext(r.Context()).Add(tc.failed...)
+				_, _ = w.Write([]byte("{}"))
+			}))
+
+			req := httptest.NewRequest("GET", "/api/v1/query_range?query=up&step=60", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.expectedStatus, resp.Code)
+			assert.Equal(t, tc.expectedEnabled, enabled)
+			assert.Equal(t, tc.expectedOverridden, overridden)
+			assert.Equal(t, tc.expectedCacheControl, resp.Header().Get("Cache-Control"))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Package partialresponse carries the partial response mode of the queries, and the ingesters that
+// failed them, between the querier and the distributor.
+package partialresponse
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type contextKey int
+
+const (
+	enabledKey contextKey = iota
+	failuresKey
+)
+
+// ContextWithEnabled overrides the per-tenant partial response mode of the queries run with the returned context.
+func ContextWithEnabled(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, enabledKey, enabled)
+}
+
+// EnabledFromContext returns the partial response mode of the context, if overridden.
+func EnabledFromContext(ctx context.Context) (enabled, ok bool) {
+	enabled, ok = ctx.Value(enabledKey).(bool)
+	return
+}
+
+// Failures collects the ingesters that failed the queries run in partial response mode.
+type Failures struct {
+	parent *Failures
+
+	mtx       sync.Mutex
+	instances map[string]struct{}
+}
+
+// ContextWithFailures returns a context collecting the ingesters that failed the queries run with it.
+// The failures are collected by the failures of the parent context too.
+func ContextWithFailures(ctx context.Context) (*Failures, context.Context) {
+	failures := &Failures{parent: FailuresFromContext(ctx), instances: map[string]struct{}{}}
+	return failures, context.WithValue(ctx, failuresKey, failures)
+}
+
+// FailuresFromContext returns the failures collected by the context, or nil.
+func FailuresFromContext(ctx context.Context) *Failures {
+	failures, _ := ctx.Value(failuresKey).(*Failures)
+	return failures
+}
+
+// Add records the failed ingesters.
+func (f *Failures) Add(instances ...string) {
+	if f == nil {
+		return
+	}
+
+	f.mtx.Lock()
+	for _, instance := range instances {
+		f.instances[instance] = struct{}{}
+	}
+	f.mtx.Unlock()
+
+	f.parent.Add(instances...)
+}
+
+// Instances returns the sorted addresses of the failed ingesters.
+func (f *Failures) Instances() []string {
+	if f == nil {
+		return nil
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	out := make([]string, 0, len(f.instances))
+	for instance := range f.instances {
+		out = append(out, instance)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Warning returns the warning of the partial response, or nil if no ingester failed.
+func (f *Failures) Warning() error {
+	instances := f.Instances()
+	if len(instances) == 0 {
+		return nil
+	}
+	return fmt.Errorf("partial response: the results of the failed ingesters are missing: %s", strings.Join(instances, ", "))
+}
@@ -0,0 +1,34 @@
+package partialresponse
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnabledFromContext(t *testing.T) {
+	_, ok := EnabledFromContext(context.Background())
+	assert.False(t, ok)
+
+	enabled, ok := EnabledFromContext(ContextWithEnabled(context.Background(), true))
+	assert.True(t, ok)
+	assert.True(t, enabled)
+}
+
+func TestFailures(t *testing.T) {
+	parent, ctx := ContextWithFailures(context.Background())
+	child, ctx := ContextWithFailures(ctx)
+	assert.Same(t, child, FailuresFromContext(ctx))
+	assert.NoError(t, child.Warning())
+
+	// The failures are collected by the parent too.
+	FailuresFromContext(ctx).Add("ingester-2", "ingester-1", "ingester-2")
+	assert.Equal(t, []string{"ingester-1", "ingester-2"}, child.Instances())
+	assert.Equal(t, []string{"ingester-1", "ingester-2"}, parent.Instances())
+	assert.EqualError(t, child.Warning(), "partial response: the results of the failed ingesters are missing: ingester-1, ingester-2")
+
+	// The failures aren't collected without a context collecting them.
+	FailuresFromContext(context.Background()).Add("ingester-3")
+	assert.Nil(t, FailuresFromContext(context.Background()).Instances())
+}
@@ -0,0 +1,115 @@
+package querier
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	thanos_downsample "github.com/thanos-io/thanos/pkg/compact/downsample"
+
+	"github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
+)
+
+// preAggregatedSeries are the aggregations of a series pushed at the same resolution.
+type preAggregatedSeries struct {
+	lset         labels.Labels
+	resolution   int64
+	aggregations map[string][]model.SamplePair
+}
+
+// newPreAggregatedSeriesSet converts the pre-aggregated series of the set into the series the
+// function is evaluated on, the same way the aggregated chunks of the downsampled blocks are:
+// the aggregation the function is mapped to is read as is, or the average of the sum and count
+// ones for the other functions. The reserved labels are removed, so that the series
+// are merged with the raw ones with the same labels. A series pushed at several resolutions is
+// only read at the finest one.
+func newPreAggregatedSeriesSet(set storage.SeriesSet, function string) storage.SeriesSet {
+	byLabels := map[string]*preAggregatedSeries{}
+
+	var it chunkenc.Iterator
+	for set.Next() {
+		s := set.At()
+		resolution, err := strconv.ParseInt(s.Labels().Get(downsample.ResolutionLabel), 10, 64)
+		if err != nil {
+			continue
+		}
+		aggregation := s.Labels().Get(downsample.AggregationLabel)
+		lset := labels.NewBuilder(s.Labels()).Del(downsample.ResolutionLabel, downsample.AggregationLabel).Labels()
+
+		key := lset.String()
+		p, ok := byLabels[key]
+		if !ok || resolution < p.resolution {
+			p = &preAggregatedSeries{lset: lset, resolution: resolution, aggregations: map[string][]model.SamplePair{}}
+			byLabels[key] = p
+		} else if resolution > p.resolution {
+			continue
+		}
+
+		var samples []model.SamplePair
+		it = s.Iterator(it)
+		for typ := it.Next(); typ != chunkenc.ValNone; typ = it.Next() {
+			if typ != chunkenc.ValFloat {
+				continue
+			}
+			t, v := it.At()
+			samples = append(samples, model.SamplePair{Timestamp: model.Time(t), Value: model.SampleValue(v)})
+		}
+		if err := it.Err(); err != nil {
+			return storage.ErrSeriesSet(err)
+		}
+		p.aggregations[aggregation] = samples
+	}
+	if err := set.Err(); err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+
+	result := make([]storage.Series, 0, len(byLabels))
+	for _, p := range byLabels {
+		if samples, ok := p.samples(function); ok {
+			result = append(result, series.NewConcreteSeries(p.lset, samples))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return labels.Compare(result[i].Labels(), result[j].Labels()) < 0
+	})
+	return series.NewSeriesSetWithWarnings(series.NewConcreteSeriesSet(false, result), set.Warnings())
+}
+
+// samples returns the samples the function is evaluated on, and false if the aggregations pushed
+// aren't enough to compute them.
+func (p *preAggregatedSeries) samples(function string) ([]model.SamplePair, bool) {
+	aggregations := downsample.AggregationsForFunction(function)
+	if len(aggregations) == 1 {
+		samples, ok := p.aggregations[aggregations[0].String()]
+		return samples, ok
+	}
+
+	sums, hasSum := p.aggregations[thanos_downsample.AggrSum.String()]
+	counts, hasCount := p.aggregations[thanos_downsample.AggrCount.String()]
+	if !hasSum || !hasCount {
+		return nil, false
+	}
+	return averageSamples(sums, counts), true
+}
+
+// averageSamples returns the averages of the sums and counts with the same timestamps.
+func averageSamples(sums, counts []model.SamplePair) []model.SamplePair {
+	averages := make([]model.SamplePair, 0, len(sums))
+	for i, j := 0, 0; i < len(sums) && j < len(counts); {
+		switch {
+		case sums[i].Timestamp < counts[j].Timestamp:
+			i++
+		case sums[i].Timestamp > counts[j].Timestamp:
+			j++
+		default:
+			averages = append(averages, model.SamplePair{Timestamp: sums[i].Timestamp, Value: sums[i].Value / counts[j].Value})
+			i++
+			j++
+		}
+	}
+	return averages
+}
@@ -0,0 +1,140 @@
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/teststorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestQuerier_SelectShouldMergePreAggregatedSeries(t *testing.T) {
+	t.Parallel()
+
+	// The samples are older than the ingesters lookback, so that they're only queried from the store.
+	start := util.TimeToMillis(time.Now().Add(-3 * time.Hour))
+	end := start + time.Hour.Milliseconds()
+	step := 5 * time.Minute.Milliseconds()
+
+	db := teststorage.New(t)
+	t.Cleanup(func() { _ = db.Close() })
+
+	preAggregated := func(resolution, aggregation string) labels.Labels {
+		return labels.FromStrings(labels.MetricName, "foo", "job", "edge", downsample.ResolutionLabel, resolution, downsample.AggregationLabel, aggregation)
+	}
+	app := db.Appender(context.Background())
+	for ts := start; ts < end; ts += step {
+		for _, s := range []struct {
+			lset labels.Labels
+			v    float64
+		}{
+			{labels.FromStrings(labels.MetricName, "foo", "job", "raw"), 1},
+			{preAggregated("300000", "sum"), 10},
+			{preAggregated("300000", "count"), 4},
+			{preAggregated("300000", "max"), 5},
+			{preAggregated("300000", "counter"), 100},
+			{preAggregated("3600000", "max"), 50},
+		} {
+			_, err := app.Append(0, s.lset, ts, s.v)
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, app.Commit())
+
+	cfg := DefaultQuerierConfig()
+	cfg.ActiveQueryTrackerDir = ""
+	cfg.QueryIngestersWithin = time.Hour
+	limits := DefaultLimitsConfig()
+	limits.AcceptPreAggregatedSamples = true
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	queryable, _, _ := New(cfg, overrides, &MockDistributor{}, []QueryableWithFilter{UseAlwaysQueryable(db)}, nil, log.NewNopLogger())
+
+	nameMatcher := labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "foo")
+	for name, tc := range map[string]struct {
+		maxResolution int64
+		function      string
+		matchers      []*labels.Matcher
+		expected      map[string]float64
+	}{
+		"raw data only": {
+			function: "max_over_time",
+			matchers: []*labels.Matcher{nameMatcher},
+			expected: map[string]float64{`{__name__="foo", job="raw"}`: 1},
+		},
+		"aggregation of the function": {
+			maxResolution: 300000,
+			function:      "max_over_time",
+			matchers:      []*labels.Matcher{nameMatcher},
+			expected:      map[string]float64{`{__name__="foo", job="edge"}`: 5, `{__name__="foo", job="raw"}`: 1},
+		},
+		"finest resolution": {
+			maxResolution: 3600000,
+			function:      "max_over_time",
+			matchers:      []*labels.Matcher{nameMatcher},
+			expected:      map[string]float64{`{__name__="foo", job="edge"}`: 5, `{__name__="foo", job="raw"}`: 1},
+		},
+		"counter": {
+			maxResolution: 300000,
+			function:      "rate",
+			matchers:      []*labels.Matcher{nameMatcher},
+			expected:      map[string]float64{`{__name__="foo", job="edge"}`: 100, `{__name__="foo", job="raw"}`: 1},
+		},
+		"average of the sum and count": {
+			maxResolution: 300000,
+			function:      "avg_over_time",
+			matchers:      []*labels.Matcher{nameMatcher},
+			expected:      map[string]float64{`{__name__="foo", job="edge"}`: 2.5, `{__name__="foo", job="raw"}`: 1},
+		},
+		"explicit resolution": {
+			maxResolution: 300000,
+			function:      "max_over_time",
+			matchers:      []*labels.Matcher{nameMatcher, labels.MustNewMatcher(labels.MatchEqual, downsample.ResolutionLabel, "3600000")},
+			expected:      map[string]float64{`{__aggregation__="max", __name__="foo", __resolution__="3600000", job="edge"}`: 50},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := downsample.ContextWithMaxResolution(user.InjectOrgID(context.Background(), "test"), tc.maxResolution)
+			q, err := queryable.Querier(start, end)
+			require.NoError(t, err)
+
+			set := q.Select(ctx, true, &storage.SelectHints{Start: start, End: end, Step: step, Func: tc.function}, tc.matchers...)
+			actual := map[string]float64{}
+			for set.Next() {
+				s := set.At()
+				var samples int
+				it := s.Iterator(nil)
+				for it.Next() != 0 {
+					_, v := it.At()
+					actual[s.Labels().String()] = v
+					samples++
+				}
+				require.NoError(t, it.Err())
+				assert.Equal(t, int((end-start)/step), samples, s.Labels().String())
+			}
+			require.NoError(t, set.Err())
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestAverageSamples(t *testing.T) {
+	sums := []model.SamplePair{{Timestamp: 1, Value: 10}, {Timestamp: 2, Value: 20}, {Timestamp: 4, Value: 40}}
+	counts := []model.SamplePair{{Timestamp: 2, Value: 4}, {Timestamp: 3, Value: 1}, {Timestamp: 4, Value: 8}}
+	assert.Equal(t, []model.SamplePair{{Timestamp: 2, Value: 5}, {Timestamp: 4, Value: 5}}, averageSamples(sums, counts))
+}
@@ -0,0 +1,58 @@
+package querier
+
+import (
+	"fmt"
+
+	"github.com/prometheus/prometheus/model/labels"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/apipb"
+)
+
+// ProtobufCodec encodes the labels, label values and series responses of the Prometheus API as
+// protobuf, when requested by the Accept header of the request, saving the cost of encoding the
+// large responses as JSON. The labels and label values responses are encoded as
+// apipb.LabelsResponse, and the series responses as apipb.SeriesResponse.
+type ProtobufCodec struct{}
+
+// ContentType implements v1.Codec.
+func (ProtobufCodec) ContentType() v1.MIMEType {
+	return v1.MIMEType{Type: "application", SubType: "x-protobuf"}
+}
+
+// CanEncode implements v1.Codec. The other responses can only be encoded as JSON.
+func (ProtobufCodec) CanEncode(resp *v1.Response) bool {
+	switch resp.Data.(type) {
+	case []string, []labels.Labels:
+		return true
+	default:
+		return false
+	}
+}
+
+// Encode implements v1.Codec.
+func (ProtobufCodec) Encode(resp *v1.Response) ([]byte, error) {
+	switch data := resp.Data.(type) {
+	case []string:
+		return (&apipb.LabelsResponse{
+			Status:   string(resp.Status),
+			Data:     data,
+			Warnings: resp.Warnings,
+		}).Marshal()
+
+	case []labels.Labels:
+		series := make([]cortexpb.Metric, 0, len(data))
+		for _, lbls := range data {
+			series = append(series, cortexpb.Metric{Labels: cortexpb.FromLabelsToLabelAdapters(lbls)})
+		}
+		return (&apipb.SeriesResponse{
+			Status:   string(resp.Status),
+			Data:     series,
+			Warnings: resp.Warnings,
+		}).Marshal()
+
+	default:
+		return nil, fmt.Errorf("can't encode the %T response as protobuf", resp.Data)
+	}
+}
@@ -0,0 +1,56 @@
+package querier
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/apipb"
+)
+
+func TestProtobufCodec(t *testing.T) {
+	codec := ProtobufCodec{}
+	assert.Equal(t, "application/x-protobuf", codec.ContentType().String())
+
+	t.Run("labels", func(t *testing.T) {
+		resp := &v1.Response{Status: "success", Data: []string{"env", "job"}, Warnings: []string{"warning"}}
+		require.True(t, codec.CanEncode(resp))
+
+		b, err := codec.Encode(resp)
+		require.NoError(t, err)
+
+		var decoded apipb.LabelsResponse
+		require.NoError(t, decoded.Unmarshal(b))
+		assert.Equal(t, apipb.LabelsResponse{Status: "success", Data: []string{"env", "job"}, Warnings: []string{"warning"}}, decoded)
+	})
+
+	t.Run("series", func(t *testing.T) {
+		series := []labels.Labels{
+			labels.FromStrings("__name__", "up", "job", "a"),
+			labels.FromStrings("__name__", "up", "job", "b"),
+		}
+		resp := &v1.Response{Status: "success", Data: series}
+		require.True(t, codec.CanEncode(resp))
+
+		b, err := codec.Encode(resp)
+		require.NoError(t, err)
+
+		var decoded apipb.SeriesResponse
+		require.NoError(t, decoded.Unmarshal(b))
+		assert.Equal(t, "success", decoded.Status)
+		require.Len(t, decoded.Data, 2)
+		for i, m := range decoded.Data {
+			assert.Equal(t, series[i], cortexpb.FromLabelAdaptersToLabels(m.Labels))
+		}
+	})
+
+	t.Run("other responses", func(t *testing.T) {
+		resp := &v1.Response{Status: "success", Data: &v1.QueryData{ResultType: "vector", Result: promql.Vector{}}}
+		assert.False(t, codec.CanEncode(resp))
+	})
+}
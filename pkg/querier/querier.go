@@ -30,6 +30,8 @@ import (
 	"github.com/cortexproject/cortex/pkg/querier/iterators"
 	"github.com/cortexproject/cortex/pkg/querier/lazyquery"
 	seriesset "github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/querier/serieslimit"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
 	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
@@ -83,12 +85,43 @@ type Config struct {
 	// Experimental. Use https://github.com/thanos-io/promql-engine rather than
 	// the Prometheus query engine.
 	ThanosEngine bool `yaml:"thanos_engine"`
+
+	// Experimental. Serve the rule queries of the rulers over gRPC.
+	RuleEvaluatorEnabled bool `yaml:"rule_evaluator_enabled"`
+
+	// Experimental. Simplify and reorder the matchers of the selectors.
+	OptimizeMatchers bool `yaml:"optimize_matchers"`
+
+	// Experimental. Reject the queries while the process heap exceeds the limit.
+	MaxHeapInuseBytes uint64 `yaml:"max_heap_inuse_bytes"`
+
+	// Experimental. Cap the number of requests executed at the same time.
+	MaxInflightRequests int `yaml:"max_inflight_requests"`
+
+	// Experimental. Keep CPUs for the write path by capping the number of requests executed at the same time.
+	WritePathReservedCPUs int `yaml:"write_path_reserved_cpus"`
+
+	// Experimental. Query the blocks on the store-gateways of the other zones when a store-gateway fails.
+	StoreGatewayZoneFailoverEnabled bool `yaml:"store_gateway_zone_failover_enabled"`
+
+	// Experimental. Don't fail the queries on the recently uploaded blocks not loaded by the store-gateways yet.
+	ConsistencyCheckGracePeriod time.Duration `yaml:"consistency_check_grace_period"`
+
+	// Experimental. How long the queries wait for the running queries of their tenant to complete.
+	TenantQueryQueueTimeout time.Duration `yaml:"tenant_query_queue_timeout"`
+
+	// Experimental. Merge the series streamed by the ingesters as they're received.
+	IngesterStreamingMergeBufferSize int `yaml:"ingester_streaming_merge_buffer_size"`
+
+	AdminQuery  AdminQueryConfig  `yaml:"admin_query"`
+	QueryExport QueryExportConfig `yaml:"query_export"`
 }
 
 var (
 	errBadLookbackConfigs                             = errors.New("bad settings, query_store_after >= query_ingesters_within which can result in queries not being sent")
 	errShuffleShardingLookbackLessThanQueryStoreAfter = errors.New("the shuffle-sharding lookback period should be greater or equal than the configured 'query store after'")
 	errEmptyTimeRange                                 = errors.New("empty time range")
+	errNegativeWritePathReservedCPUs                  = errors.New("the number of CPUs reserved to the write path must be positive or 0")
 )
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
@@ -115,6 +148,17 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.LookbackDelta, "querier.lookback-delta", 5*time.Minute, "Time since the last sample after which a time series is considered stale and ignored by expression evaluations.")
 	f.DurationVar(&cfg.ShuffleShardingIngestersLookbackPeriod, "querier.shuffle-sharding-ingesters-lookback-period", 0, "When distributor's sharding strategy is shuffle-sharding and this setting is > 0, queriers fetch in-memory series from the minimum set of required ingesters, selecting only ingesters which may have received series since 'now - lookback period'. The lookback period should be greater or equal than the configured 'query store after' and 'query ingesters within'. If this setting is 0, queriers always query all ingesters (ingesters shuffle sharding on read path is disabled).")
 	f.BoolVar(&cfg.ThanosEngine, "querier.thanos-engine", false, "Experimental. Use Thanos promql engine https://github.com/thanos-io/promql-engine rather than the Prometheus promql engine.")
+	f.BoolVar(&cfg.RuleEvaluatorEnabled, "querier.rule-evaluator-enabled", false, "Experimental. Serve the Prometheus query API over gRPC, as a remote rule evaluator of the rulers configured with -ruler.remote-evaluation.addresses.")
+	f.BoolVar(&cfg.OptimizeMatchers, "querier.optimize-matchers", false, "Experimental. Simplify the matchers of the selectors before querying the ingesters and the store-gateways: the regex matchers of literal values are rewritten as equality or set matchers, the redundant matchers are removed, and the most selective matchers are evaluated first.")
+	f.Uint64Var(&cfg.MaxHeapInuseBytes, "querier.max-heap-inuse-bytes", 0, "Experimental. Reject the queries with a 503 while the heap in use by the process exceeds this value, so that a query storm can't starve of memory the other components running in the same process, like the distributor and the ingester in single binary mode. The difference with the memory limit of the process is the memory reserved to the other components. 0 to disable.")
+	f.IntVar(&cfg.MaxInflightRequests, "querier.max-inflight-requests", 0, "Experimental. Maximum number of requests, including the metadata ones, the querier executes at the same time, whether received from the query-frontend, the query-scheduler, the rulers or the HTTP API. The other requests wait for a slot. Unlike -querier.max-concurrent, it doesn't depend on the active query tracker, so that setting it below the number of CPUs keeps the remaining ones for the other components running in the same process, like the distributor and the ingester in single binary mode. 0 to disable.")
+	f.IntVar(&cfg.WritePathReservedCPUs, "querier.write-path-reserved-cpus", 0, "Experimental. Number of CPUs, out of GOMAXPROCS, reserved to the other components running in the same process, like the distributor and the ingester in single binary mode. The querier executes at most GOMAXPROCS minus this value requests at the same time, and at least 1, or -querier.max-inflight-requests if lower. It's a concurrency budget, each request being accounted for one CPU, rather than a strict CPU reservation: a request may use more than one CPU while it's executed. 0 to disable.")
+	f.BoolVar(&cfg.StoreGatewayZoneFailoverEnabled, "querier.store-gateway-zone-failover-enabled", false, "Experimental. When the store-gateway zone awareness is enabled, query the blocks on the store-gateways of the other zones when a store-gateway fails to serve them for any reason but the query limits, instead of failing the query. The store-gateways of the zones are attempted up to 3 times in total.")
+	f.DurationVar(&cfg.ConsistencyCheckGracePeriod, "querier.consistency-check-grace-period", 0, "Experimental. Period after the upload of a block, or its discovery in the bucket index, during which the queries don't fail if no store-gateway has loaded the block yet, as long as its samples are still served by the ingesters (within their retention period and -querier.query-ingesters-within), by the raw blocks it has been downsampled from, or by the blocks it has been compacted from. 0 to disable.")
+	f.DurationVar(&cfg.TenantQueryQueueTimeout, "querier.tenant-query-queue-timeout", 10*time.Second, "Experimental. How long a query waits for one of the running queries of its tenant to complete, once the tenant reached the per-tenant -querier.max-concurrent-queries-per-tenant limit, before being rejected. 0 to reject the query immediately.")
+	f.IntVar(&cfg.IngesterStreamingMergeBufferSize, "querier.ingester-streaming-merge-buffer-size", 0, "Experimental. When -querier.ingester-streaming is enabled, merge the series streamed by the ingesters lazily as the query consumes them, instead of receiving all of them before running the query, buffering up to this number of series per ingester on top of the ones of the message being received. Requires the ingesters to support streaming their series sorted. 0 to disable.")
+	cfg.AdminQuery.RegisterFlags(f)
+	cfg.QueryExport.RegisterFlags(f)
 	f.Int64Var(&cfg.MaxSubQuerySteps, "querier.max-subquery-steps", 0, "Max number of steps allowed for every subquery expression in query. Number of steps is calculated using subquery range / step. A value > 0 enables it.")
 }
 
@@ -133,9 +177,39 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if cfg.WritePathReservedCPUs < 0 {
+		return errNegativeWritePathReservedCPUs
+	}
+
+	if err := cfg.StoreGatewayClient.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.AdminQuery.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.QueryExport.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// InflightRequestsLimit returns the max number of requests the querier executes at the same time,
+// given the number of CPUs the process can use, or 0 if unlimited. The CPUs reserved to the write
+// path are taken out of the concurrency budget, each request being accounted for one CPU.
+func (cfg *Config) InflightRequestsLimit(maxProcs int) int {
+	limit := cfg.MaxInflightRequests
+	if cfg.WritePathReservedCPUs > 0 {
+		available := max(1, maxProcs-cfg.WritePathReservedCPUs)
+		if limit <= 0 || available < limit {
+			limit = available
+		}
+	}
+	return limit
+}
+
 func (cfg *Config) GetStoreGatewayAddresses() []string {
 	if cfg.StoreGatewayAddresses == "" {
 		return nil
@@ -157,7 +231,7 @@ func getChunksIteratorFunction(cfg Config) chunkIteratorFunc {
 func New(cfg Config, limits *validation.Overrides, distributor Distributor, stores []QueryableWithFilter, reg prometheus.Registerer, logger log.Logger) (storage.SampleAndChunkQueryable, storage.ExemplarQueryable, v1.QueryEngine) {
 	iteratorFunc := getChunksIteratorFunction(cfg)
 
-	distributorQueryable := newDistributorQueryable(distributor, cfg.IngesterStreaming, cfg.IngesterMetadataStreaming, iteratorFunc, cfg.QueryIngestersWithin, cfg.QueryStoreForLabels)
+	distributorQueryable := newDistributorQueryable(distributor, cfg.IngesterStreaming, cfg.IngesterStreamingMergeBufferSize, cfg.IngesterMetadataStreaming, iteratorFunc, cfg.QueryIngestersWithin, cfg.QueryStoreForLabels)
 
 	ns := make([]QueryableWithFilter, len(stores))
 	for ix, s := range stores {
@@ -166,7 +240,7 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, stor
 			QueryStoreAfter:     cfg.QueryStoreAfter,
 		}
 	}
-	queryable := NewQueryable(distributorQueryable, ns, iteratorFunc, cfg, limits)
+	queryable := NativeHistogramsAsClassicQueryable(NewQueryable(distributorQueryable, ns, iteratorFunc, cfg, limits), limits)
 	exemplarQueryable := newDistributorExemplarQueryable(distributor)
 
 	lazyQueryable := storage.QueryableFunc(func(mint int64, maxt int64) (storage.Querier, error) {
@@ -185,11 +259,45 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, stor
 	})
 	maxConcurrentMetric.Set(float64(cfg.MaxConcurrent))
 
-	var queryEngine v1.QueryEngine
-	opts := promql.EngineOpts{
+	opts := newEngineOpts(cfg, logger)
+	opts.Reg = reg
+	opts.ActiveQueryTracker = createActiveQueryTracker(cfg, logger)
+	return NewSampleAndChunkQueryable(lazyQueryable), exemplarQueryable, newQueryEngine(cfg, opts)
+}
+
+// NewIngestersQueryable returns a queryable reading the series from the ingesters only.
+func NewIngestersQueryable(cfg Config, distributor Distributor) storage.Queryable {
+	return newDistributorQueryable(distributor, cfg.IngesterStreaming, cfg.IngesterStreamingMergeBufferSize, cfg.IngesterMetadataStreaming, getChunksIteratorFunction(cfg), cfg.QueryIngestersWithin, cfg.QueryStoreForLabels)
+}
+
+// NewStoresQueryable returns a queryable reading the series from the given stores only, or nil
+// if there is no store.
+func NewStoresQueryable(stores []QueryableWithFilter) storage.Queryable {
+	if len(stores) == 0 {
+		return nil
+	}
+
+	return storage.QueryableFunc(func(mint int64, maxt int64) (storage.Querier, error) {
+		queriers := make([]storage.Querier, 0, len(stores))
+		for _, s := range stores {
+			q, err := s.Querier(mint, maxt)
+			if err != nil {
+				for _, q := range queriers {
+					_ = q.Close()
+				}
+				return nil, err
+			}
+			queriers = append(queriers, q)
+		}
+		return storage.NewMergeQuerier(queriers, nil, storage.ChainedSeriesMerge), nil
+	})
+}
+
+// newEngineOpts returns the promql engine options of the querier, without registering the
+// engine metrics nor tracking the active queries.
+func newEngineOpts(cfg Config, logger log.Logger) promql.EngineOpts {
+	return promql.EngineOpts{
 		Logger:               logger,
-		Reg:                  reg,
-		ActiveQueryTracker:   createActiveQueryTracker(cfg, logger),
 		MaxSamples:           cfg.MaxSamples,
 		Timeout:              cfg.Timeout,
 		LookbackDelta:        cfg.LookbackDelta,
@@ -200,15 +308,29 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, stor
 			return cfg.DefaultEvaluationInterval.Milliseconds()
 		},
 	}
+}
+
+// NewQueryEngineWithLimits returns a PromQL engine configured like the querier one, except for
+// the max samples and timeout when greater than zero. The engine metrics aren't registered.
+func NewQueryEngineWithLimits(cfg Config, maxSamples int, timeout time.Duration, logger log.Logger) v1.QueryEngine {
+	opts := newEngineOpts(cfg, logger)
+	if maxSamples > 0 {
+		opts.MaxSamples = maxSamples
+	}
+	if timeout > 0 {
+		opts.Timeout = timeout
+	}
+	return newQueryEngine(cfg, opts)
+}
+
+func newQueryEngine(cfg Config, opts promql.EngineOpts) v1.QueryEngine {
 	if cfg.ThanosEngine {
-		queryEngine = engine.New(engine.Opts{
+		return engine.New(engine.Opts{
 			EngineOpts:        opts,
 			LogicalOptimizers: logicalplan.AllOptimizers,
 		})
-	} else {
-		queryEngine = promql.NewEngine(opts)
 	}
-	return NewSampleAndChunkQueryable(lazyQueryable), exemplarQueryable, queryEngine
+	return promql.NewEngine(opts)
 }
 
 // NewSampleAndChunkQueryable creates a SampleAndChunkQueryable from a
@@ -260,6 +382,7 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 			limits:              limits,
 			maxQueryIntoFuture:  cfg.MaxQueryIntoFuture,
 			queryStoreForLabels: cfg.QueryStoreForLabels,
+			optimizeMatchers:    cfg.OptimizeMatchers,
 			distributor:         distributor,
 			stores:              stores,
 			limiterHolder:       &limiterHolder{},
@@ -277,6 +400,7 @@ type querier struct {
 	limits              *validation.Overrides
 	maxQueryIntoFuture  time.Duration
 	queryStoreForLabels bool
+	optimizeMatchers    bool
 	distributor         QueryableWithFilter
 	stores              []QueryableWithFilter
 	limiterHolder       *limiterHolder
@@ -289,7 +413,7 @@ func (q querier) setupFromCtx(ctx context.Context) (context.Context, string, int
 	}
 
 	q.limiterHolder.limiterInitializer.Do(func() {
-		q.limiterHolder.limiter = limiter.NewQueryLimiter(q.limits.MaxFetchedSeriesPerQuery(userID), q.limits.MaxFetchedChunkBytesPerQuery(userID), q.limits.MaxChunksPerQuery(userID), q.limits.MaxFetchedDataBytesPerQuery(userID))
+		q.limiterHolder.limiter = limiter.NewQueryLimiter(q.limits.MaxFetchedSeriesPerQuery(userID), q.limits.MaxFetchedChunkBytesPerQuery(userID), limiter.MaxChunksPerQueryFromContext(ctx, q.limits.MaxChunksPerQuery(userID)), q.limits.MaxFetchedDataBytesPerQuery(userID))
 	})
 
 	ctx = limiter.AddQueryLimiterToContext(ctx, q.limiterHolder.limiter)
@@ -328,6 +452,42 @@ func (q querier) setupFromCtx(ctx context.Context) (context.Context, string, int
 // Select implements storage.Querier interface.
 // The bool passed is ignored because the series is always sorted.
 func (q querier) Select(ctx context.Context, sortSeries bool, sp *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	// The series are limited to the first ones by labels, so that the subset is deterministic.
+	if limit := serieslimit.LimitFromContext(ctx); limit > 0 {
+		return newSeriesLimitSeriesSet(q.selectSeries(ctx, true, sp, matchers...), limit, serieslimit.TruncationFromContext(ctx))
+	}
+	return q.selectSeries(ctx, sortSeries, sp, matchers...)
+}
+
+// selectSeries selects the raw series of the tenant, merged with its pre-aggregated series when the
+// query is allowed to read downsampled data, unless the matchers select a resolution explicitly.
+func (q querier) selectSeries(ctx context.Context, sortSeries bool, sp *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	if !q.limits.AcceptPreAggregatedSamples(userID) {
+		return q.selectMatchingSeries(ctx, sortSeries, sp, matchers...)
+	}
+
+	rawMatchers := downsample.RawSeriesMatchers(matchers)
+	if sp == nil || sp.Func == "series" {
+		return q.selectMatchingSeries(ctx, sortSeries, sp, rawMatchers...)
+	}
+	preAggregatedMatchers, ok := downsample.PreAggregatedSeriesMatchers(matchers, downsample.MaxResolutionFromContext(ctx), sp.Func)
+	if !ok {
+		return q.selectMatchingSeries(ctx, sortSeries, sp, rawMatchers...)
+	}
+
+	// Both selections are sorted to be merged. The hints are copied since they're updated with the
+	// validated time range.
+	preAggregatedHints := *sp
+	raw := q.selectMatchingSeries(ctx, true, sp, rawMatchers...)
+	preAggregated := newPreAggregatedSeriesSet(q.selectMatchingSeries(ctx, true, &preAggregatedHints, preAggregatedMatchers...), sp.Func)
+	return storage.NewMergeSeriesSet([]storage.SeriesSet{raw, preAggregated}, storage.ChainedSeriesMerge)
+}
+
+func (q querier) selectMatchingSeries(ctx context.Context, sortSeries bool, sp *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
 	ctx, userID, mint, maxt, metadataQuerier, queriers, err := q.setupFromCtx(ctx)
 	if err == errEmptyTimeRange {
 		return storage.EmptySeriesSet()
@@ -338,6 +498,10 @@ func (q querier) Select(ctx context.Context, sortSeries bool, sp *storage.Select
 	log, ctx := spanlogger.New(ctx, "querier.Select")
 	defer log.Span.Finish()
 
+	if q.optimizeMatchers {
+		matchers = optimizeMatchers(matchers)
+	}
+
 	if sp != nil {
 		level.Debug(log).Log("start", util.TimeFromMillis(sp.Start).UTC().String(), "end", util.TimeFromMillis(sp.End).UTC().String(), "step", sp.Step, "matchers", matchers)
 	}
@@ -424,12 +588,17 @@ func (q querier) Select(ctx context.Context, sortSeries bool, sp *storage.Select
 
 // LabelValues implements storage.Querier.
 func (q querier) LabelValues(ctx context.Context, name string, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
-	ctx, _, _, _, metadataQuerier, queriers, err := q.setupFromCtx(ctx)
+	ctx, userID, _, _, metadataQuerier, queriers, err := q.setupFromCtx(ctx)
 	if err == errEmptyTimeRange {
 		return nil, nil, nil
 	} else if err != nil {
 		return nil, nil, err
 	}
+
+	// The pre-aggregated series are filtered out even without matchers, so that the values of the
+	// labels only carried by them aren't returned.
+	matchers = q.rawSeriesMatchers(userID, matchers)
+
 	if !q.queryStoreForLabels {
 		return metadataQuerier.LabelValues(ctx, name, matchers...)
 	}
@@ -473,13 +642,17 @@ func (q querier) LabelValues(ctx context.Context, name string, matchers ...*labe
 }
 
 func (q querier) LabelNames(ctx context.Context, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
-	ctx, _, _, _, metadataQuerier, queriers, err := q.setupFromCtx(ctx)
+	ctx, userID, _, _, metadataQuerier, queriers, err := q.setupFromCtx(ctx)
 	if err == errEmptyTimeRange {
 		return nil, nil, nil
 	} else if err != nil {
 		return nil, nil, err
 	}
 
+	// The pre-aggregated series are filtered out even without matchers, so that the names of the
+	// labels only carried by them aren't returned.
+	matchers = q.rawSeriesMatchers(userID, matchers)
+
 	if !q.queryStoreForLabels {
 		return metadataQuerier.LabelNames(ctx, matchers...)
 	}
@@ -522,6 +695,16 @@ func (q querier) LabelNames(ctx context.Context, matchers ...*labels.Matcher) ([
 	return strutil.MergeSlices(sets...), warnings, nil
 }
 
+// rawSeriesMatchers returns the matchers excluding the pre-aggregated series of the tenant, which
+// are only returned as is when explicitly selected, otherwise they would be double counted with the
+// raw samples they've been computed from.
+func (q querier) rawSeriesMatchers(userID string, matchers []*labels.Matcher) []*labels.Matcher {
+	if !q.limits.AcceptPreAggregatedSamples(userID) {
+		return matchers
+	}
+	return downsample.RawSeriesMatchers(matchers)
+}
+
 func (querier) Close() error {
 	return nil
 }
@@ -33,6 +33,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/prom1/storage/metric"
 	"github.com/cortexproject/cortex/pkg/querier/batch"
 	"github.com/cortexproject/cortex/pkg/querier/iterators"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
 	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
@@ -221,8 +222,8 @@ func TestShouldSortSeriesIfQueryingMultipleQueryables(t *testing.T) {
 
 	distributor.On("QueryStream", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&unorderedResponse, nil)
 	distributor.On("Query", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(unorderedResponseMatrix, nil)
-	distributorQueryableStreaming := newDistributorQueryable(distributor, true, cfg.IngesterMetadataStreaming, batch.NewChunkMergeIterator, cfg.QueryIngestersWithin, cfg.QueryStoreForLabels)
-	distributorQueryable := newDistributorQueryable(distributor, false, cfg.IngesterMetadataStreaming, batch.NewChunkMergeIterator, cfg.QueryIngestersWithin, cfg.QueryStoreForLabels)
+	distributorQueryableStreaming := newDistributorQueryable(distributor, true, 0, cfg.IngesterMetadataStreaming, batch.NewChunkMergeIterator, cfg.QueryIngestersWithin, cfg.QueryStoreForLabels)
+	distributorQueryable := newDistributorQueryable(distributor, false, 0, cfg.IngesterMetadataStreaming, batch.NewChunkMergeIterator, cfg.QueryIngestersWithin, cfg.QueryStoreForLabels)
 
 	tCases := []struct {
 		name                 string
@@ -407,7 +408,7 @@ func TestLimits(t *testing.T) {
 		response: &streamResponse,
 	}
 
-	distributorQueryableStreaming := newDistributorQueryable(distributor, true, cfg.IngesterMetadataStreaming, batch.NewChunkMergeIterator, cfg.QueryIngestersWithin, cfg.QueryStoreForLabels)
+	distributorQueryableStreaming := newDistributorQueryable(distributor, true, 0, cfg.IngesterMetadataStreaming, batch.NewChunkMergeIterator, cfg.QueryIngestersWithin, cfg.QueryStoreForLabels)
 
 	tCases := []struct {
 		name                 string
@@ -1179,6 +1180,61 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryLookback(t *testing.T) {
 	}
 }
 
+func TestQuerier_LabelsShouldExcludePreAggregatedSeries(t *testing.T) {
+	t.Parallel()
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	jobMatcher := labels.MustNewMatcher(labels.MatchEqual, "job", "api")
+	rawMatcher := labels.MustNewMatcher(labels.MatchEqual, downsample.ResolutionLabel, "")
+
+	for _, acceptPreAggregated := range []bool{true, false} {
+		acceptPreAggregated := acceptPreAggregated
+		t.Run(fmt.Sprintf("accept pre-aggregated samples=%t", acceptPreAggregated), func(t *testing.T) {
+			t.Parallel()
+
+			cfg := DefaultQuerierConfig()
+			cfg.ActiveQueryTrackerDir = ""
+			limits := DefaultLimitsConfig()
+			limits.AcceptPreAggregatedSamples = acceptPreAggregated
+			overrides, err := validation.NewOverrides(limits, nil)
+			require.NoError(t, err)
+
+			// The pre-aggregated series are filtered out with or without matchers.
+			var withoutMatchers []*labels.Matcher
+			withMatchers := []*labels.Matcher{jobMatcher}
+			if acceptPreAggregated {
+				withoutMatchers = []*labels.Matcher{rawMatcher}
+				withMatchers = []*labels.Matcher{jobMatcher, rawMatcher}
+			}
+
+			distributor := &MockDistributor{}
+			if !acceptPreAggregated {
+				distributor.On("LabelNames", mock.Anything, mock.Anything, mock.Anything).Return([]string{labels.MetricName, "job"}, nil)
+			}
+			for _, matchers := range [][]*labels.Matcher{withoutMatchers, withMatchers} {
+				if len(matchers) > 0 {
+					distributor.On("MetricsForLabelMatchers", mock.Anything, mock.Anything, mock.Anything, matchers).Return([]metric.Metric{}, nil)
+				}
+				distributor.On("LabelValuesForLabelName", mock.Anything, mock.Anything, mock.Anything, model.LabelName(labels.MetricName), matchers).Return([]string{}, nil)
+			}
+
+			queryable, _, _ := New(cfg, overrides, distributor, nil, nil, log.NewNopLogger())
+			q, err := queryable.Querier(0, util.TimeToMillis(time.Now()))
+			require.NoError(t, err)
+
+			_, _, err = q.LabelNames(ctx)
+			require.NoError(t, err)
+			_, _, err = q.LabelValues(ctx, labels.MetricName)
+			require.NoError(t, err)
+			_, _, err = q.LabelNames(ctx, jobMatcher)
+			require.NoError(t, err)
+			_, _, err = q.LabelValues(ctx, labels.MetricName, jobMatcher)
+			require.NoError(t, err)
+			distributor.AssertExpectations(t)
+		})
+	}
+}
+
 // Test max query length limit works with new validateQueryTimeRange function.
 func TestValidateMaxQueryLength(t *testing.T) {
 	t.Parallel()
@@ -1291,6 +1347,9 @@ func (m *errDistributor) Query(ctx context.Context, from, to model.Time, matcher
 func (m *errDistributor) QueryStream(ctx context.Context, from, to model.Time, matchers ...*labels.Matcher) (*client.QueryStreamResponse, error) {
 	return nil, errDistributorError
 }
+func (m *errDistributor) QueryStreamSeries(ctx context.Context, from, to model.Time, bufferSize int, matchers ...*labels.Matcher) (client.QueryStreamSeriesSet, error) {
+	return nil, errDistributorError
+}
 func (m *errDistributor) QueryExemplars(ctx context.Context, from, to model.Time, matchers ...[]*labels.Matcher) (*client.ExemplarQueryResponse, error) {
 	return nil, errDistributorError
 }
@@ -1317,6 +1376,10 @@ func (m *errDistributor) MetricsMetadata(ctx context.Context) ([]scrape.MetricMe
 	return nil, errDistributorError
 }
 
+func (m *errDistributor) TSDBStatus(ctx context.Context, limit int) (*client.TSDBStatusResponse, error) {
+	return nil, errDistributorError
+}
+
 type emptyChunkStore struct {
 	sync.Mutex
 	called bool
@@ -1345,6 +1408,17 @@ func (d *emptyDistributor) QueryStream(ctx context.Context, from, to model.Time,
 	return &client.QueryStreamResponse{}, nil
 }
 
+func (d *emptyDistributor) QueryStreamSeries(ctx context.Context, from, to model.Time, bufferSize int, matchers ...*labels.Matcher) (client.QueryStreamSeriesSet, error) {
+	return emptyQueryStreamSeriesSet{}, nil
+}
+
+type emptyQueryStreamSeriesSet struct{}
+
+func (emptyQueryStreamSeriesSet) Next() bool                   { return false }
+func (emptyQueryStreamSeriesSet) At() client.QueryStreamSeries { return client.QueryStreamSeries{} }
+func (emptyQueryStreamSeriesSet) Err() error                   { return nil }
+func (emptyQueryStreamSeriesSet) Close()                       {}
+
 func (d *emptyDistributor) QueryExemplars(ctx context.Context, from, to model.Time, matchers ...[]*labels.Matcher) (*client.ExemplarQueryResponse, error) {
 	return nil, nil
 }
@@ -1377,6 +1451,10 @@ func (d *emptyDistributor) MetricsMetadata(ctx context.Context) ([]scrape.Metric
 	return nil, nil
 }
 
+func (d *emptyDistributor) TSDBStatus(ctx context.Context, limit int) (*client.TSDBStatusResponse, error) {
+	return &client.TSDBStatusResponse{}, nil
+}
+
 type mockStore interface {
 	Get(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]chunk.Chunk, error)
 }
@@ -0,0 +1,489 @@
+package querier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	// QueryExportsPrefix is the prefix of the query exports objects in the tenant bucket.
+	QueryExportsPrefix = "query-exports"
+
+	queryExportStatusFilename = "status.json"
+
+	queryExportFormatJSON = "json"
+	queryExportFormatCSV  = "csv"
+
+	queryExportStateRunning   = "running"
+	queryExportStateSucceeded = "succeeded"
+	queryExportStateFailed    = "failed"
+)
+
+var (
+	errQueryExportNotFound        = errors.New("query export not found")
+	errTooManyRunningQueryExports = errors.New("too many query exports running, try again later")
+)
+
+// QueryExportConfig configures the query export API, running queries in the background and
+// writing their result to the tenant bucket.
+type QueryExportConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Timeout       time.Duration `yaml:"timeout"`
+	MaxSamples    int           `yaml:"max_samples"`
+	MaxConcurrent int           `yaml:"max_concurrent"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *QueryExportConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "querier.query-export.enabled", false, "Experimental: Enable the query export API (/api/v1/query_exports), running a query in the background and writing its result in JSON or CSV to the tenant bucket. It's meant for the reporting queries exceeding the interactive query limits.")
+	f.DurationVar(&cfg.Timeout, "querier.query-export.timeout", time.Hour, "The timeout of an exported query.")
+	f.IntVar(&cfg.MaxSamples, "querier.query-export.max-samples", 500e6, "Maximum number of samples an exported query can load into memory.")
+	f.IntVar(&cfg.MaxConcurrent, "querier.query-export.max-concurrent", 2, "Maximum number of exported queries running concurrently in a querier. Exports submitted beyond it are rejected.")
+}
+
+// Validate validates the config.
+func (cfg *QueryExportConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Timeout <= 0 {
+		return errors.New("the query export timeout must be greater than 0")
+	}
+	if cfg.MaxConcurrent <= 0 {
+		return errors.New("the query export max concurrent must be greater than 0")
+	}
+	return nil
+}
+
+// NewQueryExportEngine returns the promql engine running the exported queries, with the
+// query export timeout and max samples rather than the interactive ones.
+func NewQueryExportEngine(cfg Config, logger log.Logger) v1.QueryEngine {
+	opts := newEngineOpts(cfg, logger)
+	opts.Timeout = cfg.QueryExport.Timeout
+	opts.MaxSamples = cfg.QueryExport.MaxSamples
+	return newQueryEngine(cfg, opts)
+}
+
+// queryExport is the status of a query export, stored in the tenant bucket alongside its result.
+type queryExport struct {
+	ID     string `json:"id"`
+	Query  string `json:"query"`
+	Format string `json:"format"`
+
+	// Evaluation time of the instant queries, or range of the range queries, in milliseconds.
+	Time  int64 `json:"time,omitempty"`
+	Start int64 `json:"start,omitempty"`
+	End   int64 `json:"end,omitempty"`
+	Step  int64 `json:"step,omitempty"`
+
+	State       string     `json:"state"`
+	Error       string     `json:"error,omitempty"`
+	SubmittedAt time.Time  `json:"submittedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+func (e *queryExport) isRange() bool {
+	return e.Step > 0
+}
+
+func (e *queryExport) resultFilename() string {
+	return "result." + e.Format
+}
+
+type queryExportResponse struct {
+	Status string       `json:"status"`
+	Data   *queryExport `json:"data,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// QueryExporter runs the exported queries in the background, writing their status and result
+// to the tenant bucket, so that they can be fetched from any querier.
+type QueryExporter struct {
+	cfg         QueryExportConfig
+	engine      v1.QueryEngine
+	queryable   storage.Queryable
+	bucket      objstore.Bucket
+	cfgProvider bucket.TenantConfigProvider
+	logger      log.Logger
+
+	// Semaphore bounding the number of exports running concurrently.
+	running chan struct{}
+
+	exportsRunning prometheus.Gauge
+	exportsTotal   *prometheus.CounterVec
+}
+
+// NewQueryExporter makes a new QueryExporter.
+func NewQueryExporter(cfg QueryExportConfig, engine v1.QueryEngine, queryable storage.Queryable, bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) *QueryExporter {
+	return &QueryExporter{
+		cfg:         cfg,
+		engine:      engine,
+		queryable:   queryable,
+		bucket:      bkt,
+		cfgProvider: cfgProvider,
+		logger:      logger,
+		running:     make(chan struct{}, cfg.MaxConcurrent),
+		exportsRunning: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_querier_query_exports_running",
+			Help: "Number of query exports currently running.",
+		}),
+		exportsTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_querier_query_exports_total",
+			Help: "Total number of query exports run, by state.",
+		}, []string{"state"}),
+	}
+}
+
+// SubmitHandler returns a handler starting the export of the query in the "query" parameter,
+// as an instant query at "time" or a range query if "step" is set. The result is written in
+// the "format" parameter, json by default.
+func (e *QueryExporter) SubmitHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := tenant.TenantID(r.Context())
+		if err != nil {
+			writeQueryExportError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		export, err := parseQueryExport(r, time.Now())
+		if err != nil {
+			writeQueryExportError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		select {
+		case e.running <- struct{}{}:
+		default:
+			writeQueryExportError(w, http.StatusTooManyRequests, errTooManyRunningQueryExports)
+			return
+		}
+
+		userBkt := bucket.NewUserBucketClient(tenantID, e.bucket, e.cfgProvider)
+		if err := writeQueryExportStatus(r.Context(), userBkt, export); err != nil {
+			<-e.running
+			writeQueryExportError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		level.Info(util_log.WithContext(r.Context(), e.logger)).Log("msg", "query export submitted", "id", export.ID, "query", export.Query)
+		// The export is copied, since it's updated once it has run.
+		go e.run(tenantID, userBkt, *export)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		util.WriteJSONResponse(w, queryExportResponse{Status: statusSuccess, Data: export})
+	})
+}
+
+// StatusHandler returns a handler returning the status of the query export with the "id"
+// path variable.
+func (e *QueryExporter) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		export, _, code, err := e.readExport(r)
+		if err != nil {
+			writeQueryExportError(w, code, err)
+			return
+		}
+
+		util.WriteJSONResponse(w, queryExportResponse{Status: statusSuccess, Data: export})
+	})
+}
+
+// ResultHandler returns a handler returning the result of the succeeded query export with
+// the "id" path variable.
+func (e *QueryExporter) ResultHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		export, userBkt, code, err := e.readExport(r)
+		if err != nil {
+			writeQueryExportError(w, code, err)
+			return
+		}
+		if export.State != queryExportStateSucceeded {
+			writeQueryExportError(w, http.StatusConflict, errors.Errorf("query export is %s", export.State))
+			return
+		}
+
+		reader, err := userBkt.Get(r.Context(), path.Join(QueryExportsPrefix, export.ID, export.resultFilename()))
+		if err != nil {
+			writeQueryExportError(w, http.StatusInternalServerError, errors.Wrap(err, "read query export result"))
+			return
+		}
+		defer reader.Close() //nolint:errcheck
+
+		if export.Format == queryExportFormatCSV {
+			w.Header().Set("Content-Type", "text/csv")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		_, _ = io.Copy(w, reader)
+	})
+}
+
+// readExport reads the status of the query export requested by r, returning the HTTP status
+// code and error to reply with if it can't.
+func (e *QueryExporter) readExport(r *http.Request) (*queryExport, objstore.Bucket, int, error) {
+	tenantID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		return nil, nil, http.StatusUnauthorized, err
+	}
+
+	// The ID is part of the object path, so it must be validated.
+	id, err := ulid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		return nil, nil, http.StatusNotFound, errQueryExportNotFound
+	}
+
+	userBkt := bucket.NewUserBucketClient(tenantID, e.bucket, e.cfgProvider)
+	export, err := readQueryExportStatus(r.Context(), userBkt, id.String())
+	if errors.Is(err, errQueryExportNotFound) {
+		return nil, nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+	return export, userBkt, 0, nil
+}
+
+func (e *QueryExporter) run(tenantID string, userBkt objstore.Bucket, export queryExport) {
+	defer func() { <-e.running }()
+	e.exportsRunning.Inc()
+	defer e.exportsRunning.Dec()
+
+	logger := log.With(e.logger, "org_id", tenantID, "id", export.ID)
+
+	// The exported queries aren't subject to the per-query limits of the interactive queries,
+	// since these are set on the request context.
+	ctx, cancel := context.WithTimeout(user.InjectOrgID(context.Background(), tenantID), e.cfg.Timeout)
+	defer cancel()
+
+	err := e.export(ctx, userBkt, &export)
+	finishedAt := time.Now()
+	export.FinishedAt = &finishedAt
+	if err != nil {
+		export.State = queryExportStateFailed
+		export.Error = err.Error()
+		level.Warn(logger).Log("msg", "query export failed", "err", err)
+	} else {
+		export.State = queryExportStateSucceeded
+		level.Info(logger).Log("msg", "query export succeeded", "duration", finishedAt.Sub(export.SubmittedAt))
+	}
+	e.exportsTotal.WithLabelValues(export.State).Inc()
+
+	// The status is written even if the export timed out.
+	if err := writeQueryExportStatus(user.InjectOrgID(context.Background(), tenantID), userBkt, &export); err != nil {
+		level.Error(logger).Log("msg", "failed to write query export status", "err", err)
+	}
+}
+
+func (e *QueryExporter) export(ctx context.Context, userBkt objstore.Bucket, export *queryExport) error {
+	var (
+		q   promql.Query
+		err error
+	)
+	if export.isRange() {
+		q, err = e.engine.NewRangeQuery(ctx, e.queryable, nil, export.Query, util.TimeFromMillis(export.Start), util.TimeFromMillis(export.End), time.Duration(export.Step)*time.Millisecond)
+	} else {
+		q, err = e.engine.NewInstantQuery(ctx, e.queryable, nil, export.Query, util.TimeFromMillis(export.Time))
+	}
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return res.Err
+	}
+
+	var buf bytes.Buffer
+	if export.Format == queryExportFormatCSV {
+		err = writeQueryResultCSV(&buf, res.Value)
+	} else {
+		err = json.NewEncoder(&buf).Encode(v1.QueryData{ResultType: res.Value.Type(), Result: res.Value})
+	}
+	if err != nil {
+		return errors.Wrap(err, "encode query export result")
+	}
+
+	return errors.Wrap(userBkt.Upload(ctx, path.Join(QueryExportsPrefix, export.ID, export.resultFilename()), &buf), "upload query export result")
+}
+
+// parseQueryExport parses the export submitted by r.
+func parseQueryExport(r *http.Request, now time.Time) (*queryExport, error) {
+	export := &queryExport{
+		ID:          ulid.MustNew(ulid.Timestamp(now), rand.Reader).String(),
+		Query:       r.FormValue("query"),
+		Format:      r.FormValue("format"),
+		State:       queryExportStateRunning,
+		SubmittedAt: now,
+	}
+
+	if export.Query == "" {
+		return nil, errors.New("missing query")
+	}
+	if _, err := parser.ParseExpr(export.Query); err != nil {
+		return nil, err
+	}
+
+	switch export.Format {
+	case "":
+		export.Format = queryExportFormatJSON
+	case queryExportFormatJSON, queryExportFormatCSV:
+	default:
+		return nil, errors.Errorf("unsupported format %q, supported formats are %s and %s", export.Format, queryExportFormatJSON, queryExportFormatCSV)
+	}
+
+	var err error
+	if r.FormValue("step") == "" {
+		export.Time = util.TimeToMillis(now)
+		if t := r.FormValue("time"); t != "" {
+			if export.Time, err = util.ParseTime(t); err != nil {
+				return nil, err
+			}
+		}
+		return export, nil
+	}
+
+	if export.Start, err = util.ParseTime(r.FormValue("start")); err != nil {
+		return nil, errors.Wrap(err, "invalid start")
+	}
+	if export.End, err = util.ParseTime(r.FormValue("end")); err != nil {
+		return nil, errors.Wrap(err, "invalid end")
+	}
+	if export.End < export.Start {
+		return nil, errors.New("end timestamp must not be before start time")
+	}
+	if export.Step, err = parseStepMs(r.FormValue("step")); err != nil {
+		return nil, err
+	}
+	if export.Step <= 0 {
+		return nil, errors.New("zero or negative query resolution step widths are not accepted. Try a positive integer")
+	}
+	return export, nil
+}
+
+func parseStepMs(s string) (int64, error) {
+	if d, err := strconv.ParseFloat(s, 64); err == nil {
+		return int64(d * float64(time.Second/time.Millisecond)), nil
+	}
+	if d, err := model.ParseDuration(s); err == nil {
+		return time.Duration(d).Milliseconds(), nil
+	}
+	return 0, errors.Errorf("cannot parse %q to a valid duration", s)
+}
+
+// writeQueryResultCSV writes the query result as CSV, with a row per sample.
+func writeQueryResultCSV(w io.Writer, value parser.Value) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"series", "timestamp", "value"}); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case promql.Matrix:
+		for _, s := range v {
+			series := s.Metric.String()
+			for _, p := range s.Floats {
+				if err := writeCSVSample(cw, series, p.T, p.F, nil); err != nil {
+					return err
+				}
+			}
+			for _, p := range s.Histograms {
+				if err := writeCSVSample(cw, series, p.T, 0, p.H); err != nil {
+					return err
+				}
+			}
+		}
+	case promql.Vector:
+		for _, s := range v {
+			if err := writeCSVSample(cw, s.Metric.String(), s.T, s.F, s.H); err != nil {
+				return err
+			}
+		}
+	case promql.Scalar:
+		if err := writeCSVSample(cw, "", v.T, v.V, nil); err != nil {
+			return err
+		}
+	case promql.String:
+		if err := cw.Write([]string{"", formatCSVTimestamp(v.T), v.V}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeCSVSample writes a float sample, or a native histogram sample if h is not nil.
+func writeCSVSample(cw *csv.Writer, series string, t int64, f float64, h *histogram.FloatHistogram) error {
+	value := strconv.FormatFloat(f, 'f', -1, 64)
+	if h != nil {
+		value = h.String()
+	}
+	return cw.Write([]string{series, formatCSVTimestamp(t), value})
+}
+
+func formatCSVTimestamp(t int64) string {
+	return strconv.FormatFloat(float64(t)/1000, 'f', -1, 64)
+}
+
+func writeQueryExportStatus(ctx context.Context, userBkt objstore.Bucket, export *queryExport) error {
+	content, err := json.Marshal(export)
+	if err != nil {
+		return errors.Wrap(err, "marshal query export status")
+	}
+	return errors.Wrap(userBkt.Upload(ctx, path.Join(QueryExportsPrefix, export.ID, queryExportStatusFilename), bytes.NewReader(content)), "upload query export status")
+}
+
+func readQueryExportStatus(ctx context.Context, userBkt objstore.Bucket, id string) (*queryExport, error) {
+	reader, err := userBkt.Get(ctx, path.Join(QueryExportsPrefix, id, queryExportStatusFilename))
+	if userBkt.IsObjNotFoundErr(err) {
+		return nil, errQueryExportNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read query export status")
+	}
+	defer reader.Close() //nolint:errcheck
+
+	export := &queryExport{}
+	if err := json.NewDecoder(reader).Decode(export); err != nil {
+		return nil, errors.Wrap(err, "decode query export status")
+	}
+	return export, nil
+}
+
+func writeQueryExportError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	util.WriteJSONResponse(w, queryExportResponse{Status: statusError, Error: err.Error()})
+}
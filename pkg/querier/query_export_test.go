@@ -0,0 +1,198 @@
+package querier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+)
+
+func TestQueryExportConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&QueryExportConfig{}).Validate())
+	assert.Error(t, (&QueryExportConfig{Enabled: true, MaxConcurrent: 1}).Validate())
+	assert.Error(t, (&QueryExportConfig{Enabled: true, Timeout: time.Minute}).Validate())
+	assert.NoError(t, (&QueryExportConfig{Enabled: true, Timeout: time.Minute, MaxConcurrent: 1}).Validate())
+}
+
+func TestQueryExporter(t *testing.T) {
+	const tenantID = "team-a"
+
+	cfg := QueryExportConfig{Enabled: true, Timeout: time.Minute, MaxConcurrent: 1}
+	engine := promql.NewEngine(promql.EngineOpts{
+		Logger:     log.NewNopLogger(),
+		Timeout:    cfg.Timeout,
+		MaxSamples: 1e6,
+	})
+	queryable := storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+		return brokenMetricQuerier{}, nil
+	})
+	bkt := objstore.NewInMemBucket()
+	exporter := NewQueryExporter(cfg, engine, queryable, bkt, nil, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+
+	router := mux.NewRouter()
+	router.Path("/api/v1/query_exports").Methods("POST").Handler(exporter.SubmitHandler())
+	router.Path("/api/v1/query_exports/{id}").Methods("GET").Handler(exporter.StatusHandler())
+	router.Path("/api/v1/query_exports/{id}/result").Methods("GET").Handler(exporter.ResultHandler())
+
+	do := func(method, path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req = req.WithContext(user.InjectOrgID(req.Context(), tenantID))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// submit submits the export and waits until it has run.
+	submit := func(t *testing.T, form url.Values) queryExport {
+		resp := do("POST", "/api/v1/query_exports", form)
+		require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+
+		submitted := queryExportResponse{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &submitted))
+		require.Equal(t, queryExportStateRunning, submitted.Data.State)
+
+		var status queryExportResponse
+		require.Eventually(t, func() bool {
+			resp := do("GET", "/api/v1/query_exports/"+submitted.Data.ID, nil)
+			require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+			return status.Data.State != queryExportStateRunning
+		}, 5*time.Second, 10*time.Millisecond)
+		return *status.Data
+	}
+
+	t.Run("should export an instant query to CSV", func(t *testing.T) {
+		export := submit(t, url.Values{"query": {"up"}, "time": {"100"}, "format": {"csv"}})
+		require.Equal(t, queryExportStateSucceeded, export.State)
+		assert.NotNil(t, export.FinishedAt)
+
+		resp := do("GET", "/api/v1/query_exports/"+export.ID+"/result", nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "text/csv", resp.Header().Get("Content-Type"))
+		assert.Equal(t, "series,timestamp,value\n\"{__name__=\"\"up\"\", tenant=\"\"team-a\"\"}\",100,1\n", resp.Body.String())
+
+		// The result is stored in the tenant bucket.
+		exists, err := bkt.Exists(context.Background(), tenantID+"/"+QueryExportsPrefix+"/"+export.ID+"/result.csv")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("should export a range query to JSON", func(t *testing.T) {
+		export := submit(t, url.Values{"query": {"up"}, "start": {"100"}, "end": {"110"}, "step": {"5s"}})
+		require.Equal(t, queryExportStateSucceeded, export.State)
+		assert.Equal(t, queryExportFormatJSON, export.Format)
+		assert.Equal(t, int64(5000), export.Step)
+
+		resp := do("GET", "/api/v1/query_exports/"+export.ID+"/result", nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"resultType": "matrix", "result": [{"metric": {"__name__": "up", "tenant": "team-a"}, "values": [[100, "1"], [105, "1"], [110, "1"]]}]}`, resp.Body.String())
+	})
+
+	t.Run("should report failed exports", func(t *testing.T) {
+		export := submit(t, url.Values{"query": {"broken"}, "time": {"100"}})
+		require.Equal(t, queryExportStateFailed, export.State)
+		assert.Equal(t, "expanding series: storage unavailable", export.Error)
+
+		resp := do("GET", "/api/v1/query_exports/"+export.ID+"/result", nil)
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.JSONEq(t, `{"status": "error", "error": "query export is failed"}`, resp.Body.String())
+	})
+
+	t.Run("should reject invalid exports", func(t *testing.T) {
+		for form, expectedErr := range map[string]string{
+			"time=100":                         "missing query",
+			"query=up{":                        "1:4: parse error: unexpected end of input inside braces",
+			"query=up&format=parquet":          `unsupported format "parquet", supported formats are json and csv`,
+			"query=up&end=100&step=5":          `invalid start: rpc error: code = Code(400) desc = cannot parse "" to a valid timestamp`,
+			"query=up&start=100&end=90&step=5": "end timestamp must not be before start time",
+		} {
+			values, err := url.ParseQuery(form)
+			require.NoError(t, err)
+
+			resp := do("POST", "/api/v1/query_exports", values)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, form)
+			assert.JSONEq(t, `{"status": "error", "error": `+jsonString(t, expectedErr)+`}`, resp.Body.String(), form)
+		}
+	})
+
+	t.Run("should reject exports beyond the max concurrency", func(t *testing.T) {
+		exporter.running <- struct{}{}
+		defer func() { <-exporter.running }()
+
+		resp := do("POST", "/api/v1/query_exports", url.Values{"query": {"up"}})
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	})
+
+	t.Run("should return 404 for unknown exports", func(t *testing.T) {
+		for _, id := range []string{"01H8Z9GQ8N8VJ5JZ2R4M3X6T7P", "not-an-id"} {
+			assert.Equal(t, http.StatusNotFound, do("GET", "/api/v1/query_exports/"+id, nil).Code)
+			assert.Equal(t, http.StatusNotFound, do("GET", "/api/v1/query_exports/"+id+"/result", nil).Code)
+		}
+	})
+
+	t.Run("should not return the result of a running export", func(t *testing.T) {
+		export := &queryExport{ID: "01H8Z9GQ8N8VJ5JZ2R4M3X6T7Q", Query: "up", Format: queryExportFormatJSON, State: queryExportStateRunning}
+		require.NoError(t, writeQueryExportStatus(context.Background(), bucket.NewUserBucketClient(tenantID, bkt, nil), export))
+
+		resp := do("GET", "/api/v1/query_exports/"+export.ID+"/result", nil)
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.JSONEq(t, `{"status": "error", "error": "query export is running"}`, resp.Body.String())
+	})
+}
+
+func TestWriteQueryResultCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeQueryResultCSV(&buf, promql.Matrix{
+		{Metric: labels.FromStrings("job", "a"), Floats: []promql.FPoint{{T: 1000, F: 1}, {T: 2500, F: 0.5}}},
+		{Metric: labels.FromStrings("job", "b"), Floats: []promql.FPoint{{T: 1000, F: 2}}},
+	}))
+
+	content, err := io.ReadAll(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "series,timestamp,value\n\"{job=\"\"a\"\"}\",1,1\n\"{job=\"\"a\"\"}\",2.5,0.5\n\"{job=\"\"b\"\"}\",1,2\n", string(content))
+
+	buf.Reset()
+	require.NoError(t, writeQueryResultCSV(&buf, promql.Scalar{T: 1000, V: 3}))
+	assert.Equal(t, "series,timestamp,value\n,1,3\n", buf.String())
+}
+
+// brokenMetricQuerier is a tenantQuerier failing the selection of the "broken" metric.
+type brokenMetricQuerier struct {
+	tenantQuerier
+}
+
+func (q brokenMetricQuerier) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	for _, m := range matchers {
+		if m.Name == labels.MetricName && m.Matches("broken") {
+			return storage.ErrSeriesSet(errors.New("storage unavailable"))
+		}
+	}
+	return q.tenantQuerier.Select(ctx, sortSeries, hints, matchers...)
+}
+
+func jsonString(t *testing.T, s string) string {
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	return string(b)
+}
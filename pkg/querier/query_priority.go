@@ -0,0 +1,22 @@
+package querier
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/priority"
+)
+
+// QueryPriorityMiddleware injects the priority assigned to the query by the query-frontend in the
+// request context, so that it's propagated to the ingesters and the store-gateways serving it.
+func QueryPriorityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if value := r.Header.Get(util.QueryPriorityHeaderKey); value != "" {
+			if p, err := strconv.ParseInt(value, 10, 64); err == nil {
+				r = r.WithContext(priority.ContextWithPriority(r.Context(), p))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,56 @@
+package querier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/priority"
+)
+
+func TestQueryPriorityMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		header           string
+		expectedPriority int64
+		expectedOK       bool
+	}{
+		"no priority": {},
+		"priority assigned by the query-frontend": {
+			header:           "5",
+			expectedPriority: 5,
+			expectedOK:       true,
+		},
+		"negative priority": {
+			header:           "-1",
+			expectedPriority: -1,
+			expectedOK:       true,
+		},
+		"invalid priority": {
+			header: "foo",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				actualPriority int64
+				actualOK       bool
+			)
+			next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				actualPriority, actualOK = priority.FromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil)
+			if tc.header != "" {
+				req.Header.Set(util.QueryPriorityHeaderKey, tc.header)
+			}
+			QueryPriorityMiddleware(next).ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tc.expectedOK, actualOK)
+			assert.Equal(t, tc.expectedPriority, actualPriority)
+		})
+	}
+}
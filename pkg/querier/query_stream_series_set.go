@@ -0,0 +1,103 @@
+package querier
+
+import (
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/annotations"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/querier/partialresponse"
+	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
+)
+
+// queryStreamSeriesSet implements storage.SeriesSet over the series merged lazily from the query
+// streams of the ingesters.
+type queryStreamSeriesSet struct {
+	set client.QueryStreamSeriesSet
+	// failures is the ingesters failing the query in partial response mode.
+	failures *partialresponse.Failures
+
+	chunkIterFn chunkIteratorFunc
+	mint, maxt  int64
+
+	cur storage.Series
+	err error
+}
+
+// Next implements storage.SeriesSet interface.
+func (s *queryStreamSeriesSet) Next() bool {
+	if s.err != nil {
+		return false
+	}
+
+	for s.set.Next() {
+		series, err := s.series(s.set.At())
+		if err != nil {
+			s.err = err
+			s.set.Close()
+			return false
+		}
+		// Sometimes the ingester can send series that have no data.
+		if series == nil {
+			continue
+		}
+		s.cur = series
+		return true
+	}
+	return false
+}
+
+// series returns the series of its chunks and samples, or nil if it has none.
+func (s *queryStreamSeriesSet) series(result client.QueryStreamSeries) (storage.Series, error) {
+	ls := cortexpb.FromLabelAdaptersToLabels(result.Labels)
+
+	var serieses []storage.Series
+	if len(result.Chunks) > 0 {
+		chunks, err := chunkcompat.FromChunks(ls, result.Chunks)
+		if err != nil {
+			return nil, err
+		}
+		serieses = append(serieses, &chunkSeries{
+			labels:            ls,
+			chunks:            chunks,
+			chunkIteratorFunc: s.chunkIterFn,
+			mint:              s.mint,
+			maxt:              s.maxt,
+		})
+	}
+	if len(result.Samples) > 0 || len(result.Histograms) > 0 {
+		serieses = append(serieses, &timeseries{series: cortexpb.TimeSeries{
+			Labels:     result.Labels,
+			Samples:    result.Samples,
+			Histograms: result.Histograms,
+		}})
+	}
+
+	switch len(serieses) {
+	case 0:
+		return nil, nil
+	case 1:
+		return serieses[0], nil
+	default:
+		return storage.ChainedSeriesMerge(serieses...), nil
+	}
+}
+
+// At implements storage.SeriesSet interface.
+func (s *queryStreamSeriesSet) At() storage.Series { return s.cur }
+
+// Err implements storage.SeriesSet interface.
+func (s *queryStreamSeriesSet) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.set.Err()
+}
+
+// Warnings implements storage.SeriesSet interface.
+func (s *queryStreamSeriesSet) Warnings() annotations.Annotations {
+	if warning := s.failures.Warning(); warning != nil {
+		return annotations.New().Add(warning)
+	}
+	return nil
+}
@@ -1,27 +1,45 @@
 package querier
 
 import (
+	"context"
 	"net/http"
+	"sync"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
 	"github.com/cortexproject/cortex/pkg/util"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 )
 
-// Queries are a set of matchers with time ranges - should not get into megabytes
-const maxRemoteReadQuerySize = 1024 * 1024
+const (
+	// Queries are a set of matchers with time ranges - should not get into megabytes
+	maxRemoteReadQuerySize = 1024 * 1024
 
-// RemoteReadHandler handles Prometheus remote read requests.
+	// The max size of the frames of the streamed chunks responses, as the Prometheus default.
+	remoteReadMaxBytesInFrame = 1024 * 1024
+
+	remoteReadStreamedChunksContentType = "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse"
+)
+
+// RemoteReadHandler handles Prometheus remote read requests. The responses are either the samples
+// or the streamed XOR chunks of the series, as negotiated with the accepted response types of the
+// request. The queries read the downsampled data up to the resolution set by the max_source_resolution
+// parameter of the request URL, whose "auto" value selects the resolution from the step of the query hints.
 func RemoteReadHandler(q storage.Queryable, logger log.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		var req client.ReadRequest
+		// The request is compatible with the client.ReadRequest, and carries the query hints
+		// and the accepted response types.
+		var req prompb.ReadRequest
 		logger := util_log.WithContext(r.Context(), logger)
 		if err := util.ParseProtoReader(ctx, r.Body, int(r.ContentLength), maxRemoteReadQuerySize, &req, util.RawSnappy); err != nil {
 			level.Error(logger).Log("msg", "failed to parse proto", "err", err.Error())
@@ -29,51 +47,134 @@ func RemoteReadHandler(q storage.Queryable, logger log.Logger) http.Handler {
 			return
 		}
 
-		// Fetch samples for all queries in parallel.
-		resp := client.ReadResponse{
-			Results: make([]*client.QueryResponse, len(req.Queries)),
+		responseType, err := remote.NegotiateResponseType(req.AcceptedResponseTypes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-		errors := make(chan error)
-		for i, qr := range req.Queries {
-			go func(i int, qr *client.QueryRequest) {
-				from, to, matchers, err := client.FromQueryRequest(qr)
-				if err != nil {
-					errors <- err
-					return
-				}
-
-				querier, err := q.Querier(int64(from), int64(to))
-				if err != nil {
-					errors <- err
-					return
-				}
-
-				params := &storage.SelectHints{
-					Start: int64(from),
-					End:   int64(to),
-				}
-				seriesSet := querier.Select(ctx, false, params, matchers...)
-				resp.Results[i], err = seriesSetToQueryResponse(seriesSet)
-				errors <- err
-			}(i, qr)
+
+		switch responseType {
+		case prompb.ReadRequest_STREAMED_XOR_CHUNKS:
+			remoteReadStreamedXORChunks(ctx, q, r, req.Queries, w, logger)
+		default:
+			remoteReadSamples(ctx, q, r, req.Queries, w, logger)
 		}
+	})
+}
 
-		var lastErr error
-		for range req.Queries {
-			err := <-errors
+func remoteReadSamples(ctx context.Context, q storage.Queryable, r *http.Request, queries []*prompb.Query, w http.ResponseWriter, logger log.Logger) {
+	// Fetch samples for all queries in parallel.
+	resp := client.ReadResponse{
+		Results: make([]*client.QueryResponse, len(queries)),
+	}
+	errors := make(chan error)
+	for i, qr := range queries {
+		go func(i int, qr *prompb.Query) {
+			ctx, params, matchers, err := remoteReadQuery(ctx, r, qr)
 			if err != nil {
-				lastErr = err
+				errors <- err
+				return
 			}
+
+			querier, err := q.Querier(params.Start, params.End)
+			if err != nil {
+				errors <- err
+				return
+			}
+			defer querier.Close()
+
+			seriesSet := querier.Select(ctx, false, params, matchers...)
+			resp.Results[i], err = seriesSetToQueryResponse(seriesSet)
+			errors <- err
+		}(i, qr)
+	}
+
+	var lastErr error
+	for range queries {
+		err := <-errors
+		if err != nil {
+			lastErr = err
 		}
-		if lastErr != nil {
-			http.Error(w, lastErr.Error(), http.StatusBadRequest)
+	}
+	if lastErr != nil {
+		http.Error(w, lastErr.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Add("Content-Type", "application/x-protobuf")
+	if err := util.SerializeProtoResponse(w, &resp, util.RawSnappy); err != nil {
+		level.Error(logger).Log("msg", "error sending remote read response", "err", err)
+	}
+}
+
+// remoteReadStreamedXORChunks streams the series of the queries, in order, as XOR chunks. The
+// samples, or the aggregates of the downsampled data, are encoded in chunks of up to 120 samples.
+func remoteReadStreamedXORChunks(ctx context.Context, q storage.Queryable, r *http.Request, queries []*prompb.Query, w http.ResponseWriter, logger log.Logger) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "internal http.ResponseWriter does not implement http.Flusher interface", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", remoteReadStreamedChunksContentType)
+	stream := remote.NewChunkedWriter(w, f)
+	marshalPool := &sync.Pool{}
+
+	for i, qr := range queries {
+		err := func() error {
+			ctx, params, matchers, err := remoteReadQuery(ctx, r, qr)
+			if err != nil {
+				return err
+			}
+
+			querier, err := q.Querier(params.Start, params.End)
+			if err != nil {
+				return err
+			}
+			defer querier.Close()
+
+			// The series of the streamed responses must be sorted.
+			seriesSet := querier.Select(ctx, true, params, matchers...)
+			_, err = remote.StreamChunkedReadResponses(stream, int64(i), storage.NewSeriesSetToChunkSet(seriesSet), nil, remoteReadMaxBytesInFrame, marshalPool)
+			return err
+		}()
+		if err != nil {
+			level.Error(logger).Log("msg", "error streaming remote read response", "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		w.Header().Add("Content-Type", "application/x-protobuf")
-		if err := util.SerializeProtoResponse(w, &resp, util.RawSnappy); err != nil {
-			level.Error(logger).Log("msg", "error sending remote read response", "err", err)
-		}
-	})
+	}
+}
+
+// remoteReadQuery returns the context, select hints and matchers of the remote read query. The
+// context carries the max resolution of the downsampled data the query reads.
+func remoteReadQuery(ctx context.Context, r *http.Request, qr *prompb.Query) (context.Context, *storage.SelectHints, []*labels.Matcher, error) {
+	matchers, err := remote.FromLabelMatchers(qr.Matchers)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	params := &storage.SelectHints{
+		Start: qr.StartTimestampMs,
+		End:   qr.EndTimestampMs,
+	}
+	// The function selects the aggregates read from the downsampled blocks. The series
+	// requests still read the samples, which are always returned by remote read.
+	if h := qr.Hints; h != nil && h.Func != "series" {
+		params.Step = h.StepMs
+		params.Func = h.Func
+		params.Range = h.RangeMs
+		params.Grouping = h.Grouping
+		params.By = h.By
+	}
+
+	resolution, err := downsample.ParseMaxSourceResolution(r.URL.Query().Get(downsample.MaxSourceResolutionParam), params.Step)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if resolution > 0 {
+		ctx = downsample.ContextWithMaxResolution(ctx, resolution)
+	}
+	return ctx, params, matchers, nil
 }
 
 func seriesSetToQueryResponse(s storage.SeriesSet) (*client.QueryResponse, error) {
@@ -14,13 +14,18 @@ import (
 	"github.com/golang/snappy"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/util/annotations"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/ingester/client"
 	"github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
 )
 
 func TestRemoteReadHandler(t *testing.T) {
@@ -88,6 +93,136 @@ func TestRemoteReadHandler(t *testing.T) {
 	require.Equal(t, expected, response)
 }
 
+func TestRemoteReadHandler_StreamedXORChunks(t *testing.T) {
+	t.Parallel()
+	q := storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+		return mockQuerier{
+			matrix: model.Matrix{
+				{Metric: model.Metric{"foo": "baz"}, Values: []model.SamplePair{{Timestamp: 0, Value: 0}, {Timestamp: 1, Value: 1}}},
+				{Metric: model.Metric{"foo": "bar"}, Values: []model.SamplePair{{Timestamp: 2, Value: 2}, {Timestamp: 3, Value: 3}}},
+			},
+		}, nil
+	})
+	handler := RemoteReadHandler(q, log.NewNopLogger())
+
+	requestBody, err := proto.Marshal(&prompb.ReadRequest{
+		Queries: []*prompb.Query{
+			{StartTimestampMs: 0, EndTimestampMs: 10},
+			{StartTimestampMs: 0, EndTimestampMs: 10},
+		},
+		AcceptedResponseTypes: []prompb.ReadRequest_ResponseType{prompb.ReadRequest_STREAMED_XOR_CHUNKS},
+	})
+	require.NoError(t, err)
+	request, err := http.NewRequest("POST", "/query", bytes.NewReader(snappy.Encode(nil, requestBody)))
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, 200, recorder.Result().StatusCode)
+	require.Equal(t, "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse", recorder.Result().Header.Get("Content-Type"))
+
+	// Each query streams its series, sorted, as XOR chunks.
+	reader := remote.NewChunkedReader(recorder.Result().Body, remote.DefaultChunkedReadLimit, nil)
+	for queryIndex := int64(0); queryIndex < 2; queryIndex++ {
+		for _, expected := range []model.SampleStream{
+			{Metric: model.Metric{"foo": "bar"}, Values: []model.SamplePair{{Timestamp: 2, Value: 2}, {Timestamp: 3, Value: 3}}},
+			{Metric: model.Metric{"foo": "baz"}, Values: []model.SamplePair{{Timestamp: 0, Value: 0}, {Timestamp: 1, Value: 1}}},
+		} {
+			var resp prompb.ChunkedReadResponse
+			require.NoError(t, reader.NextProto(&resp))
+			assert.Equal(t, queryIndex, resp.QueryIndex)
+			require.Len(t, resp.ChunkedSeries, 1)
+			assert.Equal(t, []prompb.Label{{Name: "foo", Value: string(expected.Metric["foo"])}}, resp.ChunkedSeries[0].Labels)
+			require.Len(t, resp.ChunkedSeries[0].Chunks, 1)
+
+			chk := resp.ChunkedSeries[0].Chunks[0]
+			assert.Equal(t, prompb.Chunk_XOR, chk.Type)
+			c, err := chunkenc.FromData(chunkenc.EncXOR, chk.Data)
+			require.NoError(t, err)
+			var values []model.SamplePair
+			it := c.Iterator(nil)
+			for it.Next() != chunkenc.ValNone {
+				ts, v := it.At()
+				values = append(values, model.SamplePair{Timestamp: model.Time(ts), Value: model.SampleValue(v)})
+			}
+			assert.Equal(t, expected.Values, values)
+		}
+	}
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestRemoteReadHandler_MaxSourceResolution(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		url                string
+		hints              *prompb.ReadHints
+		expectedResolution int64
+		expectedFunc       string
+	}{
+		"raw data by default": {
+			url:          "/api/v1/read",
+			hints:        &prompb.ReadHints{StepMs: 3600000, Func: "rate"},
+			expectedFunc: "rate",
+		},
+		"explicit max source resolution": {
+			url:                "/api/v1/read?max_source_resolution=5m",
+			hints:              &prompb.ReadHints{Func: "rate"},
+			expectedResolution: 300000,
+			expectedFunc:       "rate",
+		},
+		"max source resolution selected from the step of the query hints": {
+			url:                "/api/v1/read?max_source_resolution=auto",
+			hints:              &prompb.ReadHints{StepMs: 3600000, Func: "avg_over_time"},
+			expectedResolution: 720000,
+			expectedFunc:       "avg_over_time",
+		},
+		"series requests read the samples": {
+			url:                "/api/v1/read?max_source_resolution=1h",
+			hints:              &prompb.ReadHints{Func: "series"},
+			expectedResolution: 3600000,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var (
+				resolution int64
+				function   string
+			)
+			q := storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+				return hintsCapturingQuerier{capture: func(ctx context.Context, sp *storage.SelectHints) {
+					resolution = downsample.MaxResolutionFromContext(ctx)
+					function = sp.Func
+				}}, nil
+			})
+
+			requestBody, err := proto.Marshal(&prompb.ReadRequest{
+				Queries: []*prompb.Query{{StartTimestampMs: 0, EndTimestampMs: 10, Hints: tc.hints}},
+			})
+			require.NoError(t, err)
+			request := httptest.NewRequest("POST", tc.url, bytes.NewReader(snappy.Encode(nil, requestBody)))
+			recorder := httptest.NewRecorder()
+			RemoteReadHandler(q, log.NewNopLogger()).ServeHTTP(recorder, request)
+
+			require.Equal(t, 200, recorder.Result().StatusCode)
+			assert.Equal(t, tc.expectedResolution, resolution)
+			assert.Equal(t, tc.expectedFunc, function)
+		})
+	}
+}
+
+type hintsCapturingQuerier struct {
+	mockQuerier
+	capture func(ctx context.Context, sp *storage.SelectHints)
+}
+
+func (m hintsCapturingQuerier) Select(ctx context.Context, sortSeries bool, sp *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	m.capture(ctx, sp)
+	return m.mockQuerier.Select(ctx, sortSeries, sp, matchers...)
+}
+
 type mockQuerier struct {
 	matrix model.Matrix
 }
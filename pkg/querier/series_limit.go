@@ -0,0 +1,81 @@
+package querier
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/annotations"
+
+	"github.com/cortexproject/cortex/pkg/querier/serieslimit"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+// SeriesLimitMiddleware limits the series selected by each selector of the query to the first ones
+// by labels, when requested by the header or by the hint forwarded by the query-frontend. It's meant
+// for preview queries, which don't need to scan all the series matching their selectors.
+func SeriesLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hints, err := tripperware.DecodeHints(r.Header)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if v, ok := hints.Metadata[tripperware.SeriesLimitHint]; ok {
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit < 0 {
+				http.Error(w, "invalid series limit hint: "+v, http.StatusBadRequest)
+				return
+			}
+			if limit > 0 {
+				_, ctx := serieslimit.ContextWithLimit(r.Context(), limit)
+				r = r.WithContext(ctx)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// seriesLimitSeriesSet returns the first limit series of the sorted set, and marks the truncation
+// if there are more.
+type seriesLimitSeriesSet struct {
+	storage.SeriesSet
+	limit      int
+	truncation *serieslimit.Truncation
+
+	count int
+	done  bool
+}
+
+func newSeriesLimitSeriesSet(set storage.SeriesSet, limit int, truncation *serieslimit.Truncation) storage.SeriesSet {
+	return &seriesLimitSeriesSet{SeriesSet: set, limit: limit, truncation: truncation}
+}
+
+// Next implements storage.SeriesSet interface.
+func (s *seriesLimitSeriesSet) Next() bool {
+	if s.done {
+		return false
+	}
+	if !s.SeriesSet.Next() {
+		s.done = true
+		return false
+	}
+	if s.count++; s.count > s.limit {
+		s.done = true
+		s.truncation.Mark()
+		return false
+	}
+	return true
+}
+
+// Warnings implements storage.SeriesSet interface.
+func (s *seriesLimitSeriesSet) Warnings() annotations.Annotations {
+	warning := s.truncation.Warning()
+	if warning == nil {
+		return s.SeriesSet.Warnings()
+	}
+	warnings := annotations.New()
+	warnings.Merge(s.SeriesSet.Warnings())
+	return warnings.Add(warning)
+}
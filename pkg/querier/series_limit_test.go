@@ -0,0 +1,112 @@
+package querier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/annotations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/querier/serieslimit"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+func TestSeriesLimitMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		headers        map[string]string
+		expectedStatus int
+		expectedLimit  int
+	}{
+		"no header": {
+			expectedStatus: http.StatusOK,
+		},
+		"limited by the header": {
+			headers:        map[string]string{tripperware.SeriesLimitHeader: "10"},
+			expectedStatus: http.StatusOK,
+			expectedLimit:  10,
+		},
+		"limited by the hint forwarded by the query-frontend": {
+			headers:        map[string]string{tripperware.RequestHintsHeader: "series_limit=5"},
+			expectedStatus: http.StatusOK,
+			expectedLimit:  5,
+		},
+		"disabled by a limit of 0": {
+			headers:        map[string]string{tripperware.SeriesLimitHeader: "0"},
+			expectedStatus: http.StatusOK,
+		},
+		"invalid header": {
+			headers:        map[string]string{tripperware.SeriesLimitHeader: "many"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		"invalid hint": {
+			headers:        map[string]string{tripperware.RequestHintsHeader: "series_limit=-1"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			limit := 0
+			handler := SeriesLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				limit = serieslimit.LimitFromContext(r.Context())
+				_, _ = w.Write([]byte("{}"))
+			}))
+
+			req := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.expectedStatus, resp.Code)
+			assert.Equal(t, tc.expectedLimit, limit)
+		})
+	}
+}
+
+func TestSeriesLimitSeriesSet(t *testing.T) {
+	var input []storage.Series
+	for i := 0; i < 5; i++ {
+		input = append(input, series.NewConcreteSeries(labels.FromStrings(labels.MetricName, fmt.Sprintf("series_%d", i)), nil))
+	}
+	inputWarning := errors.New("input warning")
+
+	for _, limit := range []int{1, 4, 5, 10} {
+		t.Run(fmt.Sprintf("limit %d", limit), func(t *testing.T) {
+			truncation, _ := serieslimit.ContextWithLimit(context.Background(), limit)
+			set := newSeriesLimitSeriesSet(series.NewSeriesSetWithWarnings(series.NewConcreteSeriesSet(true, input), annotations.New().Add(inputWarning)), limit, truncation)
+
+			var actual []labels.Labels
+			for set.Next() {
+				actual = append(actual, set.At().Labels())
+			}
+			require.NoError(t, set.Err())
+			// Next keeps returning false once the limit is reached.
+			assert.False(t, set.Next())
+
+			expected := min(limit, len(input))
+			require.Len(t, actual, expected)
+			for i, ls := range actual {
+				assert.Equal(t, input[i].Labels(), ls)
+			}
+
+			assert.Equal(t, limit < len(input), truncation.Truncated())
+			warnings := set.Warnings()
+			assert.Contains(t, warnings, inputWarning.Error())
+			if limit < len(input) {
+				assert.Len(t, warnings, 2)
+			} else {
+				assert.Len(t, warnings, 1)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Package serieslimit carries the series limit hint of the queries, and whether their results have
+// been truncated to it, between the querier, the distributor and the blocks store.
+package serieslimit
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/atomic"
+)
+
+type contextKey int
+
+const (
+	limitKey contextKey = iota
+	truncationKey
+)
+
+// Truncation records whether the series selected by the queries have been truncated to the limit.
+type Truncation struct {
+	limit     int
+	truncated atomic.Bool
+}
+
+// ContextWithLimit returns a context limiting the series selected by each selector of the queries run
+// with it to the first limit series by labels, and the truncation of their results. A limit of 0 is
+// disabled.
+func ContextWithLimit(ctx context.Context, limit int) (*Truncation, context.Context) {
+	truncation := &Truncation{limit: limit}
+	return truncation, context.WithValue(context.WithValue(ctx, limitKey, limit), truncationKey, truncation)
+}
+
+// LimitFromContext returns the series limit of the context, 0 if not limited.
+func LimitFromContext(ctx context.Context) int {
+	limit, _ := ctx.Value(limitKey).(int)
+	return limit
+}
+
+// TruncationFromContext returns the truncation of the context, or nil.
+func TruncationFromContext(ctx context.Context) *Truncation {
+	truncation, _ := ctx.Value(truncationKey).(*Truncation)
+	return truncation
+}
+
+// Mark records that some series have been left out by the limit.
+func (t *Truncation) Mark() {
+	if t == nil {
+		return
+	}
+	t.truncated.Store(true)
+}
+
+// Truncated returns whether some series have been left out by the limit.
+func (t *Truncation) Truncated() bool {
+	return t != nil && t.truncated.Load()
+}
+
+// Warning returns the warning of the truncated results, or nil if they're complete.
+func (t *Truncation) Warning() error {
+	if !t.Truncated() {
+		return nil
+	}
+	return fmt.Errorf("series limit: the results are truncated to the first %d series of each selector", t.limit)
+}
@@ -0,0 +1,30 @@
+package serieslimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitFromContext(t *testing.T) {
+	assert.Equal(t, 0, LimitFromContext(context.Background()))
+
+	_, ctx := ContextWithLimit(context.Background(), 10)
+	assert.Equal(t, 10, LimitFromContext(ctx))
+}
+
+func TestTruncation(t *testing.T) {
+	truncation, ctx := ContextWithLimit(context.Background(), 10)
+	assert.Same(t, truncation, TruncationFromContext(ctx))
+	assert.False(t, truncation.Truncated())
+	assert.NoError(t, truncation.Warning())
+
+	TruncationFromContext(ctx).Mark()
+	assert.True(t, truncation.Truncated())
+	assert.EqualError(t, truncation.Warning(), "series limit: the results are truncated to the first 10 series of each selector")
+
+	// The truncation isn't recorded without a context limiting the series.
+	TruncationFromContext(context.Background()).Mark()
+	assert.False(t, TruncationFromContext(context.Background()).Truncated())
+}
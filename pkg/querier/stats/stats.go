@@ -3,6 +3,7 @@ package stats
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic" //lint:ignore faillint we can't use go.uber.org/atomic with a protobuf struct without wrapping it.
 	"time"
@@ -164,6 +165,23 @@ func (s *QueryStats) LoadFetchedSamples() uint64 {
 	return atomic.LoadUint64(&s.FetchedSamplesCount)
 }
 
+// AddFetchedHistogramSamples adds the number of native histogram samples fetched.
+func (s *QueryStats) AddFetchedHistogramSamples(count uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.FetchedHistogramSamplesCount, count)
+}
+
+func (s *QueryStats) LoadFetchedHistogramSamples() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.FetchedHistogramSamplesCount)
+}
+
 func (s *QueryStats) AddFetchedChunks(count uint64) {
 	if s == nil {
 		return
@@ -196,6 +214,145 @@ func (s *QueryStats) LoadSplitQueries() uint64 {
 	return atomic.LoadUint64(&s.SplitQueries)
 }
 
+// AddFetchedIngestersChunkBytes adds the bytes of the chunks fetched from the ingesters.
+func (s *QueryStats) AddFetchedIngestersChunkBytes(bytes uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.FetchedIngestersChunkBytes, bytes)
+}
+
+func (s *QueryStats) LoadFetchedIngestersChunkBytes() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.FetchedIngestersChunkBytes)
+}
+
+// AddFetchedRawBlocksChunkBytes adds the bytes of the raw chunks fetched from the store-gateways.
+func (s *QueryStats) AddFetchedRawBlocksChunkBytes(bytes uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.FetchedRawBlocksChunkBytes, bytes)
+}
+
+func (s *QueryStats) LoadFetchedRawBlocksChunkBytes() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.FetchedRawBlocksChunkBytes)
+}
+
+// AddFetchedDownsampledBlocksChunkBytes adds the bytes of the downsampled chunks fetched from the store-gateways.
+func (s *QueryStats) AddFetchedDownsampledBlocksChunkBytes(bytes uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.FetchedDownsampledBlocksChunkBytes, bytes)
+}
+
+func (s *QueryStats) LoadFetchedDownsampledBlocksChunkBytes() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.FetchedDownsampledBlocksChunkBytes)
+}
+
+// AddQueriedIngesters adds the number of ingesters queried.
+func (s *QueryStats) AddQueriedIngesters(count uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.QueriedIngesters, count)
+}
+
+func (s *QueryStats) LoadQueriedIngesters() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.QueriedIngesters)
+}
+
+// AddFailedIngesters adds the number of ingesters which failed the query.
+func (s *QueryStats) AddFailedIngesters(count uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.FailedIngesters, count)
+}
+
+func (s *QueryStats) LoadFailedIngesters() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.FailedIngesters)
+}
+
+// UpdateSlowestIngesterLatency records the latency of an ingester, if it's the slowest one so far.
+func (s *QueryStats) UpdateSlowestIngesterLatency(latency time.Duration) {
+	if s == nil {
+		return
+	}
+
+	for {
+		current := atomic.LoadInt64((*int64)(&s.SlowestIngesterLatency))
+		if int64(latency) <= current || atomic.CompareAndSwapInt64((*int64)(&s.SlowestIngesterLatency), current, int64(latency)) {
+			return
+		}
+	}
+}
+
+func (s *QueryStats) LoadSlowestIngesterLatency() time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	return time.Duration(atomic.LoadInt64((*int64)(&s.SlowestIngesterLatency)))
+}
+
+// AddQueriedIngesterZones adds the zones of the ingesters queried, which are kept once each.
+func (s *QueryStats) AddQueriedIngesterZones(zones ...string) {
+	if s == nil {
+		return
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for _, zone := range zones {
+		i := sort.SearchStrings(s.QueriedIngesterZones, zone)
+		if i < len(s.QueriedIngesterZones) && s.QueriedIngesterZones[i] == zone {
+			continue
+		}
+		s.QueriedIngesterZones = append(s.QueriedIngesterZones, "")
+		copy(s.QueriedIngesterZones[i+1:], s.QueriedIngesterZones[i:])
+		s.QueriedIngesterZones[i] = zone
+	}
+}
+
+// LoadQueriedIngesterZones returns the zones of the ingesters queried, sorted.
+func (s *QueryStats) LoadQueriedIngesterZones() []string {
+	if s == nil {
+		return nil
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return append([]string(nil), s.QueriedIngesterZones...)
+}
+
 // Merge the provided Stats into this one.
 func (s *QueryStats) Merge(other *QueryStats) {
 	if s == nil || other == nil {
@@ -207,7 +364,15 @@ func (s *QueryStats) Merge(other *QueryStats) {
 	s.AddFetchedChunkBytes(other.LoadFetchedChunkBytes())
 	s.AddFetchedDataBytes(other.LoadFetchedDataBytes())
 	s.AddFetchedSamples(other.LoadFetchedSamples())
+	s.AddFetchedHistogramSamples(other.LoadFetchedHistogramSamples())
 	s.AddFetchedChunks(other.LoadFetchedChunks())
+	s.AddFetchedIngestersChunkBytes(other.LoadFetchedIngestersChunkBytes())
+	s.AddFetchedRawBlocksChunkBytes(other.LoadFetchedRawBlocksChunkBytes())
+	s.AddFetchedDownsampledBlocksChunkBytes(other.LoadFetchedDownsampledBlocksChunkBytes())
+	s.AddQueriedIngesters(other.LoadQueriedIngesters())
+	s.AddFailedIngesters(other.LoadFailedIngesters())
+	s.UpdateSlowestIngesterLatency(other.LoadSlowestIngesterLatency())
+	s.AddQueriedIngesterZones(other.LoadQueriedIngesterZones()...)
 	s.AddExtraFields(other.LoadExtraFields()...)
 }
 
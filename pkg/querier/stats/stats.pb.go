@@ -51,6 +51,22 @@ type Stats struct {
 	// The total number of split queries sent after going through all the middlewares.
 	// It includes the number of requests that might be discarded by the queue.
 	SplitQueries uint64 `protobuf:"varint,9,opt,name=split_queries,json=splitQueries,proto3" json:"split_queries,omitempty"`
+	// The number of bytes of the chunks fetched from the ingesters for the query
+	FetchedIngestersChunkBytes uint64 `protobuf:"varint,10,opt,name=fetched_ingesters_chunk_bytes,json=fetchedIngestersChunkBytes,proto3" json:"fetched_ingesters_chunk_bytes,omitempty"`
+	// The number of bytes of the raw chunks fetched from the blocks for the query
+	FetchedRawBlocksChunkBytes uint64 `protobuf:"varint,11,opt,name=fetched_raw_blocks_chunk_bytes,json=fetchedRawBlocksChunkBytes,proto3" json:"fetched_raw_blocks_chunk_bytes,omitempty"`
+	// The number of bytes of the downsampled chunks fetched from the blocks for the query
+	FetchedDownsampledBlocksChunkBytes uint64 `protobuf:"varint,12,opt,name=fetched_downsampled_blocks_chunk_bytes,json=fetchedDownsampledBlocksChunkBytes,proto3" json:"fetched_downsampled_blocks_chunk_bytes,omitempty"`
+	// The number of histogram samples fetched for the query
+	FetchedHistogramSamplesCount uint64 `protobuf:"varint,13,opt,name=fetched_histogram_samples_count,json=fetchedHistogramSamplesCount,proto3" json:"fetched_histogram_samples_count,omitempty"`
+	// The number of ingesters queried for the query
+	QueriedIngesters uint64 `protobuf:"varint,14,opt,name=queried_ingesters,json=queriedIngesters,proto3" json:"queried_ingesters,omitempty"`
+	// The number of ingesters which failed the query
+	FailedIngesters uint64 `protobuf:"varint,15,opt,name=failed_ingesters,json=failedIngesters,proto3" json:"failed_ingesters,omitempty"`
+	// The latency of the slowest ingester which succeeded the query
+	SlowestIngesterLatency time.Duration `protobuf:"bytes,16,opt,name=slowest_ingester_latency,json=slowestIngesterLatency,proto3,stdduration" json:"slowest_ingester_latency"`
+	// The zones of the ingesters queried for the query
+	QueriedIngesterZones []string `protobuf:"bytes,17,rep,name=queried_ingester_zones,json=queriedIngesterZones,proto3" json:"queried_ingester_zones,omitempty"`
 }
 
 func (m *Stats) Reset()      { *m = Stats{} }
@@ -148,6 +164,62 @@ func (m *Stats) GetSplitQueries() uint64 {
 	return 0
 }
 
+func (m *Stats) GetFetchedIngestersChunkBytes() uint64 {
+	if m != nil {
+		return m.FetchedIngestersChunkBytes
+	}
+	return 0
+}
+
+func (m *Stats) GetFetchedRawBlocksChunkBytes() uint64 {
+	if m != nil {
+		return m.FetchedRawBlocksChunkBytes
+	}
+	return 0
+}
+
+func (m *Stats) GetFetchedDownsampledBlocksChunkBytes() uint64 {
+	if m != nil {
+		return m.FetchedDownsampledBlocksChunkBytes
+	}
+	return 0
+}
+
+func (m *Stats) GetFetchedHistogramSamplesCount() uint64 {
+	if m != nil {
+		return m.FetchedHistogramSamplesCount
+	}
+	return 0
+}
+
+func (m *Stats) GetQueriedIngesters() uint64 {
+	if m != nil {
+		return m.QueriedIngesters
+	}
+	return 0
+}
+
+func (m *Stats) GetFailedIngesters() uint64 {
+	if m != nil {
+		return m.FailedIngesters
+	}
+	return 0
+}
+
+func (m *Stats) GetSlowestIngesterLatency() time.Duration {
+	if m != nil {
+		return m.SlowestIngesterLatency
+	}
+	return 0
+}
+
+func (m *Stats) GetQueriedIngesterZones() []string {
+	if m != nil {
+		return m.QueriedIngesterZones
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Stats)(nil), "stats.Stats")
 	proto.RegisterMapType((map[string]string)(nil), "stats.Stats.ExtraFieldsEntry")
@@ -156,36 +228,47 @@ func init() {
 func init() { proto.RegisterFile("stats.proto", fileDescriptor_b4756a0aec8b9d44) }
 
 var fileDescriptor_b4756a0aec8b9d44 = []byte{
-	// 458 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x92, 0x31, 0x6f, 0xd3, 0x40,
-	0x14, 0xc7, 0x7d, 0x4d, 0x52, 0xe2, 0x4b, 0x91, 0xc2, 0x11, 0x24, 0x37, 0x12, 0xd7, 0x00, 0x4b,
-	0x06, 0xe4, 0xa0, 0xb0, 0x20, 0x90, 0x50, 0x95, 0xb6, 0x88, 0x15, 0x87, 0x89, 0xc5, 0xba, 0x24,
-	0x97, 0xe4, 0x54, 0xc7, 0x17, 0xec, 0x67, 0x20, 0x1b, 0x2b, 0x1b, 0x23, 0x1f, 0x81, 0x8f, 0x92,
-	0x31, 0x63, 0xa7, 0x42, 0x9c, 0x85, 0xb1, 0x1f, 0x01, 0xdd, 0x3b, 0xbb, 0xd0, 0x6e, 0xf7, 0xde,
-	0xef, 0xfd, 0x9f, 0xee, 0xff, 0xbf, 0xa3, 0x8d, 0x14, 0x04, 0xa4, 0xfe, 0x32, 0xd1, 0xa0, 0x59,
-	0x0d, 0x8b, 0x76, 0x6b, 0xa6, 0x67, 0x1a, 0x3b, 0x3d, 0x73, 0xb2, 0xb0, 0xcd, 0x67, 0x5a, 0xcf,
-	0x22, 0xd9, 0xc3, 0x6a, 0x94, 0x4d, 0x7b, 0x93, 0x2c, 0x11, 0xa0, 0x74, 0x5c, 0xf0, 0xc3, 0xdb,
-	0x5c, 0xc4, 0x2b, 0x8b, 0x1e, 0x7f, 0xab, 0xd2, 0xda, 0xd0, 0xac, 0x66, 0xc7, 0xd4, 0xfd, 0x2c,
-	0xa2, 0x28, 0x04, 0xb5, 0x90, 0x1e, 0xe9, 0x90, 0x6e, 0xa3, 0x7f, 0xe8, 0x5b, 0xa1, 0x5f, 0x0a,
-	0xfd, 0xd3, 0x62, 0xf1, 0xa0, 0xbe, 0xbe, 0x3c, 0x72, 0x7e, 0xfc, 0x3a, 0x22, 0x41, 0xdd, 0xa8,
-	0xde, 0xab, 0x85, 0x64, 0xcf, 0x68, 0x6b, 0x2a, 0x61, 0x3c, 0x97, 0x93, 0x30, 0x95, 0x89, 0x92,
-	0x69, 0x38, 0xd6, 0x59, 0x0c, 0xde, 0x5e, 0x87, 0x74, 0xab, 0x01, 0x2b, 0xd8, 0x10, 0xd1, 0x89,
-	0x21, 0xcc, 0xa7, 0xf7, 0x4b, 0xc5, 0x78, 0x9e, 0xc5, 0xe7, 0xe1, 0x68, 0x05, 0x32, 0xf5, 0x2a,
-	0x28, 0xb8, 0x57, 0xa0, 0x13, 0x43, 0x06, 0x06, 0xb0, 0xa7, 0xb4, 0xdc, 0x12, 0x4e, 0x04, 0x88,
-	0x62, 0xbc, 0x8a, 0xe3, 0xcd, 0x82, 0x9c, 0x0a, 0x10, 0x76, 0xfa, 0x98, 0x1e, 0xc8, 0x2f, 0x90,
-	0x88, 0x70, 0xaa, 0x64, 0x34, 0x49, 0xbd, 0x5a, 0xa7, 0xd2, 0x6d, 0xf4, 0x1f, 0xfa, 0x36, 0x57,
-	0x74, 0xed, 0x9f, 0x99, 0x81, 0x37, 0xc8, 0xcf, 0x62, 0x48, 0x56, 0x41, 0x43, 0xfe, 0xeb, 0xfc,
-	0xef, 0x08, 0xef, 0x57, 0x3a, 0xda, 0xbf, 0xe1, 0x08, 0x2f, 0x58, 0x38, 0xea, 0xd3, 0x07, 0xd7,
-	0x19, 0x88, 0xc5, 0x32, 0xba, 0x0e, 0xe1, 0x0e, 0x4a, 0x4a, 0xbb, 0x43, 0xcb, 0xac, 0xe6, 0x11,
-	0x75, 0x23, 0xb5, 0x50, 0x10, 0xce, 0x15, 0x78, 0xf5, 0x0e, 0xe9, 0xba, 0x83, 0xea, 0xfa, 0xd2,
-	0x44, 0x8b, 0xed, 0xb7, 0x0a, 0xd8, 0x13, 0x7a, 0x37, 0x5d, 0x46, 0x0a, 0xc2, 0x8f, 0x19, 0xc6,
-	0xe7, 0xb9, 0xb8, 0xee, 0x00, 0x9b, 0xef, 0x6c, 0xaf, 0xfd, 0x9a, 0x36, 0x6f, 0xdb, 0x61, 0x4d,
-	0x5a, 0x39, 0x97, 0x2b, 0x7c, 0x4f, 0x37, 0x30, 0x47, 0xd6, 0xa2, 0xb5, 0x4f, 0x22, 0xca, 0x24,
-	0x3e, 0x8b, 0x1b, 0xd8, 0xe2, 0xe5, 0xde, 0x0b, 0x32, 0x78, 0xb5, 0xd9, 0x72, 0xe7, 0x62, 0xcb,
-	0x9d, 0xab, 0x2d, 0x27, 0x5f, 0x73, 0x4e, 0x7e, 0xe6, 0x9c, 0xac, 0x73, 0x4e, 0x36, 0x39, 0x27,
-	0xbf, 0x73, 0x4e, 0xfe, 0xe4, 0xdc, 0xb9, 0xca, 0x39, 0xf9, 0xbe, 0xe3, 0xce, 0x66, 0xc7, 0x9d,
-	0x8b, 0x1d, 0x77, 0x3e, 0xd8, 0x9f, 0x39, 0xda, 0xc7, 0x3f, 0xf2, 0xfc, 0x6f, 0x00, 0x00, 0x00,
-	0xff, 0xff, 0x24, 0xfa, 0x1b, 0xec, 0xb6, 0x02, 0x00, 0x00,
+	// 636 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x94, 0xcf, 0x6e, 0x13, 0x3d,
+	0x14, 0xc5, 0xc7, 0x4d, 0xd3, 0x2f, 0xe3, 0xb4, 0x5f, 0x53, 0x13, 0xaa, 0x69, 0x44, 0xdd, 0x50,
+	0x24, 0x14, 0x04, 0x9a, 0xa2, 0xc2, 0x02, 0x81, 0x84, 0x4a, 0xda, 0xa2, 0x22, 0xb1, 0x61, 0xca,
+	0xaa, 0x12, 0x1a, 0x39, 0x89, 0x93, 0x58, 0x75, 0xc6, 0x65, 0xec, 0x10, 0xc2, 0x8a, 0x47, 0x60,
+	0xd9, 0x47, 0xe0, 0x51, 0xba, 0xec, 0xb2, 0xab, 0x42, 0xa7, 0x1b, 0x96, 0x7d, 0x04, 0x34, 0xf6,
+	0x38, 0xff, 0x60, 0xc1, 0x6e, 0x7c, 0xcf, 0xb9, 0xbf, 0xdc, 0x7b, 0x66, 0x1c, 0x58, 0x94, 0x8a,
+	0x28, 0xe9, 0x9f, 0xc4, 0x42, 0x09, 0x94, 0xd7, 0x87, 0x4a, 0xb9, 0x23, 0x3a, 0x42, 0x57, 0xb6,
+	0xd2, 0x27, 0x23, 0x56, 0x70, 0x47, 0x88, 0x0e, 0xa7, 0x5b, 0xfa, 0xd4, 0xe8, 0xb7, 0xb7, 0x5a,
+	0xfd, 0x98, 0x28, 0x26, 0xa2, 0x4c, 0x5f, 0x9b, 0xd5, 0x49, 0x34, 0x34, 0xd2, 0xe6, 0x69, 0x01,
+	0xe6, 0x0f, 0x53, 0x34, 0xda, 0x81, 0xee, 0x80, 0x70, 0x1e, 0x2a, 0xd6, 0xa3, 0x1e, 0xa8, 0x82,
+	0x5a, 0x71, 0x7b, 0xcd, 0x37, 0x8d, 0xbe, 0x6d, 0xf4, 0xf7, 0x32, 0x70, 0xbd, 0x70, 0x76, 0xb9,
+	0xe1, 0x9c, 0xfe, 0xd8, 0x00, 0x41, 0x21, 0xed, 0x7a, 0xcf, 0x7a, 0x14, 0x3d, 0x86, 0xe5, 0x36,
+	0x55, 0xcd, 0x2e, 0x6d, 0x85, 0x92, 0xc6, 0x8c, 0xca, 0xb0, 0x29, 0xfa, 0x91, 0xf2, 0xe6, 0xaa,
+	0xa0, 0x36, 0x1f, 0xa0, 0x4c, 0x3b, 0xd4, 0xd2, 0x6e, 0xaa, 0x20, 0x1f, 0xde, 0xb2, 0x1d, 0xcd,
+	0x6e, 0x3f, 0x3a, 0x0e, 0x1b, 0x43, 0x45, 0xa5, 0x97, 0xd3, 0x0d, 0x2b, 0x99, 0xb4, 0x9b, 0x2a,
+	0xf5, 0x54, 0x40, 0x8f, 0xa0, 0xa5, 0x84, 0x2d, 0xa2, 0x48, 0x66, 0x9f, 0xd7, 0xf6, 0x52, 0xa6,
+	0xec, 0x11, 0x45, 0x8c, 0x7b, 0x07, 0x2e, 0xd2, 0xcf, 0x2a, 0x26, 0x61, 0x9b, 0x51, 0xde, 0x92,
+	0x5e, 0xbe, 0x9a, 0xab, 0x15, 0xb7, 0xd7, 0x7d, 0x93, 0xab, 0xde, 0xda, 0xdf, 0x4f, 0x0d, 0xaf,
+	0xb5, 0xbe, 0x1f, 0xa9, 0x78, 0x18, 0x14, 0xe9, 0xb8, 0x32, 0xb9, 0x91, 0x9e, 0xcf, 0x6e, 0xb4,
+	0x30, 0xb5, 0x91, 0x1e, 0x30, 0xdb, 0x68, 0x1b, 0xde, 0x1e, 0x65, 0x40, 0x7a, 0x27, 0x7c, 0x14,
+	0xc2, 0x7f, 0xba, 0xc5, 0xae, 0x7b, 0x68, 0x34, 0xd3, 0x73, 0x17, 0xba, 0x9c, 0xf5, 0x98, 0x0a,
+	0xbb, 0x4c, 0x79, 0x85, 0x2a, 0xa8, 0xb9, 0xf5, 0xf9, 0xb3, 0xcb, 0x34, 0x5a, 0x5d, 0x3e, 0x60,
+	0x0a, 0xdd, 0x83, 0x4b, 0xf2, 0x84, 0x33, 0x15, 0x7e, 0xec, 0xeb, 0xf8, 0x3c, 0x57, 0xe3, 0x16,
+	0x75, 0xf1, 0x9d, 0xa9, 0xa1, 0x57, 0x70, 0xdd, 0xfe, 0x36, 0x8b, 0x3a, 0x54, 0x2a, 0x1a, 0xcb,
+	0xa9, 0x5c, 0xa1, 0x6e, 0xaa, 0x64, 0xa6, 0x37, 0xd6, 0x33, 0x11, 0x70, 0x1d, 0x62, 0x8b, 0x88,
+	0xc9, 0x20, 0x6c, 0x70, 0xd1, 0x3c, 0x9e, 0x66, 0x14, 0xa7, 0x18, 0x01, 0x19, 0xd4, 0xb5, 0x67,
+	0x82, 0x11, 0xc0, 0xfb, 0xa3, 0x97, 0x24, 0x06, 0x91, 0x89, 0xa1, 0xf5, 0x37, 0xd6, 0xa2, 0x66,
+	0x6d, 0xda, 0x17, 0x37, 0x36, 0xff, 0xc1, 0xdc, 0x87, 0x1b, 0x96, 0xd9, 0x65, 0x52, 0x89, 0x4e,
+	0x4c, 0x7a, 0x33, 0x01, 0x2f, 0x69, 0xd8, 0x9d, 0xcc, 0x76, 0x60, 0x5d, 0x53, 0x49, 0x3f, 0x84,
+	0x2b, 0x26, 0xc0, 0x89, 0x84, 0xbc, 0xff, 0xcd, 0xe7, 0x93, 0x09, 0xa3, 0x54, 0xd0, 0x03, 0x58,
+	0x6a, 0x13, 0xc6, 0xa7, 0xbc, 0xcb, 0xda, 0xbb, 0x6c, 0xea, 0x63, 0xeb, 0x07, 0xe8, 0x49, 0x2e,
+	0x06, 0x54, 0xaa, 0x91, 0x37, 0xe4, 0x44, 0xd1, 0xa8, 0x39, 0xf4, 0x4a, 0xff, 0x7e, 0x95, 0x56,
+	0x33, 0x88, 0x05, 0xbf, 0x35, 0x08, 0xf4, 0x14, 0xae, 0xce, 0x8e, 0x1d, 0x7e, 0x11, 0x11, 0x95,
+	0xde, 0x4a, 0x35, 0x57, 0x73, 0x83, 0xf2, 0xcc, 0xec, 0x47, 0xa9, 0x56, 0x79, 0x09, 0x4b, 0xb3,
+	0x5f, 0x37, 0x2a, 0xc1, 0xdc, 0x31, 0x1d, 0xea, 0xeb, 0xed, 0x06, 0xe9, 0x23, 0x2a, 0xc3, 0xfc,
+	0x27, 0xc2, 0xfb, 0x54, 0xdf, 0x52, 0x37, 0x30, 0x87, 0xe7, 0x73, 0xcf, 0x40, 0xfd, 0xc5, 0xf9,
+	0x15, 0x76, 0x2e, 0xae, 0xb0, 0x73, 0x73, 0x85, 0xc1, 0xd7, 0x04, 0x83, 0xef, 0x09, 0x06, 0x67,
+	0x09, 0x06, 0xe7, 0x09, 0x06, 0x3f, 0x13, 0x0c, 0x7e, 0x25, 0xd8, 0xb9, 0x49, 0x30, 0xf8, 0x76,
+	0x8d, 0x9d, 0xf3, 0x6b, 0xec, 0x5c, 0x5c, 0x63, 0xe7, 0xc8, 0xfc, 0x51, 0x35, 0x16, 0xf4, 0x9e,
+	0x4f, 0x7e, 0x0f, 0x00, 0x73, 0x40, 0x86, 0x7c, 0xc5, 0x04, 0x00, 0x00,
 }
 
 func (this *Stats) Equal(that interface{}) bool {
@@ -239,13 +322,42 @@ func (this *Stats) Equal(that interface{}) bool {
 	if this.SplitQueries != that1.SplitQueries {
 		return false
 	}
+	if this.FetchedIngestersChunkBytes != that1.FetchedIngestersChunkBytes {
+		return false
+	}
+	if this.FetchedRawBlocksChunkBytes != that1.FetchedRawBlocksChunkBytes {
+		return false
+	}
+	if this.FetchedDownsampledBlocksChunkBytes != that1.FetchedDownsampledBlocksChunkBytes {
+		return false
+	}
+	if this.FetchedHistogramSamplesCount != that1.FetchedHistogramSamplesCount {
+		return false
+	}
+	if this.QueriedIngesters != that1.QueriedIngesters {
+		return false
+	}
+	if this.FailedIngesters != that1.FailedIngesters {
+		return false
+	}
+	if this.SlowestIngesterLatency != that1.SlowestIngesterLatency {
+		return false
+	}
+	if len(this.QueriedIngesterZones) != len(that1.QueriedIngesterZones) {
+		return false
+	}
+	for i := range this.QueriedIngesterZones {
+		if this.QueriedIngesterZones[i] != that1.QueriedIngesterZones[i] {
+			return false
+		}
+	}
 	return true
 }
 func (this *Stats) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 13)
+	s := make([]string, 0, 21)
 	s = append(s, "&stats.Stats{")
 	s = append(s, "WallTime: "+fmt.Sprintf("%#v", this.WallTime)+",\n")
 	s = append(s, "FetchedSeriesCount: "+fmt.Sprintf("%#v", this.FetchedSeriesCount)+",\n")
@@ -268,6 +380,14 @@ func (this *Stats) GoString() string {
 	s = append(s, "FetchedSamplesCount: "+fmt.Sprintf("%#v", this.FetchedSamplesCount)+",\n")
 	s = append(s, "LimitHit: "+fmt.Sprintf("%#v", this.LimitHit)+",\n")
 	s = append(s, "SplitQueries: "+fmt.Sprintf("%#v", this.SplitQueries)+",\n")
+	s = append(s, "FetchedIngestersChunkBytes: "+fmt.Sprintf("%#v", this.FetchedIngestersChunkBytes)+",\n")
+	s = append(s, "FetchedRawBlocksChunkBytes: "+fmt.Sprintf("%#v", this.FetchedRawBlocksChunkBytes)+",\n")
+	s = append(s, "FetchedDownsampledBlocksChunkBytes: "+fmt.Sprintf("%#v", this.FetchedDownsampledBlocksChunkBytes)+",\n")
+	s = append(s, "FetchedHistogramSamplesCount: "+fmt.Sprintf("%#v", this.FetchedHistogramSamplesCount)+",\n")
+	s = append(s, "QueriedIngesters: "+fmt.Sprintf("%#v", this.QueriedIngesters)+",\n")
+	s = append(s, "FailedIngesters: "+fmt.Sprintf("%#v", this.FailedIngesters)+",\n")
+	s = append(s, "SlowestIngesterLatency: "+fmt.Sprintf("%#v", this.SlowestIngesterLatency)+",\n")
+	s = append(s, "QueriedIngesterZones: "+fmt.Sprintf("%#v", this.QueriedIngesterZones)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -299,6 +419,57 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.QueriedIngesterZones) > 0 {
+		for iNdEx := len(m.QueriedIngesterZones) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.QueriedIngesterZones[iNdEx])
+			copy(dAtA[i:], m.QueriedIngesterZones[iNdEx])
+			i = encodeVarintStats(dAtA, i, uint64(len(m.QueriedIngesterZones[iNdEx])))
+			i--
+			dAtA[i] = 0x1
+			i--
+			dAtA[i] = 0x8a
+		}
+	}
+	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.SlowestIngesterLatency, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.SlowestIngesterLatency):])
+	if err1 != nil {
+		return 0, err1
+	}
+	i -= n1
+	i = encodeVarintStats(dAtA, i, uint64(n1))
+	i--
+	dAtA[i] = 0x1
+	i--
+	dAtA[i] = 0x82
+	if m.FailedIngesters != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.FailedIngesters))
+		i--
+		dAtA[i] = 0x78
+	}
+	if m.QueriedIngesters != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.QueriedIngesters))
+		i--
+		dAtA[i] = 0x70
+	}
+	if m.FetchedHistogramSamplesCount != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.FetchedHistogramSamplesCount))
+		i--
+		dAtA[i] = 0x68
+	}
+	if m.FetchedDownsampledBlocksChunkBytes != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.FetchedDownsampledBlocksChunkBytes))
+		i--
+		dAtA[i] = 0x60
+	}
+	if m.FetchedRawBlocksChunkBytes != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.FetchedRawBlocksChunkBytes))
+		i--
+		dAtA[i] = 0x58
+	}
+	if m.FetchedIngestersChunkBytes != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.FetchedIngestersChunkBytes))
+		i--
+		dAtA[i] = 0x50
+	}
 	if m.SplitQueries != 0 {
 		i = encodeVarintStats(dAtA, i, uint64(m.SplitQueries))
 		i--
@@ -355,12 +526,12 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0x10
 	}
-	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.WallTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.WallTime):])
-	if err1 != nil {
-		return 0, err1
+	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.WallTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.WallTime):])
+	if err2 != nil {
+		return 0, err2
 	}
-	i -= n1
-	i = encodeVarintStats(dAtA, i, uint64(n1))
+	i -= n2
+	i = encodeVarintStats(dAtA, i, uint64(n2))
 	i--
 	dAtA[i] = 0xa
 	return len(dAtA) - i, nil
@@ -415,6 +586,32 @@ func (m *Stats) Size() (n int) {
 	if m.SplitQueries != 0 {
 		n += 1 + sovStats(uint64(m.SplitQueries))
 	}
+	if m.FetchedIngestersChunkBytes != 0 {
+		n += 1 + sovStats(uint64(m.FetchedIngestersChunkBytes))
+	}
+	if m.FetchedRawBlocksChunkBytes != 0 {
+		n += 1 + sovStats(uint64(m.FetchedRawBlocksChunkBytes))
+	}
+	if m.FetchedDownsampledBlocksChunkBytes != 0 {
+		n += 1 + sovStats(uint64(m.FetchedDownsampledBlocksChunkBytes))
+	}
+	if m.FetchedHistogramSamplesCount != 0 {
+		n += 1 + sovStats(uint64(m.FetchedHistogramSamplesCount))
+	}
+	if m.QueriedIngesters != 0 {
+		n += 1 + sovStats(uint64(m.QueriedIngesters))
+	}
+	if m.FailedIngesters != 0 {
+		n += 1 + sovStats(uint64(m.FailedIngesters))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.SlowestIngesterLatency)
+	n += 2 + l + sovStats(uint64(l))
+	if len(m.QueriedIngesterZones) > 0 {
+		for _, s := range m.QueriedIngesterZones {
+			l = len(s)
+			n += 2 + l + sovStats(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -448,6 +645,14 @@ func (this *Stats) String() string {
 		`FetchedSamplesCount:` + fmt.Sprintf("%v", this.FetchedSamplesCount) + `,`,
 		`LimitHit:` + fmt.Sprintf("%v", this.LimitHit) + `,`,
 		`SplitQueries:` + fmt.Sprintf("%v", this.SplitQueries) + `,`,
+		`FetchedIngestersChunkBytes:` + fmt.Sprintf("%v", this.FetchedIngestersChunkBytes) + `,`,
+		`FetchedRawBlocksChunkBytes:` + fmt.Sprintf("%v", this.FetchedRawBlocksChunkBytes) + `,`,
+		`FetchedDownsampledBlocksChunkBytes:` + fmt.Sprintf("%v", this.FetchedDownsampledBlocksChunkBytes) + `,`,
+		`FetchedHistogramSamplesCount:` + fmt.Sprintf("%v", this.FetchedHistogramSamplesCount) + `,`,
+		`QueriedIngesters:` + fmt.Sprintf("%v", this.QueriedIngesters) + `,`,
+		`FailedIngesters:` + fmt.Sprintf("%v", this.FailedIngesters) + `,`,
+		`SlowestIngesterLatency:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.SlowestIngesterLatency), "Duration", "protobuf.Duration", 1), `&`, ``, 1) + `,`,
+		`QueriedIngesterZones:` + fmt.Sprintf("%v", this.QueriedIngesterZones) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -795,6 +1000,185 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedIngestersChunkBytes", wireType)
+			}
+			m.FetchedIngestersChunkBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedIngestersChunkBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedRawBlocksChunkBytes", wireType)
+			}
+			m.FetchedRawBlocksChunkBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedRawBlocksChunkBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedDownsampledBlocksChunkBytes", wireType)
+			}
+			m.FetchedDownsampledBlocksChunkBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedDownsampledBlocksChunkBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedHistogramSamplesCount", wireType)
+			}
+			m.FetchedHistogramSamplesCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedHistogramSamplesCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueriedIngesters", wireType)
+			}
+			m.QueriedIngesters = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueriedIngesters |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FailedIngesters", wireType)
+			}
+			m.FailedIngesters = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FailedIngesters |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 16:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SlowestIngesterLatency", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.SlowestIngesterLatency, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 17:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueriedIngesterZones", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QueriedIngesterZones = append(m.QueriedIngesterZones, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStats_WallTime(t *testing.T) {
@@ -133,6 +134,123 @@ func TestStats_AddFetchedSamples(t *testing.T) {
 	})
 }
 
+func TestStats_AddFetchedHistogramSamples(t *testing.T) {
+	t.Parallel()
+	t.Run("add and load histogram samples", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.AddFetchedHistogramSamples(4096)
+		stats.AddFetchedHistogramSamples(4096)
+
+		assert.Equal(t, uint64(8192), stats.LoadFetchedHistogramSamples())
+	})
+
+	t.Run("add and load histogram samples nil receiver", func(t *testing.T) {
+		var stats *QueryStats
+		stats.AddFetchedHistogramSamples(1024)
+
+		assert.Equal(t, uint64(0), stats.LoadFetchedHistogramSamples())
+	})
+
+	t.Run("marshal and unmarshal histogram samples", func(t *testing.T) {
+		stats := &QueryStats{}
+		stats.AddFetchedHistogramSamples(42)
+
+		data, err := stats.Stats.Marshal()
+		require.NoError(t, err)
+
+		decoded := Stats{}
+		require.NoError(t, decoded.Unmarshal(data))
+		assert.Equal(t, stats.Stats, decoded)
+	})
+}
+
+func TestStats_AddFetchedChunkBytesBySource(t *testing.T) {
+	t.Parallel()
+	t.Run("add and load bytes", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.AddFetchedIngestersChunkBytes(1024)
+		stats.AddFetchedIngestersChunkBytes(1024)
+		stats.AddFetchedRawBlocksChunkBytes(4096)
+		stats.AddFetchedDownsampledBlocksChunkBytes(512)
+
+		assert.Equal(t, uint64(2048), stats.LoadFetchedIngestersChunkBytes())
+		assert.Equal(t, uint64(4096), stats.LoadFetchedRawBlocksChunkBytes())
+		assert.Equal(t, uint64(512), stats.LoadFetchedDownsampledBlocksChunkBytes())
+	})
+
+	t.Run("add and load bytes nil receiver", func(t *testing.T) {
+		var stats *QueryStats
+		stats.AddFetchedIngestersChunkBytes(1024)
+		stats.AddFetchedRawBlocksChunkBytes(1024)
+		stats.AddFetchedDownsampledBlocksChunkBytes(1024)
+
+		assert.Equal(t, uint64(0), stats.LoadFetchedIngestersChunkBytes())
+		assert.Equal(t, uint64(0), stats.LoadFetchedRawBlocksChunkBytes())
+		assert.Equal(t, uint64(0), stats.LoadFetchedDownsampledBlocksChunkBytes())
+	})
+
+	t.Run("marshal and unmarshal bytes", func(t *testing.T) {
+		stats := &QueryStats{}
+		stats.AddFetchedIngestersChunkBytes(1)
+		stats.AddFetchedRawBlocksChunkBytes(2)
+		stats.AddFetchedDownsampledBlocksChunkBytes(3)
+
+		data, err := stats.Stats.Marshal()
+		require.NoError(t, err)
+
+		decoded := Stats{}
+		require.NoError(t, decoded.Unmarshal(data))
+		assert.Equal(t, stats.Stats, decoded)
+	})
+}
+
+func TestStats_IngesterFanOut(t *testing.T) {
+	t.Parallel()
+	t.Run("add and load ingester fan-out", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.AddQueriedIngesters(3)
+		stats.AddQueriedIngesters(2)
+		stats.AddFailedIngesters(1)
+		stats.UpdateSlowestIngesterLatency(2 * time.Second)
+		stats.UpdateSlowestIngesterLatency(time.Second)
+		stats.AddQueriedIngesterZones("zone-b", "zone-a")
+		stats.AddQueriedIngesterZones("zone-c", "zone-a")
+
+		assert.Equal(t, uint64(5), stats.LoadQueriedIngesters())
+		assert.Equal(t, uint64(1), stats.LoadFailedIngesters())
+		assert.Equal(t, 2*time.Second, stats.LoadSlowestIngesterLatency())
+		assert.Equal(t, []string{"zone-a", "zone-b", "zone-c"}, stats.LoadQueriedIngesterZones())
+	})
+
+	t.Run("add and load ingester fan-out nil receiver", func(t *testing.T) {
+		var stats *QueryStats
+		stats.AddQueriedIngesters(3)
+		stats.AddFailedIngesters(1)
+		stats.UpdateSlowestIngesterLatency(time.Second)
+		stats.AddQueriedIngesterZones("zone-a")
+
+		assert.Equal(t, uint64(0), stats.LoadQueriedIngesters())
+		assert.Equal(t, uint64(0), stats.LoadFailedIngesters())
+		assert.Equal(t, time.Duration(0), stats.LoadSlowestIngesterLatency())
+		assert.Empty(t, stats.LoadQueriedIngesterZones())
+	})
+
+	t.Run("marshal and unmarshal ingester fan-out", func(t *testing.T) {
+		stats := &QueryStats{}
+		stats.AddQueriedIngesters(3)
+		stats.AddFailedIngesters(1)
+		stats.UpdateSlowestIngesterLatency(time.Second)
+		stats.AddQueriedIngesterZones("zone-a", "zone-b")
+
+		data, err := stats.Stats.Marshal()
+		require.NoError(t, err)
+
+		decoded := Stats{}
+		require.NoError(t, decoded.Unmarshal(data))
+		assert.Equal(t, stats.Stats, decoded)
+	})
+}
+
 func TestStats_Merge(t *testing.T) {
 	t.Parallel()
 	t.Run("merge two stats objects", func(t *testing.T) {
@@ -141,6 +259,13 @@ func TestStats_Merge(t *testing.T) {
 		stats1.AddFetchedSeries(50)
 		stats1.AddFetchedChunkBytes(42)
 		stats1.AddFetchedDataBytes(100)
+		stats1.AddFetchedIngestersChunkBytes(10)
+		stats1.AddFetchedRawBlocksChunkBytes(20)
+		stats1.AddFetchedDownsampledBlocksChunkBytes(12)
+		stats1.AddFetchedHistogramSamples(5)
+		stats1.AddQueriedIngesters(3)
+		stats1.UpdateSlowestIngesterLatency(time.Second)
+		stats1.AddQueriedIngesterZones("zone-a", "zone-b")
 		stats1.AddExtraFields("a", "b")
 		stats1.AddExtraFields("a", "b")
 
@@ -149,6 +274,13 @@ func TestStats_Merge(t *testing.T) {
 		stats2.AddFetchedSeries(60)
 		stats2.AddFetchedChunkBytes(100)
 		stats2.AddFetchedDataBytes(101)
+		stats2.AddFetchedIngestersChunkBytes(50)
+		stats2.AddFetchedDownsampledBlocksChunkBytes(50)
+		stats2.AddFetchedHistogramSamples(7)
+		stats2.AddQueriedIngesters(4)
+		stats2.AddFailedIngesters(1)
+		stats2.UpdateSlowestIngesterLatency(500 * time.Millisecond)
+		stats2.AddQueriedIngesterZones("zone-b", "zone-c")
 		stats2.AddExtraFields("c", "d")
 
 		stats1.Merge(stats2)
@@ -157,6 +289,14 @@ func TestStats_Merge(t *testing.T) {
 		assert.Equal(t, uint64(110), stats1.LoadFetchedSeries())
 		assert.Equal(t, uint64(142), stats1.LoadFetchedChunkBytes())
 		assert.Equal(t, uint64(201), stats1.LoadFetchedDataBytes())
+		assert.Equal(t, uint64(60), stats1.LoadFetchedIngestersChunkBytes())
+		assert.Equal(t, uint64(20), stats1.LoadFetchedRawBlocksChunkBytes())
+		assert.Equal(t, uint64(62), stats1.LoadFetchedDownsampledBlocksChunkBytes())
+		assert.Equal(t, uint64(12), stats1.LoadFetchedHistogramSamples())
+		assert.Equal(t, uint64(7), stats1.LoadQueriedIngesters())
+		assert.Equal(t, uint64(1), stats1.LoadFailedIngesters())
+		assert.Equal(t, time.Second, stats1.LoadSlowestIngesterLatency())
+		assert.Equal(t, []string{"zone-a", "zone-b", "zone-c"}, stats1.LoadQueriedIngesterZones())
 		checkExtraFields(t, []interface{}{"a", "b", "c", "d"}, stats1.LoadExtraFields())
 	})
 
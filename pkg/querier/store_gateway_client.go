@@ -17,7 +17,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util/tls"
 )
 
-func newStoreGatewayClientFactory(clientCfg grpcclient.Config, reg prometheus.Registerer) client.PoolFactory {
+func newStoreGatewayClientFactory(clientCfg grpcclient.Config, health *storeGatewaysHealth, reg prometheus.Registerer) client.PoolFactory {
 	requestDuration := promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
 		Namespace:   "cortex",
 		Name:        "storegateway_client_request_duration_seconds",
@@ -27,12 +27,18 @@ func newStoreGatewayClientFactory(clientCfg grpcclient.Config, reg prometheus.Re
 	}, []string{"operation", "status_code"})
 
 	return func(addr string) (client.PoolClient, error) {
-		return dialStoreGatewayClient(clientCfg, addr, requestDuration)
+		return dialStoreGatewayClient(clientCfg, addr, health, requestDuration)
 	}
 }
 
-func dialStoreGatewayClient(clientCfg grpcclient.Config, addr string, requestDuration *prometheus.HistogramVec) (*storeGatewayClient, error) {
-	opts, err := clientCfg.DialOption(grpcclient.Instrument(requestDuration))
+func dialStoreGatewayClient(clientCfg grpcclient.Config, addr string, health *storeGatewaysHealth, requestDuration *prometheus.HistogramVec) (*storeGatewayClient, error) {
+	unary, stream := grpcclient.Instrument(requestDuration)
+	if health != nil {
+		unary = append(unary, health.unaryClientInterceptor(addr))
+		stream = append(stream, health.streamClientInterceptor(addr))
+	}
+
+	opts, err := clientCfg.DialOption(unary, stream)
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +73,7 @@ func (c *storeGatewayClient) RemoteAddress() string {
 	return c.conn.Target()
 }
 
-func newStoreGatewayClientPool(discovery client.PoolServiceDiscovery, clientConfig ClientConfig, logger log.Logger, reg prometheus.Registerer) *client.Pool {
+func newStoreGatewayClientPool(discovery client.PoolServiceDiscovery, clientConfig ClientConfig, health *storeGatewaysHealth, logger log.Logger, reg prometheus.Registerer) *client.Pool {
 	// We prefer sane defaults instead of exposing further config options.
 	clientCfg := grpcclient.Config{
 		MaxRecvMsgSize:      100 << 20,
@@ -92,17 +98,38 @@ func newStoreGatewayClientPool(discovery client.PoolServiceDiscovery, clientConf
 		ConstLabels: map[string]string{"client": "querier"},
 	})
 
-	return client.NewPool("store-gateway", poolCfg, discovery, newStoreGatewayClientFactory(clientCfg, reg), clientsCount, logger)
+	return client.NewPool("store-gateway", poolCfg, discovery, newStoreGatewayClientFactory(clientCfg, health, reg), clientsCount, logger)
 }
 
 type ClientConfig struct {
 	TLSEnabled      bool             `yaml:"tls_enabled"`
 	TLS             tls.ClientConfig `yaml:",inline"`
 	GRPCCompression string           `yaml:"grpc_compression"`
+
+	SubsetSize                 int           `yaml:"subset_size"`
+	UnhealthyFailuresThreshold int           `yaml:"unhealthy_failures_threshold"`
+	UnhealthyCooldown          time.Duration `yaml:"unhealthy_cooldown"`
+	RetryBudgetRatio           float64       `yaml:"retry_budget_ratio"`
+	RetryBudgetMinPerSecond    int           `yaml:"retry_budget_min_per_second"`
 }
 
 func (cfg *ClientConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.BoolVar(&cfg.TLSEnabled, prefix+".tls-enabled", cfg.TLSEnabled, "Enable TLS for gRPC client connecting to store-gateway.")
-	f.StringVar(&cfg.GRPCCompression, prefix+".grpc-compression", "", "Use compression when sending messages. Supported values are: 'gzip', 'snappy' and '' (disable compression)")
+	f.StringVar(&cfg.GRPCCompression, prefix+".grpc-compression", "", "Use compression when sending messages. Supported values are: 'gzip', 'snappy', 'snappy-block' ,'zstd' and '' (disable compression)")
 	cfg.TLS.RegisterFlagsWithPrefix(prefix, f)
+	f.IntVar(&cfg.SubsetSize, prefix+".subset-size", 0, "Experimental: Number of the store-gateways resolved from -querier.store-gateway-addresses each querier connects to, picked by rendezvous hashing of the querier hostname, so that the queriers don't connect to all the store-gateways. The other store-gateways are only queried once the ones of the subset are exhausted by the retries. Applies only when the store-gateway sharding is disabled, since the sharded blocks can only be queried on the store-gateways owning them. 0 to disable.")
+	f.IntVar(&cfg.UnhealthyFailuresThreshold, prefix+".unhealthy-failures-threshold", 0, "Experimental: Number of consecutive requests a store-gateway must fail, because it's unavailable, failing or overloaded, to be considered unhealthy. The unhealthy store-gateways are only queried when no healthy store-gateway is left for a block. 0 to disable.")
+	f.DurationVar(&cfg.UnhealthyCooldown, prefix+".unhealthy-cooldown", 30*time.Second, "Experimental: How long a store-gateway is considered unhealthy, once it failed the consecutive requests of -"+prefix+".unhealthy-failures-threshold.")
+	f.Float64Var(&cfg.RetryBudgetRatio, prefix+".retry-budget-ratio", 0, "Experimental: Maximum ratio of the retries of the blocks to other store-gateways to the queries, over the last 10 seconds, on top of -"+prefix+".retry-budget-min-per-second. Once the budget is spent, the queries are no longer retried, to protect the store-gateways from retry storms. 0 to disable.")
+	f.IntVar(&cfg.RetryBudgetMinPerSecond, prefix+".retry-budget-min-per-second", 10, "Experimental: Number of retries per second allowed regardless of -"+prefix+".retry-budget-ratio, so that the queriers serving few queries can still retry.")
+}
+
+func (cfg *ClientConfig) Validate() error {
+	if cfg.SubsetSize < 0 {
+		return errors.New("the store-gateway client subset size must be positive or 0")
+	}
+	if cfg.RetryBudgetRatio < 0 {
+		return errors.New("the store-gateway client retry budget ratio must be positive or 0")
+	}
+	return grpcclient.ValidateCompression(cfg.GRPCCompression)
 }
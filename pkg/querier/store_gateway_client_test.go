@@ -40,7 +40,7 @@ func Test_newStoreGatewayClientFactory(t *testing.T) {
 	flagext.DefaultValues(&cfg)
 
 	reg := prometheus.NewPedanticRegistry()
-	factory := newStoreGatewayClientFactory(cfg, reg)
+	factory := newStoreGatewayClientFactory(cfg, nil, reg)
 
 	for i := 0; i < 2; i++ {
 		client, err := factory(listener.Addr().String())
@@ -0,0 +1,157 @@
+package querier
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/storegateway"
+)
+
+// storeGatewayHealthState is the health of a store-gateway, as observed by the querier.
+type storeGatewayHealthState struct {
+	consecutiveFailures int
+	unhealthyUntil      time.Time
+}
+
+// storeGatewaysHealth tracks the failures of the requests to each store-gateway. A store-gateway
+// failing the threshold of consecutive requests is considered unhealthy for the cooldown, and only
+// queried when no healthy store-gateway is left for a block.
+type storeGatewaysHealth struct {
+	failuresThreshold int
+	cooldown          time.Duration
+
+	mtx       sync.Mutex
+	instances map[string]*storeGatewayHealthState
+
+	ejections prometheus.Counter
+}
+
+// newStoreGatewaysHealth returns nil if the health tracking is disabled.
+func newStoreGatewaysHealth(cfg ClientConfig, reg prometheus.Registerer) *storeGatewaysHealth {
+	if cfg.UnhealthyFailuresThreshold <= 0 {
+		return nil
+	}
+
+	return &storeGatewaysHealth{
+		failuresThreshold: cfg.UnhealthyFailuresThreshold,
+		cooldown:          cfg.UnhealthyCooldown,
+		instances:         map[string]*storeGatewayHealthState{},
+		ejections: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_storegateway_unhealthy_instances_total",
+			Help:      "Total number of times a store-gateway was considered unhealthy after failing consecutive requests.",
+		}),
+	}
+}
+
+// healthy returns whether the store-gateway is not in the cooldown of its failures.
+func (h *storeGatewaysHealth) healthy(addr string) bool {
+	if h == nil {
+		return true
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	s, ok := h.instances[addr]
+	return !ok || time.Now().After(s.unhealthyUntil)
+}
+
+func (h *storeGatewaysHealth) success(addr string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	// The healthy store-gateways aren't tracked, to not leak the ones removed from the ring.
+	delete(h.instances, addr)
+}
+
+func (h *storeGatewaysHealth) failure(addr string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	s, ok := h.instances[addr]
+	if !ok {
+		s = &storeGatewayHealthState{}
+		h.instances[addr] = s
+	}
+	s.consecutiveFailures++
+	if s.consecutiveFailures >= h.failuresThreshold {
+		s.consecutiveFailures = 0
+		s.unhealthyUntil = time.Now().Add(h.cooldown)
+		h.ejections.Inc()
+	}
+}
+
+// observe records the outcome of a request to the store-gateway. The errors caused by the query,
+// like a limit hit or the cancellation of the query, don't tell anything about the store-gateway.
+func (h *storeGatewaysHealth) observe(ctx context.Context, addr string, err error) {
+	switch {
+	case err == nil || errors.Is(err, io.EOF):
+		h.success(addr)
+	case ctx.Err() != nil:
+	case isStoreGatewayUnhealthyError(err):
+		h.failure(addr)
+	}
+}
+
+// isStoreGatewayUnhealthyError returns whether the error is caused by the store-gateway being
+// unavailable, failing or overloaded.
+func isStoreGatewayUnhealthyError(err error) bool {
+	cause := errors.Cause(err)
+	switch status.Code(cause) {
+	case codes.Unavailable, codes.Unknown, codes.Internal, codes.DeadlineExceeded, codes.Aborted, codes.DataLoss:
+		return true
+	case codes.ResourceExhausted:
+		return errors.Is(cause, storegateway.ErrTooManyInflightRequests)
+	default:
+		return false
+	}
+}
+
+// unaryClientInterceptor returns the interceptor observing the requests to the store-gateway.
+func (h *storeGatewaysHealth) unaryClientInterceptor(addr string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		h.observe(ctx, addr, err)
+		return err
+	}
+}
+
+// streamClientInterceptor returns the interceptor observing the streams of the store-gateway,
+// until the end of the stream or its first error.
+func (h *storeGatewaysHealth) streamClientInterceptor(addr string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			h.observe(ctx, addr, err)
+			return nil, err
+		}
+		return &healthObservedClientStream{ClientStream: stream, ctx: ctx, addr: addr, health: h}, nil
+	}
+}
+
+type healthObservedClientStream struct {
+	grpc.ClientStream
+
+	ctx    context.Context
+	addr   string
+	health *storeGatewaysHealth
+	once   sync.Once
+}
+
+func (s *healthObservedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() { s.health.observe(s.ctx, s.addr, err) })
+	}
+	return err
+}
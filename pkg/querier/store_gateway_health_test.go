@@ -0,0 +1,104 @@
+package querier
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/storegateway"
+)
+
+func TestStoreGatewaysHealth(t *testing.T) {
+	assert.Nil(t, newStoreGatewaysHealth(ClientConfig{}, nil))
+	// The health tracking disabled considers all the store-gateways healthy.
+	assert.True(t, (*storeGatewaysHealth)(nil).healthy("1.1.1.1"))
+
+	reg := prometheus.NewPedanticRegistry()
+	h := newStoreGatewaysHealth(ClientConfig{UnhealthyFailuresThreshold: 2, UnhealthyCooldown: time.Hour}, reg)
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	h.observe(ctx, "1.1.1.1", unavailable)
+	assert.True(t, h.healthy("1.1.1.1"))
+
+	// A success resets the consecutive failures.
+	h.observe(ctx, "1.1.1.1", nil)
+	h.observe(ctx, "1.1.1.1", unavailable)
+	assert.True(t, h.healthy("1.1.1.1"))
+
+	h.observe(ctx, "1.1.1.1", errors.Wrap(unavailable, "failed to fetch series"))
+	assert.False(t, h.healthy("1.1.1.1"))
+	assert.True(t, h.healthy("2.2.2.2"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(h.ejections))
+
+	// The errors caused by the query don't tell anything about the store-gateway.
+	h.observe(ctx, "2.2.2.2", status.Error(codes.ResourceExhausted, "limit hit"))
+	h.observe(ctx, "2.2.2.2", status.Error(codes.InvalidArgument, "invalid matchers"))
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	h.observe(canceled, "2.2.2.2", status.Error(codes.Canceled, "canceled"))
+	assert.True(t, h.healthy("2.2.2.2"))
+
+	// The overloaded store-gateways fail.
+	h.observe(ctx, "2.2.2.2", storegateway.ErrTooManyInflightRequests)
+	h.observe(ctx, "2.2.2.2", storegateway.ErrTooManyInflightRequests)
+	assert.False(t, h.healthy("2.2.2.2"))
+
+	// The store-gateway is healthy again after the cooldown.
+	h.cooldown = 0
+	h.observe(ctx, "3.3.3.3", unavailable)
+	h.observe(ctx, "3.3.3.3", unavailable)
+	assert.True(t, h.healthy("3.3.3.3"))
+}
+
+func TestStoreGatewaysHealth_ClientInterceptors(t *testing.T) {
+	h := newStoreGatewaysHealth(ClientConfig{UnhealthyFailuresThreshold: 1, UnhealthyCooldown: time.Hour}, nil)
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	unary := h.unaryClientInterceptor("1.1.1.1")
+	require.Equal(t, unavailable, unary(ctx, "/method", nil, nil, nil, func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return unavailable
+	}))
+	assert.False(t, h.healthy("1.1.1.1"))
+
+	// The streams are observed until their end.
+	stream := h.streamClientInterceptor("2.2.2.2")
+	s, err := stream(ctx, &grpc.StreamDesc{}, nil, "/method", func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &mockClientStream{errs: []error{nil, unavailable}}, nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.RecvMsg(nil))
+	assert.True(t, h.healthy("2.2.2.2"))
+	require.Equal(t, unavailable, s.RecvMsg(nil))
+	assert.False(t, h.healthy("2.2.2.2"))
+
+	s, err = stream(ctx, &grpc.StreamDesc{}, nil, "/method", func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &mockClientStream{errs: []error{io.EOF}}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, io.EOF, s.RecvMsg(nil))
+	assert.True(t, h.healthy("2.2.2.2"))
+}
+
+// mockClientStream returns the errors in order from RecvMsg.
+type mockClientStream struct {
+	grpc.ClientStream
+	errs []error
+}
+
+func (s *mockClientStream) RecvMsg(interface{}) error {
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
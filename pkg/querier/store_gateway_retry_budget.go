@@ -0,0 +1,93 @@
+package querier
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// retryBudgetWindow is the time window over which the retries are budgeted.
+	retryBudgetWindow = 10 * time.Second
+	// retryBudgetBuckets is the number of buckets the window is sliced into.
+	retryBudgetBuckets = 10
+)
+
+type retryBudgetBucket struct {
+	// second is the start of the bucket, in seconds since the epoch.
+	second  int64
+	queries int
+	retries int
+}
+
+// retryBudget limits the retries of the queries to the store-gateways to a ratio of the queries
+// over the last window, on top of a minimum number of retries per second. It protects the
+// store-gateways from the retry storms when a subset of them is slow or failing.
+type retryBudget struct {
+	ratio        float64
+	minPerSecond int
+
+	mtx     sync.Mutex
+	buckets [retryBudgetBuckets]retryBudgetBucket
+
+	// now is overridden in the tests.
+	now func() time.Time
+}
+
+// newRetryBudget returns nil if the retry budget is disabled.
+func newRetryBudget(cfg ClientConfig) *retryBudget {
+	if cfg.RetryBudgetRatio <= 0 {
+		return nil
+	}
+	return &retryBudget{
+		ratio:        cfg.RetryBudgetRatio,
+		minPerSecond: cfg.RetryBudgetMinPerSecond,
+		now:          time.Now,
+	}
+}
+
+// bucket returns the bucket of the current second. Must be called with the lock held.
+func (b *retryBudget) bucket() *retryBudgetBucket {
+	second := b.now().Unix()
+	bucket := &b.buckets[second%retryBudgetBuckets]
+	if bucket.second != second {
+		*bucket = retryBudgetBucket{second: second}
+	}
+	return bucket
+}
+
+// query records a query, adding to the budget of the retries.
+func (b *retryBudget) query() {
+	if b == nil {
+		return
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.bucket().queries++
+}
+
+// retry returns whether the budget allows a retry, and records it if it does.
+func (b *retryBudget) retry() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	current := b.bucket()
+	queries, retries := 0, 0
+	for _, bucket := range b.buckets {
+		if current.second-bucket.second < retryBudgetBuckets {
+			queries += bucket.queries
+			retries += bucket.retries
+		}
+	}
+
+	budget := b.ratio*float64(queries) + float64(b.minPerSecond)*retryBudgetWindow.Seconds()
+	if float64(retries+1) > budget {
+		return false
+	}
+	current.retries++
+	return true
+}
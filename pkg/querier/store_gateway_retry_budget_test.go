@@ -0,0 +1,56 @@
+package querier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBudget(t *testing.T) {
+	assert.Nil(t, newRetryBudget(ClientConfig{}))
+	// The retries are unlimited without budget.
+	assert.True(t, (*retryBudget)(nil).retry())
+
+	now := time.Unix(1000, 0)
+	b := newRetryBudget(ClientConfig{RetryBudgetRatio: 0.5, RetryBudgetMinPerSecond: 0})
+	b.now = func() time.Time { return now }
+
+	assert.False(t, b.retry())
+
+	for i := 0; i < 4; i++ {
+		b.query()
+	}
+	assert.True(t, b.retry())
+	assert.True(t, b.retry())
+	assert.False(t, b.retry())
+
+	// The queries and retries of the window are budgeted together.
+	now = now.Add(5 * time.Second)
+	b.query()
+	b.query()
+	assert.True(t, b.retry())
+	assert.False(t, b.retry())
+
+	// The budget of the first queries expires with the window.
+	now = now.Add(6 * time.Second)
+	assert.False(t, b.retry())
+	b.query()
+	b.query()
+	assert.True(t, b.retry())
+}
+
+func TestRetryBudget_MinPerSecond(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newRetryBudget(ClientConfig{RetryBudgetRatio: 0.1, RetryBudgetMinPerSecond: 1})
+	b.now = func() time.Time { return now }
+
+	// The minimum retries per second are budgeted over the window.
+	for i := 0; i < 10; i++ {
+		assert.True(t, b.retry())
+	}
+	assert.False(t, b.retry())
+
+	now = now.Add(retryBudgetWindow)
+	assert.True(t, b.retry())
+}
@@ -0,0 +1,217 @@
+package querier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const errTooManyConcurrentQueries = "the query was rejected because the tenant is running too many concurrent queries, try again later (limit: %d queries)"
+
+// TenantConcurrencyLimits is the limits of the tenant concurrency engine.
+type TenantConcurrencyLimits interface {
+	MaxConcurrentQueriesPerTenant(userID string) int
+}
+
+// tenantQueries is the queries of a tenant running in the engine, and waiting to run.
+type tenantQueries struct {
+	running int
+	waiting []chan struct{}
+}
+
+// tenantConcurrencyEngine runs at most the max concurrent queries of each tenant at a time. The
+// other queries of the tenant wait in its queue, in order, until a query completes or the queue
+// timeout expires.
+type tenantConcurrencyEngine struct {
+	engine       v1.QueryEngine
+	limits       TenantConcurrencyLimits
+	queueTimeout time.Duration
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantQueries
+
+	runningQueries  *prometheus.GaugeVec
+	queuedQueries   *prometheus.GaugeVec
+	rejectedQueries *prometheus.CounterVec
+	queueDuration   prometheus.Histogram
+}
+
+// NewTenantConcurrencyEngine returns a query engine limiting the concurrent queries of each tenant
+// run by engine, including the queries evaluated by the ruler and the queries not sent through
+// the query-frontend.
+func NewTenantConcurrencyEngine(engine v1.QueryEngine, limits TenantConcurrencyLimits, queueTimeout time.Duration, reg prometheus.Registerer) v1.QueryEngine {
+	return &tenantConcurrencyEngine{
+		engine:       engine,
+		limits:       limits,
+		queueTimeout: queueTimeout,
+		tenants:      map[string]*tenantQueries{},
+		runningQueries: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_querier_tenant_running_queries",
+			Help: "Number of queries of the tenant running, when the tenant has a max concurrent queries limit.",
+		}, []string{"user"}),
+		queuedQueries: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_querier_tenant_queued_queries",
+			Help: "Number of queries of the tenant waiting for one of its running queries to complete.",
+		}, []string{"user"}),
+		rejectedQueries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_querier_tenant_rejected_queries_total",
+			Help: "Total number of queries rejected because the tenant reached its max concurrent queries limit for longer than the queue timeout.",
+		}, []string{"user"}),
+		queueDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_querier_tenant_query_queue_duration_seconds",
+			Help:    "Time spent by the queries waiting for one of the running queries of their tenant to complete.",
+			Buckets: prometheus.DefBuckets,
+		}),
+	}
+}
+
+func (e *tenantConcurrencyEngine) SetQueryLogger(l promql.QueryLogger) {
+	e.engine.SetQueryLogger(l)
+}
+
+func (e *tenantConcurrencyEngine) NewInstantQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
+	query, err := e.engine.NewInstantQuery(ctx, q, opts, qs, ts)
+	if err != nil {
+		return nil, err
+	}
+	return &tenantConcurrencyQuery{Query: query, engine: e}, nil
+}
+
+func (e *tenantConcurrencyEngine) NewRangeQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	query, err := e.engine.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+	return &tenantConcurrencyQuery{Query: query, engine: e}, nil
+}
+
+// acquire waits for the query of the tenant to be allowed to run, and returns the function to
+// call once it completes.
+func (e *tenantConcurrencyEngine) acquire(ctx context.Context) (func(), error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		// The query fails on the tenant on its own.
+		return func() {}, nil
+	}
+	limit := validation.SmallestPositiveIntPerTenant(tenantIDs, e.limits.MaxConcurrentQueriesPerTenant)
+	if limit <= 0 {
+		return func() {}, nil
+	}
+	userID := tenant.JoinTenantIDs(tenantIDs)
+	release := func() { e.release(userID, limit) }
+
+	e.mtx.Lock()
+	t, ok := e.tenants[userID]
+	if !ok {
+		t = &tenantQueries{}
+		e.tenants[userID] = t
+	}
+	if t.running < limit && len(t.waiting) == 0 {
+		t.running++
+		e.runningQueries.WithLabelValues(userID).Set(float64(t.running))
+		e.mtx.Unlock()
+		return release, nil
+	}
+	if e.queueTimeout <= 0 {
+		e.rejectedQueries.WithLabelValues(userID).Inc()
+		e.mtx.Unlock()
+		return nil, validation.LimitError(fmt.Sprintf(errTooManyConcurrentQueries, limit))
+	}
+	ready := make(chan struct{})
+	t.waiting = append(t.waiting, ready)
+	e.queuedQueries.WithLabelValues(userID).Set(float64(len(t.waiting)))
+	e.mtx.Unlock()
+
+	start := time.Now()
+	defer func() { e.queueDuration.Observe(time.Since(start).Seconds()) }()
+
+	timer := time.NewTimer(e.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+		return release, nil
+	case <-timer.C:
+		err = validation.LimitError(fmt.Sprintf(errTooManyConcurrentQueries, limit))
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	// The query may have been given the slot of a completed query in the meantime.
+	given := true
+	e.mtx.Lock()
+	for i, c := range t.waiting {
+		if c == ready {
+			t.waiting = append(t.waiting[:i], t.waiting[i+1:]...)
+			e.queuedQueries.WithLabelValues(userID).Set(float64(len(t.waiting)))
+			e.cleanup(userID, t)
+			given = false
+			break
+		}
+	}
+	e.mtx.Unlock()
+	if given {
+		release()
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil, err
+	}
+	e.rejectedQueries.WithLabelValues(userID).Inc()
+	return nil, err
+}
+
+// release hands the slot of the completed query to the next query of the tenant waiting in
+// its queue, if the tenant is still under the limit.
+func (e *tenantConcurrencyEngine) release(userID string, limit int) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	t := e.tenants[userID]
+	if len(t.waiting) > 0 && t.running <= limit {
+		close(t.waiting[0])
+		t.waiting = t.waiting[1:]
+		e.queuedQueries.WithLabelValues(userID).Set(float64(len(t.waiting)))
+		return
+	}
+
+	t.running--
+	e.runningQueries.WithLabelValues(userID).Set(float64(t.running))
+	e.cleanup(userID, t)
+}
+
+// cleanup removes the tenant without running or waiting queries. Must be called with the lock held.
+func (e *tenantConcurrencyEngine) cleanup(userID string, t *tenantQueries) {
+	if t.running > 0 || len(t.waiting) > 0 {
+		return
+	}
+	delete(e.tenants, userID)
+	e.runningQueries.DeleteLabelValues(userID)
+	e.queuedQueries.DeleteLabelValues(userID)
+}
+
+type tenantConcurrencyQuery struct {
+	promql.Query
+	engine *tenantConcurrencyEngine
+}
+
+func (q *tenantConcurrencyQuery) Exec(ctx context.Context) *promql.Result {
+	release, err := q.engine.acquire(ctx)
+	if err != nil {
+		return &promql.Result{Err: err}
+	}
+	defer release()
+
+	return q.Query.Exec(ctx)
+}
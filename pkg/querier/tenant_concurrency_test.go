@@ -0,0 +1,138 @@
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+type tenantConcurrencyLimitsMock map[string]int
+
+func (m tenantConcurrencyLimitsMock) MaxConcurrentQueriesPerTenant(userID string) int {
+	return m[userID]
+}
+
+// blockingEngine runs the queries until they are unblocked, by their query string.
+type blockingEngine struct {
+	started chan string
+	unblock map[string]chan struct{}
+}
+
+func newBlockingEngine(queries ...string) *blockingEngine {
+	e := &blockingEngine{started: make(chan string, len(queries)), unblock: map[string]chan struct{}{}}
+	for _, q := range queries {
+		e.unblock[q] = make(chan struct{})
+	}
+	return e
+}
+
+func (e *blockingEngine) SetQueryLogger(promql.QueryLogger) {}
+
+func (e *blockingEngine) NewInstantQuery(_ context.Context, _ storage.Queryable, _ promql.QueryOpts, qs string, _ time.Time) (promql.Query, error) {
+	return &blockingQuery{engine: e, qs: qs}, nil
+}
+
+func (e *blockingEngine) NewRangeQuery(_ context.Context, _ storage.Queryable, _ promql.QueryOpts, qs string, _, _ time.Time, _ time.Duration) (promql.Query, error) {
+	return &blockingQuery{engine: e, qs: qs}, nil
+}
+
+type blockingQuery struct {
+	promql.Query
+	engine *blockingEngine
+	qs     string
+}
+
+func (q *blockingQuery) Exec(context.Context) *promql.Result {
+	q.engine.started <- q.qs
+	<-q.engine.unblock[q.qs]
+	return &promql.Result{Value: promql.Vector{}}
+}
+
+// execQuery runs the query of the tenant in the background, and returns the channel of its error.
+func execQuery(ctx context.Context, t *testing.T, e *tenantConcurrencyEngine, userID, qs string) chan error {
+	q, err := e.NewInstantQuery(user.InjectOrgID(ctx, userID), nil, nil, qs, time.Now())
+	require.NoError(t, err)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- q.Exec(user.InjectOrgID(ctx, userID)).Err
+	}()
+	return errs
+}
+
+func TestTenantConcurrencyEngine_ShouldQueueTheQueriesAboveTheLimit(t *testing.T) {
+	ctx := context.Background()
+	blocking := newBlockingEngine("first", "second", "third", "other")
+	reg := prometheus.NewPedanticRegistry()
+	e := NewTenantConcurrencyEngine(blocking, tenantConcurrencyLimitsMock{"user-1": 1}, time.Hour, reg).(*tenantConcurrencyEngine)
+
+	first := execQuery(ctx, t, e, "user-1", "first")
+	require.Equal(t, "first", <-blocking.started)
+	second := execQuery(ctx, t, e, "user-1", "second")
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(e.queuedQueries.WithLabelValues("user-1")) == 1
+	}, time.Second, 10*time.Millisecond)
+	third := execQuery(ctx, t, e, "user-1", "third")
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(e.queuedQueries.WithLabelValues("user-1")) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// The other tenants aren't limited.
+	other := execQuery(ctx, t, e, "user-2", "other")
+	require.Equal(t, "other", <-blocking.started)
+	close(blocking.unblock["other"])
+	require.NoError(t, <-other)
+
+	// The queued queries run in order, once the running query completes.
+	close(blocking.unblock["first"])
+	require.NoError(t, <-first)
+	require.Equal(t, "second", <-blocking.started)
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.runningQueries.WithLabelValues("user-1")))
+
+	close(blocking.unblock["second"])
+	require.NoError(t, <-second)
+	require.Equal(t, "third", <-blocking.started)
+	close(blocking.unblock["third"])
+	require.NoError(t, <-third)
+
+	assert.Empty(t, e.tenants)
+	assert.Equal(t, 0.0, testutil.ToFloat64(e.rejectedQueries.WithLabelValues("user-1")))
+}
+
+func TestTenantConcurrencyEngine_ShouldRejectTheQueriesQueuedForLongerThanTheTimeout(t *testing.T) {
+	ctx := context.Background()
+	blocking := newBlockingEngine("first", "second")
+	e := NewTenantConcurrencyEngine(blocking, tenantConcurrencyLimitsMock{"user-1": 1}, 50*time.Millisecond, nil).(*tenantConcurrencyEngine)
+
+	first := execQuery(ctx, t, e, "user-1", "first")
+	require.Equal(t, "first", <-blocking.started)
+
+	err := <-execQuery(ctx, t, e, "user-1", "second")
+	require.Error(t, err)
+	assert.IsType(t, validation.LimitError(""), err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.rejectedQueries.WithLabelValues("user-1")))
+
+	// The canceled queries aren't accounted as rejected.
+	cancelCtx, cancel := context.WithCancel(ctx)
+	canceled := execQuery(cancelCtx, t, e, "user-1", "second")
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(e.queuedQueries.WithLabelValues("user-1")) == 1
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-canceled, context.Canceled)
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.rejectedQueries.WithLabelValues("user-1")))
+
+	close(blocking.unblock["first"])
+	require.NoError(t, <-first)
+	assert.Empty(t, e.tenants)
+}
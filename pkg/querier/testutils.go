@@ -31,6 +31,10 @@ func (m *MockDistributor) QueryStream(ctx context.Context, from, to model.Time,
 	args := m.Called(ctx, from, to, matchers)
 	return args.Get(0).(*client.QueryStreamResponse), args.Error(1)
 }
+func (m *MockDistributor) QueryStreamSeries(ctx context.Context, from, to model.Time, bufferSize int, matchers ...*labels.Matcher) (client.QueryStreamSeriesSet, error) {
+	args := m.Called(ctx, from, to, bufferSize, matchers)
+	return args.Get(0).(client.QueryStreamSeriesSet), args.Error(1)
+}
 func (m *MockDistributor) LabelValuesForLabelName(ctx context.Context, from, to model.Time, lbl model.LabelName, matchers ...*labels.Matcher) ([]string, error) {
 	args := m.Called(ctx, from, to, lbl, matchers)
 	return args.Get(0).([]string), args.Error(1)
@@ -61,6 +65,11 @@ func (m *MockDistributor) MetricsMetadata(ctx context.Context) ([]scrape.MetricM
 	return args.Get(0).([]scrape.MetricMetadata), args.Error(1)
 }
 
+func (m *MockDistributor) TSDBStatus(ctx context.Context, limit int) (*client.TSDBStatusResponse, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).(*client.TSDBStatusResponse), args.Error(1)
+}
+
 type MockLimitingDistributor struct {
 	MockDistributor
 	response *client.QueryStreamResponse
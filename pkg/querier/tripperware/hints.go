@@ -0,0 +1,97 @@
+package tripperware
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxSourceResolutionHeader is the header the max source resolution hint is forwarded with.
+	MaxSourceResolutionHeader = "X-Cortex-Max-Source-Resolution"
+
+	// RequestHintsHeader is the header the generic hints are forwarded with, URL encoded.
+	RequestHintsHeader = "X-Cortex-Request-Hints"
+
+	// PartialResponseHeader is the header of the queries overriding the partial response mode of the tenant.
+	PartialResponseHeader = "X-Cortex-Partial-Response"
+
+	// PartialResponseHint is the generic hint the partial response mode is forwarded with.
+	PartialResponseHint = "partial_response"
+
+	// SeriesLimitHeader is the header of the queries limiting the series selected by each selector.
+	SeriesLimitHeader = "X-Cortex-Series-Limit"
+
+	// SeriesLimitHint is the generic hint the series limit is forwarded with.
+	SeriesLimitHint = "series_limit"
+)
+
+// WithMetadata returns a copy of the hints with the given generic hint set. Hints are
+// shared between the requests cloned from the same request, so they must never be modified in place.
+func (m RequestHints) WithMetadata(name, value string) RequestHints {
+	metadata := make(map[string]string, len(m.Metadata)+1)
+	for k, v := range m.Metadata {
+		metadata[k] = v
+	}
+	metadata[name] = value
+
+	m.Metadata = metadata
+	return m
+}
+
+// EncodeHints sets the headers forwarding the hints to a downstream request.
+func EncodeHints(hints RequestHints, h http.Header) {
+	if hints.MaxSourceResolution > 0 {
+		h.Set(MaxSourceResolutionHeader, strconv.FormatInt(hints.MaxSourceResolution, 10))
+	}
+
+	if len(hints.Metadata) > 0 {
+		values := url.Values{}
+		for name, value := range hints.Metadata {
+			values.Set(name, value)
+		}
+		h.Set(RequestHintsHeader, values.Encode())
+	}
+}
+
+// DecodeHints reads the hints forwarded by an upstream request.
+func DecodeHints(h http.Header) (RequestHints, error) {
+	hints := RequestHints{}
+
+	if v := h.Get(MaxSourceResolutionHeader); v != "" {
+		resolution, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || resolution < 0 {
+			return RequestHints{}, errors.Errorf("invalid %s header %q", MaxSourceResolutionHeader, v)
+		}
+		hints.MaxSourceResolution = resolution
+	}
+
+	if v := h.Get(RequestHintsHeader); v != "" {
+		values, err := url.ParseQuery(v)
+		if err != nil {
+			return RequestHints{}, errors.Wrapf(err, "invalid %s header", RequestHintsHeader)
+		}
+		hints.Metadata = make(map[string]string, len(values))
+		for name := range values {
+			hints.Metadata[name] = values.Get(name)
+		}
+	}
+
+	if v := h.Get(PartialResponseHeader); v != "" {
+		if _, err := strconv.ParseBool(v); err != nil {
+			return RequestHints{}, errors.Errorf("invalid %s header %q", PartialResponseHeader, v)
+		}
+		hints = hints.WithMetadata(PartialResponseHint, v)
+	}
+
+	if v := h.Get(SeriesLimitHeader); v != "" {
+		if limit, err := strconv.Atoi(v); err != nil || limit < 0 {
+			return RequestHints{}, errors.Errorf("invalid %s header %q", SeriesLimitHeader, v)
+		}
+		hints = hints.WithMetadata(SeriesLimitHint, v)
+	}
+
+	return hints, nil
+}
@@ -0,0 +1,91 @@
+package tripperware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHints_EncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	for name, hints := range map[string]RequestHints{
+		"empty":                 {},
+		"max source resolution": {MaxSourceResolution: 300000},
+		"metadata":              {Metadata: map[string]string{"cache_policy": "bypass", "shard": "1_of_4"}},
+		"all": {
+			MaxSourceResolution: 3600000,
+			Metadata:            map[string]string{"priority": "high & urgent"},
+		},
+	} {
+		hints := hints
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := http.Header{}
+			EncodeHints(hints, h)
+
+			actual, err := DecodeHints(h)
+			require.NoError(t, err)
+			assert.Equal(t, hints, actual)
+		})
+	}
+}
+
+func TestDecodeHints_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := DecodeHints(http.Header{MaxSourceResolutionHeader: []string{"-1"}})
+	require.EqualError(t, err, `invalid X-Cortex-Max-Source-Resolution header "-1"`)
+
+	_, err = DecodeHints(http.Header{RequestHintsHeader: []string{"%zz"}})
+	require.Error(t, err)
+
+	_, err = DecodeHints(http.Header{PartialResponseHeader: []string{"maybe"}})
+	require.EqualError(t, err, `invalid X-Cortex-Partial-Response header "maybe"`)
+
+	_, err = DecodeHints(http.Header{SeriesLimitHeader: []string{"-1"}})
+	require.EqualError(t, err, `invalid X-Cortex-Series-Limit header "-1"`)
+}
+
+func TestDecodeHints_PartialResponse(t *testing.T) {
+	t.Parallel()
+
+	// The partial response header is forwarded as a generic hint.
+	hints, err := DecodeHints(http.Header{PartialResponseHeader: []string{"true"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{PartialResponseHint: "true"}, hints.Metadata)
+
+	h := http.Header{}
+	EncodeHints(hints, h)
+	actual, err := DecodeHints(h)
+	require.NoError(t, err)
+	assert.Equal(t, hints, actual)
+}
+
+func TestDecodeHints_SeriesLimit(t *testing.T) {
+	t.Parallel()
+
+	// The series limit header is forwarded as a generic hint.
+	hints, err := DecodeHints(http.Header{SeriesLimitHeader: []string{"100"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{SeriesLimitHint: "100"}, hints.Metadata)
+
+	h := http.Header{}
+	EncodeHints(hints, h)
+	actual, err := DecodeHints(h)
+	require.NoError(t, err)
+	assert.Equal(t, hints, actual)
+}
+
+func TestRequestHints_WithMetadata(t *testing.T) {
+	t.Parallel()
+
+	original := RequestHints{Metadata: map[string]string{"a": "1"}}
+	updated := original.WithMetadata("b", "2")
+
+	assert.Equal(t, map[string]string{"a": "1"}, original.Metadata)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, updated.Metadata)
+}
@@ -0,0 +1,123 @@
+package tripperware
+
+import (
+	"flag"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+// InfoLabelsParam is the parameter of the query and query range requests listing the labels of
+// the info metric to join onto the query results.
+const InfoLabelsParam = "info_labels"
+
+// InfoJoinConfig configures the join of the info metric labels onto the query results.
+type InfoJoinConfig struct {
+	Enabled    bool                   `yaml:"enabled"`
+	MetricName string                 `yaml:"metric_name"`
+	JoinLabels flagext.StringSliceCSV `yaml:"join_labels"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *InfoJoinConfig) RegisterFlags(f *flag.FlagSet) {
+	cfg.JoinLabels = []string{"job", "instance"}
+
+	f.BoolVar(&cfg.Enabled, "frontend.info-join.enabled", false, "Experimental: Join the labels of the info metric listed in the "+InfoLabelsParam+" parameter of the query and query range requests onto the query results, like the OpenTelemetry resource attributes of target_info. The query is rewritten to (<query>) * on(<join labels>) group_left(<info labels>) <info metric>, so the metric name is dropped from the results like with any such join.")
+	f.StringVar(&cfg.MetricName, "frontend.info-join.metric-name", "target_info", "Name of the info metric whose labels are joined.")
+	f.Var(&cfg.JoinLabels, "frontend.info-join.join-labels", "Comma-separated list of labels identifying the target of the series in both the query results and the info metric.")
+}
+
+// Validate validates the config.
+func (cfg *InfoJoinConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if !model.IsValidMetricName(model.LabelValue(cfg.MetricName)) {
+		return errors.Errorf("invalid info join metric name %q", cfg.MetricName)
+	}
+	if len(cfg.JoinLabels) == 0 {
+		return errors.New("the info join labels must not be empty")
+	}
+	for _, name := range cfg.JoinLabels {
+		if !model.LabelName(name).IsValid() {
+			return errors.Errorf("invalid info join label %q", name)
+		}
+	}
+	return nil
+}
+
+// newInfoJoinRoundTripper rewrites the query and query range requests with the InfoLabelsParam
+// parameter to join the listed labels of the info metric onto the query results.
+func newInfoJoinRoundTripper(next http.RoundTripper, cfg InfoJoinConfig) http.RoundTripper {
+	return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if !strings.HasSuffix(r.URL.Path, "/query") && !strings.HasSuffix(r.URL.Path, "/query_range") {
+			return next.RoundTrip(r)
+		}
+		if err := r.ParseForm(); err != nil {
+			return next.RoundTrip(r)
+		}
+		infoLabels := r.FormValue(InfoLabelsParam)
+		if infoLabels == "" {
+			return next.RoundTrip(r)
+		}
+
+		query, err := joinInfoLabels(r.FormValue("query"), cfg, strings.Split(infoLabels, ","))
+		if err != nil {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+		}
+
+		form := cloneForm(r)
+		form.Set("query", query)
+		form.Del(InfoLabelsParam)
+		return next.RoundTrip(withForm(r, form))
+	})
+}
+
+// joinInfoLabels returns the query joining the given labels of the info metric onto the results
+// of the given query. The queries which can't be parsed are returned as is, so that they fail in
+// the queriers like the others.
+func joinInfoLabels(query string, cfg InfoJoinConfig, infoLabels []string) (string, error) {
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return query, nil
+	}
+	if expr.Type() != parser.ValueTypeVector {
+		return "", errors.Errorf("the %s parameter is only supported by the queries returning an instant vector, got %s", InfoLabelsParam, parser.DocumentedType(expr.Type()))
+	}
+
+	include := make([]string, 0, len(infoLabels))
+	for _, name := range infoLabels {
+		name = strings.TrimSpace(name)
+		if !model.LabelName(name).IsValid() {
+			return "", errors.Errorf("invalid label name %q in the %s parameter", name, InfoLabelsParam)
+		}
+		if util.StringsContain(cfg.JoinLabels, name) {
+			return "", errors.Errorf("the label %q in the %s parameter is already a join label", name, InfoLabelsParam)
+		}
+		include = append(include, name)
+	}
+
+	joined := &parser.BinaryExpr{
+		Op:  parser.MUL,
+		LHS: &parser.ParenExpr{Expr: expr},
+		RHS: &parser.VectorSelector{
+			Name:          cfg.MetricName,
+			LabelMatchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, model.MetricNameLabel, cfg.MetricName)},
+		},
+		VectorMatching: &parser.VectorMatching{
+			Card:           parser.CardManyToOne,
+			MatchingLabels: cfg.JoinLabels,
+			On:             true,
+			Include:        include,
+		},
+	}
+	return joined.String(), nil
+}
@@ -0,0 +1,134 @@
+package tripperware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+func TestInfoJoinConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, (&InfoJoinConfig{}).Validate())
+	assert.NoError(t, (&InfoJoinConfig{Enabled: true, MetricName: "target_info", JoinLabels: []string{"job", "instance"}}).Validate())
+	assert.EqualError(t, (&InfoJoinConfig{Enabled: true, MetricName: "target-info", JoinLabels: []string{"job"}}).Validate(), `invalid info join metric name "target-info"`)
+	assert.EqualError(t, (&InfoJoinConfig{Enabled: true, MetricName: "target_info"}).Validate(), "the info join labels must not be empty")
+	assert.EqualError(t, (&InfoJoinConfig{Enabled: true, MetricName: "target_info", JoinLabels: []string{"job", "in-stance"}}).Validate(), `invalid info join label "in-stance"`)
+}
+
+func TestJoinInfoLabels(t *testing.T) {
+	t.Parallel()
+	cfg := InfoJoinConfig{Enabled: true, MetricName: "target_info", JoinLabels: []string{"job", "instance"}}
+
+	for _, tc := range []struct {
+		name          string
+		query         string
+		infoLabels    []string
+		expectedQuery string
+		expectedErr   string
+	}{
+		{
+			name:          "vector selector",
+			query:         `http_requests_total{status="500"}`,
+			infoLabels:    []string{"k8s_cluster_name", " service_version"},
+			expectedQuery: `(http_requests_total{status="500"}) * on (job, instance) group_left (k8s_cluster_name, service_version) target_info`,
+		},
+		{
+			name:          "aggregation",
+			query:         `sum by (job, instance) (rate(http_requests_total[5m]))`,
+			infoLabels:    []string{"k8s_cluster_name"},
+			expectedQuery: `(sum by (job, instance) (rate(http_requests_total[5m]))) * on (job, instance) group_left (k8s_cluster_name) target_info`,
+		},
+		{
+			name:          "invalid query",
+			query:         `sum(`,
+			infoLabels:    []string{"k8s_cluster_name"},
+			expectedQuery: `sum(`,
+		},
+		{
+			name:        "range vector",
+			query:       `up[5m]`,
+			infoLabels:  []string{"k8s_cluster_name"},
+			expectedErr: "the info_labels parameter is only supported by the queries returning an instant vector, got range vector",
+		},
+		{
+			name:        "scalar",
+			query:       `1`,
+			infoLabels:  []string{"k8s_cluster_name"},
+			expectedErr: "the info_labels parameter is only supported by the queries returning an instant vector, got scalar",
+		},
+		{
+			name:        "invalid label name",
+			query:       `up`,
+			infoLabels:  []string{"k8s.cluster.name"},
+			expectedErr: `invalid label name "k8s.cluster.name" in the info_labels parameter`,
+		},
+		{
+			name:        "join label",
+			query:       `up`,
+			infoLabels:  []string{"instance"},
+			expectedErr: `the label "instance" in the info_labels parameter is already a join label`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, err := joinInfoLabels(tc.query, cfg, tc.infoLabels)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedQuery, query)
+		})
+	}
+}
+
+func TestInfoJoinRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	var received *http.Request
+	next := RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		received = r
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	cfg := InfoJoinConfig{Enabled: true, MetricName: "target_info", JoinLabels: []string{"job", "instance"}}
+	roundTripper := newInfoJoinRoundTripper(next, cfg)
+
+	// The query range request is rewritten, without the info labels parameter.
+	params := url.Values{"query": {"up"}, "start": {"0"}, "end": {"100"}, "step": {"10"}, InfoLabelsParam: {"service_version"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/query_range", strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err := roundTripper.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"query": {"(up) * on (job, instance) group_left (service_version) target_info"},
+		"start": {"0"},
+		"end":   {"100"},
+		"step":  {"10"},
+	}, received.URL.Query())
+
+	// The requests without info labels and the other endpoints aren't rewritten.
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil)
+	_, err = roundTripper.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Same(t, req, received)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/series?match[]=up&"+InfoLabelsParam+"=service_version", nil)
+	_, err = roundTripper.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Same(t, req, received)
+
+	// The invalid info labels are rejected.
+	_, err = roundTripper.RoundTrip(httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up&"+InfoLabelsParam+"=job", nil))
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+}
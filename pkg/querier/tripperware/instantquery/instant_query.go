@@ -24,6 +24,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware/queryrange"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/spanlogger"
 )
@@ -45,6 +46,7 @@ type PrometheusRequest struct {
 	Query   string
 	Path    string
 	Headers http.Header
+	Hints   tripperware.RequestHints
 }
 
 // GetTime returns time in milliseconds.
@@ -104,6 +106,18 @@ func (r *PrometheusRequest) WithStats(stats string) tripperware.Request {
 	return &q
 }
 
+// GetHints returns the hints of the request.
+func (r *PrometheusRequest) GetHints() tripperware.RequestHints {
+	return r.Hints
+}
+
+// WithHints clones the current `PrometheusRequest` with new hints.
+func (r *PrometheusRequest) WithHints(hints tripperware.RequestHints) tripperware.Request {
+	q := *r
+	q.Hints = hints
+	return &q
+}
+
 type instantQueryCodec struct {
 	tripperware.Codec
 	now func() time.Time
@@ -139,6 +153,19 @@ func (c instantQueryCodec) DecodeRequest(_ context.Context, r *http.Request, for
 	result.Stats = r.FormValue("stats")
 	result.Path = r.URL.Path
 
+	result.Hints, err = tripperware.DecodeHints(r.Header)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	// Instant queries have no step, so "auto" selects raw data.
+	if v := r.FormValue(downsample.MaxSourceResolutionParam); v != "" {
+		result.Hints.MaxSourceResolution, err = downsample.ParseMaxSourceResolution(v, 0)
+		if err != nil {
+			return nil, decorateWithParamName(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), downsample.MaxSourceResolutionParam)
+		}
+	}
+
 	// Include the specified headers from http request in prometheusRequest.
 	for _, header := range forwardHeaders {
 		for h, hv := range r.Header {
@@ -207,6 +234,8 @@ func (instantQueryCodec) EncodeRequest(ctx context.Context, r tripperware.Reques
 		}
 	}
 
+	tripperware.EncodeHints(promReq.Hints, h)
+
 	// Always ask gzip to the querier
 	h.Set("Accept-Encoding", "gzip")
 
@@ -57,6 +57,19 @@ func TestRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			url:         "/api/v1/query?max_source_resolution=1h&query=sum%28container_memory_rss%29+by+%28namespace%29&time=1536673680",
+			expectedURL: "/api/v1/query?query=sum%28container_memory_rss%29+by+%28namespace%29&time=1536673680",
+			expected: &PrometheusRequest{
+				Path:  "/api/v1/query",
+				Time:  1536673680 * 1e3,
+				Query: "sum(container_memory_rss) by (namespace)",
+				Hints: tripperware.RequestHints{MaxSourceResolution: 3600 * 1e3},
+				Headers: map[string][]string{
+					"Test-Header": {"test"},
+				},
+			},
+		},
 		{
 			url:         "/api/v1/query?query=sum%28container_memory_rss%29+by+%28namespace%29",
 			expectedURL: "/api/v1/query?query=sum%28container_memory_rss%29+by+%28namespace%29&time=",
@@ -98,6 +111,10 @@ func TestRequest(t *testing.T) {
 			rdash, err := codec.EncodeRequest(context.Background(), req)
 			require.NoError(t, err)
 			require.EqualValues(t, tc.expectedURL, rdash.RequestURI)
+
+			hints, err := tripperware.DecodeHints(rdash.Header)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected.Hints.MaxSourceResolution, hints.MaxSourceResolution)
 		})
 	}
 }
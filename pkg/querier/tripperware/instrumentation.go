@@ -2,14 +2,32 @@ package tripperware
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/instrument"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// Outcomes of the requests handled by an instrumented middleware.
+const (
+	outcomeSuccess     = "success"
+	outcomeCanceled    = "canceled"
+	outcomeTimeout     = "timeout"
+	outcomeClientError = "client_error"
+	outcomeServerError = "server_error"
 )
 
 // InstrumentMiddleware can be inserted into the middleware chain to expose timing information.
+// The metrics track the time spent in all the middlewares following it in the chain, so the
+// latency of a single middleware is the difference with the next instrumented one.
 func InstrumentMiddleware(name string, metrics *InstrumentMiddlewareMetrics) Middleware {
 	var durationCol instrument.Collector
 
@@ -23,31 +41,121 @@ func InstrumentMiddleware(name string, metrics *InstrumentMiddlewareMetrics) Mid
 	return MiddlewareFunc(func(next Handler) Handler {
 		return HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
 			var resp Response
+			start := time.Now()
 			err := instrument.CollectedRequest(ctx, name, durationCol, instrument.ErrorCode, func(ctx context.Context) error {
 				var err error
 				resp, err = next.Do(ctx, req)
 				return err
 			})
+			if metrics != nil {
+				metrics.observeTenant(ctx, name, time.Since(start), err)
+			}
 			return resp, err
 		})
 	})
 }
 
+// SubRequestsMiddleware can be inserted into the middleware chain right after a middleware
+// issuing sub-requests (eg. splitting or sharding the query), to count them.
+func SubRequestsMiddleware(name string, metrics *InstrumentMiddlewareMetrics) Middleware {
+	return MiddlewareFunc(func(next Handler) Handler {
+		if metrics == nil {
+			return next
+		}
+
+		return HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+			if userID, err := tenantID(ctx); err == nil {
+				metrics.subRequests.WithLabelValues(name, userID).Inc()
+			}
+			return next.Do(ctx, req)
+		})
+	})
+}
+
 // InstrumentMiddlewareMetrics holds the metrics tracked by InstrumentMiddleware.
 type InstrumentMiddlewareMetrics struct {
 	duration *prometheus.HistogramVec
+
+	// Per tenant metrics.
+	requests    *prometheus.CounterVec
+	seconds     *prometheus.CounterVec
+	subRequests *prometheus.CounterVec
+	activeUsers *util.ActiveUsersCleanupService
 }
 
 // NewInstrumentMiddlewareMetrics makes a new InstrumentMiddlewareMetrics.
-func NewInstrumentMiddlewareMetrics(registerer prometheus.Registerer) *InstrumentMiddlewareMetrics {
-	return &InstrumentMiddlewareMetrics{
+func NewInstrumentMiddlewareMetrics(registerer prometheus.Registerer, logger log.Logger) *InstrumentMiddlewareMetrics {
+	m := &InstrumentMiddlewareMetrics{
 		duration: promauto.With(registerer).NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: "cortex",
 			Name:      "frontend_query_range_duration_seconds",
 			Help:      "Total time spent in seconds doing query range requests.",
 			Buckets:   prometheus.DefBuckets,
 		}, []string{"method", "status_code"}),
+		requests: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "frontend_query_range_middleware_requests_total",
+			Help:      "Total number of query range requests handled by each middleware per tenant, by outcome.",
+		}, []string{"method", "user", "outcome"}),
+		seconds: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "frontend_query_range_middleware_seconds_total",
+			Help:      "Total time spent in seconds handling query range requests by each middleware per tenant, including the following middlewares.",
+		}, []string{"method", "user"}),
+		subRequests: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "frontend_query_range_middleware_sub_requests_total",
+			Help:      "Total number of sub-requests issued by each middleware per tenant.",
+		}, []string{"method", "user"}),
+	}
+
+	m.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(func(user string) {
+		for _, vec := range []*prometheus.CounterVec{m.requests, m.seconds, m.subRequests} {
+			if err := util.DeleteMatchingLabels(vec, map[string]string{"user": user}); err != nil {
+				level.Warn(logger).Log("msg", "failed to remove query range middleware metrics for user", "user", user, "err", err)
+			}
+		}
+	})
+	// If cleaner stops or fail, we will simply not clean the metrics for inactive users.
+	_ = m.activeUsers.StartAsync(context.Background())
+
+	return m
+}
+
+func (m *InstrumentMiddlewareMetrics) observeTenant(ctx context.Context, method string, duration time.Duration, err error) {
+	userID, tenantErr := tenantID(ctx)
+	if tenantErr != nil {
+		return
+	}
+
+	m.activeUsers.UpdateUserTimestamp(userID, time.Now())
+	m.requests.WithLabelValues(method, userID, classifyOutcome(err)).Inc()
+	m.seconds.WithLabelValues(method, userID).Add(duration.Seconds())
+}
+
+func tenantID(ctx context.Context) (string, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return "", err
+	}
+	return tenant.JoinTenantIDs(tenantIDs), nil
+}
+
+// classifyOutcome classifies the error returned by a middleware.
+func classifyOutcome(err error) string {
+	switch {
+	case err == nil:
+		return outcomeSuccess
+	case errors.Is(err, context.Canceled):
+		return outcomeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return outcomeTimeout
+	}
+
+	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok && resp.Code/100 == 4 {
+		return outcomeClientError
 	}
+	return outcomeServerError
 }
 
 // NoopCollector is a noop collector that can be used as placeholder when no metric
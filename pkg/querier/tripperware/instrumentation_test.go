@@ -0,0 +1,79 @@
+package tripperware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+)
+
+func TestClassifyOutcome(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err      error
+		expected string
+	}{
+		"success":       {err: nil, expected: outcomeSuccess},
+		"canceled":      {err: context.Canceled, expected: outcomeCanceled},
+		"deadline":      {err: context.DeadlineExceeded, expected: outcomeTimeout},
+		"client error":  {err: httpgrpc.Errorf(http.StatusBadRequest, "bad"), expected: outcomeClientError},
+		"server error":  {err: httpgrpc.Errorf(http.StatusInternalServerError, "boom"), expected: outcomeServerError},
+		"generic error": {err: errors.New("boom"), expected: outcomeServerError},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, classifyOutcome(tc.err))
+		})
+	}
+}
+
+func TestInstrumentMiddleware_PerTenantMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	metrics := NewInstrumentMiddlewareMetrics(reg, log.NewNopLogger())
+
+	// The split middleware issues two sub-requests, the second one failing.
+	calls := 0
+	downstream := HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+		calls++
+		if calls == 2 {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, "bad")
+		}
+		return nil, nil
+	})
+	split := MiddlewareFunc(func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+			if _, err := next.Do(ctx, req); err != nil {
+				return nil, err
+			}
+			return next.Do(ctx, req)
+		})
+	})
+
+	handler := MergeMiddlewares(
+		InstrumentMiddleware("split_by_interval", metrics),
+		split,
+		SubRequestsMiddleware("split_by_interval", metrics),
+		InstrumentMiddleware("results_cache", metrics),
+	).Wrap(downstream)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	_, err := handler.Do(ctx, nil)
+	require.Error(t, err)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_query_range_middleware_requests_total Total number of query range requests handled by each middleware per tenant, by outcome.
+		# TYPE cortex_frontend_query_range_middleware_requests_total counter
+		cortex_frontend_query_range_middleware_requests_total{method="results_cache",outcome="client_error",user="user-1"} 1
+		cortex_frontend_query_range_middleware_requests_total{method="results_cache",outcome="success",user="user-1"} 1
+		cortex_frontend_query_range_middleware_requests_total{method="split_by_interval",outcome="client_error",user="user-1"} 1
+		# HELP cortex_frontend_query_range_middleware_sub_requests_total Total number of sub-requests issued by each middleware per tenant.
+		# TYPE cortex_frontend_query_range_middleware_sub_requests_total counter
+		cortex_frontend_query_range_middleware_sub_requests_total{method="split_by_interval",user="user-1"} 2
+	`), "cortex_frontend_query_range_middleware_requests_total", "cortex_frontend_query_range_middleware_sub_requests_total"))
+}
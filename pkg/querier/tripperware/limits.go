@@ -28,4 +28,22 @@ type Limits interface {
 
 	// QueryPriority returns the query priority config for the tenant, including different priorities and their attributes.
 	QueryPriority(userID string) validation.QueryPriority
+
+	// QueryAllowedFunctions returns the PromQL functions the tenant is allowed to use, or an empty list if all are allowed.
+	QueryAllowedFunctions(userID string) []string
+
+	// QuerySubqueriesDisabled returns whether the tenant is not allowed to use subqueries.
+	QuerySubqueriesDisabled(userID string) bool
+
+	// QueryMaxAtModifierLookback returns how far in the past the @ modifier timestamps of the tenant's queries can be.
+	QueryMaxAtModifierLookback(userID string) time.Duration
+
+	// CacheWarmupMaxQueries returns the maximum number of queries whose results cache entries are warmed up for the tenant.
+	CacheWarmupMaxQueries(userID string) int
+
+	// QueryEndTimeOffset returns how far back from now the end time of the tenant's queries is moved.
+	QueryEndTimeOffset(userID string) time.Duration
+
+	// QueryTimeZone returns the time zone the steps of the tenant's queries are aligned to when requested, or an empty string for UTC.
+	QueryTimeZone(userID string) string
 }
@@ -0,0 +1,303 @@
+package tripperware
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	metadataEndpointLabels      = "labels"
+	metadataEndpointLabelValues = "label_values"
+	metadataEndpointSeries      = "series"
+
+	// The key of the cached response is stored along with it, to detect the collisions of the hashed keys.
+	metadataCacheKeyHeader = "X-Cortex-Metadata-Cache-Key"
+
+	// The content type of the metadata responses encoded as protobuf by the queriers.
+	metadataProtobufContentType = "application/x-protobuf"
+)
+
+var (
+	labelValuesPathRegexp = regexp.MustCompile(`/label/([^/]+)/values$`)
+
+	// The headers of the responses kept in the cache.
+	metadataCachedHeaders = []string{"Content-Type", "Content-Encoding"}
+)
+
+// MetadataCacheConfig configures the cache of the labels, label values and series responses.
+type MetadataCacheConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	TTL         time.Duration `yaml:"ttl"`
+	CacheConfig cache.Config  `yaml:"cache"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *MetadataCacheConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "frontend.metadata-cache.enabled", false, "Experimental: Cache the responses of the labels, label values and series requests, like the Grafana variable queries. The requests with the same matchers, regardless of their order, and with a start and end within the same TTL period share the cached response.")
+	f.DurationVar(&cfg.TTL, "frontend.metadata-cache.ttl", time.Minute, "How long the responses of the labels, label values and series requests are served from the cache. The start and end of the requests are aligned to it.")
+	cfg.CacheConfig.RegisterFlagsWithPrefix("frontend.metadata-cache.", "", f)
+}
+
+// Validate validates the config.
+func (cfg *MetadataCacheConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TTL <= 0 {
+		return errors.New("the metadata cache TTL must be positive")
+	}
+	return cfg.CacheConfig.Validate()
+}
+
+// MetadataCache serves the labels, label values and series requests from the cache.
+type MetadataCache struct {
+	cfg    MetadataCacheConfig
+	cache  cache.Cache
+	logger log.Logger
+
+	requests *prometheus.CounterVec
+	hits     *prometheus.CounterVec
+}
+
+// NewMetadataCache makes a new MetadataCache.
+func NewMetadataCache(cfg MetadataCacheConfig, logger log.Logger, reg prometheus.Registerer) (*MetadataCache, error) {
+	// The entries are never read once their TTL period is over.
+	if cfg.CacheConfig.DefaultValidity == 0 {
+		cfg.CacheConfig.DefaultValidity = 2 * cfg.TTL
+	}
+	c, err := cache.New(cfg.CacheConfig, reg, logger)
+	if err != nil {
+		return nil, err
+	}
+	if cache.IsEmptyTieredCache(c) {
+		return nil, errors.New("the metadata cache requires a cache backend")
+	}
+
+	return &MetadataCache{
+		cfg:    cfg,
+		cache:  c,
+		logger: logger,
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_metadata_cache_requests_total",
+			Help: "Total number of labels, label values and series requests looked up in the metadata cache.",
+		}, []string{"endpoint"}),
+		hits: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_metadata_cache_hits_total",
+			Help: "Total number of labels, label values and series requests served from the metadata cache.",
+		}, []string{"endpoint"}),
+	}, nil
+}
+
+// Wrap returns a tripperware serving the metadata requests from the cache, and the other requests
+// and the cache misses with the given tripperware.
+func (m *MetadataCache) Wrap(tw Tripperware) Tripperware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		rt := tw(next)
+		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return m.roundTrip(rt, r)
+		})
+	}
+}
+
+// Stop stops the cache.
+func (m *MetadataCache) Stop() {
+	m.cache.Stop()
+}
+
+func (m *MetadataCache) roundTrip(next http.RoundTripper, r *http.Request) (*http.Response, error) {
+	endpoint, name := metadataEndpoint(r.URL.Path)
+	if endpoint == "" {
+		return next.RoundTrip(r)
+	}
+	if err := r.ParseForm(); err != nil {
+		return next.RoundTrip(r)
+	}
+	// The body of the POST requests has been consumed by the parsing.
+	downstream := withForm(r, cloneForm(r))
+
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return next.RoundTrip(downstream)
+	}
+	key, ok := metadataCacheKey(tenant.JoinTenantIDs(tenantIDs), endpoint, name, r.Form, m.cfg.TTL, time.Now())
+	if !ok {
+		// The invalid requests are rejected by the queriers.
+		return next.RoundTrip(downstream)
+	}
+
+	// The responses encoded as JSON and protobuf are cached separately.
+	if strings.Contains(r.Header.Get("Accept"), metadataProtobufContentType) {
+		key += ":protobuf"
+	}
+
+	m.requests.WithLabelValues(endpoint).Inc()
+	if resp, ok := m.get(r.Context(), key); ok {
+		m.hits.WithLabelValues(endpoint).Inc()
+		return resp, nil
+	}
+
+	resp, err := next.RoundTrip(downstream)
+	if err != nil || resp.StatusCode != http.StatusOK || strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	m.put(r.Context(), key, resp.Header, body)
+	return resp, nil
+}
+
+func (m *MetadataCache) get(ctx context.Context, key string) (*http.Response, bool) {
+	found, bufs, _ := m.cache.Fetch(ctx, []string{cache.HashKey(key)})
+	if len(found) != 1 {
+		return nil, false
+	}
+
+	var cached httpgrpc.HTTPResponse
+	if err := proto.Unmarshal(bufs[0], &cached); err != nil {
+		level.Error(util_log.WithContext(ctx, m.logger)).Log("msg", "error unmarshalling the cached metadata response", "err", err)
+		return nil, false
+	}
+
+	header := http.Header{}
+	for _, h := range cached.Headers {
+		header[h.Key] = h.Values
+	}
+	if header.Get(metadataCacheKeyHeader) != key {
+		return nil, false
+	}
+	header.Del(metadataCacheKeyHeader)
+
+	return &http.Response{
+		StatusCode:    int(cached.Code),
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+	}, true
+}
+
+func (m *MetadataCache) put(ctx context.Context, key string, header http.Header, body []byte) {
+	cached := httpgrpc.HTTPResponse{
+		Code:    http.StatusOK,
+		Headers: []*httpgrpc.Header{{Key: metadataCacheKeyHeader, Values: []string{key}}},
+		Body:    body,
+	}
+	for _, name := range metadataCachedHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			cached.Headers = append(cached.Headers, &httpgrpc.Header{Key: name, Values: values})
+		}
+	}
+
+	buf, err := proto.Marshal(&cached)
+	if err != nil {
+		level.Error(util_log.WithContext(ctx, m.logger)).Log("msg", "error marshalling the metadata response", "err", err)
+		return
+	}
+	m.cache.Store(ctx, []string{cache.HashKey(key)}, [][]byte{buf})
+}
+
+// metadataEndpoint returns the metadata endpoint of the path, and the label name of the label
+// values endpoint, or an empty endpoint if the path isn't a metadata endpoint.
+func metadataEndpoint(path string) (string, string) {
+	switch {
+	case strings.HasSuffix(path, "/labels"):
+		return metadataEndpointLabels, ""
+	case strings.HasSuffix(path, "/series"):
+		return metadataEndpointSeries, ""
+	}
+	if m := labelValuesPathRegexp.FindStringSubmatch(path); m != nil {
+		return metadataEndpointLabelValues, m[1]
+	}
+	return "", ""
+}
+
+// metadataCacheKey returns the cache key of the metadata request, made of its start and end
+// aligned to the TTL, its selectors with their matchers sorted, and the current TTL period.
+// It returns false if the parameters of the request are invalid.
+func metadataCacheKey(userID, endpoint, name string, form url.Values, ttl time.Duration, now time.Time) (string, bool) {
+	period := ttl.Milliseconds()
+	alignedTime := func(param string) (string, bool) {
+		value := form.Get(param)
+		if value == "" {
+			return "", true
+		}
+		t, err := util.ParseTime(value)
+		if err != nil {
+			return "", false
+		}
+		return strconv.FormatInt(t-t%period, 10), true
+	}
+
+	start, ok := alignedTime("start")
+	if !ok {
+		return "", false
+	}
+	end, ok := alignedTime("end")
+	if !ok {
+		return "", false
+	}
+
+	// The responses are the union of the series matched by the selectors.
+	unique := map[string]struct{}{}
+	for _, s := range form["match[]"] {
+		matchers, err := parser.ParseMetricSelector(s)
+		if err != nil {
+			return "", false
+		}
+		unique[canonicalSelector(matchers)] = struct{}{}
+	}
+	selectors := make([]string, 0, len(unique))
+	for s := range unique {
+		selectors = append(selectors, s)
+	}
+	sort.Strings(selectors)
+
+	return fmt.Sprintf("metadata:%s:%s:%s:%s:%s:%s:%s:%d", userID, endpoint, name, start, end, strings.Join(selectors, ","), form.Get("limit"), now.UnixMilli()/period), true
+}
+
+func canonicalSelector(matchers []*labels.Matcher) string {
+	sort.Slice(matchers, func(i, j int) bool {
+		if matchers[i].Name != matchers[j].Name {
+			return matchers[i].Name < matchers[j].Name
+		}
+		if matchers[i].Type != matchers[j].Type {
+			return matchers[i].Type < matchers[j].Type
+		}
+		return matchers[i].Value < matchers[j].Value
+	})
+
+	parts := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		parts = append(parts, m.String())
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,119 @@
+package tripperware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+)
+
+func TestMetadataCacheKey(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	key := func(form url.Values, now time.Time) string {
+		k, ok := metadataCacheKey("user-1", metadataEndpointSeries, "", form, time.Minute, now)
+		require.True(t, ok)
+		return k
+	}
+
+	reference := key(url.Values{"match[]": {`up{job="a",env=~"prod"}`, "node_load1"}, "start": {"1200"}, "end": {"1800"}}, now)
+
+	// The order of the selectors and matchers, and the time within the TTL period don't matter.
+	assert.Equal(t, reference, key(url.Values{"match[]": {"node_load1", `{env=~"prod",__name__="up",job="a"}`, "node_load1"}, "start": {"1230.5"}, "end": {"1830"}}, now.Add(10*time.Second)))
+
+	// The start, end, selectors, limit and TTL period do.
+	assert.NotEqual(t, reference, key(url.Values{"match[]": {`up{job="a",env=~"prod"}`, "node_load1"}, "start": {"1260"}, "end": {"1800"}}, now))
+	assert.NotEqual(t, reference, key(url.Values{"match[]": {`up{job="a"}`, "node_load1"}, "start": {"1200"}, "end": {"1800"}}, now))
+	assert.NotEqual(t, reference, key(url.Values{"match[]": {`up{job="a",env=~"prod"}`, "node_load1"}, "start": {"1200"}, "end": {"1800"}, "limit": {"10"}}, now))
+	assert.NotEqual(t, reference, key(url.Values{"match[]": {`up{job="a",env=~"prod"}`, "node_load1"}, "start": {"1200"}, "end": {"1800"}}, now.Add(time.Minute)))
+
+	// The invalid requests aren't cached.
+	_, ok := metadataCacheKey("user-1", metadataEndpointSeries, "", url.Values{"match[]": {"up{"}}, time.Minute, now)
+	assert.False(t, ok)
+	_, ok = metadataCacheKey("user-1", metadataEndpointSeries, "", url.Values{"start": {"yesterday"}}, time.Minute, now)
+	assert.False(t, ok)
+}
+
+func TestMetadataCache_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	requests := atomic.NewInt64(0)
+	status := atomic.NewInt64(http.StatusOK)
+	next := RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests.Inc()
+		assert.Equal(t, http.MethodGet, r.Method)
+		return &http.Response{
+			StatusCode: int(status.Load()),
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"status":"success","data":["` + r.URL.Path + `"]}`)),
+		}, nil
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	m, err := NewMetadataCache(MetadataCacheConfig{Enabled: true, TTL: time.Hour, CacheConfig: cache.Config{Cache: cache.NewMockCache()}}, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	roundTripper := m.Wrap(func(next http.RoundTripper) http.RoundTripper { return next })(next)
+
+	do := func(userID string, req *http.Request) string {
+		req = req.WithContext(user.InjectOrgID(req.Context(), userID))
+		resp, err := roundTripper.RoundTrip(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// The label values are cached per label name and tenant.
+	first := do("user-1", httptest.NewRequest(http.MethodGet, "/api/v1/label/job/values", nil))
+	assert.Equal(t, first, do("user-1", httptest.NewRequest(http.MethodGet, "/api/v1/label/job/values", nil)))
+	assert.Equal(t, int64(1), requests.Load())
+	do("user-2", httptest.NewRequest(http.MethodGet, "/api/v1/label/job/values", nil))
+	do("user-1", httptest.NewRequest(http.MethodGet, "/api/v1/label/env/values", nil))
+	assert.Equal(t, int64(3), requests.Load())
+
+	// The series requests are cached regardless of the method and the order of the matchers.
+	params := url.Values{"match[]": {`up{job="a",env="prod"}`}}
+	post := httptest.NewRequest(http.MethodPost, "/api/v1/series", strings.NewReader(params.Encode()))
+	post.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	do("user-1", post)
+	do("user-1", httptest.NewRequest(http.MethodGet, "/api/v1/series?match[]="+url.QueryEscape(`up{env="prod",job="a"}`), nil))
+	assert.Equal(t, int64(4), requests.Load())
+
+	// The failed requests aren't cached.
+	status.Store(http.StatusInternalServerError)
+	do("user-1", httptest.NewRequest(http.MethodGet, "/api/v1/labels", nil))
+	status.Store(http.StatusOK)
+	do("user-1", httptest.NewRequest(http.MethodGet, "/api/v1/labels", nil))
+	do("user-1", httptest.NewRequest(http.MethodGet, "/api/v1/labels", nil))
+	assert.Equal(t, int64(6), requests.Load())
+
+	// The responses encoded as protobuf are cached separately.
+	protobuf := httptest.NewRequest(http.MethodGet, "/api/v1/labels", nil)
+	protobuf.Header.Set("Accept", "application/x-protobuf")
+	do("user-1", protobuf)
+	assert.Equal(t, int64(7), requests.Load())
+
+	// The other requests aren't cached.
+	do("user-1", httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil))
+	do("user-1", httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil))
+	assert.Equal(t, int64(9), requests.Load())
+
+	for endpoint, expected := range map[string]float64{metadataEndpointLabels: 4, metadataEndpointLabelValues: 4, metadataEndpointSeries: 2} {
+		assert.Equal(t, expected, testutil.ToFloat64(m.requests.WithLabelValues(endpoint)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(m.hits.WithLabelValues(endpoint)))
+	}
+}
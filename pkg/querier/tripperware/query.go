@@ -81,6 +81,10 @@ type Request interface {
 	GetStats() string
 	// WithStats clones the current `PrometheusRequest` with a new stats.
 	WithStats(stats string) Request
+	// GetHints returns the hints shared across the middlewares handling the request.
+	GetHints() RequestHints
+	// WithHints clones the current request with different hints.
+	WithHints(hints RequestHints) Request
 }
 
 func decodeSampleStream(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
@@ -9,6 +9,7 @@ import (
 	github_com_cortexproject_cortex_pkg_cortexpb "github.com/cortexproject/cortex/pkg/cortexpb"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
 	io "io"
 	math "math"
 	math_bits "math/bits"
@@ -320,6 +321,81 @@ func (m *PrometheusRequestHeader) GetValues() []string {
 	return nil
 }
 
+// RequestHints carries metadata across the middlewares handling a request.
+// Hints are forwarded to the downstream requests via headers.
+type RequestHints struct {
+	// Max resolution, in milliseconds, of the data the query is evaluated against.
+	MaxSourceResolution int64 `protobuf:"varint,1,opt,name=maxSourceResolution,proto3" json:"maxSourceResolution,omitempty"`
+	// Generic hints, for the middlewares not covered by the typed ones.
+	Metadata map[string]string `protobuf:"bytes,2,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Whether the steps are aligned to the day boundaries of the time zone of the tenant.
+	// Only used by the query-frontend.
+	AlignToTimeZone bool `protobuf:"varint,3,opt,name=alignToTimeZone,proto3" json:"alignToTimeZone,omitempty"`
+	// Offset, in milliseconds, of the time zone the steps are aligned to.
+	// Only used by the query-frontend.
+	TimeZoneOffset int64 `protobuf:"varint,4,opt,name=timeZoneOffset,proto3" json:"timeZoneOffset,omitempty"`
+}
+
+func (m *RequestHints) Reset()      { *m = RequestHints{} }
+func (*RequestHints) ProtoMessage() {}
+func (*RequestHints) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{6}
+}
+func (m *RequestHints) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RequestHints) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RequestHints.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RequestHints) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestHints.Merge(m, src)
+}
+func (m *RequestHints) XXX_Size() int {
+	return m.Size()
+}
+func (m *RequestHints) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestHints.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestHints proto.InternalMessageInfo
+
+func (m *RequestHints) GetMaxSourceResolution() int64 {
+	if m != nil {
+		return m.MaxSourceResolution
+	}
+	return 0
+}
+
+func (m *RequestHints) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *RequestHints) GetAlignToTimeZone() bool {
+	if m != nil {
+		return m.AlignToTimeZone
+	}
+	return false
+}
+
+func (m *RequestHints) GetTimeZoneOffset() int64 {
+	if m != nil {
+		return m.TimeZoneOffset
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*SampleStream)(nil), "tripperware.SampleStream")
 	proto.RegisterType((*PrometheusResponseStats)(nil), "tripperware.PrometheusResponseStats")
@@ -327,43 +403,53 @@ func init() {
 	proto.RegisterType((*PrometheusResponseQueryableSamplesStatsPerStep)(nil), "tripperware.PrometheusResponseQueryableSamplesStatsPerStep")
 	proto.RegisterType((*PrometheusResponseHeader)(nil), "tripperware.PrometheusResponseHeader")
 	proto.RegisterType((*PrometheusRequestHeader)(nil), "tripperware.PrometheusRequestHeader")
+	proto.RegisterType((*RequestHints)(nil), "tripperware.RequestHints")
+	proto.RegisterMapType((map[string]string)(nil), "tripperware.RequestHints.MetadataEntry")
 }
 
 func init() { proto.RegisterFile("query.proto", fileDescriptor_5c6ac9b241082464) }
 
 var fileDescriptor_5c6ac9b241082464 = []byte{
-	// 495 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x53, 0xbf, 0x6e, 0xd4, 0x4e,
-	0x10, 0xf6, 0x26, 0xbf, 0xdc, 0x4f, 0xac, 0x23, 0x84, 0x96, 0x20, 0x2e, 0x11, 0xac, 0x8f, 0xab,
-	0x22, 0x21, 0x7c, 0x52, 0xa8, 0x80, 0x2a, 0xae, 0x90, 0xf8, 0x77, 0xd8, 0x88, 0x82, 0x06, 0xad,
-	0x2f, 0xa3, 0x8b, 0xc1, 0xcb, 0x6e, 0x76, 0xd7, 0x10, 0x3a, 0x1e, 0x81, 0x86, 0x07, 0xa0, 0xe3,
-	0x41, 0x28, 0x52, 0x5e, 0x19, 0x51, 0x58, 0x9c, 0xaf, 0x41, 0xae, 0xf2, 0x08, 0xc8, 0x6b, 0x3b,
-	0x17, 0xe0, 0x74, 0x28, 0xa2, 0xdb, 0xfd, 0xbe, 0xf9, 0xe6, 0x9b, 0x9d, 0x9d, 0xc1, 0xee, 0x41,
-	0x06, 0xea, 0xbd, 0x2f, 0x95, 0x30, 0x82, 0xb8, 0x46, 0x25, 0x52, 0x82, 0x7a, 0xc7, 0x14, 0x6c,
-	0x6d, 0x8c, 0xc5, 0x58, 0x58, 0x7c, 0x50, 0x9d, 0xea, 0x90, 0xad, 0x3b, 0xe3, 0xc4, 0xec, 0x67,
-	0xb1, 0x3f, 0x12, 0x7c, 0x30, 0x12, 0xca, 0xc0, 0xa1, 0x54, 0xe2, 0x15, 0x8c, 0x4c, 0x73, 0x1b,
-	0xc8, 0xd7, 0xe3, 0x96, 0x88, 0x9b, 0x43, 0x2d, 0xed, 0x7f, 0x45, 0x78, 0x3d, 0x62, 0x5c, 0xa6,
-	0x10, 0x19, 0x05, 0x8c, 0x93, 0x43, 0xdc, 0x49, 0x59, 0x0c, 0xa9, 0xee, 0xa2, 0xde, 0xea, 0xb6,
-	0xbb, 0x73, 0xd9, 0x6f, 0x85, 0xfe, 0xc3, 0x0a, 0x1f, 0xb2, 0x44, 0x05, 0x0f, 0x8e, 0x72, 0xcf,
-	0xf9, 0x96, 0x7b, 0xe7, 0x32, 0xae, 0xf5, 0xbb, 0x7b, 0x4c, 0x1a, 0x50, 0x65, 0xee, 0x75, 0x38,
-	0x18, 0x95, 0x8c, 0xc2, 0xc6, 0x8f, 0xdc, 0xc5, 0xff, 0x6b, 0x5b, 0x89, 0xee, 0xae, 0x58, 0xeb,
-	0x4b, 0x73, 0xeb, 0xba, 0xc4, 0xe0, 0x62, 0xe5, 0x5b, 0x49, 0xdf, 0xb2, 0x34, 0x03, 0x1d, 0xb6,
-	0x82, 0x3e, 0xc7, 0x57, 0x87, 0x4a, 0x70, 0x30, 0xfb, 0x90, 0xe9, 0x10, 0xb4, 0x14, 0x6f, 0x34,
-	0x44, 0x86, 0x19, 0x4d, 0xc2, 0x79, 0x5a, 0xd4, 0x43, 0xdb, 0xee, 0xce, 0x4d, 0xff, 0x4c, 0x47,
-	0xfd, 0x05, 0xb2, 0x3a, 0xda, 0xaa, 0x03, 0xb7, 0xcc, 0xbd, 0x56, 0x3f, 0xb7, 0xfb, 0xb4, 0x82,
-	0xe9, 0x72, 0x21, 0x79, 0x82, 0xaf, 0x18, 0x61, 0x58, 0xfa, 0xb4, 0xfa, 0x4a, 0x16, 0xa7, 0x2d,
-	0x6b, 0x8b, 0x58, 0x0d, 0x36, 0xcb, 0xdc, 0x5b, 0x1c, 0x10, 0x2e, 0x86, 0xc9, 0x67, 0x84, 0xaf,
-	0x2d, 0x64, 0x86, 0xa0, 0x22, 0x03, 0xb2, 0x69, 0xda, 0xbd, 0xbf, 0xbc, 0xee, 0x77, 0xb5, 0xad,
-	0xb6, 0x49, 0x11, 0xf4, 0xca, 0xdc, 0x5b, 0x6a, 0x12, 0x2e, 0x65, 0xfb, 0x09, 0x3e, 0xa7, 0x23,
-	0xd9, 0xc0, 0x6b, 0xf6, 0x2f, 0xeb, 0xb6, 0x84, 0xf5, 0x85, 0xdc, 0xc0, 0xeb, 0x26, 0xe1, 0xa0,
-	0x0d, 0xe3, 0xf2, 0x25, 0xaf, 0xe6, 0xa1, 0x22, 0xdd, 0x53, 0xec, 0x91, 0xee, 0x3f, 0xc3, 0xdd,
-	0x3f, 0xad, 0xee, 0x03, 0xdb, 0x03, 0x45, 0x36, 0xf1, 0x7f, 0x8f, 0x19, 0xaf, 0x73, 0x5e, 0x08,
-	0xd6, 0xca, 0xdc, 0x43, 0xb7, 0x42, 0x0b, 0x91, 0xeb, 0xb8, 0xf3, 0xdc, 0xce, 0x8e, 0x6d, 0xd7,
-	0x29, 0xd9, 0x80, 0xfd, 0xe8, 0xd7, 0x39, 0x3a, 0xc8, 0x40, 0x9b, 0x7f, 0x4d, 0x1a, 0xec, 0x4e,
-	0xa6, 0xd4, 0x39, 0x9e, 0x52, 0xe7, 0x64, 0x4a, 0xd1, 0x87, 0x82, 0xa2, 0x2f, 0x05, 0x45, 0x47,
-	0x05, 0x45, 0x93, 0x82, 0xa2, 0xef, 0x05, 0x45, 0x3f, 0x0a, 0xea, 0x9c, 0x14, 0x14, 0x7d, 0x9c,
-	0x51, 0x67, 0x32, 0xa3, 0xce, 0xf1, 0x8c, 0x3a, 0x2f, 0xce, 0xee, 0x7d, 0xdc, 0xb1, 0xdb, 0x7a,
-	0xfb, 0x67, 0x00, 0x00, 0x00, 0xff, 0xff, 0xe4, 0x6b, 0xc1, 0x41, 0x1a, 0x04, 0x00, 0x00,
+	// 618 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xcf, 0x4e, 0x14, 0x4f,
+	0x10, 0x9e, 0xde, 0x85, 0xfd, 0xf1, 0xeb, 0x45, 0x24, 0x0d, 0xc6, 0x85, 0x68, 0xcf, 0xba, 0x07,
+	0xdd, 0xc4, 0x38, 0x18, 0xbc, 0x28, 0x9c, 0x18, 0x63, 0x62, 0xa2, 0x08, 0xce, 0x10, 0x0f, 0x5c,
+	0x4c, 0xef, 0x52, 0x2c, 0x23, 0xd3, 0xd3, 0x43, 0x77, 0x8f, 0xc2, 0xcd, 0x47, 0xf0, 0xa0, 0x0f,
+	0xe0, 0xcd, 0x07, 0xf1, 0xc0, 0x91, 0x23, 0xf1, 0x30, 0x91, 0xe1, 0x62, 0xf6, 0xc4, 0x23, 0x98,
+	0xf9, 0xb3, 0xec, 0x82, 0x9b, 0x35, 0xc4, 0x5b, 0xf5, 0x57, 0xf5, 0xd5, 0x57, 0xf3, 0x55, 0x65,
+	0x70, 0x75, 0x2f, 0x02, 0x79, 0x60, 0x85, 0x52, 0x68, 0x41, 0xaa, 0x5a, 0x7a, 0x61, 0x08, 0xf2,
+	0x03, 0x93, 0x30, 0x3f, 0xdb, 0x11, 0x1d, 0x91, 0xe1, 0x0b, 0x69, 0x94, 0x97, 0xcc, 0x3f, 0xe9,
+	0x78, 0x7a, 0x27, 0x6a, 0x59, 0x6d, 0xc1, 0x17, 0xda, 0x42, 0x6a, 0xd8, 0x0f, 0xa5, 0x78, 0x07,
+	0x6d, 0x5d, 0xbc, 0x16, 0xc2, 0xdd, 0x4e, 0x2f, 0xd1, 0x2a, 0x82, 0x9c, 0xda, 0xf8, 0x8e, 0xf0,
+	0xa4, 0xcb, 0x78, 0xe8, 0x83, 0xab, 0x25, 0x30, 0x4e, 0xf6, 0x71, 0xc5, 0x67, 0x2d, 0xf0, 0x55,
+	0x0d, 0xd5, 0xcb, 0xcd, 0xea, 0xe2, 0x8c, 0xd5, 0x23, 0x5a, 0x2f, 0x53, 0x7c, 0x9d, 0x79, 0xd2,
+	0x7e, 0x71, 0x18, 0x9b, 0xc6, 0x8f, 0xd8, 0xbc, 0x92, 0x70, 0xce, 0x5f, 0xd9, 0x62, 0xa1, 0x06,
+	0xd9, 0x8d, 0xcd, 0x0a, 0x07, 0x2d, 0xbd, 0xb6, 0x53, 0xe8, 0x91, 0x25, 0xfc, 0x9f, 0xca, 0x26,
+	0x51, 0xb5, 0x52, 0x26, 0x3d, 0xdd, 0x97, 0xce, 0x47, 0xb4, 0xa7, 0x52, 0xdd, 0x94, 0xfa, 0x9e,
+	0xf9, 0x11, 0x28, 0xa7, 0x47, 0x68, 0x70, 0x7c, 0x73, 0x5d, 0x0a, 0x0e, 0x7a, 0x07, 0x22, 0xe5,
+	0x80, 0x0a, 0x45, 0xa0, 0xc0, 0xd5, 0x4c, 0x2b, 0xe2, 0xf4, 0xdb, 0xa2, 0x3a, 0x6a, 0x56, 0x17,
+	0xef, 0x5b, 0x03, 0x8e, 0x5a, 0x43, 0x68, 0x79, 0x75, 0xc6, 0xb6, 0xab, 0xdd, 0xd8, 0xec, 0xf1,
+	0xfb, 0x72, 0x5f, 0x4a, 0x98, 0x8e, 0x26, 0x92, 0x35, 0x7c, 0x43, 0x0b, 0xcd, 0xfc, 0xd7, 0xe9,
+	0x2a, 0x59, 0xcb, 0x07, 0x77, 0x60, 0x88, 0xb2, 0x3d, 0xd7, 0x8d, 0xcd, 0xe1, 0x05, 0xce, 0x70,
+	0x98, 0x7c, 0x45, 0xf8, 0xd6, 0xd0, 0xcc, 0x3a, 0x48, 0x57, 0x43, 0x58, 0x98, 0xb6, 0xfc, 0x97,
+	0xaf, 0xbb, 0xcc, 0xce, 0xa6, 0x2d, 0x5a, 0xd8, 0xf5, 0x6e, 0x6c, 0x8e, 0x14, 0x71, 0x46, 0x66,
+	0x1b, 0x1e, 0xbe, 0xa2, 0x22, 0x99, 0xc5, 0xe3, 0xd9, 0x2e, 0x73, 0x5b, 0x9c, 0xfc, 0x41, 0xee,
+	0xe0, 0x49, 0xed, 0x71, 0x50, 0x9a, 0xf1, 0xf0, 0x2d, 0x4f, 0xef, 0x21, 0x4d, 0x56, 0xcf, 0xb1,
+	0x55, 0xd5, 0xd8, 0xc0, 0xb5, 0x3f, 0xa5, 0x9e, 0x03, 0xdb, 0x02, 0x49, 0xe6, 0xf0, 0xd8, 0x2b,
+	0xc6, 0xf3, 0x9e, 0xff, 0xdb, 0xe3, 0xdd, 0xd8, 0x44, 0x0f, 0x9c, 0x0c, 0x22, 0xb7, 0x71, 0xe5,
+	0x4d, 0x76, 0x3b, 0x99, 0x5d, 0xe7, 0xc9, 0x02, 0x6c, 0xb8, 0x17, 0xef, 0x68, 0x2f, 0x02, 0xa5,
+	0xff, 0xb9, 0xe9, 0xe7, 0x12, 0x9e, 0xec, 0xf5, 0xf2, 0x02, 0xad, 0xc8, 0x43, 0x3c, 0xc3, 0xd9,
+	0xbe, 0x2b, 0x22, 0xd9, 0x06, 0x07, 0x94, 0xf0, 0x23, 0xed, 0x89, 0xa0, 0xb0, 0x60, 0x58, 0x8a,
+	0x3c, 0xc5, 0x13, 0x1c, 0x34, 0xdb, 0x62, 0x9a, 0x15, 0x7b, 0xbe, 0x77, 0x61, 0xcf, 0x83, 0xed,
+	0xad, 0xd5, 0xa2, 0xf2, 0x59, 0xa0, 0xe5, 0x81, 0x73, 0x4e, 0x24, 0x4d, 0x7c, 0x9d, 0xf9, 0x5e,
+	0x27, 0xd8, 0x10, 0x1b, 0x1e, 0x87, 0x4d, 0x11, 0x40, 0xad, 0x5c, 0x47, 0xcd, 0x09, 0xe7, 0x32,
+	0x4c, 0xee, 0xe2, 0x29, 0x5d, 0xc4, 0x6b, 0xdb, 0xdb, 0x0a, 0x74, 0x6d, 0x2c, 0x9b, 0xed, 0x12,
+	0x3a, 0xbf, 0x8c, 0xaf, 0x5d, 0x10, 0x23, 0xd3, 0xb8, 0xbc, 0x0b, 0x07, 0xb9, 0x47, 0x4e, 0x1a,
+	0xf6, 0x17, 0x5c, 0xca, 0xb0, 0xfc, 0xb1, 0x54, 0x7a, 0x8c, 0xec, 0x95, 0xa3, 0x13, 0x6a, 0x1c,
+	0x9f, 0x50, 0xe3, 0xec, 0x84, 0xa2, 0x8f, 0x09, 0x45, 0xdf, 0x12, 0x8a, 0x0e, 0x13, 0x8a, 0x8e,
+	0x12, 0x8a, 0x7e, 0x26, 0x14, 0xfd, 0x4a, 0xa8, 0x71, 0x96, 0x50, 0xf4, 0xe9, 0x94, 0x1a, 0x47,
+	0xa7, 0xd4, 0x38, 0x3e, 0xa5, 0xc6, 0xe6, 0xe0, 0xef, 0xb0, 0x55, 0xc9, 0x7e, 0x62, 0x8f, 0x7e,
+	0x0f, 0x00, 0xc3, 0x01, 0xb9, 0xd8, 0x31, 0x05, 0x00, 0x00,
 }
 
 func (this *SampleStream) Equal(that interface{}) bool {
@@ -550,6 +636,44 @@ func (this *PrometheusRequestHeader) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *RequestHints) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*RequestHints)
+	if !ok {
+		that2, ok := that.(RequestHints)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.MaxSourceResolution != that1.MaxSourceResolution {
+		return false
+	}
+	if len(this.Metadata) != len(that1.Metadata) {
+		return false
+	}
+	for i := range this.Metadata {
+		if this.Metadata[i] != that1.Metadata[i] {
+			return false
+		}
+	}
+	if this.AlignToTimeZone != that1.AlignToTimeZone {
+		return false
+	}
+	if this.TimeZoneOffset != that1.TimeZoneOffset {
+		return false
+	}
+	return true
+}
 func (this *SampleStream) GoString() string {
 	if this == nil {
 		return "nil"
@@ -625,6 +749,31 @@ func (this *PrometheusRequestHeader) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *RequestHints) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&tripperware.RequestHints{")
+	s = append(s, "MaxSourceResolution: "+fmt.Sprintf("%#v", this.MaxSourceResolution)+",\n")
+	keysForMetadata := make([]string, 0, len(this.Metadata))
+	for k, _ := range this.Metadata {
+		keysForMetadata = append(keysForMetadata, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForMetadata)
+	mapStringForMetadata := "map[string]string{"
+	for _, k := range keysForMetadata {
+		mapStringForMetadata += fmt.Sprintf("%#v: %#v,", k, this.Metadata[k])
+	}
+	mapStringForMetadata += "}"
+	if this.Metadata != nil {
+		s = append(s, "Metadata: "+mapStringForMetadata+",\n")
+	}
+	s = append(s, "AlignToTimeZone: "+fmt.Sprintf("%#v", this.AlignToTimeZone)+",\n")
+	s = append(s, "TimeZoneOffset: "+fmt.Sprintf("%#v", this.TimeZoneOffset)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringQuery(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -872,6 +1021,68 @@ func (m *PrometheusRequestHeader) MarshalToSizedBuffer(dAtA []byte) (int, error)
 	return len(dAtA) - i, nil
 }
 
+func (m *RequestHints) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RequestHints) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RequestHints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.TimeZoneOffset != 0 {
+		i = encodeVarintQuery(dAtA, i, uint64(m.TimeZoneOffset))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.AlignToTimeZone {
+		i--
+		if m.AlignToTimeZone {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Metadata) > 0 {
+		for k := range m.Metadata {
+			v := m.Metadata[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintQuery(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintQuery(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintQuery(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.MaxSourceResolution != 0 {
+		i = encodeVarintQuery(dAtA, i, uint64(m.MaxSourceResolution))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintQuery(dAtA []byte, offset int, v uint64) int {
 	offset -= sovQuery(v)
 	base := offset
@@ -988,6 +1199,32 @@ func (m *PrometheusRequestHeader) Size() (n int) {
 	return n
 }
 
+func (m *RequestHints) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.MaxSourceResolution != 0 {
+		n += 1 + sovQuery(uint64(m.MaxSourceResolution))
+	}
+	if len(m.Metadata) > 0 {
+		for k, v := range m.Metadata {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovQuery(uint64(len(k))) + 1 + len(v) + sovQuery(uint64(len(v)))
+			n += mapEntrySize + 1 + sovQuery(uint64(mapEntrySize))
+		}
+	}
+	if m.AlignToTimeZone {
+		n += 2
+	}
+	if m.TimeZoneOffset != 0 {
+		n += 1 + sovQuery(uint64(m.TimeZoneOffset))
+	}
+	return n
+}
+
 func sovQuery(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1069,6 +1306,29 @@ func (this *PrometheusRequestHeader) String() string {
 	}, "")
 	return s
 }
+func (this *RequestHints) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForMetadata := make([]string, 0, len(this.Metadata))
+	for k, _ := range this.Metadata {
+		keysForMetadata = append(keysForMetadata, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForMetadata)
+	mapStringForMetadata := "map[string]string{"
+	for _, k := range keysForMetadata {
+		mapStringForMetadata += fmt.Sprintf("%v: %v,", k, this.Metadata[k])
+	}
+	mapStringForMetadata += "}"
+	s := strings.Join([]string{`&RequestHints{`,
+		`MaxSourceResolution:` + fmt.Sprintf("%v", this.MaxSourceResolution) + `,`,
+		`Metadata:` + mapStringForMetadata + `,`,
+		`AlignToTimeZone:` + fmt.Sprintf("%v", this.AlignToTimeZone) + `,`,
+		`TimeZoneOffset:` + fmt.Sprintf("%v", this.TimeZoneOffset) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringQuery(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1718,6 +1978,244 @@ func (m *PrometheusRequestHeader) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *RequestHints) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowQuery
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RequestHints: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RequestHints: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxSourceResolution", wireType)
+			}
+			m.MaxSourceResolution = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxSourceResolution |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Metadata == nil {
+				m.Metadata = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowQuery
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowQuery
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthQuery
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthQuery
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowQuery
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthQuery
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthQuery
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipQuery(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthQuery
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Metadata[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AlignToTimeZone", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.AlignToTimeZone = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimeZoneOffset", wireType)
+			}
+			m.TimeZoneOffset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TimeZoneOffset |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipQuery(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthQuery
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthQuery
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipQuery(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
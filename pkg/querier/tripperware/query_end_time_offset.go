@@ -0,0 +1,115 @@
+package tripperware
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// newQueryEndTimeOffsetRoundTripper moves the end time of the query range requests, and the time
+// of the instant queries, back to now minus the largest end time offset of the tenants when
+// they're more recent, so that queries don't race the samples not yet ingested. The responses
+// of the moved requests have the offset in the QueryEndTimeOffsetHeaderKey header.
+func newQueryEndTimeOffsetRoundTripper(next http.RoundTripper, limits Limits) http.RoundTripper {
+	return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		isQuery := strings.HasSuffix(r.URL.Path, "/query")
+		isQueryRange := strings.HasSuffix(r.URL.Path, "/query_range")
+		if !isQuery && !isQueryRange {
+			return next.RoundTrip(r)
+		}
+
+		tenantIDs, err := tenant.TenantIDs(r.Context())
+		if err != nil {
+			return nil, err
+		}
+		offset := validation.MaxDurationPerTenant(tenantIDs, limits.QueryEndTimeOffset)
+		if offset <= 0 {
+			return next.RoundTrip(r)
+		}
+
+		shifted, ok := shiftQueryEndTime(r, isQueryRange, util.TimeToMillis(time.Now().Add(-offset)))
+		if !ok {
+			return next.RoundTrip(r)
+		}
+
+		resp, err := next.RoundTrip(shifted)
+		if err != nil {
+			return nil, err
+		}
+		resp.Header.Set(util.QueryEndTimeOffsetHeaderKey, offset.String())
+		return resp, nil
+	})
+}
+
+// shiftQueryEndTime returns a copy of the request whose end time, or time for instant queries,
+// is moved back to maxEnd, and whether the request had to be moved. The invalid requests
+// aren't moved, so that they fail in the queriers like the others.
+func shiftQueryEndTime(r *http.Request, isQueryRange bool, maxEnd int64) (*http.Request, bool) {
+	if err := r.ParseForm(); err != nil {
+		return r, false
+	}
+	form := cloneForm(r)
+
+	if isQueryRange {
+		start, err := util.ParseTime(r.FormValue("start"))
+		if err != nil {
+			return r, false
+		}
+		end, err := util.ParseTime(r.FormValue("end"))
+		if err != nil || end <= maxEnd {
+			return r, false
+		}
+
+		// The requests fully within the delay are reduced to their first step.
+		if start > maxEnd {
+			form.Set("start", EncodeTime(maxEnd))
+		}
+		form.Set("end", EncodeTime(maxEnd))
+	} else {
+		ts := util.TimeToMillis(time.Now())
+		if v := r.FormValue("time"); v != "" {
+			var err error
+			if ts, err = util.ParseTime(v); err != nil {
+				return r, false
+			}
+		}
+		if ts <= maxEnd {
+			return r, false
+		}
+		form.Set("time", EncodeTime(maxEnd))
+	}
+
+	return withForm(r, form), true
+}
+
+// cloneForm returns a copy of the parsed form of the request.
+func cloneForm(r *http.Request) url.Values {
+	form := make(url.Values, len(r.Form))
+	for name, values := range r.Form {
+		form[name] = values
+	}
+	return form
+}
+
+// withForm returns a copy of the request with the given form, always sent as a GET request with
+// the parameters in the URL.
+func withForm(r *http.Request, form url.Values) *http.Request {
+	u := *r.URL
+	u.RawQuery = form.Encode()
+
+	req := r.Clone(r.Context())
+	req.Method = http.MethodGet
+	req.URL = &u
+	req.RequestURI = u.String()
+	req.Body = http.NoBody
+	req.ContentLength = 0
+	req.Header.Del("Content-Type")
+	req.Form = nil
+	req.PostForm = nil
+	return req
+}
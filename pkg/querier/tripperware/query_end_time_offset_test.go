@@ -0,0 +1,165 @@
+package tripperware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+func TestShiftQueryEndTime(t *testing.T) {
+	t.Parallel()
+	maxEnd := time.Unix(1700000000, 0)
+	ts := func(t time.Time) string { return EncodeTime(util.TimeToMillis(t)) }
+
+	for _, tc := range []struct {
+		name           string
+		method         string
+		path           string
+		params         url.Values
+		expectedParams url.Values
+	}{
+		{
+			name:   "query range ending before the max end",
+			path:   "/api/v1/query_range",
+			params: url.Values{"query": {"up"}, "start": {ts(maxEnd.Add(-time.Hour))}, "end": {ts(maxEnd)}, "step": {"60"}},
+		},
+		{
+			name:           "query range ending after the max end",
+			path:           "/api/v1/query_range",
+			params:         url.Values{"query": {"up"}, "start": {ts(maxEnd.Add(-time.Hour))}, "end": {ts(maxEnd.Add(time.Minute))}, "step": {"60"}},
+			expectedParams: url.Values{"query": {"up"}, "start": {ts(maxEnd.Add(-time.Hour))}, "end": {ts(maxEnd)}, "step": {"60"}},
+		},
+		{
+			name:           "query range starting after the max end",
+			path:           "/api/v1/query_range",
+			params:         url.Values{"query": {"up"}, "start": {ts(maxEnd.Add(time.Minute))}, "end": {ts(maxEnd.Add(time.Hour))}, "step": {"60"}},
+			expectedParams: url.Values{"query": {"up"}, "start": {ts(maxEnd)}, "end": {ts(maxEnd)}, "step": {"60"}},
+		},
+		{
+			name:           "POST query range",
+			method:         http.MethodPost,
+			path:           "/api/v1/query_range",
+			params:         url.Values{"query": {"up"}, "start": {ts(maxEnd.Add(-time.Hour))}, "end": {ts(maxEnd.Add(time.Minute))}, "step": {"60"}},
+			expectedParams: url.Values{"query": {"up"}, "start": {ts(maxEnd.Add(-time.Hour))}, "end": {ts(maxEnd)}, "step": {"60"}},
+		},
+		{
+			name:   "invalid query range",
+			path:   "/api/v1/query_range",
+			params: url.Values{"query": {"up"}, "start": {"invalid"}, "end": {ts(maxEnd.Add(time.Minute))}, "step": {"60"}},
+		},
+		{
+			name:   "instant query before the max end",
+			path:   "/api/v1/query",
+			params: url.Values{"query": {"up"}, "time": {ts(maxEnd.Add(-time.Minute))}},
+		},
+		{
+			name:           "instant query after the max end",
+			path:           "/api/v1/query",
+			params:         url.Values{"query": {"up"}, "time": {ts(maxEnd.Add(time.Minute))}},
+			expectedParams: url.Values{"query": {"up"}, "time": {ts(maxEnd)}},
+		},
+		{
+			name:           "instant query without time",
+			path:           "/api/v1/query",
+			params:         url.Values{"query": {"up"}},
+			expectedParams: url.Values{"query": {"up"}, "time": {ts(maxEnd)}},
+		},
+		{
+			name:   "invalid instant query",
+			path:   "/api/v1/query",
+			params: url.Values{"query": {"up"}, "time": {"invalid"}},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var req *http.Request
+			if tc.method == http.MethodPost {
+				req = httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.params.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req = httptest.NewRequest(http.MethodGet, tc.path+"?"+tc.params.Encode(), nil)
+			}
+
+			shifted, ok := shiftQueryEndTime(req, strings.HasSuffix(tc.path, "/query_range"), util.TimeToMillis(maxEnd))
+			if tc.expectedParams == nil {
+				assert.False(t, ok)
+				assert.Same(t, req, shifted)
+				return
+			}
+
+			require.True(t, ok)
+			assert.Equal(t, http.MethodGet, shifted.Method)
+			assert.Equal(t, tc.path, shifted.URL.Path)
+			assert.Equal(t, tc.expectedParams, shifted.URL.Query())
+			body, err := io.ReadAll(shifted.Body)
+			require.NoError(t, err)
+			assert.Empty(t, body)
+		})
+	}
+}
+
+func TestQueryEndTimeOffsetRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	var received *http.Request
+	next := RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		received = r
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	limits := endTimeOffsetPerTenant{offsets: map[string]time.Duration{"team-a": time.Minute, "team-b": time.Hour}}
+	roundTripper := newQueryEndTimeOffsetRoundTripper(next, limits)
+
+	do := func(orgID, target string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), orgID))
+		resp, err := roundTripper.RoundTrip(req)
+		require.NoError(t, err)
+		return resp
+	}
+	end := func() time.Time {
+		ms, err := util.ParseTime(received.FormValue("end"))
+		require.NoError(t, err)
+		return util.TimeFromMillis(ms)
+	}
+
+	// The query ending now is moved back by the offset of the tenant.
+	now := time.Now()
+	resp := do("team-b", "/api/v1/query_range?query=up&start=0&end="+EncodeTime(util.TimeToMillis(now))+"&step=60")
+	assert.Equal(t, "1h0m0s", resp.Header.Get(util.QueryEndTimeOffsetHeaderKey))
+	assert.WithinDuration(t, now.Add(-time.Hour), end(), time.Second)
+
+	// The query ending before the offset isn't moved.
+	resp = do("team-a", "/api/v1/query_range?query=up&start=0&end=100&step=60")
+	assert.Empty(t, resp.Header.Get(util.QueryEndTimeOffsetHeaderKey))
+	assert.Equal(t, "100", received.FormValue("end"))
+
+	// The tenants without offset and the other endpoints aren't moved.
+	resp = do("team-c", "/api/v1/query?query=up")
+	assert.Empty(t, resp.Header.Get(util.QueryEndTimeOffsetHeaderKey))
+	assert.Empty(t, received.FormValue("time"))
+	resp = do("team-a", "/api/v1/series?match[]=up")
+	assert.Empty(t, resp.Header.Get(util.QueryEndTimeOffsetHeaderKey))
+}
+
+// endTimeOffsetPerTenant are mockLimits with the end time offset set by tenant.
+type endTimeOffsetPerTenant struct {
+	mockLimits
+	offsets map[string]time.Duration
+}
+
+func (l endTimeOffsetPerTenant) QueryEndTimeOffset(userID string) time.Duration {
+	return l.offsets[userID]
+}
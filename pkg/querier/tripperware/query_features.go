@@ -0,0 +1,108 @@
+package tripperware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const (
+	queryFeatureFunction   = "function"
+	queryFeatureSubquery   = "subquery"
+	queryFeatureAtModifier = "at_modifier"
+)
+
+// queryFeatureError is the body of the response to a query using a PromQL function or feature
+// not allowed for the tenant. It extends the Prometheus API error format with the feature, so
+// that clients can tell the restrictions apart from the other bad data errors.
+type queryFeatureError struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Feature   string `json:"feature"`
+	Function  string `json:"function,omitempty"`
+}
+
+func newQueryFeatureError(feature, function, format string, args ...interface{}) error {
+	body, err := json.Marshal(queryFeatureError{
+		Status:    "error",
+		ErrorType: "bad_data",
+		Error:     fmt.Sprintf(format, args...),
+		Feature:   feature,
+		Function:  function,
+	})
+	if err != nil {
+		return err
+	}
+
+	return httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+		Code:    http.StatusBadRequest,
+		Headers: []*httpgrpc.Header{{Key: "Content-Type", Values: []string{"application/json"}}},
+		Body:    body,
+	})
+}
+
+// QueryFeaturesCheck ensures the query only uses the PromQL functions and features allowed for
+// all the tenants.
+func QueryFeaturesCheck(query string, tenantIDs []string, limits Limits, now time.Time) error {
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		// If query fails to parse, we don't throw an error here
+		// but fail query later on querier.
+		return nil
+	}
+
+	var (
+		subqueriesDisabled bool
+		allowedFunctions   []map[string]struct{}
+		maxAtLookback      = validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, limits.QueryMaxAtModifierLookback)
+	)
+	for _, tenantID := range tenantIDs {
+		subqueriesDisabled = subqueriesDisabled || limits.QuerySubqueriesDisabled(tenantID)
+		if functions := limits.QueryAllowedFunctions(tenantID); len(functions) > 0 {
+			allowed := make(map[string]struct{}, len(functions))
+			for _, f := range functions {
+				allowed[f] = struct{}{}
+			}
+			allowedFunctions = append(allowedFunctions, allowed)
+		}
+	}
+
+	checkAtModifier := func(ts *int64) error {
+		if maxAtLookback <= 0 || ts == nil {
+			return nil
+		}
+		if minTime := now.Add(-maxAtLookback); timestamp.Time(*ts).Before(minTime) {
+			return newQueryFeatureError(queryFeatureAtModifier, "", "the @ modifier timestamp %s is further in the past than allowed (limit: %s)", timestamp.Time(*ts).Format(time.RFC3339), maxAtLookback)
+		}
+		return nil
+	}
+
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.Call:
+			for _, allowed := range allowedFunctions {
+				if _, ok := allowed[n.Func.Name]; !ok {
+					err = newQueryFeatureError(queryFeatureFunction, n.Func.Name, "the function %q is not allowed", n.Func.Name)
+					break
+				}
+			}
+		case *parser.SubqueryExpr:
+			if subqueriesDisabled {
+				err = newQueryFeatureError(queryFeatureSubquery, "", "subqueries are not allowed")
+			} else {
+				err = checkAtModifier(n.Timestamp)
+			}
+		case *parser.VectorSelector:
+			err = checkAtModifier(n.Timestamp)
+		}
+		return err
+	})
+	return err
+}
@@ -0,0 +1,107 @@
+package tripperware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+func TestQueryFeaturesCheck(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 0)
+
+	for _, tc := range []struct {
+		name         string
+		query        string
+		tenantIDs    []string
+		limits       Limits
+		expectedBody string
+	}{
+		{
+			name:   "invalid query",
+			query:  "sum(rate(up",
+			limits: mockLimits{queryAllowedFunctions: []string{"rate"}},
+		},
+		{
+			name:   "no restrictions",
+			query:  `label_replace(sum_over_time(up[1h:1m] @ 100), "a", "$1", "b", "(.*)")`,
+			limits: mockLimits{},
+		},
+		{
+			name:   "allowed functions",
+			query:  "sum(rate(up[5m])) / sum(irate(up[5m]))",
+			limits: mockLimits{queryAllowedFunctions: []string{"rate", "irate"}},
+		},
+		{
+			name:         "function not allowed",
+			query:        `sum(rate(up[5m])) + label_replace(up, "a", "$1", "b", "(.*)")`,
+			limits:       mockLimits{queryAllowedFunctions: []string{"rate"}},
+			expectedBody: `{"status":"error","errorType":"bad_data","error":"the function \"label_replace\" is not allowed","feature":"function","function":"label_replace"}`,
+		},
+		{
+			name:         "function not allowed for one of the tenants",
+			query:        "sum(rate(up[5m])) / sum(irate(up[5m]))",
+			tenantIDs:    []string{"team-a", "team-b"},
+			limits:       allowedFunctionsPerTenant{allowedFunctions: map[string][]string{"team-a": {"rate", "irate"}, "team-b": {"rate"}}},
+			expectedBody: `{"status":"error","errorType":"bad_data","error":"the function \"irate\" is not allowed","feature":"function","function":"irate"}`,
+		},
+		{
+			name:         "subqueries not allowed",
+			query:        "max_over_time(rate(up[5m])[1h:1m])",
+			limits:       mockLimits{querySubqueriesDisabled: true},
+			expectedBody: `{"status":"error","errorType":"bad_data","error":"subqueries are not allowed","feature":"subquery"}`,
+		},
+		{
+			name:   "@ modifier within the lookback",
+			query:  "up @ 1699999000 + up @ end()",
+			limits: mockLimits{queryMaxAtModifierLookback: time.Hour},
+		},
+		{
+			name:         "@ modifier beyond the lookback",
+			query:        "up + rate(up[5m] @ 1600000000)",
+			limits:       mockLimits{queryMaxAtModifierLookback: time.Hour},
+			expectedBody: `{"status":"error","errorType":"bad_data","error":"the @ modifier timestamp 2020-09-13T12:26:40Z is further in the past than allowed (limit: 1h0m0s)","feature":"at_modifier"}`,
+		},
+		{
+			name:         "subquery @ modifier beyond the lookback",
+			query:        "max_over_time(up[1h:1m] @ 1600000000)",
+			limits:       mockLimits{queryMaxAtModifierLookback: time.Hour},
+			expectedBody: `{"status":"error","errorType":"bad_data","error":"the @ modifier timestamp 2020-09-13T12:26:40Z is further in the past than allowed (limit: 1h0m0s)","feature":"at_modifier"}`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tenantIDs := tc.tenantIDs
+			if tenantIDs == nil {
+				tenantIDs = []string{"team-a"}
+			}
+
+			err := QueryFeaturesCheck(tc.query, tenantIDs, tc.limits, now)
+			if tc.expectedBody == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			resp, ok := httpgrpc.HTTPResponseFromError(err)
+			require.True(t, ok)
+			assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+			assert.Equal(t, []*httpgrpc.Header{{Key: "Content-Type", Values: []string{"application/json"}}}, resp.Headers)
+			assert.JSONEq(t, tc.expectedBody, string(resp.Body))
+		})
+	}
+}
+
+// allowedFunctionsPerTenant are mockLimits with the allowed functions set by tenant.
+type allowedFunctionsPerTenant struct {
+	mockLimits
+	allowedFunctions map[string][]string
+}
+
+func (l allowedFunctionsPerTenant) QueryAllowedFunctions(userID string) []string {
+	return l.allowedFunctions[userID]
+}
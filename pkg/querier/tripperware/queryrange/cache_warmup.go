@@ -0,0 +1,315 @@
+package queryrange
+
+import (
+	"context"
+	"flag"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+const (
+	// The requests of a query closer than this are considered part of the same dashboard
+	// refresh, so they don't count to learn the refresh interval.
+	cacheWarmupMinRefreshInterval = 10 * time.Second
+
+	// Weight of the last interval between two requests in the learned refresh interval.
+	cacheWarmupIntervalWeight = 0.3
+
+	// A query not requested for this number of refresh intervals isn't warmed up anymore,
+	// because its dashboard has likely been closed.
+	cacheWarmupMaxMissedRefreshes = 3
+
+	// A query not requested for this long is forgotten.
+	cacheWarmupRetention = 24 * time.Hour
+)
+
+// CacheWarmupConfig configures the results cache warm-up.
+type CacheWarmupConfig struct {
+	Enabled                    bool          `yaml:"enabled"`
+	Interval                   time.Duration `yaml:"interval"`
+	LeadTime                   time.Duration `yaml:"lead_time"`
+	MinRequests                int           `yaml:"min_requests"`
+	MaxTrackedQueriesPerTenant int           `yaml:"max_tracked_queries_per_tenant"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *CacheWarmupConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "frontend.cache-warmup.enabled", false, "Experimental: Learn the most frequent query range requests of each tenant, like the ones of the dashboards refreshed periodically, and run them again shortly before they're expected to be requested, so that their results are cached. The number of warmed up queries per tenant is limited by -frontend.cache-warmup.max-queries-per-tenant. Requires the results cache.")
+	f.DurationVar(&cfg.Interval, "frontend.cache-warmup.interval", 15*time.Second, "How frequently the queries due to be requested are checked.")
+	f.DurationVar(&cfg.LeadTime, "frontend.cache-warmup.lead-time", 30*time.Second, "How long before the time a query is expected to be requested its results cache entries are warmed up.")
+	f.IntVar(&cfg.MinRequests, "frontend.cache-warmup.min-requests", 3, "Minimum number of requests of a query for it to be warmed up.")
+	f.IntVar(&cfg.MaxTrackedQueriesPerTenant, "frontend.cache-warmup.max-tracked-queries-per-tenant", 1000, "Maximum number of distinct queries tracked per tenant to learn the most frequent ones. When reached, the least recently requested query is forgotten.")
+}
+
+// Validate validates the config.
+func (cfg *CacheWarmupConfig) Validate(cacheResults bool) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if !cacheResults {
+		return errors.New("the results cache warm-up requires the results cache to be enabled")
+	}
+	if cfg.Interval <= 0 {
+		return errors.New("the results cache warm-up interval must be greater than 0")
+	}
+	if cfg.MaxTrackedQueriesPerTenant <= 0 {
+		return errors.New("the results cache warm-up max tracked queries per tenant must be greater than 0")
+	}
+	return nil
+}
+
+// trackedQuery is a query range request (query and step) of a tenant and how frequently it's
+// requested.
+type trackedQuery struct {
+	// Last request, used to warm up the results cache with the same parameters.
+	req      tripperware.Request
+	requests int
+
+	lastRequest time.Time
+	lastWarmup  time.Time
+
+	// Learned interval between the refreshes of the query.
+	refreshInterval time.Duration
+}
+
+type trackedQueryKey struct {
+	query string
+	step  int64
+}
+
+// CacheWarmer tracks the query range requests going through its middleware and warms up the
+// results cache entries of the most frequent ones of each tenant before they're requested
+// again, by running them through the rest of the middlewares chain.
+type CacheWarmer struct {
+	services.Service
+
+	cfg    CacheWarmupConfig
+	limits tripperware.Limits
+	logger log.Logger
+
+	mtx sync.Mutex
+	// Handler running the warm-up requests, set once the middleware has wrapped it.
+	next    tripperware.Handler
+	tenants map[string]map[trackedQueryKey]*trackedQuery
+
+	warmups *prometheus.CounterVec
+}
+
+// NewCacheWarmer makes a new CacheWarmer.
+func NewCacheWarmer(cfg CacheWarmupConfig, limits tripperware.Limits, logger log.Logger, registerer prometheus.Registerer) *CacheWarmer {
+	w := &CacheWarmer{
+		cfg:     cfg,
+		limits:  limits,
+		logger:  logger,
+		tenants: map[string]map[trackedQueryKey]*trackedQuery{},
+
+		warmups: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "frontend_query_range_cache_warmups_total",
+			Help:      "Total number of query range requests run to warm up the results cache.",
+		}, []string{"status"}),
+	}
+	promauto.With(registerer).NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "cortex",
+		Name:      "frontend_query_range_cache_warmup_tracked_queries",
+		Help:      "Number of distinct query range requests tracked to learn the most frequent ones.",
+	}, w.countTrackedQueries)
+
+	w.Service = services.NewTimerService(cfg.Interval, nil, w.iteration, nil)
+	return w
+}
+
+// Middleware returns the Middleware tracking the requests. The warm-up requests run through
+// the handler it wraps.
+func (w *CacheWarmer) Middleware() tripperware.Middleware {
+	return tripperware.MiddlewareFunc(func(next tripperware.Handler) tripperware.Handler {
+		w.mtx.Lock()
+		w.next = next
+		w.mtx.Unlock()
+
+		return tripperware.HandlerFunc(func(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+			w.track(ctx, r, time.Now())
+			return next.Do(ctx, r)
+		})
+	})
+}
+
+func (w *CacheWarmer) track(ctx context.Context, r tripperware.Request, now time.Time) {
+	// The requests not cached can't be warmed up.
+	if req, ok := r.(*PrometheusRequest); !ok || req.CachingOptions.Disabled {
+		return
+	}
+
+	// The budget is per tenant, so only the single tenant requests are tracked.
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil || len(tenantIDs) != 1 {
+		return
+	}
+	userID := tenantIDs[0]
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	queries := w.tenants[userID]
+	if queries == nil {
+		queries = map[trackedQueryKey]*trackedQuery{}
+		w.tenants[userID] = queries
+	}
+
+	key := trackedQueryKey{query: r.GetQuery(), step: r.GetStep()}
+	q := queries[key]
+	if q == nil {
+		if len(queries) >= w.cfg.MaxTrackedQueriesPerTenant {
+			evictLeastRecentlyRequested(queries)
+		}
+		q = &trackedQuery{}
+		queries[key] = q
+	}
+
+	q.requests++
+	q.req = r
+
+	since := now.Sub(q.lastRequest)
+	switch {
+	case q.lastRequest.IsZero():
+		q.lastRequest = now
+	case since < cacheWarmupMinRefreshInterval:
+		// Same refresh.
+	case q.refreshInterval == 0:
+		q.refreshInterval = since
+		q.lastRequest = now
+	default:
+		q.refreshInterval = time.Duration(cacheWarmupIntervalWeight*float64(since) + (1-cacheWarmupIntervalWeight)*float64(q.refreshInterval))
+		q.lastRequest = now
+	}
+}
+
+func evictLeastRecentlyRequested(queries map[trackedQueryKey]*trackedQuery) {
+	var (
+		oldestKey trackedQueryKey
+		oldest    *trackedQuery
+	)
+	for key, q := range queries {
+		if oldest == nil || q.lastRequest.Before(oldest.lastRequest) {
+			oldestKey, oldest = key, q
+		}
+	}
+	delete(queries, oldestKey)
+}
+
+func (w *CacheWarmer) iteration(ctx context.Context) error {
+	w.warmup(ctx, time.Now())
+	return nil
+}
+
+// warmup runs the queries due to be requested within the lead time.
+func (w *CacheWarmer) warmup(ctx context.Context, now time.Time) {
+	type warmupRequest struct {
+		userID string
+		req    tripperware.Request
+	}
+
+	w.mtx.Lock()
+	next := w.next
+	var due []warmupRequest
+	for userID, queries := range w.tenants {
+		for key, q := range queries {
+			if now.Sub(q.lastRequest) > cacheWarmupRetention {
+				delete(queries, key)
+			}
+		}
+		if len(queries) == 0 {
+			delete(w.tenants, userID)
+			continue
+		}
+
+		for _, q := range w.dueQueries(userID, queries, now) {
+			q.lastWarmup = now
+			due = append(due, warmupRequest{userID: userID, req: q.req.WithStartEnd(warmupRange(q.req, now))})
+		}
+	}
+	w.mtx.Unlock()
+
+	if next == nil {
+		return
+	}
+
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if _, err := next.Do(user.InjectOrgID(ctx, d.userID), d.req); err != nil {
+			w.warmups.WithLabelValues("failed").Inc()
+			level.Warn(w.logger).Log("msg", "failed to warm up the results cache", "user", d.userID, "query", d.req.GetQuery(), "err", err)
+			continue
+		}
+		w.warmups.WithLabelValues("success").Inc()
+	}
+}
+
+// dueQueries returns the most frequent queries of the tenant, within its budget, which are
+// expected to be requested within the lead time and haven't been warmed up since their last
+// request. Must be called with the lock held.
+func (w *CacheWarmer) dueQueries(userID string, queries map[trackedQueryKey]*trackedQuery, now time.Time) []*trackedQuery {
+	budget := w.limits.CacheWarmupMaxQueries(userID)
+	if budget <= 0 {
+		return nil
+	}
+
+	popular := make([]*trackedQuery, 0, len(queries))
+	for _, q := range queries {
+		if q.requests >= w.cfg.MinRequests && q.refreshInterval > 0 && now.Sub(q.lastRequest) <= cacheWarmupMaxMissedRefreshes*q.refreshInterval {
+			popular = append(popular, q)
+		}
+	}
+	sort.Slice(popular, func(i, j int) bool {
+		return popular[i].requests > popular[j].requests
+	})
+	if len(popular) > budget {
+		popular = popular[:budget]
+	}
+
+	due := popular[:0]
+	for _, q := range popular {
+		expected := q.lastRequest.Add(q.refreshInterval)
+		if q.lastWarmup.Before(q.lastRequest) && !now.Add(w.cfg.LeadTime).Before(expected) {
+			due = append(due, q)
+		}
+	}
+	return due
+}
+
+// warmupRange returns the time range of the warm-up request: the same length as the last
+// request, ending now and aligned to the step.
+func warmupRange(r tripperware.Request, now time.Time) (int64, int64) {
+	length := r.GetEnd() - r.GetStart()
+	end := now.UnixMilli()
+	if step := r.GetStep(); step > 0 {
+		end -= end % step
+	}
+	return end - length, end
+}
+
+func (w *CacheWarmer) countTrackedQueries() float64 {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	count := 0
+	for _, queries := range w.tenants {
+		count += len(queries)
+	}
+	return float64(count)
+}
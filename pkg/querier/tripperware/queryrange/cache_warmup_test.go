@@ -0,0 +1,120 @@
+package queryrange
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+func TestCacheWarmupConfig_Validate(t *testing.T) {
+	cfg := CacheWarmupConfig{Enabled: true, Interval: time.Second, MaxTrackedQueriesPerTenant: 10}
+	assert.NoError(t, cfg.Validate(true))
+	assert.EqualError(t, cfg.Validate(false), "the results cache warm-up requires the results cache to be enabled")
+	assert.NoError(t, (&CacheWarmupConfig{}).Validate(false))
+}
+
+func TestCacheWarmer(t *testing.T) {
+	var (
+		t0     = time.Date(2023, time.March, 15, 10, 0, 0, 0, time.UTC)
+		length = time.Hour.Milliseconds()
+		step   = time.Minute.Milliseconds()
+	)
+
+	type warmup struct {
+		userID     string
+		query      string
+		start, end int64
+	}
+	var warmups []warmup
+	next := tripperware.HandlerFunc(func(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+		userID, err := user.ExtractOrgID(ctx)
+		require.NoError(t, err)
+		warmups = append(warmups, warmup{userID: userID, query: r.GetQuery(), start: r.GetStart(), end: r.GetEnd()})
+		return &PrometheusResponse{Status: StatusSuccess}, nil
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	cfg := CacheWarmupConfig{Enabled: true, Interval: 15 * time.Second, LeadTime: 30 * time.Second, MinRequests: 3, MaxTrackedQueriesPerTenant: 10}
+	w := NewCacheWarmer(cfg, mockLimits{cacheWarmupMaxQueries: 1}, log.NewNopLogger(), reg)
+	w.Middleware().Wrap(next)
+
+	request := func(userID, query string, at time.Time) {
+		req := &PrometheusRequest{Path: "/api/v1/query_range", Query: query, Start: at.UnixMilli() - length, End: at.UnixMilli(), Step: step}
+		w.track(user.InjectOrgID(context.Background(), userID), req, at)
+	}
+
+	// A dashboard refreshed every minute, requesting "popular" once and "other" twice per refresh.
+	for i := 0; i < 5; i++ {
+		at := t0.Add(time.Duration(i) * time.Minute)
+		request("team-a", "popular", at)
+		request("team-a", "popular", at.Add(time.Second))
+		if i >= 2 {
+			request("team-a", "other", at)
+		}
+	}
+	// Queries requested only once or without caching aren't warmed up.
+	request("team-a", "once", t0.Add(4*time.Minute))
+	w.track(user.InjectOrgID(context.Background(), "team-a"), &PrometheusRequest{Query: "uncached", Step: step, CachingOptions: CachingOptions{Disabled: true}}, t0)
+
+	lastRequest := t0.Add(4 * time.Minute)
+	assert.Equal(t, time.Minute, w.tenants["team-a"][trackedQueryKey{query: "popular", step: step}].refreshInterval)
+	assert.Len(t, w.tenants["team-a"], 3)
+
+	// The next request isn't expected within the lead time.
+	w.warmup(context.Background(), lastRequest.Add(20*time.Second))
+	assert.Empty(t, warmups)
+
+	// Only the most frequent query is warmed up, within the tenant budget.
+	now := lastRequest.Add(40 * time.Second)
+	w.warmup(context.Background(), now)
+	alignedNow := now.UnixMilli() - now.UnixMilli()%step
+	assert.Equal(t, []warmup{{userID: "team-a", query: "popular", start: alignedNow - length, end: alignedNow}}, warmups)
+
+	// The query isn't warmed up again until it's requested.
+	w.warmup(context.Background(), now.Add(15*time.Second))
+	assert.Len(t, warmups, 1)
+
+	// The queries not requested anymore aren't warmed up.
+	request("team-a", "popular", lastRequest.Add(time.Minute))
+	w.warmup(context.Background(), lastRequest.Add(10*time.Minute))
+	assert.Len(t, warmups, 1)
+
+	// The queries not requested for a long time are forgotten.
+	w.warmup(context.Background(), lastRequest.Add(25*time.Hour))
+	assert.Empty(t, w.tenants)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_query_range_cache_warmup_tracked_queries Number of distinct query range requests tracked to learn the most frequent ones.
+		# TYPE cortex_frontend_query_range_cache_warmup_tracked_queries gauge
+		cortex_frontend_query_range_cache_warmup_tracked_queries 0
+		# HELP cortex_frontend_query_range_cache_warmups_total Total number of query range requests run to warm up the results cache.
+		# TYPE cortex_frontend_query_range_cache_warmups_total counter
+		cortex_frontend_query_range_cache_warmups_total{status="success"} 1
+	`)))
+}
+
+func TestCacheWarmer_ShouldEvictTheLeastRecentlyRequestedQueries(t *testing.T) {
+	cfg := CacheWarmupConfig{Enabled: true, Interval: time.Minute, MaxTrackedQueriesPerTenant: 2}
+	w := NewCacheWarmer(cfg, mockLimits{}, log.NewNopLogger(), nil)
+	ctx := user.InjectOrgID(context.Background(), "team-a")
+	t0 := time.Now()
+
+	w.track(ctx, &PrometheusRequest{Query: "first"}, t0)
+	w.track(ctx, &PrometheusRequest{Query: "second"}, t0.Add(time.Minute))
+	w.track(ctx, &PrometheusRequest{Query: "first"}, t0.Add(2*time.Minute))
+	w.track(ctx, &PrometheusRequest{Query: "third"}, t0.Add(3*time.Minute))
+
+	assert.Len(t, w.tenants["team-a"], 2)
+	assert.Contains(t, w.tenants["team-a"], trackedQueryKey{query: "first"})
+	assert.Contains(t, w.tenants["team-a"], trackedQueryKey{query: "third"})
+}
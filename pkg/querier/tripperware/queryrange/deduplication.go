@@ -0,0 +1,129 @@
+package queryrange
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/tenant"
+)
+
+// inflightQuery is a query being executed, whose response is shared with the identical
+// requests received in the meantime.
+type inflightQuery struct {
+	done    chan struct{}
+	waiters int // Guarded by the mutex of the in-flight queries.
+	resp    tripperware.Response
+	err     error
+}
+
+// NewDeduplicationMiddleware makes a new Middleware collapsing the identical concurrent
+// requests: while a query is executing, the requests with the same tenants, query, start,
+// end, step, stats and hints wait for it and share its response instead of being executed too.
+func NewDeduplicationMiddleware(registerer prometheus.Registerer) tripperware.Middleware {
+	collapsed := promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+		Namespace: "cortex",
+		Name:      "frontend_query_range_collapsed_requests_total",
+		Help:      "Total number of query range requests which shared the response of an identical request already executing.",
+	})
+
+	// The in-flight queries are shared by all the handlers wrapped by the middleware.
+	var (
+		mtx      sync.Mutex
+		inflight = map[string]*inflightQuery{}
+	)
+
+	return tripperware.MiddlewareFunc(func(next tripperware.Handler) tripperware.Handler {
+		return deduplication{
+			next:      next,
+			mtx:       &mtx,
+			inflight:  inflight,
+			collapsed: collapsed,
+		}
+	})
+}
+
+type deduplication struct {
+	next tripperware.Handler
+
+	mtx      *sync.Mutex
+	inflight map[string]*inflightQuery
+
+	collapsed prometheus.Counter
+}
+
+func (d deduplication) Do(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	key := deduplicationKey(tenant.JoinTenantIDs(tenantIDs), r)
+
+	d.mtx.Lock()
+	if query, ok := d.inflight[key]; ok {
+		query.waiters++
+		d.mtx.Unlock()
+		d.collapsed.Inc()
+
+		select {
+		case <-query.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// The query was canceled because the client of the first request went away, so
+		// this one has to be executed.
+		if errors.Is(query.err, context.Canceled) {
+			return d.next.Do(ctx, r)
+		}
+		if query.err != nil {
+			return nil, query.err
+		}
+		// The next middlewares may modify the response of each request in place.
+		return proto.Clone(query.resp).(tripperware.Response), nil
+	}
+
+	query := &inflightQuery{done: make(chan struct{})}
+	d.inflight[key] = query
+	d.mtx.Unlock()
+
+	query.resp, query.err = d.next.Do(ctx, r)
+
+	d.mtx.Lock()
+	delete(d.inflight, key)
+	waiters := query.waiters
+	d.mtx.Unlock()
+	close(query.done)
+
+	// The waiting requests clone the response, so it's only modified once they're done with it.
+	if waiters > 0 && query.err == nil {
+		return proto.Clone(query.resp).(tripperware.Response), nil
+	}
+	return query.resp, query.err
+}
+
+func deduplicationKey(userID string, r tripperware.Request) string {
+	var b strings.Builder
+	b.WriteString(userID)
+	for _, part := range []string{
+		strconv.FormatInt(r.GetStart(), 10),
+		strconv.FormatInt(r.GetEnd(), 10),
+		strconv.FormatInt(r.GetStep(), 10),
+		r.GetStats(),
+		r.GetQuery(),
+		hintsKeySuffix(r.GetHints()),
+	} {
+		b.WriteByte(0)
+		b.WriteString(part)
+	}
+	return b.String()
+}
@@ -0,0 +1,233 @@
+package queryrange
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+func TestDeduplicationMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls   = atomic.NewInt32(0)
+		started = make(chan struct{}, 10)
+		release = make(chan struct{})
+	)
+	next := tripperware.HandlerFunc(func(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+		calls.Inc()
+		started <- struct{}{}
+
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return &PrometheusResponse{Status: StatusSuccess, Data: PrometheusData{ResultType: r.GetQuery()}}, nil
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	handler := NewDeduplicationMiddleware(reg).Wrap(next)
+
+	type result struct {
+		resp tripperware.Response
+		err  error
+	}
+	do := func(ctx context.Context, tenantID string, req tripperware.Request) chan result {
+		ch := make(chan result, 1)
+		go func() {
+			resp, err := handler.Do(user.InjectOrgID(ctx, tenantID), req)
+			ch <- result{resp: resp, err: err}
+		}()
+		return ch
+	}
+	// waitCollapsed waits until the given number of requests has been collapsed.
+	waitCollapsed := func(expected int) {
+		require.Eventually(t, func() bool {
+			return testutil.ToFloat64(handler.(deduplication).collapsed) == float64(expected)
+		}, 5*time.Second, time.Millisecond)
+	}
+
+	req := &PrometheusRequest{Query: "up", Start: 0, End: 3600000, Step: 60000}
+
+	// The first request is executed, while the identical ones wait for it.
+	first := do(context.Background(), "team-a", req)
+	<-started
+	var collapsed []chan result
+	for i := 0; i < 3; i++ {
+		collapsed = append(collapsed, do(context.Background(), "team-a", req))
+	}
+	waitCollapsed(3)
+
+	// The requests of another tenant or with different parameters are executed.
+	otherTenant := do(context.Background(), "team-b", req)
+	<-started
+	otherStep := do(context.Background(), "team-a", &PrometheusRequest{Query: "up", Start: 0, End: 3600000, Step: 30000})
+	<-started
+	assert.Equal(t, int32(3), calls.Load())
+
+	close(release)
+	firstResult := <-first
+	require.NoError(t, firstResult.err)
+	for _, ch := range collapsed {
+		res := <-ch
+		require.NoError(t, res.err)
+		// Each request gets its own copy of the response.
+		assert.Equal(t, firstResult.resp, res.resp)
+		assert.NotSame(t, firstResult.resp, res.resp)
+	}
+	for _, ch := range []chan result{otherTenant, otherStep} {
+		res := <-ch
+		require.NoError(t, res.err)
+		assert.NotSame(t, firstResult.resp, res.resp)
+	}
+
+	// Once done, the request is executed again.
+	require.NoError(t, (<-do(context.Background(), "team-a", req)).err)
+	<-started
+	assert.Equal(t, int32(4), calls.Load())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_query_range_collapsed_requests_total Total number of query range requests which shared the response of an identical request already executing.
+		# TYPE cortex_frontend_query_range_collapsed_requests_total counter
+		cortex_frontend_query_range_collapsed_requests_total 3
+	`)))
+}
+
+func TestDeduplicationMiddleware_ShouldNotCollapseTheRequestsWithDifferentHints(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls   = atomic.NewInt32(0)
+		release = make(chan struct{})
+	)
+	next := tripperware.HandlerFunc(func(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+		calls.Inc()
+		<-release
+		return &PrometheusResponse{Status: StatusSuccess}, nil
+	})
+
+	handler := NewDeduplicationMiddleware(nil).Wrap(next)
+	ctx := user.InjectOrgID(context.Background(), "team-a")
+	req := &PrometheusRequest{Query: "up", Start: 0, End: 3600000, Step: 60000}
+
+	// The requests only differ by the resolution of the data they're evaluated against, or by
+	// their partial response mode.
+	var wg sync.WaitGroup
+	for _, hints := range []tripperware.RequestHints{
+		{},
+		{MaxSourceResolution: 300000},
+		{Metadata: map[string]string{tripperware.PartialResponseHint: "false"}},
+		{Metadata: map[string]string{tripperware.PartialResponseHint: "true"}},
+	} {
+		wg.Add(1)
+		go func(r tripperware.Request) {
+			defer wg.Done()
+			_, err := handler.Do(ctx, r)
+			assert.NoError(t, err)
+		}(req.WithHints(hints))
+	}
+
+	// All the requests are executing concurrently.
+	assert.Eventually(t, func() bool {
+		return calls.Load() == 4
+	}, 5*time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, float64(0), testutil.ToFloat64(handler.(deduplication).collapsed))
+}
+
+func TestDeduplicationMiddleware_ShouldExecuteTheRequestIfTheFirstOneIsCanceled(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls   = atomic.NewInt32(0)
+		started = make(chan struct{}, 10)
+	)
+	next := tripperware.HandlerFunc(func(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+		// Only the first call blocks until canceled.
+		if calls.Inc() == 1 {
+			started <- struct{}{}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &PrometheusResponse{Status: StatusSuccess}, nil
+	})
+
+	handler := NewDeduplicationMiddleware(nil).Wrap(next)
+	req := &PrometheusRequest{Query: "up", Start: 0, End: 3600000, Step: 60000}
+
+	firstCtx, cancel := context.WithCancel(user.InjectOrgID(context.Background(), "team-a"))
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := handler.Do(firstCtx, req)
+		firstErr <- err
+	}()
+	<-started
+
+	secondResp := make(chan tripperware.Response, 1)
+	go func() {
+		resp, err := handler.Do(user.InjectOrgID(context.Background(), "team-a"), req)
+		assert.NoError(t, err)
+		secondResp <- resp
+	}()
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(handler.(deduplication).collapsed) == 1
+	}, 5*time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	assert.Equal(t, &PrometheusResponse{Status: StatusSuccess}, <-secondResp)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestDeduplicationMiddleware_ShouldShareTheInflightQueriesAcrossHandlers(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	calls := atomic.NewInt32(0)
+	next := tripperware.HandlerFunc(func(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+		calls.Inc()
+		wg.Done()
+		<-release
+		return &PrometheusResponse{Status: StatusSuccess}, nil
+	})
+
+	// The in-flight queries are shared by all the handlers wrapped by the same middleware.
+	middleware := NewDeduplicationMiddleware(nil)
+	first, second := middleware.Wrap(next), middleware.Wrap(next)
+	ctx := user.InjectOrgID(context.Background(), "team-a")
+	req := &PrometheusRequest{Query: "up", Start: 0, End: 3600000, Step: 60000}
+
+	done := make(chan struct{})
+	go func() {
+		_, err := first.Do(ctx, req)
+		assert.NoError(t, err)
+		close(done)
+	}()
+	wg.Wait()
+
+	go func() {
+		assert.Eventually(t, func() bool {
+			return testutil.ToFloat64(second.(deduplication).collapsed) == 1
+		}, 5*time.Second, time.Millisecond)
+		close(release)
+	}()
+	_, err := second.Do(ctx, req)
+	require.NoError(t, err)
+	<-done
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -198,6 +198,9 @@ type mockLimits struct {
 	maxQueryLookback  time.Duration
 	maxQueryLength    time.Duration
 	maxCacheFreshness time.Duration
+
+	cacheWarmupMaxQueries int
+	queryTimeZone         string
 }
 
 func (m mockLimits) MaxQueryLookback(string) time.Duration {
@@ -224,6 +227,30 @@ func (m mockLimits) QueryPriority(userID string) validation.QueryPriority {
 	return validation.QueryPriority{}
 }
 
+func (m mockLimits) QueryAllowedFunctions(userID string) []string {
+	return nil
+}
+
+func (m mockLimits) QuerySubqueriesDisabled(userID string) bool {
+	return false
+}
+
+func (m mockLimits) QueryMaxAtModifierLookback(userID string) time.Duration {
+	return 0
+}
+
+func (m mockLimits) CacheWarmupMaxQueries(userID string) int {
+	return m.cacheWarmupMaxQueries
+}
+
+func (mockLimits) QueryEndTimeOffset(string) time.Duration {
+	return 0
+}
+
+func (m mockLimits) QueryTimeZone(string) string {
+	return m.queryTimeZone
+}
+
 type mockHandler struct {
 	mock.Mock
 }
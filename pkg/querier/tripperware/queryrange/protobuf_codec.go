@@ -0,0 +1,107 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+const (
+	// RequestFormatHTTP encodes the requests sent to the queriers as HTTP form values.
+	RequestFormatHTTP = "http"
+	// RequestFormatProtobuf encodes the requests sent to the queriers as protobuf.
+	RequestFormatProtobuf = "protobuf"
+
+	// protobufRequestContentType is the content type of the protobuf encoded requests.
+	protobufRequestContentType = "application/x-protobuf"
+)
+
+var requestFormats = []string{RequestFormatHTTP, RequestFormatProtobuf}
+
+type protobufCodec struct {
+	prometheusCodec
+}
+
+// NewProtobufCodec returns a codec which encodes the requests sent to the queriers as protobuf,
+// saving the cost of encoding and parsing the HTTP form values. The requests received by the
+// query-frontend and the responses are still encoded as HTTP, so that the codec is compatible with
+// any Prometheus API client. The queriers must decode the requests with ProtobufRequestMiddleware.
+func NewProtobufCodec(sharded bool) tripperware.Codec {
+	return protobufCodec{prometheusCodec: prometheusCodec{sharded: sharded}}
+}
+
+func (protobufCodec) EncodeRequest(ctx context.Context, r tripperware.Request) (*http.Request, error) {
+	promReq, ok := r.(*PrometheusRequest)
+	if !ok {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "invalid request format")
+	}
+
+	// Headers are sent as HTTP headers.
+	body := *promReq
+	body.Headers = nil
+	data, err := body.Marshal()
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusInternalServerError, "error encoding request: %v", err)
+	}
+
+	h := promReq.downstreamHeaders()
+	h.Set("Content-Type", protobufRequestContentType)
+
+	u := &url.URL{Path: promReq.Path}
+	req := &http.Request{
+		Method:        "POST",
+		RequestURI:    u.String(), // This is what the httpgrpc code looks at.
+		URL:           u,
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Header:        h,
+	}
+
+	return req.WithContext(ctx), nil
+}
+
+// ProtobufRequestMiddleware decodes the query range requests encoded as protobuf by the
+// query-frontend into the query parameters expected by the Prometheus API. Any other request
+// is passed through unchanged.
+func ProtobufRequestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != protobufRequestContentType {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error reading request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		var promReq PrometheusRequest
+		if err := promReq.Unmarshal(data); err != nil {
+			http.Error(w, fmt.Sprintf("error decoding request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		u := *r.URL
+		u.RawQuery = promReq.queryParams().Encode()
+
+		decoded := r.Clone(r.Context())
+		decoded.Method = "GET"
+		decoded.URL = &u
+		decoded.RequestURI = u.String()
+		decoded.Body = http.NoBody
+		decoded.ContentLength = 0
+		decoded.Header.Del("Content-Type")
+		decoded.Form = nil
+		decoded.PostForm = nil
+
+		next.ServeHTTP(w, decoded)
+	})
+}
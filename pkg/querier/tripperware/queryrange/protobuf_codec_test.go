@@ -0,0 +1,79 @@
+package queryrange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+func TestProtobufCodec_EncodeRequest(t *testing.T) {
+	t.Parallel()
+
+	req := &PrometheusRequest{
+		Path:    "/api/v1/query_range",
+		Start:   1536673680 * 1e3,
+		End:     1536716898 * 1e3,
+		Step:    120 * 1e3,
+		Timeout: time.Minute,
+		Query:   "sum(container_memory_rss) by (namespace)",
+		Stats:   "all",
+		Headers: reqHeaders,
+		Hints:   tripperware.RequestHints{MaxSourceResolution: 300 * 1e3},
+	}
+
+	httpReq, err := NewProtobufCodec(false).EncodeRequest(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, http.MethodPost, httpReq.Method)
+	require.Equal(t, "/api/v1/query_range", httpReq.RequestURI)
+	require.Equal(t, "test", httpReq.Header.Get("Test-Header"))
+	require.Equal(t, "300000", httpReq.Header.Get(tripperware.MaxSourceResolutionHeader))
+
+	// The querier must see the same request it would receive from the HTTP codec.
+	var decoded tripperware.Request
+	handler := ProtobufRequestMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		decoded, err = PrometheusCodec.DecodeRequest(r.Context(), r, []string{"Test-Header"})
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httpReq)
+	require.NoError(t, err)
+
+	expected := *req
+	expected.Timeout = 0
+	require.Equal(t, &expected, decoded)
+}
+
+func TestProtobufRequestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should pass through HTTP encoded requests", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, err := PrometheusCodec.EncodeRequest(context.Background(), parsedRequest)
+		require.NoError(t, err)
+
+		var actual *http.Request
+		ProtobufRequestMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			actual = r
+		})).ServeHTTP(httptest.NewRecorder(), httpReq)
+		require.Same(t, httpReq, actual)
+	})
+
+	t.Run("should reject invalid protobuf requests", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/query_range", strings.NewReader("\xff"))
+		httpReq.Header.Set("Content-Type", protobufRequestContentType)
+
+		w := httptest.NewRecorder()
+		ProtobufRequestMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			t.Fatal("next handler should not be called")
+		})).ServeHTTP(w, httpReq)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/spanlogger"
 )
@@ -74,6 +75,13 @@ func (q *PrometheusRequest) WithStats(stats string) tripperware.Request {
 	return &new
 }
 
+// WithHints clones the current `PrometheusRequest` with new hints.
+func (q *PrometheusRequest) WithHints(hints tripperware.RequestHints) tripperware.Request {
+	new := *q
+	new.Hints = hints
+	return &new
+}
+
 // LogToSpan logs the current `PrometheusRequest` parameters to the specified span.
 func (q *PrometheusRequest) LogToSpan(sp opentracing.Span) {
 	sp.LogFields(
@@ -81,6 +89,7 @@ func (q *PrometheusRequest) LogToSpan(sp opentracing.Span) {
 		otlog.String("start", timestamp.Time(q.GetStart()).String()),
 		otlog.String("end", timestamp.Time(q.GetEnd()).String()),
 		otlog.Int64("step (ms)", q.GetStep()),
+		otlog.Int64("max source resolution (ms)", q.Hints.MaxSourceResolution),
 	)
 }
 
@@ -194,6 +203,28 @@ func (c prometheusCodec) DecodeRequest(_ context.Context, r *http.Request, forwa
 	result.Stats = r.FormValue("stats")
 	result.Path = r.URL.Path
 
+	result.Hints, err = tripperware.DecodeHints(r.Header)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	// The resolution is resolved here, so that all the requests pushed down to
+	// the queriers are evaluated at the same resolution.
+	if v := r.FormValue(downsample.MaxSourceResolutionParam); v != "" {
+		result.Hints.MaxSourceResolution, err = downsample.ParseMaxSourceResolution(v, result.Step)
+		if err != nil {
+			return nil, decorateWithParamName(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), downsample.MaxSourceResolutionParam)
+		}
+	}
+
+	// The time zone offset is resolved from the tenant limits by the time zone align middleware.
+	if v := r.FormValue(AlignToTimeZoneParam); v != "" {
+		result.Hints.AlignToTimeZone, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, decorateWithParamName(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), AlignToTimeZoneParam)
+		}
+	}
+
 	// Include the specified headers from http request in prometheusRequest.
 	for _, header := range forwardHeaders {
 		for h, hv := range r.Header {
@@ -219,39 +250,50 @@ func (prometheusCodec) EncodeRequest(ctx context.Context, r tripperware.Request)
 	if !ok {
 		return nil, httpgrpc.Errorf(http.StatusBadRequest, "invalid request format")
 	}
-	params := url.Values{
-		"start": []string{tripperware.EncodeTime(promReq.Start)},
-		"end":   []string{tripperware.EncodeTime(promReq.End)},
-		"step":  []string{encodeDurationMs(promReq.Step)},
-		"query": []string{promReq.Query},
-		"stats": []string{promReq.Stats},
-	}
 	u := &url.URL{
 		Path:     promReq.Path,
-		RawQuery: params.Encode(),
-	}
-	var h = http.Header{}
-
-	for _, hv := range promReq.Headers {
-		for _, v := range hv.Values {
-			h.Add(hv.Name, v)
-		}
+		RawQuery: promReq.queryParams().Encode(),
 	}
 
-	// Always ask gzip to the querier
-	h.Set("Accept-Encoding", "gzip")
-
 	req := &http.Request{
 		Method:     "GET",
 		RequestURI: u.String(), // This is what the httpgrpc code looks at.
 		URL:        u,
 		Body:       http.NoBody,
-		Header:     h,
+		Header:     promReq.downstreamHeaders(),
 	}
 
 	return req.WithContext(ctx), nil
 }
 
+// queryParams returns the Prometheus API query parameters of the request.
+func (q *PrometheusRequest) queryParams() url.Values {
+	return url.Values{
+		"start": []string{tripperware.EncodeTime(q.Start)},
+		"end":   []string{tripperware.EncodeTime(q.End)},
+		"step":  []string{encodeDurationMs(q.Step)},
+		"query": []string{q.Query},
+		"stats": []string{q.Stats},
+	}
+}
+
+// downstreamHeaders returns the headers of the request sent to the queriers.
+func (q *PrometheusRequest) downstreamHeaders() http.Header {
+	var h = http.Header{}
+
+	for _, hv := range q.Headers {
+		for _, v := range hv.Values {
+			h.Add(hv.Name, v)
+		}
+	}
+
+	tripperware.EncodeHints(q.Hints, h)
+
+	// Always ask gzip to the querier
+	h.Set("Accept-Encoding", "gzip")
+	return h
+}
+
 func (prometheusCodec) DecodeResponse(ctx context.Context, r *http.Response, _ tripperware.Request) (tripperware.Response, error) {
 	log, ctx := spanlogger.New(ctx, "ParseQueryRangeResponse") //nolint:ineffassign,staticcheck
 	defer log.Finish()
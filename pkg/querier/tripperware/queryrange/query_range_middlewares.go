@@ -17,6 +17,8 @@ package queryrange
 
 import (
 	"flag"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
@@ -27,6 +29,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/chunk/cache"
 	"github.com/cortexproject/cortex/pkg/querier"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
 )
 
@@ -41,6 +44,20 @@ type Config struct {
 	MaxRetries             int  `yaml:"max_retries"`
 	// List of headers which query_range middleware chain would forward to downstream querier.
 	ForwardHeaders flagext.StringSlice `yaml:"forward_headers_list"`
+	// Format of the requests sent to the downstream querier.
+	DownstreamRequestFormat string `yaml:"downstream_request_format"`
+	// How to handle invalid responses.
+	ResponseValidation string `yaml:"response_validation"`
+	// Whether identical concurrent requests share the response.
+	DeduplicateQueries bool `yaml:"deduplicate_queries"`
+	// Warm-up of the results cache entries of the most frequent queries.
+	CacheWarmup CacheWarmupConfig `yaml:"cache_warmup"`
+	// Join of the info metric labels onto the query results.
+	InfoJoin tripperware.InfoJoinConfig `yaml:"info_join"`
+	// Cache of the labels, label values and series responses.
+	MetadataCache tripperware.MetadataCacheConfig `yaml:"metadata_cache"`
+	// Persistence of the split queries of the failed queries, for their retries.
+	QueryState QueryStateConfig `yaml:"query_state"`
 
 	// Populated based on the query configuration
 	VerticalShardSize int `yaml:"-"`
@@ -53,11 +70,24 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.AlignQueriesWithStep, "querier.align-querier-with-step", false, "Mutate incoming queries to align their start and end with their step.")
 	f.BoolVar(&cfg.CacheResults, "querier.cache-results", false, "Cache query results.")
 	f.Var(&cfg.ForwardHeaders, "frontend.forward-headers-list", "List of headers forwarded by the query Frontend to downstream querier.")
+	f.StringVar(&cfg.DownstreamRequestFormat, "frontend.downstream-request-format", RequestFormatHTTP, fmt.Sprintf("Experimental: Format of the query range requests sent by the query Frontend to downstream querier. Supported values: %s. The protobuf format is cheaper to encode, but requires queriers supporting it.", strings.Join(requestFormats, ", ")))
+	f.StringVar(&cfg.ResponseValidation, "frontend.response-validation", ResponseValidationDisabled, fmt.Sprintf("Experimental: Sanity-check query range responses before caching and returning them: duplicated series, unordered or misaligned samples and samples outside of the requested range. Supported values: %s. In log mode invalid responses are only logged, while in reject mode they're also replaced by an error.", strings.Join(responseValidationModes, ", ")))
+	f.BoolVar(&cfg.DeduplicateQueries, "frontend.deduplicate-queries", false, "Experimental: Collapse the identical concurrent query range requests (same tenant, query, start, end, step and hints): while a query is executing, the identical requests wait for it and get a copy of its response.")
 	cfg.ResultsCacheConfig.RegisterFlags(f)
+	cfg.CacheWarmup.RegisterFlags(f)
+	cfg.InfoJoin.RegisterFlags(f)
+	cfg.MetadataCache.RegisterFlags(f)
+	cfg.QueryState.RegisterFlags(f)
 }
 
 // Validate validates the config.
 func (cfg *Config) Validate(qCfg querier.Config) error {
+	if !util.StringsContain(requestFormats, cfg.DownstreamRequestFormat) {
+		return errors.Errorf("unsupported downstream request format %q", cfg.DownstreamRequestFormat)
+	}
+	if !util.StringsContain(responseValidationModes, cfg.ResponseValidation) {
+		return errors.Errorf("unsupported response validation mode %q", cfg.ResponseValidation)
+	}
 	if cfg.CacheResults {
 		if cfg.SplitQueriesByInterval <= 0 {
 			return errors.New("querier.cache-results may only be enabled in conjunction with querier.split-queries-by-interval. Please set the latter")
@@ -66,10 +96,27 @@ func (cfg *Config) Validate(qCfg querier.Config) error {
 			return errors.Wrap(err, "invalid ResultsCache config")
 		}
 	}
+	if err := cfg.CacheWarmup.Validate(cfg.CacheResults); err != nil {
+		return errors.Wrap(err, "invalid cache warm-up config")
+	}
+	if err := cfg.InfoJoin.Validate(); err != nil {
+		return errors.Wrap(err, "invalid info join config")
+	}
+	if err := cfg.MetadataCache.Validate(); err != nil {
+		return errors.Wrap(err, "invalid metadata cache config")
+	}
+	if cfg.QueryState.Enabled && cfg.SplitQueriesByInterval <= 0 {
+		return errors.New("frontend.query-state.enabled may only be enabled in conjunction with querier.split-queries-by-interval. Please set the latter")
+	}
+	if err := cfg.QueryState.Validate(); err != nil {
+		return errors.Wrap(err, "invalid query state config")
+	}
 	return nil
 }
 
-// Middlewares returns list of middlewares that should be applied for range query.
+// Middlewares returns list of middlewares that should be applied for range query. The returned
+// CacheWarmer, if the results cache warm-up is enabled, has to be started to run the warm-ups.
+// The returned cache, made of the caches used by the middlewares, has to be stopped.
 func Middlewares(
 	cfg Config,
 	log log.Logger,
@@ -79,20 +126,51 @@ func Middlewares(
 	queryAnalyzer querysharding.Analyzer,
 	prometheusCodec tripperware.Codec,
 	shardedPrometheusCodec tripperware.Codec,
-) ([]tripperware.Middleware, cache.Cache, error) {
+) ([]tripperware.Middleware, cache.Cache, *CacheWarmer, error) {
 	// Metric used to keep track of each middleware execution duration.
-	metrics := tripperware.NewInstrumentMiddlewareMetrics(registerer)
+	metrics := tripperware.NewInstrumentMiddlewareMetrics(registerer, log)
 
 	queryRangeMiddleware := []tripperware.Middleware{NewLimitsMiddleware(limits)}
+
+	// The warm-up requests run through all the following middlewares, to fill the results cache
+	// like the requests they anticipate.
+	var cacheWarmer *CacheWarmer
+	if cfg.CacheWarmup.Enabled {
+		cacheWarmer = NewCacheWarmer(cfg.CacheWarmup, limits, log, registerer)
+		queryRangeMiddleware = append(queryRangeMiddleware, cacheWarmer.Middleware())
+	}
+	if cfg.DeduplicateQueries {
+		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("deduplication", metrics), NewDeduplicationMiddleware(registerer))
+	}
+
+	// The response validation runs both on the final response and on the responses
+	// going to be cached, which are the merge of the sharded queries.
+	var responseValidation tripperware.Middleware
+	if cfg.ResponseValidation != "" && cfg.ResponseValidation != ResponseValidationDisabled {
+		responseValidation = NewResponseValidationMiddleware(cfg.ResponseValidation, log, registerer)
+		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("response_validation", metrics), responseValidation)
+	}
+	// The time zone alignment is resolved before the step alignment, the split by interval and the
+	// results cache, following the boundaries of the time zone.
+	queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("time_zone_align", metrics), NewTimeZoneAlignMiddleware(limits))
 	if cfg.AlignQueriesWithStep {
 		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("step_align", metrics), StepAlignMiddleware)
 	}
+	var caches []cache.Cache
 	if cfg.SplitQueriesByInterval != 0 {
+		var state *QueryState
+		if cfg.QueryState.Enabled {
+			var err error
+			state, err = NewQueryState(cfg.QueryState, log, registerer)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			caches = append(caches, state.cache)
+		}
 		staticIntervalFn := func(_ tripperware.Request) time.Duration { return cfg.SplitQueriesByInterval }
-		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("split_by_interval", metrics), SplitByIntervalMiddleware(staticIntervalFn, limits, prometheusCodec, registerer))
+		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("split_by_interval", metrics), SplitByIntervalMiddleware(staticIntervalFn, limits, prometheusCodec, state, registerer), tripperware.SubRequestsMiddleware("split_by_interval", metrics))
 	}
 
-	var c cache.Cache
 	if cfg.CacheResults {
 		shouldCache := func(r tripperware.Request) bool {
 			if v, ok := r.(*PrometheusRequest); ok {
@@ -102,13 +180,20 @@ func Middlewares(
 		}
 		queryCacheMiddleware, cache, err := NewResultsCacheMiddleware(log, cfg.ResultsCacheConfig, constSplitter(cfg.SplitQueriesByInterval), limits, prometheusCodec, cacheExtractor, shouldCache, registerer)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
-		c = cache
-		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("results_cache", metrics), queryCacheMiddleware)
+		caches = append(caches, cache)
+		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("results_cache", metrics), queryCacheMiddleware, tripperware.SubRequestsMiddleware("results_cache", metrics))
 	}
 
-	queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("shardBy", metrics), tripperware.ShardByMiddleware(log, limits, shardedPrometheusCodec, queryAnalyzer))
+	if responseValidation != nil {
+		queryRangeMiddleware = append(queryRangeMiddleware, responseValidation)
+	}
+	queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("shardBy", metrics), tripperware.ShardByMiddleware(log, limits, shardedPrometheusCodec, queryAnalyzer), tripperware.SubRequestsMiddleware("shardBy", metrics))
 
-	return queryRangeMiddleware, c, nil
+	var c cache.Cache
+	if len(caches) > 0 {
+		c = cache.NewTiered(caches)
+	}
+	return queryRangeMiddleware, c, cacheWarmer, nil
 }
@@ -52,7 +52,7 @@ func TestRoundTrip(t *testing.T) {
 	}
 
 	qa := querysharding.NewQueryAnalyzer()
-	queyrangemiddlewares, _, err := Middlewares(Config{},
+	queyrangemiddlewares, _, _, err := Middlewares(Config{},
 		log.NewNopLogger(),
 		mockLimits{},
 		nil,
@@ -75,6 +75,7 @@ func TestRoundTrip(t *testing.T) {
 		time.Minute,
 		0,
 		0,
+		tripperware.InfoJoinConfig{},
 	)
 
 	for i, tc := range []struct {
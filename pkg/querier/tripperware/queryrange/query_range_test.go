@@ -37,6 +37,10 @@ func TestRequest(t *testing.T) {
 			url:      query,
 			expected: &parsedRequestWithHeaders,
 		},
+		{
+			url:         "api/v1/query_range?start=123&end=456&step=60&max_source_resolution=foo",
+			expectedErr: httpgrpc.Errorf(http.StatusBadRequest, "invalid parameter \"max_source_resolution\"; invalid max source resolution \"foo\""),
+		},
 		{
 			url:         "api/v1/query_range?start=foo&stats=all",
 			expectedErr: httpgrpc.Errorf(http.StatusBadRequest, "invalid parameter \"start\"; cannot parse \"foo\" to a valid timestamp"),
@@ -88,6 +92,34 @@ func TestRequest(t *testing.T) {
 	}
 }
 
+func TestRequestHints(t *testing.T) {
+	t.Parallel()
+
+	r, err := http.NewRequest("GET", query+"&max_source_resolution=5m", nil)
+	require.NoError(t, err)
+	r.Header.Set(tripperware.RequestHintsHeader, "cache=bypass")
+
+	req, err := PrometheusCodec.DecodeRequest(context.Background(), r, nil)
+	require.NoError(t, err)
+
+	expected := tripperware.RequestHints{MaxSourceResolution: 300 * 1e3, Metadata: map[string]string{"cache": "bypass"}}
+	require.Equal(t, expected, req.GetHints())
+
+	// Hints must be propagated to the requests pushed down by the middlewares.
+	req = req.WithQuery("sum(up)").WithStartEnd(0, 1000)
+	require.Equal(t, expected, req.GetHints())
+
+	// Hints are forwarded to the downstream request via headers.
+	downstream, err := PrometheusCodec.EncodeRequest(context.Background(), req)
+	require.NoError(t, err)
+	require.NotContains(t, downstream.RequestURI, "max_source_resolution")
+	require.Equal(t, "300000", downstream.Header.Get(tripperware.MaxSourceResolutionHeader))
+
+	decoded, err := PrometheusCodec.DecodeRequest(context.Background(), downstream, nil)
+	require.NoError(t, err)
+	require.Equal(t, expected, decoded.GetHints())
+}
+
 func TestResponse(t *testing.T) {
 	t.Parallel()
 	r := *parsedResponse
@@ -0,0 +1,212 @@
+package queryrange
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+// QueryStateConfig configures the persistence of the split queries which succeeded when a query fails.
+type QueryStateConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	TTL         time.Duration `yaml:"ttl"`
+	CacheConfig cache.Config  `yaml:"cache"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *QueryStateConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "frontend.query-state.enabled", false, "Experimental: When a query split by interval fails transiently, persist the results of its split queries which succeeded, so that the retry of the same query only executes the missing split queries. Requires the split of the queries by interval.")
+	f.DurationVar(&cfg.TTL, "frontend.query-state.ttl", 5*time.Minute, "How long the results of the split queries of a failed query are kept for its retry.")
+	cfg.CacheConfig.RegisterFlagsWithPrefix("frontend.query-state.", "", f)
+}
+
+// Validate validates the config.
+func (cfg *QueryStateConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TTL <= 0 {
+		return errors.New("the query state TTL must be positive")
+	}
+	return cfg.CacheConfig.Validate()
+}
+
+// QueryState keeps the results of the split queries which succeeded when a query fails, keyed by
+// the query, for its retry.
+type QueryState struct {
+	cache  cache.Cache
+	logger log.Logger
+
+	persistedQueries prometheus.Counter
+	resumedQueries   prometheus.Counter
+	reusedSplits     prometheus.Counter
+}
+
+// NewQueryState makes a new QueryState.
+func NewQueryState(cfg QueryStateConfig, logger log.Logger, reg prometheus.Registerer) (*QueryState, error) {
+	// The results are only kept for the retries of the failed queries.
+	if cfg.CacheConfig.DefaultValidity == 0 {
+		cfg.CacheConfig.DefaultValidity = cfg.TTL
+	}
+	c, err := cache.New(cfg.CacheConfig, reg, logger)
+	if err != nil {
+		return nil, err
+	}
+	if cache.IsEmptyTieredCache(c) {
+		return nil, errors.New("the query state requires a cache backend")
+	}
+	return newQueryState(c, logger, reg), nil
+}
+
+func newQueryState(c cache.Cache, logger log.Logger, reg prometheus.Registerer) *QueryState {
+	return &QueryState{
+		cache:  c,
+		logger: logger,
+		persistedQueries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_state_persisted_queries_total",
+			Help: "Total number of failed queries whose succeeded split queries have been persisted for the retries.",
+		}),
+		resumedQueries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_state_resumed_queries_total",
+			Help: "Total number of queries resumed from the persisted split queries of a previous failed execution.",
+		}),
+		reusedSplits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_state_reused_split_queries_total",
+			Help: "Total number of split queries served from the persisted results of a previous failed execution.",
+		}),
+	}
+}
+
+// Stop stops the cache.
+func (q *QueryState) Stop() {
+	q.cache.Stop()
+}
+
+// completed returns the persisted results of the split queries of the query with the given key,
+// among the given ones, and the split queries which are missing.
+func (q *QueryState) completed(ctx context.Context, key string, reqs []tripperware.Request) ([]tripperware.RequestResponse, []tripperware.Request) {
+	extents, ok := q.get(ctx, key)
+	if !ok {
+		return nil, reqs
+	}
+
+	byRange := make(map[[2]int64]tripperware.Response, len(extents))
+	for _, e := range extents {
+		resp, err := e.toResponse()
+		if err != nil {
+			level.Error(util_log.WithContext(ctx, q.logger)).Log("msg", "error decoding the persisted split query", "err", err)
+			return nil, reqs
+		}
+		byRange[[2]int64{e.Start, e.End}] = resp
+	}
+
+	var (
+		done    []tripperware.RequestResponse
+		missing []tripperware.Request
+	)
+	for _, req := range reqs {
+		if resp, ok := byRange[[2]int64{req.GetStart(), req.GetEnd()}]; ok {
+			done = append(done, tripperware.RequestResponse{Request: req, Response: resp})
+			continue
+		}
+		missing = append(missing, req)
+	}
+	if len(done) > 0 {
+		q.resumedQueries.Inc()
+		q.reusedSplits.Add(float64(len(done)))
+	}
+	return done, missing
+}
+
+// persist keeps the results of the split queries which succeeded, if the query failed because of
+// a transient error.
+func (q *QueryState) persist(ctx context.Context, key string, done []tripperware.RequestResponse, queryErr error) {
+	// The queries failing because of the request, like the limits or a bad query, fail again.
+	if resp, ok := httpgrpc.HTTPResponseFromError(queryErr); ok && resp.Code/100 == 4 {
+		return
+	}
+
+	extents := make([]Extent, 0, len(done))
+	for _, reqResp := range done {
+		// The responses which mustn't be cached, like the partial ones, are executed again.
+		if isNoStoreResponse(reqResp.Response) {
+			continue
+		}
+		extent, err := toExtent(ctx, reqResp.Request, reqResp.Response)
+		if err != nil {
+			level.Error(util_log.WithContext(ctx, q.logger)).Log("msg", "error encoding the split query to persist", "err", err)
+			return
+		}
+		extents = append(extents, extent)
+	}
+	if len(extents) == 0 {
+		return
+	}
+
+	buf, err := proto.Marshal(&CachedResponse{
+		Key:     key,
+		Extents: extents,
+	})
+	if err != nil {
+		level.Error(util_log.WithContext(ctx, q.logger)).Log("msg", "error marshalling the query state", "err", err)
+		return
+	}
+	q.cache.Store(ctx, []string{cache.HashKey(key)}, [][]byte{buf})
+	q.persistedQueries.Inc()
+}
+
+// clear drops the results of the split queries once the query succeeded, so that they don't
+// outlive the query they have been kept for.
+func (q *QueryState) clear(ctx context.Context, key string) {
+	buf, err := proto.Marshal(&CachedResponse{Key: key})
+	if err != nil {
+		level.Error(util_log.WithContext(ctx, q.logger)).Log("msg", "error marshalling the query state", "err", err)
+		return
+	}
+	q.cache.Store(ctx, []string{cache.HashKey(key)}, [][]byte{buf})
+}
+
+func (q *QueryState) get(ctx context.Context, key string) ([]Extent, bool) {
+	found, bufs, _ := q.cache.Fetch(ctx, []string{cache.HashKey(key)})
+	if len(found) != 1 {
+		return nil, false
+	}
+
+	var resp CachedResponse
+	if err := proto.Unmarshal(bufs[0], &resp); err != nil {
+		level.Error(util_log.WithContext(ctx, q.logger)).Log("msg", "error unmarshalling the query state", "err", err)
+		return nil, false
+	}
+	// The keys are hashed, the colliding ones are ignored.
+	if resp.Key != key {
+		return nil, false
+	}
+	return resp.Extents, true
+}
+
+func isNoStoreResponse(r tripperware.Response) bool {
+	for _, v := range getHeaderValuesWithName(r, cacheControlHeader) {
+		if v == noStoreValue {
+			return true
+		}
+	}
+	return false
+}
+
+// queryStateKey returns the key of the state of the query, which is the same for its retries.
+func queryStateKey(userID string, r tripperware.Request) string {
+	return fmt.Sprintf("query-state:%s:%d:%d:%d:%s:%s", userID, r.GetStart(), r.GetEnd(), r.GetStep(), r.GetStats(), r.GetQuery()) + hintsKeySuffix(r.GetHints())
+}
@@ -0,0 +1,167 @@
+package queryrange
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+func TestSplitByInterval_QueryState(t *testing.T) {
+	t.Parallel()
+
+	// The request is split in 3 days.
+	req := &PrometheusRequest{
+		Start: 0,
+		End:   3*toMs(day) - 15*seconds,
+		Step:  15 * seconds,
+		Query: "foo",
+	}
+	failedStart := toMs(day)
+
+	tests := map[string]struct {
+		err                  error
+		expectedRetryQueries []int64
+	}{
+		"transient failure": {
+			err:                  httpgrpc.Errorf(http.StatusInternalServerError, "ingester unavailable"),
+			expectedRetryQueries: []int64{failedStart},
+		},
+		"failure caused by the request": {
+			err:                  httpgrpc.Errorf(http.StatusUnprocessableEntity, "query limit reached"),
+			expectedRetryQueries: []int64{0, failedStart, 2 * toMs(day)},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mtx     sync.Mutex
+				fail    = true
+				queries []int64
+			)
+			next := tripperware.HandlerFunc(func(_ context.Context, r tripperware.Request) (tripperware.Response, error) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				queries = append(queries, r.GetStart())
+				if fail && r.GetStart() == failedStart {
+					return nil, tc.err
+				}
+				return splitResponse(r), nil
+			})
+			reset := func() []int64 {
+				mtx.Lock()
+				defer mtx.Unlock()
+				executed := queries
+				queries = nil
+				fail = false
+				return executed
+			}
+
+			state := newQueryState(cache.NewMockCache(), log.NewNopLogger(), nil)
+			interval := func(_ tripperware.Request) time.Duration { return day }
+			handler := SplitByIntervalMiddleware(interval, mockLimits{}, PrometheusCodec, state, nil).Wrap(next)
+			ctx := user.InjectOrgID(context.Background(), "1")
+
+			_, err := handler.Do(ctx, req)
+			require.Error(t, err)
+			assert.Len(t, reset(), 3)
+
+			resp, err := handler.Do(ctx, req)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expectedRetryQueries, reset())
+			require.Len(t, resp.(*PrometheusResponse).Data.Result, 1)
+			assert.Equal(t, []cortexpb.Sample{
+				{TimestampMs: 0, Value: 1},
+				{TimestampMs: failedStart, Value: 1},
+				{TimestampMs: 2 * toMs(day), Value: 1},
+			}, resp.(*PrometheusResponse).Data.Result[0].Samples)
+
+			// The state is dropped once the query succeeded.
+			_, err = handler.Do(ctx, req)
+			require.NoError(t, err)
+			assert.Len(t, reset(), 3)
+		})
+	}
+}
+
+func TestSplitByInterval_QueryStateIsolation(t *testing.T) {
+	t.Parallel()
+
+	req := &PrometheusRequest{
+		Start: 0,
+		End:   2*toMs(day) - 15*seconds,
+		Step:  15 * seconds,
+		Query: "foo",
+	}
+
+	var (
+		mtx     sync.Mutex
+		queries int
+	)
+	next := tripperware.HandlerFunc(func(_ context.Context, r tripperware.Request) (tripperware.Response, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		queries++
+		if r.GetStart() > 0 {
+			return nil, httpgrpc.Errorf(http.StatusInternalServerError, "ingester unavailable")
+		}
+		return splitResponse(r), nil
+	})
+
+	state := newQueryState(cache.NewMockCache(), log.NewNopLogger(), nil)
+	interval := func(_ tripperware.Request) time.Duration { return day }
+	handler := SplitByIntervalMiddleware(interval, mockLimits{}, PrometheusCodec, state, nil).Wrap(next)
+
+	_, err := handler.Do(user.InjectOrgID(context.Background(), "1"), req)
+	require.Error(t, err)
+	require.Equal(t, 2, queries)
+
+	// The state of the query isn't shared with the other tenants nor the other queries.
+	for _, tc := range []struct {
+		userID string
+		req    tripperware.Request
+	}{
+		{userID: "2", req: req},
+		{userID: "1", req: req.WithQuery("bar")},
+		{userID: "1", req: req.WithStartEnd(req.Start, req.End+toMs(day))},
+	} {
+		queries = 0
+		_, err := handler.Do(user.InjectOrgID(context.Background(), tc.userID), tc.req)
+		require.Error(t, err)
+		assert.Equal(t, len(splitQueryOrFail(t, tc.req)), queries)
+	}
+}
+
+func splitQueryOrFail(t *testing.T, r tripperware.Request) []tripperware.Request {
+	reqs, err := splitQuery(r, day)
+	require.NoError(t, err)
+	return reqs
+}
+
+func splitResponse(r tripperware.Request) *PrometheusResponse {
+	return &PrometheusResponse{
+		Status: StatusSuccess,
+		Data: PrometheusData{
+			ResultType: "matrix",
+			Result: []tripperware.SampleStream{{
+				Labels:  []cortexpb.LabelAdapter{{Name: "foo", Value: "bar"}},
+				Samples: []cortexpb.Sample{{TimestampMs: r.GetStart(), Value: 1}},
+			}},
+		},
+	}
+}
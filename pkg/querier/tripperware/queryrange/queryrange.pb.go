@@ -41,6 +41,7 @@ type PrometheusRequest struct {
 	CachingOptions CachingOptions                         `protobuf:"bytes,7,opt,name=cachingOptions,proto3" json:"cachingOptions"`
 	Headers        []*tripperware.PrometheusRequestHeader `protobuf:"bytes,8,rep,name=Headers,proto3" json:"-"`
 	Stats          string                                 `protobuf:"bytes,9,opt,name=stats,proto3" json:"stats,omitempty"`
+	Hints          tripperware.RequestHints               `protobuf:"bytes,10,opt,name=hints,proto3" json:"hints"`
 }
 
 func (m *PrometheusRequest) Reset()      { *m = PrometheusRequest{} }
@@ -138,6 +139,13 @@ func (m *PrometheusRequest) GetStats() string {
 	return ""
 }
 
+func (m *PrometheusRequest) GetHints() tripperware.RequestHints {
+	if m != nil {
+		return m.Hints
+	}
+	return tripperware.RequestHints{}
+}
+
 type PrometheusResponse struct {
 	Status    string                                  `protobuf:"bytes,1,opt,name=Status,proto3" json:"status"`
 	Data      PrometheusData                          `protobuf:"bytes,2,opt,name=Data,proto3" json:"data,omitempty"`
@@ -446,55 +454,56 @@ func init() {
 func init() { proto.RegisterFile("queryrange.proto", fileDescriptor_79b02382e213d0b2) }
 
 var fileDescriptor_79b02382e213d0b2 = []byte{
-	// 763 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x54, 0xbd, 0x6e, 0xdb, 0x48,
-	0x10, 0x16, 0x45, 0xfd, 0xae, 0x0f, 0xb2, 0x6f, 0x6d, 0xdc, 0x51, 0x2a, 0x48, 0x41, 0xf0, 0x01,
-	0x3a, 0xc0, 0x47, 0x01, 0x3e, 0x5c, 0x79, 0x41, 0x4c, 0xff, 0xc0, 0x49, 0xe3, 0x80, 0x4e, 0x95,
-	0x26, 0x58, 0x89, 0x13, 0x8a, 0xb6, 0x44, 0xd2, 0xcb, 0x25, 0x62, 0x75, 0x79, 0x84, 0x94, 0x79,
-	0x84, 0x20, 0xc8, 0x83, 0xb8, 0x74, 0x91, 0xc2, 0x15, 0x1d, 0xcb, 0x4d, 0xc0, 0xca, 0x8f, 0x10,
-	0x70, 0x77, 0x29, 0xd1, 0x76, 0x82, 0x34, 0xc6, 0xcc, 0xec, 0x37, 0xa3, 0xef, 0xfb, 0x86, 0x63,
-	0xb4, 0x76, 0x16, 0x03, 0x9d, 0x51, 0xe2, 0xbb, 0x60, 0x86, 0x34, 0x60, 0x01, 0x46, 0xcb, 0x4a,
-	0x67, 0xc3, 0x0d, 0xdc, 0x80, 0x97, 0x07, 0x59, 0x24, 0x10, 0x1d, 0xdd, 0x0d, 0x02, 0x77, 0x02,
-	0x03, 0x9e, 0x0d, 0xe3, 0x37, 0x03, 0x27, 0xa6, 0x84, 0x79, 0x81, 0x2f, 0xdf, 0xdb, 0x0f, 0xdf,
-	0x89, 0x3f, 0x93, 0x4f, 0xbb, 0xae, 0xc7, 0xc6, 0xf1, 0xd0, 0x1c, 0x05, 0xd3, 0xc1, 0x28, 0xa0,
-	0x0c, 0xce, 0x43, 0x1a, 0x9c, 0xc0, 0x88, 0xc9, 0x6c, 0x10, 0x9e, 0xba, 0x83, 0x8c, 0x80, 0x07,
-	0x74, 0xc0, 0xa8, 0x17, 0x86, 0x40, 0xdf, 0x12, 0x0a, 0xbc, 0x26, 0x87, 0xf4, 0xae, 0xcb, 0xe8,
-	0xf7, 0x17, 0x34, 0x98, 0x02, 0x1b, 0x43, 0x1c, 0xd9, 0x70, 0x16, 0x43, 0xc4, 0x30, 0x46, 0x95,
-	0x90, 0xb0, 0xb1, 0xa6, 0x74, 0x95, 0x7e, 0xd3, 0xe6, 0x31, 0xde, 0x40, 0xd5, 0x88, 0x11, 0xca,
-	0xb4, 0x72, 0x57, 0xe9, 0xab, 0xb6, 0x48, 0xf0, 0x1a, 0x52, 0xc1, 0x77, 0x34, 0x95, 0xd7, 0xb2,
-	0x30, 0xeb, 0x8d, 0x18, 0x84, 0x5a, 0x85, 0x97, 0x78, 0x8c, 0xff, 0x47, 0x75, 0xe6, 0x4d, 0x21,
-	0x88, 0x99, 0x56, 0xed, 0x2a, 0xfd, 0x95, 0xed, 0xb6, 0x29, 0x74, 0x99, 0xb9, 0x2e, 0x73, 0x4f,
-	0xea, 0xb6, 0x1a, 0x17, 0x89, 0x51, 0xfa, 0x70, 0x6d, 0x28, 0x76, 0xde, 0x93, 0xfd, 0x34, 0xe7,
-	0xac, 0xd5, 0x38, 0x1f, 0x91, 0xe0, 0x43, 0xd4, 0x1a, 0x91, 0xd1, 0xd8, 0xf3, 0xdd, 0xa3, 0x30,
-	0xeb, 0x8c, 0xb4, 0x3a, 0x9f, 0xdd, 0x31, 0x0b, 0x7b, 0xd8, 0xbd, 0x87, 0xb0, 0x2a, 0xd9, 0x70,
-	0xfb, 0x41, 0x1f, 0xde, 0x47, 0xf5, 0x43, 0x20, 0x0e, 0xd0, 0x48, 0x6b, 0x74, 0xd5, 0xfe, 0xca,
-	0xf6, 0xa6, 0x59, 0xf0, 0xcb, 0x7c, 0xe4, 0x8f, 0x00, 0x5b, 0xd5, 0x34, 0x31, 0x94, 0x7f, 0xec,
-	0xbc, 0x57, 0x3a, 0xc4, 0x22, 0xad, 0x29, 0x68, 0xf2, 0xa4, 0xf7, 0xa9, 0x8c, 0x70, 0x71, 0x42,
-	0x14, 0x06, 0x7e, 0x04, 0xb8, 0x87, 0x6a, 0xc7, 0x8c, 0xb0, 0x38, 0x12, 0x26, 0x5b, 0x28, 0x4d,
-	0x8c, 0x5a, 0xc4, 0x2b, 0xb6, 0x7c, 0xc1, 0x07, 0xa8, 0xb2, 0x47, 0x18, 0xe1, 0x8e, 0x3f, 0xd0,
-	0xb5, 0x9c, 0x98, 0x21, 0xac, 0x3f, 0x32, 0x5d, 0x69, 0x62, 0xb4, 0x1c, 0xc2, 0xc8, 0x56, 0x30,
-	0xf5, 0x18, 0x4c, 0x43, 0x36, 0xb3, 0x79, 0x3f, 0xfe, 0x0f, 0x35, 0xf7, 0x29, 0x0d, 0xe8, 0xcb,
-	0x59, 0x08, 0x7c, 0x55, 0x4d, 0xeb, 0xcf, 0x34, 0x31, 0xd6, 0x21, 0x2f, 0x16, 0x3a, 0x96, 0x48,
-	0xfc, 0x37, 0xaa, 0xf2, 0x84, 0xaf, 0xb2, 0x69, 0xad, 0xa7, 0x89, 0xb1, 0xca, 0x5b, 0x0a, 0x70,
-	0x81, 0xc0, 0x07, 0x4b, 0x07, 0xab, 0xdc, 0xc1, 0xbf, 0x7e, 0xea, 0xa0, 0xd0, 0xff, 0x63, 0x0b,
-	0x7b, 0x5f, 0x14, 0xd4, 0xba, 0x2f, 0x0d, 0x9b, 0x08, 0xd9, 0x10, 0xc5, 0x13, 0xc6, 0xd9, 0x0b,
-	0xb3, 0x5a, 0x69, 0x62, 0x20, 0xba, 0xa8, 0xda, 0x05, 0x04, 0xde, 0x41, 0x35, 0x91, 0x69, 0x65,
-	0xce, 0xa4, 0x7d, 0x8f, 0xc9, 0x31, 0x99, 0x86, 0x13, 0x38, 0x66, 0x14, 0xc8, 0xd4, 0x6a, 0x49,
-	0xd7, 0x6a, 0x62, 0x94, 0x2d, 0x1b, 0xf1, 0x51, 0xbe, 0x48, 0x95, 0x1b, 0xbf, 0xf9, 0x0b, 0x2d,
-	0xd9, 0xb6, 0x22, 0x61, 0x0f, 0x6f, 0x2b, 0xda, 0x23, 0xbe, 0x81, 0x13, 0xd4, 0xca, 0x3e, 0x44,
-	0x70, 0x16, 0xeb, 0x6f, 0x23, 0xf5, 0x14, 0x66, 0x52, 0x4e, 0x3d, 0x4d, 0x8c, 0x2c, 0xb5, 0xb3,
-	0x3f, 0xd9, 0xb1, 0xc0, 0x39, 0x03, 0x9f, 0x45, 0x52, 0x01, 0x2e, 0x2e, 0x7e, 0x9f, 0x3f, 0x59,
-	0xab, 0x92, 0x7a, 0x0e, 0xb5, 0xf3, 0xa0, 0xf7, 0x59, 0x41, 0x35, 0x01, 0xc2, 0x46, 0x7e, 0xb2,
-	0xd9, 0xcf, 0xa8, 0x56, 0x33, 0x4d, 0x0c, 0x51, 0xc8, 0xaf, 0xb7, 0x2d, 0xae, 0x97, 0x5f, 0xb4,
-	0x60, 0x01, 0xbe, 0x23, 0xce, 0xb8, 0x8b, 0x1a, 0x8c, 0x92, 0x11, 0xbc, 0xf6, 0x1c, 0xb9, 0xff,
-	0x7c, 0x57, 0xbc, 0xfc, 0xcc, 0xc1, 0x4f, 0x50, 0x83, 0x4a, 0x39, 0xf2, 0xaa, 0x37, 0x1e, 0x5d,
-	0xf5, 0x8e, 0x3f, 0xb3, 0x7e, 0x4b, 0x13, 0x63, 0x81, 0xb4, 0x17, 0xd1, 0xf3, 0x4a, 0x43, 0x5d,
-	0xab, 0xf4, 0xb6, 0x84, 0x35, 0x85, 0x6b, 0xec, 0xa0, 0x86, 0xe3, 0x45, 0x64, 0x38, 0x01, 0x87,
-	0x13, 0x6f, 0xd8, 0x8b, 0xdc, 0x7a, 0x7a, 0x79, 0xa3, 0x97, 0xae, 0x6e, 0xf4, 0xd2, 0xdd, 0x8d,
-	0xae, 0xbc, 0x9b, 0xeb, 0xca, 0xc7, 0xb9, 0xae, 0x5c, 0xcc, 0x75, 0xe5, 0x72, 0xae, 0x2b, 0x5f,
-	0xe7, 0xba, 0xf2, 0x6d, 0xae, 0x97, 0xee, 0xe6, 0xba, 0xf2, 0xfe, 0x56, 0x2f, 0x5d, 0xde, 0xea,
-	0xa5, 0xab, 0x5b, 0xbd, 0xf4, 0xaa, 0xf0, 0x6f, 0x78, 0x58, 0xe3, 0xdc, 0xfe, 0xfd, 0x1e, 0x00,
-	0x00, 0xff, 0xff, 0xe0, 0x63, 0x3d, 0x6f, 0xad, 0x05, 0x00, 0x00,
+	// 782 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0xbd, 0x6e, 0xdb, 0x48,
+	0x10, 0xd6, 0x8a, 0xfa, 0x5d, 0x1f, 0x64, 0xdf, 0xda, 0xb8, 0xa3, 0x54, 0x90, 0x82, 0x70, 0x07,
+	0xe8, 0x00, 0x1f, 0x05, 0xf8, 0xe0, 0xf2, 0x0e, 0x67, 0xfa, 0x07, 0xbe, 0x6b, 0x1c, 0xd0, 0xa9,
+	0xd2, 0x04, 0x2b, 0x71, 0x43, 0xd1, 0x96, 0x48, 0x7a, 0xb9, 0x44, 0xac, 0x2e, 0x8f, 0x90, 0x2a,
+	0xc8, 0x23, 0x04, 0x41, 0x1e, 0xc4, 0xa5, 0x8b, 0x14, 0xae, 0x98, 0x58, 0x6e, 0x02, 0x56, 0x7e,
+	0x84, 0x60, 0x7f, 0x28, 0xd1, 0x56, 0x92, 0x86, 0x98, 0xf9, 0xf6, 0x9b, 0xe1, 0xcc, 0x37, 0x3b,
+	0x0b, 0x37, 0x2e, 0x12, 0x42, 0x67, 0x14, 0x07, 0x1e, 0xb1, 0x22, 0x1a, 0xb2, 0x10, 0xc1, 0x25,
+	0xd2, 0xd9, 0xf2, 0x42, 0x2f, 0x14, 0xf0, 0x80, 0x5b, 0x92, 0xd1, 0x31, 0xbc, 0x30, 0xf4, 0x26,
+	0x64, 0x20, 0xbc, 0x61, 0xf2, 0x62, 0xe0, 0x26, 0x14, 0x33, 0x3f, 0x0c, 0xd4, 0x79, 0xfb, 0xf1,
+	0x39, 0x0e, 0x66, 0xea, 0x68, 0xdf, 0xf3, 0xd9, 0x38, 0x19, 0x5a, 0xa3, 0x70, 0x3a, 0x18, 0x85,
+	0x94, 0x91, 0xcb, 0x88, 0x86, 0x67, 0x64, 0xc4, 0x94, 0x37, 0x88, 0xce, 0xbd, 0x01, 0x2f, 0xc0,
+	0x27, 0x74, 0xc0, 0xa8, 0x1f, 0x45, 0x84, 0xbe, 0xc4, 0x94, 0x08, 0x4c, 0x25, 0xe9, 0xbd, 0xd1,
+	0xe0, 0xcf, 0x4f, 0x68, 0x38, 0x25, 0x6c, 0x4c, 0x92, 0xd8, 0x21, 0x17, 0x09, 0x89, 0x19, 0x42,
+	0xb0, 0x12, 0x61, 0x36, 0xd6, 0x41, 0x17, 0xf4, 0x9b, 0x8e, 0xb0, 0xd1, 0x16, 0xac, 0xc6, 0x0c,
+	0x53, 0xa6, 0x97, 0xbb, 0xa0, 0xaf, 0x39, 0xd2, 0x41, 0x1b, 0x50, 0x23, 0x81, 0xab, 0x6b, 0x02,
+	0xe3, 0x26, 0x8f, 0x8d, 0x19, 0x89, 0xf4, 0x8a, 0x80, 0x84, 0x8d, 0xfe, 0x86, 0x75, 0xe6, 0x4f,
+	0x49, 0x98, 0x30, 0xbd, 0xda, 0x05, 0xfd, 0xb5, 0x9d, 0xb6, 0x25, 0xfb, 0xb2, 0xf2, 0xbe, 0xac,
+	0x03, 0xd5, 0xb7, 0xdd, 0xb8, 0x4a, 0xcd, 0xd2, 0xdb, 0x4f, 0x26, 0x70, 0xf2, 0x18, 0xfe, 0x6b,
+	0x51, 0xb3, 0x5e, 0x13, 0xf5, 0x48, 0x07, 0x1d, 0xc3, 0xd6, 0x08, 0x8f, 0xc6, 0x7e, 0xe0, 0x9d,
+	0x44, 0x3c, 0x32, 0xd6, 0xeb, 0x22, 0x77, 0xc7, 0x2a, 0xcc, 0x61, 0xff, 0x01, 0xc3, 0xae, 0xf0,
+	0xe4, 0xce, 0xa3, 0x38, 0x74, 0x08, 0xeb, 0xc7, 0x04, 0xbb, 0x84, 0xc6, 0x7a, 0xa3, 0xab, 0xf5,
+	0xd7, 0x76, 0x7e, 0xb3, 0x0a, 0x7a, 0x59, 0x2b, 0xfa, 0x48, 0xb2, 0x5d, 0xcd, 0x52, 0x13, 0xfc,
+	0xe9, 0xe4, 0xb1, 0x4a, 0x21, 0x16, 0xeb, 0x4d, 0x59, 0xa6, 0x70, 0xd0, 0x2e, 0xac, 0x8e, 0xfd,
+	0x80, 0xc5, 0x3a, 0x54, 0x9d, 0x17, 0x53, 0xe7, 0x09, 0x39, 0x41, 0x15, 0x27, 0xd9, 0xbd, 0xf7,
+	0x65, 0x88, 0x8a, 0x3f, 0x8e, 0xa3, 0x30, 0x88, 0x09, 0xea, 0xc1, 0xda, 0x29, 0xc3, 0x2c, 0x89,
+	0xe5, 0x6c, 0x6c, 0x98, 0xa5, 0x66, 0x2d, 0x16, 0x88, 0xa3, 0x4e, 0xd0, 0x11, 0xac, 0x1c, 0x60,
+	0x86, 0xf5, 0xf2, 0xaa, 0x1c, 0xcb, 0x8c, 0x9c, 0x61, 0xff, 0xc2, 0xff, 0x98, 0xa5, 0x66, 0xcb,
+	0xc5, 0x0c, 0x6f, 0x87, 0x53, 0x9f, 0x91, 0x69, 0xc4, 0x66, 0x8e, 0x88, 0x47, 0xbb, 0xb0, 0x79,
+	0x48, 0x69, 0x48, 0x9f, 0xce, 0x22, 0x22, 0x26, 0xdc, 0xb4, 0x7f, 0xcd, 0x52, 0x73, 0x93, 0xe4,
+	0x60, 0x21, 0x62, 0xc9, 0x44, 0x7f, 0xc0, 0xaa, 0x70, 0xc4, 0x0d, 0x68, 0xda, 0x9b, 0x59, 0x6a,
+	0xae, 0x8b, 0x90, 0x02, 0x5d, 0x32, 0xd0, 0xd1, 0x52, 0xf8, 0xaa, 0x10, 0xfe, 0xf7, 0xef, 0x0a,
+	0x2f, 0xfb, 0xff, 0xb6, 0xf2, 0xbd, 0x8f, 0x00, 0xb6, 0x1e, 0xb6, 0x86, 0x2c, 0x08, 0x1d, 0x12,
+	0x27, 0x13, 0x26, 0xaa, 0x97, 0x62, 0xb5, 0xb2, 0xd4, 0x84, 0x74, 0x81, 0x3a, 0x05, 0x06, 0xda,
+	0x83, 0x35, 0xe9, 0xe9, 0xe5, 0xae, 0xb6, 0x32, 0xa7, 0x53, 0x3c, 0x8d, 0x26, 0xe4, 0x94, 0x51,
+	0x82, 0xa7, 0x76, 0x4b, 0xa9, 0x56, 0x93, 0xa9, 0x1c, 0x15, 0x88, 0x4e, 0xf2, 0xf9, 0x6b, 0x5d,
+	0xf0, 0xc3, 0x4b, 0x24, 0x7b, 0xe1, 0xd3, 0x8a, 0xa5, 0x3c, 0x22, 0xac, 0x28, 0x8f, 0x00, 0x7a,
+	0x67, 0xb0, 0xc5, 0xef, 0x2f, 0x71, 0x17, 0xe3, 0x6f, 0x43, 0xed, 0x9c, 0xcc, 0x54, 0x3b, 0xf5,
+	0x2c, 0x35, 0xb9, 0xeb, 0xf0, 0x0f, 0xdf, 0x31, 0x72, 0xc9, 0x08, 0xbf, 0x69, 0xb2, 0x03, 0x54,
+	0x1c, 0xfc, 0xa1, 0x38, 0xb2, 0xd7, 0x55, 0xe9, 0x39, 0xd5, 0xc9, 0x8d, 0xde, 0x07, 0x00, 0x6b,
+	0x92, 0x84, 0xcc, 0x7c, 0xd3, 0xf9, 0x6f, 0x34, 0xbb, 0x99, 0xa5, 0xa6, 0x04, 0xf2, 0xa5, 0x6f,
+	0xcb, 0xa5, 0x17, 0x0f, 0x81, 0xac, 0x82, 0x04, 0xae, 0xdc, 0xfe, 0x2e, 0x6c, 0x30, 0x8a, 0x47,
+	0xe4, 0xb9, 0xef, 0xaa, 0xf9, 0xe7, 0xb3, 0x12, 0xf0, 0x7f, 0x2e, 0xfa, 0x07, 0x36, 0xa8, 0x6a,
+	0x47, 0x3d, 0x06, 0x5b, 0x2b, 0x8f, 0xc1, 0x5e, 0x30, 0xb3, 0x7f, 0xca, 0x52, 0x73, 0xc1, 0x74,
+	0x16, 0xd6, 0xff, 0x95, 0x86, 0xb6, 0x51, 0xe9, 0x6d, 0x4b, 0x69, 0x0a, 0x4b, 0xdc, 0x81, 0x0d,
+	0xd7, 0x8f, 0xf1, 0x70, 0x42, 0x5c, 0x51, 0x78, 0xc3, 0x59, 0xf8, 0xf6, 0xbf, 0xd7, 0xb7, 0x46,
+	0xe9, 0xe6, 0xd6, 0x28, 0xdd, 0xdf, 0x1a, 0xe0, 0xd5, 0xdc, 0x00, 0xef, 0xe6, 0x06, 0xb8, 0x9a,
+	0x1b, 0xe0, 0x7a, 0x6e, 0x80, 0xcf, 0x73, 0x03, 0x7c, 0x99, 0x1b, 0xa5, 0xfb, 0xb9, 0x01, 0x5e,
+	0xdf, 0x19, 0xa5, 0xeb, 0x3b, 0xa3, 0x74, 0x73, 0x67, 0x94, 0x9e, 0x15, 0x5e, 0xef, 0x61, 0x4d,
+	0xd4, 0xf6, 0xd7, 0xd7, 0x01, 0x00, 0x8f, 0xac, 0x92, 0x2d, 0xe4, 0x05, 0x00, 0x00,
 }
 
 func (this *PrometheusRequest) Equal(that interface{}) bool {
@@ -548,6 +557,9 @@ func (this *PrometheusRequest) Equal(that interface{}) bool {
 	if this.Stats != that1.Stats {
 		return false
 	}
+	if !this.Hints.Equal(&that1.Hints) {
+		return false
+	}
 	return true
 }
 func (this *PrometheusResponse) Equal(that interface{}) bool {
@@ -719,7 +731,7 @@ func (this *PrometheusRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 13)
+	s := make([]string, 0, 14)
 	s = append(s, "&queryrange.PrometheusRequest{")
 	s = append(s, "Path: "+fmt.Sprintf("%#v", this.Path)+",\n")
 	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
@@ -732,6 +744,7 @@ func (this *PrometheusRequest) GoString() string {
 		s = append(s, "Headers: "+fmt.Sprintf("%#v", this.Headers)+",\n")
 	}
 	s = append(s, "Stats: "+fmt.Sprintf("%#v", this.Stats)+",\n")
+	s = append(s, "Hints: "+strings.Replace(this.Hints.GoString(), `&`, ``, 1)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -841,6 +854,16 @@ func (m *PrometheusRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	{
+		size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintQueryrange(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x52
 	if len(m.Stats) > 0 {
 		i -= len(m.Stats)
 		copy(dAtA[i:], m.Stats)
@@ -879,12 +902,12 @@ func (m *PrometheusRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0x32
 	}
-	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Timeout, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout):])
-	if err2 != nil {
-		return 0, err2
+	n3, err3 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Timeout, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout):])
+	if err3 != nil {
+		return 0, err3
 	}
-	i -= n2
-	i = encodeVarintQueryrange(dAtA, i, uint64(n2))
+	i -= n3
+	i = encodeVarintQueryrange(dAtA, i, uint64(n3))
 	i--
 	dAtA[i] = 0x2a
 	if m.Step != 0 {
@@ -1213,6 +1236,8 @@ func (m *PrometheusRequest) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovQueryrange(uint64(l))
 	}
+	l = m.Hints.Size()
+	n += 1 + l + sovQueryrange(uint64(l))
 	return n
 }
 
@@ -1347,6 +1372,7 @@ func (this *PrometheusRequest) String() string {
 		`CachingOptions:` + strings.Replace(strings.Replace(this.CachingOptions.String(), "CachingOptions", "CachingOptions", 1), `&`, ``, 1) + `,`,
 		`Headers:` + repeatedStringForHeaders + `,`,
 		`Stats:` + fmt.Sprintf("%v", this.Stats) + `,`,
+		`Hints:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Hints), "RequestHints", "tripperware.RequestHints", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1716,6 +1742,39 @@ func (m *PrometheusRequest) Unmarshal(dAtA []byte) error {
 			}
 			m.Stats = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQueryrange
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipQueryrange(dAtA[iNdEx:])
@@ -0,0 +1,160 @@
+package queryrange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+// Supported response validation modes.
+const (
+	ResponseValidationDisabled = "disabled"
+	ResponseValidationLog      = "log"
+	ResponseValidationReject   = "reject"
+)
+
+var responseValidationModes = []string{ResponseValidationDisabled, ResponseValidationLog, ResponseValidationReject}
+
+// Reasons why a response is considered invalid.
+const (
+	invalidReasonResultType     = "unexpected_result_type"
+	invalidReasonDuplicate      = "duplicate_series"
+	invalidReasonOrdering       = "unordered_samples"
+	invalidReasonOutOfRange     = "sample_out_of_range"
+	invalidReasonStepMisaligned = "step_misaligned"
+	invalidReasonStaleNaN       = "stale_marker"
+)
+
+// responseValidationError describes why a response is invalid.
+type responseValidationError struct {
+	reason string
+	msg    string
+}
+
+func (e responseValidationError) Error() string {
+	return e.msg
+}
+
+// NewResponseValidationMiddleware makes a new Middleware which sanity-checks the query range
+// responses: the result must be a matrix without duplicated series, and samples must be
+// ordered, within the requested time range, spaced by a multiple of the step and not stale markers.
+// Invalid responses are logged and, in reject mode, replaced by an error so that they're
+// neither cached nor returned. The same Middleware can be used multiple times in the chain.
+func NewResponseValidationMiddleware(mode string, logger log.Logger, registerer prometheus.Registerer) tripperware.Middleware {
+	invalidResponses := promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "cortex",
+		Name:      "frontend_query_range_invalid_responses_total",
+		Help:      "Total number of invalid query range responses detected by the response validation.",
+	}, []string{"reason"})
+
+	return tripperware.MiddlewareFunc(func(next tripperware.Handler) tripperware.Handler {
+		return responseValidation{
+			next:             next,
+			reject:           mode == ResponseValidationReject,
+			logger:           logger,
+			invalidResponses: invalidResponses,
+		}
+	})
+}
+
+type responseValidation struct {
+	next   tripperware.Handler
+	reject bool
+	logger log.Logger
+
+	invalidResponses *prometheus.CounterVec
+}
+
+func (v responseValidation) Do(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+	resp, err := v.next.Do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	promResp, ok := resp.(*PrometheusResponse)
+	if !ok || promResp.Status != StatusSuccess {
+		return resp, nil
+	}
+
+	verr := validateResponse(r, promResp)
+	if verr == nil {
+		return resp, nil
+	}
+
+	v.invalidResponses.WithLabelValues(verr.reason).Inc()
+	level.Error(util_log.WithContext(ctx, v.logger)).Log(
+		"msg", "invalid query range response",
+		"query", r.GetQuery(),
+		"start", r.GetStart(),
+		"end", r.GetEnd(),
+		"step", r.GetStep(),
+		"reason", verr.reason,
+		"err", verr,
+	)
+
+	if v.reject {
+		return nil, httpgrpc.Errorf(http.StatusInternalServerError, "invalid query range response: %s", verr.Error())
+	}
+	return resp, nil
+}
+
+// validateResponse returns the first problem found in the response, if any.
+func validateResponse(r tripperware.Request, resp *PrometheusResponse) *responseValidationError {
+	if resp.Data.ResultType != model.ValMatrix.String() {
+		return &responseValidationError{reason: invalidReasonResultType, msg: fmt.Sprintf("unexpected result type %q", resp.Data.ResultType)}
+	}
+
+	seen := make(map[string]struct{}, len(resp.Data.Result))
+	buf := make([]byte, 0, 1024)
+	for _, stream := range resp.Data.Result {
+		lbls := cortexpb.FromLabelAdaptersToLabels(stream.Labels)
+		key := string(lbls.Bytes(buf))
+		if _, ok := seen[key]; ok {
+			return &responseValidationError{reason: invalidReasonDuplicate, msg: fmt.Sprintf("duplicate series %s", lbls)}
+		}
+		seen[key] = struct{}{}
+
+		if err := validateSamples(r, stream.Samples); err != nil {
+			err.msg = fmt.Sprintf("series %s: %s", lbls, err.msg)
+			return err
+		}
+	}
+	return nil
+}
+
+func validateSamples(r tripperware.Request, samples []cortexpb.Sample) *responseValidationError {
+	start, end, step := r.GetStart(), r.GetEnd(), r.GetStep()
+
+	for i, s := range samples {
+		if value.IsStaleNaN(s.Value) {
+			return &responseValidationError{reason: invalidReasonStaleNaN, msg: fmt.Sprintf("stale marker at timestamp %d", s.TimestampMs)}
+		}
+		if s.TimestampMs < start || s.TimestampMs > end {
+			return &responseValidationError{reason: invalidReasonOutOfRange, msg: fmt.Sprintf("sample timestamp %d out of the requested range [%d, %d]", s.TimestampMs, start, end)}
+		}
+		if i == 0 {
+			continue
+		}
+
+		prev := samples[i-1].TimestampMs
+		if s.TimestampMs <= prev {
+			return &responseValidationError{reason: invalidReasonOrdering, msg: fmt.Sprintf("sample timestamp %d is not after the previous one %d", s.TimestampMs, prev)}
+		}
+		if step > 0 && (s.TimestampMs-prev)%step != 0 {
+			return &responseValidationError{reason: invalidReasonStepMisaligned, msg: fmt.Sprintf("samples at timestamps %d and %d are not spaced by a multiple of the step %d", prev, s.TimestampMs, step)}
+		}
+	}
+	return nil
+}
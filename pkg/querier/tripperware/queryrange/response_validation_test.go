@@ -0,0 +1,133 @@
+package queryrange
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+func TestValidateResponse(t *testing.T) {
+	req := &PrometheusRequest{Start: 1000, End: 5000, Step: 1000}
+	series := func(name string, samples ...cortexpb.Sample) tripperware.SampleStream {
+		return tripperware.SampleStream{
+			Labels:  []cortexpb.LabelAdapter{{Name: "__name__", Value: name}},
+			Samples: samples,
+		}
+	}
+	matrix := func(streams ...tripperware.SampleStream) *PrometheusResponse {
+		return &PrometheusResponse{Status: StatusSuccess, Data: PrometheusData{ResultType: "matrix", Result: streams}}
+	}
+
+	for name, tc := range map[string]struct {
+		resp     *PrometheusResponse
+		expected string
+	}{
+		"valid": {
+			resp: matrix(
+				series("a", cortexpb.Sample{TimestampMs: 1000, Value: 1}, cortexpb.Sample{TimestampMs: 3000, Value: math.NaN()}),
+				series("b", cortexpb.Sample{TimestampMs: 5000, Value: 1}),
+			),
+		},
+		"empty": {
+			resp: NewEmptyPrometheusResponse(),
+		},
+		"unexpected result type": {
+			resp:     &PrometheusResponse{Status: StatusSuccess, Data: PrometheusData{ResultType: "vector"}},
+			expected: invalidReasonResultType,
+		},
+		"duplicate series": {
+			resp: matrix(
+				series("a", cortexpb.Sample{TimestampMs: 1000, Value: 1}),
+				series("a", cortexpb.Sample{TimestampMs: 2000, Value: 1}),
+			),
+			expected: invalidReasonDuplicate,
+		},
+		"unordered samples": {
+			resp:     matrix(series("a", cortexpb.Sample{TimestampMs: 2000, Value: 1}, cortexpb.Sample{TimestampMs: 1000, Value: 1})),
+			expected: invalidReasonOrdering,
+		},
+		"repeated samples": {
+			resp:     matrix(series("a", cortexpb.Sample{TimestampMs: 2000, Value: 1}, cortexpb.Sample{TimestampMs: 2000, Value: 1})),
+			expected: invalidReasonOrdering,
+		},
+		"sample before start": {
+			resp:     matrix(series("a", cortexpb.Sample{TimestampMs: 0, Value: 1})),
+			expected: invalidReasonOutOfRange,
+		},
+		"sample after end": {
+			resp:     matrix(series("a", cortexpb.Sample{TimestampMs: 6000, Value: 1})),
+			expected: invalidReasonOutOfRange,
+		},
+		"misaligned step": {
+			resp:     matrix(series("a", cortexpb.Sample{TimestampMs: 1000, Value: 1}, cortexpb.Sample{TimestampMs: 2500, Value: 1})),
+			expected: invalidReasonStepMisaligned,
+		},
+		"stale marker": {
+			resp:     matrix(series("a", cortexpb.Sample{TimestampMs: 1000, Value: math.Float64frombits(value.StaleNaN)})),
+			expected: invalidReasonStaleNaN,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := validateResponse(req, tc.resp)
+			if tc.expected == "" {
+				require.Nil(t, err)
+				return
+			}
+			require.NotNil(t, err)
+			require.Equal(t, tc.expected, err.reason)
+		})
+	}
+}
+
+func TestResponseValidationMiddleware(t *testing.T) {
+	req := &PrometheusRequest{Query: "up", Start: 1000, End: 5000, Step: 1000}
+	invalid := &PrometheusResponse{
+		Status: StatusSuccess,
+		Data: PrometheusData{
+			ResultType: "matrix",
+			Result: []tripperware.SampleStream{
+				{Labels: []cortexpb.LabelAdapter{{Name: "__name__", Value: "up"}}},
+				{Labels: []cortexpb.LabelAdapter{{Name: "__name__", Value: "up"}}},
+			},
+		},
+	}
+	next := tripperware.HandlerFunc(func(context.Context, tripperware.Request) (tripperware.Response, error) {
+		return invalid, nil
+	})
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	for _, mode := range []string{ResponseValidationLog, ResponseValidationReject} {
+		t.Run(mode, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			resp, err := NewResponseValidationMiddleware(mode, log.NewNopLogger(), reg).Wrap(next).Do(ctx, req)
+
+			if mode == ResponseValidationReject {
+				require.Nil(t, resp)
+				httpResp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				require.Equal(t, int32(500), httpResp.Code)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, invalid, resp)
+			}
+
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+				# HELP cortex_frontend_query_range_invalid_responses_total Total number of invalid query range responses detected by the response validation.
+				# TYPE cortex_frontend_query_range_invalid_responses_total counter
+				cortex_frontend_query_range_invalid_responses_total{reason="duplicate_series"} 1
+			`)))
+		})
+	}
+}
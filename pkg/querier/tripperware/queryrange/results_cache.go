@@ -135,8 +135,30 @@ type constSplitter time.Duration
 
 // GenerateCacheKey generates a cache key based on the userID, Request and interval.
 func (t constSplitter) GenerateCacheKey(userID string, r tripperware.Request) string {
-	currentInterval := r.GetStart() / int64(time.Duration(t)/time.Millisecond)
-	return fmt.Sprintf("%s:%s:%d:%d", userID, r.GetQuery(), r.GetStep(), currentInterval)
+	hints := r.GetHints()
+	currentInterval := (r.GetStart() + hints.TimeZoneOffset) / int64(time.Duration(t)/time.Millisecond)
+	return fmt.Sprintf("%s:%s:%d:%d", userID, r.GetQuery(), r.GetStep(), currentInterval) + hintsKeySuffix(hints)
+}
+
+// hintsKeySuffix returns the suffix of the keys of the requests with the given hints, so that
+// the results evaluated against downsampled data, aligned to a time zone, truncated to a
+// series limit or overriding the partial response mode aren't mixed with the ones of the
+// plain requests.
+func hintsKeySuffix(hints tripperware.RequestHints) string {
+	var suffix string
+	if resolution := hints.MaxSourceResolution; resolution > 0 {
+		suffix += fmt.Sprintf(":%d", resolution)
+	}
+	if hints.AlignToTimeZone {
+		suffix += fmt.Sprintf(":tz%d", hints.TimeZoneOffset)
+	}
+	if limit := hints.Metadata[tripperware.SeriesLimitHint]; limit != "" && limit != "0" {
+		suffix += fmt.Sprintf(":limit%s", limit)
+	}
+	if partial, ok := hints.Metadata[tripperware.PartialResponseHint]; ok {
+		suffix += fmt.Sprintf(":partial%s", partial)
+	}
+	return suffix
 }
 
 // ShouldCacheFn checks whether the current request should go to cache
@@ -585,7 +607,7 @@ func (s resultsCache) partition(req tripperware.Request, extents []Extent) ([]tr
 }
 
 func (s resultsCache) filterRecentExtents(req tripperware.Request, maxCacheFreshness time.Duration, extents []Extent) ([]Extent, error) {
-	maxCacheTime := (int64(model.Now().Add(-maxCacheFreshness)) / req.GetStep()) * req.GetStep()
+	maxCacheTime := alignToStep(int64(model.Now().Add(-maxCacheFreshness)), req.GetStep(), req.GetHints().TimeZoneOffset)
 	for i := range extents {
 		// Never cache data for the latest freshness period.
 		if extents[i].End > maxCacheTime {
@@ -1148,6 +1148,10 @@ func TestConstSplitter_generateCacheKey(t *testing.T) {
 		{"<1d", &PrometheusRequest{Start: toMs(22 * time.Hour), Step: 10, Query: "foo{}"}, 24 * time.Hour, "fake:foo{}:10:0"},
 		{"4d", &PrometheusRequest{Start: toMs(4 * 24 * time.Hour), Step: 10, Query: "foo{}"}, 24 * time.Hour, "fake:foo{}:10:4"},
 		{"3d5h", &PrometheusRequest{Start: toMs(77 * time.Hour), Step: 10, Query: "foo{}"}, 24 * time.Hour, "fake:foo{}:10:3"},
+		{"downsampled", &PrometheusRequest{Start: toMs(77 * time.Hour), Step: 10, Query: "foo{}", Hints: tripperware.RequestHints{MaxSourceResolution: 300000}}, 24 * time.Hour, "fake:foo{}:10:3:300000"},
+		{"time zone", &PrometheusRequest{Start: toMs(70 * time.Hour), Step: 10, Query: "foo{}", Hints: tripperware.RequestHints{AlignToTimeZone: true, TimeZoneOffset: toMs(2 * time.Hour)}}, 24 * time.Hour, "fake:foo{}:10:3:tz7200000"},
+		{"series limit", &PrometheusRequest{Start: toMs(61 * time.Minute), Step: 10, Query: "foo{}", Hints: tripperware.RequestHints{Metadata: map[string]string{tripperware.SeriesLimitHint: "100"}}}, time.Hour, "fake:foo{}:10:1:limit100"},
+		{"partial response", &PrometheusRequest{Start: toMs(61 * time.Minute), Step: 10, Query: "foo{}", Hints: tripperware.RequestHints{Metadata: map[string]string{tripperware.PartialResponseHint: "false"}}}, time.Hour, "fake:foo{}:10:1:partialfalse"},
 	}
 	for _, tt := range tests {
 		tt := tt
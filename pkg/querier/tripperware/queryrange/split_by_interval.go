@@ -11,18 +11,22 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/tenant"
 )
 
 type IntervalFn func(r tripperware.Request) time.Duration
 
 // SplitByIntervalMiddleware creates a new Middleware that splits requests by a given interval.
-func SplitByIntervalMiddleware(interval IntervalFn, limits tripperware.Limits, merger tripperware.Merger, registerer prometheus.Registerer) tripperware.Middleware {
+// If the state is not nil, the split queries which succeeded are kept when a request fails, for
+// its retry.
+func SplitByIntervalMiddleware(interval IntervalFn, limits tripperware.Limits, merger tripperware.Merger, state *QueryState, registerer prometheus.Registerer) tripperware.Middleware {
 	return tripperware.MiddlewareFunc(func(next tripperware.Handler) tripperware.Handler {
 		return splitByInterval{
 			next:     next,
 			limits:   limits,
 			merger:   merger,
 			interval: interval,
+			state:    state,
 			splitByCounter: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 				Namespace: "cortex",
 				Name:      "frontend_split_queries_total",
@@ -37,6 +41,7 @@ type splitByInterval struct {
 	limits   tripperware.Limits
 	merger   tripperware.Merger
 	interval IntervalFn
+	state    *QueryState
 
 	// Metrics.
 	splitByCounter prometheus.Counter
@@ -56,7 +61,7 @@ func (s splitByInterval) Do(ctx context.Context, r tripperware.Request) (tripper
 	}
 	s.splitByCounter.Add(float64(len(reqs)))
 
-	reqResps, err := tripperware.DoRequests(ctx, s.next, reqs, s.limits)
+	reqResps, err := s.doRequests(ctx, r, reqs)
 	if err != nil {
 		return nil, err
 	}
@@ -73,6 +78,39 @@ func (s splitByInterval) Do(ctx context.Context, r tripperware.Request) (tripper
 	return response, nil
 }
 
+// doRequests executes the split queries, skipping the ones which succeeded in a previous failed
+// execution of the request.
+func (s splitByInterval) doRequests(ctx context.Context, r tripperware.Request, reqs []tripperware.Request) ([]tripperware.RequestResponse, error) {
+	if s.state == nil || len(reqs) < 2 {
+		return tripperware.DoRequests(ctx, s.next, reqs, s.limits)
+	}
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	key := queryStateKey(tenant.JoinTenantIDs(tenantIDs), r)
+
+	done, missing := s.state.completed(ctx, key, reqs)
+	if len(missing) == 0 {
+		s.state.clear(ctx, key)
+		return done, nil
+	}
+
+	// The responses of the split queries which succeeded are returned along with the error.
+	reqResps, err := tripperware.DoRequests(ctx, s.next, missing, s.limits)
+	done = append(done, reqResps...)
+	if err != nil {
+		if len(reqResps) > 0 {
+			s.state.persist(ctx, key, done, err)
+		}
+		return nil, err
+	}
+	if len(done) > len(reqResps) {
+		s.state.clear(ctx, key)
+	}
+	return done, nil
+}
+
 func splitQuery(r tripperware.Request, interval time.Duration) ([]tripperware.Request, error) {
 	// If Start == end we should just run the original request
 	if r.GetStart() == r.GetEnd() {
@@ -85,9 +123,13 @@ func splitQuery(r tripperware.Request, interval time.Duration) ([]tripperware.Re
 	if err != nil {
 		return nil, err
 	}
+	// The requests aligned to the time zone of the tenant are split at the interval boundaries of
+	// the time zone.
+	offset := r.GetHints().TimeZoneOffset
+
 	var reqs []tripperware.Request
-	for start := r.GetStart(); start < r.GetEnd(); start = nextIntervalBoundary(start, r.GetStep(), interval) + r.GetStep() {
-		end := nextIntervalBoundary(start, r.GetStep(), interval)
+	for start := r.GetStart(); start < r.GetEnd(); start = nextIntervalBoundary(start, r.GetStep(), interval, offset) + r.GetStep() {
+		end := nextIntervalBoundary(start, r.GetStep(), interval, offset)
 		if end+r.GetStep() >= r.GetEnd() {
 			end = r.GetEnd()
 		}
@@ -120,10 +162,11 @@ func evaluateAtModifierFunction(query string, start, end int64) (string, error)
 	return expr.String(), err
 }
 
-// Round up to the step before the next interval boundary.
-func nextIntervalBoundary(t, step int64, interval time.Duration) int64 {
+// Round up to the step before the next interval boundary, of the time zone with the given offset
+// in milliseconds.
+func nextIntervalBoundary(t, step int64, interval time.Duration, offset int64) int64 {
 	msPerInterval := int64(interval / time.Millisecond)
-	startOfNextInterval := ((t / msPerInterval) + 1) * msPerInterval
+	startOfNextInterval := (((t+offset)/msPerInterval)+1)*msPerInterval - offset
 	// ensure that target is a multiple of steps away from the start time
 	target := startOfNextInterval - ((startOfNextInterval - t) % step)
 	if target == startOfNextInterval {
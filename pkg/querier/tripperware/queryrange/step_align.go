@@ -7,7 +7,8 @@ import (
 )
 
 // StepAlignMiddleware aligns the start and end of request to the step to
-// improve the cacheability of the query results.
+// improve the cacheability of the query results. The requests aligned to the
+// time zone of the tenant are aligned to the steps of the time zone.
 var StepAlignMiddleware = tripperware.MiddlewareFunc(func(next tripperware.Handler) tripperware.Handler {
 	return stepAlign{
 		next: next,
@@ -19,7 +20,14 @@ type stepAlign struct {
 }
 
 func (s stepAlign) Do(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
-	start := (r.GetStart() / r.GetStep()) * r.GetStep()
-	end := (r.GetEnd() / r.GetStep()) * r.GetStep()
+	offset := r.GetHints().TimeZoneOffset
+	start := alignToStep(r.GetStart(), r.GetStep(), offset)
+	end := alignToStep(r.GetEnd(), r.GetStep(), offset)
 	return s.next.Do(ctx, r.WithStartEnd(start, end))
 }
+
+// alignToStep rounds t down to the step, counting the steps from the midnight of the time zone
+// with the given offset in milliseconds.
+func alignToStep(t, step, offset int64) int64 {
+	return ((t+offset)/step)*step - offset
+}
@@ -0,0 +1,77 @@
+package queryrange
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/tenant"
+)
+
+// AlignToTimeZoneParam is the query range parameter asking to align the steps to the day
+// boundaries of the time zone of the tenant.
+const AlignToTimeZoneParam = "align_to_time_zone"
+
+type timeZoneAlign struct {
+	limits tripperware.Limits
+	next   tripperware.Handler
+}
+
+// NewTimeZoneAlignMiddleware creates a new Middleware aligning the steps of the requests asking
+// for it to the day boundaries of the time zone of the tenant, so that the daily aggregations
+// line up with its calendar days. The offset of the time zone at the start of the request is
+// kept in the request hints, so that the following middlewares split and cache the request
+// along the same boundaries.
+func NewTimeZoneAlignMiddleware(limits tripperware.Limits) tripperware.Middleware {
+	return tripperware.MiddlewareFunc(func(next tripperware.Handler) tripperware.Handler {
+		return timeZoneAlign{
+			limits: limits,
+			next:   next,
+		}
+	})
+}
+
+func (t timeZoneAlign) Do(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+	hints := r.GetHints()
+	if !hints.AlignToTimeZone {
+		return t.next.Do(ctx, r)
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	loc, err := tenantsTimeZone(tenantIDs, t.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	// The offset doesn't follow the daylight saving time changes within the request.
+	_, offset := time.UnixMilli(r.GetStart()).In(loc).Zone()
+	hints.TimeZoneOffset = int64(offset) * int64(time.Second/time.Millisecond)
+
+	start := alignToStep(r.GetStart(), r.GetStep(), hints.TimeZoneOffset)
+	end := alignToStep(r.GetEnd(), r.GetStep(), hints.TimeZoneOffset)
+	return t.next.Do(ctx, r.WithHints(hints).WithStartEnd(start, end))
+}
+
+// tenantsTimeZone returns the query time zone of the tenants, which must be the same for all of
+// them. The tenants without time zone are in UTC.
+func tenantsTimeZone(tenantIDs []string, limits tripperware.Limits) (*time.Location, error) {
+	var name string
+	for i, tenantID := range tenantIDs {
+		tz := limits.QueryTimeZone(tenantID)
+		if i > 0 && tz != name {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, "the steps can't be aligned to the time zone of tenants with different time zones")
+		}
+		name = tz
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "invalid query time zone %q: %s", name, err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,151 @@
+package queryrange
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+func TestDecodeRequest_AlignToTimeZone(t *testing.T) {
+	t.Parallel()
+
+	r, err := http.NewRequest("GET", query+"&align_to_time_zone=true", nil)
+	require.NoError(t, err)
+	req, err := PrometheusCodec.DecodeRequest(context.Background(), r, nil)
+	require.NoError(t, err)
+	assert.True(t, req.GetHints().AlignToTimeZone)
+
+	// The time zone alignment isn't forwarded to the queriers, which get the aligned requests.
+	downstream, err := PrometheusCodec.EncodeRequest(context.Background(), req)
+	require.NoError(t, err)
+	assert.NotContains(t, downstream.RequestURI, AlignToTimeZoneParam)
+
+	r, err = http.NewRequest("GET", query+"&align_to_time_zone=maybe", nil)
+	require.NoError(t, err)
+	_, err = PrometheusCodec.DecodeRequest(context.Background(), r, nil)
+	require.Error(t, err)
+}
+
+func TestTimeZoneAlignMiddleware(t *testing.T) {
+	t.Parallel()
+
+	// 2023-07-01T00:00:00Z, when Europe/Paris is at UTC+2.
+	start := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	hourMs := toMs(time.Hour)
+
+	for name, tc := range map[string]struct {
+		timeZone      string
+		hints         tripperware.RequestHints
+		expectedStart int64
+		expectedEnd   int64
+		expectedHints tripperware.RequestHints
+		expectedErr   bool
+	}{
+		"not requested": {
+			timeZone:      "Europe/Paris",
+			expectedStart: start + 30*60*1000,
+			expectedEnd:   start + 4*24*hourMs,
+		},
+		"tenant in UTC": {
+			hints:         tripperware.RequestHints{AlignToTimeZone: true},
+			expectedStart: start,
+			expectedEnd:   start + 4*24*hourMs,
+			expectedHints: tripperware.RequestHints{AlignToTimeZone: true},
+		},
+		"tenant ahead of UTC": {
+			timeZone:      "Europe/Paris",
+			hints:         tripperware.RequestHints{AlignToTimeZone: true},
+			expectedStart: start - 2*hourMs,
+			expectedEnd:   start + 4*24*hourMs - 2*hourMs,
+			expectedHints: tripperware.RequestHints{AlignToTimeZone: true, TimeZoneOffset: 2 * hourMs},
+		},
+		"tenant behind UTC": {
+			timeZone:      "America/New_York",
+			hints:         tripperware.RequestHints{AlignToTimeZone: true},
+			expectedStart: start - 20*hourMs,
+			expectedEnd:   start + 3*24*hourMs + 4*hourMs,
+			expectedHints: tripperware.RequestHints{AlignToTimeZone: true, TimeZoneOffset: -4 * hourMs},
+		},
+		"invalid time zone": {
+			timeZone:    "Mars/Olympus_Mons",
+			hints:       tripperware.RequestHints{AlignToTimeZone: true},
+			expectedErr: true,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var result tripperware.Request
+			next := tripperware.HandlerFunc(func(_ context.Context, req tripperware.Request) (tripperware.Response, error) {
+				result = req
+				return nil, nil
+			})
+			mw := NewTimeZoneAlignMiddleware(mockLimits{queryTimeZone: tc.timeZone}).Wrap(next)
+
+			// Daily steps, starting in the middle of the first hour of the day in UTC.
+			req := &PrometheusRequest{Start: start + 30*60*1000, End: start + 4*24*hourMs, Step: 24 * hourMs, Hints: tc.hints}
+			_, err := mw.Do(user.InjectOrgID(context.Background(), "user-1"), req)
+			if tc.expectedErr {
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStart, result.GetStart())
+			assert.Equal(t, tc.expectedEnd, result.GetEnd())
+			assert.Equal(t, tc.expectedHints, result.GetHints())
+		})
+	}
+}
+
+func TestTimeZoneAlignMiddleware_ShouldRejectTenantsWithDifferentTimeZones(t *testing.T) {
+	t.Parallel()
+
+	_, err := tenantsTimeZone([]string{"user-1", "user-2"}, timeZonePerTenant{timeZones: map[string]string{"user-1": "Europe/Paris", "user-2": "Asia/Tokyo"}})
+	require.Error(t, err)
+
+	loc, err := tenantsTimeZone([]string{"user-1", "user-2"}, timeZonePerTenant{timeZones: map[string]string{"user-1": "Asia/Tokyo", "user-2": "Asia/Tokyo"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", loc.String())
+}
+
+func TestSplitQuery_ShouldSplitAtTheTimeZoneDayBoundaries(t *testing.T) {
+	t.Parallel()
+
+	offset := toMs(2 * time.Hour)
+	req := &PrometheusRequest{
+		Start: toMs(22 * time.Hour),
+		End:   toMs(70 * time.Hour),
+		Step:  toMs(time.Hour),
+		Query: "foo",
+		Hints: tripperware.RequestHints{AlignToTimeZone: true, TimeZoneOffset: offset},
+	}
+
+	reqs, err := splitQuery(req, day)
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+	assert.Equal(t, toMs(22*time.Hour), reqs[0].GetStart())
+	assert.Equal(t, toMs(45*time.Hour), reqs[0].GetEnd())
+	assert.Equal(t, toMs(46*time.Hour), reqs[1].GetStart())
+	assert.Equal(t, toMs(70*time.Hour), reqs[1].GetEnd())
+}
+
+// timeZonePerTenant are mockLimits with the query time zone set by tenant.
+type timeZonePerTenant struct {
+	mockLimits
+	timeZones map[string]string
+}
+
+func (l timeZonePerTenant) QueryTimeZone(userID string) string {
+	return l.timeZones[userID]
+}
@@ -106,6 +106,7 @@ func NewQueryTripperware(
 	defaultSubQueryInterval time.Duration,
 	maxSubQuerySteps int64,
 	lookbackDelta time.Duration,
+	infoJoin InfoJoinConfig,
 ) Tripperware {
 	// Per tenant query metrics.
 	queriesPerTenant := promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
@@ -127,7 +128,7 @@ func NewQueryTripperware(
 		if len(queryRangeMiddleware) > 0 || len(instantRangeMiddleware) > 0 {
 			queryrange := NewRoundTripper(next, queryRangeCodec, forwardHeaders, queryRangeMiddleware...)
 			instantQuery := NewRoundTripper(next, instantQueryCodec, forwardHeaders, instantRangeMiddleware...)
-			return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			var roundTripper http.RoundTripper = RoundTripFunc(func(r *http.Request) (*http.Response, error) {
 				isQuery := strings.HasSuffix(r.URL.Path, "/query")
 				isQueryRange := strings.HasSuffix(r.URL.Path, "/query_range")
 				isSeries := strings.HasSuffix(r.URL.Path, "/series")
@@ -159,6 +160,13 @@ func NewQueryTripperware(
 						}
 					}
 
+					if limits != nil {
+						// Check the PromQL functions and features allowed for the tenants.
+						if err := QueryFeaturesCheck(query, tenantIDs, limits, now); err != nil {
+							return nil, err
+						}
+					}
+
 					if limits != nil && limits.QueryPriority(userStr).Enabled {
 						priority, err := GetPriority(r, userStr, limits, now, lookbackDelta)
 						if err != nil && err == errParseExpr {
@@ -189,6 +197,14 @@ func NewQueryTripperware(
 				}
 				return next.RoundTrip(r)
 			})
+			if limits != nil {
+				roundTripper = newQueryEndTimeOffsetRoundTripper(roundTripper, limits)
+			}
+			if infoJoin.Enabled {
+				// The query is rewritten before any other check, so that they all apply to the joined query.
+				roundTripper = newInfoJoinRoundTripper(roundTripper, infoJoin)
+			}
+			return roundTripper
 		}
 		return next
 	}
@@ -236,6 +252,7 @@ func (q roundTripper) Do(ctx context.Context, r Request) (Response, error) {
 	if headerMap := util_log.HeaderMapFromContext(ctx); headerMap != nil {
 		util_log.InjectHeadersIntoHTTPRequest(headerMap, request)
 	}
+	util_log.InjectRequestIDIntoHTTPRequest(ctx, request)
 
 	if err := user.InjectOrgIDIntoHTTPRequest(ctx, request); err != nil {
 		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
@@ -105,6 +105,10 @@ func TestRoundTrip(t *testing.T) {
 	limitsWithVerticalSharding := validation.Limits{QueryVerticalShardSize: 3}
 	shardingOverrides, err := validation.NewOverrides(limitsWithVerticalSharding, nil)
 	require.NoError(t, err)
+
+	limitsWithSubqueriesDisabled := validation.Limits{QuerySubqueriesDisabled: true}
+	subqueriesDisabledOverrides, err := validation.NewOverrides(limitsWithSubqueriesDisabled, nil)
+	require.NoError(t, err)
 	for _, tc := range []struct {
 		path, expectedBody string
 		expectedErr        error
@@ -173,6 +177,12 @@ func TestRoundTrip(t *testing.T) {
 			limits:           defaultOverrides,
 			maxSubQuerySteps: 11000,
 		},
+		{
+			path:             querySubqueryStepSizeTooSmall,
+			expectedErr:      newQueryFeatureError(queryFeatureSubquery, "", "subqueries are not allowed"),
+			limits:           subqueriesDisabledOverrides,
+			maxSubQuerySteps: 0,
+		},
 	} {
 		t.Run(tc.path, func(t *testing.T) {
 			if tc.path != querySubqueryStepSizeTooSmall {
@@ -202,6 +212,7 @@ func TestRoundTrip(t *testing.T) {
 				time.Minute,
 				tc.maxSubQuerySteps,
 				0,
+				InfoJoinConfig{},
 			)
 			resp, err := tw(downstream).RoundTrip(req)
 			if tc.expectedErr == nil {
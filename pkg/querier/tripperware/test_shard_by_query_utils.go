@@ -468,6 +468,11 @@ type mockLimits struct {
 	maxCacheFreshness time.Duration
 	shardSize         int
 	queryPriority     validation.QueryPriority
+
+	queryAllowedFunctions      []string
+	querySubqueriesDisabled    bool
+	queryMaxAtModifierLookback time.Duration
+	queryEndTimeOffset         time.Duration
 }
 
 func (m mockLimits) MaxQueryLookback(string) time.Duration {
@@ -494,6 +499,30 @@ func (m mockLimits) QueryPriority(userID string) validation.QueryPriority {
 	return m.queryPriority
 }
 
+func (m mockLimits) QueryAllowedFunctions(userID string) []string {
+	return m.queryAllowedFunctions
+}
+
+func (m mockLimits) QuerySubqueriesDisabled(userID string) bool {
+	return m.querySubqueriesDisabled
+}
+
+func (m mockLimits) QueryMaxAtModifierLookback(userID string) time.Duration {
+	return m.queryMaxAtModifierLookback
+}
+
+func (m mockLimits) CacheWarmupMaxQueries(userID string) int {
+	return 0
+}
+
+func (m mockLimits) QueryEndTimeOffset(userID string) time.Duration {
+	return m.queryEndTimeOffset
+}
+
+func (m mockLimits) QueryTimeZone(userID string) string {
+	return ""
+}
+
 type singleHostRoundTripper struct {
 	host string
 	next http.RoundTripper
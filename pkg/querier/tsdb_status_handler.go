@@ -0,0 +1,69 @@
+package querier
+
+import (
+	"net/http"
+	"strconv"
+
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// defaultTSDBStatusLimit is the default number of items returned in each top list,
+// matching Prometheus.
+const defaultTSDBStatusLimit = 10
+
+type tsdbStatusResult struct {
+	Status string         `json:"status"`
+	Data   *v1.TSDBStatus `json:"data,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// TSDBStatusHandler returns the cardinality statistics of the ingesters head for a given
+// tenant, in the same format as the Prometheus /api/v1/status/tsdb endpoint.
+func TSDBStatusHandler(d Distributor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultTSDBStatusLimit
+		if s := r.FormValue("limit"); s != "" {
+			var err error
+			if limit, err = strconv.Atoi(s); err != nil || limit < 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				util.WriteJSONResponse(w, tsdbStatusResult{Status: statusError, Error: "limit must be a positive number"})
+				return
+			}
+		}
+
+		resp, err := d.TSDBStatus(r.Context(), limit)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			util.WriteJSONResponse(w, tsdbStatusResult{Status: statusError, Error: err.Error()})
+			return
+		}
+
+		util.WriteJSONResponse(w, tsdbStatusResult{
+			Status: statusSuccess,
+			Data: &v1.TSDBStatus{
+				HeadStats: v1.HeadStats{
+					NumSeries:     resp.NumSeries,
+					NumLabelPairs: int(resp.NumLabelPairs),
+					ChunkCount:    int64(resp.ChunkCount),
+					MinTime:       resp.MinTime,
+					MaxTime:       resp.MaxTime,
+				},
+				SeriesCountByMetricName:     toTSDBStats(resp.SeriesCountByMetricName),
+				LabelValueCountByLabelName:  toTSDBStats(resp.LabelValueCountByLabelName),
+				MemoryInBytesByLabelName:    toTSDBStats(resp.MemoryInBytesByLabelName),
+				SeriesCountByLabelValuePair: toTSDBStats(resp.SeriesCountByLabelValuePair),
+			},
+		})
+	})
+}
+
+func toTSDBStats(stats []client.TSDBStatistic) []v1.TSDBStat {
+	out := make([]v1.TSDBStat, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, v1.TSDBStat{Name: s.Name, Value: s.Value})
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package querier
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+)
+
+func TestTSDBStatusHandler_Success(t *testing.T) {
+	t.Parallel()
+
+	d := &MockDistributor{}
+	d.On("TSDBStatus", mock.Anything, 5).Return(
+		&client.TSDBStatusResponse{
+			NumSeries:                   10,
+			ChunkCount:                  20,
+			MinTime:                     1000,
+			MaxTime:                     2000,
+			NumLabelPairs:               4,
+			SeriesCountByMetricName:     []client.TSDBStatistic{{Name: "up", Value: 10}},
+			LabelValueCountByLabelName:  []client.TSDBStatistic{{Name: "job", Value: 2}},
+			MemoryInBytesByLabelName:    []client.TSDBStatistic{{Name: "job", Value: 100}},
+			SeriesCountByLabelValuePair: []client.TSDBStatistic{{Name: "job=api", Value: 6}},
+		},
+		nil)
+
+	request, err := http.NewRequest("GET", "/api/v1/status/tsdb?limit=5", nil)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	TSDBStatusHandler(d).ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	responseBody, err := io.ReadAll(recorder.Result().Body)
+	require.NoError(t, err)
+
+	expectedJSON := `
+	{
+		"status": "success",
+		"data": {
+			"headStats": {"numSeries": 10, "numLabelPairs": 4, "chunkCount": 20, "minTime": 1000, "maxTime": 2000},
+			"seriesCountByMetricName": [{"name": "up", "value": 10}],
+			"labelValueCountByLabelName": [{"name": "job", "value": 2}],
+			"memoryInBytesByLabelName": [{"name": "job", "value": 100}],
+			"seriesCountByLabelValuePair": [{"name": "job=api", "value": 6}]
+		}
+	}
+	`
+	require.JSONEq(t, expectedJSON, string(responseBody))
+}
+
+func TestTSDBStatusHandler_Error(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		url          string
+		err          error
+		expectedCode int
+		expectedJSON string
+	}{
+		"invalid limit": {
+			url:          "/api/v1/status/tsdb?limit=0",
+			expectedCode: http.StatusBadRequest,
+			expectedJSON: `{"status": "error", "error": "limit must be a positive number"}`,
+		},
+		"distributor error": {
+			url:          "/api/v1/status/tsdb",
+			err:          errors.New("no healthy ingester"),
+			expectedCode: http.StatusInternalServerError,
+			expectedJSON: `{"status": "error", "error": "no healthy ingester"}`,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			d := &MockDistributor{}
+			d.On("TSDBStatus", mock.Anything, defaultTSDBStatusLimit).Return((*client.TSDBStatusResponse)(nil), tc.err)
+
+			request, err := http.NewRequest("GET", tc.url, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			TSDBStatusHandler(d).ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.expectedCode, recorder.Result().StatusCode)
+			responseBody, err := io.ReadAll(recorder.Result().Body)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expectedJSON, string(responseBody))
+		})
+	}
+}
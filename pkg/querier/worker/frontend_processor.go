@@ -141,6 +141,9 @@ func (fp *frontendProcessor) runRequest(ctx context.Context, request *httpgrpc.H
 		ctx = user.InjectOrgID(ctx, orgID)
 	}
 	ctx = util_log.ContextWithHeaderMap(ctx, headerMap)
+	if requestID, ok := headers[textproto.CanonicalMIMEHeaderKey(util_log.RequestIDHeaderName)]; ok {
+		ctx = util_log.ContextWithRequestID(ctx, requestID)
+	}
 	logger := util_log.WithContext(ctx, fp.log)
 
 	response, err := fp.handler.Handle(ctx, request)
@@ -145,6 +145,9 @@ func (sp *schedulerProcessor) querierLoop(c schedulerpb.SchedulerForQuerier_Quer
 				}
 			}
 			ctx = util_log.ContextWithHeaderMap(ctx, headerMap)
+			if requestID, ok := headers[textproto.CanonicalMIMEHeaderKey(util_log.RequestIDHeaderName)]; ok {
+				ctx = util_log.ContextWithRequestID(ctx, requestID)
+			}
 
 			tracer := opentracing.GlobalTracer()
 			// Ignore errors here. If we cannot get parent span, we just don't create new one.
@@ -0,0 +1,382 @@
+package querycanary
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+const (
+	// MetricName is the name of the waveform series written by the canary.
+	MetricName = "cortex_query_canary_waveform"
+
+	waveformSawtooth = "sawtooth"
+	waveformSine     = "sine"
+
+	// waveformPeriod is the number of samples of a period of the waveforms.
+	waveformPeriod = 240
+
+	queryValues = "values"
+	queryCount  = "count"
+
+	statusSuccess  = "success"
+	statusMismatch = "mismatch"
+	statusFailure  = "failure"
+
+	// maxPointsPerQuery is the maximum number of points of a range query accepted by the Prometheus API.
+	maxPointsPerQuery = 11000
+)
+
+var waveforms = []string{waveformSawtooth, waveformSine}
+
+// Config configures the query correctness canary.
+type Config struct {
+	Tenants          flagext.StringSliceCSV `yaml:"tenants"`
+	InstanceID       string                 `yaml:"instance_id" doc:"hidden"`
+	WriteInterval    time.Duration          `yaml:"write_interval"`
+	QueryInterval    time.Duration          `yaml:"query_interval"`
+	QueryRanges      flagext.StringSliceCSV `yaml:"query_ranges"`
+	QueryResolutions flagext.StringSliceCSV `yaml:"query_resolutions"`
+}
+
+// RegisterFlags registers the query canary flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	hostname, _ := os.Hostname()
+
+	cfg.QueryRanges = []string{"15m", "2h", "24h"}
+	cfg.QueryResolutions = []string{"15s", "1m", "5m"}
+
+	f.Var(&cfg.Tenants, "query-canary.tenants", "Comma separated list of the tenants the query canary writes the waveform series to and queries them back from. Required by the query-canary module.")
+	f.StringVar(&cfg.InstanceID, "query-canary.instance-id", hostname, "Instance ID, added as a label to the waveform series so that each query canary checks its own series only.")
+	f.DurationVar(&cfg.WriteInterval, "query-canary.write-interval", 15*time.Second, "Interval between two samples of the waveform series.")
+	f.DurationVar(&cfg.QueryInterval, "query-canary.query-interval", time.Minute, "How frequently the waveform series are queried back and checked.")
+	f.Var(&cfg.QueryRanges, "query-canary.query-ranges", "Comma separated list of the time ranges, ending at the last written sample, the waveform series are queried back over.")
+	f.Var(&cfg.QueryResolutions, "query-canary.query-resolutions", "Comma separated list of the steps the waveform series are queried back with, over each time range. Each step must be a multiple of the write interval.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	for _, userID := range cfg.Tenants {
+		if err := tenant.ValidTenantID(userID); err != nil {
+			return errors.Wrapf(err, "invalid query canary tenant %q", userID)
+		}
+	}
+	if cfg.WriteInterval <= 0 || cfg.WriteInterval%time.Millisecond != 0 {
+		return errors.New("the query canary write interval must be a positive number of milliseconds")
+	}
+	if cfg.QueryInterval <= 0 {
+		return errors.New("the query canary query interval must be greater than 0")
+	}
+	if _, err := parseDurations(cfg.QueryRanges); err != nil {
+		return errors.Wrap(err, "invalid query canary query ranges")
+	}
+	steps, err := parseDurations(cfg.QueryResolutions)
+	if err != nil {
+		return errors.Wrap(err, "invalid query canary query resolutions")
+	}
+	for _, step := range steps {
+		if step%cfg.WriteInterval != 0 {
+			return errors.Errorf("the query canary query resolution %s is not a multiple of the write interval", step)
+		}
+	}
+	return nil
+}
+
+func parseDurations(values []string) ([]time.Duration, error) {
+	res := make([]time.Duration, 0, len(values))
+	for _, v := range values {
+		d, err := model.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, errors.Errorf("%s is not greater than 0", v)
+		}
+		res = append(res, time.Duration(d))
+	}
+	if len(res) == 0 {
+		return nil, errors.New("at least one value is required")
+	}
+	return res, nil
+}
+
+// Pusher writes the waveform series, like the distributor does.
+type Pusher interface {
+	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
+}
+
+// streak is the time range of the samples of a tenant continuously written without failures.
+// Only this time range is checked, since the samples the canary failed to write are missing.
+type streak struct {
+	first, last int64
+}
+
+// Canary continuously writes waveform series of known values to each tenant, and queries them
+// back over several time ranges and resolutions, checking the exact values returned. It catches
+// the silent data loss and the merge bugs, like the samples duplicated across the replicas.
+type Canary struct {
+	services.Service
+
+	cfg       Config
+	ranges    []time.Duration
+	steps     []time.Duration
+	engine    v1.QueryEngine
+	queryable storage.Queryable
+	pusher    Pusher
+	logger    log.Logger
+
+	mtx     sync.Mutex
+	streaks map[string]streak
+
+	writes  *prometheus.CounterVec
+	queries *prometheus.CounterVec
+}
+
+// NewCanary makes a new Canary.
+func NewCanary(cfg Config, engine v1.QueryEngine, queryable storage.Queryable, pusher Pusher, logger log.Logger, reg prometheus.Registerer) (*Canary, error) {
+	ranges, err := parseDurations(cfg.QueryRanges)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := parseDurations(cfg.QueryResolutions)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Canary{
+		cfg:       cfg,
+		ranges:    ranges,
+		steps:     steps,
+		engine:    engine,
+		queryable: queryable,
+		pusher:    pusher,
+		logger:    logger,
+		streaks:   map[string]streak{},
+
+		writes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_canary_writes_total",
+			Help: "Total number of writes of the waveform series by the query canary.",
+		}, []string{"user", "status"}),
+		queries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_canary_queries_total",
+			Help: "Total number of queries of the waveform series run by the query canary, by outcome. A mismatch is a query returning values different from the ones written.",
+		}, []string{"user", "range", "step", "query", "status"}),
+	}
+
+	c.Service = services.NewBasicService(nil, c.running, nil)
+	return c, nil
+}
+
+func (c *Canary) running(ctx context.Context) error {
+	// The writes run apart from the queries, so that the slow queries don't delay them.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.writeLoop(ctx)
+	}()
+	defer wg.Wait()
+
+	queryTicker := time.NewTicker(c.cfg.QueryInterval)
+	defer queryTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-queryTicker.C:
+			c.check(ctx)
+		}
+	}
+}
+
+func (c *Canary) writeLoop(ctx context.Context) {
+	writeTicker := time.NewTicker(c.cfg.WriteInterval)
+	defer writeTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-writeTicker.C:
+			c.write(ctx, now)
+		}
+	}
+}
+
+// write writes the sample of each waveform series at the last multiple of the write interval.
+func (c *Canary) write(ctx context.Context, now time.Time) {
+	intervalMs := c.cfg.WriteInterval.Milliseconds()
+	ts := now.UnixMilli() / intervalMs * intervalMs
+
+	series := make([]labels.Labels, 0, len(waveforms))
+	samples := make([]cortexpb.Sample, 0, len(waveforms))
+	for _, w := range waveforms {
+		series = append(series, c.seriesLabels(w))
+		samples = append(samples, cortexpb.Sample{TimestampMs: ts, Value: waveformValue(w, ts/intervalMs)})
+	}
+
+	for _, userID := range c.cfg.Tenants {
+		req := cortexpb.ToWriteRequest(series, samples, nil, nil, cortexpb.API)
+		_, err := c.pusher.Push(user.InjectOrgID(ctx, userID), req)
+
+		c.mtx.Lock()
+		s := c.streaks[userID]
+		switch {
+		case err != nil:
+			// The checked time range restarts after the failed write.
+			delete(c.streaks, userID)
+		case s.last == 0 || ts != s.last+intervalMs:
+			c.streaks[userID] = streak{first: ts, last: ts}
+		default:
+			c.streaks[userID] = streak{first: s.first, last: ts}
+		}
+		c.mtx.Unlock()
+
+		if err != nil {
+			c.writes.WithLabelValues(userID, statusFailure).Inc()
+			level.Warn(c.logger).Log("msg", "failed to write the query canary waveform series", "user", userID, "err", err)
+			continue
+		}
+		c.writes.WithLabelValues(userID, statusSuccess).Inc()
+	}
+}
+
+func (c *Canary) seriesLabels(waveform string) labels.Labels {
+	return labels.FromStrings(labels.MetricName, MetricName, "instance", c.cfg.InstanceID, "waveform", waveform)
+}
+
+// waveformValue returns the value of the n-th sample of the waveform.
+func waveformValue(waveform string, n int64) float64 {
+	phase := n % waveformPeriod
+	if waveform == waveformSine {
+		return math.Sin(2 * math.Pi * float64(phase) / waveformPeriod)
+	}
+	return float64(phase)
+}
+
+// check queries back the waveform series of each tenant over each time range and resolution.
+func (c *Canary) check(ctx context.Context) {
+	for _, userID := range c.cfg.Tenants {
+		c.mtx.Lock()
+		s, ok := c.streaks[userID]
+		c.mtx.Unlock()
+		if !ok {
+			continue
+		}
+
+		for _, r := range c.ranges {
+			for _, step := range c.steps {
+				c.checkRange(ctx, userID, s, r, step)
+			}
+		}
+	}
+}
+
+// checkRange runs the queries of a time range and resolution. The queried time range is aligned
+// to the step, so that each step matches a written sample.
+func (c *Canary) checkRange(ctx context.Context, userID string, s streak, r, step time.Duration) {
+	stepMs := step.Milliseconds()
+	end := s.last / stepMs * stepMs
+	// The count of samples over each step is only known for the steps fully within the streak.
+	start := max(end-r.Milliseconds(), s.first+stepMs-c.cfg.WriteInterval.Milliseconds())
+	start = (start + stepMs - 1) / stepMs * stepMs
+	if start > end || (end-start)/stepMs+1 > maxPointsPerQuery {
+		return
+	}
+
+	logger := log.With(c.logger, "user", userID, "range", r, "step", step)
+	ctx = user.InjectOrgID(ctx, userID)
+	selector := fmt.Sprintf(`%s{instance=%q}`, MetricName, c.cfg.InstanceID)
+
+	queries := []struct {
+		name     string
+		query    string
+		expected func(waveform string, ts int64) float64
+	}{
+		{name: queryValues, query: selector, expected: func(waveform string, ts int64) float64 {
+			return waveformValue(waveform, ts/c.cfg.WriteInterval.Milliseconds())
+		}},
+		// The range selectors include both ends, so the window is shortened to hold the samples of a single step.
+		{name: queryCount, query: fmt.Sprintf("count_over_time(%s[%s])", selector, model.Duration(step-time.Millisecond)), expected: func(string, int64) float64 {
+			return float64(step / c.cfg.WriteInterval)
+		}},
+	}
+
+	for _, q := range queries {
+		err := c.runQuery(ctx, q.query, start, end, step, q.expected)
+		status := statusSuccess
+		if errors.Is(err, errMismatch) {
+			status = statusMismatch
+			level.Error(logger).Log("msg", "query canary got unexpected values", "query", q.query, "err", err)
+		} else if err != nil {
+			status = statusFailure
+			level.Warn(logger).Log("msg", "query canary query failed", "query", q.query, "err", err)
+		}
+		c.queries.WithLabelValues(userID, model.Duration(r).String(), model.Duration(step).String(), q.name, status).Inc()
+	}
+}
+
+var errMismatch = errors.New("mismatch")
+
+func (c *Canary) runQuery(ctx context.Context, qs string, start, end int64, step time.Duration, expected func(waveform string, ts int64) float64) error {
+	q, err := c.engine.NewRangeQuery(ctx, c.queryable, nil, qs, time.UnixMilli(start), time.UnixMilli(end), step)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return res.Err
+	}
+	matrix, err := res.Matrix()
+	if err != nil {
+		return err
+	}
+
+	points := int((end-start)/step.Milliseconds()) + 1
+	found := map[string]bool{}
+	for _, series := range matrix {
+		waveform := series.Metric.Get("waveform")
+		if found[waveform] {
+			return errors.Wrapf(errMismatch, "duplicated %s series", waveform)
+		}
+		found[waveform] = true
+
+		if len(series.Floats) != points {
+			return errors.Wrapf(errMismatch, "%s series has %d points instead of %d", waveform, len(series.Floats), points)
+		}
+		for _, p := range series.Floats {
+			if exp := expected(waveform, p.T); p.F != exp {
+				return errors.Wrapf(errMismatch, "%s series has value %v at %s instead of %v", waveform, p.F, time.UnixMilli(p.T).UTC().Format(time.RFC3339), exp)
+			}
+		}
+	}
+	for _, w := range waveforms {
+		if !found[w] {
+			return errors.Wrapf(errMismatch, "missing %s series", w)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package querycanary
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/util/teststorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+type pusherFunc func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
+
+func (f pusherFunc) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	return f(ctx, req)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	require.NoError(t, cfg.Validate())
+
+	cfg.QueryResolutions = []string{"20s"}
+	require.EqualError(t, cfg.Validate(), "the query canary query resolution 20s is not a multiple of the write interval")
+
+	cfg.QueryResolutions = []string{"1m"}
+	cfg.QueryRanges = []string{"1x"}
+	require.Error(t, cfg.Validate())
+
+	cfg.QueryRanges = []string{"1h"}
+	cfg.Tenants = []string{"a/b"}
+	require.Error(t, cfg.Validate())
+}
+
+func TestCanary(t *testing.T) {
+	start := time.Unix(1700000040, 0)
+
+	tests := map[string]struct {
+		// mutate is applied to the samples written at the given write, simulating a bug.
+		mutate           func(n int, req *cortexpb.WriteRequest)
+		failWrite        int
+		expectedStatuses map[string]string
+	}{
+		"should succeed when the values are the written ones": {
+			expectedStatuses: map[string]string{queryValues: statusSuccess, queryCount: statusSuccess},
+		},
+		"should detect the samples written with a different value": {
+			mutate: func(n int, req *cortexpb.WriteRequest) {
+				if n == 52 {
+					req.Timeseries[0].Samples[0].Value++
+				}
+			},
+			expectedStatuses: map[string]string{queryValues: statusMismatch, queryCount: statusSuccess},
+		},
+		"should detect the duplicated samples": {
+			mutate: func(n int, req *cortexpb.WriteRequest) {
+				if n == 50 {
+					s := req.Timeseries[0].Samples[0]
+					req.Timeseries[0].Samples = append(req.Timeseries[0].Samples, cortexpb.Sample{TimestampMs: s.TimestampMs + 1, Value: s.Value})
+				}
+			},
+			expectedStatuses: map[string]string{queryValues: statusSuccess, queryCount: statusMismatch},
+		},
+		"should only check the samples written after the last failed write": {
+			mutate: func(n int, req *cortexpb.WriteRequest) {
+				if n == 20 {
+					req.Timeseries[0].Samples[0].Value++
+				}
+			},
+			failWrite:        40,
+			expectedStatuses: map[string]string{queryValues: statusSuccess, queryCount: statusSuccess},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := teststorage.New(t)
+			t.Cleanup(func() { _ = db.Close() })
+
+			n := 0
+			pusher := pusherFunc(func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+				userID, err := user.ExtractOrgID(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, "user-1", userID)
+
+				if n == tc.failWrite && n > 0 {
+					return nil, errors.New("write failed")
+				}
+				if tc.mutate != nil {
+					tc.mutate(n, req)
+				}
+
+				app := db.Appender(ctx)
+				for _, ts := range req.Timeseries {
+					for _, s := range ts.Samples {
+						_, err := app.Append(0, cortexpb.FromLabelAdaptersToLabels(ts.Labels), s.TimestampMs, s.Value)
+						require.NoError(t, err)
+					}
+				}
+				return &cortexpb.WriteResponse{}, app.Commit()
+			})
+
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			cfg.Tenants = []string{"user-1"}
+			cfg.InstanceID = "canary-1"
+			cfg.QueryRanges = []string{"1h"}
+			cfg.QueryResolutions = []string{"15s", "1m"}
+
+			engine := promql.NewEngine(promql.EngineOpts{MaxSamples: 1e6, Timeout: time.Minute})
+			reg := prometheus.NewPedanticRegistry()
+			c, err := NewCanary(cfg, engine, db, pusher, log.NewNopLogger(), reg)
+			require.NoError(t, err)
+
+			for n = 0; n < 100; n++ {
+				c.write(context.Background(), start.Add(time.Duration(n)*cfg.WriteInterval))
+			}
+			c.check(context.Background())
+
+			for query, status := range tc.expectedStatuses {
+				for _, step := range []string{"15s", "1m"} {
+					assert.Equal(t, 1.0, testutil.ToFloat64(c.queries.WithLabelValues("user-1", "1h", step, query, status)), "query: %s, step: %s", query, step)
+				}
+			}
+
+			expectedWrites := `
+				# HELP cortex_query_canary_writes_total Total number of writes of the waveform series by the query canary.
+				# TYPE cortex_query_canary_writes_total counter
+				cortex_query_canary_writes_total{status="success",user="user-1"} 100
+			`
+			if tc.failWrite > 0 {
+				expectedWrites = `
+				# HELP cortex_query_canary_writes_total Total number of writes of the waveform series by the query canary.
+				# TYPE cortex_query_canary_writes_total counter
+				cortex_query_canary_writes_total{status="failure",user="user-1"} 1
+				cortex_query_canary_writes_total{status="success",user="user-1"} 99
+			`
+			}
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedWrites), "cortex_query_canary_writes_total"))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package replicator
+
+import (
+	"flag"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+// Config configures the replication of the blocks shipped by the ingesters to the storage of a
+// secondary cluster.
+type Config struct {
+	Tenants           flagext.StringSliceCSV `yaml:"tenants"`
+	Interval          time.Duration          `yaml:"interval"`
+	TenantConcurrency int                    `yaml:"tenant_concurrency"`
+	Destination       bucket.Config          `yaml:"destination"`
+}
+
+// RegisterFlags registers the replicator flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.Var(&cfg.Tenants, "replicator.tenants", "Comma separated list of the tenants whose blocks are replicated. Empty to replicate all the tenants.")
+	f.DurationVar(&cfg.Interval, "replicator.interval", time.Minute, "How frequently the new blocks shipped by the ingesters are looked for and replicated.")
+	f.IntVar(&cfg.TenantConcurrency, "replicator.tenant-concurrency", 4, "Maximum number of tenants replicated at the same time.")
+	cfg.Destination.RegisterFlagsWithPrefix("replicator.destination.", f)
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	for _, userID := range cfg.Tenants {
+		if err := tenant.ValidTenantID(userID); err != nil {
+			return errors.Wrapf(err, "invalid replicator tenant %q", userID)
+		}
+	}
+	if cfg.Interval <= 0 {
+		return errors.New("the replicator interval must be greater than 0")
+	}
+	if cfg.TenantConcurrency <= 0 {
+		return errors.New("the replicator tenant concurrency must be greater than 0")
+	}
+	return errors.Wrap(cfg.Destination.Validate(), "invalid replicator destination")
+}
@@ -0,0 +1,347 @@
+package replicator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+// CheckpointFilename is the name of the checkpoint of the replication of a tenant, stored in the
+// destination bucket, so that the replication resumes where it stopped.
+const CheckpointFilename = "replication-checkpoint.json"
+
+// Checkpoint keeps track of the blocks of a tenant already replicated. The blocks deleted from the
+// source bucket, like once compacted, are removed from the checkpoint.
+type Checkpoint struct {
+	Replicated []ulid.ULID `json:"replicated"`
+
+	// UpdatedAt is a unix timestamp (seconds precision) of when the checkpoint has been updated.
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// Replicator periodically copies the blocks shipped by the ingesters to the bucket of a secondary
+// cluster, which compacts them on its own, for an active/passive disaster recovery setup that
+// doesn't require the clients to write to both clusters.
+type Replicator struct {
+	services.Service
+
+	cfg         Config
+	source      objstore.Bucket
+	destination objstore.Bucket
+	cfgProvider bucket.TenantConfigProvider
+	logger      log.Logger
+
+	// Users replicated by the last run, whose metrics are removed once they're not anymore.
+	usersMtx sync.Mutex
+	users    map[string]struct{}
+
+	replicatedBlocks *prometheus.CounterVec
+	replicatedBytes  *prometheus.CounterVec
+	failures         *prometheus.CounterVec
+	pendingBlocks    *prometheus.GaugeVec
+	lag              *prometheus.GaugeVec
+	lastSuccess      prometheus.Gauge
+}
+
+// NewReplicator makes a new Replicator copying the blocks from the source to the destination bucket.
+func NewReplicator(cfg Config, source, destination objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) *Replicator {
+	r := &Replicator{
+		cfg:         cfg,
+		source:      source,
+		destination: destination,
+		cfgProvider: cfgProvider,
+		logger:      logger,
+		users:       map[string]struct{}{},
+
+		replicatedBlocks: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_replicator_replicated_blocks_total",
+			Help: "Total number of blocks replicated to the destination bucket.",
+		}, []string{"user"}),
+		replicatedBytes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_replicator_replicated_bytes_total",
+			Help: "Total number of bytes of the block files copied to the destination bucket.",
+		}, []string{"user"}),
+		failures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_replicator_failures_total",
+			Help: "Total number of failed replications of a tenant.",
+		}, []string{"user"}),
+		pendingBlocks: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_replicator_pending_blocks",
+			Help: "Number of blocks shipped by the ingesters not replicated yet, as of the last replication of the tenant.",
+		}, []string{"user"}),
+		lag: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_replicator_lag_seconds",
+			Help: "How long ago the oldest block not replicated yet has been created, as of the last replication of the tenant. 0 if all the blocks are replicated.",
+		}, []string{"user"}),
+		lastSuccess: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_replicator_last_successful_run_timestamp_seconds",
+			Help: "Unix timestamp of the last run replicating all the tenants successfully.",
+		}),
+	}
+
+	r.Service = services.NewTimerService(cfg.Interval, nil, r.iteration, nil)
+	return r
+}
+
+func (r *Replicator) iteration(ctx context.Context) error {
+	if err := r.replicateUsers(ctx); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to replicate the blocks", "err", err)
+	}
+
+	// A failed replication is retried at the next run.
+	return nil
+}
+
+func (r *Replicator) replicateUsers(ctx context.Context) error {
+	users, _, err := cortex_tsdb.NewUsersScanner(r.source, r.isReplicated, r.logger).ScanUsers(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to discover the users")
+	}
+	r.cleanupUsers(users)
+
+	err = concurrency.ForEachUser(ctx, users, r.cfg.TenantConcurrency, func(ctx context.Context, userID string) error {
+		if err := r.replicateUser(ctx, userID, time.Now()); err != nil {
+			r.failures.WithLabelValues(userID).Inc()
+			level.Warn(util_log.WithUserID(userID, r.logger)).Log("msg", "failed to replicate the blocks of the user", "err", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.lastSuccess.SetToCurrentTime()
+	return nil
+}
+
+func (r *Replicator) isReplicated(userID string) (bool, error) {
+	return len(r.cfg.Tenants) == 0 || util.StringsContain(r.cfg.Tenants, userID), nil
+}
+
+// cleanupUsers removes the metrics of the users not replicated anymore.
+func (r *Replicator) cleanupUsers(users []string) {
+	r.usersMtx.Lock()
+	defer r.usersMtx.Unlock()
+
+	current := make(map[string]struct{}, len(users))
+	for _, userID := range users {
+		current[userID] = struct{}{}
+	}
+	for userID := range r.users {
+		if _, ok := current[userID]; ok {
+			continue
+		}
+		r.replicatedBlocks.DeleteLabelValues(userID)
+		r.replicatedBytes.DeleteLabelValues(userID)
+		r.failures.DeleteLabelValues(userID)
+		r.pendingBlocks.DeleteLabelValues(userID)
+		r.lag.DeleteLabelValues(userID)
+	}
+	r.users = current
+}
+
+// replicateUser copies the blocks shipped by the ingesters which haven't been replicated yet, from
+// the oldest to the newest, updating the checkpoint after each of them.
+func (r *Replicator) replicateUser(ctx context.Context, userID string, now time.Time) error {
+	logger := util_log.WithUserID(userID, r.logger)
+	src := bucket.NewUserBucketClient(userID, r.source, r.cfgProvider)
+	dst := bucket.NewUserBucketClient(userID, r.destination, r.cfgProvider)
+
+	checkpoint, err := ReadCheckpoint(ctx, dst)
+	if err != nil {
+		return err
+	}
+	replicated := make(map[ulid.ULID]struct{}, len(checkpoint.Replicated))
+	for _, id := range checkpoint.Replicated {
+		replicated[id] = struct{}{}
+	}
+
+	var existing, pending []ulid.ULID
+	err = src.Iter(ctx, "", func(name string) error {
+		if id, ok := block.IsBlockDir(name); ok {
+			existing = append(existing, id)
+			if _, ok := replicated[id]; !ok {
+				pending = append(pending, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the blocks")
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Compare(pending[j]) < 0 })
+
+	// The blocks deleted from the source bucket won't be listed again.
+	retained := make(map[ulid.ULID]struct{}, len(existing))
+	for _, id := range existing {
+		if _, ok := replicated[id]; ok {
+			retained[id] = struct{}{}
+		}
+	}
+	replicated = retained
+
+	// The lag is measured from the oldest block not replicated, including the ones failing.
+	defer func() {
+		r.pendingBlocks.WithLabelValues(userID).Set(float64(len(pending)))
+		if len(pending) == 0 {
+			r.lag.WithLabelValues(userID).Set(0)
+		} else {
+			r.lag.WithLabelValues(userID).Set(now.Sub(ulid.Time(pending[0].Time())).Seconds())
+		}
+	}()
+
+	for len(pending) > 0 {
+		id := pending[0]
+
+		meta, err := block.DownloadMeta(ctx, logger, src, id)
+		switch {
+		case src.IsObjNotFoundErr(errors.Cause(err)):
+			// The block is still being uploaded, or has been deleted in the meantime: it will be
+			// looked for again at the next run.
+			pending = pending[1:]
+			continue
+		case err != nil:
+			return errors.Wrapf(err, "failed to read the meta of block %s", id)
+		}
+
+		// Only the blocks shipped by the ingesters are replicated, since the destination cluster
+		// compacts and downsamples them on its own.
+		if meta.Thanos.Source == metadata.ReceiveSource {
+			size, err := copyBlock(ctx, src, dst, id)
+			if err != nil {
+				return errors.Wrapf(err, "failed to replicate block %s", id)
+			}
+			r.replicatedBlocks.WithLabelValues(userID).Inc()
+			r.replicatedBytes.WithLabelValues(userID).Add(float64(size))
+			level.Info(logger).Log("msg", "replicated block", "block", id, "bytes", size)
+		}
+
+		replicated[id] = struct{}{}
+		pending = pending[1:]
+		if err := WriteCheckpoint(ctx, dst, replicated, now); err != nil {
+			return err
+		}
+	}
+
+	// The checkpoint is written again when blocks have been deleted from the source bucket only.
+	if len(replicated) != len(checkpoint.Replicated) {
+		return WriteCheckpoint(ctx, dst, replicated, now)
+	}
+	return nil
+}
+
+// copyBlock copies the files of the block to the destination bucket and returns the number of bytes
+// copied. The meta.json is copied last, so that the block is only visible once complete. The files
+// already copied by an interrupted replication are skipped.
+func copyBlock(ctx context.Context, src, dst objstore.Bucket, id ulid.ULID) (int64, error) {
+	var files []string
+	err := src.Iter(ctx, id.String(), func(name string) error {
+		switch path.Base(name) {
+		case block.MetaFilename, metadata.DeletionMarkFilename, metadata.NoCompactMarkFilename:
+			// The markers of the source cluster don't apply to the destination one.
+		default:
+			files = append(files, name)
+		}
+		return nil
+	}, objstore.WithRecursiveIter)
+	if err != nil {
+		return 0, err
+	}
+
+	var copied int64
+	for _, name := range append(files, path.Join(id.String(), block.MetaFilename)) {
+		size, err := copyObject(ctx, src, dst, name, !strings.HasSuffix(name, block.MetaFilename))
+		if err != nil {
+			return copied, errors.Wrapf(err, "copy %s", name)
+		}
+		copied += size
+	}
+	return copied, nil
+}
+
+// copyObject copies the object to the destination bucket, unless resumable and already copied
+// with the same size. It returns the number of bytes copied.
+func copyObject(ctx context.Context, src, dst objstore.Bucket, name string, resumable bool) (int64, error) {
+	attrs, err := src.Attributes(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	if resumable {
+		if dstAttrs, err := dst.Attributes(ctx, name); err == nil && dstAttrs.Size == attrs.Size {
+			return 0, nil
+		} else if err != nil && !dst.IsObjNotFoundErr(err) {
+			return 0, err
+		}
+	}
+
+	reader, err := src.Get(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	if err := dst.Upload(ctx, name, reader); err != nil {
+		return 0, err
+	}
+	return attrs.Size, nil
+}
+
+// ReadCheckpoint reads the replication checkpoint from the user bucket. An empty checkpoint is
+// returned if it doesn't exist yet.
+func ReadCheckpoint(ctx context.Context, userBucket objstore.Bucket) (*Checkpoint, error) {
+	reader, err := userBucket.Get(ctx, CheckpointFilename)
+	if userBucket.IsObjNotFoundErr(err) {
+		return &Checkpoint{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the replication checkpoint")
+	}
+	defer reader.Close()
+
+	checkpoint := &Checkpoint{}
+	if err := json.NewDecoder(reader).Decode(checkpoint); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the replication checkpoint")
+	}
+	return checkpoint, nil
+}
+
+// WriteCheckpoint writes the replication checkpoint to the user bucket.
+func WriteCheckpoint(ctx context.Context, userBucket objstore.Bucket, replicated map[ulid.ULID]struct{}, now time.Time) error {
+	checkpoint := Checkpoint{Replicated: make([]ulid.ULID, 0, len(replicated)), UpdatedAt: now.Unix()}
+	for id := range replicated {
+		checkpoint.Replicated = append(checkpoint.Replicated, id)
+	}
+	sort.Slice(checkpoint.Replicated, func(i, j int) bool {
+		return checkpoint.Replicated[i].Compare(checkpoint.Replicated[j]) < 0
+	})
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the replication checkpoint")
+	}
+	return errors.Wrap(userBucket.Upload(ctx, CheckpointFilename, bytes.NewReader(data)), "failed to write the replication checkpoint")
+}
@@ -0,0 +1,161 @@
+package replicator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+)
+
+func TestReplicator_ReplicateUser(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	source := objstore.NewInMemBucket()
+	destination := objstore.NewInMemBucket()
+
+	shipped1 := uploadBlock(t, source, "user-1", now.Add(-3*time.Hour), metadata.ReceiveSource)
+	shipped2 := uploadBlock(t, source, "user-1", now.Add(-time.Hour), metadata.ReceiveSource)
+	compacted := uploadBlock(t, source, "user-1", now.Add(-2*time.Hour), metadata.CompactorSource)
+	require.NoError(t, source.Upload(ctx, path.Join("user-1", shipped1.String(), metadata.DeletionMarkFilename), strings.NewReader("{}")))
+
+	reg := prometheus.NewPedanticRegistry()
+	r := NewReplicator(Config{Interval: time.Minute, TenantConcurrency: 1}, source, destination, nil, log.NewNopLogger(), reg)
+	require.NoError(t, r.replicateUser(ctx, "user-1", now))
+
+	// Only the blocks shipped by the ingesters are replicated, without the markers.
+	for _, id := range []ulid.ULID{shipped1, shipped2} {
+		for _, name := range []string{block.MetaFilename, block.IndexFilename, "chunks/000001"} {
+			exists, err := destination.Exists(ctx, path.Join("user-1", id.String(), name))
+			require.NoError(t, err)
+			assert.True(t, exists, name)
+		}
+	}
+	assertObjectExists(t, destination, path.Join("user-1", shipped1.String(), metadata.DeletionMarkFilename), false)
+	assertObjectExists(t, destination, path.Join("user-1", compacted.String(), block.MetaFilename), false)
+
+	checkpoint, err := ReadCheckpoint(ctx, bucket.NewUserBucketClient("user-1", destination, nil))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{shipped1, shipped2, compacted}, checkpoint.Replicated)
+	assert.Equal(t, 2.0, testutil.ToFloat64(r.replicatedBlocks.WithLabelValues("user-1")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(r.lag.WithLabelValues("user-1")))
+
+	// The blocks deleted from the source bucket are removed from the checkpoint, while the new
+	// blocks are replicated.
+	require.NoError(t, block.Delete(ctx, log.NewNopLogger(), bucket.NewUserBucketClient("user-1", source, nil), shipped1))
+	shipped3 := uploadBlock(t, source, "user-1", now, metadata.ReceiveSource)
+	require.NoError(t, r.replicateUser(ctx, "user-1", now))
+
+	checkpoint, err = ReadCheckpoint(ctx, bucket.NewUserBucketClient("user-1", destination, nil))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{shipped2, compacted, shipped3}, checkpoint.Replicated)
+	assert.Equal(t, 3.0, testutil.ToFloat64(r.replicatedBlocks.WithLabelValues("user-1")))
+}
+
+func TestReplicator_ShouldResumeInterruptedReplication(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	source := objstore.NewInMemBucket()
+	destination := objstore.NewInMemBucket()
+
+	failing := uploadBlock(t, source, "user-1", now.Add(-2*time.Hour), metadata.ReceiveSource)
+	next := uploadBlock(t, source, "user-1", now.Add(-time.Hour), metadata.ReceiveSource)
+
+	// The index has been copied before the interruption, while the chunks can't be read.
+	index := path.Join("user-1", failing.String(), block.IndexFilename)
+	require.NoError(t, destination.Upload(ctx, index, strings.NewReader("index")))
+	failingSource := &errorBucket{Bucket: source, failing: path.Join("user-1", failing.String(), "chunks/000001")}
+
+	r := NewReplicator(Config{Interval: time.Minute, TenantConcurrency: 1}, failingSource, destination, nil, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.Error(t, r.replicateUser(ctx, "user-1", now))
+
+	// The incomplete block isn't visible, and the following ones aren't replicated before it.
+	assertObjectExists(t, destination, path.Join("user-1", failing.String(), block.MetaFilename), false)
+	assertObjectExists(t, destination, path.Join("user-1", next.String(), block.MetaFilename), false)
+	assert.Equal(t, 2.0, testutil.ToFloat64(r.pendingBlocks.WithLabelValues("user-1")))
+	assert.InDelta(t, (2 * time.Hour).Seconds(), testutil.ToFloat64(r.lag.WithLabelValues("user-1")), 1)
+
+	failingSource.failing = ""
+	require.NoError(t, r.replicateUser(ctx, "user-1", now))
+
+	assertObjectExists(t, destination, path.Join("user-1", failing.String(), block.MetaFilename), true)
+	assertObjectExists(t, destination, path.Join("user-1", next.String(), block.MetaFilename), true)
+	assert.Equal(t, 0.0, testutil.ToFloat64(r.pendingBlocks.WithLabelValues("user-1")))
+	// The files already copied aren't copied again.
+	expected := len("index") + 2*len("chunkschunks") + metaSize(t, source, failing) + metaSize(t, source, next)
+	assert.Equal(t, float64(expected), testutil.ToFloat64(r.replicatedBytes.WithLabelValues("user-1")))
+}
+
+func TestReplicator_ReplicateUsers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	source := objstore.NewInMemBucket()
+	destination := objstore.NewInMemBucket()
+
+	uploadBlock(t, source, "user-1", now, metadata.ReceiveSource)
+	uploadBlock(t, source, "user-2", now, metadata.ReceiveSource)
+
+	r := NewReplicator(Config{Tenants: []string{"user-2"}, Interval: time.Minute, TenantConcurrency: 2}, source, destination, nil, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, r.replicateUsers(ctx))
+
+	assertObjectExists(t, destination, path.Join("user-1", CheckpointFilename), false)
+	assertObjectExists(t, destination, path.Join("user-2", CheckpointFilename), true)
+	assert.NotZero(t, testutil.ToFloat64(r.lastSuccess))
+}
+
+// uploadBlock uploads the files of a block created at the given time.
+func uploadBlock(t *testing.T, bkt objstore.Bucket, userID string, createdAt time.Time, source metadata.SourceType) ulid.ULID {
+	id := ulid.MustNew(ulid.Timestamp(createdAt), nil)
+	meta := metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{ULID: id, MinTime: 0, MaxTime: 1, Version: metadata.TSDBVersion1},
+		Thanos:    metadata.Thanos{Source: source},
+	}
+	data, err := json.Marshal(meta)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, bkt.Upload(ctx, path.Join(userID, id.String(), block.MetaFilename), bytes.NewReader(data)))
+	require.NoError(t, bkt.Upload(ctx, path.Join(userID, id.String(), block.IndexFilename), strings.NewReader("index")))
+	require.NoError(t, bkt.Upload(ctx, path.Join(userID, id.String(), "chunks/000001"), strings.NewReader("chunkschunks")))
+	return id
+}
+
+func assertObjectExists(t *testing.T, bkt objstore.Bucket, name string, expected bool) {
+	exists, err := bkt.Exists(context.Background(), name)
+	require.NoError(t, err)
+	assert.Equal(t, expected, exists, name)
+}
+
+func metaSize(t *testing.T, bkt objstore.Bucket, id ulid.ULID) int {
+	attrs, err := bkt.Attributes(context.Background(), path.Join("user-1", id.String(), block.MetaFilename))
+	require.NoError(t, err)
+	return int(attrs.Size)
+}
+
+type errorBucket struct {
+	objstore.Bucket
+	failing string
+}
+
+func (b *errorBucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	if name == b.failing {
+		return objstore.ObjectAttributes{}, errors.New("failed to read")
+	}
+	return b.Bucket.Attributes(ctx, name)
+}
@@ -30,7 +30,7 @@ func NewNoopFlushTransferer() *NoopFlushTransferer {
 // Flush is a noop
 func (t *NoopFlushTransferer) Flush() {}
 
-// TransferOut is a noop
+// TransferOut is a noop, returning ErrTransferDisabled
 func (t *NoopFlushTransferer) TransferOut(ctx context.Context) error {
-	return nil
+	return ErrTransferDisabled
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
@@ -22,6 +23,8 @@ type MultiConfig struct {
 	MirrorEnabled bool          `yaml:"mirror_enabled"`
 	MirrorTimeout time.Duration `yaml:"mirror_timeout"`
 
+	CompareInterval time.Duration `yaml:"compare_interval"`
+
 	// ConfigProvider returns channel with MultiRuntimeConfig updates.
 	ConfigProvider func() <-chan MultiRuntimeConfig `yaml:"-"`
 }
@@ -32,6 +35,7 @@ func (cfg *MultiConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string)
 	f.StringVar(&cfg.Secondary, prefix+"multi.secondary", "", "Secondary backend storage used by multi-client.")
 	f.BoolVar(&cfg.MirrorEnabled, prefix+"multi.mirror-enabled", false, "Mirror writes to secondary store.")
 	f.DurationVar(&cfg.MirrorTimeout, prefix+"multi.mirror-timeout", 2*time.Second, "Timeout for storing value to secondary store.")
+	f.DurationVar(&cfg.CompareInterval, prefix+"multi.compare-interval", 0, "How often to compare the values of the keys mirrored to the secondary store with the primary store, to check that the stores are in sync before switching the primary store. 0 to disable.")
 }
 
 // MultiRuntimeConfig has values that can change in runtime (via overrides)
@@ -42,6 +46,28 @@ type MultiRuntimeConfig struct {
 
 	// Mirroring enabled or not. Nil = no change.
 	Mirroring *bool `yaml:"mirror_enabled"`
+
+	// Rings overrides the values above for the multi-clients of the ring with the given name (eg. ingester),
+	// so that the rings can be migrated to a different store one at a time.
+	Rings map[string]MultiRuntimeConfig `yaml:"rings,omitempty"`
+}
+
+// ForRing returns the runtime config of the multi-clients of the ring with the given name: the
+// values overridden for the ring take precedence over the ones of all the rings.
+func (c MultiRuntimeConfig) ForRing(name string) MultiRuntimeConfig {
+	res := MultiRuntimeConfig{PrimaryStore: c.PrimaryStore, Mirroring: c.Mirroring}
+
+	ringCfg, ok := c.Rings[name]
+	if !ok {
+		return res
+	}
+	if ringCfg.PrimaryStore != "" {
+		res.PrimaryStore = ringCfg.PrimaryStore
+	}
+	if ringCfg.Mirroring != nil {
+		res.Mirroring = ringCfg.Mirroring
+	}
+	return res
 }
 
 type kvclient struct {
@@ -78,19 +104,26 @@ type MultiClient struct {
 	inProgress    map[int]clientInProgress
 	inProgressCnt int
 
+	// Keys written to the secondary stores, compared with the primary store.
+	mirroredKeysMu sync.Mutex
+	mirroredKeys   map[string]struct{}
+
 	primaryStoreGauge     *prometheus.GaugeVec
 	mirrorEnabledGauge    prometheus.Gauge
 	mirrorWritesCounter   prometheus.Counter
 	mirrorFailuresCounter prometheus.Counter
+	comparedKeysCounter   *prometheus.CounterVec
+	mismatchedKeysGauge   *prometheus.GaugeVec
 }
 
 // NewMultiClient creates new MultiClient with given KV Clients.
 // First client in the slice is the primary client.
 func NewMultiClient(cfg MultiConfig, clients []kvclient, logger log.Logger, registerer prometheus.Registerer) *MultiClient {
 	c := &MultiClient{
-		clients:    clients,
-		primaryID:  atomic.NewInt32(0),
-		inProgress: map[int]clientInProgress{},
+		clients:      clients,
+		primaryID:    atomic.NewInt32(0),
+		inProgress:   map[int]clientInProgress{},
+		mirroredKeys: map[string]struct{}{},
 
 		mirrorTimeout:    cfg.MirrorTimeout,
 		mirroringEnabled: atomic.NewBool(cfg.MirrorEnabled),
@@ -101,13 +134,16 @@ func NewMultiClient(cfg MultiConfig, clients []kvclient, logger log.Logger, regi
 	ctx, cancelFn := context.WithCancel(context.Background())
 	c.cancel = cancelFn
 
-	if cfg.ConfigProvider != nil {
-		go c.watchConfigChannel(ctx, cfg.ConfigProvider())
-	}
-
 	c.registerMetrics(registerer)
 	c.updatePrimaryStoreGauge()
 	c.updateMirrorEnabledGauge()
+
+	if cfg.ConfigProvider != nil {
+		go c.watchConfigChannel(ctx, cfg.ConfigProvider())
+	}
+	if cfg.CompareInterval > 0 {
+		go c.compareLoop(ctx, cfg.CompareInterval)
+	}
 	return c
 }
 
@@ -202,6 +238,16 @@ func (m *MultiClient) registerMetrics(registerer prometheus.Registerer) {
 		Name: "multikv_mirror_write_errors_total",
 		Help: "Number of failures to mirror-write to secondary store",
 	})
+
+	m.comparedKeysCounter = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Name: "multikv_compared_keys_total",
+		Help: "Number of mirrored keys compared between the primary and a secondary store, by result (match, mismatch, missing or error)",
+	}, []string{"store", "result"})
+
+	m.mismatchedKeysGauge = promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "multikv_mismatched_keys",
+		Help: "Number of mirrored keys whose value in a secondary store differs from the primary store, or is missing, as of the last comparison",
+	}, []string{"store"})
 }
 
 func (m *MultiClient) updatePrimaryStoreGauge() {
@@ -298,7 +344,13 @@ func (m *MultiClient) Get(ctx context.Context, key string) (interface{}, error)
 // Delete is a part of the kv.Client interface.
 func (m *MultiClient) Delete(ctx context.Context, key string) error {
 	_, kv := m.getPrimaryClient()
-	return kv.client.Delete(ctx, key)
+	err := kv.client.Delete(ctx, key)
+
+	if err == nil && m.mirroringEnabled.Load() {
+		m.deleteFromSecondary(ctx, kv, key)
+	}
+
+	return err
 }
 
 // CAS is a part of kv.Client interface.
@@ -341,6 +393,10 @@ func (m *MultiClient) LastUpdateTime(key string) time.Time {
 }
 
 func (m *MultiClient) writeToSecondary(ctx context.Context, primary kvclient, key string, newValue interface{}) {
+	m.mirroredKeysMu.Lock()
+	m.mirroredKeys[key] = struct{}{}
+	m.mirroredKeysMu.Unlock()
+
 	if m.mirrorTimeout > 0 {
 		var cfn context.CancelFunc
 		ctx, cfn = context.WithTimeout(ctx, m.mirrorTimeout)
@@ -367,3 +423,107 @@ func (m *MultiClient) writeToSecondary(ctx context.Context, primary kvclient, ke
 		}
 	}
 }
+
+func (m *MultiClient) deleteFromSecondary(ctx context.Context, primary kvclient, key string) {
+	m.mirroredKeysMu.Lock()
+	delete(m.mirroredKeys, key)
+	m.mirroredKeysMu.Unlock()
+
+	if m.mirrorTimeout > 0 {
+		var cfn context.CancelFunc
+		ctx, cfn = context.WithTimeout(ctx, m.mirrorTimeout)
+		defer cfn()
+	}
+
+	for _, kvc := range m.clients {
+		if kvc == primary {
+			continue
+		}
+
+		m.mirrorWritesCounter.Inc()
+		if err := kvc.client.Delete(ctx, key); err != nil {
+			m.mirrorFailuresCounter.Inc()
+			level.Warn(m.logger).Log("msg", "failed to delete value in secondary store", "key", key, "err", err, "primary", primary.name, "secondary", kvc.name)
+		} else {
+			level.Debug(m.logger).Log("msg", "deleted value in secondary store", "key", key, "primary", primary.name, "secondary", kvc.name)
+		}
+	}
+}
+
+func (m *MultiClient) compareLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.compareStores(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// compareStores compares the values of the mirrored keys in the secondary stores with the
+// primary store. The stores are in sync, and the primary store can be switched, once no key
+// is mismatched.
+func (m *MultiClient) compareStores(ctx context.Context) {
+	m.mirroredKeysMu.Lock()
+	keys := make([]string, 0, len(m.mirroredKeys))
+	for key := range m.mirroredKeys {
+		keys = append(keys, key)
+	}
+	m.mirroredKeysMu.Unlock()
+
+	_, primary := m.getPrimaryClient()
+	mismatched := make(map[string]int, len(m.clients))
+
+	for _, key := range keys {
+		expected, err := primary.client.Get(ctx, key)
+		if err != nil {
+			level.Warn(m.logger).Log("msg", "failed to get value from primary store for comparison", "key", key, "err", err, "primary", primary.name)
+			continue
+		}
+
+		for _, kvc := range m.clients {
+			if kvc == primary {
+				continue
+			}
+
+			result := "match"
+			actual, err := kvc.client.Get(ctx, key)
+			switch {
+			case err != nil:
+				result = "error"
+				level.Warn(m.logger).Log("msg", "failed to get value from secondary store for comparison", "key", key, "err", err, "secondary", kvc.name)
+			case actual == nil && expected != nil:
+				result = "missing"
+			case !equalValues(expected, actual):
+				result = "mismatch"
+			}
+
+			if result == "missing" || result == "mismatch" {
+				mismatched[kvc.name]++
+				level.Debug(m.logger).Log("msg", "value in secondary store differs from primary store", "key", key, "result", result, "primary", primary.name, "secondary", kvc.name)
+			}
+			m.comparedKeysCounter.WithLabelValues(kvc.name, result).Inc()
+		}
+	}
+
+	for _, kvc := range m.clients {
+		if kvc == primary {
+			// The primary store may have been switched since the previous comparison.
+			m.mismatchedKeysGauge.DeleteLabelValues(kvc.name)
+			continue
+		}
+		m.mismatchedKeysGauge.WithLabelValues(kvc.name).Set(float64(mismatched[kvc.name]))
+	}
+}
+
+// equalValues returns whether the values decoded by the codec of two stores are equal.
+func equalValues(a, b interface{}) bool {
+	if eq, ok := a.(interface{ Equal(that interface{}) bool }); ok {
+		return eq.Equal(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -1,10 +1,18 @@
 package kv
 
 import (
+	"context"
+	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
 )
 
 func boolPtr(b bool) *bool {
@@ -30,3 +38,81 @@ func TestMultiRuntimeConfigWithVariousEnabledValues(t *testing.T) {
 		})
 	}
 }
+
+func TestMultiRuntimeConfig_ForRing(t *testing.T) {
+	c := MultiRuntimeConfig{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+primary: consul
+mirror_enabled: true
+rings:
+  ingester:
+    primary: memberlist
+  distributor:
+    mirror_enabled: false
+`), &c))
+
+	assert.Equal(t, MultiRuntimeConfig{PrimaryStore: "memberlist", Mirroring: boolPtr(true)}, c.ForRing("ingester"))
+	assert.Equal(t, MultiRuntimeConfig{PrimaryStore: "consul", Mirroring: boolPtr(false)}, c.ForRing("distributor"))
+	assert.Equal(t, MultiRuntimeConfig{PrimaryStore: "consul", Mirroring: boolPtr(true)}, c.ForRing("ruler"))
+}
+
+func TestMultiClient_CompareStores(t *testing.T) {
+	primary, primaryCloser := consul.NewInMemoryClient(codec.String{}, testLogger{}, nil)
+	t.Cleanup(func() { assert.NoError(t, primaryCloser.Close()) })
+	secondary, secondaryCloser := consul.NewInMemoryClient(codec.String{}, testLogger{}, nil)
+	t.Cleanup(func() { assert.NoError(t, secondaryCloser.Close()) })
+
+	reg := prometheus.NewPedanticRegistry()
+	m := NewMultiClient(MultiConfig{MirrorEnabled: true}, []kvclient{
+		{client: primary, name: "primary"},
+		{client: secondary, name: "secondary"},
+	}, testLogger{}, reg)
+
+	ctx := context.Background()
+	set := func(c Client, key, value string) {
+		require.NoError(t, c.CAS(ctx, key, func(in interface{}) (out interface{}, retry bool, err error) {
+			return value, false, nil
+		}))
+	}
+
+	// The writes are mirrored to the secondary store.
+	set(m, "a", "1")
+	set(m, "b", "1")
+	m.compareStores(ctx)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP multikv_compared_keys_total Number of mirrored keys compared between the primary and a secondary store, by result (match, mismatch, missing or error)
+		# TYPE multikv_compared_keys_total counter
+		multikv_compared_keys_total{result="match",store="secondary"} 2
+		# HELP multikv_mismatched_keys Number of mirrored keys whose value in a secondary store differs from the primary store, or is missing, as of the last comparison
+		# TYPE multikv_mismatched_keys gauge
+		multikv_mismatched_keys{store="secondary"} 0
+	`), "multikv_compared_keys_total", "multikv_mismatched_keys"))
+
+	// The stores diverge.
+	set(secondary, "a", "2")
+	require.NoError(t, secondary.Delete(ctx, "b"))
+	m.compareStores(ctx)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP multikv_compared_keys_total Number of mirrored keys compared between the primary and a secondary store, by result (match, mismatch, missing or error)
+		# TYPE multikv_compared_keys_total counter
+		multikv_compared_keys_total{result="match",store="secondary"} 2
+		multikv_compared_keys_total{result="mismatch",store="secondary"} 1
+		multikv_compared_keys_total{result="missing",store="secondary"} 1
+		# HELP multikv_mismatched_keys Number of mirrored keys whose value in a secondary store differs from the primary store, or is missing, as of the last comparison
+		# TYPE multikv_mismatched_keys gauge
+		multikv_mismatched_keys{store="secondary"} 2
+	`), "multikv_compared_keys_total", "multikv_mismatched_keys"))
+
+	// The deletes are mirrored too, and the deleted keys aren't compared anymore.
+	set(m, "a", "3")
+	require.NoError(t, m.Delete(ctx, "b"))
+	v, err := secondary.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "3", v)
+	m.compareStores(ctx)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP multikv_mismatched_keys Number of mirrored keys whose value in a secondary store differs from the primary store, or is missing, as of the last comparison
+		# TYPE multikv_mismatched_keys gauge
+		multikv_mismatched_keys{store="secondary"} 0
+	`), "multikv_mismatched_keys"))
+}
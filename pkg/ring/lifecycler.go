@@ -452,11 +452,13 @@ func (i *Lifecycler) loop(ctx context.Context) error {
 			if joined {
 				continue
 			}
-			joined = true
 			level.Debug(i.logger).Log("msg", "JoinAfter expired", "ring", i.RingName)
 			// Will only fire once, after auto join timeout.  If we haven't entered "JOINING" state,
-			// then pick some tokens and enter ACTIVE state.
+			// then pick some tokens and enter ACTIVE state. If we're JOINING because of an incoming
+			// transfer, we don't mark the instance as joined, so that Join() can be called again
+			// if the transfer fails.
 			if i.GetState() == PENDING {
+				joined = true
 				level.Info(i.logger).Log("msg", "auto-joining cluster after timeout", "ring", i.RingName)
 
 				if i.cfg.ObservePeriod > 0 {
@@ -828,7 +830,10 @@ func (i *Lifecycler) changeState(ctx context.Context, state InstanceState) error
 		(currState == JOINING && state == PENDING) || // triggered by TransferChunks on failure
 		(currState == JOINING && state == ACTIVE) || // triggered by TransferChunks on success
 		(currState == PENDING && state == ACTIVE) || // triggered by autoJoin
-		(currState == ACTIVE && state == LEAVING)) { // triggered by shutdown
+		(currState == ACTIVE && state == LEAVING) || // triggered by shutdown
+		(currState == ACTIVE && state == READONLY) || // triggered by the preparation of a scale-down
+		(currState == READONLY && state == ACTIVE) || // triggered by the cancellation of a scale-down
+		(currState == READONLY && state == LEAVING)) { // triggered by shutdown
 		return fmt.Errorf("Changing instance state from %v -> %v is disallowed", currState, state)
 	}
 
@@ -885,6 +890,19 @@ func (i *Lifecycler) SetUnregisterOnShutdown(enabled bool) {
 func (i *Lifecycler) processShutdown(ctx context.Context) {
 	flushRequired := i.flushOnShutdown.Load()
 
+	transferStart := time.Now()
+	if err := i.flushTransferer.TransferOut(ctx); err != nil {
+		// Most instances don't support transfers, so there's nothing to log when they're disabled.
+		if err != ErrTransferDisabled {
+			level.Error(i.logger).Log("msg", "failed to transfer data to another instance", "ring", i.RingName, "err", err)
+			i.lifecyclerMetrics.shutdownDuration.WithLabelValues("transfer", "fail").Observe(time.Since(transferStart).Seconds())
+		}
+	} else {
+		// The data is now owned by the instance it has been transferred to.
+		flushRequired = false
+		i.lifecyclerMetrics.shutdownDuration.WithLabelValues("transfer", "success").Observe(time.Since(transferStart).Seconds())
+	}
+
 	if flushRequired {
 		flushStart := time.Now()
 		i.flushTransferer.Flush()
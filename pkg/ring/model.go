@@ -98,7 +98,7 @@ func (d *Desc) FindIngestersByState(state InstanceState) []InstanceDesc {
 	return result
 }
 
-// IsReady returns no error when all instance are ACTIVE and healthy,
+// IsReady returns no error when all instance are ACTIVE (or READONLY) and healthy,
 // and the ring has some tokens.
 func (d *Desc) IsReady(storageLastUpdated time.Time, heartbeatTimeout time.Duration) error {
 	numTokens := 0
@@ -148,12 +148,12 @@ func (i *InstanceDesc) IsHeartbeatHealthy(heartbeatTimeout time.Duration, storag
 	return storageLastUpdated.Sub(time.Unix(i.Timestamp, 0)) <= heartbeatTimeout
 }
 
-// IsReady returns no error if the instance is ACTIVE and healthy.
+// IsReady returns no error if the instance is ACTIVE (or READONLY) and healthy.
 func (i *InstanceDesc) IsReady(storageLastUpdated time.Time, heartbeatTimeout time.Duration) error {
 	if !i.IsHeartbeatHealthy(heartbeatTimeout, storageLastUpdated) {
 		return fmt.Errorf("instance %s past heartbeat timeout", i.Addr)
 	}
-	if i.State != ACTIVE {
+	if i.State != ACTIVE && i.State != READONLY {
 		return fmt.Errorf("instance %s in state %v", i.Addr, i.State)
 	}
 	return nil
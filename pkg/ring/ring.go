@@ -103,9 +103,11 @@ var (
 	WriteNoExtend = NewOp([]InstanceState{ACTIVE}, nil)
 
 	// Read operation that extends the replica set if an instance is not ACTIVE, LEAVING OR JOINING
-	Read = NewOp([]InstanceState{ACTIVE, PENDING, LEAVING, JOINING}, func(s InstanceState) bool {
+	Read = NewOp([]InstanceState{ACTIVE, PENDING, LEAVING, JOINING, READONLY}, func(s InstanceState) bool {
 		// To match Write with extended replica set we have to also increase the
 		// size of the replica set for Read, but we can read from LEAVING ingesters.
+		// READONLY ingesters are read from, but the replica set is still extended
+		// to include the ingester which received their writes.
 		return s != ACTIVE && s != LEAVING && s != JOINING
 	})
 
@@ -607,7 +609,7 @@ func (r *Ring) updateRingMetrics(compareResult CompareResult) {
 	oldestTimestampByState := map[string]int64{}
 
 	// Initialized to zero so we emit zero-metrics (instead of not emitting anything)
-	for _, s := range []string{unhealthy, ACTIVE.String(), LEAVING.String(), PENDING.String(), JOINING.String()} {
+	for _, s := range []string{unhealthy, ACTIVE.String(), LEAVING.String(), PENDING.String(), JOINING.String(), READONLY.String()} {
 		numByState[s] = 0
 		oldestTimestampByState[s] = 0
 	}
@@ -930,7 +932,7 @@ func NewOp(healthyStates []InstanceState, shouldExtendReplicaSet func(s Instance
 	}
 
 	if shouldExtendReplicaSet != nil {
-		for _, s := range []InstanceState{ACTIVE, LEAVING, PENDING, JOINING, LEAVING, LEFT} {
+		for _, s := range []InstanceState{ACTIVE, LEAVING, PENDING, JOINING, LEAVING, LEFT, READONLY} {
 			if shouldExtendReplicaSet(s) {
 				op |= (0x10000 << s)
 			}
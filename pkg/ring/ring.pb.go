@@ -37,6 +37,9 @@ const (
 	// This state is only used by gossiping code to distribute information about
 	// instances that have been removed from the ring. Ring users should not use it directly.
 	LEFT InstanceState = 4
+	// The instance doesn't receive writes anymore, but still serves reads. It's used
+	// to safely remove an ingester from the ring, see the ingesters scale-down API.
+	READONLY InstanceState = 5
 )
 
 var InstanceState_name = map[int32]string{
@@ -45,14 +48,16 @@ var InstanceState_name = map[int32]string{
 	2: "PENDING",
 	3: "JOINING",
 	4: "LEFT",
+	5: "READONLY",
 }
 
 var InstanceState_value = map[string]int32{
-	"ACTIVE":  0,
-	"LEAVING": 1,
-	"PENDING": 2,
-	"JOINING": 3,
-	"LEFT":    4,
+	"ACTIVE":   0,
+	"LEAVING":  1,
+	"PENDING":  2,
+	"JOINING":  3,
+	"LEFT":     4,
+	"READONLY": 5,
 }
 
 func (InstanceState) EnumDescriptor() ([]byte, []int) {
@@ -210,33 +215,34 @@ func init() {
 func init() { proto.RegisterFile("ring.proto", fileDescriptor_26381ed67e202a6e) }
 
 var fileDescriptor_26381ed67e202a6e = []byte{
-	// 409 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x92, 0xc1, 0x8a, 0xd3, 0x50,
-	0x18, 0x85, 0xef, 0x9f, 0xdc, 0x64, 0xd2, 0xbf, 0xce, 0x10, 0xee, 0x0c, 0x12, 0x07, 0xb9, 0x86,
-	0x59, 0x45, 0x17, 0x15, 0xab, 0x0b, 0x11, 0x5c, 0xcc, 0x38, 0x51, 0x12, 0x4a, 0x1d, 0x62, 0x99,
-	0xad, 0xc4, 0xe9, 0x25, 0x84, 0xb1, 0x49, 0x49, 0xae, 0x42, 0x5d, 0xf9, 0x08, 0xbe, 0x80, 0x7b,
-	0x1f, 0xa5, 0xcb, 0xae, 0xa4, 0x2b, 0xb1, 0xe9, 0xc6, 0x65, 0x1f, 0x41, 0x6e, 0xd2, 0x92, 0xe9,
-	0xee, 0x9c, 0xff, 0x9c, 0x9c, 0x2f, 0x81, 0x20, 0x16, 0x69, 0x96, 0xf4, 0xa6, 0x45, 0x2e, 0x73,
-	0x46, 0x95, 0x3e, 0x3d, 0x49, 0xf2, 0x24, 0xaf, 0x0f, 0x4f, 0x95, 0x6a, 0xb2, 0xb3, 0x9f, 0x80,
-	0xf4, 0x52, 0x94, 0x37, 0xec, 0x35, 0x76, 0xd2, 0x2c, 0x11, 0xa5, 0x14, 0x45, 0xe9, 0x80, 0xab,
-	0x7b, 0xdd, 0xfe, 0x83, 0x5e, 0x3d, 0xa2, 0xe2, 0x5e, 0xb0, 0xcb, 0xfc, 0x4c, 0x16, 0xb3, 0x0b,
-	0x3a, 0xff, 0xf3, 0x88, 0x44, 0xed, 0x13, 0xa7, 0x57, 0x78, 0xb4, 0x5f, 0x61, 0x36, 0xea, 0xb7,
-	0x62, 0xe6, 0x80, 0x0b, 0x5e, 0x27, 0x52, 0x92, 0x79, 0x68, 0x7c, 0x8d, 0x3f, 0x7f, 0x11, 0x8e,
-	0xe6, 0x82, 0xd7, 0xed, 0xb3, 0x66, 0x3e, 0xc8, 0x4a, 0x19, 0x67, 0x37, 0x42, 0x61, 0xa2, 0xa6,
-	0xf0, 0x4a, 0x7b, 0x09, 0x21, 0xb5, 0x34, 0x5b, 0x3f, 0xfb, 0x0d, 0x78, 0xef, 0x6e, 0x83, 0x31,
-	0xa4, 0xf1, 0x78, 0x5c, 0x6c, 0x77, 0x6b, 0xcd, 0x1e, 0x62, 0x47, 0xa6, 0x13, 0x51, 0xca, 0x78,
-	0x32, 0xad, 0xc7, 0xf5, 0xa8, 0x3d, 0xb0, 0xc7, 0x68, 0x94, 0x32, 0x96, 0xc2, 0xd1, 0x5d, 0xf0,
-	0x8e, 0xfa, 0xc7, 0xfb, 0xd8, 0x0f, 0x2a, 0x8a, 0x9a, 0x06, 0xbb, 0x8f, 0xa6, 0xcc, 0x6f, 0x45,
-	0x56, 0x3a, 0xa6, 0xab, 0x7b, 0x87, 0xd1, 0xd6, 0x29, 0xe8, 0xb7, 0x3c, 0x13, 0xce, 0x41, 0x03,
-	0x55, 0x9a, 0x3d, 0xc3, 0x93, 0x42, 0x24, 0xa9, 0xfa, 0x62, 0x31, 0xfe, 0xd8, 0xf2, 0xad, 0x9a,
-	0x7f, 0xdc, 0x66, 0xa3, 0x5d, 0x14, 0x52, 0x8b, 0xda, 0x46, 0x48, 0x2d, 0xc3, 0x36, 0x9f, 0x0c,
-	0xf0, 0x70, 0xef, 0x15, 0x18, 0xa2, 0x79, 0xfe, 0x66, 0x14, 0x5c, 0xfb, 0x36, 0x61, 0x5d, 0x3c,
-	0x18, 0xf8, 0xe7, 0xd7, 0xc1, 0xf0, 0x9d, 0x0d, 0xca, 0x5c, 0xf9, 0xc3, 0x4b, 0x65, 0x34, 0x65,
-	0xc2, 0xf7, 0xc1, 0x50, 0x19, 0x9d, 0x59, 0x48, 0x07, 0xfe, 0xdb, 0x91, 0x4d, 0x2f, 0x5e, 0x2c,
-	0x56, 0x9c, 0x2c, 0x57, 0x9c, 0x6c, 0x56, 0x1c, 0xbe, 0x57, 0x1c, 0x7e, 0x55, 0x1c, 0xe6, 0x15,
-	0x87, 0x45, 0xc5, 0xe1, 0x6f, 0xc5, 0xe1, 0x5f, 0xc5, 0xc9, 0xa6, 0xe2, 0xf0, 0x63, 0xcd, 0xc9,
-	0x62, 0xcd, 0xc9, 0x72, 0xcd, 0xc9, 0x27, 0xb3, 0xfe, 0x07, 0x9e, 0xff, 0x0f, 0x00, 0x00, 0xff,
-	0xff, 0xd3, 0x1c, 0x09, 0x3a, 0x2d, 0x02, 0x00, 0x00,
+	// 419 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x92, 0x41, 0x6b, 0xd4, 0x40,
+	0x1c, 0xc5, 0xe7, 0xbf, 0x99, 0xa4, 0xd9, 0xff, 0xb6, 0x65, 0x98, 0x16, 0x89, 0x45, 0xc6, 0xd0,
+	0x53, 0xf4, 0xb0, 0xe2, 0xea, 0x41, 0x04, 0x0f, 0x5b, 0x37, 0x4a, 0xc2, 0x92, 0x96, 0xb8, 0x14,
+	0xf4, 0x22, 0xb1, 0x3b, 0x84, 0x50, 0x9b, 0x94, 0x64, 0x14, 0xea, 0xc9, 0x8f, 0xe0, 0x17, 0xf0,
+	0xee, 0x47, 0xe9, 0x71, 0x4f, 0xd2, 0x93, 0xb8, 0xd9, 0x8b, 0xc7, 0x7e, 0x04, 0x99, 0xa4, 0x25,
+	0xdd, 0xdb, 0x7b, 0xff, 0xf7, 0xf2, 0x7e, 0x09, 0x04, 0xb1, 0xcc, 0xf2, 0x74, 0x78, 0x5e, 0x16,
+	0xaa, 0xe0, 0x54, 0xeb, 0xbd, 0xdd, 0xb4, 0x48, 0x8b, 0xe6, 0xf0, 0x44, 0xab, 0x36, 0xdb, 0xff,
+	0x09, 0x48, 0x27, 0xb2, 0x3a, 0xe1, 0xaf, 0xb0, 0x9f, 0xe5, 0xa9, 0xac, 0x94, 0x2c, 0x2b, 0x07,
+	0x5c, 0xc3, 0x1b, 0x8c, 0xee, 0x0f, 0x9b, 0x11, 0x1d, 0x0f, 0x83, 0xdb, 0xcc, 0xcf, 0x55, 0x79,
+	0x71, 0x40, 0x2f, 0xff, 0x3c, 0x24, 0x71, 0xf7, 0xc4, 0xde, 0x11, 0x6e, 0xaf, 0x57, 0x38, 0x43,
+	0xe3, 0x54, 0x5e, 0x38, 0xe0, 0x82, 0xd7, 0x8f, 0xb5, 0xe4, 0x1e, 0x9a, 0x5f, 0x93, 0xcf, 0x5f,
+	0xa4, 0xd3, 0x73, 0xc1, 0x1b, 0x8c, 0x78, 0x3b, 0x1f, 0xe4, 0x95, 0x4a, 0xf2, 0x13, 0xa9, 0x31,
+	0x71, 0x5b, 0x78, 0xd9, 0x7b, 0x01, 0x21, 0xb5, 0x7b, 0xcc, 0xd8, 0xff, 0x0d, 0xb8, 0x79, 0xb7,
+	0xc1, 0x39, 0xd2, 0x64, 0x3e, 0x2f, 0x6f, 0x76, 0x1b, 0xcd, 0x1f, 0x60, 0x5f, 0x65, 0x67, 0xb2,
+	0x52, 0xc9, 0xd9, 0x79, 0x33, 0x6e, 0xc4, 0xdd, 0x81, 0x3f, 0x42, 0xb3, 0x52, 0x89, 0x92, 0x8e,
+	0xe1, 0x82, 0xb7, 0x3d, 0xda, 0x59, 0xc7, 0xbe, 0xd3, 0x51, 0xdc, 0x36, 0xf8, 0x3d, 0xb4, 0x54,
+	0x71, 0x2a, 0xf3, 0xca, 0xb1, 0x5c, 0xc3, 0xdb, 0x8a, 0x6f, 0x9c, 0x86, 0x7e, 0x2b, 0x72, 0xe9,
+	0x6c, 0xb4, 0x50, 0xad, 0xf9, 0x53, 0xdc, 0x2d, 0x65, 0x9a, 0xe9, 0x2f, 0x96, 0xf3, 0x8f, 0x1d,
+	0xdf, 0x6e, 0xf8, 0x3b, 0x5d, 0x36, 0xbb, 0x8d, 0x42, 0x6a, 0x53, 0x66, 0x86, 0xd4, 0x36, 0x99,
+	0xf5, 0xf8, 0x03, 0x6e, 0xad, 0xbd, 0x02, 0x47, 0xb4, 0xc6, 0xaf, 0x67, 0xc1, 0xb1, 0xcf, 0x08,
+	0x1f, 0xe0, 0xc6, 0xd4, 0x1f, 0x1f, 0x07, 0xd1, 0x5b, 0x06, 0xda, 0x1c, 0xf9, 0xd1, 0x44, 0x9b,
+	0x9e, 0x36, 0xe1, 0x61, 0x10, 0x69, 0x63, 0x70, 0x1b, 0xe9, 0xd4, 0x7f, 0x33, 0x63, 0x94, 0x6f,
+	0xa2, 0x1d, 0xfb, 0xe3, 0xc9, 0x61, 0x34, 0x7d, 0xcf, 0xcc, 0x83, 0xe7, 0x8b, 0xa5, 0x20, 0x57,
+	0x4b, 0x41, 0xae, 0x97, 0x02, 0xbe, 0xd7, 0x02, 0x7e, 0xd5, 0x02, 0x2e, 0x6b, 0x01, 0x8b, 0x5a,
+	0xc0, 0xdf, 0x5a, 0xc0, 0xbf, 0x5a, 0x90, 0xeb, 0x5a, 0xc0, 0x8f, 0x95, 0x20, 0x8b, 0x95, 0x20,
+	0x57, 0x2b, 0x41, 0x3e, 0x59, 0xcd, 0x1f, 0xf1, 0xec, 0xff, 0x00, 0x75, 0x1d, 0x75, 0xff, 0x3b,
+	0x02, 0x00, 0x00,
 }
 
 func (x InstanceState) String() string {
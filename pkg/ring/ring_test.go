@@ -2979,6 +2979,7 @@ func TestUpdateMetrics(t *testing.T) {
 		ring_members{name="test",state="JOINING"} 0
 		ring_members{name="test",state="LEAVING"} 0
 		ring_members{name="test",state="PENDING"} 0
+		ring_members{name="test",state="READONLY"} 0
 		ring_members{name="test",state="Unhealthy"} 0
 		# HELP ring_oldest_member_timestamp Timestamp of the oldest member in the ring.
 		# TYPE ring_oldest_member_timestamp gauge
@@ -2986,6 +2987,7 @@ func TestUpdateMetrics(t *testing.T) {
 		ring_oldest_member_timestamp{name="test",state="JOINING"} 0
 		ring_oldest_member_timestamp{name="test",state="LEAVING"} 0
 		ring_oldest_member_timestamp{name="test",state="PENDING"} 0
+		ring_oldest_member_timestamp{name="test",state="READONLY"} 0
 		ring_oldest_member_timestamp{name="test",state="Unhealthy"} 0
 		# HELP ring_tokens_owned The number of tokens in the ring owned by the member
 		# TYPE ring_tokens_owned gauge
@@ -3031,6 +3033,7 @@ func TestUpdateMetricsWithRemoval(t *testing.T) {
 		ring_members{name="test",state="JOINING"} 0
 		ring_members{name="test",state="LEAVING"} 0
 		ring_members{name="test",state="PENDING"} 0
+		ring_members{name="test",state="READONLY"} 0
 		ring_members{name="test",state="Unhealthy"} 0
 		# HELP ring_oldest_member_timestamp Timestamp of the oldest member in the ring.
 		# TYPE ring_oldest_member_timestamp gauge
@@ -3038,6 +3041,7 @@ func TestUpdateMetricsWithRemoval(t *testing.T) {
 		ring_oldest_member_timestamp{name="test",state="JOINING"} 0
 		ring_oldest_member_timestamp{name="test",state="LEAVING"} 0
 		ring_oldest_member_timestamp{name="test",state="PENDING"} 0
+		ring_oldest_member_timestamp{name="test",state="READONLY"} 0
 		ring_oldest_member_timestamp{name="test",state="Unhealthy"} 0
 		# HELP ring_tokens_owned The number of tokens in the ring owned by the member
 		# TYPE ring_tokens_owned gauge
@@ -3066,6 +3070,7 @@ func TestUpdateMetricsWithRemoval(t *testing.T) {
 		ring_members{name="test",state="JOINING"} 0
 		ring_members{name="test",state="LEAVING"} 0
 		ring_members{name="test",state="PENDING"} 0
+		ring_members{name="test",state="READONLY"} 0
 		ring_members{name="test",state="Unhealthy"} 0
 		# HELP ring_oldest_member_timestamp Timestamp of the oldest member in the ring.
 		# TYPE ring_oldest_member_timestamp gauge
@@ -3073,6 +3078,7 @@ func TestUpdateMetricsWithRemoval(t *testing.T) {
 		ring_oldest_member_timestamp{name="test",state="JOINING"} 0
 		ring_oldest_member_timestamp{name="test",state="LEAVING"} 0
 		ring_oldest_member_timestamp{name="test",state="PENDING"} 0
+		ring_oldest_member_timestamp{name="test",state="READONLY"} 0
 		ring_oldest_member_timestamp{name="test",state="Unhealthy"} 0
 		# HELP ring_tokens_owned The number of tokens in the ring owned by the member
 		# TYPE ring_tokens_owned gauge
@@ -335,7 +335,37 @@ var (
 	ErrBadRuleGroup = errors.New("unable to decoded rule group")
 )
 
-func marshalAndSend(output interface{}, w http.ResponseWriter, logger log.Logger) {
+const (
+	// Export formats of the rule groups.
+	formatYAML = "yaml"
+	formatJSON = "json"
+)
+
+// exportFormat returns the format the rule groups are exported in: the one of the format
+// parameter if any, else JSON if the client only accepts it, else YAML.
+func exportFormat(req *http.Request) (string, error) {
+	switch format := req.URL.Query().Get("format"); format {
+	case formatYAML, formatJSON:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported format %q, supported formats are %q and %q", format, formatYAML, formatJSON)
+	}
+
+	accept := req.Header.Get("Accept")
+	if strings.Contains(accept, "application/json") && !strings.Contains(accept, "yaml") {
+		return formatJSON, nil
+	}
+	return formatYAML, nil
+}
+
+func marshalAndSend(output interface{}, w http.ResponseWriter, req *http.Request, logger log.Logger) {
+	format, err := exportFormat(req)
+	if err != nil {
+		respondBadRequest(logger, w, err.Error())
+		return
+	}
+
 	d, err := yaml.Marshal(&output)
 	if err != nil {
 		level.Error(logger).Log("msg", "error marshalling yaml rule groups", "err", err)
@@ -343,9 +373,25 @@ func marshalAndSend(output interface{}, w http.ResponseWriter, logger log.Logger
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/yaml")
+	contentType := "application/yaml"
+	if format == formatJSON {
+		// The rule groups are only described by their YAML tags, so they're converted from
+		// their YAML representation to keep the same field names and values.
+		var v interface{}
+		if err = yaml.Unmarshal(d, &v); err == nil {
+			d, err = json.Marshal(v)
+		}
+		if err != nil {
+			level.Error(logger).Log("msg", "error marshalling json rule groups", "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		contentType = "application/json"
+	}
+
+	w.Header().Set("Content-Type", contentType)
 	if _, err := w.Write(d); err != nil {
-		level.Error(logger).Log("msg", "error writing yaml response", "err", err)
+		level.Error(logger).Log("msg", "error writing rule groups response", "err", err)
 		return
 	}
 }
@@ -429,6 +475,17 @@ func parseRequest(req *http.Request, requireNamespace, requireGroup bool) (strin
 }
 
 func (a *API) ListRules(w http.ResponseWriter, req *http.Request) {
+	a.listRules(w, req, false)
+}
+
+// ListRulesConfig lists the rule groups like ListRules, for the rules config API used by the
+// Grafana unified alerting, which expects an empty list rather than a not found error when
+// there are no rule groups.
+func (a *API) ListRulesConfig(w http.ResponseWriter, req *http.Request) {
+	a.listRules(w, req, true)
+}
+
+func (a *API) listRules(w http.ResponseWriter, req *http.Request, allowEmpty bool) {
 	logger := util_log.WithContext(req.Context(), a.logger)
 
 	userID, namespace, _, err := parseRequest(req, false, false)
@@ -444,6 +501,11 @@ func (a *API) ListRules(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if len(rgs) == 0 && allowEmpty {
+		marshalAndSend(map[string][]rulefmt.RuleGroup{}, w, req, logger)
+		return
+	}
+
 	if len(rgs) == 0 {
 		level.Info(logger).Log("msg", "no rule groups found", "userID", userID)
 		http.Error(w, ErrNoRuleGroups.Error(), http.StatusNotFound)
@@ -459,7 +521,7 @@ func (a *API) ListRules(w http.ResponseWriter, req *http.Request) {
 	level.Debug(logger).Log("msg", "retrieved rule groups from rule store", "userID", userID, "num_namespaces", len(rgs))
 
 	formatted := rgs.Formatted()
-	marshalAndSend(formatted, w, logger)
+	marshalAndSend(formatted, w, req, logger)
 }
 
 func (a *API) GetRuleGroup(w http.ResponseWriter, req *http.Request) {
@@ -480,8 +542,21 @@ func (a *API) GetRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	formatted := rulespb.FromProto(rg)
-	marshalAndSend(formatted, w, logger)
+	formatted := ruleGroupWithOptions{
+		RuleGroup: rulespb.FromProto(rg),
+		RuleGroupOptions: rulespb.RuleGroupOptions{
+			Downsampling: rulespb.DownsamplingFromProto(rg.Downsampling),
+			QueryLimits:  rulespb.QueryLimitsFromProto(rg.QueryLimits),
+		},
+	}
+	marshalAndSend(formatted, w, req, logger)
+}
+
+// ruleGroupWithOptions is the YAML representation of a rule group, including the Cortex
+// specific options.
+type ruleGroupWithOptions struct {
+	rulefmt.RuleGroup        `yaml:",inline"`
+	rulespb.RuleGroupOptions `yaml:",inline"`
 }
 
 func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
@@ -509,7 +584,22 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	opts := rulespb.RuleGroupOptions{}
+	err = yaml.Unmarshal(payload, &opts)
+	if err != nil {
+		level.Error(logger).Log("msg", "unable to unmarshal rule group options", "err", err.Error())
+		http.Error(w, ErrBadRuleGroup.Error(), http.StatusBadRequest)
+		return
+	}
+
 	errs := a.ruler.manager.ValidateRuleGroup(rg)
+	if len(errs) == 0 && opts.Downsampling != nil {
+		applyDownsamplingDefaults(opts.Downsampling)
+		errs = validateRuleGroupDownsampling(rg, opts.Downsampling)
+	}
+	if len(errs) == 0 && opts.QueryLimits != nil {
+		errs = validateRuleGroupQueryLimits(rg, opts.QueryLimits)
+	}
 	if len(errs) > 0 {
 		e := []string{}
 		for _, err := range errs {
@@ -543,6 +633,8 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 	}
 
 	rgProto := rulespb.ToProto(userID, namespace, rg)
+	rgProto.Downsampling = rulespb.DownsamplingToProto(opts.Downsampling)
+	rgProto.QueryLimits = rulespb.QueryLimitsToProto(opts.QueryLimits)
 	loadedRg := rulespb.FromProto(rgProto)
 	rgYaml, err := yaml.Marshal(loadedRg)
 	if err == nil {
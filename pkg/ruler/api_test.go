@@ -280,6 +280,74 @@ rules:
 `,
 			output: "name: test\ninterval: 15s\nrules:\n    - record: up_rule\n      expr: up{}\n    - alert: up_alert\n      expr: sum(up{}) > 1\n      for: 30s\n      labels:\n        test: test\n      annotations:\n        test: test\n",
 		},
+		{
+			name:   "with downsampling options",
+			status: 202,
+			input: `
+name: test
+interval: 5m
+downsampling:
+  max_resolution: 1h
+rules:
+- record: slo:error_ratio:rate30d
+  expr: sum(rate(errors_total[30d])) / sum(rate(requests_total[30d]))
+`,
+			output: "name: test\ninterval: 5m\nrules:\n    - record: slo:error_ratio:rate30d\n      expr: sum(rate(errors_total[30d])) / sum(rate(requests_total[30d]))\ndownsampling:\n    max_resolution: 1h\n    min_step: 5h\n",
+		},
+		{
+			name: "with downsampling options and a rule requiring raw precision",
+			input: `
+name: test
+downsampling:
+  max_resolution: 5m
+rules:
+- alert: up_alert
+  expr: up == 0
+`,
+			status: 400,
+			err:    errors.New(`invalid rules config: rule group 'test', rule 0, "up_alert": instant vector selector up requires raw precision and can't be evaluated against downsampled data`),
+		},
+		{
+			name: "with an unsupported downsampling resolution",
+			input: `
+name: test
+downsampling:
+  max_resolution: 1m
+rules:
+- record: up_rule
+  expr: max_over_time(up[1d])
+`,
+			status: 400,
+			err:    errors.New("invalid rules config: rule group 'test' has unsupported downsampling max resolution 1m"),
+		},
+		{
+			name:   "with query limits options",
+			status: 202,
+			input: `
+name: test
+query_limits:
+  max_samples: 100000000
+  timeout: 10m
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			output: "name: test\nrules:\n    - record: up_rule\n      expr: up{}\nquery_limits:\n    max_samples: 100000000\n    timeout: 10m\n",
+		},
+		{
+			name: "with negative query limits",
+			input: `
+name: test
+query_limits:
+  max_samples: -1
+  max_fetched_chunks: -1
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			status: 400,
+			err:    errors.New("invalid rules config: rule group 'test' has negative query limits max samples -1, invalid rules config: rule group 'test' has negative query limits max fetched chunks -1"),
+		},
 	}
 
 	for _, tt := range tc {
@@ -347,6 +415,87 @@ func TestRuler_DeleteNamespace(t *testing.T) {
 	require.Equal(t, "{\"status\":\"error\",\"data\":null,\"errorType\":\"server_error\",\"error\":\"unable to delete rg\"}", w.Body.String())
 }
 
+func TestRuler_RulesConfigAPI(t *testing.T) {
+	store := newMockRuleStore(mockRules, nil)
+	cfg := defaultRulerConfig(t)
+
+	r := newTestRuler(t, cfg, store, nil)
+	defer services.StopAndAwaitTerminated(context.Background(), r) //nolint:errcheck
+
+	a := NewAPI(r, r.store, log.NewNopLogger())
+
+	router := mux.NewRouter()
+	router.Path("/api/v1/rules").Methods(http.MethodGet).HandlerFunc(a.ListRules)
+	router.Path("/prometheus/config/v1/rules").Methods(http.MethodGet).HandlerFunc(a.ListRulesConfig)
+	router.Path("/prometheus/config/v1/rules/{namespace}/{groupName}").Methods(http.MethodGet).HandlerFunc(a.GetRuleGroup)
+
+	for name, tc := range map[string]struct {
+		url         string
+		accept      string
+		userID      string
+		status      int
+		contentType string
+		body        string
+	}{
+		"rule group exported in YAML by default": {
+			url:         "/prometheus/config/v1/rules/namespace1/group1",
+			userID:      "user1",
+			status:      http.StatusOK,
+			contentType: "application/yaml",
+			body:        "name: group1\ninterval: 1m\nrules:\n    - record: UP_RULE\n      expr: up\n    - alert: UP_ALERT\n      expr: up < 1\n",
+		},
+		"rule group exported in JSON with the format parameter": {
+			url:         "/prometheus/config/v1/rules/namespace1/group1?format=json",
+			userID:      "user1",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"interval":"1m","name":"group1","rules":[{"expr":"up","record":"UP_RULE"},{"alert":"UP_ALERT","expr":"up \u003c 1"}]}`,
+		},
+		"rule groups exported in JSON when only accepted by the client": {
+			url:         "/prometheus/config/v1/rules",
+			accept:      "application/json",
+			userID:      "user1",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"namespace1":[{"interval":"1m","name":"group1","rules":[{"expr":"up","record":"UP_RULE"},{"alert":"UP_ALERT","expr":"up \u003c 1"}]}]}`,
+		},
+		"unsupported format": {
+			url:    "/prometheus/config/v1/rules?format=xml",
+			userID: "user1",
+			status: http.StatusBadRequest,
+			body:   `{"status":"error","data":null,"errorType":"bad_data","error":"unsupported format \"xml\", supported formats are \"yaml\" and \"json\""}`,
+		},
+		"empty rule groups with the config API": {
+			url:         "/prometheus/config/v1/rules",
+			userID:      "user-without-rules",
+			status:      http.StatusOK,
+			contentType: "application/yaml",
+			body:        "{}\n",
+		},
+		"no rule groups with the ruler API": {
+			url:    "/api/v1/rules",
+			userID: "user-without-rules",
+			status: http.StatusNotFound,
+			body:   "no rule groups found\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := requestFor(t, http.MethodGet, "https://localhost:8080"+tc.url, nil, tc.userID)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+			require.Equal(t, tc.status, w.Code)
+			require.Equal(t, tc.body, w.Body.String())
+			if tc.contentType != "" {
+				require.Equal(t, tc.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
 func TestRuler_LimitsPerGroup(t *testing.T) {
 	store := newMockRuleStore(make(map[string]rulespb.RuleGroupList), nil)
 	cfg := defaultRulerConfig(t)
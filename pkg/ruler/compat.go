@@ -244,6 +244,7 @@ func RecordAndReportRuleQueryMetrics(qf rules.QueryFunc, queryTime prometheus.Co
 				"fetched_series_count", queryStats.FetchedSeriesCount,
 				"fetched_chunks_count", queryStats.FetchedChunksCount,
 				"fetched_samples_count", queryStats.FetchedSamplesCount,
+				"fetched_histogram_samples_count", queryStats.FetchedHistogramSamplesCount,
 				"fetched_chunks_bytes", queryStats.FetchedChunkBytes,
 				"fetched_data_bytes", queryStats.FetchedDataBytes,
 			)
@@ -274,7 +275,10 @@ type RulesManager interface {
 // ManagerFactory is a function that creates new RulesManager for given user and notifier.Manager.
 type ManagerFactory func(ctx context.Context, userID string, notifier *notifier.Manager, logger log.Logger, reg prometheus.Registerer) RulesManager
 
-func DefaultTenantManagerFactory(cfg Config, p Pusher, q storage.Queryable, engine v1.QueryEngine, overrides RulesLimits, reg prometheus.Registerer) ManagerFactory {
+// DefaultTenantManagerFactory returns the factory of the Prometheus rules managers of the tenants.
+// The rule queries are evaluated by the remote evaluator, if not nil, for the tenants with the
+// remote evaluation enabled.
+func DefaultTenantManagerFactory(cfg Config, p Pusher, q storage.Queryable, engine v1.QueryEngine, overrides RulesLimits, evaluator *RemoteEvaluator, reg prometheus.Registerer) ManagerFactory {
 	totalWritesVec := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 		Name: "cortex_ruler_write_requests_total",
 		Help: "Number of write requests to ingesters.",
@@ -317,6 +321,9 @@ func DefaultTenantManagerFactory(cfg Config, p Pusher, q storage.Queryable, engi
 		failedWrites := failedWritesVec.WithLabelValues(userID)
 
 		engineQueryFunc := EngineQueryFunc(engine, q, overrides, userID)
+		if evaluator != nil {
+			engineQueryFunc = evaluator.QueryFunc(engineQueryFunc, userID)
+		}
 		metricsQueryFunc := MetricsQueryFunc(engineQueryFunc, totalQueries, failedQueries)
 
 		return rules.NewManager(&rules.ManagerOptions{
@@ -0,0 +1,111 @@
+package ruler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
+)
+
+// defaultMinStepFactor is the factor applied to the max resolution to compute the
+// default min step of a rule group evaluated against downsampled data.
+const defaultMinStepFactor = 5
+
+// rawPrecisionFunctions are the PromQL functions which look at individual samples, and
+// thus can't be evaluated against downsampled data.
+var rawPrecisionFunctions = map[string]struct{}{
+	"changes":   {},
+	"idelta":    {},
+	"irate":     {},
+	"resets":    {},
+	"timestamp": {},
+}
+
+// applyDownsamplingDefaults sets the default min step if not configured.
+func applyDownsamplingDefaults(cfg *rulespb.DownsamplingConfig) {
+	if cfg.MinStep == 0 {
+		cfg.MinStep = cfg.MaxResolution * defaultMinStepFactor
+	}
+}
+
+// validateRuleGroupDownsampling checks the downsampling options of a rule group and
+// rejects the rules which require raw precision: instant vector selectors, range
+// selectors and subqueries shorter than the min step, and functions looking at individual samples.
+func validateRuleGroupDownsampling(g rulefmt.RuleGroup, cfg *rulespb.DownsamplingConfig) []error {
+	resolution := time.Duration(cfg.MaxResolution).Milliseconds()
+	supported := false
+	for _, r := range downsample.SupportedResolutions {
+		if r == resolution {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return []error{fmt.Errorf("invalid rules config: rule group '%s' has unsupported downsampling max resolution %s", g.Name, cfg.MaxResolution)}
+	}
+	if cfg.MinStep < cfg.MaxResolution {
+		return []error{fmt.Errorf("invalid rules config: rule group '%s' has downsampling min step %s lower than the max resolution %s", g.Name, cfg.MinStep, cfg.MaxResolution)}
+	}
+
+	var errs []error
+	for i, r := range g.Rules {
+		ruleName := r.Record.Value
+		if r.Alert.Value != "" {
+			ruleName = r.Alert.Value
+		}
+
+		expr, err := parser.ParseExpr(r.Expr.Value)
+		if err != nil {
+			// Already reported by the rule validation.
+			continue
+		}
+		if err := validateExprDownsampling(expr, time.Duration(cfg.MinStep)); err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid rules config: rule group '%s', rule %d, %q", g.Name, i, ruleName))
+		}
+	}
+	return errs
+}
+
+func validateExprDownsampling(expr parser.Expr, minStep time.Duration) error {
+	var err error
+	parser.Inspect(expr, func(node parser.Node, path []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.Call:
+			if _, ok := rawPrecisionFunctions[n.Func.Name]; ok {
+				err = errors.Errorf("function %s requires raw precision and can't be evaluated against downsampled data", n.Func.Name)
+			}
+		case *parser.MatrixSelector:
+			if n.Range < minStep {
+				err = errors.Errorf("range %s is shorter than the downsampling min step %s", model.Duration(n.Range), model.Duration(minStep))
+			}
+		case *parser.SubqueryExpr:
+			if n.Range < minStep {
+				err = errors.Errorf("subquery range %s is shorter than the downsampling min step %s", model.Duration(n.Range), model.Duration(minStep))
+			}
+		case *parser.VectorSelector:
+			if !withinRange(path) {
+				err = errors.Errorf("instant vector selector %s requires raw precision and can't be evaluated against downsampled data", n.String())
+			}
+		}
+		return err
+	})
+	return err
+}
+
+// withinRange returns whether the node at the given path is evaluated over a range,
+// either by a range selector or by a subquery.
+func withinRange(path []parser.Node) bool {
+	for _, n := range path {
+		switch n.(type) {
+		case *parser.MatrixSelector, *parser.SubqueryExpr:
+			return true
+		}
+	}
+	return false
+}
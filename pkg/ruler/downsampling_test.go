@@ -0,0 +1,96 @@
+package ruler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
+)
+
+func TestValidateRuleGroupDownsampling(t *testing.T) {
+	tests := map[string]struct {
+		cfg         rulespb.DownsamplingConfig
+		expr        string
+		expectedErr string
+	}{
+		"range functions over long windows": {
+			cfg:  rulespb.DownsamplingConfig{MaxResolution: model.Duration(time.Hour)},
+			expr: `1 - sum(rate(requests_total{code!~"5.."}[30d])) / sum(rate(requests_total[30d]))`,
+		},
+		"subquery with a long range": {
+			cfg:  rulespb.DownsamplingConfig{MaxResolution: model.Duration(5 * time.Minute)},
+			expr: `max_over_time(sum(up)[1d:5m])`,
+		},
+		"unsupported resolution": {
+			cfg:         rulespb.DownsamplingConfig{MaxResolution: model.Duration(time.Minute)},
+			expr:        `max_over_time(up[1d])`,
+			expectedErr: "invalid rules config: rule group 'test' has unsupported downsampling max resolution 1m",
+		},
+		"min step lower than the resolution": {
+			cfg:         rulespb.DownsamplingConfig{MaxResolution: model.Duration(time.Hour), MinStep: model.Duration(time.Minute)},
+			expr:        `max_over_time(up[1d])`,
+			expectedErr: "invalid rules config: rule group 'test' has downsampling min step 1m lower than the max resolution 1h",
+		},
+		"range shorter than the min step": {
+			cfg:         rulespb.DownsamplingConfig{MaxResolution: model.Duration(5 * time.Minute)},
+			expr:        `rate(requests_total[5m])`,
+			expectedErr: `invalid rules config: rule group 'test', rule 0, "test": range 5m is shorter than the downsampling min step 25m`,
+		},
+		"subquery shorter than the min step": {
+			cfg:         rulespb.DownsamplingConfig{MaxResolution: model.Duration(5 * time.Minute)},
+			expr:        `max_over_time(sum(up)[10m:1m])`,
+			expectedErr: `invalid rules config: rule group 'test', rule 0, "test": subquery range 10m is shorter than the downsampling min step 25m`,
+		},
+		"function requiring raw precision": {
+			cfg:         rulespb.DownsamplingConfig{MaxResolution: model.Duration(5 * time.Minute)},
+			expr:        `irate(requests_total[1d])`,
+			expectedErr: `invalid rules config: rule group 'test', rule 0, "test": function irate requires raw precision and can't be evaluated against downsampled data`,
+		},
+		"instant vector selector": {
+			cfg:         rulespb.DownsamplingConfig{MaxResolution: model.Duration(5 * time.Minute)},
+			expr:        `sum(rate(requests_total[1d])) / sum(up)`,
+			expectedErr: `invalid rules config: rule group 'test', rule 0, "test": instant vector selector up requires raw precision and can't be evaluated against downsampled data`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			expr := yaml.Node{}
+			expr.SetString(tc.expr)
+			record := yaml.Node{}
+			record.SetString("test")
+
+			g := rulefmt.RuleGroup{
+				Name:  "test",
+				Rules: []rulefmt.RuleNode{{Record: record, Expr: expr}},
+			}
+
+			cfg := tc.cfg
+			applyDownsamplingDefaults(&cfg)
+
+			errs := validateRuleGroupDownsampling(g, &cfg)
+			if tc.expectedErr == "" {
+				assert.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, 1)
+			assert.EqualError(t, errs[0], tc.expectedErr)
+		})
+	}
+}
+
+func TestApplyDownsamplingDefaults(t *testing.T) {
+	cfg := rulespb.DownsamplingConfig{MaxResolution: model.Duration(time.Hour)}
+	applyDownsamplingDefaults(&cfg)
+	assert.Equal(t, model.Duration(5*time.Hour), cfg.MinStep)
+
+	cfg = rulespb.DownsamplingConfig{MaxResolution: model.Duration(time.Hour), MinStep: model.Duration(2 * time.Hour)}
+	applyDownsamplingDefaults(&cfg)
+	assert.Equal(t, model.Duration(2*time.Hour), cfg.MinStep)
+}
@@ -21,6 +21,7 @@ import (
 	"golang.org/x/net/context/ctxhttp"
 
 	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
 )
 
 type DefaultMultiTenantManager struct {
@@ -36,6 +37,10 @@ type DefaultMultiTenantManager struct {
 	userManagers       map[string]RulesManager
 	userManagerMetrics *ManagerMetrics
 
+	// Per-user rule groups with Cortex specific options, keyed by mapped rule file and group name.
+	groupOptionsMtx sync.RWMutex
+	groupOptions    map[string]map[string]map[string]*rulespb.RuleGroupDesc
+
 	// Per-user notifiers with separate queues.
 	notifiersMtx sync.Mutex
 	notifiers    map[string]*rulerNotifier
@@ -66,6 +71,7 @@ func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg
 		notifiers:          map[string]*rulerNotifier{},
 		mapper:             newMapper(cfg.RulePath, logger),
 		userManagers:       map[string]RulesManager{},
+		groupOptions:       map[string]map[string]map[string]*rulespb.RuleGroupDesc{},
 		userManagerMetrics: userManagerMetrics,
 		managersTotal: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
 			Namespace: "cortex",
@@ -110,6 +116,7 @@ func (r *DefaultMultiTenantManager) SyncRuleGroups(ctx context.Context, ruleGrou
 
 			r.removeNotifier(userID)
 			r.mapper.cleanupUser(userID)
+			r.setGroupOptions(userID, nil)
 			r.lastReloadSuccessful.DeleteLabelValues(userID)
 			r.lastReloadSuccessfulTimestamp.DeleteLabelValues(userID)
 			r.configUpdatesTotal.DeleteLabelValues(userID)
@@ -133,6 +140,10 @@ func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user
 		return
 	}
 
+	// The Cortex specific options are not part of the mapped rule files, so they're
+	// updated on every sync.
+	r.setGroupOptions(user, groups)
+
 	manager, exists := r.userManagers[user]
 	if !exists || update {
 		level.Debug(r.logger).Log("msg", "updating rules", "user", user)
@@ -151,7 +162,7 @@ func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user
 			r.userManagers[user] = manager
 		}
 
-		err = manager.Update(r.cfg.EvaluationInterval, files, r.cfg.ExternalLabels, r.cfg.ExternalURL.String(), ruleGroupIterationFunc)
+		err = manager.Update(r.cfg.EvaluationInterval, files, r.cfg.ExternalLabels, r.cfg.ExternalURL.String(), r.ruleGroupIterationFunc(user))
 		if err != nil {
 			r.lastReloadSuccessful.WithLabelValues(user).Set(0)
 			level.Error(r.logger).Log("msg", "unable to update rule manager", "user", user, "err", err)
@@ -163,6 +174,21 @@ func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user
 	}
 }
 
+// ruleGroupIterationFunc returns the function evaluating the user's rule groups. Rule groups
+// configured with downsampling options are evaluated with the max resolution injected in the context,
+// and the ones with query limits overrides with their query limits.
+func (r *DefaultMultiTenantManager) ruleGroupIterationFunc(user string) promRules.GroupEvalIterationFunc {
+	return func(ctx context.Context, g *promRules.Group, evalTimestamp time.Time) {
+		if d := r.getGroupDownsampling(user, g.File(), g.Name()); d != nil {
+			ctx = downsample.ContextWithMaxResolution(ctx, d.MaxResolution.Milliseconds())
+		}
+		if l := r.getGroupQueryLimits(user, g.File(), g.Name()); l != nil {
+			ctx = contextWithQueryLimits(ctx, l)
+		}
+		ruleGroupIterationFunc(ctx, g, evalTimestamp)
+	}
+}
+
 func ruleGroupIterationFunc(ctx context.Context, g *promRules.Group, evalTimestamp time.Time) {
 	logMessage := []interface{}{
 		"msg", "evaluating rule group",
@@ -175,10 +201,59 @@ func ruleGroupIterationFunc(ctx context.Context, g *promRules.Group, evalTimesta
 		"eval_time", evalTimestamp,
 	}
 
+	if resolution := downsample.MaxResolutionFromContext(ctx); resolution > 0 {
+		logMessage = append(logMessage, "max_resolution", time.Duration(resolution)*time.Millisecond)
+	}
+	if l := queryLimitsFromContext(ctx); l != nil {
+		logMessage = append(logMessage, "query_max_samples", l.MaxSamples, "query_timeout", l.Timeout, "query_max_fetched_chunks", l.MaxFetchedChunks)
+	}
+
 	level.Info(g.Logger()).Log(logMessage...)
 	promRules.DefaultEvalIterationFunc(ctx, g, evalTimestamp)
 }
 
+// setGroupOptions stores the user's rule groups configured with Cortex specific options.
+func (r *DefaultMultiTenantManager) setGroupOptions(user string, groups rulespb.RuleGroupList) {
+	files := map[string]map[string]*rulespb.RuleGroupDesc{}
+	for _, g := range groups {
+		if g.Downsampling == nil && g.QueryLimits == nil {
+			continue
+		}
+		file := r.mapper.ruleFilePath(user, g.Namespace)
+		if files[file] == nil {
+			files[file] = map[string]*rulespb.RuleGroupDesc{}
+		}
+		files[file][g.Name] = g
+	}
+
+	r.groupOptionsMtx.Lock()
+	defer r.groupOptionsMtx.Unlock()
+
+	if len(files) == 0 {
+		delete(r.groupOptions, user)
+		return
+	}
+	r.groupOptions[user] = files
+}
+
+// getGroupDownsampling returns the downsampling options of a rule group, or nil if
+// the rule group is evaluated against raw data.
+func (r *DefaultMultiTenantManager) getGroupDownsampling(user, file, group string) *rulespb.RuleGroupDownsampling {
+	r.groupOptionsMtx.RLock()
+	defer r.groupOptionsMtx.RUnlock()
+
+	return r.groupOptions[user][file][group].GetDownsampling()
+}
+
+// getGroupQueryLimits returns the query limits overrides of a rule group, or nil if
+// the rule queries are evaluated with the limits of the tenant.
+func (r *DefaultMultiTenantManager) getGroupQueryLimits(user, file, group string) *rulespb.RuleGroupQueryLimits {
+	r.groupOptionsMtx.RLock()
+	defer r.groupOptionsMtx.RUnlock()
+
+	return r.groupOptions[user][file][group].GetQueryLimits()
+}
+
 // newManager creates a prometheus rule manager wrapped with a user id
 // configured storage, appendable, notifier, and instrumentation
 func (r *DefaultMultiTenantManager) newManager(ctx context.Context, userID string) (RulesManager, error) {
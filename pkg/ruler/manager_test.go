@@ -2,6 +2,8 @@ package ruler
 
 import (
 	"context"
+	"net/url"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -96,6 +98,69 @@ func TestSyncRuleGroups(t *testing.T) {
 	})
 }
 
+func TestSyncRuleGroups_Downsampling(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := NewDefaultMultiTenantManager(Config{RulePath: dir}, factory, nil, log.NewNopLogger())
+	require.NoError(t, err)
+	defer m.Stop()
+
+	const user = "testUser"
+
+	downsampling := &rulespb.RuleGroupDownsampling{MaxResolution: time.Hour, MinStep: 5 * time.Hour}
+	m.SyncRuleGroups(context.Background(), map[string]rulespb.RuleGroupList{
+		user: {
+			&rulespb.RuleGroupDesc{Name: "raw", Namespace: "ns/1", Interval: time.Minute, User: user},
+			&rulespb.RuleGroupDesc{Name: "slo", Namespace: "ns/1", Interval: time.Minute, User: user, Downsampling: downsampling},
+		},
+	})
+
+	file := filepath.Join(dir, user, url.PathEscape("ns/1"))
+	require.Equal(t, downsampling, m.getGroupDownsampling(user, file, "slo"))
+	require.Nil(t, m.getGroupDownsampling(user, file, "raw"))
+
+	// Removing the options from the rule group doesn't change the mapped rule files,
+	// but the options must be removed anyway.
+	m.SyncRuleGroups(context.Background(), map[string]rulespb.RuleGroupList{
+		user: {
+			&rulespb.RuleGroupDesc{Name: "raw", Namespace: "ns/1", Interval: time.Minute, User: user},
+			&rulespb.RuleGroupDesc{Name: "slo", Namespace: "ns/1", Interval: time.Minute, User: user},
+		},
+	})
+	require.Nil(t, m.getGroupDownsampling(user, file, "slo"))
+}
+
+func TestSyncRuleGroups_QueryLimits(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := NewDefaultMultiTenantManager(Config{RulePath: dir}, factory, nil, log.NewNopLogger())
+	require.NoError(t, err)
+	defer m.Stop()
+
+	const user = "testUser"
+
+	queryLimits := &rulespb.RuleGroupQueryLimits{MaxSamples: 1000, Timeout: time.Minute}
+	downsampling := &rulespb.RuleGroupDownsampling{MaxResolution: time.Hour, MinStep: 5 * time.Hour}
+	m.SyncRuleGroups(context.Background(), map[string]rulespb.RuleGroupList{
+		user: {
+			&rulespb.RuleGroupDesc{Name: "dashboard", Namespace: "ns", Interval: time.Minute, User: user},
+			&rulespb.RuleGroupDesc{Name: "critical", Namespace: "ns", Interval: time.Minute, User: user, QueryLimits: queryLimits},
+			&rulespb.RuleGroupDesc{Name: "slo", Namespace: "ns", Interval: time.Minute, User: user, Downsampling: downsampling},
+		},
+	})
+
+	file := filepath.Join(dir, user, "ns")
+	require.Equal(t, queryLimits, m.getGroupQueryLimits(user, file, "critical"))
+	require.Nil(t, m.getGroupDownsampling(user, file, "critical"))
+	require.Nil(t, m.getGroupQueryLimits(user, file, "dashboard"))
+	require.Nil(t, m.getGroupQueryLimits(user, file, "slo"))
+	require.Equal(t, downsampling, m.getGroupDownsampling(user, file, "slo"))
+
+	// The options are removed with the user's rule groups.
+	m.SyncRuleGroups(context.Background(), map[string]rulespb.RuleGroupList{})
+	require.Nil(t, m.getGroupQueryLimits(user, file, "critical"))
+}
+
 func getManager(m *DefaultMultiTenantManager, user string) RulesManager {
 	m.userManagerMtx.Lock()
 	defer m.userManagerMtx.Unlock()
@@ -69,6 +69,11 @@ func (m *mapper) users() ([]string, error) {
 	return result, err
 }
 
+// ruleFilePath returns the path of the file the rule groups of the user's namespace are mapped to.
+func (m *mapper) ruleFilePath(user, namespace string) string {
+	return filepath.Join(m.Path, user, url.PathEscape(namespace))
+}
+
 func (m *mapper) MapRules(user string, ruleConfigs map[string][]rulefmt.RuleGroup) (bool, []string, error) {
 	anyUpdated := false
 	filenames := []string{}
@@ -83,8 +88,7 @@ func (m *mapper) MapRules(user string, ruleConfigs map[string][]rulefmt.RuleGrou
 	// write all rule configs to disk
 	for filename, groups := range ruleConfigs {
 		// Store the encoded file name to better handle `/` characters
-		encodedFileName := url.PathEscape(filename)
-		fullFileName := filepath.Join(path, encodedFileName)
+		fullFileName := m.ruleFilePath(user, filename)
 
 		fileUpdated, err := m.writeRuleGroupsIfNewer(groups, fullFileName)
 		if err != nil {
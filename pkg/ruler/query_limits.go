@@ -0,0 +1,122 @@
+package ruler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+
+	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
+	"github.com/cortexproject/cortex/pkg/util/limiter"
+)
+
+// validateRuleGroupQueryLimits checks the query limits overrides of a rule group.
+func validateRuleGroupQueryLimits(g rulefmt.RuleGroup, cfg *rulespb.QueryLimitsConfig) []error {
+	var errs []error
+	if cfg.MaxSamples < 0 {
+		errs = append(errs, fmt.Errorf("invalid rules config: rule group '%s' has negative query limits max samples %d", g.Name, cfg.MaxSamples))
+	}
+	if cfg.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid rules config: rule group '%s' has negative query limits timeout %s", g.Name, time.Duration(cfg.Timeout)))
+	}
+	if cfg.MaxFetchedChunks < 0 {
+		errs = append(errs, fmt.Errorf("invalid rules config: rule group '%s' has negative query limits max fetched chunks %d", g.Name, cfg.MaxFetchedChunks))
+	}
+	return errs
+}
+
+type queryLimitsCtxKey struct{}
+
+// contextWithQueryLimits injects the query limits overrides of a rule group in the context of
+// its evaluation. The max fetched chunks are enforced by the querier, the max samples and timeout
+// by the query engine.
+func contextWithQueryLimits(ctx context.Context, l *rulespb.RuleGroupQueryLimits) context.Context {
+	if l.MaxFetchedChunks > 0 {
+		ctx = limiter.AddMaxChunksPerQueryToContext(ctx, int(l.MaxFetchedChunks))
+	}
+	return context.WithValue(ctx, queryLimitsCtxKey{}, l)
+}
+
+// queryLimitsFromContext returns the query limits overrides of the rule group evaluated with
+// the context, or nil if the rule group has none.
+func queryLimitsFromContext(ctx context.Context) *rulespb.RuleGroupQueryLimits {
+	l, _ := ctx.Value(queryLimitsCtxKey{}).(*rulespb.RuleGroupQueryLimits)
+	return l
+}
+
+// QueryEngineFactory creates a query engine with the given max samples and timeout. A zero
+// limit keeps the default one.
+type QueryEngineFactory func(maxSamples int, timeout time.Duration) v1.QueryEngine
+
+type engineLimits struct {
+	maxSamples int
+	timeout    time.Duration
+}
+
+// queryLimitsEngine evaluates the rule queries with an engine configured with the max samples
+// and timeout of their rule group. The engines are created on first use and shared by all the
+// rule groups with the same limits.
+type queryLimitsEngine struct {
+	engine    v1.QueryEngine
+	newEngine QueryEngineFactory
+
+	mtx         sync.Mutex
+	engines     map[engineLimits]v1.QueryEngine
+	queryLogger promql.QueryLogger
+}
+
+// NewQueryLimitsEngine returns a query engine evaluating the rule queries of the rule groups
+// with query limits overrides with an engine created by newEngine, and the others with engine.
+func NewQueryLimitsEngine(engine v1.QueryEngine, newEngine QueryEngineFactory) v1.QueryEngine {
+	return &queryLimitsEngine{
+		engine:    engine,
+		newEngine: newEngine,
+		engines:   map[engineLimits]v1.QueryEngine{},
+	}
+}
+
+func (e *queryLimitsEngine) SetQueryLogger(l promql.QueryLogger) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.queryLogger = l
+	e.engine.SetQueryLogger(l)
+	for _, engine := range e.engines {
+		engine.SetQueryLogger(l)
+	}
+}
+
+func (e *queryLimitsEngine) NewInstantQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
+	return e.engineFor(ctx).NewInstantQuery(ctx, q, opts, qs, ts)
+}
+
+func (e *queryLimitsEngine) NewRangeQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	return e.engineFor(ctx).NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+}
+
+// engineFor returns the engine configured with the query limits of the rule group evaluated with the context.
+func (e *queryLimitsEngine) engineFor(ctx context.Context) v1.QueryEngine {
+	l := queryLimitsFromContext(ctx)
+	if l == nil || (l.MaxSamples == 0 && l.Timeout == 0) {
+		return e.engine
+	}
+	key := engineLimits{maxSamples: int(l.MaxSamples), timeout: l.Timeout}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	engine, ok := e.engines[key]
+	if !ok {
+		engine = e.newEngine(key.maxSamples, key.timeout)
+		if e.queryLogger != nil {
+			engine.SetQueryLogger(e.queryLogger)
+		}
+		e.engines[key] = engine
+	}
+	return engine
+}
@@ -0,0 +1,81 @@
+package ruler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
+	"github.com/cortexproject/cortex/pkg/util/limiter"
+)
+
+func TestValidateRuleGroupQueryLimits(t *testing.T) {
+	g := rulefmt.RuleGroup{Name: "test"}
+
+	assert.Empty(t, validateRuleGroupQueryLimits(g, &rulespb.QueryLimitsConfig{MaxSamples: 1000, Timeout: model.Duration(time.Minute), MaxFetchedChunks: 10}))
+	assert.Empty(t, validateRuleGroupQueryLimits(g, &rulespb.QueryLimitsConfig{}))
+
+	errs := validateRuleGroupQueryLimits(g, &rulespb.QueryLimitsConfig{Timeout: model.Duration(-time.Minute)})
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "invalid rules config: rule group 'test' has negative query limits timeout -1m0s")
+}
+
+func TestQueryLimitsEngine(t *testing.T) {
+	var created []engineLimits
+	newEngine := func(maxSamples int, timeout time.Duration) v1.QueryEngine {
+		created = append(created, engineLimits{maxSamples: maxSamples, timeout: timeout})
+		// The default engine only allows a single sample per query.
+		if maxSamples == 0 {
+			maxSamples = 1
+		}
+		if timeout == 0 {
+			timeout = time.Minute
+		}
+		return promql.NewEngine(promql.EngineOpts{MaxSamples: maxSamples, Timeout: timeout})
+	}
+	engine := NewQueryLimitsEngine(newEngine(0, 0), newEngine)
+	created = nil
+
+	queryable := storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+		return storage.NoopQuerier(), nil
+	})
+	queryFunc := EngineQueryFunc(engine, queryable, ruleLimits{}, "user-1")
+
+	const query = "count_over_time(vector(1)[10m:1m])"
+	evalTime := time.Unix(1700000000, 0)
+
+	// The rule groups without query limits overrides are evaluated with the default engine.
+	_, err := queryFunc(context.Background(), query, evalTime)
+	require.ErrorContains(t, err, "query processing would load too many samples into memory")
+	assert.Empty(t, created)
+
+	// The max fetched chunks alone are enforced by the querier, with the default engine.
+	ctx := contextWithQueryLimits(context.Background(), &rulespb.RuleGroupQueryLimits{MaxFetchedChunks: 10})
+	assert.Equal(t, 10, limiter.MaxChunksPerQueryFromContext(ctx, 1000))
+	_, err = queryFunc(ctx, query, evalTime)
+	require.Error(t, err)
+	assert.Empty(t, created)
+
+	// The rule groups with max samples are evaluated with an engine created on first use.
+	ctx = contextWithQueryLimits(context.Background(), &rulespb.RuleGroupQueryLimits{MaxSamples: 100})
+	for i := 0; i < 2; i++ {
+		v, err := queryFunc(ctx, query, evalTime)
+		require.NoError(t, err)
+		require.Len(t, v, 1)
+		assert.Equal(t, float64(10), v[0].F)
+	}
+	assert.Equal(t, []engineLimits{{maxSamples: 100}}, created)
+
+	ctx = contextWithQueryLimits(context.Background(), &rulespb.RuleGroupQueryLimits{MaxSamples: 100, Timeout: time.Second})
+	_, err = queryFunc(ctx, query, evalTime)
+	require.NoError(t, err)
+	assert.Equal(t, []engineLimits{{maxSamples: 100}, {maxSamples: 100, timeout: time.Second}}, created)
+}
@@ -0,0 +1,309 @@
+package ruler
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/cortexproject/cortex/pkg/ring/client"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/grpcclient"
+)
+
+// RemoteEvaluationConfig configures the evaluation of the rule queries by remote evaluators.
+type RemoteEvaluationConfig struct {
+	Addresses    flagext.StringSliceCSV `yaml:"addresses"`
+	Timeout      time.Duration          `yaml:"timeout"`
+	ClientConfig grpcclient.Config      `yaml:"grpc_client_config"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *RemoteEvaluationConfig) RegisterFlags(f *flag.FlagSet) {
+	f.Var(&cfg.Addresses, "ruler.remote-evaluation.addresses", "Experimental: comma separated list of the gRPC addresses of the remote rule evaluators, which are queriers with -querier.rule-evaluator-enabled. The rule queries of the tenants with ruler_remote_evaluation_enabled are evaluated by them instead of the ruler.")
+	f.DurationVar(&cfg.Timeout, "ruler.remote-evaluation.timeout", 2*time.Minute, "Timeout of the rule queries evaluated by the remote rule evaluators.")
+	cfg.ClientConfig.RegisterFlagsWithPrefix("ruler.remote-evaluation.grpc-client-config", f)
+}
+
+// Validate the config.
+func (cfg *RemoteEvaluationConfig) Validate(log log.Logger) error {
+	if err := cfg.ClientConfig.Validate(log); err != nil {
+		return errors.Wrap(err, "invalid ruler remote evaluation gRPC client config")
+	}
+	return nil
+}
+
+// RemoteEvaluationLimits defines the limits used by the remote evaluation of the rule queries.
+type RemoteEvaluationLimits interface {
+	EvaluationDelay(userID string) time.Duration
+	RulerRemoteEvaluationEnabled(userID string) bool
+	RulerRemoteEvaluatorShardSize(userID string) int
+}
+
+// RemoteEvaluator evaluates the rule queries on a pool of remote evaluators, over the
+// HTTP-over-gRPC protocol, so that the expensive rules run on a capacity isolated from the
+// interactive queries. Each tenant is routed to its own shard of the evaluators.
+type RemoteEvaluator struct {
+	*client.Pool
+
+	cfg       RemoteEvaluationConfig
+	queryPath string
+	limits    RemoteEvaluationLimits
+
+	// The sorted addresses of the evaluators.
+	addresses []string
+	// Used to balance the queries of a tenant across the evaluators of its shard.
+	next atomic.Uint64
+
+	queries *prometheus.CounterVec
+}
+
+// NewRemoteEvaluator makes a new RemoteEvaluator. The queries are sent to the Prometheus
+// query API of the evaluators, served under the given path prefix.
+func NewRemoteEvaluator(cfg RemoteEvaluationConfig, pathPrefix string, limits RemoteEvaluationLimits, logger log.Logger, reg prometheus.Registerer) *RemoteEvaluator {
+	// We prefer sane defaults instead of exposing further config options.
+	poolCfg := client.PoolConfig{
+		CheckInterval:      time.Minute,
+		HealthCheckEnabled: true,
+		HealthCheckTimeout: 10 * time.Second,
+	}
+
+	clientsCount := promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_ruler_remote_evaluator_clients",
+		Help: "The current number of remote rule evaluator clients in the pool.",
+	})
+
+	requestDuration := promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cortex_ruler_remote_evaluator_request_duration_seconds",
+		Help:    "Time spent executing requests to the remote rule evaluators.",
+		Buckets: prometheus.ExponentialBuckets(0.008, 4, 7),
+	}, []string{"operation", "status_code"})
+
+	addresses := append([]string(nil), cfg.Addresses...)
+	sort.Strings(addresses)
+
+	return &RemoteEvaluator{
+		Pool: client.NewPool("ruler-remote-evaluator", poolCfg, nil, func(addr string) (client.PoolClient, error) {
+			return dialRemoteEvaluatorClient(cfg.ClientConfig, addr, requestDuration)
+		}, clientsCount, logger),
+		cfg:       cfg,
+		queryPath: path.Join(pathPrefix, "/api/v1/query"),
+		limits:    limits,
+		addresses: addresses,
+		queries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_remote_evaluation_queries_total",
+			Help: "Total number of rule queries sent to the remote rule evaluators, by evaluator.",
+		}, []string{"evaluator"}),
+	}
+}
+
+// QueryFunc returns the query function of the tenant: the queries are evaluated by the remote
+// evaluators when the remote evaluation is enabled for the tenant, by the local one otherwise.
+func (e *RemoteEvaluator) QueryFunc(local rules.QueryFunc, userID string) rules.QueryFunc {
+	return func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		if !e.limits.RulerRemoteEvaluationEnabled(userID) {
+			return local(ctx, qs, t)
+		}
+		return e.query(ctx, userID, qs, t.Add(-e.limits.EvaluationDelay(userID)))
+	}
+}
+
+// tenantShard returns the addresses of the evaluators of the tenant, in the order of preference
+// of the tenant. The shard is all the evaluators when the shard size is 0.
+func (e *RemoteEvaluator) tenantShard(userID string) []string {
+	// Like for the shuffle sharding, the shard is stable as long as the evaluators don't change.
+	rnd := rand.New(rand.NewSource(util.ShuffleShardSeed(userID, "")))
+	shard := make([]string, 0, len(e.addresses))
+	for _, i := range rnd.Perm(len(e.addresses)) {
+		shard = append(shard, e.addresses[i])
+	}
+
+	if size := e.limits.RulerRemoteEvaluatorShardSize(userID); size > 0 && size < len(shard) {
+		shard = shard[:size]
+	}
+	return shard
+}
+
+func (e *RemoteEvaluator) query(ctx context.Context, userID, qs string, t time.Time) (promql.Vector, error) {
+	shard := e.tenantShard(userID)
+	if len(shard) == 0 {
+		return nil, QueryableError{err: errors.New("no remote rule evaluator configured")}
+	}
+
+	req := e.queryRequest(ctx, userID, qs, t)
+
+	ctx, cancel := context.WithTimeout(user.InjectOrgID(ctx, userID), e.cfg.Timeout)
+	defer cancel()
+
+	// The queries are balanced across the evaluators of the shard, and retried on the next
+	// evaluators on server errors.
+	start := int(e.next.Inc() % uint64(len(shard)))
+	var err error
+	for i := range shard {
+		var resp *httpgrpc.HTTPResponse
+		resp, err = e.send(ctx, shard[(start+i)%len(shard)], req)
+		if err == nil {
+			return decodeRemoteQueryResponse(resp)
+		}
+		if errResp, ok := httpgrpc.HTTPResponseFromError(err); ok && errResp.Code/100 == 4 {
+			// The client errors aren't retried, and are user errors like for the local evaluation.
+			return nil, remoteQueryError(errResp)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	// The server errors are reported like the storage errors of the local evaluation.
+	return nil, QueryableError{err: errors.Wrap(err, "remote rule evaluation failed")}
+}
+
+func (e *RemoteEvaluator) queryRequest(ctx context.Context, userID, qs string, t time.Time) *httpgrpc.HTTPRequest {
+	form := url.Values{
+		"query": []string{qs},
+		"time":  []string{strconv.FormatFloat(float64(t.UnixMilli())/1e3, 'f', -1, 64)},
+	}
+	// The rule groups with downsampling options are evaluated against the same resolution.
+	if resolution := downsample.MaxResolutionFromContext(ctx); resolution > 0 {
+		form.Set(downsample.MaxSourceResolutionParam, strconv.FormatFloat(float64(resolution)/1e3, 'f', -1, 64))
+	}
+
+	return &httpgrpc.HTTPRequest{
+		Method: http.MethodPost,
+		Url:    e.queryPath,
+		Body:   []byte(form.Encode()),
+		// The headers are set as is on the requests served by the evaluators, so their keys must be canonical.
+		Headers: []*httpgrpc.Header{
+			{Key: "Content-Type", Values: []string{"application/x-www-form-urlencoded"}},
+			{Key: http.CanonicalHeaderKey(user.OrgIDHeaderName), Values: []string{userID}},
+		},
+	}
+}
+
+func (e *RemoteEvaluator) send(ctx context.Context, addr string, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+	c, err := e.GetClientFor(addr)
+	if err != nil {
+		return nil, err
+	}
+	e.queries.WithLabelValues(addr).Inc()
+
+	resp, err := c.(*remoteEvaluatorClient).Handle(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code/100 != 2 {
+		return nil, httpgrpc.ErrorFromHTTPResponse(resp)
+	}
+	return resp, nil
+}
+
+// remoteQueryResponse is the response of the Prometheus query API.
+type remoteQueryResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+}
+
+func decodeRemoteQueryResponse(resp *httpgrpc.HTTPResponse) (promql.Vector, error) {
+	var r remoteQueryResponse
+	if err := json.Unmarshal(resp.Body, &r); err != nil {
+		return nil, QueryableError{err: errors.Wrap(err, "failed to decode the remote rule evaluation response")}
+	}
+
+	switch r.Data.ResultType {
+	case model.ValVector.String():
+		var v model.Vector
+		if err := json.Unmarshal(r.Data.Result, &v); err != nil {
+			return nil, QueryableError{err: errors.Wrap(err, "failed to decode the remote rule evaluation vector")}
+		}
+		vector := make(promql.Vector, 0, len(v))
+		for _, s := range v {
+			if s.Histogram != nil {
+				return nil, errors.New("native histograms are not supported by the remote rule evaluation")
+			}
+			b := labels.NewScratchBuilder(len(s.Metric))
+			for name, value := range s.Metric {
+				b.Add(string(name), string(value))
+			}
+			b.Sort()
+			vector = append(vector, promql.Sample{Metric: b.Labels(), T: int64(s.Timestamp), F: float64(s.Value)})
+		}
+		return vector, nil
+	case model.ValScalar.String():
+		var s model.Scalar
+		if err := json.Unmarshal(r.Data.Result, &s); err != nil {
+			return nil, QueryableError{err: errors.Wrap(err, "failed to decode the remote rule evaluation scalar")}
+		}
+		return promql.Vector{promql.Sample{T: int64(s.Timestamp), F: float64(s.Value), Metric: labels.Labels{}}}, nil
+	default:
+		return nil, errors.New("rule result is not a vector or scalar")
+	}
+}
+
+// remoteQueryError returns the error of the query API response.
+func remoteQueryError(resp *httpgrpc.HTTPResponse) error {
+	var r remoteQueryResponse
+	if err := json.Unmarshal(resp.Body, &r); err == nil && r.Error != "" {
+		return errors.New(r.Error)
+	}
+	return fmt.Errorf("remote rule evaluation failed with status code %d: %s", resp.Code, resp.Body)
+}
+
+func dialRemoteEvaluatorClient(clientCfg grpcclient.Config, addr string, requestDuration *prometheus.HistogramVec) (*remoteEvaluatorClient, error) {
+	opts, err := clientCfg.DialOption(grpcclient.Instrument(requestDuration))
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dial remote rule evaluator %s", addr)
+	}
+
+	return &remoteEvaluatorClient{
+		HTTPClient:   httpgrpc.NewHTTPClient(conn),
+		HealthClient: grpc_health_v1.NewHealthClient(conn),
+		conn:         conn,
+	}, nil
+}
+
+type remoteEvaluatorClient struct {
+	httpgrpc.HTTPClient
+	grpc_health_v1.HealthClient
+	conn *grpc.ClientConn
+}
+
+func (c *remoteEvaluatorClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *remoteEvaluatorClient) String() string {
+	return c.conn.Target()
+}
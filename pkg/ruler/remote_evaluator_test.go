@@ -0,0 +1,194 @@
+package ruler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	httpgrpc_server "github.com/weaveworks/common/httpgrpc/server"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+type remoteEvaluationLimits struct {
+	enabled   bool
+	shardSize int
+	evalDelay time.Duration
+}
+
+func (l remoteEvaluationLimits) EvaluationDelay(string) time.Duration {
+	return l.evalDelay
+}
+
+func (l remoteEvaluationLimits) RulerRemoteEvaluationEnabled(string) bool {
+	return l.enabled
+}
+
+func (l remoteEvaluationLimits) RulerRemoteEvaluatorShardSize(string) int {
+	return l.shardSize
+}
+
+// startRemoteEvaluator starts a remote rule evaluator serving the handler, and returns its address.
+func startRemoteEvaluator(t *testing.T, handler http.HandlerFunc) string {
+	grpcServer := grpc.NewServer()
+	t.Cleanup(grpcServer.GracefulStop)
+	httpgrpc.RegisterHTTPServer(grpcServer, httpgrpc_server.NewServer(handler))
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	return listener.Addr().String()
+}
+
+func TestRemoteEvaluator_QueryFunc(t *testing.T) {
+	evalTime := time.Unix(1700000000, 0)
+
+	for name, tc := range map[string]struct {
+		limits       remoteEvaluationLimits
+		handlers     []http.HandlerFunc
+		ctx          context.Context
+		expected     promql.Vector
+		expectedErr  string
+		queryableErr bool
+	}{
+		"local evaluation when the remote evaluation is disabled for the tenant": {
+			limits: remoteEvaluationLimits{enabled: false},
+			handlers: []http.HandlerFunc{func(w http.ResponseWriter, r *http.Request) {
+				t.Error("unexpected remote evaluation")
+			}},
+			expected: promql.Vector{{Metric: labels.FromStrings("source", "local"), T: evalTime.UnixMilli(), F: 1}},
+		},
+		"vector result": {
+			limits: remoteEvaluationLimits{enabled: true, evalDelay: time.Minute},
+			handlers: []http.HandlerFunc{func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/prometheus/api/v1/query", r.URL.Path)
+				assert.Equal(t, "user-1", r.Header.Get(user.OrgIDHeaderName))
+				assert.Equal(t, "up == 0", r.FormValue("query"))
+				assert.Equal(t, "1699999940", r.FormValue("time"))
+				assert.Equal(t, "", r.FormValue(downsample.MaxSourceResolutionParam))
+				_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"api","instance":"a"},"value":[1699999940,"0"]}]}}`))
+			}},
+			expected: promql.Vector{{Metric: labels.FromStrings("instance", "a", "job", "api"), T: 1699999940000, F: 0}},
+		},
+		"scalar result with the max resolution of the rule group": {
+			limits: remoteEvaluationLimits{enabled: true},
+			ctx:    downsample.ContextWithMaxResolution(context.Background(), time.Hour.Milliseconds()),
+			handlers: []http.HandlerFunc{func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "3600", r.FormValue(downsample.MaxSourceResolutionParam))
+				_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1700000000,"2"]}}`))
+			}},
+			expected: promql.Vector{{Metric: labels.Labels{}, T: 1700000000000, F: 2}},
+		},
+		"client errors are not retried": {
+			limits: remoteEvaluationLimits{enabled: true},
+			handlers: []http.HandlerFunc{
+				func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"invalid parameter \"query\""}`))
+				},
+				func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"invalid parameter \"query\""}`))
+				},
+			},
+			expectedErr: `invalid parameter "query"`,
+		},
+		"server errors are retried on the other evaluators of the shard": {
+			limits: remoteEvaluationLimits{enabled: true},
+			handlers: []http.HandlerFunc{
+				func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				},
+				func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+				},
+			},
+			expected: promql.Vector{},
+		},
+		"server errors of all the evaluators": {
+			limits: remoteEvaluationLimits{enabled: true},
+			handlers: []http.HandlerFunc{
+				func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			},
+			expectedErr:  "remote rule evaluation failed",
+			queryableErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := RemoteEvaluationConfig{}
+			flagext.DefaultValues(&cfg)
+			for _, h := range tc.handlers {
+				cfg.Addresses = append(cfg.Addresses, startRemoteEvaluator(t, h))
+			}
+
+			e := NewRemoteEvaluator(cfg, "/prometheus", tc.limits, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), e))
+			defer services.StopAndAwaitTerminated(context.Background(), e) //nolint:errcheck
+
+			local := func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+				return promql.Vector{{Metric: labels.FromStrings("source", "local"), T: t.UnixMilli(), F: 1}}, nil
+			}
+
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			// The queries of each case are balanced across all the evaluators.
+			for range tc.handlers {
+				v, err := e.QueryFunc(local, "user-1")(ctx, "up == 0", evalTime)
+				if tc.expectedErr != "" {
+					require.ErrorContains(t, err, tc.expectedErr)
+					assert.Equal(t, tc.queryableErr, errors.As(err, &QueryableError{}))
+					continue
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, v)
+			}
+		})
+	}
+}
+
+func TestRemoteEvaluator_tenantShard(t *testing.T) {
+	cfg := RemoteEvaluationConfig{Addresses: []string{"evaluator-3", "evaluator-1", "evaluator-4", "evaluator-2"}}
+
+	limits := remoteEvaluationLimits{enabled: true, shardSize: 2}
+	e := NewRemoteEvaluator(cfg, "", limits, log.NewNopLogger(), nil)
+
+	shard := e.tenantShard("user-1")
+	assert.Len(t, shard, 2)
+	// The shard of a tenant is stable, and doesn't depend on the order of the addresses.
+	cfg.Addresses = []string{"evaluator-1", "evaluator-2", "evaluator-3", "evaluator-4"}
+	assert.Equal(t, shard, NewRemoteEvaluator(cfg, "", limits, log.NewNopLogger(), nil).tenantShard("user-1"))
+
+	// The shards of the tenants are spread across the evaluators.
+	evaluators := map[string]bool{}
+	for _, userID := range []string{"user-1", "user-2", "user-3", "user-4", "user-5", "user-6"} {
+		for _, addr := range e.tenantShard(userID) {
+			evaluators[addr] = true
+		}
+	}
+	assert.Greater(t, len(evaluators), 2)
+
+	// All the evaluators without shard size.
+	e.limits = remoteEvaluationLimits{enabled: true}
+	assert.ElementsMatch(t, cfg.Addresses, e.tenantShard("user-1"))
+}
@@ -134,6 +134,12 @@ type Config struct {
 
 	EnableQueryStats      bool `yaml:"query_stats_enabled"`
 	DisableRuleGroupLabel bool `yaml:"disable_rule_group_label"`
+
+	// Evaluation of the rule queries by remote evaluators.
+	RemoteEvaluation RemoteEvaluationConfig `yaml:"remote_evaluation"`
+
+	// Write-ahead log of the rule results.
+	WAL WALConfig `yaml:"wal"`
 }
 
 // Validate config and returns error on failure
@@ -149,7 +155,7 @@ func (cfg *Config) Validate(limits validation.Limits, log log.Logger) error {
 	if err := cfg.ClientTLSConfig.Validate(log); err != nil {
 		return errors.Wrap(err, "invalid ruler gRPC client config")
 	}
-	return nil
+	return cfg.RemoteEvaluation.Validate(log)
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
@@ -157,6 +163,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.ClientTLSConfig.RegisterFlagsWithPrefix("ruler.client", f)
 	cfg.Ring.RegisterFlags(f)
 	cfg.Notifier.RegisterFlags(f)
+	cfg.RemoteEvaluation.RegisterFlags(f)
+	cfg.WAL.RegisterFlags(f)
 
 	// Deprecated Flags that will be maintained to avoid user disruption
 
@@ -75,3 +75,70 @@ func FromProto(rg *RuleGroupDesc) rulefmt.RuleGroup {
 
 	return formattedRuleGroup
 }
+
+// RuleGroupOptions holds the Cortex specific rule group options. They're not part of the
+// Prometheus rule group format, so they're ignored when rule groups are mapped to disk.
+type RuleGroupOptions struct {
+	Downsampling *DownsamplingConfig `yaml:"downsampling,omitempty"`
+	QueryLimits  *QueryLimitsConfig  `yaml:"query_limits,omitempty"`
+}
+
+// DownsamplingConfig configures the evaluation of a rule group against downsampled data.
+type DownsamplingConfig struct {
+	MaxResolution model.Duration `yaml:"max_resolution"`
+	MinStep       model.Duration `yaml:"min_step,omitempty"`
+}
+
+// DownsamplingToProto transforms the downsampling config to its protobuf representation.
+func DownsamplingToProto(cfg *DownsamplingConfig) *RuleGroupDownsampling {
+	if cfg == nil {
+		return nil
+	}
+	return &RuleGroupDownsampling{
+		MaxResolution: time.Duration(cfg.MaxResolution),
+		MinStep:       time.Duration(cfg.MinStep),
+	}
+}
+
+// DownsamplingFromProto generates the downsampling config from its protobuf representation.
+func DownsamplingFromProto(d *RuleGroupDownsampling) *DownsamplingConfig {
+	if d == nil {
+		return nil
+	}
+	return &DownsamplingConfig{
+		MaxResolution: model.Duration(d.MaxResolution),
+		MinStep:       model.Duration(d.MinStep),
+	}
+}
+
+// QueryLimitsConfig overrides the query limits of the rule queries of a rule group. A zero
+// limit keeps the limit of the tenant.
+type QueryLimitsConfig struct {
+	MaxSamples       int            `yaml:"max_samples,omitempty"`
+	Timeout          model.Duration `yaml:"timeout,omitempty"`
+	MaxFetchedChunks int            `yaml:"max_fetched_chunks,omitempty"`
+}
+
+// QueryLimitsToProto transforms the query limits config to its protobuf representation.
+func QueryLimitsToProto(cfg *QueryLimitsConfig) *RuleGroupQueryLimits {
+	if cfg == nil {
+		return nil
+	}
+	return &RuleGroupQueryLimits{
+		MaxSamples:       int64(cfg.MaxSamples),
+		Timeout:          time.Duration(cfg.Timeout),
+		MaxFetchedChunks: int64(cfg.MaxFetchedChunks),
+	}
+}
+
+// QueryLimitsFromProto generates the query limits config from its protobuf representation.
+func QueryLimitsFromProto(l *RuleGroupQueryLimits) *QueryLimitsConfig {
+	if l == nil {
+		return nil
+	}
+	return &QueryLimitsConfig{
+		MaxSamples:       int(l.MaxSamples),
+		Timeout:          model.Duration(l.Timeout),
+		MaxFetchedChunks: int(l.MaxFetchedChunks),
+	}
+}
@@ -45,6 +45,12 @@ type RuleGroupDesc struct {
 	// to the Prometheus Manager.
 	Options []*types.Any `protobuf:"bytes,9,rep,name=options,proto3" json:"options,omitempty"`
 	Limit   int64        `protobuf:"varint,10,opt,name=limit,proto3" json:"limit,omitempty"`
+	// The downsampling options allow long-range rule groups to be evaluated
+	// against downsampled blocks instead of raw chunks.
+	Downsampling *RuleGroupDownsampling `protobuf:"bytes,11,opt,name=downsampling,proto3" json:"downsampling,omitempty"`
+	// The query limits overrides of the rule queries, distinct from the limits
+	// of the interactive queries.
+	QueryLimits *RuleGroupQueryLimits `protobuf:"bytes,12,opt,name=query_limits,json=queryLimits,proto3" json:"query_limits,omitempty"`
 }
 
 func (m *RuleGroupDesc) Reset()      { *m = RuleGroupDesc{} }
@@ -128,6 +134,134 @@ func (m *RuleGroupDesc) GetLimit() int64 {
 	return 0
 }
 
+func (m *RuleGroupDesc) GetDownsampling() *RuleGroupDownsampling {
+	if m != nil {
+		return m.Downsampling
+	}
+	return nil
+}
+
+func (m *RuleGroupDesc) GetQueryLimits() *RuleGroupQueryLimits {
+	if m != nil {
+		return m.QueryLimits
+	}
+	return nil
+}
+
+// RuleGroupDownsampling holds the options to evaluate a rule group against
+// downsampled data.
+type RuleGroupDownsampling struct {
+	MaxResolution time.Duration `protobuf:"bytes,1,opt,name=max_resolution,json=maxResolution,proto3,stdduration" json:"max_resolution"`
+	MinStep       time.Duration `protobuf:"bytes,2,opt,name=min_step,json=minStep,proto3,stdduration" json:"min_step"`
+}
+
+func (m *RuleGroupDownsampling) Reset()      { *m = RuleGroupDownsampling{} }
+func (*RuleGroupDownsampling) ProtoMessage() {}
+func (*RuleGroupDownsampling) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e722d3e922f0937, []int{1}
+}
+func (m *RuleGroupDownsampling) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RuleGroupDownsampling) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RuleGroupDownsampling.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RuleGroupDownsampling) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RuleGroupDownsampling.Merge(m, src)
+}
+func (m *RuleGroupDownsampling) XXX_Size() int {
+	return m.Size()
+}
+func (m *RuleGroupDownsampling) XXX_DiscardUnknown() {
+	xxx_messageInfo_RuleGroupDownsampling.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RuleGroupDownsampling proto.InternalMessageInfo
+
+func (m *RuleGroupDownsampling) GetMaxResolution() time.Duration {
+	if m != nil {
+		return m.MaxResolution
+	}
+	return 0
+}
+
+func (m *RuleGroupDownsampling) GetMinStep() time.Duration {
+	if m != nil {
+		return m.MinStep
+	}
+	return 0
+}
+
+// RuleGroupQueryLimits holds the query limits overrides of the rule queries
+// of a rule group.
+type RuleGroupQueryLimits struct {
+	MaxSamples       int64         `protobuf:"varint,1,opt,name=max_samples,json=maxSamples,proto3" json:"max_samples,omitempty"`
+	Timeout          time.Duration `protobuf:"bytes,2,opt,name=timeout,proto3,stdduration" json:"timeout"`
+	MaxFetchedChunks int64         `protobuf:"varint,3,opt,name=max_fetched_chunks,json=maxFetchedChunks,proto3" json:"max_fetched_chunks,omitempty"`
+}
+
+func (m *RuleGroupQueryLimits) Reset()      { *m = RuleGroupQueryLimits{} }
+func (*RuleGroupQueryLimits) ProtoMessage() {}
+func (*RuleGroupQueryLimits) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e722d3e922f0937, []int{2}
+}
+func (m *RuleGroupQueryLimits) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RuleGroupQueryLimits) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RuleGroupQueryLimits.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RuleGroupQueryLimits) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RuleGroupQueryLimits.Merge(m, src)
+}
+func (m *RuleGroupQueryLimits) XXX_Size() int {
+	return m.Size()
+}
+func (m *RuleGroupQueryLimits) XXX_DiscardUnknown() {
+	xxx_messageInfo_RuleGroupQueryLimits.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RuleGroupQueryLimits proto.InternalMessageInfo
+
+func (m *RuleGroupQueryLimits) GetMaxSamples() int64 {
+	if m != nil {
+		return m.MaxSamples
+	}
+	return 0
+}
+
+func (m *RuleGroupQueryLimits) GetTimeout() time.Duration {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *RuleGroupQueryLimits) GetMaxFetchedChunks() int64 {
+	if m != nil {
+		return m.MaxFetchedChunks
+	}
+	return 0
+}
+
 // RuleDesc is a proto representation of a Prometheus Rule
 type RuleDesc struct {
 	Expr          string                                                      `protobuf:"bytes,1,opt,name=expr,proto3" json:"expr,omitempty"`
@@ -142,7 +276,7 @@ type RuleDesc struct {
 func (m *RuleDesc) Reset()      { *m = RuleDesc{} }
 func (*RuleDesc) ProtoMessage() {}
 func (*RuleDesc) Descriptor() ([]byte, []int) {
-	return fileDescriptor_8e722d3e922f0937, []int{1}
+	return fileDescriptor_8e722d3e922f0937, []int{3}
 }
 func (m *RuleDesc) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -208,46 +342,59 @@ func (m *RuleDesc) GetKeepFiringFor() time.Duration {
 
 func init() {
 	proto.RegisterType((*RuleGroupDesc)(nil), "rules.RuleGroupDesc")
+	proto.RegisterType((*RuleGroupDownsampling)(nil), "rules.RuleGroupDownsampling")
+	proto.RegisterType((*RuleGroupQueryLimits)(nil), "rules.RuleGroupQueryLimits")
 	proto.RegisterType((*RuleDesc)(nil), "rules.RuleDesc")
 }
 
 func init() { proto.RegisterFile("rules.proto", fileDescriptor_8e722d3e922f0937) }
 
 var fileDescriptor_8e722d3e922f0937 = []byte{
-	// 524 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x52, 0x4f, 0x6f, 0xd3, 0x30,
-	0x1c, 0x8d, 0xd7, 0x34, 0x4b, 0x5d, 0x55, 0xab, 0x4c, 0x85, 0xbc, 0x81, 0xdc, 0x6a, 0x12, 0x52,
-	0x4f, 0x89, 0x34, 0xc4, 0x81, 0x03, 0x42, 0xad, 0xa6, 0x21, 0x55, 0x1c, 0x50, 0x8e, 0x08, 0x69,
-	0x72, 0x52, 0x37, 0x84, 0xa5, 0x71, 0xe4, 0x38, 0x68, 0xbb, 0xf1, 0x11, 0xb8, 0x20, 0xf1, 0x11,
-	0xf8, 0x28, 0x3b, 0x96, 0xdb, 0xc4, 0xa1, 0xd0, 0xf4, 0x82, 0x38, 0xed, 0x23, 0x20, 0xdb, 0x09,
-	0x7f, 0x0f, 0xc0, 0x81, 0x53, 0x7e, 0xcf, 0x2f, 0xcf, 0xef, 0xf9, 0xd9, 0xb0, 0x2b, 0xca, 0x94,
-	0x15, 0x5e, 0x2e, 0xb8, 0xe4, 0xa8, 0xad, 0xc1, 0xc1, 0x20, 0xe6, 0x31, 0xd7, 0x2b, 0xbe, 0x9a,
-	0x0c, 0x79, 0x40, 0x62, 0xce, 0xe3, 0x94, 0xf9, 0x1a, 0x85, 0xe5, 0xc2, 0x9f, 0x97, 0x82, 0xca,
-	0x84, 0x67, 0x35, 0xbf, 0xff, 0x2b, 0x4f, 0xb3, 0x8b, 0x9a, 0xba, 0x1f, 0x27, 0xf2, 0x79, 0x19,
-	0x7a, 0x11, 0x5f, 0xfa, 0x11, 0x17, 0x92, 0x9d, 0xe7, 0x82, 0xbf, 0x60, 0x91, 0xac, 0x91, 0x9f,
-	0x9f, 0xc5, 0x0d, 0x11, 0xd6, 0x83, 0x91, 0x1e, 0xbe, 0xd9, 0x81, 0xbd, 0xa0, 0x4c, 0xd9, 0x23,
-	0xc1, 0xcb, 0xfc, 0x98, 0x15, 0x11, 0x42, 0xd0, 0xce, 0xe8, 0x92, 0x61, 0x30, 0x02, 0xe3, 0x4e,
-	0xa0, 0x67, 0x74, 0x1b, 0x76, 0xd4, 0xb7, 0xc8, 0x69, 0xc4, 0xf0, 0x8e, 0x26, 0xbe, 0x2f, 0xa0,
-	0x87, 0xd0, 0x4d, 0x32, 0xc9, 0xc4, 0x4b, 0x9a, 0xe2, 0xd6, 0x08, 0x8c, 0xbb, 0x47, 0xfb, 0x9e,
-	0x09, 0xeb, 0x35, 0x61, 0xbd, 0xe3, 0xfa, 0x30, 0x53, 0xf7, 0x72, 0x3d, 0xb4, 0xde, 0x7e, 0x1c,
-	0x82, 0xe0, 0x9b, 0x08, 0xdd, 0x81, 0xa6, 0x19, 0x6c, 0x8f, 0x5a, 0xe3, 0xee, 0xd1, 0x9e, 0x67,
-	0x4a, 0x53, 0xb9, 0x54, 0xa4, 0xc0, 0xb0, 0x2a, 0x59, 0x59, 0x30, 0x81, 0x1d, 0x93, 0x4c, 0xcd,
-	0xc8, 0x83, 0xbb, 0x3c, 0x57, 0x1b, 0x17, 0xb8, 0xa3, 0xc5, 0x83, 0xdf, 0xac, 0x27, 0xd9, 0x45,
-	0xd0, 0xfc, 0x84, 0x06, 0xb0, 0x9d, 0x26, 0xcb, 0x44, 0x62, 0x38, 0x02, 0xe3, 0x56, 0x60, 0xc0,
-	0xcc, 0x76, 0xdb, 0x7d, 0x67, 0x66, 0xbb, 0xbb, 0x7d, 0x77, 0x66, 0xbb, 0x6e, 0xbf, 0x73, 0xf8,
-	0xbe, 0x05, 0xdd, 0xc6, 0x5f, 0x19, 0xab, 0x4a, 0x9b, 0x4a, 0xd4, 0x8c, 0x6e, 0x42, 0x47, 0xb0,
-	0x88, 0x8b, 0x79, 0xdd, 0x47, 0x8d, 0x94, 0x01, 0x4d, 0x99, 0x90, 0xba, 0x89, 0x4e, 0x60, 0x00,
-	0xba, 0x07, 0x5b, 0x0b, 0x2e, 0xb0, 0xfd, 0xf7, 0xed, 0xa8, 0xff, 0x51, 0x06, 0x9d, 0x94, 0x86,
-	0x2c, 0x2d, 0x70, 0x5b, 0x1f, 0xee, 0x86, 0xd7, 0xdc, 0xa2, 0xf7, 0x58, 0xad, 0x3f, 0xa1, 0x89,
-	0x98, 0x4e, 0x94, 0xe6, 0xc3, 0x7a, 0xf8, 0x4f, 0xaf, 0xc0, 0xe8, 0x27, 0x73, 0x9a, 0x4b, 0x26,
-	0x82, 0xda, 0x05, 0x9d, 0xc3, 0x2e, 0xcd, 0x32, 0x2e, 0xa9, 0x69, 0xd4, 0xf9, 0xaf, 0xa6, 0x3f,
-	0x5a, 0xa1, 0x67, 0xb0, 0x77, 0xc6, 0x58, 0x7e, 0x92, 0x88, 0x24, 0x8b, 0x4f, 0xb8, 0xc0, 0xbd,
-	0x3f, 0x55, 0x75, 0x4b, 0x25, 0xf8, 0xb2, 0x1e, 0xee, 0x29, 0xdd, 0xe9, 0x42, 0x0b, 0x4f, 0x17,
-	0x5c, 0xe8, 0xf6, 0x7e, 0xde, 0x4c, 0xdf, 0x6c, 0x6f, 0xfa, 0x60, 0xb5, 0x21, 0xd6, 0xd5, 0x86,
-	0x58, 0xd7, 0x1b, 0x02, 0x5e, 0x55, 0x04, 0xbc, 0xab, 0x08, 0xb8, 0xac, 0x08, 0x58, 0x55, 0x04,
-	0x7c, 0xaa, 0x08, 0xf8, 0x5c, 0x11, 0xeb, 0xba, 0x22, 0xe0, 0xf5, 0x96, 0x58, 0xab, 0x2d, 0xb1,
-	0xae, 0xb6, 0xc4, 0x7a, 0xba, 0xab, 0x1f, 0x5f, 0x1e, 0x86, 0x8e, 0xce, 0x70, 0xf7, 0x6b, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x77, 0x8c, 0xa0, 0x4e, 0xd3, 0x03, 0x00, 0x00,
+	// 698 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x53, 0x3b, 0x6f, 0x13, 0x4b,
+	0x14, 0xf6, 0x64, 0xfd, 0x58, 0x8f, 0xe3, 0x1b, 0x6b, 0xae, 0xef, 0xd5, 0xe6, 0xa1, 0xb5, 0x65,
+	0xe9, 0x4a, 0x2e, 0xae, 0xd6, 0x52, 0x10, 0x05, 0x45, 0x02, 0x09, 0x51, 0x90, 0xac, 0x14, 0xb0,
+	0xe9, 0x10, 0x92, 0x35, 0x5e, 0x8f, 0x37, 0x4b, 0x76, 0x67, 0x36, 0xb3, 0xb3, 0xe0, 0x74, 0xfc,
+	0x04, 0x3a, 0xe8, 0xa1, 0xe0, 0xa7, 0xa4, 0x0c, 0x5d, 0x44, 0x11, 0xc8, 0xa6, 0x41, 0x54, 0xf9,
+	0x09, 0x68, 0x66, 0xd6, 0x79, 0x11, 0x89, 0xa4, 0xa0, 0x9a, 0xf3, 0xfa, 0xce, 0xf9, 0xe6, 0xe8,
+	0x3b, 0xb0, 0xc6, 0xd3, 0x90, 0x24, 0x4e, 0xcc, 0x99, 0x60, 0xa8, 0xa4, 0x9c, 0x85, 0xa6, 0xcf,
+	0x7c, 0xa6, 0x22, 0x3d, 0x69, 0xe9, 0xe4, 0x82, 0xed, 0x33, 0xe6, 0x87, 0xa4, 0xa7, 0xbc, 0x61,
+	0x3a, 0xee, 0x8d, 0x52, 0x8e, 0x45, 0xc0, 0x68, 0x9e, 0x9f, 0xbf, 0x9e, 0xc7, 0x74, 0x3f, 0x4f,
+	0x3d, 0xf0, 0x03, 0xb1, 0x93, 0x0e, 0x1d, 0x8f, 0x45, 0x3d, 0x8f, 0x71, 0x41, 0x26, 0x31, 0x67,
+	0x2f, 0x89, 0x27, 0x72, 0xaf, 0x17, 0xef, 0xfa, 0xd3, 0xc4, 0x30, 0x37, 0x34, 0xb4, 0xf3, 0xce,
+	0x80, 0x75, 0x37, 0x0d, 0xc9, 0x13, 0xce, 0xd2, 0x78, 0x83, 0x24, 0x1e, 0x42, 0xb0, 0x48, 0x71,
+	0x44, 0x2c, 0xd0, 0x06, 0xdd, 0xaa, 0xab, 0x6c, 0xb4, 0x04, 0xab, 0xf2, 0x4d, 0x62, 0xec, 0x11,
+	0x6b, 0x46, 0x25, 0x2e, 0x02, 0xe8, 0x21, 0x34, 0x03, 0x2a, 0x08, 0x7f, 0x85, 0x43, 0xcb, 0x68,
+	0x83, 0x6e, 0x6d, 0x79, 0xde, 0xd1, 0x64, 0x9d, 0x29, 0x59, 0x67, 0x23, 0xff, 0xcc, 0xba, 0x79,
+	0x70, 0xdc, 0x2a, 0xbc, 0xff, 0xda, 0x02, 0xee, 0x39, 0x08, 0xfd, 0x07, 0xf5, 0x66, 0xac, 0x62,
+	0xdb, 0xe8, 0xd6, 0x96, 0xe7, 0x1c, 0xe5, 0x39, 0x92, 0x97, 0xa4, 0xe4, 0xea, 0xac, 0x64, 0x96,
+	0x26, 0x84, 0x5b, 0x65, 0xcd, 0x4c, 0xda, 0xc8, 0x81, 0x15, 0x16, 0xcb, 0xc6, 0x89, 0x55, 0x55,
+	0xe0, 0xe6, 0x2f, 0xa3, 0xd7, 0xe8, 0xbe, 0x3b, 0x2d, 0x42, 0x4d, 0x58, 0x0a, 0x83, 0x28, 0x10,
+	0x16, 0x6c, 0x83, 0xae, 0xe1, 0x6a, 0x07, 0x3d, 0x82, 0xb3, 0x23, 0xf6, 0x9a, 0x26, 0x38, 0x8a,
+	0xc3, 0x80, 0xfa, 0x56, 0x4d, 0xfd, 0x62, 0xe9, 0x12, 0x0f, 0xbd, 0x9f, 0x4b, 0x35, 0xee, 0x15,
+	0x04, 0x5a, 0x85, 0xb3, 0x7b, 0x29, 0xe1, 0xfb, 0x03, 0xd5, 0x30, 0xb1, 0x66, 0x55, 0x87, 0xc5,
+	0xeb, 0x1d, 0x9e, 0xc9, 0x9a, 0x2d, 0x55, 0xe2, 0xd6, 0xf6, 0x2e, 0x9c, 0x7e, 0xd1, 0x2c, 0x35,
+	0xca, 0xfd, 0xa2, 0x59, 0x69, 0x98, 0xfd, 0xa2, 0x69, 0x36, 0xaa, 0x9d, 0x0f, 0x00, 0xfe, 0x73,
+	0xe3, 0x64, 0xd4, 0x87, 0x7f, 0x45, 0x78, 0x32, 0xe0, 0x24, 0x61, 0x61, 0x2a, 0xbf, 0x65, 0x81,
+	0xdb, 0x6f, 0xbd, 0x1e, 0xe1, 0x89, 0x7b, 0x8e, 0x44, 0xab, 0xd0, 0x8c, 0x02, 0x3a, 0x48, 0x04,
+	0x89, 0xad, 0x99, 0xdb, 0x77, 0xa9, 0x44, 0x01, 0xdd, 0x16, 0x24, 0xee, 0x7c, 0x04, 0xb0, 0x79,
+	0xd3, 0xef, 0x50, 0x0b, 0xd6, 0x24, 0x49, 0x45, 0x9a, 0x24, 0x8a, 0xa1, 0xe1, 0xc2, 0x08, 0x4f,
+	0xb6, 0x75, 0x04, 0xad, 0xc0, 0x8a, 0x08, 0x22, 0xc2, 0x52, 0x71, 0xa7, 0xc1, 0x39, 0x06, 0xfd,
+	0x0f, 0x91, 0xec, 0x3f, 0x26, 0xc2, 0xdb, 0x21, 0xa3, 0x81, 0xb7, 0x93, 0xd2, 0xdd, 0x44, 0xc9,
+	0xcf, 0x70, 0x1b, 0x11, 0x9e, 0x6c, 0xea, 0xc4, 0x63, 0x15, 0xef, 0x7c, 0x36, 0xa0, 0x39, 0x95,
+	0x93, 0xd4, 0x91, 0xbc, 0x90, 0xa9, 0xc2, 0xa5, 0x8d, 0xfe, 0x85, 0x65, 0x4e, 0x3c, 0xc6, 0x47,
+	0xb9, 0xbc, 0x73, 0x4f, 0xea, 0x05, 0x87, 0x84, 0x0b, 0xd5, 0xb9, 0xea, 0x6a, 0x07, 0xdd, 0x87,
+	0xc6, 0x98, 0x71, 0xab, 0x78, 0x7b, 0xde, 0xb2, 0x1e, 0x51, 0x58, 0x0e, 0xf1, 0x90, 0x84, 0x89,
+	0x55, 0x52, 0x5a, 0xfd, 0xdb, 0x99, 0x1e, 0xa5, 0xb3, 0x25, 0xe3, 0x4f, 0x71, 0xc0, 0xd7, 0xd7,
+	0x24, 0xe6, 0xcb, 0x71, 0xeb, 0x4e, 0x47, 0xad, 0xf1, 0x6b, 0x23, 0x1c, 0x0b, 0xc2, 0xdd, 0x7c,
+	0x0a, 0x9a, 0xc0, 0x1a, 0xa6, 0x94, 0x09, 0xac, 0x0f, 0xa4, 0xfc, 0x47, 0x87, 0x5e, 0x1e, 0x85,
+	0x5e, 0xc0, 0xfa, 0x2e, 0x21, 0xf1, 0x66, 0xc0, 0x03, 0xea, 0x6f, 0x32, 0x6e, 0xd5, 0x7f, 0xb7,
+	0xaa, 0x45, 0xc9, 0xe0, 0xc7, 0x71, 0x6b, 0x4e, 0xe2, 0x06, 0x63, 0x05, 0x1c, 0x8c, 0x19, 0xd7,
+	0xa2, 0xbd, 0xd2, 0x4c, 0x9d, 0x49, 0x7d, 0x7d, 0xe5, 0xf0, 0xc4, 0x2e, 0x1c, 0x9d, 0xd8, 0x85,
+	0xb3, 0x13, 0x1b, 0xbc, 0xc9, 0x6c, 0xf0, 0x29, 0xb3, 0xc1, 0x41, 0x66, 0x83, 0xc3, 0xcc, 0x06,
+	0xdf, 0x32, 0x1b, 0x7c, 0xcf, 0xec, 0xc2, 0x59, 0x66, 0x83, 0xb7, 0xa7, 0x76, 0xe1, 0xf0, 0xd4,
+	0x2e, 0x1c, 0x9d, 0xda, 0x85, 0xe7, 0x15, 0x75, 0x91, 0xf1, 0x70, 0x58, 0x56, 0x1c, 0xee, 0xfd,
+	0x1c, 0x00, 0x9f, 0x11, 0xb1, 0xea, 0xa2, 0x05, 0x00, 0x00,
 }
 
 func (this *RuleGroupDesc) Equal(that interface{}) bool {
@@ -300,6 +447,69 @@ func (this *RuleGroupDesc) Equal(that interface{}) bool {
 	if this.Limit != that1.Limit {
 		return false
 	}
+	if !this.Downsampling.Equal(that1.Downsampling) {
+		return false
+	}
+	if !this.QueryLimits.Equal(that1.QueryLimits) {
+		return false
+	}
+	return true
+}
+func (this *RuleGroupDownsampling) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*RuleGroupDownsampling)
+	if !ok {
+		that2, ok := that.(RuleGroupDownsampling)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.MaxResolution != that1.MaxResolution {
+		return false
+	}
+	if this.MinStep != that1.MinStep {
+		return false
+	}
+	return true
+}
+func (this *RuleGroupQueryLimits) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*RuleGroupQueryLimits)
+	if !ok {
+		that2, ok := that.(RuleGroupQueryLimits)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.MaxSamples != that1.MaxSamples {
+		return false
+	}
+	if this.Timeout != that1.Timeout {
+		return false
+	}
+	if this.MaxFetchedChunks != that1.MaxFetchedChunks {
+		return false
+	}
 	return true
 }
 func (this *RuleDesc) Equal(that interface{}) bool {
@@ -358,7 +568,7 @@ func (this *RuleGroupDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 11)
+	s := make([]string, 0, 13)
 	s = append(s, "&rulespb.RuleGroupDesc{")
 	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
 	s = append(s, "Namespace: "+fmt.Sprintf("%#v", this.Namespace)+",\n")
@@ -371,6 +581,35 @@ func (this *RuleGroupDesc) GoString() string {
 		s = append(s, "Options: "+fmt.Sprintf("%#v", this.Options)+",\n")
 	}
 	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	if this.Downsampling != nil {
+		s = append(s, "Downsampling: "+fmt.Sprintf("%#v", this.Downsampling)+",\n")
+	}
+	if this.QueryLimits != nil {
+		s = append(s, "QueryLimits: "+fmt.Sprintf("%#v", this.QueryLimits)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *RuleGroupDownsampling) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&rulespb.RuleGroupDownsampling{")
+	s = append(s, "MaxResolution: "+fmt.Sprintf("%#v", this.MaxResolution)+",\n")
+	s = append(s, "MinStep: "+fmt.Sprintf("%#v", this.MinStep)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *RuleGroupQueryLimits) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&rulespb.RuleGroupQueryLimits{")
+	s = append(s, "MaxSamples: "+fmt.Sprintf("%#v", this.MaxSamples)+",\n")
+	s = append(s, "Timeout: "+fmt.Sprintf("%#v", this.Timeout)+",\n")
+	s = append(s, "MaxFetchedChunks: "+fmt.Sprintf("%#v", this.MaxFetchedChunks)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -418,6 +657,30 @@ func (m *RuleGroupDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.QueryLimits != nil {
+		{
+			size, err := m.QueryLimits.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRules(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x62
+	}
+	if m.Downsampling != nil {
+		{
+			size, err := m.Downsampling.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRules(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x5a
+	}
 	if m.Limit != 0 {
 		i = encodeVarintRules(dAtA, i, uint64(m.Limit))
 		i--
@@ -458,12 +721,12 @@ func (m *RuleGroupDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0x22
 		}
 	}
-	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Interval, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Interval):])
-	if err1 != nil {
-		return 0, err1
+	n3, err3 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Interval, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Interval):])
+	if err3 != nil {
+		return 0, err3
 	}
-	i -= n1
-	i = encodeVarintRules(dAtA, i, uint64(n1))
+	i -= n3
+	i = encodeVarintRules(dAtA, i, uint64(n3))
 	i--
 	dAtA[i] = 0x1a
 	if len(m.Namespace) > 0 {
@@ -483,6 +746,86 @@ func (m *RuleGroupDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *RuleGroupDownsampling) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RuleGroupDownsampling) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RuleGroupDownsampling) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	n4, err4 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.MinStep, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.MinStep):])
+	if err4 != nil {
+		return 0, err4
+	}
+	i -= n4
+	i = encodeVarintRules(dAtA, i, uint64(n4))
+	i--
+	dAtA[i] = 0x12
+	n5, err5 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.MaxResolution, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.MaxResolution):])
+	if err5 != nil {
+		return 0, err5
+	}
+	i -= n5
+	i = encodeVarintRules(dAtA, i, uint64(n5))
+	i--
+	dAtA[i] = 0xa
+	return len(dAtA) - i, nil
+}
+
+func (m *RuleGroupQueryLimits) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RuleGroupQueryLimits) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RuleGroupQueryLimits) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.MaxFetchedChunks != 0 {
+		i = encodeVarintRules(dAtA, i, uint64(m.MaxFetchedChunks))
+		i--
+		dAtA[i] = 0x18
+	}
+	n6, err6 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Timeout, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout):])
+	if err6 != nil {
+		return 0, err6
+	}
+	i -= n6
+	i = encodeVarintRules(dAtA, i, uint64(n6))
+	i--
+	dAtA[i] = 0x12
+	if m.MaxSamples != 0 {
+		i = encodeVarintRules(dAtA, i, uint64(m.MaxSamples))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *RuleDesc) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -503,12 +846,12 @@ func (m *RuleDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
-	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.KeepFiringFor, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.KeepFiringFor):])
-	if err2 != nil {
-		return 0, err2
+	n7, err7 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.KeepFiringFor, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.KeepFiringFor):])
+	if err7 != nil {
+		return 0, err7
 	}
-	i -= n2
-	i = encodeVarintRules(dAtA, i, uint64(n2))
+	i -= n7
+	i = encodeVarintRules(dAtA, i, uint64(n7))
 	i--
 	dAtA[i] = 0x6a
 	if len(m.Annotations) > 0 {
@@ -539,12 +882,12 @@ func (m *RuleDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0x2a
 		}
 	}
-	n3, err3 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.For, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.For):])
-	if err3 != nil {
-		return 0, err3
+	n8, err8 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.For, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.For):])
+	if err8 != nil {
+		return 0, err8
 	}
-	i -= n3
-	i = encodeVarintRules(dAtA, i, uint64(n3))
+	i -= n8
+	i = encodeVarintRules(dAtA, i, uint64(n8))
 	i--
 	dAtA[i] = 0x22
 	if len(m.Alert) > 0 {
@@ -617,6 +960,44 @@ func (m *RuleGroupDesc) Size() (n int) {
 	if m.Limit != 0 {
 		n += 1 + sovRules(uint64(m.Limit))
 	}
+	if m.Downsampling != nil {
+		l = m.Downsampling.Size()
+		n += 1 + l + sovRules(uint64(l))
+	}
+	if m.QueryLimits != nil {
+		l = m.QueryLimits.Size()
+		n += 1 + l + sovRules(uint64(l))
+	}
+	return n
+}
+
+func (m *RuleGroupDownsampling) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.MaxResolution)
+	n += 1 + l + sovRules(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.MinStep)
+	n += 1 + l + sovRules(uint64(l))
+	return n
+}
+
+func (m *RuleGroupQueryLimits) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.MaxSamples != 0 {
+		n += 1 + sovRules(uint64(m.MaxSamples))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout)
+	n += 1 + l + sovRules(uint64(l))
+	if m.MaxFetchedChunks != 0 {
+		n += 1 + sovRules(uint64(m.MaxFetchedChunks))
+	}
 	return n
 }
 
@@ -685,6 +1066,31 @@ func (this *RuleGroupDesc) String() string {
 		`User:` + fmt.Sprintf("%v", this.User) + `,`,
 		`Options:` + repeatedStringForOptions + `,`,
 		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`Downsampling:` + strings.Replace(this.Downsampling.String(), "RuleGroupDownsampling", "RuleGroupDownsampling", 1) + `,`,
+		`QueryLimits:` + strings.Replace(this.QueryLimits.String(), "RuleGroupQueryLimits", "RuleGroupQueryLimits", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *RuleGroupDownsampling) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&RuleGroupDownsampling{`,
+		`MaxResolution:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.MaxResolution), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`MinStep:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.MinStep), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *RuleGroupQueryLimits) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&RuleGroupQueryLimits{`,
+		`MaxSamples:` + fmt.Sprintf("%v", this.MaxSamples) + `,`,
+		`Timeout:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Timeout), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`MaxFetchedChunks:` + fmt.Sprintf("%v", this.MaxFetchedChunks) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -958,6 +1364,318 @@ func (m *RuleGroupDesc) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Downsampling", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Downsampling == nil {
+				m.Downsampling = &RuleGroupDownsampling{}
+			}
+			if err := m.Downsampling.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryLimits", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.QueryLimits == nil {
+				m.QueryLimits = &RuleGroupQueryLimits{}
+			}
+			if err := m.QueryLimits.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRules(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRules
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRules
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RuleGroupDownsampling) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRules
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RuleGroupDownsampling: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RuleGroupDownsampling: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxResolution", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.MaxResolution, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinStep", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.MinStep, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRules(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRules
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RuleGroupQueryLimits) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRules
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RuleGroupQueryLimits: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RuleGroupQueryLimits: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxSamples", wireType)
+			}
+			m.MaxSamples = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxSamples |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeout", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.Timeout, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxFetchedChunks", wireType)
+			}
+			m.MaxFetchedChunks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxFetchedChunks |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRules(dAtA[iNdEx:])
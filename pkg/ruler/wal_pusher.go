@@ -0,0 +1,304 @@
+package ruler
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+// WALConfig configures the write-ahead log queueing the rule results before they're pushed.
+type WALConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Dir         string        `yaml:"dir"`
+	FlushPeriod time.Duration `yaml:"flush_period"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *WALConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "ruler.wal.enabled", false, "Experimental: Queue the results of the rules in a write-ahead log on disk before pushing them, so that they're pushed once the distributors are back instead of being lost on transient outages. The rule evaluations fail once the write-ahead log of the tenant reaches the ruler_wal_max_size_bytes limit.")
+	f.StringVar(&cfg.Dir, "ruler.wal.dir", "./ruler-wal/", "Directory of the write-ahead logs of the tenants.")
+	f.DurationVar(&cfg.FlushPeriod, "ruler.wal.flush-period", 5*time.Second, "How frequently the queued rule results are pushed. The failed pushes are retried on the next period.")
+}
+
+// WALLimits defines the limits of the write-ahead logs of the rule results.
+type WALLimits interface {
+	RulerWALMaxSizeBytes(userID string) int
+}
+
+// WALPusher is a Pusher queueing the rule results of each tenant in a write-ahead log on disk,
+// pushed in the background to the next Pusher. The results are pushed in order, and the pushes
+// failing for another reason than a client error are retried until they succeed, so that the
+// transient distributors outages don't lose recording rule samples.
+type WALPusher struct {
+	services.Service
+
+	cfg    WALConfig
+	next   Pusher
+	limits WALLimits
+	logger log.Logger
+
+	queuesMtx sync.Mutex
+	queues    map[string]*walQueue
+
+	pushedSamples  *prometheus.CounterVec
+	droppedSamples *prometheus.CounterVec
+	pushFailures   *prometheus.CounterVec
+	rejectedWrites *prometheus.CounterVec
+	diskUsage      *prometheus.GaugeVec
+	pendingSegment *prometheus.GaugeVec
+}
+
+type walQueue struct {
+	userID string
+	wal    *wlog.WL
+
+	// Serializes the flushes of the queue.
+	flushMtx sync.Mutex
+	// The number of records of the oldest segment already pushed.
+	pushedRecords int
+}
+
+// NewWALPusher makes a new WALPusher.
+func NewWALPusher(cfg WALConfig, next Pusher, limits WALLimits, logger log.Logger, reg prometheus.Registerer) *WALPusher {
+	p := &WALPusher{
+		cfg:    cfg,
+		next:   next,
+		limits: limits,
+		logger: logger,
+		queues: map[string]*walQueue{},
+		pushedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_wal_pushed_samples_total",
+			Help: "Total number of samples of the rule results pushed from the write-ahead log.",
+		}, []string{"user"}),
+		droppedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_wal_dropped_samples_total",
+			Help: "Total number of samples of the rule results dropped from the write-ahead log, since rejected by the distributors with a client error.",
+		}, []string{"user"}),
+		pushFailures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_wal_push_failures_total",
+			Help: "Total number of failed pushes of the rule results from the write-ahead log, retried on the next flush.",
+		}, []string{"user"}),
+		rejectedWrites: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_wal_rejected_writes_total",
+			Help: "Total number of rule results rejected since the write-ahead log of the tenant is full.",
+		}, []string{"user"}),
+		diskUsage: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ruler_wal_disk_usage_bytes",
+			Help: "Size on disk of the write-ahead log of the rule results of the tenant.",
+		}, []string{"user"}),
+		pendingSegment: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ruler_wal_pending_segments",
+			Help: "Number of segments of the write-ahead log of the tenant not pushed yet.",
+		}, []string{"user"}),
+	}
+
+	p.Service = services.NewTimerService(cfg.FlushPeriod, p.starting, p.flush, p.stopping)
+	return p
+}
+
+func (p *WALPusher) starting(_ context.Context) error {
+	if err := os.MkdirAll(p.cfg.Dir, os.ModePerm); err != nil {
+		return errors.Wrap(err, "create the ruler write-ahead logs directory")
+	}
+
+	// The results queued before the restart are pushed, even if the tenant has no rules anymore.
+	entries, err := os.ReadDir(p.cfg.Dir)
+	if err != nil {
+		return errors.Wrap(err, "list the ruler write-ahead logs")
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := p.queue(e.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *WALPusher) flush(ctx context.Context) error {
+	for _, q := range p.queuesSnapshot() {
+		if err := p.flushQueue(ctx, q); err != nil {
+			level.Warn(p.logger).Log("msg", "failed to push the rule results queued in the write-ahead log", "user", q.userID, "err", err)
+		}
+	}
+	return nil
+}
+
+func (p *WALPusher) stopping(_ error) error {
+	// The results still queued are pushed on the next start.
+	for _, q := range p.queuesSnapshot() {
+		if err := q.wal.Close(); err != nil {
+			level.Warn(p.logger).Log("msg", "failed to close the write-ahead log of the rule results", "user", q.userID, "err", err)
+		}
+	}
+	return nil
+}
+
+// Push implements Pusher, queueing the request in the write-ahead log of the tenant.
+func (p *WALPusher) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	defer cortexpb.ReuseSlice(req.Timeseries)
+
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q, err := p.queue(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit := p.limits.RulerWALMaxSizeBytes(userID); limit > 0 {
+		size, err := q.wal.Size()
+		if err != nil {
+			return nil, err
+		}
+		if size >= int64(limit) {
+			p.rejectedWrites.WithLabelValues(userID).Inc()
+			return nil, errors.Errorf("the write-ahead log of the rule results reached the max size of %d bytes", limit)
+		}
+	}
+
+	data, err := req.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if err := q.wal.Log(data); err != nil {
+		return nil, errors.Wrap(err, "write the rule results to the write-ahead log")
+	}
+	return &cortexpb.WriteResponse{}, nil
+}
+
+// queue returns the queue of the tenant, opening its write-ahead log if needed.
+func (p *WALPusher) queue(userID string) (*walQueue, error) {
+	p.queuesMtx.Lock()
+	defer p.queuesMtx.Unlock()
+
+	if q, ok := p.queues[userID]; ok {
+		return q, nil
+	}
+
+	wal, err := wlog.New(log.With(p.logger, "user", userID), nil, filepath.Join(p.cfg.Dir, userID), wlog.CompressionSnappy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open the write-ahead log of the rule results of the user %s", userID)
+	}
+	q := &walQueue{userID: userID, wal: wal}
+	p.queues[userID] = q
+	return q, nil
+}
+
+func (p *WALPusher) queuesSnapshot() []*walQueue {
+	p.queuesMtx.Lock()
+	defer p.queuesMtx.Unlock()
+
+	queues := make([]*walQueue, 0, len(p.queues))
+	for _, q := range p.queues {
+		queues = append(queues, q)
+	}
+	return queues
+}
+
+// flushQueue pushes the requests queued in the write-ahead log, in order, deleting the segments
+// once all their requests have been pushed. It stops at the first failed push, retried on the
+// next flush.
+func (p *WALPusher) flushQueue(ctx context.Context, q *walQueue) error {
+	q.flushMtx.Lock()
+	defer q.flushMtx.Unlock()
+
+	defer func() {
+		if size, err := q.wal.Size(); err == nil {
+			p.diskUsage.WithLabelValues(q.userID).Set(float64(size))
+		}
+		if first, last, err := wlog.Segments(q.wal.Dir()); err == nil {
+			p.pendingSegment.WithLabelValues(q.userID).Set(float64(last - first))
+		}
+	}()
+
+	// The segment being written is closed, so that all the queued requests can be read.
+	if _, offset, err := q.wal.LastSegmentAndOffset(); err != nil {
+		return err
+	} else if offset > 0 {
+		if _, err := q.wal.NextSegmentSync(); err != nil {
+			return err
+		}
+	}
+
+	first, last, err := wlog.Segments(q.wal.Dir())
+	if err != nil {
+		return err
+	}
+	for segment := first; segment < last; segment++ {
+		if err := p.flushSegment(ctx, q, segment); err != nil {
+			return err
+		}
+		if err := q.wal.Truncate(segment + 1); err != nil {
+			return err
+		}
+		q.pushedRecords = 0
+	}
+	return nil
+}
+
+func (p *WALPusher) flushSegment(ctx context.Context, q *walQueue, segment int) error {
+	s, err := wlog.OpenReadSegment(wlog.SegmentName(q.wal.Dir(), segment))
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	ctx = user.InjectOrgID(ctx, q.userID)
+	r := wlog.NewReader(wlog.NewSegmentBufReader(s))
+	for records := 1; r.Next(); records++ {
+		if records <= q.pushedRecords {
+			continue
+		}
+
+		// The record is copied since the request references it after being unmarshalled.
+		req := &cortexpb.WriteRequest{}
+		if err := req.Unmarshal(append([]byte(nil), r.Record()...)); err != nil {
+			level.Warn(p.logger).Log("msg", "skipped a corrupted rule results record of the write-ahead log", "user", q.userID, "segment", segment, "err", err)
+			q.pushedRecords = records
+			continue
+		}
+
+		samples := 0
+		for _, ts := range req.Timeseries {
+			samples += len(ts.Samples)
+		}
+
+		if _, err := p.next.Push(ctx, req); err != nil {
+			// The client errors, like the out of order samples, would fail again.
+			resp, ok := httpgrpc.HTTPResponseFromError(err)
+			if !ok || resp.Code/100 != 4 {
+				p.pushFailures.WithLabelValues(q.userID).Inc()
+				return err
+			}
+			p.droppedSamples.WithLabelValues(q.userID).Add(float64(samples))
+		} else {
+			p.pushedSamples.WithLabelValues(q.userID).Add(float64(samples))
+		}
+		q.pushedRecords = records
+	}
+
+	if err := r.Err(); err != nil {
+		level.Warn(p.logger).Log("msg", "skipped the rest of a corrupted segment of the rule results write-ahead log", "user", q.userID, "segment", segment, "err", err)
+	}
+	return nil
+}
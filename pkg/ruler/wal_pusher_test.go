@@ -0,0 +1,139 @@
+package ruler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+type walLimitsMock map[string]int
+
+func (m walLimitsMock) RulerWALMaxSizeBytes(userID string) int {
+	return m[userID]
+}
+
+// recordingPusher records the value of the samples pushed, failing with err if set.
+type recordingPusher struct {
+	mtx    sync.Mutex
+	err    error
+	values []float64
+}
+
+func (p *recordingPusher) Push(_ context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	defer cortexpb.ReuseSlice(req.Timeseries)
+	if p.err != nil {
+		return nil, p.err
+	}
+	for _, ts := range req.Timeseries {
+		for _, s := range ts.Samples {
+			p.values = append(p.values, s.Value)
+		}
+	}
+	return &cortexpb.WriteResponse{}, nil
+}
+
+func (p *recordingPusher) setErr(err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.err = err
+}
+
+func pushRuleResult(t *testing.T, p Pusher, userID string, value float64) error {
+	req := cortexpb.ToWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "job:up:sum")}, []cortexpb.Sample{{TimestampMs: time.Now().UnixMilli(), Value: value}}, nil, nil, cortexpb.RULE)
+	_, err := p.Push(user.InjectOrgID(context.Background(), userID), req)
+	return err
+}
+
+func TestWALPusher_ShouldRetryTheFailedPushesInOrder(t *testing.T) {
+	ctx := context.Background()
+	next := &recordingPusher{err: errors.New("distributors unavailable")}
+	reg := prometheus.NewPedanticRegistry()
+	p := NewWALPusher(WALConfig{Dir: t.TempDir(), FlushPeriod: time.Hour}, next, walLimitsMock{}, log.NewNopLogger(), reg)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, p))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, p))
+	})
+
+	require.NoError(t, pushRuleResult(t, p, "user-1", 1))
+	require.NoError(t, pushRuleResult(t, p, "user-1", 2))
+	require.NoError(t, p.flush(ctx))
+	assert.Empty(t, next.values)
+	assert.Equal(t, 1.0, testutil.ToFloat64(p.pushFailures.WithLabelValues("user-1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(p.pendingSegment.WithLabelValues("user-1")))
+
+	// The results queued while the distributors were unavailable are pushed first.
+	next.setErr(nil)
+	require.NoError(t, pushRuleResult(t, p, "user-1", 3))
+	require.NoError(t, p.flush(ctx))
+	assert.Equal(t, []float64{1, 2, 3}, next.values)
+	assert.Equal(t, 3.0, testutil.ToFloat64(p.pushedSamples.WithLabelValues("user-1")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(p.pendingSegment.WithLabelValues("user-1")))
+
+	// The results rejected with a client error aren't retried.
+	next.setErr(httpgrpc.Errorf(http.StatusBadRequest, "out of order sample"))
+	require.NoError(t, pushRuleResult(t, p, "user-1", 4))
+	require.NoError(t, p.flush(ctx))
+	next.setErr(nil)
+	require.NoError(t, p.flush(ctx))
+	assert.Equal(t, []float64{1, 2, 3}, next.values)
+	assert.Equal(t, 1.0, testutil.ToFloat64(p.droppedSamples.WithLabelValues("user-1")))
+}
+
+func TestWALPusher_ShouldPushTheResultsQueuedBeforeARestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	next := &recordingPusher{}
+
+	p := NewWALPusher(WALConfig{Dir: dir, FlushPeriod: time.Hour}, next, walLimitsMock{}, log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, p))
+	require.NoError(t, pushRuleResult(t, p, "user-1", 1))
+	require.NoError(t, pushRuleResult(t, p, "user-2", 2))
+	require.NoError(t, services.StopAndAwaitTerminated(ctx, p))
+	assert.Empty(t, next.values)
+
+	p = NewWALPusher(WALConfig{Dir: dir, FlushPeriod: time.Hour}, next, walLimitsMock{}, log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, p))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, p))
+	})
+	require.NoError(t, p.flush(ctx))
+	assert.ElementsMatch(t, []float64{1, 2}, next.values)
+}
+
+func TestWALPusher_ShouldRejectTheResultsOnceTheMaxSizeIsReached(t *testing.T) {
+	ctx := context.Background()
+	next := &recordingPusher{err: errors.New("distributors unavailable")}
+	p := NewWALPusher(WALConfig{Dir: t.TempDir(), FlushPeriod: time.Hour}, next, walLimitsMock{"user-1": 1}, log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, p))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, p))
+	})
+
+	// The size of the write-ahead log only accounts for the pages written to disk.
+	require.NoError(t, pushRuleResult(t, p, "user-1", 1))
+	require.NoError(t, p.flush(ctx))
+	require.EqualError(t, pushRuleResult(t, p, "user-1", 2), "the write-ahead log of the rule results reached the max size of 1 bytes")
+	assert.Equal(t, 1.0, testutil.ToFloat64(p.rejectedWrites.WithLabelValues("user-1")))
+
+	// The other tenants aren't limited.
+	require.NoError(t, pushRuleResult(t, p, "user-2", 1))
+	require.NoError(t, pushRuleResult(t, p, "user-2", 2))
+}
@@ -0,0 +1,90 @@
+package scheduledquery
+
+import (
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v2"
+)
+
+// Definitions are the scheduled queries of all tenants, as configured in the definitions file.
+type Definitions struct {
+	Tenants map[string][]Definition `yaml:"tenants"`
+}
+
+// Definition is a query run on a schedule, whose result is delivered to one or more destinations.
+type Definition struct {
+	Name     string `yaml:"name"`
+	Query    string `yaml:"query"`
+	Schedule string `yaml:"schedule"`
+
+	// Destinations of the result.
+	WebhookURL string `yaml:"webhook_url"`
+	Bucket     bool   `yaml:"bucket"`
+	Record     string `yaml:"record"`
+}
+
+func (d *Definition) validate() error {
+	if d.Name == "" {
+		return errors.New("missing name")
+	}
+	if strings.ContainsAny(d.Name, `/\`) || d.Name == "." || d.Name == ".." {
+		return errors.Errorf("invalid name %q", d.Name)
+	}
+	if _, err := parser.ParseExpr(d.Query); err != nil {
+		return errors.Wrap(err, "invalid query")
+	}
+	if _, err := parseSchedule(d.Schedule); err != nil {
+		return errors.Wrap(err, "invalid schedule")
+	}
+	if d.WebhookURL == "" && !d.Bucket && d.Record == "" {
+		return errors.New("no destination configured, at least one of webhook_url, bucket and record is required")
+	}
+	if d.WebhookURL != "" {
+		if u, err := url.Parse(d.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.Errorf("invalid webhook URL %q", d.WebhookURL)
+		}
+	}
+	if d.Record != "" && !model.IsValidMetricName(model.LabelValue(d.Record)) {
+		return errors.Errorf("invalid record metric name %q", d.Record)
+	}
+	return nil
+}
+
+// Validate validates the definitions of all tenants.
+func (d *Definitions) Validate() error {
+	for userID, defs := range d.Tenants {
+		names := make(map[string]struct{}, len(defs))
+		for i := range defs {
+			if err := defs[i].validate(); err != nil {
+				return errors.Wrapf(err, "invalid scheduled query %q of tenant %s", defs[i].Name, userID)
+			}
+			if _, ok := names[defs[i].Name]; ok {
+				return errors.Errorf("duplicate scheduled query %q of tenant %s", defs[i].Name, userID)
+			}
+			names[defs[i].Name] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// LoadDefinitions reads and validates the definitions file.
+func LoadDefinitions(filename string) (*Definitions, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defs := &Definitions{}
+	if err := yaml.UnmarshalStrict(content, defs); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the scheduled queries definitions")
+	}
+	if err := defs.Validate(); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
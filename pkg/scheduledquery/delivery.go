@@ -0,0 +1,104 @@
+package scheduledquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+)
+
+// ResultsPrefix is the prefix of the scheduled queries results in the tenant bucket.
+const ResultsPrefix = "scheduled-queries"
+
+// Result is the result of a scheduled query run, as delivered to the webhook and the bucket.
+type Result struct {
+	Tenant     string           `json:"tenant"`
+	Name       string           `json:"name"`
+	Query      string           `json:"query"`
+	Timestamp  int64            `json:"timestamp"`
+	ResultType parser.ValueType `json:"resultType"`
+	Result     parser.Value     `json:"result"`
+}
+
+func (s *Scheduler) deliverWebhook(ctx context.Context, url string, result *Result) error {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned HTTP status %s", resp.Status)
+	}
+	return nil
+}
+
+// deliverBucket uploads the result to <tenant>/scheduled-queries/<name>/<timestamp>.json.
+func (s *Scheduler) deliverBucket(ctx context.Context, result *Result) error {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	userBkt := bucket.NewUserBucketClient(result.Tenant, s.bkt, nil)
+	return userBkt.Upload(ctx, path.Join(ResultsPrefix, result.Name, strconv.FormatInt(result.Timestamp, 10)+".json"), bytes.NewReader(payload))
+}
+
+// deliverRemoteWrite pushes the result as new series named after the record metric name, at the
+// time of the run.
+func (s *Scheduler) deliverRemoteWrite(ctx context.Context, record string, result *Result) error {
+	var (
+		series  []labels.Labels
+		samples []cortexpb.Sample
+		ts      = result.Timestamp * 1000
+	)
+
+	switch v := result.Result.(type) {
+	case promql.Vector:
+		for _, sample := range v {
+			if sample.H != nil {
+				return errors.New("recording native histograms is not supported")
+			}
+			b := labels.NewBuilder(sample.Metric)
+			b.Set(labels.MetricName, record)
+			series = append(series, b.Labels())
+			samples = append(samples, cortexpb.Sample{TimestampMs: ts, Value: sample.F})
+		}
+	case promql.Scalar:
+		series = append(series, labels.FromStrings(labels.MetricName, record))
+		samples = append(samples, cortexpb.Sample{TimestampMs: ts, Value: v.V})
+	default:
+		return errors.Errorf("recording a %s result is not supported, the query must return an instant vector or a scalar", result.ResultType)
+	}
+	if len(series) == 0 {
+		return nil
+	}
+
+	// The pusher (the distributor) reuses the request slices once done.
+	_, err := s.pusher.Push(ctx, cortexpb.ToWriteRequest(series, samples, nil, nil, cortexpb.RULE))
+	return err
+}
@@ -0,0 +1,172 @@
+package scheduledquery
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+// schedule returns the next time a query should run, strictly after the given time.
+type schedule interface {
+	next(after time.Time) time.Time
+}
+
+// everySchedule runs at a fixed interval, aligned to the Unix epoch.
+type everySchedule time.Duration
+
+func (s everySchedule) next(after time.Time) time.Time {
+	return after.Truncate(time.Duration(s)).Add(time.Duration(s))
+}
+
+// cronSchedule runs at the minutes matching a cron expression, in UTC. Each field is the
+// bitmask of the matching values.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// Whether the day of month and day of week fields are restricted, i.e. not starting with "*".
+	domRestricted, dowRestricted bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var (
+	cronFields = []cronField{
+		{name: "minute", min: 0, max: 59},
+		{name: "hour", min: 0, max: 23},
+		{name: "day of month", min: 1, max: 31},
+		{name: "month", min: 1, max: 12},
+		{name: "day of week", min: 0, max: 6},
+	}
+
+	cronDescriptors = map[string]string{
+		"@hourly":  "0 * * * *",
+		"@daily":   "0 0 * * *",
+		"@weekly":  "0 0 * * 0",
+		"@monthly": "0 0 1 * *",
+		"@yearly":  "0 0 1 1 *",
+	}
+)
+
+// parseSchedule parses a cron expression with 5 fields (minute, hour, day of month, month and
+// day of week) supporting "*", values, ranges, lists and steps, one of the @hourly, @daily,
+// @weekly, @monthly and @yearly descriptors, or "@every <duration>".
+func parseSchedule(s string) (schedule, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "@every ") {
+		d, err := model.ParseDuration(strings.TrimSpace(strings.TrimPrefix(s, "@every ")))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid @every duration")
+		}
+		if time.Duration(d) < time.Second {
+			return nil, errors.New("the @every duration must be at least 1s")
+		}
+		return everySchedule(d), nil
+	}
+	if expr, ok := cronDescriptors[s]; ok {
+		s = expr
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != len(cronFields) {
+		return nil, errors.Errorf("expected %d fields in the cron expression, got %d", len(cronFields), len(fields))
+	}
+
+	masks := make([]uint64, len(fields))
+	for i, field := range fields {
+		var err error
+		if masks[i], err = parseCronField(field, cronFields[i]); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", cronFields[i].name)
+		}
+	}
+	return &cronSchedule{
+		minute:        masks[0],
+		hour:          masks[1],
+		dom:           masks[2],
+		month:         masks[3],
+		dow:           masks[4],
+		domRestricted: !strings.HasPrefix(fields[2], "*"),
+		dowRestricted: !strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, f cronField) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			rng = part[:idx]
+			if step, err = strconv.Atoi(part[idx+1:]); err != nil || step <= 0 {
+				return 0, errors.Errorf("invalid step %q", part[idx+1:])
+			}
+		}
+
+		low, high := f.min, f.max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, errors.Errorf("invalid value %q", bounds[0])
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, errors.Errorf("invalid value %q", bounds[1])
+				}
+			} else if step > 1 {
+				// "a/n" means from a to the max value.
+				high = f.max
+			}
+		}
+		if low < f.min || high > f.max || low > high {
+			return 0, errors.Errorf("%q is out of the range %d-%d", rng, f.min, f.max)
+		}
+
+		for v := low; v <= high; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+// next implements schedule. It returns the zero time if the schedule never matches.
+func (s *cronSchedule) next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+
+	// No valid schedule matches a time more than 5 years away (e.g. Feb 29th on a given day
+	// of week), so the search is bounded.
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay returns whether the day of t matches the schedule. Like cron, if both the day of
+// month and the day of week are restricted, either of them has to match.
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
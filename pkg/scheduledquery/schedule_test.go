@@ -0,0 +1,98 @@
+package scheduledquery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// Wednesday.
+	after := time.Date(2023, time.March, 15, 10, 17, 30, 0, time.UTC)
+
+	tests := map[string]struct {
+		schedule     string
+		expectedNext []time.Time
+		expectedErr  string
+	}{
+		"every": {
+			schedule:     "@every 5m",
+			expectedNext: []time.Time{time.Date(2023, time.March, 15, 10, 20, 0, 0, time.UTC), time.Date(2023, time.March, 15, 10, 25, 0, 0, time.UTC)},
+		},
+		"every minute": {
+			schedule:     "* * * * *",
+			expectedNext: []time.Time{time.Date(2023, time.March, 15, 10, 18, 0, 0, time.UTC), time.Date(2023, time.March, 15, 10, 19, 0, 0, time.UTC)},
+		},
+		"steps": {
+			schedule:     "*/15 */6 * * *",
+			expectedNext: []time.Time{time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC), time.Date(2023, time.March, 15, 12, 15, 0, 0, time.UTC)},
+		},
+		"lists and ranges": {
+			schedule:     "5,35 9-11 * * *",
+			expectedNext: []time.Time{time.Date(2023, time.March, 15, 10, 35, 0, 0, time.UTC), time.Date(2023, time.March, 15, 11, 5, 0, 0, time.UTC), time.Date(2023, time.March, 15, 11, 35, 0, 0, time.UTC), time.Date(2023, time.March, 16, 9, 5, 0, 0, time.UTC)},
+		},
+		"daily": {
+			schedule:     "@daily",
+			expectedNext: []time.Time{time.Date(2023, time.March, 16, 0, 0, 0, 0, time.UTC), time.Date(2023, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		},
+		"monthly": {
+			schedule:     "@monthly",
+			expectedNext: []time.Time{time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		"day of week": {
+			schedule:     "30 8 * * 1-5",
+			expectedNext: []time.Time{time.Date(2023, time.March, 16, 8, 30, 0, 0, time.UTC), time.Date(2023, time.March, 17, 8, 30, 0, 0, time.UTC), time.Date(2023, time.March, 20, 8, 30, 0, 0, time.UTC)},
+		},
+		"day of month or day of week": {
+			schedule:     "0 0 20 * 5",
+			expectedNext: []time.Time{time.Date(2023, time.March, 17, 0, 0, 0, 0, time.UTC), time.Date(2023, time.March, 20, 0, 0, 0, 0, time.UTC), time.Date(2023, time.March, 24, 0, 0, 0, 0, time.UTC)},
+		},
+		"leap day": {
+			schedule:     "0 0 29 2 *",
+			expectedNext: []time.Time{time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		},
+		"never matching": {
+			schedule:     "0 0 31 2 *",
+			expectedNext: []time.Time{{}},
+		},
+		"too short every": {
+			schedule:    "@every 100ms",
+			expectedErr: "the @every duration must be at least 1s",
+		},
+		"wrong number of fields": {
+			schedule:    "* * * *",
+			expectedErr: "expected 5 fields in the cron expression, got 4",
+		},
+		"out of range": {
+			schedule:    "0 24 * * *",
+			expectedErr: `invalid hour: "24" is out of the range 0-23`,
+		},
+		"invalid step": {
+			schedule:    "*/0 * * * *",
+			expectedErr: `invalid minute: invalid step "0"`,
+		},
+		"invalid value": {
+			schedule:    "0 0 * jan *",
+			expectedErr: `invalid month: invalid value "jan"`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			s, err := parseSchedule(testData.schedule)
+			if testData.expectedErr != "" {
+				require.EqualError(t, err, testData.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			next := after
+			for _, expected := range testData.expectedNext {
+				next = s.next(next)
+				assert.Equal(t, expected, next)
+			}
+		})
+	}
+}
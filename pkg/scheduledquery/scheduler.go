@@ -0,0 +1,325 @@
+package scheduledquery
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+const (
+	stageQuery       = "query"
+	stageWebhook     = "webhook"
+	stageBucket      = "bucket"
+	stageRemoteWrite = "remote_write"
+)
+
+// Config configures the scheduled queries.
+type Config struct {
+	DefinitionsFile string        `yaml:"definitions_file"`
+	ReloadInterval  time.Duration `yaml:"reload_interval"`
+	Concurrency     int           `yaml:"concurrency"`
+	DeliveryTimeout time.Duration `yaml:"delivery_timeout"`
+}
+
+// RegisterFlags registers the scheduled queries flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.DefinitionsFile, "scheduled-query.definitions-file", "", "File with the scheduled queries of each tenant. Required by the scheduled-query module.")
+	f.DurationVar(&cfg.ReloadInterval, "scheduled-query.reload-interval", time.Minute, "How frequently the definitions file is reloaded.")
+	f.IntVar(&cfg.Concurrency, "scheduled-query.concurrency", 4, "Maximum number of scheduled queries evaluated concurrently.")
+	f.DurationVar(&cfg.DeliveryTimeout, "scheduled-query.delivery-timeout", 30*time.Second, "Timeout of the delivery of a result to each destination.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if cfg.ReloadInterval <= 0 {
+		return errors.New("the scheduled queries reload interval must be greater than 0")
+	}
+	if cfg.Concurrency <= 0 {
+		return errors.New("the scheduled queries concurrency must be greater than 0")
+	}
+	if cfg.DeliveryTimeout <= 0 {
+		return errors.New("the scheduled queries delivery timeout must be greater than 0")
+	}
+	return nil
+}
+
+// Limits are the per-tenant limits of the scheduled queries.
+type Limits interface {
+	ScheduledQueryMaxQueriesPerTenant(userID string) int
+	ScheduledQueryMaxResultSeries(userID string) int
+}
+
+// Pusher writes the recorded series, like the distributor does.
+type Pusher interface {
+	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
+}
+
+type jobKey struct {
+	userID, name string
+}
+
+type job struct {
+	userID   string
+	def      Definition
+	schedule schedule
+	next     time.Time
+	running  bool
+}
+
+// Scheduler runs the queries of the definitions file on their schedule, and delivers their
+// result to a webhook, the tenant bucket and/or the distributor as new series.
+type Scheduler struct {
+	services.Service
+
+	cfg       Config
+	engine    v1.QueryEngine
+	queryable storage.Queryable
+	bkt       objstore.Bucket
+	pusher    Pusher
+	limits    Limits
+	client    *http.Client
+	logger    log.Logger
+
+	mtx  sync.Mutex
+	jobs map[jobKey]*job
+
+	// Limits the number of concurrent evaluations.
+	evaluationsSlots chan struct{}
+	wg               sync.WaitGroup
+
+	evaluations *prometheus.CounterVec
+	failures    *prometheus.CounterVec
+	scheduled   *prometheus.GaugeVec
+}
+
+// NewScheduler makes a new Scheduler.
+func NewScheduler(cfg Config, engine v1.QueryEngine, queryable storage.Queryable, bkt objstore.Bucket, pusher Pusher, limits Limits, logger log.Logger, reg prometheus.Registerer) *Scheduler {
+	s := &Scheduler{
+		cfg:              cfg,
+		engine:           engine,
+		queryable:        queryable,
+		bkt:              bkt,
+		pusher:           pusher,
+		limits:           limits,
+		client:           &http.Client{},
+		logger:           logger,
+		jobs:             map[jobKey]*job{},
+		evaluationsSlots: make(chan struct{}, cfg.Concurrency),
+
+		evaluations: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_scheduled_query_evaluations_total",
+			Help: "Total number of scheduled query evaluations.",
+		}, []string{"user"}),
+		failures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_scheduled_query_failures_total",
+			Help: "Total number of scheduled query evaluations failed, by stage.",
+		}, []string{"user", "stage"}),
+		scheduled: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_scheduled_query_scheduled_queries",
+			Help: "Number of scheduled queries, excluding the ones beyond the per-tenant limit.",
+		}, []string{"user"}),
+	}
+
+	s.Service = services.NewBasicService(s.starting, s.running, s.stopping)
+	return s
+}
+
+func (s *Scheduler) starting(_ context.Context) error {
+	defs, err := LoadDefinitions(s.cfg.DefinitionsFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the scheduled queries definitions")
+	}
+	s.setDefinitions(defs, time.Now())
+	return nil
+}
+
+func (s *Scheduler) running(ctx context.Context) error {
+	evalTicker := time.NewTicker(time.Second)
+	defer evalTicker.Stop()
+
+	reloadTicker := time.NewTicker(s.cfg.ReloadInterval)
+	defer reloadTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-evalTicker.C:
+			s.runDue(ctx, now)
+		case <-reloadTicker.C:
+			defs, err := LoadDefinitions(s.cfg.DefinitionsFile)
+			if err != nil {
+				level.Error(s.logger).Log("msg", "failed to reload the scheduled queries definitions, keeping the previous ones", "err", err)
+				continue
+			}
+			s.setDefinitions(defs, time.Now())
+		}
+	}
+}
+
+func (s *Scheduler) stopping(_ error) error {
+	s.wg.Wait()
+	return nil
+}
+
+// setDefinitions replaces the scheduled jobs. The unchanged queries keep their next run.
+func (s *Scheduler) setDefinitions(defs *Definitions, now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	jobs := make(map[jobKey]*job, len(s.jobs))
+	s.scheduled.Reset()
+	for userID, userDefs := range defs.Tenants {
+		if limit := s.limits.ScheduledQueryMaxQueriesPerTenant(userID); limit > 0 && len(userDefs) > limit {
+			level.Warn(s.logger).Log("msg", "tenant has more scheduled queries than allowed, the ones beyond the limit are not run", "user", userID, "queries", len(userDefs), "limit", limit)
+			userDefs = userDefs[:limit]
+		}
+
+		for _, def := range userDefs {
+			key := jobKey{userID: userID, name: def.Name}
+			if existing, ok := s.jobs[key]; ok && existing.def == def {
+				jobs[key] = existing
+				continue
+			}
+
+			// The definitions have already been validated.
+			sched, _ := parseSchedule(def.Schedule)
+			jobs[key] = &job{userID: userID, def: def, schedule: sched, next: sched.next(now)}
+		}
+		s.scheduled.WithLabelValues(userID).Set(float64(len(userDefs)))
+	}
+	s.jobs = jobs
+}
+
+// runDue starts the evaluation of the jobs due at the given time. A job still running from the
+// previous run is skipped, and runs missed while the scheduler was busy are not caught up.
+func (s *Scheduler) runDue(ctx context.Context, now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, j := range s.jobs {
+		if j.next.IsZero() || now.Before(j.next) {
+			continue
+		}
+
+		ts := j.next
+		j.next = j.schedule.next(now)
+		if j.running {
+			level.Warn(s.logger).Log("msg", "skipping scheduled query run because the previous one is still running", "user", j.userID, "name", j.def.Name)
+			continue
+		}
+
+		j.running = true
+		s.wg.Add(1)
+		go func(j *job) {
+			defer s.wg.Done()
+			defer func() {
+				s.mtx.Lock()
+				j.running = false
+				s.mtx.Unlock()
+			}()
+
+			select {
+			case s.evaluationsSlots <- struct{}{}:
+				defer func() { <-s.evaluationsSlots }()
+			case <-ctx.Done():
+				return
+			}
+			s.evaluate(ctx, j.userID, j.def, ts)
+		}(j)
+	}
+}
+
+// evaluate runs the query at the given time and delivers its result.
+func (s *Scheduler) evaluate(ctx context.Context, userID string, def Definition, ts time.Time) {
+	logger := log.With(s.logger, "user", userID, "name", def.Name)
+	ctx = user.InjectOrgID(ctx, userID)
+	s.evaluations.WithLabelValues(userID).Inc()
+
+	q, err := s.engine.NewInstantQuery(ctx, s.queryable, nil, def.Query, ts)
+	if err != nil {
+		s.fail(logger, userID, stageQuery, err)
+		return
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		s.fail(logger, userID, stageQuery, res.Err)
+		return
+	}
+	if limit := s.limits.ScheduledQueryMaxResultSeries(userID); limit > 0 && resultSeries(res.Value) > limit {
+		s.fail(logger, userID, stageQuery, errors.Errorf("the result has more than %d series", limit))
+		return
+	}
+
+	result := &Result{
+		Tenant:     userID,
+		Name:       def.Name,
+		Query:      def.Query,
+		Timestamp:  ts.Unix(),
+		ResultType: res.Value.Type(),
+		Result:     res.Value,
+	}
+
+	deliveries := []struct {
+		stage   string
+		enabled bool
+		deliver func(ctx context.Context) error
+	}{
+		{stage: stageWebhook, enabled: def.WebhookURL != "", deliver: func(ctx context.Context) error {
+			return s.deliverWebhook(ctx, def.WebhookURL, result)
+		}},
+		{stage: stageBucket, enabled: def.Bucket, deliver: func(ctx context.Context) error {
+			return s.deliverBucket(ctx, result)
+		}},
+		{stage: stageRemoteWrite, enabled: def.Record != "", deliver: func(ctx context.Context) error {
+			return s.deliverRemoteWrite(ctx, def.Record, result)
+		}},
+	}
+	for _, d := range deliveries {
+		if !d.enabled {
+			continue
+		}
+
+		deliveryCtx, cancel := context.WithTimeout(ctx, s.cfg.DeliveryTimeout)
+		err := d.deliver(deliveryCtx)
+		cancel()
+		if err != nil {
+			s.fail(logger, userID, d.stage, err)
+		}
+	}
+}
+
+func (s *Scheduler) fail(logger log.Logger, userID, stage string, err error) {
+	s.failures.WithLabelValues(userID, stage).Inc()
+	level.Warn(logger).Log("msg", "scheduled query failed", "stage", stage, "err", err)
+}
+
+func resultSeries(v parser.Value) int {
+	switch v := v.(type) {
+	case promql.Vector:
+		return len(v)
+	case promql.Matrix:
+		return len(v)
+	default:
+		return 1
+	}
+}
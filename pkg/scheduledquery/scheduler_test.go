@@ -0,0 +1,255 @@
+package scheduledquery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/util/teststorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+func TestLoadDefinitions(t *testing.T) {
+	tests := map[string]struct {
+		content     string
+		expectedErr string
+	}{
+		"valid": {
+			content: `
+tenants:
+  team-a:
+    - name: errors
+      query: sum(rate(errors_total[5m]))
+      schedule: "*/5 * * * *"
+      webhook_url: http://example.com/hook
+      bucket: true
+      record: errors:rate5m
+`,
+		},
+		"unknown field": {
+			content:     "tenants:\n  team-a:\n    - name: errors\n      unknown: true\n",
+			expectedErr: "failed to parse the scheduled queries definitions",
+		},
+		"invalid query": {
+			content:     "tenants:\n  team-a:\n    - {name: errors, query: 'sum(', schedule: '@daily', bucket: true}\n",
+			expectedErr: `invalid scheduled query "errors" of tenant team-a: invalid query`,
+		},
+		"invalid schedule": {
+			content:     "tenants:\n  team-a:\n    - {name: errors, query: up, schedule: '@sometimes', bucket: true}\n",
+			expectedErr: `invalid scheduled query "errors" of tenant team-a: invalid schedule`,
+		},
+		"invalid name": {
+			content:     "tenants:\n  team-a:\n    - {name: a/b, query: up, schedule: '@daily', bucket: true}\n",
+			expectedErr: `invalid scheduled query "a/b" of tenant team-a: invalid name "a/b"`,
+		},
+		"no destination": {
+			content:     "tenants:\n  team-a:\n    - {name: errors, query: up, schedule: '@daily'}\n",
+			expectedErr: `invalid scheduled query "errors" of tenant team-a: no destination configured`,
+		},
+		"invalid webhook URL": {
+			content:     "tenants:\n  team-a:\n    - {name: errors, query: up, schedule: '@daily', webhook_url: 'ftp://example.com'}\n",
+			expectedErr: `invalid scheduled query "errors" of tenant team-a: invalid webhook URL "ftp://example.com"`,
+		},
+		"invalid record": {
+			content:     "tenants:\n  team-a:\n    - {name: errors, query: up, schedule: '@daily', record: '1up'}\n",
+			expectedErr: `invalid scheduled query "errors" of tenant team-a: invalid record metric name "1up"`,
+		},
+		"duplicate name": {
+			content:     "tenants:\n  team-a:\n    - {name: errors, query: up, schedule: '@daily', bucket: true}\n    - {name: errors, query: up, schedule: '@hourly', bucket: true}\n",
+			expectedErr: `duplicate scheduled query "errors" of tenant team-a`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "definitions.yaml")
+			require.NoError(t, os.WriteFile(filename, []byte(testData.content), 0o600))
+
+			defs, err := LoadDefinitions(filename)
+			if testData.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), testData.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, defs.Tenants["team-a"], 1)
+		})
+	}
+}
+
+func TestScheduler_runDue(t *testing.T) {
+	now := time.Date(2023, time.March, 15, 10, 0, 0, 0, time.UTC)
+
+	storage := teststorage.New(t)
+	t.Cleanup(func() { _ = storage.Close() })
+	app := storage.Appender(context.Background())
+	for _, pod := range []string{"a", "b"} {
+		_, err := app.Append(0, labels.FromStrings(labels.MetricName, "up", "pod", pod), now.Add(-time.Minute).UnixMilli(), 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	var (
+		webhookMtx     sync.Mutex
+		webhookResults []map[string]interface{}
+	)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		result := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(body, &result))
+
+		webhookMtx.Lock()
+		webhookResults = append(webhookResults, result)
+		webhookMtx.Unlock()
+	}))
+	t.Cleanup(webhook.Close)
+
+	bkt := objstore.NewInMemBucket()
+	pusher := &pusherMock{}
+	limits := &limitsMock{maxQueries: 3, maxResultSeries: 1}
+	reg := prometheus.NewPedanticRegistry()
+	engine := promql.NewEngine(promql.EngineOpts{Logger: log.NewNopLogger(), MaxSamples: 1e6, Timeout: time.Minute})
+	cfg := Config{ReloadInterval: time.Minute, Concurrency: 2, DeliveryTimeout: time.Second}
+	s := NewScheduler(cfg, engine, storage, bkt, pusher, limits, log.NewNopLogger(), reg)
+
+	s.setDefinitions(&Definitions{Tenants: map[string][]Definition{
+		"team-a": {
+			{Name: "count", Query: "count(up)", Schedule: "*/5 * * * *", WebhookURL: webhook.URL, Bucket: true, Record: "up:count"},
+			{Name: "hourly", Query: "count(up)", Schedule: "@hourly", Bucket: true},
+			{Name: "too-many-series", Query: "up", Schedule: "@every 5m", Bucket: true},
+			{Name: "beyond-limit", Query: "up", Schedule: "@every 5m", Bucket: true},
+		},
+	}}, now.Add(-time.Second))
+
+	s.runDue(context.Background(), now)
+	s.wg.Wait()
+
+	// The webhook received the result.
+	require.Len(t, webhookResults, 1)
+	assert.Equal(t, map[string]interface{}{
+		"tenant":     "team-a",
+		"name":       "count",
+		"query":      "count(up)",
+		"timestamp":  float64(now.Unix()),
+		"resultType": "vector",
+		"result":     []interface{}{map[string]interface{}{"metric": map[string]interface{}{}, "value": []interface{}{float64(now.Unix()), "2"}}},
+	}, webhookResults[0])
+
+	// The result has been uploaded to the tenant bucket.
+	var objects []string
+	require.NoError(t, bkt.Iter(context.Background(), "team-a/"+ResultsPrefix, func(name string) error {
+		return bkt.Iter(context.Background(), name, func(name string) error {
+			objects = append(objects, name)
+			return nil
+		})
+	}))
+	assert.ElementsMatch(t, []string{
+		"team-a/scheduled-queries/count/1678874400.json",
+		"team-a/scheduled-queries/hourly/1678874400.json",
+	}, objects)
+
+	// The result has been recorded.
+	require.Len(t, pusher.requests, 1)
+	assert.Equal(t, "team-a", pusher.userIDs[0])
+	assert.Equal(t, cortexpb.RULE, pusher.requests[0].Source)
+	require.Len(t, pusher.requests[0].Timeseries, 1)
+	assert.Equal(t, labels.FromStrings(labels.MetricName, "up:count"), cortexpb.FromLabelAdaptersToLabels(pusher.requests[0].Timeseries[0].Labels))
+	assert.Equal(t, []cortexpb.Sample{{TimestampMs: now.UnixMilli(), Value: 2}}, pusher.requests[0].Timeseries[0].Samples)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_scheduled_query_evaluations_total Total number of scheduled query evaluations.
+		# TYPE cortex_scheduled_query_evaluations_total counter
+		cortex_scheduled_query_evaluations_total{user="team-a"} 3
+		# HELP cortex_scheduled_query_failures_total Total number of scheduled query evaluations failed, by stage.
+		# TYPE cortex_scheduled_query_failures_total counter
+		cortex_scheduled_query_failures_total{stage="query",user="team-a"} 1
+		# HELP cortex_scheduled_query_scheduled_queries Number of scheduled queries, excluding the ones beyond the per-tenant limit.
+		# TYPE cortex_scheduled_query_scheduled_queries gauge
+		cortex_scheduled_query_scheduled_queries{user="team-a"} 3
+	`)))
+
+	// The jobs are not run again until their next run.
+	s.runDue(context.Background(), now.Add(time.Minute))
+	s.wg.Wait()
+	assert.Len(t, webhookResults, 1)
+
+	s.runDue(context.Background(), now.Add(5*time.Minute))
+	s.wg.Wait()
+	assert.Len(t, webhookResults, 2)
+	assert.Equal(t, float64(now.Add(5*time.Minute).Unix()), webhookResults[1]["timestamp"])
+}
+
+func TestScheduler_setDefinitions(t *testing.T) {
+	now := time.Date(2023, time.March, 15, 10, 2, 0, 0, time.UTC)
+	s := NewScheduler(Config{Concurrency: 1}, nil, nil, nil, nil, &limitsMock{}, log.NewNopLogger(), nil)
+
+	unchanged := Definition{Name: "unchanged", Query: "up", Schedule: "@hourly", Bucket: true}
+	changed := Definition{Name: "changed", Query: "up", Schedule: "@hourly", Bucket: true}
+	s.setDefinitions(&Definitions{Tenants: map[string][]Definition{"team-a": {unchanged, changed}}}, now)
+	require.Len(t, s.jobs, 2)
+	s.jobs[jobKey{userID: "team-a", name: "unchanged"}].running = true
+
+	// The unchanged jobs are kept as is, while the changed ones are rescheduled.
+	changed.Schedule = "@every 5m"
+	s.setDefinitions(&Definitions{Tenants: map[string][]Definition{"team-a": {unchanged, changed}}}, now)
+	require.Len(t, s.jobs, 2)
+	assert.True(t, s.jobs[jobKey{userID: "team-a", name: "unchanged"}].running)
+	assert.Equal(t, time.Date(2023, time.March, 15, 11, 0, 0, 0, time.UTC), s.jobs[jobKey{userID: "team-a", name: "unchanged"}].next)
+	assert.Equal(t, time.Date(2023, time.March, 15, 10, 5, 0, 0, time.UTC), s.jobs[jobKey{userID: "team-a", name: "changed"}].next.UTC())
+
+	// The removed jobs are not run anymore.
+	s.setDefinitions(&Definitions{}, now)
+	assert.Empty(t, s.jobs)
+}
+
+type pusherMock struct {
+	mtx      sync.Mutex
+	userIDs  []string
+	requests []*cortexpb.WriteRequest
+}
+
+func (p *pusherMock) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	userID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.userIDs = append(p.userIDs, userID)
+	p.requests = append(p.requests, req)
+	return &cortexpb.WriteResponse{}, nil
+}
+
+type limitsMock struct {
+	maxQueries      int
+	maxResultSeries int
+}
+
+func (l *limitsMock) ScheduledQueryMaxQueriesPerTenant(string) int {
+	return l.maxQueries
+}
+
+func (l *limitsMock) ScheduledQueryMaxResultSeries(string) int {
+	return l.maxResultSeries
+}
@@ -0,0 +1,243 @@
+package scheduler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	util_math "github.com/cortexproject/cortex/pkg/util/math"
+)
+
+const (
+	// How frequently the dequeue rates are updated, and the demand metrics exported.
+	demandTickInterval = 5 * time.Second
+
+	// Weight of the last tick in the dequeue rates, which roughly averages over the last minute.
+	demandRateAlpha = 0.2
+)
+
+// tenantDemand tracks the requests of a tenant queued and being executed by queriers.
+type tenantDemand struct {
+	queued      map[requestKey]time.Time // Enqueue time of the queued requests.
+	inflight    int
+	dequeueRate *util_math.EwmaRate
+}
+
+// estimatedQueueWait returns the time a request enqueued now is expected to wait before being
+// picked up by a querier. It's estimated from the number of queued requests and the rate they've
+// been recently dequeued at, but it's never lower than the wait of the oldest queued request.
+func (d *tenantDemand) estimatedQueueWait(now time.Time) time.Duration {
+	if len(d.queued) == 0 {
+		return 0
+	}
+
+	var oldestWait time.Duration
+	for _, enqueueTime := range d.queued {
+		if wait := now.Sub(enqueueTime); wait > oldestWait {
+			oldestWait = wait
+		}
+	}
+
+	if rate := d.dequeueRate.Rate(); rate > 0 {
+		if estimate := time.Duration(float64(len(d.queued)) / rate * float64(time.Second)); estimate > oldestWait {
+			return estimate
+		}
+	}
+	return oldestWait
+}
+
+// demandTracker tracks the query demand, to expose signals suitable for autoscaling the
+// queriers: unlike their CPU usage, the demand includes the queries waiting in the queue.
+type demandTracker struct {
+	connectedWorkers  func() int
+	connectedQueriers func() int
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantDemand
+
+	// Metrics.
+	inflightRequests   *prometheus.GaugeVec
+	estimatedQueueWait *prometheus.GaugeVec
+	requiredWorkers    prometheus.Gauge
+	desiredQueriers    prometheus.Gauge
+}
+
+func newDemandTracker(connectedWorkers, connectedQueriers func() int, registerer prometheus.Registerer) *demandTracker {
+	return &demandTracker{
+		connectedWorkers:  connectedWorkers,
+		connectedQueriers: connectedQueriers,
+		tenants:           map[string]*tenantDemand{},
+
+		inflightRequests: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_query_scheduler_inflight_requests_per_user",
+			Help: "Number of queries dispatched to queriers and still being executed.",
+		}, []string{"user"}),
+		estimatedQueueWait: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_query_scheduler_estimated_queue_wait_seconds",
+			Help: "Estimated time a query enqueued now waits before being picked up by a querier.",
+		}, []string{"user"}),
+		requiredWorkers: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_query_scheduler_required_querier_workers",
+			Help: "Number of querier workers required to execute all queued and in-flight queries concurrently.",
+		}),
+		desiredQueriers: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_query_scheduler_desired_queriers",
+			Help: "Number of queriers required to execute all queued and in-flight queries concurrently, based on the workers per connected querier.",
+		}),
+	}
+}
+
+func (t *demandTracker) getOrAddTenant(userID string) *tenantDemand {
+	d, ok := t.tenants[userID]
+	if !ok {
+		d = &tenantDemand{
+			queued:      map[requestKey]time.Time{},
+			dequeueRate: util_math.NewEWMARate(demandRateAlpha, demandTickInterval),
+		}
+		t.tenants[userID] = d
+	}
+	return d
+}
+
+// enqueued tracks a request added to the queue.
+func (t *demandTracker) enqueued(userID string, key requestKey, now time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.getOrAddTenant(userID).queued[key] = now
+}
+
+// dequeued tracks a request removed from the queue, to be executed by a querier.
+func (t *demandTracker) dequeued(userID string, key requestKey) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	d := t.getOrAddTenant(userID)
+	delete(d.queued, key)
+	d.inflight++
+	d.dequeueRate.Inc()
+}
+
+// completed tracks a dequeued request which is not executed by a querier anymore.
+func (t *demandTracker) completed(userID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if d, ok := t.tenants[userID]; ok && d.inflight > 0 {
+		d.inflight--
+	}
+}
+
+type tenantDemandStatus struct {
+	Tenant                    string  `json:"tenant"`
+	QueuedRequests            int     `json:"queued_requests"`
+	InflightRequests          int     `json:"inflight_requests"`
+	DequeueRate               float64 `json:"dequeue_rate"`
+	EstimatedQueueWaitSeconds float64 `json:"estimated_queue_wait_seconds"`
+	RequiredWorkers           int     `json:"required_workers"`
+}
+
+type demandStatus struct {
+	ConnectedQueriers       int                  `json:"connected_queriers"`
+	ConnectedQuerierWorkers int                  `json:"connected_querier_workers"`
+	QueuedRequests          int                  `json:"queued_requests"`
+	InflightRequests        int                  `json:"inflight_requests"`
+	RequiredWorkers         int                  `json:"required_workers"`
+	DesiredQueriers         int                  `json:"desired_queriers"`
+	Tenants                 []tenantDemandStatus `json:"tenants"`
+}
+
+// status returns the current demand, overall and by tenant.
+func (t *demandTracker) status(now time.Time) demandStatus {
+	// Read the connections before locking, since they're tracked by the queue under its own lock.
+	status := demandStatus{
+		ConnectedQueriers:       t.connectedQueriers(),
+		ConnectedQuerierWorkers: t.connectedWorkers(),
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for userID, d := range t.tenants {
+		tenant := tenantDemandStatus{
+			Tenant:                    userID,
+			QueuedRequests:            len(d.queued),
+			InflightRequests:          d.inflight,
+			DequeueRate:               d.dequeueRate.Rate(),
+			EstimatedQueueWaitSeconds: d.estimatedQueueWait(now).Seconds(),
+			RequiredWorkers:           len(d.queued) + d.inflight,
+		}
+		status.QueuedRequests += tenant.QueuedRequests
+		status.InflightRequests += tenant.InflightRequests
+		status.RequiredWorkers += tenant.RequiredWorkers
+		status.Tenants = append(status.Tenants, tenant)
+	}
+	sort.Slice(status.Tenants, func(i, j int) bool { return status.Tenants[i].Tenant < status.Tenants[j].Tenant })
+
+	status.DesiredQueriers = desiredQueriers(status.RequiredWorkers, status.ConnectedQuerierWorkers, status.ConnectedQueriers)
+	return status
+}
+
+// desiredQueriers returns the number of queriers required to run the required workers, assuming
+// all queriers run the same number of workers as the connected ones. If no querier is connected,
+// a single querier is requested when there's demand, so that the queriers can be scaled from zero.
+func desiredQueriers(requiredWorkers, connectedWorkers, connectedQueriers int) int {
+	if requiredWorkers == 0 {
+		return 0
+	}
+	if connectedWorkers == 0 || connectedQueriers == 0 {
+		return 1
+	}
+	workersPerQuerier := float64(connectedWorkers) / float64(connectedQueriers)
+	return int(math.Ceil(float64(requiredWorkers) / workersPerQuerier))
+}
+
+// tick updates the dequeue rates and the metrics, and forgets the tenants without demand.
+func (t *demandTracker) tick(_ context.Context) error {
+	for _, userID := range t.tickRates() {
+		t.cleanupMetricsForInactiveUser(userID)
+	}
+
+	status := t.status(time.Now())
+	for _, tenant := range status.Tenants {
+		t.inflightRequests.WithLabelValues(tenant.Tenant).Set(float64(tenant.InflightRequests))
+		t.estimatedQueueWait.WithLabelValues(tenant.Tenant).Set(tenant.EstimatedQueueWaitSeconds)
+	}
+	t.requiredWorkers.Set(float64(status.RequiredWorkers))
+	t.desiredQueriers.Set(float64(status.DesiredQueriers))
+	return nil
+}
+
+// tickRates updates the dequeue rates, and returns the tenants removed because without demand.
+func (t *demandTracker) tickRates() []string {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	var removed []string
+	for userID, d := range t.tenants {
+		d.dequeueRate.Tick()
+		if len(d.queued) == 0 && d.inflight == 0 && d.dequeueRate.Rate() < 0.01 {
+			delete(t.tenants, userID)
+			removed = append(removed, userID)
+		}
+	}
+	return removed
+}
+
+func (t *demandTracker) cleanupMetricsForInactiveUser(user string) {
+	t.inflightRequests.DeleteLabelValues(user)
+	t.estimatedQueueWait.DeleteLabelValues(user)
+}
+
+// AutoscalingHandler serves the current query demand, overall and by tenant, meant to drive the
+// queriers autoscaling (eg. through the KEDA metrics API scaler, reading "desired_queriers").
+func (s *Scheduler) AutoscalingHandler(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, s.demand.status(time.Now()))
+}
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDemandTracker(t *testing.T) {
+	now := time.Now()
+	reg := prometheus.NewPedanticRegistry()
+	workers, queriers := 0, 0
+	tracker := newDemandTracker(func() int { return workers }, func() int { return queriers }, reg)
+
+	key := func(id uint64) requestKey { return requestKey{frontendAddr: "frontend", queryID: id} }
+
+	// No demand.
+	assert.Equal(t, demandStatus{}, tracker.status(now))
+
+	// Queued requests ask for a querier, to scale from zero.
+	tracker.enqueued("user-1", key(1), now.Add(-10*time.Second))
+	tracker.enqueued("user-1", key(2), now.Add(-5*time.Second))
+	tracker.enqueued("user-2", key(3), now)
+	status := tracker.status(now)
+	assert.Equal(t, 3, status.QueuedRequests)
+	assert.Equal(t, 3, status.RequiredWorkers)
+	assert.Equal(t, 1, status.DesiredQueriers)
+	require.Len(t, status.Tenants, 2)
+	assert.Equal(t, "user-1", status.Tenants[0].Tenant)
+	assert.Equal(t, 10.0, status.Tenants[0].EstimatedQueueWaitSeconds)
+
+	// A querier with 2 workers connects and picks up requests.
+	workers, queriers = 2, 1
+	tracker.dequeued("user-1", key(1))
+	tracker.dequeued("user-2", key(3))
+	status = tracker.status(now)
+	assert.Equal(t, 1, status.QueuedRequests)
+	assert.Equal(t, 2, status.InflightRequests)
+	assert.Equal(t, 3, status.RequiredWorkers)
+	assert.Equal(t, 2, status.DesiredQueriers)
+	assert.Equal(t, 5.0, status.Tenants[0].EstimatedQueueWaitSeconds)
+	assert.Equal(t, 0.0, status.Tenants[1].EstimatedQueueWaitSeconds)
+
+	// Once the dequeue rate is known, it's used to estimate the queue wait.
+	require.NoError(t, tracker.tick(context.Background()))
+	assert.Equal(t, 0.2, tracker.status(now).Tenants[0].DequeueRate)
+	for i := uint64(10); i < 20; i++ {
+		tracker.enqueued("user-1", key(i), now)
+	}
+	assert.Equal(t, 55.0, tracker.status(now).Tenants[0].EstimatedQueueWaitSeconds)
+
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_desired_queriers Number of queriers required to execute all queued and in-flight queries concurrently, based on the workers per connected querier.
+		# TYPE cortex_query_scheduler_desired_queriers gauge
+		cortex_query_scheduler_desired_queriers 2
+		# HELP cortex_query_scheduler_inflight_requests_per_user Number of queries dispatched to queriers and still being executed.
+		# TYPE cortex_query_scheduler_inflight_requests_per_user gauge
+		cortex_query_scheduler_inflight_requests_per_user{user="user-1"} 1
+		cortex_query_scheduler_inflight_requests_per_user{user="user-2"} 1
+		# HELP cortex_query_scheduler_required_querier_workers Number of querier workers required to execute all queued and in-flight queries concurrently.
+		# TYPE cortex_query_scheduler_required_querier_workers gauge
+		cortex_query_scheduler_required_querier_workers 3
+	`), "cortex_query_scheduler_desired_queriers", "cortex_query_scheduler_inflight_requests_per_user", "cortex_query_scheduler_required_querier_workers"))
+
+	// Tenants without demand are forgotten once their dequeue rate decays.
+	tracker.completed("user-2")
+	for i := 0; i < 50; i++ {
+		require.NoError(t, tracker.tick(context.Background()))
+	}
+	status = tracker.status(now)
+	require.Len(t, status.Tenants, 1)
+	assert.Equal(t, "user-1", status.Tenants[0].Tenant)
+}
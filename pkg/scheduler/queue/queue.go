@@ -12,6 +12,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
 	"github.com/cortexproject/cortex/pkg/util/services"
 )
 
@@ -21,7 +22,7 @@ const (
 )
 
 var (
-	ErrTooManyRequests = errors.New("too many outstanding requests")
+	ErrTooManyRequests = errors.New(globalerror.TooManyOutstandingRequests.Message("too many outstanding requests"))
 	ErrStopped         = errors.New("queue is stopped")
 )
 
@@ -247,3 +248,17 @@ func (q *RequestQueue) QuerierDisconnecting() {
 func (q *RequestQueue) GetConnectedQuerierWorkersMetric() float64 {
 	return float64(q.connectedQuerierWorkers.Load())
 }
+
+// GetConnectedQueriers returns the number of queriers with at least a worker connected.
+func (q *RequestQueue) GetConnectedQueriers() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	connected := 0
+	for _, querier := range q.queues.queriers {
+		if querier.connections > 0 {
+			connected++
+		}
+	}
+	return connected
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"io"
 	"net/http"
+	"net/textproto"
 	"strconv"
 	"sync"
 	"time"
@@ -28,6 +29,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/grpcclient"
 	"github.com/cortexproject/cortex/pkg/util/httpgrpcutil"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/services"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
@@ -50,6 +52,7 @@ type Scheduler struct {
 
 	requestQueue *queue.RequestQueue
 	activeUsers  *util.ActiveUsersCleanupService
+	demand       *demandTracker
 
 	pendingRequestsMu sync.Mutex
 	pendingRequests   map[requestKey]*schedulerRequest // Request is kept in this map even after being dispatched to querier. It can still be canceled at that time.
@@ -129,10 +132,14 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		Help: "Number of query-frontend worker clients currently connected to the query-scheduler.",
 	}, s.getConnectedFrontendClientsMetric)
 
+	s.demand = newDemandTracker(func() int {
+		return int(s.requestQueue.GetConnectedQuerierWorkersMetric())
+	}, s.requestQueue.GetConnectedQueriers, registerer)
+
 	s.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(s.cleanupMetricsForInactiveUser)
 
 	var err error
-	s.subservices, err = services.NewManager(s.requestQueue, s.activeUsers)
+	s.subservices, err = services.NewManager(s.requestQueue, s.activeUsers, services.NewTimerService(demandTickInterval, nil, s.demand.tick, nil))
 	if err != nil {
 		return nil, err
 	}
@@ -295,6 +302,10 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 		return err
 	}
 
+	if requestID := httpgrpcutil.GetHeader(*msg.HttpRequest, textproto.CanonicalMIMEHeaderKey(util_log.RequestIDHeaderName)); requestID != "" {
+		ctx = util_log.ContextWithRequestID(ctx, requestID)
+	}
+
 	userID := msg.GetUserID()
 
 	req := &schedulerRequest{
@@ -325,7 +336,9 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 
 		s.pendingRequestsMu.Lock()
 		defer s.pendingRequestsMu.Unlock()
-		s.pendingRequests[requestKey{frontendAddr: frontendAddr, queryID: msg.QueryID}] = req
+		key := requestKey{frontendAddr: frontendAddr, queryID: msg.QueryID}
+		s.pendingRequests[key] = req
+		s.demand.enqueued(userID, key, now)
 	})
 }
 
@@ -376,6 +389,7 @@ func (s *Scheduler) QuerierLoop(querier schedulerpb.SchedulerForQuerier_QuerierL
 
 		s.queueDuration.Observe(time.Since(r.enqueueTime).Seconds())
 		r.queueSpan.Finish()
+		s.demand.dequeued(r.userID, requestKey{frontendAddr: r.frontendAddress, queryID: r.queryID})
 
 		/*
 		  We want to dequeue the next unexpired request from the chosen tenant queue.
@@ -392,12 +406,15 @@ func (s *Scheduler) QuerierLoop(querier schedulerpb.SchedulerForQuerier_QuerierL
 		if r.ctx.Err() != nil {
 			// Remove from pending requests.
 			s.cancelRequestAndRemoveFromPending(r.frontendAddress, r.queryID)
+			s.demand.completed(r.userID)
 
 			lastUserIndex = lastUserIndex.ReuseLastUser()
 			continue
 		}
 
-		if err := s.forwardRequestToQuerier(querier, r); err != nil {
+		err = s.forwardRequestToQuerier(querier, r)
+		s.demand.completed(r.userID)
+		if err != nil {
 			return err
 		}
 	}
@@ -459,14 +476,15 @@ func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRe
 		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
 		middleware.ClientUserHeaderInterceptor},
 		nil)
+	logger := util_log.WithContext(ctx, s.log)
 	if err != nil {
-		level.Warn(s.log).Log("msg", "failed to create gRPC options for the connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
+		level.Warn(logger).Log("msg", "failed to create gRPC options for the connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
 		return
 	}
 
 	conn, err := grpc.DialContext(ctx, req.frontendAddress, opts...)
 	if err != nil {
-		level.Warn(s.log).Log("msg", "failed to create gRPC connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
+		level.Warn(logger).Log("msg", "failed to create gRPC connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
 		return
 	}
 
@@ -486,7 +504,7 @@ func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRe
 	})
 
 	if err != nil {
-		level.Warn(s.log).Log("msg", "failed to forward error to frontend", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
+		level.Warn(logger).Log("msg", "failed to forward error to frontend", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
 		return
 	}
 }
@@ -530,6 +548,7 @@ func (s *Scheduler) cleanupMetricsForInactiveUser(user string) {
 	s.discardedRequests.DeletePartialMatch(prometheus.Labels{
 		"user": user,
 	})
+	s.demand.cleanupMetricsForInactiveUser(user)
 }
 
 func (s *Scheduler) getConnectedFrontendClientsMetric() float64 {
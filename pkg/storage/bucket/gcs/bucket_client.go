@@ -23,5 +23,9 @@ func NewBucketClient(ctx context.Context, cfg Config, name string, logger log.Lo
 		return nil, err
 	}
 
-	return gcs.NewBucket(ctx, logger, serialized, name)
+	bucket, err := gcs.NewBucket(ctx, logger, serialized, name)
+	if err != nil {
+		return nil, err
+	}
+	return &objectMetadataBucket{Bucket: bucket}, nil
 }
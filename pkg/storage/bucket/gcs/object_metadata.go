@@ -0,0 +1,49 @@
+package gcs
+
+import (
+	"context"
+	"io"
+
+	"github.com/thanos-io/objstore/providers/gcs"
+)
+
+type objectMetadataContextKey int
+
+const objectMetadataKey objectMetadataContextKey = 0
+
+// ContextWithObjectMetadata returns a context with the custom metadata set on the objects uploaded
+// with it. The returned context should be passed to the Upload function of a bucket client created
+// by NewBucketClient.
+func ContextWithObjectMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	return context.WithValue(ctx, objectMetadataKey, metadata)
+}
+
+func objectMetadataFromContext(ctx context.Context) map[string]string {
+	metadata, _ := ctx.Value(objectMetadataKey).(map[string]string)
+	return metadata
+}
+
+// objectMetadataBucket uploads the objects with the custom metadata of the context, which the
+// Thanos client doesn't support.
+type objectMetadataBucket struct {
+	*gcs.Bucket
+}
+
+// Upload implements objstore.Bucket.
+func (b *objectMetadataBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	metadata := objectMetadataFromContext(ctx)
+	if len(metadata) == 0 {
+		return b.Bucket.Upload(ctx, name, r)
+	}
+
+	// The upload is aborted, instead of committing a partial object, when the copy fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := b.Handle().Object(name).NewWriter(ctx)
+	w.Metadata = metadata
+	if _, err := io.Copy(w, r); err != nil {
+		return err
+	}
+	return w.Close()
+}
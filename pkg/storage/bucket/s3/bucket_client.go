@@ -30,9 +30,13 @@ func NewBucketClient(cfg Config, name string, logger log.Logger) (objstore.Bucke
 	if err != nil {
 		return nil, err
 	}
+	taggingBucket, err := newObjectTaggingBucket(bucket, s3Cfg)
+	if err != nil {
+		return nil, err
+	}
 	return &BucketWithRetries{
 		logger:           logger,
-		bucket:           bucket,
+		bucket:           taggingBucket,
 		operationRetries: defaultOperationRetries,
 		retryMinBackoff:  defaultRetryMinBackoff,
 		retryMaxBackoff:  defaultRetryMaxBackoff,
@@ -0,0 +1,122 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/tags"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/objstore/exthttp"
+	"github.com/thanos-io/objstore/providers/s3"
+)
+
+type objectTagsContextKey int
+
+const objectTagsKey objectTagsContextKey = 0
+
+// ContextWithObjectTags returns a context with the tags set on the objects uploaded with it.
+// The returned context should be passed to the Upload function of a bucket client created by
+// NewBucketClient.
+func ContextWithObjectTags(ctx context.Context, objectTags map[string]string) context.Context {
+	return context.WithValue(ctx, objectTagsKey, objectTags)
+}
+
+func objectTagsFromContext(ctx context.Context) map[string]string {
+	objectTags, _ := ctx.Value(objectTagsKey).(map[string]string)
+	return objectTags
+}
+
+// objectTaggingBucket tags the objects uploaded with the tags of the context, which the Thanos
+// client doesn't support. The object is tagged once uploaded, with a separate request.
+type objectTaggingBucket struct {
+	objstore.Bucket
+
+	client     *minio.Client
+	bucketName string
+}
+
+func newObjectTaggingBucket(bkt objstore.Bucket, cfg s3.Config) (*objectTaggingBucket, error) {
+	client, err := newMinioClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &objectTaggingBucket{Bucket: bkt, client: client, bucketName: cfg.Bucket}, nil
+}
+
+// Upload implements objstore.Bucket.
+func (b *objectTaggingBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if err := b.Bucket.Upload(ctx, name, r); err != nil {
+		return err
+	}
+
+	objectTags := objectTagsFromContext(ctx)
+	if len(objectTags) == 0 {
+		return nil
+	}
+	t, err := tags.NewTags(objectTags, true)
+	if err != nil {
+		return errors.Wrap(err, "invalid object tags")
+	}
+	return errors.Wrap(b.client.PutObjectTagging(ctx, b.bucketName, name, t, minio.PutObjectTaggingOptions{}), "tag s3 object")
+}
+
+// newMinioClient makes a minio client authenticated like the Thanos S3 client.
+func newMinioClient(cfg s3.Config) (*minio.Client, error) {
+	var chain []credentials.Provider
+	switch {
+	case cfg.AWSSDKAuth:
+		chain = []credentials.Provider{&s3.AWSSDKAuth{Region: cfg.Region}}
+	case cfg.AccessKey != "":
+		chain = []credentials.Provider{&credentials.Static{
+			Value: credentials.Value{
+				AccessKeyID:     cfg.AccessKey,
+				SecretAccessKey: cfg.SecretKey,
+				SessionToken:    cfg.SessionToken,
+				SignerType:      credentials.SignatureV4,
+			},
+		}}
+	default:
+		chain = []credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{Client: &http.Client{Transport: http.DefaultTransport}, Endpoint: cfg.STSEndpoint},
+		}
+	}
+	if cfg.SignatureV2 {
+		for i, p := range chain {
+			chain[i] = signatureV2Provider{Provider: p}
+		}
+	}
+
+	rt := cfg.HTTPConfig.Transport
+	if rt == nil {
+		var err error
+		if rt, err = exthttp.DefaultTransport(cfg.HTTPConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewChainCredentials(chain),
+		Secure:       !cfg.Insecure,
+		Region:       cfg.Region,
+		Transport:    rt,
+		BucketLookup: cfg.BucketLookupType.MinioType(),
+	})
+	return client, errors.Wrap(err, "initialize s3 client")
+}
+
+// signatureV2Provider enforces the signature version 2 of the provided credentials.
+type signatureV2Provider struct {
+	credentials.Provider
+}
+
+func (p signatureV2Provider) Retrieve() (credentials.Value, error) {
+	v, err := p.Provider.Retrieve()
+	v.SignerType = credentials.SignatureV2
+	return v, err
+}
@@ -13,6 +13,7 @@ import (
 
 	cortex_errors "github.com/cortexproject/cortex/pkg/util/errors"
 
+	cortex_gcs "github.com/cortexproject/cortex/pkg/storage/bucket/gcs"
 	cortex_s3 "github.com/cortexproject/cortex/pkg/storage/bucket/s3"
 )
 
@@ -26,6 +27,9 @@ type TenantConfigProvider interface {
 
 	// S3SSEKMSEncryptionContext returns the per-tenant S3 KMS-SSE key id or an empty string if not set.
 	S3SSEKMSEncryptionContext(userID string) string
+
+	// ObjectStorageTags returns the per-tenant tags of the uploaded objects or nil if not set.
+	ObjectStorageTags(userID string) map[string]string
 }
 
 // SSEBucketClient is a wrapper around a objstore.BucketReader that configures the object
@@ -60,6 +64,14 @@ func (b *SSEBucketClient) Upload(ctx context.Context, name string, r io.Reader)
 		ctx = s3.ContextWithSSEConfig(ctx, sse)
 	}
 
+	// The tags are ignored by the backends other than S3 and GCS.
+	if b.cfgProvider != nil {
+		if objectTags := b.cfgProvider.ObjectStorageTags(b.userID); len(objectTags) > 0 {
+			ctx = cortex_s3.ContextWithObjectTags(ctx, objectTags)
+			ctx = cortex_gcs.ContextWithObjectMetadata(ctx, objectTags)
+		}
+	}
+
 	return b.bucket.Upload(ctx, name, r)
 }
 
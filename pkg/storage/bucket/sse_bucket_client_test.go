@@ -3,6 +3,7 @@ package bucket
 import (
 	"context"
 	"encoding/base64"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -106,6 +107,56 @@ func TestSSEBucketClient_Upload_ShouldInjectCustomSSEConfig(t *testing.T) {
 	}
 }
 
+func TestSSEBucketClient_Upload_ShouldTagObjects(t *testing.T) {
+	type request struct {
+		method, path, query, body string
+	}
+	var reqs []request
+
+	// Start a fake HTTP server which simulate S3.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs = append(reqs, request{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery, body: string(body)})
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	s3Cfg := s3.Config{
+		Endpoint:         srv.Listener.Addr().String(),
+		Region:           "test",
+		BucketName:       "test-bucket",
+		SecretAccessKey:  flagext.Secret{Value: "test"},
+		AccessKeyID:      "test",
+		Insecure:         true,
+		BucketLookupType: s3.BucketPathLookup,
+	}
+
+	s3Client, err := s3.NewBucketClient(s3Cfg, "test", log.NewNopLogger())
+	require.NoError(t, err)
+
+	// Configure the config provider with NO tags.
+	cfgProvider := &mockTenantConfigProvider{}
+	bkt := NewSSEBucketClient("user-1", s3Client, cfgProvider)
+
+	require.NoError(t, bkt.Upload(context.Background(), "test", strings.NewReader("test")))
+	require.Len(t, reqs, 1)
+	assert.Equal(t, http.MethodPut, reqs[0].method)
+	assert.Equal(t, "", reqs[0].query)
+
+	// Configure the config provider with tags.
+	reqs = nil
+	cfgProvider.objectStorageTags = map[string]string{"team": "billing"}
+
+	require.NoError(t, bkt.Upload(context.Background(), "test", strings.NewReader("test")))
+	require.Len(t, reqs, 2)
+	assert.Equal(t, "", reqs[0].query)
+	assert.Equal(t, http.MethodPut, reqs[1].method)
+	assert.Equal(t, "/test-bucket/test", reqs[1].path)
+	assert.Equal(t, "tagging=", reqs[1].query)
+	assert.Contains(t, reqs[1].body, "<Tag><Key>team</Key><Value>billing</Value></Tag>")
+}
+
 func Test_shouldWrapSSeErrors(t *testing.T) {
 	cfgProvider := &mockTenantConfigProvider{}
 
@@ -123,6 +174,7 @@ type mockTenantConfigProvider struct {
 	s3SseType              string
 	s3KmsKeyID             string
 	s3KmsEncryptionContext string
+	objectStorageTags      map[string]string
 }
 
 func (m *mockTenantConfigProvider) S3SSEType(_ string) string {
@@ -136,3 +188,7 @@ func (m *mockTenantConfigProvider) S3SSEKMSKeyID(_ string) string {
 func (m *mockTenantConfigProvider) S3SSEKMSEncryptionContext(_ string) string {
 	return m.s3KmsEncryptionContext
 }
+
+func (m *mockTenantConfigProvider) ObjectStorageTags(_ string) map[string]string {
+	return m.objectStorageTags
+}
@@ -0,0 +1,109 @@
+package bloom
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/thanos-io/objstore"
+)
+
+const (
+	// BlockFilterFilename is the name of the label pairs bloom filter object, stored alongside
+	// the meta.json of a block.
+	BlockFilterFilename = "label-pairs-bloom-filter.bin"
+
+	// DefaultFalsePositiveRate is the false positive rate of the block filters.
+	DefaultFalsePositiveRate = 0.01
+
+	labelPairSeparator = '\xff'
+)
+
+// IndexReader is the subset of the block index reader used to build a block filter.
+type IndexReader interface {
+	LabelNames(ctx context.Context, matchers ...*labels.Matcher) ([]string, error)
+	LabelValues(ctx context.Context, name string, matchers ...*labels.Matcher) ([]string, error)
+}
+
+// BuildBlockFilter returns the filter of all the label pairs in the block index.
+func BuildBlockFilter(ctx context.Context, ir IndexReader, falsePositiveRate float64) (*Filter, error) {
+	names, err := ir.LabelNames(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "read label names")
+	}
+
+	values := make(map[string][]string, len(names))
+	numPairs := 0
+	for _, name := range names {
+		if values[name], err = ir.LabelValues(ctx, name); err != nil {
+			return nil, errors.Wrapf(err, "read values of label %s", name)
+		}
+		numPairs += len(values[name])
+	}
+
+	f := NewFilter(numPairs, falsePositiveRate)
+	var buf []byte
+	for name, vals := range values {
+		for _, value := range vals {
+			buf = appendLabelPair(buf[:0], name, value)
+			f.Add(buf)
+		}
+	}
+	return f, nil
+}
+
+// CanMatch returns false if no series of the block whose filter is f can match all the
+// matchers. Only the equality matchers on a non-empty value are checked.
+func CanMatch(f *Filter, matchers []*labels.Matcher) bool {
+	var buf []byte
+	for _, m := range matchers {
+		if m.Type != labels.MatchEqual || m.Value == "" {
+			continue
+		}
+
+		buf = appendLabelPair(buf[:0], m.Name, m.Value)
+		if !f.Test(buf) {
+			return false
+		}
+	}
+	return true
+}
+
+// UploadBlockFilter uploads the filter of the block to the bucket.
+func UploadBlockFilter(ctx context.Context, bkt objstore.Bucket, blockID ulid.ULID, f *Filter) error {
+	return bkt.Upload(ctx, blockFilterPath(blockID), bytes.NewReader(f.Marshal()))
+}
+
+// ReadBlockFilter reads the filter of the block from the bucket. It returns nil if the
+// block doesn't have a filter.
+func ReadBlockFilter(ctx context.Context, bkt objstore.BucketReader, blockID ulid.ULID) (*Filter, error) {
+	r, err := bkt.Get(ctx, blockFilterPath(blockID))
+	if bkt.IsObjNotFoundErr(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read bloom filter of block %s", blockID)
+	}
+	defer r.Close() //nolint:errcheck
+
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read bloom filter of block %s", blockID)
+	}
+	f, err := Unmarshal(buf)
+	return f, errors.Wrapf(err, "decode bloom filter of block %s", blockID)
+}
+
+func blockFilterPath(blockID ulid.ULID) string {
+	return path.Join(blockID.String(), BlockFilterFilename)
+}
+
+func appendLabelPair(buf []byte, name, value string) []byte {
+	buf = append(buf, name...)
+	buf = append(buf, labelPairSeparator)
+	return append(buf, value...)
+}
@@ -0,0 +1,116 @@
+// Package bloom implements the bloom filters of the label pairs of a block, used to skip the
+// blocks which can't contain any series matching the query selector.
+package bloom
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	filterVersion1 = 1
+
+	// filterHeaderSize is the size of the version (1 byte), number of hash functions (1 byte)
+	// and number of bits (8 bytes).
+	filterHeaderSize = 10
+
+	maxHashFunctions = 32
+)
+
+var errInvalidFilter = errors.New("invalid bloom filter")
+
+// Filter is a bloom filter. It's not safe for concurrent writes.
+type Filter struct {
+	words []uint64
+	k     uint8
+}
+
+// NewFilter returns a filter sized to hold n items with the given false positive rate.
+func NewFilter(n int, falsePositiveRate float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+
+	// Optimal number of bits and hash functions, see https://en.wikipedia.org/wiki/Bloom_filter.
+	m := math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+
+	return &Filter{
+		words: make([]uint64, (uint64(m)+63)/64),
+		k:     uint8(math.Max(1, math.Min(k, maxHashFunctions))),
+	}
+}
+
+// Add adds the item to the filter.
+func (f *Filter) Add(item []byte) {
+	h1, h2 := hashes(item)
+	nbits := f.numBits()
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % nbits
+		f.words[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Test returns false if the item is definitely not in the filter, true if it may be.
+func (f *Filter) Test(item []byte) bool {
+	h1, h2 := hashes(item)
+	nbits := f.numBits()
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % nbits
+		if f.words[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the size of the filter in memory, in bytes.
+func (f *Filter) Size() int {
+	return len(f.words) * 8
+}
+
+// Marshal returns the binary encoding of the filter.
+func (f *Filter) Marshal() []byte {
+	buf := make([]byte, filterHeaderSize+len(f.words)*8)
+	buf[0] = filterVersion1
+	buf[1] = f.k
+	binary.BigEndian.PutUint64(buf[2:], f.numBits())
+	for i, w := range f.words {
+		binary.BigEndian.PutUint64(buf[filterHeaderSize+i*8:], w)
+	}
+	return buf
+}
+
+// Unmarshal decodes a filter encoded with Marshal.
+func Unmarshal(buf []byte) (*Filter, error) {
+	if len(buf) < filterHeaderSize || buf[0] != filterVersion1 {
+		return nil, errInvalidFilter
+	}
+
+	k := buf[1]
+	nbits := binary.BigEndian.Uint64(buf[2:])
+	if k == 0 || k > maxHashFunctions || nbits == 0 || nbits%64 != 0 || uint64(len(buf)-filterHeaderSize) != nbits/8 {
+		return nil, errInvalidFilter
+	}
+
+	f := &Filter{words: make([]uint64, nbits/64), k: k}
+	for i := range f.words {
+		f.words[i] = binary.BigEndian.Uint64(buf[filterHeaderSize+i*8:])
+	}
+	return f, nil
+}
+
+func (f *Filter) numBits() uint64 {
+	return uint64(len(f.words)) * 64
+}
+
+// hashes returns the two hashes used to derive the k hash functions with double hashing.
+func hashes(item []byte) (uint64, uint64) {
+	h := xxhash.Sum64(item)
+	h1, h2 := h&math.MaxUint32, h>>32
+	// An even h2 would only set the bits with the same parity, and a zero one a single bit.
+	return h1, h2 | 1
+}
@@ -0,0 +1,116 @@
+package bloom
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestFilter(t *testing.T) {
+	const numItems = 10000
+
+	f := NewFilter(numItems, DefaultFalsePositiveRate)
+	for i := 0; i < numItems; i++ {
+		f.Add([]byte(fmt.Sprintf("item-%d", i)))
+	}
+
+	// No false negatives.
+	for i := 0; i < numItems; i++ {
+		require.True(t, f.Test([]byte(fmt.Sprintf("item-%d", i))))
+	}
+
+	// Few false positives.
+	falsePositives := 0
+	for i := 0; i < numItems; i++ {
+		if f.Test([]byte(fmt.Sprintf("other-%d", i))) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, float64(falsePositives)/numItems, 2*DefaultFalsePositiveRate)
+
+	decoded, err := Unmarshal(f.Marshal())
+	require.NoError(t, err)
+	assert.Equal(t, f, decoded)
+
+	_, err = Unmarshal(f.Marshal()[:100])
+	assert.Equal(t, errInvalidFilter, err)
+}
+
+type mockIndexReader map[string][]string
+
+func (r mockIndexReader) LabelNames(context.Context, ...*labels.Matcher) ([]string, error) {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (r mockIndexReader) LabelValues(_ context.Context, name string, _ ...*labels.Matcher) ([]string, error) {
+	return r[name], nil
+}
+
+func TestBlockFilter(t *testing.T) {
+	ctx := context.Background()
+	f, err := BuildBlockFilter(ctx, mockIndexReader{
+		labels.MetricName: {"up", "http_requests_total"},
+		"job":             {"api"},
+	}, DefaultFalsePositiveRate)
+	require.NoError(t, err)
+
+	bkt := objstore.NewInMemBucket()
+	blockID := ulid.MustNew(1, nil)
+	require.NoError(t, UploadBlockFilter(ctx, bkt, blockID, f))
+
+	f, err = ReadBlockFilter(ctx, bkt, blockID)
+	require.NoError(t, err)
+
+	for name, testData := range map[string]struct {
+		matchers []*labels.Matcher
+		expected bool
+	}{
+		"no matchers": {
+			expected: true,
+		},
+		"matching equal matchers": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "api"),
+			},
+			expected: true,
+		},
+		"not matching equal matcher": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "ingester"),
+			},
+			expected: false,
+		},
+		"equal matcher on empty value": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "pod", "")},
+			expected: true,
+		},
+		"other matchers": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchRegexp, "job", "ingester"),
+				labels.MustNewMatcher(labels.MatchNotEqual, "job", "api"),
+			},
+			expected: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testData.expected, CanMatch(f, testData.matchers))
+		})
+	}
+
+	// Blocks without a filter.
+	f, err = ReadBlockFilter(ctx, bkt, ulid.MustNew(2, nil))
+	require.NoError(t, err)
+	assert.Nil(t, f)
+}
@@ -84,6 +84,13 @@ type Block struct {
 	SeriesMaxSize int64 `json:"series_max_size,omitempty"`
 	ChunkMaxSize  int64 `json:"chunk_max_size,omitempty"`
 
+	// Resolution is the downsampling resolution of the block (millis precision), 0 for raw blocks.
+	Resolution int64 `json:"resolution,omitempty"`
+
+	// SizeBytes is the total size of the block files, as listed in the meta.json. It's 0 if
+	// the meta.json doesn't list the block files.
+	SizeBytes int64 `json:"size_bytes,omitempty"`
+
 	// UploadedAt is a unix timestamp (seconds precision) of when the block has been completed to be uploaded
 	// to the storage.
 	UploadedAt int64 `json:"uploaded_at"`
@@ -116,6 +123,7 @@ func (m *Block) ThanosMeta(userID string) *metadata.Meta {
 			Labels: map[string]string{
 				cortex_tsdb.TenantIDExternalLabel: userID,
 			},
+			Downsample:   metadata.ThanosDownsample{Resolution: m.Resolution},
 			SegmentFiles: m.thanosMetaSegmentFiles(),
 			IndexStats: metadata.IndexStats{
 				SeriesMaxSize: m.SeriesMaxSize,
@@ -153,7 +161,17 @@ func BlockFromThanosMeta(meta metadata.Meta) *Block {
 		SegmentsNum:    segmentsNum,
 		SeriesMaxSize:  meta.Thanos.IndexStats.SeriesMaxSize,
 		ChunkMaxSize:   meta.Thanos.IndexStats.ChunkMaxSize,
+		Resolution:     meta.Thanos.Downsample.Resolution,
+		SizeBytes:      blockSizeBytes(meta),
+	}
+}
+
+func blockSizeBytes(meta metadata.Meta) int64 {
+	size := int64(0)
+	for _, f := range meta.Thanos.Files {
+		size += f.SizeBytes
 	}
+	return size
 }
 
 func detectBlockSegmentsFormat(meta metadata.Meta) (string, int) {
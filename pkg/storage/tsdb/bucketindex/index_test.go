@@ -232,6 +232,32 @@ func TestBlockFromThanosMeta(t *testing.T) {
 				ChunkMaxSize:   1000,
 			},
 		},
+		"meta.json of a downsampled block with Files sizes": {
+			meta: metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+				},
+				Thanos: metadata.Thanos{
+					Downsample: metadata.ThanosDownsample{Resolution: 300000},
+					Files: []metadata.File{
+						{RelPath: "index", SizeBytes: 100},
+						{RelPath: "chunks/000001", SizeBytes: 1000},
+						{RelPath: "meta.json"},
+					},
+				},
+			},
+			expected: Block{
+				ID:             blockID,
+				MinTime:        10,
+				MaxTime:        20,
+				SegmentsFormat: SegmentsFormat1Based6Digits,
+				SegmentsNum:    1,
+				Resolution:     300000,
+				SizeBytes:      1100,
+			},
+		},
 	}
 
 	for testName, testData := range tests {
@@ -374,6 +400,30 @@ func TestBlock_ThanosMeta(t *testing.T) {
 				},
 			},
 		},
+		"downsampled block": {
+			block: Block{
+				ID:             blockID,
+				MinTime:        10,
+				MaxTime:        20,
+				SegmentsFormat: SegmentsFormatUnknown,
+				Resolution:     300000,
+			},
+			expected: &metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+					Version: metadata.TSDBVersion1,
+				},
+				Thanos: metadata.Thanos{
+					Version: metadata.ThanosVersion1,
+					Labels: map[string]string{
+						"__org_id__": userID,
+					},
+					Downsample: metadata.ThanosDownsample{Resolution: 300000},
+				},
+			},
+		},
 	}
 
 	for testName, testData := range tests {
@@ -40,14 +40,16 @@ const (
 
 // Validation errors
 var (
-	errInvalidShipConcurrency       = errors.New("invalid TSDB ship concurrency")
-	errInvalidOpeningConcurrency    = errors.New("invalid TSDB opening concurrency")
-	errInvalidCompactionInterval    = errors.New("invalid TSDB compaction interval")
-	errInvalidCompactionConcurrency = errors.New("invalid TSDB compaction concurrency")
-	errInvalidWALSegmentSizeBytes   = errors.New("invalid TSDB WAL segment size bytes")
-	errInvalidStripeSize            = errors.New("invalid TSDB stripe size")
-	errInvalidOutOfOrderCapMax      = errors.New("invalid TSDB OOO chunks capacity (in samples)")
-	errEmptyBlockranges             = errors.New("empty block ranges for TSDB")
+	errInvalidShipConcurrency        = errors.New("invalid TSDB ship concurrency")
+	errInvalidOpeningConcurrency     = errors.New("invalid TSDB opening concurrency")
+	errInvalidCompactionInterval     = errors.New("invalid TSDB compaction interval")
+	errInvalidCompactionConcurrency  = errors.New("invalid TSDB compaction concurrency")
+	errInvalidWALSegmentSizeBytes    = errors.New("invalid TSDB WAL segment size bytes")
+	errInvalidStripeSize             = errors.New("invalid TSDB stripe size")
+	errInvalidOutOfOrderCapMax       = errors.New("invalid TSDB OOO chunks capacity (in samples)")
+	errInvalidMemorySnapshotInterval = errors.New("invalid TSDB memory snapshot interval")
+	errEmptyBlockranges              = errors.New("empty block ranges for TSDB")
+	errSecondaryIndexNoBloomFilters  = errors.New("the blocks secondary indexes require the blocks bloom filters")
 )
 
 // BlocksStorageConfig holds the config information for the blocks storage.
@@ -153,6 +155,9 @@ type TSDBConfig struct {
 	// Enable snapshotting of in-memory TSDB data on disk when shutting down.
 	MemorySnapshotOnShutdown bool `yaml:"memory_snapshot_on_shutdown"`
 
+	// How frequently the in-memory TSDB data is snapshotted on disk. 0 means disabled.
+	MemorySnapshotInterval time.Duration `yaml:"memory_snapshot_interval"`
+
 	// OutOfOrderCapMax is maximum capacity for OOO chunks (in samples).
 	OutOfOrderCapMax int64 `yaml:"out_of_order_cap_max"`
 }
@@ -181,6 +186,7 @@ func (cfg *TSDBConfig) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.HeadChunksWriteQueueSize, "blocks-storage.tsdb.head-chunks-write-queue-size", chunks.DefaultWriteQueueSize, "The size of the in-memory queue used before flushing chunks to the disk.")
 	f.IntVar(&cfg.MaxExemplars, "blocks-storage.tsdb.max-exemplars", 0, "Deprecated, use maxExemplars in limits instead. If the MaxExemplars value in limits is set to zero, cortex will fallback on this value. This setting enables support for exemplars in TSDB and sets the maximum number that will be stored. 0 or less means disabled.")
 	f.BoolVar(&cfg.MemorySnapshotOnShutdown, "blocks-storage.tsdb.memory-snapshot-on-shutdown", false, "True to enable snapshotting of in-memory TSDB data on disk when shutting down.")
+	f.DurationVar(&cfg.MemorySnapshotInterval, "blocks-storage.tsdb.memory-snapshot-interval", 0, "How frequently the in-memory TSDB data is snapshotted on disk, in addition to the snapshot on shutdown, so that a restart only replays the WAL written after the latest snapshot, even if the ingester didn't shut down cleanly. The pushes to a tenant wait while its snapshot is taken. Implies -blocks-storage.tsdb.memory-snapshot-on-shutdown. 0 means disabled.")
 	f.Int64Var(&cfg.OutOfOrderCapMax, "blocks-storage.tsdb.out-of-order-cap-max", tsdb.DefaultOutOfOrderCapMax, "[EXPERIMENTAL] Configures the maximum number of samples per chunk that can be out-of-order.")
 }
 
@@ -222,9 +228,19 @@ func (cfg *TSDBConfig) Validate() error {
 		return errInvalidOutOfOrderCapMax
 	}
 
+	if cfg.MemorySnapshotInterval < 0 {
+		return errInvalidMemorySnapshotInterval
+	}
+
 	return nil
 }
 
+// IsMemorySnapshotEnabled returns whether the in-memory TSDB data is snapshotted on disk,
+// and loaded from the latest snapshot on startup.
+func (cfg *TSDBConfig) IsMemorySnapshotEnabled() bool {
+	return cfg.MemorySnapshotOnShutdown || cfg.MemorySnapshotInterval > 0
+}
+
 // BlocksDir returns the directory path where TSDB blocks and wal should be
 // stored by the ingester
 func (cfg *TSDBConfig) BlocksDir(userID string) string {
@@ -241,6 +257,7 @@ type BucketStoreConfig struct {
 	SyncDir                  string              `yaml:"sync_dir"`
 	SyncInterval             time.Duration       `yaml:"sync_interval"`
 	MaxConcurrent            int                 `yaml:"max_concurrent"`
+	QueryPriorityEnabled     bool                `yaml:"query_priority_enabled"`
 	MaxInflightRequests      int                 `yaml:"max_inflight_requests"`
 	TenantSyncConcurrency    int                 `yaml:"tenant_sync_concurrency"`
 	BlockSyncConcurrency     int                 `yaml:"block_sync_concurrency"`
@@ -283,6 +300,13 @@ type BucketStoreConfig struct {
 
 	// Controls how many series to fetch per batch in Store Gateway. Default value is 10000.
 	SeriesBatchSize int `yaml:"series_batch_size"`
+
+	// Controls whether the blocks bloom filters are used to skip the blocks which can't match a query.
+	BloomFiltersEnabled        bool  `yaml:"bloom_filters_enabled"`
+	BloomFiltersCacheSizeBytes int64 `yaml:"bloom_filters_cache_size_bytes"`
+
+	// Controls whether the blocks secondary indexes are checked along with the bloom filters.
+	SecondaryIndexEnabled bool `yaml:"secondary_index_enabled"`
 }
 
 // RegisterFlags registers the BucketStore flags
@@ -298,6 +322,7 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.ChunkPoolMinBucketSizeBytes, "blocks-storage.bucket-store.chunk-pool-min-bucket-size-bytes", ChunkPoolDefaultMinBucketSize, "Size - in bytes - of the smallest chunks pool bucket.")
 	f.IntVar(&cfg.ChunkPoolMaxBucketSizeBytes, "blocks-storage.bucket-store.chunk-pool-max-bucket-size-bytes", ChunkPoolDefaultMaxBucketSize, "Size - in bytes - of the largest chunks pool bucket.")
 	f.IntVar(&cfg.MaxConcurrent, "blocks-storage.bucket-store.max-concurrent", 100, "Max number of concurrent queries to execute against the long-term storage. The limit is shared across all tenants.")
+	f.BoolVar(&cfg.QueryPriorityEnabled, "blocks-storage.bucket-store.query-priority-enabled", false, "Experimental: Execute the queries waiting for -blocks-storage.bucket-store.max-concurrent by decreasing priority, as assigned by the query-frontend, instead of in arrival order.")
 	f.IntVar(&cfg.MaxInflightRequests, "blocks-storage.bucket-store.max-inflight-requests", 0, "Max number of inflight queries to execute against the long-term storage. The limit is shared across all tenants. 0 to disable.")
 	f.IntVar(&cfg.TenantSyncConcurrency, "blocks-storage.bucket-store.tenant-sync-concurrency", 10, "Maximum number of concurrent tenants synching blocks.")
 	f.IntVar(&cfg.BlockSyncConcurrency, "blocks-storage.bucket-store.block-sync-concurrency", 20, "Maximum number of concurrent blocks synching per tenant.")
@@ -315,6 +340,9 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.Uint64Var(&cfg.EstimatedMaxChunkSizeBytes, "blocks-storage.bucket-store.estimated-max-chunk-size-bytes", store.EstimatedMaxChunkSize, "Estimated max chunk size in bytes. Setting a large value might result in over fetching data while a small value might result in data refetch. Default value is 16KiB.")
 	f.BoolVar(&cfg.LazyExpandedPostingsEnabled, "blocks-storage.bucket-store.lazy-expanded-postings-enabled", false, "If true, Store Gateway will estimate postings size and try to lazily expand postings if it downloads less data than expanding all postings.")
 	f.IntVar(&cfg.SeriesBatchSize, "blocks-storage.bucket-store.series-batch-size", store.SeriesBatchSize, "Controls how many series to fetch per batch in Store Gateway. Default value is 10000.")
+	f.BoolVar(&cfg.BloomFiltersEnabled, "blocks-storage.bucket-store.bloom-filters-enabled", false, "If enabled, the store-gateway skips the blocks whose label pairs bloom filter, created by the compactor when -compactor.bloom-filters-enabled is enabled, can't match the equality matchers of a query.")
+	f.Int64Var(&cfg.BloomFiltersCacheSizeBytes, "blocks-storage.bucket-store.bloom-filters-cache-size-bytes", int64(256*units.Mebibyte), "Maximum size in bytes of the in-memory cache of the blocks bloom filters.")
+	f.BoolVar(&cfg.SecondaryIndexEnabled, "blocks-storage.bucket-store.secondary-index-enabled", false, "If enabled, the store-gateway also skips the blocks whose secondary index, created by the compactor for the labels configured in -compactor.secondary-index-labels, doesn't contain the values looked up by the equality and regex set matchers of a query. The secondary indexes are checked along with the bloom filters and share their cache, so it requires -blocks-storage.bucket-store.bloom-filters-enabled.")
 }
 
 // Validate the config.
@@ -331,6 +359,9 @@ func (cfg *BucketStoreConfig) Validate() error {
 	if err != nil {
 		return errors.Wrap(err, "metadata-cache configuration")
 	}
+	if cfg.SecondaryIndexEnabled && !cfg.BloomFiltersEnabled {
+		return errSecondaryIndexNoBloomFilters
+	}
 	return nil
 }
 
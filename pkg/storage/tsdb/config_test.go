@@ -121,6 +121,25 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErr: errInvalidOutOfOrderCapMax,
 		},
+		"should fail on negative memory snapshot interval": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.TSDB.MemorySnapshotInterval = -time.Minute
+			},
+			expectedErr: errInvalidMemorySnapshotInterval,
+		},
+		"should fail on secondary indexes without bloom filters": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.SecondaryIndexEnabled = true
+			},
+			expectedErr: errSecondaryIndexNoBloomFilters,
+		},
+		"should pass on secondary indexes with bloom filters": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.SecondaryIndexEnabled = true
+				cfg.BucketStore.BloomFiltersEnabled = true
+			},
+			expectedErr: nil,
+		},
 	}
 
 	for testName, testData := range tests {
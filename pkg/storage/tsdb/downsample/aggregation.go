@@ -0,0 +1,50 @@
+package downsample
+
+import (
+	thanos_downsample "github.com/thanos-io/thanos/pkg/compact/downsample"
+)
+
+// BuiltinAggregations are the aggregations stored in the aggregated chunks of the downsampled
+// blocks. Cortex doesn't downsample blocks itself: they're written by an external downsampler,
+// like the Thanos compactor, which always computes these aggregations.
+var BuiltinAggregations = []thanos_downsample.AggrType{
+	thanos_downsample.AggrCount,
+	thanos_downsample.AggrSum,
+	thanos_downsample.AggrMin,
+	thanos_downsample.AggrMax,
+	thanos_downsample.AggrCounter,
+}
+
+// BuiltinAggrType returns the Thanos aggregation type for a built-in aggregation name.
+func BuiltinAggrType(name string) (thanos_downsample.AggrType, bool) {
+	for _, t := range BuiltinAggregations {
+		if t.String() == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// functionAggregations maps the PromQL functions to the aggregations they're evaluated on when
+// reading downsampled data. Same mapping used by the Thanos querier.
+var functionAggregations = map[string][]thanos_downsample.AggrType{
+	"min_over_time":   {thanos_downsample.AggrMin},
+	"max_over_time":   {thanos_downsample.AggrMax},
+	"count_over_time": {thanos_downsample.AggrCount},
+	"sum_over_time":   {thanos_downsample.AggrSum},
+	"avg_over_time":   {thanos_downsample.AggrSum, thanos_downsample.AggrCount},
+	"rate":            {thanos_downsample.AggrCounter},
+	"increase":        {thanos_downsample.AggrCounter},
+	"irate":           {thanos_downsample.AggrCounter},
+	"resets":          {thanos_downsample.AggrCounter},
+}
+
+// AggregationsForFunction returns the aggregations required to evaluate the given PromQL
+// function on downsampled data. Functions without a mapping read sum and count, which
+// allows to compute an average.
+func AggregationsForFunction(function string) []thanos_downsample.AggrType {
+	if aggrs, ok := functionAggregations[function]; ok {
+		return aggrs
+	}
+	return []thanos_downsample.AggrType{thanos_downsample.AggrSum, thanos_downsample.AggrCount}
+}
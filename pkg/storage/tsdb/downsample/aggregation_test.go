@@ -0,0 +1,24 @@
+package downsample
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	thanos_downsample "github.com/thanos-io/thanos/pkg/compact/downsample"
+)
+
+func TestAggregationsForFunction(t *testing.T) {
+	assert.Equal(t, []thanos_downsample.AggrType{thanos_downsample.AggrCounter}, AggregationsForFunction("rate"))
+	assert.Equal(t, []thanos_downsample.AggrType{thanos_downsample.AggrMax}, AggregationsForFunction("max_over_time"))
+	assert.Equal(t, []thanos_downsample.AggrType{thanos_downsample.AggrSum, thanos_downsample.AggrCount}, AggregationsForFunction("avg_over_time"))
+	assert.Equal(t, []thanos_downsample.AggrType{thanos_downsample.AggrSum, thanos_downsample.AggrCount}, AggregationsForFunction("last_over_time"))
+}
+
+func TestBuiltinAggrType(t *testing.T) {
+	aggr, ok := BuiltinAggrType("counter")
+	assert.True(t, ok)
+	assert.Equal(t, thanos_downsample.AggrCounter, aggr)
+
+	_, ok = BuiltinAggrType("p50")
+	assert.False(t, ok)
+}
@@ -0,0 +1,96 @@
+package downsample
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	thanos_downsample "github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+// MaxSourceResolutionParam is the query API parameter, compatible with the Thanos one, used to
+// select the maximum resolution of the data a query is evaluated against.
+const MaxSourceResolutionParam = "max_source_resolution"
+
+// autoMaxSourceResolution is the max source resolution value used to select the resolution
+// based on the query step.
+const autoMaxSourceResolution = "auto"
+
+type contextKey int
+
+const maxResolutionContextKey contextKey = 0
+
+// ContextWithMaxResolution returns a new context carrying the maximum resolution, in milliseconds,
+// the queries executed with the context are allowed to read. A resolution of 0 means raw data only.
+func ContextWithMaxResolution(ctx context.Context, resolution int64) context.Context {
+	return context.WithValue(ctx, maxResolutionContextKey, resolution)
+}
+
+// MaxResolutionFromContext returns the maximum resolution, in milliseconds, stored in the context
+// or 0 (raw data only) if not set.
+func MaxResolutionFromContext(ctx context.Context) int64 {
+	if resolution, ok := ctx.Value(maxResolutionContextKey).(int64); ok {
+		return resolution
+	}
+	return 0
+}
+
+// ParseMaxSourceResolution parses the max source resolution query parameter, expressed either as
+// a Prometheus duration or as a float number of seconds, and returns it in milliseconds. The "auto"
+// value selects a resolution of a fifth of the step, in milliseconds, as the Thanos querier does.
+func ParseMaxSourceResolution(s string, step int64) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if s == autoMaxSourceResolution {
+		return step / 5, nil
+	}
+
+	var resolution int64
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, errors.Errorf("invalid max source resolution %q", s)
+		}
+		resolution = int64(f * float64(time.Second/time.Millisecond))
+	} else if d, err := model.ParseDuration(s); err == nil {
+		resolution = time.Duration(d).Milliseconds()
+	} else {
+		return 0, errors.Errorf("invalid max source resolution %q", s)
+	}
+
+	if resolution < 0 {
+		return 0, errors.Errorf("negative max source resolution %q", s)
+	}
+	return resolution, nil
+}
+
+// StoreAggregates returns the aggregates to request to the store-gateway in order to evaluate
+// the given PromQL function on downsampled blocks.
+func StoreAggregates(function string) []storepb.Aggr {
+	types := AggregationsForFunction(function)
+	aggrs := make([]storepb.Aggr, 0, len(types))
+	for _, t := range types {
+		aggrs = append(aggrs, toStoreAggr(t))
+	}
+	return aggrs
+}
+
+func toStoreAggr(t thanos_downsample.AggrType) storepb.Aggr {
+	switch t {
+	case thanos_downsample.AggrCount:
+		return storepb.Aggr_COUNT
+	case thanos_downsample.AggrSum:
+		return storepb.Aggr_SUM
+	case thanos_downsample.AggrMin:
+		return storepb.Aggr_MIN
+	case thanos_downsample.AggrMax:
+		return storepb.Aggr_MAX
+	case thanos_downsample.AggrCounter:
+		return storepb.Aggr_COUNTER
+	}
+	return storepb.Aggr_RAW
+}
@@ -0,0 +1,53 @@
+package downsample
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+func TestMaxResolutionContext(t *testing.T) {
+	assert.Equal(t, int64(0), MaxResolutionFromContext(context.Background()))
+	assert.Equal(t, int64(300000), MaxResolutionFromContext(ContextWithMaxResolution(context.Background(), 300000)))
+}
+
+func TestStoreAggregates(t *testing.T) {
+	assert.Equal(t, []storepb.Aggr{storepb.Aggr_COUNTER}, StoreAggregates("rate"))
+	assert.Equal(t, []storepb.Aggr{storepb.Aggr_MAX}, StoreAggregates("max_over_time"))
+	assert.Equal(t, []storepb.Aggr{storepb.Aggr_SUM, storepb.Aggr_COUNT}, StoreAggregates("avg_over_time"))
+	assert.Equal(t, []storepb.Aggr{storepb.Aggr_SUM, storepb.Aggr_COUNT}, StoreAggregates(""))
+
+	// The functions without a mapping read sum and count.
+	assert.Equal(t, []storepb.Aggr{storepb.Aggr_SUM, storepb.Aggr_COUNT}, StoreAggregates("last_over_time"))
+}
+
+func TestParseMaxSourceResolution(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		step        int64
+		expected    int64
+		expectedErr string
+	}{
+		"empty":            {input: "", expected: 0},
+		"auto":             {input: "auto", step: 3600000, expected: 720000},
+		"duration":         {input: "5m", expected: 300000},
+		"seconds":          {input: "3600", expected: 3600000},
+		"raw":              {input: "0s", expected: 0},
+		"negative seconds": {input: "-1", expectedErr: `negative max source resolution "-1"`},
+		"invalid":          {input: "foo", expectedErr: `invalid max source resolution "foo"`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ParseMaxSourceResolution(tc.input, tc.step)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package downsample
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	thanos_downsample "github.com/thanos-io/thanos/pkg/compact/downsample"
+)
+
+const (
+	// ResolutionLabel is the reserved label attached to pre-aggregated series. Its value
+	// is the resolution, in milliseconds, the series has been aggregated at.
+	ResolutionLabel = "__resolution__"
+
+	// AggregationLabel is the reserved label holding the aggregation a pre-aggregated
+	// series has been computed with (eg. sum, count, max).
+	AggregationLabel = "__aggregation__"
+)
+
+// SupportedResolutions are the resolutions, in milliseconds, pre-aggregated series can be pushed at.
+// They match the resolutions produced by the downsampler.
+var SupportedResolutions = []int64{thanos_downsample.ResLevel1, thanos_downsample.ResLevel2}
+
+var (
+	errUnsupportedResolution = errors.New("unsupported resolution")
+	errMissingAggregation    = errors.New("missing aggregation label")
+	errUnknownAggregation    = errors.New("unknown aggregation")
+)
+
+// ParseResolution parses a resolution expressed either as a Prometheus duration (eg. 5m)
+// or as a number of milliseconds, and checks it's supported.
+func ParseResolution(s string) (int64, error) {
+	var resolution int64
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		resolution = ms
+	} else {
+		d, err := model.ParseDuration(s)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid resolution %q", s)
+		}
+		resolution = time.Duration(d).Milliseconds()
+	}
+
+	for _, r := range SupportedResolutions {
+		if r == resolution {
+			return resolution, nil
+		}
+	}
+	return 0, errors.Wrapf(errUnsupportedResolution, "resolution %q", s)
+}
+
+// ValidatePreAggregatedSeries checks the reserved labels of a pre-aggregated series.
+func ValidatePreAggregatedSeries(resolution, aggregation string) error {
+	if _, err := ParseResolution(resolution); err != nil {
+		return err
+	}
+	if aggregation == "" {
+		return errMissingAggregation
+	}
+	if _, ok := BuiltinAggrType(aggregation); !ok {
+		return errors.Wrapf(errUnknownAggregation, "aggregation %q", aggregation)
+	}
+	return nil
+}
+
+// RawSeriesMatchers returns the matchers to use to select only raw series, excluding the
+// pre-aggregated ones which would otherwise be double counted with the raw samples. Matchers
+// explicitly selecting a resolution are returned unchanged.
+func RawSeriesMatchers(matchers []*labels.Matcher) []*labels.Matcher {
+	for _, m := range matchers {
+		if m.Name == ResolutionLabel {
+			return matchers
+		}
+	}
+
+	out := make([]*labels.Matcher, 0, len(matchers)+1)
+	out = append(out, matchers...)
+	return append(out, labels.MustNewMatcher(labels.MatchEqual, ResolutionLabel, ""))
+}
+
+// PreAggregatedSeriesMatchers returns the matchers selecting the pre-aggregated series to merge
+// with the raw series of a query allowed to read data up to maxResolution: the ones pushed at the
+// supported resolutions up to maxResolution, with the aggregations the function is evaluated on,
+// as for the downsampled blocks. It returns false if there's no such series, or if the matchers
+// explicitly select a resolution, in which case the pre-aggregated series are returned as is.
+func PreAggregatedSeriesMatchers(matchers []*labels.Matcher, maxResolution int64, function string) ([]*labels.Matcher, bool) {
+	for _, m := range matchers {
+		if m.Name == ResolutionLabel {
+			return nil, false
+		}
+	}
+
+	var resolutions []string
+	for _, r := range SupportedResolutions {
+		if r <= maxResolution {
+			resolutions = append(resolutions, strconv.FormatInt(r, 10))
+		}
+	}
+	if len(resolutions) == 0 {
+		return nil, false
+	}
+
+	var aggregations []string
+	for _, t := range AggregationsForFunction(function) {
+		aggregations = append(aggregations, t.String())
+	}
+
+	out := make([]*labels.Matcher, 0, len(matchers)+2)
+	out = append(out, matchers...)
+	return append(out,
+		labels.MustNewMatcher(labels.MatchRegexp, ResolutionLabel, strings.Join(resolutions, "|")),
+		labels.MustNewMatcher(labels.MatchRegexp, AggregationLabel, strings.Join(aggregations, "|")),
+	), true
+}
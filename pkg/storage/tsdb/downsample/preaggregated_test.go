@@ -0,0 +1,68 @@
+package downsample
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResolution(t *testing.T) {
+	for input, expected := range map[string]int64{
+		"5m":      300000,
+		"300000":  300000,
+		"1h":      3600000,
+		"3600000": 3600000,
+	} {
+		actual, err := ParseResolution(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, actual, input)
+	}
+
+	for _, input := range []string{"", "0", "1m", "abc"} {
+		_, err := ParseResolution(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestValidatePreAggregatedSeries(t *testing.T) {
+	assert.NoError(t, ValidatePreAggregatedSeries("300000", "max"))
+	assert.NoError(t, ValidatePreAggregatedSeries("300000", "counter"))
+	assert.ErrorIs(t, ValidatePreAggregatedSeries("1000", "max"), errUnsupportedResolution)
+	assert.ErrorIs(t, ValidatePreAggregatedSeries("300000", ""), errMissingAggregation)
+	assert.ErrorIs(t, ValidatePreAggregatedSeries("300000", "last"), errUnknownAggregation)
+}
+
+func TestRawSeriesMatchers(t *testing.T) {
+	nameMatcher := labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "foo")
+	resolutionMatcher := labels.MustNewMatcher(labels.MatchEqual, ResolutionLabel, "300000")
+
+	assert.Equal(t, []*labels.Matcher{nameMatcher, labels.MustNewMatcher(labels.MatchEqual, ResolutionLabel, "")}, RawSeriesMatchers([]*labels.Matcher{nameMatcher}))
+	assert.Equal(t, []*labels.Matcher{nameMatcher, resolutionMatcher}, RawSeriesMatchers([]*labels.Matcher{nameMatcher, resolutionMatcher}))
+}
+
+func TestPreAggregatedSeriesMatchers(t *testing.T) {
+	nameMatcher := labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "foo")
+
+	_, ok := PreAggregatedSeriesMatchers([]*labels.Matcher{nameMatcher}, 0, "rate")
+	assert.False(t, ok)
+	_, ok = PreAggregatedSeriesMatchers([]*labels.Matcher{nameMatcher, labels.MustNewMatcher(labels.MatchEqual, ResolutionLabel, "300000")}, 3600000, "rate")
+	assert.False(t, ok)
+
+	matchers, ok := PreAggregatedSeriesMatchers([]*labels.Matcher{nameMatcher}, 300000, "rate")
+	require.True(t, ok)
+	assert.Equal(t, []*labels.Matcher{
+		nameMatcher,
+		labels.MustNewMatcher(labels.MatchRegexp, ResolutionLabel, "300000"),
+		labels.MustNewMatcher(labels.MatchRegexp, AggregationLabel, "counter"),
+	}, matchers)
+
+	matchers, ok = PreAggregatedSeriesMatchers([]*labels.Matcher{nameMatcher}, 3600000, "avg_over_time")
+	require.True(t, ok)
+	assert.Equal(t, []*labels.Matcher{
+		nameMatcher,
+		labels.MustNewMatcher(labels.MatchRegexp, ResolutionLabel, "300000|3600000"),
+		labels.MustNewMatcher(labels.MatchRegexp, AggregationLabel, "sum|count"),
+	}, matchers)
+}
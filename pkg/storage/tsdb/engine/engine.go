@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// DefaultEngine is the name of the engine storing the chunks in the Prometheus XOR encoding.
+const DefaultEngine = "xor"
+
+var (
+	errEmptyEngineName     = errors.New("storage engine name cannot be empty")
+	errEngineAlreadyExists = errors.New("storage engine already registered")
+	errEncodingInUse       = errors.New("chunk encoding already in use")
+
+	// ErrUnsupportedChunk is returned by Engine.Encode when the engine can't encode the chunk,
+	// in which case the chunk must be stored in its original encoding.
+	ErrUnsupportedChunk = errors.New("chunk not supported by the storage engine")
+)
+
+// Engine encodes and decodes the chunks of a series. Engines are identified by their name in the
+// configuration, and by their chunk encoding once the chunks have been encoded.
+type Engine interface {
+	// Name returns the unique name of the engine.
+	Name() string
+
+	// Encoding returns the unique encoding of the chunks encoded by the engine.
+	Encoding() chunkenc.Encoding
+
+	// Encode re-encodes a chunk, returning ErrUnsupportedChunk if the engine can't encode it.
+	// The data of the encoded chunk must start with the number of samples as a big-endian
+	// uint16, like the Prometheus XOR chunks.
+	Encode(c chunkenc.Chunk) (chunkenc.Chunk, error)
+
+	// Decode returns the chunk encoded in data.
+	Decode(data []byte) (chunkenc.Chunk, error)
+}
+
+var (
+	mtx        sync.RWMutex
+	engines    = map[string]Engine{}
+	byEncoding = map[chunkenc.Encoding]Engine{}
+)
+
+func init() {
+	MustRegister(xorEngine{})
+	MustRegister(xorSnappyEngine{})
+}
+
+// Register adds an engine to the registry. Both its name and its encoding must be unique, and
+// the encoding must not clash with one of the Prometheus encodings, unless the engine is the default one.
+func Register(e Engine) error {
+	name := e.Name()
+	if name == "" {
+		return errEmptyEngineName
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	if _, ok := engines[name]; ok {
+		return errors.Wrapf(errEngineAlreadyExists, "storage engine %q", name)
+	}
+	if _, ok := byEncoding[e.Encoding()]; ok || (name != DefaultEngine && chunkenc.IsValidEncoding(e.Encoding())) {
+		return errors.Wrapf(errEncodingInUse, "storage engine %q, encoding %d", name, e.Encoding())
+	}
+
+	engines[name] = e
+	byEncoding[e.Encoding()] = e
+	return nil
+}
+
+// MustRegister is like Register, but panics on error.
+func MustRegister(e Engine) {
+	if err := Register(e); err != nil {
+		panic(err)
+	}
+}
+
+// Get returns the registered engine with the given name.
+func Get(name string) (Engine, bool) {
+	mtx.RLock()
+	defer mtx.RUnlock()
+
+	e, ok := engines[name]
+	return e, ok
+}
+
+// ForEncoding returns the registered engine encoding the chunks with the given encoding.
+func ForEncoding(enc chunkenc.Encoding) (Engine, bool) {
+	mtx.RLock()
+	defer mtx.RUnlock()
+
+	e, ok := byEncoding[enc]
+	return e, ok
+}
+
+// Names returns the sorted names of the registered engines.
+func Names() []string {
+	mtx.RLock()
+	defer mtx.RUnlock()
+
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate returns an error if no engine is registered with the given name.
+func Validate(name string) error {
+	if _, ok := Get(name); !ok {
+		return errors.Errorf("unknown storage engine %q, supported engines: %v", name, Names())
+	}
+	return nil
+}
+
+// FromData returns the chunk with the given encoding, decoding it with the registered engines
+// and falling back to the Prometheus encodings. It allows reading the chunks written by any
+// registered engine, whatever the engine currently configured for the tenant.
+func FromData(enc chunkenc.Encoding, data []byte) (chunkenc.Chunk, error) {
+	if chunkenc.IsValidEncoding(enc) {
+		return chunkenc.FromData(enc, data)
+	}
+	if e, ok := ForEncoding(enc); ok {
+		return e.Decode(data)
+	}
+	return nil, errors.Errorf("unknown chunk encoding: %d", enc)
+}
+
+// Encode re-encodes the chunk with the engine with the given name. The original chunk is returned
+// if the engine can't encode it, so that it can still be read by the readers of the original encoding.
+func Encode(name string, c chunkenc.Chunk) (chunkenc.Chunk, error) {
+	e, ok := Get(name)
+	if !ok || name == DefaultEngine {
+		return c, nil
+	}
+
+	encoded, err := e.Encode(c)
+	if errors.Is(err, ErrUnsupportedChunk) {
+		return c, nil
+	}
+	return encoded, err
+}
+
+// NewPool returns a chunkenc.Pool decoding the chunks of the registered engines,
+// and delegating to the fallback pool for all the other encodings.
+func NewPool(fallback chunkenc.Pool) chunkenc.Pool {
+	return &pool{fallback: fallback}
+}
+
+type pool struct {
+	fallback chunkenc.Pool
+}
+
+func (p *pool) Get(enc chunkenc.Encoding, data []byte) (chunkenc.Chunk, error) {
+	if !chunkenc.IsValidEncoding(enc) {
+		if e, ok := ForEncoding(enc); ok {
+			return e.Decode(data)
+		}
+	}
+	return p.fallback.Get(enc, data)
+}
+
+func (p *pool) Put(c chunkenc.Chunk) error {
+	if !chunkenc.IsValidEncoding(c.Encoding()) {
+		if _, ok := ForEncoding(c.Encoding()); ok {
+			return nil
+		}
+	}
+	return p.fallback.Put(c)
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+)
+
+type testEngine struct {
+	name string
+	enc  chunkenc.Encoding
+}
+
+func (e testEngine) Name() string                                  { return e.name }
+func (e testEngine) Encoding() chunkenc.Encoding                   { return e.enc }
+func (e testEngine) Encode(chunkenc.Chunk) (chunkenc.Chunk, error) { return nil, ErrUnsupportedChunk }
+func (e testEngine) Decode([]byte) (chunkenc.Chunk, error)         { return nil, nil }
+
+func TestRegister(t *testing.T) {
+	assert.Equal(t, []string{"xor", "xor-snappy"}, Names())
+
+	// Duplicated names and encodings are rejected, as well as the Prometheus encodings.
+	require.ErrorIs(t, Register(testEngine{name: "xor", enc: 0x90}), errEngineAlreadyExists)
+	require.ErrorIs(t, Register(testEngine{name: "other", enc: EncXORSnappy}), errEncodingInUse)
+	require.ErrorIs(t, Register(testEngine{name: "other", enc: chunkenc.EncHistogram}), errEncodingInUse)
+	require.ErrorIs(t, Register(testEngine{name: "", enc: 0x90}), errEmptyEngineName)
+
+	require.NoError(t, Validate("xor-snappy"))
+	require.Error(t, Validate("unknown"))
+}
+
+func TestXORSnappyEngine(t *testing.T) {
+	xor := chunkenc.NewXORChunk()
+	app, err := xor.Appender()
+	require.NoError(t, err)
+	for i := 0; i < 120; i++ {
+		app.Append(int64(i)*15000, float64(i%10))
+	}
+
+	encoded, err := Encode("xor-snappy", xor)
+	require.NoError(t, err)
+	assert.Equal(t, EncXORSnappy, encoded.Encoding())
+	assert.Equal(t, xor.Bytes()[:2], encoded.Bytes()[:2])
+
+	// The chunk can be read back through FromData and through the pool.
+	decoded, err := FromData(encoded.Encoding(), encoded.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, samples(t, xor), samples(t, decoded))
+
+	p := NewPool(downsample.NewPool())
+	decoded, err = p.Get(encoded.Encoding(), encoded.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, samples(t, xor), samples(t, decoded))
+	require.NoError(t, p.Put(decoded))
+
+	// The native encodings are still decoded by the fallback pool.
+	decoded, err = p.Get(chunkenc.EncXOR, xor.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, samples(t, xor), samples(t, decoded))
+}
+
+func TestEncode_ShouldFallBackToTheOriginalChunk(t *testing.T) {
+	h := chunkenc.NewHistogramChunk()
+
+	// Histograms aren't supported by the xor-snappy engine, so they're kept as is.
+	encoded, err := Encode("xor-snappy", h)
+	require.NoError(t, err)
+	assert.Equal(t, chunkenc.EncHistogram, encoded.Encoding())
+
+	// Unknown engines keep the chunk as is.
+	encoded, err = Encode("unknown", h)
+	require.NoError(t, err)
+	assert.Same(t, h, encoded)
+}
+
+func samples(t *testing.T, c chunkenc.Chunk) (res [][2]float64) {
+	it := c.Iterator(nil)
+	for it.Next() == chunkenc.ValFloat {
+		ts, v := it.At()
+		res = append(res, [2]float64{float64(ts), v})
+	}
+	require.NoError(t, it.Err())
+	return res
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// EncXORSnappy is the encoding of the chunks of the "xor-snappy" engine. It's outside of
+// the range of the Prometheus encodings, and doesn't clash with the Thanos aggregated chunks.
+const EncXORSnappy chunkenc.Encoding = 0x80
+
+// xorEngine stores the chunks in the Prometheus XOR encoding.
+type xorEngine struct{}
+
+func (xorEngine) Name() string                { return DefaultEngine }
+func (xorEngine) Encoding() chunkenc.Encoding { return chunkenc.EncXOR }
+
+func (xorEngine) Encode(c chunkenc.Chunk) (chunkenc.Chunk, error) {
+	if c.Encoding() != chunkenc.EncXOR {
+		return nil, ErrUnsupportedChunk
+	}
+	return c, nil
+}
+
+func (xorEngine) Decode(data []byte) (chunkenc.Chunk, error) {
+	return chunkenc.FromData(chunkenc.EncXOR, data)
+}
+
+// xorSnappyEngine is an experimental engine compressing the Prometheus XOR chunks with snappy.
+// The number of samples is kept uncompressed in front of the compressed XOR data.
+type xorSnappyEngine struct{}
+
+func (xorSnappyEngine) Name() string                { return "xor-snappy" }
+func (xorSnappyEngine) Encoding() chunkenc.Encoding { return EncXORSnappy }
+
+func (xorSnappyEngine) Encode(c chunkenc.Chunk) (chunkenc.Chunk, error) {
+	if c.Encoding() != chunkenc.EncXOR {
+		return nil, ErrUnsupportedChunk
+	}
+
+	xor := c.Bytes()
+	if len(xor) < 2 {
+		return nil, errors.New("XOR chunk too short")
+	}
+
+	data := make([]byte, 2, 2+snappy.MaxEncodedLen(len(xor)-2))
+	copy(data, xor[:2])
+	data = append(data, snappy.Encode(nil, xor[2:])...)
+	return &xorSnappyChunk{Chunk: c, data: data}, nil
+}
+
+func (xorSnappyEngine) Decode(data []byte) (chunkenc.Chunk, error) {
+	if len(data) < 2 {
+		return nil, errors.New("xor-snappy chunk too short")
+	}
+
+	decoded, err := snappy.Decode(nil, data[2:])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decompress xor-snappy chunk")
+	}
+	xor, err := chunkenc.FromData(chunkenc.EncXOR, append(data[:2:2], decoded...))
+	if err != nil {
+		return nil, err
+	}
+	return &xorSnappyChunk{Chunk: xor, data: data}, nil
+}
+
+// xorSnappyChunk is a read-only chunk iterating over the decompressed XOR chunk.
+type xorSnappyChunk struct {
+	chunkenc.Chunk
+	data []byte
+}
+
+func (c *xorSnappyChunk) Bytes() []byte               { return c.data }
+func (c *xorSnappyChunk) Encoding() chunkenc.Encoding { return EncXORSnappy }
+
+func (c *xorSnappyChunk) Appender() (chunkenc.Appender, error) {
+	return nil, errors.New("xor-snappy chunks are read-only")
+}
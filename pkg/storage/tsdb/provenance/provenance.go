@@ -0,0 +1,223 @@
+package provenance
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+)
+
+// ErrBlockNotFound is returned when the meta.json of the block doesn't exist.
+var ErrBlockNotFound = errors.New("block not found")
+
+// BlockInfo describes a block and the blocks it has been produced from.
+type BlockInfo struct {
+	ID         ulid.ULID           `json:"id"`
+	MinTime    int64               `json:"min_time"`
+	MaxTime    int64               `json:"max_time"`
+	Resolution int64               `json:"resolution"`
+	Level      int                 `json:"compaction_level"`
+	Source     metadata.SourceType `json:"source"`
+	Parents    []ulid.ULID         `json:"parents,omitempty"`
+
+	// Ingesters which shipped the block or its sources, if known.
+	Ingesters []string `json:"ingesters,omitempty"`
+
+	// Component, with its version, which wrote the block, if known.
+	Producer string `json:"producer,omitempty"`
+	Version  string `json:"version,omitempty"`
+}
+
+// Provenance is recorded in the meta.json extensions of the blocks written by the compactor.
+// Since the compacted blocks are deleted once superseded, it keeps track of all the ancestors
+// of the block.
+type Provenance struct {
+	Producer  string      `json:"producer"`
+	Version   string      `json:"version"`
+	Ingesters []string    `json:"ingesters,omitempty"`
+	Ancestors []BlockInfo `json:"ancestors,omitempty"`
+}
+
+// Extensions is the content of the meta.json extensions of the blocks written by the compactor.
+type Extensions struct {
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// FromMeta returns the provenance recorded in the block meta, or nil if there's none.
+func FromMeta(meta *metadata.Meta) (*Provenance, error) {
+	ext, err := meta.Thanos.ParseExtensions(&Extensions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse extensions of block %s", meta.ULID)
+	}
+	if ext == nil {
+		return nil, nil
+	}
+	return ext.(*Extensions).Provenance, nil
+}
+
+// Describe returns the info of the block known from its meta. The ingester which shipped the
+// block is known from its external labels, as long as they haven't been removed.
+func Describe(meta *metadata.Meta) (BlockInfo, error) {
+	info := BlockInfo{
+		ID:         meta.ULID,
+		MinTime:    meta.MinTime,
+		MaxTime:    meta.MaxTime,
+		Resolution: meta.Thanos.Downsample.Resolution,
+		Level:      meta.Compaction.Level,
+		Source:     meta.Thanos.Source,
+	}
+	for _, p := range meta.Compaction.Parents {
+		info.Parents = append(info.Parents, p.ULID)
+	}
+
+	p, err := FromMeta(meta)
+	if err != nil {
+		return BlockInfo{}, err
+	}
+	if p != nil {
+		info.Ingesters = p.Ingesters
+		info.Producer = p.Producer
+		info.Version = p.Version
+	} else if ingester := meta.Thanos.Labels[cortex_tsdb.IngesterIDExternalLabel]; ingester != "" {
+		info.Ingesters = []string{ingester}
+	}
+	return info, nil
+}
+
+// ForCompaction returns the provenance of the block produced from the given blocks.
+func ForCompaction(parents []*metadata.Meta, producer, version string) (*Provenance, error) {
+	ingesters := map[string]struct{}{}
+	ancestors := map[ulid.ULID]BlockInfo{}
+
+	for _, meta := range parents {
+		info, err := Describe(meta)
+		if err != nil {
+			return nil, err
+		}
+		for _, ingester := range info.Ingesters {
+			ingesters[ingester] = struct{}{}
+		}
+		ancestors[info.ID] = info
+
+		p, err := FromMeta(meta)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			for _, a := range p.Ancestors {
+				ancestors[a.ID] = a
+			}
+		}
+	}
+
+	res := &Provenance{Producer: producer, Version: version}
+	for ingester := range ingesters {
+		res.Ingesters = append(res.Ingesters, ingester)
+	}
+	sort.Strings(res.Ingesters)
+	for _, a := range ancestors {
+		res.Ancestors = append(res.Ancestors, a)
+	}
+	sortBlocks(res.Ancestors)
+	return res, nil
+}
+
+// Lineage is the lineage of a block of the storage.
+type Lineage struct {
+	Block BlockInfo `json:"block"`
+
+	// Ancestors are the blocks the block has been compacted from, recorded in its provenance.
+	Ancestors []BlockInfo `json:"ancestors,omitempty"`
+
+	// DownsamplingSources are the blocks of the storage at a lower resolution sharing source
+	// blocks with the block, which has been downsampled from them.
+	DownsamplingSources []BlockInfo `json:"downsampling_sources,omitempty"`
+}
+
+// GetLineage returns the lineage of the block of the tenant. The index is used to look for the
+// downsampling sources of the block and can be nil, in which case they're not looked for.
+func GetLineage(ctx context.Context, userBucket objstore.Bucket, idx *bucketindex.Index, id ulid.ULID, logger log.Logger) (*Lineage, error) {
+	meta, err := block.DownloadMeta(ctx, logger, userBucket, id)
+	if userBucket.IsObjNotFoundErr(errors.Cause(err)) {
+		return nil, ErrBlockNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := Describe(&meta)
+	if err != nil {
+		return nil, err
+	}
+	res := &Lineage{Block: info}
+
+	p, err := FromMeta(&meta)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		res.Ancestors = p.Ancestors
+	}
+
+	if idx == nil || meta.Thanos.Downsample.Resolution == 0 {
+		return res, nil
+	}
+
+	sources := make(map[ulid.ULID]struct{}, len(meta.Compaction.Sources))
+	for _, s := range meta.Compaction.Sources {
+		sources[s] = struct{}{}
+	}
+
+	for _, b := range idx.Blocks {
+		if b.Resolution >= meta.Thanos.Downsample.Resolution || b.MinTime >= meta.MaxTime || b.MaxTime <= meta.MinTime {
+			continue
+		}
+
+		candidate, err := block.DownloadMeta(ctx, logger, userBucket, b.ID)
+		if userBucket.IsObjNotFoundErr(errors.Cause(err)) {
+			// The block has been deleted in the meantime.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !shareSources(sources, candidate.Compaction.Sources) {
+			continue
+		}
+
+		info, err := Describe(&candidate)
+		if err != nil {
+			return nil, err
+		}
+		res.DownsamplingSources = append(res.DownsamplingSources, info)
+	}
+	sortBlocks(res.DownsamplingSources)
+
+	return res, nil
+}
+
+func shareSources(sources map[ulid.ULID]struct{}, other []ulid.ULID) bool {
+	for _, s := range other {
+		if _, ok := sources[s]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sortBlocks(blocks []BlockInfo) {
+	sort.Slice(blocks, func(i, j int) bool {
+		if blocks[i].MinTime != blocks[j].MinTime {
+			return blocks[i].MinTime < blocks[j].MinTime
+		}
+		return blocks[i].ID.Compare(blocks[j].ID) < 0
+	})
+}
@@ -0,0 +1,131 @@
+package provenance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+)
+
+func newMeta(id uint64, minT, maxT, resolution int64, source metadata.SourceType, sources []ulid.ULID, parents ...*metadata.Meta) *metadata.Meta {
+	m := &metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{
+			ULID:    ulid.MustNew(id, nil),
+			MinTime: minT,
+			MaxTime: maxT,
+			Version: metadata.TSDBVersion1,
+		},
+		Thanos: metadata.Thanos{
+			Version:    metadata.ThanosVersion1,
+			Source:     source,
+			Downsample: metadata.ThanosDownsample{Resolution: resolution},
+		},
+	}
+	m.Compaction.Level = 1
+	m.Compaction.Sources = sources
+	if len(sources) == 0 {
+		m.Compaction.Sources = []ulid.ULID{m.ULID}
+	}
+	for _, p := range parents {
+		m.Compaction.Parents = append(m.Compaction.Parents, tsdb.BlockDesc{ULID: p.ULID, MinTime: p.MinTime, MaxTime: p.MaxTime})
+		m.Compaction.Level = p.Compaction.Level + 1
+	}
+	return m
+}
+
+func uploadMeta(t *testing.T, bkt objstore.Bucket, m *metadata.Meta) {
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	require.NoError(t, bkt.Upload(context.Background(), path.Join(m.ULID.String(), block.MetaFilename), bytes.NewReader(data)))
+}
+
+func TestForCompaction(t *testing.T) {
+	// Two blocks shipped by different ingesters, compacted together, then with a third one.
+	block1 := newMeta(1, 0, 10, 0, metadata.ReceiveSource, nil)
+	block1.Thanos.Labels = map[string]string{cortex_tsdb.IngesterIDExternalLabel: "ingester-1"}
+	block2 := newMeta(2, 0, 10, 0, metadata.ReceiveSource, nil)
+	block2.Thanos.Labels = map[string]string{cortex_tsdb.IngesterIDExternalLabel: "ingester-2"}
+	block3 := newMeta(3, 10, 20, 0, metadata.ReceiveSource, nil)
+	block3.Thanos.Labels = map[string]string{cortex_tsdb.IngesterIDExternalLabel: "ingester-1"}
+
+	p, err := ForCompaction([]*metadata.Meta{block1, block2}, "compactor", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ingester-1", "ingester-2"}, p.Ingesters)
+	assert.Len(t, p.Ancestors, 2)
+
+	compacted := newMeta(4, 0, 10, 0, metadata.CompactorSource, []ulid.ULID{block1.ULID, block2.ULID}, block1, block2)
+	compacted.Thanos.Extensions = &Extensions{Provenance: p}
+
+	p, err = ForCompaction([]*metadata.Meta{compacted, block3}, "compactor", "1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "compactor", p.Producer)
+	assert.Equal(t, "1.1.0", p.Version)
+	assert.Equal(t, []string{"ingester-1", "ingester-2"}, p.Ingesters)
+
+	var ancestors []ulid.ULID
+	for _, a := range p.Ancestors {
+		ancestors = append(ancestors, a.ID)
+	}
+	assert.Equal(t, []ulid.ULID{block1.ULID, block2.ULID, compacted.ULID, block3.ULID}, ancestors)
+	assert.Equal(t, BlockInfo{
+		ID:        compacted.ULID,
+		MinTime:   0,
+		MaxTime:   10,
+		Level:     2,
+		Source:    metadata.CompactorSource,
+		Parents:   []ulid.ULID{block1.ULID, block2.ULID},
+		Ingesters: []string{"ingester-1", "ingester-2"},
+		Producer:  "compactor",
+		Version:   "1.0.0",
+	}, p.Ancestors[2])
+}
+
+func TestGetLineage(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	logger := log.NewNopLogger()
+
+	source1, source2 := ulid.MustNew(1, nil), ulid.MustNew(2, nil)
+	raw := newMeta(10, 0, 100, 0, metadata.CompactorSource, []ulid.ULID{source1, source2})
+	p := &Provenance{Producer: "compactor", Version: "1.0.0", Ingesters: []string{"ingester-1"}, Ancestors: []BlockInfo{{ID: source1}, {ID: source2}}}
+	raw.Thanos.Extensions = &Extensions{Provenance: p}
+	other := newMeta(11, 0, 100, 0, metadata.CompactorSource, []ulid.ULID{ulid.MustNew(3, nil)})
+	downsampled := newMeta(12, 0, 100, 300000, metadata.CompactorSource, []ulid.ULID{source1, source2})
+	for _, m := range []*metadata.Meta{raw, other, downsampled} {
+		uploadMeta(t, bkt, m)
+	}
+
+	idx := &bucketindex.Index{}
+	for _, m := range []*metadata.Meta{raw, other, downsampled} {
+		idx.Blocks = append(idx.Blocks, bucketindex.BlockFromThanosMeta(*m))
+	}
+
+	lineage, err := GetLineage(ctx, bkt, idx, raw.ULID, logger)
+	require.NoError(t, err)
+	assert.Equal(t, raw.ULID, lineage.Block.ID)
+	assert.Equal(t, []string{"ingester-1"}, lineage.Block.Ingesters)
+	assert.Equal(t, p.Ancestors, lineage.Ancestors)
+	assert.Empty(t, lineage.DownsamplingSources)
+
+	lineage, err = GetLineage(ctx, bkt, idx, downsampled.ULID, logger)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300000), lineage.Block.Resolution)
+	require.Len(t, lineage.DownsamplingSources, 1)
+	assert.Equal(t, raw.ULID, lineage.DownsamplingSources[0].ID)
+
+	_, err = GetLineage(ctx, bkt, idx, ulid.MustNew(100, nil), logger)
+	require.ErrorIs(t, err, ErrBlockNotFound)
+}
@@ -0,0 +1,176 @@
+package secondaryindex
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/chunk"
+)
+
+const (
+	// IndexFilename is the name of the secondary index object, stored alongside the meta.json
+	// of a block.
+	IndexFilename = "secondary-index.json.gz"
+
+	// IndexVersion1 is the only supported version of the secondary index.
+	IndexVersion1 = 1
+
+	// Estimated memory overhead of each indexed label value.
+	valueOverhead = 16
+)
+
+var ErrIndexCorrupted = errors.New("secondary index corrupted")
+
+// Index is the inverted index of the values of a set of labels in a block. Unlike the block
+// index, it allows to check whether a block contains a label value without reading the postings.
+type Index struct {
+	// Version of the index format.
+	Version int `json:"version"`
+
+	// Sorted values of each indexed label. A label is indexed even if no series of the block
+	// has it, in which case it has no values.
+	Labels map[string][]string `json:"labels"`
+}
+
+// IndexReader is the subset of the block index reader used to build a secondary index.
+type IndexReader interface {
+	LabelValues(ctx context.Context, name string, matchers ...*labels.Matcher) ([]string, error)
+}
+
+// BuildIndex returns the secondary index of the given labels of the block.
+func BuildIndex(ctx context.Context, ir IndexReader, labelNames []string) (*Index, error) {
+	idx := &Index{
+		Version: IndexVersion1,
+		Labels:  make(map[string][]string, len(labelNames)),
+	}
+
+	for _, name := range labelNames {
+		values, err := ir.LabelValues(ctx, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read values of label %s", name)
+		}
+
+		// The values are copied, since they may point to the memory mapped index of the block.
+		copied := make([]string, 0, len(values))
+		for _, value := range values {
+			copied = append(copied, strings.Clone(value))
+		}
+		sort.Strings(copied)
+		idx.Labels[name] = copied
+	}
+
+	return idx, nil
+}
+
+// Size returns the estimated memory size of the index in bytes.
+func (idx *Index) Size() int {
+	size := 0
+	for name, values := range idx.Labels {
+		size += len(name) + valueOverhead
+		for _, value := range values {
+			size += len(value) + valueOverhead
+		}
+	}
+	return size
+}
+
+// CanMatch returns false if no series of the block can match all the matchers. Only the
+// equality matchers and the regex matchers on a set of values, which don't match an empty
+// value, are checked for the indexed labels.
+func (idx *Index) CanMatch(matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		values, ok := idx.Labels[m.Name]
+		if !ok || m.Matches("") {
+			continue
+		}
+
+		switch m.Type {
+		case labels.MatchEqual:
+			if !containsValue(values, m.Value) {
+				return false
+			}
+		case labels.MatchRegexp:
+			set := chunk.FindSetMatches(m.Value)
+			if len(set) == 0 {
+				continue
+			}
+
+			found := false
+			for _, value := range set {
+				if containsValue(values, value) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func containsValue(values []string, value string) bool {
+	i := sort.SearchStrings(values, value)
+	return i < len(values) && values[i] == value
+}
+
+// UploadIndex uploads the secondary index of the block to the bucket.
+func UploadIndex(ctx context.Context, bkt objstore.Bucket, blockID ulid.ULID, idx *Index) error {
+	content, err := json.Marshal(idx)
+	if err != nil {
+		return errors.Wrap(err, "marshal secondary index")
+	}
+
+	var gzipContent bytes.Buffer
+	gzip := gzip.NewWriter(&gzipContent)
+	gzip.Name = IndexFilename
+
+	if _, err := gzip.Write(content); err != nil {
+		return errors.Wrap(err, "gzip secondary index")
+	}
+	if err := gzip.Close(); err != nil {
+		return errors.Wrap(err, "close gzip secondary index")
+	}
+
+	return errors.Wrap(bkt.Upload(ctx, indexPath(blockID), bytes.NewReader(gzipContent.Bytes())), "upload secondary index")
+}
+
+// ReadIndex reads the secondary index of the block from the bucket. It returns nil if the
+// block doesn't have a secondary index.
+func ReadIndex(ctx context.Context, bkt objstore.BucketReader, blockID ulid.ULID) (*Index, error) {
+	r, err := bkt.Get(ctx, indexPath(blockID))
+	if bkt.IsObjNotFoundErr(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read secondary index of block %s", blockID)
+	}
+	defer r.Close() //nolint:errcheck
+
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, ErrIndexCorrupted
+	}
+	defer gzipReader.Close() //nolint:errcheck
+
+	idx := &Index{}
+	if err := json.NewDecoder(gzipReader).Decode(idx); err != nil || idx.Version != IndexVersion1 {
+		return nil, ErrIndexCorrupted
+	}
+	return idx, nil
+}
+
+func indexPath(blockID ulid.ULID) string {
+	return path.Join(blockID.String(), IndexFilename)
+}
@@ -0,0 +1,97 @@
+package secondaryindex
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+type mockIndexReader map[string][]string
+
+func (r mockIndexReader) LabelValues(_ context.Context, name string, _ ...*labels.Matcher) ([]string, error) {
+	return r[name], nil
+}
+
+func TestIndex(t *testing.T) {
+	ctx := context.Background()
+	idx, err := BuildIndex(ctx, mockIndexReader{
+		labels.MetricName: {"up"},
+		"trace_id":        {"c", "a", "b"},
+	}, []string{"trace_id", "instance"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"trace_id": {"a", "b", "c"}, "instance": {}}, idx.Labels)
+
+	bkt := objstore.NewInMemBucket()
+	blockID := ulid.MustNew(1, nil)
+	require.NoError(t, UploadIndex(ctx, bkt, blockID, idx))
+
+	read, err := ReadIndex(ctx, bkt, blockID)
+	require.NoError(t, err)
+	assert.Equal(t, idx, read)
+
+	for name, testData := range map[string]struct {
+		matchers []*labels.Matcher
+		expected bool
+	}{
+		"no matchers": {
+			expected: true,
+		},
+		"matching equal matcher": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "trace_id", "b")},
+			expected: true,
+		},
+		"not matching equal matcher": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "trace_id", "d")},
+			expected: false,
+		},
+		"equal matcher on a label missing in the block": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "instance", "host-1")},
+			expected: false,
+		},
+		"equal matcher on a not indexed label": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "down")},
+			expected: true,
+		},
+		"matching set matcher": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "trace_id", "d|c")},
+			expected: true,
+		},
+		"not matching set matcher": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "trace_id", "d|e")},
+			expected: false,
+		},
+		"set matcher matching an empty value": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "trace_id", "d|")},
+			expected: true,
+		},
+		"regex matcher": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "trace_id", "d.+")},
+			expected: true,
+		},
+		"not equal matcher": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchNotEqual, "trace_id", "a")},
+			expected: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testData.expected, idx.CanMatch(testData.matchers))
+		})
+	}
+
+	// Blocks without a secondary index.
+	read, err = ReadIndex(ctx, bkt, ulid.MustNew(2, nil))
+	require.NoError(t, err)
+	assert.Nil(t, read)
+
+	// Corrupted index.
+	require.NoError(t, bkt.Upload(ctx, path.Join(blockID.String(), IndexFilename), bytes.NewReader([]byte("invalid"))))
+	_, err = ReadIndex(ctx, bkt, blockID)
+	assert.Equal(t, ErrIndexCorrupted, err)
+}
@@ -0,0 +1,292 @@
+package storegateway
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/store/hintspb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bloom"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/secondaryindex"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+)
+
+const (
+	// How long to remember that a block has no bloom filter or secondary index, since the compactor
+	// uploads them right after the block.
+	bloomFilterMissingTTL = 10 * time.Minute
+
+	bloomFiltersFetchConcurrency = 10
+
+	// Estimated memory overhead of each cached bloom filter.
+	bloomFilterEntryOverhead = 128
+)
+
+// bloomFilters skips the blocks of a series request which can't match its selector, according
+// to the label pairs bloom filters uploaded by the compactor and, if enabled, the secondary indexes
+// of the high-selectivity labels. The decoded filters and indexes are kept in a LRU cache, bounded
+// in size.
+type bloomFilters struct {
+	maxCacheSize     int64
+	secondaryIndexes bool
+
+	mtx       sync.Mutex
+	entries   map[ulid.ULID]*list.Element
+	lru       *list.List
+	cacheSize int64
+
+	checkedBlocks      prometheus.Counter
+	skippedBlocks      prometheus.Counter
+	indexSkippedBlocks prometheus.Counter
+	fetchFailures      prometheus.Counter
+	indexFetchFailures prometheus.Counter
+}
+
+type bloomFilterEntry struct {
+	blockID   ulid.ULID
+	filter    *bloom.Filter         // nil if the block has no filter.
+	index     *secondaryindex.Index // nil if the block has no secondary index or they're disabled.
+	fetchedAt time.Time
+}
+
+func (e *bloomFilterEntry) size() int64 {
+	size := int64(bloomFilterEntryOverhead)
+	if e.filter != nil {
+		size += int64(e.filter.Size())
+	}
+	if e.index != nil {
+		size += int64(e.index.Size())
+	}
+	return size
+}
+
+func newBloomFilters(maxCacheSize int64, reg prometheus.Registerer) *bloomFilters {
+	return &bloomFilters{
+		maxCacheSize: maxCacheSize,
+		entries:      map[ulid.ULID]*list.Element{},
+		lru:          list.New(),
+		checkedBlocks: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_bloom_filter_checked_blocks_total",
+			Help: "Total number of blocks checked against their bloom filter before being queried.",
+		}),
+		skippedBlocks: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_bloom_filter_skipped_blocks_total",
+			Help: "Total number of blocks not queried because their bloom filter didn't match the query.",
+		}),
+		fetchFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_bloom_filter_fetch_failures_total",
+			Help: "Total number of failures fetching the bloom filter of a block.",
+		}),
+		indexSkippedBlocks: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_secondary_index_skipped_blocks_total",
+			Help: "Total number of blocks not queried because their secondary index didn't match the query.",
+		}),
+		indexFetchFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_secondary_index_fetch_failures_total",
+			Help: "Total number of failures fetching the secondary index of a block.",
+		}),
+	}
+}
+
+// newBloomFiltersWithSecondaryIndexes returns bloomFilters also checking the secondary indexes of the blocks.
+func newBloomFiltersWithSecondaryIndexes(maxCacheSize int64, reg prometheus.Registerer) *bloomFilters {
+	b := newBloomFilters(maxCacheSize, reg)
+	b.secondaryIndexes = true
+	return b
+}
+
+// skipBlocks returns the request without the blocks which can't match its matchers, and the
+// skipped blocks. The request is returned unchanged if it doesn't select the blocks to query.
+func (b *bloomFilters) skipBlocks(ctx context.Context, bkt objstore.BucketReader, req *storepb.SeriesRequest) (*storepb.SeriesRequest, []ulid.ULID, error) {
+	if req.Hints == nil {
+		return req, nil, nil
+	}
+
+	hints := hintspb.SeriesRequestHints{}
+	if err := types.UnmarshalAny(req.Hints, &hints); err != nil {
+		return nil, nil, err
+	}
+
+	blockMatcherIdx := -1
+	for idx, m := range hints.BlockMatchers {
+		if m.Name == block.BlockIDLabel && m.Type == storepb.LabelMatcher_RE {
+			blockMatcherIdx = idx
+		}
+	}
+	if blockMatcherIdx < 0 || hints.BlockMatchers[blockMatcherIdx].Value == "" {
+		return req, nil, nil
+	}
+
+	blockIDs := strings.Split(hints.BlockMatchers[blockMatcherIdx].Value, "|")
+	for _, id := range blockIDs {
+		// The blocks aren't selected by their exact ID.
+		if _, err := ulid.Parse(id); err != nil {
+			return req, nil, nil
+		}
+	}
+
+	matchers, err := storepb.MatchersToPromMatchers(req.Matchers...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		skippedMtx sync.Mutex
+		skipped    = map[string]struct{}{}
+	)
+	err = concurrency.ForEach(ctx, concurrency.CreateJobsFromStrings(blockIDs), bloomFiltersFetchConcurrency, func(ctx context.Context, job interface{}) error {
+		blockID := ulid.MustParse(job.(string))
+
+		entry, err := b.get(ctx, bkt, blockID)
+		if err != nil {
+			// The block is queried as usual.
+			return nil
+		}
+
+		b.checkedBlocks.Inc()
+
+		// The secondary index is checked first, since its lookups are exact.
+		skip := false
+		switch {
+		case entry.index != nil && !entry.index.CanMatch(matchers):
+			b.indexSkippedBlocks.Inc()
+			skip = true
+		case entry.filter != nil && !bloom.CanMatch(entry.filter, matchers):
+			b.skippedBlocks.Inc()
+			skip = true
+		}
+		if skip {
+			skippedMtx.Lock()
+			skipped[blockID.String()] = struct{}{}
+			skippedMtx.Unlock()
+		}
+		return nil
+	})
+	if err != nil || len(skipped) == 0 {
+		return req, nil, err
+	}
+
+	remaining := make([]string, 0, len(blockIDs)-len(skipped))
+	skippedIDs := make([]ulid.ULID, 0, len(skipped))
+	for _, id := range blockIDs {
+		if _, ok := skipped[id]; ok {
+			skippedIDs = append(skippedIDs, ulid.MustParse(id))
+		} else {
+			remaining = append(remaining, id)
+		}
+	}
+
+	hints.BlockMatchers = append([]storepb.LabelMatcher(nil), hints.BlockMatchers...)
+	hints.BlockMatchers[blockMatcherIdx].Value = strings.Join(remaining, "|")
+	anyHints, err := types.MarshalAny(&hints)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filtered := *req
+	filtered.Hints = anyHints
+	return &filtered, skippedIDs, nil
+}
+
+// getFilter returns the bloom filter of the block, or nil if it has none.
+func (b *bloomFilters) getFilter(ctx context.Context, bkt objstore.BucketReader, blockID ulid.ULID) (*bloom.Filter, error) {
+	entry, err := b.get(ctx, bkt, blockID)
+	if err != nil {
+		return nil, err
+	}
+	return entry.filter, nil
+}
+
+// get returns the cached bloom filter and secondary index of the block, fetching them on cache miss.
+// The entry is fetched again once the TTL of a missing object expires.
+func (b *bloomFilters) get(ctx context.Context, bkt objstore.BucketReader, blockID ulid.ULID) (*bloomFilterEntry, error) {
+	b.mtx.Lock()
+	if elem, ok := b.entries[blockID]; ok {
+		entry := elem.Value.(*bloomFilterEntry)
+		complete := entry.filter != nil && (entry.index != nil || !b.secondaryIndexes)
+		if complete || time.Since(entry.fetchedAt) < bloomFilterMissingTTL {
+			b.lru.MoveToFront(elem)
+			b.mtx.Unlock()
+			return entry, nil
+		}
+	}
+	b.mtx.Unlock()
+
+	f, err := bloom.ReadBlockFilter(ctx, bkt, blockID)
+	if err != nil {
+		b.fetchFailures.Inc()
+		return nil, err
+	}
+
+	var idx *secondaryindex.Index
+	if b.secondaryIndexes {
+		if idx, err = secondaryindex.ReadIndex(ctx, bkt, blockID); err != nil {
+			b.indexFetchFailures.Inc()
+			return nil, err
+		}
+	}
+
+	entry := &bloomFilterEntry{blockID: blockID, filter: f, index: idx, fetchedAt: time.Now()}
+	b.add(entry)
+	return entry, nil
+}
+
+func (b *bloomFilters) add(entry *bloomFilterEntry) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if elem, ok := b.entries[entry.blockID]; ok {
+		b.remove(elem)
+	}
+
+	b.entries[entry.blockID] = b.lru.PushFront(entry)
+	b.cacheSize += entry.size()
+
+	for b.cacheSize > b.maxCacheSize && b.lru.Len() > 0 {
+		b.remove(b.lru.Back())
+	}
+}
+
+func (b *bloomFilters) remove(elem *list.Element) {
+	entry := b.lru.Remove(elem).(*bloomFilterEntry)
+	delete(b.entries, entry.blockID)
+	b.cacheSize -= entry.size()
+}
+
+// bloomFilterSeriesServer reports the blocks skipped because of their bloom filter as queried
+// in the response hints, since the querier checks all the requested blocks have been queried.
+type bloomFilterSeriesServer struct {
+	storepb.Store_SeriesServer
+
+	skippedBlocks []ulid.ULID
+}
+
+func (s bloomFilterSeriesServer) Send(resp *storepb.SeriesResponse) error {
+	if h := resp.GetHints(); h != nil {
+		hints := hintspb.SeriesResponseHints{}
+		if err := types.UnmarshalAny(h, &hints); err != nil {
+			return err
+		}
+		for _, id := range s.skippedBlocks {
+			hints.AddQueriedBlock(id)
+		}
+
+		anyHints, err := types.MarshalAny(&hints)
+		if err != nil {
+			return err
+		}
+		resp = storepb.NewHintsSeriesResponse(anyHints)
+	}
+
+	return s.Store_SeriesServer.Send(resp)
+}
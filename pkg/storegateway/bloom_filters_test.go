@@ -0,0 +1,237 @@
+package storegateway
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/gogo/protobuf/types"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/store/hintspb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/bucket/filesystem"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bloom"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/secondaryindex"
+)
+
+func TestBucketStores_Series_ShouldSkipBlocksNotMatchingBloomFilter(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.BloomFiltersEnabled = true
+
+	storageDir := t.TempDir()
+	generateStorageBlock(t, storageDir, userID, "series_1", 0, 100, 15)
+	generateStorageBlock(t, storageDir, userID, "series_2", 0, 100, 15)
+
+	bkt, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	// Upload the bloom filters of the blocks, like the compactor does.
+	entries, err := os.ReadDir(filepath.Join(storageDir, userID))
+	require.NoError(t, err)
+	var blockIDs []string
+	for _, entry := range entries {
+		blockID, err := ulid.Parse(entry.Name())
+		require.NoError(t, err)
+		blockIDs = append(blockIDs, blockID.String())
+
+		ir, err := index.NewFileReader(filepath.Join(storageDir, userID, entry.Name(), block.IndexFilename))
+		require.NoError(t, err)
+		f, err := bloom.BuildBlockFilter(ctx, ir, bloom.DefaultFalsePositiveRate)
+		require.NoError(t, err)
+		require.NoError(t, ir.Close())
+		require.NoError(t, bloom.UploadBlockFilter(ctx, userBkt, blockID, f))
+	}
+	require.Len(t, blockIDs, 2)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(bkt), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	hints, err := types.MarshalAny(&hintspb.SeriesRequestHints{
+		BlockMatchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: block.BlockIDLabel, Value: strings.Join(blockIDs, "|")}},
+	})
+	require.NoError(t, err)
+
+	srv := newBucketStoreSeriesServer(setUserIDToGRPCContext(ctx, userID))
+	require.NoError(t, stores.Series(&storepb.SeriesRequest{
+		MinTime:                 0,
+		MaxTime:                 100,
+		Matchers:                []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: "series_1"}},
+		PartialResponseStrategy: storepb.PartialResponseStrategy_ABORT,
+		Hints:                   hints,
+	}, srv))
+
+	require.Len(t, srv.SeriesSet, 1)
+	assert.Equal(t, labels.FromStrings(labels.MetricName, "series_1"), srv.SeriesSet[0].PromLabels())
+
+	// The skipped block is reported as queried.
+	var queriedBlocks []string
+	for _, b := range srv.Hints.QueriedBlocks {
+		queriedBlocks = append(queriedBlocks, b.Id)
+	}
+	assert.ElementsMatch(t, blockIDs, queriedBlocks)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_bloom_filter_checked_blocks_total Total number of blocks checked against their bloom filter before being queried.
+		# TYPE cortex_bucket_store_bloom_filter_checked_blocks_total counter
+		cortex_bucket_store_bloom_filter_checked_blocks_total 2
+		# HELP cortex_bucket_store_bloom_filter_skipped_blocks_total Total number of blocks not queried because their bloom filter didn't match the query.
+		# TYPE cortex_bucket_store_bloom_filter_skipped_blocks_total counter
+		cortex_bucket_store_bloom_filter_skipped_blocks_total 1
+	`), "cortex_bucket_store_bloom_filter_checked_blocks_total", "cortex_bucket_store_bloom_filter_skipped_blocks_total"))
+}
+
+func TestBloomFilters_getFilter(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	f := bloom.NewFilter(10, bloom.DefaultFalsePositiveRate)
+	blockWithFilter, blockWithoutFilter := ulid.MustNew(1, nil), ulid.MustNew(2, nil)
+	require.NoError(t, bloom.UploadBlockFilter(ctx, bkt, blockWithFilter, f))
+
+	// The cache only fits the filter.
+	filters := newBloomFilters(int64(f.Size()+bloomFilterEntryOverhead), prometheus.NewPedanticRegistry())
+
+	actual, err := filters.getFilter(ctx, bkt, blockWithFilter)
+	require.NoError(t, err)
+	assert.Equal(t, f, actual)
+
+	// The missing filter is cached too, evicting the least recently used one.
+	actual, err = filters.getFilter(ctx, bkt, blockWithoutFilter)
+	require.NoError(t, err)
+	assert.Nil(t, actual)
+	assert.Equal(t, 1, filters.lru.Len())
+	assert.Contains(t, filters.entries, blockWithoutFilter)
+	assert.Equal(t, int64(bloomFilterEntryOverhead), filters.cacheSize)
+}
+
+func TestBucketStores_Series_ShouldSkipBlocksNotMatchingSecondaryIndex(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.BloomFiltersEnabled = true
+	cfg.BucketStore.SecondaryIndexEnabled = true
+
+	storageDir := t.TempDir()
+	generateStorageBlock(t, storageDir, userID, "series_1", 0, 100, 15)
+	generateStorageBlock(t, storageDir, userID, "series_2", 0, 100, 15)
+
+	bkt, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	// Upload the secondary indexes of the blocks, but no bloom filter.
+	entries, err := os.ReadDir(filepath.Join(storageDir, userID))
+	require.NoError(t, err)
+	var blockIDs []string
+	for _, entry := range entries {
+		blockID, err := ulid.Parse(entry.Name())
+		require.NoError(t, err)
+		blockIDs = append(blockIDs, blockID.String())
+
+		ir, err := index.NewFileReader(filepath.Join(storageDir, userID, entry.Name(), block.IndexFilename))
+		require.NoError(t, err)
+		idx, err := secondaryindex.BuildIndex(ctx, ir, []string{labels.MetricName})
+		require.NoError(t, err)
+		require.NoError(t, ir.Close())
+		require.NoError(t, secondaryindex.UploadIndex(ctx, userBkt, blockID, idx))
+	}
+	require.Len(t, blockIDs, 2)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(bkt), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	hints, err := types.MarshalAny(&hintspb.SeriesRequestHints{
+		BlockMatchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: block.BlockIDLabel, Value: strings.Join(blockIDs, "|")}},
+	})
+	require.NoError(t, err)
+
+	// The regex set matchers are looked up in the secondary indexes.
+	srv := newBucketStoreSeriesServer(setUserIDToGRPCContext(ctx, userID))
+	require.NoError(t, stores.Series(&storepb.SeriesRequest{
+		MinTime:                 0,
+		MaxTime:                 100,
+		Matchers:                []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_1|series_3"}},
+		PartialResponseStrategy: storepb.PartialResponseStrategy_ABORT,
+		Hints:                   hints,
+	}, srv))
+
+	require.Len(t, srv.SeriesSet, 1)
+	assert.Equal(t, labels.FromStrings(labels.MetricName, "series_1"), srv.SeriesSet[0].PromLabels())
+
+	// The skipped block is reported as queried.
+	var queriedBlocks []string
+	for _, b := range srv.Hints.QueriedBlocks {
+		queriedBlocks = append(queriedBlocks, b.Id)
+	}
+	assert.ElementsMatch(t, blockIDs, queriedBlocks)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_bloom_filter_checked_blocks_total Total number of blocks checked against their bloom filter before being queried.
+		# TYPE cortex_bucket_store_bloom_filter_checked_blocks_total counter
+		cortex_bucket_store_bloom_filter_checked_blocks_total 2
+		# HELP cortex_bucket_store_bloom_filter_skipped_blocks_total Total number of blocks not queried because their bloom filter didn't match the query.
+		# TYPE cortex_bucket_store_bloom_filter_skipped_blocks_total counter
+		cortex_bucket_store_bloom_filter_skipped_blocks_total 0
+		# HELP cortex_bucket_store_secondary_index_skipped_blocks_total Total number of blocks not queried because their secondary index didn't match the query.
+		# TYPE cortex_bucket_store_secondary_index_skipped_blocks_total counter
+		cortex_bucket_store_secondary_index_skipped_blocks_total 1
+	`), "cortex_bucket_store_bloom_filter_checked_blocks_total", "cortex_bucket_store_bloom_filter_skipped_blocks_total", "cortex_bucket_store_secondary_index_skipped_blocks_total"))
+}
+
+func TestBloomFilters_get_WithSecondaryIndexes(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	f := bloom.NewFilter(10, bloom.DefaultFalsePositiveRate)
+	idx := &secondaryindex.Index{Version: secondaryindex.IndexVersion1, Labels: map[string][]string{"trace_id": {"a", "b"}}}
+	blockWithBoth, blockWithIndex := ulid.MustNew(1, nil), ulid.MustNew(2, nil)
+	require.NoError(t, bloom.UploadBlockFilter(ctx, bkt, blockWithBoth, f))
+	require.NoError(t, secondaryindex.UploadIndex(ctx, bkt, blockWithBoth, idx))
+	require.NoError(t, secondaryindex.UploadIndex(ctx, bkt, blockWithIndex, idx))
+
+	// The cache only fits the filter and index of a block.
+	filters := newBloomFiltersWithSecondaryIndexes(int64(f.Size()+idx.Size()+bloomFilterEntryOverhead), prometheus.NewPedanticRegistry())
+
+	entry, err := filters.get(ctx, bkt, blockWithBoth)
+	require.NoError(t, err)
+	assert.Equal(t, f, entry.filter)
+	assert.Equal(t, idx, entry.index)
+	assert.Equal(t, int64(f.Size()+idx.Size()+bloomFilterEntryOverhead), filters.cacheSize)
+
+	// The block without filter is still checked against its index, evicting the least recently used one.
+	entry, err = filters.get(ctx, bkt, blockWithIndex)
+	require.NoError(t, err)
+	assert.Nil(t, entry.filter)
+	assert.Equal(t, idx, entry.index)
+	assert.Equal(t, 1, filters.lru.Len())
+	assert.Contains(t, filters.entries, blockWithIndex)
+	assert.Equal(t, int64(idx.Size()+bloomFilterEntryOverhead), filters.cacheSize)
+
+	// The indexes aren't fetched when disabled.
+	entry, err = newBloomFilters(1000, prometheus.NewPedanticRegistry()).get(ctx, bkt, blockWithBoth)
+	require.NoError(t, err)
+	assert.Equal(t, f, entry.filter)
+	assert.Nil(t, entry.index)
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util/backoff"
 	cortex_errors "github.com/cortexproject/cortex/pkg/util/errors"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/priority"
 	"github.com/cortexproject/cortex/pkg/util/spanlogger"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
@@ -65,6 +66,9 @@ type BucketStores struct {
 	// Gate used to limit query concurrency across all tenants.
 	queryGate gate.Gate
 
+	// Blocks bloom filters shared across all tenants, nil if disabled.
+	bloomFilters *bloomFilters
+
 	// Keeps a bucket store for each tenant.
 	storesMu sync.RWMutex
 	stores   map[string]*store.BucketStore
@@ -82,6 +86,9 @@ type BucketStores struct {
 	syncLastSuccess   prometheus.Gauge
 	tenantsDiscovered prometheus.Gauge
 	tenantsSynced     prometheus.Gauge
+
+	// Series requests aborted because they exceeded the limits of their query.
+	queryLimitsAborted *prometheus.CounterVec
 }
 
 var ErrTooManyInflightRequests = status.Error(codes.ResourceExhausted, "too many inflight requests in store gateway")
@@ -97,6 +104,10 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 	// The number of concurrent queries against the tenants BucketStores are limited.
 	queryGateReg := extprom.WrapRegistererWithPrefix("cortex_bucket_stores_", reg)
 	queryGate := gate.New(queryGateReg, cfg.BucketStore.MaxConcurrent, gate.Queries)
+	if cfg.BucketStore.QueryPriorityEnabled && cfg.BucketStore.MaxConcurrent > 0 {
+		// The priority gate admits up to the max concurrent queries, so the inner one never waits.
+		queryGate = priority.NewGate(cfg.BucketStore.MaxConcurrent, queryGate, "cortex_bucket_stores_queries_", reg)
+	}
 	promauto.With(reg).NewGauge(prometheus.GaugeOpts{
 		Name: "cortex_bucket_stores_gate_queries_concurrent_max",
 		Help: "Number of maximum concurrent queries allowed.",
@@ -128,12 +139,22 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 			Name: "cortex_bucket_stores_tenants_discovered",
 			Help: "Number of tenants discovered in the bucket.",
 		}),
+		queryLimitsAborted: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_stores_series_requests_aborted_total",
+			Help: "Total number of series requests aborted because the query exceeded its limit of series or chunks.",
+		}, []string{"limit"}),
 		tenantsSynced: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
 			Name: "cortex_bucket_stores_tenants_synced",
 			Help: "Number of tenants synced.",
 		}),
 	}
 
+	if cfg.BucketStore.BloomFiltersEnabled && cfg.BucketStore.SecondaryIndexEnabled {
+		u.bloomFilters = newBloomFiltersWithSecondaryIndexes(cfg.BucketStore.BloomFiltersCacheSizeBytes, reg)
+	} else if cfg.BucketStore.BloomFiltersEnabled {
+		u.bloomFilters = newBloomFilters(cfg.BucketStore.BloomFiltersCacheSizeBytes, reg)
+	}
+
 	// Init the index cache.
 	if u.indexCache, err = tsdb.NewIndexCache(cfg.BucketStore.IndexCache, logger, reg); err != nil {
 		return nil, errors.Wrap(err, "create index cache")
@@ -331,10 +352,39 @@ func (u *BucketStores) Series(req *storepb.SeriesRequest, srv storepb.Store_Seri
 		defer u.decrementInflightRequestCnt()
 	}
 
+	if u.bloomFilters != nil {
+		var skippedBlocks []ulid.ULID
+		if req, skippedBlocks, err = u.bloomFilters.skipBlocks(spanCtx, userBkt, req); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if len(skippedBlocks) > 0 {
+			srv = bloomFilterSeriesServer{Store_SeriesServer: srv, skippedBlocks: skippedBlocks}
+		}
+	}
+
+	var limitsSrv *queryLimitsSeriesServer
+	if maxSeries, maxChunks := getQueryLimitsFromGRPCContext(spanCtx); maxSeries > 0 || maxChunks > 0 {
+		limitsSrv = &queryLimitsSeriesServer{Store_SeriesServer: srv, maxSeries: maxSeries, maxChunks: maxChunks, aborted: u.queryLimitsAborted}
+		srv = limitsSrv
+	}
+
+	// The series left out by the series limit don't count against the query limits.
+	var seriesLimitSrv *seriesLimitSeriesServer
+	if limit := getSeriesLimitFromGRPCContext(spanCtx); limit > 0 {
+		seriesLimitSrv = &seriesLimitSeriesServer{Store_SeriesServer: srv, limit: limit}
+		srv = seriesLimitSrv
+	}
+
 	err = store.Series(req, spanSeriesServer{
 		Store_SeriesServer: srv,
 		ctx:                spanCtx,
 	})
+	if limitsSrv != nil && limitsSrv.limitErr != nil {
+		return limitsSrv.limitErr
+	}
+	if seriesLimitSrv != nil && seriesLimitSrv.reached {
+		return seriesLimitSrv.sendQueriedBlocks(req)
+	}
 
 	return err
 }
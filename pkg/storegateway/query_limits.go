@@ -0,0 +1,100 @@
+package storegateway
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	util_limiter "github.com/cortexproject/cortex/pkg/util/limiter"
+)
+
+const (
+	// QueryMaxSeriesMetadataKey is the gRPC metadata key of the Series requests holding the max
+	// number of series the query can fetch.
+	QueryMaxSeriesMetadataKey = "cortex-query-max-series"
+	// QueryMaxChunksMetadataKey is the gRPC metadata key of the Series requests holding the max
+	// number of chunks the query can still fetch.
+	QueryMaxChunksMetadataKey = "cortex-query-max-chunks"
+
+	limitSeries = "series"
+	limitChunks = "chunks"
+)
+
+// AppendQueryLimitsToOutgoingContext returns a context sending the per-query limits along with
+// the Series requests, so that the store-gateways abort them as soon as a limit is exceeded.
+// A limit of 0 is disabled and not sent.
+func AppendQueryLimitsToOutgoingContext(ctx context.Context, maxSeries, maxChunks int) context.Context {
+	if maxSeries > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, QueryMaxSeriesMetadataKey, strconv.Itoa(maxSeries))
+	}
+	if maxChunks > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, QueryMaxChunksMetadataKey, strconv.Itoa(maxChunks))
+	}
+	return ctx
+}
+
+// getQueryLimitsFromGRPCContext returns the per-query limits sent along with a Series request,
+// 0 if not sent.
+func getQueryLimitsFromGRPCContext(ctx context.Context) (maxSeries, maxChunks int) {
+	meta, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, 0
+	}
+	return parseQueryLimit(meta, QueryMaxSeriesMetadataKey), parseQueryLimit(meta, QueryMaxChunksMetadataKey)
+}
+
+func parseQueryLimit(meta metadata.MD, key string) int {
+	values := meta.Get(key)
+	if len(values) != 1 {
+		return 0
+	}
+
+	limit, err := strconv.Atoi(values[0])
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// queryLimitsSeriesServer is a storepb.Store_SeriesServer failing the request as soon as the
+// series sent exceed the per-query limits, instead of sending them all to the querier.
+type queryLimitsSeriesServer struct {
+	storepb.Store_SeriesServer
+
+	maxSeries int
+	maxChunks int
+	aborted   *prometheus.CounterVec
+
+	seriesCount int
+	chunksCount int
+
+	// The error the request has been aborted with, since the bucket store doesn't keep the
+	// status of the errors returned by Send.
+	limitErr error
+}
+
+func (s *queryLimitsSeriesServer) Send(resp *storepb.SeriesResponse) error {
+	if series := resp.GetSeries(); series != nil {
+		s.seriesCount++
+		s.chunksCount += len(series.Chunks)
+
+		if s.maxSeries > 0 && s.seriesCount > s.maxSeries {
+			s.aborted.WithLabelValues(limitSeries).Inc()
+			s.limitErr = status.Error(codes.ResourceExhausted, fmt.Sprintf(util_limiter.ErrMaxSeriesHit, s.maxSeries))
+			return s.limitErr
+		}
+		if s.maxChunks > 0 && s.chunksCount > s.maxChunks {
+			s.aborted.WithLabelValues(limitChunks).Inc()
+			s.limitErr = status.Error(codes.ResourceExhausted, fmt.Sprintf(util_limiter.ErrMaxChunksPerQueryLimit, s.maxChunks))
+			return s.limitErr
+		}
+	}
+
+	return s.Store_SeriesServer.Send(resp)
+}
@@ -0,0 +1,113 @@
+package storegateway
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket/filesystem"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	util_limiter "github.com/cortexproject/cortex/pkg/util/limiter"
+)
+
+func TestBucketStores_Series_ShouldAbortWhenQueryLimitsAreExceeded(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	storageDir := t.TempDir()
+
+	// Generate 2 series, each one with 10 chunks.
+	generateStorageBlock(t, storageDir, userID, "series_1", 0, 1200, 1)
+	generateStorageBlock(t, storageDir, userID, "series_2", 0, 1200, 1)
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(bucket), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	tests := map[string]struct {
+		maxSeries      int
+		maxChunks      int
+		expectedErr    error
+		expectedSeries int
+		expectedLimit  string
+	}{
+		"no limits": {
+			expectedSeries: 2,
+		},
+		"limits not exceeded": {
+			maxSeries:      2,
+			maxChunks:      20,
+			expectedSeries: 2,
+		},
+		"max series exceeded": {
+			maxSeries:      1,
+			expectedErr:    status.Error(codes.ResourceExhausted, fmt.Sprintf(util_limiter.ErrMaxSeriesHit, 1)),
+			expectedSeries: 1,
+			expectedLimit:  limitSeries,
+		},
+		"max chunks exceeded": {
+			maxChunks:      15,
+			expectedErr:    status.Error(codes.ResourceExhausted, fmt.Sprintf(util_limiter.ErrMaxChunksPerQueryLimit, 15)),
+			expectedSeries: 1,
+			expectedLimit:  limitChunks,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := &storepb.SeriesRequest{
+				MinTime: 0,
+				MaxTime: 1200,
+				Matchers: []storepb.LabelMatcher{{
+					Type:  storepb.LabelMatcher_RE,
+					Name:  labels.MetricName,
+					Value: "series_.*",
+				}},
+				PartialResponseStrategy: storepb.PartialResponseStrategy_ABORT,
+			}
+
+			reqCtx := AppendQueryLimitsToOutgoingContext(ctx, testData.maxSeries, testData.maxChunks)
+			md, _ := metadata.FromOutgoingContext(reqCtx)
+			md = metadata.Join(md, metadata.Pairs(cortex_tsdb.TenantIDExternalLabel, userID))
+
+			srv := newBucketStoreSeriesServer(metadata.NewIncomingContext(ctx, md))
+			err := stores.Series(req, srv)
+			if testData.expectedErr != nil {
+				assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+				assert.EqualError(t, err, testData.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, srv.SeriesSet, testData.expectedSeries)
+
+			if testData.expectedLimit != "" {
+				assert.Equal(t, float64(1), testutil.ToFloat64(stores.queryLimitsAborted.WithLabelValues(testData.expectedLimit)))
+			}
+		})
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_stores_series_requests_aborted_total Total number of series requests aborted because the query exceeded its limit of series or chunks.
+		# TYPE cortex_bucket_stores_series_requests_aborted_total counter
+		cortex_bucket_stores_series_requests_aborted_total{limit="chunks"} 1
+		cortex_bucket_stores_series_requests_aborted_total{limit="series"} 1
+	`), "cortex_bucket_stores_series_requests_aborted_total"))
+}
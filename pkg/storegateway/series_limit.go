@@ -0,0 +1,108 @@
+package storegateway
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/store/hintspb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc/metadata"
+)
+
+// SeriesLimitMetadataKey is the gRPC metadata key of the Series requests holding the number of
+// series to return, the first ones by labels.
+const SeriesLimitMetadataKey = "cortex-series-limit"
+
+// errSeriesLimitReached stops the bucket store once the series limit of the request is reached.
+var errSeriesLimitReached = errors.New("series limit reached")
+
+// AppendSeriesLimitToOutgoingContext returns a context asking the store-gateways to only return
+// the first limit series of the Series requests. A limit of 0 is disabled and not sent.
+func AppendSeriesLimitToOutgoingContext(ctx context.Context, limit int) context.Context {
+	if limit <= 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, SeriesLimitMetadataKey, strconv.Itoa(limit))
+}
+
+// getSeriesLimitFromGRPCContext returns the series limit sent along with a Series request, 0 if
+// not sent.
+func getSeriesLimitFromGRPCContext(ctx context.Context) int {
+	meta, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0
+	}
+	return parseQueryLimit(meta, SeriesLimitMetadataKey)
+}
+
+// seriesLimitSeriesServer is a storepb.Store_SeriesServer stopping the request once the first
+// limit series, sent in order by the bucket store, have been sent.
+type seriesLimitSeriesServer struct {
+	storepb.Store_SeriesServer
+
+	limit       int
+	seriesCount int
+	reached     bool
+}
+
+func (s *seriesLimitSeriesServer) Send(resp *storepb.SeriesResponse) error {
+	if resp.GetSeries() != nil {
+		if s.seriesCount >= s.limit {
+			s.reached = true
+			return errSeriesLimitReached
+		}
+		s.seriesCount++
+	}
+
+	return s.Store_SeriesServer.Send(resp)
+}
+
+// sendQueriedBlocks sends the response hints the bucket store doesn't send once stopped, reporting
+// all the blocks of the request as queried, since the querier checks all of them have been queried.
+func (s *seriesLimitSeriesServer) sendQueriedBlocks(req *storepb.SeriesRequest) error {
+	hints := hintspb.SeriesResponseHints{}
+	for _, id := range requestedBlockIDs(req) {
+		hints.AddQueriedBlock(id)
+	}
+
+	anyHints, err := types.MarshalAny(&hints)
+	if err != nil {
+		return err
+	}
+	return s.Store_SeriesServer.Send(storepb.NewHintsSeriesResponse(anyHints))
+}
+
+// requestedBlockIDs returns the IDs of the blocks selected by the request hints, or nil if it
+// doesn't select the blocks by their exact ID.
+func requestedBlockIDs(req *storepb.SeriesRequest) []ulid.ULID {
+	if req.Hints == nil {
+		return nil
+	}
+
+	hints := hintspb.SeriesRequestHints{}
+	if err := types.UnmarshalAny(req.Hints, &hints); err != nil {
+		return nil
+	}
+
+	for _, m := range hints.BlockMatchers {
+		if m.Name != block.BlockIDLabel || m.Type != storepb.LabelMatcher_RE || m.Value == "" {
+			continue
+		}
+
+		var ids []ulid.ULID
+		for _, value := range strings.Split(m.Value, "|") {
+			id, err := ulid.Parse(value)
+			if err != nil {
+				return nil
+			}
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package storegateway
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/gogo/protobuf/types"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/store/hintspb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket/filesystem"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+)
+
+func TestBucketStores_Series_ShouldReturnTheFirstSeriesUpToTheSeriesLimit(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	storageDir := t.TempDir()
+
+	// Generate 3 series, each one in its own block.
+	generateStorageBlock(t, storageDir, userID, "series_3", 0, 100, 15)
+	generateStorageBlock(t, storageDir, userID, "series_1", 0, 100, 15)
+	generateStorageBlock(t, storageDir, userID, "series_2", 0, 100, 15)
+
+	entries, err := os.ReadDir(filepath.Join(storageDir, userID))
+	require.NoError(t, err)
+	var blockIDs []string
+	for _, entry := range entries {
+		blockID, err := ulid.Parse(entry.Name())
+		require.NoError(t, err)
+		blockIDs = append(blockIDs, blockID.String())
+	}
+	require.Len(t, blockIDs, 3)
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(bucket), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	hints, err := types.MarshalAny(&hintspb.SeriesRequestHints{
+		BlockMatchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: block.BlockIDLabel, Value: strings.Join(blockIDs, "|")}},
+	})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		limit          int
+		expectedSeries []string
+	}{
+		"no limit": {
+			expectedSeries: []string{"series_1", "series_2", "series_3"},
+		},
+		"limit below the number of series": {
+			limit:          2,
+			expectedSeries: []string{"series_1", "series_2"},
+		},
+		"limit equal to the number of series": {
+			limit:          3,
+			expectedSeries: []string{"series_1", "series_2", "series_3"},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := &storepb.SeriesRequest{
+				MinTime: 0,
+				MaxTime: 100,
+				Matchers: []storepb.LabelMatcher{{
+					Type:  storepb.LabelMatcher_RE,
+					Name:  labels.MetricName,
+					Value: "series_.*",
+				}},
+				PartialResponseStrategy: storepb.PartialResponseStrategy_ABORT,
+				Hints:                   hints,
+			}
+
+			reqCtx := AppendSeriesLimitToOutgoingContext(ctx, testData.limit)
+			md, _ := metadata.FromOutgoingContext(reqCtx)
+			md = metadata.Join(md, metadata.Pairs(cortex_tsdb.TenantIDExternalLabel, userID))
+
+			srv := newBucketStoreSeriesServer(metadata.NewIncomingContext(ctx, md))
+			require.NoError(t, stores.Series(req, srv))
+
+			var series []string
+			for _, s := range srv.SeriesSet {
+				series = append(series, s.PromLabels().Get(labels.MetricName))
+			}
+			assert.Equal(t, testData.expectedSeries, series)
+
+			// All the blocks are reported as queried, even if the request has been stopped.
+			var queriedBlocks []string
+			for _, b := range srv.Hints.QueriedBlocks {
+				queriedBlocks = append(queriedBlocks, b.Id)
+			}
+			assert.ElementsMatch(t, blockIDs, queriedBlocks)
+		})
+	}
+}
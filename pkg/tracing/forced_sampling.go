@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var samplingPriorityKey = attribute.Key(ext.SamplingPriority)
+
+// StartForcedSampledSpan starts a span sampled regardless of the sampling of the tracer, so that the
+// spans started from the returned context are sampled too.
+func StartForcedSampledSpan(ctx context.Context, operationName string) (opentracing.Span, context.Context) {
+	return opentracing.StartSpanFromContext(ctx, operationName, opentracing.Tag{Key: string(ext.SamplingPriority), Value: uint16(1)})
+}
+
+// samplingPrioritySampler samples the spans started with a positive sampling.priority tag, like
+// the Jaeger tracer does, and delegates the sampling of the other spans.
+type samplingPrioritySampler struct {
+	sdktrace.Sampler
+}
+
+func (s samplingPrioritySampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, attr := range p.Attributes {
+		if attr.Key == samplingPriorityKey && attr.Value.Type() == attribute.INT64 && attr.Value.AsInt64() > 0 {
+			return sdktrace.SamplingResult{
+				Decision:   sdktrace.RecordAndSample,
+				Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+			}
+		}
+	}
+	return s.Sampler.ShouldSample(p)
+}
+
+func (s samplingPrioritySampler) Description() string {
+	return fmt.Sprintf("SamplingPriority{%s}", s.Sampler.Description())
+}
@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestSamplingPrioritySampler(t *testing.T) {
+	sampler := samplingPrioritySampler{sdktrace.ParentBased(sdktrace.NeverSample())}
+
+	for name, tc := range map[string]struct {
+		attributes []attribute.KeyValue
+		expected   sdktrace.SamplingDecision
+	}{
+		"no sampling priority": {
+			expected: sdktrace.Drop,
+		},
+		"positive sampling priority": {
+			attributes: []attribute.KeyValue{samplingPriorityKey.Int64(1)},
+			expected:   sdktrace.RecordAndSample,
+		},
+		"zero sampling priority": {
+			attributes: []attribute.KeyValue{samplingPriorityKey.Int64(0)},
+			expected:   sdktrace.Drop,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			result := sampler.ShouldSample(sdktrace.SamplingParameters{
+				ParentContext: context.Background(),
+				Name:          "test",
+				Attributes:    tc.attributes,
+			})
+			require.Equal(t, tc.expected, result.Decision)
+		})
+	}
+}
@@ -150,7 +150,7 @@ func newTraceProvider(r *resource.Resource, c Config, exporter *otlptrace.Export
 	default:
 	}
 
-	options = append(options, sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.Otel.SampleRatio))))
+	options = append(options, sdktrace.WithSampler(samplingPrioritySampler{sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.Otel.SampleRatio))}))
 
 	return propagator, sdktrace.NewTracerProvider(options...)
 }
@@ -0,0 +1,44 @@
+package flagext
+
+import (
+	"flag"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces the value of the flags holding secrets.
+const redacted = "********"
+
+// RedactedValues returns the current value of all the flags registered in the FlagSet, keyed
+// by flag name. The value of Secret flags and of flags whose name ends with "password" or
+// "secret" is redacted, as well as the password of URL values.
+func RedactedValues(fs *flag.FlagSet) map[string]string {
+	values := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		values[f.Name] = redactValue(f)
+	})
+	return values
+}
+
+func redactValue(f *flag.Flag) string {
+	value := f.Value.String()
+	if value == "" {
+		return value
+	}
+
+	if _, ok := f.Value.(*Secret); ok {
+		return redacted
+	}
+
+	name := strings.ToLower(f.Name)
+	if strings.HasSuffix(name, "password") || strings.HasSuffix(name, "secret") {
+		return redacted
+	}
+
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+	return value
+}
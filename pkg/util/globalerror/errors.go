@@ -0,0 +1,139 @@
+// Package globalerror defines the machine-readable codes of the errors returned to the clients, so
+// that they can match on the errors instead of their free-text messages.
+package globalerror
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ID identifies an error returned to the clients. Once released, an ID must not be changed, as
+// the clients match on it.
+type ID string
+
+// The errors of the write path.
+const (
+	MissingMetricName         ID = "missing-metric-name"
+	InvalidMetricName         ID = "metric-name-invalid"
+	MaxLabelNamesPerSeries    ID = "max-label-names-per-series"
+	LabelNameTooLong          ID = "label-name-too-long"
+	LabelValueTooLong         ID = "label-value-too-long"
+	LabelValueInvalidUTF8     ID = "label-value-invalid-utf8"
+	MaxLabelsSizeBytes        ID = "max-labels-size-bytes"
+	InvalidLabel              ID = "label-invalid"
+	DuplicateLabelNames       ID = "duplicate-label-names"
+	LabelsNotSorted           ID = "labels-not-sorted"
+	LabelSchemaViolation      ID = "label-schema-violation"
+	LabelValuesBudgetExceeded ID = "label-values-budget-exceeded"
+	PreAggregatedNotAllowed   ID = "pre-aggregated-series-not-allowed"
+	InvalidPreAggregated      ID = "pre-aggregated-series-invalid"
+	SampleTooOld              ID = "sample-timestamp-too-old"
+	SampleTooFarInFuture      ID = "sample-timestamp-too-far-in-future"
+	ExemplarLabelsMissing     ID = "exemplar-labels-missing"
+	ExemplarTimestampMissing  ID = "exemplar-timestamp-missing"
+	ExemplarLabelsTooLong     ID = "exemplar-labels-too-long"
+	MetricMetadataMissingName ID = "metric-metadata-missing-metric-name"
+	MetricMetadataTooLong     ID = "metric-metadata-too-long"
+
+	IngestionRateLimited               ID = "ingestion-rate-limited"
+	DistributorMaxIngestionRate        ID = "distributor-max-ingestion-rate"
+	DistributorMaxInflightPushRequests ID = "distributor-max-inflight-push-requests"
+	DecodingMaxSeriesPerRequest        ID = "decoding-max-series-per-request"
+	DecodingMaxMetadataPerRequest      ID = "decoding-max-metadata-per-request"
+	DecodingMaxLabelsPerSeries         ID = "decoding-max-labels-per-series"
+	DecodingMaxExemplarsPerSeries      ID = "decoding-max-exemplars-per-series"
+	MaxSeriesPerUser                   ID = "max-series-per-user"
+	MaxSeriesPerMetric                 ID = "max-series-per-metric"
+	MaxEphemeralSeriesPerUser          ID = "max-ephemeral-series-per-user"
+	MaxMetadataPerUser                 ID = "max-metadata-per-user"
+	MaxMetadataPerMetric               ID = "max-metadata-per-metric"
+	SampleOutOfBounds                  ID = "sample-out-of-bounds"
+	SampleOutOfOrder                   ID = "sample-out-of-order"
+	SampleDuplicateTimestamp           ID = "sample-duplicate-timestamp"
+	IngesterMaxIngestionRate           ID = "ingester-max-ingestion-rate"
+	IngesterMaxTenants                 ID = "ingester-max-tenants"
+	IngesterMaxSeries                  ID = "ingester-max-series"
+	IngesterMaxInflightPushRequests    ID = "ingester-max-inflight-push-requests"
+	WriteInProgress                    ID = "write-in-progress"
+)
+
+// The errors of the read path.
+const (
+	MaxSeriesPerQuery          ID = "max-series-per-query"
+	MaxChunksPerQuery          ID = "max-chunks-per-query"
+	MaxChunkBytesPerQuery      ID = "max-chunk-bytes-per-query"
+	MaxDataBytesPerQuery       ID = "max-data-bytes-per-query"
+	MaxQueryLength             ID = "max-query-length"
+	TooManyOutstandingRequests ID = "too-many-outstanding-requests"
+)
+
+const (
+	errPrefix = "err-cortex-"
+
+	// HeaderName is the HTTP header carrying the code of the error responses.
+	HeaderName = "X-Cortex-Error-Code"
+
+	documentationURL = "https://cortexmetrics.io/docs/operations/error-codes/"
+)
+
+var (
+	messagesMtx sync.RWMutex
+	messages    []message
+
+	verbRegexp = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]*)?[a-zA-Z%]`)
+)
+
+// message is the format of the message of an error, matching the messages formatted with it.
+type message struct {
+	id     ID
+	format *regexp.Regexp
+}
+
+// Code returns the code of the error, as returned to the clients, like err-cortex-max-series-per-query.
+func (id ID) Code() string {
+	return errPrefix + string(id)
+}
+
+// DocumentationURL returns the URL of the documentation of the error.
+func (id ID) DocumentationURL() string {
+	return documentationURL + "#" + id.Code()
+}
+
+// Message registers the format of the message of the error, so that the error responses with a message
+// formatted with it are given the code of the error, and returns the format unchanged. It must be called
+// at the initialization of the packages, for every Cortex component to know the formats of all the errors.
+func (id ID) Message(format string) string {
+	var pattern strings.Builder
+	pattern.WriteString("(?s)")
+	last := 0
+	for _, loc := range verbRegexp.FindAllStringIndex(format, -1) {
+		pattern.WriteString(regexp.QuoteMeta(format[last:loc[0]]))
+		if format[loc[1]-1] == '%' {
+			pattern.WriteString("%")
+		} else {
+			pattern.WriteString(".*?")
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(format[last:]))
+
+	messagesMtx.Lock()
+	defer messagesMtx.Unlock()
+
+	messages = append(messages, message{id: id, format: regexp.MustCompile(pattern.String())})
+	return format
+}
+
+// IDFromMessage returns the ID of the first error whose message is found in msg, if any.
+func IDFromMessage(msg string) (ID, bool) {
+	messagesMtx.RLock()
+	defer messagesMtx.RUnlock()
+
+	for _, m := range messages {
+		if m.format.MatchString(msg) {
+			return m.id, true
+		}
+	}
+	return "", false
+}
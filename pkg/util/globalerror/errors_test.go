@@ -0,0 +1,40 @@
+package globalerror
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestID_Message(t *testing.T) {
+	assert.Equal(t, "the query hit the max number of series limit (limit: %d series)", MaxSeriesPerQuery.Message("the query hit the max number of series limit (limit: %d series)"))
+	assert.Equal(t, "err-cortex-max-series-per-query", MaxSeriesPerQuery.Code())
+	assert.Equal(t, "https://cortexmetrics.io/docs/operations/error-codes/#err-cortex-max-series-per-query", MaxSeriesPerQuery.DocumentationURL())
+}
+
+func TestIDFromMessage(t *testing.T) {
+	MaxSeriesPerQuery.Message("the query hit the max number of series limit (limit: %d series)")
+	MaxSeriesPerMetric.Message("per-metric series limit of %d exceeded, %s (local limit: %d global limit: %d actual local limit: %d)")
+	TooManyOutstandingRequests.Message("too many outstanding requests")
+	LabelsNotSorted.Message("labels not sorted: %.200q metric %.200q")
+
+	for msg, expected := range map[string]ID{
+		`{"status":"error","errorType":"execution","error":"expanding series: the query hit the max number of series limit (limit: 10 series)"}`:                               MaxSeriesPerQuery,
+		"user=user-1: per-metric series limit of 10 exceeded, please contact administrator to raise it (local limit: 0 global limit: 10 actual local limit: 10) for series {}": MaxSeriesPerMetric,
+		"too many outstanding requests\n":                                              TooManyOutstandingRequests,
+		`{"error":"labels not sorted: \"a\" metric \"up{b=\\\"c\\\", a=\\\"d\\\"}\""}`: LabelsNotSorted,
+	} {
+		id, ok := IDFromMessage(msg)
+		assert.True(t, ok, msg)
+		assert.Equal(t, expected, id, msg)
+	}
+
+	for _, msg := range []string{
+		"the query hit the max number of series limit",
+		"per-metric series limit of 10 exceeded",
+		"too many requests",
+	} {
+		_, ok := IDFromMessage(msg)
+		assert.False(t, ok, msg)
+	}
+}
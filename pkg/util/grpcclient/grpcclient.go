@@ -55,11 +55,16 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 }
 
 func (cfg *Config) Validate(log log.Logger) error {
-	switch cfg.GRPCCompression {
+	return ValidateCompression(cfg.GRPCCompression)
+}
+
+// ValidateCompression returns an error if the gRPC compression isn't supported.
+func ValidateCompression(compression string) error {
+	switch compression {
 	case gzip.Name, snappy.Name, zstd.Name, snappyblock.Name, "":
 		// valid
 	default:
-		return errors.Errorf("unsupported compression type: %s", cfg.GRPCCompression)
+		return errors.Errorf("unsupported compression type: %s", compression)
 	}
 	return nil
 }
@@ -14,11 +14,15 @@ import (
 func Instrument(requestDuration *prometheus.HistogramVec) ([]grpc.UnaryClientInterceptor, []grpc.StreamClientInterceptor) {
 	return []grpc.UnaryClientInterceptor{
 			grpcutil.HTTPHeaderPropagationClientInterceptor,
+			grpcutil.RequestIDPropagationClientInterceptor,
+			grpcutil.QueryPriorityPropagationClientInterceptor,
 			otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
 			middleware.ClientUserHeaderInterceptor,
 			cortexmiddleware.PrometheusGRPCUnaryInstrumentation(requestDuration),
 		}, []grpc.StreamClientInterceptor{
 			grpcutil.HTTPHeaderPropagationStreamClientInterceptor,
+			grpcutil.RequestIDPropagationStreamClientInterceptor,
+			grpcutil.QueryPriorityPropagationStreamClientInterceptor,
 			otgrpc.OpenTracingStreamClientInterceptor(opentracing.GlobalTracer()),
 			middleware.StreamClientUserHeaderInterceptor,
 			cortexmiddleware.PrometheusGRPCStreamInstrumentation(requestDuration),
@@ -2,6 +2,7 @@ package grpcencoding
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
@@ -10,6 +11,8 @@ import (
 	"github.com/cortexproject/cortex/pkg/util/grpcencoding/snappyblock"
 	"github.com/cortexproject/cortex/pkg/util/grpcencoding/zstd"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/encoding"
@@ -175,3 +178,46 @@ func decompress(compressor encoding.Compressor, d []byte, maxReceiveMessageSize
 	d, err = io.ReadAll(io.LimitReader(dcReader, int64(maxReceiveMessageSize)+1))
 	return d, len(d), err
 }
+
+func TestInstrumentedCompressor(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	c := &instrumentedCompressor{Compressor: encoding.GetCompressor(zstd.Name), metrics: newCompressionMetrics(reg)}
+	input := strings.Repeat("123456789", 2024)
+
+	buf := &bytes.Buffer{}
+	w, err := c.Compress(buf)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(input))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	compressedSize := buf.Len()
+
+	out, _, err := decompress(c, buf.Bytes(), len(input))
+	require.NoError(t, err)
+	assert.Equal(t, input, string(out))
+
+	assert.Less(t, compressedSize, len(input))
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+		# HELP cortex_grpc_compression_compressed_bytes_total Total number of bytes of the gRPC messages after compression or before decompression.
+		# TYPE cortex_grpc_compression_compressed_bytes_total counter
+		cortex_grpc_compression_compressed_bytes_total{compression="zstd",operation="compress"} %[1]d
+		cortex_grpc_compression_compressed_bytes_total{compression="zstd",operation="decompress"} %[1]d
+		# HELP cortex_grpc_compression_uncompressed_bytes_total Total number of bytes of the gRPC messages before compression or after decompression.
+		# TYPE cortex_grpc_compression_uncompressed_bytes_total counter
+		cortex_grpc_compression_uncompressed_bytes_total{compression="zstd",operation="compress"} %[2]d
+		cortex_grpc_compression_uncompressed_bytes_total{compression="zstd",operation="decompress"} %[2]d
+	`, compressedSize, len(input)))))
+}
+
+func TestInstrumentCompressors_ShouldOnlyInstrumentOnce(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	require.NotPanics(t, func() {
+		InstrumentCompressors(reg)
+		InstrumentCompressors(reg)
+	})
+
+	c, ok := encoding.GetCompressor(zstd.Name).(*instrumentedCompressor)
+	require.True(t, ok)
+	_, ok = c.Compressor.(*instrumentedCompressor)
+	assert.False(t, ok)
+}
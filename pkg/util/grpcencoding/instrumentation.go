@@ -0,0 +1,136 @@
+package grpcencoding
+
+import (
+	"io"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+
+	"github.com/cortexproject/cortex/pkg/util/grpcencoding/snappy"
+	"github.com/cortexproject/cortex/pkg/util/grpcencoding/snappyblock"
+	"github.com/cortexproject/cortex/pkg/util/grpcencoding/zstd"
+)
+
+const (
+	operationCompress   = "compress"
+	operationDecompress = "decompress"
+)
+
+// Compressors are the names of the gRPC compressors supported by the Cortex gRPC clients and servers.
+var Compressors = []string{gzip.Name, snappy.Name, snappyblock.Name, zstd.Name}
+
+var instrumentOnce sync.Once
+
+type compressionMetrics struct {
+	uncompressedBytes *prometheus.CounterVec
+	compressedBytes   *prometheus.CounterVec
+}
+
+// InstrumentCompressors replaces the registered gRPC compressors with compressors tracking the
+// number of bytes before and after the compression, so that the compression ratio of the gRPC
+// messages sent and received can be monitored. It must be called before any gRPC traffic.
+//
+// The gRPC compressors are global to the process, so they're only instrumented once: the next
+// calls are no-ops, and their registerer is ignored.
+func InstrumentCompressors(reg prometheus.Registerer) {
+	instrumentOnce.Do(func() {
+		instrumentCompressors(reg)
+	})
+}
+
+func instrumentCompressors(reg prometheus.Registerer) {
+	m := newCompressionMetrics(reg)
+	for _, name := range Compressors {
+		c := encoding.GetCompressor(name)
+		if c == nil {
+			continue
+		}
+		if ic, ok := c.(*instrumentedCompressor); ok {
+			c = ic.Compressor
+		}
+		encoding.RegisterCompressor(&instrumentedCompressor{Compressor: c, metrics: m})
+	}
+}
+
+func newCompressionMetrics(reg prometheus.Registerer) *compressionMetrics {
+	return &compressionMetrics{
+		uncompressedBytes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_grpc_compression_uncompressed_bytes_total",
+			Help: "Total number of bytes of the gRPC messages before compression or after decompression.",
+		}, []string{"compression", "operation"}),
+		compressedBytes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_grpc_compression_compressed_bytes_total",
+			Help: "Total number of bytes of the gRPC messages after compression or before decompression.",
+		}, []string{"compression", "operation"}),
+	}
+}
+
+type instrumentedCompressor struct {
+	encoding.Compressor
+	metrics *compressionMetrics
+}
+
+func (c *instrumentedCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	compressed := &countingWriter{Writer: w, counter: c.metrics.compressedBytes.WithLabelValues(c.Name(), operationCompress)}
+	wc, err := c.Compressor.Compress(compressed)
+	if err != nil {
+		return nil, err
+	}
+	return &countingWriteCloser{WriteCloser: wc, counter: c.metrics.uncompressedBytes.WithLabelValues(c.Name(), operationCompress)}, nil
+}
+
+func (c *instrumentedCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	compressed := &countingReader{Reader: r, counter: c.metrics.compressedBytes.WithLabelValues(c.Name(), operationDecompress)}
+	dr, err := c.Compressor.Decompress(compressed)
+	if err != nil {
+		return nil, err
+	}
+	return &countingReader{Reader: dr, counter: c.metrics.uncompressedBytes.WithLabelValues(c.Name(), operationDecompress)}, nil
+}
+
+// DecompressedSize lets gRPC size its buffers when the wrapped compressor knows the size of
+// the decompressed message, and returns -1 otherwise.
+func (c *instrumentedCompressor) DecompressedSize(compressedBytes []byte) int {
+	if sizer, ok := c.Compressor.(interface {
+		DecompressedSize(compressedBytes []byte) int
+	}); ok {
+		return sizer.DecompressedSize(compressedBytes)
+	}
+	return -1
+}
+
+type countingWriter struct {
+	io.Writer
+	counter prometheus.Counter
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.counter.Add(float64(n))
+	return n, err
+}
+
+type countingWriteCloser struct {
+	io.WriteCloser
+	counter prometheus.Counter
+}
+
+func (w *countingWriteCloser) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.counter.Add(float64(n))
+	return n, err
+}
+
+type countingReader struct {
+	io.Reader
+	counter prometheus.Counter
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.counter.Add(float64(n))
+	return n, err
+}
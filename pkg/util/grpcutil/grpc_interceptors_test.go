@@ -9,6 +9,7 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/priority"
 )
 
 func TestHTTPHeaderPropagationClientInterceptor(t *testing.T) {
@@ -90,3 +91,55 @@ func TestGRPCHeaderDifferentLengthsForHTTPPropagationServerInterceptor(t *testin
 
 	assert.Nil(t, util_log.HeaderMapFromContext(ctx))
 }
+
+func TestRequestIDPropagationInterceptors(t *testing.T) {
+	ctx := util_log.ContextWithRequestID(context.Background(), "request-1")
+	ctx = injectRequestIDIntoMetadata(ctx)
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, []string{"request-1"}, md.Get(util_log.RequestIDMetadataKey))
+
+	// The request ID already in the metadata isn't added again.
+	md, _ = metadata.FromOutgoingContext(injectRequestIDIntoMetadata(ctx))
+	assert.Equal(t, []string{"request-1"}, md.Get(util_log.RequestIDMetadataKey))
+
+	incomingCtx := metadata.NewIncomingContext(context.Background(), md)
+	_, err := RequestIDPropagationServerInterceptor(incomingCtx, nil, nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		assert.Equal(t, "request-1", util_log.RequestIDFromContext(ctx))
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	// The contexts without request ID aren't changed.
+	ctx = context.Background()
+	assert.Equal(t, ctx, injectRequestIDIntoMetadata(ctx))
+	assert.Equal(t, ctx, extractRequestIDFromMetadata(ctx))
+}
+
+func TestQueryPriorityPropagationInterceptors(t *testing.T) {
+	ctx := priority.ContextWithPriority(context.Background(), 5)
+	ctx = injectQueryPriorityIntoMetadata(ctx)
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, []string{"5"}, md.Get(priority.MetadataKey))
+
+	// The priority already in the metadata isn't added again.
+	md, _ = metadata.FromOutgoingContext(injectQueryPriorityIntoMetadata(ctx))
+	assert.Equal(t, []string{"5"}, md.Get(priority.MetadataKey))
+
+	incomingCtx := metadata.NewIncomingContext(context.Background(), md)
+	_, err := QueryPriorityPropagationServerInterceptor(incomingCtx, nil, nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		p, ok := priority.FromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, int64(5), p)
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	// The contexts without priority aren't changed.
+	ctx = context.Background()
+	assert.Equal(t, ctx, injectQueryPriorityIntoMetadata(ctx))
+	assert.Equal(t, ctx, extractQueryPriorityFromMetadata(ctx))
+}
@@ -0,0 +1,57 @@
+package grpcutil
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/cortexproject/cortex/pkg/util/priority"
+)
+
+// QueryPriorityPropagationServerInterceptor places the query priority propagated by the caller into the context
+// of the request - works alongside QueryPriorityPropagationClientInterceptor
+func QueryPriorityPropagationServerInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	return handler(extractQueryPriorityFromMetadata(ctx), req)
+}
+
+// QueryPriorityPropagationStreamServerInterceptor does the same as QueryPriorityPropagationServerInterceptor but for streams
+func QueryPriorityPropagationStreamServerInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, wrappedServerStream{
+		ctx:          extractQueryPriorityFromMetadata(ss.Context()),
+		ServerStream: ss,
+	})
+}
+
+func extractQueryPriorityFromMetadata(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return priority.ContextWithPriorityFromMetadata(ctx, md)
+}
+
+// QueryPriorityPropagationClientInterceptor propagates the query priority of the context to the called component -
+// works alongside QueryPriorityPropagationServerInterceptor
+func QueryPriorityPropagationClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(injectQueryPriorityIntoMetadata(ctx), method, req, reply, cc, opts...)
+}
+
+// QueryPriorityPropagationStreamClientInterceptor does the same as QueryPriorityPropagationClientInterceptor but for streams
+func QueryPriorityPropagationStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+	streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(injectQueryPriorityIntoMetadata(ctx), desc, cc, method, opts...)
+}
+
+func injectQueryPriorityIntoMetadata(ctx context.Context) context.Context {
+	p, ok := priority.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(priority.MetadataKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, priority.MetadataKey, strconv.FormatInt(p, 10))
+}
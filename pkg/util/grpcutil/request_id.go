@@ -0,0 +1,56 @@
+package grpcutil
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+// RequestIDPropagationServerInterceptor places the request ID propagated by the caller into the context of
+// the request - works alongside RequestIDPropagationClientInterceptor
+func RequestIDPropagationServerInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	return handler(extractRequestIDFromMetadata(ctx), req)
+}
+
+// RequestIDPropagationStreamServerInterceptor does the same as RequestIDPropagationServerInterceptor but for streams
+func RequestIDPropagationStreamServerInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, wrappedServerStream{
+		ctx:          extractRequestIDFromMetadata(ss.Context()),
+		ServerStream: ss,
+	})
+}
+
+func extractRequestIDFromMetadata(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return util_log.ContextWithRequestIDFromMetadata(ctx, md)
+}
+
+// RequestIDPropagationClientInterceptor propagates the request ID of the context to the called component - works
+// alongside RequestIDPropagationServerInterceptor
+func RequestIDPropagationClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(injectRequestIDIntoMetadata(ctx), method, req, reply, cc, opts...)
+}
+
+// RequestIDPropagationStreamClientInterceptor does the same as RequestIDPropagationClientInterceptor but for streams
+func RequestIDPropagationStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+	streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(injectRequestIDIntoMetadata(ctx), desc, cc, method, opts...)
+}
+
+func injectRequestIDIntoMetadata(ctx context.Context) context.Context {
+	requestID := util_log.RequestIDFromContext(ctx)
+	if requestID == "" {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(util_log.RequestIDMetadataKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, util_log.RequestIDMetadataKey, requestID)
+}
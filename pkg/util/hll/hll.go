@@ -0,0 +1,86 @@
+// Package hll implements HyperLogLog sketches, estimating the number of distinct values of large
+// sets in a fixed amount of memory, with a standard error of about 1.6%. The sketches of several
+// sets can be merged to estimate the number of distinct values of their union.
+package hll
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+const (
+	// The first bits of the hashes select the register of the values.
+	precision = 12
+	registers = 1 << precision
+)
+
+// Sketch is a HyperLogLog sketch of the hashes of the values of a set. It's not safe for
+// concurrent use.
+type Sketch struct {
+	registers []uint8
+}
+
+// New returns an empty sketch.
+func New() *Sketch {
+	return &Sketch{registers: make([]uint8, registers)}
+}
+
+// FromBytes returns the sketch encoded by Bytes.
+func FromBytes(b []byte) (*Sketch, error) {
+	if len(b) != registers {
+		return nil, fmt.Errorf("invalid sketch size %d, expected %d", len(b), registers)
+	}
+	s := New()
+	copy(s.registers, b)
+	return s, nil
+}
+
+// Bytes returns the encoding of the sketch.
+func (s *Sketch) Bytes() []byte {
+	b := make([]byte, registers)
+	copy(b, s.registers)
+	return b
+}
+
+// Insert adds the 64 bits hash of a value to the sketch. The hashes must be uniformly
+// distributed, like xxhash.
+func (s *Sketch) Insert(hash uint64) {
+	idx := hash >> (64 - precision)
+	// The rank of the first set bit of the rest of the hash, bounded by the sentinel bit.
+	rank := uint8(bits.LeadingZeros64(hash<<precision|1<<(precision-1))) + 1
+	if rank > s.registers[idx] {
+		s.registers[idx] = rank
+	}
+}
+
+// Merge adds the values of the other sketch to the sketch.
+func (s *Sketch) Merge(other *Sketch) {
+	for i, r := range other.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+		}
+	}
+}
+
+// Estimate returns the estimated number of distinct values added to the sketch.
+func (s *Sketch) Estimate() uint64 {
+	m := float64(registers)
+
+	sum := 0.0
+	zeros := 0
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// The small cardinalities are better estimated with linear counting. The 64 bits hashes
+	// don't require the correction of the large ones.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
@@ -0,0 +1,51 @@
+package hll
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSketch_Estimate(t *testing.T) {
+	for _, n := range []int{0, 1, 100, 5000, 50000, 1000000} {
+		t.Run(strconv.Itoa(n), func(t *testing.T) {
+			s := New()
+			for i := 0; i < n; i++ {
+				// The duplicated values aren't counted.
+				s.Insert(xxhash.Sum64String(strconv.Itoa(i)))
+				s.Insert(xxhash.Sum64String(strconv.Itoa(i)))
+			}
+			assert.InEpsilon(t, float64(n)+1, float64(s.Estimate())+1, 0.05)
+		})
+	}
+}
+
+func TestSketch_Merge(t *testing.T) {
+	a, b := New(), New()
+	for i := 0; i < 30000; i++ {
+		a.Insert(xxhash.Sum64String(strconv.Itoa(i)))
+	}
+	for i := 20000; i < 50000; i++ {
+		b.Insert(xxhash.Sum64String(strconv.Itoa(i)))
+	}
+
+	a.Merge(b)
+	assert.InEpsilon(t, 50000, float64(a.Estimate()), 0.05)
+}
+
+func TestSketch_Bytes(t *testing.T) {
+	s := New()
+	for i := 0; i < 1000; i++ {
+		s.Insert(xxhash.Sum64String(strconv.Itoa(i)))
+	}
+
+	decoded, err := FromBytes(s.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, s.Estimate(), decoded.Estimate())
+
+	_, err = FromBytes([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
@@ -22,6 +22,22 @@ import (
 )
 
 const QueryPriorityHeaderKey = "X-Cortex-Query-Priority"
+
+// QueryEndTimeOffsetHeaderKey is the header of the query responses whose end time was moved back
+// because of the tenant's data availability delay.
+const QueryEndTimeOffsetHeaderKey = "X-Cortex-Query-End-Time-Offset"
+
+// IngestionSamplingFactorHeaderKey is the header of the push responses whose samples were sampled
+// because the tenant exceeded its ingestion rate limit.
+const IngestionSamplingFactorHeaderKey = "X-Cortex-Ingestion-Sampling-Factor"
+
+// EphemeralSeriesHeaderKey is the header of the push requests whose series are ephemeral, when set to true.
+const EphemeralSeriesHeaderKey = "X-Cortex-Ephemeral"
+
+// IdempotencyKeyHeaderKey is the header of the push requests carrying the client-supplied key
+// identifying the retries of the same push request.
+const IdempotencyKeyHeaderKey = "Idempotency-Key"
+
 const messageSizeLargerErrFmt = "received message larger than max (%d vs %d)"
 
 // IsRequestBodyTooLarge returns true if the error is "http: request body too large".
@@ -0,0 +1,16 @@
+package util
+
+import "context"
+
+type idempotencyKeyContextKey struct{}
+
+// ContextWithIdempotencyKey returns a context carrying the idempotency key of a push request.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key of the push request, or an empty string if none.
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
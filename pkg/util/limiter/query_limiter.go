@@ -10,16 +10,19 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
 )
 
 type queryLimiterCtxKey struct{}
 
+type maxChunksPerQueryCtxKey struct{}
+
 var (
 	ctxKey                    = &queryLimiterCtxKey{}
-	ErrMaxSeriesHit           = "the query hit the max number of series limit (limit: %d series)"
-	ErrMaxChunkBytesHit       = "the query hit the aggregated chunks size limit (limit: %d bytes)"
-	ErrMaxDataBytesHit        = "the query hit the aggregated data size limit (limit: %d bytes)"
-	ErrMaxChunksPerQueryLimit = "the query hit the max number of chunks limit (limit: %d chunks)"
+	ErrMaxSeriesHit           = globalerror.MaxSeriesPerQuery.Message("the query hit the max number of series limit (limit: %d series)")
+	ErrMaxChunkBytesHit       = globalerror.MaxChunkBytesPerQuery.Message("the query hit the aggregated chunks size limit (limit: %d bytes)")
+	ErrMaxDataBytesHit        = globalerror.MaxDataBytesPerQuery.Message("the query hit the aggregated data size limit (limit: %d bytes)")
+	ErrMaxChunksPerQueryLimit = globalerror.MaxChunksPerQuery.Message("the query hit the max number of chunks limit (limit: %d chunks)")
 )
 
 type QueryLimiter struct {
@@ -65,6 +68,21 @@ func QueryLimiterFromContextWithFallback(ctx context.Context) *QueryLimiter {
 	return ql
 }
 
+// AddMaxChunksPerQueryToContext overrides the max number of chunks per query of the tenant for the
+// queries run with the returned context, like the rule queries of a rule group with query limits.
+func AddMaxChunksPerQueryToContext(ctx context.Context, maxChunksPerQuery int) context.Context {
+	return context.WithValue(ctx, maxChunksPerQueryCtxKey{}, maxChunksPerQuery)
+}
+
+// MaxChunksPerQueryFromContext returns the max number of chunks per query overridden in the context,
+// or the given tenant limit if not overridden.
+func MaxChunksPerQueryFromContext(ctx context.Context, tenantLimit int) int {
+	if v, ok := ctx.Value(maxChunksPerQueryCtxKey{}).(int); ok {
+		return v
+	}
+	return tenantLimit
+}
+
 // AddSeriesBatch adds the batch of input series and returns an error if the limit is reached.
 func (ql *QueryLimiter) AddSeries(series ...[]cortexpb.LabelAdapter) error {
 	// If the max series is unlimited just return without managing map
@@ -89,6 +107,14 @@ func (ql *QueryLimiter) AddSeries(series ...[]cortexpb.LabelAdapter) error {
 	return nil
 }
 
+// MaxSeriesPerQuery returns the max number of series the query can fetch, 0 if unlimited.
+func (ql *QueryLimiter) MaxSeriesPerQuery() int {
+	if ql == nil {
+		return 0
+	}
+	return ql.maxSeriesPerQuery
+}
+
 // uniqueSeriesCount returns the count of unique series seen by this query limiter.
 func (ql *QueryLimiter) uniqueSeriesCount() int {
 	ql.uniqueSeriesMx.Lock()
@@ -1,6 +1,7 @@
 package limiter
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
@@ -190,3 +191,11 @@ func AddSeriesConcurrentBench(b *testing.B, batchSize int) {
 
 	wg.Wait()
 }
+
+func TestMaxChunksPerQueryFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, 100, MaxChunksPerQueryFromContext(ctx, 100))
+
+	ctx = AddMaxChunksPerQueryToContext(ctx, 10)
+	assert.Equal(t, 10, MaxChunksPerQueryFromContext(ctx, 100))
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	requestIDContextKey contextKey = 1
+
+	// RequestIDHeaderName is the HTTP header carrying the request ID, accepted from the clients
+	// and returned in the responses.
+	RequestIDHeaderName = "X-Request-ID"
+
+	// RequestIDMetadataKey is the gRPC metadata key propagating the request ID across the components.
+	RequestIDMetadataKey = "x-cortex-request-id"
+
+	maxRequestIDLength = 128
+)
+
+// NewRequestID generates a request ID.
+func NewRequestID() string {
+	return ulid.MustNew(ulid.Timestamp(time.Now()), rand.Reader).String()
+}
+
+// IsValidRequestID returns whether the request ID given by a client can be used as is: it must
+// be short, and made of printable ASCII characters only, as it ends up in the logs.
+func IsValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' || id[i] == '"' || id[i] == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// ContextWithRequestID returns a context carrying the request ID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// RequestIDFromContext returns the request ID carried by the context, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// ContextWithRequestIDFromMetadata returns a context carrying the request ID propagated in the gRPC metadata, if any.
+func ContextWithRequestIDFromMetadata(ctx context.Context, md metadata.MD) context.Context {
+	ids := md.Get(RequestIDMetadataKey)
+	if len(ids) == 0 || !IsValidRequestID(ids[0]) {
+		return ctx
+	}
+	return ContextWithRequestID(ctx, ids[0])
+}
+
+// InjectRequestIDIntoHTTPRequest sets the request ID from the context into the request headers.
+func InjectRequestIDIntoHTTPRequest(ctx context.Context, request *http.Request) {
+	if id := RequestIDFromContext(ctx); id != "" {
+		request.Header.Set(RequestIDHeaderName, id)
+	}
+}
+
+// WithRequestID returns a Logger that has information about the request ID in
+// its details.
+func WithRequestID(requestID string, l log.Logger) log.Logger {
+	return log.With(l, "request_id", requestID)
+}
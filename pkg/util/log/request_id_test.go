@@ -0,0 +1,28 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestIsValidRequestID(t *testing.T) {
+	require.True(t, IsValidRequestID(NewRequestID()))
+	require.True(t, IsValidRequestID("0b5a1f3c-7d4e-4c8e-9f2a-1b3c5d7e9f11"))
+	require.False(t, IsValidRequestID(""))
+	require.False(t, IsValidRequestID("request id"))
+	require.False(t, IsValidRequestID("request\nid"))
+	require.False(t, IsValidRequestID(`request"id`))
+	require.False(t, IsValidRequestID(strings.Repeat("a", maxRequestIDLength+1)))
+}
+
+func TestContextWithRequestIDFromMetadata(t *testing.T) {
+	ctx := ContextWithRequestIDFromMetadata(context.Background(), metadata.Pairs(RequestIDMetadataKey, "request-1"))
+	require.Equal(t, "request-1", RequestIDFromContext(ctx))
+
+	ctx = ContextWithRequestIDFromMetadata(context.Background(), metadata.Pairs(RequestIDMetadataKey, "request 1"))
+	require.Empty(t, RequestIDFromContext(ctx))
+}
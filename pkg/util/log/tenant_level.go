@@ -0,0 +1,79 @@
+package log
+
+import (
+	"sync/atomic"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// tenantLogLevel returns the log level of a tenant, see SetTenantLogLevel.
+var tenantLogLevel atomic.Pointer[func(userID string) string]
+
+// SetTenantLogLevel sets the function returning the log level of the log lines of a tenant, used
+// instead of the -log.level when more verbose, or an empty string to use the -log.level.
+func SetTenantLogLevel(f func(userID string) string) {
+	tenantLogLevel.Store(&f)
+}
+
+// tenantLevelFilter filters the log lines by level, like level.NewFilter, but lets the log lines
+// of a tenant through at the level returned for the tenant by the SetTenantLogLevel function.
+type tenantLevelFilter struct {
+	next        log.Logger
+	minPriority int
+}
+
+func newTenantLevelFilter(next log.Logger, logLevel string) log.Logger {
+	return &tenantLevelFilter{
+		next:        next,
+		minPriority: max(levelPriority(logLevel), 0),
+	}
+}
+
+func (f *tenantLevelFilter) Log(keyvals ...interface{}) error {
+	priority, userID := -1, ""
+	for i := 1; i < len(keyvals); i += 2 {
+		switch keyvals[i-1] {
+		case level.Key():
+			if v, ok := keyvals[i].(level.Value); ok {
+				priority = levelPriority(v.String())
+			}
+		case "org_id", "user":
+			userID, _ = keyvals[i].(string)
+		}
+	}
+
+	// Like level.NewFilter, the log lines without level are never filtered.
+	if priority >= 0 && priority < f.minPriority && !allowedForTenant(priority, userID) {
+		return nil
+	}
+	return f.next.Log(keyvals...)
+}
+
+func allowedForTenant(priority int, userID string) bool {
+	if userID == "" {
+		return false
+	}
+	f := tenantLogLevel.Load()
+	if f == nil {
+		return false
+	}
+	tenantPriority := levelPriority((*f)(userID))
+	return tenantPriority >= 0 && priority >= tenantPriority
+}
+
+// levelPriority returns the priority of the level, or -1 for an unknown level.
+func levelPriority(l string) int {
+	switch l {
+	case "debug":
+		return 0
+	case "info":
+		return 1
+	case "warn":
+		return 2
+	case "error":
+		return 3
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantLevelFilter(t *testing.T) {
+	t.Cleanup(func() { tenantLogLevel.Store(nil) })
+
+	buf := &bytes.Buffer{}
+	logger := newTenantLevelFilter(log.NewLogfmtLogger(buf), "info")
+
+	level.Debug(logger).Log("msg", "before override", "org_id", "user-1")
+	level.Info(logger).Log("msg", "info", "org_id", "user-2")
+	logger.Log("msg", "no level")
+
+	SetTenantLogLevel(func(userID string) string {
+		if userID == "user-1" {
+			return "debug"
+		}
+		return ""
+	})
+
+	level.Debug(log.With(logger, "org_id", "user-1")).Log("msg", "debug of user-1")
+	level.Debug(logger).Log("msg", "debug of user-1 by user field", "user", "user-1")
+	level.Debug(logger).Log("msg", "debug of user-2", "org_id", "user-2")
+	level.Debug(logger).Log("msg", "debug without tenant")
+
+	assert.Equal(t, []string{
+		"level=info msg=info org_id=user-2",
+		"msg=\"no level\"",
+		"level=debug org_id=user-1 msg=\"debug of user-1\"",
+		"level=debug msg=\"debug of user-1 by user field\" user=user-1",
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
@@ -35,6 +35,10 @@ func WithTraceID(traceID string, l log.Logger) log.Logger {
 func WithContext(ctx context.Context, l log.Logger) log.Logger {
 	l = headersFromContext(ctx, l)
 
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		l = WithRequestID(requestID, l)
+	}
+
 	// Weaveworks uses "orgs" and "orgID" to represent Cortex users,
 	// even though the code-base generally uses `userID` to refer to the same thing.
 	userID, err := tenant.TenantID(ctx)
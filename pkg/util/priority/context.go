@@ -0,0 +1,42 @@
+// Package priority propagates the priority of the queries to the components serving them, and
+// schedules their work by priority.
+package priority
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataKey is the gRPC metadata key propagating the priority of a query to the
+// ingesters and the store-gateways.
+const MetadataKey = "x-cortex-query-priority"
+
+type contextKey struct{}
+
+// ContextWithPriority returns a context carrying the priority of the query.
+func ContextWithPriority(ctx context.Context, priority int64) context.Context {
+	return context.WithValue(ctx, contextKey{}, priority)
+}
+
+// FromContext returns the priority of the query carried by the context, and whether
+// the context carries one.
+func FromContext(ctx context.Context) (int64, bool) {
+	priority, ok := ctx.Value(contextKey{}).(int64)
+	return priority, ok
+}
+
+// ContextWithPriorityFromMetadata returns a context carrying the priority of the query
+// propagated in the gRPC metadata, if any.
+func ContextWithPriorityFromMetadata(ctx context.Context, md metadata.MD) context.Context {
+	values := md.Get(MetadataKey)
+	if len(values) == 0 {
+		return ctx
+	}
+	priority, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return ctx
+	}
+	return ContextWithPriority(ctx, priority)
+}
@@ -0,0 +1,162 @@
+package priority
+
+import (
+	"container/heap"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/thanos/pkg/gate"
+)
+
+// Gate limits the number of concurrent operations. The waiting operations are admitted by
+// decreasing priority of the query they're run for, then in arrival order. The operations
+// of the queries without a priority have the priority 0.
+type Gate struct {
+	maxConcurrent int
+	next          gate.Gate
+
+	mtx      sync.Mutex
+	inflight int
+	waiting  waiters
+	seq      uint64
+
+	queueLength  *prometheus.GaugeVec
+	waitDuration *prometheus.HistogramVec
+}
+
+// NewGate makes a new Gate admitting up to maxConcurrent operations, which then start on the
+// next gate. The metrics are registered with the given prefix.
+func NewGate(maxConcurrent int, next gate.Gate, prefix string, reg prometheus.Registerer) *Gate {
+	return &Gate{
+		maxConcurrent: maxConcurrent,
+		next:          next,
+
+		queueLength: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: prefix + "priority_queue_length",
+			Help: "Number of operations waiting to be admitted, per query priority.",
+		}, []string{"priority"}),
+		waitDuration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    prefix + "priority_queue_wait_duration_seconds",
+			Help:    "Time spent by the operations waiting to be admitted, per query priority.",
+			Buckets: []float64{0.01, 0.1, 0.3, 0.6, 1, 3, 6, 9, 20, 30, 60, 90, 120, 240, 360, 720},
+		}, []string{"priority"}),
+	}
+}
+
+// Start waits until the operation is admitted, or the context is done.
+func (g *Gate) Start(ctx context.Context) error {
+	p, _ := FromContext(ctx)
+	label := strconv.FormatInt(p, 10)
+	start := time.Now()
+
+	g.mtx.Lock()
+	if g.inflight < g.maxConcurrent && len(g.waiting) == 0 {
+		g.inflight++
+		g.mtx.Unlock()
+		g.waitDuration.WithLabelValues(label).Observe(0)
+		return g.startNext(ctx)
+	}
+
+	w := &waiter{priority: p, seq: g.seq, label: label, admitted: make(chan struct{})}
+	g.seq++
+	heap.Push(&g.waiting, w)
+	g.queueLength.WithLabelValues(label).Inc()
+	g.mtx.Unlock()
+
+	select {
+	case <-w.admitted:
+		g.waitDuration.WithLabelValues(label).Observe(time.Since(start).Seconds())
+		return g.startNext(ctx)
+
+	case <-ctx.Done():
+		g.mtx.Lock()
+		if w.index >= 0 {
+			heap.Remove(&g.waiting, w.index)
+			g.queueLength.WithLabelValues(label).Dec()
+			g.mtx.Unlock()
+			return ctx.Err()
+		}
+		g.mtx.Unlock()
+
+		// The operation has been admitted in the meanwhile: let the next one in.
+		g.release()
+		return ctx.Err()
+	}
+}
+
+func (g *Gate) startNext(ctx context.Context) error {
+	if err := g.next.Start(ctx); err != nil {
+		g.release()
+		return err
+	}
+	return nil
+}
+
+// Done completes an operation admitted by Start.
+func (g *Gate) Done() {
+	g.next.Done()
+	g.release()
+}
+
+// release admits the waiting operation of highest priority, if any, in place of a completed one.
+func (g *Gate) release() {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if len(g.waiting) == 0 {
+		g.inflight--
+		return
+	}
+
+	w := heap.Pop(&g.waiting).(*waiter)
+	g.queueLength.WithLabelValues(w.label).Dec()
+	close(w.admitted)
+}
+
+type waiter struct {
+	priority int64
+	seq      uint64
+	label    string
+	admitted chan struct{}
+
+	// Index in the heap, -1 once admitted.
+	index int
+}
+
+// waiters is a heap of the waiting operations, the one of highest priority first.
+type waiters []*waiter
+
+func (w waiters) Len() int { return len(w) }
+
+func (w waiters) Less(i, j int) bool {
+	if w[i].priority != w[j].priority {
+		return w[i].priority > w[j].priority
+	}
+	return w[i].seq < w[j].seq
+}
+
+func (w waiters) Swap(i, j int) {
+	w[i], w[j] = w[j], w[i]
+	w[i].index = i
+	w[j].index = j
+}
+
+func (w *waiters) Push(x interface{}) {
+	item := x.(*waiter)
+	item.index = len(*w)
+	*w = append(*w, item)
+}
+
+func (w *waiters) Pop() interface{} {
+	old := *w
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*w = old[:n-1]
+	return item
+}
@@ -0,0 +1,81 @@
+package priority
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/gate"
+)
+
+func TestGate_ShouldAdmitWaitingOperationsByPriority(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	g := NewGate(1, gate.NewNoop(), "test_", reg)
+	ctx := context.Background()
+
+	require.NoError(t, g.Start(ctx))
+
+	admitted := make(chan int64, 4)
+	for _, p := range []int64{0, 5, 1, 5} {
+		p := p
+		queued := g.queued()
+
+		go func() {
+			if err := g.Start(ContextWithPriority(ctx, p)); err == nil {
+				admitted <- p
+			}
+		}()
+
+		// Wait until queued, to get a deterministic arrival order.
+		require.Eventually(t, func() bool {
+			return g.queued() == queued+1
+		}, time.Second, time.Millisecond)
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP test_priority_queue_length Number of operations waiting to be admitted, per query priority.
+		# TYPE test_priority_queue_length gauge
+		test_priority_queue_length{priority="0"} 1
+		test_priority_queue_length{priority="1"} 1
+		test_priority_queue_length{priority="5"} 2
+	`), "test_priority_queue_length"))
+
+	var order []int64
+	for i := 0; i < 4; i++ {
+		g.Done()
+		order = append(order, <-admitted)
+	}
+	assert.Equal(t, []int64{5, 5, 1, 0}, order)
+
+	g.Done()
+	assert.Equal(t, 0, g.inflight)
+}
+
+func TestGate_ShouldRemoveWaitingOperationWhenContextIsDone(t *testing.T) {
+	g := NewGate(1, gate.NewNoop(), "test_", prometheus.NewPedanticRegistry())
+
+	require.NoError(t, g.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, g.Start(ctx), context.DeadlineExceeded)
+	assert.Len(t, g.waiting, 0)
+	assert.Equal(t, float64(0), testutil.ToFloat64(g.queueLength.WithLabelValues("0")))
+
+	// The gate is released once the admitted operation is done.
+	g.Done()
+	require.NoError(t, g.Start(context.Background()))
+	g.Done()
+	assert.Equal(t, 0, g.inflight)
+}
+
+func (g *Gate) queued() int {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return len(g.waiting)
+}
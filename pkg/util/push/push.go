@@ -3,12 +3,14 @@ package push
 import (
 	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/go-kit/log/level"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/middleware"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/log"
 )
@@ -16,8 +18,9 @@ import (
 // Func defines the type of the push. It is similar to http.HandlerFunc.
 type Func func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
 
-// Handler is a http.Handler which accepts WriteRequests.
-func Handler(maxRecvMsgSize int, sourceIPs *middleware.SourceIPExtractor, push Func) http.Handler {
+// Handler is a http.Handler which accepts WriteRequests. The requests exceeding the decoding limits
+// are rejected before being decoded.
+func Handler(maxRecvMsgSize int, decodingLimits cortexpb.DecodingLimits, sourceIPs *middleware.SourceIPExtractor, push Func) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger := log.WithContext(ctx, log.Logger)
@@ -28,7 +31,7 @@ func Handler(maxRecvMsgSize int, sourceIPs *middleware.SourceIPExtractor, push F
 				logger = log.WithSourceIPs(source, logger)
 			}
 		}
-		var req cortexpb.PreallocWriteRequest
+		req := cortexpb.PreallocWriteRequest{DecodingLimits: decodingLimits}
 		err := util.ParseProtoReader(ctx, r.Body, int(r.ContentLength), maxRecvMsgSize, &req, util.RawSnappy)
 		if err != nil {
 			level.Error(logger).Log("err", err.Error())
@@ -37,11 +40,20 @@ func Handler(maxRecvMsgSize int, sourceIPs *middleware.SourceIPExtractor, push F
 		}
 
 		req.SkipLabelNameValidation = false
+		req.Ephemeral = r.Header.Get(util.EphemeralSeriesHeaderKey) == "true"
 		if req.Source == 0 {
 			req.Source = cortexpb.API
 		}
 
-		if _, err := push(ctx, &req.WriteRequest); err != nil {
+		if key := r.Header.Get(util.IdempotencyKeyHeaderKey); key != "" {
+			ctx = util.ContextWithIdempotencyKey(ctx, key)
+		}
+
+		writeResp, err := push(ctx, &req.WriteRequest)
+		if factor := writeResp.GetSamplingFactor(); factor > 1 {
+			w.Header().Set(util.IngestionSamplingFactorHeaderKey, strconv.Itoa(int(factor)))
+		}
+		if err != nil {
 			resp, ok := httpgrpc.HTTPResponseFromError(err)
 			if !ok {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -56,3 +68,36 @@ func Handler(maxRecvMsgSize int, sourceIPs *middleware.SourceIPExtractor, push F
 		}
 	})
 }
+
+// PreAggregatedHandler is a http.Handler which accepts WriteRequests of series pre-aggregated
+// at the resolution given by the "resolution" URL parameter (eg. 5m). The resolution is attached
+// to every series via the reserved resolution label, while each series is expected to carry the
+// aggregation label. The distributor rejects such series unless the tenant is allowed to push them.
+func PreAggregatedHandler(maxRecvMsgSize int, decodingLimits cortexpb.DecodingLimits, sourceIPs *middleware.SourceIPExtractor, push Func) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resolution, err := downsample.ParseResolution(r.URL.Query().Get("resolution"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		value := strconv.FormatInt(resolution, 10)
+
+		Handler(maxRecvMsgSize, decodingLimits, sourceIPs, func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+			for _, ts := range req.Timeseries {
+				ts.Labels = setLabel(ts.Labels, downsample.ResolutionLabel, value)
+			}
+			return push(ctx, req)
+		}).ServeHTTP(w, r)
+	})
+}
+
+// setLabel sets the label value, overriding the existing one if any.
+func setLabel(ls []cortexpb.LabelAdapter, name, value string) []cortexpb.LabelAdapter {
+	for i := range ls {
+		if ls[i].Name == name {
+			ls[i].Value = value
+			return ls
+		}
+	}
+	return append(ls, cortexpb.LabelAdapter{Name: name, Value: value})
+}
@@ -15,12 +15,13 @@ import (
 	"github.com/weaveworks/common/middleware"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util"
 )
 
 func TestHandler_remoteWrite(t *testing.T) {
 	req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
 	resp := httptest.NewRecorder()
-	handler := Handler(100000, nil, verifyWriteRequestHandler(t, cortexpb.API))
+	handler := Handler(100000, cortexpb.DecodingLimits{}, nil, verifyWriteRequestHandler(t, cortexpb.API))
 	handler.ServeHTTP(resp, req)
 	assert.Equal(t, 200, resp.Code)
 }
@@ -29,7 +30,7 @@ func TestHandler_cortexWriteRequest(t *testing.T) {
 	req := createRequest(t, createCortexWriteRequestProtobuf(t, false))
 	resp := httptest.NewRecorder()
 	sourceIPs, _ := middleware.NewSourceIPs("SomeField", "(.*)")
-	handler := Handler(100000, sourceIPs, verifyWriteRequestHandler(t, cortexpb.RULE))
+	handler := Handler(100000, cortexpb.DecodingLimits{}, sourceIPs, verifyWriteRequestHandler(t, cortexpb.RULE))
 	handler.ServeHTTP(resp, req)
 	assert.Equal(t, 200, resp.Code)
 }
@@ -40,12 +41,117 @@ func TestHandler_ignoresSkipLabelNameValidationIfSet(t *testing.T) {
 		createRequest(t, createCortexWriteRequestProtobuf(t, false)),
 	} {
 		resp := httptest.NewRecorder()
-		handler := Handler(100000, nil, verifyWriteRequestHandler(t, cortexpb.RULE))
+		handler := Handler(100000, cortexpb.DecodingLimits{}, nil, verifyWriteRequestHandler(t, cortexpb.RULE))
 		handler.ServeHTTP(resp, req)
 		assert.Equal(t, 200, resp.Code)
 	}
 }
 
+func TestHandler_ShouldSetTheSamplingFactorHeader(t *testing.T) {
+	for _, factor := range []int32{0, 10} {
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
+		resp := httptest.NewRecorder()
+		handler := Handler(100000, cortexpb.DecodingLimits{}, nil, func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+			return &cortexpb.WriteResponse{SamplingFactor: factor}, nil
+		})
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, 200, resp.Code)
+
+		expected := ""
+		if factor > 0 {
+			expected = "10"
+		}
+		assert.Equal(t, expected, resp.Header().Get("X-Cortex-Ingestion-Sampling-Factor"))
+	}
+}
+
+func TestHandler_ShouldMarkEphemeralSeriesFromHeader(t *testing.T) {
+	for header, expected := range map[string]bool{"": false, "false": false, "true": true} {
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
+		if header != "" {
+			req.Header.Set("X-Cortex-Ephemeral", header)
+		}
+		resp := httptest.NewRecorder()
+		handler := Handler(100000, cortexpb.DecodingLimits{}, nil, func(_ context.Context, request *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+			assert.Equal(t, expected, request.Ephemeral)
+			return &cortexpb.WriteResponse{}, nil
+		})
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, 200, resp.Code)
+	}
+}
+
+func TestHandler_ShouldPropagateTheIdempotencyKey(t *testing.T) {
+	for _, key := range []string{"", "batch-1"} {
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		resp := httptest.NewRecorder()
+		handler := Handler(100000, cortexpb.DecodingLimits{}, nil, func(ctx context.Context, _ *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+			assert.Equal(t, key, util.IdempotencyKeyFromContext(ctx))
+			return &cortexpb.WriteResponse{}, nil
+		})
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, 200, resp.Code)
+	}
+}
+
+func TestHandler_ShouldRejectRequestsExceedingTheDecodingLimits(t *testing.T) {
+	limits := cortexpb.DecodingLimits{MaxSeriesPerRequest: 1, MaxLabelsPerSeries: 1}
+
+	// The requests within the limits are accepted.
+	resp := httptest.NewRecorder()
+	Handler(100000, limits, nil, verifyWriteRequestHandler(t, cortexpb.API)).ServeHTTP(resp, createRequest(t, createPrometheusRemoteWriteProtobuf(t)))
+	assert.Equal(t, 200, resp.Code)
+
+	input := prompb.WriteRequest{Timeseries: []prompb.TimeSeries{{
+		Labels: []prompb.Label{{Name: "__name__", Value: "foo"}, {Name: "job", Value: "bar"}},
+	}}}
+	protobuf, err := input.Marshal()
+	require.NoError(t, err)
+
+	resp = httptest.NewRecorder()
+	Handler(100000, limits, nil, func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+		t.Fatal("the request exceeding the decoding limits shouldn't be pushed")
+		return nil, nil
+	}).ServeHTTP(resp, createRequest(t, protobuf))
+	assert.Equal(t, 400, resp.Code)
+	assert.Contains(t, resp.Body.String(), "the write request exceeds the limit of 1 labels per series")
+}
+
+func TestPreAggregatedHandler(t *testing.T) {
+	t.Run("should attach the resolution label to every series", func(t *testing.T) {
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
+		req.URL.RawQuery = "resolution=5m"
+		resp := httptest.NewRecorder()
+
+		handler := PreAggregatedHandler(100000, cortexpb.DecodingLimits{}, nil, func(ctx context.Context, request *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+			require.Len(t, request.Timeseries, 1)
+			assert.Equal(t, []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "__resolution__", Value: "300000"},
+			}, request.Timeseries[0].Labels)
+			return &cortexpb.WriteResponse{}, nil
+		})
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, 200, resp.Code)
+	})
+
+	t.Run("should reject unsupported resolutions", func(t *testing.T) {
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
+		req.URL.RawQuery = "resolution=7m"
+		resp := httptest.NewRecorder()
+
+		handler := PreAggregatedHandler(100000, cortexpb.DecodingLimits{}, nil, func(ctx context.Context, request *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+			t.Fatal("push should not be called")
+			return nil, nil
+		})
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, 400, resp.Code)
+	})
+}
+
 func verifyWriteRequestHandler(t *testing.T, expectSource cortexpb.WriteRequest_SourceEnum) func(ctx context.Context, request *cortexpb.WriteRequest) (response *cortexpb.WriteResponse, err error) {
 	t.Helper()
 	return func(ctx context.Context, request *cortexpb.WriteRequest) (response *cortexpb.WriteResponse, err error) {
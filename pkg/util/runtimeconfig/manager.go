@@ -7,6 +7,8 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,22 +28,33 @@ type BucketClientFactory func(ctx context.Context) (objstore.Bucket, error)
 // Loader loads the configuration from file.
 type Loader func(r io.Reader) (interface{}, error)
 
+// Validator checks the configuration returned by the Loader. A configuration failing
+// the validation is rejected, and the previous one is kept.
+type Validator func(config interface{}) error
+
 // Config holds the config for an Manager instance.
 // It holds config related to loading per-tenant config.
 type Config struct {
 	ReloadPeriod time.Duration `yaml:"period"`
 	// LoadPath contains the path to the runtime config file, requires an
-	// non-empty value
-	LoadPath string `yaml:"file"`
-	Loader   Loader `yaml:"-"`
+	// non-empty value. If it is an HTTP(S) URL, the file is fetched from it
+	// instead of the storage backend.
+	LoadPath   string      `yaml:"file"`
+	Loader     Loader      `yaml:"-"`
+	Validators []Validator `yaml:"-"`
+
+	HTTPTimeout      time.Duration `yaml:"http_timeout"`
+	HTTPMaxBodyBytes int64         `yaml:"http_max_body_bytes"`
 
 	StorageConfig bucket.Config `yaml:",inline"`
 }
 
 // RegisterFlags registers flags.
 func (mc *Config) RegisterFlags(f *flag.FlagSet) {
-	f.StringVar(&mc.LoadPath, "runtime-config.file", "", "File with the configuration that can be updated in runtime.")
+	f.StringVar(&mc.LoadPath, "runtime-config.file", "", "File with the configuration that can be updated in runtime. If it is an HTTP or HTTPS URL, the file is fetched from it, and only reloaded when its ETag changes.")
 	f.DurationVar(&mc.ReloadPeriod, "runtime-config.reload-period", 10*time.Second, "How often to check runtime config file.")
+	f.DurationVar(&mc.HTTPTimeout, "runtime-config.http-timeout", 10*time.Second, "Timeout of the requests fetching the runtime config file, when it is an HTTP or HTTPS URL.")
+	f.Int64Var(&mc.HTTPMaxBodyBytes, "runtime-config.http-max-body-bytes", 10<<20, "Max size of the runtime config file, when it is an HTTP or HTTPS URL. The reloads fetching a larger file fail, keeping the previous config. 0 to disable the limit.")
 
 	mc.StorageConfig.RegisterFlagsWithPrefixAndBackend("runtime-config.", f, bucket.Filesystem)
 }
@@ -65,6 +78,9 @@ type Manager struct {
 
 	bucketClient        objstore.Bucket
 	bucketClientFactory BucketClientFactory
+
+	httpClient *http.Client
+	etag       string // ETag of the runtime config file last fetched over HTTP.
 }
 
 // New creates an instance of Manager and starts reload config loop based on config
@@ -73,7 +89,7 @@ func New(cfg Config, registerer prometheus.Registerer, logger log.Logger, factor
 		return nil, errors.New("LoadPath is empty")
 	}
 
-	if cfg.StorageConfig.Backend == "" {
+	if !cfg.isHTTP() && cfg.StorageConfig.Backend == "" {
 		return nil, errors.New("Backend should not be explicitly empty")
 	}
 
@@ -89,6 +105,7 @@ func New(cfg Config, registerer prometheus.Registerer, logger log.Logger, factor
 		}, []string{"sha256"}),
 		logger:              logger,
 		bucketClientFactory: factory,
+		httpClient:          &http.Client{Timeout: cfg.HTTPTimeout},
 	}
 
 	mgr.Service = services.NewBasicService(mgr.starting, mgr.loop, mgr.stopping)
@@ -100,10 +117,12 @@ func (om *Manager) starting(ctx context.Context) error {
 		return nil
 	}
 
-	var err error
-	om.bucketClient, err = om.bucketClientFactory(ctx)
-	if err != nil {
-		return err
+	if !om.cfg.isHTTP() {
+		var err error
+		om.bucketClient, err = om.bucketClientFactory(ctx)
+		if err != nil {
+			return err
+		}
 	}
 
 	return errors.Wrap(om.loadConfig(ctx), "failed to load runtime config")
@@ -166,12 +185,26 @@ func (om *Manager) loop(ctx context.Context) error {
 // loadConfig loads configuration using the loader function, and if successful,
 // stores it as current configuration and notifies listeners.
 func (om *Manager) loadConfig(ctx context.Context) error {
-	buf, err := om.loadConfigFromBucket(ctx)
+	var (
+		buf  []byte
+		etag string
+		err  error
+	)
+	if om.cfg.isHTTP() {
+		buf, etag, err = om.loadConfigFromHTTP(ctx)
+	} else {
+		buf, err = om.loadConfigFromBucket(ctx)
+	}
 
 	if err != nil {
 		om.configLoadSuccess.Set(0)
 		return errors.Wrap(err, "read file")
 	}
+	if buf == nil {
+		// The file fetched over HTTP didn't change since the last reload.
+		om.configLoadSuccess.Set(1)
+		return nil
+	}
 	hash := sha256.Sum256(buf)
 
 	cfg, err := om.cfg.Loader(bytes.NewReader(buf))
@@ -179,7 +212,16 @@ func (om *Manager) loadConfig(ctx context.Context) error {
 		om.configLoadSuccess.Set(0)
 		return errors.Wrap(err, "load file")
 	}
+	for _, validate := range om.cfg.Validators {
+		if err := validate(cfg); err != nil {
+			om.configLoadSuccess.Set(0)
+			return errors.Wrap(err, "validate file")
+		}
+	}
 	om.configLoadSuccess.Set(1)
+	// The ETag is only kept once the file is applied, so that an invalid file is fetched
+	// again on the next reload.
+	om.etag = etag
 
 	om.setConfig(cfg)
 	om.callListeners(cfg)
@@ -205,6 +247,49 @@ func (om *Manager) loadConfigFromBucket(ctx context.Context) ([]byte, error) {
 	return buf, err
 }
 
+// loadConfigFromHTTP fetches the runtime config file from its URL. The request is conditional on
+// the ETag of the file last applied: if it didn't change, a nil buffer is returned.
+func (om *Manager) loadConfigFromHTTP(ctx context.Context) (buf []byte, etag string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, om.cfg.LoadPath, nil)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "create request")
+	}
+	if om.etag != "" {
+		req.Header.Set("If-None-Match", om.etag)
+	}
+
+	resp, err := om.httpClient.Do(req)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "fetch file")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, "", nil
+	case http.StatusOK:
+	default:
+		return nil, "", fmt.Errorf("fetch file: unexpected status code %d", resp.StatusCode)
+	}
+
+	body := io.Reader(resp.Body)
+	if om.cfg.HTTPMaxBodyBytes > 0 {
+		// One more byte than the limit is read, to tell apart the files exceeding it.
+		body = io.LimitReader(resp.Body, om.cfg.HTTPMaxBodyBytes+1)
+	}
+	buf, err = io.ReadAll(body)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "read entire file")
+	}
+	if om.cfg.HTTPMaxBodyBytes > 0 && int64(len(buf)) > om.cfg.HTTPMaxBodyBytes {
+		return nil, "", fmt.Errorf("read entire file: the file exceeds the max size of %d bytes", om.cfg.HTTPMaxBodyBytes)
+	}
+	if buf == nil {
+		buf = []byte{}
+	}
+	return buf, resp.Header.Get("ETag"), nil
+}
+
 func (om *Manager) setConfig(config interface{}) {
 	om.configMtx.Lock()
 	defer om.configMtx.Unlock()
@@ -244,3 +329,7 @@ func (om *Manager) GetConfig() interface{} {
 
 	return om.config
 }
+
+func (cfg *Config) isHTTP() bool {
+	return strings.HasPrefix(cfg.LoadPath, "http://") || strings.HasPrefix(cfg.LoadPath, "https://")
+}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -348,6 +351,164 @@ func TestManager_GetsRuntimeConfigFromBackendStore(t *testing.T) {
 	bucketClient.AssertExpectations(t)
 }
 
+func TestManager_ShouldRejectConfigFailingValidation(t *testing.T) {
+	config1 := []byte(`overrides:
+  user1:
+    limit2: 150`)
+	config2 := []byte(`overrides:
+  user1:
+    limit2: -1`)
+
+	defaultTestLimits = &TestLimits{Limit1: 100}
+
+	cfg := Config{
+		ReloadPeriod: time.Second,
+		LoadPath:     "runtime-config",
+		Loader:       testLoadOverrides,
+		Validators: []Validator{func(config interface{}) error {
+			for _, l := range config.(*testOverrides).Overrides {
+				if l.Limit2 < 0 {
+					return errors.New("negative limit2")
+				}
+			}
+			return nil
+		}},
+		StorageConfig: bucket.Config{Backend: bucket.Filesystem},
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	manager, err := New(cfg, reg, log.NewNopLogger(), mockBucketClientFactory(config1, config2))
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), manager))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), manager))
+	})
+
+	ch := manager.CreateListenerChannel(1)
+
+	// The invalid config is rejected, and the previous one is kept.
+	require.EqualError(t, manager.loadConfig(context.Background()), "validate file: negative limit2")
+	require.Equal(t, 150, manager.GetConfig().(*testOverrides).Overrides["user1"].Limit2)
+	require.Len(t, ch, 0)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+					# HELP runtime_config_hash Hash of the currently active runtime config file.
+					# TYPE runtime_config_hash gauge
+					runtime_config_hash{sha256="%s"} 1
+					# HELP runtime_config_last_reload_successful Whether the last runtime-config reload attempt was successful.
+					# TYPE runtime_config_last_reload_successful gauge
+					runtime_config_last_reload_successful 0
+				`, fmt.Sprintf("%x", sha256.Sum256(config1))))))
+}
+
+func TestManager_GetsRuntimeConfigFromHTTP(t *testing.T) {
+	config1 := `overrides:
+  user1:
+    limit2: 150`
+	config2 := `overrides:
+  user1:
+    limit2: 200`
+
+	var (
+		config  = atomic.NewString(config1)
+		fetches = atomic.NewInt32(0)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := config.Load()
+		etag := fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(body)))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetches.Inc()
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	defaultTestLimits = &TestLimits{Limit1: 100}
+
+	// The bucket client is not used for an HTTP URL.
+	cfg := Config{
+		ReloadPeriod: time.Second,
+		LoadPath:     server.URL + "/runtime-config.yaml",
+		Loader:       testLoadOverrides,
+		HTTPTimeout:  time.Second,
+	}
+	manager, err := New(cfg, nil, log.NewNopLogger(), func(ctx context.Context) (objstore.Bucket, error) {
+		return nil, errors.New("unexpected bucket client")
+	})
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), manager))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), manager))
+	})
+	require.Equal(t, 150, manager.GetConfig().(*testOverrides).Overrides["user1"].Limit2)
+
+	ch := manager.CreateListenerChannel(1)
+
+	// The unchanged file is not fetched nor applied again.
+	require.NoError(t, manager.loadConfig(context.Background()))
+	require.Equal(t, int32(1), fetches.Load())
+	require.Len(t, ch, 0)
+
+	config.Store(config2)
+	require.NoError(t, manager.loadConfig(context.Background()))
+	require.Equal(t, int32(2), fetches.Load())
+	require.Equal(t, 200, manager.GetConfig().(*testOverrides).Overrides["user1"].Limit2)
+	require.Len(t, ch, 1)
+}
+
+func TestManager_ShouldFailOnUnexpectedHTTPStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := Config{
+		ReloadPeriod: time.Second,
+		LoadPath:     server.URL,
+		Loader:       testLoadOverrides,
+	}
+	manager, err := New(cfg, nil, log.NewNopLogger(), mockBucketClientFactory())
+	require.NoError(t, err)
+	require.ErrorContains(t, services.StartAndAwaitRunning(context.Background(), manager), "unexpected status code 403")
+}
+
+func TestManager_ShouldRejectHTTPConfigExceedingMaxBodyBytes(t *testing.T) {
+	config := `overrides:
+  user1:
+    limit2: 150`
+	body := atomic.NewString(config)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body.Load()))
+	}))
+	t.Cleanup(server.Close)
+
+	defaultTestLimits = &TestLimits{Limit1: 100}
+
+	cfg := Config{
+		ReloadPeriod:     time.Second,
+		LoadPath:         server.URL,
+		Loader:           testLoadOverrides,
+		HTTPMaxBodyBytes: int64(len(config)),
+	}
+	manager, err := New(cfg, nil, log.NewNopLogger(), mockBucketClientFactory())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), manager))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), manager))
+	})
+	require.Equal(t, 150, manager.GetConfig().(*testOverrides).Overrides["user1"].Limit2)
+
+	// The reload fails, keeping the previous config.
+	body.Store(`overrides:
+  user1:
+    limit2: 2000`)
+	require.ErrorContains(t, manager.loadConfig(context.Background()), "exceeds the max size")
+	require.Equal(t, 150, manager.GetConfig().(*testOverrides).Overrides["user1"].Limit2)
+}
+
 func mockBucketClientFactory(configs ...[]byte) BucketClientFactory {
 	return func(ctx context.Context) (objstore.Bucket, error) {
 		return createMockBucketClient(configs...), nil
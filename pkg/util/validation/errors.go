@@ -8,6 +8,31 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
+)
+
+// The formats of the messages of the validation errors, registered with the codes of the errors.
+var (
+	errInvalidLabel              = globalerror.InvalidLabel.Message("sample invalid label: %.200q metric %.200q")
+	errLabelValueInvalidUTF8     = globalerror.LabelValueInvalidUTF8.Message("label value not valid UTF-8 for label: %.200q metric %.200q")
+	errDuplicateLabelNames       = globalerror.DuplicateLabelNames.Message("duplicate label name: %.200q metric %.200q")
+	errLabelsNotSorted           = globalerror.LabelsNotSorted.Message("labels not sorted: %.200q metric %.200q")
+	errPreAggregatedNotAllowed   = globalerror.PreAggregatedNotAllowed.Message("pre-aggregated series not allowed: %.200q metric %.200q")
+	errInvalidPreAggregated      = globalerror.InvalidPreAggregated.Message("invalid pre-aggregated series: %.200q metric %.200q")
+	errLabelNameTooLong          = globalerror.LabelNameTooLong.Message("label name too long for metric (actual: %d, limit: %d) metric: %.200q label name: %.200q")
+	errLabelValueTooLong         = globalerror.LabelValueTooLong.Message("label value too long for metric (actual: %d, limit: %d) metric: %.200q label name: %.200q label value: %.200q")
+	errLabelsSizeBytesExceeded   = globalerror.MaxLabelsSizeBytes.Message("labels size bytes exceeded for metric (actual: %d, limit: %d) metric: %.200q")
+	errLabelSchemaViolation      = globalerror.LabelSchemaViolation.Message("series violates the label schema (rule: %s, label name: %.200q) metric %.200q")
+	errLabelValuesBudgetExceeded = globalerror.LabelValuesBudgetExceeded.Message("series has a new value of the label %.200q, whose number of distinct values exceeds its budget (limit: %d) metric %.200q")
+	errTooManyLabels             = globalerror.MaxLabelNamesPerSeries.Message("series has too many labels (actual: %d, limit: %d) series: '%s'")
+	errNoMetricName              = globalerror.MissingMetricName.Message("sample missing metric name")
+	errInvalidMetricName         = globalerror.InvalidMetricName.Message("sample invalid metric name: %.200q")
+	errSampleTimestampTooOld     = globalerror.SampleTooOld.Message("timestamp too old: %d metric: %.200q")
+	errSampleTimestampTooNew     = globalerror.SampleTooFarInFuture.Message("timestamp too new: %d metric: %.200q")
+	errExemplarEmptyLabels       = globalerror.ExemplarLabelsMissing.Message("exemplar missing labels, timestamp: %d series: %s labels: %s")
+	errExemplarMissingTimestamp  = globalerror.ExemplarTimestampMissing.Message("exemplar missing timestamp, timestamp: %d series: %s labels: %s")
+	errExemplarLabelLength       = globalerror.ExemplarLabelsTooLong.Message("exemplar combined labelset exceeds " + strconv.Itoa(ExemplarMaxLabelSetLength) + " characters, timestamp: %d series: %s labels: %s")
 )
 
 // ValidationError is an error returned by series validation.
@@ -37,7 +62,7 @@ type labelNameTooLongError struct {
 }
 
 func (e *labelNameTooLongError) Error() string {
-	return fmt.Sprintf("label name too long for metric (actual: %d, limit: %d) metric: %.200q label name: %.200q", len(e.labelName), e.limit, formatLabelSet(e.series), e.labelName)
+	return fmt.Sprintf(errLabelNameTooLong, len(e.labelName), e.limit, formatLabelSet(e.series), e.labelName)
 }
 
 func newLabelNameTooLongError(series []cortexpb.LabelAdapter, labelName string, limit int) ValidationError {
@@ -58,7 +83,7 @@ type labelValueTooLongError struct {
 }
 
 func (e *labelValueTooLongError) Error() string {
-	return fmt.Sprintf("label value too long for metric (actual: %d, limit: %d) metric: %.200q label name: %.200q label value: %.200q",
+	return fmt.Sprintf(errLabelValueTooLong,
 		len(e.labelValue), e.limit, formatLabelSet(e.series), e.labelName, e.labelValue)
 }
 
@@ -80,7 +105,7 @@ type labelsSizeBytesExceededError struct {
 }
 
 func (e *labelsSizeBytesExceededError) Error() string {
-	return fmt.Sprintf("labels size bytes exceeded for metric (actual: %d, limit: %d) metric: %.200q", e.labelsSizeBytes, e.limit, formatLabelSet(e.series))
+	return fmt.Sprintf(errLabelsSizeBytesExceeded, e.labelsSizeBytes, e.limit, formatLabelSet(e.series))
 }
 
 func labelSizeBytesExceededError(series []cortexpb.LabelAdapter, labelsSizeBytes int, limit int) ValidationError {
@@ -93,7 +118,15 @@ func labelSizeBytesExceededError(series []cortexpb.LabelAdapter, labelsSizeBytes
 
 func newInvalidLabelError(series []cortexpb.LabelAdapter, labelName string) ValidationError {
 	return &genericValidationError{
-		message: "sample invalid label: %.200q metric %.200q",
+		message: errInvalidLabel,
+		cause:   labelName,
+		series:  series,
+	}
+}
+
+func newLabelValueInvalidUTF8Error(series []cortexpb.LabelAdapter, labelName string) ValidationError {
+	return &genericValidationError{
+		message: errLabelValueInvalidUTF8,
 		cause:   labelName,
 		series:  series,
 	}
@@ -101,7 +134,7 @@ func newInvalidLabelError(series []cortexpb.LabelAdapter, labelName string) Vali
 
 func newDuplicatedLabelError(series []cortexpb.LabelAdapter, labelName string) ValidationError {
 	return &genericValidationError{
-		message: "duplicate label name: %.200q metric %.200q",
+		message: errDuplicateLabelNames,
 		cause:   labelName,
 		series:  series,
 	}
@@ -109,12 +142,70 @@ func newDuplicatedLabelError(series []cortexpb.LabelAdapter, labelName string) V
 
 func newLabelsNotSortedError(series []cortexpb.LabelAdapter, labelName string) ValidationError {
 	return &genericValidationError{
-		message: "labels not sorted: %.200q metric %.200q",
+		message: errLabelsNotSorted,
 		cause:   labelName,
 		series:  series,
 	}
 }
 
+func newPreAggregatedNotAllowedError(series []cortexpb.LabelAdapter) ValidationError {
+	return &genericValidationError{
+		message: errPreAggregatedNotAllowed,
+		cause:   downsample.ResolutionLabel,
+		series:  series,
+	}
+}
+
+func newInvalidPreAggregatedError(series []cortexpb.LabelAdapter, err error) ValidationError {
+	return &genericValidationError{
+		message: errInvalidPreAggregated,
+		cause:   err.Error(),
+		series:  series,
+	}
+}
+
+// labelSchemaViolationError is a customized ValidationError, in that it also reports the
+// violated rule.
+type labelSchemaViolationError struct {
+	rule      string
+	labelName string
+	series    []cortexpb.LabelAdapter
+}
+
+func newLabelSchemaViolationError(series []cortexpb.LabelAdapter, rule, labelName string) ValidationError {
+	return &labelSchemaViolationError{
+		rule:      rule,
+		labelName: labelName,
+		series:    series,
+	}
+}
+
+func (e *labelSchemaViolationError) Error() string {
+	return fmt.Sprintf(errLabelSchemaViolation, e.rule, e.labelName, formatLabelSet(e.series))
+}
+
+// labelValuesBudgetExceededError is a customized ValidationError, in that it also reports the
+// label over its values budget.
+type labelValuesBudgetExceededError struct {
+	labelName string
+	budget    int
+	series    []cortexpb.LabelAdapter
+}
+
+// NewLabelValuesBudgetExceededError returns the error of a series with a new value of a label
+// over its values budget.
+func NewLabelValuesBudgetExceededError(series []cortexpb.LabelAdapter, labelName string, budget int) ValidationError {
+	return &labelValuesBudgetExceededError{
+		labelName: labelName,
+		budget:    budget,
+		series:    series,
+	}
+}
+
+func (e *labelValuesBudgetExceededError) Error() string {
+	return fmt.Sprintf(errLabelValuesBudgetExceeded, e.labelName, e.budget, formatLabelSet(e.series))
+}
+
 type tooManyLabelsError struct {
 	series []cortexpb.LabelAdapter
 	limit  int
@@ -129,7 +220,7 @@ func newTooManyLabelsError(series []cortexpb.LabelAdapter, limit int) Validation
 
 func (e *tooManyLabelsError) Error() string {
 	return fmt.Sprintf(
-		"series has too many labels (actual: %d, limit: %d) series: '%s'",
+		errTooManyLabels,
 		len(e.series), e.limit, cortexpb.FromLabelAdaptersToMetric(e.series).String())
 }
 
@@ -140,7 +231,7 @@ func newNoMetricNameError() ValidationError {
 }
 
 func (e *noMetricNameError) Error() string {
-	return "sample missing metric name"
+	return errNoMetricName
 }
 
 type invalidMetricNameError struct {
@@ -154,7 +245,7 @@ func newInvalidMetricNameError(metricName string) ValidationError {
 }
 
 func (e *invalidMetricNameError) Error() string {
-	return fmt.Sprintf("sample invalid metric name: %.200q", e.metricName)
+	return fmt.Sprintf(errInvalidMetricName, e.metricName)
 }
 
 // sampleValidationError is a ValidationError implementation suitable for sample validation errors.
@@ -170,7 +261,7 @@ func (e *sampleValidationError) Error() string {
 
 func newSampleTimestampTooOldError(metricName string, timestamp int64) ValidationError {
 	return &sampleValidationError{
-		message:    "timestamp too old: %d metric: %.200q",
+		message:    errSampleTimestampTooOld,
 		metricName: metricName,
 		timestamp:  timestamp,
 	}
@@ -178,7 +269,7 @@ func newSampleTimestampTooOldError(metricName string, timestamp int64) Validatio
 
 func newSampleTimestampTooNewError(metricName string, timestamp int64) ValidationError {
 	return &sampleValidationError{
-		message:    "timestamp too new: %d metric: %.200q",
+		message:    errSampleTimestampTooNew,
 		metricName: metricName,
 		timestamp:  timestamp,
 	}
@@ -198,7 +289,7 @@ func (e *exemplarValidationError) Error() string {
 
 func newExemplarEmtpyLabelsError(seriesLabels []cortexpb.LabelAdapter, exemplarLabels []cortexpb.LabelAdapter, timestamp int64) ValidationError {
 	return &exemplarValidationError{
-		message:        "exemplar missing labels, timestamp: %d series: %s labels: %s",
+		message:        errExemplarEmptyLabels,
 		seriesLabels:   seriesLabels,
 		exemplarLabels: exemplarLabels,
 		timestamp:      timestamp,
@@ -207,18 +298,16 @@ func newExemplarEmtpyLabelsError(seriesLabels []cortexpb.LabelAdapter, exemplarL
 
 func newExemplarMissingTimestampError(seriesLabels []cortexpb.LabelAdapter, exemplarLabels []cortexpb.LabelAdapter, timestamp int64) ValidationError {
 	return &exemplarValidationError{
-		message:        "exemplar missing timestamp, timestamp: %d series: %s labels: %s",
+		message:        errExemplarMissingTimestamp,
 		seriesLabels:   seriesLabels,
 		exemplarLabels: exemplarLabels,
 		timestamp:      timestamp,
 	}
 }
 
-var labelLenMsg = "exemplar combined labelset exceeds " + strconv.Itoa(ExemplarMaxLabelSetLength) + " characters, timestamp: %d series: %s labels: %s"
-
 func newExemplarLabelLengthError(seriesLabels []cortexpb.LabelAdapter, exemplarLabels []cortexpb.LabelAdapter, timestamp int64) ValidationError {
 	return &exemplarValidationError{
-		message:        labelLenMsg,
+		message:        errExemplarLabelLength,
 		seriesLabels:   seriesLabels,
 		exemplarLabels: exemplarLabels,
 		timestamp:      timestamp,
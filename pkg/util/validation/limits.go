@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"math"
 	"regexp"
 	"strings"
@@ -12,20 +13,36 @@ import (
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/promql/parser"
 	"golang.org/x/time/rate"
 
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/engine"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
 )
 
 var errMaxGlobalSeriesPerUserValidation = errors.New("The ingester.max-global-series-per-user limit is unsupported if distributor.shard-by-all-labels is disabled")
 var errDuplicateQueryPriorities = errors.New("duplicate entry of priorities found. Make sure they are all unique, including the default priority")
 var errCompilingQueryPriorityRegex = errors.New("error compiling query priority regex")
+var errInvalidLabelSchemaMode = errors.New("invalid label schema mode, supported values are: " + LabelSchemaModeEnforce + ", " + LabelSchemaModeWarn)
+var errCompilingLabelSchemaRegex = errors.New("error compiling label schema regex")
+var errInvalidDiagnosticsLogLevel = errors.New("invalid diagnostics log level, supported values are: debug, info, warn, error")
+var errInvalidDiagnosticsTraceSamplingRatio = errors.New("invalid diagnostics trace sampling ratio, must be between 0 and 1")
+var errMissingDiagnosticsExpiry = errors.New("the diagnostics expires_at is required when the diagnostics log level or trace sampling ratio are set")
 
 // Supported values for enum limits
 const (
 	LocalIngestionRateStrategy  = "local"
 	GlobalIngestionRateStrategy = "global"
+
+	LabelSchemaModeEnforce = "enforce"
+	LabelSchemaModeWarn    = "warn"
+
+	// Policies applied to the NaN and ±Inf values of the query results.
+	NonFiniteValuesPolicyKeep  = "keep"
+	NonFiniteValuesPolicyDrop  = "drop"
+	NonFiniteValuesPolicyClamp = "clamp"
 )
 
 // AccessDeniedError are errors that do not comply with the limits specified.
@@ -73,31 +90,131 @@ type TimeWindow struct {
 	End   model.Duration `yaml:"end" json:"end" doc:"nocli|description=End of the time window that the query should be within. If set to 0, it won't be checked.|default=0"`
 }
 
+// LabelSchema are the rules the labels of the series pushed by a tenant must follow.
+type LabelSchema struct {
+	Mode              string   `yaml:"mode" json:"mode" doc:"nocli|description=Whether the series violating the schema are rejected (enforce) or only counted as violations (warn).|default=enforce"`
+	RequiredLabels    []string `yaml:"required_labels" json:"required_labels" doc:"nocli|description=Labels every series must have, with a non-empty value."`
+	ForbiddenLabels   []string `yaml:"forbidden_labels" json:"forbidden_labels" doc:"nocli|description=Labels no series can have."`
+	LabelNamePattern  string   `yaml:"label_name_pattern" json:"label_name_pattern" doc:"nocli|description=Regex the label names must fully match. The names starting with __ are not checked. If not set, it won't be checked."`
+	LabelValuePattern string   `yaml:"label_value_pattern" json:"label_value_pattern" doc:"nocli|description=Regex the label values must fully match. The values of the labels starting with __ are not checked. If not set, it won't be checked."`
+
+	labelNameRegex  *regexp.Regexp
+	labelValueRegex *regexp.Regexp
+}
+
+// Enabled returns whether the schema has any rule.
+func (s *LabelSchema) Enabled() bool {
+	return len(s.RequiredLabels) > 0 || len(s.ForbiddenLabels) > 0 || s.LabelNamePattern != "" || s.LabelValuePattern != ""
+}
+
+// Enforced returns whether the series violating the schema are rejected.
+func (s *LabelSchema) Enforced() bool {
+	return s.Mode != LabelSchemaModeWarn
+}
+
+func (s *LabelSchema) compile() error {
+	switch s.Mode {
+	case "", LabelSchemaModeEnforce, LabelSchemaModeWarn:
+	default:
+		return errInvalidLabelSchemaMode
+	}
+
+	var err error
+	if s.labelNameRegex, err = compileAnchoredRegex(s.LabelNamePattern); err != nil {
+		return errors.Join(errCompilingLabelSchemaRegex, err)
+	}
+	if s.labelValueRegex, err = compileAnchoredRegex(s.LabelValuePattern); err != nil {
+		return errors.Join(errCompilingLabelSchemaRegex, err)
+	}
+	return nil
+}
+
+func compileAnchoredRegex(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// TenantDiagnostics temporarily increases the diagnostics collected for a tenant, to troubleshoot it
+// without increasing them for all the tenants.
+type TenantDiagnostics struct {
+	LogLevel           string  `yaml:"log_level" json:"log_level" doc:"nocli|description=Log level of the log lines of the tenant, used instead of the -log.level when more verbose. Supported values are: debug, info, warn, error. If not set, the -log.level is used."`
+	TraceSamplingRatio float64 `yaml:"trace_sampling_ratio" json:"trace_sampling_ratio" doc:"nocli|description=Ratio, between 0 and 1, of the API requests of the tenant traced regardless of the sampling of the tracer.|default=0"`
+	ExpiresAt          string  `yaml:"expires_at" json:"expires_at" doc:"nocli|description=Time, in RFC3339 format, after which the diagnostics of the tenant are back to normal. Required when the log level or the trace sampling ratio are set."`
+
+	expiresAt time.Time
+}
+
+// Enabled returns whether any diagnostics are increased for the tenant, expired or not.
+func (d *TenantDiagnostics) Enabled() bool {
+	return d.LogLevel != "" || d.TraceSamplingRatio > 0
+}
+
+// Active returns whether the diagnostics are increased for the tenant at the given time.
+func (d *TenantDiagnostics) Active(now time.Time) bool {
+	return d.Enabled() && now.Before(d.expiresAt)
+}
+
+func (d *TenantDiagnostics) compile() error {
+	switch d.LogLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return errInvalidDiagnosticsLogLevel
+	}
+
+	if d.TraceSamplingRatio < 0 || d.TraceSamplingRatio > 1 {
+		return errInvalidDiagnosticsTraceSamplingRatio
+	}
+
+	d.expiresAt = time.Time{}
+	if d.ExpiresAt == "" {
+		if d.Enabled() {
+			return errMissingDiagnosticsExpiry
+		}
+		return nil
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, d.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid diagnostics expires_at %q: %w", d.ExpiresAt, err)
+	}
+	d.expiresAt = expiresAt
+	return nil
+}
+
 // Limits describe all the limits for users; can be used to describe global default
 // limits via flags, or per-user limits via yaml config.
 type Limits struct {
 	// Distributor enforced limits.
-	IngestionRate             float64             `yaml:"ingestion_rate" json:"ingestion_rate"`
-	IngestionRateStrategy     string              `yaml:"ingestion_rate_strategy" json:"ingestion_rate_strategy"`
-	IngestionBurstSize        int                 `yaml:"ingestion_burst_size" json:"ingestion_burst_size"`
-	AcceptHASamples           bool                `yaml:"accept_ha_samples" json:"accept_ha_samples"`
-	HAClusterLabel            string              `yaml:"ha_cluster_label" json:"ha_cluster_label"`
-	HAReplicaLabel            string              `yaml:"ha_replica_label" json:"ha_replica_label"`
-	HAMaxClusters             int                 `yaml:"ha_max_clusters" json:"ha_max_clusters"`
-	DropLabels                flagext.StringSlice `yaml:"drop_labels" json:"drop_labels"`
-	MaxLabelNameLength        int                 `yaml:"max_label_name_length" json:"max_label_name_length"`
-	MaxLabelValueLength       int                 `yaml:"max_label_value_length" json:"max_label_value_length"`
-	MaxLabelNamesPerSeries    int                 `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
-	MaxLabelsSizeBytes        int                 `yaml:"max_labels_size_bytes" json:"max_labels_size_bytes"`
-	MaxMetadataLength         int                 `yaml:"max_metadata_length" json:"max_metadata_length"`
-	RejectOldSamples          bool                `yaml:"reject_old_samples" json:"reject_old_samples"`
-	RejectOldSamplesMaxAge    model.Duration      `yaml:"reject_old_samples_max_age" json:"reject_old_samples_max_age"`
-	CreationGracePeriod       model.Duration      `yaml:"creation_grace_period" json:"creation_grace_period"`
-	EnforceMetadataMetricName bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name"`
-	EnforceMetricName         bool                `yaml:"enforce_metric_name" json:"enforce_metric_name"`
-	IngestionTenantShardSize  int                 `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
-	MetricRelabelConfigs      []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs."`
-	MaxExemplars              int                 `yaml:"max_exemplars" json:"max_exemplars"`
+	IngestionRate              float64             `yaml:"ingestion_rate" json:"ingestion_rate"`
+	IngestionRateStrategy      string              `yaml:"ingestion_rate_strategy" json:"ingestion_rate_strategy"`
+	IngestionBurstSize         int                 `yaml:"ingestion_burst_size" json:"ingestion_burst_size"`
+	IngestionSamplingFactor    int                 `yaml:"ingestion_sampling_factor" json:"ingestion_sampling_factor"`
+	AcceptHASamples            bool                `yaml:"accept_ha_samples" json:"accept_ha_samples"`
+	HAClusterLabel             string              `yaml:"ha_cluster_label" json:"ha_cluster_label"`
+	HAReplicaLabel             string              `yaml:"ha_replica_label" json:"ha_replica_label"`
+	HAMaxClusters              int                 `yaml:"ha_max_clusters" json:"ha_max_clusters"`
+	DropLabels                 flagext.StringSlice `yaml:"drop_labels" json:"drop_labels"`
+	MaxLabelNameLength         int                 `yaml:"max_label_name_length" json:"max_label_name_length"`
+	MaxLabelValueLength        int                 `yaml:"max_label_value_length" json:"max_label_value_length"`
+	LabelValueLengthStrategy   string              `yaml:"label_value_length_over_limit_strategy" json:"label_value_length_over_limit_strategy"`
+	LabelValueUTF8Strategy     string              `yaml:"label_value_invalid_utf8_strategy" json:"label_value_invalid_utf8_strategy"`
+	MaxLabelNamesPerSeries     int                 `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
+	MaxLabelsSizeBytes         int                 `yaml:"max_labels_size_bytes" json:"max_labels_size_bytes"`
+	MaxMetadataLength          int                 `yaml:"max_metadata_length" json:"max_metadata_length"`
+	RejectOldSamples           bool                `yaml:"reject_old_samples" json:"reject_old_samples"`
+	RejectOldSamplesMaxAge     model.Duration      `yaml:"reject_old_samples_max_age" json:"reject_old_samples_max_age"`
+	CreationGracePeriod        model.Duration      `yaml:"creation_grace_period" json:"creation_grace_period"`
+	EnforceMetadataMetricName  bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name"`
+	EnforceMetricName          bool                `yaml:"enforce_metric_name" json:"enforce_metric_name"`
+	IngestionTenantShardSize   int                 `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
+	MetricRelabelConfigs       []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs."`
+	MaxExemplars               int                 `yaml:"max_exemplars" json:"max_exemplars"`
+	AcceptPreAggregatedSamples bool                `yaml:"accept_pre_aggregated_samples" json:"accept_pre_aggregated_samples"`
+	LabelSchema                LabelSchema         `yaml:"label_schema" json:"label_schema" doc:"nocli|description=Experimental: Rules the labels of the series pushed by the tenant must follow, validated by the distributor."`
+	MetadataTypeValidation     bool                `yaml:"metadata_type_validation_enabled" json:"metadata_type_validation_enabled"`
+	LabelValuesBudgets         map[string]int      `yaml:"label_values_budgets" json:"label_values_budgets" doc:"nocli|description=Experimental: Maximum number of distinct values of the labels, by label name, like {pod: 50000}, across the in-memory series of the tenant. The distinct values are approximately counted by the ingesters, and synced by the distributors every -distributor.label-values-budgets.sync-period, which reject the series with a new value of a label over its budget. Requires -distributor.label-values-budgets.enabled."`
 
 	// Ingester enforced limits.
 	// Series
@@ -114,30 +231,59 @@ type Limits struct {
 	MaxGlobalMetadataPerMetric          int `yaml:"max_global_metadata_per_metric" json:"max_global_metadata_per_metric"`
 	// Out-of-order
 	OutOfOrderTimeWindow model.Duration `yaml:"out_of_order_time_window" json:"out_of_order_time_window"`
+	// Created timestamps
+	CreatedTimestampZeroIngestion bool `yaml:"created_timestamp_zero_ingestion_enabled" json:"created_timestamp_zero_ingestion_enabled"`
+	// WAL
+	IngesterWALDisabled bool `yaml:"ingester_wal_disabled" json:"ingester_wal_disabled"`
+	// Ephemeral series
+	EphemeralSeriesMatchers   []string `yaml:"ephemeral_series_matchers" json:"ephemeral_series_matchers" doc:"nocli|description=Experimental: Series selectors, like {__name__=~'autoscaling_.+'}, of the series of the tenant kept only in the ingesters memory for the -ingester.ephemeral-series-retention-period, and never shipped to the storage. The series pushed with the X-Cortex-Ephemeral: true header are ephemeral too."`
+	MaxEphemeralSeriesPerUser int      `yaml:"max_ephemeral_series_per_user" json:"max_ephemeral_series_per_user"`
+	ephemeralSeriesMatchers   [][]*labels.Matcher
+	// Storage engine
+	StorageEngine string `yaml:"storage_engine" json:"storage_engine"`
 
 	// Querier enforced limits.
-	MaxChunksPerQuery            int            `yaml:"max_fetched_chunks_per_query" json:"max_fetched_chunks_per_query"`
-	MaxFetchedSeriesPerQuery     int            `yaml:"max_fetched_series_per_query" json:"max_fetched_series_per_query"`
-	MaxFetchedChunkBytesPerQuery int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
-	MaxFetchedDataBytesPerQuery  int            `yaml:"max_fetched_data_bytes_per_query" json:"max_fetched_data_bytes_per_query"`
-	MaxQueryLookback             model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
-	MaxQueryLength               model.Duration `yaml:"max_query_length" json:"max_query_length"`
-	MaxQueryParallelism          int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
-	MaxCacheFreshness            model.Duration `yaml:"max_cache_freshness" json:"max_cache_freshness"`
-	MaxQueriersPerTenant         float64        `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
-	QueryVerticalShardSize       int            `yaml:"query_vertical_shard_size" json:"query_vertical_shard_size" doc:"hidden"`
+	MaxChunksPerQuery             int            `yaml:"max_fetched_chunks_per_query" json:"max_fetched_chunks_per_query"`
+	MaxFetchedSeriesPerQuery      int            `yaml:"max_fetched_series_per_query" json:"max_fetched_series_per_query"`
+	MaxFetchedChunkBytesPerQuery  int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
+	MaxFetchedDataBytesPerQuery   int            `yaml:"max_fetched_data_bytes_per_query" json:"max_fetched_data_bytes_per_query"`
+	MaxQueryLookback              model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
+	MaxQueryLength                model.Duration `yaml:"max_query_length" json:"max_query_length"`
+	MaxQueryParallelism           int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
+	MaxCacheFreshness             model.Duration `yaml:"max_cache_freshness" json:"max_cache_freshness"`
+	MaxQueriersPerTenant          float64        `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
+	QueryVerticalShardSize        int            `yaml:"query_vertical_shard_size" json:"query_vertical_shard_size" doc:"hidden"`
+	NativeHistogramsAsClassic     bool           `yaml:"native_histograms_as_classic_enabled" json:"native_histograms_as_classic_enabled"`
+	MaxConcurrentQueriesPerTenant int            `yaml:"max_concurrent_queries_per_tenant" json:"max_concurrent_queries_per_tenant"`
+	NativeHistogramsClassicLe     []float64      `yaml:"native_histograms_classic_buckets" json:"native_histograms_classic_buckets" doc:"nocli|description=Experimental: The upper bounds of the classic buckets synthesized from the native histograms, like the buckets of the classic histograms the tenant's dashboards were written for. Empty to synthesize a classic bucket per native bucket."`
+	QueryPartialResponse          bool           `yaml:"query_partial_response" json:"query_partial_response"`
+	QueryNonFiniteValuesPolicy    string         `yaml:"query_non_finite_values_policy" json:"query_non_finite_values_policy"`
 
 	// Query Frontend / Scheduler enforced limits.
 	MaxOutstandingPerTenant    int           `yaml:"max_outstanding_requests_per_tenant" json:"max_outstanding_requests_per_tenant"`
 	QueryPriority              QueryPriority `yaml:"query_priority" json:"query_priority" doc:"nocli|description=Configuration for query priority."`
 	queryPriorityRegexHash     uint64
 	queryPriorityCompiledRegex map[string]*regexp.Regexp
+	QueryAllowedFunctions      flagext.StringSliceCSV `yaml:"query_allowed_functions" json:"query_allowed_functions"`
+	QuerySubqueriesDisabled    bool                   `yaml:"query_subqueries_disabled" json:"query_subqueries_disabled"`
+	QueryMaxAtModifierLookback model.Duration         `yaml:"query_max_at_modifier_lookback" json:"query_max_at_modifier_lookback"`
+	CacheWarmupMaxQueries      int                    `yaml:"cache_warmup_max_queries" json:"cache_warmup_max_queries"`
+	QueryEndTimeOffset         model.Duration         `yaml:"query_end_time_offset" json:"query_end_time_offset"`
+	QueryTimeZone              string                 `yaml:"query_time_zone" json:"query_time_zone"`
+	FederationClusters         flagext.StringSliceCSV `yaml:"federation_clusters" json:"federation_clusters"`
 
 	// Ruler defaults and limits.
 	RulerEvaluationDelay        model.Duration `yaml:"ruler_evaluation_delay_duration" json:"ruler_evaluation_delay_duration"`
 	RulerTenantShardSize        int            `yaml:"ruler_tenant_shard_size" json:"ruler_tenant_shard_size"`
 	RulerMaxRulesPerRuleGroup   int            `yaml:"ruler_max_rules_per_rule_group" json:"ruler_max_rules_per_rule_group"`
 	RulerMaxRuleGroupsPerTenant int            `yaml:"ruler_max_rule_groups_per_tenant" json:"ruler_max_rule_groups_per_tenant"`
+	RulerRemoteEvaluation       bool           `yaml:"ruler_remote_evaluation_enabled" json:"ruler_remote_evaluation_enabled"`
+	RulerRemoteEvaluatorShard   int            `yaml:"ruler_remote_evaluator_shard_size" json:"ruler_remote_evaluator_shard_size"`
+	RulerWALMaxSizeBytes        int            `yaml:"ruler_wal_max_size_bytes" json:"ruler_wal_max_size_bytes"`
+
+	// Scheduled queries.
+	ScheduledQueryMaxQueriesPerTenant int `yaml:"scheduled_query_max_queries_per_tenant" json:"scheduled_query_max_queries_per_tenant"`
+	ScheduledQueryMaxResultSeries     int `yaml:"scheduled_query_max_result_series" json:"scheduled_query_max_result_series"`
 
 	// Store-gateway.
 	StoreGatewayTenantShardSize  float64 `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
@@ -153,6 +299,8 @@ type Limits struct {
 	S3SSEKMSKeyID             string `yaml:"s3_sse_kms_key_id" json:"s3_sse_kms_key_id" doc:"nocli|description=S3 server-side encryption KMS Key ID. Ignored if the SSE type override is not set."`
 	S3SSEKMSEncryptionContext string `yaml:"s3_sse_kms_encryption_context" json:"s3_sse_kms_encryption_context" doc:"nocli|description=S3 server-side encryption KMS encryption context. If unset and the key ID override is set, the encryption context will not be provided to S3. Ignored if the SSE type override is not set."`
 
+	ObjectStorageTags map[string]string `yaml:"object_storage_tags" json:"object_storage_tags" doc:"nocli|description=Tags set on the objects uploaded to the long-term storage for the tenant, like the blocks including the downsampled ones, to attribute the storage costs. Set as object tags on S3, requiring the s3:PutObjectTagging permission, as custom metadata on GCS, and ignored by the other backends."`
+
 	// Alertmanager.
 	AlertmanagerReceiversBlockCIDRNetworks     flagext.CIDRSliceCSV `yaml:"alertmanager_receivers_firewall_block_cidr_networks" json:"alertmanager_receivers_firewall_block_cidr_networks"`
 	AlertmanagerReceiversBlockPrivateAddresses bool                 `yaml:"alertmanager_receivers_firewall_block_private_addresses" json:"alertmanager_receivers_firewall_block_private_addresses"`
@@ -167,6 +315,9 @@ type Limits struct {
 	AlertmanagerMaxAlertsCount                 int                `yaml:"alertmanager_max_alerts_count" json:"alertmanager_max_alerts_count"`
 	AlertmanagerMaxAlertsSizeBytes             int                `yaml:"alertmanager_max_alerts_size_bytes" json:"alertmanager_max_alerts_size_bytes"`
 	DisabledRuleGroups                         DisabledRuleGroups `yaml:"disabled_rule_groups" json:"disabled_rule_groups" doc:"nocli|description=list of rule groups to disable"`
+
+	// Diagnostics.
+	Diagnostics TenantDiagnostics `yaml:"diagnostics" json:"diagnostics" doc:"nocli|description=Experimental: Diagnostics temporarily increased for the tenant, like the log verbosity and the trace sampling, to troubleshoot it. Only meant to be set in the per-tenant overrides, and ignored once expired."`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
@@ -175,6 +326,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Float64Var(&l.IngestionRate, "distributor.ingestion-rate-limit", 25000, "Per-user ingestion rate limit in samples per second.")
 	f.StringVar(&l.IngestionRateStrategy, "distributor.ingestion-rate-limit-strategy", "local", "Whether the ingestion rate limit should be applied individually to each distributor instance (local), or evenly shared across the cluster (global).")
 	f.IntVar(&l.IngestionBurstSize, "distributor.ingestion-burst-size", 50000, "Per-user allowed ingestion burst size (in number of samples).")
+	f.IntVar(&l.IngestionSamplingFactor, "distributor.ingestion-sampling-factor", 0, "Experimental: When greater than 1, the push requests exceeding the ingestion rate limit are sampled instead of rejected: only 1 in N samples of each series is kept, chosen deterministically from the series and the sample timestamp, so that HA replicas and retries keep the same samples. The sampled requests have the X-Cortex-Ingestion-Sampling-Factor response header, and the ones still exceeding the limit once sampled are rejected. 0 to disable.")
 	f.BoolVar(&l.AcceptHASamples, "distributor.ha-tracker.enable-for-all-users", false, "Flag to enable, for all users, handling of samples with external labels identifying replicas in an HA Prometheus setup.")
 	f.StringVar(&l.HAClusterLabel, "distributor.ha-tracker.cluster", "cluster", "Prometheus label to look for in samples to identify a Prometheus HA cluster.")
 	f.StringVar(&l.HAReplicaLabel, "distributor.ha-tracker.replica", "__replica__", "Prometheus label to look for in samples to identify a Prometheus HA replica.")
@@ -182,6 +334,8 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.DropLabels, "distributor.drop-label", "This flag can be used to specify label names that to drop during sample ingestion within the distributor and can be repeated in order to drop multiple labels.")
 	f.IntVar(&l.MaxLabelNameLength, "validation.max-length-label-name", 1024, "Maximum length accepted for label names")
 	f.IntVar(&l.MaxLabelValueLength, "validation.max-length-label-value", 2048, "Maximum length accepted for label value. This setting also applies to the metric name")
+	f.StringVar(&l.LabelValueLengthStrategy, "validation.label-value-length-over-limit-strategy", LabelValueLengthStrategyError, "Experimental: What to do with the label values longer than -validation.max-length-label-value. Supported values are: error (the series is rejected), truncate (the value is truncated to the limit), hash (the value is truncated and suffixed with a hash of the whole value, so that the different values are kept distinct).")
+	f.StringVar(&l.LabelValueUTF8Strategy, "validation.label-value-invalid-utf8-strategy", LabelValueUTF8StrategyAccept, "Experimental: What to do with the label values not valid UTF-8. Supported values are: accept (the value is ingested as is), error (the series is rejected), replace (the invalid bytes are replaced by the U+FFFD replacement character).")
 	f.IntVar(&l.MaxLabelNamesPerSeries, "validation.max-label-names-per-series", 30, "Maximum number of label names per series.")
 	f.IntVar(&l.MaxLabelsSizeBytes, "validation.max-labels-size-bytes", 0, "Maximum combined size in bytes of all labels and label values accepted for a series. 0 to disable the limit.")
 	f.IntVar(&l.MaxMetadataLength, "validation.max-metadata-length", 1024, "Maximum length accepted for metric metadata. Metadata refers to Metric Name, HELP and UNIT.")
@@ -192,6 +346,8 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.CreationGracePeriod, "validation.create-grace-period", "Duration which table will be created/deleted before/after it's needed; we won't accept sample from before this time.")
 	f.BoolVar(&l.EnforceMetricName, "validation.enforce-metric-name", true, "Enforce every sample has a metric name.")
 	f.BoolVar(&l.EnforceMetadataMetricName, "validation.enforce-metadata-metric-name", true, "Enforce every metadata has a metric name.")
+	f.BoolVar(&l.MetadataTypeValidation, "distributor.metadata-type-validation-enabled", false, "Experimental: Validate the pushed samples against the type of their metric family, learned from the pushed metric metadata: counters must not be negative nor decrease by less than half, classic histograms must have consistent buckets and summaries valid quantiles. The violations are only counted by the cortex_distributor_metadata_type_violations_total metric, the samples are never rejected.")
+	f.BoolVar(&l.AcceptPreAggregatedSamples, "distributor.accept-pre-aggregated-samples", false, "[Experimental] Accept series pre-aggregated at a downsampling resolution, pushed via the pre-aggregated push API by trusted agents. Pre-aggregated series are excluded from the label APIs and from the raw data queries, and merged with the raw series by the queries allowed to read downsampled data.")
 
 	f.IntVar(&l.MaxSeriesPerQuery, "ingester.max-series-per-query", 100000, "The maximum number of series for which a query can fetch samples from each ingester. This limit is enforced only in the ingesters (when querying samples not flushed to the storage yet) and it's a per-instance limit. This limit is ignored when running the Cortex blocks storage. When running Cortex with blocks storage use -querier.max-fetched-series-per-query limit instead.")
 	f.IntVar(&l.MaxLocalSeriesPerUser, "ingester.max-series-per-user", 5000000, "The maximum number of active series per user, per ingester. 0 to disable.")
@@ -200,6 +356,10 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxGlobalSeriesPerMetric, "ingester.max-global-series-per-metric", 0, "The maximum number of active series per metric name, across the cluster before replication. 0 to disable.")
 	f.IntVar(&l.MaxExemplars, "ingester.max-exemplars", 0, "Enables support for exemplars in TSDB and sets the maximum number that will be stored. less than zero means disabled. If the value is set to zero, cortex will fallback to blocks-storage.tsdb.max-exemplars value.")
 	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", "[Experimental] Configures the allowed time window for ingestion of out-of-order samples. Disabled (0s) by default.")
+	f.BoolVar(&l.CreatedTimestampZeroIngestion, "ingester.created-timestamp-zero-ingestion-enabled", false, "Experimental: Ingest a zero sample at the created timestamp of the counter series pushed with one, when newer than the latest sample of the series, so that rate() and increase() account for the increase since the counter creation or reset. Ignored for the tenants with an out-of-order time window.")
+	f.IntVar(&l.MaxEphemeralSeriesPerUser, "ingester.max-ephemeral-series-per-user", 0, "Experimental: The maximum number of ephemeral series per user, per ingester. 0 to disable.")
+	f.StringVar(&l.StorageEngine, "ingester.storage-engine", engine.DefaultEngine, fmt.Sprintf("Experimental: The storage engine encoding the tenant's chunks sent by the ingesters to the queriers. The chunks the engine can't encode, like the native histograms, are sent in their original encoding. The queriers read the chunks of all the engines, whatever the tenant's engine, so they must be upgraded before the ingesters. Supported values are: %s.", strings.Join(engine.Names(), ", ")))
+	f.BoolVar(&l.IngesterWALDisabled, "ingester.wal-disabled", false, "Experimental: Disable the WAL of the tenant's TSDB in the ingesters, to reduce the disk IOPS for high-churn ephemeral metrics. The replication is then the only durability of the samples not compacted to a block yet: they're lost when all the ingesters holding them restart or crash. Applied when the tenant's TSDB is opened in the ingester.")
 
 	f.IntVar(&l.MaxLocalMetricsWithMetadataPerUser, "ingester.max-metadata-per-user", 8000, "The maximum number of active metrics with metadata per user, per ingester. 0 to disable.")
 	f.IntVar(&l.MaxLocalMetadataPerMetric, "ingester.max-metadata-per-metric", 10, "The maximum number of metadata per metric, per ingester. 0 to disable.")
@@ -211,20 +371,37 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxFetchedDataBytesPerQuery, "querier.max-fetched-data-bytes-per-query", 0, "The maximum combined size of all data that a query can fetch from each ingester and storage. This limit is enforced in the querier and ruler for `query`, `query_range` and `series` APIs. 0 to disable.")
 	f.Var(&l.MaxQueryLength, "store.max-query-length", "Limit the query time range (end - start time). This limit is enforced in the query-frontend (on the received query) and in the querier (on the query possibly split by the query-frontend). 0 to disable.")
 	f.Var(&l.MaxQueryLookback, "querier.max-query-lookback", "Limit how long back data (series and metadata) can be queried, up until <lookback> duration ago. This limit is enforced in the query-frontend, querier and ruler. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
+	f.BoolVar(&l.NativeHistogramsAsClassic, "querier.native-histograms-as-classic-enabled", false, "Experimental: Synthesize the classic _bucket, _sum and _count series of the native histograms when selected by these names, so that the queries written for the classic histograms keep working once migrated to native histograms.")
+	f.BoolVar(&l.QueryPartialResponse, "querier.partial-response", false, "Experimental: Return the merged results of the ingesters that succeeded, with a warning listing the failed ingesters, instead of failing the query once more ingesters failed than tolerated by the replication. The queries with the X-Cortex-Partial-Response header override it. The queries in partial response mode wait for all the ingesters.")
+	f.StringVar(&l.QueryNonFiniteValuesPolicy, "querier.non-finite-values-policy", NonFiniteValuesPolicyKeep, "Experimental: What to do with the NaN, +Inf and -Inf float values of the instant and range query results, for the clients whose JSON parsers break on them. Supported values are: keep (the values are returned as is), drop (the samples are removed from the results), clamp (+Inf and -Inf are replaced by the largest and smallest finite values, and NaN samples are removed). The values of the scalar results are never removed.")
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 14, "Maximum number of split queries will be scheduled in parallel by the frontend.")
 	_ = l.MaxCacheFreshness.Set("1m")
 	f.Var(&l.MaxCacheFreshness, "frontend.max-cache-freshness", "Most recent allowed cacheable result per-tenant, to prevent caching very recent results that might still be in flux.")
+	f.IntVar(&l.MaxConcurrentQueriesPerTenant, "querier.max-concurrent-queries-per-tenant", 0, "Experimental: Maximum number of queries of a tenant run at the same time by each querier, and by each ruler, including the queries not sent through the query-frontend. The other queries of the tenant wait for up to -querier.tenant-query-queue-timeout before being rejected. 0 to disable.")
 	f.Float64Var(&l.MaxQueriersPerTenant, "frontend.max-queriers-per-tenant", 0, "Maximum number of queriers that can handle requests for a single tenant. If set to 0 or value higher than number of available queriers, *all* queriers will handle requests for the tenant. If the value is < 1, it will be treated as a percentage and the gets a percentage of the total queriers. Each frontend (or query-scheduler, if used) will select the same set of queriers for the same tenant (given that all queriers are connected to all frontends / query-schedulers). This option only works with queriers connecting to the query-frontend / query-scheduler, not when using downstream URL.")
 	f.IntVar(&l.QueryVerticalShardSize, "frontend.query-vertical-shard-size", 0, "[Experimental] Number of shards to use when distributing shardable PromQL queries.")
 	f.BoolVar(&l.QueryPriority.Enabled, "frontend.query-priority.enabled", false, "Whether queries are assigned with priorities.")
 	f.Int64Var(&l.QueryPriority.DefaultPriority, "frontend.query-priority.default-priority", 0, "Priority assigned to all queries by default. Must be a unique value. Use this as a baseline to make certain queries higher/lower priority.")
 
 	f.IntVar(&l.MaxOutstandingPerTenant, "frontend.max-outstanding-requests-per-tenant", 100, "Maximum number of outstanding requests per tenant per request queue (either query frontend or query scheduler); requests beyond this error with HTTP 429.")
+	f.Var(&l.QueryAllowedFunctions, "frontend.query-allowed-functions", "Comma-separated list of the PromQL functions a tenant is allowed to use in queries, enforced in the query-frontend. Empty to allow all functions.")
+	f.BoolVar(&l.QuerySubqueriesDisabled, "frontend.query-subqueries-disabled", false, "Reject the queries using subqueries, enforced in the query-frontend.")
+	f.Var(&l.QueryMaxAtModifierLookback, "frontend.query-max-at-modifier-lookback", "Reject the queries using the @ modifier with a timestamp further in the past than this duration, enforced in the query-frontend. 0 to disable.")
+	f.IntVar(&l.CacheWarmupMaxQueries, "frontend.cache-warmup.max-queries-per-tenant", 10, "Maximum number of the most frequent query range requests of a tenant whose results cache entries are warmed up, when the results cache warm-up is enabled. 0 to disable the warm-up for the tenant.")
+	f.Var(&l.QueryEndTimeOffset, "frontend.query-end-time-offset", "Delay of the data availability of the tenant: the end time of the query range requests and the time of the instant queries more recent than now minus this duration are moved back to it, enforced in the query-frontend, so that queries don't race samples not yet ingested. The shifted responses have the X-Cortex-Query-End-Time-Offset header. 0 to disable.")
+	f.StringVar(&l.QueryTimeZone, "frontend.query-time-zone", "", "Experimental: IANA name of the time zone of the tenant, like Europe/Paris. The steps of the query range requests with the align_to_time_zone=true parameter, and their results cache entries, are aligned to the day boundaries of this time zone, using its offset at the start of the request. Empty for UTC.")
+	f.Var(&l.FederationClusters, "frontend.federation-clusters", "Experimental: Comma-separated list of the names of the remote clusters, configured with -frontend.federation.remote-clusters, the queries of the tenant are fanned out to in addition to the local cluster. Empty to only query the local cluster.")
 
 	f.Var(&l.RulerEvaluationDelay, "ruler.evaluation-delay-duration", "Duration to delay the evaluation of rules to ensure the underlying metrics have been pushed to Cortex.")
 	f.IntVar(&l.RulerTenantShardSize, "ruler.tenant-shard-size", 0, "The default tenant's shard size when the shuffle-sharding strategy is used by ruler. When this setting is specified in the per-tenant overrides, a value of 0 disables shuffle sharding for the tenant.")
 	f.IntVar(&l.RulerMaxRulesPerRuleGroup, "ruler.max-rules-per-rule-group", 0, "Maximum number of rules per rule group per-tenant. 0 to disable.")
 	f.IntVar(&l.RulerMaxRuleGroupsPerTenant, "ruler.max-rule-groups-per-tenant", 0, "Maximum number of rule groups per-tenant. 0 to disable.")
+	f.BoolVar(&l.RulerRemoteEvaluation, "ruler.remote-evaluation-enabled", false, "Experimental: evaluate the rule queries of the tenant by the remote rule evaluators configured with -ruler.remote-evaluation.addresses, instead of the ruler.")
+	f.IntVar(&l.RulerWALMaxSizeBytes, "ruler.wal-max-size-bytes", 0, "The maximum size on disk of the write-ahead log of the rule results of the tenant, when -ruler.wal.enabled. The rule evaluations fail while it's reached. 0 to disable.")
+	f.IntVar(&l.RulerRemoteEvaluatorShard, "ruler.remote-evaluator-shard-size", 0, "Number of remote rule evaluators the rule queries of the tenant are balanced across, picked like for the shuffle sharding. 0 to use all the evaluators.")
+
+	f.IntVar(&l.ScheduledQueryMaxQueriesPerTenant, "scheduled-query.max-queries-per-tenant", 0, "Maximum number of scheduled queries per-tenant. The queries beyond the limit are not run. 0 to disable.")
+	f.IntVar(&l.ScheduledQueryMaxResultSeries, "scheduled-query.max-result-series", 0, "Maximum number of series in the result of a scheduled query per-tenant. The results with more series are not delivered. 0 to disable.")
 
 	f.Var(&l.CompactorBlocksRetentionPeriod, "compactor.blocks-retention-period", "Delete blocks containing samples older than the specified retention period. 0 to disable.")
 	f.IntVar(&l.CompactorTenantShardSize, "compactor.tenant-shard-size", 0, "The default tenant's shard size when the shuffle-sharding strategy is used by the compactor. When this setting is specified in the per-tenant overrides, a value of 0 disables shuffle sharding for the tenant.")
@@ -260,6 +437,42 @@ func (l *Limits) Validate(shardByAllLabels bool) error {
 		return errMaxGlobalSeriesPerUserValidation
 	}
 
+	for _, name := range l.QueryAllowedFunctions {
+		if _, ok := parser.Functions[name]; !ok {
+			return fmt.Errorf("unknown PromQL function %q in the query allowed functions", name)
+		}
+	}
+
+	switch l.LabelValueLengthStrategy {
+	case "", LabelValueLengthStrategyError, LabelValueLengthStrategyTruncate:
+	case LabelValueLengthStrategyHash:
+		if l.MaxLabelValueLength <= labelValueHashSuffixLength {
+			return fmt.Errorf("the max label value length must be greater than %d to use the hash label value length strategy", labelValueHashSuffixLength)
+		}
+	default:
+		return fmt.Errorf("unsupported label value length over limit strategy %q", l.LabelValueLengthStrategy)
+	}
+	switch l.LabelValueUTF8Strategy {
+	case "", LabelValueUTF8StrategyAccept, LabelValueUTF8StrategyError, LabelValueUTF8StrategyReplace:
+	default:
+		return fmt.Errorf("unsupported label value invalid UTF-8 strategy %q", l.LabelValueUTF8Strategy)
+	}
+	switch l.QueryNonFiniteValuesPolicy {
+	case "", NonFiniteValuesPolicyKeep, NonFiniteValuesPolicyDrop, NonFiniteValuesPolicyClamp:
+	default:
+		return fmt.Errorf("unsupported query non-finite values policy %q", l.QueryNonFiniteValuesPolicy)
+	}
+
+	if _, err := time.LoadLocation(l.QueryTimeZone); err != nil {
+		return fmt.Errorf("invalid query time zone %q: %w", l.QueryTimeZone, err)
+	}
+
+	if l.StorageEngine != "" {
+		if err := engine.Validate(l.StorageEngine); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -284,6 +497,18 @@ func (l *Limits) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
+	if err := l.LabelSchema.compile(); err != nil {
+		return err
+	}
+
+	if err := l.Diagnostics.compile(); err != nil {
+		return err
+	}
+
+	if err := l.compileEphemeralSeriesMatchers(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -310,6 +535,18 @@ func (l *Limits) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if err := l.LabelSchema.compile(); err != nil {
+		return err
+	}
+
+	if err := l.Diagnostics.compile(); err != nil {
+		return err
+	}
+
+	if err := l.compileEphemeralSeriesMatchers(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -340,6 +577,18 @@ func (l *Limits) hasQueryPriorityRegexChanged() bool {
 	return false
 }
 
+func (l *Limits) compileEphemeralSeriesMatchers() error {
+	l.ephemeralSeriesMatchers = nil
+	for _, selector := range l.EphemeralSeriesMatchers {
+		matchers, err := parser.ParseMetricSelector(selector)
+		if err != nil {
+			return fmt.Errorf("invalid ephemeral series matcher %q: %w", selector, err)
+		}
+		l.ephemeralSeriesMatchers = append(l.ephemeralSeriesMatchers, matchers)
+	}
+	return nil
+}
+
 func (l *Limits) compileQueryPriorityRegex() error {
 	if l.QueryPriority.Enabled {
 		hasQueryPriorityRegexChanged := l.hasQueryPriorityRegexChanged()
@@ -429,11 +678,49 @@ func (o *Overrides) IngestionBurstSize(userID string) int {
 	return o.GetOverridesForUser(userID).IngestionBurstSize
 }
 
+// IngestionSamplingFactor returns the N of the 1 in N samples kept when the tenant exceeds its
+// ingestion rate limit, or 0 if the requests exceeding it are rejected.
+func (o *Overrides) IngestionSamplingFactor(userID string) int {
+	return o.GetOverridesForUser(userID).IngestionSamplingFactor
+}
+
+// MetadataTypeValidation returns whether the distributor validates the samples of the tenant
+// against the type of their metric family.
+func (o *Overrides) MetadataTypeValidation(userID string) bool {
+	return o.GetOverridesForUser(userID).MetadataTypeValidation
+}
+
+// LabelValuesBudgets returns the maximum number of distinct values of the labels of the tenant,
+// by label name.
+func (o *Overrides) LabelValuesBudgets(userID string) map[string]int {
+	return o.GetOverridesForUser(userID).LabelValuesBudgets
+}
+
 // AcceptHASamples returns whether the distributor should track and accept samples from HA replicas for this user.
 func (o *Overrides) AcceptHASamples(userID string) bool {
 	return o.GetOverridesForUser(userID).AcceptHASamples
 }
 
+// NativeHistogramsAsClassic returns whether the classic series of the native histograms are synthesized at query time.
+func (o *Overrides) NativeHistogramsAsClassic(userID string) bool {
+	return o.GetOverridesForUser(userID).NativeHistogramsAsClassic
+}
+
+// MaxConcurrentQueriesPerTenant returns the maximum number of queries of the tenant run at the same time by each querier.
+func (o *Overrides) MaxConcurrentQueriesPerTenant(userID string) int {
+	return o.GetOverridesForUser(userID).MaxConcurrentQueriesPerTenant
+}
+
+// NativeHistogramsClassicLe returns the upper bounds of the classic buckets synthesized from the native histograms.
+func (o *Overrides) NativeHistogramsClassicLe(userID string) []float64 {
+	return o.GetOverridesForUser(userID).NativeHistogramsClassicLe
+}
+
+// AcceptPreAggregatedSamples returns whether the distributor should accept series pre-aggregated at a downsampling resolution.
+func (o *Overrides) AcceptPreAggregatedSamples(userID string) bool {
+	return o.GetOverridesForUser(userID).AcceptPreAggregatedSamples
+}
+
 // HAClusterLabel returns the cluster label to look for when deciding whether to accept a sample from a Prometheus HA replica.
 func (o *Overrides) HAClusterLabel(userID string) string {
 	return o.GetOverridesForUser(userID).HAClusterLabel
@@ -518,6 +805,32 @@ func (o *Overrides) OutOfOrderTimeWindow(userID string) model.Duration {
 	return o.GetOverridesForUser(userID).OutOfOrderTimeWindow
 }
 
+// CreatedTimestampZeroIngestion returns whether a zero sample is ingested at the created timestamp of the counter series.
+func (o *Overrides) CreatedTimestampZeroIngestion(userID string) bool {
+	return o.GetOverridesForUser(userID).CreatedTimestampZeroIngestion
+}
+
+// IngesterWALDisabled returns whether the WAL of the tenant's TSDB is disabled in the ingesters.
+func (o *Overrides) IngesterWALDisabled(userID string) bool {
+	return o.GetOverridesForUser(userID).IngesterWALDisabled
+}
+
+// EphemeralSeriesMatchers returns the matchers of the tenant's ephemeral series. A series is
+// ephemeral if it matches all the matchers of any of the returned sets.
+func (o *Overrides) EphemeralSeriesMatchers(userID string) [][]*labels.Matcher {
+	return o.GetOverridesForUser(userID).ephemeralSeriesMatchers
+}
+
+// MaxEphemeralSeriesPerUser returns the maximum number of ephemeral series a user is allowed to store in a single ingester.
+func (o *Overrides) MaxEphemeralSeriesPerUser(userID string) int {
+	return o.GetOverridesForUser(userID).MaxEphemeralSeriesPerUser
+}
+
+// StorageEngine returns the name of the storage engine encoding the tenant's chunks.
+func (o *Overrides) StorageEngine(userID string) string {
+	return o.GetOverridesForUser(userID).StorageEngine
+}
+
 // MaxGlobalSeriesPerMetric returns the maximum number of series allowed per metric across the cluster.
 func (o *Overrides) MaxGlobalSeriesPerMetric(userID string) int {
 	return o.GetOverridesForUser(userID).MaxGlobalSeriesPerMetric
@@ -539,6 +852,16 @@ func (o *Overrides) MaxFetchedSeriesPerQuery(userID string) int {
 	return o.GetOverridesForUser(userID).MaxFetchedSeriesPerQuery
 }
 
+// QueryPartialResponse returns whether the queries of the tenant return the results of the ingesters that succeeded by default.
+func (o *Overrides) QueryPartialResponse(userID string) bool {
+	return o.GetOverridesForUser(userID).QueryPartialResponse
+}
+
+// QueryNonFiniteValuesPolicy returns what to do with the NaN and ±Inf values of the query results of the tenant.
+func (o *Overrides) QueryNonFiniteValuesPolicy(userID string) string {
+	return o.GetOverridesForUser(userID).QueryNonFiniteValuesPolicy
+}
+
 // MaxFetchedChunkBytesPerQuery returns the maximum number of bytes for chunks allowed per query when fetching
 // chunks from ingesters and blocks storage.
 func (o *Overrides) MaxFetchedChunkBytesPerQuery(userID string) int {
@@ -595,6 +918,41 @@ func (o *Overrides) MaxOutstandingPerTenant(userID string) int {
 	return o.GetOverridesForUser(userID).MaxOutstandingPerTenant
 }
 
+// QueryAllowedFunctions returns the PromQL functions the tenant is allowed to use, or an empty list if all are allowed.
+func (o *Overrides) QueryAllowedFunctions(userID string) []string {
+	return o.GetOverridesForUser(userID).QueryAllowedFunctions
+}
+
+// QuerySubqueriesDisabled returns whether the tenant is not allowed to use subqueries.
+func (o *Overrides) QuerySubqueriesDisabled(userID string) bool {
+	return o.GetOverridesForUser(userID).QuerySubqueriesDisabled
+}
+
+// QueryMaxAtModifierLookback returns how far in the past the @ modifier timestamps of the tenant's queries can be.
+func (o *Overrides) QueryMaxAtModifierLookback(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).QueryMaxAtModifierLookback)
+}
+
+// CacheWarmupMaxQueries returns the maximum number of queries whose results cache entries are warmed up for the tenant.
+func (o *Overrides) CacheWarmupMaxQueries(userID string) int {
+	return o.GetOverridesForUser(userID).CacheWarmupMaxQueries
+}
+
+// QueryEndTimeOffset returns how far back from now the end time of the tenant's queries is moved.
+func (o *Overrides) QueryEndTimeOffset(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).QueryEndTimeOffset)
+}
+
+// QueryTimeZone returns the time zone the steps of the tenant's queries are aligned to when requested, or an empty string for UTC.
+func (o *Overrides) QueryTimeZone(userID string) string {
+	return o.GetOverridesForUser(userID).QueryTimeZone
+}
+
+// FederationClusters returns the names of the remote clusters the tenant's queries are fanned out to.
+func (o *Overrides) FederationClusters(userID string) []string {
+	return o.GetOverridesForUser(userID).FederationClusters
+}
+
 // QueryPriority returns the query priority config for the tenant, including different priorities and their attributes
 func (o *Overrides) QueryPriority(userID string) QueryPriority {
 	return o.GetOverridesForUser(userID).QueryPriority
@@ -670,6 +1028,31 @@ func (o *Overrides) RulerMaxRuleGroupsPerTenant(userID string) int {
 	return o.GetOverridesForUser(userID).RulerMaxRuleGroupsPerTenant
 }
 
+// RulerRemoteEvaluationEnabled returns whether the rule queries of the user are evaluated by the remote rule evaluators.
+func (o *Overrides) RulerRemoteEvaluationEnabled(userID string) bool {
+	return o.GetOverridesForUser(userID).RulerRemoteEvaluation
+}
+
+// RulerWALMaxSizeBytes returns the maximum size on disk of the write-ahead log of the rule results of the user.
+func (o *Overrides) RulerWALMaxSizeBytes(userID string) int {
+	return o.GetOverridesForUser(userID).RulerWALMaxSizeBytes
+}
+
+// RulerRemoteEvaluatorShardSize returns the number of remote rule evaluators used by the user.
+func (o *Overrides) RulerRemoteEvaluatorShardSize(userID string) int {
+	return o.GetOverridesForUser(userID).RulerRemoteEvaluatorShard
+}
+
+// ScheduledQueryMaxQueriesPerTenant returns the maximum number of scheduled queries for a given user.
+func (o *Overrides) ScheduledQueryMaxQueriesPerTenant(userID string) int {
+	return o.GetOverridesForUser(userID).ScheduledQueryMaxQueriesPerTenant
+}
+
+// ScheduledQueryMaxResultSeries returns the maximum number of series in the result of a scheduled query for a given user.
+func (o *Overrides) ScheduledQueryMaxResultSeries(userID string) int {
+	return o.GetOverridesForUser(userID).ScheduledQueryMaxResultSeries
+}
+
 // StoreGatewayTenantShardSize returns the store-gateway shard size for a given user.
 func (o *Overrides) StoreGatewayTenantShardSize(userID string) float64 {
 	return o.GetOverridesForUser(userID).StoreGatewayTenantShardSize
@@ -695,6 +1078,11 @@ func (o *Overrides) S3SSEKMSEncryptionContext(user string) string {
 	return o.GetOverridesForUser(user).S3SSEKMSEncryptionContext
 }
 
+// ObjectStorageTags returns the per-tenant tags of the objects uploaded to the storage.
+func (o *Overrides) ObjectStorageTags(user string) map[string]string {
+	return o.GetOverridesForUser(user).ObjectStorageTags
+}
+
 // AlertmanagerReceiversBlockCIDRNetworks returns the list of network CIDRs that should be blocked
 // in the Alertmanager receivers for the given user.
 func (o *Overrides) AlertmanagerReceiversBlockCIDRNetworks(user string) []flagext.CIDR {
@@ -804,6 +1192,26 @@ func (o *Overrides) DisabledRuleGroups(userID string) DisabledRuleGroups {
 	return DisabledRuleGroups{}
 }
 
+// DiagnosticsLogLevel returns the log level of the log lines of the tenant, or an empty string if
+// the tenant has no active diagnostics log level.
+func (o *Overrides) DiagnosticsLogLevel(userID string) string {
+	d := &o.GetOverridesForUser(userID).Diagnostics
+	if !d.Active(time.Now()) {
+		return ""
+	}
+	return d.LogLevel
+}
+
+// DiagnosticsTraceSamplingRatio returns the ratio of the API requests of the tenant traced regardless
+// of the sampling of the tracer, or 0 if the tenant has no active diagnostics.
+func (o *Overrides) DiagnosticsTraceSamplingRatio(userID string) float64 {
+	d := &o.GetOverridesForUser(userID).Diagnostics
+	if !d.Active(time.Now()) {
+		return 0
+	}
+	return d.TraceSamplingRatio
+}
+
 // GetOverridesForUser returns the per-tenant limits with overrides.
 func (o *Overrides) GetOverridesForUser(userID string) *Limits {
 	if o.tenantLimits != nil {
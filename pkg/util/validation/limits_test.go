@@ -2,6 +2,7 @@ package validation
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
 	"regexp"
 	"strings"
@@ -62,6 +63,41 @@ func TestLimits_Validate(t *testing.T) {
 			shardByAllLabels: true,
 			expected:         nil,
 		},
+		"query-allowed-functions with known functions": {
+			limits:           Limits{QueryAllowedFunctions: []string{"rate", "label_replace"}},
+			shardByAllLabels: true,
+			expected:         nil,
+		},
+		"query-allowed-functions with an unknown function": {
+			limits:           Limits{QueryAllowedFunctions: []string{"rate", "sum"}},
+			shardByAllLabels: true,
+			expected:         errors.New(`unknown PromQL function "sum" in the query allowed functions`),
+		},
+		"label-value-length-over-limit-strategy=hash with a long enough max label value length": {
+			limits:           Limits{LabelValueLengthStrategy: LabelValueLengthStrategyHash, MaxLabelValueLength: 64},
+			shardByAllLabels: true,
+			expected:         nil,
+		},
+		"label-value-length-over-limit-strategy=hash with a too short max label value length": {
+			limits:           Limits{LabelValueLengthStrategy: LabelValueLengthStrategyHash, MaxLabelValueLength: 10},
+			shardByAllLabels: true,
+			expected:         errors.New("the max label value length must be greater than 17 to use the hash label value length strategy"),
+		},
+		"unknown label-value-length-over-limit-strategy": {
+			limits:           Limits{LabelValueLengthStrategy: "drop"},
+			shardByAllLabels: true,
+			expected:         errors.New(`unsupported label value length over limit strategy "drop"`),
+		},
+		"unknown label-value-invalid-utf8-strategy": {
+			limits:           Limits{LabelValueUTF8Strategy: "drop"},
+			shardByAllLabels: true,
+			expected:         errors.New(`unsupported label value invalid UTF-8 strategy "drop"`),
+		},
+		"unknown query-non-finite-values-policy": {
+			limits:           Limits{QueryNonFiniteValuesPolicy: "replace"},
+			shardByAllLabels: true,
+			expected:         errors.New(`unsupported query non-finite values policy "replace"`),
+		},
 	}
 
 	for testName, testData := range tests {
@@ -675,3 +711,64 @@ func TestCompileQueryPriorityRegex(t *testing.T) {
 	require.NoError(t, err)
 	require.Nil(t, l.QueryPriority.Priorities[0].QueryAttributes[0].CompiledRegex)
 }
+
+func TestLabelSchemaLoadingFromYaml(t *testing.T) {
+	SetDefaultLimitsForYAMLUnmarshalling(Limits{})
+
+	inp := `
+label_schema:
+  mode: warn
+  required_labels: [namespace]
+  label_name_pattern: "[a-z_]+"
+`
+	l := Limits{}
+	require.NoError(t, yaml.UnmarshalStrict([]byte(inp), &l))
+	assert.Equal(t, LabelSchemaModeWarn, l.LabelSchema.Mode)
+	assert.Equal(t, []string{"namespace"}, l.LabelSchema.RequiredLabels)
+	assert.Equal(t, regexp.MustCompile("^(?:[a-z_]+)$"), l.LabelSchema.labelNameRegex)
+	assert.Nil(t, l.LabelSchema.labelValueRegex)
+
+	l = Limits{}
+	assert.ErrorIs(t, yaml.UnmarshalStrict([]byte("label_schema:\n  mode: reject\n"), &l), errInvalidLabelSchemaMode)
+	assert.ErrorIs(t, yaml.UnmarshalStrict([]byte("label_schema:\n  label_value_pattern: \"[\"\n"), &l), errCompilingLabelSchemaRegex)
+}
+
+func TestDiagnosticsOverridesPerTenant(t *testing.T) {
+	SetDefaultLimitsForYAMLUnmarshalling(Limits{})
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	overridesYAML := `
+tenant1:
+  diagnostics:
+    log_level: debug
+    trace_sampling_ratio: 0.5
+    expires_at: ` + expiresAt + `
+tenant2:
+  diagnostics:
+    log_level: debug
+    trace_sampling_ratio: 1
+    expires_at: 2020-01-01T00:00:00Z
+`
+
+	overrides := map[string]*Limits{}
+	require.NoError(t, yaml.UnmarshalStrict([]byte(overridesYAML), &overrides))
+
+	ov, err := NewOverrides(Limits{}, newMockTenantLimits(overrides))
+	require.NoError(t, err)
+
+	assert.Equal(t, "debug", ov.DiagnosticsLogLevel("tenant1"))
+	assert.Equal(t, 0.5, ov.DiagnosticsTraceSamplingRatio("tenant1"))
+
+	// The expired diagnostics are ignored.
+	assert.Equal(t, "", ov.DiagnosticsLogLevel("tenant2"))
+	assert.Equal(t, 0.0, ov.DiagnosticsTraceSamplingRatio("tenant2"))
+
+	assert.Equal(t, "", ov.DiagnosticsLogLevel("tenant3"))
+	assert.Equal(t, 0.0, ov.DiagnosticsTraceSamplingRatio("tenant3"))
+
+	l := Limits{}
+	assert.ErrorIs(t, yaml.UnmarshalStrict([]byte("diagnostics:\n  log_level: trace\n  expires_at: "+expiresAt+"\n"), &l), errInvalidDiagnosticsLogLevel)
+	assert.ErrorIs(t, yaml.UnmarshalStrict([]byte("diagnostics:\n  trace_sampling_ratio: 2\n  expires_at: "+expiresAt+"\n"), &l), errInvalidDiagnosticsTraceSamplingRatio)
+	assert.ErrorIs(t, yaml.UnmarshalStrict([]byte("diagnostics:\n  log_level: debug\n"), &l), errMissingDiagnosticsExpiry)
+	assert.Error(t, yaml.UnmarshalStrict([]byte("diagnostics:\n  log_level: debug\n  expires_at: tomorrow\n"), &l))
+}
@@ -1,6 +1,8 @@
 package validation
 
 import (
+	"fmt"
+	"hash/fnv"
 	"net/http"
 	"strings"
 	"time"
@@ -13,16 +15,23 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/downsample"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/extract"
+	"github.com/cortexproject/cortex/pkg/util/globalerror"
+)
+
+var (
+	errMetadataMissingMetricName = globalerror.MetricMetadataMissingName.Message("metadata missing metric name")
+	errMetadataTooLong           = globalerror.MetricMetadataTooLong.Message("metadata '%s' value too long: %.200q metric %.200q")
+
+	// ErrQueryTooLong is used in chunk store, querier and query frontend.
+	ErrQueryTooLong = globalerror.MaxQueryLength.Message("the query time range exceeds the limit (query length: %s, limit: %s)")
 )
 
 const (
 	discardReasonLabel = "reason"
 
-	errMetadataMissingMetricName = "metadata missing metric name"
-	errMetadataTooLong           = "metadata '%s' value too long: %.200q metric %.200q"
-
 	typeMetricName = "METRIC_NAME"
 	typeHelp       = "HELP"
 	typeUnit       = "UNIT"
@@ -31,9 +40,6 @@ const (
 	helpTooLong       = "help_too_long"
 	unitTooLong       = "unit_too_long"
 
-	// ErrQueryTooLong is used in chunk store, querier and query frontend.
-	ErrQueryTooLong = "the query time range exceeds the limit (query length: %s, limit: %s)"
-
 	missingMetricName       = "missing_metric_name"
 	invalidMetricName       = "metric_name_invalid"
 	greaterThanMaxSampleAge = "greater_than_max_sample_age"
@@ -44,7 +50,17 @@ const (
 	duplicateLabelNames     = "duplicate_label_names"
 	labelsNotSorted         = "labels_not_sorted"
 	labelValueTooLong       = "label_value_too_long"
+	labelValueInvalidUTF8   = "label_value_invalid_utf8"
 	labelsSizeBytesExceeded = "labels_size_bytes_exceeded"
+	preAggregatedNotAllowed = "pre_aggregated_not_allowed"
+	invalidPreAggregated    = "pre_aggregated_invalid"
+	labelSchemaViolation    = "label_schema_violation"
+
+	// Label schema rules.
+	labelSchemaRequiredLabel     = "required_label"
+	labelSchemaForbiddenLabel    = "forbidden_label"
+	labelSchemaLabelNamePattern  = "label_name_pattern"
+	labelSchemaLabelValuePattern = "label_value_pattern"
 
 	// Exemplar-specific validation reasons
 	exemplarLabelsMissing    = "exemplar_labels_missing"
@@ -55,6 +71,14 @@ const (
 	// Declared here to avoid duplication in ingester and distributor.
 	RateLimited = "rate_limited"
 
+	// RateLimitedSampled is the reason of the samples discarded by the sampling of the requests
+	// exceeding the ingestion rate limit.
+	RateLimitedSampled = "rate_limited_sampled"
+
+	// LabelValuesBudgetExceeded is the reason of the series discarded by the distributor for
+	// having a new value of a label over its values budget.
+	LabelValuesBudgetExceeded = "label_values_budget_exceeded"
+
 	// Too many HA clusters is one of the reasons for discarding samples.
 	TooManyHAClusters = "too_many_ha_clusters"
 
@@ -63,6 +87,20 @@ const (
 	// DroppedByUserConfigurationOverride Samples discarded due to user configuration removing label __name__
 	DroppedByUserConfigurationOverride = "user_label_removal_configuration"
 
+	// Strategies applied to the label values longer than the limit.
+	LabelValueLengthStrategyError    = "error"
+	LabelValueLengthStrategyTruncate = "truncate"
+	LabelValueLengthStrategyHash     = "hash"
+
+	// Strategies applied to the label values not valid UTF-8.
+	LabelValueUTF8StrategyAccept  = "accept"
+	LabelValueUTF8StrategyError   = "error"
+	LabelValueUTF8StrategyReplace = "replace"
+
+	// labelValueHashSuffixLength is the length of the suffix of the label values truncated by the
+	// hash strategy: a separator and the hex-encoded 64 bits hash of the whole value.
+	labelValueHashSuffixLength = 17
+
 	// The combined length of the label names and values of an Exemplar's LabelSet MUST NOT exceed 128 UTF-8 characters
 	// https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md#exemplars
 	ExemplarMaxLabelSetLength = 128
@@ -95,10 +133,32 @@ var DiscardedMetadata = prometheus.NewCounterVec(
 	[]string{discardReasonLabel, "user"},
 )
 
+// LabelSchemaViolations is a metric of the number of series violating the label schema of
+// their tenant, by rule and mode.
+var LabelSchemaViolations = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cortex_label_schema_violations_total",
+		Help: "The total number of series violating the label schema of their tenant.",
+	},
+	[]string{"rule", "mode", "user"},
+)
+
+// ReplacedLabelValues is a metric of the number of label values replaced instead of rejecting
+// their series, by reason and strategy.
+var ReplacedLabelValues = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cortex_label_values_replaced_total",
+		Help: "The total number of label values replaced, instead of rejecting their series, since longer than the limit or not valid UTF-8.",
+	},
+	[]string{discardReasonLabel, "strategy", "user"},
+)
+
 func init() {
 	prometheus.MustRegister(DiscardedSamples)
 	prometheus.MustRegister(DiscardedExemplars)
 	prometheus.MustRegister(DiscardedMetadata)
+	prometheus.MustRegister(LabelSchemaViolations)
+	prometheus.MustRegister(ReplacedLabelValues)
 }
 
 // ValidateSample returns an err if the sample is invalid.
@@ -157,6 +217,8 @@ func ValidateExemplar(userID string, ls []cortexpb.LabelAdapter, e cortexpb.Exem
 }
 
 // ValidateLabels returns an err if the labels are invalid.
+// The label values longer than the limit or not valid UTF-8 may be replaced in place instead,
+// according to the tenant's strategies.
 // The returned error may retain the provided series labels.
 func ValidateLabels(limits *Limits, userID string, ls []cortexpb.LabelAdapter, skipLabelNameValidation bool) ValidationError {
 	if limits.EnforceMetricName {
@@ -184,7 +246,25 @@ func ValidateLabels(limits *Limits, userID string, ls []cortexpb.LabelAdapter, s
 	maxLabelsSizeBytes := limits.MaxLabelsSizeBytes
 	labelsSizeBytes := 0
 
-	for _, l := range ls {
+	for i := range ls {
+		switch limits.LabelValueUTF8Strategy {
+		case LabelValueUTF8StrategyError:
+			if !utf8.ValidString(ls[i].Value) {
+				DiscardedSamples.WithLabelValues(labelValueInvalidUTF8, userID).Inc()
+				return newLabelValueInvalidUTF8Error(ls, ls[i].Name)
+			}
+		case LabelValueUTF8StrategyReplace:
+			if !utf8.ValidString(ls[i].Value) {
+				ls[i].Value = strings.ToValidUTF8(ls[i].Value, string(utf8.RuneError))
+				ReplacedLabelValues.WithLabelValues(labelValueInvalidUTF8, limits.LabelValueUTF8Strategy, userID).Inc()
+			}
+		}
+		if len(ls[i].Value) > maxLabelValueLength && limits.LabelValueLengthStrategy != "" && limits.LabelValueLengthStrategy != LabelValueLengthStrategyError {
+			ls[i].Value = shortenLabelValue(ls[i].Value, maxLabelValueLength, limits.LabelValueLengthStrategy)
+			ReplacedLabelValues.WithLabelValues(labelValueTooLong, limits.LabelValueLengthStrategy, userID).Inc()
+		}
+
+		l := ls[i]
 		if !skipLabelNameValidation && !model.LabelName(l.Name).IsValid() {
 			DiscardedSamples.WithLabelValues(invalidLabel, userID).Inc()
 			return newInvalidLabelError(ls, l.Name)
@@ -214,6 +294,114 @@ func ValidateLabels(limits *Limits, userID string, ls []cortexpb.LabelAdapter, s
 	return nil
 }
 
+// shortenLabelValue shortens the label value to the max length, at a rune boundary. The hash
+// strategy suffixes the truncated value with the hash of the whole value.
+func shortenLabelValue(value string, maxLength int, strategy string) string {
+	suffix := ""
+	if strategy == LabelValueLengthStrategyHash {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(value))
+		suffix = fmt.Sprintf("~%016x", h.Sum64())
+	}
+
+	n := maxLength - len(suffix)
+	if n < 0 {
+		return suffix[:maxLength]
+	}
+	for n > 0 && !utf8.RuneStart(value[n]) {
+		n--
+	}
+	return value[:n] + suffix
+}
+
+// ValidatePreAggregatedLabels returns an err if the series carries the reserved pre-aggregation
+// labels but the tenant is not allowed to push pre-aggregated series, or the labels are invalid.
+func ValidatePreAggregatedLabels(limits *Limits, userID string, ls []cortexpb.LabelAdapter) ValidationError {
+	var resolution, aggregation string
+	found := false
+	for _, l := range ls {
+		switch l.Name {
+		case downsample.ResolutionLabel:
+			resolution = l.Value
+			found = true
+		case downsample.AggregationLabel:
+			aggregation = l.Value
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	if !limits.AcceptPreAggregatedSamples {
+		DiscardedSamples.WithLabelValues(preAggregatedNotAllowed, userID).Inc()
+		return newPreAggregatedNotAllowedError(ls)
+	}
+	if err := downsample.ValidatePreAggregatedSeries(resolution, aggregation); err != nil {
+		DiscardedSamples.WithLabelValues(invalidPreAggregated, userID).Inc()
+		return newInvalidPreAggregatedError(ls, err)
+	}
+	return nil
+}
+
+// ValidateLabelSchema returns an err if the series violates the label schema of the tenant and
+// the schema is enforced. In warn mode, the violation is only counted.
+// The returned error may retain the provided series labels.
+func ValidateLabelSchema(limits *Limits, userID string, ls []cortexpb.LabelAdapter) ValidationError {
+	schema := &limits.LabelSchema
+	if !schema.Enabled() {
+		return nil
+	}
+
+	rule, cause := labelSchemaViolatedRule(schema, ls)
+	if rule == "" {
+		return nil
+	}
+
+	if !schema.Enforced() {
+		LabelSchemaViolations.WithLabelValues(rule, LabelSchemaModeWarn, userID).Inc()
+		return nil
+	}
+	LabelSchemaViolations.WithLabelValues(rule, LabelSchemaModeEnforce, userID).Inc()
+	DiscardedSamples.WithLabelValues(labelSchemaViolation, userID).Inc()
+	return newLabelSchemaViolationError(ls, rule, cause)
+}
+
+// labelSchemaViolatedRule returns the first rule of the schema violated by the series and the
+// label causing it, or empty strings if the series follows the schema.
+func labelSchemaViolatedRule(schema *LabelSchema, ls []cortexpb.LabelAdapter) (string, string) {
+	for _, name := range schema.RequiredLabels {
+		found := false
+		for _, l := range ls {
+			if l.Name == name {
+				found = l.Value != ""
+				break
+			}
+		}
+		if !found {
+			return labelSchemaRequiredLabel, name
+		}
+	}
+
+	for _, l := range ls {
+		for _, name := range schema.ForbiddenLabels {
+			if l.Name == name {
+				return labelSchemaForbiddenLabel, l.Name
+			}
+		}
+		// The internal labels, like the metric name, aren't subject to the patterns.
+		if strings.HasPrefix(l.Name, "__") {
+			continue
+		}
+		if schema.labelNameRegex != nil && !schema.labelNameRegex.MatchString(l.Name) {
+			return labelSchemaLabelNamePattern, l.Name
+		}
+		if schema.labelValueRegex != nil && !schema.labelValueRegex.MatchString(l.Value) {
+			return labelSchemaLabelValuePattern, l.Name
+		}
+	}
+	return "", ""
+}
+
 // ValidateMetadata returns an err if a metric metadata is invalid.
 func ValidateMetadata(cfg *Limits, userID string, metadata *cortexpb.MetricMetadata) error {
 	if cfg.EnforceMetadataMetricName && metadata.GetMetricFamilyName() == "" {
@@ -259,4 +447,10 @@ func DeletePerUserValidationMetrics(userID string, log log.Logger) {
 	if err := util.DeleteMatchingLabels(DiscardedMetadata, filter); err != nil {
 		level.Warn(log).Log("msg", "failed to remove cortex_discarded_metadata_total metric for user", "user", userID, "err", err)
 	}
+	if err := util.DeleteMatchingLabels(LabelSchemaViolations, filter); err != nil {
+		level.Warn(log).Log("msg", "failed to remove cortex_label_schema_violations_total metric for user", "user", userID, "err", err)
+	}
+	if err := util.DeleteMatchingLabels(ReplacedLabelValues, filter); err != nil {
+		level.Warn(log).Log("msg", "failed to remove cortex_label_values_replaced_total metric for user", "user", userID, "err", err)
+	}
 }
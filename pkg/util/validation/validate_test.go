@@ -122,6 +122,218 @@ func TestValidateLabels(t *testing.T) {
 	`), "cortex_discarded_samples_total"))
 }
 
+func TestValidateLabels_LabelValueStrategies(t *testing.T) {
+	const userID = "strategies-user"
+	longValue := strings.Repeat("a", 20) + "é" + strings.Repeat("b", 20)
+
+	for name, tc := range map[string]struct {
+		lengthStrategy string
+		utf8Strategy   string
+		value          string
+		expectedValue  string
+		expectedErr    error
+	}{
+		"too long value rejected": {
+			lengthStrategy: LabelValueLengthStrategyError,
+			utf8Strategy:   LabelValueUTF8StrategyAccept,
+			value:          longValue,
+			expectedErr: newLabelValueTooLongError([]cortexpb.LabelAdapter{
+				{Name: model.MetricNameLabel, Value: "up"},
+				{Name: "value", Value: longValue},
+			}, "value", longValue, 30),
+		},
+		"too long value truncated at a rune boundary": {
+			lengthStrategy: LabelValueLengthStrategyTruncate,
+			utf8Strategy:   LabelValueUTF8StrategyAccept,
+			value:          strings.Repeat("a", 29) + "é" + "b",
+			expectedValue:  strings.Repeat("a", 29),
+		},
+		"too long value hashed": {
+			lengthStrategy: LabelValueLengthStrategyHash,
+			utf8Strategy:   LabelValueUTF8StrategyAccept,
+			value:          longValue,
+			expectedValue:  shortenLabelValue(longValue, 30, LabelValueLengthStrategyHash),
+		},
+		"invalid UTF-8 accepted": {
+			lengthStrategy: LabelValueLengthStrategyError,
+			utf8Strategy:   LabelValueUTF8StrategyAccept,
+			value:          "a\xffb",
+			expectedValue:  "a\xffb",
+		},
+		"invalid UTF-8 rejected": {
+			lengthStrategy: LabelValueLengthStrategyError,
+			utf8Strategy:   LabelValueUTF8StrategyError,
+			value:          "a\xffb",
+			expectedErr: newLabelValueInvalidUTF8Error([]cortexpb.LabelAdapter{
+				{Name: model.MetricNameLabel, Value: "up"},
+				{Name: "value", Value: "a\xffb"},
+			}, "value"),
+		},
+		"invalid UTF-8 replaced": {
+			lengthStrategy: LabelValueLengthStrategyError,
+			utf8Strategy:   LabelValueUTF8StrategyReplace,
+			value:          "a\xffb",
+			expectedValue:  "a\uFFFDb",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			limits := &Limits{
+				MaxLabelNameLength:       25,
+				MaxLabelValueLength:      30,
+				MaxLabelNamesPerSeries:   10,
+				LabelValueLengthStrategy: tc.lengthStrategy,
+				LabelValueUTF8Strategy:   tc.utf8Strategy,
+			}
+			ls := []cortexpb.LabelAdapter{
+				{Name: model.MetricNameLabel, Value: "up"},
+				{Name: "value", Value: tc.value},
+			}
+
+			err := ValidateLabels(limits, userID, ls, false)
+			if tc.expectedErr != nil {
+				assert.Equal(t, tc.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedValue, ls[1].Value)
+			assert.LessOrEqual(t, len(ls[1].Value), 30)
+		})
+	}
+
+	require.NoError(t, testutil.GatherAndCompare(prometheus.DefaultGatherer, strings.NewReader(`
+			# HELP cortex_label_values_replaced_total The total number of label values replaced, instead of rejecting their series, since longer than the limit or not valid UTF-8.
+			# TYPE cortex_label_values_replaced_total counter
+			cortex_label_values_replaced_total{reason="label_value_invalid_utf8",strategy="replace",user="strategies-user"} 1
+			cortex_label_values_replaced_total{reason="label_value_too_long",strategy="hash",user="strategies-user"} 1
+			cortex_label_values_replaced_total{reason="label_value_too_long",strategy="truncate",user="strategies-user"} 1
+	`), "cortex_label_values_replaced_total"))
+	DeletePerUserValidationMetrics(userID, util_log.Logger)
+}
+
+func TestShortenLabelValue(t *testing.T) {
+	first := shortenLabelValue(strings.Repeat("a", 40)+"1", 30, LabelValueLengthStrategyHash)
+	second := shortenLabelValue(strings.Repeat("a", 40)+"2", 30, LabelValueLengthStrategyHash)
+
+	assert.Len(t, first, 30)
+	assert.True(t, strings.HasPrefix(first, strings.Repeat("a", 30-labelValueHashSuffixLength)))
+	assert.NotEqual(t, first, second)
+}
+
+func TestValidatePreAggregatedLabels(t *testing.T) {
+	userID := "preAggregatedUser"
+	defer DeletePerUserValidationMetrics(userID, util_log.Logger)
+
+	rawSeries := []cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}}
+	preAggregatedSeries := []cortexpb.LabelAdapter{
+		{Name: "__aggregation__", Value: "sum"},
+		{Name: model.MetricNameLabel, Value: "foo"},
+		{Name: "__resolution__", Value: "300000"},
+	}
+
+	disabled := &Limits{}
+	assert.NoError(t, ValidatePreAggregatedLabels(disabled, userID, rawSeries))
+	assert.Equal(t, newPreAggregatedNotAllowedError(preAggregatedSeries), ValidatePreAggregatedLabels(disabled, userID, preAggregatedSeries))
+
+	enabled := &Limits{AcceptPreAggregatedSamples: true}
+	assert.NoError(t, ValidatePreAggregatedLabels(enabled, userID, rawSeries))
+	assert.NoError(t, ValidatePreAggregatedLabels(enabled, userID, preAggregatedSeries))
+
+	invalidResolution := []cortexpb.LabelAdapter{
+		{Name: "__aggregation__", Value: "sum"},
+		{Name: model.MetricNameLabel, Value: "foo"},
+		{Name: "__resolution__", Value: "1000"},
+	}
+	assert.Error(t, ValidatePreAggregatedLabels(enabled, userID, invalidResolution))
+
+	missingAggregation := []cortexpb.LabelAdapter{
+		{Name: model.MetricNameLabel, Value: "foo"},
+		{Name: "__resolution__", Value: "300000"},
+	}
+	assert.Error(t, ValidatePreAggregatedLabels(enabled, userID, missingAggregation))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(DiscardedSamples.WithLabelValues(invalidPreAggregated, userID)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(DiscardedSamples.WithLabelValues(preAggregatedNotAllowed, userID)))
+}
+
+func TestValidateLabelSchema(t *testing.T) {
+	userID := "labelSchemaUser"
+	defer DeletePerUserValidationMetrics(userID, util_log.Logger)
+
+	schema := LabelSchema{
+		RequiredLabels:    []string{"namespace", "service"},
+		ForbiddenLabels:   []string{"pod_ip"},
+		LabelNamePattern:  "[a-z_]+",
+		LabelValuePattern: "[a-z0-9-]*",
+	}
+	require.NoError(t, schema.compile())
+	limits := &Limits{LabelSchema: schema}
+
+	series := func(extra ...cortexpb.LabelAdapter) []cortexpb.LabelAdapter {
+		return append([]cortexpb.LabelAdapter{
+			{Name: model.MetricNameLabel, Value: "http_requests_total"},
+			{Name: "namespace", Value: "shop"},
+			{Name: "service", Value: "checkout-api"},
+		}, extra...)
+	}
+
+	for name, tc := range map[string]struct {
+		series       []cortexpb.LabelAdapter
+		expectedRule string
+		expectedName string
+	}{
+		"valid series": {
+			series: series(cortexpb.LabelAdapter{Name: "__replica__", Value: "Replica-1"}),
+		},
+		"missing required label": {
+			series:       []cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "up"}, {Name: "namespace", Value: "shop"}},
+			expectedRule: labelSchemaRequiredLabel,
+			expectedName: "service",
+		},
+		"empty required label": {
+			series:       []cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "up"}, {Name: "namespace", Value: "shop"}, {Name: "service", Value: ""}},
+			expectedRule: labelSchemaRequiredLabel,
+			expectedName: "service",
+		},
+		"forbidden label": {
+			series:       series(cortexpb.LabelAdapter{Name: "pod_ip", Value: "10-0-0-1"}),
+			expectedRule: labelSchemaForbiddenLabel,
+			expectedName: "pod_ip",
+		},
+		"label name not matching the pattern": {
+			series:       series(cortexpb.LabelAdapter{Name: "podName", Value: "a"}),
+			expectedRule: labelSchemaLabelNamePattern,
+			expectedName: "podName",
+		},
+		"label value not matching the pattern": {
+			series:       series(cortexpb.LabelAdapter{Name: "pod", Value: "Checkout_1"}),
+			expectedRule: labelSchemaLabelValuePattern,
+			expectedName: "pod",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateLabelSchema(limits, userID, tc.series)
+			if tc.expectedRule == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, newLabelSchemaViolationError(tc.series, tc.expectedRule, tc.expectedName), err)
+		})
+	}
+
+	// In warn mode, the violations are counted but the series are accepted.
+	warnLimits := &Limits{LabelSchema: schema}
+	warnLimits.LabelSchema.Mode = LabelSchemaModeWarn
+	assert.NoError(t, ValidateLabelSchema(warnLimits, userID, series(cortexpb.LabelAdapter{Name: "pod_ip", Value: "a"})))
+
+	// Without rules, nothing is checked.
+	assert.NoError(t, ValidateLabelSchema(&Limits{}, userID, []cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "up"}}))
+
+	assert.Equal(t, float64(5), testutil.ToFloat64(DiscardedSamples.WithLabelValues(labelSchemaViolation, userID)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(LabelSchemaViolations.WithLabelValues(labelSchemaForbiddenLabel, LabelSchemaModeEnforce, userID)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(LabelSchemaViolations.WithLabelValues(labelSchemaForbiddenLabel, LabelSchemaModeWarn, userID)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(LabelSchemaViolations.WithLabelValues(labelSchemaRequiredLabel, LabelSchemaModeEnforce, userID)))
+}
+
 func TestValidateExemplars(t *testing.T) {
 	userID := "testUser"
 
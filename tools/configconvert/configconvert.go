@@ -0,0 +1,198 @@
+package configconvert
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cortexproject/cortex/pkg/cortex"
+)
+
+// Source is the project the converted config comes from.
+type Source string
+
+const (
+	SourceCortex Source = "cortex"
+	SourceMimir  Source = "mimir"
+)
+
+// Sources are the supported sources of the converted configs.
+var Sources = []Source{SourceCortex, SourceMimir}
+
+// Rename is a field of the source config moved to another path.
+type Rename struct {
+	From string
+	To   string
+}
+
+// Default is a field not set in the source config, set to the default value of the source project
+// since it differs from the Cortex one.
+type Default struct {
+	Path  string
+	Value interface{}
+}
+
+// Result is the outcome of a config conversion.
+type Result struct {
+	// Config is the converted YAML config.
+	Config []byte
+
+	Renamed    []Rename
+	Defaulted  []Default
+	Unmappable []string
+}
+
+// Convert translates the YAML config of the source project into a config of this Cortex version.
+// The fields without equivalent are dropped from the converted config and reported as unmappable.
+func Convert(in []byte, source Source) (*Result, error) {
+	var renames []Rename
+	var defaults []Default
+	switch source {
+	case SourceCortex:
+		renames = cortexRenames
+	case SourceMimir:
+		renames, defaults = mimirRenames, mimirDefaults
+	default:
+		return nil, fmt.Errorf("unsupported source %q", source)
+	}
+
+	var input yaml.MapSlice
+	if err := yaml.Unmarshal(in, &input); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the source config")
+	}
+
+	c := converter{
+		schema:  newSchema(reflect.TypeOf(cortex.Config{})),
+		renames: renames,
+		result:  &Result{},
+	}
+	c.convert(nil, input)
+
+	for _, d := range defaults {
+		path := strings.Split(d.Path, ".")
+		if _, ok := get(c.output, path); ok {
+			continue
+		}
+		c.output = set(c.output, path, d.Value)
+		c.result.Defaulted = append(c.result.Defaulted, d)
+	}
+
+	out, err := yaml.Marshal(c.output)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode the converted config")
+	}
+
+	// The converted values are checked against the fields types.
+	var cfg cortex.Config
+	if err := yaml.UnmarshalStrict(out, &cfg); err != nil {
+		return nil, errors.Wrap(err, "the converted config is invalid")
+	}
+
+	c.result.Config = out
+	return c.result, nil
+}
+
+type converter struct {
+	schema  *schemaNode
+	renames []Rename
+	output  yaml.MapSlice
+	result  *Result
+}
+
+// convert copies the fields of the source config under the given path to the converted config.
+func (c *converter) convert(path []string, value yaml.MapSlice) {
+	for _, item := range value {
+		key, ok := item.Key.(string)
+		if !ok {
+			c.result.Unmappable = append(c.result.Unmappable, strings.Join(append(path, fmt.Sprint(item.Key)), "."))
+			continue
+		}
+		from := append(append([]string{}, path...), key)
+		to, renamed := c.rename(from)
+
+		node := c.schema.lookup(to)
+		if children, ok := item.Value.(yaml.MapSlice); ok && ((node != nil && node.children != nil) || c.hasRenamedFields(from)) {
+			c.convert(from, children)
+			continue
+		}
+		if node == nil {
+			c.result.Unmappable = append(c.result.Unmappable, strings.Join(from, "."))
+			continue
+		}
+
+		c.output = set(c.output, to, item.Value)
+		if renamed {
+			c.result.Renamed = append(c.result.Renamed, Rename{From: strings.Join(from, "."), To: strings.Join(to, ".")})
+		}
+	}
+}
+
+// rename returns the path of the field in the converted config, renamed by the longest matching
+// prefix, and whether it has been renamed.
+func (c *converter) rename(path []string) ([]string, bool) {
+	joined := strings.Join(path, ".")
+
+	var match *Rename
+	for i, r := range c.renames {
+		if (joined == r.From || strings.HasPrefix(joined, r.From+".")) && (match == nil || len(r.From) > len(match.From)) {
+			match = &c.renames[i]
+		}
+	}
+	if match == nil {
+		return path, false
+	}
+	return strings.Split(match.To+strings.TrimPrefix(joined, match.From), "."), true
+}
+
+// hasRenamedFields returns whether some fields under the given path are renamed.
+func (c *converter) hasRenamedFields(path []string) bool {
+	prefix := strings.Join(path, ".") + "."
+	for _, r := range c.renames {
+		if strings.HasPrefix(r.From, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// get returns the value at the given path of the config.
+func get(m yaml.MapSlice, path []string) (interface{}, bool) {
+	for _, item := range m {
+		if item.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return item.Value, true
+		}
+		children, ok := item.Value.(yaml.MapSlice)
+		if !ok {
+			return nil, false
+		}
+		return get(children, path[1:])
+	}
+	return nil, false
+}
+
+// set sets the value at the given path of the config, preserving the order of the existing keys.
+func set(m yaml.MapSlice, path []string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			m[i].Value = value
+			return m
+		}
+		children, _ := item.Value.(yaml.MapSlice)
+		m[i].Value = set(children, path[1:], value)
+		return m
+	}
+
+	if len(path) == 1 {
+		return append(m, yaml.MapItem{Key: path[0], Value: value})
+	}
+	return append(m, yaml.MapItem{Key: path[0], Value: set(nil, path[1:], value)})
+}
@@ -0,0 +1,152 @@
+package configconvert
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/cortex"
+)
+
+func TestConvert(t *testing.T) {
+	tests := map[string]struct {
+		source             Source
+		input              string
+		expected           string
+		expectedRenamed    []Rename
+		expectedUnmappable []string
+		expectedDefaulted  int
+	}{
+		"cortex config": {
+			source: SourceCortex,
+			input: `
+target: querier
+querier:
+  query_store_after: 12h
+  unknown_field: true
+limits:
+  max_fetched_chunks_per_query: 1000
+`,
+			expected: `
+target: querier
+querier:
+  query_store_after: 12h
+limits:
+  max_fetched_chunks_per_query: 1000
+`,
+			expectedUnmappable: []string{"querier.unknown_field"},
+		},
+		"mimir config": {
+			source: SourceMimir,
+			input: `
+multitenancy_enabled: false
+ingester:
+  ring:
+    replication_factor: 3
+    instance_availability_zone: zone-a
+    kvstore:
+      store: consul
+      consul:
+        host: consul:8500
+frontend:
+  split_queries_by_interval: 24h
+  query_sharding_target_series_per_shard: 2500
+limits:
+  query_sharding_total_shards: 16
+  compactor_blocks_retention_period: 1y
+common:
+  storage:
+    backend: s3
+`,
+			expected: `
+auth_enabled: false
+ingester:
+  lifecycler:
+    ring:
+      replication_factor: 3
+      kvstore:
+        store: consul
+        consul:
+          host: consul:8500
+    availability_zone: zone-a
+query_range:
+  split_queries_by_interval: 24h
+limits:
+  query_vertical_shard_size: 16
+  compactor_blocks_retention_period: 1y
+distributor:
+  ring:
+    kvstore:
+      store: memberlist
+store_gateway:
+  sharding_ring:
+    kvstore:
+      store: memberlist
+  sharding_enabled: true
+compactor:
+  sharding_ring:
+    kvstore:
+      store: memberlist
+  sharding_enabled: true
+ruler:
+  ring:
+    kvstore:
+      store: memberlist
+  enable_sharding: true
+alertmanager:
+  sharding_ring:
+    kvstore:
+      store: memberlist
+  sharding_enabled: true
+blocks_storage:
+  backend: filesystem
+`,
+			expectedRenamed: []Rename{
+				{From: "multitenancy_enabled", To: "auth_enabled"},
+				{From: "ingester.ring.replication_factor", To: "ingester.lifecycler.ring.replication_factor"},
+				{From: "ingester.ring.instance_availability_zone", To: "ingester.lifecycler.availability_zone"},
+				{From: "ingester.ring.kvstore.store", To: "ingester.lifecycler.ring.kvstore.store"},
+				{From: "ingester.ring.kvstore.consul.host", To: "ingester.lifecycler.ring.kvstore.consul.host"},
+				{From: "frontend.split_queries_by_interval", To: "query_range.split_queries_by_interval"},
+				{From: "limits.query_sharding_total_shards", To: "limits.query_vertical_shard_size"},
+			},
+			expectedUnmappable: []string{"frontend.query_sharding_target_series_per_shard", "common"},
+			// All but the ingesters ring store.
+			expectedDefaulted: len(mimirDefaults) - 1,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := Convert([]byte(testData.input), testData.source)
+			require.NoError(t, err)
+
+			assert.Equal(t, testData.expectedRenamed, result.Renamed)
+			assert.Equal(t, testData.expectedUnmappable, result.Unmappable)
+			assert.Len(t, result.Defaulted, testData.expectedDefaulted)
+			assert.YAMLEq(t, testData.expected, string(result.Config))
+		})
+	}
+}
+
+func TestConvert_UnsupportedSource(t *testing.T) {
+	_, err := Convert([]byte("target: all"), "loki")
+	require.Error(t, err)
+}
+
+func TestMappings_ShouldTargetExistingFields(t *testing.T) {
+	schema := newSchema(reflect.TypeOf(cortex.Config{}))
+
+	for _, r := range append(cortexRenames, mimirRenames...) {
+		assert.NotNil(t, schema.lookup(strings.Split(r.To, ".")), r.To)
+	}
+	for _, d := range mimirDefaults {
+		node := schema.lookup(strings.Split(d.Path, "."))
+		if assert.NotNil(t, node, d.Path) {
+			assert.Nil(t, node.children, d.Path)
+		}
+	}
+}
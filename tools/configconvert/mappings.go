@@ -0,0 +1,62 @@
+package configconvert
+
+// cortexRenames are the fields of the upstream Cortex config moved in this Cortex version. None
+// has been moved so far: the upstream fields are kept at the same path, and the upstream fields
+// this version doesn't have are reported as unmappable.
+var cortexRenames []Rename
+
+// mimirRenames are the fields of the Mimir config moved in this Cortex version. The Mimir fields not
+// listed here are kept at the same path, when it exists.
+var mimirRenames = []Rename{
+	{From: "multitenancy_enabled", To: "auth_enabled"},
+
+	// The ingesters ring is configured in the lifecycler.
+	{From: "ingester.ring.kvstore", To: "ingester.lifecycler.ring.kvstore"},
+	{From: "ingester.ring.heartbeat_timeout", To: "ingester.lifecycler.ring.heartbeat_timeout"},
+	{From: "ingester.ring.replication_factor", To: "ingester.lifecycler.ring.replication_factor"},
+	{From: "ingester.ring.zone_awareness_enabled", To: "ingester.lifecycler.ring.zone_awareness_enabled"},
+	{From: "ingester.ring.excluded_zones", To: "ingester.lifecycler.ring.excluded_zones"},
+	{From: "ingester.ring.num_tokens", To: "ingester.lifecycler.num_tokens"},
+	{From: "ingester.ring.heartbeat_period", To: "ingester.lifecycler.heartbeat_period"},
+	{From: "ingester.ring.observe_period", To: "ingester.lifecycler.observe_period"},
+	{From: "ingester.ring.join_after", To: "ingester.lifecycler.join_after"},
+	{From: "ingester.ring.min_ready_duration", To: "ingester.lifecycler.min_ready_duration"},
+	{From: "ingester.ring.final_sleep", To: "ingester.lifecycler.final_sleep"},
+	{From: "ingester.ring.tokens_file_path", To: "ingester.lifecycler.tokens_file_path"},
+	{From: "ingester.ring.unregister_on_shutdown", To: "ingester.lifecycler.unregister_on_shutdown"},
+	{From: "ingester.ring.readiness_check_ring_health", To: "ingester.lifecycler.readiness_check_ring_health"},
+	{From: "ingester.ring.instance_availability_zone", To: "ingester.lifecycler.availability_zone"},
+	{From: "ingester.ring.instance_interface_names", To: "ingester.lifecycler.interface_names"},
+	{From: "ingester.ring.instance_addr", To: "ingester.lifecycler.address"},
+	{From: "ingester.ring.instance_port", To: "ingester.lifecycler.port"},
+	{From: "ingester.ring.instance_id", To: "ingester.lifecycler.id"},
+
+	// The query-frontend middlewares are configured in the query range config.
+	{From: "frontend.results_cache", To: "query_range.results_cache"},
+	{From: "frontend.results_cache.memcached", To: "query_range.results_cache.cache.memcached_client"},
+	{From: "frontend.cache_results", To: "query_range.cache_results"},
+	{From: "frontend.split_queries_by_interval", To: "query_range.split_queries_by_interval"},
+	{From: "frontend.align_queries_with_step", To: "query_range.align_queries_with_step"},
+	{From: "frontend.max_retries", To: "query_range.max_retries"},
+
+	{From: "limits.query_sharding_total_shards", To: "limits.query_vertical_shard_size"},
+	{From: "limits.max_total_query_length", To: "limits.max_query_length"},
+	// Not a per-tenant limit in Cortex.
+	{From: "limits.query_ingesters_within", To: "querier.query_ingesters_within"},
+}
+
+// mimirDefaults are the Mimir defaults differing from the Cortex ones: the rings are stored in
+// memberlist, and the store-gateways, compactors, rulers and alertmanagers are always sharded.
+var mimirDefaults = []Default{
+	{Path: "ingester.lifecycler.ring.kvstore.store", Value: "memberlist"},
+	{Path: "distributor.ring.kvstore.store", Value: "memberlist"},
+	{Path: "store_gateway.sharding_ring.kvstore.store", Value: "memberlist"},
+	{Path: "compactor.sharding_ring.kvstore.store", Value: "memberlist"},
+	{Path: "ruler.ring.kvstore.store", Value: "memberlist"},
+	{Path: "alertmanager.sharding_ring.kvstore.store", Value: "memberlist"},
+	{Path: "store_gateway.sharding_enabled", Value: true},
+	{Path: "compactor.sharding_enabled", Value: true},
+	{Path: "ruler.enable_sharding", Value: true},
+	{Path: "alertmanager.sharding_enabled", Value: true},
+	{Path: "blocks_storage.backend", Value: "filesystem"},
+}
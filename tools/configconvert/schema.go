@@ -0,0 +1,84 @@
+package configconvert
+
+import (
+	"reflect"
+	"strings"
+)
+
+// schemaNode is a field of the config. The fields of a struct type have children, while the other
+// fields are leaves accepting any YAML value.
+type schemaNode struct {
+	children map[string]*schemaNode
+}
+
+// newSchema returns the fields of the YAML config unmarshalled into a value of the given type.
+func newSchema(t reflect.Type) *schemaNode {
+	return buildSchemaNode(t, map[reflect.Type]bool{})
+}
+
+func buildSchemaNode(t reflect.Type, visiting map[reflect.Type]bool) *schemaNode {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	node := &schemaNode{}
+	if t.Kind() != reflect.Struct || visiting[t] {
+		return node
+	}
+
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	node.children = map[string]*schemaNode{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, inline := parseYAMLTag(field)
+		if name == "-" {
+			continue
+		}
+
+		child := buildSchemaNode(field.Type, visiting)
+		if inline {
+			for k, v := range child.children {
+				node.children[k] = v
+			}
+			continue
+		}
+		node.children[name] = child
+	}
+	return node
+}
+
+// parseYAMLTag returns the YAML key of the struct field, as yaml.v2 does, and whether it's inlined.
+func parseYAMLTag(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("yaml")
+	parts := strings.Split(tag, ",")
+
+	name := parts[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			return name, true
+		}
+	}
+	return name, false
+}
+
+// lookup returns the field at the given path, or nil if there's none.
+func (n *schemaNode) lookup(path []string) *schemaNode {
+	for _, key := range path {
+		if n.children == nil {
+			return nil
+		}
+		n = n.children[key]
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}